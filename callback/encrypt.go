@@ -0,0 +1,106 @@
+// Package callback provides types and functions for notifying consumers of
+// the API about changes in their jobs.
+package callback
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	// ContentTypeJSON is the content type of plain callback payloads.
+	ContentTypeJSON = "application/json"
+
+	// ContentTypeJOSE is the content type of encrypted callback payloads,
+	// in JWE compact serialization.
+	ContentTypeJOSE = "application/jose"
+)
+
+var jweHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RSA-OAEP-256","enc":"A256GCM","cty":"application/json"}`))
+
+// ParsePublicKey parses a PEM-encoded RSA public key, in either PKIX or
+// PKCS#1 format.
+func ParsePublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("invalid public key: no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("invalid public key: " + err.Error())
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("invalid public key: only RSA keys are supported")
+	}
+	return rsaKey, nil
+}
+
+// Encode serializes the given payload for delivery. When publicKey is not
+// empty, the payload is encrypted using JWE (RSA-OAEP-256 and A256GCM), so
+// signed URLs in the payload aren't exposed to intermediate systems that
+// log requests.
+func Encode(payload interface{}, publicKey string) ([]byte, string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	if publicKey == "" {
+		return data, ContentTypeJSON, nil
+	}
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, "", err
+	}
+	encrypted, err := encrypt(data, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return encrypted, ContentTypeJOSE, nil
+}
+
+func encrypt(plaintext []byte, key *rsa.PublicKey) ([]byte, error) {
+	cek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, cek, nil)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(jweHeader))
+	tagStart := len(sealed) - gcm.Overhead()
+	parts := []string{
+		jweHeader,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(sealed[:tagStart]),
+		base64.RawURLEncoding.EncodeToString(sealed[tagStart:]),
+	}
+	return []byte(strings.Join(parts, ".")), nil
+}
@@ -0,0 +1,118 @@
+package callback
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodePlain(t *testing.T) {
+	payload := map[string]string{"jobId": "job-123", "url": "https://cdn.example.com/video.mp4?signature=abc"}
+	data, contentType, err := Encode(payload, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != ContentTypeJSON {
+		t.Errorf("wrong content type. Want %q. Got %q", ContentTypeJSON, contentType)
+	}
+	var got map[string]string
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, payload) {
+		t.Errorf("wrong payload. Want %#v. Got %#v", payload, got)
+	}
+}
+
+func TestEncodeEncrypted(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+	payload := map[string]string{"jobId": "job-123", "url": "https://cdn.example.com/video.mp4?signature=abc"}
+	data, contentType, err := Encode(payload, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != ContentTypeJOSE {
+		t.Errorf("wrong content type. Want %q. Got %q", ContentTypeJOSE, contentType)
+	}
+	if strings.Contains(string(data), "signature=abc") {
+		t.Errorf("encrypted payload contains the signed URL: %s", data)
+	}
+	plaintext := decrypt(t, string(data), privateKey)
+	var got map[string]string
+	err = json.Unmarshal(plaintext, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, payload) {
+		t.Errorf("wrong payload. Want %#v. Got %#v", payload, got)
+	}
+}
+
+func TestEncodeInvalidKey(t *testing.T) {
+	_, _, err := Encode(map[string]string{"jobId": "job-123"}, "not a key")
+	if err == nil {
+		t.Fatal("unexpected <nil> error")
+	}
+	expectedMsg := "invalid public key: no PEM data found"
+	if err.Error() != expectedMsg {
+		t.Errorf("wrong error message. Want %q. Got %q", expectedMsg, err.Error())
+	}
+}
+
+func decrypt(t *testing.T, token string, key *rsa.PrivateKey) []byte {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		t.Fatalf("invalid JWE compact serialization: %q", token)
+	}
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		var err error
+		decoded[i], err = base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var header map[string]string
+	err := json.Unmarshal(decoded[0], &header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header["alg"] != "RSA-OAEP-256" || header["enc"] != "A256GCM" {
+		t.Fatalf("unexpected JWE header: %#v", header)
+	}
+	cek, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, decoded[1], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, decoded[2], append(decoded[3], decoded[4]...), []byte(parts[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plaintext
+}
@@ -0,0 +1,34 @@
+package callback
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	// SignatureHeader is the header with the signature of signed callback
+	// payloads, in the format "sha256=<hex-encoded HMAC>".
+	SignatureHeader = "X-Transcoding-Signature"
+
+	// TimestampHeader is the header with the time of signed callback
+	// payloads, in seconds since the Unix epoch. It's part of the
+	// signature, so receivers can reject replayed payloads.
+	TimestampHeader = "X-Transcoding-Timestamp"
+)
+
+// Sign returns the signature of a callback payload sent at the given time,
+// computed with HMAC-SHA256 over the timestamp and the body of the request,
+// separated by a dot.
+func Sign(key, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns whether the given signature matches the payload sent at
+// the given time.
+func Verify(key, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(key, timestamp, body)), []byte(signature))
+}
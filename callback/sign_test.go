@@ -0,0 +1,43 @@
+package callback
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		key       string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			"payload",
+			"callback-secret",
+			"1500000000",
+			`{"jobId":"job-123","status":"finished"}`,
+			"sha256=8aff71e5820c1ba6d6116039a25b4d0ce323a9b1f847b22e0e404c41e3a062fc",
+		},
+		{
+			"empty payload",
+			"callback-secret",
+			"1500000000",
+			"",
+			"sha256=6f8f2e47659d09faf401eee55982e689357e0585f8371f92113ca9db9a96869a",
+		},
+	}
+	for _, test := range tests {
+		got := Sign(test.key, test.timestamp, []byte(test.body))
+		if got != test.want {
+			t.Errorf("%s: wrong signature. Want %q. Got %q", test.testCase, test.want, got)
+		}
+		if !Verify(test.key, test.timestamp, []byte(test.body), got) {
+			t.Errorf("%s: signature not verified", test.testCase)
+		}
+		if Verify("other-secret", test.timestamp, []byte(test.body), got) {
+			t.Errorf("%s: signature verified with the wrong key", test.testCase)
+		}
+		if Verify(test.key, "1500000001", []byte(test.body), got) {
+			t.Errorf("%s: signature verified with the wrong timestamp", test.testCase)
+		}
+	}
+}
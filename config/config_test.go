@@ -5,6 +5,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
@@ -39,16 +40,113 @@ func TestLoadConfigFromEnv(t *testing.T) {
 		"ELEMENTALCONDUCTOR_AWS_ACCESS_KEY_ID":     "AKIANOTREALLY",
 		"ELEMENTALCONDUCTOR_AWS_SECRET_ACCESS_KEY": "secret-key",
 		"ELEMENTALCONDUCTOR_DESTINATION":           "https://safe-stuff",
+		"MEDIACONVERT_AWS_ACCESS_KEY_ID":           "AKIANOTREALLY",
+		"MEDIACONVERT_AWS_SECRET_ACCESS_KEY":       "secret-key",
+		"MEDIACONVERT_AWS_REGION":                  "us-west-2",
+		"MEDIACONVERT_ENDPOINT":                    "https://abcd1234.mediaconvert.us-west-2.amazonaws.com",
+		"MEDIACONVERT_ROLE_ARN":                    "arn:aws:iam::123456789012:role/MediaConvert",
+		"MEDIACONVERT_QUEUE_ARN":                   "arn:aws:mediaconvert:us-west-2:123456789012:queues/Default",
+		"MEDIACONVERT_DESTINATION":                 "s3://mediaconvert-bucket/",
+		"BITMOVIN_API_KEY":                         "bitmovin-key",
+		"BITMOVIN_AWS_ACCESS_KEY_ID":               "AKIANOTREALLY",
+		"BITMOVIN_AWS_SECRET_ACCESS_KEY":           "secret-key",
+		"BITMOVIN_DESTINATION":                     "s3://bitmovin-bucket/",
+		"BITMOVIN_ENCODING_REGION":                 "GOOGLE_EUROPE_WEST_1",
+		"GCP_TRANSCODER_PROJECT_ID":                "transcoding-project",
+		"GCP_TRANSCODER_CREDENTIALS_FILE":          "/etc/gcp/transcoder.json",
+		"GCP_TRANSCODER_DESTINATION":               "gs://transcoder-bucket/",
 		"SWAGGER_MANIFEST_PATH":                    "/opt/video-transcoding-api-swagger.json",
 		"HTTP_ACCESS_LOG":                          accessLog,
 		"HTTP_PORT":                                "8080",
 		"DEFAULT_SEGMENT_DURATION":                 "3",
+		"FALLBACK_PROVIDERS":                       "zencoder,elastictranscoder",
 		"GCP_CREDENTIALS_FILE":                     gcpCredsTestFilePath,
+		"SOURCE_ALLOW_PRIVATE_NETWORKS":            "true",
+		"SOURCE_ALLOWED_PORTS":                     "80,443,8080",
+		"SOURCE_ALLOWED_SCHEMES":                   "http,https,s3",
+		"SOURCE_BLOCKED_HOSTS":                     "internal.example.com",
+		"SOURCE_ENCRYPTION_KEYS":                   "archive:MDEyMzQ1Njc4OWFiY2RlZg==",
+		"SOURCE_DECRYPTION_STAGING_URL":            "https://staging-bucket.s3.amazonaws.com/decrypted/",
+		"OUTPUT_ENCRYPTION_KMS_KEY_ID":             "alias/embargo",
+		"OUTPUT_ENCRYPTION_STAGING_DESTINATION":    "s3://staging-bucket/encrypted/",
+		"OUTPUT_ENCRYPTION_KEY_ACCESS_TOKEN":       "key-token",
+		"DELIVERY_ENVIRONMENT":                     "staging",
+		"JOB_ID_FORMAT":                            "ulid",
+		"DATABASE_DRIVER":                          "postgres",
+		"TENANT_HEADER":                            "X-Authenticated-Tenant",
+		"SEGMENT_VERIFICATION_ENABLED":             "true",
+		"SEGMENT_VERIFICATION_FAIL_JOBS":           "true",
+		"SEGMENT_VERIFICATION_TOLERANCE":           "0.25",
+		"ANALYSIS_FFMPEG_PATH":                     "/usr/local/bin/ffmpeg",
+		"ANALYSIS_AWS_ACCESS_KEY_ID":               "AKIANOTREALLY",
+		"ANALYSIS_AWS_SECRET_ACCESS_KEY":           "secret-key",
+		"ANALYSIS_FINGERPRINT_INTERVAL":            "0.5",
+		"PREDICTION_COST_PER_MINUTE":               "zencoder:0.0375,elastictranscoder:0.03",
+		"PREDICTION_HISTORY_SIZE":                  "50",
+		"NETSTORAGE_HOST":                          "example-nsu.akamaihd.net",
+		"NETSTORAGE_KEY_NAME":                      "upload",
+		"NETSTORAGE_KEY":                           "secret-key",
+		"NETSTORAGE_STAGING_DESTINATION":           "s3://staging-bucket/netstorage/",
+		"ASPERA_HOST":                              "aspera.example.com",
+		"ASPERA_USER":                              "archive",
+		"ASPERA_KEY_FILE":                          "/etc/aspera/id_rsa",
+		"ASPERA_STAGING_DESTINATION":               "s3://staging-bucket/aspera/",
+		"SIGNIANT_COMMAND":                         "sigcli upload {source} {destination}",
+		"SIGNIANT_STAGING_DESTINATION":             "s3://staging-bucket/signiant/",
+		"RECONCILIATION_INTERVAL":                  "5m",
+		"UPLOAD_INTERVAL":                          "1m",
+		"UPLOAD_MAX_ATTEMPTS":                      "5",
+		"FALLBACK_INTERVAL":                        "2m",
+		"WATCH_FOLDERS_INTERVAL":                   "30s",
+		"WATCH_FOLDERS_SFTP_KEY_FILE":              "/etc/watch/id_rsa",
+		"CALLBACK_INTERVAL":                        "2s",
+		"CALLBACK_SIGNING_KEY":                     "callback-secret",
+		"CALLBACK_MAX_RETRIES":                     "5",
+		"PROVIDER_CALLBACKS_BASE_URL":              "https://transcoding.example.com",
+		"PROVIDER_CALLBACKS_ZENCODER_TOKEN":        "zencoder-secret",
+		"PROVIDER_CALLBACKS_MEDIACONVERT_TOPIC":    "arn:aws:sns:us-west-2:123456789012:mediaconvert-events",
+		"STATUS_POLLER_INTERVAL":                   "30s",
+		"SELFTEST_PROVIDER":                        "zencoder",
+		"SELFTEST_SOURCE_MEDIA":                    "s3://selftest-bucket/source.mp4",
+		"SELFTEST_PRESETMAP":                       "mp4_360p",
+		"SELFTEST_DESTINATION":                     "s3://selftest-bucket/scratch/",
+		"SELFTEST_TIMEOUT":                         "5m",
+		"POSTGRES_URL":                             "postgres://transcoding@db.example.com/transcoding",
+		"POSTGRES_MAX_OPEN_CONNS":                  "20",
+		"DYNAMODB_AWS_REGION":                      "us-west-2",
+		"DYNAMODB_TABLE_PREFIX":                    "video-api-",
+		"DYNAMODB_CREATE_TABLES":                   "true",
+		"JOB_TTL":                                  "720h",
+		"JOB_SWEEP_INTERVAL":                       "30m",
+		"CAMPAIGN_INTERVAL":                        "5m",
+		"PRE_PROCESSORS":                           "rights:webhook:https://rights.example.com/check",
+		"POST_PROCESSORS":                          "catalog:webhook:https://catalog.example.com/hooks,thumbs:lambda:thumbnailer",
+		"POST_PROCESSORS_INTERVAL":                 "1m",
+		"HOOKS_TIMEOUT":                            "1m",
+		"HOOKS_AWS_REGION":                         "us-west-2",
+		"POLICY_FILE":                              "/etc/transcoding-api/policy.json",
+		"DEADLINE_PROVIDERS":                       "mediaconvert,bitmovin",
+		"DEADLINE_WATCHDOG_INTERVAL":               "1m",
+		"BACKPRESSURE_MAX_IN_FLIGHT":               "20",
+		"BACKPRESSURE_RETRY_AFTER":                 "60",
+		"MAINTENANCE_MODE":                         "true",
+		"PUBLISH_STAGING_PREFIX":                   ".staging",
+		"MAINTENANCE_MESSAGE":                      "migrating storage",
+		"SANDBOX_ALLOWED_DESTINATIONS":             "s3://sandbox-bucket/",
+		"SANDBOX_ZENCODER_API_KEY":                 "sandbox-api-key",
+		"SANDBOX_ZENCODER_MIN_REMAINING_MINUTES":   "10",
+		"CHAOS_PROVIDER_ENABLED":                   "true",
+		"CHAOS_WEBHOOK_INTERVAL":                   "5s",
 	})
 	cfg := LoadConfig()
 	expectedCfg := Config{
 		SwaggerManifest:        "/opt/video-transcoding-api-swagger.json",
 		DefaultSegmentDuration: 3,
+		DeliveryEnvironment:    "staging",
+		JobIDFormat:            "ulid",
+		FallbackProviders:      "zencoder,elastictranscoder",
+		DatabaseDriver:         "postgres",
+		TenantHeader:           "X-Authenticated-Tenant",
 		Redis: &storage.Config{
 			SentinelAddrs:      "10.10.10.10:26379,10.10.10.11:26379,10.10.10.12:26379",
 			SentinelMasterName: "supermaster",
@@ -79,10 +177,153 @@ func TestLoadConfigFromEnv(t *testing.T) {
 			SecretAccessKey: "secret-key",
 			Destination:     "https://safe-stuff",
 		},
+		MediaConvert: &MediaConvert{
+			AccessKeyID:     "AKIANOTREALLY",
+			SecretAccessKey: "secret-key",
+			Region:          "us-west-2",
+			Endpoint:        "https://abcd1234.mediaconvert.us-west-2.amazonaws.com",
+			Role:            "arn:aws:iam::123456789012:role/MediaConvert",
+			Queue:           "arn:aws:mediaconvert:us-west-2:123456789012:queues/Default",
+			Destination:     "s3://mediaconvert-bucket/",
+		},
+		Bitmovin: &Bitmovin{
+			APIKey:          "bitmovin-key",
+			Endpoint:        "https://api.bitmovin.com/v1/",
+			AccessKeyID:     "AKIANOTREALLY",
+			SecretAccessKey: "secret-key",
+			Destination:     "s3://bitmovin-bucket/",
+			EncodingRegion:  "GOOGLE_EUROPE_WEST_1",
+		},
+		GCPTranscoder: &GCPTranscoder{
+			ProjectID:       "transcoding-project",
+			Location:        "us-central1",
+			CredentialsFile: "/etc/gcp/transcoder.json",
+			Destination:     "gs://transcoder-bucket/",
+			Endpoint:        "https://transcoder.googleapis.com/v1/",
+		},
 		Server: &server.Config{
 			HTTPPort:      8080,
 			HTTPAccessLog: &accessLog,
 		},
+		SourceValidation: &SourceValidation{
+			AllowPrivateNetworks: true,
+			AllowedPorts:         "80,443,8080",
+			AllowedSchemes:       "http,https,s3",
+			BlockedHosts:         "internal.example.com",
+		},
+		SourceEncryption: &SourceEncryption{
+			Keys:       "archive:MDEyMzQ1Njc4OWFiY2RlZg==",
+			StagingURL: "https://staging-bucket.s3.amazonaws.com/decrypted/",
+		},
+		OutputEncryption: &OutputEncryption{
+			KMSKeyID:           "alias/embargo",
+			Region:             "us-east-1",
+			StagingDestination: "s3://staging-bucket/encrypted/",
+			KeyAccessToken:     "key-token",
+		},
+		SegmentVerification: &SegmentVerification{
+			Enabled:   true,
+			FailJobs:  true,
+			Tolerance: 0.25,
+		},
+		Analysis: &Analysis{
+			FFmpegPath:          "/usr/local/bin/ffmpeg",
+			AccessKeyID:         "AKIANOTREALLY",
+			SecretAccessKey:     "secret-key",
+			Region:              "us-east-1",
+			FingerprintInterval: 0.5,
+		},
+		Prediction: &Prediction{
+			CostPerMinute: "zencoder:0.0375,elastictranscoder:0.03",
+			HistorySize:   50,
+		},
+		NetStorage: &NetStorage{
+			Host:               "example-nsu.akamaihd.net",
+			KeyName:            "upload",
+			Key:                "secret-key",
+			StagingDestination: "s3://staging-bucket/netstorage/",
+		},
+		Aspera: &Aspera{
+			Host:               "aspera.example.com",
+			User:               "archive",
+			KeyFile:            "/etc/aspera/id_rsa",
+			TargetRate:         "1g",
+			StagingDestination: "s3://staging-bucket/aspera/",
+		},
+		Signiant: &Signiant{
+			Command:            "sigcli upload {source} {destination}",
+			StagingDestination: "s3://staging-bucket/signiant/",
+		},
+		Uploads:   &Uploads{Interval: time.Minute, MaxAttempts: 5},
+		Fallbacks: &Fallbacks{Interval: 2 * time.Minute},
+		Reconciliation: &Reconciliation{
+			Interval: 5 * time.Minute,
+		},
+		StatusPoller: &StatusPoller{Interval: 30 * time.Second},
+		WatchFolders: &WatchFolders{
+			Interval:    30 * time.Second,
+			Region:      "us-east-1",
+			SFTPKeyFile: "/etc/watch/id_rsa",
+		},
+		Callbacks: &Callbacks{
+			Interval:      2 * time.Second,
+			SigningKey:    "callback-secret",
+			MaxRetries:    5,
+			RetryInterval: 10 * time.Second,
+		},
+		Hooks: &Hooks{
+			PreProcessors:          "rights:webhook:https://rights.example.com/check",
+			PostProcessors:         "catalog:webhook:https://catalog.example.com/hooks,thumbs:lambda:thumbnailer",
+			PostProcessorsInterval: time.Minute,
+			Timeout:                time.Minute,
+			Region:                 "us-west-2",
+		},
+		Policy: &Policy{File: "/etc/transcoding-api/policy.json"},
+		ProviderCallbacks: &ProviderCallbacks{
+			BaseURL:              "https://transcoding.example.com",
+			ZencoderToken:        "zencoder-secret",
+			MediaConvertTopicARN: "arn:aws:sns:us-west-2:123456789012:mediaconvert-events",
+		},
+		Deadlines: &Deadlines{Providers: "mediaconvert,bitmovin", WatchdogInterval: time.Minute},
+		Backpressure: &Backpressure{
+			MaxInFlight: 20,
+			MaxQueued:   1000,
+			RetryAfter:  60,
+		},
+		Maintenance: &Maintenance{
+			Enabled: true,
+			Message: "migrating storage",
+		},
+		SelfTest: &SelfTest{
+			Provider:    "zencoder",
+			SourceMedia: "s3://selftest-bucket/source.mp4",
+			PresetMap:   "mp4_360p",
+			Destination: "s3://selftest-bucket/scratch/",
+			Timeout:     5 * time.Minute,
+		},
+		Postgres: &Postgres{
+			URL:          "postgres://transcoding@db.example.com/transcoding",
+			MaxOpenConns: 20,
+		},
+		DynamoDB: &DynamoDB{
+			Region:       "us-west-2",
+			TablePrefix:  "video-api-",
+			CreateTables: true,
+		},
+		JobExpiration: &JobExpiration{TTL: 720 * time.Hour, SweepInterval: 30 * time.Minute},
+		Publish:       &Publish{Region: "us-east-1", StagingPrefix: ".staging"},
+		Sandbox: &Sandbox{
+			AllowedDestinations: "s3://sandbox-bucket/",
+			encodingCom:         &EncodingCom{StatusEndpoint: "http://status.encoding.com"},
+			elasticTranscoder:   &ElasticTranscoder{},
+			elementalConductor:  &ElementalConductor{},
+			mediaConvert:        &MediaConvert{},
+			bitmovin:            &Bitmovin{Endpoint: "https://api.bitmovin.com/v1/", EncodingRegion: "AWS_US_EAST_1"},
+			gcpTranscoder:       &GCPTranscoder{Location: "us-central1", Endpoint: "https://transcoder.googleapis.com/v1/"},
+			zencoder:            &Zencoder{APIKey: "sandbox-api-key", MinRemainingMinutes: 10},
+		},
+		Campaigns: &Campaigns{Interval: 5 * time.Minute},
+		Chaos:     &Chaos{Enabled: true, WebhookInterval: 5 * time.Second},
 		GCPCredentials: &envconfigfromfile.EnvConfigFromFile{
 			FilePath: gcpCredsTestFilePath,
 			Value:    string(gcpCredsTestFileContents),
@@ -94,6 +335,21 @@ func TestLoadConfigFromEnv(t *testing.T) {
 	if cfg.DefaultSegmentDuration != expectedCfg.DefaultSegmentDuration {
 		t.Errorf("LoadConfig(): wrong default segment duration. Want %q. Got %q", expectedCfg.DefaultSegmentDuration, cfg.DefaultSegmentDuration)
 	}
+	if cfg.DeliveryEnvironment != expectedCfg.DeliveryEnvironment {
+		t.Errorf("LoadConfig(): wrong delivery environment. Want %q. Got %q", expectedCfg.DeliveryEnvironment, cfg.DeliveryEnvironment)
+	}
+	if cfg.JobIDFormat != expectedCfg.JobIDFormat {
+		t.Errorf("LoadConfig(): wrong job id format. Want %q. Got %q", expectedCfg.JobIDFormat, cfg.JobIDFormat)
+	}
+	if cfg.FallbackProviders != expectedCfg.FallbackProviders {
+		t.Errorf("LoadConfig(): wrong fallback providers. Want %q. Got %q", expectedCfg.FallbackProviders, cfg.FallbackProviders)
+	}
+	if cfg.DatabaseDriver != expectedCfg.DatabaseDriver {
+		t.Errorf("LoadConfig(): wrong database driver. Want %q. Got %q", expectedCfg.DatabaseDriver, cfg.DatabaseDriver)
+	}
+	if cfg.TenantHeader != expectedCfg.TenantHeader {
+		t.Errorf("LoadConfig(): wrong tenant header. Want %q. Got %q", expectedCfg.TenantHeader, cfg.TenantHeader)
+	}
 	if !reflect.DeepEqual(*cfg.Redis, *expectedCfg.Redis) {
 		t.Errorf("LoadConfig(): wrong Redis config returned. Want %#v. Got %#v.", *expectedCfg.Redis, *cfg.Redis)
 	}
@@ -106,6 +362,102 @@ func TestLoadConfigFromEnv(t *testing.T) {
 	if !reflect.DeepEqual(*cfg.ElementalConductor, *expectedCfg.ElementalConductor) {
 		t.Errorf("LoadConfig(): wrong Elemental Conductor config returned. Want %#v. Got %#v.", *expectedCfg.ElementalConductor, *cfg.ElementalConductor)
 	}
+	if !reflect.DeepEqual(*cfg.MediaConvert, *expectedCfg.MediaConvert) {
+		t.Errorf("LoadConfig(): wrong MediaConvert config returned. Want %#v. Got %#v.", *expectedCfg.MediaConvert, *cfg.MediaConvert)
+	}
+	if !reflect.DeepEqual(*cfg.Bitmovin, *expectedCfg.Bitmovin) {
+		t.Errorf("LoadConfig(): wrong Bitmovin config returned. Want %#v. Got %#v.", *expectedCfg.Bitmovin, *cfg.Bitmovin)
+	}
+	if !reflect.DeepEqual(*cfg.GCPTranscoder, *expectedCfg.GCPTranscoder) {
+		t.Errorf("LoadConfig(): wrong GCP Transcoder config returned. Want %#v. Got %#v.", *expectedCfg.GCPTranscoder, *cfg.GCPTranscoder)
+	}
+	if !reflect.DeepEqual(*cfg.SourceValidation, *expectedCfg.SourceValidation) {
+		t.Errorf("LoadConfig(): wrong SourceValidation config returned. Want %#v. Got %#v.", *expectedCfg.SourceValidation, *cfg.SourceValidation)
+	}
+	if !reflect.DeepEqual(*cfg.SourceEncryption, *expectedCfg.SourceEncryption) {
+		t.Errorf("LoadConfig(): wrong SourceEncryption config returned. Want %#v. Got %#v.", *expectedCfg.SourceEncryption, *cfg.SourceEncryption)
+	}
+	if !reflect.DeepEqual(*cfg.OutputEncryption, *expectedCfg.OutputEncryption) {
+		t.Errorf("LoadConfig(): wrong OutputEncryption config returned. Want %#v. Got %#v.", *expectedCfg.OutputEncryption, *cfg.OutputEncryption)
+	}
+	if !reflect.DeepEqual(*cfg.SegmentVerification, *expectedCfg.SegmentVerification) {
+		t.Errorf("LoadConfig(): wrong SegmentVerification config returned. Want %#v. Got %#v.", *expectedCfg.SegmentVerification, *cfg.SegmentVerification)
+	}
+	if !reflect.DeepEqual(*cfg.Analysis, *expectedCfg.Analysis) {
+		t.Errorf("LoadConfig(): wrong Analysis config returned. Want %#v. Got %#v.", *expectedCfg.Analysis, *cfg.Analysis)
+	}
+	if !reflect.DeepEqual(*cfg.Prediction, *expectedCfg.Prediction) {
+		t.Errorf("LoadConfig(): wrong Prediction config returned. Want %#v. Got %#v.", *expectedCfg.Prediction, *cfg.Prediction)
+	}
+	if !reflect.DeepEqual(*cfg.NetStorage, *expectedCfg.NetStorage) {
+		t.Errorf("LoadConfig(): wrong NetStorage config returned. Want %#v. Got %#v.", *expectedCfg.NetStorage, *cfg.NetStorage)
+	}
+	if !reflect.DeepEqual(*cfg.Aspera, *expectedCfg.Aspera) {
+		t.Errorf("LoadConfig(): wrong Aspera config returned. Want %#v. Got %#v.", *expectedCfg.Aspera, *cfg.Aspera)
+	}
+	if !reflect.DeepEqual(*cfg.Signiant, *expectedCfg.Signiant) {
+		t.Errorf("LoadConfig(): wrong Signiant config returned. Want %#v. Got %#v.", *expectedCfg.Signiant, *cfg.Signiant)
+	}
+	if !reflect.DeepEqual(*cfg.Uploads, *expectedCfg.Uploads) {
+		t.Errorf("LoadConfig(): wrong Uploads config returned. Want %#v. Got %#v.", *expectedCfg.Uploads, *cfg.Uploads)
+	}
+	if !reflect.DeepEqual(*cfg.Fallbacks, *expectedCfg.Fallbacks) {
+		t.Errorf("LoadConfig(): wrong Fallbacks config returned. Want %#v. Got %#v.", *expectedCfg.Fallbacks, *cfg.Fallbacks)
+	}
+	if !reflect.DeepEqual(*cfg.Reconciliation, *expectedCfg.Reconciliation) {
+		t.Errorf("LoadConfig(): wrong Reconciliation config returned. Want %#v. Got %#v.", *expectedCfg.Reconciliation, *cfg.Reconciliation)
+	}
+	if !reflect.DeepEqual(*cfg.StatusPoller, *expectedCfg.StatusPoller) {
+		t.Errorf("LoadConfig(): wrong StatusPoller config returned. Want %#v. Got %#v.", *expectedCfg.StatusPoller, *cfg.StatusPoller)
+	}
+	if !reflect.DeepEqual(*cfg.WatchFolders, *expectedCfg.WatchFolders) {
+		t.Errorf("LoadConfig(): wrong WatchFolders config returned. Want %#v. Got %#v.", *expectedCfg.WatchFolders, *cfg.WatchFolders)
+	}
+	if !reflect.DeepEqual(*cfg.Callbacks, *expectedCfg.Callbacks) {
+		t.Errorf("LoadConfig(): wrong Callbacks config returned. Want %#v. Got %#v.", *expectedCfg.Callbacks, *cfg.Callbacks)
+	}
+	if !reflect.DeepEqual(*cfg.Hooks, *expectedCfg.Hooks) {
+		t.Errorf("LoadConfig(): wrong Hooks config returned. Want %#v. Got %#v.", *expectedCfg.Hooks, *cfg.Hooks)
+	}
+	if !reflect.DeepEqual(*cfg.Policy, *expectedCfg.Policy) {
+		t.Errorf("LoadConfig(): wrong Policy config returned. Want %#v. Got %#v.", *expectedCfg.Policy, *cfg.Policy)
+	}
+	if !reflect.DeepEqual(*cfg.Deadlines, *expectedCfg.Deadlines) {
+		t.Errorf("LoadConfig(): wrong Deadlines config returned. Want %#v. Got %#v.", *expectedCfg.Deadlines, *cfg.Deadlines)
+	}
+	if !reflect.DeepEqual(*cfg.ProviderCallbacks, *expectedCfg.ProviderCallbacks) {
+		t.Errorf("LoadConfig(): wrong ProviderCallbacks config returned. Want %#v. Got %#v.", *expectedCfg.ProviderCallbacks, *cfg.ProviderCallbacks)
+	}
+	if !reflect.DeepEqual(*cfg.Backpressure, *expectedCfg.Backpressure) {
+		t.Errorf("LoadConfig(): wrong Backpressure config returned. Want %#v. Got %#v.", *expectedCfg.Backpressure, *cfg.Backpressure)
+	}
+	if !reflect.DeepEqual(*cfg.Maintenance, *expectedCfg.Maintenance) {
+		t.Errorf("LoadConfig(): wrong Maintenance config returned. Want %#v. Got %#v.", *expectedCfg.Maintenance, *cfg.Maintenance)
+	}
+	if !reflect.DeepEqual(*cfg.SelfTest, *expectedCfg.SelfTest) {
+		t.Errorf("LoadConfig(): wrong SelfTest config returned. Want %#v. Got %#v.", *expectedCfg.SelfTest, *cfg.SelfTest)
+	}
+	if !reflect.DeepEqual(*cfg.Postgres, *expectedCfg.Postgres) {
+		t.Errorf("LoadConfig(): wrong Postgres config returned. Want %#v. Got %#v.", *expectedCfg.Postgres, *cfg.Postgres)
+	}
+	if !reflect.DeepEqual(*cfg.DynamoDB, *expectedCfg.DynamoDB) {
+		t.Errorf("LoadConfig(): wrong DynamoDB config returned. Want %#v. Got %#v.", *expectedCfg.DynamoDB, *cfg.DynamoDB)
+	}
+	if !reflect.DeepEqual(*cfg.JobExpiration, *expectedCfg.JobExpiration) {
+		t.Errorf("LoadConfig(): wrong JobExpiration config returned. Want %#v. Got %#v.", *expectedCfg.JobExpiration, *cfg.JobExpiration)
+	}
+	if !reflect.DeepEqual(*cfg.Campaigns, *expectedCfg.Campaigns) {
+		t.Errorf("LoadConfig(): wrong Campaigns config returned. Want %#v. Got %#v.", *expectedCfg.Campaigns, *cfg.Campaigns)
+	}
+	if !reflect.DeepEqual(*cfg.Sandbox, *expectedCfg.Sandbox) {
+		t.Errorf("LoadConfig(): wrong Sandbox config returned. Want %#v. Got %#v.", *expectedCfg.Sandbox, *cfg.Sandbox)
+	}
+	if !reflect.DeepEqual(*cfg.Chaos, *expectedCfg.Chaos) {
+		t.Errorf("LoadConfig(): wrong Chaos config returned. Want %#v. Got %#v.", *expectedCfg.Chaos, *cfg.Chaos)
+	}
+	if !reflect.DeepEqual(*cfg.Publish, *expectedCfg.Publish) {
+		t.Errorf("LoadConfig(): wrong Publish config returned. Want %#v. Got %#v.", *expectedCfg.Publish, *cfg.Publish)
+	}
 	if !reflect.DeepEqual(*cfg.GCPCredentials, *expectedCfg.GCPCredentials) {
 		t.Errorf("LoadConfig(): Wrong GCPCredentials returned. Want %#v. Got %#v.", *expectedCfg.GCPCredentials, *cfg.GCPCredentials)
 	}
@@ -144,6 +496,9 @@ func TestLoadConfigFromEnvWithDefauts(t *testing.T) {
 	expectedCfg := Config{
 		SwaggerManifest:        "/opt/video-transcoding-api-swagger.json",
 		DefaultSegmentDuration: 5,
+		DeliveryEnvironment:    "production",
+		JobIDFormat:            "random",
+		DatabaseDriver:         "redis",
 		Redis: &storage.Config{
 			SentinelAddrs:      "10.10.10.10:26379,10.10.10.11:26379,10.10.10.12:26379",
 			SentinelMasterName: "supermaster",
@@ -175,6 +530,60 @@ func TestLoadConfigFromEnvWithDefauts(t *testing.T) {
 			SecretAccessKey: "secret-key",
 			Destination:     "https://safe-stuff",
 		},
+		MediaConvert: &MediaConvert{},
+		Bitmovin: &Bitmovin{
+			Endpoint:       "https://api.bitmovin.com/v1/",
+			EncodingRegion: "AWS_US_EAST_1",
+		},
+		GCPTranscoder: &GCPTranscoder{
+			Location: "us-central1",
+			Endpoint: "https://transcoder.googleapis.com/v1/",
+		},
+		SourceValidation: &SourceValidation{
+			AllowedPorts:   "80,443",
+			AllowedSchemes: "http,https,s3,gs,ftp,sftp",
+			BlockedHosts:   "metadata.google.internal,metadata",
+		},
+		SourceEncryption: &SourceEncryption{},
+		OutputEncryption: &OutputEncryption{Region: "us-east-1"},
+		SegmentVerification: &SegmentVerification{
+			Tolerance: 0.5,
+		},
+		Analysis: &Analysis{FFmpegPath: "ffmpeg", Region: "us-east-1", FingerprintInterval: 2},
+		Prediction: &Prediction{
+			HistorySize: 100,
+		},
+		NetStorage:        &NetStorage{},
+		Aspera:            &Aspera{TargetRate: "1g"},
+		Signiant:          &Signiant{},
+		Uploads:           &Uploads{Interval: 30 * time.Second, MaxAttempts: 3},
+		Fallbacks:         &Fallbacks{Interval: 30 * time.Second},
+		Reconciliation:    &Reconciliation{},
+		StatusPoller:      &StatusPoller{},
+		WatchFolders:      &WatchFolders{Region: "us-east-1"},
+		Callbacks:         &Callbacks{Interval: 5 * time.Second, MaxRetries: 3, RetryInterval: 10 * time.Second},
+		Hooks:             &Hooks{PostProcessorsInterval: 30 * time.Second, Timeout: 30 * time.Second, Region: "us-east-1"},
+		Policy:            &Policy{},
+		Deadlines:         &Deadlines{},
+		ProviderCallbacks: &ProviderCallbacks{},
+		Backpressure:      &Backpressure{MaxQueued: 1000, RetryAfter: 30},
+		Maintenance:       &Maintenance{Message: "the API is under maintenance, please retry later"},
+		SelfTest:          &SelfTest{Timeout: 10 * time.Minute},
+		Postgres:          &Postgres{MaxOpenConns: 10},
+		DynamoDB:          &DynamoDB{Region: "us-east-1", TablePrefix: "transcoding-"},
+		JobExpiration:     &JobExpiration{SweepInterval: time.Hour},
+		Campaigns:         &Campaigns{Interval: time.Minute},
+		Publish:           &Publish{Region: "us-east-1", StagingPrefix: "unpublished"},
+		Sandbox: &Sandbox{
+			encodingCom:        &EncodingCom{StatusEndpoint: "http://status.encoding.com"},
+			elasticTranscoder:  &ElasticTranscoder{},
+			elementalConductor: &ElementalConductor{},
+			mediaConvert:       &MediaConvert{},
+			bitmovin:           &Bitmovin{Endpoint: "https://api.bitmovin.com/v1/", EncodingRegion: "AWS_US_EAST_1"},
+			gcpTranscoder:      &GCPTranscoder{Location: "us-central1", Endpoint: "https://transcoder.googleapis.com/v1/"},
+			zencoder:           &Zencoder{},
+		},
+		Chaos: &Chaos{WebhookInterval: time.Second},
 		Server: &server.Config{
 			HTTPPort:      8080,
 			HTTPAccessLog: &accessLog,
@@ -186,6 +595,18 @@ func TestLoadConfigFromEnvWithDefauts(t *testing.T) {
 	if cfg.DefaultSegmentDuration != expectedCfg.DefaultSegmentDuration {
 		t.Errorf("LoadConfig(): wrong default segment duration. Want %q. Got %q", expectedCfg.DefaultSegmentDuration, cfg.DefaultSegmentDuration)
 	}
+	if cfg.DeliveryEnvironment != expectedCfg.DeliveryEnvironment {
+		t.Errorf("LoadConfig(): wrong delivery environment. Want %q. Got %q", expectedCfg.DeliveryEnvironment, cfg.DeliveryEnvironment)
+	}
+	if cfg.JobIDFormat != expectedCfg.JobIDFormat {
+		t.Errorf("LoadConfig(): wrong job id format. Want %q. Got %q", expectedCfg.JobIDFormat, cfg.JobIDFormat)
+	}
+	if cfg.FallbackProviders != expectedCfg.FallbackProviders {
+		t.Errorf("LoadConfig(): wrong fallback providers. Want %q. Got %q", expectedCfg.FallbackProviders, cfg.FallbackProviders)
+	}
+	if cfg.DatabaseDriver != expectedCfg.DatabaseDriver {
+		t.Errorf("LoadConfig(): wrong database driver. Want %q. Got %q", expectedCfg.DatabaseDriver, cfg.DatabaseDriver)
+	}
 	if !reflect.DeepEqual(*cfg.Redis, *expectedCfg.Redis) {
 		t.Errorf("LoadConfig(): wrong Redis config returned. Want %#v. Got %#v.", *expectedCfg.Redis, *cfg.Redis)
 	}
@@ -198,11 +619,137 @@ func TestLoadConfigFromEnvWithDefauts(t *testing.T) {
 	if !reflect.DeepEqual(*cfg.ElementalConductor, *expectedCfg.ElementalConductor) {
 		t.Errorf("LoadConfig(): wrong Elemental Conductor config returned. Want %#v. Got %#v.", *expectedCfg.ElementalConductor, *cfg.ElementalConductor)
 	}
+	if !reflect.DeepEqual(*cfg.MediaConvert, *expectedCfg.MediaConvert) {
+		t.Errorf("LoadConfig(): wrong MediaConvert config returned. Want %#v. Got %#v.", *expectedCfg.MediaConvert, *cfg.MediaConvert)
+	}
+	if !reflect.DeepEqual(*cfg.Bitmovin, *expectedCfg.Bitmovin) {
+		t.Errorf("LoadConfig(): wrong Bitmovin config returned. Want %#v. Got %#v.", *expectedCfg.Bitmovin, *cfg.Bitmovin)
+	}
+	if !reflect.DeepEqual(*cfg.GCPTranscoder, *expectedCfg.GCPTranscoder) {
+		t.Errorf("LoadConfig(): wrong GCP Transcoder config returned. Want %#v. Got %#v.", *expectedCfg.GCPTranscoder, *cfg.GCPTranscoder)
+	}
+	if !reflect.DeepEqual(*cfg.SourceValidation, *expectedCfg.SourceValidation) {
+		t.Errorf("LoadConfig(): wrong SourceValidation config returned. Want %#v. Got %#v.", *expectedCfg.SourceValidation, *cfg.SourceValidation)
+	}
+	if !reflect.DeepEqual(*cfg.SourceEncryption, *expectedCfg.SourceEncryption) {
+		t.Errorf("LoadConfig(): wrong SourceEncryption config returned. Want %#v. Got %#v.", *expectedCfg.SourceEncryption, *cfg.SourceEncryption)
+	}
+	if !reflect.DeepEqual(*cfg.OutputEncryption, *expectedCfg.OutputEncryption) {
+		t.Errorf("LoadConfig(): wrong OutputEncryption config returned. Want %#v. Got %#v.", *expectedCfg.OutputEncryption, *cfg.OutputEncryption)
+	}
+	if !reflect.DeepEqual(*cfg.SegmentVerification, *expectedCfg.SegmentVerification) {
+		t.Errorf("LoadConfig(): wrong SegmentVerification config returned. Want %#v. Got %#v.", *expectedCfg.SegmentVerification, *cfg.SegmentVerification)
+	}
+	if !reflect.DeepEqual(*cfg.Analysis, *expectedCfg.Analysis) {
+		t.Errorf("LoadConfig(): wrong Analysis config returned. Want %#v. Got %#v.", *expectedCfg.Analysis, *cfg.Analysis)
+	}
+	if !reflect.DeepEqual(*cfg.Prediction, *expectedCfg.Prediction) {
+		t.Errorf("LoadConfig(): wrong Prediction config returned. Want %#v. Got %#v.", *expectedCfg.Prediction, *cfg.Prediction)
+	}
+	if !reflect.DeepEqual(*cfg.NetStorage, *expectedCfg.NetStorage) {
+		t.Errorf("LoadConfig(): wrong NetStorage config returned. Want %#v. Got %#v.", *expectedCfg.NetStorage, *cfg.NetStorage)
+	}
+	if !reflect.DeepEqual(*cfg.Aspera, *expectedCfg.Aspera) {
+		t.Errorf("LoadConfig(): wrong Aspera config returned. Want %#v. Got %#v.", *expectedCfg.Aspera, *cfg.Aspera)
+	}
+	if !reflect.DeepEqual(*cfg.Signiant, *expectedCfg.Signiant) {
+		t.Errorf("LoadConfig(): wrong Signiant config returned. Want %#v. Got %#v.", *expectedCfg.Signiant, *cfg.Signiant)
+	}
+	if !reflect.DeepEqual(*cfg.Uploads, *expectedCfg.Uploads) {
+		t.Errorf("LoadConfig(): wrong Uploads config returned. Want %#v. Got %#v.", *expectedCfg.Uploads, *cfg.Uploads)
+	}
+	if !reflect.DeepEqual(*cfg.Fallbacks, *expectedCfg.Fallbacks) {
+		t.Errorf("LoadConfig(): wrong Fallbacks config returned. Want %#v. Got %#v.", *expectedCfg.Fallbacks, *cfg.Fallbacks)
+	}
+	if !reflect.DeepEqual(*cfg.Reconciliation, *expectedCfg.Reconciliation) {
+		t.Errorf("LoadConfig(): wrong Reconciliation config returned. Want %#v. Got %#v.", *expectedCfg.Reconciliation, *cfg.Reconciliation)
+	}
+	if !reflect.DeepEqual(*cfg.StatusPoller, *expectedCfg.StatusPoller) {
+		t.Errorf("LoadConfig(): wrong StatusPoller config returned. Want %#v. Got %#v.", *expectedCfg.StatusPoller, *cfg.StatusPoller)
+	}
+	if !reflect.DeepEqual(*cfg.WatchFolders, *expectedCfg.WatchFolders) {
+		t.Errorf("LoadConfig(): wrong WatchFolders config returned. Want %#v. Got %#v.", *expectedCfg.WatchFolders, *cfg.WatchFolders)
+	}
+	if !reflect.DeepEqual(*cfg.Callbacks, *expectedCfg.Callbacks) {
+		t.Errorf("LoadConfig(): wrong Callbacks config returned. Want %#v. Got %#v.", *expectedCfg.Callbacks, *cfg.Callbacks)
+	}
+	if !reflect.DeepEqual(*cfg.Hooks, *expectedCfg.Hooks) {
+		t.Errorf("LoadConfig(): wrong Hooks config returned. Want %#v. Got %#v.", *expectedCfg.Hooks, *cfg.Hooks)
+	}
+	if !reflect.DeepEqual(*cfg.Policy, *expectedCfg.Policy) {
+		t.Errorf("LoadConfig(): wrong Policy config returned. Want %#v. Got %#v.", *expectedCfg.Policy, *cfg.Policy)
+	}
+	if !reflect.DeepEqual(*cfg.Deadlines, *expectedCfg.Deadlines) {
+		t.Errorf("LoadConfig(): wrong Deadlines config returned. Want %#v. Got %#v.", *expectedCfg.Deadlines, *cfg.Deadlines)
+	}
+	if !reflect.DeepEqual(*cfg.ProviderCallbacks, *expectedCfg.ProviderCallbacks) {
+		t.Errorf("LoadConfig(): wrong ProviderCallbacks config returned. Want %#v. Got %#v.", *expectedCfg.ProviderCallbacks, *cfg.ProviderCallbacks)
+	}
+	if !reflect.DeepEqual(*cfg.Backpressure, *expectedCfg.Backpressure) {
+		t.Errorf("LoadConfig(): wrong Backpressure config returned. Want %#v. Got %#v.", *expectedCfg.Backpressure, *cfg.Backpressure)
+	}
+	if !reflect.DeepEqual(*cfg.Maintenance, *expectedCfg.Maintenance) {
+		t.Errorf("LoadConfig(): wrong Maintenance config returned. Want %#v. Got %#v.", *expectedCfg.Maintenance, *cfg.Maintenance)
+	}
+	if !reflect.DeepEqual(*cfg.SelfTest, *expectedCfg.SelfTest) {
+		t.Errorf("LoadConfig(): wrong SelfTest config returned. Want %#v. Got %#v.", *expectedCfg.SelfTest, *cfg.SelfTest)
+	}
+	if !reflect.DeepEqual(*cfg.Postgres, *expectedCfg.Postgres) {
+		t.Errorf("LoadConfig(): wrong Postgres config returned. Want %#v. Got %#v.", *expectedCfg.Postgres, *cfg.Postgres)
+	}
+	if !reflect.DeepEqual(*cfg.DynamoDB, *expectedCfg.DynamoDB) {
+		t.Errorf("LoadConfig(): wrong DynamoDB config returned. Want %#v. Got %#v.", *expectedCfg.DynamoDB, *cfg.DynamoDB)
+	}
+	if !reflect.DeepEqual(*cfg.JobExpiration, *expectedCfg.JobExpiration) {
+		t.Errorf("LoadConfig(): wrong JobExpiration config returned. Want %#v. Got %#v.", *expectedCfg.JobExpiration, *cfg.JobExpiration)
+	}
+	if !reflect.DeepEqual(*cfg.Campaigns, *expectedCfg.Campaigns) {
+		t.Errorf("LoadConfig(): wrong Campaigns config returned. Want %#v. Got %#v.", *expectedCfg.Campaigns, *cfg.Campaigns)
+	}
+	if !reflect.DeepEqual(*cfg.Sandbox, *expectedCfg.Sandbox) {
+		t.Errorf("LoadConfig(): wrong Sandbox config returned. Want %#v. Got %#v.", *expectedCfg.Sandbox, *cfg.Sandbox)
+	}
+	if !reflect.DeepEqual(*cfg.Chaos, *expectedCfg.Chaos) {
+		t.Errorf("LoadConfig(): wrong Chaos config returned. Want %#v. Got %#v.", *expectedCfg.Chaos, *cfg.Chaos)
+	}
+	if !reflect.DeepEqual(*cfg.Publish, *expectedCfg.Publish) {
+		t.Errorf("LoadConfig(): wrong Publish config returned. Want %#v. Got %#v.", *expectedCfg.Publish, *cfg.Publish)
+	}
 	if !reflect.DeepEqual(*cfg.Server, *expectedCfg.Server) {
 		t.Errorf("LoadConfig(): wrong Server config returned. Want %#v. Got %#v.", *expectedCfg.Server, *cfg.Server)
 	}
 }
 
+func TestSandboxConfig(t *testing.T) {
+	os.Clearenv()
+	setEnvs(map[string]string{
+		"ZENCODER_API_KEY":             "production-api-key",
+		"ELEMENTALCONDUCTOR_HOST":      "https://conductor.example.com",
+		"SANDBOX_ZENCODER_API_KEY":     "sandbox-api-key",
+		"SANDBOX_ZENCODER_DESTINATION": "s3://sandbox-bucket/",
+		"SANDBOX_ENCODINGCOM_USER_ID":  "sandbox-user",
+		"SANDBOX_ALLOWED_DESTINATIONS": "s3://sandbox-bucket/",
+	})
+	cfg := LoadConfig()
+	sandbox := cfg.SandboxConfig()
+	if sandbox.Zencoder.APIKey != "sandbox-api-key" || sandbox.Zencoder.Destination != "s3://sandbox-bucket/" {
+		t.Errorf("wrong sandbox Zencoder config: %#v", *sandbox.Zencoder)
+	}
+	if sandbox.EncodingCom.UserID != "sandbox-user" {
+		t.Errorf("wrong sandbox EncodingCom config: %#v", *sandbox.EncodingCom)
+	}
+	if sandbox.ElementalConductor.Host != "" {
+		t.Errorf("sandbox should never use production credentials. Got %#v", *sandbox.ElementalConductor)
+	}
+	if cfg.Zencoder.APIKey != "production-api-key" {
+		t.Errorf("production config changed: %#v", *cfg.Zencoder)
+	}
+	empty := (&Config{}).SandboxConfig()
+	if empty.Zencoder == nil || *empty.Zencoder != (Zencoder{}) {
+		t.Errorf("wrong sandbox Zencoder config for unloaded sandbox: %#v", empty.Zencoder)
+	}
+}
+
 func setEnvs(envs map[string]string) {
 	for k, v := range envs {
 		os.Setenv(k, v)
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// sandboxPrefix is the prefix of the environment variables that configure
+// the providers in the sandbox environment.
+const sandboxPrefix = "SANDBOX_"
+
+// Sandbox represents the sandbox environment of the providers. Providers are
+// configured in the sandbox with the same environment variables used in
+// production, prefixed by SANDBOX_ (for example, SANDBOX_ZENCODER_API_KEY).
+// Sandbox jobs can only write to destinations starting with one of the
+// AllowedDestinations (a comma separated list of prefixes).
+type Sandbox struct {
+	AllowedDestinations string `envconfig:"SANDBOX_ALLOWED_DESTINATIONS"`
+
+	encodingCom        *EncodingCom
+	elasticTranscoder  *ElasticTranscoder
+	elementalConductor *ElementalConductor
+	mediaConvert       *MediaConvert
+	bitmovin           *Bitmovin
+	gcpTranscoder      *GCPTranscoder
+	zencoder           *Zencoder
+}
+
+func (s *Sandbox) loadProviders() {
+	s.encodingCom = new(EncodingCom)
+	s.elasticTranscoder = new(ElasticTranscoder)
+	s.elementalConductor = new(ElementalConductor)
+	s.mediaConvert = new(MediaConvert)
+	s.bitmovin = new(Bitmovin)
+	s.gcpTranscoder = new(GCPTranscoder)
+	s.zencoder = new(Zencoder)
+	loadFromPrefixedEnv(sandboxPrefix, s.encodingCom, s.elasticTranscoder, s.elementalConductor, s.mediaConvert, s.bitmovin, s.gcpTranscoder, s.zencoder)
+}
+
+// SandboxConfig returns a copy of the configuration where the providers are
+// configured for the sandbox environment. Providers that aren't configured
+// in the sandbox get an empty configuration, so they're never initialized
+// with production credentials.
+func (c *Config) SandboxConfig() *Config {
+	sandbox := *c
+	s := c.Sandbox
+	if s == nil {
+		s = new(Sandbox)
+	}
+	sandbox.EncodingCom = s.encodingCom
+	if sandbox.EncodingCom == nil {
+		sandbox.EncodingCom = new(EncodingCom)
+	}
+	sandbox.ElasticTranscoder = s.elasticTranscoder
+	if sandbox.ElasticTranscoder == nil {
+		sandbox.ElasticTranscoder = new(ElasticTranscoder)
+	}
+	sandbox.ElementalConductor = s.elementalConductor
+	if sandbox.ElementalConductor == nil {
+		sandbox.ElementalConductor = new(ElementalConductor)
+	}
+	sandbox.MediaConvert = s.mediaConvert
+	if sandbox.MediaConvert == nil {
+		sandbox.MediaConvert = new(MediaConvert)
+	}
+	sandbox.Bitmovin = s.bitmovin
+	if sandbox.Bitmovin == nil {
+		sandbox.Bitmovin = new(Bitmovin)
+	}
+	sandbox.GCPTranscoder = s.gcpTranscoder
+	if sandbox.GCPTranscoder == nil {
+		sandbox.GCPTranscoder = new(GCPTranscoder)
+	}
+	sandbox.Zencoder = s.zencoder
+	if sandbox.Zencoder == nil {
+		sandbox.Zencoder = new(Zencoder)
+	}
+	return &sandbox
+}
+
+// loadFromPrefixedEnv loads the given configurations from the environment
+// variables named after their envconfig tags with the given prefix. Unlike
+// envconfig, it never falls back to the variables without the prefix.
+func loadFromPrefixedEnv(prefix string, cfgs ...interface{}) {
+	for _, cfg := range cfgs {
+		value := reflect.ValueOf(cfg).Elem()
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			name := field.Tag.Get("envconfig")
+			if name == "" {
+				continue
+			}
+			env, ok := os.LookupEnv(prefix + name)
+			if !ok {
+				env = field.Tag.Get("default")
+			}
+			if env != "" {
+				setField(value.Field(i), env)
+			}
+		}
+	}
+}
+
+// setField sets the given value to the field, ignoring invalid values.
+func setField(field reflect.Value, value string) {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		if d, err := time.ParseDuration(value); err == nil {
+			field.SetInt(int64(d))
+		}
+		return
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			field.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(value, 10, field.Type().Bits()); err == nil {
+			field.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(value, 10, field.Type().Bits()); err == nil {
+			field.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, field.Type().Bits()); err == nil {
+			field.SetFloat(f)
+		}
+	}
+}
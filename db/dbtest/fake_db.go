@@ -2,6 +2,8 @@ package dbtest
 
 import (
 	"errors"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/NYTimes/video-transcoding-api/db"
@@ -10,7 +12,23 @@ import (
 type fakeRepository struct {
 	triggerError bool
 	presetmaps   map[string]*db.PresetMap
+	versions     map[string][]db.PresetMapVersion
 	localpresets map[string]*db.LocalPreset
+	tenants      map[string]*db.Tenant
+	artifacts    map[string][]*db.Artifact
+	experiments  map[string]*db.Experiment
+	samples      map[string]map[string]*db.ExperimentSample
+	encodes      map[string]map[string]*db.SourceEncode
+	targets      map[string]*db.DeliveryTarget
+	ladders      map[string]*db.Ladder
+	campaigns    map[string]*db.Campaign
+	watchFolders map[string]*db.WatchFolder
+	pauses       map[string]*db.SubmissionPause
+	maintenance  *db.MaintenanceMode
+	throughputs  map[string]*db.ProviderThroughput
+	notifyMtx    sync.Mutex // notifications are delivered concurrently
+	notifs       map[string]db.Notification
+	sequences    map[string]uint64
 	jobs         []*db.Job
 }
 
@@ -21,7 +39,21 @@ func NewFakeRepository(triggerError bool) db.Repository {
 	return &fakeRepository{
 		triggerError: triggerError,
 		presetmaps:   make(map[string]*db.PresetMap),
+		versions:     make(map[string][]db.PresetMapVersion),
 		localpresets: make(map[string]*db.LocalPreset),
+		tenants:      make(map[string]*db.Tenant),
+		artifacts:    make(map[string][]*db.Artifact),
+		experiments:  make(map[string]*db.Experiment),
+		samples:      make(map[string]map[string]*db.ExperimentSample),
+		encodes:      make(map[string]map[string]*db.SourceEncode),
+		targets:      make(map[string]*db.DeliveryTarget),
+		ladders:      make(map[string]*db.Ladder),
+		campaigns:    make(map[string]*db.Campaign),
+		watchFolders: make(map[string]*db.WatchFolder),
+		pauses:       make(map[string]*db.SubmissionPause),
+		throughputs:  make(map[string]*db.ProviderThroughput),
+		notifs:       make(map[string]db.Notification),
+		sequences:    make(map[string]uint64),
 	}
 }
 
@@ -36,6 +68,33 @@ func (d *fakeRepository) CreateJob(job *db.Job) error {
 	return nil
 }
 
+func (d *fakeRepository) UpdateJob(job *db.Job) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	index, err := d.findJob(job.ID)
+	if err != nil {
+		return err
+	}
+	d.jobs[index] = job
+	return nil
+}
+
+func (d *fakeRepository) UpdateJobIfStatus(job *db.Job, status string) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	index, err := d.findJob(job.ID)
+	if err != nil {
+		return err
+	}
+	if d.jobs[index].Status != status {
+		return db.ErrJobStatusChanged
+	}
+	d.jobs[index] = job
+	return nil
+}
+
 func (d *fakeRepository) DeleteJob(job *db.Job) error {
 	if d.triggerError {
 		return errors.New("database error")
@@ -81,20 +140,65 @@ func (d *fakeRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
 		return nil, errors.New("database error")
 	}
 	jobs := make([]db.Job, 0, len(d.jobs))
-	var count uint
+	var skipped uint
 	for _, job := range d.jobs {
 		if job.CreationTime.Before(filter.Since) {
 			continue
 		}
-		if filter.Limit != 0 && count == filter.Limit {
+		if !filter.Until.IsZero() && !job.CreationTime.Before(filter.Until) {
+			continue
+		}
+		if filter.Status != "" && job.Status != filter.Status {
+			continue
+		}
+		if filter.ProviderName != "" && job.ProviderName != filter.ProviderName {
+			continue
+		}
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		if filter.Limit != 0 && uint(len(jobs)) == filter.Limit {
 			break
 		}
 		jobs = append(jobs, *job)
-		count++
 	}
 	return jobs, nil
 }
 
+func (d *fakeRepository) ListJobsByExternalID(tenant, externalID string) ([]db.Job, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	var jobs []db.Job
+	for _, job := range d.jobs {
+		if job.Tenant == tenant && job.ExternalID == externalID {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
+
+func (d *fakeRepository) GetJobByProviderJobID(providerName, providerJobID string) (*db.Job, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	for _, job := range d.jobs {
+		if job.ProviderName == providerName && job.ProviderJobID == providerJobID {
+			return job, nil
+		}
+	}
+	return nil, db.ErrJobNotFound
+}
+
+func (d *fakeRepository) NextJobSequence(tenant string) (uint64, error) {
+	if d.triggerError {
+		return 0, errors.New("database error")
+	}
+	d.sequences[tenant]++
+	return d.sequences[tenant], nil
+}
+
 func (d *fakeRepository) CreatePresetMap(presetmap *db.PresetMap) error {
 	if d.triggerError {
 		return errors.New("database error")
@@ -105,6 +209,7 @@ func (d *fakeRepository) CreatePresetMap(presetmap *db.PresetMap) error {
 	if _, ok := d.presetmaps[presetmap.Name]; ok {
 		return db.ErrPresetMapAlreadyExists
 	}
+	presetmap.Version = len(d.versions[presetmap.Name]) + 1
 	d.presetmaps[presetmap.Name] = presetmap
 	return nil
 }
@@ -113,9 +218,12 @@ func (d *fakeRepository) UpdatePresetMap(presetmap *db.PresetMap) error {
 	if d.triggerError {
 		return errors.New("database error")
 	}
-	if _, ok := d.presetmaps[presetmap.Name]; !ok {
+	current, ok := d.presetmaps[presetmap.Name]
+	if !ok {
 		return db.ErrPresetMapNotFound
 	}
+	d.addPresetMapVersion(current)
+	presetmap.Version = len(d.versions[presetmap.Name]) + 1
 	d.presetmaps[presetmap.Name] = presetmap
 	return nil
 }
@@ -137,11 +245,12 @@ func (d *fakeRepository) DeletePresetMap(presetmap *db.PresetMap) error {
 	if _, ok := d.presetmaps[presetmap.Name]; !ok {
 		return db.ErrPresetMapNotFound
 	}
+	d.addPresetMapVersion(d.presetmaps[presetmap.Name])
 	delete(d.presetmaps, presetmap.Name)
 	return nil
 }
 
-func (d *fakeRepository) ListPresetMaps() ([]db.PresetMap, error) {
+func (d *fakeRepository) ListPresetMaps(filter db.PresetMapFilter) ([]db.PresetMap, error) {
 	if d.triggerError {
 		return nil, errors.New("database error")
 	}
@@ -149,7 +258,29 @@ func (d *fakeRepository) ListPresetMaps() ([]db.PresetMap, error) {
 	for _, presetmap := range d.presetmaps {
 		presetmaps = append(presetmaps, *presetmap)
 	}
-	return presetmaps, nil
+	return filter.Apply(presetmaps), nil
+}
+
+// addPresetMapVersion stores a copy of the given presetmap as its last
+// version.
+func (d *fakeRepository) addPresetMapVersion(presetmap *db.PresetMap) {
+	version := db.PresetMapVersion{
+		Version:    len(d.versions[presetmap.Name]) + 1,
+		ReplacedAt: time.Now().UTC(),
+		PresetMap:  *presetmap,
+	}
+	version.PresetMap.ProviderMapping = make(map[string]string, len(presetmap.ProviderMapping))
+	for provider, presetID := range presetmap.ProviderMapping {
+		version.PresetMap.ProviderMapping[provider] = presetID
+	}
+	d.versions[presetmap.Name] = append(d.versions[presetmap.Name], version)
+}
+
+func (d *fakeRepository) ListPresetMapVersions(name string) ([]db.PresetMapVersion, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	return append([]db.PresetMapVersion{}, d.versions[name]...), nil
 }
 
 func (d *fakeRepository) CreateLocalPreset(preset *db.LocalPreset) error {
@@ -198,3 +329,534 @@ func (d *fakeRepository) DeleteLocalPreset(preset *db.LocalPreset) error {
 	delete(d.localpresets, preset.Name)
 	return nil
 }
+
+func (d *fakeRepository) CreateTenant(tenant *db.Tenant) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if tenant.Name == "" {
+		return errors.New("invalid tenant name")
+	}
+	if _, ok := d.tenants[tenant.Name]; ok {
+		return db.ErrTenantAlreadyExists
+	}
+	d.tenants[tenant.Name] = tenant
+	return nil
+}
+
+func (d *fakeRepository) UpdateTenant(tenant *db.Tenant) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.tenants[tenant.Name]; !ok {
+		return db.ErrTenantNotFound
+	}
+	d.tenants[tenant.Name] = tenant
+	return nil
+}
+
+func (d *fakeRepository) GetTenant(name string) (*db.Tenant, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	if tenant, ok := d.tenants[name]; ok {
+		return tenant, nil
+	}
+	return nil, db.ErrTenantNotFound
+}
+
+func (d *fakeRepository) DeleteTenant(tenant *db.Tenant) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.tenants[tenant.Name]; !ok {
+		return db.ErrTenantNotFound
+	}
+	delete(d.tenants, tenant.Name)
+	return nil
+}
+
+func (d *fakeRepository) ListTenants() ([]db.Tenant, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	tenants := make([]db.Tenant, 0, len(d.tenants))
+	for _, tenant := range d.tenants {
+		tenants = append(tenants, *tenant)
+	}
+	return tenants, nil
+}
+
+func (d *fakeRepository) CreateArtifact(artifact *db.Artifact) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	for _, a := range d.artifacts[artifact.JobID] {
+		if a.Name == artifact.Name {
+			return db.ErrArtifactAlreadyExists
+		}
+	}
+	if artifact.CreationTime.IsZero() {
+		artifact.CreationTime = time.Now().UTC()
+	}
+	d.artifacts[artifact.JobID] = append(d.artifacts[artifact.JobID], artifact)
+	return nil
+}
+
+func (d *fakeRepository) ListArtifacts(jobID string) ([]db.Artifact, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	artifacts := make([]db.Artifact, 0, len(d.artifacts[jobID]))
+	for _, artifact := range d.artifacts[jobID] {
+		artifacts = append(artifacts, *artifact)
+	}
+	return artifacts, nil
+}
+
+func (d *fakeRepository) CreateExperiment(experiment *db.Experiment) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if experiment.Name == "" {
+		return errors.New("invalid experiment name")
+	}
+	if _, ok := d.experiments[experiment.Name]; ok {
+		return db.ErrExperimentAlreadyExists
+	}
+	if experiment.CreationTime.IsZero() {
+		experiment.CreationTime = time.Now().UTC()
+	}
+	d.experiments[experiment.Name] = experiment
+	return nil
+}
+
+func (d *fakeRepository) DeleteExperiment(experiment *db.Experiment) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.experiments[experiment.Name]; !ok {
+		return db.ErrExperimentNotFound
+	}
+	delete(d.experiments, experiment.Name)
+	delete(d.samples, experiment.Name)
+	return nil
+}
+
+func (d *fakeRepository) GetExperiment(name string) (*db.Experiment, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	if experiment, ok := d.experiments[name]; ok {
+		return experiment, nil
+	}
+	return nil, db.ErrExperimentNotFound
+}
+
+func (d *fakeRepository) ListExperiments() ([]db.Experiment, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	experiments := make([]db.Experiment, 0, len(d.experiments))
+	for _, experiment := range d.experiments {
+		experiments = append(experiments, *experiment)
+	}
+	return experiments, nil
+}
+
+func (d *fakeRepository) SaveExperimentSample(sample *db.ExperimentSample) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if sample.CreationTime.IsZero() {
+		sample.CreationTime = time.Now().UTC()
+	}
+	if d.samples[sample.Experiment] == nil {
+		d.samples[sample.Experiment] = make(map[string]*db.ExperimentSample)
+	}
+	d.samples[sample.Experiment][sample.JobID] = sample
+	return nil
+}
+
+func (d *fakeRepository) ListExperimentSamples(experiment string) ([]db.ExperimentSample, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	samples := make([]db.ExperimentSample, 0, len(d.samples[experiment]))
+	for _, sample := range d.samples[experiment] {
+		samples = append(samples, *sample)
+	}
+	return samples, nil
+}
+
+func (d *fakeRepository) SaveSourceEncode(encode *db.SourceEncode) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if d.encodes[encode.Checksum] == nil {
+		d.encodes[encode.Checksum] = make(map[string]*db.SourceEncode)
+	}
+	d.encodes[encode.Checksum][encode.JobID] = encode
+	return nil
+}
+
+func (d *fakeRepository) ListSourceEncodes(checksum string) ([]db.SourceEncode, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	encodes := make([]db.SourceEncode, 0, len(d.encodes[checksum]))
+	for _, encode := range d.encodes[checksum] {
+		encodes = append(encodes, *encode)
+	}
+	return encodes, nil
+}
+
+func (d *fakeRepository) CreateDeliveryTarget(target *db.DeliveryTarget) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if target.Name == "" {
+		return errors.New("invalid delivery target name")
+	}
+	if _, ok := d.targets[target.Name]; ok {
+		return db.ErrDeliveryTargetAlreadyExists
+	}
+	d.targets[target.Name] = target
+	return nil
+}
+
+func (d *fakeRepository) UpdateDeliveryTarget(target *db.DeliveryTarget) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.targets[target.Name]; !ok {
+		return db.ErrDeliveryTargetNotFound
+	}
+	d.targets[target.Name] = target
+	return nil
+}
+
+func (d *fakeRepository) GetDeliveryTarget(name string) (*db.DeliveryTarget, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	if target, ok := d.targets[name]; ok {
+		return target, nil
+	}
+	return nil, db.ErrDeliveryTargetNotFound
+}
+
+func (d *fakeRepository) DeleteDeliveryTarget(target *db.DeliveryTarget) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.targets[target.Name]; !ok {
+		return db.ErrDeliveryTargetNotFound
+	}
+	delete(d.targets, target.Name)
+	return nil
+}
+
+func (d *fakeRepository) ListDeliveryTargets() ([]db.DeliveryTarget, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	targets := make([]db.DeliveryTarget, 0, len(d.targets))
+	for _, target := range d.targets {
+		targets = append(targets, *target)
+	}
+	return targets, nil
+}
+
+func (d *fakeRepository) CreateLadder(ladder *db.Ladder) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if ladder.Name == "" {
+		return errors.New("invalid ladder name")
+	}
+	if _, ok := d.ladders[ladder.Name]; ok {
+		return db.ErrLadderAlreadyExists
+	}
+	d.ladders[ladder.Name] = ladder
+	return nil
+}
+
+func (d *fakeRepository) UpdateLadder(ladder *db.Ladder) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.ladders[ladder.Name]; !ok {
+		return db.ErrLadderNotFound
+	}
+	d.ladders[ladder.Name] = ladder
+	return nil
+}
+
+func (d *fakeRepository) GetLadder(name string) (*db.Ladder, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	if ladder, ok := d.ladders[name]; ok {
+		return ladder, nil
+	}
+	return nil, db.ErrLadderNotFound
+}
+
+func (d *fakeRepository) DeleteLadder(ladder *db.Ladder) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.ladders[ladder.Name]; !ok {
+		return db.ErrLadderNotFound
+	}
+	delete(d.ladders, ladder.Name)
+	return nil
+}
+
+func (d *fakeRepository) ListLadders() ([]db.Ladder, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	ladders := make([]db.Ladder, 0, len(d.ladders))
+	for _, ladder := range d.ladders {
+		ladders = append(ladders, *ladder)
+	}
+	return ladders, nil
+}
+
+func (d *fakeRepository) CreateWatchFolder(folder *db.WatchFolder) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if folder.Name == "" {
+		return errors.New("invalid watch folder name")
+	}
+	if _, ok := d.watchFolders[folder.Name]; ok {
+		return db.ErrWatchFolderAlreadyExists
+	}
+	d.watchFolders[folder.Name] = folder
+	return nil
+}
+
+func (d *fakeRepository) GetWatchFolder(name string) (*db.WatchFolder, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	if folder, ok := d.watchFolders[name]; ok {
+		return folder, nil
+	}
+	return nil, db.ErrWatchFolderNotFound
+}
+
+func (d *fakeRepository) DeleteWatchFolder(folder *db.WatchFolder) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.watchFolders[folder.Name]; !ok {
+		return db.ErrWatchFolderNotFound
+	}
+	delete(d.watchFolders, folder.Name)
+	return nil
+}
+
+func (d *fakeRepository) ListWatchFolders() ([]db.WatchFolder, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	folders := make([]db.WatchFolder, 0, len(d.watchFolders))
+	for _, folder := range d.watchFolders {
+		folders = append(folders, *folder)
+	}
+	return folders, nil
+}
+
+func (d *fakeRepository) SetMaintenanceMode(mode *db.MaintenanceMode) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	mode.UpdateTime = time.Now().UTC()
+	d.maintenance = mode
+	return nil
+}
+
+func (d *fakeRepository) GetMaintenanceMode() (*db.MaintenanceMode, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	if d.maintenance == nil {
+		return nil, db.ErrMaintenanceModeNotFound
+	}
+	return d.maintenance, nil
+}
+
+func (d *fakeRepository) SaveProviderThroughput(throughput *db.ProviderThroughput) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	throughput.UpdateTime = time.Now().UTC()
+	d.throughputs[throughput.Provider] = throughput
+	return nil
+}
+
+func (d *fakeRepository) GetProviderThroughput(providerName string) (*db.ProviderThroughput, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	throughput, ok := d.throughputs[providerName]
+	if !ok {
+		return nil, db.ErrProviderThroughputNotFound
+	}
+	return throughput, nil
+}
+
+func (d *fakeRepository) CreateNotification(notification *db.Notification) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	d.notifyMtx.Lock()
+	defer d.notifyMtx.Unlock()
+	if _, ok := d.notifs[notification.ID]; ok {
+		return db.ErrNotificationAlreadyExists
+	}
+	notification.CreationTime = time.Now().UTC()
+	d.notifs[notification.ID] = *notification
+	return nil
+}
+
+func (d *fakeRepository) UpdateNotification(notification *db.Notification) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	d.notifyMtx.Lock()
+	defer d.notifyMtx.Unlock()
+	if _, ok := d.notifs[notification.ID]; !ok {
+		return db.ErrNotificationNotFound
+	}
+	d.notifs[notification.ID] = *notification
+	return nil
+}
+
+func (d *fakeRepository) DeleteNotification(notification *db.Notification) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	d.notifyMtx.Lock()
+	defer d.notifyMtx.Unlock()
+	if _, ok := d.notifs[notification.ID]; !ok {
+		return db.ErrNotificationNotFound
+	}
+	delete(d.notifs, notification.ID)
+	return nil
+}
+
+func (d *fakeRepository) ListNotifications() ([]db.Notification, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	d.notifyMtx.Lock()
+	defer d.notifyMtx.Unlock()
+	notifications := make([]db.Notification, 0, len(d.notifs))
+	for _, notification := range d.notifs {
+		notifications = append(notifications, notification)
+	}
+	sort.Sort(notificationsByID(notifications))
+	return notifications, nil
+}
+
+type notificationsByID []db.Notification
+
+func (n notificationsByID) Len() int           { return len(n) }
+func (n notificationsByID) Less(i, j int) bool { return n[i].ID < n[j].ID }
+func (n notificationsByID) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+
+func (d *fakeRepository) CreateSubmissionPause(pause *db.SubmissionPause) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.pauses[pause.Provider]; ok {
+		return db.ErrSubmissionPauseAlreadyExists
+	}
+	pause.CreationTime = time.Now().UTC()
+	d.pauses[pause.Provider] = pause
+	return nil
+}
+
+func (d *fakeRepository) DeleteSubmissionPause(pause *db.SubmissionPause) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.pauses[pause.Provider]; !ok {
+		return db.ErrSubmissionPauseNotFound
+	}
+	delete(d.pauses, pause.Provider)
+	return nil
+}
+
+func (d *fakeRepository) ListSubmissionPauses() ([]db.SubmissionPause, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	pauses := make([]db.SubmissionPause, 0, len(d.pauses))
+	for _, pause := range d.pauses {
+		pauses = append(pauses, *pause)
+	}
+	return pauses, nil
+}
+
+func (d *fakeRepository) CreateCampaign(campaign *db.Campaign) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if campaign.Name == "" {
+		return errors.New("invalid campaign name")
+	}
+	if _, ok := d.campaigns[campaign.Name]; ok {
+		return db.ErrCampaignAlreadyExists
+	}
+	d.campaigns[campaign.Name] = campaign
+	return nil
+}
+
+func (d *fakeRepository) UpdateCampaign(campaign *db.Campaign) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.campaigns[campaign.Name]; !ok {
+		return db.ErrCampaignNotFound
+	}
+	d.campaigns[campaign.Name] = campaign
+	return nil
+}
+
+func (d *fakeRepository) GetCampaign(name string) (*db.Campaign, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	if campaign, ok := d.campaigns[name]; ok {
+		return campaign, nil
+	}
+	return nil, db.ErrCampaignNotFound
+}
+
+func (d *fakeRepository) DeleteCampaign(campaign *db.Campaign) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.campaigns[campaign.Name]; !ok {
+		return db.ErrCampaignNotFound
+	}
+	delete(d.campaigns, campaign.Name)
+	return nil
+}
+
+func (d *fakeRepository) ListCampaigns() ([]db.Campaign, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	campaigns := make([]db.Campaign, 0, len(d.campaigns))
+	for _, campaign := range d.campaigns {
+		campaigns = append(campaigns, *campaign)
+	}
+	return campaigns, nil
+}
@@ -91,6 +91,55 @@ func TestGetJobDBError(t *testing.T) {
 	}
 }
 
+func TestUpdateJob(t *testing.T) {
+	repo := NewFakeRepository(false)
+	job := db.Job{ID: "j-123", ProviderName: "myprovider", ProviderJobID: "abc"}
+	err := repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := job
+	updated.ProviderJobID = "def"
+	err = repo.UpdateJob(&updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrievedJob, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*retrievedJob, updated) {
+		t.Errorf("Wrong job returned. Want %#v. Got %#v", updated, *retrievedJob)
+	}
+	err = repo.UpdateJob(&db.Job{ID: "some-job"})
+	if err != db.ErrJobNotFound {
+		t.Errorf("Wrong error returned. Want %#v. Got %#v", db.ErrJobNotFound, err)
+	}
+}
+
+func TestUpdateJobIfStatus(t *testing.T) {
+	repo := NewFakeRepository(false)
+	job := db.Job{ID: "j-123", ProviderName: "myprovider", Status: "queued-locally"}
+	err := repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed := job
+	claimed.Status = "submitting"
+	err = repo.UpdateJobIfStatus(&claimed, "queued-locally")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.UpdateJobIfStatus(&claimed, "queued-locally")
+	if err != db.ErrJobStatusChanged {
+		t.Errorf("Wrong error returned. Want %#v. Got %#v", db.ErrJobStatusChanged, err)
+	}
+	err = repo.UpdateJobIfStatus(&db.Job{ID: "some-job"}, "")
+	if err != db.ErrJobNotFound {
+		t.Errorf("Wrong error returned. Want %#v. Got %#v", db.ErrJobNotFound, err)
+	}
+}
+
 func TestDeleteJob(t *testing.T) {
 	repo := NewFakeRepository(false)
 	job := db.Job{ID: "j-123", ProviderName: "myprovider"}
@@ -205,6 +254,48 @@ func TestListJobsLimit(t *testing.T) {
 	}
 }
 
+func TestListJobsFilters(t *testing.T) {
+	now := time.Now().UTC()
+	repo := NewFakeRepository(false)
+	jobs := []db.Job{
+		{ID: "job-1", ProviderName: "zencoder", Status: "finished", CreationTime: now.Add(-time.Hour)},
+		{ID: "job-2", ProviderName: "mediaconvert", Status: "finished", CreationTime: now.Add(-40 * time.Minute)},
+		{ID: "job-3", ProviderName: "zencoder", Status: "failed", CreationTime: now.Add(-20 * time.Minute)},
+		{ID: "job-4", ProviderName: "zencoder", Status: "finished", CreationTime: now.Add(-10 * time.Minute)},
+	}
+	for i := range jobs {
+		if err := repo.CreateJob(&jobs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var tests = []struct {
+		givenTestCase string
+		givenFilter   db.JobFilter
+		wantJobs      []string
+	}{
+		{"status", db.JobFilter{Status: "finished"}, []string{"job-1", "job-2", "job-4"}},
+		{"provider", db.JobFilter{ProviderName: "zencoder"}, []string{"job-1", "job-3", "job-4"}},
+		{"status and provider", db.JobFilter{Status: "finished", ProviderName: "zencoder"}, []string{"job-1", "job-4"}},
+		{"until", db.JobFilter{Until: now.Add(-20 * time.Minute)}, []string{"job-1", "job-2"}},
+		{"offset", db.JobFilter{Offset: 3}, []string{"job-4"}},
+		{"offset and limit", db.JobFilter{ProviderName: "zencoder", Offset: 1, Limit: 1}, []string{"job-3"}},
+		{"offset past the end", db.JobFilter{Offset: 10}, []string{}},
+	}
+	for _, test := range tests {
+		gotJobs, err := repo.ListJobs(test.givenFilter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotIDs := []string{}
+		for _, job := range gotJobs {
+			gotIDs = append(gotIDs, job.ID)
+		}
+		if !reflect.DeepEqual(gotIDs, test.wantJobs) {
+			t.Errorf("%s: wrong jobs returned. Want %#v. Got %#v", test.givenTestCase, test.wantJobs, gotIDs)
+		}
+	}
+}
+
 func TestListJobsDBError(t *testing.T) {
 	repo := NewFakeRepository(true)
 	jobs, err := repo.ListJobs(db.JobFilter{})
@@ -216,6 +307,73 @@ func TestListJobsDBError(t *testing.T) {
 	}
 }
 
+func TestListJobsByExternalID(t *testing.T) {
+	repo := NewFakeRepository(false)
+	jobs := []db.Job{
+		{ID: "job-1", Tenant: "nyt", ExternalID: "asset-1"},
+		{ID: "job-2", Tenant: "nyt", ExternalID: "asset-2"},
+		{ID: "job-3", Tenant: "other", ExternalID: "asset-1"},
+		{ID: "job-4", Tenant: "nyt", ExternalID: "asset-1"},
+	}
+	for i := range jobs {
+		if err := repo.CreateJob(&jobs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := repo.ListJobsByExternalID("nyt", "asset-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []db.Job{jobs[0], jobs[3]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrong jobs returned. Want %#v. Got %#v", want, got)
+	}
+}
+
+func TestGetJobByProviderJobID(t *testing.T) {
+	repo := NewFakeRepository(false)
+	jobs := []db.Job{
+		{ID: "job-1", ProviderName: "zencoder", ProviderJobID: "123"},
+		{ID: "job-2", ProviderName: "mediaconvert", ProviderJobID: "123"},
+	}
+	for i := range jobs {
+		if err := repo.CreateJob(&jobs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := repo.GetJobByProviderJobID("mediaconvert", "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "job-2" {
+		t.Errorf("Wrong job returned. Want job-2. Got %#v", got)
+	}
+	_, err = repo.GetJobByProviderJobID("zencoder", "456")
+	if err != db.ErrJobNotFound {
+		t.Errorf("Wrong error returned. Want %#v. Got %#v", db.ErrJobNotFound, err)
+	}
+}
+
+func TestNextJobSequence(t *testing.T) {
+	repo := NewFakeRepository(false)
+	for _, want := range []uint64{1, 2} {
+		got, err := repo.NextJobSequence("nyt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("wrong sequence number. Want %d. Got %d", want, got)
+		}
+	}
+	got, err := repo.NextJobSequence("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("sequences should be kept per tenant. Want 1. Got %d", got)
+	}
+}
+
 func TestCreatePresetMap(t *testing.T) {
 	repo := NewFakeRepository(false)
 	preset := db.PresetMap{Name: "mypreset"}
@@ -399,7 +557,7 @@ func TestListPresetMaps(t *testing.T) {
 		t.Fatal(err)
 	}
 	expectedPresetMaps := []db.PresetMap{preset}
-	presets, err := repo.ListPresetMaps()
+	presets, err := repo.ListPresetMaps(db.PresetMapFilter{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -410,7 +568,7 @@ func TestListPresetMaps(t *testing.T) {
 
 func TestListPresetMapsDBError(t *testing.T) {
 	repo := NewFakeRepository(true)
-	presets, err := repo.ListPresetMaps()
+	presets, err := repo.ListPresetMaps(db.PresetMapFilter{})
 	if len(presets) > 0 {
 		t.Errorf("ListPresetMaps: got unexpected non-empty list: %#v", presets)
 	}
@@ -573,3 +731,243 @@ func TestDeleteLocalPresetDBError(t *testing.T) {
 		t.Errorf("DeleteLocalPreset: wrong error message. Want %q. Got %q", dbErrorMsg, err.Error())
 	}
 }
+
+func TestCreateTenant(t *testing.T) {
+	repo := NewFakeRepository(false)
+	tenant := db.Tenant{Name: "newsroom", Defaults: db.JobDefaults{Provider: "zencoder"}}
+	err := repo.CreateTenant(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTenants := map[string]*db.Tenant{"newsroom": &tenant}
+	tenants := repo.(*fakeRepository).tenants
+	if !reflect.DeepEqual(tenants, expectedTenants) {
+		t.Errorf("Wrong internal tenant registry. Want %#v. Got %#v", expectedTenants, tenants)
+	}
+	err = repo.CreateTenant(&tenant)
+	if err != db.ErrTenantAlreadyExists {
+		t.Errorf("CreateTenant: wrong error returned. Want %#v. Got %#v", db.ErrTenantAlreadyExists, err)
+	}
+}
+
+func TestGetTenant(t *testing.T) {
+	repo := NewFakeRepository(false)
+	tenant := db.Tenant{Name: "newsroom", Defaults: db.JobDefaults{Provider: "zencoder"}}
+	err := repo.CreateTenant(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotTenant, err := repo.GetTenant(tenant.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotTenant, tenant) {
+		t.Errorf("GetTenant: wrong tenant returned. Want %#v. Got %#v", tenant, *gotTenant)
+	}
+	_, err = repo.GetTenant("cooking")
+	if err != db.ErrTenantNotFound {
+		t.Errorf("GetTenant: wrong error. Want ErrTenantNotFound. Got %#v", err)
+	}
+}
+
+func TestDeleteTenant(t *testing.T) {
+	repo := NewFakeRepository(false)
+	tenant := db.Tenant{Name: "newsroom"}
+	err := repo.CreateTenant(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteTenant(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tenants := repo.(*fakeRepository).tenants; len(tenants) > 0 {
+		t.Errorf("DeleteTenant: unexpected non-empty tenant registry: %#v", tenants)
+	}
+	err = repo.DeleteTenant(&tenant)
+	if err != db.ErrTenantNotFound {
+		t.Errorf("DeleteTenant: wrong error. Want %#v. Got %#v", db.ErrTenantNotFound, err)
+	}
+}
+
+func TestListTenantsDBError(t *testing.T) {
+	repo := NewFakeRepository(true)
+	tenants, err := repo.ListTenants()
+	if len(tenants) > 0 {
+		t.Errorf("ListTenants: got unexpected non-empty list: %#v", tenants)
+	}
+	if err.Error() != dbErrorMsg {
+		t.Errorf("ListTenants: wrong error message. Want %q. Got %q", dbErrorMsg, err.Error())
+	}
+}
+
+func TestCreateArtifact(t *testing.T) {
+	repo := NewFakeRepository(false)
+	artifact := db.Artifact{JobID: "job-123", Name: "qc-report"}
+	err := repo.CreateArtifact(&artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if artifact.CreationTime.IsZero() {
+		t.Error("Did not set the CreationTime")
+	}
+	err = repo.CreateArtifact(&db.Artifact{JobID: "job-123", Name: "qc-report"})
+	if err != db.ErrArtifactAlreadyExists {
+		t.Errorf("CreateArtifact: wrong error returned. Want %#v. Got %#v", db.ErrArtifactAlreadyExists, err)
+	}
+	artifacts, err := repo.ListArtifacts("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []db.Artifact{artifact}
+	if !reflect.DeepEqual(artifacts, expected) {
+		t.Errorf("ListArtifacts: wrong list returned. Want %#v. Got %#v", expected, artifacts)
+	}
+}
+
+func TestCreateExperiment(t *testing.T) {
+	repo := NewFakeRepository(false)
+	experiment := db.Experiment{Name: "exp1", Variants: []db.ExperimentVariant{{Name: "control"}, {Name: "hevc"}}}
+	err := repo.CreateExperiment(&experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if experiment.CreationTime.IsZero() {
+		t.Error("Did not set the CreationTime")
+	}
+	err = repo.CreateExperiment(&db.Experiment{Name: "exp1"})
+	if err != db.ErrExperimentAlreadyExists {
+		t.Errorf("CreateExperiment: wrong error returned. Want %#v. Got %#v", db.ErrExperimentAlreadyExists, err)
+	}
+	got, err := repo.GetExperiment("exp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, experiment) {
+		t.Errorf("GetExperiment: wrong experiment returned. Want %#v. Got %#v", experiment, *got)
+	}
+}
+
+func TestDeleteExperiment(t *testing.T) {
+	repo := NewFakeRepository(false)
+	experiment := db.Experiment{Name: "exp1"}
+	err := repo.CreateExperiment(&experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.SaveExperimentSample(&db.ExperimentSample{Experiment: "exp1", JobID: "job-123", Variant: "control"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteExperiment(&experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.GetExperiment("exp1")
+	if err != db.ErrExperimentNotFound {
+		t.Errorf("GetExperiment: wrong error returned. Want %#v. Got %#v", db.ErrExperimentNotFound, err)
+	}
+	samples, err := repo.ListExperimentSamples("exp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 0 {
+		t.Errorf("DeleteExperiment: samples not deleted: %#v", samples)
+	}
+}
+
+func TestSaveExperimentSample(t *testing.T) {
+	repo := NewFakeRepository(false)
+	sample := db.ExperimentSample{Experiment: "exp1", JobID: "job-123", Variant: "control"}
+	err := repo.SaveExperimentSample(&sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample = db.ExperimentSample{
+		Experiment: "exp1",
+		JobID:      "job-123",
+		Variant:    "control",
+		Metrics:    db.ExperimentMetrics{QCScore: 93},
+	}
+	err = repo.SaveExperimentSample(&sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, err := repo.ListExperimentSamples("exp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []db.ExperimentSample{sample}
+	if !reflect.DeepEqual(samples, expected) {
+		t.Errorf("ListExperimentSamples: wrong list returned. Want %#v. Got %#v", expected, samples)
+	}
+}
+
+func TestCreateDeliveryTarget(t *testing.T) {
+	repo := NewFakeRepository(false)
+	target := db.DeliveryTarget{Name: "vod", Origins: map[string]db.DeliveryOrigin{"production": {Destination: "s3://vod-origin/"}}}
+	err := repo.CreateDeliveryTarget(&target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotTarget, err := repo.GetDeliveryTarget("vod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotTarget, target) {
+		t.Errorf("GetDeliveryTarget: wrong target returned. Want %#v. Got %#v", target, *gotTarget)
+	}
+	err = repo.CreateDeliveryTarget(&target)
+	if err != db.ErrDeliveryTargetAlreadyExists {
+		t.Errorf("CreateDeliveryTarget: wrong error returned. Want %#v. Got %#v", db.ErrDeliveryTargetAlreadyExists, err)
+	}
+}
+
+func TestDeleteDeliveryTarget(t *testing.T) {
+	repo := NewFakeRepository(false)
+	target := db.DeliveryTarget{Name: "vod"}
+	err := repo.CreateDeliveryTarget(&target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteDeliveryTarget(&target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets := repo.(*fakeRepository).targets; len(targets) > 0 {
+		t.Errorf("DeleteDeliveryTarget: unexpected non-empty registry: %#v", targets)
+	}
+	err = repo.DeleteDeliveryTarget(&target)
+	if err != db.ErrDeliveryTargetNotFound {
+		t.Errorf("DeleteDeliveryTarget: wrong error. Want %#v. Got %#v", db.ErrDeliveryTargetNotFound, err)
+	}
+}
+
+func TestSubmissionPauses(t *testing.T) {
+	repo := NewFakeRepository(false)
+	pause := db.SubmissionPause{Provider: "zencoder", Reason: "maintenance"}
+	err := repo.CreateSubmissionPause(&pause)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.CreateSubmissionPause(&db.SubmissionPause{Provider: "zencoder"})
+	if err != db.ErrSubmissionPauseAlreadyExists {
+		t.Errorf("CreateSubmissionPause: wrong error returned. Want %#v. Got %#v", db.ErrSubmissionPauseAlreadyExists, err)
+	}
+	pauses, err := repo.ListSubmissionPauses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []db.SubmissionPause{pause}
+	if !reflect.DeepEqual(pauses, expected) {
+		t.Errorf("ListSubmissionPauses: wrong list returned. Want %#v. Got %#v", expected, pauses)
+	}
+	err = repo.DeleteSubmissionPause(&pause)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteSubmissionPause(&pause)
+	if err != db.ErrSubmissionPauseNotFound {
+		t.Errorf("DeleteSubmissionPause: wrong error returned. Want %#v. Got %#v", db.ErrSubmissionPauseNotFound, err)
+	}
+}
@@ -0,0 +1,51 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (r *dynamoRepository) CreateArtifact(artifact *db.Artifact) error {
+	if artifact.JobID == "" || artifact.Name == "" {
+		return errors.New("job id and name are required")
+	}
+	if artifact.CreationTime.IsZero() {
+		artifact.CreationTime = time.Now().UTC()
+	}
+	data, err := json.Marshal(artifact)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{
+		TableName: r.table(artifactsTable),
+		Item: map[string]*dynamodb.AttributeValue{
+			"jobId": stringValue(artifact.JobID),
+			"name":  stringValue(artifact.Name),
+			"data":  stringValue(string(data)),
+		},
+		ConditionExpression:      aws.String(conditionNotExists),
+		ExpressionAttributeNames: nameAttribute,
+	})
+	return conditionError(err, db.ErrArtifactAlreadyExists)
+}
+
+func (r *dynamoRepository) ListArtifacts(jobID string) ([]db.Artifact, error) {
+	artifacts := []db.Artifact{}
+	err := r.queryDocuments(&dynamodb.QueryInput{
+		TableName:                 r.table(artifactsTable),
+		KeyConditionExpression:    aws.String("jobId = :jobId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":jobId": stringValue(jobID)},
+		ConsistentRead:            aws.Bool(true),
+	}, func(data []byte) error {
+		var artifact db.Artifact
+		err := json.Unmarshal(data, &artifact)
+		artifacts = append(artifacts, artifact)
+		return err
+	})
+	return artifacts, err
+}
@@ -0,0 +1,39 @@
+package dynamodb
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *dynamoRepository) CreateCampaign(campaign *db.Campaign) error {
+	return r.insertDocument(campaignsTable, campaign.Name, campaign, db.ErrCampaignAlreadyExists)
+}
+
+func (r *dynamoRepository) UpdateCampaign(campaign *db.Campaign) error {
+	return r.updateDocument(campaignsTable, campaign.Name, campaign, db.ErrCampaignNotFound)
+}
+
+func (r *dynamoRepository) DeleteCampaign(campaign *db.Campaign) error {
+	return r.deleteDocument(campaignsTable, campaign.Name, db.ErrCampaignNotFound)
+}
+
+func (r *dynamoRepository) GetCampaign(name string) (*db.Campaign, error) {
+	campaign := db.Campaign{Name: name}
+	err := r.getDocument(campaignsTable, name, &campaign, db.ErrCampaignNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (r *dynamoRepository) ListCampaigns() ([]db.Campaign, error) {
+	campaigns := []db.Campaign{}
+	err := r.listDocuments(campaignsTable, func(data []byte) error {
+		var campaign db.Campaign
+		err := json.Unmarshal(data, &campaign)
+		campaigns = append(campaigns, campaign)
+		return err
+	})
+	return campaigns, err
+}
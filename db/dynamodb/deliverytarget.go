@@ -0,0 +1,39 @@
+package dynamodb
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *dynamoRepository) CreateDeliveryTarget(target *db.DeliveryTarget) error {
+	return r.insertDocument(deliveryTargetsTable, target.Name, target, db.ErrDeliveryTargetAlreadyExists)
+}
+
+func (r *dynamoRepository) UpdateDeliveryTarget(target *db.DeliveryTarget) error {
+	return r.updateDocument(deliveryTargetsTable, target.Name, target, db.ErrDeliveryTargetNotFound)
+}
+
+func (r *dynamoRepository) DeleteDeliveryTarget(target *db.DeliveryTarget) error {
+	return r.deleteDocument(deliveryTargetsTable, target.Name, db.ErrDeliveryTargetNotFound)
+}
+
+func (r *dynamoRepository) GetDeliveryTarget(name string) (*db.DeliveryTarget, error) {
+	target := db.DeliveryTarget{Name: name}
+	err := r.getDocument(deliveryTargetsTable, name, &target, db.ErrDeliveryTargetNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+func (r *dynamoRepository) ListDeliveryTargets() ([]db.DeliveryTarget, error) {
+	targets := []db.DeliveryTarget{}
+	err := r.listDocuments(deliveryTargetsTable, func(data []byte) error {
+		var target db.DeliveryTarget
+		err := json.Unmarshal(data, &target)
+		targets = append(targets, target)
+		return err
+	})
+	return targets, err
+}
@@ -0,0 +1,156 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Types other than jobs are stored as JSON documents in tables with a name
+// attribute (the hash key) and a data attribute. Since "name" is a reserved
+// word in DynamoDB, expressions refer to it as #name.
+
+const (
+	conditionNotExists = "attribute_not_exists(#name)"
+	conditionExists    = "attribute_exists(#name)"
+)
+
+var nameAttribute = map[string]*string{"#name": aws.String("name")}
+
+// insertDocument stores a new document, returning the given error when
+// there's already a document with the same name.
+func (r *dynamoRepository) insertDocument(table, name string, v interface{}, errAlreadyExists error) error {
+	return r.putDocument(table, name, v, conditionNotExists, errAlreadyExists)
+}
+
+// updateDocument replaces the document with the given name, returning the
+// given error when there's no such document.
+func (r *dynamoRepository) updateDocument(table, name string, v interface{}, errNotFound error) error {
+	return r.putDocument(table, name, v, conditionExists, errNotFound)
+}
+
+func (r *dynamoRepository) putDocument(table, name string, v interface{}, condition string, errCondition error) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{
+		TableName:                r.table(table),
+		Item:                     map[string]*dynamodb.AttributeValue{"name": stringValue(name), "data": stringValue(string(data))},
+		ConditionExpression:      aws.String(condition),
+		ExpressionAttributeNames: nameAttribute,
+	})
+	return conditionError(err, errCondition)
+}
+
+func (r *dynamoRepository) deleteDocument(table, name string, errNotFound error) error {
+	_, err := r.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName:                r.table(table),
+		Key:                      map[string]*dynamodb.AttributeValue{"name": stringValue(name)},
+		ConditionExpression:      aws.String(conditionExists),
+		ExpressionAttributeNames: nameAttribute,
+	})
+	return conditionError(err, errNotFound)
+}
+
+func (r *dynamoRepository) getDocument(table, name string, v interface{}, errNotFound error) error {
+	output, err := r.client.GetItem(&dynamodb.GetItemInput{
+		TableName:      r.table(table),
+		Key:            map[string]*dynamodb.AttributeValue{"name": stringValue(name)},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	if len(output.Item) == 0 {
+		return errNotFound
+	}
+	return json.Unmarshal([]byte(itemString(output.Item, "data")), v)
+}
+
+// listDocuments calls add with each document in the table, sorted by name.
+func (r *dynamoRepository) listDocuments(table string, add func(data []byte) error) error {
+	var names []string
+	documents := make(map[string]string)
+	err := r.client.ScanPages(&dynamodb.ScanInput{TableName: r.table(table), ConsistentRead: aws.Bool(true)}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			name := itemString(item, "name")
+			names = append(names, name)
+			documents[name] = itemString(item, "data")
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err = add([]byte(documents[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryDocuments calls add with the data attribute of each item returned
+// by the given query, in the order of the range key.
+func (r *dynamoRepository) queryDocuments(input *dynamodb.QueryInput, add func(data []byte) error) error {
+	var err error
+	queryErr := r.client.QueryPages(input, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			if err = add([]byte(itemString(item, "data"))); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if queryErr != nil {
+		return queryErr
+	}
+	return err
+}
+
+// conditionError returns errCondition when the conditional write failed.
+func conditionError(err error, errCondition error) error {
+	if isErrorCode(err, dynamodb.ErrCodeConditionalCheckFailedException) {
+		return errCondition
+	}
+	return err
+}
+
+func isErrorCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
+
+// stringValue returns the attribute value of the given string. DynamoDB
+// doesn't store empty strings, so callers omit empty attributes with
+// setString.
+func stringValue(s string) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{S: aws.String(s)}
+}
+
+func numberValue(n int64) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(n, 10))}
+}
+
+// setString sets the given attribute of the item, unless the value is
+// empty.
+func setString(item map[string]*dynamodb.AttributeValue, name, value string) {
+	if value != "" {
+		item[name] = stringValue(value)
+	}
+}
+
+// itemString returns the string attribute of the item, or an empty string
+// when the attribute is missing.
+func itemString(item map[string]*dynamodb.AttributeValue, name string) string {
+	if value, ok := item[name]; ok {
+		return aws.StringValue(value.S)
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+// Package dynamodb implements db.Repository using DynamoDB for persistence,
+// for deployments without servers to manage.
+//
+// Jobs are stored along with the attributes used by the secondary indexes
+// for listing them and looking them up, and the other types are stored as
+// JSON documents keyed by their names. Uniqueness is enforced with
+// conditional writes.
+package dynamodb
+
+import (
+	"sync"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// initialized records the sets of tables that were already created,
+// keyed by endpoint, region and prefix. Repositories are created whenever
+// providers are, so the tables are only checked once.
+var initialized = struct {
+	sync.Mutex
+	tables map[string]bool
+}{tables: make(map[string]bool)}
+
+// NewRepository creates a new Repository that uses DynamoDB for
+// persistence, creating the missing tables when configured to.
+func NewRepository(cfg *config.Config) (db.Repository, error) {
+	dynamoCfg := cfg.DynamoDB
+	if dynamoCfg == nil {
+		dynamoCfg = &config.DynamoDB{}
+	}
+	awsConfig := aws.NewConfig().WithRegion(dynamoCfg.Region)
+	if dynamoCfg.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(dynamoCfg.AccessKeyID, dynamoCfg.SecretAccessKey, ""))
+	}
+	if dynamoCfg.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(dynamoCfg.Endpoint)
+	}
+	repo := &dynamoRepository{
+		config: cfg,
+		client: dynamodb.New(session.New(awsConfig)),
+		prefix: dynamoCfg.TablePrefix,
+	}
+	if dynamoCfg.CreateTables {
+		initialized.Lock()
+		defer initialized.Unlock()
+		key := dynamoCfg.Endpoint + "|" + dynamoCfg.Region + "|" + dynamoCfg.TablePrefix
+		if !initialized.tables[key] {
+			if err := repo.createTables(); err != nil {
+				return nil, err
+			}
+			initialized.tables[key] = true
+		}
+	}
+	return repo, nil
+}
+
+type dynamoRepository struct {
+	config *config.Config
+	client dynamodbiface.DynamoDBAPI
+	prefix string
+}
+
+// table returns the name of the given table, with the configured prefix.
+func (r *dynamoRepository) table(name string) *string {
+	return aws.String(r.prefix + name)
+}
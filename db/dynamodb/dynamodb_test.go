@@ -0,0 +1,70 @@
+package dynamodb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// newTestRepository creates the tables of a new repository in the
+// DynamoDB endpoint in DYNAMODB_TEST_ENDPOINT (like DynamoDB Local),
+// skipping the test when it's not set. The tables are deleted when the
+// test finishes. Reads from the secondary indexes are only consistent in
+// DynamoDB Local, so tests shouldn't run against DynamoDB itself.
+func newTestRepository(t *testing.T) (*dynamoRepository, func()) {
+	endpoint := os.Getenv("DYNAMODB_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_TEST_ENDPOINT is not set")
+	}
+	cfg := config.DynamoDB{
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		Region:          "us-east-1",
+		Endpoint:        endpoint,
+		TablePrefix:     fmt.Sprintf("test-%d-", time.Now().UnixNano()),
+		CreateTables:    true,
+	}
+	repo, err := NewRepository(&config.Config{DynamoDB: &cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := repo.(*dynamoRepository)
+	return r, func() {
+		for _, definition := range r.tableDefinitions() {
+			r.client.DeleteTable(&dynamodb.DeleteTableInput{TableName: definition.TableName})
+		}
+	}
+}
+
+func TestCreateTablesIsIdempotent(t *testing.T) {
+	r, cleanup := newTestRepository(t)
+	defer cleanup()
+	err := r.createTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, definition := range r.tableDefinitions() {
+		_, err = r.client.DescribeTable(&dynamodb.DescribeTableInput{TableName: definition.TableName})
+		if err != nil {
+			t.Errorf("%s: %s", aws.StringValue(definition.TableName), err)
+		}
+	}
+}
+
+func TestTableDefinitionsPrefix(t *testing.T) {
+	r := dynamoRepository{prefix: "video-api-"}
+	for _, definition := range r.tableDefinitions() {
+		name := aws.StringValue(definition.TableName)
+		if len(name) <= len(r.prefix) || name[:len(r.prefix)] != r.prefix {
+			t.Errorf("table %q doesn't have the prefix %q", name, r.prefix)
+		}
+		if aws.StringValue(definition.BillingMode) != dynamodb.BillingModePayPerRequest {
+			t.Errorf("%s: wrong billing mode %q", name, aws.StringValue(definition.BillingMode))
+		}
+	}
+}
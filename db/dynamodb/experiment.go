@@ -0,0 +1,102 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (r *dynamoRepository) CreateExperiment(experiment *db.Experiment) error {
+	if experiment.Name == "" {
+		return errors.New("experiment name is required")
+	}
+	if experiment.CreationTime.IsZero() {
+		experiment.CreationTime = time.Now().UTC()
+	}
+	return r.insertDocument(experimentsTable, experiment.Name, experiment, db.ErrExperimentAlreadyExists)
+}
+
+// DeleteExperiment deletes the experiment along with its samples. Samples
+// are deleted after the experiment, so a failure leaves samples that are
+// replaced if an experiment with the same name is created.
+func (r *dynamoRepository) DeleteExperiment(experiment *db.Experiment) error {
+	err := r.deleteDocument(experimentsTable, experiment.Name, db.ErrExperimentNotFound)
+	if err != nil {
+		return err
+	}
+	samples, err := r.ListExperimentSamples(experiment.Name)
+	if err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		_, err = r.client.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: r.table(experimentSamplesTable),
+			Key:       sampleKey(sample.Experiment, sample.JobID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *dynamoRepository) GetExperiment(name string) (*db.Experiment, error) {
+	experiment := db.Experiment{Name: name}
+	err := r.getDocument(experimentsTable, name, &experiment, db.ErrExperimentNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+func (r *dynamoRepository) ListExperiments() ([]db.Experiment, error) {
+	experiments := []db.Experiment{}
+	err := r.listDocuments(experimentsTable, func(data []byte) error {
+		var experiment db.Experiment
+		err := json.Unmarshal(data, &experiment)
+		experiments = append(experiments, experiment)
+		return err
+	})
+	return experiments, err
+}
+
+func (r *dynamoRepository) SaveExperimentSample(sample *db.ExperimentSample) error {
+	if sample.Experiment == "" || sample.JobID == "" {
+		return errors.New("experiment and job id are required")
+	}
+	if sample.CreationTime.IsZero() {
+		sample.CreationTime = time.Now().UTC()
+	}
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	item := sampleKey(sample.Experiment, sample.JobID)
+	item["data"] = stringValue(string(data))
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{TableName: r.table(experimentSamplesTable), Item: item})
+	return err
+}
+
+func (r *dynamoRepository) ListExperimentSamples(experiment string) ([]db.ExperimentSample, error) {
+	samples := []db.ExperimentSample{}
+	err := r.queryDocuments(&dynamodb.QueryInput{
+		TableName:                 r.table(experimentSamplesTable),
+		KeyConditionExpression:    aws.String("experiment = :experiment"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":experiment": stringValue(experiment)},
+		ConsistentRead:            aws.Bool(true),
+	}, func(data []byte) error {
+		var sample db.ExperimentSample
+		err := json.Unmarshal(data, &sample)
+		samples = append(samples, sample)
+		return err
+	})
+	return samples, err
+}
+
+func sampleKey(experiment, jobID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"experiment": stringValue(experiment), "jobId": stringValue(jobID)}
+}
@@ -0,0 +1,297 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// jobsListing is the hash key of all jobs in the creation time index, which
+// lists jobs sorted by creation time.
+const jobsListing = "jobs"
+
+var idAttribute = map[string]*string{"#id": aws.String("id")}
+
+func (r *dynamoRepository) CreateJob(job *db.Job) error {
+	if job.ID == "" {
+		return errors.New("job id is required")
+	}
+	job.CreationTime = time.Now().UTC()
+	err := r.putJob(job, "attribute_not_exists(#id)")
+	if err == errJobCondition {
+		return fmt.Errorf("job %q already exists", job.ID)
+	}
+	return err
+}
+
+func (r *dynamoRepository) UpdateJob(job *db.Job) error {
+	err := r.putJob(job, "attribute_exists(#id)")
+	if err == errJobCondition {
+		return db.ErrJobNotFound
+	}
+	return err
+}
+
+func (r *dynamoRepository) UpdateJobIfStatus(job *db.Job, status string) error {
+	item, err := jobItem(job)
+	if err != nil {
+		return err
+	}
+	// empty statuses aren't stored
+	input := dynamodb.PutItemInput{
+		TableName:                r.table(jobsTable),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_exists(#id) AND attribute_not_exists(#status)"),
+		ExpressionAttributeNames: map[string]*string{"#id": aws.String("id"), "#status": aws.String("status")},
+	}
+	if status != "" {
+		input.ConditionExpression = aws.String("#status = :status")
+		input.ExpressionAttributeNames = map[string]*string{"#status": aws.String("status")}
+		input.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{":status": stringValue(status)}
+	}
+	_, err = r.client.PutItem(&input)
+	err = conditionError(err, db.ErrJobStatusChanged)
+	if err == db.ErrJobStatusChanged {
+		if _, getErr := r.GetJob(job.ID); getErr == db.ErrJobNotFound {
+			return getErr
+		}
+	}
+	return err
+}
+
+var errJobCondition = errors.New("conditional write of job failed")
+
+func (r *dynamoRepository) putJob(job *db.Job, condition string) error {
+	item, err := jobItem(job)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{
+		TableName:                r.table(jobsTable),
+		Item:                     item,
+		ConditionExpression:      aws.String(condition),
+		ExpressionAttributeNames: idAttribute,
+	})
+	return conditionError(err, errJobCondition)
+}
+
+func (r *dynamoRepository) DeleteJob(job *db.Job) error {
+	_, err := r.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName:                r.table(jobsTable),
+		Key:                      map[string]*dynamodb.AttributeValue{"id": stringValue(job.ID)},
+		ConditionExpression:      aws.String("attribute_exists(#id)"),
+		ExpressionAttributeNames: idAttribute,
+	})
+	return conditionError(err, db.ErrJobNotFound)
+}
+
+func (r *dynamoRepository) GetJob(id string) (*db.Job, error) {
+	output, err := r.client.GetItem(&dynamodb.GetItemInput{
+		TableName:      r.table(jobsTable),
+		Key:            map[string]*dynamodb.AttributeValue{"id": stringValue(id)},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.Item) == 0 {
+		return nil, db.ErrJobNotFound
+	}
+	return itemJob(output.Item)
+}
+
+// ListJobs lists the jobs matching the filter, oldest first, using the
+// creation time index. Status and provider are filtered by DynamoDB, so
+// the offset and the limit are applied while paginating the results.
+func (r *dynamoRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
+	until := int64(math.MaxInt64)
+	if !filter.Until.IsZero() {
+		until = filter.Until.UnixNano() - 1
+	}
+	since := int64(0)
+	if !filter.Since.IsZero() {
+		since = filter.Since.UnixNano()
+	}
+	if until < since {
+		return []db.Job{}, nil
+	}
+	input := dynamodb.QueryInput{
+		TableName:                r.table(jobsTable),
+		IndexName:                aws.String(creationTimeIndex),
+		KeyConditionExpression:   aws.String("listing = :listing AND creationTime BETWEEN :since AND :until"),
+		ExpressionAttributeNames: map[string]*string{},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":listing": stringValue(jobsListing),
+			":since":   numberValue(since),
+			":until":   numberValue(until),
+		},
+	}
+	var conditions []string
+	if filter.Status != "" {
+		conditions = append(conditions, "#status = :status")
+		input.ExpressionAttributeNames["#status"] = aws.String("status")
+		input.ExpressionAttributeValues[":status"] = stringValue(filter.Status)
+	}
+	if filter.ProviderName != "" {
+		conditions = append(conditions, "providerName = :providerName")
+		input.ExpressionAttributeValues[":providerName"] = stringValue(filter.ProviderName)
+	}
+	if len(conditions) > 0 {
+		input.FilterExpression = aws.String(strings.Join(conditions, " AND "))
+	}
+	if len(input.ExpressionAttributeNames) == 0 {
+		input.ExpressionAttributeNames = nil
+	}
+	skip := filter.Offset
+	return r.queryJobs(&input, func(db.Job) (include, more bool) {
+		if skip > 0 {
+			skip--
+			return false, true
+		}
+		return true, true
+	}, filter.Limit)
+}
+
+func (r *dynamoRepository) ListJobsByExternalID(tenant, externalID string) ([]db.Job, error) {
+	return r.queryJobs(&dynamodb.QueryInput{
+		TableName:                 r.table(jobsTable),
+		IndexName:                 aws.String(externalIDIndex),
+		KeyConditionExpression:    aws.String("externalKey = :externalKey"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":externalKey": stringValue(externalKey(tenant, externalID))},
+	}, nil, 0)
+}
+
+func (r *dynamoRepository) GetJobByProviderJobID(providerName, providerJobID string) (*db.Job, error) {
+	jobs, err := r.queryJobs(&dynamodb.QueryInput{
+		TableName:                 r.table(jobsTable),
+		IndexName:                 aws.String(providerJobIDIndex),
+		KeyConditionExpression:    aws.String("providerKey = :providerKey"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":providerKey": stringValue(providerKey(providerName, providerJobID))},
+	}, nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, db.ErrJobNotFound
+	}
+	return &jobs[0], nil
+}
+
+func (r *dynamoRepository) NextJobSequence(tenant string) (uint64, error) {
+	output, err := r.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 r.table(jobSequencesTable),
+		Key:                       map[string]*dynamodb.AttributeValue{"tenant": stringValue(tenant)},
+		UpdateExpression:          aws.String("ADD #value :one"),
+		ExpressionAttributeNames:  map[string]*string{"#value": aws.String("value")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":one": numberValue(1)},
+		ReturnValues:              aws.String(dynamodb.ReturnValueUpdatedNew),
+	})
+	if err != nil {
+		return 0, err
+	}
+	value, ok := output.Attributes["value"]
+	if !ok {
+		return 0, errors.New("missing value of the job sequence")
+	}
+	return strconv.ParseUint(aws.StringValue(value.N), 10, 64)
+}
+
+// queryJobs returns the jobs returned by the query, in the order of the
+// range key of the index. When accept is given, it's called with each job
+// to decide whether the job is included in the result and whether the
+// query should go on. A non-zero limit caps the number of jobs returned.
+func (r *dynamoRepository) queryJobs(input *dynamodb.QueryInput, accept func(db.Job) (include, more bool), limit uint) ([]db.Job, error) {
+	jobs := []db.Job{}
+	var err error
+	queryErr := r.client.QueryPages(input, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var job *db.Job
+			job, err = itemJob(item)
+			if err != nil {
+				return false
+			}
+			include, more := true, true
+			if accept != nil {
+				include, more = accept(*job)
+			}
+			if include {
+				jobs = append(jobs, *job)
+			}
+			if !more || (limit > 0 && uint(len(jobs)) >= limit) {
+				return false
+			}
+		}
+		return true
+	})
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	return jobs, err
+}
+
+// jobItem returns the item of the job. Fields of the job that aren't
+// encoded in JSON (like the DRM key) are stored in their own attributes,
+// along with the keys of the secondary indexes. Keys of jobs without an
+// external id or a provider job id are omitted, keeping them out of the
+// indexes.
+func jobItem(job *db.Job) (map[string]*dynamodb.AttributeValue, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	item := map[string]*dynamodb.AttributeValue{
+		"id":           stringValue(job.ID),
+		"data":         stringValue(string(data)),
+		"listing":      stringValue(jobsListing),
+		"creationTime": numberValue(job.CreationTime.UnixNano()),
+	}
+	setString(item, "drmKey", job.DRMKey)
+	setString(item, "statusSnapshot", job.StatusSnapshot)
+	if !job.StatusSnapshotTime.IsZero() {
+		item["statusSnapshotTime"] = stringValue(job.StatusSnapshotTime.Format(time.RFC3339Nano))
+	}
+	setString(item, "status", job.Status)
+	setString(item, "providerName", job.ProviderName)
+	if job.ExternalID != "" {
+		item["externalKey"] = stringValue(externalKey(job.Tenant, job.ExternalID))
+	}
+	if job.ProviderJobID != "" {
+		item["providerKey"] = stringValue(providerKey(job.ProviderName, job.ProviderJobID))
+	}
+	return item, nil
+}
+
+func itemJob(item map[string]*dynamodb.AttributeValue) (*db.Job, error) {
+	var job db.Job
+	err := json.Unmarshal([]byte(itemString(item, "data")), &job)
+	if err != nil {
+		return nil, err
+	}
+	job.DRMKey = itemString(item, "drmKey")
+	job.StatusSnapshot = itemString(item, "statusSnapshot")
+	if snapshotTime := itemString(item, "statusSnapshotTime"); snapshotTime != "" {
+		job.StatusSnapshotTime, err = time.Parse(time.RFC3339Nano, snapshotTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &job, nil
+}
+
+// externalKey is the key of jobs in the external id index. Tenant names
+// can't contain slashes, since they're part of the paths of the API.
+func externalKey(tenant, externalID string) string {
+	return tenant + "/" + externalID
+}
+
+func providerKey(providerName, providerJobID string) string {
+	return providerName + "/" + providerJobID
+}
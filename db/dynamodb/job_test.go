@@ -0,0 +1,165 @@
+package dynamodb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestCreateAndGetJob(t *testing.T) {
+	r, cleanup := newTestRepository(t)
+	defer cleanup()
+	job := db.Job{
+		ID:                 "myjob",
+		ProviderName:       "zencoder",
+		ProviderJobID:      "123",
+		SourceMedia:        "s3://newsroom-bucket/master.mov",
+		Labels:             []string{"partner"},
+		DRMKey:             "secret-key",
+		StatusSnapshot:     `{"status":"started"}`,
+		StatusSnapshotTime: time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	err := r.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJob, err := r.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotJob.CreationTime.Equal(job.CreationTime) {
+		t.Errorf("wrong creation time. Want %s. Got %s", job.CreationTime, gotJob.CreationTime)
+	}
+	gotJob.CreationTime = job.CreationTime
+	if !reflect.DeepEqual(*gotJob, job) {
+		t.Errorf("wrong job. Want %#v. Got %#v", job, *gotJob)
+	}
+	gotJob, err = r.GetJobByProviderJobID("zencoder", "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotJob.ID != job.ID {
+		t.Errorf("wrong job found by provider job id. Want %q. Got %q", job.ID, gotJob.ID)
+	}
+	_, err = r.GetJob("unknown")
+	if err != db.ErrJobNotFound {
+		t.Errorf("wrong error for unknown job. Want ErrJobNotFound. Got %#v", err)
+	}
+}
+
+func TestUpdateAndDeleteJob(t *testing.T) {
+	r, cleanup := newTestRepository(t)
+	defer cleanup()
+	job := db.Job{ID: "myjob", ProviderName: "zencoder", ProviderJobID: "123"}
+	err := r.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.CreateJob(&job); err == nil || err.Error() != `job "myjob" already exists` {
+		t.Errorf("wrong error creating duplicate job: %v", err)
+	}
+	job.ProviderName = "mediaconvert"
+	job.ProviderJobID = "456"
+	job.Status = "started"
+	err = r.UpdateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.GetJobByProviderJobID("zencoder", "123"); err != db.ErrJobNotFound {
+		t.Errorf("job found by its previous provider job id: %v", err)
+	}
+	gotJob, err := r.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotJob.ProviderJobID != "456" || gotJob.Status != "started" {
+		t.Errorf("job wasn't updated: %#v", *gotJob)
+	}
+	err = r.DeleteJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.DeleteJob(&job); err != db.ErrJobNotFound {
+		t.Errorf("wrong error deleting unknown job. Want ErrJobNotFound. Got %#v", err)
+	}
+	if err = r.UpdateJob(&job); err != db.ErrJobNotFound {
+		t.Errorf("wrong error updating unknown job. Want ErrJobNotFound. Got %#v", err)
+	}
+}
+
+func TestListJobs(t *testing.T) {
+	r, cleanup := newTestRepository(t)
+	defer cleanup()
+	now := time.Now().UTC()
+	jobs := []db.Job{
+		{ID: "job-1", Tenant: "nyt", ExternalID: "asset-1", ProviderName: "zencoder", Status: "finished"},
+		{ID: "job-2", Tenant: "nyt", ExternalID: "asset-2", ProviderName: "mediaconvert", Status: "finished"},
+		{ID: "job-3", Tenant: "nyt", ExternalID: "asset-1", ProviderName: "zencoder", Status: "failed"},
+		{ID: "job-4", Tenant: "nyt", ExternalID: "asset-3", ProviderName: "zencoder", Status: "finished"},
+	}
+	for i := range jobs {
+		err := r.CreateJob(&jobs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs[i].CreationTime = now.Add(time.Duration(i-4) * time.Minute)
+		if err = r.UpdateJob(&jobs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var tests = []struct {
+		givenTestCase string
+		givenFilter   db.JobFilter
+		wantJobs      []string
+	}{
+		{"all jobs", db.JobFilter{}, []string{"job-1", "job-2", "job-3", "job-4"}},
+		{"since", db.JobFilter{Since: now.Add(-150 * time.Second)}, []string{"job-3", "job-4"}},
+		{"until", db.JobFilter{Until: now.Add(-3 * time.Minute)}, []string{"job-1"}},
+		{"status", db.JobFilter{Status: "finished"}, []string{"job-1", "job-2", "job-4"}},
+		{"status and provider", db.JobFilter{Status: "finished", ProviderName: "zencoder"}, []string{"job-1", "job-4"}},
+		{"offset and limit", db.JobFilter{Offset: 1, Limit: 2}, []string{"job-2", "job-3"}},
+	}
+	for _, test := range tests {
+		gotJobs, err := r.ListJobs(test.givenFilter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotIDs := []string{}
+		for _, job := range gotJobs {
+			gotIDs = append(gotIDs, job.ID)
+		}
+		if !reflect.DeepEqual(gotIDs, test.wantJobs) {
+			t.Errorf("%s: wrong jobs returned. Want %#v. Got %#v", test.givenTestCase, test.wantJobs, gotIDs)
+		}
+	}
+	gotJobs, err := r.ListJobsByExternalID("nyt", "asset-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotJobs) != 2 || gotJobs[0].ID != "job-1" || gotJobs[1].ID != "job-3" {
+		t.Errorf("wrong jobs listed by external id: %#v", gotJobs)
+	}
+}
+
+func TestNextJobSequence(t *testing.T) {
+	r, cleanup := newTestRepository(t)
+	defer cleanup()
+	for _, want := range []uint64{1, 2, 3} {
+		n, err := r.NextJobSequence("nyt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("wrong sequence number. Want %d. Got %d", want, n)
+		}
+	}
+	n, err := r.NextJobSequence("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("wrong sequence number of another tenant. Want 1. Got %d", n)
+	}
+}
@@ -0,0 +1,39 @@
+package dynamodb
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *dynamoRepository) CreateLadder(ladder *db.Ladder) error {
+	return r.insertDocument(laddersTable, ladder.Name, ladder, db.ErrLadderAlreadyExists)
+}
+
+func (r *dynamoRepository) UpdateLadder(ladder *db.Ladder) error {
+	return r.updateDocument(laddersTable, ladder.Name, ladder, db.ErrLadderNotFound)
+}
+
+func (r *dynamoRepository) DeleteLadder(ladder *db.Ladder) error {
+	return r.deleteDocument(laddersTable, ladder.Name, db.ErrLadderNotFound)
+}
+
+func (r *dynamoRepository) GetLadder(name string) (*db.Ladder, error) {
+	ladder := db.Ladder{Name: name}
+	err := r.getDocument(laddersTable, name, &ladder, db.ErrLadderNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &ladder, nil
+}
+
+func (r *dynamoRepository) ListLadders() ([]db.Ladder, error) {
+	ladders := []db.Ladder{}
+	err := r.listDocuments(laddersTable, func(data []byte) error {
+		var ladder db.Ladder
+		err := json.Unmarshal(data, &ladder)
+		ladders = append(ladders, ladder)
+		return err
+	})
+	return ladders, err
+}
@@ -0,0 +1,31 @@
+package dynamodb
+
+import (
+	"errors"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *dynamoRepository) CreateLocalPreset(localPreset *db.LocalPreset) error {
+	if localPreset.Name == "" {
+		return errors.New("preset name missing")
+	}
+	return r.insertDocument(localPresetsTable, localPreset.Name, localPreset, db.ErrLocalPresetAlreadyExists)
+}
+
+func (r *dynamoRepository) UpdateLocalPreset(localPreset *db.LocalPreset) error {
+	return r.updateDocument(localPresetsTable, localPreset.Name, localPreset, db.ErrLocalPresetNotFound)
+}
+
+func (r *dynamoRepository) DeleteLocalPreset(localPreset *db.LocalPreset) error {
+	return r.deleteDocument(localPresetsTable, localPreset.Name, db.ErrLocalPresetNotFound)
+}
+
+func (r *dynamoRepository) GetLocalPreset(name string) (*db.LocalPreset, error) {
+	localPreset := db.LocalPreset{Name: name}
+	err := r.getDocument(localPresetsTable, name, &localPreset, db.ErrLocalPresetNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &localPreset, nil
+}
@@ -0,0 +1,34 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const maintenanceSetting = "maintenance"
+
+func (r *dynamoRepository) SetMaintenanceMode(mode *db.MaintenanceMode) error {
+	mode.UpdateTime = time.Now().UTC()
+	data, err := json.Marshal(mode)
+	if err != nil {
+		return err
+	}
+	item := map[string]*dynamodb.AttributeValue{
+		"name": stringValue(maintenanceSetting),
+		"data": stringValue(string(data)),
+	}
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{TableName: r.table(settingsTable), Item: item})
+	return err
+}
+
+func (r *dynamoRepository) GetMaintenanceMode() (*db.MaintenanceMode, error) {
+	var mode db.MaintenanceMode
+	err := r.getDocument(settingsTable, maintenanceSetting, &mode, db.ErrMaintenanceModeNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &mode, nil
+}
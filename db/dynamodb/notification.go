@@ -0,0 +1,32 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *dynamoRepository) CreateNotification(notification *db.Notification) error {
+	notification.CreationTime = time.Now().UTC()
+	return r.insertDocument(notificationsTable, notification.ID, notification, db.ErrNotificationAlreadyExists)
+}
+
+func (r *dynamoRepository) UpdateNotification(notification *db.Notification) error {
+	return r.updateDocument(notificationsTable, notification.ID, notification, db.ErrNotificationNotFound)
+}
+
+func (r *dynamoRepository) DeleteNotification(notification *db.Notification) error {
+	return r.deleteDocument(notificationsTable, notification.ID, db.ErrNotificationNotFound)
+}
+
+func (r *dynamoRepository) ListNotifications() ([]db.Notification, error) {
+	notifications := []db.Notification{}
+	err := r.listDocuments(notificationsTable, func(data []byte) error {
+		var notification db.Notification
+		err := json.Unmarshal(data, &notification)
+		notifications = append(notifications, notification)
+		return err
+	})
+	return notifications, err
+}
@@ -0,0 +1,206 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// maxVersionAttempts is the number of times numbering a presetmap version
+// is attempted when concurrent updates take the same number.
+const maxVersionAttempts = 5
+
+// conditionUnchanged only writes presetmaps that didn't change since they
+// were stored as a version.
+const conditionUnchanged = "#data = :data"
+
+var (
+	errVersionTaken = errors.New("presetmap version already exists")
+	dataAttribute   = map[string]*string{"#data": aws.String("data")}
+)
+
+// CreatePresetMap stores the presetmap, numbering it after the last version
+// kept for presetmaps with the same name.
+func (r *dynamoRepository) CreatePresetMap(presetMap *db.PresetMap) error {
+	last, err := r.lastPresetMapVersion(presetMap.Name)
+	if err != nil {
+		return err
+	}
+	presetMap.Version = last + 1
+	return r.insertDocument(presetMapsTable, presetMap.Name, presetMap, db.ErrPresetMapAlreadyExists)
+}
+
+// UpdatePresetMap replaces the presetmap, storing the presetmap it replaces
+// as its last version.
+func (r *dynamoRepository) UpdatePresetMap(presetMap *db.PresetMap) error {
+	return r.archivePresetMap(presetMap.Name, func(version int, current string) error {
+		presetMap.Version = version + 1
+		data, err := json.Marshal(presetMap)
+		if err != nil {
+			return err
+		}
+		_, err = r.client.PutItem(&dynamodb.PutItemInput{
+			TableName:                 r.table(presetMapsTable),
+			Item:                      map[string]*dynamodb.AttributeValue{"name": stringValue(presetMap.Name), "data": stringValue(string(data))},
+			ConditionExpression:       aws.String(conditionUnchanged),
+			ExpressionAttributeNames:  dataAttribute,
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":data": stringValue(current)},
+		})
+		return conditionError(err, errVersionTaken)
+	})
+}
+
+// DeletePresetMap deletes the presetmap, storing it as its last version.
+func (r *dynamoRepository) DeletePresetMap(presetMap *db.PresetMap) error {
+	return r.archivePresetMap(presetMap.Name, func(version int, current string) error {
+		_, err := r.client.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName:                 r.table(presetMapsTable),
+			Key:                       map[string]*dynamodb.AttributeValue{"name": stringValue(presetMap.Name)},
+			ConditionExpression:       aws.String(conditionUnchanged),
+			ExpressionAttributeNames:  dataAttribute,
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":data": stringValue(current)},
+		})
+		return conditionError(err, errVersionTaken)
+	})
+}
+
+func (r *dynamoRepository) GetPresetMap(name string) (*db.PresetMap, error) {
+	presetMap := db.PresetMap{Name: name, ProviderMapping: make(map[string]string)}
+	err := r.getDocument(presetMapsTable, name, &presetMap, db.ErrPresetMapNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &presetMap, nil
+}
+
+func (r *dynamoRepository) ListPresetMaps(filter db.PresetMapFilter) ([]db.PresetMap, error) {
+	presetMaps := []db.PresetMap{}
+	err := r.listDocuments(presetMapsTable, func(data []byte) error {
+		presetMap := db.PresetMap{ProviderMapping: make(map[string]string)}
+		err := json.Unmarshal(data, &presetMap)
+		presetMaps = append(presetMaps, presetMap)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filter.Apply(presetMaps), nil
+}
+
+// archivePresetMap stores the current presetmap with the given name as its
+// last version, and then calls replace with the number of that version and
+// the stored presetmap. DynamoDB writes aren't transactional, so replace must
+// only write if the stored presetmap didn't change, returning errVersionTaken
+// otherwise, and the version is deleted again when replace fails. Concurrent
+// updates are retried with the following version.
+func (r *dynamoRepository) archivePresetMap(name string, replace func(version int, current string) error) error {
+	err := errVersionTaken
+	for i := 0; i < maxVersionAttempts && err == errVersionTaken; i++ {
+		var current string
+		var version int
+		current, err = r.presetMapData(name)
+		if err != nil {
+			return err
+		}
+		version, err = r.addPresetMapVersion(name, current)
+		if err != nil {
+			continue
+		}
+		if err = replace(version, current); err != nil {
+			_, deleteErr := r.client.DeleteItem(&dynamodb.DeleteItemInput{
+				TableName: r.table(presetMapVersionsTable),
+				Key:       versionKey(name, version),
+			})
+			if deleteErr != nil {
+				return deleteErr
+			}
+		}
+	}
+	return err
+}
+
+func (r *dynamoRepository) presetMapData(name string) (string, error) {
+	output, err := r.client.GetItem(&dynamodb.GetItemInput{
+		TableName:      r.table(presetMapsTable),
+		Key:            map[string]*dynamodb.AttributeValue{"name": stringValue(name)},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(output.Item) == 0 {
+		return "", db.ErrPresetMapNotFound
+	}
+	return itemString(output.Item, "data"), nil
+}
+
+// addPresetMapVersion stores the given presetmap as the version following
+// the last version of the presetmap, returning errVersionTaken when a
+// concurrent update took the number.
+func (r *dynamoRepository) addPresetMapVersion(name, presetMapData string) (int, error) {
+	version := db.PresetMapVersion{ReplacedAt: time.Now().UTC()}
+	err := json.Unmarshal([]byte(presetMapData), &version.PresetMap)
+	if err != nil {
+		return 0, err
+	}
+	version.Version, err = r.lastPresetMapVersion(name)
+	if err != nil {
+		return 0, err
+	}
+	version.Version++
+	data, err := json.Marshal(version)
+	if err != nil {
+		return 0, err
+	}
+	item := versionKey(name, version.Version)
+	item["data"] = stringValue(string(data))
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{
+		TableName:                r.table(presetMapVersionsTable),
+		Item:                     item,
+		ConditionExpression:      aws.String(conditionNotExists),
+		ExpressionAttributeNames: nameAttribute,
+	})
+	return version.Version, conditionError(err, errVersionTaken)
+}
+
+func (r *dynamoRepository) lastPresetMapVersion(name string) (int, error) {
+	output, err := r.client.Query(&dynamodb.QueryInput{
+		TableName:                 r.table(presetMapVersionsTable),
+		KeyConditionExpression:    aws.String("#name = :name"),
+		ExpressionAttributeNames:  nameAttribute,
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":name": stringValue(name)},
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int64(1),
+		ConsistentRead:            aws.Bool(true),
+	})
+	if err != nil || len(output.Items) == 0 {
+		return 0, err
+	}
+	return strconv.Atoi(aws.StringValue(output.Items[0]["version"].N))
+}
+
+func (r *dynamoRepository) ListPresetMapVersions(name string) ([]db.PresetMapVersion, error) {
+	versions := []db.PresetMapVersion{}
+	err := r.queryDocuments(&dynamodb.QueryInput{
+		TableName:                 r.table(presetMapVersionsTable),
+		KeyConditionExpression:    aws.String("#name = :name"),
+		ExpressionAttributeNames:  nameAttribute,
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":name": stringValue(name)},
+		ConsistentRead:            aws.Bool(true),
+	}, func(data []byte) error {
+		var version db.PresetMapVersion
+		err := json.Unmarshal(data, &version)
+		versions = append(versions, version)
+		return err
+	})
+	return versions, err
+}
+
+func versionKey(name string, version int) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"name": stringValue(name), "version": numberValue(int64(version))}
+}
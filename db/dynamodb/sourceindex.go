@@ -0,0 +1,43 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (r *dynamoRepository) SaveSourceEncode(encode *db.SourceEncode) error {
+	if encode.Checksum == "" || encode.JobID == "" {
+		return errors.New("checksum and job id are required")
+	}
+	data, err := json.Marshal(encode)
+	if err != nil {
+		return err
+	}
+	item := map[string]*dynamodb.AttributeValue{
+		"checksum": stringValue(encode.Checksum),
+		"jobId":    stringValue(encode.JobID),
+		"data":     stringValue(string(data)),
+	}
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{TableName: r.table(sourceEncodesTable), Item: item})
+	return err
+}
+
+func (r *dynamoRepository) ListSourceEncodes(checksum string) ([]db.SourceEncode, error) {
+	encodes := []db.SourceEncode{}
+	err := r.queryDocuments(&dynamodb.QueryInput{
+		TableName:                 r.table(sourceEncodesTable),
+		KeyConditionExpression:    aws.String("checksum = :checksum"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":checksum": stringValue(checksum)},
+		ConsistentRead:            aws.Bool(true),
+	}, func(data []byte) error {
+		var encode db.SourceEncode
+		err := json.Unmarshal(data, &encode)
+		encodes = append(encodes, encode)
+		return err
+	})
+	return encodes, err
+}
@@ -0,0 +1,163 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// names of the tables, without the configured prefix
+const (
+	jobsTable              = "jobs"
+	jobSequencesTable      = "jobsequences"
+	presetMapsTable        = "presetmaps"
+	localPresetsTable      = "localpresets"
+	tenantsTable           = "tenants"
+	deliveryTargetsTable   = "deliverytargets"
+	laddersTable           = "ladders"
+	campaignsTable         = "campaigns"
+	watchFoldersTable      = "watchfolders"
+	submissionPausesTable  = "submissionpauses"
+	experimentsTable       = "experiments"
+	experimentSamplesTable = "experimentsamples"
+	artifactsTable         = "artifacts"
+	presetMapVersionsTable = "presetmapversions"
+	sourceEncodesTable     = "sourceencodes"
+	settingsTable          = "settings"
+	notificationsTable     = "notifications"
+)
+
+// names of the global secondary indexes of the jobs table
+const (
+	creationTimeIndex  = "creation-time"
+	externalIDIndex    = "external-id"
+	providerJobIDIndex = "provider-job-id"
+)
+
+// documentTables are the tables of the types stored as JSON documents,
+// keyed by name.
+var documentTables = []string{
+	presetMapsTable,
+	localPresetsTable,
+	tenantsTable,
+	deliveryTargetsTable,
+	laddersTable,
+	watchFoldersTable,
+	submissionPausesTable,
+	experimentsTable,
+	campaignsTable,
+	settingsTable,
+	notificationsTable,
+}
+
+// tableDefinitions returns the definitions of all tables used by the
+// repository. Tables are billed per request, and the secondary indexes of
+// jobs project all attributes.
+func (r *dynamoRepository) tableDefinitions() []*dynamodb.CreateTableInput {
+	allAttributes := &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)}
+	jobIndex := func(name, hashKey, rangeKey string) *dynamodb.GlobalSecondaryIndex {
+		return &dynamodb.GlobalSecondaryIndex{
+			IndexName:  aws.String(name),
+			KeySchema:  keySchema(hashKey, rangeKey),
+			Projection: allAttributes,
+		}
+	}
+	definitions := []*dynamodb.CreateTableInput{
+		{
+			TableName: r.table(jobsTable),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				attribute("id", dynamodb.ScalarAttributeTypeS),
+				attribute("listing", dynamodb.ScalarAttributeTypeS),
+				attribute("creationTime", dynamodb.ScalarAttributeTypeN),
+				attribute("externalKey", dynamodb.ScalarAttributeTypeS),
+				attribute("providerKey", dynamodb.ScalarAttributeTypeS),
+			},
+			KeySchema: keySchema("id", ""),
+			GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+				jobIndex(creationTimeIndex, "listing", "creationTime"),
+				jobIndex(externalIDIndex, "externalKey", "creationTime"),
+				jobIndex(providerJobIDIndex, "providerKey", ""),
+			},
+		},
+		{
+			TableName:            r.table(jobSequencesTable),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{attribute("tenant", dynamodb.ScalarAttributeTypeS)},
+			KeySchema:            keySchema("tenant", ""),
+		},
+		{
+			TableName: r.table(experimentSamplesTable),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				attribute("experiment", dynamodb.ScalarAttributeTypeS),
+				attribute("jobId", dynamodb.ScalarAttributeTypeS),
+			},
+			KeySchema: keySchema("experiment", "jobId"),
+		},
+		{
+			TableName: r.table(artifactsTable),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				attribute("jobId", dynamodb.ScalarAttributeTypeS),
+				attribute("name", dynamodb.ScalarAttributeTypeS),
+			},
+			KeySchema: keySchema("jobId", "name"),
+		},
+		{
+			TableName: r.table(presetMapVersionsTable),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				attribute("name", dynamodb.ScalarAttributeTypeS),
+				attribute("version", dynamodb.ScalarAttributeTypeN),
+			},
+			KeySchema: keySchema("name", "version"),
+		},
+		{
+			TableName: r.table(sourceEncodesTable),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				attribute("checksum", dynamodb.ScalarAttributeTypeS),
+				attribute("jobId", dynamodb.ScalarAttributeTypeS),
+			},
+			KeySchema: keySchema("checksum", "jobId"),
+		},
+	}
+	for _, table := range documentTables {
+		definitions = append(definitions, &dynamodb.CreateTableInput{
+			TableName:            r.table(table),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{attribute("name", dynamodb.ScalarAttributeTypeS)},
+			KeySchema:            keySchema("name", ""),
+		})
+	}
+	for _, definition := range definitions {
+		definition.BillingMode = aws.String(dynamodb.BillingModePayPerRequest)
+	}
+	return definitions
+}
+
+// createTables creates the tables that don't exist yet, and waits for them
+// to become active.
+func (r *dynamoRepository) createTables() error {
+	for _, definition := range r.tableDefinitions() {
+		_, err := r.client.CreateTable(definition)
+		if err != nil {
+			if isErrorCode(err, dynamodb.ErrCodeResourceInUseException) {
+				continue
+			}
+			return err
+		}
+		err = r.client.WaitUntilTableExists(&dynamodb.DescribeTableInput{TableName: definition.TableName})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func attribute(name, attributeType string) *dynamodb.AttributeDefinition {
+	return &dynamodb.AttributeDefinition{AttributeName: aws.String(name), AttributeType: aws.String(attributeType)}
+}
+
+func keySchema(hashKey, rangeKey string) []*dynamodb.KeySchemaElement {
+	schema := []*dynamodb.KeySchemaElement{
+		{AttributeName: aws.String(hashKey), KeyType: aws.String(dynamodb.KeyTypeHash)},
+	}
+	if rangeKey != "" {
+		schema = append(schema, &dynamodb.KeySchemaElement{AttributeName: aws.String(rangeKey), KeyType: aws.String(dynamodb.KeyTypeRange)})
+	}
+	return schema
+}
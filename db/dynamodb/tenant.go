@@ -0,0 +1,39 @@
+package dynamodb
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *dynamoRepository) CreateTenant(tenant *db.Tenant) error {
+	return r.insertDocument(tenantsTable, tenant.Name, tenant, db.ErrTenantAlreadyExists)
+}
+
+func (r *dynamoRepository) UpdateTenant(tenant *db.Tenant) error {
+	return r.updateDocument(tenantsTable, tenant.Name, tenant, db.ErrTenantNotFound)
+}
+
+func (r *dynamoRepository) DeleteTenant(tenant *db.Tenant) error {
+	return r.deleteDocument(tenantsTable, tenant.Name, db.ErrTenantNotFound)
+}
+
+func (r *dynamoRepository) GetTenant(name string) (*db.Tenant, error) {
+	tenant := db.Tenant{Name: name}
+	err := r.getDocument(tenantsTable, name, &tenant, db.ErrTenantNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *dynamoRepository) ListTenants() ([]db.Tenant, error) {
+	tenants := []db.Tenant{}
+	err := r.listDocuments(tenantsTable, func(data []byte) error {
+		var tenant db.Tenant
+		err := json.Unmarshal(data, &tenant)
+		tenants = append(tenants, tenant)
+		return err
+	})
+	return tenants, err
+}
@@ -0,0 +1,34 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const throughputSettingPrefix = "throughput:"
+
+func (r *dynamoRepository) SaveProviderThroughput(throughput *db.ProviderThroughput) error {
+	throughput.UpdateTime = time.Now().UTC()
+	data, err := json.Marshal(throughput)
+	if err != nil {
+		return err
+	}
+	item := map[string]*dynamodb.AttributeValue{
+		"name": stringValue(throughputSettingPrefix + throughput.Provider),
+		"data": stringValue(string(data)),
+	}
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{TableName: r.table(settingsTable), Item: item})
+	return err
+}
+
+func (r *dynamoRepository) GetProviderThroughput(providerName string) (*db.ProviderThroughput, error) {
+	var throughput db.ProviderThroughput
+	err := r.getDocument(settingsTable, throughputSettingPrefix+providerName, &throughput, db.ErrProviderThroughputNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &throughput, nil
+}
@@ -0,0 +1,35 @@
+package dynamodb
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *dynamoRepository) CreateWatchFolder(folder *db.WatchFolder) error {
+	return r.insertDocument(watchFoldersTable, folder.Name, folder, db.ErrWatchFolderAlreadyExists)
+}
+
+func (r *dynamoRepository) DeleteWatchFolder(folder *db.WatchFolder) error {
+	return r.deleteDocument(watchFoldersTable, folder.Name, db.ErrWatchFolderNotFound)
+}
+
+func (r *dynamoRepository) GetWatchFolder(name string) (*db.WatchFolder, error) {
+	folder := db.WatchFolder{Name: name}
+	err := r.getDocument(watchFoldersTable, name, &folder, db.ErrWatchFolderNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (r *dynamoRepository) ListWatchFolders() ([]db.WatchFolder, error) {
+	folders := []db.WatchFolder{}
+	err := r.listDocuments(watchFoldersTable, func(data []byte) error {
+		var folder db.WatchFolder
+		err := json.Unmarshal(data, &folder)
+		folders = append(folders, folder)
+		return err
+	})
+	return folders, err
+}
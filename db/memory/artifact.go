@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// artifactsTable returns the table of the artifacts of the given job.
+func artifactsTable(jobID string) string {
+	return "artifacts:" + jobID
+}
+
+func (r *memoryRepository) CreateArtifact(artifact *db.Artifact) error {
+	if artifact.JobID == "" || artifact.Name == "" {
+		return errors.New("job id and name are required")
+	}
+	if artifact.CreationTime.IsZero() {
+		artifact.CreationTime = time.Now().UTC()
+	}
+	return r.insertDocument(artifactsTable(artifact.JobID), artifact.Name, artifact, db.ErrArtifactAlreadyExists)
+}
+
+func (r *memoryRepository) ListArtifacts(jobID string) ([]db.Artifact, error) {
+	artifacts := []db.Artifact{}
+	err := r.listDocuments(artifactsTable(jobID), func(data []byte) error {
+		var artifact db.Artifact
+		err := json.Unmarshal(data, &artifact)
+		artifacts = append(artifacts, artifact)
+		return err
+	})
+	return artifacts, err
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Types other than jobs are stored as JSON documents in tables keyed by
+// name. Documents that belong to another one (like the artifacts of a job)
+// are stored in a table of their own, named after the parent.
+
+// insertDocument stores a new document, returning the given error when
+// there's already a document with the same name.
+func (r *memoryRepository) insertDocument(table, name string, v interface{}, errAlreadyExists error) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.tables[table][name]; ok {
+		return errAlreadyExists
+	}
+	r.setDocument(table, name, data)
+	return nil
+}
+
+// updateDocument replaces the document with the given name, returning the
+// given error when there's no such document.
+func (r *memoryRepository) updateDocument(table, name string, v interface{}, errNotFound error) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.tables[table][name]; !ok {
+		return errNotFound
+	}
+	r.setDocument(table, name, data)
+	return nil
+}
+
+// putDocument stores the document, replacing any document with the same
+// name.
+func (r *memoryRepository) putDocument(table, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.setDocument(table, name, data)
+	return nil
+}
+
+// setDocument must be called with the lock held.
+func (r *memoryRepository) setDocument(table, name string, data []byte) {
+	if r.tables[table] == nil {
+		r.tables[table] = make(map[string][]byte)
+	}
+	r.tables[table][name] = data
+}
+
+func (r *memoryRepository) deleteDocument(table, name string, errNotFound error) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.tables[table][name]; !ok {
+		return errNotFound
+	}
+	delete(r.tables[table], name)
+	return nil
+}
+
+func (r *memoryRepository) dropTable(table string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	delete(r.tables, table)
+}
+
+func (r *memoryRepository) getDocument(table, name string, v interface{}, errNotFound error) error {
+	r.mtx.RLock()
+	data, ok := r.tables[table][name]
+	r.mtx.RUnlock()
+	if !ok {
+		return errNotFound
+	}
+	return json.Unmarshal(data, v)
+}
+
+// listDocuments calls add with each document in the table, sorted by name.
+func (r *memoryRepository) listDocuments(table string, add func(data []byte) error) error {
+	r.mtx.RLock()
+	names := make([]string, 0, len(r.tables[table]))
+	documents := make(map[string][]byte, len(r.tables[table]))
+	for name, data := range r.tables[table] {
+		names = append(names, name)
+		documents[name] = data
+	}
+	r.mtx.RUnlock()
+	sort.Strings(names)
+	for _, name := range names {
+		if err := add(documents[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const experimentsTable = "experiments"
+
+// experimentSamplesTable returns the table of the samples of the given
+// experiment, keyed by job id.
+func experimentSamplesTable(experiment string) string {
+	return "experiment_samples:" + experiment
+}
+
+func (r *memoryRepository) CreateExperiment(experiment *db.Experiment) error {
+	if experiment.Name == "" {
+		return errors.New("experiment name is required")
+	}
+	if experiment.CreationTime.IsZero() {
+		experiment.CreationTime = time.Now().UTC()
+	}
+	return r.insertDocument(experimentsTable, experiment.Name, experiment, db.ErrExperimentAlreadyExists)
+}
+
+// DeleteExperiment deletes the experiment along with its samples.
+func (r *memoryRepository) DeleteExperiment(experiment *db.Experiment) error {
+	err := r.deleteDocument(experimentsTable, experiment.Name, db.ErrExperimentNotFound)
+	if err != nil {
+		return err
+	}
+	r.dropTable(experimentSamplesTable(experiment.Name))
+	return nil
+}
+
+func (r *memoryRepository) GetExperiment(name string) (*db.Experiment, error) {
+	experiment := db.Experiment{Name: name}
+	err := r.getDocument(experimentsTable, name, &experiment, db.ErrExperimentNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+func (r *memoryRepository) ListExperiments() ([]db.Experiment, error) {
+	experiments := []db.Experiment{}
+	err := r.listDocuments(experimentsTable, func(data []byte) error {
+		var experiment db.Experiment
+		err := json.Unmarshal(data, &experiment)
+		experiments = append(experiments, experiment)
+		return err
+	})
+	return experiments, err
+}
+
+func (r *memoryRepository) SaveExperimentSample(sample *db.ExperimentSample) error {
+	if sample.Experiment == "" || sample.JobID == "" {
+		return errors.New("experiment and job id are required")
+	}
+	if sample.CreationTime.IsZero() {
+		sample.CreationTime = time.Now().UTC()
+	}
+	return r.putDocument(experimentSamplesTable(sample.Experiment), sample.JobID, sample)
+}
+
+func (r *memoryRepository) ListExperimentSamples(experiment string) ([]db.ExperimentSample, error) {
+	samples := []db.ExperimentSample{}
+	err := r.listDocuments(experimentSamplesTable(experiment), func(data []byte) error {
+		var sample db.ExperimentSample
+		err := json.Unmarshal(data, &sample)
+		samples = append(samples, sample)
+		return err
+	})
+	return samples, err
+}
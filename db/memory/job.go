@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *memoryRepository) CreateJob(job *db.Job) error {
+	if job.ID == "" {
+		return errors.New("job id is required")
+	}
+	job.CreationTime = time.Now().UTC()
+	stored, err := copyJob(job)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.jobs[job.ID]; ok {
+		return fmt.Errorf("job %q already exists", job.ID)
+	}
+	r.jobs[job.ID] = *stored
+	return nil
+}
+
+func (r *memoryRepository) UpdateJob(job *db.Job) error {
+	stored, err := copyJob(job)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.jobs[job.ID]; !ok {
+		return db.ErrJobNotFound
+	}
+	r.jobs[job.ID] = *stored
+	return nil
+}
+
+func (r *memoryRepository) UpdateJobIfStatus(job *db.Job, status string) error {
+	stored, err := copyJob(job)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	current, ok := r.jobs[job.ID]
+	if !ok {
+		return db.ErrJobNotFound
+	}
+	if current.Status != status {
+		return db.ErrJobStatusChanged
+	}
+	r.jobs[job.ID] = *stored
+	return nil
+}
+
+func (r *memoryRepository) DeleteJob(job *db.Job) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.jobs[job.ID]; !ok {
+		return db.ErrJobNotFound
+	}
+	delete(r.jobs, job.ID)
+	return nil
+}
+
+func (r *memoryRepository) GetJob(id string) (*db.Job, error) {
+	r.mtx.RLock()
+	job, ok := r.jobs[id]
+	r.mtx.RUnlock()
+	if !ok {
+		return nil, db.ErrJobNotFound
+	}
+	return copyJob(&job)
+}
+
+// ListJobs lists the jobs matching the filter, oldest first.
+func (r *memoryRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
+	jobs, err := r.findJobs(func(job *db.Job) bool {
+		if job.CreationTime.Before(filter.Since) {
+			return false
+		}
+		if !filter.Until.IsZero() && !job.CreationTime.Before(filter.Until) {
+			return false
+		}
+		if filter.Status != "" && job.Status != filter.Status {
+			return false
+		}
+		return filter.ProviderName == "" || job.ProviderName == filter.ProviderName
+	})
+	if err != nil {
+		return nil, err
+	}
+	if filter.Offset >= uint(len(jobs)) {
+		return []db.Job{}, nil
+	}
+	jobs = jobs[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < uint(len(jobs)) {
+		jobs = jobs[:filter.Limit]
+	}
+	return jobs, nil
+}
+
+func (r *memoryRepository) ListJobsByExternalID(tenant, externalID string) ([]db.Job, error) {
+	return r.findJobs(func(job *db.Job) bool {
+		return job.Tenant == tenant && job.ExternalID == externalID
+	})
+}
+
+func (r *memoryRepository) GetJobByProviderJobID(providerName, providerJobID string) (*db.Job, error) {
+	jobs, err := r.findJobs(func(job *db.Job) bool {
+		return job.ProviderName == providerName && job.ProviderJobID == providerJobID
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, db.ErrJobNotFound
+	}
+	return &jobs[0], nil
+}
+
+func (r *memoryRepository) NextJobSequence(tenant string) (uint64, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.sequences[tenant]++
+	return r.sequences[tenant], nil
+}
+
+// findJobs returns copies of the jobs that match, sorted by creation time.
+func (r *memoryRepository) findJobs(match func(*db.Job) bool) ([]db.Job, error) {
+	r.mtx.RLock()
+	jobs := []db.Job{}
+	for _, job := range r.jobs {
+		if match(&job) {
+			jobs = append(jobs, job)
+		}
+	}
+	r.mtx.RUnlock()
+	for i := range jobs {
+		job, err := copyJob(&jobs[i])
+		if err != nil {
+			return nil, err
+		}
+		jobs[i] = *job
+	}
+	sort.Sort(jobsByCreationTime(jobs))
+	return jobs, nil
+}
+
+// copyJob makes a deep copy of the job through JSON, including the fields
+// that aren't encoded in JSON.
+func copyJob(job *db.Job) (*db.Job, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	var jobCopy db.Job
+	if err = json.Unmarshal(data, &jobCopy); err != nil {
+		return nil, err
+	}
+	jobCopy.DRMKey = job.DRMKey
+	jobCopy.StatusSnapshot = job.StatusSnapshot
+	jobCopy.StatusSnapshotTime = job.StatusSnapshotTime
+	return &jobCopy, nil
+}
+
+type jobsByCreationTime []db.Job
+
+func (s jobsByCreationTime) Len() int      { return len(s) }
+func (s jobsByCreationTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s jobsByCreationTime) Less(i, j int) bool {
+	if s[i].CreationTime.Equal(s[j].CreationTime) {
+		return s[i].ID < s[j].ID
+	}
+	return s[i].CreationTime.Before(s[j].CreationTime)
+}
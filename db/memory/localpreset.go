@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"errors"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const localPresetsTable = "local_presets"
+
+func (r *memoryRepository) CreateLocalPreset(localPreset *db.LocalPreset) error {
+	if localPreset.Name == "" {
+		return errors.New("preset name missing")
+	}
+	return r.insertDocument(localPresetsTable, localPreset.Name, localPreset, db.ErrLocalPresetAlreadyExists)
+}
+
+func (r *memoryRepository) UpdateLocalPreset(localPreset *db.LocalPreset) error {
+	return r.updateDocument(localPresetsTable, localPreset.Name, localPreset, db.ErrLocalPresetNotFound)
+}
+
+func (r *memoryRepository) DeleteLocalPreset(localPreset *db.LocalPreset) error {
+	return r.deleteDocument(localPresetsTable, localPreset.Name, db.ErrLocalPresetNotFound)
+}
+
+func (r *memoryRepository) GetLocalPreset(name string) (*db.LocalPreset, error) {
+	localPreset := db.LocalPreset{Name: name}
+	err := r.getDocument(localPresetsTable, name, &localPreset, db.ErrLocalPresetNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &localPreset, nil
+}
@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const (
+	settingsTable      = "settings"
+	maintenanceSetting = "maintenance"
+)
+
+func (r *memoryRepository) SetMaintenanceMode(mode *db.MaintenanceMode) error {
+	mode.UpdateTime = time.Now().UTC()
+	return r.putDocument(settingsTable, maintenanceSetting, mode)
+}
+
+func (r *memoryRepository) GetMaintenanceMode() (*db.MaintenanceMode, error) {
+	var mode db.MaintenanceMode
+	err := r.getDocument(settingsTable, maintenanceSetting, &mode, db.ErrMaintenanceModeNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &mode, nil
+}
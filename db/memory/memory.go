@@ -0,0 +1,57 @@
+// Package memory implements db.Repository keeping everything in memory, for
+// unit tests and for running the API locally without external services.
+//
+// Like in the other repositories, values are encoded when stored and
+// decoded when loaded, so the repository never shares slices or maps with
+// its callers. Nothing survives a restart of the process.
+package memory
+
+import (
+	"sync"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// shared is the repository returned by NewRepository. Repositories are
+// created whenever providers are, so they all share the same store, the
+// way they would share a database.
+var shared = newRepository()
+
+// NewRepository returns the in-memory Repository shared by the whole
+// process.
+func NewRepository(cfg *config.Config) (db.Repository, error) {
+	return shared, nil
+}
+
+// New creates an empty in-memory Repository that isn't shared with any
+// other, for isolating tests from each other.
+func New() db.Repository {
+	return newRepository()
+}
+
+// Reset removes everything from the repository shared by the process.
+func Reset() {
+	shared.reset()
+}
+
+type memoryRepository struct {
+	mtx       sync.RWMutex
+	tables    map[string]map[string][]byte
+	jobs      map[string]db.Job
+	sequences map[string]uint64
+}
+
+func newRepository() *memoryRepository {
+	r := &memoryRepository{}
+	r.reset()
+	return r
+}
+
+func (r *memoryRepository) reset() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.tables = make(map[string]map[string][]byte)
+	r.jobs = make(map[string]db.Job)
+	r.sequences = make(map[string]uint64)
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestNewRepositoryIsShared(t *testing.T) {
+	defer Reset()
+	repo, err := NewRepository(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.CreateTenant(&db.Tenant{Name: "nyt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewRepository(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = other.GetTenant("nyt"); err != nil {
+		t.Errorf("tenant not found in another repository: %v", err)
+	}
+	if _, err = New().GetTenant("nyt"); err != db.ErrTenantNotFound {
+		t.Errorf("wrong error in isolated repository. Want ErrTenantNotFound. Got %#v", err)
+	}
+	Reset()
+	if _, err = other.GetTenant("nyt"); err != db.ErrTenantNotFound {
+		t.Errorf("wrong error after reset. Want ErrTenantNotFound. Got %#v", err)
+	}
+}
+
+func TestJobsAreCopied(t *testing.T) {
+	r := New()
+	job := db.Job{ID: "myjob", Labels: []string{"partner"}}
+	err := r.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Labels[0] = "changed"
+	gotJob, err := r.GetJob("myjob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJob.Labels[0] = "changed again"
+	gotJob, err = r.GetJob("myjob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotJob.Labels[0] != "partner" {
+		t.Errorf("stored job changed by callers. Want label %q. Got %q", "partner", gotJob.Labels[0])
+	}
+	if err = r.CreateJob(&db.Job{ID: "myjob"}); err == nil {
+		t.Error("unexpected <nil> error creating duplicate job")
+	}
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const presetMapsTable = "presetmaps"
+
+// presetMapVersionsTable returns the table of the versions of the given
+// presetmap, keyed by the zero-padded version number so they're listed in
+// order.
+func presetMapVersionsTable(name string) string {
+	return "presetmap_versions:" + name
+}
+
+func (r *memoryRepository) CreatePresetMap(presetMap *db.PresetMap) error {
+	return r.writePresetMap(presetMap, false)
+}
+
+func (r *memoryRepository) UpdatePresetMap(presetMap *db.PresetMap) error {
+	return r.writePresetMap(presetMap, true)
+}
+
+// writePresetMap stores the presetmap, numbering it after its last version.
+// When updating, the presetmap being replaced is stored as that version.
+func (r *memoryRepository) writePresetMap(presetMap *db.PresetMap, update bool) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	current, exists := r.tables[presetMapsTable][presetMap.Name]
+	if update && !exists {
+		return db.ErrPresetMapNotFound
+	}
+	if !update && exists {
+		return db.ErrPresetMapAlreadyExists
+	}
+	if update {
+		if err := r.addPresetMapVersion(presetMap.Name, current); err != nil {
+			return err
+		}
+	}
+	presetMap.Version = len(r.tables[presetMapVersionsTable(presetMap.Name)]) + 1
+	data, err := json.Marshal(presetMap)
+	if err != nil {
+		return err
+	}
+	r.setDocument(presetMapsTable, presetMap.Name, data)
+	return nil
+}
+
+// DeletePresetMap deletes the presetmap, storing it as its last version.
+func (r *memoryRepository) DeletePresetMap(presetMap *db.PresetMap) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	current, ok := r.tables[presetMapsTable][presetMap.Name]
+	if !ok {
+		return db.ErrPresetMapNotFound
+	}
+	if err := r.addPresetMapVersion(presetMap.Name, current); err != nil {
+		return err
+	}
+	delete(r.tables[presetMapsTable], presetMap.Name)
+	return nil
+}
+
+// addPresetMapVersion stores the given encoded presetmap as its last
+// version. It must be called with the lock held.
+func (r *memoryRepository) addPresetMapVersion(name string, presetMapData []byte) error {
+	table := presetMapVersionsTable(name)
+	version := db.PresetMapVersion{Version: len(r.tables[table]) + 1, ReplacedAt: time.Now().UTC()}
+	if err := json.Unmarshal(presetMapData, &version.PresetMap); err != nil {
+		return err
+	}
+	data, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	r.setDocument(table, fmt.Sprintf("%010d", version.Version), data)
+	return nil
+}
+
+func (r *memoryRepository) GetPresetMap(name string) (*db.PresetMap, error) {
+	presetMap := db.PresetMap{Name: name, ProviderMapping: make(map[string]string)}
+	err := r.getDocument(presetMapsTable, name, &presetMap, db.ErrPresetMapNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &presetMap, nil
+}
+
+func (r *memoryRepository) ListPresetMaps(filter db.PresetMapFilter) ([]db.PresetMap, error) {
+	presetMaps := []db.PresetMap{}
+	err := r.listDocuments(presetMapsTable, func(data []byte) error {
+		presetMap := db.PresetMap{ProviderMapping: make(map[string]string)}
+		err := json.Unmarshal(data, &presetMap)
+		presetMaps = append(presetMaps, presetMap)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filter.Apply(presetMaps), nil
+}
+
+func (r *memoryRepository) ListPresetMapVersions(name string) ([]db.PresetMapVersion, error) {
+	versions := []db.PresetMapVersion{}
+	err := r.listDocuments(presetMapVersionsTable(name), func(data []byte) error {
+		var version db.PresetMapVersion
+		err := json.Unmarshal(data, &version)
+		versions = append(versions, version)
+		return err
+	})
+	return versions, err
+}
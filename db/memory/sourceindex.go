@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// sourceEncodesTable returns the table of the encodes of the source with the
+// given checksum, keyed by job id.
+func sourceEncodesTable(checksum string) string {
+	return "source_encodes:" + checksum
+}
+
+func (r *memoryRepository) SaveSourceEncode(encode *db.SourceEncode) error {
+	if encode.Checksum == "" || encode.JobID == "" {
+		return errors.New("checksum and job id are required")
+	}
+	return r.putDocument(sourceEncodesTable(encode.Checksum), encode.JobID, encode)
+}
+
+func (r *memoryRepository) ListSourceEncodes(checksum string) ([]db.SourceEncode, error) {
+	encodes := []db.SourceEncode{}
+	err := r.listDocuments(sourceEncodesTable(checksum), func(data []byte) error {
+		var encode db.SourceEncode
+		err := json.Unmarshal(data, &encode)
+		encodes = append(encodes, encode)
+		return err
+	})
+	return encodes, err
+}
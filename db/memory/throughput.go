@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const throughputSettingPrefix = "throughput:"
+
+func (r *memoryRepository) SaveProviderThroughput(throughput *db.ProviderThroughput) error {
+	throughput.UpdateTime = time.Now().UTC()
+	return r.putDocument(settingsTable, throughputSettingPrefix+throughput.Provider, throughput)
+}
+
+func (r *memoryRepository) GetProviderThroughput(providerName string) (*db.ProviderThroughput, error) {
+	var throughput db.ProviderThroughput
+	err := r.getDocument(settingsTable, throughputSettingPrefix+providerName, &throughput, db.ErrProviderThroughputNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &throughput, nil
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *postgresRepository) CreateArtifact(artifact *db.Artifact) error {
+	if artifact.JobID == "" || artifact.Name == "" {
+		return errors.New("job id and name are required")
+	}
+	if artifact.CreationTime.IsZero() {
+		artifact.CreationTime = time.Now().UTC()
+	}
+	data, err := json.Marshal(artifact)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`INSERT INTO artifacts (job_id, name, data) VALUES ($1, $2, $3)
+		ON CONFLICT (job_id, name) DO NOTHING`, artifact.JobID, artifact.Name, data)
+	return checkAffected(result, err, db.ErrArtifactAlreadyExists)
+}
+
+func (r *postgresRepository) ListArtifacts(jobID string) ([]db.Artifact, error) {
+	artifacts := []db.Artifact{}
+	err := r.queryDocuments(func(data []byte) error {
+		var artifact db.Artifact
+		err := json.Unmarshal(data, &artifact)
+		artifacts = append(artifacts, artifact)
+		return err
+	}, `SELECT data FROM artifacts WHERE job_id = $1 ORDER BY name`, jobID)
+	return artifacts, err
+}
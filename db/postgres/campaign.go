@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const campaignsTable = "campaigns"
+
+func (r *postgresRepository) CreateCampaign(campaign *db.Campaign) error {
+	return r.insertDocument(campaignsTable, campaign.Name, campaign, db.ErrCampaignAlreadyExists)
+}
+
+func (r *postgresRepository) UpdateCampaign(campaign *db.Campaign) error {
+	return r.updateDocument(campaignsTable, campaign.Name, campaign, db.ErrCampaignNotFound)
+}
+
+func (r *postgresRepository) DeleteCampaign(campaign *db.Campaign) error {
+	return r.deleteDocument(campaignsTable, campaign.Name, db.ErrCampaignNotFound)
+}
+
+func (r *postgresRepository) GetCampaign(name string) (*db.Campaign, error) {
+	campaign := db.Campaign{Name: name}
+	err := r.getDocument(campaignsTable, name, &campaign, db.ErrCampaignNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (r *postgresRepository) ListCampaigns() ([]db.Campaign, error) {
+	campaigns := []db.Campaign{}
+	err := r.listDocuments(campaignsTable, func(data []byte) error {
+		var campaign db.Campaign
+		err := json.Unmarshal(data, &campaign)
+		campaigns = append(campaigns, campaign)
+		return err
+	})
+	return campaigns, err
+}
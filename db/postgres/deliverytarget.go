@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const deliveryTargetsTable = "delivery_targets"
+
+func (r *postgresRepository) CreateDeliveryTarget(target *db.DeliveryTarget) error {
+	return r.insertDocument(deliveryTargetsTable, target.Name, target, db.ErrDeliveryTargetAlreadyExists)
+}
+
+func (r *postgresRepository) UpdateDeliveryTarget(target *db.DeliveryTarget) error {
+	return r.updateDocument(deliveryTargetsTable, target.Name, target, db.ErrDeliveryTargetNotFound)
+}
+
+func (r *postgresRepository) DeleteDeliveryTarget(target *db.DeliveryTarget) error {
+	return r.deleteDocument(deliveryTargetsTable, target.Name, db.ErrDeliveryTargetNotFound)
+}
+
+func (r *postgresRepository) GetDeliveryTarget(name string) (*db.DeliveryTarget, error) {
+	target := db.DeliveryTarget{Name: name}
+	err := r.getDocument(deliveryTargetsTable, name, &target, db.ErrDeliveryTargetNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+func (r *postgresRepository) ListDeliveryTargets() ([]db.DeliveryTarget, error) {
+	targets := []db.DeliveryTarget{}
+	err := r.listDocuments(deliveryTargetsTable, func(data []byte) error {
+		var target db.DeliveryTarget
+		err := json.Unmarshal(data, &target)
+		targets = append(targets, target)
+		return err
+	})
+	return targets, err
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Types other than jobs are stored as JSON documents in tables with a name
+// column (the primary key) and a data column. The table names are constants
+// of this package, never user input.
+
+// insertDocument stores a new document, returning the given error when
+// there's already a document with the same name.
+func (r *postgresRepository) insertDocument(table, name string, v interface{}, errAlreadyExists error) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`INSERT INTO `+table+` (name, data) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING`, name, data)
+	return checkAffected(result, err, errAlreadyExists)
+}
+
+// updateDocument replaces the document with the given name, returning the
+// given error when there's no such document.
+func (r *postgresRepository) updateDocument(table, name string, v interface{}, errNotFound error) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`UPDATE `+table+` SET data = $2 WHERE name = $1`, name, data)
+	return checkAffected(result, err, errNotFound)
+}
+
+func (r *postgresRepository) deleteDocument(table, name string, errNotFound error) error {
+	result, err := r.db.Exec(`DELETE FROM `+table+` WHERE name = $1`, name)
+	return checkAffected(result, err, errNotFound)
+}
+
+func (r *postgresRepository) getDocument(table, name string, v interface{}, errNotFound error) error {
+	var data []byte
+	err := r.db.QueryRow(`SELECT data FROM `+table+` WHERE name = $1`, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return errNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// listDocuments calls add with each document in the table, sorted by name.
+func (r *postgresRepository) listDocuments(table string, add func(data []byte) error) error {
+	return r.queryDocuments(add, `SELECT data FROM `+table+` ORDER BY name`)
+}
+
+// queryDocuments calls add with the data column of each row returned by
+// the given query.
+func (r *postgresRepository) queryDocuments(add func(data []byte) error, query string, args ...interface{}) error {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data []byte
+		if err = rows.Scan(&data); err != nil {
+			return err
+		}
+		if err = add(data); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// checkAffected returns errNone when the statement didn't affect any rows.
+func checkAffected(result sql.Result, err error, errNone error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNone
+	}
+	return nil
+}
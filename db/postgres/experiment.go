@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const experimentsTable = "experiments"
+
+func (r *postgresRepository) CreateExperiment(experiment *db.Experiment) error {
+	if experiment.Name == "" {
+		return errors.New("experiment name is required")
+	}
+	if experiment.CreationTime.IsZero() {
+		experiment.CreationTime = time.Now().UTC()
+	}
+	return r.insertDocument(experimentsTable, experiment.Name, experiment, db.ErrExperimentAlreadyExists)
+}
+
+// DeleteExperiment deletes the experiment along with its samples.
+func (r *postgresRepository) DeleteExperiment(experiment *db.Experiment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM experiments WHERE name = $1`, experiment.Name)
+	if err = checkAffected(result, err, db.ErrExperimentNotFound); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM experiment_samples WHERE experiment = $1`, experiment.Name); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *postgresRepository) GetExperiment(name string) (*db.Experiment, error) {
+	experiment := db.Experiment{Name: name}
+	err := r.getDocument(experimentsTable, name, &experiment, db.ErrExperimentNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+func (r *postgresRepository) ListExperiments() ([]db.Experiment, error) {
+	experiments := []db.Experiment{}
+	err := r.listDocuments(experimentsTable, func(data []byte) error {
+		var experiment db.Experiment
+		err := json.Unmarshal(data, &experiment)
+		experiments = append(experiments, experiment)
+		return err
+	})
+	return experiments, err
+}
+
+func (r *postgresRepository) SaveExperimentSample(sample *db.ExperimentSample) error {
+	if sample.Experiment == "" || sample.JobID == "" {
+		return errors.New("experiment and job id are required")
+	}
+	if sample.CreationTime.IsZero() {
+		sample.CreationTime = time.Now().UTC()
+	}
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO experiment_samples (experiment, job_id, data) VALUES ($1, $2, $3)
+		ON CONFLICT (experiment, job_id) DO UPDATE SET data = excluded.data`, sample.Experiment, sample.JobID, data)
+	return err
+}
+
+func (r *postgresRepository) ListExperimentSamples(experiment string) ([]db.ExperimentSample, error) {
+	samples := []db.ExperimentSample{}
+	err := r.queryDocuments(func(data []byte) error {
+		var sample db.ExperimentSample
+		err := json.Unmarshal(data, &sample)
+		samples = append(samples, sample)
+		return err
+	}, `SELECT data FROM experiment_samples WHERE experiment = $1 ORDER BY job_id`, experiment)
+	return samples, err
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestDeleteExperimentDeletesSamples(t *testing.T) {
+	r := newTestRepository(t)
+	experiment := db.Experiment{Name: "vp9", SamplePercent: 10, Variants: []db.ExperimentVariant{{Name: "control"}, {Name: "vp9"}}}
+	err := r.CreateExperiment(&experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sample := range []db.ExperimentSample{
+		{Experiment: "vp9", JobID: "job-1", Variant: "control"},
+		{Experiment: "vp9", JobID: "job-1", Variant: "vp9"},
+		{Experiment: "vp9", JobID: "job-2", Variant: "control"},
+	} {
+		sample := sample
+		if err = r.SaveExperimentSample(&sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+	samples, err := r.ListExperimentSamples("vp9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].Variant != "vp9" {
+		t.Errorf("wrong samples listed: %#v", samples)
+	}
+	if err = r.DeleteExperiment(&experiment); err != nil {
+		t.Fatal(err)
+	}
+	if samples, err = r.ListExperimentSamples("vp9"); err != nil || len(samples) != 0 {
+		t.Errorf("samples of the deleted experiment weren't deleted: %#v (%v)", samples, err)
+	}
+	if err = r.DeleteExperiment(&experiment); err != db.ErrExperimentNotFound {
+		t.Errorf("wrong error deleting unknown experiment. Want ErrExperimentNotFound. Got %#v", err)
+	}
+}
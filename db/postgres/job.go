@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// jobColumns are the columns loaded for each job. Fields of the job that
+// aren't encoded in JSON (like the DRM key) are stored in their own
+// columns.
+const jobColumns = `data, drm_key, status_snapshot, status_snapshot_time`
+
+func (r *postgresRepository) CreateJob(job *db.Job) error {
+	if job.ID == "" {
+		return errors.New("job id is required")
+	}
+	job.CreationTime = time.Now().UTC()
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO jobs (id, tenant, external_id, provider_name, provider_job_id, status, creation_time, drm_key, status_snapshot, status_snapshot_time, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		job.ID, job.Tenant, job.ExternalID, job.ProviderName, job.ProviderJobID, job.Status, job.CreationTime,
+		job.DRMKey, job.StatusSnapshot, job.StatusSnapshotTime, data)
+	return err
+}
+
+func (r *postgresRepository) UpdateJob(job *db.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`UPDATE jobs SET tenant = $2, external_id = $3, provider_name = $4, provider_job_id = $5, status = $6,
+		drm_key = $7, status_snapshot = $8, status_snapshot_time = $9, data = $10 WHERE id = $1`,
+		job.ID, job.Tenant, job.ExternalID, job.ProviderName, job.ProviderJobID, job.Status,
+		job.DRMKey, job.StatusSnapshot, job.StatusSnapshotTime, data)
+	return checkAffected(result, err, db.ErrJobNotFound)
+}
+
+func (r *postgresRepository) UpdateJobIfStatus(job *db.Job, status string) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`UPDATE jobs SET tenant = $2, external_id = $3, provider_name = $4, provider_job_id = $5, status = $6,
+		drm_key = $7, status_snapshot = $8, status_snapshot_time = $9, data = $10 WHERE id = $1 AND status = $11`,
+		job.ID, job.Tenant, job.ExternalID, job.ProviderName, job.ProviderJobID, job.Status,
+		job.DRMKey, job.StatusSnapshot, job.StatusSnapshotTime, data, status)
+	err = checkAffected(result, err, db.ErrJobStatusChanged)
+	if err == db.ErrJobStatusChanged {
+		if _, getErr := r.GetJob(job.ID); getErr == db.ErrJobNotFound {
+			return getErr
+		}
+	}
+	return err
+}
+
+func (r *postgresRepository) DeleteJob(job *db.Job) error {
+	result, err := r.db.Exec(`DELETE FROM jobs WHERE id = $1`, job.ID)
+	return checkAffected(result, err, db.ErrJobNotFound)
+}
+
+func (r *postgresRepository) GetJob(id string) (*db.Job, error) {
+	return r.queryJob(`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id)
+}
+
+// ListJobs lists the jobs matching the filter, oldest first. The filters
+// map to indexed columns, and pagination is done by the database.
+func (r *postgresRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
+	conditions := []string{"creation_time >= $1"}
+	args := []interface{}{filter.Since}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+" $"+strconv.Itoa(len(args)))
+	}
+	if !filter.Until.IsZero() {
+		addCondition("creation_time <", filter.Until)
+	}
+	if filter.Status != "" {
+		addCondition("status =", filter.Status)
+	}
+	if filter.ProviderName != "" {
+		addCondition("provider_name =", filter.ProviderName)
+	}
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY creation_time, id`
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + strconv.FormatUint(uint64(filter.Limit), 10)
+	}
+	if filter.Offset > 0 {
+		query += ` OFFSET ` + strconv.FormatUint(uint64(filter.Offset), 10)
+	}
+	return r.queryJobs(query, args...)
+}
+
+func (r *postgresRepository) ListJobsByExternalID(tenant, externalID string) ([]db.Job, error) {
+	return r.queryJobs(`SELECT `+jobColumns+` FROM jobs WHERE tenant = $1 AND external_id = $2 ORDER BY creation_time, id`, tenant, externalID)
+}
+
+func (r *postgresRepository) GetJobByProviderJobID(providerName, providerJobID string) (*db.Job, error) {
+	return r.queryJob(`SELECT `+jobColumns+` FROM jobs WHERE provider_name = $1 AND provider_job_id = $2`, providerName, providerJobID)
+}
+
+func (r *postgresRepository) NextJobSequence(tenant string) (uint64, error) {
+	var n uint64
+	err := r.db.QueryRow(`INSERT INTO job_sequences (tenant, value) VALUES ($1, 1)
+		ON CONFLICT (tenant) DO UPDATE SET value = job_sequences.value + 1 RETURNING value`, tenant).Scan(&n)
+	return n, err
+}
+
+func (r *postgresRepository) queryJob(query string, args ...interface{}) (*db.Job, error) {
+	job, err := scanJob(r.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, db.ErrJobNotFound
+	}
+	return job, err
+}
+
+func (r *postgresRepository) queryJobs(query string, args ...interface{}) ([]db.Job, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	jobs := []db.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row scanner) (*db.Job, error) {
+	var job db.Job
+	var data []byte
+	err := row.Scan(&data, &job.DRMKey, &job.StatusSnapshot, &job.StatusSnapshotTime)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	job.StatusSnapshotTime = job.StatusSnapshotTime.UTC()
+	return &job, nil
+}
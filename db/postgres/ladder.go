@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const laddersTable = "ladders"
+
+func (r *postgresRepository) CreateLadder(ladder *db.Ladder) error {
+	return r.insertDocument(laddersTable, ladder.Name, ladder, db.ErrLadderAlreadyExists)
+}
+
+func (r *postgresRepository) UpdateLadder(ladder *db.Ladder) error {
+	return r.updateDocument(laddersTable, ladder.Name, ladder, db.ErrLadderNotFound)
+}
+
+func (r *postgresRepository) DeleteLadder(ladder *db.Ladder) error {
+	return r.deleteDocument(laddersTable, ladder.Name, db.ErrLadderNotFound)
+}
+
+func (r *postgresRepository) GetLadder(name string) (*db.Ladder, error) {
+	ladder := db.Ladder{Name: name}
+	err := r.getDocument(laddersTable, name, &ladder, db.ErrLadderNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &ladder, nil
+}
+
+func (r *postgresRepository) ListLadders() ([]db.Ladder, error) {
+	ladders := []db.Ladder{}
+	err := r.listDocuments(laddersTable, func(data []byte) error {
+		var ladder db.Ladder
+		err := json.Unmarshal(data, &ladder)
+		ladders = append(ladders, ladder)
+		return err
+	})
+	return ladders, err
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestLocalPresets(t *testing.T) {
+	r := newTestRepository(t)
+	localPreset := db.LocalPreset{
+		Name: "mypreset",
+		Preset: db.Preset{
+			Name:      "mypreset",
+			Container: "mp4",
+			Video:     db.VideoPreset{Codec: "h264", Bitrate: "1000000", Height: "720"},
+			Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+		},
+	}
+	err := r.CreateLocalPreset(&localPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.CreateLocalPreset(&localPreset); err != db.ErrLocalPresetAlreadyExists {
+		t.Errorf("wrong error creating duplicate local preset. Want ErrLocalPresetAlreadyExists. Got %#v", err)
+	}
+	localPreset.Preset.Video.Bitrate = "2000000"
+	if err = r.UpdateLocalPreset(&localPreset); err != nil {
+		t.Fatal(err)
+	}
+	gotPreset, err := r.GetLocalPreset(localPreset.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotPreset, localPreset) {
+		t.Errorf("wrong local preset. Want %#v. Got %#v", localPreset, *gotPreset)
+	}
+	if err = r.DeleteLocalPreset(&localPreset); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.DeleteLocalPreset(&localPreset); err != db.ErrLocalPresetNotFound {
+		t.Errorf("wrong error deleting unknown local preset. Want ErrLocalPresetNotFound. Got %#v", err)
+	}
+}
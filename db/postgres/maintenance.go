@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const (
+	settingsTable      = "settings"
+	maintenanceSetting = "maintenance"
+)
+
+func (r *postgresRepository) SetMaintenanceMode(mode *db.MaintenanceMode) error {
+	mode.UpdateTime = time.Now().UTC()
+	data, err := json.Marshal(mode)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO `+settingsTable+` (name, data) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data`, maintenanceSetting, data)
+	return err
+}
+
+func (r *postgresRepository) GetMaintenanceMode() (*db.MaintenanceMode, error) {
+	var mode db.MaintenanceMode
+	err := r.getDocument(settingsTable, maintenanceSetting, &mode, db.ErrMaintenanceModeNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &mode, nil
+}
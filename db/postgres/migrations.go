@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrationsLockID is the key of the advisory lock held while migrating.
+const migrationsLockID = 7264853
+
+// migrations are the changes to the schema, in order. The version of a
+// migration is its position in the list, and the version of the database is
+// recorded in the schema_migrations table. Migrations are never changed once
+// released: changes to the schema are new migrations appended to the list.
+var migrations = []string{
+	`CREATE TABLE jobs (
+		id text PRIMARY KEY,
+		tenant text NOT NULL DEFAULT '',
+		external_id text NOT NULL DEFAULT '',
+		provider_name text NOT NULL DEFAULT '',
+		provider_job_id text NOT NULL DEFAULT '',
+		status text NOT NULL DEFAULT '',
+		creation_time timestamptz NOT NULL,
+		drm_key text NOT NULL DEFAULT '',
+		status_snapshot text NOT NULL DEFAULT '',
+		status_snapshot_time timestamptz NOT NULL,
+		data jsonb NOT NULL
+	);
+	CREATE INDEX jobs_creation_time ON jobs (creation_time, id);
+	CREATE INDEX jobs_status ON jobs (status, creation_time);
+	CREATE INDEX jobs_provider ON jobs (provider_name, creation_time);
+	CREATE INDEX jobs_provider_job ON jobs (provider_name, provider_job_id);
+	CREATE INDEX jobs_external_id ON jobs (tenant, external_id, creation_time);
+	CREATE TABLE job_sequences (
+		tenant text PRIMARY KEY,
+		value bigint NOT NULL
+	);
+	CREATE TABLE presetmaps (name text PRIMARY KEY, data jsonb NOT NULL);
+	CREATE TABLE local_presets (name text PRIMARY KEY, data jsonb NOT NULL);
+	CREATE TABLE tenants (name text PRIMARY KEY, data jsonb NOT NULL);
+	CREATE TABLE delivery_targets (name text PRIMARY KEY, data jsonb NOT NULL);
+	CREATE TABLE ladders (name text PRIMARY KEY, data jsonb NOT NULL);
+	CREATE TABLE watch_folders (name text PRIMARY KEY, data jsonb NOT NULL);
+	CREATE TABLE submission_pauses (name text PRIMARY KEY, data jsonb NOT NULL);
+	CREATE TABLE experiments (name text PRIMARY KEY, data jsonb NOT NULL);
+	CREATE TABLE experiment_samples (
+		experiment text NOT NULL,
+		job_id text NOT NULL,
+		data jsonb NOT NULL,
+		PRIMARY KEY (experiment, job_id)
+	);
+	CREATE TABLE artifacts (
+		job_id text NOT NULL,
+		name text NOT NULL,
+		data jsonb NOT NULL,
+		PRIMARY KEY (job_id, name)
+	);`,
+	`CREATE TABLE presetmap_versions (
+		name text NOT NULL,
+		version integer NOT NULL,
+		data jsonb NOT NULL,
+		PRIMARY KEY (name, version)
+	);`,
+	`CREATE TABLE campaigns (name text PRIMARY KEY, data jsonb NOT NULL);`,
+	`CREATE TABLE source_encodes (
+		checksum text NOT NULL,
+		job_id text NOT NULL,
+		data jsonb NOT NULL,
+		PRIMARY KEY (checksum, job_id)
+	);`,
+	`CREATE TABLE settings (name text PRIMARY KEY, data jsonb NOT NULL);`,
+	`CREATE TABLE notifications (name text PRIMARY KEY, data jsonb NOT NULL);`,
+}
+
+// migrate upgrades the schema of the database to the latest version. The
+// pending migrations run in a single transaction, holding a lock so
+// instances of the API starting at the same time don't run them
+// concurrently.
+func migrate(conn *sql.DB) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	err = runMigrations(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func runMigrations(tx *sql.Tx) error {
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationsLockID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version integer PRIMARY KEY)`)
+	if err != nil {
+		return err
+	}
+	var version int
+	err = tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return err
+	}
+	for ; version < len(migrations); version++ {
+		if _, err = tx.Exec(migrations[version]); err != nil {
+			return fmt.Errorf("running migration %d: %s", version+1, err)
+		}
+		if _, err = tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
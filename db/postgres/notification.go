@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const notificationsTable = "notifications"
+
+func (r *postgresRepository) CreateNotification(notification *db.Notification) error {
+	notification.CreationTime = time.Now().UTC()
+	return r.insertDocument(notificationsTable, notification.ID, notification, db.ErrNotificationAlreadyExists)
+}
+
+func (r *postgresRepository) UpdateNotification(notification *db.Notification) error {
+	return r.updateDocument(notificationsTable, notification.ID, notification, db.ErrNotificationNotFound)
+}
+
+func (r *postgresRepository) DeleteNotification(notification *db.Notification) error {
+	return r.deleteDocument(notificationsTable, notification.ID, db.ErrNotificationNotFound)
+}
+
+func (r *postgresRepository) ListNotifications() ([]db.Notification, error) {
+	notifications := []db.Notification{}
+	err := r.listDocuments(notificationsTable, func(data []byte) error {
+		var notification db.Notification
+		err := json.Unmarshal(data, &notification)
+		notifications = append(notifications, notification)
+		return err
+	})
+	return notifications, err
+}
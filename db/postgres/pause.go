@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const submissionPausesTable = "submission_pauses"
+
+func (r *postgresRepository) CreateSubmissionPause(pause *db.SubmissionPause) error {
+	pause.CreationTime = time.Now().UTC()
+	return r.insertDocument(submissionPausesTable, pause.Provider, pause, db.ErrSubmissionPauseAlreadyExists)
+}
+
+func (r *postgresRepository) DeleteSubmissionPause(pause *db.SubmissionPause) error {
+	return r.deleteDocument(submissionPausesTable, pause.Provider, db.ErrSubmissionPauseNotFound)
+}
+
+func (r *postgresRepository) ListSubmissionPauses() ([]db.SubmissionPause, error) {
+	pauses := []db.SubmissionPause{}
+	err := r.listDocuments(submissionPausesTable, func(data []byte) error {
+		var pause db.SubmissionPause
+		err := json.Unmarshal(data, &pause)
+		pauses = append(pauses, pause)
+		return err
+	})
+	return pauses, err
+}
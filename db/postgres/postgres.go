@@ -0,0 +1,70 @@
+// Package postgres implements db.Repository using PostgreSQL for
+// persistence.
+//
+// Jobs are stored along with the columns used for looking them up and
+// listing them, and the other types are stored as JSON documents keyed by
+// their names. The schema is created and upgraded by migrations that run
+// when the repository is created.
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+
+	// registers the PostgreSQL driver of database/sql
+	_ "github.com/lib/pq"
+)
+
+// connections are the pools of connections to the databases, keyed by URL.
+// Repositories are created whenever providers are, so they share the pool
+// of their database, and the schema is only migrated when the pool is
+// opened.
+var connections = struct {
+	sync.Mutex
+	pools map[string]*sql.DB
+}{pools: make(map[string]*sql.DB)}
+
+// NewRepository creates a new Repository that uses PostgreSQL for
+// persistence, migrating the schema of the database to the latest version.
+func NewRepository(cfg *config.Config) (db.Repository, error) {
+	if cfg.Postgres == nil || cfg.Postgres.URL == "" {
+		return nil, errors.New("postgres url is required")
+	}
+	conn, err := connect(cfg.Postgres)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresRepository{config: cfg, db: conn}, nil
+}
+
+func connect(cfg *config.Postgres) (*sql.DB, error) {
+	connections.Lock()
+	defer connections.Unlock()
+	if conn, ok := connections.pools[cfg.URL]; ok {
+		return conn, nil
+	}
+	conn, err := sql.Open("postgres", cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	if err = conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err = migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	connections.pools[cfg.URL] = conn
+	return conn, nil
+}
+
+type postgresRepository struct {
+	config *config.Config
+	db     *sql.DB
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"os"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+)
+
+// newTestRepository connects to the database in POSTGRES_TEST_URL, skipping
+// the test when it's not set, and empties all tables.
+func newTestRepository(t *testing.T) *postgresRepository {
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL is not set")
+	}
+	repo, err := NewRepository(&config.Config{Postgres: &config.Postgres{URL: url, MaxOpenConns: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := repo.(*postgresRepository)
+	_, err = r.db.Exec(`TRUNCATE jobs, job_sequences, presetmaps, local_presets, tenants, delivery_targets,
+		ladders, watch_folders, submission_pauses, experiments, experiment_samples, artifacts`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestNewRepositoryRequiresURL(t *testing.T) {
+	_, err := NewRepository(&config.Config{Postgres: &config.Postgres{}})
+	if err == nil || err.Error() != "postgres url is required" {
+		t.Errorf("wrong error returned. Want %q. Got %v", "postgres url is required", err)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	r := newTestRepository(t)
+	err := migrate(r.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var version int
+	err = r.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("wrong schema version. Want %d. Got %d", len(migrations), version)
+	}
+}
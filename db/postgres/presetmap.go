@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const presetMapsTable = "presetmaps"
+
+// CreatePresetMap stores the presetmap, numbering it after the last version
+// kept for presetmaps with the same name.
+func (r *postgresRepository) CreatePresetMap(presetMap *db.PresetMap) error {
+	return r.presetMapTx(presetMap.Name, func(tx *sql.Tx) error {
+		data, err := numberPresetMap(tx, presetMap)
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec(`INSERT INTO presetmaps (name, data) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING`, presetMap.Name, data)
+		return checkAffected(result, err, db.ErrPresetMapAlreadyExists)
+	})
+}
+
+// UpdatePresetMap replaces the presetmap, storing the presetmap it replaces
+// as its last version.
+func (r *postgresRepository) UpdatePresetMap(presetMap *db.PresetMap) error {
+	return r.presetMapTx(presetMap.Name, func(tx *sql.Tx) error {
+		err := addPresetMapVersion(tx, presetMap.Name)
+		if err != nil {
+			return err
+		}
+		data, err := numberPresetMap(tx, presetMap)
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec(`UPDATE presetmaps SET data = $2 WHERE name = $1`, presetMap.Name, data)
+		return checkAffected(result, err, db.ErrPresetMapNotFound)
+	})
+}
+
+// DeletePresetMap deletes the presetmap, storing it as its last version.
+func (r *postgresRepository) DeletePresetMap(presetMap *db.PresetMap) error {
+	return r.presetMapTx(presetMap.Name, func(tx *sql.Tx) error {
+		err := addPresetMapVersion(tx, presetMap.Name)
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec(`DELETE FROM presetmaps WHERE name = $1`, presetMap.Name)
+		return checkAffected(result, err, db.ErrPresetMapNotFound)
+	})
+}
+
+func (r *postgresRepository) GetPresetMap(name string) (*db.PresetMap, error) {
+	presetMap := db.PresetMap{Name: name, ProviderMapping: make(map[string]string)}
+	err := r.getDocument(presetMapsTable, name, &presetMap, db.ErrPresetMapNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &presetMap, nil
+}
+
+func (r *postgresRepository) ListPresetMaps(filter db.PresetMapFilter) ([]db.PresetMap, error) {
+	presetMaps := []db.PresetMap{}
+	err := r.listDocuments(presetMapsTable, func(data []byte) error {
+		presetMap := db.PresetMap{ProviderMapping: make(map[string]string)}
+		err := json.Unmarshal(data, &presetMap)
+		presetMaps = append(presetMaps, presetMap)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filter.Apply(presetMaps), nil
+}
+
+// presetMapTx runs fn in a transaction holding a lock on the versions of the
+// presetmap with the given name, so they're numbered one at a time.
+func (r *postgresRepository) presetMapTx(name string, fn func(*sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('presetmap_versions:' || $1))`, name)
+	if err == nil {
+		err = fn(tx)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// nextPresetMapVersion returns the number following the last version of the
+// presetmap.
+func nextPresetMapVersion(tx *sql.Tx, name string) (int, error) {
+	var version int
+	err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) + 1 FROM presetmap_versions WHERE name = $1`, name).Scan(&version)
+	return version, err
+}
+
+// numberPresetMap sets the version of the presetmap to the number following
+// its last version, returning the encoded presetmap.
+func numberPresetMap(tx *sql.Tx, presetMap *db.PresetMap) ([]byte, error) {
+	var err error
+	presetMap.Version, err = nextPresetMapVersion(tx, presetMap.Name)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(presetMap)
+}
+
+// addPresetMapVersion stores the current presetmap with the given name as
+// its last version.
+func addPresetMapVersion(tx *sql.Tx, name string) error {
+	var data []byte
+	err := tx.QueryRow(`SELECT data FROM presetmaps WHERE name = $1 FOR UPDATE`, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return db.ErrPresetMapNotFound
+	}
+	if err != nil {
+		return err
+	}
+	version := db.PresetMapVersion{ReplacedAt: time.Now().UTC()}
+	if err = json.Unmarshal(data, &version.PresetMap); err != nil {
+		return err
+	}
+	if version.Version, err = nextPresetMapVersion(tx, name); err != nil {
+		return err
+	}
+	if data, err = json.Marshal(version); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO presetmap_versions (name, version, data) VALUES ($1, $2, $3)`, name, version.Version, data)
+	return err
+}
+
+func (r *postgresRepository) ListPresetMapVersions(name string) ([]db.PresetMapVersion, error) {
+	versions := []db.PresetMapVersion{}
+	err := r.queryDocuments(func(data []byte) error {
+		var version db.PresetMapVersion
+		err := json.Unmarshal(data, &version)
+		versions = append(versions, version)
+		return err
+	}, `SELECT data FROM presetmap_versions WHERE name = $1 ORDER BY version`, name)
+	return versions, err
+}
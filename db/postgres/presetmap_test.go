@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestPresetMaps(t *testing.T) {
+	r := newTestRepository(t)
+	presetMap := db.PresetMap{
+		Name:            "mypreset",
+		ProviderMapping: map[string]string{"zencoder": "abc123", "elastictranscoder": "1281742-93939"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	}
+	err := r.CreatePresetMap(&presetMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.CreatePresetMap(&presetMap); err != db.ErrPresetMapAlreadyExists {
+		t.Errorf("wrong error creating duplicate presetmap. Want ErrPresetMapAlreadyExists. Got %#v", err)
+	}
+	presetMap.ProviderMapping["mediaconvert"] = "preset-1"
+	if err = r.UpdatePresetMap(&presetMap); err != nil {
+		t.Fatal(err)
+	}
+	gotPresetMap, err := r.GetPresetMap(presetMap.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotPresetMap, presetMap) {
+		t.Errorf("wrong presetmap. Want %#v. Got %#v", presetMap, *gotPresetMap)
+	}
+	r.CreatePresetMap(&db.PresetMap{Name: "another", ProviderMapping: map[string]string{}})
+	presetMaps, err := r.ListPresetMaps(db.PresetMapFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presetMaps) != 2 || presetMaps[0].Name != "another" || presetMaps[1].Name != "mypreset" {
+		t.Errorf("wrong presetmaps listed: %#v", presetMaps)
+	}
+	if err = r.DeletePresetMap(&presetMap); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.GetPresetMap(presetMap.Name); err != db.ErrPresetMapNotFound {
+		t.Errorf("wrong error for deleted presetmap. Want ErrPresetMapNotFound. Got %#v", err)
+	}
+	if err = r.UpdatePresetMap(&presetMap); err != db.ErrPresetMapNotFound {
+		t.Errorf("wrong error updating deleted presetmap. Want ErrPresetMapNotFound. Got %#v", err)
+	}
+}
+
+func TestPresetMapVersions(t *testing.T) {
+	r := newTestRepository(t)
+	presetMap := db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"zencoder": "v1"}}
+	err := r.CreatePresetMap(&presetMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, presetID := range []string{"v2", "v3"} {
+		presetMap = db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"zencoder": presetID}}
+		if err = r.UpdatePresetMap(&presetMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if presetMap.Version != 3 {
+		t.Errorf("wrong version of the presetmap. Want 3. Got %d", presetMap.Version)
+	}
+	versions, err := r.ListPresetMapVersions("mypreset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("wrong number of versions. Want 2. Got %d", len(versions))
+	}
+	for i, version := range versions {
+		want := db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"zencoder": "v" + strconv.Itoa(i+1)}, Version: i + 1}
+		if version.Version != i+1 || version.ReplacedAt.IsZero() || !reflect.DeepEqual(version.PresetMap, want) {
+			t.Errorf("wrong version %d: %#v", i+1, version)
+		}
+	}
+	if err = r.DeletePresetMap(&presetMap); err != nil {
+		t.Fatal(err)
+	}
+	presetMap = db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"zencoder": "v4"}}
+	if err = r.CreatePresetMap(&presetMap); err != nil {
+		t.Fatal(err)
+	}
+	if presetMap.Version != 4 {
+		t.Errorf("wrong version of the presetmap created again. Want 4. Got %d", presetMap.Version)
+	}
+	versions, err = r.ListPresetMapVersions("mypreset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[2].PresetMap.ProviderMapping["zencoder"] != "v3" {
+		t.Errorf("the deleted presetmap wasn't kept as a version: %#v", versions)
+	}
+}
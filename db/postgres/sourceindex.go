@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func (r *postgresRepository) SaveSourceEncode(encode *db.SourceEncode) error {
+	if encode.Checksum == "" || encode.JobID == "" {
+		return errors.New("checksum and job id are required")
+	}
+	data, err := json.Marshal(encode)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO source_encodes (checksum, job_id, data) VALUES ($1, $2, $3)
+		ON CONFLICT (checksum, job_id) DO UPDATE SET data = excluded.data`, encode.Checksum, encode.JobID, data)
+	return err
+}
+
+func (r *postgresRepository) ListSourceEncodes(checksum string) ([]db.SourceEncode, error) {
+	encodes := []db.SourceEncode{}
+	err := r.queryDocuments(func(data []byte) error {
+		var encode db.SourceEncode
+		err := json.Unmarshal(data, &encode)
+		encodes = append(encodes, encode)
+		return err
+	}, `SELECT data FROM source_encodes WHERE checksum = $1 ORDER BY job_id`, checksum)
+	return encodes, err
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const tenantsTable = "tenants"
+
+func (r *postgresRepository) CreateTenant(tenant *db.Tenant) error {
+	return r.insertDocument(tenantsTable, tenant.Name, tenant, db.ErrTenantAlreadyExists)
+}
+
+func (r *postgresRepository) UpdateTenant(tenant *db.Tenant) error {
+	return r.updateDocument(tenantsTable, tenant.Name, tenant, db.ErrTenantNotFound)
+}
+
+func (r *postgresRepository) DeleteTenant(tenant *db.Tenant) error {
+	return r.deleteDocument(tenantsTable, tenant.Name, db.ErrTenantNotFound)
+}
+
+func (r *postgresRepository) GetTenant(name string) (*db.Tenant, error) {
+	tenant := db.Tenant{Name: name}
+	err := r.getDocument(tenantsTable, name, &tenant, db.ErrTenantNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *postgresRepository) ListTenants() ([]db.Tenant, error) {
+	tenants := []db.Tenant{}
+	err := r.listDocuments(tenantsTable, func(data []byte) error {
+		var tenant db.Tenant
+		err := json.Unmarshal(data, &tenant)
+		tenants = append(tenants, tenant)
+		return err
+	})
+	return tenants, err
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const throughputSettingPrefix = "throughput:"
+
+func (r *postgresRepository) SaveProviderThroughput(throughput *db.ProviderThroughput) error {
+	throughput.UpdateTime = time.Now().UTC()
+	data, err := json.Marshal(throughput)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO `+settingsTable+` (name, data) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data`, throughputSettingPrefix+throughput.Provider, data)
+	return err
+}
+
+func (r *postgresRepository) GetProviderThroughput(providerName string) (*db.ProviderThroughput, error) {
+	var throughput db.ProviderThroughput
+	err := r.getDocument(settingsTable, throughputSettingPrefix+providerName, &throughput, db.ErrProviderThroughputNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &throughput, nil
+}
@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"gopkg.in/redis.v4"
+)
+
+func (r *redisRepository) CreateArtifact(artifact *db.Artifact) error {
+	if artifact.JobID == "" || artifact.Name == "" {
+		return errors.New("job id and name are required")
+	}
+	if artifact.CreationTime.IsZero() {
+		artifact.CreationTime = time.Now().UTC()
+	}
+	fields, err := r.storage.FieldMap(artifact)
+	if err != nil {
+		return err
+	}
+	artifactKey := r.artifactKey(artifact.JobID, artifact.Name)
+	setKey := r.artifactsSetKey(artifact.JobID)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		added, err := tx.SAdd(setKey, artifact.Name).Result()
+		if err != nil {
+			return err
+		}
+		if added == 0 {
+			return db.ErrArtifactAlreadyExists
+		}
+		return tx.HMSet(artifactKey, fields).Err()
+	}, artifactKey)
+}
+
+func (r *redisRepository) ListArtifacts(jobID string) ([]db.Artifact, error) {
+	names, err := r.storage.RedisClient().SMembers(r.artifactsSetKey(jobID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	artifacts := make([]db.Artifact, 0, len(names))
+	for _, name := range names {
+		var artifact db.Artifact
+		err := r.storage.Load(r.artifactKey(jobID, name), &artifact)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
+func (r *redisRepository) artifactKey(jobID, name string) string {
+	return "artifact:" + jobID + ":" + name
+}
+
+func (r *redisRepository) artifactsSetKey(jobID string) string {
+	return "artifacts:" + jobID
+}
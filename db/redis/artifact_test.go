@@ -0,0 +1,94 @@
+package redis
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestCreateArtifact(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact := db.Artifact{
+		JobID:       "job-123",
+		Name:        "ffprobe",
+		Kind:        "ffprobe",
+		Source:      "qc",
+		ContentType: "application/json",
+		Data:        json.RawMessage(`{"format":{"duration":"183.0"}}`),
+	}
+	err = repo.CreateArtifact(&artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	items, err := client.HGetAll("artifact:job-123:ffprobe").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"jobID":        "job-123",
+		"name":         "ffprobe",
+		"kind":         "ffprobe",
+		"source":       "qc",
+		"contentType":  "application/json",
+		"data":         `{"format":{"duration":"183.0"}}`,
+		"creationTime": artifact.CreationTime.Format(time.RFC3339Nano),
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Wrong artifact hash returned from Redis. Want %#v. Got %#v.", expected, items)
+	}
+	err = repo.CreateArtifact(&artifact)
+	if err != db.ErrArtifactAlreadyExists {
+		t.Errorf("Wrong error returned. Want ErrArtifactAlreadyExists. Got %#v.", err)
+	}
+}
+
+func TestListArtifacts(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts := []db.Artifact{
+		{JobID: "job-123", Name: "qc-report", Data: json.RawMessage(`{"passed":true}`)},
+		{JobID: "job-123", Name: "loudness-graph", ContentType: "image/png", URL: "s3://sidecar/job-123/loudness.png"},
+		{JobID: "job-456", Name: "qc-report", Data: json.RawMessage(`{"passed":false}`)},
+	}
+	for i := range artifacts {
+		err = repo.CreateArtifact(&artifacts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	gotArtifacts, err := repo.ListArtifacts("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]db.Artifact, len(gotArtifacts))
+	for _, artifact := range gotArtifacts {
+		got[artifact.Name] = artifact
+	}
+	expected := map[string]db.Artifact{
+		"qc-report":      artifacts[0],
+		"loudness-graph": artifacts[1],
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ListArtifacts: wrong list. Want %#v. Got %#v.", expected, got)
+	}
+}
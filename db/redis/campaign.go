@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+	"gopkg.in/redis.v4"
+)
+
+const campaignsSetKey = "campaigns"
+
+func (r *redisRepository) CreateCampaign(campaign *db.Campaign) error {
+	if _, err := r.GetCampaign(campaign.Name); err == nil {
+		return db.ErrCampaignAlreadyExists
+	}
+	return r.saveCampaign(campaign)
+}
+
+func (r *redisRepository) UpdateCampaign(campaign *db.Campaign) error {
+	if _, err := r.GetCampaign(campaign.Name); err == db.ErrCampaignNotFound {
+		return err
+	}
+	return r.saveCampaign(campaign)
+}
+
+func (r *redisRepository) saveCampaign(campaign *db.Campaign) error {
+	fields, err := r.storage.FieldMap(campaign)
+	if err != nil {
+		return err
+	}
+	campaignKey := r.campaignKey(campaign.Name)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		err := tx.HMSet(campaignKey, fields).Err()
+		if err != nil {
+			return err
+		}
+		return tx.SAdd(campaignsSetKey, campaign.Name).Err()
+	}, campaignKey)
+}
+
+func (r *redisRepository) DeleteCampaign(campaign *db.Campaign) error {
+	err := r.storage.Delete(r.campaignKey(campaign.Name))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return db.ErrCampaignNotFound
+		}
+		return err
+	}
+	r.storage.RedisClient().SRem(campaignsSetKey, campaign.Name)
+	return nil
+}
+
+func (r *redisRepository) GetCampaign(name string) (*db.Campaign, error) {
+	campaign := db.Campaign{Name: name}
+	err := r.storage.Load(r.campaignKey(name), &campaign)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrCampaignNotFound
+	}
+	return &campaign, err
+}
+
+func (r *redisRepository) ListCampaigns() ([]db.Campaign, error) {
+	names, err := r.storage.RedisClient().SMembers(campaignsSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	campaigns := make([]db.Campaign, 0, len(names))
+	for _, name := range names {
+		campaign, err := r.GetCampaign(name)
+		if err != nil && err != db.ErrCampaignNotFound {
+			return nil, err
+		}
+		if campaign != nil {
+			campaigns = append(campaigns, *campaign)
+		}
+	}
+	return campaigns, nil
+}
+
+func (r *redisRepository) campaignKey(name string) string {
+	return "campaign:" + name
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestCampaign(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	campaign := db.Campaign{
+		Name:           "av1",
+		Filter:         db.CampaignFilter{Preset: "h264_720p", Tenant: "news", Limit: 100},
+		Presets:        map[string]string{"h264_720p": "av1_720p"},
+		JobsPerHour:    60,
+		Status:         db.CampaignStatusRunning,
+		Jobs:           []db.CampaignJob{{SourceJobID: "job-1"}, {SourceJobID: "job-2"}},
+		Progress:       db.CampaignProgress{Total: 2, EstimatedCost: 1.5},
+		CreationTime:   time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC),
+		LastSubmission: time.Date(2017, 3, 1, 11, 0, 0, 0, time.UTC),
+	}
+	err = repo.CreateCampaign(&campaign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.CreateCampaign(&campaign)
+	if err != db.ErrCampaignAlreadyExists {
+		t.Errorf("Wrong error returned. Want ErrCampaignAlreadyExists. Got %#v", err)
+	}
+	campaign.Jobs[0] = db.CampaignJob{SourceJobID: "job-1", JobID: "job-3", Status: "finished", Cost: 0.75}
+	campaign.Progress = db.CampaignProgress{Total: 2, Submitted: 1, Finished: 1, Cost: 0.75, EstimatedCost: 1.5}
+	err = repo.UpdateCampaign(&campaign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetCampaign("av1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, campaign) {
+		t.Errorf("Wrong campaign.\nWant %#v\nGot  %#v", campaign, *got)
+	}
+	campaigns, err := repo.ListCampaigns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(campaigns) != 1 {
+		t.Errorf("Wrong number of campaigns. Want 1. Got %d", len(campaigns))
+	}
+	err = repo.DeleteCampaign(&campaign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.GetCampaign("av1")
+	if err != db.ErrCampaignNotFound {
+		t.Errorf("Wrong error returned. Want ErrCampaignNotFound. Got %#v", err)
+	}
+	err = repo.UpdateCampaign(&campaign)
+	if err != db.ErrCampaignNotFound {
+		t.Errorf("Wrong error returned. Want ErrCampaignNotFound. Got %#v", err)
+	}
+}
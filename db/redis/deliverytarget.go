@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+	"gopkg.in/redis.v4"
+)
+
+const deliveryTargetsSetKey = "deliverytargets"
+
+func (r *redisRepository) CreateDeliveryTarget(target *db.DeliveryTarget) error {
+	if _, err := r.GetDeliveryTarget(target.Name); err == nil {
+		return db.ErrDeliveryTargetAlreadyExists
+	}
+	return r.saveDeliveryTarget(target)
+}
+
+func (r *redisRepository) UpdateDeliveryTarget(target *db.DeliveryTarget) error {
+	if _, err := r.GetDeliveryTarget(target.Name); err == db.ErrDeliveryTargetNotFound {
+		return err
+	}
+	return r.saveDeliveryTarget(target)
+}
+
+func (r *redisRepository) saveDeliveryTarget(target *db.DeliveryTarget) error {
+	fields, err := r.storage.FieldMap(target)
+	if err != nil {
+		return err
+	}
+	targetKey := r.deliveryTargetKey(target.Name)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		err := tx.HMSet(targetKey, fields).Err()
+		if err != nil {
+			return err
+		}
+		return tx.SAdd(deliveryTargetsSetKey, target.Name).Err()
+	}, targetKey)
+}
+
+func (r *redisRepository) DeleteDeliveryTarget(target *db.DeliveryTarget) error {
+	err := r.storage.Delete(r.deliveryTargetKey(target.Name))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return db.ErrDeliveryTargetNotFound
+		}
+		return err
+	}
+	r.storage.RedisClient().SRem(deliveryTargetsSetKey, target.Name)
+	return nil
+}
+
+func (r *redisRepository) GetDeliveryTarget(name string) (*db.DeliveryTarget, error) {
+	target := db.DeliveryTarget{Name: name}
+	err := r.storage.Load(r.deliveryTargetKey(name), &target)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrDeliveryTargetNotFound
+	}
+	return &target, err
+}
+
+func (r *redisRepository) ListDeliveryTargets() ([]db.DeliveryTarget, error) {
+	names, err := r.storage.RedisClient().SMembers(deliveryTargetsSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]db.DeliveryTarget, 0, len(names))
+	for _, name := range names {
+		target, err := r.GetDeliveryTarget(name)
+		if err != nil && err != db.ErrDeliveryTargetNotFound {
+			return nil, err
+		}
+		if target != nil {
+			targets = append(targets, *target)
+		}
+	}
+	return targets, nil
+}
+
+func (r *redisRepository) deliveryTargetKey(name string) string {
+	return "deliverytarget:" + name
+}
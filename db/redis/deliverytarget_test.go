@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestCreateDeliveryTarget(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := db.DeliveryTarget{
+		Name: "vod",
+		Origins: map[string]db.DeliveryOrigin{
+			"production": {Destination: "s3://vod-origin/videos/", CDNBaseURL: "https://vod.example.com/videos/"},
+		},
+	}
+	err = repo.CreateDeliveryTarget(&target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	items, err := client.HGetAll("deliverytarget:vod").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedItems := map[string]string{
+		"origins": `{"production":{"destination":"s3://vod-origin/videos/","cdnBaseURL":"https://vod.example.com/videos/"}}`,
+	}
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("Wrong delivery target hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
+	}
+	err = repo.CreateDeliveryTarget(&target)
+	if err != db.ErrDeliveryTargetAlreadyExists {
+		t.Errorf("Wrong error returned. Want ErrDeliveryTargetAlreadyExists. Got %#v", err)
+	}
+}
+
+func TestUpdateDeliveryTarget(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := db.DeliveryTarget{
+		Name:    "vod",
+		Origins: map[string]db.DeliveryOrigin{"production": {Destination: "s3://vod-origin/videos/"}},
+	}
+	err = repo.UpdateDeliveryTarget(&target)
+	if err != db.ErrDeliveryTargetNotFound {
+		t.Errorf("Wrong error returned. Want ErrDeliveryTargetNotFound. Got %#v", err)
+	}
+	err = repo.CreateDeliveryTarget(&target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.Origins["staging"] = db.DeliveryOrigin{Destination: "s3://vod-origin-staging/videos/"}
+	err = repo.UpdateDeliveryTarget(&target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetDeliveryTarget("vod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, target) {
+		t.Errorf("Wrong delivery target. Want %#v. Got %#v", target, *got)
+	}
+}
+
+func TestDeleteDeliveryTarget(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := db.DeliveryTarget{
+		Name:    "vod",
+		Origins: map[string]db.DeliveryOrigin{"production": {Destination: "s3://vod-origin/videos/"}},
+	}
+	err = repo.CreateDeliveryTarget(&target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteDeliveryTarget(&target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, err := repo.ListDeliveryTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 0 {
+		t.Errorf("DeleteDeliveryTarget: target still listed: %#v", targets)
+	}
+	err = repo.DeleteDeliveryTarget(&target)
+	if err != db.ErrDeliveryTargetNotFound {
+		t.Errorf("Wrong error returned. Want ErrDeliveryTargetNotFound. Got %#v", err)
+	}
+}
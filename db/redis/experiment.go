@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+	"gopkg.in/redis.v4"
+)
+
+const experimentsSetKey = "experiments"
+
+func (r *redisRepository) CreateExperiment(experiment *db.Experiment) error {
+	if experiment.Name == "" {
+		return errors.New("experiment name is required")
+	}
+	if experiment.CreationTime.IsZero() {
+		experiment.CreationTime = time.Now().UTC()
+	}
+	fields, err := r.storage.FieldMap(experiment)
+	if err != nil {
+		return err
+	}
+	experimentKey := r.experimentKey(experiment.Name)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		added, err := tx.SAdd(experimentsSetKey, experiment.Name).Result()
+		if err != nil {
+			return err
+		}
+		if added == 0 {
+			return db.ErrExperimentAlreadyExists
+		}
+		return tx.HMSet(experimentKey, fields).Err()
+	}, experimentKey)
+}
+
+func (r *redisRepository) DeleteExperiment(experiment *db.Experiment) error {
+	err := r.storage.Delete(r.experimentKey(experiment.Name))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return db.ErrExperimentNotFound
+		}
+		return err
+	}
+	client := r.storage.RedisClient()
+	client.SRem(experimentsSetKey, experiment.Name)
+	jobIDs, err := client.SMembers(r.experimentSamplesSetKey(experiment.Name)).Result()
+	if err != nil {
+		return err
+	}
+	keys := []string{r.experimentSamplesSetKey(experiment.Name)}
+	for _, jobID := range jobIDs {
+		keys = append(keys, r.experimentSampleKey(experiment.Name, jobID))
+	}
+	return client.Del(keys...).Err()
+}
+
+func (r *redisRepository) GetExperiment(name string) (*db.Experiment, error) {
+	experiment := db.Experiment{Name: name}
+	err := r.storage.Load(r.experimentKey(name), &experiment)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrExperimentNotFound
+	}
+	return &experiment, err
+}
+
+func (r *redisRepository) ListExperiments() ([]db.Experiment, error) {
+	names, err := r.storage.RedisClient().SMembers(experimentsSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	experiments := make([]db.Experiment, 0, len(names))
+	for _, name := range names {
+		experiment, err := r.GetExperiment(name)
+		if err != nil && err != db.ErrExperimentNotFound {
+			return nil, err
+		}
+		if experiment != nil {
+			experiments = append(experiments, *experiment)
+		}
+	}
+	return experiments, nil
+}
+
+func (r *redisRepository) SaveExperimentSample(sample *db.ExperimentSample) error {
+	if sample.Experiment == "" || sample.JobID == "" {
+		return errors.New("experiment and job id are required")
+	}
+	if sample.CreationTime.IsZero() {
+		sample.CreationTime = time.Now().UTC()
+	}
+	fields, err := r.storage.FieldMap(sample)
+	if err != nil {
+		return err
+	}
+	sampleKey := r.experimentSampleKey(sample.Experiment, sample.JobID)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		err := tx.HMSet(sampleKey, fields).Err()
+		if err != nil {
+			return err
+		}
+		return tx.SAdd(r.experimentSamplesSetKey(sample.Experiment), sample.JobID).Err()
+	}, sampleKey)
+}
+
+func (r *redisRepository) ListExperimentSamples(experiment string) ([]db.ExperimentSample, error) {
+	jobIDs, err := r.storage.RedisClient().SMembers(r.experimentSamplesSetKey(experiment)).Result()
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]db.ExperimentSample, 0, len(jobIDs))
+	for _, jobID := range jobIDs {
+		var sample db.ExperimentSample
+		err := r.storage.Load(r.experimentSampleKey(experiment, jobID), &sample)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+func (r *redisRepository) experimentKey(name string) string {
+	return "experiment:" + name
+}
+
+func (r *redisRepository) experimentSampleKey(experiment, jobID string) string {
+	return "experiment-sample:" + experiment + ":" + jobID
+}
+
+func (r *redisRepository) experimentSamplesSetKey(experiment string) string {
+	return "experiment-samples:" + experiment
+}
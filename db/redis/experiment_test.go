@@ -0,0 +1,171 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestCreateExperiment(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	experiment := db.Experiment{
+		Name:          "x264-vs-x265",
+		SamplePercent: 10,
+		Variants: []db.ExperimentVariant{
+			{Name: "control", Ladder: []string{"720p_h264"}},
+			{Name: "hevc", Provider: "zencoder", Ladder: []string{"720p_hevc"}},
+		},
+	}
+	err = repo.CreateExperiment(&experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	items, err := client.HGetAll("experiment:x264-vs-x265").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"samplePercent": "10",
+		"variants":      `[{"name":"control","ladder":["720p_h264"]},{"name":"hevc","provider":"zencoder","ladder":["720p_hevc"]}]`,
+		"creationTime":  experiment.CreationTime.Format(time.RFC3339Nano),
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Wrong experiment hash returned from Redis. Want %#v. Got %#v.", expected, items)
+	}
+	err = repo.CreateExperiment(&experiment)
+	if err != db.ErrExperimentAlreadyExists {
+		t.Errorf("Wrong error returned. Want ErrExperimentAlreadyExists. Got %#v.", err)
+	}
+}
+
+func TestGetExperiment(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	experiment := db.Experiment{
+		Name:          "x264-vs-x265",
+		Description:   "compares HEVC and H.264",
+		SamplePercent: 50,
+		Variants: []db.ExperimentVariant{
+			{Name: "control"},
+			{Name: "hevc", Ladder: []string{"720p_hevc"}},
+		},
+	}
+	err = repo.CreateExperiment(&experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetExperiment(experiment.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, experiment) {
+		t.Errorf("GetExperiment: wrong experiment. Want %#v. Got %#v.", experiment, *got)
+	}
+	_, err = repo.GetExperiment("unknown")
+	if err != db.ErrExperimentNotFound {
+		t.Errorf("GetExperiment: wrong error. Want ErrExperimentNotFound. Got %#v.", err)
+	}
+}
+
+func TestDeleteExperiment(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	experiment := db.Experiment{Name: "x264-vs-x265", Variants: []db.ExperimentVariant{{Name: "control"}}}
+	err = repo.CreateExperiment(&experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.SaveExperimentSample(&db.ExperimentSample{Experiment: experiment.Name, JobID: "job-123", Variant: "control"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteExperiment(&experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	experiments, err := repo.ListExperiments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(experiments) != 0 {
+		t.Errorf("DeleteExperiment: experiment still listed: %#v", experiments)
+	}
+	samples, err := repo.ListExperimentSamples(experiment.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 0 {
+		t.Errorf("DeleteExperiment: samples not deleted: %#v", samples)
+	}
+	err = repo.DeleteExperiment(&experiment)
+	if err != db.ErrExperimentNotFound {
+		t.Errorf("DeleteExperiment: wrong error. Want ErrExperimentNotFound. Got %#v.", err)
+	}
+}
+
+func TestListExperimentSamples(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := []db.ExperimentSample{
+		{Experiment: "exp1", JobID: "job-1", Variant: "control", Metrics: db.ExperimentMetrics{QCScore: 93.5, OutputSize: 1 << 20, EncodeDuration: 42}},
+		{Experiment: "exp1", JobID: "job-2", Variant: "hevc", Metrics: db.ExperimentMetrics{QCScore: 94.1, OutputSize: 1 << 19, EncodeDuration: 90}},
+		{Experiment: "exp2", JobID: "job-3", Variant: "control"},
+	}
+	for i := range samples {
+		err = repo.SaveExperimentSample(&samples[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	samples[1].Metrics.QCScore = 95
+	err = repo.SaveExperimentSample(&samples[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotSamples, err := repo.ListExperimentSamples("exp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]db.ExperimentSample, len(gotSamples))
+	for _, sample := range gotSamples {
+		got[sample.JobID] = sample
+	}
+	expected := map[string]db.ExperimentSample{
+		"job-1": samples[0],
+		"job-2": samples[1],
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ListExperimentSamples: wrong list. Want %#v. Got %#v.", expected, got)
+	}
+}
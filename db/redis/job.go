@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
 	"time"
@@ -10,39 +11,302 @@ import (
 	"gopkg.in/redis.v4"
 )
 
-const jobsSetKey = "jobs"
+const (
+	jobsSetKey = "jobs"
+
+	// expiringJobsKey is the sorted set of the jobs set to expire, scored
+	// by their expiration time, and expiringJobIndexesKey is the hash of
+	// the index keys that each of them was added to, so their entries can
+	// be swept once they expire.
+	expiringJobsKey       = "jobs:expiring"
+	expiringJobIndexesKey = "jobs:expiring:indexes"
+)
+
+// terminalStatuses are the statuses of jobs that expire (the terminal
+// statuses of provider.Status).
+var terminalStatuses = map[string]bool{
+	"finished":               true,
+	"finished-with-warnings": true,
+	"failed":                 true,
+	"canceled":               true,
+}
 
 func (r *redisRepository) CreateJob(job *db.Job) error {
 	if job.ID == "" {
 		return errors.New("job id is required")
 	}
 	job.CreationTime = time.Now().UTC()
-	return r.saveJob(job)
+	return r.saveJob(job, nil)
 }
 
-func (r *redisRepository) saveJob(job *db.Job) error {
-	fields, err := r.storage.FieldMap(job)
+func (r *redisRepository) UpdateJob(job *db.Job) error {
+	current, err := r.GetJob(job.ID)
+	if err == db.ErrJobNotFound {
+		return err
+	}
+	return r.saveJob(job, current)
+}
+
+func (r *redisRepository) UpdateJobIfStatus(job *db.Job, status string) error {
+	current, err := r.GetJob(job.ID)
+	if err != nil {
+		return err
+	}
+	if current.Status != status {
+		return db.ErrJobStatusChanged
+	}
+	write, err := r.jobWriter(job, current)
 	if err != nil {
 		return err
 	}
 	jobKey := r.jobKey(job.ID)
+	err = r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		stored, err := tx.HGet(jobKey, "status").Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if stored != status {
+			return db.ErrJobStatusChanged
+		}
+		_, err = tx.MultiExec(func() error {
+			return write(tx)
+		})
+		return err
+	}, jobKey)
+	if err == redis.TxFailedErr {
+		return db.ErrJobStatusChanged
+	}
+	return err
+}
+
+// saveJob stores the job and its entries in the indexes of jobs. Jobs are
+// indexed by status and provider in sorted sets scored by creation time, and
+// the entries of the previous version of the job are removed from the
+// indexes whenever its status or provider change. When a TTL is configured,
+// jobs in a terminal status expire, and their index entries are left for
+// SweepExpiredJobs.
+func (r *redisRepository) saveJob(job, previous *db.Job) error {
+	write, err := r.jobWriter(job, previous)
+	if err != nil {
+		return err
+	}
 	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
-		err := tx.HMSet(jobKey, fields).Err()
+		_, err := tx.MultiExec(func() error {
+			return write(tx)
+		})
+		return err
+	}, r.jobKey(job.ID))
+}
+
+// jobWriter returns the function that writes the job and its index entries
+// in a transaction, as described in saveJob. The hash of the job is
+// rewritten rather than updated, so fields that were cleared (and omitted
+// from the hash) don't keep their previous value.
+func (r *redisRepository) jobWriter(job, previous *db.Job) (func(*redis.Tx) error, error) {
+	fields, err := r.storage.FieldMap(job)
+	if err != nil {
+		return nil, err
+	}
+	jobKey := r.jobKey(job.ID)
+	ttl := r.jobTTL()
+	expires := ttl > 0 && terminalStatuses[job.Status]
+	var providerJobTTL time.Duration
+	if expires {
+		providerJobTTL = ttl
+	}
+	return func(tx *redis.Tx) error {
+		err := tx.Del(jobKey).Err()
 		if err != nil {
 			return err
 		}
-		return tx.ZAddNX(jobsSetKey, redis.Z{Member: job.ID, Score: float64(job.CreationTime.UnixNano())}).Err()
-	}, jobKey)
+		err = tx.HMSet(jobKey, fields).Err()
+		if err != nil {
+			return err
+		}
+		member := redis.Z{Member: job.ID, Score: float64(job.CreationTime.UnixNano())}
+		if job.ExternalID != "" {
+			err = tx.ZAddNX(r.externalIDKey(job.Tenant, job.ExternalID), member).Err()
+			if err != nil {
+				return err
+			}
+		}
+		if job.ProviderJobID != "" {
+			err = tx.Set(r.providerJobKey(job.ProviderName, job.ProviderJobID), job.ID, providerJobTTL).Err()
+			if err != nil {
+				return err
+			}
+		}
+		if expires {
+			err = r.expireJob(tx, job, ttl)
+		} else if ttl > 0 {
+			err = r.persistJob(tx, job.ID)
+		}
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			if previous.Status != "" && previous.Status != job.Status {
+				err = tx.ZRem(r.jobStatusKey(previous.Status), job.ID).Err()
+				if err != nil {
+					return err
+				}
+			}
+			if previous.ProviderName != "" && previous.ProviderName != job.ProviderName {
+				err = tx.ZRem(r.jobProviderKey(previous.ProviderName), job.ID).Err()
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if job.Status != "" {
+			err = tx.ZAddNX(r.jobStatusKey(job.Status), member).Err()
+			if err != nil {
+				return err
+			}
+		}
+		if job.ProviderName != "" {
+			err = tx.ZAddNX(r.jobProviderKey(job.ProviderName), member).Err()
+			if err != nil {
+				return err
+			}
+		}
+		return tx.ZAddNX(jobsSetKey, member).Err()
+	}, nil
+}
+
+// jobTTL returns the time jobs are kept after reaching a terminal status,
+// zero meaning forever.
+func (r *redisRepository) jobTTL() time.Duration {
+	if r.config.JobExpiration == nil {
+		return 0
+	}
+	return r.config.JobExpiration.TTL
+}
+
+// expireJob sets the expiration of the job, recording it along with the
+// index keys of the job for sweeping them later.
+func (r *redisRepository) expireJob(tx *redis.Tx, job *db.Job, ttl time.Duration) error {
+	indexes := []string{jobsSetKey}
+	if job.ExternalID != "" {
+		indexes = append(indexes, r.externalIDKey(job.Tenant, job.ExternalID))
+	}
+	if job.Status != "" {
+		indexes = append(indexes, r.jobStatusKey(job.Status))
+	}
+	if job.ProviderName != "" {
+		indexes = append(indexes, r.jobProviderKey(job.ProviderName))
+	}
+	data, err := json.Marshal(indexes)
+	if err != nil {
+		return err
+	}
+	err = tx.Expire(r.jobKey(job.ID), ttl).Err()
+	if err != nil {
+		return err
+	}
+	err = tx.HSet(expiringJobIndexesKey, job.ID, string(data)).Err()
+	if err != nil {
+		return err
+	}
+	expiration := redis.Z{Member: job.ID, Score: float64(time.Now().Add(ttl).UnixNano())}
+	return tx.ZAdd(expiringJobsKey, expiration).Err()
+}
+
+// persistJob cancels the expiration of the job, as in jobs that leave a
+// terminal status when they're submitted again.
+func (r *redisRepository) persistJob(tx *redis.Tx, id string) error {
+	err := tx.Persist(r.jobKey(id)).Err()
+	if err != nil {
+		return err
+	}
+	err = tx.ZRem(expiringJobsKey, id).Err()
+	if err != nil {
+		return err
+	}
+	return tx.HDel(expiringJobIndexesKey, id).Err()
+}
+
+// SweepExpiredJobs removes the entries of the jobs that expired from the
+// indexes of jobs.
+func (r *redisRepository) SweepExpiredJobs() (int, error) {
+	client := r.storage.RedisClient()
+	ids, err := client.ZRangeByScore(expiringJobsKey, redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().UnixNano(), 10),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	var swept int
+	for _, id := range ids {
+		exists, err := client.Exists(r.jobKey(id)).Result()
+		if err != nil {
+			return swept, err
+		}
+		if exists {
+			// not expired yet, as in jobs updated after the range query
+			continue
+		}
+		data, err := client.HGet(expiringJobIndexesKey, id).Result()
+		if err != nil && err != redis.Nil {
+			return swept, err
+		}
+		var indexes []string
+		if data != "" {
+			if err = json.Unmarshal([]byte(data), &indexes); err != nil {
+				return swept, err
+			}
+		}
+		for _, index := range indexes {
+			if err = client.ZRem(index, id).Err(); err != nil {
+				return swept, err
+			}
+		}
+		if err = client.HDel(expiringJobIndexesKey, id).Err(); err != nil {
+			return swept, err
+		}
+		if err = client.ZRem(expiringJobsKey, id).Err(); err != nil {
+			return swept, err
+		}
+		swept++
+	}
+	return swept, nil
+}
+
+func (r *redisRepository) NextJobSequence(tenant string) (uint64, error) {
+	n, err := r.storage.RedisClient().Incr(r.jobSequenceKey(tenant)).Result()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
 }
 
 func (r *redisRepository) DeleteJob(job *db.Job) error {
-	err := r.storage.Delete(r.jobKey(job.ID))
+	current, err := r.GetJob(job.ID)
+	if err != nil {
+		return err
+	}
+	err = r.storage.Delete(r.jobKey(job.ID))
 	if err != nil {
 		if err == storage.ErrNotFound {
 			return db.ErrJobNotFound
 		}
 		return err
 	}
+	if current.ExternalID != "" {
+		r.storage.RedisClient().ZRem(r.externalIDKey(current.Tenant, current.ExternalID), job.ID)
+	}
+	if current.ProviderJobID != "" {
+		r.storage.RedisClient().Del(r.providerJobKey(current.ProviderName, current.ProviderJobID))
+	}
+	if current.Status != "" {
+		r.storage.RedisClient().ZRem(r.jobStatusKey(current.Status), job.ID)
+	}
+	if current.ProviderName != "" {
+		r.storage.RedisClient().ZRem(r.jobProviderKey(current.ProviderName), job.ID)
+	}
+	r.storage.RedisClient().ZRem(expiringJobsKey, job.ID)
+	r.storage.RedisClient().HDel(expiringJobIndexesKey, job.ID)
 	return r.storage.RedisClient().ZRem(jobsSetKey, job.ID).Err()
 }
 
@@ -55,17 +319,67 @@ func (r *redisRepository) GetJob(id string) (*db.Job, error) {
 	return &job, err
 }
 
+// ListJobs lists the jobs from the narrowest index matching the filter: the
+// index of the status, then the index of the provider, then the index of all
+// jobs. When the filter has both a status and a provider, jobs of other
+// providers are skipped after loading them, so pagination can't be done in
+// the range query.
 func (r *redisRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
-	now := time.Now().UTC()
+	key := jobsSetKey
+	filterProvider := false
+	if filter.Status != "" {
+		key = r.jobStatusKey(filter.Status)
+		filterProvider = filter.ProviderName != ""
+	} else if filter.ProviderName != "" {
+		key = r.jobProviderKey(filter.ProviderName)
+	}
 	rangeOpts := redis.ZRangeBy{
 		Min:   strconv.FormatInt(filter.Since.UnixNano(), 10),
-		Max:   strconv.FormatInt(now.UnixNano(), 10),
-		Count: int64(filter.Limit),
+		Max:   strconv.FormatInt(time.Now().UTC().UnixNano(), 10),
+		Count: -1,
 	}
-	if rangeOpts.Count == 0 {
-		rangeOpts.Count = -1
+	if !filter.Until.IsZero() {
+		rangeOpts.Max = "(" + strconv.FormatInt(filter.Until.UnixNano(), 10)
+	}
+	if !filterProvider {
+		rangeOpts.Offset = int64(filter.Offset)
+		if filter.Limit > 0 {
+			rangeOpts.Count = int64(filter.Limit)
+		}
 	}
-	jobIDs, err := r.storage.RedisClient().ZRangeByScore(jobsSetKey, rangeOpts).Result()
+	jobIDs, err := r.storage.RedisClient().ZRangeByScore(key, rangeOpts).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]db.Job, 0, len(jobIDs))
+	var skipped uint
+	for _, id := range jobIDs {
+		job, err := r.GetJob(id)
+		if err != nil && err != db.ErrJobNotFound {
+			return nil, err
+		}
+		if job == nil {
+			continue
+		}
+		if filterProvider {
+			if job.ProviderName != filter.ProviderName {
+				continue
+			}
+			if skipped < filter.Offset {
+				skipped++
+				continue
+			}
+			if filter.Limit > 0 && uint(len(jobs)) == filter.Limit {
+				break
+			}
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+func (r *redisRepository) ListJobsByExternalID(tenant, externalID string) ([]db.Job, error) {
+	jobIDs, err := r.storage.RedisClient().ZRange(r.externalIDKey(tenant, externalID), 0, -1).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +396,47 @@ func (r *redisRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
 	return jobs, nil
 }
 
+// GetJobByProviderJobID looks up the job in the index of provider job ids.
+// Jobs resubmitted to another provider (as in fallbacks) leave stale
+// entries in the index, so the job must still match the provider job.
+func (r *redisRepository) GetJobByProviderJobID(providerName, providerJobID string) (*db.Job, error) {
+	id, err := r.storage.RedisClient().Get(r.providerJobKey(providerName, providerJobID)).Result()
+	if err == redis.Nil {
+		return nil, db.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	job, err := r.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.ProviderName != providerName || job.ProviderJobID != providerJobID {
+		return nil, db.ErrJobNotFound
+	}
+	return job, nil
+}
+
 func (r *redisRepository) jobKey(id string) string {
 	return "job:" + id
 }
+
+func (r *redisRepository) jobStatusKey(status string) string {
+	return "jobs:status:" + status
+}
+
+func (r *redisRepository) jobProviderKey(providerName string) string {
+	return "jobs:provider:" + providerName
+}
+
+func (r *redisRepository) jobSequenceKey(tenant string) string {
+	return "jobsequence:" + tenant
+}
+
+func (r *redisRepository) providerJobKey(providerName, providerJobID string) string {
+	return "providerjob:" + providerName + ":" + providerJobID
+}
+
+func (r *redisRepository) externalIDKey(tenant, externalID string) string {
+	return "externalid:" + tenant + ":" + externalID
+}
@@ -47,11 +47,16 @@ func TestCreateJob(t *testing.T) {
 		t.Fatal(err)
 	}
 	expected := map[string]string{
-		"providerName":                    "encoding.com",
-		"providerJobID":                   "",
-		"streamingparams_segmentDuration": "10",
-		"streamingparams_protocol":        "hls",
-		"creationTime":                    creationTime.Format(time.RFC3339Nano),
+		"providerName":                       "encoding.com",
+		"providerJobID":                      "",
+		"streamingparams_segmentDuration":    "10",
+		"streamingparams_protocol":           "hls",
+		"streamingparams_minBufferTime":      "0",
+		"streamingparams_audioOnlyRendition": "false",
+		"streamingparams_audioOnlyBitrate":   "0",
+		"fingerprint":                        "false",
+		"creationTime":                       creationTime.Format(time.RFC3339Nano),
+		"statusSnapshotTime":                 time.Time{}.Format(time.RFC3339Nano),
 	}
 	if !reflect.DeepEqual(items, expected) {
 		t.Errorf("Wrong job hash returned from Redis. Want %#v. Got %#v.", expected, items)
@@ -114,6 +119,129 @@ func TestCreateJobNoID(t *testing.T) {
 	}
 }
 
+func TestUpdateJob(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{
+		ID:              "myjob",
+		ProviderName:    "zencoder",
+		ProviderJobID:   "123",
+		SourceMedia:     "s3://newsroom-bucket/master.mov",
+		FallbackSources: []string{"s3://newsroom-bucket/proxy.mp4"},
+	}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.ProviderJobID = "456"
+	job.SourceMedia = "s3://newsroom-bucket/proxy.mp4"
+	job.FailedSources = []string{"s3://newsroom-bucket/master.mov"}
+	err = repo.UpdateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJob, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotJob, job) {
+		t.Errorf("Wrong job. Want %#v. Got %#v.", job, *gotJob)
+	}
+}
+
+func TestUpdateJobClearsFields(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{
+		ID:            "myjob",
+		ProviderName:  "zencoder",
+		Status:        "queued-locally",
+		StatusMessage: "queued, retry after 30s",
+	}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Status = "queued"
+	job.StatusMessage = ""
+	job.ProviderJobID = "123"
+	err = repo.UpdateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJob, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotJob, job) {
+		t.Errorf("Wrong job. Want %#v. Got %#v.", job, *gotJob)
+	}
+}
+
+func TestUpdateJobNotFound(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.UpdateJob(&db.Job{ID: "myjob"})
+	if err != db.ErrJobNotFound {
+		t.Errorf("Wrong error returned by UpdateJob. Want ErrJobNotFound. Got %#v.", err)
+	}
+}
+
+func TestUpdateJobIfStatus(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{ID: "myjob", ProviderName: "zencoder", Status: "queued-locally"}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed := job
+	claimed.Status = "submitting"
+	err = repo.UpdateJobIfStatus(&claimed, "queued-locally")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.UpdateJobIfStatus(&claimed, "queued-locally")
+	if err != db.ErrJobStatusChanged {
+		t.Errorf("Wrong error returned by UpdateJobIfStatus. Want ErrJobStatusChanged. Got %#v.", err)
+	}
+	jobs, err := repo.ListJobs(db.JobFilter{Status: "submitting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("Wrong jobs with the new status: %#v.", jobs)
+	}
+	err = repo.UpdateJobIfStatus(&db.Job{ID: "unknown"}, "")
+	if err != db.ErrJobNotFound {
+		t.Errorf("Wrong error returned by UpdateJobIfStatus. Want ErrJobNotFound. Got %#v.", err)
+	}
+}
+
 func TestDeleteJob(t *testing.T) {
 	err := cleanRedis()
 	if err != nil {
@@ -158,6 +286,126 @@ func TestDeleteJobNotFound(t *testing.T) {
 	}
 }
 
+func TestListJobsByExternalID(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []db.Job{
+		{ID: "job-1", ProviderName: "fake", Tenant: "nyt", ExternalID: "asset-1"},
+		{ID: "job-2", ProviderName: "fake", Tenant: "nyt", ExternalID: "asset-2"},
+		{ID: "job-3", ProviderName: "fake", Tenant: "other", ExternalID: "asset-1"},
+		{ID: "job-4", ProviderName: "fake", Tenant: "nyt", ExternalID: "asset-1"},
+	}
+	for i := range jobs {
+		err = repo.CreateJob(&jobs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = repo.DeleteJob(&db.Job{ID: "job-4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.ListJobsByExternalID("nyt", "asset-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "job-1" {
+		t.Errorf("Wrong jobs returned. Want [job-1]. Got %#v", got)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	if members := client.ZRange("externalid:nyt:asset-1", 0, -1).Val(); !reflect.DeepEqual(members, []string{"job-1"}) {
+		t.Errorf("Deleted job not removed from the index. Got %#v", members)
+	}
+}
+
+func TestGetJobByProviderJobID(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []db.Job{
+		{ID: "job-1", ProviderName: "zencoder", ProviderJobID: "123"},
+		{ID: "job-2", ProviderName: "mediaconvert", ProviderJobID: "123"},
+		{ID: "job-3", ProviderName: "zencoder", ProviderJobID: "456"},
+	}
+	for i := range jobs {
+		err = repo.CreateJob(&jobs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs[2].ProviderName = "mediaconvert"
+	jobs[2].ProviderJobID = "789"
+	err = repo.UpdateJob(&jobs[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		providerName  string
+		providerJobID string
+		wantJobID     string
+	}{
+		{"zencoder", "123", "job-1"},
+		{"mediaconvert", "123", "job-2"},
+		{"mediaconvert", "789", "job-3"},
+		{"zencoder", "456", ""},
+		{"zencoder", "999", ""},
+	}
+	for _, test := range tests {
+		job, err := repo.GetJobByProviderJobID(test.providerName, test.providerJobID)
+		if test.wantJobID == "" {
+			if err != db.ErrJobNotFound {
+				t.Errorf("%s/%s: wrong error returned. Want %#v. Got %#v", test.providerName, test.providerJobID, db.ErrJobNotFound, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s/%s: unexpected error: %s", test.providerName, test.providerJobID, err)
+			continue
+		}
+		if job.ID != test.wantJobID {
+			t.Errorf("%s/%s: wrong job returned. Want %q. Got %q", test.providerName, test.providerJobID, test.wantJobID, job.ID)
+		}
+	}
+}
+
+func TestNextJobSequence(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []uint64{1, 2} {
+		got, err := repo.NextJobSequence("nyt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Wrong sequence number. Want %d. Got %d", want, got)
+		}
+	}
+	got, err := repo.NextJobSequence("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("Sequences should be kept per tenant. Want 1. Got %d", got)
+	}
+}
+
 func TestGetJob(t *testing.T) {
 	err := cleanRedis()
 	if err != nil {
@@ -181,6 +429,71 @@ func TestGetJob(t *testing.T) {
 	}
 }
 
+func TestGetJobStreamingParams(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{
+		ID: "myjob",
+		StreamingParams: db.StreamingParams{
+			SegmentDuration:  4,
+			Protocol:         "dash",
+			PlaylistFileName: "manifest.mpd",
+			MinBufferTime:    1.5,
+		},
+	}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJob, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotJob.StreamingParams, job.StreamingParams) {
+		t.Errorf("Wrong streaming params. Want %#v. Got %#v.", job.StreamingParams, gotJob.StreamingParams)
+	}
+}
+
+func TestGetJobResolvedFields(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{
+		ID:           "myjob",
+		ProviderName: "zencoder",
+		Tenant:       "newsroom",
+		SourceMedia:  "s3://newsroom-bucket/source.mov",
+		Destination:  "s3://newsroom-bucket/videos/",
+		CallbackURL:  "https://newsroom.example.com/callback",
+		Outputs: []db.TranscodeOutput{
+			{Preset: "720p_mp4", FileName: "source_720p.mp4"},
+			{Preset: "1080p_mp4", FileName: "source_1080p.mp4"},
+		},
+	}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJob, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotJob, job) {
+		t.Errorf("Wrong job. Want %#v. Got %#v.", job, *gotJob)
+	}
+}
+
 func TestGetJobNotFound(t *testing.T) {
 	err := cleanRedis()
 	if err != nil {
@@ -397,7 +710,7 @@ func TestListJobsFiltering(t *testing.T) {
 	since := now.Add(-59 * time.Minute)
 	redisRepo := repo.(*redisRepository)
 	for _, job := range jobs {
-		err = redisRepo.saveJob(&job)
+		err = redisRepo.saveJob(&job, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -457,7 +770,7 @@ func TestListJobsFilteringAndLimit(t *testing.T) {
 	since := now.Add(-59 * time.Minute)
 	redisRepo := repo.(*redisRepository)
 	for _, job := range jobs {
-		err = redisRepo.saveJob(&job)
+		err = redisRepo.saveJob(&job, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -473,3 +786,171 @@ func TestListJobsFilteringAndLimit(t *testing.T) {
 		t.Errorf("ListJobs({}): wrong list returned. Want %#v. Got %#v", expectedJobs, gotJobs)
 	}
 }
+
+func TestListJobsIndexes(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	jobs := []db.Job{
+		{ID: "job-1", ProviderName: "zencoder", Status: "finished", CreationTime: now.Add(-time.Hour)},
+		{ID: "job-2", ProviderName: "mediaconvert", Status: "finished", CreationTime: now.Add(-50 * time.Minute)},
+		{ID: "job-3", ProviderName: "zencoder", Status: "queued", CreationTime: now.Add(-40 * time.Minute)},
+		{ID: "job-4", ProviderName: "zencoder", Status: "finished", CreationTime: now.Add(-30 * time.Minute)},
+		{ID: "job-5", ProviderName: "zencoder", Status: "failed", CreationTime: now.Add(-20 * time.Minute)},
+	}
+	redisRepo := repo.(*redisRepository)
+	for i := range jobs {
+		err = redisRepo.saveJob(&jobs[i], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs[2].Status = "finished"
+	err = repo.UpdateJob(&jobs[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs[4].ProviderName = "mediaconvert"
+	err = repo.UpdateJob(&jobs[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteJob(&jobs[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		givenTestCase string
+		givenFilter   db.JobFilter
+		wantJobs      []string
+	}{
+		{"status", db.JobFilter{Status: "finished"}, []string{"job-1", "job-2", "job-3"}},
+		{"previous status", db.JobFilter{Status: "queued"}, []string{}},
+		{"provider", db.JobFilter{ProviderName: "mediaconvert"}, []string{"job-2", "job-5"}},
+		{"status and provider", db.JobFilter{Status: "finished", ProviderName: "zencoder"}, []string{"job-1", "job-3"}},
+		{"until", db.JobFilter{Until: now.Add(-40 * time.Minute)}, []string{"job-1", "job-2"}},
+		{"offset and limit", db.JobFilter{Offset: 1, Limit: 2}, []string{"job-2", "job-3"}},
+		{"status with offset", db.JobFilter{Status: "finished", Offset: 2}, []string{"job-3"}},
+		{"status and provider with offset and limit", db.JobFilter{Status: "finished", ProviderName: "zencoder", Offset: 1, Limit: 1}, []string{"job-3"}},
+	}
+	for _, test := range tests {
+		gotJobs, err := repo.ListJobs(test.givenFilter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotIDs := []string{}
+		for _, job := range gotJobs {
+			gotIDs = append(gotIDs, job.ID)
+		}
+		if !reflect.DeepEqual(gotIDs, test.wantJobs) {
+			t.Errorf("%s: wrong jobs returned. Want %#v. Got %#v", test.givenTestCase, test.wantJobs, gotIDs)
+		}
+	}
+}
+
+func TestJobExpiration(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{Redis: new(storage.Config), JobExpiration: &config.JobExpiration{TTL: time.Hour}}
+	repo, err := NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	job := db.Job{ID: "job1", ProviderName: "zencoder", ProviderJobID: "123", Status: "started"}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		givenStatus string
+		wantExpire  bool
+	}{
+		{"started", false},
+		{"finished", true},
+		{"queued", false},
+		{"failed", true},
+	}
+	for _, test := range tests {
+		job.Status = test.givenStatus
+		err = repo.UpdateJob(&job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"job:job1", "providerjob:zencoder:123"} {
+			ttl, err := client.TTL(key).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expires := ttl > 0; expires != test.wantExpire {
+				t.Errorf("%s: wrong expiration of %q. Want %v. Got TTL %s", test.givenStatus, key, test.wantExpire, ttl)
+			}
+		}
+		expiring, err := client.ZRange(expiringJobsKey, 0, -1).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expires := len(expiring) == 1; expires != test.wantExpire {
+			t.Errorf("%s: wrong expiring jobs: %#v", test.givenStatus, expiring)
+		}
+	}
+}
+
+func TestSweepExpiredJobs(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{Redis: new(storage.Config), JobExpiration: &config.JobExpiration{TTL: 50 * time.Millisecond}}
+	repo, err := NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []db.Job{
+		{ID: "job1", Tenant: "nyt", ExternalID: "asset-1", ProviderName: "zencoder", Status: "finished"},
+		{ID: "job2", Tenant: "nyt", ExternalID: "asset-2", ProviderName: "zencoder", Status: "started"},
+	}
+	for i := range jobs {
+		err = repo.CreateJob(&jobs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	swept, err := repo.(db.JobSweeper).SweepExpiredJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swept != 1 {
+		t.Errorf("wrong number of swept jobs. Want 1. Got %d", swept)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	for _, key := range []string{jobsSetKey, "jobs:status:finished", "jobs:provider:zencoder", "externalid:nyt:asset-1"} {
+		ids, err := client.ZRange(key, 0, -1).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range ids {
+			if id == "job1" {
+				t.Errorf("expired job left in %q", key)
+			}
+		}
+	}
+	gotJobs, err := repo.ListJobs(db.JobFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotJobs) != 1 || gotJobs[0].ID != "job2" {
+		t.Errorf("wrong jobs listed after sweeping: %#v", gotJobs)
+	}
+}
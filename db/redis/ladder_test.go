@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestCreateLadder(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ladder := db.Ladder{
+		Name:            "hls_sd",
+		Presets:         []string{"hls_360p", "hls_480p"},
+		StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6},
+	}
+	err = repo.CreateLadder(&ladder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	items, err := client.HGetAll("ladder:hls_sd").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedItems := map[string]string{
+		"presets":         "hls_360p%%%hls_480p",
+		"streamingParams": `{"segmentDuration":6,"protocol":"hls"}`,
+	}
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("Wrong ladder hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
+	}
+	err = repo.CreateLadder(&ladder)
+	if err != db.ErrLadderAlreadyExists {
+		t.Errorf("Wrong error returned. Want ErrLadderAlreadyExists. Got %#v", err)
+	}
+}
+
+func TestUpdateLadder(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ladder := db.Ladder{Name: "mp4", Presets: []string{"mp4_720p"}}
+	err = repo.UpdateLadder(&ladder)
+	if err != db.ErrLadderNotFound {
+		t.Errorf("Wrong error returned. Want ErrLadderNotFound. Got %#v", err)
+	}
+	err = repo.CreateLadder(&ladder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ladder.Presets = append(ladder.Presets, "mp4_1080p")
+	err = repo.UpdateLadder(&ladder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetLadder("mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, ladder) {
+		t.Errorf("Wrong ladder. Want %#v. Got %#v", ladder, *got)
+	}
+}
+
+func TestDeleteLadder(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ladder := db.Ladder{Name: "mp4", Presets: []string{"mp4_720p"}}
+	err = repo.CreateLadder(&ladder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteLadder(&ladder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ladders, err := repo.ListLadders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ladders) != 0 {
+		t.Errorf("DeleteLadder: ladder still listed: %#v", ladders)
+	}
+	err = repo.DeleteLadder(&ladder)
+	if err != db.ErrLadderNotFound {
+		t.Errorf("Wrong error returned. Want ErrLadderNotFound. Got %#v", err)
+	}
+}
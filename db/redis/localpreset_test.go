@@ -37,7 +37,9 @@ func TestCreateLocalPreset(t *testing.T) {
 		t.Fatal(err)
 	}
 	expectedItems := map[string]string{
-		"preset_name": "test",
+		"preset_name":          "test",
+		"preset_audioonly":     "false",
+		"preset_video_tonemap": "false",
 	}
 	if !reflect.DeepEqual(items, expectedItems) {
 		t.Errorf("Wrong preset hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
@@ -102,7 +104,9 @@ func TestUpdateLocalPreset(t *testing.T) {
 		t.Fatal(err)
 	}
 	expectedItems := map[string]string{
-		"preset_name": "test-different",
+		"preset_name":          "test-different",
+		"preset_audioonly":     "false",
+		"preset_video_tonemap": "false",
 	}
 	if !reflect.DeepEqual(items, expectedItems) {
 		t.Errorf("Wrong presetmap hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
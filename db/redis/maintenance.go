@@ -0,0 +1,27 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+const maintenanceKey = "maintenance"
+
+func (r *redisRepository) SetMaintenanceMode(mode *db.MaintenanceMode) error {
+	mode.UpdateTime = time.Now().UTC()
+	return r.storage.Save(maintenanceKey, mode)
+}
+
+func (r *redisRepository) GetMaintenanceMode() (*db.MaintenanceMode, error) {
+	var mode db.MaintenanceMode
+	err := r.storage.Load(maintenanceKey, &mode)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrMaintenanceModeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mode, nil
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.GetMaintenanceMode()
+	if err != db.ErrMaintenanceModeNotFound {
+		t.Errorf("Wrong error returned. Want ErrMaintenanceModeNotFound. Got %#v.", err)
+	}
+	mode := db.MaintenanceMode{Enabled: true, Message: "migrating storage"}
+	err = repo.SetMaintenanceMode(&mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode = db.MaintenanceMode{Message: "migrating storage"}
+	err = repo.SetMaintenanceMode(&mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode.UpdateTime.IsZero() {
+		t.Error("Should set the update time of the mode, but did not")
+	}
+	gotMode, err := repo.GetMaintenanceMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotMode, mode) {
+		t.Errorf("Wrong mode. Want %#v. Got %#v.", mode, *gotMode)
+	}
+}
@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+	"gopkg.in/redis.v4"
+)
+
+const notificationsSetKey = "notifications"
+
+func (r *redisRepository) CreateNotification(notification *db.Notification) error {
+	notification.CreationTime = time.Now().UTC()
+	return r.saveNotification(notification, false, db.ErrNotificationAlreadyExists)
+}
+
+func (r *redisRepository) UpdateNotification(notification *db.Notification) error {
+	return r.saveNotification(notification, true, db.ErrNotificationNotFound)
+}
+
+// saveNotification stores the notification when its existence matches
+// exists, returning errCondition otherwise. Notifications deleted while
+// they're updated aren't stored again.
+func (r *redisRepository) saveNotification(notification *db.Notification, exists bool, errCondition error) error {
+	notificationKey := r.notificationKey(notification.ID)
+	fields, err := r.storage.FieldMap(notification)
+	if err != nil {
+		return err
+	}
+	err = r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		stored, err := tx.Exists(notificationKey).Result()
+		if err != nil {
+			return err
+		}
+		if stored != exists {
+			return errCondition
+		}
+		_, err = tx.MultiExec(func() error {
+			err := tx.HMSet(notificationKey, fields).Err()
+			if err != nil {
+				return err
+			}
+			return tx.SAdd(notificationsSetKey, notification.ID).Err()
+		})
+		return err
+	}, notificationKey)
+	if err == redis.TxFailedErr {
+		return errCondition
+	}
+	return err
+}
+
+func (r *redisRepository) DeleteNotification(notification *db.Notification) error {
+	err := r.storage.Delete(r.notificationKey(notification.ID))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return db.ErrNotificationNotFound
+		}
+		return err
+	}
+	r.storage.RedisClient().SRem(notificationsSetKey, notification.ID)
+	return nil
+}
+
+func (r *redisRepository) ListNotifications() ([]db.Notification, error) {
+	ids, err := r.storage.RedisClient().SMembers(notificationsSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+	notifications := make([]db.Notification, 0, len(ids))
+	for _, id := range ids {
+		notification := db.Notification{ID: id}
+		err := r.storage.Load(r.notificationKey(id), &notification)
+		if err == storage.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
+
+func (r *redisRepository) notificationKey(id string) string {
+	return "notification:" + id
+}
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestNotifications(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notification := db.Notification{
+		ID:          "notification-1",
+		JobID:       "job-123",
+		URL:         "https://newsroom.example.com/callback",
+		ContentType: "application/json",
+		Data:        []byte(`{"jobId":"job-123","status":"finished"}`),
+		NextAttempt: time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC),
+	}
+	err = repo.CreateNotification(&notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notification.CreationTime.IsZero() {
+		t.Error("Should set the creation time of the notification, but did not")
+	}
+	err = repo.CreateNotification(&notification)
+	if err != db.ErrNotificationAlreadyExists {
+		t.Errorf("Wrong error returned. Want ErrNotificationAlreadyExists. Got %#v.", err)
+	}
+	notification.Attempts = 1
+	notification.LastError = "callback returned 502 Bad Gateway"
+	err = repo.UpdateNotification(&notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications, err := repo.ListNotifications()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(notifications, []db.Notification{notification}) {
+		t.Errorf("Wrong notifications. Want %#v. Got %#v.", []db.Notification{notification}, notifications)
+	}
+	err = repo.DeleteNotification(&notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteNotification(&notification)
+	if err != db.ErrNotificationNotFound {
+		t.Errorf("Wrong error returned. Want ErrNotificationNotFound. Got %#v.", err)
+	}
+	err = repo.UpdateNotification(&notification)
+	if err != db.ErrNotificationNotFound {
+		t.Errorf("Wrong error returned. Want ErrNotificationNotFound. Got %#v.", err)
+	}
+}
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+	"gopkg.in/redis.v4"
+)
+
+const pausesSetKey = "pauses"
+
+func (r *redisRepository) CreateSubmissionPause(pause *db.SubmissionPause) error {
+	pauseKey := r.pauseKey(pause.Provider)
+	if err := r.storage.Load(pauseKey, &db.SubmissionPause{}); err == nil {
+		return db.ErrSubmissionPauseAlreadyExists
+	}
+	pause.CreationTime = time.Now().UTC()
+	fields, err := r.storage.FieldMap(pause)
+	if err != nil {
+		return err
+	}
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		err := tx.HMSet(pauseKey, fields).Err()
+		if err != nil {
+			return err
+		}
+		return tx.SAdd(pausesSetKey, pause.Provider).Err()
+	}, pauseKey)
+}
+
+func (r *redisRepository) DeleteSubmissionPause(pause *db.SubmissionPause) error {
+	err := r.storage.Delete(r.pauseKey(pause.Provider))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return db.ErrSubmissionPauseNotFound
+		}
+		return err
+	}
+	r.storage.RedisClient().SRem(pausesSetKey, pause.Provider)
+	return nil
+}
+
+func (r *redisRepository) ListSubmissionPauses() ([]db.SubmissionPause, error) {
+	providers, err := r.storage.RedisClient().SMembers(pausesSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	pauses := make([]db.SubmissionPause, 0, len(providers))
+	for _, provider := range providers {
+		pause := db.SubmissionPause{Provider: provider}
+		err := r.storage.Load(r.pauseKey(provider), &pause)
+		if err == storage.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pauses = append(pauses, pause)
+	}
+	return pauses, nil
+}
+
+func (r *redisRepository) pauseKey(provider string) string {
+	return "pause:" + provider
+}
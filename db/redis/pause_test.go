@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestCreateSubmissionPause(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pause := db.SubmissionPause{Provider: "zencoder", Reason: "incident in the provider"}
+	err = repo.CreateSubmissionPause(&pause)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pause.CreationTime.IsZero() {
+		t.Error("Should set the creation time of the pause, but did not")
+	}
+	err = repo.CreateSubmissionPause(&db.SubmissionPause{Provider: "zencoder"})
+	if err != db.ErrSubmissionPauseAlreadyExists {
+		t.Errorf("Wrong error returned. Want ErrSubmissionPauseAlreadyExists. Got %#v.", err)
+	}
+	pauses, err := repo.ListSubmissionPauses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []db.SubmissionPause{pause}
+	if !reflect.DeepEqual(pauses, expected) {
+		t.Errorf("Wrong pauses. Want %#v. Got %#v.", expected, pauses)
+	}
+}
+
+func TestDeleteSubmissionPause(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, provider := range []string{db.AllProviders, "zencoder", "encodingcom"} {
+		err = repo.CreateSubmissionPause(&db.SubmissionPause{Provider: provider})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = repo.DeleteSubmissionPause(&db.SubmissionPause{Provider: "zencoder"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteSubmissionPause(&db.SubmissionPause{Provider: "zencoder"})
+	if err != db.ErrSubmissionPauseNotFound {
+		t.Errorf("Wrong error returned. Want ErrSubmissionPauseNotFound. Got %#v.", err)
+	}
+	pauses, err := repo.ListSubmissionPauses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers := make([]string, len(pauses))
+	for i, pause := range pauses {
+		providers[i] = pause.Provider
+	}
+	sort.Strings(providers)
+	expected := []string{db.AllProviders, "encodingcom"}
+	if !reflect.DeepEqual(providers, expected) {
+		t.Errorf("Wrong pauses. Want %#v. Got %#v.", expected, providers)
+	}
+}
@@ -1,6 +1,9 @@
 package redis
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/NYTimes/video-transcoding-api/db"
 	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
 	"gopkg.in/redis.v4"
@@ -8,45 +11,110 @@ import (
 
 const presetmapsSetKey = "presetmaps"
 
+// maxPresetMapAttempts is the number of times writing a presetmap is
+// attempted when concurrent writes abort the transaction.
+const maxPresetMapAttempts = 5
+
+// CreatePresetMap stores the presetmap, numbering it after the last version
+// kept for presetmaps with the same name.
 func (r *redisRepository) CreatePresetMap(presetMap *db.PresetMap) error {
-	if _, err := r.GetPresetMap(presetMap.Name); err == nil {
-		return db.ErrPresetMapAlreadyExists
-	}
-	return r.savePresetMap(presetMap)
+	return r.writePresetMap(presetMap.Name, false, func(tx *redis.Tx, last int) error {
+		return r.savePresetMap(tx, presetMap, last+1)
+	})
 }
 
+// UpdatePresetMap replaces the presetmap, storing the presetmap it replaces
+// as its last version.
 func (r *redisRepository) UpdatePresetMap(presetMap *db.PresetMap) error {
-	if _, err := r.GetPresetMap(presetMap.Name); err == db.ErrPresetMapNotFound {
-		return err
-	}
-	return r.savePresetMap(presetMap)
+	return r.writePresetMap(presetMap.Name, true, func(tx *redis.Tx, last int) error {
+		return r.savePresetMap(tx, presetMap, last+1)
+	})
 }
 
-func (r *redisRepository) savePresetMap(presetMap *db.PresetMap) error {
+func (r *redisRepository) savePresetMap(tx *redis.Tx, presetMap *db.PresetMap, version int) error {
+	presetMap.Version = version
 	fields, err := r.storage.FieldMap(presetMap)
 	if err != nil {
 		return err
 	}
-	presetMapKey := r.presetMapKey(presetMap.Name)
-	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
-		err := tx.HMSet(presetMapKey, fields).Err()
+	err = tx.HMSet(r.presetMapKey(presetMap.Name), fields).Err()
+	if err != nil {
+		return err
+	}
+	return tx.SAdd(presetmapsSetKey, presetMap.Name).Err()
+}
+
+// DeletePresetMap deletes the presetmap, storing it as its last version.
+func (r *redisRepository) DeletePresetMap(presetMap *db.PresetMap) error {
+	return r.writePresetMap(presetMap.Name, true, func(tx *redis.Tx, last int) error {
+		err := tx.Del(r.presetMapKey(presetMap.Name)).Err()
 		if err != nil {
 			return err
 		}
-		return tx.SAdd(presetmapsSetKey, presetMap.Name).Err()
-	}, presetMapKey)
+		return tx.SRem(presetmapsSetKey, presetMap.Name).Err()
+	})
 }
 
-func (r *redisRepository) DeletePresetMap(presetMap *db.PresetMap) error {
-	err := r.storage.Delete(r.presetMapKey(presetMap.Name))
+// writePresetMap calls write in a transaction on the presetmap with the
+// given name and its versions, when the existence of the presetmap matches
+// exists. Existing presetmaps are appended to their list of versions in the
+// same transaction, before write. The number of a version is its position
+// in the list, and write gets the number of the last version. Transactions
+// aborted by concurrent writes are retried.
+func (r *redisRepository) writePresetMap(name string, exists bool, write func(tx *redis.Tx, last int) error) error {
+	versionsKey := r.presetMapVersionsKey(name)
+	err := redis.TxFailedErr
+	for i := 0; i < maxPresetMapAttempts && err == redis.TxFailedErr; i++ {
+		err = r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+			current, err := r.GetPresetMap(name)
+			if err != nil && err != db.ErrPresetMapNotFound {
+				return err
+			}
+			if current == nil && exists {
+				return db.ErrPresetMapNotFound
+			}
+			if current != nil && !exists {
+				return db.ErrPresetMapAlreadyExists
+			}
+			last, err := tx.LLen(versionsKey).Result()
+			if err != nil {
+				return err
+			}
+			var version []byte
+			if current != nil {
+				last++
+				version, err = json.Marshal(db.PresetMapVersion{PresetMap: *current, Version: int(last), ReplacedAt: time.Now().UTC()})
+				if err != nil {
+					return err
+				}
+			}
+			_, err = tx.MultiExec(func() error {
+				if version != nil {
+					if err := tx.RPush(versionsKey, version).Err(); err != nil {
+						return err
+					}
+				}
+				return write(tx, int(last))
+			})
+			return err
+		}, r.presetMapKey(name), versionsKey)
+	}
+	return err
+}
+
+func (r *redisRepository) ListPresetMapVersions(name string) ([]db.PresetMapVersion, error) {
+	items, err := r.storage.RedisClient().LRange(r.presetMapVersionsKey(name), 0, -1).Result()
 	if err != nil {
-		if err == storage.ErrNotFound {
-			return db.ErrPresetMapNotFound
+		return nil, err
+	}
+	versions := make([]db.PresetMapVersion, len(items))
+	for i, item := range items {
+		if err = json.Unmarshal([]byte(item), &versions[i]); err != nil {
+			return nil, err
 		}
-		return err
+		versions[i].Version = i + 1
 	}
-	r.storage.RedisClient().SRem(presetmapsSetKey, presetMap.Name)
-	return nil
+	return versions, nil
 }
 
 func (r *redisRepository) GetPresetMap(name string) (*db.PresetMap, error) {
@@ -58,7 +126,7 @@ func (r *redisRepository) GetPresetMap(name string) (*db.PresetMap, error) {
 	return &presetMap, err
 }
 
-func (r *redisRepository) ListPresetMaps() ([]db.PresetMap, error) {
+func (r *redisRepository) ListPresetMaps(filter db.PresetMapFilter) ([]db.PresetMap, error) {
 	presetMapNames, err := r.storage.RedisClient().SMembers(presetmapsSetKey).Result()
 	if err != nil {
 		return nil, err
@@ -73,9 +141,13 @@ func (r *redisRepository) ListPresetMaps() ([]db.PresetMap, error) {
 			presetsMap = append(presetsMap, *presetMap)
 		}
 	}
-	return presetsMap, nil
+	return filter.Apply(presetsMap), nil
 }
 
 func (r *redisRepository) presetMapKey(name string) string {
 	return "presetmap:" + name
 }
+
+func (r *redisRepository) presetMapVersionsKey(name string) string {
+	return "presetmapversions:" + name
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/NYTimes/video-transcoding-api/config"
@@ -42,6 +43,7 @@ func TestCreatePresetMap(t *testing.T) {
 		"pmapping_elementalconductor": "abc123",
 		"pmapping_elastictranscoder":  "1281742-93939",
 		"output_extension":            "ts",
+		"version":                     "1",
 	}
 	if !reflect.DeepEqual(items, expectedItems) {
 		t.Errorf("Wrong presetmap hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
@@ -104,6 +106,7 @@ func TestUpdatePresetMap(t *testing.T) {
 		"pmapping_elemental":         "abc1234",
 		"pmapping_elastictranscoder": "def123",
 		"output_extension":           "mp4",
+		"version":                    "2",
 	}
 	if !reflect.DeepEqual(items, expectedItems) {
 		t.Errorf("Wrong presetmap hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
@@ -257,7 +260,7 @@ func TestListPresetMaps(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	gotPresetMaps, err := repo.ListPresetMaps()
+	gotPresetMaps, err := repo.ListPresetMaps(db.PresetMapFilter{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -279,3 +282,57 @@ func presetListToMap(presetmaps []db.PresetMap) map[string]db.PresetMap {
 	}
 	return result
 }
+
+func TestPresetMapVersions(t *testing.T) {
+	if err := cleanRedis(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	presetMap := db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"zencoder": "v1"}}
+	err = r.CreatePresetMap(&presetMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, presetID := range []string{"v2", "v3"} {
+		presetMap = db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"zencoder": presetID}}
+		if err = r.UpdatePresetMap(&presetMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if presetMap.Version != 3 {
+		t.Errorf("wrong version of the presetmap. Want 3. Got %d", presetMap.Version)
+	}
+	versions, err := r.ListPresetMapVersions("mypreset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("wrong number of versions. Want 2. Got %d", len(versions))
+	}
+	for i, version := range versions {
+		want := db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"zencoder": "v" + strconv.Itoa(i+1)}, Version: i + 1}
+		if version.Version != i+1 || version.ReplacedAt.IsZero() || !reflect.DeepEqual(version.PresetMap, want) {
+			t.Errorf("wrong version %d: %#v", i+1, version)
+		}
+	}
+	if err = r.DeletePresetMap(&presetMap); err != nil {
+		t.Fatal(err)
+	}
+	presetMap = db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"zencoder": "v4"}}
+	if err = r.CreatePresetMap(&presetMap); err != nil {
+		t.Fatal(err)
+	}
+	if presetMap.Version != 4 {
+		t.Errorf("wrong version of the presetmap created again. Want 4. Got %d", presetMap.Version)
+	}
+	versions, err = r.ListPresetMapVersions("mypreset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[2].PresetMap.ProviderMapping["zencoder"] != "v3" {
+		t.Errorf("the deleted presetmap wasn't kept as a version: %#v", versions)
+	}
+}
@@ -25,6 +25,94 @@ func cleanRedis() error {
 	if err != nil {
 		return err
 	}
+	err = deleteKeys("tenant:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(tenantsSetKey, client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("artifact:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("artifacts:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("experiment:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("experiment-sample*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(experimentsSetKey, client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("deliverytarget:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(deliveryTargetsSetKey, client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("ladder:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(laddersSetKey, client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("watchfolder:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(watchFoldersSetKey, client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("externalid:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("jobs:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("jobsequence:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("pause:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(pausesSetKey, client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(maintenanceKey, client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(throughputKeyPrefix+"*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("notification:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(notificationsSetKey, client)
+	if err != nil {
+		return err
+	}
 
 	return deleteKeys(jobsSetKey, client)
 }
@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"errors"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"gopkg.in/redis.v4"
+)
+
+func (r *redisRepository) SaveSourceEncode(encode *db.SourceEncode) error {
+	if encode.Checksum == "" || encode.JobID == "" {
+		return errors.New("checksum and job id are required")
+	}
+	fields, err := r.storage.FieldMap(encode)
+	if err != nil {
+		return err
+	}
+	encodeKey := r.sourceEncodeKey(encode.Checksum, encode.JobID)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		err := tx.Del(encodeKey).Err()
+		if err != nil {
+			return err
+		}
+		err = tx.HMSet(encodeKey, fields).Err()
+		if err != nil {
+			return err
+		}
+		return tx.SAdd(r.sourceEncodesSetKey(encode.Checksum), encode.JobID).Err()
+	}, encodeKey)
+}
+
+func (r *redisRepository) ListSourceEncodes(checksum string) ([]db.SourceEncode, error) {
+	jobIDs, err := r.storage.RedisClient().SMembers(r.sourceEncodesSetKey(checksum)).Result()
+	if err != nil {
+		return nil, err
+	}
+	encodes := make([]db.SourceEncode, 0, len(jobIDs))
+	for _, jobID := range jobIDs {
+		var encode db.SourceEncode
+		err := r.storage.Load(r.sourceEncodeKey(checksum, jobID), &encode)
+		if err != nil {
+			return nil, err
+		}
+		encodes = append(encodes, encode)
+	}
+	return encodes, nil
+}
+
+func (r *redisRepository) sourceEncodeKey(checksum, jobID string) string {
+	return "source-encode:" + checksum + ":" + jobID
+}
+
+func (r *redisRepository) sourceEncodesSetKey(checksum string) string {
+	return "source-encodes:" + checksum
+}
@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestSourceEncodes(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodes := []db.SourceEncode{
+		{
+			Checksum:     "sha256:9f86d081",
+			JobID:        "job-1",
+			Ladder:       "web",
+			Presets:      []string{"mp4_720p", "webm_1080p"},
+			Provider:     "fake",
+			Destination:  "s3://mybucket/job-1",
+			Outputs:      []string{"s3://mybucket/job-1/video_720p.mp4", "s3://mybucket/job-1/video_1080p.webm"},
+			FinishedTime: time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Checksum:     "sha256:9f86d081",
+			JobID:        "job-2",
+			Presets:      []string{"prores"},
+			Provider:     "fake",
+			FinishedTime: time.Date(2017, 3, 2, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Checksum:     "sha256:2c26b46b",
+			JobID:        "job-3",
+			Presets:      []string{"prores"},
+			Provider:     "fake",
+			FinishedTime: time.Date(2017, 3, 3, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	for i := range encodes {
+		if err = repo.SaveSourceEncode(&encodes[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	encodes[0].Ladder = ""
+	encodes[0].Outputs = nil
+	if err = repo.SaveSourceEncode(&encodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	gotEncodes, err := repo.ListSourceEncodes("sha256:9f86d081")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]db.SourceEncode, len(gotEncodes))
+	for _, encode := range gotEncodes {
+		got[encode.JobID] = encode
+	}
+	expected := map[string]db.SourceEncode{
+		"job-1": encodes[0],
+		"job-2": encodes[1],
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ListSourceEncodes: wrong list. Want %#v. Got %#v.", expected, got)
+	}
+	gotEncodes, err = repo.ListSourceEncodes("sha256:fcde2b2e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotEncodes) != 0 {
+		t.Errorf("ListSourceEncodes: wrong list. Want none. Got %#v.", gotEncodes)
+	}
+}
@@ -2,6 +2,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -10,12 +11,25 @@ import (
 	"sync"
 	"time"
 
+	"github.com/golang/snappy"
 	"gopkg.in/redis.v4"
 )
 
 // ErrNotFound is the error returned when the given key is not found.
 var ErrNotFound = errors.New("not found")
 
+const (
+	// compressedPrefix marks the values compressed with snappy, and
+	// carries the version of the format of compressed values. Values
+	// without the marker (small values and values stored before values
+	// were compressed) are loaded as they are.
+	compressedPrefix = "\x00snappy1\x00"
+
+	// compressionThreshold is the size, in bytes, from which values are
+	// compressed (like provider payloads and QC reports).
+	compressionThreshold = 1024
+)
+
 // Storage is the basic type that provides methods for saving, listing and
 // deleting types on Redis.
 type Storage struct {
@@ -87,7 +101,8 @@ func (s *Storage) Save(key string, hash interface{}) error {
 }
 
 // FieldMap extract the map of fields from the given type (which can be a
-// struct, a map[string]string or pointer to those).
+// struct, a map[string]string or pointer to those). Large values are
+// compressed, and decompressed transparently by Load.
 func (s *Storage) FieldMap(hash interface{}) (map[string]string, error) {
 	if hash == nil {
 		return nil, errors.New("no fields provided")
@@ -96,14 +111,47 @@ func (s *Storage) FieldMap(hash interface{}) (map[string]string, error) {
 	if value.Kind() == reflect.Ptr {
 		value = value.Elem()
 	}
+	var fields map[string]string
+	var err error
 	switch value.Kind() {
 	case reflect.Map:
-		return s.mapToFieldList(hash)
+		fields, err = s.mapToFieldList(hash)
 	case reflect.Struct:
-		return s.structToFieldList(value)
+		fields, err = s.structToFieldList(value)
 	default:
 		return nil, errors.New("please provide a map or a struct")
 	}
+	if err != nil {
+		return nil, err
+	}
+	for key, fieldValue := range fields {
+		fields[key] = compressValue(fieldValue)
+	}
+	return fields, nil
+}
+
+// compressValue compresses values larger than the compression threshold,
+// keeping the original value when compression doesn't make it smaller.
+func compressValue(value string) string {
+	if len(value) < compressionThreshold {
+		return value
+	}
+	compressed := compressedPrefix + string(snappy.Encode(nil, []byte(value)))
+	if len(compressed) >= len(value) {
+		return value
+	}
+	return compressed
+}
+
+func decompressValue(value string) (string, error) {
+	if !strings.HasPrefix(value, compressedPrefix) {
+		return value, nil
+	}
+	data, err := snappy.Decode(nil, []byte(value[len(compressedPrefix):]))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress value: %s", err)
+	}
+	return string(data), nil
 }
 
 func (s *Storage) mapToFieldList(hash interface{}, prefixes ...string) (map[string]string, error) {
@@ -160,6 +208,15 @@ func (s *Storage) structToFieldList(value reflect.Value, prefixes ...string) (ma
 			default:
 				return nil, errors.New("can only expand structs and maps")
 			}
+		} else if len(parts) > 1 && parts[1] == "json" {
+			if parts[len(parts)-1] == "omitempty" && isEmptyValue(fieldValue) {
+				continue
+			}
+			data, err := json.Marshal(fieldValue.Interface())
+			if err != nil {
+				return nil, err
+			}
+			fields[strings.Join(append(prefixes, parts[0]), "_")] = string(data)
 		} else {
 			if parts[0] != "" {
 				key := strings.Join(append(prefixes, parts[0]), "_")
@@ -170,6 +227,10 @@ func (s *Storage) structToFieldList(value reflect.Value, prefixes ...string) (ma
 					strValue = v.Format(time.RFC3339Nano)
 				case []string:
 					strValue = strings.Join(v, "%%%")
+				case float32:
+					strValue = strconv.FormatFloat(float64(v), 'g', -1, 32)
+				case float64:
+					strValue = strconv.FormatFloat(v, 'g', -1, 64)
 				default:
 					strValue = fmt.Sprintf("%v", v)
 				}
@@ -183,6 +244,16 @@ func (s *Storage) structToFieldList(value reflect.Value, prefixes ...string) (ma
 	return fields, nil
 }
 
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
+
 // Load loads the given key in the given output. The output must be a pointer
 // to a struct or a map[string]string.
 func (s *Storage) Load(key string, out interface{}) error {
@@ -198,6 +269,11 @@ func (s *Storage) Load(key string, out interface{}) error {
 	if len(result) < 1 {
 		return ErrNotFound
 	}
+	for field, fieldValue := range result {
+		if result[field], err = decompressValue(fieldValue); err != nil {
+			return err
+		}
+	}
 	switch value.Kind() {
 	case reflect.Map:
 		return s.loadMap(result, value)
@@ -257,6 +333,14 @@ func (s *Storage) loadStruct(in map[string]string, out reflect.Value, prefixes .
 			default:
 				return errors.New("can only expand values to structs or maps")
 			}
+		} else if len(parts) > 1 && parts[1] == "json" {
+			key := strings.Join(append(prefixes, parts[0]), "_")
+			if value, ok := in[key]; ok {
+				err := json.Unmarshal([]byte(value), fieldValue.Addr().Interface())
+				if err != nil {
+					return err
+				}
+			}
 		} else {
 			key := strings.Join(append(prefixes, parts[0]), "_")
 			if value, ok := in[key]; ok {
@@ -284,6 +368,12 @@ func (s *Storage) loadStruct(in map[string]string, out reflect.Value, prefixes .
 						return err
 					}
 					fieldValue.SetUint(uintValue)
+				case reflect.Float32, reflect.Float64:
+					floatValue, err := strconv.ParseFloat(value, fieldValue.Type().Bits())
+					if err != nil {
+						return err
+					}
+					fieldValue.SetFloat(floatValue)
 				case reflect.Struct:
 					if reflect.TypeOf(time.Time{}).AssignableTo(fieldValue.Type()) {
 						timeValue, err := time.Parse(time.RFC3339Nano, value)
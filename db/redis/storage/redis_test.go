@@ -295,6 +295,168 @@ func TestLoadMap(t *testing.T) {
 	}
 }
 
+func TestSaveJSON(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		playlist Playlist
+		expected map[string]string
+	}{
+		{
+			"playlist with tracks",
+			Playlist{
+				Name:   "favorites",
+				Tracks: []Track{{Title: "Go", Length: 120}, {Title: "Gopher", Length: 95}},
+			},
+			map[string]string{
+				"name":   "favorites",
+				"tracks": `[{"title":"Go","length":120},{"title":"Gopher","length":95}]`,
+			},
+		},
+		{
+			"empty playlist",
+			Playlist{Name: "empty"},
+			map[string]string{"name": "empty"},
+		},
+	}
+	storage, err := NewStorage(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := storage.RedisClient()
+	defer client.Close()
+	for _, test := range tests {
+		err = storage.Save("playlist:test", test.playlist)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := client.HGetAll("playlist:test").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Del("playlist:test")
+		if !reflect.DeepEqual(data, test.expected) {
+			t.Errorf("%s: did not save properly.\nWant %#v\nGot  %#v", test.testCase, test.expected, data)
+		}
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	storage, err := NewStorage(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := storage.RedisClient()
+	defer client.Close()
+	err = storage.Save("test-key", map[string]string{
+		"name":   "favorites",
+		"tracks": `[{"title":"Go","length":120}]`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del("test-key")
+	var playlist Playlist
+	err = storage.Load("test-key", &playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Playlist{Name: "favorites", Tracks: []Track{{Title: "Go", Length: 120}}}
+	if !reflect.DeepEqual(playlist, expected) {
+		t.Errorf("Didn't load data to struct. Want %#v. Got %#v.", expected, playlist)
+	}
+}
+
+func TestSaveFloats(t *testing.T) {
+	storage, err := NewStorage(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := storage.RedisClient()
+	defer client.Close()
+	rating := Rating{Score: 4.25, Weight: 0.1}
+	err = storage.Save("rating:test", rating)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del("rating:test")
+	data, err := client.HGetAll("rating:test").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"score": "4.25", "weight": "0.1"}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("did not save properly.\nWant %#v\nGot  %#v", expected, data)
+	}
+	var loaded Rating
+	err = storage.Load("rating:test", &loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, rating) {
+		t.Errorf("Didn't load floats to struct. Want %#v. Got %#v.", rating, loaded)
+	}
+}
+
+func TestSaveCompressed(t *testing.T) {
+	storage, err := NewStorage(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := storage.RedisClient()
+	defer client.Close()
+	tracks := make([]Track, 100)
+	for i := range tracks {
+		tracks[i] = Track{Title: fmt.Sprintf("Gopher %d", i), Length: 120}
+	}
+	playlist := Playlist{Name: "long", Tracks: tracks}
+	err = storage.Save("playlist:long", playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del("playlist:long")
+	data, err := client.HGetAll("playlist:long").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["name"] != "long" {
+		t.Errorf("small value stored compressed: %q", data["name"])
+	}
+	if !strings.HasPrefix(data["tracks"], compressedPrefix) {
+		t.Errorf("large value stored uncompressed: %q", data["tracks"])
+	}
+	var loaded Playlist
+	err = storage.Load("playlist:long", &loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, playlist) {
+		t.Errorf("Didn't load compressed data to struct. Want %#v. Got %#v.", playlist, loaded)
+	}
+}
+
+func TestLoadUncompressed(t *testing.T) {
+	storage, err := NewStorage(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := storage.RedisClient()
+	defer client.Close()
+	tracks := `[{"title":"Go","length":120}` + strings.Repeat(`,{"title":"Go","length":120}`, 99) + "]"
+	err = client.HMSet("playlist:legacy", map[string]string{"name": "legacy", "tracks": tracks}).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del("playlist:legacy")
+	var playlist Playlist
+	err = storage.Load("playlist:legacy", &playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(playlist.Tracks) != 100 {
+		t.Errorf("Didn't load uncompressed data to struct. Want 100 tracks. Got %d.", len(playlist.Tracks))
+	}
+}
+
 func TestLoadErrors(t *testing.T) {
 	var n int
 	var invalidMap map[string]int
@@ -32,3 +32,18 @@ type InvalidStruct struct {
 type InvalidInnerStruct struct {
 	Data map[string]int `redis-hash:"data,expand"`
 }
+
+type Playlist struct {
+	Name   string  `redis-hash:"name"`
+	Tracks []Track `redis-hash:"tracks,json,omitempty"`
+}
+
+type Track struct {
+	Title  string `json:"title"`
+	Length int    `json:"length"`
+}
+
+type Rating struct {
+	Score  float64 `redis-hash:"score"`
+	Weight float32 `redis-hash:"weight"`
+}
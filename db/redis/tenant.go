@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+	"gopkg.in/redis.v4"
+)
+
+const tenantsSetKey = "tenants"
+
+func (r *redisRepository) CreateTenant(tenant *db.Tenant) error {
+	if _, err := r.GetTenant(tenant.Name); err == nil {
+		return db.ErrTenantAlreadyExists
+	}
+	return r.saveTenant(tenant)
+}
+
+func (r *redisRepository) UpdateTenant(tenant *db.Tenant) error {
+	if _, err := r.GetTenant(tenant.Name); err == db.ErrTenantNotFound {
+		return err
+	}
+	return r.saveTenant(tenant)
+}
+
+func (r *redisRepository) saveTenant(tenant *db.Tenant) error {
+	fields, err := r.storage.FieldMap(tenant)
+	if err != nil {
+		return err
+	}
+	tenantKey := r.tenantKey(tenant.Name)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		err := tx.HMSet(tenantKey, fields).Err()
+		if err != nil {
+			return err
+		}
+		return tx.SAdd(tenantsSetKey, tenant.Name).Err()
+	}, tenantKey)
+}
+
+func (r *redisRepository) DeleteTenant(tenant *db.Tenant) error {
+	err := r.storage.Delete(r.tenantKey(tenant.Name))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return db.ErrTenantNotFound
+		}
+		return err
+	}
+	r.storage.RedisClient().SRem(tenantsSetKey, tenant.Name)
+	return nil
+}
+
+func (r *redisRepository) GetTenant(name string) (*db.Tenant, error) {
+	tenant := db.Tenant{Name: name}
+	err := r.storage.Load(r.tenantKey(name), &tenant)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrTenantNotFound
+	}
+	return &tenant, err
+}
+
+func (r *redisRepository) ListTenants() ([]db.Tenant, error) {
+	tenantNames, err := r.storage.RedisClient().SMembers(tenantsSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]db.Tenant, 0, len(tenantNames))
+	for _, name := range tenantNames {
+		tenant, err := r.GetTenant(name)
+		if err != nil && err != db.ErrTenantNotFound {
+			return nil, err
+		}
+		if tenant != nil {
+			tenants = append(tenants, *tenant)
+		}
+	}
+	return tenants, nil
+}
+
+func (r *redisRepository) tenantKey(name string) string {
+	return "tenant:" + name
+}
@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestCreateTenant(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := db.Tenant{
+		Name: "newsroom",
+		Defaults: db.JobDefaults{
+			Provider:    "elastictranscoder",
+			Destination: "s3://newsroom-bucket/videos/",
+			Ladder:      []string{"720p_mp4", "1080p_mp4"},
+			CallbackURL: "https://newsroom.example.com/callback",
+		},
+	}
+	err = repo.CreateTenant(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	items, err := client.HGetAll("tenant:" + tenant.Name).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedItems := map[string]string{
+		"defaults_provider":                           "elastictranscoder",
+		"defaults_destination":                        "s3://newsroom-bucket/videos/",
+		"defaults_ladder":                             "720p_mp4%%%1080p_mp4",
+		"defaults_callbackURL":                        "https://newsroom.example.com/callback",
+		"defaults_streamingparams_segmentDuration":    "0",
+		"defaults_streamingparams_protocol":           "",
+		"defaults_streamingparams_minBufferTime":      "0",
+		"defaults_streamingparams_audioOnlyRendition": "false",
+		"defaults_streamingparams_audioOnlyBitrate":   "0",
+		"uniqueExternalIds":                           "false",
+	}
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("Wrong tenant hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
+	}
+}
+
+func TestCreateTenantDuplicate(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := db.Tenant{Name: "newsroom", Defaults: db.JobDefaults{Provider: "zencoder"}}
+	err = repo.CreateTenant(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.CreateTenant(&tenant)
+	if err != db.ErrTenantAlreadyExists {
+		t.Errorf("Got wrong error. Want %#v. Got %#v", db.ErrTenantAlreadyExists, err)
+	}
+}
+
+func TestUpdateTenantNotFound(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.UpdateTenant(&db.Tenant{Name: "newsroom"})
+	if err != db.ErrTenantNotFound {
+		t.Errorf("Wrong error returned by UpdateTenant. Want ErrTenantNotFound. Got %#v.", err)
+	}
+}
+
+func TestDeleteTenant(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := db.Tenant{Name: "newsroom", Defaults: db.JobDefaults{Provider: "zencoder"}}
+	err = repo.CreateTenant(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteTenant(&db.Tenant{Name: tenant.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.GetTenant(tenant.Name)
+	if err != db.ErrTenantNotFound {
+		t.Errorf("Wrong error returned after delete. Want ErrTenantNotFound. Got %#v.", err)
+	}
+	err = repo.DeleteTenant(&tenant)
+	if err != db.ErrTenantNotFound {
+		t.Errorf("Wrong error returned by DeleteTenant. Want ErrTenantNotFound. Got %#v.", err)
+	}
+}
+
+func TestGetTenant(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := db.Tenant{
+		Name: "newsroom",
+		Defaults: db.JobDefaults{
+			Provider:        "zencoder",
+			Ladder:          []string{"hls_360p", "hls_720p"},
+			StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6},
+		},
+	}
+	err = repo.CreateTenant(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotTenant, err := repo.GetTenant(tenant.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotTenant, tenant) {
+		t.Errorf("Wrong tenant. Want %#v. Got %#v.", tenant, *gotTenant)
+	}
+}
+
+func TestListTenants(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenants := []db.Tenant{
+		{Name: "newsroom", Defaults: db.JobDefaults{Provider: "zencoder"}},
+		{Name: "cooking", Defaults: db.JobDefaults{Provider: "elastictranscoder"}},
+	}
+	for i := range tenants {
+		err = repo.CreateTenant(&tenants[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	gotTenants, err := repo.ListTenants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]db.Tenant, len(gotTenants))
+	for _, tenant := range gotTenants {
+		got[tenant.Name] = tenant
+	}
+	expected := map[string]db.Tenant{"newsroom": tenants[0], "cooking": tenants[1]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ListTenants(): wrong list. Want %#v. Got %#v.", tenants, gotTenants)
+	}
+}
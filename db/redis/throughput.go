@@ -0,0 +1,27 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+const throughputKeyPrefix = "throughput:"
+
+func (r *redisRepository) SaveProviderThroughput(throughput *db.ProviderThroughput) error {
+	throughput.UpdateTime = time.Now().UTC()
+	return r.storage.Save(throughputKeyPrefix+throughput.Provider, throughput)
+}
+
+func (r *redisRepository) GetProviderThroughput(providerName string) (*db.ProviderThroughput, error) {
+	var throughput db.ProviderThroughput
+	err := r.storage.Load(throughputKeyPrefix+providerName, &throughput)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrProviderThroughputNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &throughput, nil
+}
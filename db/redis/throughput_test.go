@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestProviderThroughput(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.GetProviderThroughput("zencoder")
+	if err != db.ErrProviderThroughputNotFound {
+		t.Errorf("Wrong error returned. Want ErrProviderThroughputNotFound. Got %#v.", err)
+	}
+	throughput := db.ProviderThroughput{Provider: "zencoder", Throughput: 2}
+	err = repo.SaveProviderThroughput(&throughput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	throughput = db.ProviderThroughput{Provider: "zencoder", Throughput: 2.5}
+	err = repo.SaveProviderThroughput(&throughput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if throughput.UpdateTime.IsZero() {
+		t.Error("Should set the update time of the throughput, but did not")
+	}
+	gotThroughput, err := repo.GetProviderThroughput("zencoder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotThroughput, throughput) {
+		t.Errorf("Wrong throughput. Want %#v. Got %#v.", throughput, *gotThroughput)
+	}
+	_, err = repo.GetProviderThroughput("mediaconvert")
+	if err != db.ErrProviderThroughputNotFound {
+		t.Errorf("Wrong error returned. Want ErrProviderThroughputNotFound. Got %#v.", err)
+	}
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+	"gopkg.in/redis.v4"
+)
+
+const watchFoldersSetKey = "watchfolders"
+
+func (r *redisRepository) CreateWatchFolder(folder *db.WatchFolder) error {
+	if _, err := r.GetWatchFolder(folder.Name); err == nil {
+		return db.ErrWatchFolderAlreadyExists
+	}
+	fields, err := r.storage.FieldMap(folder)
+	if err != nil {
+		return err
+	}
+	folderKey := r.watchFolderKey(folder.Name)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		err := tx.HMSet(folderKey, fields).Err()
+		if err != nil {
+			return err
+		}
+		return tx.SAdd(watchFoldersSetKey, folder.Name).Err()
+	}, folderKey)
+}
+
+func (r *redisRepository) DeleteWatchFolder(folder *db.WatchFolder) error {
+	err := r.storage.Delete(r.watchFolderKey(folder.Name))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return db.ErrWatchFolderNotFound
+		}
+		return err
+	}
+	r.storage.RedisClient().SRem(watchFoldersSetKey, folder.Name)
+	return nil
+}
+
+func (r *redisRepository) GetWatchFolder(name string) (*db.WatchFolder, error) {
+	folder := db.WatchFolder{Name: name}
+	err := r.storage.Load(r.watchFolderKey(name), &folder)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrWatchFolderNotFound
+	}
+	return &folder, err
+}
+
+func (r *redisRepository) ListWatchFolders() ([]db.WatchFolder, error) {
+	names, err := r.storage.RedisClient().SMembers(watchFoldersSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	folders := make([]db.WatchFolder, 0, len(names))
+	for _, name := range names {
+		folder, err := r.GetWatchFolder(name)
+		if err != nil && err != db.ErrWatchFolderNotFound {
+			return nil, err
+		}
+		if folder != nil {
+			folders = append(folders, *folder)
+		}
+	}
+	return folders, nil
+}
+
+func (r *redisRepository) watchFolderKey(name string) string {
+	return "watchfolder:" + name
+}
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+)
+
+func TestCreateWatchFolder(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder := db.WatchFolder{
+		Name:     "partner",
+		URL:      "s3://dropbox/partner/",
+		Queue:    "https://sqs.us-east-1.amazonaws.com/123456789012/dropbox",
+		Template: map[string]interface{}{"provider": "zencoder", "ladder": "hls_sd"},
+	}
+	err = repo.CreateWatchFolder(&folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	items, err := client.HGetAll("watchfolder:partner").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedItems := map[string]string{
+		"url":      "s3://dropbox/partner/",
+		"queue":    "https://sqs.us-east-1.amazonaws.com/123456789012/dropbox",
+		"template": `{"ladder":"hls_sd","provider":"zencoder"}`,
+	}
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("Wrong watch folder hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
+	}
+	got, err := repo.GetWatchFolder("partner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, folder) {
+		t.Errorf("Wrong watch folder. Want %#v. Got %#v", folder, *got)
+	}
+	err = repo.CreateWatchFolder(&folder)
+	if err != db.ErrWatchFolderAlreadyExists {
+		t.Errorf("Wrong error returned. Want ErrWatchFolderAlreadyExists. Got %#v", err)
+	}
+}
+
+func TestDeleteWatchFolder(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder := db.WatchFolder{
+		Name:     "partner",
+		URL:      "sftp://partner@sftp.example.com/incoming/",
+		Template: map[string]interface{}{"provider": "zencoder", "ladder": "hls_sd"},
+	}
+	err = repo.CreateWatchFolder(&folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeleteWatchFolder(&folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	folders, err := repo.ListWatchFolders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 0 {
+		t.Errorf("DeleteWatchFolder: watch folder still listed: %#v", folders)
+	}
+	err = repo.DeleteWatchFolder(&folder)
+	if err != db.ErrWatchFolderNotFound {
+		t.Errorf("Wrong error returned. Want ErrWatchFolderNotFound. Got %#v", err)
+	}
+}
@@ -2,14 +2,19 @@ package db
 
 import (
 	"errors"
+	"sort"
 	"time"
 )
 
 var (
-	// ErrJobNotFound is the error returned when the job is not found on GetJob or
-	// DeleteJob.
+	// ErrJobNotFound is the error returned when the job is not found on GetJob,
+	// UpdateJob or DeleteJob.
 	ErrJobNotFound = errors.New("job not found")
 
+	// ErrJobStatusChanged is the error returned by UpdateJobIfStatus when
+	// the stored job doesn't have the expected status anymore.
+	ErrJobStatusChanged = errors.New("job status changed")
+
 	// ErrPresetMapNotFound is the error returned when the presetmap is not found
 	// on GetPresetMap, UpdatePresetMap or DeletePresetMap.
 	ErrPresetMapNotFound = errors.New("presetmap not found")
@@ -25,6 +30,83 @@ var (
 	// ErrLocalPresetAlreadyExists is the error returned when the local preset already
 	// exists.
 	ErrLocalPresetAlreadyExists = errors.New("local preset already exists")
+
+	// ErrTenantNotFound is the error returned when the tenant is not found on
+	// GetTenant, UpdateTenant or DeleteTenant.
+	ErrTenantNotFound = errors.New("tenant not found")
+
+	// ErrTenantAlreadyExists is the error returned when the tenant already
+	// exists.
+	ErrTenantAlreadyExists = errors.New("tenant already exists")
+
+	// ErrArtifactAlreadyExists is the error returned when the job already
+	// has an artifact with the same name.
+	ErrArtifactAlreadyExists = errors.New("artifact already exists")
+
+	// ErrExperimentNotFound is the error returned when the experiment is not
+	// found on GetExperiment or DeleteExperiment.
+	ErrExperimentNotFound = errors.New("experiment not found")
+
+	// ErrExperimentAlreadyExists is the error returned when the experiment
+	// already exists.
+	ErrExperimentAlreadyExists = errors.New("experiment already exists")
+
+	// ErrDeliveryTargetNotFound is the error returned when the delivery
+	// target is not found on GetDeliveryTarget, UpdateDeliveryTarget or
+	// DeleteDeliveryTarget.
+	ErrDeliveryTargetNotFound = errors.New("delivery target not found")
+
+	// ErrDeliveryTargetAlreadyExists is the error returned when the
+	// delivery target already exists.
+	ErrDeliveryTargetAlreadyExists = errors.New("delivery target already exists")
+
+	// ErrLadderNotFound is the error returned when the ladder is not found
+	// on GetLadder, UpdateLadder or DeleteLadder.
+	ErrLadderNotFound = errors.New("ladder not found")
+
+	// ErrLadderAlreadyExists is the error returned when the ladder already
+	// exists.
+	ErrLadderAlreadyExists = errors.New("ladder already exists")
+
+	// ErrWatchFolderNotFound is the error returned when the watch folder
+	// is not found on GetWatchFolder or DeleteWatchFolder.
+	ErrWatchFolderNotFound = errors.New("watch folder not found")
+
+	// ErrWatchFolderAlreadyExists is the error returned when the watch
+	// folder already exists.
+	ErrWatchFolderAlreadyExists = errors.New("watch folder already exists")
+
+	// ErrCampaignNotFound is the error returned when the campaign is not
+	// found on GetCampaign, UpdateCampaign or DeleteCampaign.
+	ErrCampaignNotFound = errors.New("campaign not found")
+
+	// ErrCampaignAlreadyExists is the error returned when the campaign
+	// already exists.
+	ErrCampaignAlreadyExists = errors.New("campaign already exists")
+
+	// ErrSubmissionPauseNotFound is the error returned when the submission
+	// pause is not found on DeleteSubmissionPause.
+	ErrSubmissionPauseNotFound = errors.New("submission pause not found")
+
+	// ErrSubmissionPauseAlreadyExists is the error returned when the
+	// submission pause already exists.
+	ErrSubmissionPauseAlreadyExists = errors.New("submission pause already exists")
+
+	// ErrMaintenanceModeNotFound is the error returned by
+	// GetMaintenanceMode when the mode was never switched.
+	ErrMaintenanceModeNotFound = errors.New("maintenance mode not found")
+
+	// ErrProviderThroughputNotFound is the error returned by
+	// GetProviderThroughput when no job of the provider finished yet.
+	ErrProviderThroughputNotFound = errors.New("provider throughput not found")
+
+	// ErrNotificationNotFound is the error returned when the notification
+	// is not found on UpdateNotification or DeleteNotification.
+	ErrNotificationNotFound = errors.New("notification not found")
+
+	// ErrNotificationAlreadyExists is the error returned when the
+	// notification already exists.
+	ErrNotificationAlreadyExists = errors.New("notification already exists")
 )
 
 // Repository represents the repository for persisting types of the API.
@@ -32,35 +114,189 @@ type Repository interface {
 	JobRepository
 	PresetMapRepository
 	LocalPresetRepository
+	TenantRepository
+	ArtifactRepository
+	ExperimentRepository
+	DeliveryTargetRepository
+	LadderRepository
+	WatchFolderRepository
+	SubmissionPauseRepository
+	MaintenanceRepository
+	ThroughputRepository
+	NotificationRepository
+	CampaignRepository
+	SourceIndexRepository
 }
 
 // JobRepository is the interface that defines the set of methods for managing Job
 // persistence.
 type JobRepository interface {
 	CreateJob(*Job) error
+	UpdateJob(*Job) error
+
+	// UpdateJobIfStatus stores the job only if the stored version of the
+	// job has the given status, returning ErrJobStatusChanged otherwise.
+	// It's used for claiming jobs, so that only one instance of the API
+	// acts on them.
+	UpdateJobIfStatus(job *Job, status string) error
+
 	DeleteJob(*Job) error
 	GetJob(id string) (*Job, error)
 	ListJobs(JobFilter) ([]Job, error)
+
+	// ListJobsByExternalID returns the jobs of the given tenant with the
+	// given external id, sorted by creation time.
+	ListJobsByExternalID(tenant, externalID string) ([]Job, error)
+
+	// GetJobByProviderJobID returns the job submitted to the given
+	// provider with the given provider job id.
+	GetJobByProviderJobID(providerName, providerJobID string) (*Job, error)
+
+	// NextJobSequence atomically increments and returns the sequence
+	// number used for generating ids for jobs of the given tenant.
+	NextJobSequence(tenant string) (uint64, error)
+}
+
+// JobSweeper is implemented by repositories where jobs expire on their own,
+// leaving entries behind in the indexes of jobs.
+type JobSweeper interface {
+	// SweepExpiredJobs removes the index entries of the jobs that expired,
+	// returning the number of jobs swept.
+	SweepExpiredJobs() (int, error)
 }
 
 // JobFilter contains a set of parameters for filtering the list of jobs in
-// JobRepository.
+// JobRepository. Jobs are listed by creation time, oldest first.
 type JobFilter struct {
 	// Filter jobs since the given time.
 	Since time.Time
 
+	// Filter jobs created before the given time. The zero value means no
+	// upper bound.
+	Until time.Time
+
+	// Filter jobs with the given last known status.
+	Status string
+
+	// Filter jobs submitted to the given provider.
+	ProviderName string
+
+	// Skip the given number of matching jobs, for paginating the list.
+	Offset uint
+
 	// Limit the number of jobs in the result. 0 means no limit.
 	Limit uint
 }
 
 // PresetMapRepository is the interface that defines the set of methods for
 // managing PresetMap persistence.
+//
+// Presetmaps are versioned: updating or deleting a presetmap stores the
+// version it replaces along with the change, and the Version of the
+// presetmap is set to the number following the last stored version. Versions
+// are kept when the presetmap is deleted, so a version number never refers
+// to two different definitions of a presetmap with the same name.
 type PresetMapRepository interface {
 	CreatePresetMap(*PresetMap) error
 	UpdatePresetMap(*PresetMap) error
 	DeletePresetMap(*PresetMap) error
 	GetPresetMap(name string) (*PresetMap, error)
-	ListPresetMaps() ([]PresetMap, error)
+	ListPresetMaps(PresetMapFilter) ([]PresetMap, error)
+
+	// ListPresetMapVersions returns the previous versions of the given
+	// presetmap, oldest first.
+	ListPresetMapVersions(name string) ([]PresetMapVersion, error)
+}
+
+// Fields for sorting the list of presetmaps in PresetMapRepository.
+const (
+	PresetMapSortName      = "name"
+	PresetMapSortContainer = "container"
+)
+
+// PresetMapFilter contains a set of parameters for filtering, sorting and
+// paginating the list of presetmaps in PresetMapRepository. The zero value
+// lists all presetmaps by name.
+type PresetMapFilter struct {
+	// Filter presetmaps owned by the given tenant.
+	Tenant string
+
+	// Filter presetmaps with the given container, as in the extension of
+	// their outputs.
+	Container string
+
+	// Filter presetmaps with the given video or audio codec.
+	Codec string
+
+	// Sort presetmaps by the given field, PresetMapSortName by default.
+	// Presetmaps with the same value are sorted by name.
+	SortBy string
+
+	// Sort presetmaps in descending order.
+	Descending bool
+
+	// Skip the given number of matching presetmaps, for paginating the
+	// list.
+	Offset uint
+
+	// Limit the number of presetmaps in the result. 0 means no limit.
+	Limit uint
+}
+
+// Match returns whether the given presetmap matches the filter.
+func (f PresetMapFilter) Match(presetMap *PresetMap) bool {
+	if f.Tenant != "" && presetMap.Tenant != f.Tenant {
+		return false
+	}
+	if f.Container != "" && presetMap.OutputOpts.Extension != f.Container {
+		return false
+	}
+	return f.Codec == "" || presetMap.OutputOpts.VideoCodec == f.Codec || presetMap.OutputOpts.AudioCodec == f.Codec
+}
+
+// Apply returns the page of the given presetmaps matching the filter, in
+// the order of the filter. It's used by repositories that can't filter
+// presetmaps on their own.
+func (f PresetMapFilter) Apply(presetMaps []PresetMap) []PresetMap {
+	matches := make([]PresetMap, 0, len(presetMaps))
+	for i := range presetMaps {
+		if f.Match(&presetMaps[i]) {
+			matches = append(matches, presetMaps[i])
+		}
+	}
+	sort.Sort(presetMapSorter{presetMaps: matches, filter: f})
+	if f.Offset >= uint(len(matches)) {
+		return []PresetMap{}
+	}
+	matches = matches[f.Offset:]
+	if f.Limit > 0 && f.Limit < uint(len(matches)) {
+		matches = matches[:f.Limit]
+	}
+	return matches
+}
+
+type presetMapSorter struct {
+	presetMaps []PresetMap
+	filter     PresetMapFilter
+}
+
+func (s presetMapSorter) Len() int {
+	return len(s.presetMaps)
+}
+
+func (s presetMapSorter) Less(i, j int) bool {
+	a, b := &s.presetMaps[i], &s.presetMaps[j]
+	if s.filter.Descending {
+		a, b = b, a
+	}
+	if s.filter.SortBy == PresetMapSortContainer && a.OutputOpts.Extension != b.OutputOpts.Extension {
+		return a.OutputOpts.Extension < b.OutputOpts.Extension
+	}
+	return a.Name < b.Name
+}
+
+func (s presetMapSorter) Swap(i, j int) {
+	s.presetMaps[i], s.presetMaps[j] = s.presetMaps[j], s.presetMaps[i]
 }
 
 // LocalPresetRepository provides an interface that defines the set of methods for
@@ -71,3 +307,118 @@ type LocalPresetRepository interface {
 	DeleteLocalPreset(*LocalPreset) error
 	GetLocalPreset(name string) (*LocalPreset, error)
 }
+
+// TenantRepository is the interface that defines the set of methods for
+// managing Tenant persistence.
+type TenantRepository interface {
+	CreateTenant(*Tenant) error
+	UpdateTenant(*Tenant) error
+	DeleteTenant(*Tenant) error
+	GetTenant(name string) (*Tenant, error)
+	ListTenants() ([]Tenant, error)
+}
+
+// ArtifactRepository is the interface that defines the set of methods for
+// managing the persistence of artifacts attached to jobs.
+type ArtifactRepository interface {
+	CreateArtifact(*Artifact) error
+	ListArtifacts(jobID string) ([]Artifact, error)
+}
+
+// SourceIndexRepository is the interface that defines the set of methods for
+// managing the index of finished jobs by source checksum.
+type SourceIndexRepository interface {
+	// SaveSourceEncode stores the entry, replacing any previous entry of
+	// the same job.
+	SaveSourceEncode(*SourceEncode) error
+	ListSourceEncodes(checksum string) ([]SourceEncode, error)
+}
+
+// ExperimentRepository is the interface that defines the set of methods for
+// managing experiments and the samples collected for them.
+type ExperimentRepository interface {
+	CreateExperiment(*Experiment) error
+	DeleteExperiment(*Experiment) error
+	GetExperiment(name string) (*Experiment, error)
+	ListExperiments() ([]Experiment, error)
+
+	// SaveExperimentSample stores the sample, replacing any previous
+	// sample of the same job.
+	SaveExperimentSample(*ExperimentSample) error
+	ListExperimentSamples(experiment string) ([]ExperimentSample, error)
+}
+
+// DeliveryTargetRepository is the interface that defines the set of methods
+// for managing DeliveryTarget persistence.
+type DeliveryTargetRepository interface {
+	CreateDeliveryTarget(*DeliveryTarget) error
+	UpdateDeliveryTarget(*DeliveryTarget) error
+	DeleteDeliveryTarget(*DeliveryTarget) error
+	GetDeliveryTarget(name string) (*DeliveryTarget, error)
+	ListDeliveryTargets() ([]DeliveryTarget, error)
+}
+
+// LadderRepository is the interface that defines the set of methods for
+// managing Ladder persistence.
+type LadderRepository interface {
+	CreateLadder(*Ladder) error
+	UpdateLadder(*Ladder) error
+	DeleteLadder(*Ladder) error
+	GetLadder(name string) (*Ladder, error)
+	ListLadders() ([]Ladder, error)
+}
+
+// WatchFolderRepository is the interface that defines the set of methods for
+// managing WatchFolder persistence.
+type WatchFolderRepository interface {
+	CreateWatchFolder(*WatchFolder) error
+	DeleteWatchFolder(*WatchFolder) error
+	GetWatchFolder(name string) (*WatchFolder, error)
+	ListWatchFolders() ([]WatchFolder, error)
+}
+
+// SubmissionPauseRepository is the interface that defines the set of methods
+// for managing SubmissionPause persistence.
+type SubmissionPauseRepository interface {
+	CreateSubmissionPause(*SubmissionPause) error
+	DeleteSubmissionPause(*SubmissionPause) error
+	ListSubmissionPauses() ([]SubmissionPause, error)
+}
+
+// MaintenanceRepository is the interface that defines the set of methods
+// for managing the persistence of the MaintenanceMode.
+type MaintenanceRepository interface {
+	// SetMaintenanceMode stores the mode, replacing the previous one.
+	SetMaintenanceMode(*MaintenanceMode) error
+	GetMaintenanceMode() (*MaintenanceMode, error)
+}
+
+// ThroughputRepository is the interface that defines the set of methods for
+// managing the persistence of the ProviderThroughput of each provider.
+type ThroughputRepository interface {
+	// SaveProviderThroughput stores the throughput, replacing the previous
+	// one of the same provider.
+	SaveProviderThroughput(*ProviderThroughput) error
+	GetProviderThroughput(providerName string) (*ProviderThroughput, error)
+}
+
+// NotificationRepository is the interface that defines the set of methods
+// for managing the persistence of queued Notifications.
+type NotificationRepository interface {
+	CreateNotification(*Notification) error
+	UpdateNotification(*Notification) error
+	DeleteNotification(*Notification) error
+
+	// ListNotifications returns the queued notifications, sorted by id.
+	ListNotifications() ([]Notification, error)
+}
+
+// CampaignRepository is the interface that defines the set of methods for
+// managing Campaign persistence.
+type CampaignRepository interface {
+	CreateCampaign(*Campaign) error
+	UpdateCampaign(*Campaign) error
+	DeleteCampaign(*Campaign) error
+	GetCampaign(name string) (*Campaign, error)
+	ListCampaigns() ([]Campaign, error)
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPresetMapFilterApply(t *testing.T) {
+	presetMaps := []PresetMap{
+		{Name: "webm_720p", OutputOpts: OutputOptions{Extension: "webm", VideoCodec: "vp8", AudioCodec: "vorbis"}},
+		{Name: "mp4_1080p", OutputOpts: OutputOptions{Extension: "mp4", VideoCodec: "h264", AudioCodec: "aac"}, Tenant: "newsroom"},
+		{Name: "hls_720p", OutputOpts: OutputOptions{Extension: "m3u8", VideoCodec: "h264", AudioCodec: "aac"}},
+		{Name: "m4a_128k", OutputOpts: OutputOptions{Extension: "m4a", AudioCodec: "aac"}, Tenant: "newsroom"},
+		{Name: "mp4_720p", OutputOpts: OutputOptions{Extension: "mp4", VideoCodec: "h264", AudioCodec: "aac"}},
+	}
+	var tests = []struct {
+		testCase string
+		filter   PresetMapFilter
+		want     []string
+	}{
+		{
+			"all presetmaps",
+			PresetMapFilter{},
+			[]string{"hls_720p", "m4a_128k", "mp4_1080p", "mp4_720p", "webm_720p"},
+		},
+		{
+			"by tenant",
+			PresetMapFilter{Tenant: "newsroom"},
+			[]string{"m4a_128k", "mp4_1080p"},
+		},
+		{
+			"by container",
+			PresetMapFilter{Container: "mp4"},
+			[]string{"mp4_1080p", "mp4_720p"},
+		},
+		{
+			"by video codec",
+			PresetMapFilter{Codec: "h264", Tenant: "newsroom"},
+			[]string{"mp4_1080p"},
+		},
+		{
+			"by audio codec",
+			PresetMapFilter{Codec: "aac"},
+			[]string{"hls_720p", "m4a_128k", "mp4_1080p", "mp4_720p"},
+		},
+		{
+			"descending",
+			PresetMapFilter{Descending: true},
+			[]string{"webm_720p", "mp4_720p", "mp4_1080p", "m4a_128k", "hls_720p"},
+		},
+		{
+			"sorted by container, descending",
+			PresetMapFilter{SortBy: PresetMapSortContainer, Descending: true},
+			[]string{"webm_720p", "mp4_720p", "mp4_1080p", "m4a_128k", "hls_720p"},
+		},
+		{
+			"sorted by container",
+			PresetMapFilter{SortBy: PresetMapSortContainer, Codec: "aac"},
+			[]string{"hls_720p", "m4a_128k", "mp4_1080p", "mp4_720p"},
+		},
+		{
+			"paginated",
+			PresetMapFilter{Offset: 1, Limit: 2},
+			[]string{"m4a_128k", "mp4_1080p"},
+		},
+		{
+			"past the last page",
+			PresetMapFilter{Offset: 5, Limit: 2},
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, presetMap := range test.filter.Apply(presetMaps) {
+			got = append(got, presetMap.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: wrong presetmaps.\nWant %#v\nGot  %#v", test.testCase, test.want, got)
+		}
+	}
+}
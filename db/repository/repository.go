@@ -0,0 +1,31 @@
+// Package repository creates the db.Repository of the database selected in
+// the configuration of the API.
+package repository
+
+import (
+	"fmt"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dynamodb"
+	"github.com/NYTimes/video-transcoding-api/db/memory"
+	"github.com/NYTimes/video-transcoding-api/db/postgres"
+	"github.com/NYTimes/video-transcoding-api/db/redis"
+)
+
+// New creates the repository of the database selected by the
+// DatabaseDriver of the given configuration, defaulting to Redis.
+func New(cfg *config.Config) (db.Repository, error) {
+	switch cfg.DatabaseDriver {
+	case "", "redis":
+		return redis.NewRepository(cfg)
+	case "postgres":
+		return postgres.NewRepository(cfg)
+	case "dynamodb":
+		return dynamodb.NewRepository(cfg)
+	case "memory":
+		return memory.NewRepository(cfg)
+	default:
+		return nil, fmt.Errorf("invalid database driver %q", cfg.DatabaseDriver)
+	}
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+)
+
+func TestNewInvalidDriver(t *testing.T) {
+	_, err := New(&config.Config{DatabaseDriver: "mongodb"})
+	if err == nil || err.Error() != `invalid database driver "mongodb"` {
+		t.Errorf("wrong error returned. Want %q. Got %v", `invalid database driver "mongodb"`, err)
+	}
+}
@@ -1,7 +1,15 @@
 package db
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,36 +29,1811 @@ type Job struct {
 	// required: true
 	ProviderName string `redis-hash:"providerName" json:"providerName"`
 
+	// name of the provider requested when creating the job, when the
+	// submission to it failed and the job was accepted by one of the
+	// fallback providers (ProviderName)
+	//
+	// required: false
+	RequestedProvider string `redis-hash:"requestedProvider,omitempty" json:"requestedProvider,omitempty"`
+
 	// id of the job on the provider
 	//
 	// required: true
-	ProviderJobID string `redis-hash:"providerJobID" json:"providerJobId"`
+	ProviderJobID string `redis-hash:"providerJobID" json:"providerJobId"`
+
+	// configuration for adaptive streaming jobs
+	// Defaults to false.
+	//
+	// required: false
+	StreamingParams StreamingParams `redis-hash:"streamingparams,expand" json:"streamingParams,omitempty"`
+
+	// name of the tenant that submitted the job
+	//
+	// required: false
+	Tenant string `redis-hash:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// id of the job in the system of the client (for example, the id of
+	// the asset in the CMS)
+	//
+	// required: false
+	ExternalID string `redis-hash:"externalId,omitempty" json:"externalId,omitempty"`
+
+	// labels of the job, used for filtering the feed of finished jobs
+	//
+	// required: false
+	Labels []string `redis-hash:"labels,omitempty" json:"labels,omitempty"`
+
+	// environment of the providers used by the job. Empty for production,
+	// or "sandbox" for jobs submitted to the sandbox of the provider.
+	//
+	// required: false
+	Environment string `redis-hash:"environment,omitempty" json:"environment,omitempty"`
+
+	// priority of the job: low, normal or high. Empty for normal.
+	//
+	// required: false
+	Priority string `redis-hash:"priority,omitempty" json:"priority,omitempty"`
+
+	// completion deadline of the job, and the actions taken by the API
+	// for meeting it
+	//
+	// required: false
+	Deadline *Deadline `redis-hash:"deadline,json,omitempty" json:"deadline,omitempty"`
+
+	// source media of the job. When the job falls back to another source,
+	// this is the source currently in use.
+	//
+	// required: false
+	SourceMedia string `redis-hash:"source,omitempty" json:"source,omitempty"`
+
+	// checksum of the source media, in the format "algorithm:digest".
+	// Finished jobs are indexed by the checksum of their sources.
+	//
+	// required: false
+	SourceChecksum string `redis-hash:"sourceChecksum,omitempty" json:"sourceChecksum,omitempty"`
+
+	// alternative sources, tried in order when the provider fails to read
+	// the source media
+	//
+	// required: false
+	FallbackSources []string `redis-hash:"fallbackSources,omitempty" json:"fallbackSources,omitempty"`
+
+	// sources that the provider failed to read, in the order they were
+	// tried
+	//
+	// required: false
+	FailedSources []string `redis-hash:"failedSources,omitempty" json:"failedSources,omitempty"`
+
+	// encryption of the sources of the job, for sources encrypted at rest
+	// with customer keys
+	//
+	// required: false
+	SourceEncryption *SourceEncryption `redis-hash:"sourceEncryption,json,omitempty" json:"sourceEncryption,omitempty"`
+
+	// DRM of the adaptive streaming outputs of the job
+	//
+	// required: false
+	DRM *DRM `redis-hash:"drm,json,omitempty" json:"drm,omitempty"`
+
+	// hex encoded content key of the DRM of the job. It's stored for
+	// resubmitting the job, but never returned by the API.
+	DRMKey string `redis-hash:"drmKey,omitempty" json:"-"`
+
+	// atomic publication of the outputs of the job
+	//
+	// required: false
+	Publish *Publish `redis-hash:"publish,json,omitempty" json:"publish,omitempty"`
+
+	// thumbnails generated by the job
+	//
+	// required: false
+	Thumbnails *Thumbnails `redis-hash:"thumbnails,json,omitempty" json:"thumbnails,omitempty"`
+
+	// captions ingested in the job
+	//
+	// required: false
+	Captions []Caption `redis-hash:"captions,json,omitempty" json:"captions,omitempty"`
+
+	// HTTP headers and custom metadata set on the output files
+	//
+	// required: false
+	OutputHeaders []OutputHeaders `redis-hash:"outputHeaders,json,omitempty" json:"outputHeaders,omitempty"`
+
+	// ranges of the source included in the outputs, for conform jobs
+	//
+	// required: false
+	Conform *Conform `redis-hash:"conform,json,omitempty" json:"conform,omitempty"`
+
+	// trimming of the black frames and the silence padding the source
+	//
+	// required: false
+	Trim *Trim `redis-hash:"trim,json,omitempty" json:"trim,omitempty"`
+
+	// range of the source included in the outputs, for partial
+	// transcodes
+	//
+	// required: false
+	Clip *Clip `redis-hash:"clip,json,omitempty" json:"clip,omitempty"`
+
+	// audio description of the video, delivered as an alternate audio
+	// rendition of adaptive streaming outputs
+	//
+	// required: false
+	DescribedAudio *DescribedAudio `redis-hash:"describedAudio,json,omitempty" json:"describedAudio,omitempty"`
+
+	// audio tracks of the source included in the outputs. Jobs with
+	// several tracks deliver each of them as an alternate audio rendition
+	// of adaptive streaming outputs.
+	//
+	// required: false
+	AudioTracks []AudioTrack `redis-hash:"audioTracks,json,omitempty" json:"audioTracks,omitempty"`
+
+	// base destination of the outputs of the job. When empty, providers
+	// use the destination in their configuration.
+	//
+	// required: false
+	Destination string `redis-hash:"destination,omitempty" json:"destination,omitempty"`
+
+	// URL that should be notified about the job
+	//
+	// required: false
+	CallbackURL string `redis-hash:"callbackURL,omitempty" json:"callbackURL,omitempty"`
+
+	// statuses that trigger notifications to the callback URL. Empty for
+	// all statuses.
+	//
+	// required: false
+	NotifyOn []string `redis-hash:"notifyOn,omitempty" json:"notifyOn,omitempty"`
+
+	// payload of the notifications: "full" (the default) for the whole
+	// status of the job, or "slim" for the id and the status of the job
+	//
+	// required: false
+	CallbackPayload string `redis-hash:"callbackPayload,omitempty" json:"callbackPayload,omitempty"`
+
+	// language of the audio in the source media
+	//
+	// required: false
+	Language string `redis-hash:"language,omitempty" json:"language,omitempty"`
+
+	// filters applied to the video of the outputs, from the request or the
+	// defaults of the tenant
+	//
+	// required: false
+	Filters *VideoFilters `redis-hash:"filters,json,omitempty" json:"filters,omitempty"`
+
+	// rotation of the video of the source. Empty when the video is
+	// rotated following the rotation metadata of the source.
+	//
+	// required: false
+	Rotation *Rotation `redis-hash:"rotation,json,omitempty" json:"rotation,omitempty"`
+
+	// list of outputs of the job, after resolving defaults
+	//
+	// required: false
+	Outputs []TranscodeOutput `redis-hash:"outputs,json,omitempty" json:"outputs,omitempty"`
+
+	// name of the experiment that the job is enrolled in
+	//
+	// required: false
+	Experiment string `redis-hash:"experiment,omitempty" json:"experiment,omitempty"`
+
+	// name of the experiment variant used for encoding the job
+	//
+	// required: false
+	ExperimentVariant string `redis-hash:"experimentVariant,omitempty" json:"experimentVariant,omitempty"`
+
+	// name of the delivery target of the outputs
+	//
+	// required: false
+	DeliveryTarget string `redis-hash:"deliveryTarget,omitempty" json:"deliveryTarget,omitempty"`
+
+	// name of the ladder that the outputs of the job were taken from
+	//
+	// required: false
+	Ladder string `redis-hash:"ladder,omitempty" json:"ladder,omitempty"`
+
+	// base URL of the CDN serving the outputs, resolved from the delivery
+	// target when the job was created
+	//
+	// required: false
+	CDNBaseURL string `redis-hash:"cdnBaseURL,omitempty" json:"cdnBaseURL,omitempty"`
+
+	// final destination of the outputs, for destinations that providers
+	// can't write to (like NetStorage). Outputs are written to Destination
+	// and uploaded by the API once the job finishes.
+	//
+	// required: false
+	UploadDestination string `redis-hash:"uploadDestination,omitempty" json:"uploadDestination,omitempty"`
+
+	// status of the uploads of the outputs of the job to the upload
+	// destination, once the job finishes
+	//
+	// required: false
+	Uploads []FileUpload `redis-hash:"uploads,json,omitempty" json:"uploads,omitempty"`
+
+	// data key used for encrypting the outputs of the job at rest, wrapped
+	// by KMS
+	//
+	// required: false
+	OutputEncryption *OutputEncryption `redis-hash:"outputEncryption,json,omitempty" json:"outputEncryption,omitempty"`
+
+	// whether perceptual fingerprints of the outputs are computed once the
+	// job finishes
+	//
+	// required: false
+	Fingerprint bool `redis-hash:"fingerprint,omitempty" json:"fingerprint,omitempty"`
+
+	// perceptual fingerprints of the video outputs of the job
+	//
+	// required: false
+	Fingerprints []OutputFingerprint `redis-hash:"fingerprints,json,omitempty" json:"fingerprints,omitempty"`
+
+	// thresholds of the audio QC of the outputs, and its outcome once the
+	// job finishes
+	//
+	// required: false
+	AudioQC *AudioQC `redis-hash:"audioQC,json,omitempty" json:"audioQC,omitempty"`
+
+	// settings of the photosensitivity (flash) test of the outputs, and
+	// its outcome once the job finishes
+	//
+	// required: false
+	Photosensitivity *PhotosensitivityCheck `redis-hash:"photosensitivity,json,omitempty" json:"photosensitivity,omitempty"`
+
+	// thresholds of the video QC of the outputs, and its outcome once the
+	// job finishes
+	//
+	// required: false
+	VideoQC *VideoQC `redis-hash:"videoQC,json,omitempty" json:"videoQC,omitempty"`
+
+	// settings of the poster frame extracted from the outputs, and the
+	// chosen frame once the job finishes
+	//
+	// required: false
+	Poster *Poster `redis-hash:"poster,json,omitempty" json:"poster,omitempty"`
+
+	// results of the post-processors run by the API once the job finished
+	//
+	// required: false
+	PostProcessors []PostProcessorResult `redis-hash:"postProcessors,json,omitempty" json:"postProcessors,omitempty"`
+
+	// Time when an instance of the API claimed the job for running its
+	// post-processors
+	//
+	// required: false
+	PostProcessingTime *time.Time `redis-hash:"postProcessingTime,json,omitempty" json:"postProcessingTime,omitempty"`
+
+	// outcome of the verification of the segments of the adaptive
+	// streaming outputs, once the job finishes
+	//
+	// required: false
+	SegmentVerification *SegmentVerification `redis-hash:"segmentVerification,json,omitempty" json:"segmentVerification,omitempty"`
+
+	// last status of the job known by the API. It's updated whenever the
+	// status of the job is retrieved from the provider.
+	//
+	// required: false
+	Status string `redis-hash:"status,omitempty" json:"status,omitempty"`
+
+	// message describing the status of jobs that were never submitted to
+	// the provider (for example, jobs that failed to leave the local queue)
+	//
+	// required: false
+	StatusMessage string `redis-hash:"statusMessage,omitempty" json:"statusMessage,omitempty"`
+
+	// JSON encoded status of the job loaded by the status poller, and the
+	// time it was loaded. Reads of the status of the job are served from
+	// it while it's fresh.
+	StatusSnapshot     string    `redis-hash:"statusSnapshot,omitempty" json:"-"`
+	StatusSnapshotTime time.Time `redis-hash:"statusSnapshotTime" json:"-"`
+
+	// Time when the API recorded the job as finished
+	//
+	// required: false
+	FinishTime *time.Time `redis-hash:"finishTime,json,omitempty" json:"finishTime,omitempty"`
+
+	// Time of the creation of the job in the API
+	//
+	// required: true
+	CreationTime time.Time `redis-hash:"creationTime" json:"creationTime"`
+}
+
+// EnvironmentSandbox is the environment of jobs submitted to the sandbox of
+// the providers.
+const EnvironmentSandbox = "sandbox"
+
+// Payloads of the notifications sent to the callback URL of jobs.
+const (
+	CallbackPayloadFull = "full"
+	CallbackPayloadSlim = "slim"
+)
+
+// Priorities of jobs.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// Deadline is the completion deadline of a job, along with the actions taken
+// by the API when the job was at risk of missing it.
+type Deadline struct {
+	// time by which the job should be finished
+	Time time.Time `json:"time"`
+
+	// time the job was submitted to its current provider
+	SubmissionTime time.Time `json:"submissionTime"`
+
+	// whether the job was escalated for being at risk of missing the
+	// deadline, with no faster provider to fail over to
+	Escalated bool `json:"escalated,omitempty"`
+
+	// providers that the job was moved away from for being at risk of
+	// missing the deadline in them, in order
+	FailedOver []string `json:"failedOver,omitempty"`
+}
+
+// Encryption modes of sources encrypted at rest.
+const (
+	// SourceEncryptionAES128 is the mode of sources encrypted with
+	// AES-128 in CBC mode, with PKCS#7 padding.
+	SourceEncryptionAES128 = "aes-128"
+
+	// SourceEncryptionSSEC is the mode of sources stored in S3 with
+	// server-side encryption using customer provided keys (SSE-C).
+	SourceEncryptionSSEC = "sse-c"
+)
+
+// SourceEncryption describes how the sources of a job are encrypted at rest.
+// Keys are never sent in requests or stored with jobs: KeyReference is the
+// name of the key in the key store of the API.
+//
+// swagger:model
+type SourceEncryption struct {
+	// encryption mode of the sources (aes-128 or sse-c)
+	//
+	// required: true
+	Mode string `json:"mode"`
+
+	// name of the key in the key store of the API
+	//
+	// required: true
+	KeyReference string `json:"keyReference"`
+
+	// hex encoded initialization vector, required for aes-128
+	//
+	// required: false
+	IV string `json:"iv,omitempty"`
+}
+
+// DRM describes the encryption of the adaptive streaming outputs of a job.
+// The content key is stored in Job.DRMKey.
+//
+// swagger:model
+type DRM struct {
+	// DRM scheme (widevine, fairplay, playready or aes-128)
+	//
+	// required: true
+	Scheme string `json:"scheme"`
+
+	// hex encoded id of the content key
+	//
+	// required: false
+	KeyID string `json:"keyId,omitempty"`
+
+	// URL of the license server (or of the key, for aes-128)
+	//
+	// required: true
+	LicenseURL string `json:"licenseUrl"`
+
+	// hex encoded initialization vector
+	//
+	// required: false
+	IV string `json:"iv,omitempty"`
+}
+
+// Publish describes the atomic publication of the outputs of a job. The
+// provider writes the outputs to Job.Destination, a staging prefix, and the
+// API moves them to Destination once they pass verification.
+//
+// swagger:model
+type Publish struct {
+	// final destination of the outputs
+	//
+	// required: true
+	Destination string `json:"destination"`
+
+	// whether the outputs were moved to the final destination
+	//
+	// required: true
+	Published bool `json:"published"`
+}
+
+// Thumbnails describes the thumbnails generated by a job.
+//
+// swagger:model
+type Thumbnails struct {
+	// time between thumbnails, in seconds
+	//
+	// required: false
+	Interval uint `json:"interval,omitempty"`
+
+	// number of thumbnails, evenly spaced over the source
+	//
+	// required: false
+	Count uint `json:"count,omitempty"`
+
+	// maximum width of the thumbnails
+	//
+	// required: false
+	Width uint `json:"width,omitempty"`
+
+	// maximum height of the thumbnails
+	//
+	// required: false
+	Height uint `json:"height,omitempty"`
+
+	// image format of the thumbnails (jpg or png), or empty for the format
+	// of the provider
+	//
+	// required: false
+	Format string `json:"format,omitempty"`
+}
+
+// Caption is a caption (or subtitle) file ingested in a job.
+//
+// swagger:model
+type Caption struct {
+	// URL of the caption file
+	//
+	// required: true
+	Source string `json:"source"`
+
+	// language of the captions, as an ISO 639 code
+	//
+	// required: true
+	Language string `json:"language"`
+
+	// format of the caption file (srt, webvtt or ttml)
+	//
+	// required: true
+	Format string `json:"format"`
+
+	// sidecar (delivered as a caption file along with the outputs) or
+	// burn-in (rendered in the video of the outputs)
+	//
+	// required: true
+	Mode string `json:"mode"`
+
+	// name of the delivered caption file, for sidecar captions
+	//
+	// required: false
+	FileName string `json:"fileName,omitempty"`
+
+	// time the last cue ends, in seconds, for captions converted by the
+	// API. It's checked against the duration of the source once the job
+	// finishes.
+	//
+	// required: false
+	End float64 `json:"end,omitempty"`
+}
+
+// OutputHeaders are the HTTP headers and the custom metadata set on the
+// output files matching the given extension and preset. Rules without
+// extension and preset match all files.
+//
+// swagger:model
+type OutputHeaders struct {
+	// extension of the matching files (for example, "m3u8" or "ts")
+	//
+	// required: false
+	Extension string `json:"extension,omitempty"`
+
+	// name of the presetmap of the matching outputs
+	//
+	// required: false
+	Preset string `json:"preset,omitempty"`
+
+	// value of the Cache-Control header
+	//
+	// required: false
+	CacheControl string `json:"cacheControl,omitempty"`
+
+	// value of the Content-Disposition header
+	//
+	// required: false
+	ContentDisposition string `json:"contentDisposition,omitempty"`
+
+	// custom metadata of the files (x-amz-meta-* headers, in S3)
+	//
+	// required: false
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// OutputEncryption holds the per-job data key used for encrypting the
+// outputs of a job at rest (S3 SSE-C). The data key is generated by KMS and
+// only its wrapped (encrypted) version is stored, so it can only be
+// retrieved through the API, after the embargo.
+//
+// swagger:model
+type OutputEncryption struct {
+	// id of the KMS key that wraps the data key
+	//
+	// required: true
+	KMSKeyID string `json:"kmsKeyId"`
+
+	// base64 encoded data key, encrypted by KMS
+	//
+	// required: true
+	WrappedKey string `json:"wrappedKey"`
+
+	// the data key can't be retrieved before this time
+	//
+	// required: false
+	EmbargoUntil time.Time `json:"embargoUntil"`
+}
+
+// Conform describes the ranges of the source that are transcoded in a
+// conform job. The outputs contain the ranges in order, cut with
+// frame-accurate in and out points.
+//
+// swagger:model
+type Conform struct {
+	// frame rate of the source, used for interpreting the timecodes
+	//
+	// required: true
+	FrameRate float64 `json:"frameRate"`
+
+	// ranges of the source, in the order they appear in the outputs
+	//
+	// required: true
+	Ranges []EditRange `json:"ranges"`
+}
+
+// EditRange is a range of the source in a conform job. In and Out are
+// SMPTE timecodes (HH:MM:SS:FF, or HH:MM:SS;FF for drop-frame) relative to
+// the start of the source, and the frame at Out is not included.
+//
+// swagger:model
+type EditRange struct {
+	// timecode of the first frame of the range
+	//
+	// required: true
+	In string `json:"in"`
+
+	// timecode of the frame after the last frame of the range
+	//
+	// required: true
+	Out string `json:"out"`
+}
+
+// Trim describes the trimming of the leading and trailing black frames and
+// silence of the source of a job. When both are enabled, only the padding
+// that is both black and silent is trimmed. The padding is detected when the
+// job is submitted to the provider, and the ranges that were removed are
+// recorded in Trimmed.
+//
+// swagger:model
+type Trim struct {
+	// trim leading and trailing black frames
+	//
+	// required: false
+	Black bool `json:"black,omitempty"`
+
+	// trim leading and trailing silence
+	//
+	// required: false
+	Silence bool `json:"silence,omitempty"`
+
+	// ranges of the source that were trimmed
+	//
+	// required: false
+	Trimmed []TimeRange `json:"trimmed,omitempty"`
+}
+
+// DescribedAudio is an audio track describing the video for blind and
+// visually impaired viewers. It's provided as an additional input, aligned
+// with the source, and delivered as an alternate audio rendition flagged as
+// describing the video.
+//
+// swagger:model
+type DescribedAudio struct {
+	// URL of the audio file
+	//
+	// required: true
+	Source string `json:"source"`
+
+	// language of the description (ISO 639-2). Defaults to the language
+	// of the job.
+	Language string `json:"language,omitempty"`
+}
+
+// AudioTrack is an audio track of the source included in the outputs of a
+// job, like the dubbed versions of a film or the commentary of a game,
+// labeled with its language and name in the master playlist (or manifest).
+//
+// swagger:model
+type AudioTrack struct {
+	// position of the track among the audio tracks of the source,
+	// starting at 1
+	//
+	// required: true
+	Track int `json:"track"`
+
+	// language of the track (ISO 639-2). Defaults to the language of the
+	// job.
+	//
+	// required: false
+	Language string `json:"language,omitempty"`
+
+	// name of the track, like "English" or "Director's commentary".
+	// Defaults to the language of the track.
+	//
+	// required: false
+	Name string `json:"name,omitempty"`
+
+	// whether players select the track by default. Defaults to the first
+	// track.
+	//
+	// required: false
+	Default bool `json:"default,omitempty"`
+}
+
+// DefaultAudioTrack returns the track of the given audio tracks selected by
+// default, or nil when there are no tracks.
+func DefaultAudioTrack(tracks []AudioTrack) *AudioTrack {
+	for i := range tracks {
+		if tracks[i].Default {
+			return &tracks[i]
+		}
+	}
+	if len(tracks) > 0 {
+		return &tracks[0]
+	}
+	return nil
+}
+
+// Clip is the range of the source transcoded in a partial transcode, in
+// seconds. A zero Duration includes the rest of the source.
+//
+// swagger:model
+type Clip struct {
+	// start of the range, in seconds
+	//
+	// required: true
+	Start float64 `json:"start"`
+
+	// duration of the range, in seconds
+	//
+	// required: false
+	Duration float64 `json:"duration,omitempty"`
+}
+
+// Rotation is the rotation applied to the video of the source, for sources
+// like phone-shot videos, which are stored sideways with metadata telling
+// players how to rotate them.
+//
+// swagger:model
+type Rotation struct {
+	// whether the video is rotated following the rotation metadata of the
+	// source. Ignored when Degrees is set.
+	//
+	// required: false
+	Autorotate bool `json:"autorotate"`
+
+	// clockwise rotation of the video: 90, 180 or 270 degrees
+	//
+	// required: false
+	Degrees int `json:"degrees,omitempty"`
+}
+
+// Auto returns whether the video is rotated following the rotation metadata
+// of the source, which is the default for jobs without rotation settings.
+func (r *Rotation) Auto() bool {
+	return r == nil || (r.Autorotate && r.Degrees == 0)
+}
+
+// Disabled returns whether the video is left as stored in the source,
+// ignoring its rotation metadata.
+func (r *Rotation) Disabled() bool {
+	return r != nil && !r.Autorotate && r.Degrees == 0
+}
+
+// TimeRange is a range of a media file, in seconds.
+//
+// swagger:model
+type TimeRange struct {
+	// start of the range, in seconds
+	//
+	// required: true
+	Start float64 `json:"start"`
+
+	// end of the range, in seconds
+	//
+	// required: true
+	End float64 `json:"end"`
+}
+
+// OutputFingerprint is the perceptual fingerprint of an output, used for
+// matching republished copies of the content back to the job. Hashes are
+// 64-bit difference hashes (dHash) of frames sampled every Interval
+// seconds, hex encoded. Copies of the output have hashes within a small
+// Hamming distance of the original ones.
+//
+// swagger:model
+type OutputFingerprint struct {
+	// path of the output file
+	//
+	// required: true
+	Path string `json:"path"`
+
+	// algorithm used for hashing the frames ("dhash")
+	//
+	// required: true
+	Algorithm string `json:"algorithm"`
+
+	// time between the sampled frames, in seconds
+	//
+	// required: true
+	Interval float64 `json:"interval"`
+
+	// hashes of the sampled frames, in order
+	//
+	// required: true
+	Hashes []string `json:"hashes"`
+}
+
+// FileUpload is the status of the upload of an output file of a job to the
+// upload destination of the job.
+//
+// swagger:model
+type FileUpload struct {
+	// path of the file written by the provider
+	//
+	// required: true
+	Path string `json:"path"`
+
+	// path of the file in the upload destination
+	//
+	// required: true
+	Destination string `json:"destination"`
+
+	// status of the upload: pending, started, finished or failed
+	//
+	// required: true
+	Status string `json:"status"`
+
+	// error returned by the last attempt
+	//
+	// required: false
+	Error string `json:"error,omitempty"`
+
+	// number of attempts made
+	//
+	// required: false
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// SegmentVerification is the outcome of the verification of the segments
+// of the adaptive streaming outputs of a job.
+//
+// swagger:model
+type SegmentVerification struct {
+	// inconsistencies found in the outputs
+	//
+	// required: false
+	Problems []string `json:"problems,omitempty"`
+
+	// error that prevented the verification of the outputs
+	//
+	// required: false
+	Error string `json:"error,omitempty"`
+}
+
+// PostProcessorResult is the result of a post-processor configured in the
+// API, run with the status of a job once it finishes.
+//
+// swagger:model
+type PostProcessorResult struct {
+	// name of the post-processor
+	//
+	// required: true
+	Name string `json:"name"`
+
+	// JSON output of the post-processor
+	//
+	// required: false
+	Output json.RawMessage `json:"output,omitempty"`
+
+	// error returned by the post-processor
+	//
+	// required: false
+	Error string `json:"error,omitempty"`
+
+	// time the post-processor finished
+	//
+	// required: true
+	Time time.Time `json:"time"`
+}
+
+// AudioQC is the quality control of the audio of the outputs of a job. The
+// outputs are checked once the job finishes, and the job fails when any of
+// them violates the thresholds. Zero thresholds aren't enforced.
+//
+// swagger:model
+type AudioQC struct {
+	// target integrated loudness, in LUFS (for example, -23 for EBU R128
+	// or -24 for ATSC A/85)
+	LoudnessTarget float64 `json:"loudnessTarget,omitempty"`
+
+	// maximum distance from the target loudness, in LU
+	LoudnessTolerance float64 `json:"loudnessTolerance,omitempty"`
+
+	// maximum true peak, in dBTP
+	MaxTruePeak *float64 `json:"maxTruePeak,omitempty"`
+
+	// maximum duration of silent ranges, in seconds
+	MaxSilence float64 `json:"maxSilence,omitempty"`
+
+	// fail outputs with clipped samples
+	RejectClipping bool `json:"rejectClipping,omitempty"`
+
+	// whether the outputs were checked
+	Checked bool `json:"checked,omitempty"`
+
+	// thresholds violated by the outputs
+	Violations []string `json:"violations,omitempty"`
+}
+
+// AudioQCReport is the report of the audio QC of an output, attached to the
+// job as an "audio-qc" artifact.
+//
+// swagger:model
+type AudioQCReport struct {
+	// path of the output file
+	//
+	// required: true
+	Path string `json:"path"`
+
+	// integrated loudness, in LUFS
+	//
+	// required: true
+	IntegratedLoudness float64 `json:"integratedLoudness"`
+
+	// loudness range, in LU
+	//
+	// required: true
+	LoudnessRange float64 `json:"loudnessRange"`
+
+	// true peak, in dBTP
+	//
+	// required: true
+	TruePeak float64 `json:"truePeak"`
+
+	// number of clipped samples
+	//
+	// required: true
+	ClippedSamples int64 `json:"clippedSamples"`
+
+	// silent ranges lasting one second or more
+	Silence []TimeRange `json:"silence,omitempty"`
+}
+
+// PhotosensitivityCheck is the photosensitivity test of the outputs of a
+// job, looking for flashing sequences that fail the general flash test of
+// the Harding and the PSE guidelines. The outputs are checked once the job
+// finishes.
+//
+// swagger:model
+type PhotosensitivityCheck struct {
+	// fail the job when an output fails the test
+	Reject bool `json:"reject,omitempty"`
+
+	// whether the outputs were checked
+	Checked bool `json:"checked,omitempty"`
+
+	// sequences of the outputs failing the test
+	Failures []string `json:"failures,omitempty"`
+}
+
+// PhotosensitivityReport is the report of the photosensitivity test of an
+// output, attached to the job as a "photosensitivity" artifact.
+//
+// swagger:model
+type PhotosensitivityReport struct {
+	// path of the output file
+	//
+	// required: true
+	Path string `json:"path"`
+
+	// most flashes in any one-second window
+	//
+	// required: true
+	MaxFlashes int `json:"maxFlashes"`
+
+	// ranges with more flashes per second than allowed
+	Failures []TimeRange `json:"failures,omitempty"`
+}
+
+// VideoQC is the quality control of the video of the outputs of a job,
+// looking for extended black and frozen ranges and for severe macroblocking.
+// The outputs are checked once the job finishes, and the job finishes with
+// warnings (or fails, with Reject) when any of them violates the thresholds.
+//
+// swagger:model
+type VideoQC struct {
+	// maximum duration of black ranges, in seconds
+	MaxBlack float64 `json:"maxBlack,omitempty"`
+
+	// maximum duration of frozen ranges, in seconds
+	MaxFreeze float64 `json:"maxFreeze,omitempty"`
+
+	// blockiness, as measured by the ffmpeg blockdetect filter, from
+	// which frames are considered macroblocked. Zero disables the check.
+	MaxBlockiness float64 `json:"maxBlockiness,omitempty"`
+
+	// fail the job when an output violates the thresholds, instead of
+	// finishing it with warnings
+	Reject bool `json:"reject,omitempty"`
+
+	// whether the outputs were checked
+	Checked bool `json:"checked,omitempty"`
+
+	// thresholds violated by the outputs
+	Violations []string `json:"violations,omitempty"`
+}
+
+// VideoQCReport is the report of the video QC of an output, attached to the
+// job as a "video-qc" artifact.
+//
+// swagger:model
+type VideoQCReport struct {
+	// path of the output file
+	//
+	// required: true
+	Path string `json:"path"`
+
+	// black ranges lasting half a second or more
+	Black []TimeRange `json:"black,omitempty"`
+
+	// frozen ranges lasting half a second or more
+	Frozen []TimeRange `json:"frozen,omitempty"`
+
+	// ranges of macroblocked frames
+	Macroblocked []TimeRange `json:"macroblocked,omitempty"`
+
+	// blockiness of the blockiest frame
+	//
+	// required: true
+	MaxBlockiness float64 `json:"maxBlockiness"`
+}
+
+// Poster is the poster frame of a job, chosen among Candidates frames of
+// the video output with the highest resolution by the given analyzer, and
+// uploaded next to the outputs once the job finishes.
+//
+// swagger:model
+type Poster struct {
+	// number of candidate frames
+	Candidates uint `json:"candidates"`
+
+	// analyzer that scores the candidates
+	Analyzer string `json:"analyzer"`
+
+	// name of the image, relative to the destination of the outputs
+	FileName string `json:"fileName"`
+
+	// path of the uploaded image
+	Path string `json:"path,omitempty"`
+
+	// time of the chosen frame, in seconds
+	Time float64 `json:"time,omitempty"`
+
+	// score of the chosen frame, given by the analyzer
+	Score float64 `json:"score,omitempty"`
+
+	// error that prevented the extraction of the poster frame
+	Error string `json:"error,omitempty"`
+}
+
+// StreamingParams represents the params necessary to create Adaptive Streaming jobs
+//
+// swagger:model
+type StreamingParams struct {
+	// duration of the segment
+	//
+	// required: true
+	SegmentDuration uint `redis-hash:"segmentDuration" json:"segmentDuration"`
+
+	// the protocol name (hls, dash or cmaf)
+	//
+	// required: true
+	Protocol string `redis-hash:"protocol" json:"protocol"`
+
+	// name of the master playlist (or manifest) of the output, defaults to
+	// hls/index.m3u8, dash/index.mpd or cmaf/index.m3u8 depending on the
+	// protocol
+	//
+	// required: false
+	PlaylistFileName string `redis-hash:"playlistFileName,omitempty" json:"playlistFileName,omitempty"`
+
+	// minimum buffer time, in seconds, declared in DASH manifests
+	//
+	// required: false
+	MinBufferTime float64 `redis-hash:"minBufferTime,omitempty" json:"minBufferTime,omitempty"`
+
+	// container of the segments of HLS jobs (ts or fmp4, defaults to ts)
+	//
+	// required: false
+	SegmentFormat string `redis-hash:"segmentFormat,omitempty" json:"segmentFormat,omitempty"`
+
+	// add an audio-only variant to the master playlist of HLS jobs
+	//
+	// required: false
+	AudioOnlyRendition bool `redis-hash:"audioOnlyRendition,omitempty" json:"audioOnlyRendition,omitempty"`
+
+	// bitrate of the audio-only variant, in bits per second (defaults to
+	// 64000)
+	//
+	// required: false
+	AudioOnlyBitrate uint `redis-hash:"audioOnlyBitrate,omitempty" json:"audioOnlyBitrate,omitempty"`
+}
+
+// TranscodeOutput represents a single output of a job, as a pair of presetmap
+// and file name.
+//
+// swagger:model
+type TranscodeOutput struct {
+	// name of the presetmap used in the output
+	//
+	// required: true
+	Preset string `json:"preset"`
+
+	// version of the presetmap used in the output, as listed in
+	// /presets/{name}/versions. The current version of a presetmap is its
+	// version field. Defaults to the current version, which is recorded in
+	// the job, so the outputs of retries and fallbacks use the same
+	// settings.
+	//
+	// required: false
+	PresetVersion int `json:"presetVersion,omitempty"`
+
+	// name of the output file. It may contain the tokens {lang} and
+	// {variant}, which are replaced by the language and the variant of
+	// the output.
+	//
+	// required: true
+	FileName string `json:"fileName"`
+
+	// language of the output, defaults to the language of the job, or to
+	// the one of its default audio track
+	//
+	// required: false
+	Language string `json:"language,omitempty"`
+
+	// variant of the output (for example, "main" or "described")
+	//
+	// required: false
+	Variant string `json:"variant,omitempty"`
+
+	// makes the output a short preview clip of the source (for example,
+	// for previews of paywalled content)
+	//
+	// required: false
+	Preview *Preview `json:"preview,omitempty"`
+}
+
+// Preview is a short clip of the source delivered as one of the outputs of
+// a job, along with the full outputs.
+//
+// swagger:model
+type Preview struct {
+	// start of the clip, in seconds from the start of the source (or of
+	// the range transcoded in partial transcodes)
+	//
+	// required: false
+	Start float64 `json:"start,omitempty"`
+
+	// duration of the clip, in seconds. Defaults to 30.
+	//
+	// required: false
+	Duration float64 `json:"duration,omitempty"`
+
+	// maximum height of the clip, scaled down from the height of the
+	// preset keeping the aspect ratio
+	//
+	// required: false
+	MaxHeight uint `json:"maxHeight,omitempty"`
+
+	// image overlaid on the video of the clip
+	//
+	// required: false
+	Watermark *Watermark `json:"watermark,omitempty"`
+}
+
+// Tenant represents a client of the API, with a set of defaults that are
+// applied to all jobs submitted on its behalf.
+//
+// swagger:model
+type Tenant struct {
+	// name of the tenant
+	//
+	// unique: true
+	// required: true
+	Name string `redis-hash:"-" json:"name"`
+
+	// defaults applied to jobs submitted by the tenant
+	//
+	// required: true
+	Defaults JobDefaults `redis-hash:"defaults,expand" json:"defaults"`
+
+	// list of destination prefixes allowed in jobs submitted by the tenant
+	// (for example, "s3://my-bucket/videos/"). An empty list doesn't
+	// restrict the destination.
+	//
+	// required: false
+	AllowedDestinations []string `redis-hash:"allowedDestinations,omitempty" json:"allowedDestinations,omitempty"`
+
+	// list of domains allowed in the source media of jobs submitted by the
+	// tenant. Subdomains are also allowed. An empty list doesn't restrict
+	// the source.
+	//
+	// required: false
+	AllowedSourceDomains []string `redis-hash:"allowedSourceDomains,omitempty" json:"allowedSourceDomains,omitempty"`
+
+	// PEM-encoded RSA public key of the tenant. When set, callbacks sent to
+	// the tenant are encrypted using JWE.
+	//
+	// required: false
+	CallbackPublicKey string `redis-hash:"callbackPublicKey,omitempty" json:"callbackPublicKey,omitempty"`
+
+	// whether external ids must be unique among the jobs of the tenant.
+	// Jobs that failed or were canceled don't hold their external ids.
+	//
+	// required: false
+	UniqueExternalIDs bool `redis-hash:"uniqueExternalIds,omitempty" json:"uniqueExternalIds,omitempty"`
+
+	// list of conventions that the names of the output files of jobs
+	// submitted by the tenant must follow. Jobs with outputs breaking any
+	// of the rules are rejected.
+	//
+	// required: false
+	NamingRules []NamingRule `redis-hash:"namingRules,json,omitempty" json:"namingRules,omitempty"`
+}
+
+// NamingRule is a convention for the names of output files, defined either
+// as a regular expression or as a template.
+//
+// swagger:model
+type NamingRule struct {
+	// description of the rule, included in the errors of the outputs
+	// breaking it
+	//
+	// required: false
+	Description string `json:"description,omitempty"`
+
+	// list of extensions of the files that the rule applies to (for
+	// example, "mp4"). An empty list applies the rule to all files.
+	//
+	// required: false
+	Extensions []string `json:"extensions,omitempty"`
+
+	// regular expression that the whole file name must match
+	//
+	// required: false
+	Pattern string `json:"pattern,omitempty"`
+
+	// template of the file names, where {source} is the name of the source
+	// media without extension, {preset} the name of the preset, {ext} the
+	// extension of the preset, {lang} the language and {variant} the
+	// variant of the output
+	//
+	// required: false
+	Template string `json:"template,omitempty"`
+}
+
+// FileNameTokens are the values of the tokens of naming rule templates for
+// an output.
+type FileNameTokens struct {
+	Source    string
+	Preset    string
+	Extension string
+	Language  string
+	Variant   string
+}
+
+var templateTokenRegexp = regexp.MustCompile(`\{[^{}]*\}`)
+
+var namingTemplateTokens = map[string]bool{
+	"{source}":  true,
+	"{preset}":  true,
+	"{ext}":     true,
+	"{lang}":    true,
+	"{variant}": true,
+}
+
+// Validate checks that the rule defines a valid pattern or template.
+func (r *NamingRule) Validate() error {
+	if r.Pattern == "" && r.Template == "" {
+		return errors.New("naming rules require a pattern or a template")
+	}
+	if r.Pattern != "" {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid naming rule pattern %q: %s", r.Pattern, err)
+		}
+	}
+	for _, token := range templateTokenRegexp.FindAllString(r.Template, -1) {
+		if !namingTemplateTokens[token] {
+			return fmt.Errorf("invalid naming rule template %q: unknown token %s", r.Template, token)
+		}
+	}
+	return nil
+}
+
+// appliesTo returns whether the rule applies to the given file.
+func (r *NamingRule) appliesTo(fileName string) bool {
+	if len(r.Extensions) == 0 {
+		return true
+	}
+	extension := strings.TrimPrefix(path.Ext(fileName), ".")
+	for _, e := range r.Extensions {
+		if strings.EqualFold(strings.TrimPrefix(e, "."), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// check checks that the file name follows the rule.
+func (r *NamingRule) check(fileName string, tokens FileNameTokens) error {
+	if r.Pattern != "" {
+		pattern, err := regexp.Compile("^(?:" + r.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid naming rule pattern %q: %s", r.Pattern, err)
+		}
+		if !pattern.MatchString(fileName) {
+			return fmt.Errorf("it doesn't match %q", r.Pattern)
+		}
+	}
+	if r.Template != "" {
+		want := strings.NewReplacer(
+			"{source}", tokens.Source,
+			"{preset}", tokens.Preset,
+			"{ext}", tokens.Extension,
+			"{lang}", tokens.Language,
+			"{variant}", tokens.Variant,
+		).Replace(r.Template)
+		if fileName != want {
+			return fmt.Errorf("it should be %q", want)
+		}
+	}
+	return nil
+}
+
+// ValidateDestination checks that the given destination is allowed for the
+// tenant. An empty destination is always allowed, as it means that the
+// destination configured in the provider will be used.
+func (t *Tenant) ValidateDestination(destination string) error {
+	if destination == "" || len(t.AllowedDestinations) == 0 {
+		return nil
+	}
+	for _, prefix := range t.AllowedDestinations {
+		if destination == prefix || strings.HasPrefix(destination, strings.TrimRight(prefix, "/")+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("destination %q is not allowed for tenant %q", destination, t.Name)
+}
+
+// ValidateFileName checks that the given output file name follows the naming
+// rules of the tenant.
+func (t *Tenant) ValidateFileName(fileName string, tokens FileNameTokens) error {
+	for _, rule := range t.NamingRules {
+		if !rule.appliesTo(fileName) {
+			continue
+		}
+		if err := rule.check(fileName, tokens); err != nil {
+			reason := err.Error()
+			if rule.Description != "" {
+				reason = rule.Description + ": " + reason
+			}
+			return fmt.Errorf("file name %q breaks the naming rules of tenant %q (%s)", fileName, t.Name, reason)
+		}
+	}
+	return nil
+}
+
+// ValidateSource checks that the host of the given source media is in the
+// list of domains allowed for the tenant.
+func (t *Tenant) ValidateSource(source string) error {
+	if len(t.AllowedSourceDomains) == 0 {
+		return nil
+	}
+	sourceURL, err := url.Parse(source)
+	if err != nil {
+		return fmt.Errorf("invalid source media %q: %s", source, err)
+	}
+	host := strings.ToLower(sourceURL.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, domain := range t.AllowedSourceDomains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("source media %q is not allowed for tenant %q", source, t.Name)
+}
+
+// JobDefaults is the set of values used in new jobs when they're omitted in
+// the request.
+//
+// swagger:model
+type JobDefaults struct {
+	// name of the provider
+	Provider string `redis-hash:"provider,omitempty" json:"provider,omitempty"`
+
+	// base destination of the outputs
+	Destination string `redis-hash:"destination,omitempty" json:"destination,omitempty"`
+
+	// list of presetmaps used for generating outputs
+	Ladder []string `redis-hash:"ladder,omitempty" json:"ladder,omitempty"`
+
+	// URL that should be notified about jobs
+	CallbackURL string `redis-hash:"callbackURL,omitempty" json:"callbackURL,omitempty"`
+
+	// configuration for adaptive streaming jobs
+	StreamingParams StreamingParams `redis-hash:"streamingparams,expand" json:"streamingParams,omitempty"`
+
+	// name of the experiment that jobs are enrolled in
+	Experiment string `redis-hash:"experiment,omitempty" json:"experiment,omitempty"`
+
+	// name of the delivery target of the outputs, used when the job
+	// doesn't define a destination
+	DeliveryTarget string `redis-hash:"deliveryTarget,omitempty" json:"deliveryTarget,omitempty"`
+
+	// filters applied to the video of the outputs (for example, disabling
+	// deinterlacing for film-sourced content)
+	Filters *VideoFilters `redis-hash:"filters,json,omitempty" json:"filters,omitempty"`
+}
+
+// Ladder is a named, ordered list of presetmaps, along with the settings of
+// adaptive streaming, referenced by jobs instead of listing their outputs.
+//
+// swagger:model
+type Ladder struct {
+	// name of the ladder
+	//
+	// unique: true
+	// required: true
+	Name string `redis-hash:"-" json:"name"`
+
+	// description of the ladder
+	//
+	// required: false
+	Description string `redis-hash:"description,omitempty" json:"description,omitempty"`
+
+	// list of presetmaps used for generating the outputs, in order
+	//
+	// required: true
+	Presets []string `redis-hash:"presets" json:"presets"`
+
+	// configuration for adaptive streaming jobs
+	//
+	// required: false
+	StreamingParams StreamingParams `redis-hash:"streamingParams,json" json:"streamingParams,omitempty"`
+}
+
+// WatchFolder is a folder monitored by the API for new sources, bound to the
+// template of the jobs created for the files dropped in it. Folders in S3
+// are monitored through the event notifications of the bucket, delivered to
+// an SQS queue, and folders in SFTP servers are listed periodically.
+//
+// swagger:model
+type WatchFolder struct {
+	// name of the watch folder
+	//
+	// unique: true
+	// required: true
+	Name string `redis-hash:"-" json:"name"`
+
+	// URL of the folder, in the format s3://bucket/prefix/ or
+	// sftp://user@host[:port]/path/
+	//
+	// required: true
+	URL string `redis-hash:"url" json:"url"`
+
+	// URL of the SQS queue receiving the notifications of new objects in
+	// the bucket (S3 folders only)
+	//
+	// required: false
+	Queue string `redis-hash:"queue,omitempty" json:"queue,omitempty"`
+
+	// request of the jobs created for new files, in the format of the
+	// body of POST /jobs, without the source
+	//
+	// required: true
+	Template map[string]interface{} `redis-hash:"template,json" json:"template"`
+}
+
+// Statuses of campaigns.
+const (
+	CampaignStatusRunning  = "running"
+	CampaignStatusPaused   = "paused"
+	CampaignStatusFinished = "finished"
+)
+
+// Campaign re-encodes previously finished jobs with new presets, like
+// rolling out a new codec across the archive. The jobs matching the filter
+// of the campaign are resubmitted at a controlled rate, replacing the
+// presets of their outputs.
+//
+// swagger:model
+type Campaign struct {
+	// name of the campaign
+	//
+	// unique: true
+	// required: true
+	Name string `redis-hash:"-" json:"name"`
+
+	// description of the campaign
+	//
+	// required: false
+	Description string `redis-hash:"description,omitempty" json:"description,omitempty"`
+
+	// filter selecting the finished jobs that are re-encoded
+	//
+	// required: true
+	Filter CampaignFilter `redis-hash:"filter,json" json:"filter"`
+
+	// mapping of the presetmaps used in the original jobs to the ones
+	// replacing them. Outputs using other presetmaps are kept as they were.
+	//
+	// required: true
+	Presets map[string]string `redis-hash:"presets,json" json:"presets"`
+
+	// provider of the new jobs. Defaults to the provider of each original
+	// job.
+	//
+	// required: false
+	Provider string `redis-hash:"provider,omitempty" json:"provider,omitempty"`
+
+	// maximum number of jobs submitted per hour
+	//
+	// required: true
+	JobsPerHour uint `redis-hash:"jobsPerHour" json:"jobsPerHour"`
 
-	// configuration for adaptive streaming jobs
-	// Defaults to false.
+	// status of the campaign: running, paused or finished
+	//
+	// required: true
+	Status string `redis-hash:"status" json:"status"`
+
+	// jobs of the campaign, in the order they're submitted
+	//
+	// required: true
+	Jobs []CampaignJob `redis-hash:"jobs,json" json:"jobs"`
+
+	// progress of the campaign
+	//
+	// required: true
+	Progress CampaignProgress `redis-hash:"progress,json" json:"progress"`
+
+	// Time of the last submission of jobs of the campaign, used for
+	// pacing the submissions
 	//
 	// required: false
-	StreamingParams StreamingParams `redis-hash:"streamingparams,expand" json:"streamingParams,omitempty"`
+	LastSubmission time.Time `redis-hash:"lastSubmission" json:"lastSubmission"`
 
-	// Time of the creation of the job in the API
+	// Time of the creation of the campaign in the API
 	//
 	// required: true
 	CreationTime time.Time `redis-hash:"creationTime" json:"creationTime"`
 }
 
-// StreamingParams represents the params necessary to create Adaptive Streaming jobs
+// CampaignFilter selects the finished jobs re-encoded by a campaign. Empty
+// fields don't restrict the jobs.
+type CampaignFilter struct {
+	// presetmap used by at least one of the outputs of the job. Defaults
+	// to any of the presetmaps replaced by the campaign.
+	Preset string `json:"preset,omitempty"`
+
+	// provider that encoded the job
+	Provider string `json:"provider,omitempty"`
+
+	// tenant that submitted the job
+	Tenant string `json:"tenant,omitempty"`
+
+	// only jobs created since the given time
+	Since time.Time `json:"since,omitempty"`
+
+	// only jobs created before the given time
+	Until time.Time `json:"until,omitempty"`
+
+	// maximum number of jobs in the campaign. 0 means no limit.
+	Limit uint `json:"limit,omitempty"`
+}
+
+// CampaignJob is a job re-encoded by a campaign.
+type CampaignJob struct {
+	// id of the original job
+	SourceJobID string `json:"sourceJobId"`
+
+	// id of the job re-encoding it, once submitted
+	JobID string `json:"jobId,omitempty"`
+
+	// last known status of the job re-encoding it
+	Status string `json:"status,omitempty"`
+
+	// error submitting the job
+	Error string `json:"error,omitempty"`
+
+	// cost of the job, based on the duration of the source and the price
+	// per minute of the provider
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// CampaignProgress summarizes the jobs of a campaign.
+type CampaignProgress struct {
+	// number of jobs in the campaign
+	Total int `json:"total"`
+
+	// number of jobs submitted
+	Submitted int `json:"submitted"`
+
+	// number of jobs that finished
+	Finished int `json:"finished"`
+
+	// number of jobs that failed, either when submitted or while encoding
+	Failed int `json:"failed"`
+
+	// cost of the finished jobs
+	Cost float64 `json:"cost"`
+
+	// estimated cost of the whole campaign, based on the original jobs
+	EstimatedCost float64 `json:"estimatedCost"`
+}
+
+// DeliveryTarget is a named location for delivering the outputs of jobs,
+// decoupling jobs from the delivery topology. Each environment (for example,
+// "production" or "staging") has its own origin, served by a CDN.
 //
 // swagger:model
-type StreamingParams struct {
-	// duration of the segment
+type DeliveryTarget struct {
+	// name of the delivery target
 	//
+	// unique: true
 	// required: true
-	SegmentDuration uint `redis-hash:"segmentDuration" json:"segmentDuration"`
+	Name string `redis-hash:"-" json:"name"`
+
+	// description of the delivery target
+	//
+	// required: false
+	Description string `redis-hash:"description,omitempty" json:"description,omitempty"`
 
-	// the protocol name (hls or dash)
+	// origins of the delivery target, by environment
 	//
 	// required: true
-	Protocol string `redis-hash:"protocol" json:"protocol"`
+	Origins map[string]DeliveryOrigin `redis-hash:"origins,json" json:"origins"`
+}
+
+// Origin returns the origin of the delivery target in the given environment.
+func (t *DeliveryTarget) Origin(environment string) (DeliveryOrigin, error) {
+	origin, ok := t.Origins[environment]
+	if !ok {
+		return origin, fmt.Errorf("delivery target %q has no origin for environment %q", t.Name, environment)
+	}
+	return origin, nil
+}
+
+// DeliveryOrigin is the origin of a delivery target in one environment.
+type DeliveryOrigin struct {
+	// base destination of the outputs (for example, "s3://origin-bucket/videos/")
+	Destination string `json:"destination"`
+
+	// base URL of the CDN that serves the origin (for example,
+	// "https://cdn.example.com/videos/")
+	CDNBaseURL string `json:"cdnBaseURL,omitempty"`
+}
+
+// URL returns the CDN-facing URL of the given file, or an empty string if the
+// file isn't in the origin or the origin isn't served by a CDN.
+func (o *DeliveryOrigin) URL(path string) string {
+	if o.CDNBaseURL == "" || o.Destination == "" {
+		return ""
+	}
+	prefix := strings.TrimRight(o.Destination, "/") + "/"
+	if !strings.HasPrefix(path, prefix) {
+		return ""
+	}
+	return strings.TrimRight(o.CDNBaseURL, "/") + "/" + strings.TrimPrefix(path, prefix)
+}
+
+// Artifact is a piece of structured output attached to a job, like a QC
+// report, a loudness graph or the output of ffprobe. Small artifacts are
+// stored inline, in the Data field, while larger artifacts are stored in a
+// sidecar bucket and referenced by URL.
+//
+// swagger:model
+type Artifact struct {
+	// id of the job that the artifact is attached to
+	//
+	// required: true
+	JobID string `redis-hash:"jobID" json:"jobId"`
+
+	// name of the artifact, unique in the job
+	//
+	// required: true
+	Name string `redis-hash:"name" json:"name"`
+
+	// kind of artifact (for example, "qc-report" or "ffprobe")
+	//
+	// required: false
+	Kind string `redis-hash:"kind,omitempty" json:"kind,omitempty"`
+
+	// name of the provider or post-step that generated the artifact
+	//
+	// required: false
+	Source string `redis-hash:"source,omitempty" json:"source,omitempty"`
+
+	// content type of the artifact
+	//
+	// required: false
+	ContentType string `redis-hash:"contentType,omitempty" json:"contentType,omitempty"`
+
+	// location of the artifact, when it's stored outside of the API
+	//
+	// required: false
+	URL string `redis-hash:"url,omitempty" json:"url,omitempty"`
+
+	// inline JSON content of the artifact
+	//
+	// required: false
+	Data json.RawMessage `redis-hash:"data,json,omitempty" json:"data,omitempty"`
+
+	// Time of the creation of the artifact in the API
+	//
+	// required: true
+	CreationTime time.Time `redis-hash:"creationTime" json:"creationTime"`
+}
+
+// SourceEncode is an entry of the source index, recording a finished job
+// that encoded the source media with the given checksum.
+//
+// swagger:model
+type SourceEncode struct {
+	// checksum of the source media, in the format "algorithm:digest"
+	//
+	// required: true
+	Checksum string `redis-hash:"checksum" json:"checksum"`
+
+	// id of the job that encoded the source
+	//
+	// required: true
+	JobID string `redis-hash:"jobID" json:"jobId"`
+
+	// name of the ladder used by the job
+	//
+	// required: false
+	Ladder string `redis-hash:"ladder,omitempty" json:"ladder,omitempty"`
+
+	// presetmaps of the outputs of the job, sorted by name
+	//
+	// required: true
+	Presets []string `redis-hash:"presets,json" json:"presets"`
+
+	// provider that encoded the source
+	//
+	// required: true
+	Provider string `redis-hash:"provider" json:"provider"`
+
+	// destination of the outputs
+	//
+	// required: false
+	Destination string `redis-hash:"destination,omitempty" json:"destination,omitempty"`
+
+	// paths of the output files
+	//
+	// required: false
+	Outputs []string `redis-hash:"outputs,json,omitempty" json:"outputs,omitempty"`
+
+	// Time when the job finished
+	//
+	// required: true
+	FinishedTime time.Time `redis-hash:"finishedTime" json:"finishedTime"`
+}
+
+// Experiment is an A/B test between encoding configurations. A sample of the
+// jobs submitted with the experiment is enrolled in it, and each enrolled job
+// is encoded with one of the variants of the experiment. The first variant is
+// the control.
+//
+// swagger:model
+type Experiment struct {
+	// name of the experiment
+	//
+	// unique: true
+	// required: true
+	Name string `redis-hash:"-" json:"name"`
+
+	// description of the experiment
+	//
+	// required: false
+	Description string `redis-hash:"description,omitempty" json:"description,omitempty"`
+
+	// percentage of the jobs submitted with the experiment that are
+	// enrolled in it (0-100)
+	//
+	// required: true
+	SamplePercent uint `redis-hash:"samplePercent" json:"samplePercent"`
+
+	// encoding configurations compared in the experiment
+	//
+	// required: true
+	Variants []ExperimentVariant `redis-hash:"variants,json" json:"variants"`
+
+	// Time of the creation of the experiment in the API
+	//
+	// required: true
+	CreationTime time.Time `redis-hash:"creationTime" json:"creationTime"`
+}
+
+// ExperimentVariant is one of the encoding configurations of an experiment.
+// Empty fields keep the value in the job request.
+type ExperimentVariant struct {
+	// name of the variant, unique in the experiment
+	Name string `json:"name"`
+
+	// name of the provider
+	Provider string `json:"provider,omitempty"`
+
+	// list of presetmaps used for generating outputs
+	Ladder []string `json:"ladder,omitempty"`
+}
+
+// ExperimentSample contains the metrics collected for one of the jobs
+// enrolled in an experiment.
+//
+// swagger:model
+type ExperimentSample struct {
+	// name of the experiment
+	//
+	// required: true
+	Experiment string `redis-hash:"experiment" json:"experiment"`
+
+	// id of the job
+	//
+	// required: true
+	JobID string `redis-hash:"jobID" json:"jobId"`
+
+	// name of the variant used for encoding the job
+	//
+	// required: true
+	Variant string `redis-hash:"variant" json:"variant"`
+
+	// metrics collected for the job
+	//
+	// required: true
+	Metrics ExperimentMetrics `redis-hash:"metrics,json" json:"metrics"`
+
+	// Time of the creation of the sample in the API
+	//
+	// required: true
+	CreationTime time.Time `redis-hash:"creationTime" json:"creationTime"`
+}
+
+// ExperimentMetrics are the metrics compared between the variants of an
+// experiment.
+type ExperimentMetrics struct {
+	// quality score of the outputs (for example, VMAF)
+	QCScore float64 `json:"qcScore"`
+
+	// total size of the outputs, in bytes
+	OutputSize int64 `json:"outputSize"`
+
+	// time taken for encoding the job, in seconds
+	EncodeDuration float64 `json:"encodeDuration"`
 }
 
 // LocalPreset is a struct to persist encoding configurations. Some providers don't have
@@ -70,15 +1853,46 @@ type LocalPreset struct {
 }
 
 // Preset define the set of parameters of a given preset
+//
+// Audio-only presets (used for podcasts and audio extraction) have no video
+// settings, and generate outputs without a video track. Presets with a
+// watermark overlay an image (like the logo of a brand) on the video.
 type Preset struct {
-	Name         string      `json:"name,omitempty" redis-hash:"name"`
-	Description  string      `json:"description,omitempty" redis-hash:"description,omitempty"`
-	Container    string      `json:"container,omitempty" redis-hash:"container,omitempty"`
-	Profile      string      `json:"profile,omitempty" redis-hash:"profile,omitempty"`
-	ProfileLevel string      `json:"profileLevel,omitempty" redis-hash:"profilelevel,omitempty"`
-	RateControl  string      `json:"rateControl,omitempty" redis-hash:"ratecontrol,omitempty"`
-	Video        VideoPreset `json:"video" redis-hash:"video,expand"`
-	Audio        AudioPreset `json:"audio" redis-hash:"audio,expand"`
+	Name         string        `json:"name,omitempty" redis-hash:"name"`
+	Description  string        `json:"description,omitempty" redis-hash:"description,omitempty"`
+	Container    string        `json:"container,omitempty" redis-hash:"container,omitempty"`
+	Profile      string        `json:"profile,omitempty" redis-hash:"profile,omitempty"`
+	ProfileLevel string        `json:"profileLevel,omitempty" redis-hash:"profilelevel,omitempty"`
+	RateControl  string        `json:"rateControl,omitempty" redis-hash:"ratecontrol,omitempty"`
+	AudioOnly    bool          `json:"audioOnly,omitempty" redis-hash:"audioonly,omitempty"`
+	Video        VideoPreset   `json:"video" redis-hash:"video,expand"`
+	Audio        AudioPreset   `json:"audio" redis-hash:"audio,expand"`
+	Watermark    *Watermark    `json:"watermark,omitempty" redis-hash:"watermark,json,omitempty"`
+	Filters      *VideoFilters `json:"filters,omitempty" redis-hash:"filters,json,omitempty"`
+}
+
+// Watermark is an image overlaid on the video of the outputs of a preset.
+//
+// Offsets and sizes are in pixels ("20") or relative to the frame ("5%").
+type Watermark struct {
+	// URL of the image (PNG or JPEG). Elastic Transcoder requires the
+	// image in the input bucket of the pipeline.
+	URL string `json:"url"`
+
+	// corner of the frame where the image is placed: top-left, top-right,
+	// bottom-left or bottom-right (the default)
+	Position string `json:"position,omitempty"`
+
+	// distance between the image and the edges of the frame
+	Offset string `json:"offset,omitempty"`
+
+	// maximum size of the image, scaled down keeping its aspect ratio
+	Width  string `json:"width,omitempty"`
+	Height string `json:"height,omitempty"`
+
+	// opacity of the image, from 0 (transparent) to 100 (opaque, the
+	// default)
+	Opacity string `json:"opacity,omitempty"`
 }
 
 // VideoPreset define the set of parameters for video on a given preset
@@ -90,12 +1904,183 @@ type VideoPreset struct {
 	GopSize       string `json:"gopSize,omitempty" redis-hash:"gopsize,omitempty"`
 	GopMode       string `json:"gopMode,omitempty" redis-hash:"gopmode,omitempty"`
 	InterlaceMode string `json:"interlaceMode,omitempty" redis-hash:"interlacemode,omitempty"`
+
+	// number of encoding passes: "1" or "2". Empty keeps the default of
+	// the provider.
+	Passes string `json:"passes,omitempty" redis-hash:"passes,omitempty"`
+
+	// color space of the output: bt709 or bt2020. Empty keeps the color
+	// space of the source.
+	ColorSpace string `json:"colorSpace,omitempty" redis-hash:"colorspace,omitempty"`
+
+	// transfer function of the output: bt709 (SDR), pq (HDR10) or hlg.
+	// HDR transfer functions require the hevc, vp9 or av1 codecs.
+	TransferFunction string `json:"transferFunction,omitempty" redis-hash:"transferfunction,omitempty"`
+
+	// mastering display metadata of HDR10 outputs, in the SMPTE ST 2086
+	// format used by x265, e.g.
+	// "G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50)"
+	MasteringDisplay string `json:"masteringDisplay,omitempty" redis-hash:"masteringdisplay,omitempty"`
+
+	// content light level of HDR10 outputs, as "MaxCLL,MaxFALL" in nits,
+	// e.g. "1000,400"
+	ContentLightLevel string `json:"contentLightLevel,omitempty" redis-hash:"contentlightlevel,omitempty"`
+
+	// whether HDR sources are tone mapped to SDR (bt709)
+	ToneMap bool `json:"toneMap,omitempty" redis-hash:"tonemap,omitempty"`
+}
+
+// HDR returns whether the video preset generates HDR outputs.
+func (p VideoPreset) HDR() bool {
+	return p.TransferFunction == "pq" || p.TransferFunction == "hlg"
+}
+
+// MasteringDisplay is the mastering display metadata of HDR10 outputs.
+// Chromaticity coordinates are in units of 0.00002 and luminances in units
+// of 0.0001 cd/m2, as defined in SMPTE ST 2086.
+type MasteringDisplay struct {
+	GreenX, GreenY int64
+	BlueX, BlueY   int64
+	RedX, RedY     int64
+	WhitePointX    int64
+	WhitePointY    int64
+	MaxLuminance   int64
+	MinLuminance   int64
+}
+
+var masteringDisplayRegexp = regexp.MustCompile(`^G\((\d+),(\d+)\)B\((\d+),(\d+)\)R\((\d+),(\d+)\)WP\((\d+),(\d+)\)L\((\d+),(\d+)\)$`)
+
+// ParseMasteringDisplay parses mastering display metadata in the SMPTE ST
+// 2086 format used by x265.
+func ParseMasteringDisplay(value string) (*MasteringDisplay, error) {
+	match := masteringDisplayRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return nil, fmt.Errorf("invalid mastering display %q", value)
+	}
+	var values [10]int64
+	for i := range values {
+		n, err := strconv.ParseInt(match[i+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mastering display %q", value)
+		}
+		values[i] = n
+	}
+	display := MasteringDisplay{
+		GreenX: values[0], GreenY: values[1],
+		BlueX: values[2], BlueY: values[3],
+		RedX: values[4], RedY: values[5],
+		WhitePointX: values[6], WhitePointY: values[7],
+		MaxLuminance: values[8], MinLuminance: values[9],
+	}
+	if display.MinLuminance >= display.MaxLuminance {
+		return nil, fmt.Errorf("invalid mastering display %q, the minimum luminance must be lower than the maximum", value)
+	}
+	return &display, nil
+}
+
+// ParseContentLightLevel parses the content light level of HDR10 outputs,
+// in the "MaxCLL,MaxFALL" format.
+func ParseContentLightLevel(value string) (maxCLL, maxFALL int64, err error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid content light level %q", value)
+	}
+	maxCLL, err = strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	if err == nil {
+		maxFALL, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+	}
+	if err != nil || maxCLL < 0 || maxFALL < 0 || maxFALL > maxCLL {
+		return 0, 0, fmt.Errorf("invalid content light level %q", value)
+	}
+	return maxCLL, maxFALL, nil
+}
+
+// VideoFilters are the filters applied to the video before encoding. Empty
+// settings keep the default of the provider.
+type VideoFilters struct {
+	// deinterlacing of the source: "on", "off" or "detect"
+	Deinterlace string `json:"deinterlace,omitempty"`
+
+	// strength of the denoising: "weak", "medium", "strong" or "strongest"
+	Denoise string `json:"denoise,omitempty"`
+
+	// sharpen the video
+	Sharpen bool `json:"sharpen,omitempty"`
+}
+
+// ResolveVideoFilters combines the filters of a job with the filters of a
+// preset. The settings of the job take precedence over the ones of the
+// preset.
+func ResolveVideoFilters(job, preset *VideoFilters) VideoFilters {
+	var filters VideoFilters
+	if preset != nil {
+		filters = *preset
+	}
+	if job == nil {
+		return filters
+	}
+	if job.Deinterlace != "" {
+		filters.Deinterlace = job.Deinterlace
+	}
+	if job.Denoise != "" {
+		filters.Denoise = job.Denoise
+	}
+	filters.Sharpen = filters.Sharpen || job.Sharpen
+	return filters
 }
 
 // AudioPreset define the set of parameters for audio on a given preset
 type AudioPreset struct {
 	Codec   string `json:"codec,omitempty" redis-hash:"codec,omitempty"`
 	Bitrate string `json:"bitrate,omitempty" redis-hash:"bitrate,omitempty"`
+
+	// target integrated loudness of the output, in LUFS, e.g. "-23" for
+	// EBU R128 or "-24" for ATSC A/85. Empty keeps the loudness of the
+	// source.
+	Loudness string `json:"loudness,omitempty" redis-hash:"loudness,omitempty"`
+
+	// maximum true peak of the normalized output, in dBTP, e.g. "-1".
+	// Requires a target loudness.
+	TruePeak string `json:"truePeak,omitempty" redis-hash:"truepeak,omitempty"`
+
+	// layout of the channels of the output, AudioLayoutStereo or
+	// AudioLayout51 (5.1 surround). Empty uses the default of the
+	// provider, usually stereo.
+	ChannelLayout string `json:"channelLayout,omitempty" redis-hash:"channellayout,omitempty"`
+}
+
+// Channel layouts of audio presets.
+const (
+	AudioLayoutStereo = "stereo"
+	AudioLayout51     = "5.1"
+)
+
+// Channels returns the number of channels of the output of the audio
+// preset: 6 for 5.1 surround and 2 otherwise.
+func (p AudioPreset) Channels() int {
+	if p.ChannelLayout == AudioLayout51 {
+		return 6
+	}
+	return 2
+}
+
+// Normalization returns the target loudness and the true peak of the audio
+// preset. Both are zero when the loudness of the source is kept, and the
+// true peak is zero when the preset doesn't limit it.
+func (p AudioPreset) Normalization() (loudness, truePeak float64, err error) {
+	if p.Loudness != "" {
+		loudness, err = strconv.ParseFloat(p.Loudness, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid loudness %q", p.Loudness)
+		}
+	}
+	if p.TruePeak != "" {
+		truePeak, err = strconv.ParseFloat(p.TruePeak, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid true peak %q", p.TruePeak)
+		}
+	}
+	return loudness, truePeak, nil
 }
 
 // PresetMap represents the preset that is persisted in the repository of the
@@ -121,6 +2106,40 @@ type PresetMap struct {
 	//
 	// required: true
 	OutputOpts OutputOptions `redis-hash:"output,expand" json:"output"`
+
+	// name of the tenant that owns the presetmap. Presetmaps without a
+	// tenant are shared by all tenants.
+	//
+	// required: false
+	Tenant string `redis-hash:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// number of the version of the presetmap, set by the API whenever
+	// the presetmap is created or updated
+	//
+	// required: false
+	Version int `redis-hash:"version,omitempty" json:"version,omitempty"`
+}
+
+// PresetMapVersion is a previous version of a presetmap, kept when the
+// presetmap is updated or deleted so the change can be rolled back.
+//
+// swagger:model
+type PresetMapVersion struct {
+	// number of the version, starting at 1 for the first version of the
+	// presetmap that was replaced
+	//
+	// required: true
+	Version int `json:"version"`
+
+	// time when the version was replaced, or deleted
+	//
+	// required: true
+	ReplacedAt time.Time `json:"replacedAt"`
+
+	// the presetmap, as of the version
+	//
+	// required: true
+	PresetMap PresetMap `json:"presetMap"`
 }
 
 // OutputOptions is the set of options for the output file.
@@ -138,6 +2157,17 @@ type OutputOptions struct {
 	//
 	// required: true
 	Extension string `redis-hash:"extension" json:"extension"`
+
+	// URL of the watermark image of the presets, for providers that take
+	// the image in the job instead of the preset (Elastic Transcoder).
+	// It's set when the presets are created through the API.
+	Watermark string `redis-hash:"watermark,omitempty" json:"watermark,omitempty"`
+
+	// codecs of the video and the audio of the presets, for filtering the
+	// list of presets. They're set when the presets are created through
+	// the API.
+	VideoCodec string `redis-hash:"videocodec,omitempty" json:"videoCodec,omitempty"`
+	AudioCodec string `redis-hash:"audiocodec,omitempty" json:"audioCodec,omitempty"`
 }
 
 // Validate checks that the OutputOptions object is properly defined.
@@ -147,3 +2177,74 @@ func (o *OutputOptions) Validate() error {
 	}
 	return nil
 }
+
+// AllProviders is the provider name used for pausing the submission of jobs
+// to all providers.
+const AllProviders = "*"
+
+// SubmissionPause pauses the submission of jobs to a provider (or to all
+// providers, when Provider is AllProviders). Jobs created while submissions
+// are paused are held in the API, and submitted when the pause is removed.
+//
+// swagger:model
+type SubmissionPause struct {
+	// name of the paused provider
+	//
+	// unique: true
+	// required: true
+	Provider string `redis-hash:"-" json:"provider"`
+
+	// reason for pausing submissions, like a maintenance window or an
+	// incident in the provider
+	Reason string `redis-hash:"reason,omitempty" json:"reason,omitempty"`
+
+	// time of the creation of the pause
+	CreationTime time.Time `redis-hash:"creationTime" json:"creationTime"`
+}
+
+// Covers returns whether the pause applies to the given provider.
+func (p SubmissionPause) Covers(providerName string) bool {
+	return p.Provider == AllProviders || p.Provider == providerName
+}
+
+// MaintenanceMode is the state of the maintenance mode shared by all the
+// instances of the API, in which the API is read-only.
+type MaintenanceMode struct {
+	Enabled bool   `redis-hash:"enabled" json:"enabled"`
+	Message string `redis-hash:"message" json:"message"`
+
+	// time of the last switch of the mode
+	UpdateTime time.Time `redis-hash:"updateTime" json:"updateTime"`
+}
+
+// ProviderThroughput is the throughput observed in the finished jobs of a
+// provider, shared by all the instances of the API for estimating the
+// progress of jobs in providers that don't report it.
+type ProviderThroughput struct {
+	Provider string `redis-hash:"provider" json:"provider"`
+
+	// moving average of the throughput, in seconds of media transcoded
+	// per second
+	Throughput float64 `redis-hash:"throughput" json:"throughput"`
+
+	// time of the last update of the throughput
+	UpdateTime time.Time `redis-hash:"updateTime" json:"updateTime"`
+}
+
+// Notification is a callback notification queued for delivery. Data is the
+// encoded body of the request, and Attempts the number of failed
+// deliveries. The notification is delivered (again) once NextAttempt is
+// reached.
+type Notification struct {
+	ID          string    `redis-hash:"-" json:"id"`
+	JobID       string    `redis-hash:"jobId" json:"jobId"`
+	URL         string    `redis-hash:"url" json:"url"`
+	ContentType string    `redis-hash:"contentType" json:"contentType"`
+	Data        []byte    `redis-hash:"data,json" json:"data"`
+	Attempts    uint      `redis-hash:"attempts" json:"attempts"`
+	LastError   string    `redis-hash:"lastError,omitempty" json:"lastError,omitempty"`
+	NextAttempt time.Time `redis-hash:"nextAttempt" json:"nextAttempt"`
+
+	// creation time of the notification
+	CreationTime time.Time `redis-hash:"creationTime" json:"creationTime"`
+}
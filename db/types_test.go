@@ -32,3 +32,258 @@ func TestOutputOptionsValidation(t *testing.T) {
 		}
 	}
 }
+
+func TestTenantValidateDestination(t *testing.T) {
+	tenant := Tenant{
+		Name:                "newsroom",
+		AllowedDestinations: []string{"s3://newsroom-bucket/videos/", "s3://newsroom-archive"},
+	}
+	var tests = []struct {
+		destination string
+		errMsg      string
+	}{
+		{"", ""},
+		{"s3://newsroom-bucket/videos/", ""},
+		{"s3://newsroom-bucket/videos/2016/", ""},
+		{"s3://newsroom-archive", ""},
+		{"s3://newsroom-archive/old/", ""},
+		{"s3://newsroom-archive-evil/", `destination "s3://newsroom-archive-evil/" is not allowed for tenant "newsroom"`},
+		{"s3://newsroom-bucket/other/", `destination "s3://newsroom-bucket/other/" is not allowed for tenant "newsroom"`},
+		{"s3://attacker-bucket/", `destination "s3://attacker-bucket/" is not allowed for tenant "newsroom"`},
+	}
+	for _, test := range tests {
+		err := tenant.ValidateDestination(test.destination)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.destination, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestTenantValidateSource(t *testing.T) {
+	tenant := Tenant{
+		Name:                 "newsroom",
+		AllowedSourceDomains: []string{"example.com", "newsroom-bucket.s3.amazonaws.com"},
+	}
+	var tests = []struct {
+		source string
+		errMsg string
+	}{
+		{"http://example.com/video.mp4", ""},
+		{"https://media.EXAMPLE.com:8443/video.mp4", ""},
+		{"http://newsroom-bucket.s3.amazonaws.com/video.mp4", ""},
+		{"http://example.com.attacker.net/video.mp4", `source media "http://example.com.attacker.net/video.mp4" is not allowed for tenant "newsroom"`},
+		{"http://notexample.com/video.mp4", `source media "http://notexample.com/video.mp4" is not allowed for tenant "newsroom"`},
+	}
+	for _, test := range tests {
+		err := tenant.ValidateSource(test.source)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.source, test.errMsg, err.Error())
+		}
+	}
+	unrestricted := Tenant{Name: "cooking"}
+	if err := unrestricted.ValidateSource("http://anything.example.net/video.mp4"); err != nil {
+		t.Errorf("unexpected error for tenant without restrictions: %s", err)
+	}
+}
+
+func TestTenantValidateFileName(t *testing.T) {
+	tenant := Tenant{
+		Name: "newsroom",
+		NamingRules: []NamingRule{
+			{Pattern: `[a-z0-9_/.]+`},
+			{Description: "MP4 outputs", Extensions: []string{"mp4"}, Template: "{source}/{preset}_{lang}.{ext}"},
+		},
+	}
+	tokens := FileNameTokens{Source: "video", Preset: "720p", Extension: "mp4", Language: "en", Variant: "main"}
+	var tests = []struct {
+		fileName string
+		errMsg   string
+	}{
+		{"video/720p_en.mp4", ""},
+		{"hls/index.m3u8", ""},
+		{"Video/720p_en.mp4", `file name "Video/720p_en.mp4" breaks the naming rules of tenant "newsroom" (it doesn't match "[a-z0-9_/.]+")`},
+		{"hls/my index.m3u8", `file name "hls/my index.m3u8" breaks the naming rules of tenant "newsroom" (it doesn't match "[a-z0-9_/.]+")`},
+		{"video/720p.mp4", `file name "video/720p.mp4" breaks the naming rules of tenant "newsroom" (MP4 outputs: it should be "video/720p_en.mp4")`},
+	}
+	for _, test := range tests {
+		err := tenant.ValidateFileName(test.fileName, tokens)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.fileName, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestNamingRuleValidate(t *testing.T) {
+	var tests = []struct {
+		rule   NamingRule
+		errMsg string
+	}{
+		{NamingRule{Pattern: `^[a-z]+\.mp4$`}, ""},
+		{NamingRule{Template: "{source}_{preset}_{variant}.{ext}"}, ""},
+		{NamingRule{Extensions: []string{"mp4"}}, "naming rules require a pattern or a template"},
+		{NamingRule{Pattern: "[a-z"}, "invalid naming rule pattern \"[a-z\": error parsing regexp: missing closing ]: `[a-z`"},
+		{NamingRule{Template: "{source}_{height}.mp4"}, `invalid naming rule template "{source}_{height}.mp4": unknown token {height}`},
+	}
+	for _, test := range tests {
+		err := test.rule.Validate()
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%#v: wrong error message\nWant %q\nGot  %q", test.rule, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestDeliveryOriginURL(t *testing.T) {
+	origin := DeliveryOrigin{
+		Destination: "s3://origin-bucket/videos",
+		CDNBaseURL:  "https://cdn.example.com/vod/",
+	}
+	var tests = []struct {
+		path string
+		url  string
+	}{
+		{"s3://origin-bucket/videos/job-123/video.mp4", "https://cdn.example.com/vod/job-123/video.mp4"},
+		{"s3://origin-bucket/videos/hls/index.m3u8", "https://cdn.example.com/vod/hls/index.m3u8"},
+		{"s3://origin-bucket/videos-private/video.mp4", ""},
+		{"s3://other-bucket/videos/video.mp4", ""},
+	}
+	for _, test := range tests {
+		url := origin.URL(test.path)
+		if url != test.url {
+			t.Errorf("%s: wrong URL\nWant %q\nGot  %q", test.path, test.url, url)
+		}
+	}
+	origin.CDNBaseURL = ""
+	if url := origin.URL("s3://origin-bucket/videos/video.mp4"); url != "" {
+		t.Errorf("origin without CDN: unexpected URL %q", url)
+	}
+}
+
+func TestResolveVideoFilters(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		job           *VideoFilters
+		preset        *VideoFilters
+		want          VideoFilters
+	}{
+		{"no filters", nil, nil, VideoFilters{}},
+		{"preset only", nil, &VideoFilters{Deinterlace: "on", Denoise: "weak"}, VideoFilters{Deinterlace: "on", Denoise: "weak"}},
+		{"job only", &VideoFilters{Deinterlace: "off", Sharpen: true}, nil, VideoFilters{Deinterlace: "off", Sharpen: true}},
+		{
+			"job overrides preset",
+			&VideoFilters{Deinterlace: "off"},
+			&VideoFilters{Deinterlace: "on", Denoise: "medium", Sharpen: true},
+			VideoFilters{Deinterlace: "off", Denoise: "medium", Sharpen: true},
+		},
+	}
+	for _, test := range tests {
+		got := ResolveVideoFilters(test.job, test.preset)
+		if got != test.want {
+			t.Errorf("%s: wrong filters\nWant %#v\nGot  %#v", test.givenTestCase, test.want, got)
+		}
+	}
+}
+
+func TestParseMasteringDisplay(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		value         string
+		want          *MasteringDisplay
+		wantErr       string
+	}{
+		{
+			"P3 D65 display",
+			"G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50)",
+			&MasteringDisplay{
+				GreenX: 13250, GreenY: 34500,
+				BlueX: 7500, BlueY: 3000,
+				RedX: 34000, RedY: 16000,
+				WhitePointX: 15635, WhitePointY: 16450,
+				MaxLuminance: 10000000, MinLuminance: 50,
+			},
+			"",
+		},
+		{"invalid format", "G(13250,34500)B(7500,3000)", nil, `invalid mastering display "G(13250,34500)B(7500,3000)"`},
+		{
+			"inverted luminance",
+			"G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(50,10000000)",
+			nil,
+			`invalid mastering display "G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(50,10000000)", the minimum luminance must be lower than the maximum`,
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseMasteringDisplay(test.value)
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.givenTestCase, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.givenTestCase, err)
+			continue
+		}
+		if *got != *test.want {
+			t.Errorf("%s: wrong mastering display\nWant %#v\nGot  %#v", test.givenTestCase, test.want, got)
+		}
+	}
+}
+
+func TestParseContentLightLevel(t *testing.T) {
+	var tests = []struct {
+		value       string
+		wantMaxCLL  int64
+		wantMaxFALL int64
+		wantErr     bool
+	}{
+		{"1000,400", 1000, 400, false},
+		{"1000, 400", 1000, 400, false},
+		{"1000", 0, 0, true},
+		{"400,1000", 0, 0, true},
+		{"a,b", 0, 0, true},
+	}
+	for _, test := range tests {
+		maxCLL, maxFALL, err := ParseContentLightLevel(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: wrong error. Want error: %v. Got %v", test.value, test.wantErr, err)
+		}
+		if maxCLL != test.wantMaxCLL || maxFALL != test.wantMaxFALL {
+			t.Errorf("%q: wrong content light level. Want %d,%d. Got %d,%d", test.value, test.wantMaxCLL, test.wantMaxFALL, maxCLL, maxFALL)
+		}
+	}
+}
+
+func TestAudioPresetNormalization(t *testing.T) {
+	var tests = []struct {
+		audio        AudioPreset
+		wantLoudness float64
+		wantTruePeak float64
+		wantErr      bool
+	}{
+		{AudioPreset{}, 0, 0, false},
+		{AudioPreset{Loudness: "-23"}, -23, 0, false},
+		{AudioPreset{Loudness: "-24", TruePeak: "-1.5"}, -24, -1.5, false},
+		{AudioPreset{Loudness: "loud"}, 0, 0, true},
+		{AudioPreset{Loudness: "-23", TruePeak: "-1dB"}, 0, 0, true},
+	}
+	for _, test := range tests {
+		loudness, truePeak, err := test.audio.Normalization()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%#v: wrong error. Want error: %v. Got %v", test.audio, test.wantErr, err)
+		}
+		if loudness != test.wantLoudness || truePeak != test.wantTruePeak {
+			t.Errorf("%#v: wrong normalization. Want %g/%g. Got %g/%g", test.audio, test.wantLoudness, test.wantTruePeak, loudness, truePeak)
+		}
+	}
+}
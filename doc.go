@@ -6,8 +6,11 @@
 // ## Currently supported providers
 //
 // + [Amazon Elastic Transcoder](https://aws.amazon.com/elastictranscoder/)
+// + [AWS Elemental MediaConvert](https://aws.amazon.com/mediaconvert/)
+// + [Bitmovin](https://bitmovin.com)
 // + [Elemental Conductor](https://www.elementaltechnologies.com/products/elemental-conductor)
 // + [Encoding.com](http://api.encoding.com)
+// + [Google Cloud Transcoder API](https://cloud.google.com/transcoder/docs)
 //
 // Schemes: http
 // BasePath: /
@@ -0,0 +1,281 @@
+// Package ffmpeg runs analysis passes over media files using the ffmpeg
+// command line tool, like detecting padding, fingerprinting videos,
+// measuring loudness or looking for flashing sequences and other defects in
+// the video.
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	blackDetectFilter   = "blackdetect=d=0.1:pix_th=0.10"
+	silenceDetectFilter = "silencedetect=noise=-60dB:d=0.1"
+
+	// audioFilter measures the loudness and the true peak of the audio
+	// (EBU R128), its sample peak and the silence lasting one second or
+	// more.
+	audioFilter = "ebur128=peak=true,astats,silencedetect=noise=-60dB:d=1"
+
+	// MinLevel is the level reported, in dB, for the loudness and the
+	// peaks of silent inputs.
+	MinLevel = -144.0
+
+	// clippingLevel is the sample peak level, in dBFS, from which samples
+	// at the peak are considered clipped.
+	clippingLevel = -0.01
+
+	// maxReportedOutput is the number of bytes of the output of ffmpeg
+	// included in errors.
+	maxReportedOutput = 512
+
+	// hashWidth and hashHeight are the dimensions of the grayscale frames
+	// used for computing difference hashes: each row of 9 pixels yields 8
+	// bits of the 64-bit hash.
+	hashWidth  = 9
+	hashHeight = 8
+)
+
+var (
+	durationRegexp      = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+	blackRegexp         = regexp.MustCompile(`black_start:\s*([\d.]+)\s+black_end:\s*([\d.]+)`)
+	silenceStartRegexp  = regexp.MustCompile(`silence_start:\s*(-?[\d.e+-]+)`)
+	silenceEndRegexp    = regexp.MustCompile(`silence_end:\s*([\d.e+-]+)`)
+	loudnessRegexp      = regexp.MustCompile(`\bI:\s*(-?[\d.]+|-inf) LUFS`)
+	loudnessRangeRegexp = regexp.MustCompile(`\bLRA:\s*([\d.]+) LU\b`)
+	truePeakRegexp      = regexp.MustCompile(`\bPeak:\s*(-?[\d.]+|-inf) dBFS`)
+	peakLevelRegexp     = regexp.MustCompile(`Peak level dB:\s*(-?[\d.]+|-inf)`)
+	peakCountRegexp     = regexp.MustCompile(`Peak count:\s*(\d+)`)
+
+	errUnknownDuration = errors.New("couldn't determine the duration of the input")
+	errNothingToDetect = errors.New("at least one of black frames or silence must be detected")
+	errNoFrames        = errors.New("the input doesn't have any video frame")
+	errNoAudio         = errors.New("the input doesn't have any audio")
+)
+
+// Runner runs ffmpeg passes over inputs that ffmpeg can read (local files
+// or HTTP(S) URLs).
+type Runner struct {
+	Path string
+
+	run    func(cmd *exec.Cmd) ([]byte, error)
+	output func(cmd *exec.Cmd) ([]byte, error)
+}
+
+// NewRunner returns a runner that uses the ffmpeg binary in the given path.
+func NewRunner(path string) *Runner {
+	if path == "" {
+		path = "ffmpeg"
+	}
+	return &Runner{Path: path, run: (*exec.Cmd).CombinedOutput, output: (*exec.Cmd).Output}
+}
+
+// Interval is a range of a media file, in seconds.
+type Interval struct {
+	Start float64
+	End   float64
+}
+
+// Padding lists the black and the silent intervals of a media file.
+type Padding struct {
+	Duration float64
+	Black    []Interval
+	Silence  []Interval
+}
+
+// AudioAnalysis is the loudness and the quality of the audio of a media
+// file. Loudness is measured as in EBU R128, with the integrated loudness
+// in LUFS, the loudness range in LU and the true peak in dBTP.
+// ClippedSamples counts the samples at full scale, and Silence lists the
+// silent intervals lasting one second or more.
+type AudioAnalysis struct {
+	Duration           float64
+	IntegratedLoudness float64
+	LoudnessRange      float64
+	TruePeak           float64
+	ClippedSamples     int64
+	Silence            []Interval
+}
+
+// DetectPadding decodes the input looking for black frames, silence or
+// both. Silence that lasts until the end of the input is reported as an
+// interval ending at Duration.
+func (r *Runner) DetectPadding(input string, black, silence bool) (*Padding, error) {
+	if !black && !silence {
+		return nil, errNothingToDetect
+	}
+	args := []string{"-hide_banner", "-nostats", "-i", input}
+	if black {
+		args = append(args, "-vf", blackDetectFilter)
+	} else {
+		args = append(args, "-vn")
+	}
+	if silence {
+		args = append(args, "-af", silenceDetectFilter)
+	} else {
+		args = append(args, "-an")
+	}
+	args = append(args, "-f", "null", "-")
+	output, err := r.run(exec.Command(r.Path, args...))
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %s: %s", err, tail(output))
+	}
+	return parsePadding(string(output))
+}
+
+// Fingerprint computes the perceptual fingerprint of the video of the
+// input: the 64-bit difference hash (dHash) of one frame every interval
+// seconds, hex encoded. Hashes of frames that look alike differ in few
+// bits, so re-encoded or rescaled copies of the input can be matched by the
+// Hamming distance between their hashes.
+func (r *Runner) Fingerprint(input string, interval float64) ([]string, error) {
+	if interval <= 0 {
+		interval = 1
+	}
+	filter := fmt.Sprintf("fps=1/%s,scale=%d:%d,format=gray", strconv.FormatFloat(interval, 'f', -1, 64), hashWidth, hashHeight)
+	cmd := exec.Command(r.Path, "-hide_banner", "-nostats", "-loglevel", "error", "-i", input, "-an", "-vf", filter, "-f", "rawvideo", "-")
+	frames, err := r.output(cmd)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("ffmpeg failed: %s: %s", err, tail(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("ffmpeg failed: %s", err)
+	}
+	frameSize := hashWidth * hashHeight
+	if len(frames) < frameSize {
+		return nil, errNoFrames
+	}
+	hashes := make([]string, len(frames)/frameSize)
+	for i := range hashes {
+		hashes[i] = fmt.Sprintf("%016x", differenceHash(frames[i*frameSize:(i+1)*frameSize]))
+	}
+	return hashes, nil
+}
+
+// AnalyzeAudio decodes the audio of the input, measuring its loudness and
+// looking for silence and clipping.
+func (r *Runner) AnalyzeAudio(input string) (*AudioAnalysis, error) {
+	output, err := r.run(exec.Command(r.Path, "-hide_banner", "-nostats", "-i", input, "-vn", "-af", audioFilter, "-f", "null", "-"))
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %s: %s", err, tail(output))
+	}
+	return parseAudioAnalysis(string(output))
+}
+
+// differenceHash returns the dHash of a grayscale frame of hashWidth by
+// hashHeight pixels: each bit tells whether a pixel is brighter than the
+// pixel to its right.
+func differenceHash(frame []byte) uint64 {
+	var hash uint64
+	for y := 0; y < hashHeight; y++ {
+		row := frame[y*hashWidth : (y+1)*hashWidth]
+		for x := 0; x < hashWidth-1; x++ {
+			hash <<= 1
+			if row[x] > row[x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+func parsePadding(output string) (*Padding, error) {
+	duration, err := parseDuration(output)
+	if err != nil {
+		return nil, err
+	}
+	padding := Padding{Duration: duration}
+	silenceStart := -1.0
+	for _, line := range strings.Split(output, "\n") {
+		if match := blackRegexp.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.ParseFloat(match[1], 64)
+			end, _ := strconv.ParseFloat(match[2], 64)
+			padding.Black = append(padding.Black, Interval{Start: start, End: end})
+		} else if match = silenceStartRegexp.FindStringSubmatch(line); match != nil {
+			silenceStart, _ = strconv.ParseFloat(match[1], 64)
+			if silenceStart < 0 {
+				silenceStart = 0
+			}
+		} else if match = silenceEndRegexp.FindStringSubmatch(line); match != nil && silenceStart >= 0 {
+			end, _ := strconv.ParseFloat(match[1], 64)
+			padding.Silence = append(padding.Silence, Interval{Start: silenceStart, End: end})
+			silenceStart = -1
+		}
+	}
+	if silenceStart >= 0 {
+		padding.Silence = append(padding.Silence, Interval{Start: silenceStart, End: padding.Duration})
+	}
+	return &padding, nil
+}
+
+// parseDuration returns the duration of the input reported by ffmpeg, in
+// seconds.
+func parseDuration(output string) (float64, error) {
+	match := durationRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return 0, errUnknownDuration
+	}
+	hours, _ := strconv.ParseFloat(match[1], 64)
+	minutes, _ := strconv.ParseFloat(match[2], 64)
+	seconds, _ := strconv.ParseFloat(match[3], 64)
+	return hours*3600 + minutes*60 + seconds, nil
+}
+
+func parseAudioAnalysis(output string) (*AudioAnalysis, error) {
+	padding, err := parsePadding(output)
+	if err != nil {
+		return nil, err
+	}
+	// the filters print their measurements over the whole input at the
+	// end, after the measurements of each frame.
+	summary := strings.LastIndex(output, "Summary:")
+	if summary < 0 {
+		return nil, errNoAudio
+	}
+	analysis := AudioAnalysis{
+		Duration:           padding.Duration,
+		IntegratedLoudness: parseLevel(loudnessRegexp, output[summary:]),
+		TruePeak:           parseLevel(truePeakRegexp, output[summary:]),
+		Silence:            padding.Silence,
+	}
+	if match := loudnessRangeRegexp.FindStringSubmatch(output[summary:]); match != nil {
+		analysis.LoudnessRange, _ = strconv.ParseFloat(match[1], 64)
+	}
+	if overall := strings.LastIndex(output, "Overall"); overall >= 0 {
+		if parseLevel(peakLevelRegexp, output[overall:]) >= clippingLevel {
+			if match := peakCountRegexp.FindStringSubmatch(output[overall:]); match != nil {
+				analysis.ClippedSamples, _ = strconv.ParseInt(match[1], 10, 64)
+			}
+		}
+	}
+	return &analysis, nil
+}
+
+// parseLevel returns the first level matched by the given regular
+// expression, or MinLevel when it's missing or -inf.
+func parseLevel(re *regexp.Regexp, output string) float64 {
+	match := re.FindStringSubmatch(output)
+	if match == nil {
+		return MinLevel
+	}
+	level, err := strconv.ParseFloat(match[1], 64)
+	if err != nil || level < MinLevel {
+		return MinLevel
+	}
+	return level
+}
+
+// tail returns the end of the output of a failed command, where ffmpeg
+// reports the error.
+func tail(output []byte) string {
+	value := strings.TrimSpace(string(output))
+	if len(value) > maxReportedOutput {
+		value = value[len(value)-maxReportedOutput:]
+	}
+	return value
+}
@@ -0,0 +1,270 @@
+package ffmpeg
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+const paddedOutput = `Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'https://example.com/wire/story.mp4':
+  Duration: 00:01:02.50, start: 0.000000, bitrate: 5120 kb/s
+    Stream #0:0(und): Video: h264 (High) (avc1 / 0x31637661), yuv420p, 1920x1080, 25 fps
+    Stream #0:1(und): Audio: aac (LC) (mp4a / 0x6134706D), 48000 Hz, stereo, fltp, 128 kb/s
+[silencedetect @ 0x7f8b4c000b80] silence_start: -0.0213333
+[silencedetect @ 0x7f8b4c000b80] silence_end: 1.92 | silence_duration: 1.94133
+[blackdetect @ 0x7f8b4c001200] black_start:0 black_end:2.04 black_duration:2.04
+[silencedetect @ 0x7f8b4c000b80] silence_start: 30.1
+[silencedetect @ 0x7f8b4c000b80] silence_end: 30.6 | silence_duration: 0.5
+[silencedetect @ 0x7f8b4c000b80] silence_start: 59.48
+[blackdetect @ 0x7f8b4c001200] black_start:60 black_end:62.5 black_duration:2.5
+`
+
+func TestDetectPadding(t *testing.T) {
+	var tests = []struct {
+		testCase     string
+		black        bool
+		silence      bool
+		expectedArgs []string
+	}{
+		{
+			"black and silence",
+			true,
+			true,
+			[]string{"/usr/bin/ffmpeg", "-hide_banner", "-nostats", "-i", "https://example.com/wire/story.mp4", "-vf", blackDetectFilter, "-af", silenceDetectFilter, "-f", "null", "-"},
+		},
+		{
+			"black",
+			true,
+			false,
+			[]string{"/usr/bin/ffmpeg", "-hide_banner", "-nostats", "-i", "https://example.com/wire/story.mp4", "-vf", blackDetectFilter, "-an", "-f", "null", "-"},
+		},
+		{
+			"silence",
+			false,
+			true,
+			[]string{"/usr/bin/ffmpeg", "-hide_banner", "-nostats", "-i", "https://example.com/wire/story.mp4", "-vn", "-af", silenceDetectFilter, "-f", "null", "-"},
+		},
+	}
+	for _, test := range tests {
+		var gotArgs []string
+		runner := NewRunner("/usr/bin/ffmpeg")
+		runner.run = func(cmd *exec.Cmd) ([]byte, error) {
+			gotArgs = cmd.Args
+			return []byte(paddedOutput), nil
+		}
+		padding, err := runner.DetectPadding("https://example.com/wire/story.mp4", test.black, test.silence)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if !reflect.DeepEqual(gotArgs, test.expectedArgs) {
+			t.Errorf("%s: wrong command.\nWant %#v\nGot  %#v", test.testCase, test.expectedArgs, gotArgs)
+		}
+		expected := Padding{
+			Duration: 62.5,
+			Black:    []Interval{{Start: 0, End: 2.04}, {Start: 60, End: 62.5}},
+			Silence:  []Interval{{Start: 0, End: 1.92}, {Start: 30.1, End: 30.6}, {Start: 59.48, End: 62.5}},
+		}
+		if !reflect.DeepEqual(*padding, expected) {
+			t.Errorf("%s: wrong padding.\nWant %#v\nGot  %#v", test.testCase, expected, *padding)
+		}
+	}
+}
+
+func TestDetectPaddingErrors(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		black    bool
+		output   string
+		runErr   error
+		errMsg   string
+	}{
+		{"nothing to detect", false, "", nil, errNothingToDetect.Error()},
+		{"ffmpeg failure", true, "story.mp4: Server returned 403 Forbidden\n", errors.New("exit status 1"), "ffmpeg failed: exit status 1: story.mp4: Server returned 403 Forbidden"},
+		{"missing duration", true, "[blackdetect @ 0x1] black_start:0 black_end:2 black_duration:2\n", nil, errUnknownDuration.Error()},
+	}
+	for _, test := range tests {
+		runner := NewRunner("")
+		runner.run = func(*exec.Cmd) ([]byte, error) {
+			return []byte(test.output), test.runErr
+		}
+		_, err := runner.DetectPadding("story.mp4", test.black, false)
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	var gotArgs []string
+	frames := make([]byte, 2*hashWidth*hashHeight)
+	for y := 0; y < hashHeight; y++ {
+		for x := 0; x < hashWidth; x++ {
+			// first frame: a gradient getting darker to the right, second
+			// frame: getting brighter.
+			frames[y*hashWidth+x] = byte(255 - x*10)
+			frames[hashWidth*hashHeight+y*hashWidth+x] = byte(x * 10)
+		}
+	}
+	runner := NewRunner("ffmpeg")
+	runner.output = func(cmd *exec.Cmd) ([]byte, error) {
+		gotArgs = cmd.Args
+		return append(frames, 0, 0, 0), nil
+	}
+	hashes, err := runner.Fingerprint("https://example.com/story.mp4", 2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedArgs := []string{"ffmpeg", "-hide_banner", "-nostats", "-loglevel", "error", "-i", "https://example.com/story.mp4", "-an", "-vf", "fps=1/2.5,scale=9:8,format=gray", "-f", "rawvideo", "-"}
+	if !reflect.DeepEqual(gotArgs, expectedArgs) {
+		t.Errorf("wrong command.\nWant %#v\nGot  %#v", expectedArgs, gotArgs)
+	}
+	expected := []string{"ffffffffffffffff", "0000000000000000"}
+	if !reflect.DeepEqual(hashes, expected) {
+		t.Errorf("wrong hashes. Want %#v. Got %#v", expected, hashes)
+	}
+}
+
+func TestFingerprintErrors(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		output   []byte
+		runErr   error
+		errMsg   string
+	}{
+		{"no frames", nil, nil, errNoFrames.Error()},
+		{"missing ffmpeg", nil, exec.ErrNotFound, "ffmpeg failed: executable file not found in $PATH"},
+	}
+	for _, test := range tests {
+		runner := NewRunner("ffmpeg")
+		runner.output = func(*exec.Cmd) ([]byte, error) {
+			return test.output, test.runErr
+		}
+		_, err := runner.Fingerprint("story.mp4", 0)
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
+
+const audioOutput = `Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'https://example.com/story_1080p.mp4':
+  Duration: 00:01:00.00, start: 0.000000, bitrate: 5120 kb/s
+    Stream #0:1(und): Audio: aac (LC) (mp4a / 0x6134706D), 48000 Hz, stereo, fltp, 128 kb/s
+[silencedetect @ 0x7f8b4c000b80] silence_start: 20.5
+[silencedetect @ 0x7f8b4c000b80] silence_end: 23 | silence_duration: 2.5
+[silencedetect @ 0x7f8b4c000b80] silence_start: 58.2
+[Parsed_ebur128_0 @ 0x7f8b4c001200] t: 59.9 TARGET:-23 LUFS M: -22.1 S: -23.4 I: -23.2 LUFS LRA: 6.1 LU FTPK: -3.1 -3.4 dBFS TPK: -0.4 -0.6 dBFS
+[Parsed_astats_1 @ 0x7f8b4c001500] Channel: 1
+[Parsed_astats_1 @ 0x7f8b4c001500] Peak level dB: -0.000000
+[Parsed_astats_1 @ 0x7f8b4c001500] Peak count: 8
+[Parsed_astats_1 @ 0x7f8b4c001500] Overall
+[Parsed_astats_1 @ 0x7f8b4c001500] Peak level dB: -0.000000
+[Parsed_astats_1 @ 0x7f8b4c001500] Peak count: 12
+[Parsed_ebur128_0 @ 0x7f8b4c001200] Summary:
+
+  Integrated loudness:
+    I:         -23.4 LUFS
+    Threshold: -33.6 LUFS
+
+  Loudness range:
+    LRA:         6.2 LU
+    Threshold:  -43.7 LUFS
+    LRA low:    -27.1 LUFS
+    LRA high:   -20.9 LUFS
+
+  True peak:
+    Peak:       -0.3 dBFS
+`
+
+func TestAnalyzeAudio(t *testing.T) {
+	var gotArgs []string
+	runner := NewRunner("ffmpeg")
+	runner.run = func(cmd *exec.Cmd) ([]byte, error) {
+		gotArgs = cmd.Args
+		return []byte(audioOutput), nil
+	}
+	analysis, err := runner.AnalyzeAudio("https://example.com/story_1080p.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedArgs := []string{"ffmpeg", "-hide_banner", "-nostats", "-i", "https://example.com/story_1080p.mp4", "-vn", "-af", audioFilter, "-f", "null", "-"}
+	if !reflect.DeepEqual(gotArgs, expectedArgs) {
+		t.Errorf("wrong command.\nWant %#v\nGot  %#v", expectedArgs, gotArgs)
+	}
+	expected := AudioAnalysis{
+		Duration:           60,
+		IntegratedLoudness: -23.4,
+		LoudnessRange:      6.2,
+		TruePeak:           -0.3,
+		ClippedSamples:     12,
+		Silence:            []Interval{{Start: 20.5, End: 23}, {Start: 58.2, End: 60}},
+	}
+	if !reflect.DeepEqual(*analysis, expected) {
+		t.Errorf("wrong analysis.\nWant %#v\nGot  %#v", expected, *analysis)
+	}
+}
+
+func TestAnalyzeAudioLevels(t *testing.T) {
+	var tests = []struct {
+		testCase         string
+		output           string
+		expectedLoudness float64
+		expectedPeak     float64
+		expectedClipped  int64
+	}{
+		{
+			"silent input",
+			"Duration: 00:00:10.00\n[Parsed_astats_1 @ 0x1] Overall\n[Parsed_astats_1 @ 0x1] Peak level dB: -inf\n[Parsed_astats_1 @ 0x1] Peak count: 480000\n[Parsed_ebur128_0 @ 0x2] Summary:\n    I:         -70.0 LUFS\n    LRA:         0.0 LU\n    Peak:       -inf dBFS\n",
+			-70,
+			MinLevel,
+			0,
+		},
+		{
+			"peaks below full scale",
+			"Duration: 00:00:10.00\n[Parsed_astats_1 @ 0x1] Overall\n[Parsed_astats_1 @ 0x1] Peak level dB: -1.500000\n[Parsed_astats_1 @ 0x1] Peak count: 2\n[Parsed_ebur128_0 @ 0x2] Summary:\n    I:         -16.1 LUFS\n    LRA:         4.0 LU\n    Peak:       -1.2 dBFS\n",
+			-16.1,
+			-1.2,
+			0,
+		},
+	}
+	for _, test := range tests {
+		runner := NewRunner("")
+		output := test.output
+		runner.run = func(*exec.Cmd) ([]byte, error) {
+			return []byte(output), nil
+		}
+		analysis, err := runner.AnalyzeAudio("story.mp4")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if analysis.IntegratedLoudness != test.expectedLoudness || analysis.TruePeak != test.expectedPeak || analysis.ClippedSamples != test.expectedClipped {
+			t.Errorf("%s: wrong levels. Want %g/%g/%d. Got %g/%g/%d", test.testCase, test.expectedLoudness, test.expectedPeak, test.expectedClipped, analysis.IntegratedLoudness, analysis.TruePeak, analysis.ClippedSamples)
+		}
+	}
+}
+
+func TestAnalyzeAudioErrors(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		output   string
+		runErr   error
+		errMsg   string
+	}{
+		{"no audio", "Duration: 00:00:10.00\nOutput file #0 does not contain any stream\n", nil, errNoAudio.Error()},
+		{"missing duration", "[Parsed_ebur128_0 @ 0x2] Summary:\n", nil, errUnknownDuration.Error()},
+		{"ffmpeg failure", "story.mp4: No such file or directory\n", errors.New("exit status 1"), "ffmpeg failed: exit status 1: story.mp4: No such file or directory"},
+	}
+	for _, test := range tests {
+		runner := NewRunner("")
+		output, runErr := test.output, test.runErr
+		runner.run = func(*exec.Cmd) ([]byte, error) {
+			return []byte(output), runErr
+		}
+		_, err := runner.AnalyzeAudio("story.mp4")
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// flashFilter prints the average luma of each frame, downscaled for
+	// speed.
+	flashFilter = "scale=-2:360,signalstats,metadata=print:key=lavfi.signalstats.YAVG"
+
+	// flashThreshold is the change in the relative luminance of the
+	// frame, from 0 (black) to 1 (white), that makes a transition of a
+	// flash, as long as the darker side is below flashDarkLevel.
+	flashThreshold = 0.1
+	flashDarkLevel = 0.8
+
+	// MaxFlashesPerSecond is the number of flashes allowed in any
+	// one-second window in the flash test.
+	MaxFlashesPerSecond = 3
+)
+
+var (
+	ptsTimeRegexp = regexp.MustCompile(`pts_time:\s*(-?[\d.]+)`)
+	lumaRegexp    = regexp.MustCompile(`lavfi\.signalstats\.YAVG=([\d.]+)`)
+)
+
+// FlashAnalysis is the outcome of the general flash test (as in the Harding
+// and the PSE guidelines) over the video of a media file. A flash is a pair
+// of opposing changes in the luminance of the frame, and the test fails in
+// the intervals with more than MaxFlashesPerSecond flashes in one second.
+// Luminance is measured over the whole frame, so flashes in small areas of
+// the frame and red flashes aren't detected.
+type FlashAnalysis struct {
+	Duration   float64
+	MaxFlashes int
+	Failures   []Interval
+}
+
+// AnalyzeFlashes decodes the video of the input looking for flashing
+// sequences that may trigger seizures in people with photosensitive
+// epilepsy.
+func (r *Runner) AnalyzeFlashes(input string) (*FlashAnalysis, error) {
+	output, err := r.run(exec.Command(r.Path, "-hide_banner", "-nostats", "-i", input, "-an", "-vf", flashFilter, "-f", "null", "-"))
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %s: %s", err, tail(output))
+	}
+	return parseFlashAnalysis(string(output))
+}
+
+type lumaSample struct {
+	time float64
+	luma float64
+}
+
+func parseFlashAnalysis(output string) (*FlashAnalysis, error) {
+	duration, err := parseDuration(output)
+	if err != nil {
+		return nil, err
+	}
+	var samples []lumaSample
+	for _, line := range strings.Split(output, "\n") {
+		if match := ptsTimeRegexp.FindStringSubmatch(line); match != nil {
+			time, _ := strconv.ParseFloat(match[1], 64)
+			samples = append(samples, lumaSample{time: time})
+		} else if match = lumaRegexp.FindStringSubmatch(line); match != nil && len(samples) > 0 {
+			luma, _ := strconv.ParseFloat(match[1], 64)
+			samples[len(samples)-1].luma = relativeLuminance(luma)
+		}
+	}
+	if len(samples) == 0 {
+		return nil, errNoFrames
+	}
+	analysis := FlashAnalysis{Duration: duration}
+	transitions := flashTransitions(samples)
+	start := 0
+	for end, time := range transitions {
+		for time-transitions[start] >= 1 {
+			start++
+		}
+		flashes := (end - start + 1) / 2
+		if flashes > analysis.MaxFlashes {
+			analysis.MaxFlashes = flashes
+		}
+		if flashes <= MaxFlashesPerSecond {
+			continue
+		}
+		failure := Interval{Start: transitions[start], End: time}
+		if last := len(analysis.Failures) - 1; last >= 0 && analysis.Failures[last].End >= failure.Start {
+			analysis.Failures[last].End = failure.End
+		} else {
+			analysis.Failures = append(analysis.Failures, failure)
+		}
+	}
+	return &analysis, nil
+}
+
+// relativeLuminance converts the average luma of a frame, in the limited
+// range of 8-bit video, to a value between 0 (black) and 1 (white).
+func relativeLuminance(luma float64) float64 {
+	return math.Max(0, math.Min(1, (luma-16)/219))
+}
+
+// flashTransitions returns the times of the changes in luminance that make
+// the transitions of flashes: changes of flashThreshold or more from the
+// last extreme, in the direction opposite to the previous transition.
+func flashTransitions(samples []lumaSample) []float64 {
+	var transitions []float64
+	extreme := samples[0].luma
+	direction := 0.0
+	for _, sample := range samples[1:] {
+		delta := sample.luma - extreme
+		if delta*direction > 0 {
+			// the previous transition goes on.
+			extreme = sample.luma
+			continue
+		}
+		if math.Abs(delta) >= flashThreshold && math.Min(sample.luma, extreme) < flashDarkLevel {
+			transitions = append(transitions, sample.time)
+			direction = delta
+			extreme = sample.luma
+		}
+	}
+	return transitions
+}
@@ -0,0 +1,119 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+// flashOutput returns the output of ffmpeg for a 25 fps video of 20 seconds
+// where the luma of the frames between start and end alternates between
+// low and high every halfPeriod seconds, and stays at low otherwise.
+func flashOutput(start, end, halfPeriod, low, high float64) string {
+	var buf bytes.Buffer
+	buf.WriteString("Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'story.mp4':\n  Duration: 00:00:20.00, start: 0.000000, bitrate: 5120 kb/s\n")
+	for i := 0; i < 500; i++ {
+		time := float64(i) * 0.04
+		luma := low
+		if time >= start && time < end && int((time-start)/halfPeriod+1e-9)%2 == 0 {
+			luma = high
+		}
+		fmt.Fprintf(&buf, "[Parsed_metadata_2 @ 0x7f8b4c001200] frame:%-4d pts:%-6d pts_time:%g\n", i, i*512, time)
+		fmt.Fprintf(&buf, "[Parsed_metadata_2 @ 0x7f8b4c001200] lavfi.signalstats.YAVG=%.2f\n", luma)
+	}
+	return buf.String()
+}
+
+func TestAnalyzeFlashes(t *testing.T) {
+	var tests = []struct {
+		testCase           string
+		output             string
+		expectedMaxFlashes int
+		expectedFailures   []Interval
+	}{
+		{
+			"steady video",
+			flashOutput(0, 0, 1, 120, 120),
+			0,
+			nil,
+		},
+		{
+			"slow flashes",
+			flashOutput(5, 10, 0.24, 16, 235),
+			2,
+			nil,
+		},
+		{
+			"fast flashes",
+			flashOutput(5, 7, 0.12, 16, 235),
+			4,
+			[]Interval{{Start: 5, End: 7}},
+		},
+		{
+			"small changes in luminance",
+			flashOutput(5, 7, 0.08, 100, 110),
+			0,
+			nil,
+		},
+		{
+			"flashes between bright frames",
+			flashOutput(5, 7, 0.08, 200, 235),
+			0,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		var gotArgs []string
+		runner := NewRunner("ffmpeg")
+		output := test.output
+		runner.run = func(cmd *exec.Cmd) ([]byte, error) {
+			gotArgs = cmd.Args
+			return []byte(output), nil
+		}
+		analysis, err := runner.AnalyzeFlashes("https://example.com/story_1080p.mp4")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		expectedArgs := []string{"ffmpeg", "-hide_banner", "-nostats", "-i", "https://example.com/story_1080p.mp4", "-an", "-vf", flashFilter, "-f", "null", "-"}
+		if !reflect.DeepEqual(gotArgs, expectedArgs) {
+			t.Errorf("%s: wrong command.\nWant %#v\nGot  %#v", test.testCase, expectedArgs, gotArgs)
+		}
+		if analysis.Duration != 20 {
+			t.Errorf("%s: wrong duration. Want 20. Got %g", test.testCase, analysis.Duration)
+		}
+		if analysis.MaxFlashes != test.expectedMaxFlashes {
+			t.Errorf("%s: wrong max flashes. Want %d. Got %d", test.testCase, test.expectedMaxFlashes, analysis.MaxFlashes)
+		}
+		if !reflect.DeepEqual(analysis.Failures, test.expectedFailures) {
+			t.Errorf("%s: wrong failures.\nWant %#v\nGot  %#v", test.testCase, test.expectedFailures, analysis.Failures)
+		}
+	}
+}
+
+func TestAnalyzeFlashesErrors(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		output   string
+		runErr   error
+		errMsg   string
+	}{
+		{"no video", "Duration: 00:00:10.00\nOutput file #0 does not contain any stream\n", nil, errNoFrames.Error()},
+		{"missing duration", "[Parsed_metadata_2 @ 0x1] frame:0 pts:0 pts_time:0\n", nil, errUnknownDuration.Error()},
+		{"ffmpeg failure", "story.mp4: No such file or directory\n", errors.New("exit status 1"), "ffmpeg failed: exit status 1: story.mp4: No such file or directory"},
+	}
+	for _, test := range tests {
+		runner := NewRunner("")
+		output, runErr := test.output, test.runErr
+		runner.run = func(*exec.Cmd) ([]byte, error) {
+			return []byte(output), runErr
+		}
+		_, err := runner.AnalyzeFlashes("story.mp4")
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// Frame is a frame of the video of a media file, encoded as JPEG.
+type Frame struct {
+	Time float64
+	Data []byte
+}
+
+// ExtractFrames extracts count frames of the video of the input, evenly
+// spaced and skipping the first and the last frames, which tend to be
+// black.
+func (r *Runner) ExtractFrames(input string, count int) ([]Frame, error) {
+	// without an output file ffmpeg fails, after printing the duration
+	// of the input.
+	output, _ := r.run(exec.Command(r.Path, "-hide_banner", "-i", input))
+	duration, err := parseDuration(string(output))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, tail(output))
+	}
+	frames := make([]Frame, 0, count)
+	for i := 0; i < count; i++ {
+		time := duration * float64(i+1) / float64(count+1)
+		cmd := exec.Command(r.Path, "-hide_banner", "-loglevel", "error", "-ss", strconv.FormatFloat(time, 'f', 3, 64), "-i", input, "-an", "-frames:v", "1", "-f", "image2pipe", "-vcodec", "mjpeg", "-q:v", "2", "-")
+		data, err := r.output(cmd)
+		if err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				return nil, fmt.Errorf("ffmpeg failed: %s: %s", err, tail(exitErr.Stderr))
+			}
+			return nil, fmt.Errorf("ffmpeg failed: %s", err)
+		}
+		if len(data) == 0 {
+			return nil, errNoFrames
+		}
+		frames = append(frames, Frame{Time: time, Data: data})
+	}
+	return frames, nil
+}
@@ -0,0 +1,60 @@
+package ffmpeg
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestExtractFrames(t *testing.T) {
+	var gotTimes []string
+	runner := NewRunner("ffmpeg")
+	runner.run = func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte("Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'story.mp4':\n  Duration: 00:00:20.00, start: 0.000000, bitrate: 5120 kb/s\nAt least one output file must be specified\n"), &exec.ExitError{}
+	}
+	runner.output = func(cmd *exec.Cmd) ([]byte, error) {
+		gotTimes = append(gotTimes, cmd.Args[5])
+		return []byte("jpeg at " + cmd.Args[5]), nil
+	}
+	frames, err := runner.ExtractFrames("https://example.com/story.mp4", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTimes := []string{"5.000", "10.000", "15.000"}
+	if !reflect.DeepEqual(gotTimes, expectedTimes) {
+		t.Errorf("wrong frame times. Want %#v. Got %#v", expectedTimes, gotTimes)
+	}
+	expected := []Frame{
+		{Time: 5, Data: []byte("jpeg at 5.000")},
+		{Time: 10, Data: []byte("jpeg at 10.000")},
+		{Time: 15, Data: []byte("jpeg at 15.000")},
+	}
+	if !reflect.DeepEqual(frames, expected) {
+		t.Errorf("wrong frames. Want %#v. Got %#v", expected, frames)
+	}
+}
+
+func TestExtractFramesErrors(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		runOutput string
+		output    []byte
+		errMsg    string
+	}{
+		{"unknown duration", "story.mp4: No such file or directory", nil, errUnknownDuration.Error() + ": story.mp4: No such file or directory"},
+		{"no frames", "  Duration: 00:00:20.00, start: 0.000000", nil, errNoFrames.Error()},
+	}
+	for _, test := range tests {
+		runner := NewRunner("ffmpeg")
+		runner.run = func(*exec.Cmd) ([]byte, error) {
+			return []byte(test.runOutput), &exec.ExitError{}
+		}
+		runner.output = func(*exec.Cmd) ([]byte, error) {
+			return test.output, nil
+		}
+		_, err := runner.ExtractFrames("story.mp4", 3)
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+)
+
+var bitrateRegexp = regexp.MustCompile(`Duration: [^\n]*bitrate: (\d+) kb/s`)
+
+// MediaInfo is the duration, in seconds, and the overall bitrate, in bits
+// per second, of a media file.
+type MediaInfo struct {
+	Duration float64
+	Bitrate  int64
+}
+
+// Size returns the approximate size of the media file, in bytes, computed
+// from its duration and its overall bitrate.
+func (m MediaInfo) Size() int64 {
+	return int64(m.Duration * float64(m.Bitrate) / 8)
+}
+
+// Probe reads the header of the input, returning its duration and its
+// overall bitrate.
+func (r *Runner) Probe(input string) (*MediaInfo, error) {
+	// without an output file ffmpeg fails, after printing the header of
+	// the input.
+	output, _ := r.run(exec.Command(r.Path, "-hide_banner", "-i", input))
+	return parseMediaInfo(string(output))
+}
+
+func parseMediaInfo(output string) (*MediaInfo, error) {
+	duration, err := parseDuration(output)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, tail([]byte(output)))
+	}
+	info := MediaInfo{Duration: duration}
+	if match := bitrateRegexp.FindStringSubmatch(output); match != nil {
+		kbps, _ := strconv.ParseInt(match[1], 10, 64)
+		info.Bitrate = kbps * 1000
+	}
+	return &info, nil
+}
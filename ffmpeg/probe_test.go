@@ -0,0 +1,63 @@
+package ffmpeg
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		runOutput string
+
+		wantInfo  *MediaInfo
+		wantSize  int64
+		wantError bool
+	}{
+		{
+			"video",
+			"Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'story.mp4':\n  Duration: 00:01:20.00, start: 0.000000, bitrate: 5120 kb/s\nAt least one output file must be specified\n",
+			&MediaInfo{Duration: 80, Bitrate: 5120000},
+			51200000,
+			false,
+		},
+		{
+			"unknown bitrate",
+			"Input #0, hls, from 'index.m3u8':\n  Duration: 00:00:10.50, start: 0.000000, bitrate: N/A\nAt least one output file must be specified\n",
+			&MediaInfo{Duration: 10.5},
+			0,
+			false,
+		},
+		{
+			"unreadable input",
+			"story.mp4: No such file or directory\n",
+			nil,
+			0,
+			true,
+		},
+	}
+	for _, test := range tests {
+		runner := NewRunner("ffmpeg")
+		runner.run = func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte(test.runOutput), &exec.ExitError{}
+		}
+		info, err := runner.Probe("story.mp4")
+		if test.wantError {
+			if err == nil {
+				t.Errorf("%s: unexpected <nil> error", test.testCase)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if !reflect.DeepEqual(info, test.wantInfo) {
+			t.Errorf("%s: wrong info. Want %#v. Got %#v", test.testCase, test.wantInfo, info)
+		}
+		if size := info.Size(); size != test.wantSize {
+			t.Errorf("%s: wrong size. Want %d. Got %d", test.testCase, test.wantSize, size)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// videoFilter detects black and frozen ranges lasting half a second or
+// more, and prints the blockiness of each frame.
+const videoFilter = "blackdetect=d=0.5:pix_th=0.10,freezedetect=n=-60dB:d=0.5,blockdetect,metadata=print:key=lavfi.block"
+
+var (
+	freezeStartRegexp = regexp.MustCompile(`freeze_start:\s*([\d.]+)`)
+	freezeEndRegexp   = regexp.MustCompile(`freeze_end:\s*([\d.]+)`)
+	blockRegexp       = regexp.MustCompile(`lavfi\.block=([\d.]+)`)
+)
+
+// VideoAnalysis lists the defects found in the video of a media file: black
+// and frozen ranges lasting half a second or more, and ranges of frames
+// with blockiness (as measured by the ffmpeg blockdetect filter) at or above
+// the given threshold. MaxBlockiness is the blockiness of the blockiest
+// frame.
+type VideoAnalysis struct {
+	Duration      float64
+	Black         []Interval
+	Frozen        []Interval
+	Blocky        []Interval
+	MaxBlockiness float64
+}
+
+// AnalyzeVideo decodes the video of the input looking for black, frozen
+// and blocky frames. Blocky ranges are only reported for a positive
+// blockiness threshold.
+func (r *Runner) AnalyzeVideo(input string, blockiness float64) (*VideoAnalysis, error) {
+	output, err := r.run(exec.Command(r.Path, "-hide_banner", "-nostats", "-i", input, "-an", "-vf", videoFilter, "-f", "null", "-"))
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %s: %s", err, tail(output))
+	}
+	return parseVideoAnalysis(string(output), blockiness)
+}
+
+func parseVideoAnalysis(output string, blockiness float64) (*VideoAnalysis, error) {
+	padding, err := parsePadding(output)
+	if err != nil {
+		return nil, err
+	}
+	analysis := VideoAnalysis{Duration: padding.Duration, Black: padding.Black}
+	var frames int
+	frameTime, freezeStart, blockyStart := 0.0, -1.0, -1.0
+	for _, line := range strings.Split(output, "\n") {
+		if match := ptsTimeRegexp.FindStringSubmatch(line); match != nil {
+			frameTime, _ = strconv.ParseFloat(match[1], 64)
+			frames++
+		} else if match = blockRegexp.FindStringSubmatch(line); match != nil {
+			value, _ := strconv.ParseFloat(match[1], 64)
+			if value > analysis.MaxBlockiness {
+				analysis.MaxBlockiness = value
+			}
+			blocky := blockiness > 0 && value >= blockiness
+			if blocky && blockyStart < 0 {
+				blockyStart = frameTime
+			} else if !blocky && blockyStart >= 0 {
+				analysis.Blocky = append(analysis.Blocky, Interval{Start: blockyStart, End: frameTime})
+				blockyStart = -1
+			}
+		} else if match = freezeStartRegexp.FindStringSubmatch(line); match != nil {
+			freezeStart, _ = strconv.ParseFloat(match[1], 64)
+		} else if match = freezeEndRegexp.FindStringSubmatch(line); match != nil && freezeStart >= 0 {
+			end, _ := strconv.ParseFloat(match[1], 64)
+			analysis.Frozen = append(analysis.Frozen, Interval{Start: freezeStart, End: end})
+			freezeStart = -1
+		}
+	}
+	if frames == 0 {
+		return nil, errNoFrames
+	}
+	if freezeStart >= 0 {
+		analysis.Frozen = append(analysis.Frozen, Interval{Start: freezeStart, End: analysis.Duration})
+	}
+	if blockyStart >= 0 {
+		analysis.Blocky = append(analysis.Blocky, Interval{Start: blockyStart, End: analysis.Duration})
+	}
+	return &analysis, nil
+}
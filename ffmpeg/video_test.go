@@ -0,0 +1,91 @@
+package ffmpeg
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+const videoOutput = `Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'https://example.com/story_1080p.mp4':
+  Duration: 00:00:10.00, start: 0.000000, bitrate: 5120 kb/s
+    Stream #0:0(und): Video: h264 (High) (avc1 / 0x31637661), yuv420p, 1920x1080, 25 fps
+[Parsed_metadata_3 @ 0x7f8b4c001200] frame:0    pts:0       pts_time:0
+[Parsed_metadata_3 @ 0x7f8b4c001200] lavfi.block=1.2
+[Parsed_metadata_3 @ 0x7f8b4c001200] frame:1    pts:1024    pts_time:2
+[Parsed_metadata_3 @ 0x7f8b4c001200] lavfi.block=6.5
+[freezedetect @ 0x7f8b4c001600] lavfi.freezedetect.freeze_start: 2.5
+[Parsed_metadata_3 @ 0x7f8b4c001200] frame:2    pts:2048    pts_time:4
+[Parsed_metadata_3 @ 0x7f8b4c001200] lavfi.block=4.8
+[freezedetect @ 0x7f8b4c001600] lavfi.freezedetect.freeze_duration: 2.5
+[freezedetect @ 0x7f8b4c001600] lavfi.freezedetect.freeze_end: 5
+[blackdetect @ 0x7f8b4c001400] black_start:5 black_end:6.5 black_duration:1.5
+[Parsed_metadata_3 @ 0x7f8b4c001200] frame:3    pts:3072    pts_time:6
+[Parsed_metadata_3 @ 0x7f8b4c001200] lavfi.block=1.1
+[Parsed_metadata_3 @ 0x7f8b4c001200] frame:4    pts:4096    pts_time:8
+[Parsed_metadata_3 @ 0x7f8b4c001200] lavfi.block=5.5
+[freezedetect @ 0x7f8b4c001600] lavfi.freezedetect.freeze_start: 8.5
+`
+
+func TestAnalyzeVideo(t *testing.T) {
+	var tests = []struct {
+		testCase       string
+		blockiness     float64
+		expectedBlocky []Interval
+	}{
+		{"blockiness not checked", 0, nil},
+		{"blocky frames", 4.5, []Interval{{Start: 2, End: 6}, {Start: 8, End: 10}}},
+		{"no blocky frames", 7, nil},
+	}
+	for _, test := range tests {
+		var gotArgs []string
+		runner := NewRunner("ffmpeg")
+		runner.run = func(cmd *exec.Cmd) ([]byte, error) {
+			gotArgs = cmd.Args
+			return []byte(videoOutput), nil
+		}
+		analysis, err := runner.AnalyzeVideo("https://example.com/story_1080p.mp4", test.blockiness)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		expectedArgs := []string{"ffmpeg", "-hide_banner", "-nostats", "-i", "https://example.com/story_1080p.mp4", "-an", "-vf", videoFilter, "-f", "null", "-"}
+		if !reflect.DeepEqual(gotArgs, expectedArgs) {
+			t.Errorf("%s: wrong command.\nWant %#v\nGot  %#v", test.testCase, expectedArgs, gotArgs)
+		}
+		expected := VideoAnalysis{
+			Duration:      10,
+			Black:         []Interval{{Start: 5, End: 6.5}},
+			Frozen:        []Interval{{Start: 2.5, End: 5}, {Start: 8.5, End: 10}},
+			Blocky:        test.expectedBlocky,
+			MaxBlockiness: 6.5,
+		}
+		if !reflect.DeepEqual(*analysis, expected) {
+			t.Errorf("%s: wrong analysis.\nWant %#v\nGot  %#v", test.testCase, expected, *analysis)
+		}
+	}
+}
+
+func TestAnalyzeVideoErrors(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		output   string
+		runErr   error
+		errMsg   string
+	}{
+		{"no video", "Duration: 00:00:10.00\nOutput file #0 does not contain any stream\n", nil, errNoFrames.Error()},
+		{"missing duration", "[Parsed_metadata_3 @ 0x1] frame:0 pts:0 pts_time:0\n", nil, errUnknownDuration.Error()},
+		{"ffmpeg failure", "story.mp4: No such file or directory\n", errors.New("exit status 1"), "ffmpeg failed: exit status 1: story.mp4: No such file or directory"},
+	}
+	for _, test := range tests {
+		runner := NewRunner("")
+		output, runErr := test.output, test.runErr
+		runner.run = func(*exec.Cmd) ([]byte, error) {
+			return []byte(output), runErr
+		}
+		_, err := runner.AnalyzeVideo("story.mp4", 5)
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
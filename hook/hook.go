@@ -0,0 +1,121 @@
+// Package hook provides the processors run by the API around jobs: HTTP
+// webhooks, AWS Lambda functions and plugins registered in the process.
+// Processors take a JSON document as input, and may return another JSON
+// document as output.
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+)
+
+// Kinds of hooks.
+const (
+	KindWebhook = "webhook"
+	KindLambda  = "lambda"
+	KindPlugin  = "plugin"
+)
+
+// ErrPluginAlreadyRegistered is the error returned when registering a plugin
+// using a name that is already in use.
+var ErrPluginAlreadyRegistered = errors.New("plugin already registered")
+
+// Processor processes the given JSON input, returning its JSON output, if
+// any.
+type Processor interface {
+	Process(input []byte) ([]byte, error)
+}
+
+// ProcessorFunc is an adapter for using functions as processors.
+type ProcessorFunc func(input []byte) ([]byte, error)
+
+// Process calls f(input).
+func (f ProcessorFunc) Process(input []byte) ([]byte, error) {
+	return f(input)
+}
+
+// Hook is a named processor configured in the API.
+type Hook struct {
+	Name string
+	Processor
+}
+
+var plugins map[string]Processor
+
+// RegisterPlugin registers a processor that runs in the process of the API,
+// referenced by hooks of the plugin kind.
+func RegisterPlugin(name string, p Processor) error {
+	if plugins == nil {
+		plugins = make(map[string]Processor)
+	}
+	if _, ok := plugins[name]; ok {
+		return ErrPluginAlreadyRegistered
+	}
+	plugins[name] = p
+	return nil
+}
+
+// Parse parses a comma separated list of "name:kind:target" triples,
+// returning the hooks in the same order.
+func Parse(spec string, cfg *config.Hooks) ([]Hook, error) {
+	if cfg == nil {
+		cfg = &config.Hooks{}
+	}
+	var hooks []Hook
+	names := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf(`invalid hook %q, it must be in the format "name:kind:target"`, item)
+		}
+		name, kind, target := parts[0], parts[1], parts[2]
+		if names[name] {
+			return nil, fmt.Errorf("duplicate hook %q", name)
+		}
+		names[name] = true
+		hook := Hook{Name: name}
+		switch kind {
+		case KindWebhook:
+			hook.Processor = NewWebhook(target, cfg.Timeout)
+		case KindLambda:
+			hook.Processor = NewLambda(target, cfg)
+		case KindPlugin:
+			plugin, ok := plugins[target]
+			if !ok {
+				return nil, fmt.Errorf("plugin %q of hook %q not registered", target, name)
+			}
+			hook.Processor = plugin
+		default:
+			return nil, fmt.Errorf("invalid kind %q of hook %q", kind, name)
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+// newHTTPClient returns the client used for running the hooks, with the
+// given timeout.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout}
+}
+
+func newLambdaClient(cfg *config.Hooks) *lambda.Lambda {
+	awsConfig := aws.NewConfig().WithRegion(cfg.Region).WithHTTPClient(newHTTPClient(cfg.Timeout))
+	if cfg.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+	}
+	return lambda.New(session.New(awsConfig))
+}
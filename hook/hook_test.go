@@ -0,0 +1,99 @@
+package hook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+)
+
+func TestParse(t *testing.T) {
+	plugins = nil
+	plugin := ProcessorFunc(func(input []byte) ([]byte, error) { return input, nil })
+	if err := RegisterPlugin("audit", plugin); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterPlugin("audit", plugin); err != ErrPluginAlreadyRegistered {
+		t.Errorf("wrong error. Want ErrPluginAlreadyRegistered. Got %#v", err)
+	}
+	var tests = []struct {
+		givenTestCase string
+		givenSpec     string
+
+		wantNames []string
+		wantError string
+	}{
+		{"empty", "", nil, ""},
+		{
+			"all kinds",
+			"catalog:webhook:https://catalog.example.com/hooks, thumbs:lambda:thumbnailer,audit:plugin:audit",
+			[]string{"catalog", "thumbs", "audit"},
+			"",
+		},
+		{"missing target", "catalog:webhook", nil, `invalid hook "catalog:webhook", it must be in the format "name:kind:target"`},
+		{"unknown kind", "catalog:ftp:ftp://catalog", nil, `invalid kind "ftp" of hook "catalog"`},
+		{"unknown plugin", "audit:plugin:unknown", nil, `plugin "unknown" of hook "audit" not registered`},
+		{"duplicate", "audit:plugin:audit,audit:plugin:audit", nil, `duplicate hook "audit"`},
+	}
+	for _, test := range tests {
+		hooks, err := Parse(test.givenSpec, &config.Hooks{Timeout: time.Second, Region: "us-east-1"})
+		if test.wantError != "" {
+			if err == nil || err.Error() != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %#v", test.givenTestCase, test.wantError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.givenTestCase, err)
+			continue
+		}
+		var names []string
+		for _, h := range hooks {
+			names = append(names, h.Name)
+		}
+		if !reflect.DeepEqual(names, test.wantNames) {
+			t.Errorf("%s: wrong hooks. Want %#v. Got %#v", test.givenTestCase, test.wantNames, names)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenStatus   int
+
+		wantOutput string
+		wantError  string
+	}{
+		{"success", http.StatusOK, `{"received":true}`, ""},
+		{"failure", http.StatusBadGateway, `{"received":true}`, "webhook returned 502 Bad Gateway"},
+	}
+	for _, test := range tests {
+		var body []byte
+		var contentType string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType = r.Header.Get("Content-Type")
+			body, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(test.givenStatus)
+			w.Write([]byte(`{"received":true}`))
+		}))
+		output, err := NewWebhook(server.URL, time.Second).Process([]byte(`{"jobId":"job-123"}`))
+		server.Close()
+		if test.wantError == "" && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.givenTestCase, err)
+		}
+		if test.wantError != "" && (err == nil || err.Error() != test.wantError) {
+			t.Errorf("%s: wrong error. Want %q. Got %#v", test.givenTestCase, test.wantError, err)
+		}
+		if string(output) != test.wantOutput {
+			t.Errorf("%s: wrong output. Want %q. Got %q", test.givenTestCase, test.wantOutput, output)
+		}
+		if string(body) != `{"jobId":"job-123"}` || contentType != "application/json" {
+			t.Errorf("%s: wrong request: %q (%s)", test.givenTestCase, body, contentType)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+)
+
+// maxOutputSize is the maximum size of the output of webhooks, in bytes.
+const maxOutputSize = 1 << 20
+
+// Webhook processes inputs by sending them to a URL in POST requests, like
+// the URL of a Cloud Function with an HTTP trigger. The body of successful
+// responses is the output.
+type Webhook struct {
+	URL string
+
+	client *http.Client
+}
+
+// NewWebhook returns a webhook for the given URL, with the given timeout
+// for each request.
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{URL: url, client: newHTTPClient(timeout)}
+}
+
+// Process sends the input to the URL of the webhook.
+func (w *Webhook) Process(input []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(input))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	output, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxOutputSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return output, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return output, nil
+}
+
+// Lambda processes inputs by invoking an AWS Lambda function. The payload
+// returned by the function is the output.
+type Lambda struct {
+	Function string
+
+	client lambdaiface.LambdaAPI
+}
+
+// NewLambda returns a processor invoking the given function, using the
+// AWS credentials of the configuration.
+func NewLambda(function string, cfg *config.Hooks) *Lambda {
+	return &Lambda{Function: function, client: newLambdaClient(cfg)}
+}
+
+// Process invokes the function synchronously with the input as payload.
+func (l *Lambda) Process(input []byte) ([]byte, error) {
+	out, err := l.client.Invoke(&lambda.InvokeInput{
+		FunctionName: aws.String(l.Function),
+		Payload:      input,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.FunctionError != nil {
+		return out.Payload, fmt.Errorf("function %s failed (%s): %s", l.Function, aws.StringValue(out.FunctionError), out.Payload)
+	}
+	return out.Payload, nil
+}
@@ -5,9 +5,13 @@ import (
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/NYTimes/video-transcoding-api/config"
+	_ "github.com/NYTimes/video-transcoding-api/provider/bitmovin"
+	_ "github.com/NYTimes/video-transcoding-api/provider/chaos"
 	_ "github.com/NYTimes/video-transcoding-api/provider/elastictranscoder"
 	_ "github.com/NYTimes/video-transcoding-api/provider/elementalconductor"
 	_ "github.com/NYTimes/video-transcoding-api/provider/encodingcom"
+	_ "github.com/NYTimes/video-transcoding-api/provider/gcptranscoder"
+	_ "github.com/NYTimes/video-transcoding-api/provider/mediaconvert"
 	_ "github.com/NYTimes/video-transcoding-api/provider/zencoder"
 	"github.com/NYTimes/video-transcoding-api/service"
 	"github.com/knq/sdhook"
@@ -35,6 +39,17 @@ func main() {
 	if err != nil {
 		server.Log.Fatal("unable to initialize service: ", err)
 	}
+	go service.RunReconciliation(nil)
+	go service.RunStatusPoller(nil)
+	go service.RunWatchFolders(nil)
+	go service.RunJobSweeper(nil)
+	go service.RunCampaigns(nil)
+	go service.RunDeadlineWatchdog(nil)
+	go service.RunUploads(nil)
+	go service.RunFallbacks(nil)
+	go service.RunCallbacks(nil)
+	go service.RunPostProcessors(nil)
+	go service.RunChaosWebhooks(nil)
 	err = server.Register(service)
 	if err != nil {
 		server.Log.Fatal("unable to register service: ", err)
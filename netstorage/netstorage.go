@@ -0,0 +1,91 @@
+// Package netstorage provides a client for uploading files to Akamai
+// NetStorage using its HTTP API.
+package netstorage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const uploadAction = "version=1&action=upload&upload-type=binary"
+
+// Client uploads files to a NetStorage storage group.
+type Client struct {
+	// Host is the HTTP API host of the storage group (for example,
+	// "example-nsu.akamaihd.net").
+	Host string
+
+	// KeyName and Key are the name and the value of the upload account
+	// key.
+	KeyName string
+	Key     string
+
+	HTTPClient *http.Client
+
+	now func() time.Time
+}
+
+// NewClient returns a client for the given storage group.
+func NewClient(host, keyName, key string) *Client {
+	return &Client{
+		Host:       host,
+		KeyName:    keyName,
+		Key:        key,
+		HTTPClient: &http.Client{Timeout: time.Hour},
+		now:        time.Now,
+	}
+}
+
+// Upload uploads the content of body to the given path, in the format
+// "/<cpcode>/<path>".
+func (c *Client) Upload(path string, body io.Reader, size int64) error {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	req, err := http.NewRequest("PUT", "https://"+c.Host+path, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	authData, err := c.authData()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Akamai-ACS-Action", uploadAction)
+	req.Header.Set("X-Akamai-ACS-Auth-Data", authData)
+	req.Header.Set("X-Akamai-ACS-Auth-Sign", c.sign(authData, path, uploadAction))
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error uploading %q to NetStorage: %s", path, resp.Status)
+	}
+	return nil
+}
+
+// authData returns the value of the X-Akamai-ACS-Auth-Data header, using
+// version 5 (HMAC-SHA256) of the authentication scheme.
+func (c *Client) authData() (string, error) {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("5, 0.0.0.0, 0.0.0.0, %d, %s, %s", c.now().Unix(), hex.EncodeToString(id[:]), c.KeyName), nil
+}
+
+// sign returns the value of the X-Akamai-ACS-Auth-Sign header.
+func (c *Client) sign(authData, path, action string) string {
+	mac := hmac.New(sha256.New, []byte(c.Key))
+	io.WriteString(mac, authData+path+"\nx-akamai-acs-action:"+action+"\n")
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
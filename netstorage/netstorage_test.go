@@ -0,0 +1,74 @@
+package netstorage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var insecureClient = &http.Client{
+	Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+}
+
+func TestUpload(t *testing.T) {
+	var (
+		gotPath    string
+		gotBody    string
+		gotHeaders http.Header
+	)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		gotPath = r.URL.Path
+		gotBody = string(data)
+		gotHeaders = r.Header
+	}))
+	defer server.Close()
+	client := NewClient(strings.TrimPrefix(server.URL, "https://"), "upload-key", "secret")
+	client.HTTPClient = insecureClient
+	client.now = func() time.Time { return time.Unix(1480000000, 0) }
+	err := client.Upload("123456/videos/video.mp4", strings.NewReader("some video"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/123456/videos/video.mp4" {
+		t.Errorf("wrong path. Want %q. Got %q", "/123456/videos/video.mp4", gotPath)
+	}
+	if gotBody != "some video" {
+		t.Errorf("wrong body. Want %q. Got %q", "some video", gotBody)
+	}
+	if action := gotHeaders.Get("X-Akamai-ACS-Action"); action != uploadAction {
+		t.Errorf("wrong action. Want %q. Got %q", uploadAction, action)
+	}
+	authData := gotHeaders.Get("X-Akamai-ACS-Auth-Data")
+	parts := strings.Split(authData, ", ")
+	if len(parts) != 6 || parts[0] != "5" || parts[3] != "1480000000" || parts[5] != "upload-key" {
+		t.Errorf("wrong auth data: %q", authData)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(authData + "/123456/videos/video.mp4\nx-akamai-acs-action:" + uploadAction + "\n"))
+	wantSign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if sign := gotHeaders.Get("X-Akamai-ACS-Auth-Sign"); sign != wantSign {
+		t.Errorf("wrong signature. Want %q. Got %q", wantSign, sign)
+	}
+}
+
+func TestUploadError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+	client := NewClient(strings.TrimPrefix(server.URL, "https://"), "upload-key", "secret")
+	client.HTTPClient = insecureClient
+	err := client.Upload("/123456/video.mp4", strings.NewReader("some video"), 10)
+	want := `error uploading "/123456/video.mp4" to NetStorage: 403 Forbidden`
+	if err == nil || err.Error() != want {
+		t.Errorf("wrong error. Want %q. Got %v", want, err)
+	}
+}
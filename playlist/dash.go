@@ -0,0 +1,89 @@
+package playlist
+
+import "encoding/xml"
+
+type mpd struct {
+	Periods []struct {
+		AdaptationSets []adaptationSet `xml:"AdaptationSet"`
+	} `xml:"Period"`
+}
+
+type adaptationSet struct {
+	SegmentTemplate *segmentTemplate `xml:"SegmentTemplate"`
+	Representations []struct {
+		ID              string           `xml:"id,attr"`
+		SegmentTemplate *segmentTemplate `xml:"SegmentTemplate"`
+		SegmentList     *struct {
+			Timescale   uint64     `xml:"timescale,attr"`
+			Duration    uint64     `xml:"duration,attr"`
+			SegmentURLs []struct{} `xml:"SegmentURL"`
+		} `xml:"SegmentList"`
+	} `xml:"Representation"`
+}
+
+type segmentTemplate struct {
+	Timescale uint64 `xml:"timescale,attr"`
+	Timeline  *struct {
+		S []struct {
+			D uint64 `xml:"d,attr"`
+			R int    `xml:"r,attr"`
+		} `xml:"S"`
+	} `xml:"SegmentTimeline"`
+}
+
+func (t *segmentTemplate) segments() []float64 {
+	if t.Timeline == nil {
+		return nil
+	}
+	timescale := float64(t.Timescale)
+	if timescale == 0 {
+		timescale = 1
+	}
+	var segments []float64
+	for _, s := range t.Timeline.S {
+		for i := 0; i <= s.R; i++ {
+			segments = append(segments, float64(s.D)/timescale)
+		}
+	}
+	return segments
+}
+
+// verifyDASH checks the segments described in the manifest. Only manifests
+// with explicit segment information (SegmentTimeline or SegmentList) can be
+// verified, as the number of segments in a SegmentTemplate with a fixed
+// duration is derived from the duration of the period.
+func verifyDASH(manifestURL string, fetch Fetcher) (*Report, error) {
+	data, err := fetch(manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	var manifest mpd
+	if err = xml.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	var report Report
+	for _, period := range manifest.Periods {
+		for _, set := range period.AdaptationSets {
+			for _, representation := range set.Representations {
+				rendition := Rendition{URI: representation.ID}
+				switch {
+				case representation.SegmentTemplate != nil:
+					rendition.Segments = representation.SegmentTemplate.segments()
+				case representation.SegmentList != nil:
+					list := representation.SegmentList
+					timescale := float64(list.Timescale)
+					if timescale == 0 {
+						timescale = 1
+					}
+					for range list.SegmentURLs {
+						rendition.Segments = append(rendition.Segments, float64(list.Duration)/timescale)
+					}
+				case set.SegmentTemplate != nil:
+					rendition.Segments = set.SegmentTemplate.segments()
+				}
+				report.Renditions = append(report.Renditions, rendition)
+			}
+		}
+	}
+	return &report, nil
+}
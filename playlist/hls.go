@@ -0,0 +1,109 @@
+package playlist
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strconv"
+	"strings"
+)
+
+func verifyHLS(masterURL string, expected []string, fetch Fetcher) (*Report, error) {
+	data, err := fetch(masterURL)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if bytes.Contains(data, []byte("#EXTINF")) {
+		// not a master playlist, so there's a single rendition
+		report.Renditions = []Rendition{parseMediaPlaylist(masterURL, data)}
+		return &report, nil
+	}
+	variants := parseMasterPlaylist(data)
+	referenced := make(map[string]bool, len(variants))
+	for _, variant := range variants {
+		referenced[path.Base(variant)] = true
+	}
+	for _, name := range expected {
+		if !referenced[path.Base(name)] {
+			report.addProblem("variant playlist %q is not referenced in the master playlist", name)
+		}
+	}
+	for _, variant := range variants {
+		variantData, err := fetch(resolve(masterURL, variant))
+		if err != nil {
+			report.addProblem("failed to fetch variant playlist %q: %s", variant, err)
+			continue
+		}
+		report.Renditions = append(report.Renditions, parseMediaPlaylist(variant, variantData))
+	}
+	return &report, nil
+}
+
+// parseMasterPlaylist returns the URIs of the variant playlists referenced
+// in the given master playlist, including alternative renditions.
+func parseMasterPlaylist(data []byte) []string {
+	var uris []string
+	var streamInf bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF"):
+			streamInf = true
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
+			if uri := attribute(line, "URI"); uri != "" {
+				uris = append(uris, uri)
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			if streamInf {
+				uris = append(uris, line)
+				streamInf = false
+			}
+		}
+	}
+	return uris
+}
+
+func parseMediaPlaylist(uri string, data []byte) Rendition {
+	rendition := Rendition{URI: uri, Truncated: true}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value := strings.TrimPrefix(line, "#EXTINF:")
+			if i := strings.Index(value, ","); i > -1 {
+				value = value[:i]
+			}
+			duration, _ := strconv.ParseFloat(value, 64)
+			rendition.Segments = append(rendition.Segments, duration)
+		case line == "#EXT-X-ENDLIST":
+			rendition.Truncated = false
+		}
+	}
+	return rendition
+}
+
+// attribute returns the value of the given attribute in a tag line, without
+// quotes.
+func attribute(line, name string) string {
+	idx := strings.Index(line, name+"=")
+	if idx < 0 {
+		return ""
+	}
+	value := line[idx+len(name)+1:]
+	if strings.HasPrefix(value, `"`) {
+		value = value[1:]
+		if end := strings.Index(value, `"`); end > -1 {
+			return value[:end]
+		}
+		return value
+	}
+	if end := strings.Index(value, ","); end > -1 {
+		return value[:end]
+	}
+	return value
+}
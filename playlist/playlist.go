@@ -0,0 +1,132 @@
+// Package playlist provides functions for verifying the consistency of
+// adaptive streaming outputs (HLS and DASH) after packaging.
+package playlist
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// DefaultTolerance is the default maximum difference, in seconds, between the
+// durations of segments at the same position in different renditions.
+const DefaultTolerance = 0.5
+
+// Fetcher retrieves the content of a manifest or playlist in the given URL.
+type Fetcher func(url string) ([]byte, error)
+
+// Rendition represents a rendition (variant) of an adaptive streaming
+// output, along with the duration of its segments.
+type Rendition struct {
+	URI       string
+	Segments  []float64
+	Truncated bool
+}
+
+// Duration returns the total duration of the rendition, in seconds.
+func (r *Rendition) Duration() float64 {
+	var total float64
+	for _, segment := range r.Segments {
+		total += segment
+	}
+	return total
+}
+
+// Report is the result of the verification of an output.
+type Report struct {
+	Renditions []Rendition
+	Problems   []string
+}
+
+// OK indicates whether the verification didn't find any problem.
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *Report) addProblem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// Verify fetches the master playlist (HLS) or the manifest (DASH) in the
+// given URL and checks that every rendition has the same number of segments
+// with matching durations. For HLS, expected contains the file names of the
+// variant playlists that must be referenced by the master playlist.
+//
+// The returned error indicates that the verification could not be
+// completed, while problems found in the output are listed in the report.
+func Verify(masterURL string, expected []string, tolerance float64, fetch Fetcher) (*Report, error) {
+	var report *Report
+	var err error
+	if strings.HasSuffix(strings.ToLower(masterURL), ".mpd") {
+		report, err = verifyDASH(masterURL, fetch)
+	} else {
+		report, err = verifyHLS(masterURL, expected, fetch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.compareRenditions(tolerance)
+	return report, nil
+}
+
+func (r *Report) compareRenditions(tolerance float64) {
+	if len(r.Renditions) == 0 {
+		r.addProblem("no renditions found")
+		return
+	}
+	reference := r.Renditions[0]
+	for _, rendition := range r.Renditions {
+		if rendition.Truncated {
+			r.addProblem("rendition %q is truncated", rendition.URI)
+		}
+		if len(rendition.Segments) == 0 {
+			r.addProblem("rendition %q has no segments", rendition.URI)
+			continue
+		}
+		if len(rendition.Segments) != len(reference.Segments) {
+			r.addProblem("rendition %q has %d segments, but %q has %d", rendition.URI, len(rendition.Segments), reference.URI, len(reference.Segments))
+			continue
+		}
+		for i, segment := range rendition.Segments {
+			if math.Abs(segment-reference.Segments[i]) > tolerance {
+				r.addProblem("segment %d of rendition %q has duration %.3f, but it has duration %.3f in %q", i, rendition.URI, segment, reference.Segments[i], reference.URI)
+				break
+			}
+		}
+	}
+}
+
+// HTTPFetcher returns a Fetcher that retrieves manifests using the given
+// HTTP client.
+func HTTPFetcher(client *http.Client) Fetcher {
+	return func(rawURL string) ([]byte, error) {
+		resp, err := client.Get(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error fetching %q: %s", rawURL, resp.Status)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}
+}
+
+// resolve resolves the reference relative to the base URL. It handles
+// non-HTTP schemes, like s3://.
+func resolve(base, ref string) string {
+	if strings.Contains(ref, "://") {
+		return ref
+	}
+	if strings.HasPrefix(ref, "/") {
+		if u, err := url.Parse(base); err == nil {
+			return u.Scheme + "://" + u.Host + path.Clean(ref)
+		}
+	}
+	dir := base[:strings.LastIndex(base, "/")+1]
+	return dir + ref
+}
@@ -0,0 +1,173 @@
+package playlist
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const masterPlaylist = `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="English",URI="audio.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1000000,RESOLUTION=640x360,AUDIO="audio"
+video_360p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=3000000,RESOLUTION=1280x720,AUDIO="audio"
+video_720p.m3u8
+`
+
+const mediaPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+segment0.ts
+#EXTINF:6.000,
+segment1.ts
+#EXTINF:3.500,
+segment2.ts
+#EXT-X-ENDLIST
+`
+
+const truncatedPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+segment0.ts
+#EXTINF:6.000,
+segment1.ts
+`
+
+const shiftedPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+segment0.ts
+#EXTINF:4.000,
+segment1.ts
+#EXTINF:5.500,
+segment2.ts
+#EXT-X-ENDLIST
+`
+
+const manifest = `<?xml version="1.0"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011">
+  <Period>
+    <AdaptationSet mimeType="video/mp4">
+      <SegmentTemplate timescale="1000" media="$RepresentationID$/$Time$.m4s">
+        <SegmentTimeline>
+          <S d="6000" r="2"/>
+          <S d="2000"/>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation id="360p" bandwidth="1000000"/>
+      <Representation id="720p" bandwidth="3000000"/>
+    </AdaptationSet>
+    <AdaptationSet mimeType="audio/mp4">
+      <Representation id="audio" bandwidth="128000">
+        <SegmentTemplate timescale="48000" media="audio/$Time$.m4s">
+          <SegmentTimeline>
+            <S d="288000" r="2"/>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+func fakeFetcher(files map[string]string) Fetcher {
+	return func(url string) ([]byte, error) {
+		if data, ok := files[url]; ok {
+			return []byte(data), nil
+		}
+		return nil, errors.New("not found")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var tests = []struct {
+		testCase     string
+		masterURL    string
+		expected     []string
+		files        map[string]string
+		wantProblems []string
+	}{
+		{
+			"consistent HLS output",
+			"s3://bucket/job-123/hls/index.m3u8",
+			[]string{"hls/video_360p.m3u8", "hls/video_720p.m3u8"},
+			map[string]string{
+				"s3://bucket/job-123/hls/index.m3u8":      masterPlaylist,
+				"s3://bucket/job-123/hls/audio.m3u8":      mediaPlaylist,
+				"s3://bucket/job-123/hls/video_360p.m3u8": mediaPlaylist,
+				"s3://bucket/job-123/hls/video_720p.m3u8": mediaPlaylist,
+			},
+			nil,
+		},
+		{
+			"truncated rendition",
+			"http://cdn.example.com/hls/index.m3u8",
+			nil,
+			map[string]string{
+				"http://cdn.example.com/hls/index.m3u8":      masterPlaylist,
+				"http://cdn.example.com/hls/audio.m3u8":      mediaPlaylist,
+				"http://cdn.example.com/hls/video_360p.m3u8": truncatedPlaylist,
+				"http://cdn.example.com/hls/video_720p.m3u8": mediaPlaylist,
+			},
+			[]string{
+				`rendition "video_360p.m3u8" is truncated`,
+				`rendition "video_360p.m3u8" has 2 segments, but "audio.m3u8" has 3`,
+			},
+		},
+		{
+			"misaligned segments and missing variant",
+			"http://cdn.example.com/hls/index.m3u8",
+			[]string{"hls/video_1080p.m3u8"},
+			map[string]string{
+				"http://cdn.example.com/hls/index.m3u8":      masterPlaylist,
+				"http://cdn.example.com/hls/audio.m3u8":      mediaPlaylist,
+				"http://cdn.example.com/hls/video_360p.m3u8": mediaPlaylist,
+			},
+			[]string{
+				`variant playlist "hls/video_1080p.m3u8" is not referenced in the master playlist`,
+				`failed to fetch variant playlist "video_720p.m3u8": not found`,
+			},
+		},
+		{
+			"segments with different durations",
+			"http://cdn.example.com/hls/index.m3u8",
+			nil,
+			map[string]string{
+				"http://cdn.example.com/hls/index.m3u8":      masterPlaylist,
+				"http://cdn.example.com/hls/audio.m3u8":      mediaPlaylist,
+				"http://cdn.example.com/hls/video_360p.m3u8": mediaPlaylist,
+				"http://cdn.example.com/hls/video_720p.m3u8": shiftedPlaylist,
+			},
+			[]string{
+				`segment 1 of rendition "video_720p.m3u8" has duration 4.000, but it has duration 6.000 in "audio.m3u8"`,
+			},
+		},
+		{
+			"DASH manifest with misaligned audio",
+			"http://cdn.example.com/dash/index.mpd",
+			nil,
+			map[string]string{"http://cdn.example.com/dash/index.mpd": manifest},
+			[]string{`rendition "audio" has 3 segments, but "360p" has 4`},
+		},
+	}
+	for _, test := range tests {
+		report, err := Verify(test.masterURL, test.expected, DefaultTolerance, fakeFetcher(test.files))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if !reflect.DeepEqual(report.Problems, test.wantProblems) {
+			t.Errorf("%s: wrong problems\nWant %#v\nGot  %#v", test.testCase, test.wantProblems, report.Problems)
+		}
+		if report.OK() != (len(test.wantProblems) == 0) {
+			t.Errorf("%s: wrong OK. Got %v", test.testCase, report.OK())
+		}
+	}
+}
+
+func TestVerifyMasterNotFound(t *testing.T) {
+	_, err := Verify("http://cdn.example.com/hls/index.m3u8", nil, DefaultTolerance, fakeFetcher(nil))
+	if err == nil {
+		t.Error("unexpected <nil> error")
+	}
+}
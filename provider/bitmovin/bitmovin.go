@@ -0,0 +1,897 @@
+// Package bitmovin provides a implementation of the provider that uses the
+// Bitmovin API for transcoding media files.
+//
+// It doesn't expose any public type. In order to use the provider, one must
+// import this package and then grab the factory from the provider package:
+//
+//     import (
+//         "github.com/NYTimes/video-transcoding-api/provider"
+//         "github.com/NYTimes/video-transcoding-api/provider/bitmovin"
+//     )
+//
+//     func UseProvider() {
+//         factory, err := provider.GetProviderFactory(bitmovin.Name)
+//         // handle err and use factory to get an instance of the provider.
+//     }
+package bitmovin
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	// Name is the name used for registering the Bitmovin provider in the
+	// registry of providers.
+	Name = "bitmovin"
+
+	defaultSegmentLength = 4
+	defaultHLSManifest   = "hls/index.m3u8"
+	defaultDASHManifest  = "dash/index.mpd"
+	audioSampleRate      = 48000
+)
+
+var errBitmovinInvalidConfig = provider.InvalidConfigError("missing Bitmovin API key, AWS credentials or destination. Please define the environment variables BITMOVIN_API_KEY, BITMOVIN_AWS_ACCESS_KEY_ID, BITMOVIN_AWS_SECRET_ACCESS_KEY and BITMOVIN_DESTINATION or set these values in the configuration file")
+
+func init() {
+	provider.Register(Name, bitmovinFactory)
+}
+
+type bitmovinProvider struct {
+	client *client
+	config *config.Bitmovin
+}
+
+// bitmovinPreset is the representation of a preset in Bitmovin: the codec
+// configurations of the video and the audio streams, and the container of
+// the output. The id of the preset is the id of the video configuration (or
+// the audio configuration, for audio-only presets), which keeps the
+// container and the id of the audio configuration in its custom data.
+type bitmovinPreset struct {
+	ID         string       `json:"id"`
+	Container  string       `json:"container"`
+	VideoCodec string       `json:"videoCodec,omitempty"`
+	Video      *codecConfig `json:"video,omitempty"`
+	AudioCodec string       `json:"audioCodec,omitempty"`
+	Audio      *codecConfig `json:"audio,omitempty"`
+}
+
+// gop returns the GOP settings of the preset.
+func (p *bitmovinPreset) gop(name string) provider.RenditionGOP {
+	gop := provider.RenditionGOP{
+		Name:  name,
+		Fixed: p.Video.SceneCutThreshold != nil && *p.Video.SceneCutThreshold == 0 && p.Video.MinGop == p.Video.MaxGop,
+	}
+	if p.Video.MaxGop > 0 {
+		gop.Size = strconv.FormatInt(p.Video.MaxGop, 10)
+		if p.Video.Rate > 0 {
+			gop.Duration = float64(p.Video.MaxGop) / p.Video.Rate
+		}
+	}
+	return gop
+}
+
+// encodingJob holds the resources created while setting up an encoding.
+type encodingJob struct {
+	encodingID    string
+	inputID       string
+	inputPath     string
+	clip          *provider.Clip
+	trimmedInputs map[int]string
+	outputID      string
+	outputPath    string
+	manifestType  string
+	manifestID    string
+	manifestDir   string
+	audioTracks   []db.AudioTrack
+	audioStreams  map[string]string
+	dashSets      map[string]string
+}
+
+func (p *bitmovinProvider) Transcode(job *db.Job, transcodeProfile provider.TranscodeProfile) (*provider.JobStatus, error) {
+	alignment := transcodeProfile.StreamingParams.KeyframeAlignment
+	if err := alignment.RequireSceneCutDisabled(Name); err != nil {
+		return nil, err
+	}
+	presets := make([]*bitmovinPreset, len(transcodeProfile.Outputs))
+	gops := make([]provider.RenditionGOP, 0, len(transcodeProfile.Outputs))
+	hls := false
+	for i, output := range transcodeProfile.Outputs {
+		presetID, ok := output.Preset.ProviderMapping[Name]
+		if !ok {
+			return nil, provider.ErrPresetMapNotFound
+		}
+		preset, err := p.getPreset(presetID)
+		if err != nil {
+			return nil, err
+		}
+		if preset.Video != nil {
+			gops = append(gops, preset.gop(output.Preset.Name))
+		}
+		hls = hls || preset.Container == "m3u8"
+		presets[i] = preset
+	}
+	if err := alignment.Validate(gops, transcodeProfile.StreamingParams.SegmentDuration); err != nil {
+		return nil, err
+	}
+	destination, err := p.destination(job)
+	if err != nil {
+		return nil, err
+	}
+	ej := encodingJob{
+		outputPath:    strings.Trim(path.Join(destination.Path, job.ID), "/"),
+		clip:          transcodeProfile.Clip,
+		trimmedInputs: make(map[int]string),
+		audioTracks:   transcodeProfile.AudioTracks,
+		audioStreams:  make(map[string]string),
+		dashSets:      make(map[string]string),
+	}
+	if ej.inputID, ej.inputPath, err = p.createInput(transcodeProfile.SourceMedia); err != nil {
+		return nil, err
+	}
+	if ej.outputID, err = p.createOutput(destination.Host); err != nil {
+		return nil, err
+	}
+	streaming := transcodeProfile.StreamingParams
+	segmentLength := float64(streaming.SegmentDuration)
+	if segmentLength == 0 {
+		segmentLength = defaultSegmentLength
+	}
+	manifestFile := streaming.PlaylistFileName
+	if streaming.Protocol == "dash" {
+		ej.manifestType = "dash"
+		if manifestFile == "" {
+			manifestFile = defaultDASHManifest
+		}
+	} else if hls {
+		ej.manifestType = "hls"
+		if manifestFile == "" {
+			manifestFile = defaultHLSManifest
+		}
+	}
+	if len(ej.audioTracks) > 1 && ej.manifestType != "dash" {
+		return nil, errors.New("bitmovin only supports multiple audio tracks in dash jobs")
+	}
+	customData := map[string]string{
+		"jobId":       job.ID,
+		"destination": strings.TrimRight(destination.String(), "/") + "/" + job.ID,
+	}
+	if ej.manifestType != "" {
+		ej.manifestDir = path.Dir(manifestFile)
+		if ej.manifestID, err = p.createManifest(job.ID, &ej, path.Base(manifestFile)); err != nil {
+			return nil, err
+		}
+		customData["manifestType"] = ej.manifestType
+		customData["manifestId"] = ej.manifestID
+		customData["manifest"] = manifestFile
+	}
+	var files []string
+	for i, output := range transcodeProfile.Outputs {
+		if presets[i].Container == "mp4" && ej.manifestType != "dash" {
+			files = append(files, output.FileName)
+		}
+	}
+	if len(files) > 0 {
+		customData["files"] = strings.Join(files, ",")
+	}
+	var enc encoding
+	err = p.client.post("encoding/encodings", encoding{
+		resource:    resource{Name: job.ID, CustomData: customData},
+		CloudRegion: p.config.EncodingRegion,
+	}, &enc)
+	if err != nil {
+		return nil, err
+	}
+	ej.encodingID = enc.ID
+	for i, output := range transcodeProfile.Outputs {
+		if err = p.addOutput(&ej, presets[i], output.FileName, segmentLength); err != nil {
+			return nil, err
+		}
+	}
+	if err = p.client.post("encoding/encodings/"+enc.ID+"/start", nil, nil); err != nil {
+		return nil, err
+	}
+	return &provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: enc.ID,
+		Status:        provider.StatusQueued,
+	}, nil
+}
+
+// destination returns the S3 URL where the outputs of the given job are
+// written.
+func (p *bitmovinProvider) destination(job *db.Job) (*url.URL, error) {
+	destination := p.config.Destination
+	if job.Destination != "" {
+		destination = job.Destination
+	}
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid destination %q: Bitmovin outputs must be written to S3", destination)
+	}
+	return u, nil
+}
+
+// createInput creates the input resource for the given source, returning
+// its id and the path of the source in the input.
+func (p *bitmovinProvider) createInput(source string) (string, string, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid source %q: %s", source, err)
+	}
+	var input storage
+	switch u.Scheme {
+	case "s3":
+		err = p.client.post("encoding/inputs/s3", storage{
+			BucketName: u.Host,
+			AccessKey:  p.config.AccessKeyID,
+			SecretKey:  p.config.SecretAccessKey,
+		}, &input)
+	case "http", "https":
+		err = p.client.post("encoding/inputs/"+u.Scheme, storage{Host: u.Host}, &input)
+	default:
+		return "", "", fmt.Errorf("unsupported source %q", source)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	inputPath := strings.TrimPrefix(u.Path, "/")
+	if u.RawQuery != "" {
+		inputPath += "?" + u.RawQuery
+	}
+	return input.ID, inputPath, nil
+}
+
+func (p *bitmovinProvider) createOutput(bucket string) (string, error) {
+	var output storage
+	err := p.client.post("encoding/outputs/s3", storage{
+		BucketName: bucket,
+		AccessKey:  p.config.AccessKeyID,
+		SecretKey:  p.config.SecretAccessKey,
+	}, &output)
+	return output.ID, err
+}
+
+// createManifest creates the HLS or DASH manifest of the job. The manifest
+// is generated once the encoding finishes.
+func (p *bitmovinProvider) createManifest(jobID string, ej *encodingJob, name string) (string, error) {
+	manifestPath := "encoding/manifests/" + ej.manifestType
+	var m manifest
+	err := p.client.post(manifestPath, manifest{
+		resource:     resource{Name: jobID},
+		ManifestName: name,
+		Outputs:      []encodingOutput{ej.output(ej.manifestDir)},
+	}, &m)
+	if err != nil || ej.manifestType != "dash" {
+		return m.ID, err
+	}
+	var period resource
+	if err = p.client.post(manifestPath+"/"+m.ID+"/periods", resource{}, &period); err != nil {
+		return "", err
+	}
+	setsPath := manifestPath + "/" + m.ID + "/periods/" + period.ID + "/adaptationsets/"
+	kinds := []string{"video", "audio"}
+	if len(ej.audioTracks) > 1 {
+		// each audio track has its own adaptation set.
+		kinds = kinds[:1]
+	}
+	for _, kind := range kinds {
+		var set resource
+		if err = p.client.post(setsPath+kind, resource{}, &set); err != nil {
+			return "", err
+		}
+		ej.dashSets[kind] = setsPath + set.ID
+	}
+	if len(ej.audioTracks) > 1 {
+		defaultTrack := db.DefaultAudioTrack(ej.audioTracks)
+		for _, track := range ej.audioTracks {
+			role := "ALTERNATE"
+			if track.Track == defaultTrack.Track {
+				role = "MAIN"
+			}
+			var set resource
+			err = p.client.post(setsPath+"audio", audioAdaptationSet{
+				Lang:   track.Language,
+				Roles:  []string{role},
+				Labels: []dashLabel{{Lang: track.Language, Value: track.Name}},
+			}, &set)
+			if err != nil {
+				return "", err
+			}
+			ej.dashSets[audioTrackKind(track.Track)] = setsPath + set.ID
+		}
+	}
+	return m.ID, nil
+}
+
+// audioTrackKind returns the kind of the adaptation set of the given audio
+// track of the source in DASH manifests with several audio tracks.
+func audioTrackKind(track int) string {
+	return "audio_" + strconv.Itoa(track)
+}
+
+// output returns the output of the encoding in the given directory,
+// relative to the destination of the job.
+func (ej *encodingJob) output(dir string) encodingOutput {
+	return encodingOutput{
+		OutputID:   ej.outputID,
+		OutputPath: path.Join(ej.outputPath, dir),
+		ACL:        []acl{{Permission: "PUBLIC_READ"}},
+	}
+}
+
+// addOutput adds the streams and the muxings of the given output to the
+// encoding.
+func (p *bitmovinProvider) addOutput(ej *encodingJob, preset *bitmovinPreset, fileName string, segmentLength float64) error {
+	var videoStream, audioStream string
+	var err error
+	if preset.Video != nil {
+		if videoStream, err = p.createStream(ej, preset.Video.ID, 0); err != nil {
+			return err
+		}
+	}
+	newAudioStream := false
+	if preset.Audio != nil && len(ej.audioTracks) < 2 {
+		track := 0
+		if defaultTrack := db.DefaultAudioTrack(ej.audioTracks); defaultTrack != nil {
+			track = defaultTrack.Track
+		}
+		if audioStream, newAudioStream, err = p.audioStream(ej, preset.Audio.ID, track); err != nil {
+			return err
+		}
+	}
+	streams := muxingStreams(videoStream, audioStream)
+	baseName := strings.TrimSuffix(path.Base(fileName), path.Ext(fileName))
+	muxingsPath := "encoding/encodings/" + ej.encodingID + "/muxings/"
+	switch {
+	case preset.Container == "m3u8":
+		if ej.manifestType != "hls" {
+			return fmt.Errorf("preset %q can't be used in %s jobs", preset.ID, ej.manifestType)
+		}
+		var ts muxing
+		err = p.client.post(muxingsPath+"ts", muxing{
+			SegmentLength: segmentLength,
+			SegmentNaming: "seg_%number%.ts",
+			Streams:       streams,
+			Outputs:       []encodingOutput{ej.output(path.Join(ej.manifestDir, baseName))},
+		}, &ts)
+		if err != nil {
+			return err
+		}
+		return p.client.post("encoding/manifests/hls/"+ej.manifestID+"/streams", hlsStream{
+			URI:         baseName + ".m3u8",
+			SegmentPath: baseName + "/",
+			EncodingID:  ej.encodingID,
+			StreamID:    streams[0].StreamID,
+			MuxingID:    ts.ID,
+		}, nil)
+	case preset.Container == "mp4" && ej.manifestType == "dash":
+		if videoStream != "" {
+			if err = p.addRepresentation(ej, "video", videoStream, baseName, segmentLength); err != nil {
+				return err
+			}
+		}
+		if newAudioStream {
+			return p.addRepresentation(ej, "audio", audioStream, "audio_"+baseName, segmentLength)
+		}
+		if preset.Audio != nil && len(ej.audioTracks) > 1 {
+			return p.addAudioTracks(ej, preset.Audio.ID, baseName, segmentLength)
+		}
+		return nil
+	case preset.Container == "mp4":
+		return p.client.post(muxingsPath+"mp4", muxing{
+			Filename: path.Base(fileName),
+			Streams:  streams,
+			Outputs:  []encodingOutput{ej.output(path.Dir(fileName))},
+		}, nil)
+	default:
+		return fmt.Errorf("unsupported container %q", preset.Container)
+	}
+}
+
+// addRepresentation adds a fragmented MP4 muxing of the given stream to the
+// DASH manifest.
+func (p *bitmovinProvider) addRepresentation(ej *encodingJob, kind, streamID, dir string, segmentLength float64) error {
+	var fmp4 muxing
+	err := p.client.post("encoding/encodings/"+ej.encodingID+"/muxings/fmp4", muxing{
+		SegmentLength: segmentLength,
+		SegmentNaming: "seg_%number%.m4s",
+		Streams:       []muxingStream{{StreamID: streamID}},
+		Outputs:       []encodingOutput{ej.output(path.Join(ej.manifestDir, dir))},
+	}, &fmp4)
+	if err != nil {
+		return err
+	}
+	return p.client.post(ej.dashSets[kind]+"/representations/fmp4", dashRepresentation{
+		Type:        "TEMPLATE",
+		SegmentPath: dir,
+		EncodingID:  ej.encodingID,
+		MuxingID:    fmp4.ID,
+	}, nil)
+}
+
+// addAudioTracks adds a representation of each audio track of the source,
+// encoded with the given codec configuration, to the DASH manifest.
+func (p *bitmovinProvider) addAudioTracks(ej *encodingJob, codecConfigID, baseName string, segmentLength float64) error {
+	for _, track := range ej.audioTracks {
+		streamID, created, err := p.audioStream(ej, codecConfigID, track.Track)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+		kind := audioTrackKind(track.Track)
+		if err = p.addRepresentation(ej, kind, streamID, kind+"_"+baseName, segmentLength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// audioStream returns the stream of the given audio track of the source
+// encoded with the given codec configuration, and whether it was created by
+// this call. Outputs sharing the codec configuration share the stream.
+func (p *bitmovinProvider) audioStream(ej *encodingJob, codecConfigID string, track int) (string, bool, error) {
+	key := codecConfigID + ":" + strconv.Itoa(track)
+	if streamID := ej.audioStreams[key]; streamID != "" {
+		return streamID, false, nil
+	}
+	streamID, err := p.createStream(ej, codecConfigID, track)
+	if err != nil {
+		return "", false, err
+	}
+	ej.audioStreams[key] = streamID
+	return streamID, true, nil
+}
+
+// inputStream returns the input stream with the given audio track of the
+// source, or with the streams selected automatically when track is 0. In
+// partial transcodes, the input stream is trimmed to the clip.
+func (p *bitmovinProvider) inputStream(ej *encodingJob, track int) (inputStream, error) {
+	input := inputStream{InputID: ej.inputID, InputPath: ej.inputPath, SelectionMode: "AUTO"}
+	if track > 0 {
+		input.SelectionMode = "AUDIO_RELATIVE"
+		input.Position = track - 1
+	}
+	if ej.clip == nil {
+		return input, nil
+	}
+	trimmedID, ok := ej.trimmedInputs[track]
+	if !ok {
+		var err error
+		if trimmedID, err = p.createTrimmedInput(ej, input); err != nil {
+			return inputStream{}, err
+		}
+		ej.trimmedInputs[track] = trimmedID
+	}
+	return inputStream{InputStreamID: trimmedID}, nil
+}
+
+// createTrimmedInput creates the input stream with the range of the given
+// ingest input stream included in partial transcodes, returning its ID.
+func (p *bitmovinProvider) createTrimmedInput(ej *encodingJob, input inputStream) (string, error) {
+	inputStreams := "encoding/encodings/" + ej.encodingID + "/input-streams/"
+	var ingest inputStream
+	if err := p.client.post(inputStreams+"ingest", input, &ingest); err != nil {
+		return "", err
+	}
+	var trimmed trimmingInputStream
+	err := p.client.post(inputStreams+"trimming/time-based", trimmingInputStream{
+		InputStreamID: ingest.ID,
+		Offset:        ej.clip.Start,
+		Duration:      ej.clip.Duration,
+	}, &trimmed)
+	return trimmed.ID, err
+}
+
+func (p *bitmovinProvider) createStream(ej *encodingJob, codecConfigID string, track int) (string, error) {
+	input, err := p.inputStream(ej, track)
+	if err != nil {
+		return "", err
+	}
+	var s stream
+	err = p.client.post("encoding/encodings/"+ej.encodingID+"/streams", stream{
+		CodecConfigID: codecConfigID,
+		InputStreams:  []inputStream{input},
+	}, &s)
+	return s.ID, err
+}
+
+func muxingStreams(ids ...string) []muxingStream {
+	streams := make([]muxingStream, 0, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			streams = append(streams, muxingStream{StreamID: id})
+		}
+	}
+	return streams
+}
+
+func (p *bitmovinProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
+	encodingPath := "encoding/encodings/" + job.ProviderJobID
+	var status taskStatus
+	if err := p.client.get(encodingPath+"/status", &status); err != nil {
+		return nil, err
+	}
+	var data customData
+	if err := p.client.get(encodingPath+"/customData", &data); err != nil {
+		return nil, err
+	}
+	jobStatus := provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: job.ProviderJobID,
+		Status:        p.statusMap(status.Status),
+		StatusMessage: errorMessage(status),
+		Progress:      status.Progress,
+		ProviderStatus: map[string]interface{}{
+			"status": status.Status,
+		},
+		Output: provider.JobOutput{Destination: data.CustomData["destination"]},
+	}
+	manifestType := data.CustomData["manifestType"]
+	if jobStatus.Status == provider.StatusFinished && manifestType != "" {
+		manifestStatus, err := p.generateManifest(manifestType, data.CustomData["manifestId"])
+		if err != nil {
+			return nil, err
+		}
+		jobStatus.ProviderStatus["manifestStatus"] = manifestStatus.Status
+		if manifestStatus.Status != "FINISHED" {
+			jobStatus.Status = p.statusMap(manifestStatus.Status)
+			if jobStatus.Status != provider.StatusFailed {
+				jobStatus.Status = provider.StatusStarted
+			}
+			jobStatus.StatusMessage = errorMessage(*manifestStatus)
+			jobStatus.Progress = 99
+		}
+	}
+	if jobStatus.Status == provider.StatusFinished {
+		jobStatus.Progress = 100
+		jobStatus.Output.Files = outputFiles(data.CustomData)
+	}
+	return &jobStatus, nil
+}
+
+// generateManifest returns the status of the given manifest, starting its
+// generation if it wasn't started yet.
+func (p *bitmovinProvider) generateManifest(manifestType, manifestID string) (*taskStatus, error) {
+	manifestPath := "encoding/manifests/" + manifestType + "/" + manifestID
+	var status taskStatus
+	if err := p.client.get(manifestPath+"/status", &status); err != nil {
+		return nil, err
+	}
+	if status.Status == "" || status.Status == "CREATED" {
+		if err := p.client.post(manifestPath+"/start", nil, nil); err != nil {
+			return nil, err
+		}
+		status.Status = "QUEUED"
+	}
+	return &status, nil
+}
+
+// outputFiles lists the files generated by the encoding, as recorded in its
+// custom data.
+func outputFiles(data map[string]string) []provider.OutputFile {
+	var names []string
+	if manifest := data["manifest"]; manifest != "" {
+		names = append(names, manifest)
+	}
+	if files := data["files"]; files != "" {
+		names = append(names, strings.Split(files, ",")...)
+	}
+	files := make([]provider.OutputFile, len(names))
+	for i, name := range names {
+		files[i] = provider.OutputFile{
+			Path:      data["destination"] + "/" + name,
+			Container: strings.TrimPrefix(path.Ext(name), "."),
+		}
+	}
+	return files
+}
+
+func errorMessage(status taskStatus) string {
+	for _, message := range status.Messages {
+		if message.Type == "ERROR" {
+			return message.Text
+		}
+	}
+	return ""
+}
+
+func (p *bitmovinProvider) statusMap(bitmovinStatus string) provider.Status {
+	switch bitmovinStatus {
+	case "CREATED", "QUEUED":
+		return provider.StatusQueued
+	case "RUNNING":
+		return provider.StatusStarted
+	case "FINISHED":
+		return provider.StatusFinished
+	case "CANCELED":
+		return provider.StatusCanceled
+	case "ERROR":
+		return provider.StatusFailed
+	default:
+		return provider.StatusUnknown
+	}
+}
+
+func (p *bitmovinProvider) CancelJob(id string) error {
+	return p.client.post("encoding/encodings/"+id+"/stop", nil, nil)
+}
+
+func (p *bitmovinProvider) CreatePreset(preset db.Preset) (string, error) {
+	container := strings.ToLower(preset.Container)
+	if container != "mp4" && container != "m3u8" {
+		return "", fmt.Errorf("unsupported container %q", preset.Container)
+	}
+	var video *codecConfig
+	var videoCodec string
+	var err error
+	if preset.Video != (db.VideoPreset{}) {
+		if video, videoCodec, err = videoConfig(preset); err != nil {
+			return "", err
+		}
+	}
+	var audio *codecConfig
+	var audioCodec string
+	if preset.Audio != (db.AudioPreset{}) {
+		if audio, audioCodec, err = audioConfig(preset); err != nil {
+			return "", err
+		}
+	}
+	if video == nil && audio == nil {
+		return "", errors.New("the preset must define video or audio settings")
+	}
+	customData := map[string]string{"container": container}
+	if audio != nil {
+		if video == nil {
+			audio.CustomData = customData
+		}
+		if err = p.client.post(configPath(audioCodec), audio, audio); err != nil {
+			return "", err
+		}
+		if video == nil {
+			return audio.ID, nil
+		}
+		customData["audio"] = audio.ID
+	}
+	video.CustomData = customData
+	if err = p.client.post(configPath(videoCodec), video, video); err != nil {
+		return "", err
+	}
+	return video.ID, nil
+}
+
+func videoConfig(preset db.Preset) (*codecConfig, string, error) {
+	bitrate, err := atoi64(preset.Video.Bitrate)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid video bitrate %q", preset.Video.Bitrate)
+	}
+	cfg := codecConfig{
+		resource: resource{Name: preset.Name, Description: preset.Description},
+		Bitrate:  bitrate,
+	}
+	if cfg.Width, err = atoi64(preset.Video.Width); err != nil {
+		return nil, "", fmt.Errorf("invalid video width %q", preset.Video.Width)
+	}
+	if cfg.Height, err = atoi64(preset.Video.Height); err != nil {
+		return nil, "", fmt.Errorf("invalid video height %q", preset.Video.Height)
+	}
+	switch preset.Video.Codec {
+	case "h264":
+		cfg.Profile = "MAIN"
+		if preset.Profile != "" {
+			cfg.Profile = strings.ToUpper(preset.Profile)
+		}
+		cfg.Level = preset.ProfileLevel
+		if cfg.MaxGop, err = atoi64(preset.Video.GopSize); err != nil {
+			return nil, "", fmt.Errorf("invalid GOP size %q", preset.Video.GopSize)
+		}
+		if preset.Video.GopMode == "fixed" {
+			// a fixed GOP disables keyframes on scene changes, so all
+			// renditions get the same keyframes.
+			var sceneCutThreshold int64
+			cfg.MinGop = cfg.MaxGop
+			cfg.SceneCutThreshold = &sceneCutThreshold
+		}
+	case "hevc":
+		cfg.Profile = "main"
+		if err = hdrConfig(&cfg, preset.Video); err != nil {
+			return nil, "", err
+		}
+	case "vp8", "vp9", "av1":
+	default:
+		return nil, "", fmt.Errorf("unsupported video codec %q", preset.Video.Codec)
+	}
+	if preset.Video.ToneMap {
+		return nil, "", errors.New("tone mapping isn't supported")
+	}
+	if mode, ok := encodingModes[preset.Video.Passes]; ok {
+		cfg.EncodingMode = mode
+	}
+	codec := preset.Video.Codec
+	if codec == "hevc" {
+		codec = "h265"
+	}
+	return &cfg, codec, nil
+}
+
+// colorTransfers are the color transfers of H.265 configurations, by
+// transfer function of the preset.
+var colorTransfers = map[string]string{"bt709": "BT709", "pq": "SMPTE2084", "hlg": "ARIB_STD_B67"}
+
+// hdrConfig sets the color settings of the given H.265 configuration,
+// encoding HDR outputs with 10 bits and the HDR10 metadata of the preset.
+func hdrConfig(cfg *codecConfig, video db.VideoPreset) error {
+	if video.ColorSpace == "" && video.TransferFunction == "" {
+		return nil
+	}
+	cfg.ColorConfig = &colorConfig{ColorTransfer: colorTransfers[video.TransferFunction]}
+	if video.ColorSpace == "bt2020" || video.HDR() {
+		cfg.ColorConfig.ColorPrimaries = "BT2020"
+		cfg.ColorConfig.ColorSpace = "BT2020_NCL"
+	} else if video.ColorSpace == "bt709" {
+		cfg.ColorConfig.ColorPrimaries = "BT709"
+		cfg.ColorConfig.ColorSpace = "BT709"
+	}
+	if !video.HDR() {
+		return nil
+	}
+	cfg.Profile = "main10"
+	cfg.PixelFormat = "YUV420P10LE"
+	cfg.MasterDisplay = video.MasteringDisplay
+	if video.ContentLightLevel != "" {
+		maxCLL, maxFALL, err := db.ParseContentLightLevel(video.ContentLightLevel)
+		if err != nil {
+			return err
+		}
+		cfg.MaxContentLightLevel, cfg.MaxPictureAverageLightLevel = maxCLL, maxFALL
+	}
+	return nil
+}
+
+// encodingModes are the encoding modes of video configurations, by number
+// of passes.
+var encodingModes = map[string]string{"1": "SINGLE_PASS", "2": "TWO_PASS"}
+
+func audioConfig(preset db.Preset) (*codecConfig, string, error) {
+	bitrate, err := atoi64(preset.Audio.Bitrate)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid audio bitrate %q", preset.Audio.Bitrate)
+	}
+	codec := strings.TrimPrefix(preset.Audio.Codec, "lib")
+	switch codec {
+	case "aac", "ac3", "eac3", "mp3", "vorbis", "opus":
+	default:
+		return nil, "", fmt.Errorf("unsupported audio codec %q", preset.Audio.Codec)
+	}
+	return &codecConfig{
+		resource:      resource{Name: preset.Name, Description: preset.Description},
+		Bitrate:       bitrate,
+		Rate:          audioSampleRate,
+		ChannelLayout: channelLayouts[preset.Audio.ChannelLayout],
+	}, codec, nil
+}
+
+// channelLayouts maps the channel layouts of audio presets to the ones of
+// Bitmovin audio configurations.
+var channelLayouts = map[string]string{
+	db.AudioLayoutStereo: "2.0",
+	db.AudioLayout51:     "5.1",
+}
+
+// configPath returns the path of the codec configurations of the given
+// codec.
+func configPath(codec string) string {
+	codec = strings.ToLower(codec)
+	switch codec {
+	case "h264", "h265", "vp8", "vp9", "av1":
+		return "encoding/configurations/video/" + codec
+	}
+	return "encoding/configurations/audio/" + codec
+}
+
+func atoi64(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (p *bitmovinProvider) GetPreset(presetID string) (interface{}, error) {
+	return p.getPreset(presetID)
+}
+
+func (p *bitmovinProvider) getPreset(presetID string) (*bitmovinPreset, error) {
+	cfg, codec, data, err := p.getCodecConfig(presetID)
+	if err != nil {
+		return nil, err
+	}
+	preset := bitmovinPreset{ID: presetID, Container: data["container"]}
+	if !strings.Contains(configPath(codec), "/video/") {
+		preset.Audio, preset.AudioCodec = cfg, codec
+		return &preset, nil
+	}
+	preset.Video, preset.VideoCodec = cfg, codec
+	if audioID := data["audio"]; audioID != "" {
+		if preset.Audio, preset.AudioCodec, _, err = p.getCodecConfig(audioID); err != nil {
+			return nil, err
+		}
+	}
+	return &preset, nil
+}
+
+// getCodecConfig returns the given codec configuration, along with its
+// codec and custom data.
+func (p *bitmovinProvider) getCodecConfig(id string) (*codecConfig, string, map[string]string, error) {
+	var cfgType codecConfigType
+	if err := p.client.get("encoding/configurations/"+id+"/type", &cfgType); err != nil {
+		return nil, "", nil, err
+	}
+	codec := strings.ToLower(cfgType.Type)
+	var cfg codecConfig
+	if err := p.client.get(configPath(codec)+"/"+id, &cfg); err != nil {
+		return nil, "", nil, err
+	}
+	var data customData
+	if err := p.client.get(configPath(codec)+"/"+id+"/customData", &data); err != nil {
+		return nil, "", nil, err
+	}
+	return &cfg, codec, data.CustomData, nil
+}
+
+func (p *bitmovinProvider) DeletePreset(presetID string) error {
+	preset, err := p.getPreset(presetID)
+	if err != nil {
+		return err
+	}
+	if preset.Video != nil {
+		if err = p.client.delete(configPath(preset.VideoCodec) + "/" + preset.Video.ID); err != nil {
+			return err
+		}
+	}
+	if preset.Audio != nil {
+		return p.client.delete(configPath(preset.AudioCodec) + "/" + preset.Audio.ID)
+	}
+	return nil
+}
+
+func (p *bitmovinProvider) Healthcheck() error {
+	var encodings resourceList
+	return p.client.get("encoding/encodings?limit=1", &encodings)
+}
+
+func (p *bitmovinProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		InputFormats:       []string{"prores", "h264", "h265", "mpeg2"},
+		OutputFormats:      []string{"mp4", "hls", "dash"},
+		Destinations:       []string{"s3"},
+		VideoCodecs:        []string{"h264", "hevc", "vp8", "vp9", "av1"},
+		AudioCodecs:        []string{"aac", "ac3", "eac3", "mp3", "opus", "vorbis"},
+		StreamingProtocols: []string{"hls", "dash"},
+		MaxAudioChannels:   6,
+		Clipping:           true,
+		HDR:                true,
+		AudioTracks:        true,
+		Progress:           true,
+	}
+}
+
+func bitmovinFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
+	bCfg := cfg.Bitmovin
+	if bCfg == nil || bCfg.APIKey == "" || bCfg.AccessKeyID == "" || bCfg.SecretAccessKey == "" || bCfg.Destination == "" {
+		return nil, errBitmovinInvalidConfig
+	}
+	return &bitmovinProvider{
+		client: newClient(bCfg.Endpoint, bCfg.APIKey),
+		config: bCfg,
+	}, nil
+}
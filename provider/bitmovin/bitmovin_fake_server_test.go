@@ -0,0 +1,174 @@
+package bitmovin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var configPathRegexp = regexp.MustCompile(`^encoding/configurations/(video|audio)/([^/]+)/([^/]+)$`)
+
+type fakeRequest struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// bitmovinFakeServer is a fake version of the Bitmovin API, that stores the
+// resources created in memory.
+type bitmovinFakeServer struct {
+	*httptest.Server
+	mtx       sync.Mutex
+	lastID    int
+	requests  []fakeRequest
+	resources map[string]map[string]interface{}
+	statuses  map[string]string
+}
+
+func newBitmovinFakeServer() *bitmovinFakeServer {
+	server := bitmovinFakeServer{
+		resources: make(map[string]map[string]interface{}),
+		statuses:  make(map[string]string),
+	}
+	server.Server = httptest.NewServer(&server)
+	return &server
+}
+
+// requestPaths returns the paths of the requests with the given method.
+func (s *bitmovinFakeServer) requestPaths(method string) []string {
+	var paths []string
+	for _, r := range s.requests {
+		if r.Method == method {
+			paths = append(paths, r.Path)
+		}
+	}
+	return paths
+}
+
+// created returns the bodies of the resources created in the given
+// collection.
+func (s *bitmovinFakeServer) created(collection string) []map[string]interface{} {
+	var bodies []map[string]interface{}
+	for _, r := range s.requests {
+		if r.Method == "POST" && r.Path == collection {
+			bodies = append(bodies, r.Body)
+		}
+	}
+	return bodies
+}
+
+func (s *bitmovinFakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if r.Header.Get("X-Api-Key") != "api-key" {
+		s.writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+	resourcePath := strings.TrimPrefix(r.URL.Path, "/v1/")
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	s.requests = append(s.requests, fakeRequest{Method: r.Method, Path: resourcePath, Body: body})
+	switch r.Method {
+	case "POST":
+		s.post(w, resourcePath, body)
+	case "GET":
+		s.get(w, resourcePath)
+	case "DELETE":
+		if _, ok := s.resources[resourcePath]; !ok {
+			s.writeError(w, http.StatusNotFound, "resource not found")
+			return
+		}
+		delete(s.resources, resourcePath)
+		s.writeResult(w, map[string]string{"id": resourcePath})
+	}
+}
+
+func (s *bitmovinFakeServer) post(w http.ResponseWriter, resourcePath string, body map[string]interface{}) {
+	if strings.HasSuffix(resourcePath, "/start") || strings.HasSuffix(resourcePath, "/stop") {
+		target := resourcePath[:strings.LastIndex(resourcePath, "/")]
+		if _, ok := s.resources[target]; !ok {
+			s.writeError(w, http.StatusNotFound, "resource not found")
+			return
+		}
+		s.statuses[target] = "RUNNING"
+		if strings.HasSuffix(resourcePath, "/stop") {
+			s.statuses[target] = "CANCELED"
+		}
+		s.writeResult(w, map[string]string{"id": target})
+		return
+	}
+	s.lastID++
+	id := fmt.Sprintf("id-%d", s.lastID)
+	if body == nil {
+		body = make(map[string]interface{})
+	}
+	body["id"] = id
+	s.resources[resourcePath+"/"+id] = body
+	s.writeResult(w, body)
+}
+
+func (s *bitmovinFakeServer) get(w http.ResponseWriter, resourcePath string) {
+	switch {
+	case resourcePath == "encoding/encodings":
+		s.writeResult(w, map[string]interface{}{"totalCount": 0, "items": []string{}})
+	case strings.HasSuffix(resourcePath, "/type"):
+		id := strings.TrimSuffix(strings.TrimPrefix(resourcePath, "encoding/configurations/"), "/type")
+		for key := range s.resources {
+			if matches := configPathRegexp.FindStringSubmatch(key); matches != nil && matches[3] == id {
+				s.writeResult(w, map[string]string{"type": strings.ToUpper(matches[2])})
+				return
+			}
+		}
+		s.writeError(w, http.StatusNotFound, "resource not found")
+	case strings.HasSuffix(resourcePath, "/customData"):
+		resource, ok := s.resources[strings.TrimSuffix(resourcePath, "/customData")]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "resource not found")
+			return
+		}
+		s.writeResult(w, map[string]interface{}{"customData": resource["customData"]})
+	case strings.HasSuffix(resourcePath, "/status"):
+		target := strings.TrimSuffix(resourcePath, "/status")
+		if _, ok := s.resources[target]; !ok {
+			s.writeError(w, http.StatusNotFound, "resource not found")
+			return
+		}
+		status := s.statuses[target]
+		if status == "" {
+			status = "CREATED"
+		}
+		result := map[string]interface{}{"status": status, "progress": 42}
+		if status == "ERROR" {
+			result["messages"] = []map[string]string{{"type": "ERROR", "text": "input file not found"}}
+		}
+		s.writeResult(w, result)
+	default:
+		resource, ok := s.resources[resourcePath]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "resource not found")
+			return
+		}
+		s.writeResult(w, resource)
+	}
+}
+
+func (s *bitmovinFakeServer) writeResult(w http.ResponseWriter, result interface{}) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requestId": "request-id",
+		"status":    "SUCCESS",
+		"data":      map[string]interface{}{"result": result},
+	})
+}
+
+func (s *bitmovinFakeServer) writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requestId": "request-id",
+		"status":    "ERROR",
+		"data":      map[string]interface{}{"code": 1000, "message": message},
+	})
+}
@@ -0,0 +1,13 @@
+// +build integration
+
+package bitmovin
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/provider/providertest"
+)
+
+func TestBitmovinIntegration(t *testing.T) {
+	providertest.Run(t, Name)
+}
@@ -0,0 +1,768 @@
+package bitmovin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func newTestProvider(server *bitmovinFakeServer) *bitmovinProvider {
+	return &bitmovinProvider{
+		client: newClient(server.URL+"/v1/", "api-key"),
+		config: &config.Bitmovin{
+			APIKey:          "api-key",
+			Endpoint:        server.URL + "/v1/",
+			AccessKeyID:     "AKIANOTREALLY",
+			SecretAccessKey: "secret",
+			Destination:     "s3://some-bucket/outputs/",
+			EncodingRegion:  "AWS_US_EAST_1",
+		},
+	}
+}
+
+func createTestPreset(t *testing.T, prov *bitmovinProvider, name, container string) db.PresetMap {
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:      name,
+		Container: container,
+		Video:     db.VideoPreset{Codec: "h264", Width: "1280", Height: "720", Bitrate: "3500000", GopSize: "90", GopMode: "fixed"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.PresetMap{Name: name, ProviderMapping: map[string]string{Name: presetID}}
+}
+
+func TestFactoryIsRegistered(t *testing.T) {
+	_, err := provider.GetProviderFactory(Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBitmovinFactoryValidation(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		cfg      *config.Bitmovin
+		valid    bool
+	}{
+		{"missing config", nil, false},
+		{"empty config", &config.Bitmovin{}, false},
+		{
+			"missing api key",
+			&config.Bitmovin{AccessKeyID: "AKIA", SecretAccessKey: "secret", Destination: "s3://bucket/"},
+			false,
+		},
+		{
+			"missing destination",
+			&config.Bitmovin{APIKey: "key", AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			false,
+		},
+		{
+			"valid config",
+			&config.Bitmovin{APIKey: "key", AccessKeyID: "AKIA", SecretAccessKey: "secret", Destination: "s3://bucket/", Endpoint: "https://api.bitmovin.com/v1"},
+			true,
+		},
+	}
+	for _, test := range tests {
+		prov, err := bitmovinFactory(&config.Config{Bitmovin: test.cfg})
+		if test.valid {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.testCase, err)
+				continue
+			}
+			bitmovinProv := prov.(*bitmovinProvider)
+			if bitmovinProv.config != test.cfg {
+				t.Errorf("%s: did not store the proper config", test.testCase)
+			}
+			if bitmovinProv.client.endpoint != "https://api.bitmovin.com/v1/" {
+				t.Errorf("%s: wrong endpoint. Got %q", test.testCase, bitmovinProv.client.endpoint)
+			}
+			continue
+		}
+		if err != errBitmovinInvalidConfig {
+			t.Errorf("%s: wrong error returned. Want %#v. Got %#v", test.testCase, errBitmovinInvalidConfig, err)
+		}
+	}
+}
+
+func TestCreatePreset(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:         "hls_720p",
+		Description:  "HLS 720p",
+		Container:    "m3u8",
+		Profile:      "high",
+		ProfileLevel: "3.1",
+		Video:        db.VideoPreset{Codec: "h264", Width: "1280", Height: "720", Bitrate: "3500000", GopSize: "90", GopMode: "fixed"},
+		Audio:        db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio := server.created("encoding/configurations/audio/aac")
+	if len(audio) != 1 || audio[0]["bitrate"] != float64(128000) || audio[0]["rate"] != float64(48000) {
+		t.Fatalf("wrong audio configuration created: %#v", audio)
+	}
+	expectedVideo := map[string]interface{}{
+		"id":                presetID,
+		"name":              "hls_720p",
+		"description":       "HLS 720p",
+		"customData":        map[string]interface{}{"container": "m3u8", "audio": audio[0]["id"]},
+		"bitrate":           float64(3500000),
+		"width":             float64(1280),
+		"height":            float64(720),
+		"profile":           "HIGH",
+		"level":             "3.1",
+		"minGop":            float64(90),
+		"maxGop":            float64(90),
+		"sceneCutThreshold": float64(0),
+	}
+	video := server.created("encoding/configurations/video/h264")
+	if len(video) != 1 || !reflect.DeepEqual(video[0], expectedVideo) {
+		t.Errorf("wrong video configuration created.\nWant %#v\nGot  %#v", expectedVideo, video)
+	}
+}
+
+func TestCreatePresetAV1(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:      "av1_720p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "av1", Width: "1280", Height: "720", Bitrate: "1500000", Passes: "2"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := server.created("encoding/configurations/video/av1")
+	if len(video) != 1 || video[0]["id"] != presetID || video[0]["bitrate"] != float64(1500000) || video[0]["encodingMode"] != "TWO_PASS" {
+		t.Errorf("wrong video configuration created: %#v", video)
+	}
+}
+
+func TestCreatePresetHDR10(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:      "hdr10_2160p",
+		Container: "mp4",
+		Video: db.VideoPreset{
+			Codec:             "hevc",
+			Width:             "3840",
+			Height:            "2160",
+			Bitrate:           "15000000",
+			TransferFunction:  "pq",
+			MasteringDisplay:  "G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50)",
+			ContentLightLevel: "1000,400",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := server.created("encoding/configurations/video/h265")
+	if len(video) != 1 || video[0]["id"] != presetID {
+		t.Fatalf("wrong video configuration created: %#v", video)
+	}
+	expected := map[string]interface{}{
+		"profile":     "main10",
+		"pixelFormat": "YUV420P10LE",
+		"colorConfig": map[string]interface{}{
+			"colorPrimaries": "BT2020",
+			"colorSpace":     "BT2020_NCL",
+			"colorTransfer":  "SMPTE2084",
+		},
+		"masterDisplay":               "G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50)",
+		"maxContentLightLevel":        float64(1000),
+		"maxPictureAverageLightLevel": float64(400),
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(video[0][key], value) {
+			t.Errorf("wrong %s in the video configuration. Want %#v. Got %#v", key, value, video[0][key])
+		}
+	}
+}
+
+func TestCreatePresetAudioOnly(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:      "audio_only",
+		Container: "mp4",
+		Audio:     db.AudioPreset{Codec: "libopus", Bitrate: "96000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	preset, err := prov.GetPreset(presetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmovinPreset := preset.(*bitmovinPreset)
+	if bitmovinPreset.Video != nil || bitmovinPreset.AudioCodec != "opus" || bitmovinPreset.Container != "mp4" {
+		t.Errorf("wrong preset returned: %#v", bitmovinPreset)
+	}
+}
+
+func TestCreatePresetSurround(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	_, err := prov.CreatePreset(db.Preset{
+		Name:      "dash_1080p_surround",
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "h264", Bitrate: "5000000", Height: "1080"},
+		Audio:     db.AudioPreset{Codec: "eac3", Bitrate: "640000", ChannelLayout: "5.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := server.created("encoding/configurations/audio/eac3")
+	if len(configs) != 1 || configs[0]["channelLayout"] != "5.1" || configs[0]["bitrate"] != float64(640000) {
+		t.Errorf("wrong audio configurations created: %#v", configs)
+	}
+}
+
+func TestCreatePresetValidation(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		preset    db.Preset
+		wantError string
+	}{
+		{
+			"unsupported container",
+			db.Preset{Container: "mov", Video: db.VideoPreset{Codec: "h264"}},
+			`unsupported container "mov"`,
+		},
+		{
+			"unsupported video codec",
+			db.Preset{Container: "mp4", Video: db.VideoPreset{Codec: "mpeg2"}},
+			`unsupported video codec "mpeg2"`,
+		},
+		{
+			"unsupported audio codec",
+			db.Preset{Container: "mp4", Audio: db.AudioPreset{Codec: "ac3"}},
+			`unsupported audio codec "ac3"`,
+		},
+		{
+			"invalid bitrate",
+			db.Preset{Container: "mp4", Video: db.VideoPreset{Codec: "h264", Bitrate: "high"}},
+			`invalid video bitrate "high"`,
+		},
+		{
+			"empty preset",
+			db.Preset{Container: "mp4"},
+			"the preset must define video or audio settings",
+		},
+	}
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	for _, test := range tests {
+		_, err := prov.CreatePreset(test.preset)
+		if err == nil || err.Error() != test.wantError {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.wantError, err)
+		}
+	}
+	if len(server.requests) > 0 {
+		t.Errorf("unexpected requests for invalid presets: %#v", server.requests)
+	}
+}
+
+func TestGetPreset(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetMap := createTestPreset(t, prov, "mp4_720p", "mp4")
+	preset, err := prov.GetPreset(presetMap.ProviderMapping[Name])
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmovinPreset := preset.(*bitmovinPreset)
+	if bitmovinPreset.Container != "mp4" || bitmovinPreset.VideoCodec != "h264" || bitmovinPreset.AudioCodec != "aac" {
+		t.Errorf("wrong preset returned: %#v", bitmovinPreset)
+	}
+	if bitmovinPreset.Video.Width != 1280 || bitmovinPreset.Audio.Bitrate != 128000 {
+		t.Errorf("wrong codec configurations returned: %#v / %#v", bitmovinPreset.Video, bitmovinPreset.Audio)
+	}
+	if _, err = prov.GetPreset("some-preset"); err == nil {
+		t.Error("unexpected <nil> error for non existing preset")
+	}
+}
+
+func TestDeletePreset(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetMap := createTestPreset(t, prov, "mp4_720p", "mp4")
+	if err := prov.DeletePreset(presetMap.ProviderMapping[Name]); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.resources) != 0 {
+		t.Errorf("codec configurations not deleted: %#v", server.resources)
+	}
+	if err := prov.DeletePreset(presetMap.ProviderMapping[Name]); err == nil {
+		t.Error("unexpected <nil> error when deleting non existing preset")
+	}
+}
+
+func TestTranscodeMP4(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetMap := createTestPreset(t, prov, "mp4_720p", "mp4")
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://source-bucket/videos/master.mov",
+		Outputs:     []provider.TranscodeOutput{{Preset: presetMap, FileName: "mp4/video_720p.mp4"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodingID := jobStatus.ProviderJobID
+	if jobStatus.Status != provider.StatusQueued || jobStatus.ProviderName != Name {
+		t.Errorf("wrong job status returned: %#v", jobStatus)
+	}
+	inputs := server.created("encoding/inputs/s3")
+	if len(inputs) != 1 || inputs[0]["bucketName"] != "source-bucket" || inputs[0]["accessKey"] != "AKIANOTREALLY" {
+		t.Errorf("wrong input created: %#v", inputs)
+	}
+	outputs := server.created("encoding/outputs/s3")
+	if len(outputs) != 1 || outputs[0]["bucketName"] != "some-bucket" {
+		t.Errorf("wrong output created: %#v", outputs)
+	}
+	encodings := server.created("encoding/encodings")
+	expectedCustomData := map[string]interface{}{
+		"jobId":       "job-123",
+		"destination": "s3://some-bucket/outputs/job-123",
+		"files":       "mp4/video_720p.mp4",
+	}
+	if len(encodings) != 1 || encodings[0]["cloudRegion"] != "AWS_US_EAST_1" || !reflect.DeepEqual(encodings[0]["customData"], expectedCustomData) {
+		t.Errorf("wrong encoding created: %#v", encodings)
+	}
+	streams := server.created("encoding/encodings/" + encodingID + "/streams")
+	if len(streams) != 2 {
+		t.Fatalf("wrong number of streams created. Want 2. Got %#v", streams)
+	}
+	inputStream := streams[0]["inputStreams"].([]interface{})[0].(map[string]interface{})
+	if inputStream["inputPath"] != "videos/master.mov" || inputStream["inputId"] != inputs[0]["id"] {
+		t.Errorf("wrong input stream: %#v", inputStream)
+	}
+	muxings := server.created("encoding/encodings/" + encodingID + "/muxings/mp4")
+	if len(muxings) != 1 {
+		t.Fatalf("wrong number of mp4 muxings created. Want 1. Got %#v", muxings)
+	}
+	if muxings[0]["filename"] != "video_720p.mp4" || len(muxings[0]["streams"].([]interface{})) != 2 {
+		t.Errorf("wrong mp4 muxing created: %#v", muxings[0])
+	}
+	muxingOutput := muxings[0]["outputs"].([]interface{})[0].(map[string]interface{})
+	if muxingOutput["outputPath"] != "outputs/job-123/mp4" || muxingOutput["outputId"] != outputs[0]["id"] {
+		t.Errorf("wrong muxing output: %#v", muxingOutput)
+	}
+	if server.statuses["encoding/encodings/"+encodingID] != "RUNNING" {
+		t.Error("the encoding wasn't started")
+	}
+}
+
+func TestTranscodeClip(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetMap := createTestPreset(t, prov, "mp4_720p", "mp4")
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://source-bucket/videos/master.mov",
+		Outputs:     []provider.TranscodeOutput{{Preset: presetMap, FileName: "mp4/preview_720p.mp4"}},
+		Clip:        &provider.Clip{Start: 5, Duration: 30},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodingPath := "encoding/encodings/" + jobStatus.ProviderJobID
+	inputs := server.created("encoding/inputs/s3")
+	ingests := server.created(encodingPath + "/input-streams/ingest")
+	if len(ingests) != 1 || ingests[0]["inputPath"] != "videos/master.mov" || ingests[0]["inputId"] != inputs[0]["id"] {
+		t.Fatalf("wrong ingest input streams created: %#v", ingests)
+	}
+	trimmings := server.created(encodingPath + "/input-streams/trimming/time-based")
+	if len(trimmings) != 1 || trimmings[0]["inputStreamId"] != ingests[0]["id"] || trimmings[0]["offset"] != 5.0 || trimmings[0]["duration"] != 30.0 {
+		t.Fatalf("wrong trimming input streams created: %#v", trimmings)
+	}
+	streams := server.created(encodingPath + "/streams")
+	if len(streams) != 2 {
+		t.Fatalf("wrong number of streams created. Want 2. Got %#v", streams)
+	}
+	for _, s := range streams {
+		expected := []interface{}{map[string]interface{}{"inputStreamId": trimmings[0]["id"]}}
+		if !reflect.DeepEqual(s["inputStreams"], expected) {
+			t.Errorf("wrong input streams.\nWant %#v\nGot  %#v", expected, s["inputStreams"])
+		}
+	}
+}
+
+func TestTranscodeHLS(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	outputs := []provider.TranscodeOutput{
+		{Preset: createTestPreset(t, prov, "hls_720p", "m3u8"), FileName: "hls/video_720p.m3u8"},
+		{Preset: createTestPreset(t, prov, "hls_480p", "m3u8"), FileName: "hls/video_480p.m3u8"},
+	}
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "https://example.com/master.mov?token=123",
+		Outputs:     outputs,
+		StreamingParams: provider.StreamingParams{
+			Protocol:          "hls",
+			SegmentDuration:   6,
+			PlaylistFileName:  "hls/master.m3u8",
+			KeyframeAlignment: &provider.KeyframeAlignment{Enabled: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodingID := jobStatus.ProviderJobID
+	if inputs := server.created("encoding/inputs/https"); len(inputs) != 1 || inputs[0]["host"] != "example.com" {
+		t.Errorf("wrong input created: %#v", inputs)
+	}
+	manifests := server.created("encoding/manifests/hls")
+	if len(manifests) != 1 || manifests[0]["manifestName"] != "master.m3u8" {
+		t.Fatalf("wrong manifest created: %#v", manifests)
+	}
+	manifestOutput := manifests[0]["outputs"].([]interface{})[0].(map[string]interface{})
+	if manifestOutput["outputPath"] != "outputs/job-123/hls" {
+		t.Errorf("wrong manifest output: %#v", manifestOutput)
+	}
+	customData := server.resources["encoding/encodings/"+encodingID]["customData"].(map[string]interface{})
+	if customData["manifestType"] != "hls" || customData["manifestId"] != manifests[0]["id"] || customData["manifest"] != "hls/master.m3u8" {
+		t.Errorf("wrong custom data in the encoding: %#v", customData)
+	}
+	muxings := server.created("encoding/encodings/" + encodingID + "/muxings/ts")
+	if len(muxings) != 2 {
+		t.Fatalf("wrong number of ts muxings created. Want 2. Got %#v", muxings)
+	}
+	muxingOutput := muxings[0]["outputs"].([]interface{})[0].(map[string]interface{})
+	if muxingOutput["outputPath"] != "outputs/job-123/hls/video_720p" || muxings[0]["segmentLength"] != float64(6) {
+		t.Errorf("wrong ts muxing created: %#v", muxings[0])
+	}
+	hlsStreams := server.created("encoding/manifests/hls/" + manifests[0]["id"].(string) + "/streams")
+	if len(hlsStreams) != 2 {
+		t.Fatalf("wrong number of HLS streams. Want 2. Got %#v", hlsStreams)
+	}
+	if hlsStreams[1]["uri"] != "video_480p.m3u8" || hlsStreams[1]["segmentPath"] != "video_480p/" || hlsStreams[1]["muxingId"] != muxings[1]["id"] {
+		t.Errorf("wrong HLS stream: %#v", hlsStreams[1])
+	}
+}
+
+func TestTranscodeDASH(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	outputs := []provider.TranscodeOutput{
+		{Preset: createTestPreset(t, prov, "dash_720p", "mp4"), FileName: "video_720p.mp4"},
+		{Preset: createTestPreset(t, prov, "dash_480p", "mp4"), FileName: "video_480p.mp4"},
+	}
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123", Destination: "s3://other-bucket/"}, provider.TranscodeProfile{
+		SourceMedia:     "s3://source-bucket/master.mov",
+		Outputs:         outputs,
+		StreamingParams: provider.StreamingParams{Protocol: "dash"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodingID := jobStatus.ProviderJobID
+	manifests := server.created("encoding/manifests/dash")
+	if len(manifests) != 1 || manifests[0]["manifestName"] != "index.mpd" {
+		t.Fatalf("wrong manifest created: %#v", manifests)
+	}
+	manifestOutput := manifests[0]["outputs"].([]interface{})[0].(map[string]interface{})
+	if manifestOutput["outputPath"] != "job-123/dash" {
+		t.Errorf("wrong manifest output: %#v", manifestOutput)
+	}
+	if outputs := server.created("encoding/outputs/s3"); len(outputs) != 1 || outputs[0]["bucketName"] != "other-bucket" {
+		t.Errorf("wrong output created: %#v", outputs)
+	}
+	muxings := server.created("encoding/encodings/" + encodingID + "/muxings/fmp4")
+	if len(muxings) != 4 {
+		t.Fatalf("wrong number of fmp4 muxings. Want 4. Got %d", len(muxings))
+	}
+	if muxings[0]["segmentLength"] != float64(defaultSegmentLength) {
+		t.Errorf("wrong segment length: %#v", muxings[0]["segmentLength"])
+	}
+	var representations []string
+	for _, path := range server.requestPaths("POST") {
+		if strings.HasSuffix(path, "/representations/fmp4") {
+			representations = append(representations, path)
+		}
+	}
+	if len(representations) != 4 || representations[0] == representations[1] {
+		t.Errorf("wrong representations created: %#v", representations)
+	}
+	customData := server.resources["encoding/encodings/"+encodingID]["customData"].(map[string]interface{})
+	if customData["files"] != nil || customData["destination"] != "s3://other-bucket/job-123" {
+		t.Errorf("wrong custom data in the encoding: %#v", customData)
+	}
+}
+
+func TestTranscodeDASHAudioTracks(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://source-bucket/master.mov",
+		Outputs:     []provider.TranscodeOutput{{Preset: createTestPreset(t, prov, "dash_720p", "mp4"), FileName: "video_720p.mp4"}},
+		AudioTracks: []db.AudioTrack{
+			{Track: 1, Language: "en", Name: "English", Default: true},
+			{Track: 3, Language: "es", Name: "Español"},
+		},
+		StreamingParams: provider.StreamingParams{Protocol: "dash"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodingPath := "encoding/encodings/" + jobStatus.ProviderJobID
+	streams := server.created(encodingPath + "/streams")
+	if len(streams) != 3 {
+		t.Fatalf("wrong number of streams created. Want 3. Got %#v", streams)
+	}
+	for i, expected := range []map[string]interface{}{
+		{"selectionMode": "AUTO"},
+		{"selectionMode": "AUDIO_RELATIVE"},
+		{"selectionMode": "AUDIO_RELATIVE", "position": 2.0},
+	} {
+		input := streams[i]["inputStreams"].([]interface{})[0].(map[string]interface{})
+		delete(input, "inputId")
+		delete(input, "inputPath")
+		if !reflect.DeepEqual(input, expected) {
+			t.Errorf("wrong input stream %d.\nWant %#v\nGot  %#v", i, expected, input)
+		}
+	}
+	manifestID := server.created("encoding/manifests/dash")[0]["id"].(string)
+	periodID := server.created("encoding/manifests/dash/" + manifestID + "/periods")[0]["id"].(string)
+	setsPath := "encoding/manifests/dash/" + manifestID + "/periods/" + periodID + "/adaptationsets/"
+	if sets := server.created(setsPath + "video"); len(sets) != 1 {
+		t.Errorf("wrong video adaptation sets created: %#v", sets)
+	}
+	sets := server.created(setsPath + "audio")
+	if len(sets) != 2 {
+		t.Fatalf("wrong number of audio adaptation sets created. Want 2. Got %#v", sets)
+	}
+	for i, expected := range []map[string]interface{}{
+		{"lang": "en", "roles": []interface{}{"MAIN"}, "labels": []interface{}{map[string]interface{}{"lang": "en", "value": "English"}}},
+		{"lang": "es", "roles": []interface{}{"ALTERNATE"}, "labels": []interface{}{map[string]interface{}{"lang": "es", "value": "Español"}}},
+	} {
+		delete(sets[i], "id")
+		if !reflect.DeepEqual(sets[i], expected) {
+			t.Errorf("wrong audio adaptation set %d.\nWant %#v\nGot  %#v", i, expected, sets[i])
+		}
+	}
+	muxings := server.created(encodingPath + "/muxings/fmp4")
+	if len(muxings) != 3 {
+		t.Fatalf("wrong number of fmp4 muxings. Want 3. Got %d", len(muxings))
+	}
+	muxingOutput := muxings[2]["outputs"].([]interface{})[0].(map[string]interface{})
+	if muxingOutput["outputPath"] != "outputs/job-123/dash/audio_3_video_720p" {
+		t.Errorf("wrong output of the audio track muxing: %#v", muxingOutput)
+	}
+}
+
+func TestTranscodeAudioTracksWithoutDASH(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	_, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia:     "s3://source-bucket/master.mov",
+		Outputs:         []provider.TranscodeOutput{{Preset: createTestPreset(t, prov, "hls_720p", "m3u8"), FileName: "hls/video_720p.m3u8"}},
+		AudioTracks:     []db.AudioTrack{{Track: 1, Default: true}, {Track: 2}},
+		StreamingParams: provider.StreamingParams{Protocol: "hls"},
+	})
+	expectedMsg := "bitmovin only supports multiple audio tracks in dash jobs"
+	if err == nil || err.Error() != expectedMsg {
+		t.Errorf("wrong error returned.\nWant %q\nGot  %v", expectedMsg, err)
+	}
+}
+
+func TestTranscodeKeyframeAlignment(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:      "hls_360p",
+		Container: "m3u8",
+		Video:     db.VideoPreset{Codec: "h264", Bitrate: "800000", GopSize: "90"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://source-bucket/master.mov",
+		Outputs: []provider.TranscodeOutput{
+			{Preset: db.PresetMap{Name: "hls_360p", ProviderMapping: map[string]string{Name: presetID}}, FileName: "360p.m3u8"},
+		},
+		StreamingParams: provider.StreamingParams{
+			Protocol:          "hls",
+			KeyframeAlignment: &provider.KeyframeAlignment{Enabled: true},
+		},
+	})
+	if _, ok := err.(provider.KeyframeAlignmentError); !ok {
+		t.Errorf("wrong error returned. Want KeyframeAlignmentError. Got %#v", err)
+	}
+}
+
+func TestTranscodePresetNotFound(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	_, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://source-bucket/master.mov",
+		Outputs: []provider.TranscodeOutput{
+			{Preset: db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{"zencoder": "123"}}, FileName: "video.mp4"},
+		},
+	})
+	if err != provider.ErrPresetMapNotFound {
+		t.Errorf("wrong error returned. Want %#v. Got %#v", provider.ErrPresetMapNotFound, err)
+	}
+}
+
+func TestJobStatus(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	job := db.Job{ID: "job-123"}
+	jobStatus, err := prov.Transcode(&job, provider.TranscodeProfile{
+		SourceMedia: "s3://source-bucket/master.mov",
+		Outputs: []provider.TranscodeOutput{
+			{Preset: createTestPreset(t, prov, "hls_720p", "m3u8"), FileName: "video_720p.m3u8"},
+			{Preset: createTestPreset(t, prov, "mp4_720p", "mp4"), FileName: "video_720p.mp4"},
+		},
+		StreamingParams: provider.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/index.m3u8"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.ProviderJobID = jobStatus.ProviderJobID
+	encodingPath := "encoding/encodings/" + job.ProviderJobID
+	manifestPath := "encoding/manifests/hls/" + server.created("encoding/manifests/hls")[0]["id"].(string)
+
+	jobStatus, err = prov.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobStatus.Status != provider.StatusStarted || jobStatus.Progress != 42 {
+		t.Errorf("wrong status for running encoding: %#v", jobStatus)
+	}
+
+	server.statuses[encodingPath] = "FINISHED"
+	jobStatus, err = prov.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobStatus.Status != provider.StatusStarted || jobStatus.ProviderStatus["manifestStatus"] != "QUEUED" {
+		t.Errorf("wrong status while generating the manifest: %#v", jobStatus)
+	}
+	if server.statuses[manifestPath] != "RUNNING" {
+		t.Error("the manifest generation wasn't started")
+	}
+
+	server.statuses[manifestPath] = "FINISHED"
+	jobStatus, err = prov.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := provider.JobOutput{
+		Destination: "s3://some-bucket/outputs/job-123",
+		Files: []provider.OutputFile{
+			{Path: "s3://some-bucket/outputs/job-123/hls/index.m3u8", Container: "m3u8"},
+			{Path: "s3://some-bucket/outputs/job-123/video_720p.mp4", Container: "mp4"},
+		},
+	}
+	if jobStatus.Status != provider.StatusFinished || jobStatus.Progress != 100 {
+		t.Errorf("wrong status for finished job: %#v", jobStatus)
+	}
+	if !reflect.DeepEqual(jobStatus.Output, expectedOutput) {
+		t.Errorf("wrong job output.\nWant %#v\nGot  %#v", expectedOutput, jobStatus.Output)
+	}
+
+	server.statuses[encodingPath] = "ERROR"
+	jobStatus, err = prov.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobStatus.Status != provider.StatusFailed || jobStatus.StatusMessage != "input file not found" {
+		t.Errorf("wrong status for failed encoding: %#v", jobStatus)
+	}
+}
+
+func TestJobStatusNotFound(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	_, err := prov.JobStatus(&db.Job{ID: "job-123", ProviderJobID: "some-encoding"})
+	if apiErr, ok := err.(*apiError); !ok || apiErr.StatusCode != 404 {
+		t.Errorf("wrong error returned: %#v", err)
+	}
+}
+
+func TestStatusMap(t *testing.T) {
+	var tests = []struct {
+		bitmovinStatus string
+		expected       provider.Status
+	}{
+		{"CREATED", provider.StatusQueued},
+		{"QUEUED", provider.StatusQueued},
+		{"RUNNING", provider.StatusStarted},
+		{"FINISHED", provider.StatusFinished},
+		{"CANCELED", provider.StatusCanceled},
+		{"ERROR", provider.StatusFailed},
+		{"TRANSFER_ERROR", provider.StatusUnknown},
+	}
+	var prov bitmovinProvider
+	for _, test := range tests {
+		if got := prov.statusMap(test.bitmovinStatus); got != test.expected {
+			t.Errorf("statusMap(%q): wrong value. Want %q. Got %q", test.bitmovinStatus, test.expected, got)
+		}
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://source-bucket/master.mov",
+		Outputs:     []provider.TranscodeOutput{{Preset: createTestPreset(t, prov, "mp4_720p", "mp4"), FileName: "video.mp4"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = prov.CancelJob(jobStatus.ProviderJobID); err != nil {
+		t.Fatal(err)
+	}
+	if status := server.statuses["encoding/encodings/"+jobStatus.ProviderJobID]; status != "CANCELED" {
+		t.Errorf("the encoding wasn't stopped. Status: %q", status)
+	}
+	if err = prov.CancelJob("some-encoding"); err == nil {
+		t.Error("unexpected <nil> error when canceling non existing encoding")
+	}
+}
+
+func TestHealthcheck(t *testing.T) {
+	server := newBitmovinFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	if err := prov.Healthcheck(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	prov.client.apiKey = "invalid"
+	if err := prov.Healthcheck(); err == nil {
+		t.Error("unexpected <nil> error with invalid api key")
+	}
+}
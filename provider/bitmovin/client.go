@@ -0,0 +1,247 @@
+package bitmovin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// client is a minimal client for the encoding resources of the Bitmovin REST
+// API.
+type client struct {
+	endpoint string
+	apiKey   string
+	http     *http.Client
+}
+
+func newClient(endpoint, apiKey string) *client {
+	return &client{
+		endpoint: strings.TrimRight(endpoint, "/") + "/",
+		apiKey:   apiKey,
+		http:     &http.Client{Timeout: time.Minute},
+	}
+}
+
+// apiResponse is the envelope of all the responses of the Bitmovin API.
+type apiResponse struct {
+	RequestID string `json:"requestId"`
+	Status    string `json:"status"`
+	Data      struct {
+		Result  json.RawMessage `json:"result"`
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+	} `json:"data"`
+}
+
+// apiError is the error returned for requests rejected by the Bitmovin API.
+type apiError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("bitmovin: %s (code %d, status %d)", e.Message, e.Code, e.StatusCode)
+}
+
+// resource contains the fields shared by all resources of the API.
+type resource struct {
+	ID          string            `json:"id,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	CustomData  map[string]string `json:"customData,omitempty"`
+}
+
+type codecConfig struct {
+	resource
+	Bitrate           int64   `json:"bitrate,omitempty"`
+	Rate              float64 `json:"rate,omitempty"`
+	Width             int64   `json:"width,omitempty"`
+	Height            int64   `json:"height,omitempty"`
+	Profile           string  `json:"profile,omitempty"`
+	Level             string  `json:"level,omitempty"`
+	MinGop            int64   `json:"minGop,omitempty"`
+	MaxGop            int64   `json:"maxGop,omitempty"`
+	SceneCutThreshold *int64  `json:"sceneCutThreshold,omitempty"`
+	EncodingMode      string  `json:"encodingMode,omitempty"`
+	ChannelLayout     string  `json:"channelLayout,omitempty"`
+
+	// HDR settings of H.265 configurations
+	PixelFormat                 string       `json:"pixelFormat,omitempty"`
+	ColorConfig                 *colorConfig `json:"colorConfig,omitempty"`
+	MasterDisplay               string       `json:"masterDisplay,omitempty"`
+	MaxContentLightLevel        int64        `json:"maxContentLightLevel,omitempty"`
+	MaxPictureAverageLightLevel int64        `json:"maxPictureAverageLightLevel,omitempty"`
+}
+
+type colorConfig struct {
+	ColorPrimaries string `json:"colorPrimaries,omitempty"`
+	ColorSpace     string `json:"colorSpace,omitempty"`
+	ColorTransfer  string `json:"colorTransfer,omitempty"`
+}
+
+type codecConfigType struct {
+	Type string `json:"type"`
+}
+
+type storage struct {
+	resource
+	BucketName string `json:"bucketName,omitempty"`
+	AccessKey  string `json:"accessKey,omitempty"`
+	SecretKey  string `json:"secretKey,omitempty"`
+	Host       string `json:"host,omitempty"`
+}
+
+type encoding struct {
+	resource
+	CloudRegion string `json:"cloudRegion,omitempty"`
+}
+
+type inputStream struct {
+	resource
+	InputID       string `json:"inputId,omitempty"`
+	InputPath     string `json:"inputPath,omitempty"`
+	SelectionMode string `json:"selectionMode,omitempty"`
+	Position      int    `json:"position,omitempty"`
+	InputStreamID string `json:"inputStreamId,omitempty"`
+}
+
+type trimmingInputStream struct {
+	resource
+	InputStreamID string  `json:"inputStreamId"`
+	Offset        float64 `json:"offset"`
+	Duration      float64 `json:"duration,omitempty"`
+}
+
+type stream struct {
+	resource
+	CodecConfigID string        `json:"codecConfigId"`
+	InputStreams  []inputStream `json:"inputStreams"`
+}
+
+type encodingOutput struct {
+	OutputID   string `json:"outputId"`
+	OutputPath string `json:"outputPath"`
+	ACL        []acl  `json:"acl,omitempty"`
+}
+
+type acl struct {
+	Permission string `json:"permission"`
+}
+
+type muxingStream struct {
+	StreamID string `json:"streamId"`
+}
+
+type muxing struct {
+	resource
+	Filename      string           `json:"filename,omitempty"`
+	SegmentLength float64          `json:"segmentLength,omitempty"`
+	SegmentNaming string           `json:"segmentNaming,omitempty"`
+	Streams       []muxingStream   `json:"streams"`
+	Outputs       []encodingOutput `json:"outputs"`
+}
+
+type manifest struct {
+	resource
+	ManifestName string           `json:"manifestName"`
+	Outputs      []encodingOutput `json:"outputs"`
+}
+
+type hlsStream struct {
+	resource
+	URI         string `json:"uri"`
+	SegmentPath string `json:"segmentPath"`
+	EncodingID  string `json:"encodingId"`
+	StreamID    string `json:"streamId"`
+	MuxingID    string `json:"muxingId"`
+}
+
+type audioAdaptationSet struct {
+	resource
+	Lang   string      `json:"lang"`
+	Roles  []string    `json:"roles,omitempty"`
+	Labels []dashLabel `json:"labels,omitempty"`
+}
+
+type dashLabel struct {
+	Lang  string `json:"lang,omitempty"`
+	Value string `json:"value"`
+}
+
+type dashRepresentation struct {
+	resource
+	Type        string `json:"type"`
+	SegmentPath string `json:"segmentPath"`
+	EncodingID  string `json:"encodingId"`
+	MuxingID    string `json:"muxingId"`
+}
+
+type taskStatus struct {
+	Status   string        `json:"status"`
+	Progress float64       `json:"progress"`
+	Messages []taskMessage `json:"messages"`
+}
+
+type taskMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type customData struct {
+	CustomData map[string]string `json:"customData"`
+}
+
+type resourceList struct {
+	TotalCount int64             `json:"totalCount"`
+	Items      []json.RawMessage `json:"items"`
+}
+
+func (c *client) get(path string, result interface{}) error {
+	return c.do("GET", path, nil, result)
+}
+
+func (c *client) post(path string, body, result interface{}) error {
+	return c.do("POST", path, body, result)
+}
+
+func (c *client) delete(path string) error {
+	return c.do("DELETE", path, nil, nil)
+}
+
+func (c *client) do(method, path string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.endpoint+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Api-Key", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var apiResp apiResponse
+	if err = json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("bitmovin: invalid response to %s %s (%s): %s", method, path, resp.Status, err)
+	}
+	if resp.StatusCode >= 300 || apiResp.Status != "SUCCESS" {
+		return &apiError{StatusCode: resp.StatusCode, Code: apiResp.Data.Code, Message: apiResp.Data.Message}
+	}
+	if result != nil && len(apiResp.Data.Result) > 0 {
+		return json.Unmarshal(apiResp.Data.Result, result)
+	}
+	return nil
+}
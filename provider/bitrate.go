@@ -0,0 +1,19 @@
+package provider
+
+import "fmt"
+
+// BitrateRange is the range of bitrates, in kbps, accepted by a provider
+// for a codec.
+type BitrateRange struct {
+	Min int64
+	Max int64
+}
+
+// Warning returns a warning about the given bitrate, in kbps, when it's out
+// of the range, or an empty string otherwise.
+func (r BitrateRange) Warning(kind string, kbps int64) string {
+	if kbps < r.Min || (r.Max > 0 && kbps > r.Max) {
+		return fmt.Sprintf("%s bitrate of %d kbps is out of the supported range (%d-%d kbps)", kind, kbps, r.Min, r.Max)
+	}
+	return ""
+}
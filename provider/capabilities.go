@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capabilities describes the available features in the provider: the
+// input and output formats, destinations, codecs and streaming protocols it
+// supports, along with the optional features that jobs and presets may
+// require. Empty caption formats means that any format is ingested. Progress
+// is set for providers that report the progress of started jobs, which is
+// estimated by the API for the other providers.
+type Capabilities struct {
+	InputFormats       []string `json:"input"`
+	OutputFormats      []string `json:"output"`
+	Destinations       []string `json:"destinations"`
+	VideoCodecs        []string `json:"videoCodecs,omitempty"`
+	AudioCodecs        []string `json:"audioCodecs,omitempty"`
+	StreamingProtocols []string `json:"streamingProtocols,omitempty"`
+	SegmentFormats     []string `json:"segmentFormats,omitempty"`
+	DRMSchemes         []string `json:"drmSchemes,omitempty"`
+	CaptionModes       []string `json:"captionModes,omitempty"`
+	CaptionFormats     []string `json:"captionFormats,omitempty"`
+	MaxAudioChannels   int      `json:"maxAudioChannels,omitempty"`
+	Thumbnails         bool     `json:"thumbnails,omitempty"`
+	Clipping           bool     `json:"clipping,omitempty"`
+	Conform            bool     `json:"conform,omitempty"`
+	Trim               bool     `json:"trim,omitempty"`
+	OutputHeaders      bool     `json:"outputHeaders,omitempty"`
+	AudioOnly          bool     `json:"audioOnly,omitempty"`
+	Watermark          bool     `json:"watermark,omitempty"`
+	Previews           bool     `json:"previews,omitempty"`
+	HDR                bool     `json:"hdr,omitempty"`
+	ToneMapping        bool     `json:"toneMapping,omitempty"`
+	Loudness           bool     `json:"loudness,omitempty"`
+	Rotation           bool     `json:"rotation,omitempty"`
+	NoAutorotation     bool     `json:"noAutorotation,omitempty"`
+	AudioOnlyRendition bool     `json:"audioOnlyRendition,omitempty"`
+	DescribedAudio     bool     `json:"describedAudio,omitempty"`
+	AudioTracks        bool     `json:"audioTracks,omitempty"`
+	Progress           bool     `json:"progress,omitempty"`
+}
+
+// Requirements describes the set of features needed by a job or a preset.
+// Empty values indicate that the feature is not needed.
+type Requirements struct {
+	OutputFormats      []string
+	VideoCodecs        []string
+	AudioCodecs        []string
+	StreamingProtocol  string
+	SegmentFormat      string
+	DRMScheme          string
+	CaptionModes       []string
+	AudioChannels      int
+	Thumbnails         bool
+	Clipping           bool
+	Conform            bool
+	Trim               bool
+	OutputHeaders      bool
+	AudioOnly          bool
+	Watermark          bool
+	Previews           bool
+	HDR                bool
+	ToneMapping        bool
+	Loudness           bool
+	Rotation           bool
+	NoAutorotation     bool
+	AudioOnlyRendition bool
+	DescribedAudio     bool
+	AudioTracks        bool
+}
+
+// UnsupportedFeatureError is returned by Capabilities.Check when the
+// provider doesn't support one of the required features.
+type UnsupportedFeatureError struct {
+	Provider string
+	Feature  string
+}
+
+func (err UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("provider %q doesn't support %s", err.Provider, err.Feature)
+}
+
+// Check verifies that the given requirements are met by the capabilities,
+// returning an UnsupportedFeatureError describing the first feature that is
+// not supported.
+func (c Capabilities) Check(providerName string, r Requirements) error {
+	unsupported := func(feature string, args ...interface{}) error {
+		return UnsupportedFeatureError{Provider: providerName, Feature: fmt.Sprintf(feature, args...)}
+	}
+	for _, format := range r.OutputFormats {
+		if !contains(c.OutputFormats, format) {
+			return unsupported("the output format %q", format)
+		}
+	}
+	for _, codec := range r.VideoCodecs {
+		if !contains(c.VideoCodecs, codec) {
+			return unsupported("the video codec %q", codec)
+		}
+	}
+	for _, codec := range r.AudioCodecs {
+		if !contains(c.AudioCodecs, codec) {
+			return unsupported("the audio codec %q", codec)
+		}
+	}
+	if r.StreamingProtocol != "" && !contains(c.StreamingProtocols, r.StreamingProtocol) {
+		return unsupported("the streaming protocol %q", r.StreamingProtocol)
+	}
+	if r.SegmentFormat != "" && !contains(c.SegmentFormats, r.SegmentFormat) {
+		return unsupported("%s segments", r.SegmentFormat)
+	}
+	if r.DRMScheme != "" && !contains(c.DRMSchemes, r.DRMScheme) {
+		return unsupported("the DRM scheme %q", r.DRMScheme)
+	}
+	for _, mode := range r.CaptionModes {
+		if !contains(c.CaptionModes, mode) {
+			return unsupported("%s captions", mode)
+		}
+	}
+	if r.AudioChannels > c.MaxAudioChannels {
+		return unsupported("%d audio channels", r.AudioChannels)
+	}
+	features := []struct {
+		name      string
+		required  bool
+		supported bool
+	}{
+		{"thumbnails", r.Thumbnails, c.Thumbnails},
+		{"clipping", r.Clipping, c.Clipping},
+		{"frame-accurate conform", r.Conform, c.Conform},
+		{"black and silence trimming", r.Trim, c.Trim},
+		{"output headers", r.OutputHeaders, c.OutputHeaders},
+		{"audio-only outputs", r.AudioOnly, c.AudioOnly},
+		{"watermarks", r.Watermark, c.Watermark},
+		{"preview clips", r.Previews, c.Previews},
+		{"HDR", r.HDR, c.HDR},
+		{"HDR to SDR tone mapping", r.ToneMapping, c.ToneMapping},
+		{"loudness normalization", r.Loudness, c.Loudness},
+		{"rotation settings", r.Rotation, c.Rotation},
+		{"disabling autorotation without explicit degrees", r.NoAutorotation, c.NoAutorotation},
+		{"audio-only HLS renditions", r.AudioOnlyRendition, c.AudioOnlyRendition},
+		{"described audio", r.DescribedAudio, c.DescribedAudio},
+		{"audio track selection", r.AudioTracks, c.AudioTracks},
+	}
+	for _, feature := range features {
+		if feature.required && !feature.supported {
+			return unsupported(feature.name)
+		}
+	}
+	return nil
+}
+
+// FormatName returns the name of the output format for the given file
+// extension, as listed in Capabilities.OutputFormats.
+func FormatName(extension string) string {
+	switch extension {
+	case "m3u8":
+		return "hls"
+	case "mpd":
+		return "dash"
+	}
+	return extension
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCapabilitiesCheck(t *testing.T) {
+	cap := Capabilities{
+		InputFormats:       []string{"prores", "h264"},
+		OutputFormats:      []string{"mp4", "hls"},
+		Destinations:       []string{"s3"},
+		VideoCodecs:        []string{"h264", "vp8"},
+		AudioCodecs:        []string{"aac"},
+		StreamingProtocols: []string{"hls"},
+		MaxAudioChannels:   2,
+	}
+	var tests = []struct {
+		testCase     string
+		requirements Requirements
+		errMsg       string
+	}{
+		{
+			"no requirements",
+			Requirements{},
+			"",
+		},
+		{
+			"supported features",
+			Requirements{
+				OutputFormats:     []string{"mp4", "hls"},
+				VideoCodecs:       []string{"H264"},
+				AudioCodecs:       []string{"aac"},
+				StreamingProtocol: "hls",
+				AudioChannels:     2,
+			},
+			"",
+		},
+		{
+			"unsupported output format",
+			Requirements{OutputFormats: []string{"mp4", "webm"}},
+			`provider "fake" doesn't support the output format "webm"`,
+		},
+		{
+			"unsupported video codec",
+			Requirements{VideoCodecs: []string{"av1"}},
+			`provider "fake" doesn't support the video codec "av1"`,
+		},
+		{
+			"unsupported audio codec",
+			Requirements{AudioCodecs: []string{"opus"}},
+			`provider "fake" doesn't support the audio codec "opus"`,
+		},
+		{
+			"unsupported streaming protocol",
+			Requirements{StreamingProtocol: "dash"},
+			`provider "fake" doesn't support the streaming protocol "dash"`,
+		},
+		{
+			"unsupported segment format",
+			Requirements{StreamingProtocol: "hls", SegmentFormat: "fmp4"},
+			`provider "fake" doesn't support fmp4 segments`,
+		},
+		{
+			"unsupported DRM scheme",
+			Requirements{DRMScheme: "widevine"},
+			`provider "fake" doesn't support the DRM scheme "widevine"`,
+		},
+		{
+			"too many audio channels",
+			Requirements{AudioChannels: 6},
+			`provider "fake" doesn't support 6 audio channels`,
+		},
+		{
+			"unsupported feature",
+			Requirements{StreamingProtocol: "hls", HDR: true},
+			`provider "fake" doesn't support HDR`,
+		},
+		{
+			"unsupported tone mapping",
+			Requirements{ToneMapping: true},
+			`provider "fake" doesn't support HDR to SDR tone mapping`,
+		},
+		{
+			"unsupported loudness normalization",
+			Requirements{Loudness: true},
+			`provider "fake" doesn't support loudness normalization`,
+		},
+		{
+			"unsupported disabling of autorotation",
+			Requirements{NoAutorotation: true},
+			`provider "fake" doesn't support disabling autorotation without explicit degrees`,
+		},
+		{
+			"unsupported audio track selection",
+			Requirements{AudioTracks: true},
+			`provider "fake" doesn't support audio track selection`,
+		},
+		{
+			"unsupported conform",
+			Requirements{Conform: true},
+			`provider "fake" doesn't support frame-accurate conform`,
+		},
+		{
+			"unsupported trimming",
+			Requirements{Trim: true},
+			`provider "fake" doesn't support black and silence trimming`,
+		},
+		{
+			"unsupported output headers",
+			Requirements{OutputHeaders: true},
+			`provider "fake" doesn't support output headers`,
+		},
+		{
+			"unsupported audio-only rendition",
+			Requirements{StreamingProtocol: "hls", AudioOnlyRendition: true},
+			`provider "fake" doesn't support audio-only HLS renditions`,
+		},
+		{
+			"unsupported caption mode",
+			Requirements{CaptionModes: []string{"burn-in"}},
+			`provider "fake" doesn't support burn-in captions`,
+		},
+	}
+	for _, test := range tests {
+		err := cap.Check("fake", test.requirements)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestFormatName(t *testing.T) {
+	var tests = []struct {
+		extension string
+		expected  string
+	}{
+		{"mp4", "mp4"},
+		{"webm", "webm"},
+		{"m3u8", "hls"},
+		{"mpd", "dash"},
+	}
+	for _, test := range tests {
+		if got := FormatName(test.extension); got != test.expected {
+			t.Errorf("FormatName(%q): want %q. Got %q", test.extension, test.expected, got)
+		}
+	}
+}
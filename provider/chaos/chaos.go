@@ -0,0 +1,289 @@
+// Package chaos provides a simulated provider that runs no transcoding, and
+// injects the failures scripted by the labels of jobs, for testing the
+// handling of provider failures deterministically.
+//
+// It doesn't expose any public type. In order to use the provider, one must
+// import this package and then grab the factory from the provider package:
+//
+//     import (
+//         "github.com/NYTimes/video-transcoding-api/provider"
+//         "github.com/NYTimes/video-transcoding-api/provider/chaos"
+//     )
+//
+//     func UseProvider() {
+//         factory, err := provider.GetProviderFactory(chaos.Name)
+//         // handle err and use factory to get an instance of the provider.
+//     }
+package chaos
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// Name is the name used for registering the chaos provider in the registry
+// of providers.
+const Name = "chaos"
+
+// Labels of jobs that script failures in the chaos provider.
+const (
+	// chaosLabel handles the job as a chaos job, without failures.
+	chaosLabel = "chaos"
+
+	// submitFailuresLabel fails the submission of the job with a 5xx
+	// error, for the given number of submissions of the same source
+	// ("chaos:submit-5xx=2"), or for all submissions.
+	submitFailuresLabel = "chaos:submit-5xx"
+
+	// stuckAtLabel keeps the job started at the given progress
+	// ("chaos:stuck-at=40") forever.
+	stuckAtLabel = "chaos:stuck-at"
+
+	// webhookLossLabel drops the notification of the completion of the
+	// job, so the API only learns about it when polling the provider.
+	webhookLossLabel = "chaos:webhook-loss"
+
+	// partialOutputsLabel finishes the job with only its first output.
+	partialOutputsLabel = "chaos:partial-outputs"
+)
+
+var (
+	errUnavailable = errors.New("503 Service Unavailable")
+	errDisabled    = provider.InvalidConfigError("the chaos provider is disabled. Please define the environment variable CHAOS_PROVIDER_ENABLED or set this value in the configuration file")
+)
+
+func init() {
+	provider.Register(Name, chaosFactory)
+}
+
+// instances holds the chaos provider of each configuration. The jobs that
+// are still running, the pending webhooks and the failed submissions live in
+// the memory of the instance, so the factory returns the same instance for
+// every call with the same configuration.
+var instances = struct {
+	sync.Mutex
+	providers map[*config.Chaos]*chaosProvider
+}{providers: make(map[*config.Chaos]*chaosProvider)}
+
+// chaosJob is a job submitted to the chaos provider.
+type chaosJob struct {
+	submitFailures int
+	stuck          bool
+	stuckAt        float64
+	webhookLoss    bool
+	partialOutputs bool
+	canceled       bool
+	outputs        []string
+	destination    string
+}
+
+// parseChaos returns the chaos scripted by the labels of the job, and
+// whether the job has any chaos label.
+func parseChaos(labels []string) (chaosJob, bool) {
+	var job chaosJob
+	var found bool
+	for _, label := range labels {
+		parts := strings.SplitN(label, "=", 2)
+		switch parts[0] {
+		case chaosLabel:
+		case submitFailuresLabel:
+			job.submitFailures = -1
+			if len(parts) == 2 {
+				job.submitFailures, _ = strconv.Atoi(parts[1])
+			}
+		case stuckAtLabel:
+			job.stuck = true
+			if len(parts) == 2 {
+				job.stuckAt, _ = strconv.ParseFloat(parts[1], 64)
+			}
+		case webhookLossLabel:
+			job.webhookLoss = true
+		case partialOutputsLabel:
+			job.partialOutputs = true
+		default:
+			continue
+		}
+		found = true
+	}
+	return job, found
+}
+
+// chaosProvider is a simulated provider. Jobs that aren't stuck finish right
+// away, and their completion is notified to the API (appended to webhooks)
+// unless the webhook is lost or webhooks are disabled.
+//
+// Jobs are forgotten once their terminal status is reported, and
+// submissions are only counted for sources with pending failures, so the
+// memory of the provider doesn't grow with the number of jobs it runs.
+type chaosProvider struct {
+	notify bool
+
+	mtx         sync.Mutex
+	jobs        map[string]chaosJob
+	submissions map[string]int
+	webhooks    []string
+}
+
+func newChaosProvider(cfg *config.Chaos) *chaosProvider {
+	return &chaosProvider{
+		notify:      cfg.WebhookInterval > 0,
+		jobs:        make(map[string]chaosJob),
+		submissions: make(map[string]int),
+	}
+}
+
+func chaosFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
+	if cfg.Chaos == nil || !cfg.Chaos.Enabled {
+		return nil, errDisabled
+	}
+	instances.Lock()
+	defer instances.Unlock()
+	p, ok := instances.providers[cfg.Chaos]
+	if !ok {
+		p = newChaosProvider(cfg.Chaos)
+		instances.providers[cfg.Chaos] = p
+	}
+	return p, nil
+}
+
+// Transcode simulates the submission of the job, failing it when the labels
+// of the job say so.
+func (p *chaosProvider) Transcode(job *db.Job, transcodeProfile provider.TranscodeProfile) (*provider.JobStatus, error) {
+	for _, output := range transcodeProfile.Outputs {
+		if _, ok := output.Preset.ProviderMapping[Name]; !ok {
+			return nil, provider.ErrPresetMapNotFound
+		}
+	}
+	chaos, _ := parseChaos(job.Labels)
+	source := transcodeProfile.SourceMedia
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if chaos.submitFailures < 0 {
+		return nil, errUnavailable
+	}
+	if chaos.submitFailures > 0 {
+		p.submissions[source]++
+		if p.submissions[source] <= chaos.submitFailures {
+			return nil, errUnavailable
+		}
+		delete(p.submissions, source)
+	}
+	for _, output := range transcodeProfile.Outputs {
+		chaos.outputs = append(chaos.outputs, output.FileName)
+	}
+	chaos.destination = destination(job)
+	id := "chaos-" + job.ID
+	p.jobs[id] = chaos
+	if p.notify && !chaos.stuck && !chaos.webhookLoss {
+		p.webhooks = append(p.webhooks, id)
+	}
+	return &provider.JobStatus{ProviderJobID: id, Status: provider.StatusQueued}, nil
+}
+
+func destination(job *db.Job) string {
+	if job.Destination != "" {
+		return strings.TrimRight(job.Destination, "/")
+	}
+	return "s3://chaos/" + job.ID
+}
+
+// JobStatus returns the status of the job, forgetting the job once its
+// status is terminal. As the status of chaos jobs is scripted by their
+// labels, the status of forgotten jobs is rebuilt from the stored job.
+func (p *chaosProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
+	if job.ProviderJobID != "chaos-"+job.ID {
+		return nil, provider.JobNotFoundError{ID: job.ProviderJobID}
+	}
+	p.mtx.Lock()
+	chaos, ok := p.jobs[job.ProviderJobID]
+	if ok && (!chaos.stuck || chaos.canceled) {
+		delete(p.jobs, job.ProviderJobID)
+	}
+	p.mtx.Unlock()
+	if !ok {
+		chaos, _ = parseChaos(job.Labels)
+		chaos.canceled = job.Status == string(provider.StatusCanceled)
+		for _, output := range job.Outputs {
+			chaos.outputs = append(chaos.outputs, output.FileName)
+		}
+		chaos.destination = destination(job)
+	}
+	if chaos.canceled {
+		return &provider.JobStatus{ProviderJobID: job.ProviderJobID, Status: provider.StatusCanceled}, nil
+	}
+	if chaos.stuck {
+		return &provider.JobStatus{
+			ProviderJobID: job.ProviderJobID,
+			Status:        provider.StatusStarted,
+			Progress:      chaos.stuckAt,
+		}, nil
+	}
+	outputs := chaos.outputs
+	if chaos.partialOutputs && len(outputs) > 1 {
+		outputs = outputs[:1]
+	}
+	status := provider.JobStatus{
+		ProviderJobID: job.ProviderJobID,
+		Status:        provider.StatusFinished,
+		Progress:      100,
+		Output:        provider.JobOutput{Destination: chaos.destination},
+	}
+	for _, output := range outputs {
+		status.Output.Files = append(status.Output.Files, provider.OutputFile{Path: chaos.destination + "/" + output, Container: "mp4"})
+	}
+	return &status, nil
+}
+
+// TakeWebhooks returns the provider job ids of the jobs whose completion
+// wasn't notified yet.
+func (p *chaosProvider) TakeWebhooks() []string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	webhooks := p.webhooks
+	p.webhooks = nil
+	return webhooks
+}
+
+func (p *chaosProvider) CancelJob(id string) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	chaos, ok := p.jobs[id]
+	if !ok {
+		return provider.JobNotFoundError{ID: id}
+	}
+	chaos.canceled = true
+	p.jobs[id] = chaos
+	return nil
+}
+
+func (p *chaosProvider) CreatePreset(preset db.Preset) (string, error) {
+	return preset.Name, nil
+}
+
+func (p *chaosProvider) DeletePreset(presetID string) error {
+	return nil
+}
+
+func (p *chaosProvider) GetPreset(presetID string) (interface{}, error) {
+	return map[string]string{"presetId": presetID}, nil
+}
+
+func (p *chaosProvider) Healthcheck() error {
+	return nil
+}
+
+func (p *chaosProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		InputFormats:  []string{"prores", "h264"},
+		OutputFormats: []string{"mp4"},
+		Destinations:  []string{"s3"},
+		VideoCodecs:   []string{"h264"},
+		AudioCodecs:   []string{"aac"},
+	}
+}
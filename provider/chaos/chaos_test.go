@@ -0,0 +1,167 @@
+package chaos
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func TestFactoryIsRegistered(t *testing.T) {
+	_, err := provider.GetProviderFactory(Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChaosFactory(t *testing.T) {
+	if _, err := chaosFactory(&config.Config{}); err != errDisabled {
+		t.Errorf("wrong error returned for a missing configuration. Want %q. Got %v", errDisabled, err)
+	}
+	if _, err := chaosFactory(&config.Config{Chaos: &config.Chaos{}}); err != errDisabled {
+		t.Errorf("wrong error returned for a disabled provider. Want %q. Got %v", errDisabled, err)
+	}
+	cfg := &config.Config{Chaos: &config.Chaos{Enabled: true}}
+	first, err := chaosFactory(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := chaosFactory(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("expected the same instance for the same configuration")
+	}
+	other, err := chaosFactory(&config.Config{Chaos: &config.Chaos{Enabled: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == first {
+		t.Error("expected a new instance for a different configuration")
+	}
+}
+
+func TestChaosSubmitFailures(t *testing.T) {
+	p := newChaosProvider(&config.Chaos{Enabled: true})
+	profile := provider.TranscodeProfile{SourceMedia: "http://another.non.existent/video.mp4"}
+	job := db.Job{ID: "job-123", Labels: []string{"chaos:submit-5xx=2"}}
+	var errs []error
+	for i := 0; i < 3; i++ {
+		_, err := p.Transcode(&job, profile)
+		errs = append(errs, err)
+	}
+	if want := []error{errUnavailable, errUnavailable, nil}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("wrong errors returned. Want %v. Got %v", want, errs)
+	}
+	if len(p.submissions) > 0 {
+		t.Errorf("unexpected submissions kept after the failures: %v", p.submissions)
+	}
+	job.Labels = []string{"chaos:submit-5xx"}
+	if _, err := p.Transcode(&job, profile); err != errUnavailable {
+		t.Errorf("wrong error returned. Want %q. Got %v", errUnavailable, err)
+	}
+	if len(p.submissions) > 0 {
+		t.Errorf("unexpected submissions counted for failing submissions: %v", p.submissions)
+	}
+}
+
+func TestChaosJobStatusForgetsFinishedJobs(t *testing.T) {
+	p := newChaosProvider(&config.Chaos{Enabled: true, WebhookInterval: time.Second})
+	job := db.Job{
+		ID:          "job-123",
+		Labels:      []string{"chaos:partial-outputs"},
+		Destination: "s3://mybucket/outputs/",
+		Outputs:     []db.TranscodeOutput{{Preset: "mp4_720p", FileName: "video_720p.mp4"}, {Preset: "mp4_1080p", FileName: "video_1080p.mp4"}},
+	}
+	submitted, err := p.Transcode(&job, provider.TranscodeProfile{
+		SourceMedia: "http://another.non.existent/video.mp4",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video_720p.mp4", Preset: db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}}},
+			{FileName: "video_1080p.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4_1080p"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.ProviderJobID = submitted.ProviderJobID
+	if webhooks := p.TakeWebhooks(); !reflect.DeepEqual(webhooks, []string{"chaos-job-123"}) {
+		t.Errorf("wrong webhooks. Want [chaos-job-123]. Got %v", webhooks)
+	}
+	want := provider.JobStatus{
+		ProviderJobID: "chaos-job-123",
+		Status:        provider.StatusFinished,
+		Progress:      100,
+		Output: provider.JobOutput{
+			Destination: "s3://mybucket/outputs",
+			Files:       []provider.OutputFile{{Path: "s3://mybucket/outputs/video_720p.mp4", Container: "mp4"}},
+		},
+	}
+	status, err := p.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*status, want) {
+		t.Errorf("wrong status.\nWant %#v\nGot  %#v", want, *status)
+	}
+	if len(p.jobs) > 0 {
+		t.Errorf("unexpected jobs kept after finishing: %v", p.jobs)
+	}
+	job.Status = string(provider.StatusFinished)
+	status, err = p.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*status, want) {
+		t.Errorf("wrong status of the forgotten job.\nWant %#v\nGot  %#v", want, *status)
+	}
+	job.ProviderJobID = "chaos-job-456"
+	if _, err = p.JobStatus(&job); err == nil {
+		t.Error("expected a JobNotFoundError for jobs of other ids")
+	}
+}
+
+func TestChaosWebhooksDisabled(t *testing.T) {
+	p := newChaosProvider(&config.Chaos{Enabled: true})
+	job := db.Job{ID: "job-123", Labels: []string{"chaos"}}
+	if _, err := p.Transcode(&job, provider.TranscodeProfile{SourceMedia: "http://another.non.existent/video.mp4"}); err != nil {
+		t.Fatal(err)
+	}
+	if webhooks := p.TakeWebhooks(); len(webhooks) > 0 {
+		t.Errorf("unexpected webhooks with webhooks disabled: %v", webhooks)
+	}
+}
+
+func TestChaosCancelJob(t *testing.T) {
+	p := newChaosProvider(&config.Chaos{Enabled: true})
+	job := db.Job{ID: "job-123", Labels: []string{"chaos:stuck-at=10"}}
+	status, err := p.Transcode(&job, provider.TranscodeProfile{SourceMedia: "http://another.non.existent/video.mp4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.ProviderJobID = status.ProviderJobID
+	if status, err = p.JobStatus(&job); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != provider.StatusStarted || status.Progress != 10 {
+		t.Errorf("wrong status of the stuck job. Want started (10%%). Got %s (%.0f%%)", status.Status, status.Progress)
+	}
+	if err = p.CancelJob(job.ProviderJobID); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = p.JobStatus(&job); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != provider.StatusCanceled {
+		t.Errorf("wrong status. Want %q. Got %q", provider.StatusCanceled, status.Status)
+	}
+	if len(p.jobs) > 0 {
+		t.Errorf("unexpected jobs kept after the cancellation: %v", p.jobs)
+	}
+	if _, ok := p.CancelJob("chaos-job-456").(provider.JobNotFoundError); !ok {
+		t.Error("expected a JobNotFoundError for unknown jobs")
+	}
+}
@@ -5,19 +5,11 @@ package provider
 // It contains the name of the provider, along with its current heath status
 // and its capabilities.
 type Description struct {
-	Name         string       `json:"name"`
-	Capabilities Capabilities `json:"capabilities"`
-	Health       Health       `json:"health"`
-	Enabled      bool         `json:"enabled"`
-}
-
-// Capabilities describes the available features in the provider. It specificie
-// which input and output formats the provider supports, along with
-// supported destinations.
-type Capabilities struct {
-	InputFormats  []string `json:"input"`
-	OutputFormats []string `json:"output"`
-	Destinations  []string `json:"destinations"`
+	Name         string        `json:"name"`
+	Capabilities Capabilities  `json:"capabilities"`
+	Health       Health        `json:"health"`
+	Enabled      bool          `json:"enabled"`
+	Usage        *AccountUsage `json:"usage,omitempty"`
 }
 
 // Health describes the current health status of the provider. If indicates
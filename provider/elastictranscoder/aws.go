@@ -16,6 +16,9 @@
 package elastictranscoder
 
 import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -41,11 +44,24 @@ const (
 
 	defaultAWSRegion = "us-east-1"
 	hlsPlayList      = "HLSv3"
+
+	// hlsv4PlayList is the format of playlists referencing fragmented MP4
+	// segments.
+	hlsv4PlayList = "HLSv4"
+
+	// thumbnailPattern is the pattern of the names of thumbnails, relative
+	// to the directory of the job.
+	thumbnailPattern = "thumbnails/thumb-{count}"
+
+	// watermarkID identifies the watermark of presets created by the API.
+	watermarkID = "watermark"
 )
 
 var (
-	errAWSInvalidConfig = errors.New("invalid Elastic Transcoder config. Please define the configuration entries in the config file or environment variables")
-	s3Pattern           = regexp.MustCompile(`^s3://`)
+	errAWSInvalidConfig   = errors.New("invalid Elastic Transcoder config. Please define the configuration entries in the config file or environment variables")
+	errDRMWithoutPlaylist = errors.New("DRM requires adaptive streaming outputs")
+	errThumbnailCount     = errors.New("elastic transcoder generates thumbnails in intervals, not in a given number")
+	s3Pattern             = regexp.MustCompile(`^s3://`)
 )
 
 func init() {
@@ -64,6 +80,21 @@ func (p *awsProvider) Transcode(job *db.Job, transcodeProfile provider.Transcode
 		PipelineId: aws.String(p.config.PipelineID),
 		Input:      &elastictranscoder.JobInput{Key: aws.String(source)},
 	}
+	if clip := transcodeProfile.Clip; clip != nil {
+		params.Input.TimeSpan = &elastictranscoder.TimeSpan{StartTime: aws.String(strconv.FormatFloat(clip.Start, 'f', 3, 64))}
+		if clip.Duration > 0 {
+			params.Input.TimeSpan.Duration = aws.String(strconv.FormatFloat(clip.Duration, 'f', 3, 64))
+		}
+	}
+	alignment := transcodeProfile.StreamingParams.KeyframeAlignment
+	if err := alignment.RequireSceneCutDisabled(Name); err != nil {
+		return nil, err
+	}
+	segmentContainer, playlistFormat := "ts", hlsPlayList
+	if transcodeProfile.StreamingParams.SegmentFormat == provider.SegmentFormatFMP4 {
+		segmentContainer, playlistFormat = "fmp4", hlsv4PlayList
+	}
+	gops := make([]provider.RenditionGOP, 0, len(transcodeProfile.Outputs))
 	params.Outputs = make([]*elastictranscoder.CreateJobOutput, len(transcodeProfile.Outputs))
 	for i, output := range transcodeProfile.Outputs {
 		presetID, ok := output.Preset.ProviderMapping[Name]
@@ -80,25 +111,63 @@ func (p *awsProvider) Transcode(job *db.Job, transcodeProfile provider.Transcode
 		if presetOutput.Preset == nil || presetOutput.Preset.Container == nil {
 			return nil, fmt.Errorf("misconfigured preset: %s", presetID)
 		}
+		if video := presetOutput.Preset.Video; video != nil {
+			gop := provider.RenditionGOP{
+				Name:  output.Preset.Name,
+				Size:  aws.StringValue(video.KeyframesMaxDist),
+				Fixed: aws.StringValue(video.FixedGOP) == "true",
+			}
+			frames, _ := strconv.ParseFloat(gop.Size, 64)
+			if frameRate, _ := strconv.ParseFloat(aws.StringValue(video.FrameRate), 64); frames > 0 && frameRate > 0 {
+				gop.Duration = frames / frameRate
+			}
+			gops = append(gops, gop)
+		}
 		var isAdaptiveStreamingPreset bool
-		if *presetOutput.Preset.Container == "ts" {
+		switch container := *presetOutput.Preset.Container; {
+		case container == segmentContainer:
 			isAdaptiveStreamingPreset = true
 			adaptiveStreamingOutputs = append(adaptiveStreamingOutputs, output)
+		case container == "ts" || container == "fmp4":
+			return nil, fmt.Errorf("preset %s generates %s segments, but the job requires %s segments", presetID, container, segmentContainer)
 		}
 		params.Outputs[i] = &elastictranscoder.CreateJobOutput{
 			PresetId: aws.String(presetID),
 			Key:      p.outputKey(job, output.FileName, isAdaptiveStreamingPreset),
 		}
+		if !job.Rotation.Auto() {
+			params.Outputs[i].Rotate = aws.String(strconv.Itoa(job.Rotation.Degrees))
+		}
+		if thumbnails := transcodeProfile.Thumbnails; thumbnails != nil && i == 0 {
+			if err = checkThumbnails(presetID, presetOutput.Preset.Thumbnails, thumbnails); err != nil {
+				return nil, err
+			}
+			params.Outputs[i].ThumbnailPattern = aws.String(job.ID + "/" + thumbnailPattern)
+		}
+		if video := presetOutput.Preset.Video; video != nil && len(video.Watermarks) > 0 {
+			if output.Preset.OutputOpts.Watermark == "" {
+				return nil, fmt.Errorf("preset %s has a watermark, but the presetmap %q has no watermark image", presetID, output.Preset.Name)
+			}
+			for _, watermark := range video.Watermarks {
+				params.Outputs[i].Watermarks = append(params.Outputs[i].Watermarks, &elastictranscoder.JobWatermark{
+					PresetWatermarkId: watermark.Id,
+					InputKey:          aws.String(p.normalizeSource(output.Preset.OutputOpts.Watermark)),
+				})
+			}
+		}
 		if isAdaptiveStreamingPreset {
 			params.Outputs[i].SegmentDuration = aws.String(strconv.Itoa(int(transcodeProfile.StreamingParams.SegmentDuration)))
 		}
 	}
 
+	if err := alignment.Validate(gops, transcodeProfile.StreamingParams.SegmentDuration); err != nil {
+		return nil, err
+	}
 	if len(adaptiveStreamingOutputs) > 0 {
 		playlistFileName := transcodeProfile.StreamingParams.PlaylistFileName
 		playlistFileName = strings.TrimRight(playlistFileName, filepath.Ext(playlistFileName))
 		jobPlaylist := elastictranscoder.CreateJobPlaylist{
-			Format: aws.String(hlsPlayList),
+			Format: aws.String(playlistFormat),
 			Name:   aws.String(job.ID + "/" + playlistFileName),
 		}
 
@@ -107,7 +176,14 @@ func (p *awsProvider) Transcode(job *db.Job, transcodeProfile provider.Transcode
 			jobPlaylist.OutputKeys[i] = p.outputKey(job, output.FileName, true)
 		}
 
+		if drm := transcodeProfile.DRM; drm != nil {
+			if err := protectPlaylist(&jobPlaylist, drm); err != nil {
+				return nil, err
+			}
+		}
 		params.Playlists = []*elastictranscoder.CreateJobPlaylist{&jobPlaylist}
+	} else if transcodeProfile.DRM != nil {
+		return nil, errDRMWithoutPlaylist
 	}
 	resp, err := p.c.CreateJob(&params)
 	if err != nil {
@@ -120,6 +196,47 @@ func (p *awsProvider) Transcode(job *db.Job, transcodeProfile provider.Transcode
 	}, nil
 }
 
+// checkThumbnails returns an error when the thumbnails generated by the
+// preset don't match the ones required by the job, as Elastic Transcoder
+// takes the settings of thumbnails from the preset.
+func checkThumbnails(presetID string, presetThumbs *elastictranscoder.Thumbnails, thumbnails *provider.Thumbnails) error {
+	if thumbnails.Count > 0 {
+		return errThumbnailCount
+	}
+	if presetThumbs == nil {
+		return fmt.Errorf("preset %s doesn't generate thumbnails", presetID)
+	}
+	if format := aws.StringValue(presetThumbs.Format); thumbnails.Format != "" && format != thumbnails.Format {
+		return fmt.Errorf("preset %s generates %s thumbnails, but the job requires %s thumbnails", presetID, format, thumbnails.Format)
+	}
+	if interval := aws.StringValue(presetThumbs.Interval); interval != strconv.Itoa(int(thumbnails.Interval)) {
+		return fmt.Errorf("preset %s generates thumbnails every %s seconds, but the job requires %d seconds", presetID, interval, thumbnails.Interval)
+	}
+	width, height := aws.StringValue(presetThumbs.MaxWidth), aws.StringValue(presetThumbs.MaxHeight)
+	if (thumbnails.Width > 0 && width != strconv.Itoa(int(thumbnails.Width))) || (thumbnails.Height > 0 && height != strconv.Itoa(int(thumbnails.Height))) {
+		return fmt.Errorf("preset %s generates thumbnails of up to %sx%s, but the job requires %dx%d", presetID, width, height, thumbnails.Width, thumbnails.Height)
+	}
+	return nil
+}
+
+// thumbnailFiles returns the thumbnails generated for the given output.
+// Elastic Transcoder doesn't list them, so their number is derived from the
+// duration of the output and the interval of the thumbnails.
+func thumbnailFiles(prefix string, output *elastictranscoder.JobOutput, thumbs *elastictranscoder.Thumbnails) []provider.OutputFile {
+	interval, _ := strconv.ParseInt(aws.StringValue(thumbs.Interval), 10, 64)
+	count := int64(1)
+	if interval > 0 && aws.Int64Value(output.Duration) > interval {
+		count = aws.Int64Value(output.Duration) / interval
+	}
+	format := aws.StringValue(thumbs.Format)
+	files := make([]provider.OutputFile, count)
+	for i := range files {
+		name := strings.Replace(aws.StringValue(output.ThumbnailPattern), "{count}", fmt.Sprintf("%05d", i+1), 1)
+		files[i] = provider.OutputFile{Path: prefix + name + "." + format, Container: format}
+	}
+	return files
+}
+
 func (p *awsProvider) normalizeSource(source string) string {
 	if s3Pattern.MatchString(source) {
 		source = strings.Replace(source, "s3://", "", 1)
@@ -177,9 +294,55 @@ func (p *awsProvider) createVideoPreset(preset db.Preset) *elastictranscoder.Vid
 	if preset.Video.GopMode == "fixed" {
 		videoPreset.FixedGOP = aws.String("true")
 	}
+	if preset.Watermark != nil {
+		videoPreset.Watermarks = []*elastictranscoder.PresetWatermark{p.createWatermarkPreset(*preset.Watermark)}
+	}
 	return &videoPreset
 }
 
+// createWatermarkPreset returns the placement of the given watermark. The
+// image itself is given in the jobs, taken from the presetmap.
+func (p *awsProvider) createWatermarkPreset(watermark db.Watermark) *elastictranscoder.PresetWatermark {
+	offset := watermark.Offset
+	if offset == "" {
+		offset = "0"
+	}
+	watermarkPreset := elastictranscoder.PresetWatermark{
+		Id:               aws.String(watermarkID),
+		HorizontalAlign:  aws.String("Right"),
+		VerticalAlign:    aws.String("Bottom"),
+		HorizontalOffset: aws.String(watermarkSize(offset)),
+		VerticalOffset:   aws.String(watermarkSize(offset)),
+		SizingPolicy:     aws.String("ShrinkToFit"),
+		Target:           aws.String("Content"),
+	}
+	if strings.HasPrefix(watermark.Position, "top-") {
+		watermarkPreset.VerticalAlign = aws.String("Top")
+	}
+	if strings.HasSuffix(watermark.Position, "-left") {
+		watermarkPreset.HorizontalAlign = aws.String("Left")
+	}
+	if watermark.Width != "" {
+		watermarkPreset.MaxWidth = aws.String(watermarkSize(watermark.Width))
+	}
+	if watermark.Height != "" {
+		watermarkPreset.MaxHeight = aws.String(watermarkSize(watermark.Height))
+	}
+	if watermark.Opacity != "" {
+		watermarkPreset.Opacity = aws.String(watermark.Opacity)
+	}
+	return &watermarkPreset
+}
+
+// watermarkSize returns the given size in the format of Elastic
+// Transcoder, which requires the unit of sizes in pixels.
+func watermarkSize(size string) string {
+	if strings.HasSuffix(size, "%") {
+		return size
+	}
+	return size + "px"
+}
+
 func (p *awsProvider) createThumbsPreset(preset db.Preset) *elastictranscoder.Thumbnails {
 	thumbsPreset := &elastictranscoder.Thumbnails{
 		PaddingPolicy: aws.String("Pad"),
@@ -213,24 +376,67 @@ func (p *awsProvider) createAudioPreset(preset db.Preset) *elastictranscoder.Aud
 	return audioPreset
 }
 
+// protectPlaylist encrypts the outputs of the given playlist with the DRM
+// of the job. The key isn't stored by Elastic Transcoder, so it must be
+// served by the license URL.
+func protectPlaylist(playlist *elastictranscoder.CreateJobPlaylist, drm *provider.DRMParams) error {
+	keyMD5 := md5.Sum(drm.Key)
+	key := aws.String(base64.StdEncoding.EncodeToString(drm.Key))
+	switch drm.Scheme {
+	case provider.DRMSchemeAES128:
+		playlist.HlsContentProtection = &elastictranscoder.HlsContentProtection{
+			Method:                aws.String("aes-128"),
+			Key:                   key,
+			KeyMd5:                aws.String(base64.StdEncoding.EncodeToString(keyMD5[:])),
+			LicenseAcquisitionUrl: aws.String(drm.LicenseURL),
+			KeyStoragePolicy:      aws.String("NoStore"),
+		}
+		if len(drm.IV) > 0 {
+			playlist.HlsContentProtection.InitializationVector = aws.String(base64.StdEncoding.EncodeToString(drm.IV))
+		}
+	case provider.DRMSchemePlayReady:
+		playlist.PlayReadyDrm = &elastictranscoder.PlayReadyDrm{
+			Format:                aws.String("discretix-3.0"),
+			Key:                   key,
+			KeyMd5:                aws.String(base64.StdEncoding.EncodeToString(keyMD5[:])),
+			KeyId:                 aws.String(hex.EncodeToString(drm.KeyID)),
+			LicenseAcquisitionUrl: aws.String(drm.LicenseURL),
+		}
+	default:
+		return fmt.Errorf("unsupported DRM scheme %q", drm.Scheme)
+	}
+	return nil
+}
+
 func (p *awsProvider) CreatePreset(preset db.Preset) (string, error) {
+	presetOutput, err := p.c.CreatePreset(p.createPresetInput(preset))
+	if err != nil {
+		return "", err
+	}
+	return *presetOutput.Preset.Id, nil
+}
+
+// createPresetInput translates the given preset into an Elastic Transcoder
+// preset.
+func (p *awsProvider) createPresetInput(preset db.Preset) *elastictranscoder.CreatePresetInput {
 	presetInput := elastictranscoder.CreatePresetInput{
 		Name:        &preset.Name,
 		Description: &preset.Description,
 	}
-	if preset.Container == "m3u8" {
+	switch preset.Container {
+	case "m3u8":
 		presetInput.Container = aws.String("ts")
-	} else {
+	case "m4a":
+		presetInput.Container = aws.String("mp4")
+	default:
 		presetInput.Container = &preset.Container
 	}
-	presetInput.Video = p.createVideoPreset(preset)
 	presetInput.Audio = p.createAudioPreset(preset)
-	presetInput.Thumbnails = p.createThumbsPreset(preset)
-	presetOutput, err := p.c.CreatePreset(&presetInput)
-	if err != nil {
-		return "", err
+	if !preset.AudioOnly {
+		presetInput.Video = p.createVideoPreset(preset)
+		presetInput.Thumbnails = p.createThumbsPreset(preset)
 	}
-	return *presetOutput.Preset.Id, nil
+	return &presetInput
 }
 
 func (p *awsProvider) GetPreset(presetID string) (interface{}, error) {
@@ -331,16 +537,22 @@ func (p *awsProvider) getOutputFiles(job *elastictranscoder.Job) ([]provider.Out
 			aws.StringValue(job.OutputKeyPrefix),
 			aws.StringValue(output.Key),
 		)
+		if aws.StringValue(output.ThumbnailPattern) != "" && preset.Preset.Thumbnails != nil {
+			prefix := fmt.Sprintf("s3://%s/%s", aws.StringValue(pipeline.Pipeline.OutputBucket), aws.StringValue(job.OutputKeyPrefix))
+			files = append(files, thumbnailFiles(prefix, output, preset.Preset.Thumbnails)...)
+		}
 		container := aws.StringValue(preset.Preset.Container)
-		if container == "ts" {
+		if container == "ts" || container == "fmp4" {
 			continue
 		}
 		file := provider.OutputFile{
-			Path:       filePath,
-			Container:  container,
-			VideoCodec: aws.StringValue(preset.Preset.Video.Codec),
-			Width:      aws.Int64Value(output.Width),
-			Height:     aws.Int64Value(output.Height),
+			Path:      filePath,
+			Container: container,
+			Width:     aws.Int64Value(output.Width),
+			Height:    aws.Int64Value(output.Height),
+		}
+		if preset.Preset.Video != nil {
+			file.VideoCodec = aws.StringValue(preset.Preset.Video.Codec)
 		}
 		files = append(files, file)
 	}
@@ -375,6 +587,33 @@ func (p *awsProvider) CancelJob(id string) error {
 	return err
 }
 
+// ActiveJobs returns the jobs that are submitted or progressing in the
+// pipeline of the provider.
+func (p *awsProvider) ActiveJobs() ([]provider.ActiveJob, error) {
+	var jobs []provider.ActiveJob
+	input := elastictranscoder.ListJobsByPipelineInput{PipelineId: aws.String(p.config.PipelineID)}
+	for {
+		resp, err := p.c.ListJobsByPipeline(&input)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range resp.Jobs {
+			switch aws.StringValue(job.Status) {
+			case "Submitted", "Progressing":
+				activeJob := provider.ActiveJob{ID: aws.StringValue(job.Id)}
+				if job.Timing != nil && job.Timing.SubmitTimeMillis != nil {
+					activeJob.CreationTime = time.Unix(0, aws.Int64Value(job.Timing.SubmitTimeMillis)*int64(time.Millisecond)).UTC()
+				}
+				jobs = append(jobs, activeJob)
+			}
+		}
+		if aws.StringValue(resp.NextPageToken) == "" {
+			return jobs, nil
+		}
+		input.PageToken = resp.NextPageToken
+	}
+}
+
 func (p *awsProvider) Healthcheck() error {
 	_, err := p.c.ReadPipeline(&elastictranscoder.ReadPipelineInput{
 		Id: aws.String(p.config.PipelineID),
@@ -384,9 +623,23 @@ func (p *awsProvider) Healthcheck() error {
 
 func (p *awsProvider) Capabilities() provider.Capabilities {
 	return provider.Capabilities{
-		InputFormats:  []string{"h264"},
-		OutputFormats: []string{"mp4", "hls", "webm"},
-		Destinations:  []string{"s3"},
+		InputFormats:       []string{"h264"},
+		OutputFormats:      []string{"mp4", "hls", "webm", "mp3", "m4a", "ogg"},
+		Destinations:       []string{"s3"},
+		VideoCodecs:        []string{"h264", "vp8", "vp9"},
+		AudioCodecs:        []string{"aac", "flac", "mp3", "pcm", "vorbis"},
+		StreamingProtocols: []string{"hls"},
+		SegmentFormats:     []string{"fmp4"},
+		DRMSchemes:         []string{"aes-128", "playready"},
+		MaxAudioChannels:   2,
+		Thumbnails:         true,
+		Clipping:           true,
+		Trim:               true,
+		AudioOnly:          true,
+		Watermark:          true,
+		Rotation:           true,
+		NoAutorotation:     true,
+		Progress:           true,
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -18,6 +19,7 @@ type failure struct {
 type fakeElasticTranscoder struct {
 	*elastictranscoder.ElasticTranscoder
 	jobs         map[string]*elastictranscoder.CreateJobInput
+	presets      map[string]*elastictranscoder.CreatePresetInput
 	canceledJobs []elastictranscoder.CancelJobInput
 	failures     chan failure
 }
@@ -27,6 +29,7 @@ func newFakeElasticTranscoder() *fakeElasticTranscoder {
 		ElasticTranscoder: &elastictranscoder.ElasticTranscoder{},
 		failures:          make(chan failure, 1),
 		jobs:              make(map[string]*elastictranscoder.CreateJobInput),
+		presets:           make(map[string]*elastictranscoder.CreatePresetInput),
 	}
 }
 
@@ -54,6 +57,7 @@ func (c *fakeElasticTranscoder) CreateJob(input *elastictranscoder.CreateJobInpu
 
 func (c *fakeElasticTranscoder) CreatePreset(input *elastictranscoder.CreatePresetInput) (*elastictranscoder.CreatePresetOutput, error) {
 	var presetID = *input.Name + "-abc123"
+	c.presets[presetID] = input
 	return &elastictranscoder.CreatePresetOutput{
 		Preset: &elastictranscoder.Preset{
 			Audio:       input.Audio,
@@ -73,16 +77,33 @@ func (c *fakeElasticTranscoder) ReadPreset(input *elastictranscoder.ReadPresetIn
 	if strings.Contains(*input.Id, "hls") {
 		container = "ts"
 	}
+	if strings.Contains(*input.Id, "fmp4") {
+		container = "fmp4"
+	}
 	if strings.Contains(*input.Id, "webm") {
 		container = "webm"
 		codec = "VP8"
 	}
+	var thumbnails *elastictranscoder.Thumbnails
+	if strings.Contains(*input.Id, "thumbs") {
+		thumbnails = &elastictranscoder.Thumbnails{
+			Format:    aws.String("png"),
+			Interval:  aws.String("10"),
+			MaxWidth:  aws.String("320"),
+			MaxHeight: aws.String("auto"),
+		}
+	}
+	video := &elastictranscoder.VideoParameters{Codec: aws.String(codec)}
+	if strings.Contains(*input.Id, "watermark") {
+		video.Watermarks = []*elastictranscoder.PresetWatermark{{Id: aws.String(watermarkID)}}
+	}
 	return &elastictranscoder.ReadPresetOutput{
 		Preset: &elastictranscoder.Preset{
-			Id:        input.Id,
-			Name:      input.Id,
-			Container: aws.String(container),
-			Video:     &elastictranscoder.VideoParameters{Codec: aws.String(codec)},
+			Id:         input.Id,
+			Name:       input.Id,
+			Container:  aws.String(container),
+			Video:      video,
+			Thumbnails: thumbnails,
 		},
 	}, nil
 }
@@ -98,12 +119,14 @@ func (c *fakeElasticTranscoder) ReadJob(input *elastictranscoder.ReadJobInput) (
 	outputs := make([]*elastictranscoder.JobOutput, len(createJobInput.Outputs))
 	for i, createJobOutput := range createJobInput.Outputs {
 		outputs[i] = &elastictranscoder.JobOutput{
-			Key:          createJobOutput.Key,
-			Status:       aws.String("Complete"),
-			StatusDetail: aws.String("it's finished!"),
-			PresetId:     aws.String(fmt.Sprintf("preset-%s", aws.StringValue(createJobOutput.Key))),
-			Width:        aws.Int64(0),
-			Height:       aws.Int64(720),
+			Key:              createJobOutput.Key,
+			Status:           aws.String("Complete"),
+			StatusDetail:     aws.String("it's finished!"),
+			PresetId:         aws.String(fmt.Sprintf("preset-%s", aws.StringValue(createJobOutput.Key))),
+			Width:            aws.Int64(0),
+			Height:           aws.Int64(720),
+			Duration:         aws.Int64(35),
+			ThumbnailPattern: createJobOutput.ThumbnailPattern,
 		}
 	}
 	playlists := make([]*elastictranscoder.Playlist, len(createJobInput.Playlists))
@@ -147,6 +170,27 @@ func (c *fakeElasticTranscoder) CancelJob(input *elastictranscoder.CancelJobInpu
 	return &elastictranscoder.CancelJobOutput{}, nil
 }
 
+func (c *fakeElasticTranscoder) ListJobsByPipeline(input *elastictranscoder.ListJobsByPipelineInput) (*elastictranscoder.ListJobsByPipelineOutput, error) {
+	if err := c.getError("ListJobsByPipeline"); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(c.jobs))
+	for id := range c.jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	jobs := make([]*elastictranscoder.Job, len(ids))
+	for i, id := range ids {
+		jobs[i] = &elastictranscoder.Job{
+			Id:         aws.String(id),
+			PipelineId: input.PipelineId,
+			Status:     aws.String("Progressing"),
+			Timing:     &elastictranscoder.Timing{SubmitTimeMillis: aws.Int64(1514862245000)},
+		}
+	}
+	return &elastictranscoder.ListJobsByPipelineOutput{Jobs: jobs}, nil
+}
+
 func (c *fakeElasticTranscoder) prepareFailure(op string, err error) {
 	c.failures <- failure{op: op, err: err}
 }
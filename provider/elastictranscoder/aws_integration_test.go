@@ -0,0 +1,13 @@
+// +build integration
+
+package elastictranscoder
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/provider/providertest"
+)
+
+func TestElasticTranscoderIntegration(t *testing.T) {
+	providertest.Run(t, Name)
+}
@@ -528,6 +528,345 @@ func TestAWSTranscodeNormalizedSource(t *testing.T) {
 	}
 }
 
+func TestAWSTranscodeClip(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		clip     provider.Clip
+		expected elastictranscoder.TimeSpan
+	}{
+		{
+			"leading padding",
+			provider.Clip{Start: 2.04},
+			elastictranscoder.TimeSpan{StartTime: aws.String("2.040")},
+		},
+		{
+			"leading and trailing padding",
+			provider.Clip{Start: 1.92, Duration: 58.08},
+			elastictranscoder.TimeSpan{StartTime: aws.String("1.920"), Duration: aws.String("58.080")},
+		},
+	}
+	for _, test := range tests {
+		fakeTranscoder := newFakeElasticTranscoder()
+		prov := &awsProvider{
+			c:      fakeTranscoder,
+			config: &config.ElasticTranscoder{PipelineID: "mypipeline"},
+		}
+		clip := test.clip
+		jobStatus, err := prov.Transcode(&db.Job{ID: "job-1"}, provider.TranscodeProfile{
+			SourceMedia: "dir/file.mov",
+			Outputs: []provider.TranscodeOutput{
+				{
+					FileName: "output_720p.mp4",
+					Preset: db.PresetMap{
+						Name:            "mp4_720p",
+						ProviderMapping: map[string]string{Name: "93239832-0001"},
+						OutputOpts:      db.OutputOptions{Extension: "mp4"},
+					},
+				},
+			},
+			Clip: &clip,
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		timeSpan := fakeTranscoder.jobs[jobStatus.ProviderJobID].Input.TimeSpan
+		if timeSpan == nil || !reflect.DeepEqual(*timeSpan, test.expected) {
+			t.Errorf("%s: wrong time span\nWant %#v\nGot  %#v", test.testCase, test.expected, timeSpan)
+		}
+	}
+}
+
+func TestAWSTranscodeRotation(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		rotation *db.Rotation
+		expected *string
+	}{
+		{"default autorotation", nil, nil},
+		{"explicit autorotation", &db.Rotation{Autorotate: true}, nil},
+		{"autorotation disabled", &db.Rotation{}, aws.String("0")},
+		{"explicit degrees", &db.Rotation{Degrees: 90}, aws.String("90")},
+	}
+	for _, test := range tests {
+		fakeTranscoder := newFakeElasticTranscoder()
+		prov := &awsProvider{
+			c:      fakeTranscoder,
+			config: &config.ElasticTranscoder{PipelineID: "mypipeline"},
+		}
+		jobStatus, err := prov.Transcode(&db.Job{ID: "job-1", Rotation: test.rotation}, provider.TranscodeProfile{
+			SourceMedia: "dir/file.mov",
+			Outputs: []provider.TranscodeOutput{
+				{
+					FileName: "output_720p.mp4",
+					Preset: db.PresetMap{
+						Name:            "mp4_720p",
+						ProviderMapping: map[string]string{Name: "93239832-0001"},
+						OutputOpts:      db.OutputOptions{Extension: "mp4"},
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		rotate := fakeTranscoder.jobs[jobStatus.ProviderJobID].Outputs[0].Rotate
+		if !reflect.DeepEqual(rotate, test.expected) {
+			t.Errorf("%s: wrong rotate\nWant %#v\nGot  %#v", test.testCase, test.expected, rotate)
+		}
+	}
+}
+
+func TestAWSTranscodeSegmentFormat(t *testing.T) {
+	var tests = []struct {
+		testCase       string
+		presetID       string
+		segmentFormat  provider.SegmentFormat
+		expectedFormat string
+		expectedErr    string
+	}{
+		{"mpeg-ts segments", "93239832-0001-hls", "", "HLSv3", ""},
+		{"fmp4 segments", "93239832-0001-fmp4", provider.SegmentFormatFMP4, "HLSv4", ""},
+		{
+			"mpeg-ts preset in fmp4 job",
+			"93239832-0001-hls",
+			provider.SegmentFormatFMP4,
+			"",
+			"preset 93239832-0001-hls generates ts segments, but the job requires fmp4 segments",
+		},
+		{
+			"fmp4 preset in mpeg-ts job",
+			"93239832-0001-fmp4",
+			provider.SegmentFormatTS,
+			"",
+			"preset 93239832-0001-fmp4 generates fmp4 segments, but the job requires ts segments",
+		},
+	}
+	for _, test := range tests {
+		fakeTranscoder := newFakeElasticTranscoder()
+		prov := &awsProvider{
+			c:      fakeTranscoder,
+			config: &config.ElasticTranscoder{PipelineID: "mypipeline"},
+		}
+		jobStatus, err := prov.Transcode(&db.Job{ID: "job-1"}, provider.TranscodeProfile{
+			SourceMedia: "dir/file.mov",
+			Outputs: []provider.TranscodeOutput{
+				{
+					FileName: "hls/video_720p.m3u8",
+					Preset: db.PresetMap{
+						Name:            "hls_720p",
+						ProviderMapping: map[string]string{Name: test.presetID},
+						OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+					},
+				},
+			},
+			StreamingParams: provider.StreamingParams{
+				PlaylistFileName: "hls/index.m3u8",
+				Protocol:         provider.ProtocolHLS,
+				SegmentDuration:  6,
+				SegmentFormat:    test.segmentFormat,
+			},
+		})
+		if test.expectedErr != "" {
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		jobInput := fakeTranscoder.jobs[jobStatus.ProviderJobID]
+		if aws.StringValue(jobInput.Outputs[0].SegmentDuration) != "6" {
+			t.Errorf("%s: wrong segment duration. Want 6. Got %q", test.testCase, aws.StringValue(jobInput.Outputs[0].SegmentDuration))
+		}
+		if len(jobInput.Playlists) != 1 || aws.StringValue(jobInput.Playlists[0].Format) != test.expectedFormat {
+			t.Errorf("%s: wrong playlists. Want format %q. Got %#v", test.testCase, test.expectedFormat, jobInput.Playlists)
+		}
+	}
+}
+
+func TestAWSTranscodeDRM(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	keyMD5 := "QDKvjWEDUSOQbljgZxQMxQ=="
+	var tests = []struct {
+		testCase      string
+		drm           provider.DRMParams
+		expectedHLS   *elastictranscoder.HlsContentProtection
+		expectedReady *elastictranscoder.PlayReadyDrm
+		expectedErr   string
+	}{
+		{
+			"aes-128",
+			provider.DRMParams{Scheme: "aes-128", Key: key, LicenseURL: "https://keys.example.com/job-1", IV: []byte("fedcba9876543210")},
+			&elastictranscoder.HlsContentProtection{
+				Method:                aws.String("aes-128"),
+				Key:                   aws.String("MDEyMzQ1Njc4OWFiY2RlZg=="),
+				KeyMd5:                aws.String(keyMD5),
+				InitializationVector:  aws.String("ZmVkY2JhOTg3NjU0MzIxMA=="),
+				LicenseAcquisitionUrl: aws.String("https://keys.example.com/job-1"),
+				KeyStoragePolicy:      aws.String("NoStore"),
+			},
+			nil,
+			"",
+		},
+		{
+			"playready",
+			provider.DRMParams{Scheme: "playready", Key: key, KeyID: []byte{0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89}, LicenseURL: "https://license.example.com/rightsmanager.asmx"},
+			nil,
+			&elastictranscoder.PlayReadyDrm{
+				Format:                aws.String("discretix-3.0"),
+				Key:                   aws.String("MDEyMzQ1Njc4OWFiY2RlZg=="),
+				KeyMd5:                aws.String(keyMD5),
+				KeyId:                 aws.String("abcdef0123456789abcdef0123456789"),
+				LicenseAcquisitionUrl: aws.String("https://license.example.com/rightsmanager.asmx"),
+			},
+			"",
+		},
+		{
+			"unsupported scheme",
+			provider.DRMParams{Scheme: "widevine", Key: key, LicenseURL: "https://license.example.com"},
+			nil,
+			nil,
+			`unsupported DRM scheme "widevine"`,
+		},
+	}
+	for _, test := range tests {
+		fakeTranscoder := newFakeElasticTranscoder()
+		prov := &awsProvider{
+			c:      fakeTranscoder,
+			config: &config.ElasticTranscoder{PipelineID: "mypipeline"},
+		}
+		drm := test.drm
+		jobStatus, err := prov.Transcode(&db.Job{ID: "job-1"}, provider.TranscodeProfile{
+			SourceMedia: "dir/file.mov",
+			Outputs: []provider.TranscodeOutput{
+				{
+					FileName: "hls/video_720p.m3u8",
+					Preset: db.PresetMap{
+						Name:            "hls_720p",
+						ProviderMapping: map[string]string{Name: "93239832-0001-hls"},
+						OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+					},
+				},
+			},
+			StreamingParams: provider.StreamingParams{PlaylistFileName: "hls/index.m3u8", Protocol: "hls", SegmentDuration: 6},
+			DRM:             &drm,
+		})
+		if test.expectedErr != "" {
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		playlist := fakeTranscoder.jobs[jobStatus.ProviderJobID].Playlists[0]
+		if !reflect.DeepEqual(playlist.HlsContentProtection, test.expectedHLS) {
+			t.Errorf("%s: wrong HLS content protection\nWant %#v\nGot  %#v", test.testCase, test.expectedHLS, playlist.HlsContentProtection)
+		}
+		if !reflect.DeepEqual(playlist.PlayReadyDrm, test.expectedReady) {
+			t.Errorf("%s: wrong PlayReady DRM\nWant %#v\nGot  %#v", test.testCase, test.expectedReady, playlist.PlayReadyDrm)
+		}
+	}
+}
+
+func TestAWSTranscodeThumbnails(t *testing.T) {
+	var tests = []struct {
+		testCase    string
+		presetID    string
+		thumbnails  provider.Thumbnails
+		expectedErr string
+	}{
+		{"matching preset", "93239832-thumbs", provider.Thumbnails{Interval: 10, Width: 320, Format: "png"}, ""},
+		{"provider format", "93239832-thumbs", provider.Thumbnails{Interval: 10}, ""},
+		{
+			"number of thumbnails",
+			"93239832-thumbs",
+			provider.Thumbnails{Count: 5},
+			"elastic transcoder generates thumbnails in intervals, not in a given number",
+		},
+		{
+			"preset without thumbnails",
+			"93239832-0001",
+			provider.Thumbnails{Interval: 10},
+			"preset 93239832-0001 doesn't generate thumbnails",
+		},
+		{
+			"different format",
+			"93239832-thumbs",
+			provider.Thumbnails{Interval: 10, Format: "jpg"},
+			"preset 93239832-thumbs generates png thumbnails, but the job requires jpg thumbnails",
+		},
+		{
+			"different interval",
+			"93239832-thumbs",
+			provider.Thumbnails{Interval: 5},
+			"preset 93239832-thumbs generates thumbnails every 10 seconds, but the job requires 5 seconds",
+		},
+		{
+			"different resolution",
+			"93239832-thumbs",
+			provider.Thumbnails{Interval: 10, Width: 640, Height: 360},
+			"preset 93239832-thumbs generates thumbnails of up to 320xauto, but the job requires 640x360",
+		},
+	}
+	for _, test := range tests {
+		fakeTranscoder := newFakeElasticTranscoder()
+		prov := &awsProvider{
+			c:      fakeTranscoder,
+			config: &config.ElasticTranscoder{PipelineID: "mypipeline"},
+		}
+		thumbnails := test.thumbnails
+		jobStatus, err := prov.Transcode(&db.Job{ID: "job-1"}, provider.TranscodeProfile{
+			SourceMedia: "dir/file.mov",
+			Outputs: []provider.TranscodeOutput{
+				{
+					FileName: "output_thumbs.mp4",
+					Preset: db.PresetMap{
+						Name:            "mp4_720p",
+						ProviderMapping: map[string]string{Name: test.presetID},
+						OutputOpts:      db.OutputOptions{Extension: "mp4"},
+					},
+				},
+			},
+			Thumbnails: &thumbnails,
+		})
+		if test.expectedErr != "" {
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		pattern := aws.StringValue(fakeTranscoder.jobs[jobStatus.ProviderJobID].Outputs[0].ThumbnailPattern)
+		if pattern != "job-1/thumbnails/thumb-{count}" {
+			t.Errorf("%s: wrong thumbnail pattern. Want %q. Got %q", test.testCase, "job-1/thumbnails/thumb-{count}", pattern)
+		}
+		jobStatus, err = prov.JobStatus(&db.Job{ID: "job-1", ProviderJobID: jobStatus.ProviderJobID})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		expectedFiles := []provider.OutputFile{
+			{Path: "s3://some bucket/job-1/thumbnails/thumb-00001.png", Container: "png"},
+			{Path: "s3://some bucket/job-1/thumbnails/thumb-00002.png", Container: "png"},
+			{Path: "s3://some bucket/job-1/thumbnails/thumb-00003.png", Container: "png"},
+			{Path: "s3://some bucket/job-1/output_thumbs.mp4", Container: "mp4", VideoCodec: "H.264", Height: 720},
+		}
+		if !reflect.DeepEqual(jobStatus.Output.Files, expectedFiles) {
+			t.Errorf("%s: wrong output files\nWant %#v\nGot  %#v", test.testCase, expectedFiles, jobStatus.Output.Files)
+		}
+	}
+}
+
 func TestAWSTranscodePresetNotFound(t *testing.T) {
 	fakeTranscoder := newFakeElasticTranscoder()
 	prov := &awsProvider{
@@ -825,6 +1164,156 @@ func TestAWSCreatePreset(t *testing.T) {
 	}
 }
 
+func TestAWSCreatePresetAudioOnly(t *testing.T) {
+	fakeTranscoder := newFakeElasticTranscoder()
+	prov := &awsProvider{
+		c: fakeTranscoder,
+		config: &config.ElasticTranscoder{
+			AccessKeyID:     "AKIA",
+			SecretAccessKey: "secret",
+			Region:          "sa-east-1",
+			PipelineID:      "mypipeline",
+		},
+	}
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:      "m4a_128k",
+		Container: "m4a",
+		AudioOnly: true,
+		Audio: db.AudioPreset{
+			Codec:   "aac",
+			Bitrate: "128000",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := fakeTranscoder.presets[presetID]
+	if input == nil {
+		t.Fatal("preset not sent to Elastic Transcoder")
+	}
+	if container := aws.StringValue(input.Container); container != "mp4" {
+		t.Errorf("wrong container. Want %q. Got %q", "mp4", container)
+	}
+	if input.Video != nil || input.Thumbnails != nil {
+		t.Errorf("unexpected video settings in audio-only preset: %#v, %#v", input.Video, input.Thumbnails)
+	}
+	expectedAudio := &elastictranscoder.AudioParameters{
+		Codec:      aws.String("AAC"),
+		BitRate:    aws.String("128"),
+		Channels:   aws.String("auto"),
+		SampleRate: aws.String("auto"),
+	}
+	if !reflect.DeepEqual(input.Audio, expectedAudio) {
+		t.Errorf("wrong audio settings. Want %#v. Got %#v", expectedAudio, input.Audio)
+	}
+}
+
+func TestAWSCreatePresetWatermark(t *testing.T) {
+	fakeTranscoder := newFakeElasticTranscoder()
+	prov := &awsProvider{
+		c: fakeTranscoder,
+		config: &config.ElasticTranscoder{
+			AccessKeyID:     "AKIA",
+			SecretAccessKey: "secret",
+			Region:          "sa-east-1",
+			PipelineID:      "mypipeline",
+		},
+	}
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:      "mp4_720p_branded",
+		Container: "mp4",
+		Profile:   "Main",
+		Video: db.VideoPreset{
+			Codec:   "h264",
+			Bitrate: "2500000",
+			GopSize: "90",
+		},
+		Audio: db.AudioPreset{
+			Codec:   "aac",
+			Bitrate: "128000",
+		},
+		Watermark: &db.Watermark{
+			URL:      "s3://bucket/logos/nyt.png",
+			Position: "top-left",
+			Offset:   "5%",
+			Width:    "120",
+			Opacity:  "70",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := fakeTranscoder.presets[presetID]
+	if input == nil || input.Video == nil {
+		t.Fatal("video preset not sent to Elastic Transcoder")
+	}
+	expectedWatermarks := []*elastictranscoder.PresetWatermark{
+		{
+			Id:               aws.String("watermark"),
+			HorizontalAlign:  aws.String("Left"),
+			VerticalAlign:    aws.String("Top"),
+			HorizontalOffset: aws.String("5%"),
+			VerticalOffset:   aws.String("5%"),
+			MaxWidth:         aws.String("120px"),
+			Opacity:          aws.String("70"),
+			SizingPolicy:     aws.String("ShrinkToFit"),
+			Target:           aws.String("Content"),
+		},
+	}
+	if !reflect.DeepEqual(input.Video.Watermarks, expectedWatermarks) {
+		t.Errorf("wrong watermarks. Want %#v. Got %#v", expectedWatermarks, input.Video.Watermarks)
+	}
+}
+
+func TestAWSTranscodeWatermark(t *testing.T) {
+	var tests = []struct {
+		testCase       string
+		givenWatermark string
+		wantInputKey   string
+		wantErr        string
+	}{
+		{"watermark image", "s3://bucket/logos/nyt.png", "logos/nyt.png", ""},
+		{"missing watermark image", "", "", `preset 93239832-watermark has a watermark, but the presetmap "mp4_720p_branded" has no watermark image`},
+	}
+	for _, test := range tests {
+		fakeTranscoder := newFakeElasticTranscoder()
+		prov := &awsProvider{
+			c:      fakeTranscoder,
+			config: &config.ElasticTranscoder{PipelineID: "mypipeline"},
+		}
+		_, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			SourceMedia: "dir/file.mov",
+			Outputs: []provider.TranscodeOutput{
+				{
+					FileName: "output-720p.mp4",
+					Preset: db.PresetMap{
+						Name:            "mp4_720p_branded",
+						ProviderMapping: map[string]string{Name: "93239832-watermark"},
+						OutputOpts:      db.OutputOptions{Extension: "mp4", Watermark: test.givenWatermark},
+					},
+				},
+			},
+		})
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", test.testCase, err)
+		}
+		for _, job := range fakeTranscoder.jobs {
+			expected := []*elastictranscoder.JobWatermark{
+				{PresetWatermarkId: aws.String("watermark"), InputKey: aws.String(test.wantInputKey)},
+			}
+			if !reflect.DeepEqual(job.Outputs[0].Watermarks, expected) {
+				t.Errorf("%s: wrong watermarks. Want %#v. Got %#v", test.testCase, expected, job.Outputs[0].Watermarks)
+			}
+		}
+	}
+}
+
 func TestCreateVideoPreset(t *testing.T) {
 	fakeTranscoder := newFakeElasticTranscoder()
 	prov := &awsProvider{
@@ -1114,6 +1603,30 @@ func TestCancelJobInternalError(t *testing.T) {
 	}
 }
 
+func TestActiveJobs(t *testing.T) {
+	fakeTranscoder := newFakeElasticTranscoder()
+	fakeTranscoder.jobs["job-2"] = &elastictranscoder.CreateJobInput{}
+	fakeTranscoder.jobs["job-1"] = &elastictranscoder.CreateJobInput{}
+	prov := &awsProvider{
+		c: fakeTranscoder,
+		config: &config.ElasticTranscoder{
+			AccessKeyID:     "AKIA",
+			SecretAccessKey: "secret",
+			Region:          "sa-east-1",
+			PipelineID:      "mypipeline",
+		},
+	}
+	jobs, err := prov.ActiveJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creationTime := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	expected := []provider.ActiveJob{{ID: "job-1", CreationTime: creationTime}, {ID: "job-2", CreationTime: creationTime}}
+	if !reflect.DeepEqual(jobs, expected) {
+		t.Errorf("wrong active jobs. Want %#v. Got %#v", expected, jobs)
+	}
+}
+
 func TestHealthcheck(t *testing.T) {
 	fakeTranscoder := newFakeElasticTranscoder()
 	provider := &awsProvider{
@@ -1153,9 +1666,23 @@ func TestHealthcheckFailure(t *testing.T) {
 func TestCapabilities(t *testing.T) {
 	var prov awsProvider
 	expected := provider.Capabilities{
-		InputFormats:  []string{"h264"},
-		OutputFormats: []string{"mp4", "hls", "webm"},
-		Destinations:  []string{"s3"},
+		InputFormats:       []string{"h264"},
+		OutputFormats:      []string{"mp4", "hls", "webm", "mp3", "m4a", "ogg"},
+		Destinations:       []string{"s3"},
+		VideoCodecs:        []string{"h264", "vp8", "vp9"},
+		AudioCodecs:        []string{"aac", "flac", "mp3", "pcm", "vorbis"},
+		StreamingProtocols: []string{"hls"},
+		SegmentFormats:     []string{"fmp4"},
+		DRMSchemes:         []string{"aes-128", "playready"},
+		MaxAudioChannels:   2,
+		Thumbnails:         true,
+		Clipping:           true,
+		Trim:               true,
+		AudioOnly:          true,
+		Watermark:          true,
+		Rotation:           true,
+		NoAutorotation:     true,
+		Progress:           true,
 	}
 	cap := prov.Capabilities()
 	if !reflect.DeepEqual(cap, expected) {
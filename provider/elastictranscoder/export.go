@@ -0,0 +1,139 @@
+package elastictranscoder
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elastictranscoder"
+)
+
+// ExportPreset translates the given Elastic Transcoder preset into the
+// generic preset format, so it can be created in other providers. Settings
+// without an equivalent in the generic format are listed as unsupported.
+func (p *awsProvider) ExportPreset(presetID string) (db.Preset, []string, error) {
+	resp, err := p.c.ReadPreset(&elastictranscoder.ReadPresetInput{Id: aws.String(presetID)})
+	if err != nil {
+		return db.Preset{}, nil, err
+	}
+	preset, unsupported := exportPreset(resp.Preset)
+	return preset, unsupported, nil
+}
+
+func exportPreset(etPreset *elastictranscoder.Preset) (db.Preset, []string) {
+	var unsupported []string
+	preset := db.Preset{
+		Name:        aws.StringValue(etPreset.Name),
+		Description: aws.StringValue(etPreset.Description),
+		Container:   aws.StringValue(etPreset.Container),
+	}
+	if preset.Container == "ts" {
+		preset.Container = "m3u8"
+	}
+	if video := etPreset.Video; video != nil {
+		preset.Video = db.VideoPreset{
+			Codec:   strings.ToLower(aws.StringValue(video.Codec)),
+			Width:   autoToEmpty(aws.StringValue(video.MaxWidth)),
+			Height:  autoToEmpty(aws.StringValue(video.MaxHeight)),
+			GopSize: aws.StringValue(video.KeyframesMaxDist),
+		}
+		if preset.Video.Codec == "h.264" {
+			preset.Video.Codec = "h264"
+		}
+		if aws.StringValue(video.FixedGOP) == "true" {
+			preset.Video.GopMode = "fixed"
+		}
+		var ok bool
+		if preset.Video.Bitrate, ok = kbpsToBps(aws.StringValue(video.BitRate)); !ok {
+			unsupported = append(unsupported, "video bit rate "+aws.StringValue(video.BitRate))
+		}
+		optionNames := make([]string, 0, len(video.CodecOptions))
+		for name := range video.CodecOptions {
+			optionNames = append(optionNames, name)
+		}
+		sort.Strings(optionNames)
+		for _, name := range optionNames {
+			value := aws.StringValue(video.CodecOptions[name])
+			switch name {
+			case "Profile":
+				if preset.Video.Codec == "h264" {
+					preset.Profile = value
+				}
+			case "Level":
+				preset.ProfileLevel = value
+			case "InterlacedMode":
+				preset.Video.InterlaceMode = strings.ToLower(value)
+			case "MaxReferenceFrames":
+				// not configurable in the generic format, providers
+				// choose their own defaults.
+			default:
+				unsupported = append(unsupported, "video codec option "+name)
+			}
+		}
+		settings := []struct {
+			name         string
+			value        *string
+			defaultValue string
+		}{
+			{"frame rate", video.FrameRate, "auto"},
+			{"max frame rate", video.MaxFrameRate, ""},
+			{"display aspect ratio", video.DisplayAspectRatio, "auto"},
+			{"sizing policy", video.SizingPolicy, "Fill"},
+			{"padding policy", video.PaddingPolicy, "Pad"},
+		}
+		for _, setting := range settings {
+			if value := aws.StringValue(setting.value); value != "" && value != setting.defaultValue {
+				unsupported = append(unsupported, "video "+setting.name+" "+value)
+			}
+		}
+		if len(video.Watermarks) > 0 {
+			unsupported = append(unsupported, "watermarks")
+		}
+	}
+	if audio := etPreset.Audio; audio != nil {
+		preset.AudioOnly = etPreset.Video == nil
+		preset.Audio = db.AudioPreset{Codec: strings.ToLower(aws.StringValue(audio.Codec))}
+		var ok bool
+		if preset.Audio.Bitrate, ok = kbpsToBps(aws.StringValue(audio.BitRate)); !ok {
+			unsupported = append(unsupported, "audio bit rate "+aws.StringValue(audio.BitRate))
+		}
+		if channels := aws.StringValue(audio.Channels); channels != "" && channels != "auto" {
+			unsupported = append(unsupported, "audio channels "+channels)
+		}
+		if sampleRate := aws.StringValue(audio.SampleRate); sampleRate != "" && sampleRate != "auto" {
+			unsupported = append(unsupported, "audio sample rate "+sampleRate)
+		}
+		if audio.AudioPackingMode != nil {
+			unsupported = append(unsupported, "audio packing mode "+aws.StringValue(audio.AudioPackingMode))
+		}
+		if audio.CodecOptions != nil && aws.StringValue(audio.CodecOptions.Profile) != "" && aws.StringValue(audio.CodecOptions.Profile) != "auto" {
+			unsupported = append(unsupported, "audio codec profile "+aws.StringValue(audio.CodecOptions.Profile))
+		}
+	}
+	if etPreset.Thumbnails != nil {
+		unsupported = append(unsupported, "thumbnails")
+	}
+	return preset, unsupported
+}
+
+// kbpsToBps converts a bit rate in kilobits per second, as used in Elastic
+// Transcoder, to bits per second, as used in the generic preset format.
+func kbpsToBps(kbps string) (string, bool) {
+	if kbps == "" {
+		return "", true
+	}
+	value, err := strconv.Atoi(kbps)
+	if err != nil {
+		return "", false
+	}
+	return strconv.Itoa(value * 1000), true
+}
+
+func autoToEmpty(value string) string {
+	if value == "auto" {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,130 @@
+package elastictranscoder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elastictranscoder"
+)
+
+func TestExportPreset(t *testing.T) {
+	var tests = []struct {
+		testCase        string
+		preset          *elastictranscoder.Preset
+		wantPreset      db.Preset
+		wantUnsupported []string
+	}{
+		{
+			"preset created by the API",
+			&elastictranscoder.Preset{
+				Name:        aws.String("hls_720p"),
+				Description: aws.String("HLS 720p"),
+				Container:   aws.String("ts"),
+				Video: &elastictranscoder.VideoParameters{
+					Codec:              aws.String("H.264"),
+					BitRate:            aws.String("2500"),
+					MaxWidth:           aws.String("auto"),
+					MaxHeight:          aws.String("720"),
+					KeyframesMaxDist:   aws.String("90"),
+					FixedGOP:           aws.String("true"),
+					FrameRate:          aws.String("auto"),
+					DisplayAspectRatio: aws.String("auto"),
+					SizingPolicy:       aws.String("Fill"),
+					PaddingPolicy:      aws.String("Pad"),
+					CodecOptions: map[string]*string{
+						"Profile":            aws.String("main"),
+						"Level":              aws.String("3.1"),
+						"MaxReferenceFrames": aws.String("2"),
+					},
+				},
+				Audio: &elastictranscoder.AudioParameters{
+					Codec:      aws.String("AAC"),
+					BitRate:    aws.String("128"),
+					Channels:   aws.String("auto"),
+					SampleRate: aws.String("auto"),
+				},
+			},
+			db.Preset{
+				Name:         "hls_720p",
+				Description:  "HLS 720p",
+				Container:    "m3u8",
+				Profile:      "main",
+				ProfileLevel: "3.1",
+				Video: db.VideoPreset{
+					Codec:   "h264",
+					Bitrate: "2500000",
+					Height:  "720",
+					GopSize: "90",
+					GopMode: "fixed",
+				},
+				Audio: db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+			},
+			nil,
+		},
+		{
+			"preset with unsupported settings",
+			&elastictranscoder.Preset{
+				Name:      aws.String("webm_360p"),
+				Container: aws.String("webm"),
+				Video: &elastictranscoder.VideoParameters{
+					Codec:        aws.String("vp8"),
+					BitRate:      aws.String("auto"),
+					FrameRate:    aws.String("30"),
+					CodecOptions: map[string]*string{"Profile": aws.String("0"), "BufferSize": aws.String("5000")},
+					Watermarks:   []*elastictranscoder.PresetWatermark{{Id: aws.String("logo")}},
+				},
+				Audio: &elastictranscoder.AudioParameters{
+					Codec:    aws.String("vorbis"),
+					BitRate:  aws.String("96"),
+					Channels: aws.String("1"),
+				},
+				Thumbnails: &elastictranscoder.Thumbnails{Format: aws.String("png")},
+			},
+			db.Preset{
+				Name:      "webm_360p",
+				Container: "webm",
+				Video:     db.VideoPreset{Codec: "vp8"},
+				Audio:     db.AudioPreset{Codec: "vorbis", Bitrate: "96000"},
+			},
+			[]string{
+				"video bit rate auto",
+				"video codec option BufferSize",
+				"video frame rate 30",
+				"watermarks",
+				"audio channels 1",
+				"thumbnails",
+			},
+		},
+		{
+			"audio-only preset",
+			&elastictranscoder.Preset{
+				Name:      aws.String("mp3_128k"),
+				Container: aws.String("mp3"),
+				Audio: &elastictranscoder.AudioParameters{
+					Codec:      aws.String("mp3"),
+					BitRate:    aws.String("128"),
+					Channels:   aws.String("auto"),
+					SampleRate: aws.String("auto"),
+				},
+			},
+			db.Preset{
+				Name:      "mp3_128k",
+				Container: "mp3",
+				AudioOnly: true,
+				Audio:     db.AudioPreset{Codec: "mp3", Bitrate: "128000"},
+			},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		preset, unsupported := exportPreset(test.preset)
+		if !reflect.DeepEqual(preset, test.wantPreset) {
+			t.Errorf("%s: wrong preset\nWant %#v\nGot  %#v", test.testCase, test.wantPreset, preset)
+		}
+		if !reflect.DeepEqual(unsupported, test.wantUnsupported) {
+			t.Errorf("%s: wrong unsupported settings\nWant %#v\nGot  %#v", test.testCase, test.wantUnsupported, unsupported)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package elastictranscoder
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// bitrates accepted by Elastic Transcoder for the video and audio of
+// presets.
+var (
+	videoBitrates = provider.BitrateRange{Min: 64, Max: 62500}
+	audioBitrates = provider.BitrateRange{Min: 64, Max: 320}
+)
+
+// ValidatePreset translates the given preset into the Elastic Transcoder
+// preset that CreatePreset would create, without creating it.
+func (p *awsProvider) ValidatePreset(preset db.Preset) (interface{}, []string, error) {
+	presetInput := p.createPresetInput(preset)
+	var warnings []string
+	warning, err := checkBitrate("audio", preset.Audio.Bitrate, aws.StringValue(presetInput.Audio.BitRate), audioBitrates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+	if presetInput.Video != nil {
+		warning, err = checkBitrate("video", preset.Video.Bitrate, aws.StringValue(presetInput.Video.BitRate), videoBitrates)
+		if err != nil {
+			return nil, nil, err
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	return presetInput, warnings, nil
+}
+
+// checkBitrate validates the bitrate of the generic preset, in bps, and
+// returns a warning when the translated bitrate, in kbps, is out of the
+// given range.
+func checkBitrate(kind, bitrate, kbps string, bitrates provider.BitrateRange) (string, error) {
+	if _, err := strconv.Atoi(bitrate); err != nil {
+		return "", fmt.Errorf("invalid %s bitrate %q", kind, bitrate)
+	}
+	value, _ := strconv.ParseInt(kbps, 10, 64)
+	return bitrates.Warning(kind, value), nil
+}
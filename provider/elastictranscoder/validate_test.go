@@ -0,0 +1,87 @@
+package elastictranscoder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elastictranscoder"
+)
+
+func TestValidatePreset(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenPreset   db.Preset
+
+		wantVideoBitrate string
+		wantWarnings     []string
+		wantError        string
+	}{
+		{
+			"valid preset",
+			db.Preset{
+				Name:      "mp4_720p",
+				Container: "mp4",
+				Video:     db.VideoPreset{Codec: "h264", Bitrate: "2500000", GopSize: "90"},
+				Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+			},
+			"2500",
+			nil,
+			"",
+		},
+		{
+			"bitrates out of range",
+			db.Preset{
+				Name:      "mp4_4k",
+				Container: "mp4",
+				Video:     db.VideoPreset{Codec: "h264", Bitrate: "80000000", GopSize: "90"},
+				Audio:     db.AudioPreset{Codec: "aac", Bitrate: "32000"},
+			},
+			"80000",
+			[]string{
+				"audio bitrate of 32 kbps is out of the supported range (64-320 kbps)",
+				"video bitrate of 80000 kbps is out of the supported range (64-62500 kbps)",
+			},
+			"",
+		},
+		{
+			"invalid bitrate",
+			db.Preset{
+				Name:      "mp4_720p",
+				Container: "mp4",
+				Video:     db.VideoPreset{Codec: "h264", Bitrate: "2.5M", GopSize: "90"},
+				Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+			},
+			"",
+			nil,
+			`invalid video bitrate "2.5M"`,
+		},
+	}
+	for _, test := range tests {
+		fakeTranscoder := newFakeElasticTranscoder()
+		prov := &awsProvider{c: fakeTranscoder, config: &config.ElasticTranscoder{PipelineID: "mypipeline"}}
+		preset, warnings, err := prov.ValidatePreset(test.givenPreset)
+		if test.wantError != "" {
+			if err == nil || err.Error() != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.givenTestCase, test.wantError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.givenTestCase, err)
+			continue
+		}
+		if !reflect.DeepEqual(warnings, test.wantWarnings) {
+			t.Errorf("%s: wrong warnings. Want %#v. Got %#v", test.givenTestCase, test.wantWarnings, warnings)
+		}
+		presetInput := preset.(*elastictranscoder.CreatePresetInput)
+		if bitrate := aws.StringValue(presetInput.Video.BitRate); bitrate != test.wantVideoBitrate {
+			t.Errorf("%s: wrong video bitrate. Want %q. Got %q", test.givenTestCase, test.wantVideoBitrate, bitrate)
+		}
+		if len(fakeTranscoder.presets) > 0 {
+			t.Errorf("%s: presets created in Elastic Transcoder: %#v", test.givenTestCase, fakeTranscoder.presets)
+		}
+	}
+}
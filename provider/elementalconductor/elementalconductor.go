@@ -147,7 +147,16 @@ func (p *elementalConductorProvider) JobStatus(job *db.Job) (*provider.JobStatus
 }
 
 func (p *elementalConductorProvider) getOutputDestination(job *db.Job) string {
-	return strings.TrimRight(p.config.ElementalConductor.Destination, "/") + "/" + job.ID
+	return strings.TrimRight(p.baseDestination(job), "/") + "/" + job.ID
+}
+
+// baseDestination returns the base destination of the given job, falling
+// back to the destination in the configuration.
+func (p *elementalConductorProvider) baseDestination(job *db.Job) string {
+	if job.Destination != "" {
+		return job.Destination
+	}
+	return p.config.ElementalConductor.Destination
 }
 
 func (p *elementalConductorProvider) getOutputFiles(job *elementalconductor.Job) []provider.OutputFile {
@@ -202,6 +211,11 @@ func (p *elementalConductorProvider) buildOutputGroupAndStreamAssemblies(outputL
 	var outputGroupList []elementalconductor.OutputGroup
 	var outputGroupOrder int
 	var streamingGroupOrder int
+	alignment := transcodeProfile.StreamingParams.KeyframeAlignment
+	if err := alignment.RequireSceneCutDisabled(Name); err != nil {
+		return outputGroupList, nil, err
+	}
+	gops := make([]provider.RenditionGOP, 0, len(transcodeProfile.Outputs))
 	for index, output := range transcodeProfile.Outputs {
 		indexString := strconv.Itoa(index)
 		streamAssemblyName := "stream_" + indexString
@@ -215,6 +229,11 @@ func (p *elementalConductorProvider) buildOutputGroupAndStreamAssemblies(outputL
 			return outputGroupList, nil, err
 		}
 		presetStruct := presetOutput.(*elementalconductor.Preset)
+		gops = append(gops, provider.RenditionGOP{
+			Name:  output.Preset.Name,
+			Size:  presetStruct.GopSize,
+			Fixed: presetStruct.GopMode == "fixed",
+		})
 		if presetStruct.Container == string(elementalconductor.AppleHTTPLiveStreaming) {
 			streamingGroupOrder++
 			out.NameModifier = fmt.Sprintf("_%010d", streamingGroupOrder)
@@ -243,6 +262,9 @@ func (p *elementalConductorProvider) buildOutputGroupAndStreamAssemblies(outputL
 		}
 		streamAssemblyList = append(streamAssemblyList, streamAssembly)
 	}
+	if err := alignment.Validate(gops, transcodeProfile.StreamingParams.SegmentDuration); err != nil {
+		return outputGroupList, nil, err
+	}
 	if len(streamingOutputList) > 0 {
 		playlistFileName := transcodeProfile.StreamingParams.PlaylistFileName
 		location := outputLocation
@@ -270,7 +292,7 @@ func (p *elementalConductorProvider) newJob(job *db.Job, transcodeProfile provid
 		Username: p.client.GetAccessKeyID(),
 		Password: p.client.GetSecretAccessKey(),
 	}
-	baseLocation := strings.TrimRight(p.config.ElementalConductor.Destination, "/")
+	baseLocation := strings.TrimRight(p.baseDestination(job), "/")
 	outputLocation := elementalconductor.Location{
 		URI:      baseLocation + "/" + job.ID,
 		Username: p.client.GetAccessKeyID(),
@@ -320,11 +342,19 @@ func (p *elementalConductorProvider) Healthcheck() error {
 	return nil
 }
 
+// Capabilities returns the features supported by Elemental Conductor.
+// Clipping isn't one of them, as the client doesn't expose the clipping
+// settings of inputs.
 func (p *elementalConductorProvider) Capabilities() provider.Capabilities {
 	return provider.Capabilities{
-		InputFormats:  []string{"prores", "h264"},
-		OutputFormats: []string{"mp4", "hls"},
-		Destinations:  []string{"akamai", "s3"},
+		InputFormats:       []string{"prores", "h264"},
+		OutputFormats:      []string{"mp4", "hls"},
+		Destinations:       []string{"akamai", "s3"},
+		VideoCodecs:        []string{"h264", "hevc", "mpeg2"},
+		AudioCodecs:        []string{"aac", "ac3", "eac3"},
+		StreamingProtocols: []string{"hls"},
+		MaxAudioChannels:   8,
+		Progress:           true,
 	}
 }
 
@@ -0,0 +1,13 @@
+// +build integration
+
+package elementalconductor
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/provider/providertest"
+)
+
+func TestElementalConductorIntegration(t *testing.T) {
+	providertest.Run(t, Name)
+}
@@ -1192,9 +1192,14 @@ func TestHealthcheck(t *testing.T) {
 func TestCapabilities(t *testing.T) {
 	var prov elementalConductorProvider
 	expected := provider.Capabilities{
-		InputFormats:  []string{"prores", "h264"},
-		OutputFormats: []string{"mp4", "hls"},
-		Destinations:  []string{"akamai", "s3"},
+		InputFormats:       []string{"prores", "h264"},
+		OutputFormats:      []string{"mp4", "hls"},
+		Destinations:       []string{"akamai", "s3"},
+		VideoCodecs:        []string{"h264", "hevc", "mpeg2"},
+		AudioCodecs:        []string{"aac", "ac3", "eac3"},
+		StreamingProtocols: []string{"hls"},
+		MaxAudioChannels:   8,
+		Progress:           true,
 	}
 	cap := prov.Capabilities()
 	if !reflect.DeepEqual(cap, expected) {
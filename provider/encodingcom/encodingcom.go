@@ -53,6 +53,9 @@ type encodingComProvider struct {
 }
 
 func (e *encodingComProvider) Transcode(job *db.Job, transcodeProfile provider.TranscodeProfile) (*provider.JobStatus, error) {
+	if alignment := transcodeProfile.StreamingParams.KeyframeAlignment; alignment != nil && alignment.Enabled {
+		return nil, provider.KeyframeAlignmentError("keyframe alignment is not supported by " + Name)
+	}
 	formats, err := e.presetsToFormats(job, transcodeProfile)
 	if err != nil {
 		return nil, fmt.Errorf("Error converting presets to formats on Transcode operation: %s", err.Error())
@@ -69,6 +72,9 @@ func (e *encodingComProvider) Transcode(job *db.Job, transcodeProfile provider.T
 }
 
 func (e *encodingComProvider) CreatePreset(preset db.Preset) (string, error) {
+	if preset.Container == "m3u8" && preset.Audio.ChannelLayout == db.AudioLayout51 {
+		return "", errors.New("encoding.com doesn't support 5.1 audio in hls presets")
+	}
 	resp, err := e.client.SavePreset(preset.Name, e.presetToFormat(preset))
 	if err != nil {
 		return "", err
@@ -106,6 +112,9 @@ func (e *encodingComProvider) presetToFormat(preset db.Preset) encodingcom.Forma
 		format.Keyframe = []string{preset.Video.GopSize}
 		format.AudioVolume = 100
 		format.AudioCodec = e.getNormalizedCodec(preset.Audio.Codec)
+		if preset.Audio.ChannelLayout != "" {
+			format.AudioChannelsNumber = strconv.Itoa(preset.Audio.Channels())
+		}
 		format.VideoCodec = e.getNormalizedCodec(preset.Video.Codec)
 		format.Size = e.getSize(preset.Video.Width, preset.Video.Height)
 	}
@@ -161,11 +170,20 @@ func (e *encodingComProvider) DeletePreset(presetID string) error {
 	return err
 }
 
-func (e *encodingComProvider) getDestinations(jobID, fileName string) []string {
-	destination := e.buildDestination(e.config.EncodingCom.Destination, jobID, fileName)
+func (e *encodingComProvider) getDestinations(job *db.Job, fileName string) []string {
+	destination := e.buildDestination(e.baseDestination(job), job.ID, fileName)
 	return []string{destination}
 }
 
+// baseDestination returns the base destination of the given job, falling
+// back to the destination in the configuration.
+func (e *encodingComProvider) baseDestination(job *db.Job) string {
+	if job.Destination != "" {
+		return job.Destination
+	}
+	return e.config.EncodingCom.Destination
+}
+
 func (e *encodingComProvider) buildDestination(baseDestination, jobID, fileName string) string {
 	outputPath := strings.TrimRight(baseDestination, "/")
 	return outputPath + "/" + path.Join(jobID, fileName)
@@ -193,7 +211,7 @@ func (e *encodingComProvider) presetsToFormats(job *db.Job, transcodeProfile pro
 		} else {
 			format := encodingcom.Format{
 				OutputPreset: presetID,
-				Destination:  e.getDestinations(job.ID, output.FileName),
+				Destination:  e.getDestinations(job, output.FileName),
 			}
 			formats = append(formats, format)
 		}
@@ -202,13 +220,21 @@ func (e *encodingComProvider) presetsToFormats(job *db.Job, transcodeProfile pro
 		falseValue := encodingcom.YesNoBoolean(false)
 		format := encodingcom.Format{
 			Output:          []string{hlsOutput},
-			Destination:     e.getDestinations(job.ID, transcodeProfile.StreamingParams.PlaylistFileName),
+			Destination:     e.getDestinations(job, transcodeProfile.StreamingParams.PlaylistFileName),
 			SegmentDuration: transcodeProfile.StreamingParams.SegmentDuration,
 			Stream:          streams,
 			PackFiles:       &falseValue,
 		}
 		formats = append(formats, format)
 	}
+	if clip := transcodeProfile.Clip; clip != nil {
+		for i := range formats {
+			formats[i].Start = strconv.FormatFloat(clip.Start, 'f', 3, 64)
+			if clip.Duration > 0 {
+				formats[i].Duration = strconv.FormatFloat(clip.Duration, 'f', 3, 64)
+			}
+		}
+	}
 	return formats, nil
 }
 
@@ -322,11 +348,12 @@ func (e *encodingComProvider) getOutputDestinationStatus(status []encodingcom.St
 }
 
 func (e *encodingComProvider) getOutputDestination(job *db.Job) string {
-	parts := httpS3Regexp.FindStringSubmatch(strings.Trim(e.config.EncodingCom.Destination, "/"))
+	destination := e.baseDestination(job)
+	parts := httpS3Regexp.FindStringSubmatch(strings.Trim(destination, "/"))
 	if len(parts) > 0 {
 		return fmt.Sprintf("s3://%s/%s/%s/", parts[1], parts[2], job.ID)
 	}
-	return strings.TrimRight(e.config.EncodingCom.Destination, "/") + "/" + job.ID
+	return strings.TrimRight(destination, "/") + "/" + job.ID
 }
 
 func (e *encodingComProvider) destinationMedia(input string) string {
@@ -370,9 +397,15 @@ func (e *encodingComProvider) Healthcheck() error {
 
 func (e *encodingComProvider) Capabilities() provider.Capabilities {
 	return provider.Capabilities{
-		InputFormats:  []string{"prores", "h264"},
-		OutputFormats: []string{"mp4", "hls", "webm"},
-		Destinations:  []string{"akamai", "s3"},
+		InputFormats:       []string{"prores", "h264"},
+		OutputFormats:      []string{"mp4", "hls", "webm"},
+		Destinations:       []string{"akamai", "s3"},
+		VideoCodecs:        []string{"h264", "hevc", "vp8", "vp9"},
+		AudioCodecs:        []string{"aac", "mp3", "vorbis"},
+		StreamingProtocols: []string{"hls"},
+		MaxAudioChannels:   6,
+		Clipping:           true,
+		Progress:           true,
 	}
 }
 
@@ -0,0 +1,13 @@
+// +build integration
+
+package encodingcom
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/provider/providertest"
+)
+
+func TestEncodingComIntegration(t *testing.T) {
+	providertest.Run(t, Name)
+}
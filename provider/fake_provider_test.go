@@ -50,3 +50,12 @@ func getFactory(fErr error, healthErr error, capabilities Capabilities) Factory
 		return &fakeProvider{healthErr: healthErr, cap: capabilities}, nil
 	}
 }
+
+type fakeUsageProvider struct {
+	fakeProvider
+	usage AccountUsage
+}
+
+func (f *fakeUsageProvider) AccountUsage() (*AccountUsage, error) {
+	return &f.usage, nil
+}
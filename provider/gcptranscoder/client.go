@@ -0,0 +1,198 @@
+package gcptranscoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2/google"
+)
+
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// client is a minimal client for the jobs and job templates of the Google
+// Cloud Transcoder API.
+type client struct {
+	endpoint string
+	parent   string
+	http     *http.Client
+}
+
+func newClient(endpoint, projectID, location string, httpClient *http.Client) *client {
+	return &client{
+		endpoint: strings.TrimRight(endpoint, "/") + "/",
+		parent:   "projects/" + projectID + "/locations/" + location,
+		http:     httpClient,
+	}
+}
+
+// serviceAccountClient returns an HTTP client authenticated with the
+// service account key in the given file.
+func serviceAccountClient(credentialsFile string) (*http.Client, error) {
+	data, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	jwtConfig, err := google.JWTConfigFromJSON(data, cloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+	return jwtConfig.Client(context.Background()), nil
+}
+
+// apiError is the error returned for requests rejected by the Transcoder
+// API.
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("gcptranscoder: %s (%s, code %d)", e.Message, e.Status, e.Code)
+}
+
+type transcoderJob struct {
+	Name      string            `json:"name,omitempty"`
+	Config    *jobConfig        `json:"config,omitempty"`
+	State     string            `json:"state,omitempty"`
+	Error     *apiError         `json:"error,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	StartTime string            `json:"startTime,omitempty"`
+	EndTime   string            `json:"endTime,omitempty"`
+}
+
+type jobTemplate struct {
+	Name   string            `json:"name,omitempty"`
+	Config *jobConfig        `json:"config"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type jobConfig struct {
+	Inputs            []input            `json:"inputs,omitempty"`
+	EditList          []editAtom         `json:"editList,omitempty"`
+	ElementaryStreams []elementaryStream `json:"elementaryStreams"`
+	MuxStreams        []muxStream        `json:"muxStreams"`
+	Manifests         []manifest         `json:"manifests,omitempty"`
+	Output            *output            `json:"output,omitempty"`
+}
+
+type input struct {
+	Key string `json:"key"`
+	URI string `json:"uri"`
+}
+
+type editAtom struct {
+	Key             string   `json:"key"`
+	Inputs          []string `json:"inputs"`
+	StartTimeOffset string   `json:"startTimeOffset,omitempty"`
+	EndTimeOffset   string   `json:"endTimeOffset,omitempty"`
+}
+
+type elementaryStream struct {
+	Key         string       `json:"key"`
+	VideoStream *videoStream `json:"videoStream,omitempty"`
+	AudioStream *audioStream `json:"audioStream,omitempty"`
+}
+
+type videoStream struct {
+	H264 *videoCodecSettings `json:"h264,omitempty"`
+	H265 *videoCodecSettings `json:"h265,omitempty"`
+	VP9  *videoCodecSettings `json:"vp9,omitempty"`
+}
+
+type videoCodecSettings struct {
+	WidthPixels   int64   `json:"widthPixels,omitempty"`
+	HeightPixels  int64   `json:"heightPixels,omitempty"`
+	FrameRate     float64 `json:"frameRate"`
+	BitrateBps    int64   `json:"bitrateBps"`
+	GopFrameCount int64   `json:"gopFrameCount,omitempty"`
+	Profile       string  `json:"profile,omitempty"`
+	RateControl   string  `json:"rateControlMode,omitempty"`
+}
+
+type audioStream struct {
+	Codec           string `json:"codec"`
+	BitrateBps      int64  `json:"bitrateBps"`
+	ChannelCount    int64  `json:"channelCount,omitempty"`
+	SampleRateHertz int64  `json:"sampleRateHertz,omitempty"`
+}
+
+type muxStream struct {
+	Key               string           `json:"key"`
+	FileName          string           `json:"fileName,omitempty"`
+	Container         string           `json:"container"`
+	ElementaryStreams []string         `json:"elementaryStreams"`
+	SegmentSettings   *segmentSettings `json:"segmentSettings,omitempty"`
+}
+
+type segmentSettings struct {
+	SegmentDuration string `json:"segmentDuration"`
+}
+
+type manifest struct {
+	FileName   string   `json:"fileName"`
+	Type       string   `json:"type"`
+	MuxStreams []string `json:"muxStreams"`
+}
+
+type output struct {
+	URI string `json:"uri"`
+}
+
+type jobTemplateList struct {
+	JobTemplates []jobTemplate `json:"jobTemplates"`
+}
+
+func (c *client) get(path string, result interface{}) error {
+	return c.do("GET", path, nil, result)
+}
+
+func (c *client) post(path string, body, result interface{}) error {
+	return c.do("POST", path, body, result)
+}
+
+func (c *client) delete(path string) error {
+	return c.do("DELETE", path, nil, nil)
+}
+
+// do sends a request for the given path, relative to the project and
+// location of the client.
+func (c *client) do(method, path string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.endpoint+c.parent+"/"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var errResp struct {
+			Error apiError `json:"error"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error.Code == 0 {
+			return &apiError{Code: resp.StatusCode, Message: resp.Status, Status: "UNKNOWN"}
+		}
+		return &errResp.Error
+	}
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
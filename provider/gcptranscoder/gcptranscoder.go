@@ -0,0 +1,458 @@
+// Package gcptranscoder provides a implementation of the provider that uses
+// the Google Cloud Transcoder API for transcoding media files.
+//
+// It doesn't expose any public type. In order to use the provider, one must
+// import this package and then grab the factory from the provider package:
+//
+//     import (
+//         "github.com/NYTimes/video-transcoding-api/provider"
+//         "github.com/NYTimes/video-transcoding-api/provider/gcptranscoder"
+//     )
+//
+//     func UseProvider() {
+//         factory, err := provider.GetProviderFactory(gcptranscoder.Name)
+//         // handle err and use factory to get an instance of the provider.
+//     }
+package gcptranscoder
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	// Name is the name used for registering the Google Cloud Transcoder
+	// provider in the registry of providers.
+	Name = "gcptranscoder"
+
+	// defaultFrameRate is the frame rate of the video streams. Presets
+	// don't define a frame rate, and the Transcoder API requires one.
+	defaultFrameRate       = 30
+	defaultSegmentDuration = 6
+	audioSampleRate        = 48000
+	gopModeLabel           = "gop_mode"
+)
+
+var (
+	errGCPTranscoderInvalidConfig = provider.InvalidConfigError("missing GCP Transcoder project id, credentials or destination. Please define the environment variables GCP_TRANSCODER_PROJECT_ID, GCP_TRANSCODER_CREDENTIALS_FILE and GCP_TRANSCODER_DESTINATION or set these values in the configuration file")
+
+	invalidTemplateIDChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+)
+
+func init() {
+	provider.Register(Name, gcpTranscoderFactory)
+}
+
+type gcpTranscoderProvider struct {
+	client *client
+	config *config.GCPTranscoder
+}
+
+// Transcode creates a job with the elementary streams and the mux streams of
+// the job templates of the outputs. The keys of the streams are prefixed by
+// the index of the output, so outputs using the same template don't clash.
+// Segmented (ts) mux streams are listed in the HLS manifest of the job.
+func (p *gcpTranscoderProvider) Transcode(job *db.Job, transcodeProfile provider.TranscodeProfile) (*provider.JobStatus, error) {
+	alignment := transcodeProfile.StreamingParams.KeyframeAlignment
+	if err := alignment.RequireSceneCutDisabled(Name); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(transcodeProfile.SourceMedia, "gs://") {
+		return nil, fmt.Errorf("invalid source %q: the Transcoder API only reads sources from GCS", transcodeProfile.SourceMedia)
+	}
+	destination, err := p.destination(job)
+	if err != nil {
+		return nil, err
+	}
+	segmentDuration := transcodeProfile.StreamingParams.SegmentDuration
+	if segmentDuration == 0 {
+		segmentDuration = defaultSegmentDuration
+	}
+	cfg := jobConfig{
+		Inputs: []input{{Key: "input0", URI: transcodeProfile.SourceMedia}},
+		Output: &output{URI: destination},
+	}
+	if clip := transcodeProfile.Clip; clip != nil {
+		atom := editAtom{Key: "atom0", Inputs: []string{"input0"}, StartTimeOffset: duration(clip.Start)}
+		if clip.Duration > 0 {
+			atom.EndTimeOffset = duration(clip.Start + clip.Duration)
+		}
+		cfg.EditList = []editAtom{atom}
+	}
+	gops := make([]provider.RenditionGOP, 0, len(transcodeProfile.Outputs))
+	var hlsStreams []string
+	for i, transcodeOutput := range transcodeProfile.Outputs {
+		templateID, ok := transcodeOutput.Preset.ProviderMapping[Name]
+		if !ok {
+			return nil, provider.ErrPresetMapNotFound
+		}
+		var template jobTemplate
+		if err = p.client.get("jobTemplates/"+templateID, &template); err != nil {
+			return nil, err
+		}
+		if template.Config == nil {
+			return nil, fmt.Errorf("job template %q doesn't have a config", templateID)
+		}
+		prefix := "o" + strconv.Itoa(i) + "-"
+		for _, stream := range template.Config.ElementaryStreams {
+			if settings := stream.VideoStream.settings(); settings != nil {
+				gops = append(gops, templateGOP(transcodeOutput.Preset.Name, settings, template.Labels))
+			}
+			stream.Key = prefix + stream.Key
+			cfg.ElementaryStreams = append(cfg.ElementaryStreams, stream)
+		}
+		for _, mux := range template.Config.MuxStreams {
+			mux.Key = prefix + mux.Key
+			streams := make([]string, len(mux.ElementaryStreams))
+			for j, key := range mux.ElementaryStreams {
+				streams[j] = prefix + key
+			}
+			mux.ElementaryStreams = streams
+			if mux.Container == "ts" {
+				mux.FileName = ""
+				mux.SegmentSettings = &segmentSettings{SegmentDuration: fmt.Sprintf("%ds", segmentDuration)}
+				hlsStreams = append(hlsStreams, mux.Key)
+			} else {
+				mux.FileName = transcodeOutput.FileName
+			}
+			cfg.MuxStreams = append(cfg.MuxStreams, mux)
+		}
+	}
+	if err = alignment.Validate(gops, segmentDuration); err != nil {
+		return nil, err
+	}
+	if len(hlsStreams) > 0 {
+		playlist := transcodeProfile.StreamingParams.PlaylistFileName
+		if playlist == "" {
+			playlist = "index.m3u8"
+		}
+		cfg.Manifests = []manifest{{FileName: playlist, Type: "HLS", MuxStreams: hlsStreams}}
+	}
+	var created transcoderJob
+	err = p.client.post("jobs", transcoderJob{Config: &cfg, Labels: map[string]string{"job_id": labelValue(job.ID)}}, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: path.Base(created.Name),
+		Status:        provider.StatusQueued,
+	}, nil
+}
+
+// duration formats the given number of seconds as a duration of the
+// Transcoder API.
+func duration(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64) + "s"
+}
+
+// destination returns the GCS URL where the outputs of the given job are
+// written.
+func (p *gcpTranscoderProvider) destination(job *db.Job) (string, error) {
+	destination := p.config.Destination
+	if job.Destination != "" {
+		destination = job.Destination
+	}
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme != "gs" || u.Host == "" {
+		return "", fmt.Errorf("invalid destination %q: Transcoder API outputs must be written to GCS", destination)
+	}
+	return strings.TrimRight(destination, "/") + "/" + job.ID + "/", nil
+}
+
+// settings returns the codec settings of the stream, or nil for audio
+// streams.
+func (s *videoStream) settings() *videoCodecSettings {
+	if s == nil {
+		return nil
+	}
+	switch {
+	case s.H264 != nil:
+		return s.H264
+	case s.H265 != nil:
+		return s.H265
+	}
+	return s.VP9
+}
+
+func templateGOP(name string, settings *videoCodecSettings, labels map[string]string) provider.RenditionGOP {
+	gop := provider.RenditionGOP{Name: name, Fixed: labels[gopModeLabel] == "fixed"}
+	if settings.GopFrameCount > 0 {
+		gop.Size = strconv.FormatInt(settings.GopFrameCount, 10)
+		if settings.FrameRate > 0 {
+			gop.Duration = float64(settings.GopFrameCount) / settings.FrameRate
+		}
+	}
+	return gop
+}
+
+func (p *gcpTranscoderProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
+	var resp transcoderJob
+	if err := p.client.get("jobs/"+job.ProviderJobID, &resp); err != nil {
+		if apiErr, ok := err.(*apiError); ok && apiErr.Code == http.StatusNotFound {
+			return nil, provider.JobNotFoundError{ID: job.ProviderJobID}
+		}
+		return nil, err
+	}
+	jobStatus := provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: job.ProviderJobID,
+		Status:        p.statusMap(resp.State),
+		ProviderStatus: map[string]interface{}{
+			"state": resp.State,
+		},
+	}
+	if resp.StartTime != "" {
+		jobStatus.ProviderStatus["startTime"] = resp.StartTime
+	}
+	if resp.EndTime != "" {
+		jobStatus.ProviderStatus["endTime"] = resp.EndTime
+	}
+	if resp.Error != nil {
+		jobStatus.StatusMessage = resp.Error.Message
+	}
+	if cfg := resp.Config; cfg != nil && cfg.Output != nil {
+		jobStatus.Output.Destination = strings.TrimRight(cfg.Output.URI, "/")
+		if jobStatus.Status == provider.StatusFinished {
+			jobStatus.Output.Files = outputFiles(cfg)
+		}
+	}
+	if jobStatus.Status == provider.StatusFinished {
+		jobStatus.Progress = 100
+	}
+	return &jobStatus, nil
+}
+
+// outputFiles lists the manifests and the non-segmented files generated by
+// the job.
+func outputFiles(cfg *jobConfig) []provider.OutputFile {
+	var names []string
+	for _, m := range cfg.Manifests {
+		names = append(names, m.FileName)
+	}
+	for _, mux := range cfg.MuxStreams {
+		if mux.SegmentSettings == nil && mux.FileName != "" {
+			names = append(names, mux.FileName)
+		}
+	}
+	files := make([]provider.OutputFile, len(names))
+	for i, name := range names {
+		files[i] = provider.OutputFile{
+			Path:      strings.TrimRight(cfg.Output.URI, "/") + "/" + name,
+			Container: strings.TrimPrefix(path.Ext(name), "."),
+		}
+	}
+	return files
+}
+
+func (p *gcpTranscoderProvider) statusMap(state string) provider.Status {
+	switch state {
+	case "PENDING":
+		return provider.StatusQueued
+	case "RUNNING":
+		return provider.StatusStarted
+	case "SUCCEEDED":
+		return provider.StatusFinished
+	case "FAILED":
+		return provider.StatusFailed
+	default:
+		return provider.StatusUnknown
+	}
+}
+
+// CancelJob deletes the job, as the Transcoder API doesn't support canceling
+// jobs. Deleting a running job stops it.
+func (p *gcpTranscoderProvider) CancelJob(id string) error {
+	return p.client.delete("jobs/" + id)
+}
+
+// CreatePreset creates a job template with the elementary streams of the
+// preset and a mux stream named "output". Presets using the m3u8 container
+// are muxed into ts segments.
+func (p *gcpTranscoderProvider) CreatePreset(preset db.Preset) (string, error) {
+	var container string
+	switch strings.ToLower(preset.Container) {
+	case "mp4":
+		container = "mp4"
+	case "m3u8":
+		container = "ts"
+	default:
+		return "", fmt.Errorf("unsupported container %q", preset.Container)
+	}
+	cfg := jobConfig{}
+	labels := make(map[string]string)
+	if preset.Video != (db.VideoPreset{}) {
+		stream, err := videoElementaryStream(preset)
+		if err != nil {
+			return "", err
+		}
+		cfg.ElementaryStreams = append(cfg.ElementaryStreams, *stream)
+		if preset.Video.GopMode == "fixed" {
+			labels[gopModeLabel] = "fixed"
+		}
+	}
+	if preset.Audio != (db.AudioPreset{}) {
+		stream, err := audioElementaryStream(preset)
+		if err != nil {
+			return "", err
+		}
+		cfg.ElementaryStreams = append(cfg.ElementaryStreams, *stream)
+	}
+	if len(cfg.ElementaryStreams) == 0 {
+		return "", errors.New("the preset must define video or audio settings")
+	}
+	mux := muxStream{Key: "output", Container: container}
+	for _, stream := range cfg.ElementaryStreams {
+		mux.ElementaryStreams = append(mux.ElementaryStreams, stream.Key)
+	}
+	cfg.MuxStreams = []muxStream{mux}
+	id := templateID(preset.Name)
+	var template jobTemplate
+	err := p.client.post("jobTemplates?jobTemplateId="+url.QueryEscape(id), jobTemplate{Config: &cfg, Labels: labels}, &template)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func videoElementaryStream(preset db.Preset) (*elementaryStream, error) {
+	bitrate, err := atoi64(preset.Video.Bitrate)
+	if err != nil || bitrate == 0 {
+		return nil, fmt.Errorf("invalid video bitrate %q", preset.Video.Bitrate)
+	}
+	settings := videoCodecSettings{FrameRate: defaultFrameRate, BitrateBps: bitrate}
+	if settings.WidthPixels, err = atoi64(preset.Video.Width); err != nil {
+		return nil, fmt.Errorf("invalid video width %q", preset.Video.Width)
+	}
+	if settings.HeightPixels, err = atoi64(preset.Video.Height); err != nil {
+		return nil, fmt.Errorf("invalid video height %q", preset.Video.Height)
+	}
+	if settings.GopFrameCount, err = atoi64(preset.Video.GopSize); err != nil {
+		return nil, fmt.Errorf("invalid GOP size %q", preset.Video.GopSize)
+	}
+	switch strings.ToLower(preset.RateControl) {
+	case "":
+	case "vbr", "crf":
+		settings.RateControl = strings.ToLower(preset.RateControl)
+	default:
+		return nil, fmt.Errorf("unsupported rate control %q", preset.RateControl)
+	}
+	var stream videoStream
+	switch preset.Video.Codec {
+	case "h264":
+		settings.Profile = strings.ToLower(preset.Profile)
+		stream.H264 = &settings
+	case "hevc", "h265":
+		settings.Profile = strings.ToLower(preset.Profile)
+		stream.H265 = &settings
+	case "vp9":
+		stream.VP9 = &settings
+	default:
+		return nil, fmt.Errorf("unsupported video codec %q", preset.Video.Codec)
+	}
+	return &elementaryStream{Key: "video", VideoStream: &stream}, nil
+}
+
+func audioElementaryStream(preset db.Preset) (*elementaryStream, error) {
+	bitrate, err := atoi64(preset.Audio.Bitrate)
+	if err != nil || bitrate == 0 {
+		return nil, fmt.Errorf("invalid audio bitrate %q", preset.Audio.Bitrate)
+	}
+	codec := strings.TrimPrefix(strings.ToLower(preset.Audio.Codec), "lib")
+	switch codec {
+	case "aac", "mp3", "opus", "vorbis":
+	default:
+		return nil, fmt.Errorf("unsupported audio codec %q", preset.Audio.Codec)
+	}
+	return &elementaryStream{
+		Key: "audio",
+		AudioStream: &audioStream{
+			Codec:           codec,
+			BitrateBps:      bitrate,
+			ChannelCount:    2,
+			SampleRateHertz: audioSampleRate,
+		},
+	}, nil
+}
+
+// templateID returns the id of the job template of the given preset. Ids
+// must start with a letter and contain only lowercase letters, digits,
+// underscores and hyphens.
+func templateID(presetName string) string {
+	id := strings.Trim(invalidTemplateIDChars.ReplaceAllString(strings.ToLower(presetName), "-"), "-")
+	if id == "" || id[0] < 'a' || id[0] > 'z' {
+		id = "preset-" + id
+	}
+	return id
+}
+
+// labelValue returns the given value in the format accepted in labels.
+func labelValue(value string) string {
+	value = invalidTemplateIDChars.ReplaceAllString(strings.ToLower(value), "_")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return value
+}
+
+func atoi64(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (p *gcpTranscoderProvider) GetPreset(presetID string) (interface{}, error) {
+	var template jobTemplate
+	if err := p.client.get("jobTemplates/"+presetID, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (p *gcpTranscoderProvider) DeletePreset(presetID string) error {
+	return p.client.delete("jobTemplates/" + presetID)
+}
+
+func (p *gcpTranscoderProvider) Healthcheck() error {
+	var templates jobTemplateList
+	return p.client.get("jobTemplates?pageSize=1", &templates)
+}
+
+func (p *gcpTranscoderProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		InputFormats:       []string{"prores", "h264", "h265", "vp9"},
+		OutputFormats:      []string{"mp4", "hls"},
+		Destinations:       []string{"gcs"},
+		VideoCodecs:        []string{"h264", "hevc", "vp9"},
+		AudioCodecs:        []string{"aac", "mp3", "opus", "vorbis"},
+		StreamingProtocols: []string{"hls"},
+		MaxAudioChannels:   2,
+		Clipping:           true,
+	}
+}
+
+func gcpTranscoderFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
+	gCfg := cfg.GCPTranscoder
+	if gCfg == nil || gCfg.ProjectID == "" || gCfg.CredentialsFile == "" || gCfg.Destination == "" {
+		return nil, errGCPTranscoderInvalidConfig
+	}
+	httpClient, err := serviceAccountClient(gCfg.CredentialsFile)
+	if err != nil {
+		return nil, provider.InvalidConfigError(fmt.Sprintf("invalid GCP Transcoder credentials: %s", err))
+	}
+	return &gcpTranscoderProvider{
+		client: newClient(gCfg.Endpoint, gCfg.ProjectID, gCfg.Location, httpClient),
+		config: gCfg,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package gcptranscoder
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const testParent = "projects/test-project/locations/us-central1"
+
+// transcoderFakeServer is a fake version of the Transcoder API, that stores
+// the job templates and the jobs in memory.
+type transcoderFakeServer struct {
+	*httptest.Server
+	mtx       sync.Mutex
+	lastID    int
+	templates map[string]jobTemplate
+	jobs      map[string]transcoderJob
+}
+
+func newTranscoderFakeServer() *transcoderFakeServer {
+	server := transcoderFakeServer{
+		templates: make(map[string]jobTemplate),
+		jobs:      make(map[string]transcoderJob),
+	}
+	server.Server = httptest.NewServer(&server)
+	return &server
+}
+
+func (s *transcoderFakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	prefix := "/v1/" + testParent + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		s.writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown parent")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	collection, id := parts[0], ""
+	if len(parts) > 1 {
+		id = parts[1]
+	}
+	switch {
+	case collection == "jobTemplates" && r.Method == "POST":
+		var template jobTemplate
+		json.NewDecoder(r.Body).Decode(&template)
+		id = r.URL.Query().Get("jobTemplateId")
+		if _, ok := s.templates[id]; ok {
+			s.writeError(w, http.StatusConflict, "ALREADY_EXISTS", "job template already exists")
+			return
+		}
+		template.Name = testParent + "/jobTemplates/" + id
+		s.templates[id] = template
+		json.NewEncoder(w).Encode(template)
+	case collection == "jobTemplates" && r.Method == "GET" && id == "":
+		var list jobTemplateList
+		for _, template := range s.templates {
+			list.JobTemplates = append(list.JobTemplates, template)
+		}
+		json.NewEncoder(w).Encode(list)
+	case collection == "jobTemplates" && r.Method == "GET":
+		template, ok := s.templates[id]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "NOT_FOUND", "job template not found")
+			return
+		}
+		json.NewEncoder(w).Encode(template)
+	case collection == "jobTemplates" && r.Method == "DELETE":
+		if _, ok := s.templates[id]; !ok {
+			s.writeError(w, http.StatusNotFound, "NOT_FOUND", "job template not found")
+			return
+		}
+		delete(s.templates, id)
+		w.Write([]byte("{}"))
+	case collection == "jobs" && r.Method == "POST":
+		var job transcoderJob
+		json.NewDecoder(r.Body).Decode(&job)
+		s.lastID++
+		id = "job-" + strconv.Itoa(s.lastID)
+		job.Name = testParent + "/jobs/" + id
+		job.State = "PENDING"
+		s.jobs[id] = job
+		json.NewEncoder(w).Encode(job)
+	case collection == "jobs" && r.Method == "GET":
+		job, ok := s.jobs[id]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "NOT_FOUND", "job not found")
+			return
+		}
+		json.NewEncoder(w).Encode(job)
+	case collection == "jobs" && r.Method == "DELETE":
+		if _, ok := s.jobs[id]; !ok {
+			s.writeError(w, http.StatusNotFound, "NOT_FOUND", "job not found")
+			return
+		}
+		delete(s.jobs, id)
+		w.Write([]byte("{}"))
+	default:
+		s.writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown resource")
+	}
+}
+
+// setState changes the state of the given job.
+func (s *transcoderFakeServer) setState(id, state string, err *apiError) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	job := s.jobs[id]
+	job.State = state
+	job.Error = err
+	s.jobs[id] = job
+}
+
+func (s *transcoderFakeServer) writeError(w http.ResponseWriter, code int, status, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": apiError{Code: code, Message: message, Status: status},
+	})
+}
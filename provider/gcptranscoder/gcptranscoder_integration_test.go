@@ -0,0 +1,13 @@
+// +build integration
+
+package gcptranscoder
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/provider/providertest"
+)
+
+func TestGCPTranscoderIntegration(t *testing.T) {
+	providertest.Run(t, Name)
+}
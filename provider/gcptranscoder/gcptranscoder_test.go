@@ -0,0 +1,470 @@
+package gcptranscoder
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func newTestProvider(server *transcoderFakeServer) *gcpTranscoderProvider {
+	cfg := config.GCPTranscoder{
+		ProjectID:   "test-project",
+		Location:    "us-central1",
+		Destination: "gs://some-bucket/outputs/",
+		Endpoint:    server.URL + "/v1/",
+	}
+	return &gcpTranscoderProvider{
+		client: newClient(cfg.Endpoint, cfg.ProjectID, cfg.Location, http.DefaultClient),
+		config: &cfg,
+	}
+}
+
+func createTestPreset(t *testing.T, prov *gcpTranscoderProvider, name, container string) db.PresetMap {
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:      name,
+		Container: container,
+		Profile:   "main",
+		Video:     db.VideoPreset{Codec: "h264", Width: "1280", Height: "720", Bitrate: "3500000", GopSize: "90", GopMode: "fixed"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.PresetMap{Name: name, ProviderMapping: map[string]string{Name: presetID}}
+}
+
+func TestFactoryIsRegistered(t *testing.T) {
+	_, err := provider.GetProviderFactory(Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGCPTranscoderFactoryValidation(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		cfg      *config.GCPTranscoder
+	}{
+		{"missing config", nil},
+		{"empty config", &config.GCPTranscoder{}},
+		{"missing project", &config.GCPTranscoder{CredentialsFile: "/tmp/creds.json", Destination: "gs://bucket/"}},
+		{"missing credentials", &config.GCPTranscoder{ProjectID: "project", Destination: "gs://bucket/"}},
+		{"missing destination", &config.GCPTranscoder{ProjectID: "project", CredentialsFile: "/tmp/creds.json"}},
+	}
+	for _, test := range tests {
+		_, err := gcpTranscoderFactory(&config.Config{GCPTranscoder: test.cfg})
+		if err != errGCPTranscoderInvalidConfig {
+			t.Errorf("%s: wrong error returned. Want %#v. Got %#v", test.testCase, errGCPTranscoderInvalidConfig, err)
+		}
+	}
+	_, err := gcpTranscoderFactory(&config.Config{GCPTranscoder: &config.GCPTranscoder{
+		ProjectID:       "project",
+		CredentialsFile: "/path/to/missing.json",
+		Destination:     "gs://bucket/",
+	}})
+	if _, ok := err.(provider.InvalidConfigError); !ok {
+		t.Errorf("wrong error returned for missing credentials file: %#v", err)
+	}
+}
+
+func TestCreatePreset(t *testing.T) {
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:        "HLS 720p",
+		Description: "HLS 720p",
+		Container:   "m3u8",
+		Profile:     "Main",
+		RateControl: "VBR",
+		Video:       db.VideoPreset{Codec: "h264", Width: "1280", Height: "720", Bitrate: "3500000", GopSize: "90", GopMode: "fixed"},
+		Audio:       db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetID != "hls-720p" {
+		t.Errorf("wrong preset id. Want %q. Got %q", "hls-720p", presetID)
+	}
+	expected := jobTemplate{
+		Name: testParent + "/jobTemplates/hls-720p",
+		Config: &jobConfig{
+			ElementaryStreams: []elementaryStream{
+				{
+					Key: "video",
+					VideoStream: &videoStream{H264: &videoCodecSettings{
+						WidthPixels:   1280,
+						HeightPixels:  720,
+						FrameRate:     30,
+						BitrateBps:    3500000,
+						GopFrameCount: 90,
+						Profile:       "main",
+						RateControl:   "vbr",
+					}},
+				},
+				{
+					Key:         "audio",
+					AudioStream: &audioStream{Codec: "aac", BitrateBps: 128000, ChannelCount: 2, SampleRateHertz: 48000},
+				},
+			},
+			MuxStreams: []muxStream{{Key: "output", Container: "ts", ElementaryStreams: []string{"video", "audio"}}},
+		},
+		Labels: map[string]string{"gop_mode": "fixed"},
+	}
+	if got := server.templates["hls-720p"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong job template created.\nWant %#v\nGot  %#v", expected, got)
+	}
+}
+
+func TestCreatePresetValidation(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		preset   db.Preset
+		errMsg   string
+	}{
+		{
+			"unsupported container",
+			db.Preset{Name: "webm", Container: "webm", Video: db.VideoPreset{Codec: "vp9", Bitrate: "1000000"}},
+			`unsupported container "webm"`,
+		},
+		{
+			"unsupported video codec",
+			db.Preset{Name: "mpeg2", Container: "mp4", Video: db.VideoPreset{Codec: "mpeg2", Bitrate: "1000000"}},
+			`unsupported video codec "mpeg2"`,
+		},
+		{
+			"unsupported audio codec",
+			db.Preset{Name: "ac3", Container: "mp4", Audio: db.AudioPreset{Codec: "ac3", Bitrate: "384000"}},
+			`unsupported audio codec "ac3"`,
+		},
+		{
+			"invalid bitrate",
+			db.Preset{Name: "mp4", Container: "mp4", Video: db.VideoPreset{Codec: "h264", Bitrate: "1M"}},
+			`invalid video bitrate "1M"`,
+		},
+		{
+			"no streams",
+			db.Preset{Name: "empty", Container: "mp4"},
+			"the preset must define video or audio settings",
+		},
+	}
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	for _, test := range tests {
+		_, err := prov.CreatePreset(test.preset)
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
+
+func TestTemplateID(t *testing.T) {
+	var tests = []struct {
+		presetName string
+		expected   string
+	}{
+		{"mp4_1080p", "mp4_1080p"},
+		{"HLS 720p", "hls-720p"},
+		{"1080p.mp4", "preset-1080p-mp4"},
+	}
+	for _, test := range tests {
+		if got := templateID(test.presetName); got != test.expected {
+			t.Errorf("templateID(%q): wrong value. Want %q. Got %q", test.presetName, test.expected, got)
+		}
+	}
+}
+
+func TestGetAndDeletePreset(t *testing.T) {
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetMap := createTestPreset(t, prov, "mp4_720p", "mp4")
+	preset, err := prov.GetPreset(presetMap.ProviderMapping[Name])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template := preset.(*jobTemplate); template.Config.MuxStreams[0].Container != "mp4" {
+		t.Errorf("wrong preset returned: %#v", template)
+	}
+	if err = prov.DeletePreset(presetMap.ProviderMapping[Name]); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.templates) != 0 {
+		t.Errorf("the job template wasn't deleted: %#v", server.templates)
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	hls := createTestPreset(t, prov, "hls_720p", "m3u8")
+	mp4 := createTestPreset(t, prov, "mp4_720p", "mp4")
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "gs://source-bucket/master.mov",
+		Outputs: []provider.TranscodeOutput{
+			{Preset: hls, FileName: "hls/video_720p.m3u8"},
+			{Preset: mp4, FileName: "video_720p.mp4"},
+		},
+		StreamingParams: provider.StreamingParams{
+			Protocol:          "hls",
+			PlaylistFileName:  "hls/index.m3u8",
+			SegmentDuration:   4,
+			KeyframeAlignment: &provider.KeyframeAlignment{Enabled: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobStatus.ProviderJobID != "job-1" || jobStatus.Status != provider.StatusQueued || jobStatus.ProviderName != Name {
+		t.Errorf("wrong job status returned: %#v", jobStatus)
+	}
+	job := server.jobs["job-1"]
+	if job.Labels["job_id"] != "job-123" {
+		t.Errorf("wrong job labels: %#v", job.Labels)
+	}
+	cfg := job.Config
+	if !reflect.DeepEqual(cfg.Inputs, []input{{Key: "input0", URI: "gs://source-bucket/master.mov"}}) {
+		t.Errorf("wrong inputs: %#v", cfg.Inputs)
+	}
+	if cfg.Output.URI != "gs://some-bucket/outputs/job-123/" {
+		t.Errorf("wrong output uri: %q", cfg.Output.URI)
+	}
+	var streamKeys []string
+	for _, stream := range cfg.ElementaryStreams {
+		streamKeys = append(streamKeys, stream.Key)
+	}
+	if expected := []string{"o0-video", "o0-audio", "o1-video", "o1-audio"}; !reflect.DeepEqual(streamKeys, expected) {
+		t.Errorf("wrong elementary streams. Want %v. Got %v", expected, streamKeys)
+	}
+	expectedMuxStreams := []muxStream{
+		{
+			Key:               "o0-output",
+			Container:         "ts",
+			ElementaryStreams: []string{"o0-video", "o0-audio"},
+			SegmentSettings:   &segmentSettings{SegmentDuration: "4s"},
+		},
+		{
+			Key:               "o1-output",
+			FileName:          "video_720p.mp4",
+			Container:         "mp4",
+			ElementaryStreams: []string{"o1-video", "o1-audio"},
+		},
+	}
+	if !reflect.DeepEqual(cfg.MuxStreams, expectedMuxStreams) {
+		t.Errorf("wrong mux streams.\nWant %#v\nGot  %#v", expectedMuxStreams, cfg.MuxStreams)
+	}
+	expectedManifests := []manifest{{FileName: "hls/index.m3u8", Type: "HLS", MuxStreams: []string{"o0-output"}}}
+	if !reflect.DeepEqual(cfg.Manifests, expectedManifests) {
+		t.Errorf("wrong manifests.\nWant %#v\nGot  %#v", expectedManifests, cfg.Manifests)
+	}
+}
+
+func TestTranscodeClip(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		clip     provider.Clip
+		expected editAtom
+	}{
+		{
+			"start",
+			provider.Clip{Start: 10},
+			editAtom{Key: "atom0", Inputs: []string{"input0"}, StartTimeOffset: "10.000s"},
+		},
+		{
+			"start and duration",
+			provider.Clip{Start: 2.5, Duration: 30},
+			editAtom{Key: "atom0", Inputs: []string{"input0"}, StartTimeOffset: "2.500s", EndTimeOffset: "32.500s"},
+		},
+	}
+	for _, test := range tests {
+		server := newTranscoderFakeServer()
+		prov := newTestProvider(server)
+		clip := test.clip
+		jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			SourceMedia: "gs://source-bucket/master.mov",
+			Outputs:     []provider.TranscodeOutput{{Preset: createTestPreset(t, prov, "mp4_720p", "mp4"), FileName: "video_720p.mp4"}},
+			Clip:        &clip,
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			server.Close()
+			continue
+		}
+		editList := server.jobs[jobStatus.ProviderJobID].Config.EditList
+		if !reflect.DeepEqual(editList, []editAtom{test.expected}) {
+			t.Errorf("%s: wrong edit list\nWant %#v\nGot  %#v", test.testCase, []editAtom{test.expected}, editList)
+		}
+		server.Close()
+	}
+}
+
+func TestTranscodeValidation(t *testing.T) {
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	presetMap := createTestPreset(t, prov, "mp4_720p", "mp4")
+	var tests = []struct {
+		testCase  string
+		job       db.Job
+		source    string
+		presetMap db.PresetMap
+		errMsg    string
+	}{
+		{
+			"source outside GCS",
+			db.Job{ID: "job-123"},
+			"s3://source-bucket/master.mov",
+			presetMap,
+			`invalid source "s3://source-bucket/master.mov": the Transcoder API only reads sources from GCS`,
+		},
+		{
+			"destination outside GCS",
+			db.Job{ID: "job-123", Destination: "s3://some-bucket/"},
+			"gs://source-bucket/master.mov",
+			presetMap,
+			`invalid destination "s3://some-bucket/": Transcoder API outputs must be written to GCS`,
+		},
+		{
+			"preset not found",
+			db.Job{ID: "job-123"},
+			"gs://source-bucket/master.mov",
+			db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{"zencoder": "123"}},
+			provider.ErrPresetMapNotFound.Error(),
+		},
+	}
+	for _, test := range tests {
+		_, err := prov.Transcode(&test.job, provider.TranscodeProfile{
+			SourceMedia: test.source,
+			Outputs:     []provider.TranscodeOutput{{Preset: test.presetMap, FileName: "video.mp4"}},
+		})
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
+
+func TestJobStatus(t *testing.T) {
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	job := db.Job{ID: "job-123"}
+	jobStatus, err := prov.Transcode(&job, provider.TranscodeProfile{
+		SourceMedia: "gs://source-bucket/master.mov",
+		Outputs: []provider.TranscodeOutput{
+			{Preset: createTestPreset(t, prov, "hls_720p", "m3u8"), FileName: "hls/video_720p.m3u8"},
+			{Preset: createTestPreset(t, prov, "mp4_720p", "mp4"), FileName: "video_720p.mp4"},
+		},
+		StreamingParams: provider.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/index.m3u8"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.ProviderJobID = jobStatus.ProviderJobID
+
+	server.setState(job.ProviderJobID, "RUNNING", nil)
+	jobStatus, err = prov.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobStatus.Status != provider.StatusStarted || jobStatus.ProviderStatus["state"] != "RUNNING" {
+		t.Errorf("wrong status for running job: %#v", jobStatus)
+	}
+
+	server.setState(job.ProviderJobID, "SUCCEEDED", nil)
+	jobStatus, err = prov.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := provider.JobOutput{
+		Destination: "gs://some-bucket/outputs/job-123",
+		Files: []provider.OutputFile{
+			{Path: "gs://some-bucket/outputs/job-123/hls/index.m3u8", Container: "m3u8"},
+			{Path: "gs://some-bucket/outputs/job-123/video_720p.mp4", Container: "mp4"},
+		},
+	}
+	if jobStatus.Status != provider.StatusFinished || jobStatus.Progress != 100 {
+		t.Errorf("wrong status for finished job: %#v", jobStatus)
+	}
+	if !reflect.DeepEqual(jobStatus.Output, expectedOutput) {
+		t.Errorf("wrong job output.\nWant %#v\nGot  %#v", expectedOutput, jobStatus.Output)
+	}
+
+	server.setState(job.ProviderJobID, "FAILED", &apiError{Code: 3, Message: "input file not found"})
+	jobStatus, err = prov.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobStatus.Status != provider.StatusFailed || jobStatus.StatusMessage != "input file not found" {
+		t.Errorf("wrong status for failed job: %#v", jobStatus)
+	}
+}
+
+func TestJobStatusNotFound(t *testing.T) {
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	_, err := prov.JobStatus(&db.Job{ID: "job-123", ProviderJobID: "some-job"})
+	if err != (provider.JobNotFoundError{ID: "some-job"}) {
+		t.Errorf("wrong error returned: %#v", err)
+	}
+}
+
+func TestStatusMap(t *testing.T) {
+	var tests = []struct {
+		state    string
+		expected provider.Status
+	}{
+		{"PENDING", provider.StatusQueued},
+		{"RUNNING", provider.StatusStarted},
+		{"SUCCEEDED", provider.StatusFinished},
+		{"FAILED", provider.StatusFailed},
+		{"PROCESSING_STATE_UNSPECIFIED", provider.StatusUnknown},
+	}
+	var prov gcpTranscoderProvider
+	for _, test := range tests {
+		if got := prov.statusMap(test.state); got != test.expected {
+			t.Errorf("statusMap(%q): wrong value. Want %q. Got %q", test.state, test.expected, got)
+		}
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "gs://source-bucket/master.mov",
+		Outputs:     []provider.TranscodeOutput{{Preset: createTestPreset(t, prov, "mp4_720p", "mp4"), FileName: "video.mp4"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = prov.CancelJob(jobStatus.ProviderJobID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.jobs[jobStatus.ProviderJobID]; ok {
+		t.Error("the job wasn't deleted")
+	}
+	if err = prov.CancelJob("some-job"); err == nil {
+		t.Error("unexpected <nil> error when canceling non existing job")
+	}
+}
+
+func TestHealthcheck(t *testing.T) {
+	server := newTranscoderFakeServer()
+	defer server.Close()
+	prov := newTestProvider(server)
+	if err := prov.Healthcheck(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	prov.client.parent = "projects/other-project/locations/us-central1"
+	if err := prov.Healthcheck(); err == nil {
+		t.Error("unexpected <nil> error with the wrong project")
+	}
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// SceneCutDisabled disables the insertion of keyframes on scene
+	// changes, so keyframes are placed only at the GOP interval.
+	SceneCutDisabled = "disabled"
+
+	// SceneCutSynchronized keeps keyframes on scene changes, but inserts
+	// them at the same position in every rendition.
+	SceneCutSynchronized = "synchronized"
+)
+
+// KeyframeAlignment defines how keyframes should be placed across all the
+// renditions of a job. Aligned keyframes are required for seamless switching
+// between renditions in adaptive streaming.
+//
+// swagger:model
+type KeyframeAlignment struct {
+	// forces identical keyframe placement in all renditions
+	Enabled bool `json:"enabled"`
+
+	// how keyframes on scene changes are handled, either "disabled"
+	// (default) or "synchronized"
+	SceneCut string `json:"sceneCut,omitempty"`
+}
+
+// KeyframeAlignmentError is returned by providers when the renditions of a
+// job can't have their keyframes aligned.
+type KeyframeAlignmentError string
+
+func (err KeyframeAlignmentError) Error() string {
+	return string(err)
+}
+
+// RenditionGOP describes the GOP settings of a rendition, as configured in
+// the provider. Duration is the length of the GOP in seconds, when the
+// provider knows the frame rate of the rendition.
+type RenditionGOP struct {
+	Name     string
+	Size     string
+	Fixed    bool
+	Duration float64
+}
+
+// Validate checks that the given renditions can have their keyframes
+// aligned: all of them must use the same GOP size, and every segment must
+// start on a keyframe, so the segment duration must be a multiple of the
+// duration of the GOPs. GOPs must also be fixed, unless keyframes on scene
+// changes are synchronized.
+func (a *KeyframeAlignment) Validate(gops []RenditionGOP, segmentDuration uint) error {
+	if a == nil || !a.Enabled {
+		return nil
+	}
+	if a.SceneCut != "" && a.SceneCut != SceneCutDisabled && a.SceneCut != SceneCutSynchronized {
+		return KeyframeAlignmentError(fmt.Sprintf("invalid sceneCut %q", a.SceneCut))
+	}
+	var size string
+	for _, gop := range gops {
+		if gop.Size == "" {
+			return KeyframeAlignmentError(fmt.Sprintf("rendition %q doesn't define a GOP size", gop.Name))
+		}
+		if !gop.Fixed && a.SceneCut != SceneCutSynchronized {
+			return KeyframeAlignmentError(fmt.Sprintf("rendition %q doesn't use a fixed GOP", gop.Name))
+		}
+		if size == "" {
+			size = gop.Size
+		} else if gop.Size != size {
+			return KeyframeAlignmentError(fmt.Sprintf("rendition %q has GOP size %s, expected %s", gop.Name, gop.Size, size))
+		}
+		if gop.Duration > 0 && segmentDuration > 0 {
+			// fractional frame rates make GOPs slightly longer than
+			// the nominal duration, so a small deviation is accepted.
+			count := float64(segmentDuration) / gop.Duration
+			whole := math.Floor(count + 0.5)
+			if whole < 1 || math.Abs(count-whole)/whole > 0.01 {
+				return KeyframeAlignmentError(fmt.Sprintf("rendition %q has GOPs of %gs, which don't divide the segment duration of %ds", gop.Name, gop.Duration, segmentDuration))
+			}
+		}
+	}
+	return nil
+}
+
+// RequireSceneCutDisabled returns an error if the alignment requires
+// synchronized scene-cut keyframes, for providers that don't support it.
+func (a *KeyframeAlignment) RequireSceneCutDisabled(providerName string) error {
+	if a != nil && a.Enabled && a.SceneCut == SceneCutSynchronized {
+		return KeyframeAlignmentError(fmt.Sprintf("synchronized scene-cut keyframes are not supported by %s", providerName))
+	}
+	return nil
+}
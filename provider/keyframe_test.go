@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyframeAlignmentValidate(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		alignment *KeyframeAlignment
+		gops      []RenditionGOP
+		segment   uint
+		errMsg    string
+	}{
+		{
+			"no alignment",
+			nil,
+			[]RenditionGOP{{Name: "720p", Size: "90"}, {Name: "1080p", Size: "60"}},
+			6,
+			"",
+		},
+		{
+			"aligned renditions",
+			&KeyframeAlignment{Enabled: true},
+			[]RenditionGOP{{Name: "720p", Size: "90", Fixed: true}, {Name: "1080p", Size: "90", Fixed: true}},
+			6,
+			"",
+		},
+		{
+			"different GOP sizes",
+			&KeyframeAlignment{Enabled: true},
+			[]RenditionGOP{{Name: "720p", Size: "90", Fixed: true}, {Name: "1080p", Size: "60", Fixed: true}},
+			6,
+			`rendition "1080p" has GOP size 60, expected 90`,
+		},
+		{
+			"variable GOP",
+			&KeyframeAlignment{Enabled: true, SceneCut: SceneCutDisabled},
+			[]RenditionGOP{{Name: "720p", Size: "90", Fixed: true}, {Name: "1080p", Size: "90"}},
+			6,
+			`rendition "1080p" doesn't use a fixed GOP`,
+		},
+		{
+			"variable GOP with synchronized scene-cut",
+			&KeyframeAlignment{Enabled: true, SceneCut: SceneCutSynchronized},
+			[]RenditionGOP{{Name: "720p", Size: "90"}, {Name: "1080p", Size: "90"}},
+			6,
+			"",
+		},
+		{
+			"missing GOP size",
+			&KeyframeAlignment{Enabled: true},
+			[]RenditionGOP{{Name: "720p", Fixed: true}},
+			6,
+			`rendition "720p" doesn't define a GOP size`,
+		},
+		{
+			"invalid scene-cut",
+			&KeyframeAlignment{Enabled: true, SceneCut: "sometimes"},
+			nil,
+			6,
+			`invalid sceneCut "sometimes"`,
+		},
+		{
+			"GOPs dividing the segment duration",
+			&KeyframeAlignment{Enabled: true},
+			[]RenditionGOP{{Name: "720p", Size: "60", Fixed: true, Duration: 2.002}, {Name: "1080p", Size: "60", Fixed: true, Duration: 2}},
+			6,
+			"",
+		},
+		{
+			"GOPs not dividing the segment duration",
+			&KeyframeAlignment{Enabled: true},
+			[]RenditionGOP{{Name: "720p", Size: "120", Fixed: true, Duration: 4}},
+			6,
+			`rendition "720p" has GOPs of 4s, which don't divide the segment duration of 6s`,
+		},
+		{
+			"GOPs longer than the segments",
+			&KeyframeAlignment{Enabled: true},
+			[]RenditionGOP{{Name: "720p", Size: "300", Fixed: true, Duration: 10}},
+			6,
+			`rendition "720p" has GOPs of 10s, which don't divide the segment duration of 6s`,
+		},
+		{
+			"unknown segment duration",
+			&KeyframeAlignment{Enabled: true},
+			[]RenditionGOP{{Name: "720p", Size: "120", Fixed: true, Duration: 4}},
+			0,
+			"",
+		},
+	}
+	for _, test := range tests {
+		err := test.alignment.Validate(test.gops, test.segment)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestKeyframeAlignmentRequireSceneCutDisabled(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		alignment *KeyframeAlignment
+		errMsg    string
+	}{
+		{"no alignment", nil, ""},
+		{"disabled scene-cut", &KeyframeAlignment{Enabled: true, SceneCut: SceneCutDisabled}, ""},
+		{"default scene-cut", &KeyframeAlignment{Enabled: true}, ""},
+		{"synchronized scene-cut without alignment", &KeyframeAlignment{SceneCut: SceneCutSynchronized}, ""},
+		{
+			"synchronized scene-cut",
+			&KeyframeAlignment{Enabled: true, SceneCut: SceneCutSynchronized},
+			"synchronized scene-cut keyframes are not supported by zencoder",
+		},
+	}
+	for _, test := range tests {
+		err := test.alignment.RequireSceneCutDisabled("zencoder")
+		if err == nil {
+			err = errors.New("")
+		} else if _, ok := err.(KeyframeAlignmentError); !ok {
+			t.Errorf("%s: wrong error type returned. Want KeyframeAlignmentError. Got %#v", test.testCase, err)
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
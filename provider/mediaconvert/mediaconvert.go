@@ -0,0 +1,933 @@
+// Package mediaconvert provides a implementation of the provider that uses
+// AWS Elemental MediaConvert for transcoding media files.
+//
+// It doesn't expose any public type. In order to use the provider, one must
+// import this package and then grab the factory from the provider package:
+//
+//     import (
+//         "github.com/NYTimes/video-transcoding-api/provider"
+//         "github.com/NYTimes/video-transcoding-api/provider/mediaconvert"
+//     )
+//
+//     func UseProvider() {
+//         factory, err := provider.GetProviderFactory(mediaconvert.Name)
+//         // handle err and use factory to get an instance of the provider.
+//     }
+package mediaconvert
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/mediaconvert"
+	"github.com/aws/aws-sdk-go/service/mediaconvert/mediaconvertiface"
+)
+
+const (
+	// Name is the name used for registering the MediaConvert provider in
+	// the registry of providers.
+	Name = "mediaconvert"
+
+	defaultAWSRegion = "us-east-1"
+	defaultQueue     = "Default"
+
+	// hopWaitMinutes is the time high priority jobs wait in the queue
+	// before hopping to the hop queue.
+	hopWaitMinutes = 5
+
+	fileGroup = "FILE_GROUP_SETTINGS"
+	hlsGroup  = "HLS_GROUP_SETTINGS"
+
+	// programAudioSelector is the audio selector used by presets: the
+	// default audio track of the source, or the default track of the job.
+	programAudioSelector = "Audio Selector 1"
+
+	// settings of the audio renditions of jobs with several audio tracks
+	// or with an audio description track.
+	describedAudioSelector = "Audio Description"
+	describedAudioBitrate  = 128000
+	audioGroupID           = "program_audio"
+)
+
+var (
+	errMediaConvertInvalidConfig = errors.New("invalid MediaConvert config. Please define the configuration entries in the config file or environment variables")
+	errMediaConvertClipPrecision = errors.New("mediaconvert only supports clips starting and lasting whole seconds")
+)
+
+func init() {
+	provider.Register(Name, mediaConvertFactory)
+}
+
+type mcProvider struct {
+	c      mediaconvertiface.MediaConvertAPI
+	config *config.MediaConvert
+}
+
+func (p *mcProvider) Transcode(job *db.Job, transcodeProfile provider.TranscodeProfile) (*provider.JobStatus, error) {
+	alignment := transcodeProfile.StreamingParams.KeyframeAlignment
+	if err := alignment.RequireSceneCutDisabled(Name); err != nil {
+		return nil, err
+	}
+	destination := p.destination(job)
+	settings := mediaconvert.JobSettings{
+		Inputs: []*mediaconvert.Input{{
+			FileInput: aws.String(transcodeProfile.SourceMedia),
+			AudioSelectors: map[string]*mediaconvert.AudioSelector{
+				programAudioSelector: {DefaultSelection: aws.String("DEFAULT")},
+			},
+			VideoSelector: &mediaconvert.VideoSelector{Rotate: aws.String(mcRotate(job.Rotation))},
+		}},
+	}
+	if enc := transcodeProfile.SourceEncryption; enc != nil {
+		if !p.DecryptsSource(enc.Mode) {
+			return nil, fmt.Errorf("unsupported source encryption mode %q", enc.Mode)
+		}
+		settings.Inputs[0].DecryptionSettings = &mediaconvert.InputDecryptionSettings{
+			DecryptionMode:         aws.String("AES_CBC"),
+			EncryptedDecryptionKey: aws.String(base64.StdEncoding.EncodeToString(enc.Key)),
+			InitializationVector:   aws.String(base64.StdEncoding.EncodeToString(enc.IV)),
+		}
+	}
+	for _, track := range transcodeProfile.AudioTracks {
+		settings.Inputs[0].AudioSelectors[audioTrackSelector(transcodeProfile.AudioTracks, track)] = &mediaconvert.AudioSelector{
+			SelectorType: aws.String("TRACK"),
+			Tracks:       []*int64{aws.Int64(int64(track.Track))},
+		}
+	}
+	if described := transcodeProfile.DescribedAudio; described != nil {
+		settings.Inputs[0].AudioSelectors[describedAudioSelector] = &mediaconvert.AudioSelector{
+			ExternalAudioFileInput: aws.String(described.Source),
+		}
+	}
+	if conform := transcodeProfile.Conform; conform != nil {
+		settings.Inputs[0].TimecodeSource = aws.String("ZEROBASED")
+		for _, r := range conform.Ranges {
+			settings.Inputs[0].InputClippings = append(settings.Inputs[0].InputClippings, &mediaconvert.InputClipping{
+				StartTimecode: aws.String(r.In),
+				EndTimecode:   aws.String(r.Out),
+			})
+		}
+	}
+	if clip := transcodeProfile.Clip; clip != nil {
+		clipping, err := inputClipping(*clip)
+		if err != nil {
+			return nil, err
+		}
+		settings.Inputs[0].TimecodeSource = aws.String("ZEROBASED")
+		settings.Inputs[0].InputClippings = []*mediaconvert.InputClipping{clipping}
+	}
+	gops := make([]provider.RenditionGOP, 0, len(transcodeProfile.Outputs))
+	var hlsOutputs []*mediaconvert.Output
+	for _, output := range transcodeProfile.Outputs {
+		presetName, ok := output.Preset.ProviderMapping[Name]
+		if !ok {
+			return nil, provider.ErrPresetMapNotFound
+		}
+		presetOutput, err := p.c.GetPreset(&mediaconvert.GetPresetInput{Name: aws.String(presetName)})
+		if err != nil {
+			return nil, err
+		}
+		presetSettings := presetOutput.Preset.Settings
+		if presetSettings == nil || presetSettings.ContainerSettings == nil {
+			return nil, fmt.Errorf("misconfigured preset: %s", presetName)
+		}
+		if gop, ok := renditionGOP(output.Preset.Name, presetSettings); ok {
+			gops = append(gops, gop)
+		}
+		baseName := strings.TrimSuffix(output.FileName, path.Ext(output.FileName))
+		if aws.StringValue(presetSettings.ContainerSettings.Container) == "M3U8" {
+			hlsOutputs = append(hlsOutputs, &mediaconvert.Output{
+				Preset:       aws.String(presetName),
+				NameModifier: aws.String("_" + baseName),
+			})
+			continue
+		}
+		settings.OutputGroups = append(settings.OutputGroups, &mediaconvert.OutputGroup{
+			Name: aws.String(output.FileName),
+			OutputGroupSettings: &mediaconvert.OutputGroupSettings{
+				Type: aws.String(fileGroup),
+				FileGroupSettings: &mediaconvert.FileGroupSettings{
+					Destination: aws.String(destination + baseName),
+				},
+			},
+			Outputs: []*mediaconvert.Output{{
+				Preset:    aws.String(presetName),
+				Extension: aws.String(strings.TrimPrefix(path.Ext(output.FileName), ".")),
+			}},
+		})
+	}
+	if err := alignment.Validate(gops, transcodeProfile.StreamingParams.SegmentDuration); err != nil {
+		return nil, err
+	}
+	described := transcodeProfile.DescribedAudio
+	if described != nil || len(transcodeProfile.AudioTracks) > 1 {
+		if len(hlsOutputs) == 0 && described != nil {
+			return nil, errors.New("described audio requires hls outputs")
+		}
+		if len(hlsOutputs) == 0 {
+			return nil, errors.New("multiple audio tracks require hls outputs")
+		}
+		for _, output := range hlsOutputs {
+			output.OutputSettings = &mediaconvert.OutputSettings{
+				HlsSettings: &mediaconvert.HlsSettings{AudioRenditionSets: aws.String(audioGroupID)},
+			}
+		}
+		hlsOutputs = append(hlsOutputs, audioRenditionOutputs(transcodeProfile.AudioTracks, described)...)
+	}
+	if len(hlsOutputs) > 0 {
+		playlistFileName := transcodeProfile.StreamingParams.PlaylistFileName
+		playlistFileName = strings.TrimSuffix(playlistFileName, path.Ext(playlistFileName))
+		settings.OutputGroups = append(settings.OutputGroups, &mediaconvert.OutputGroup{
+			Name: aws.String("hls"),
+			OutputGroupSettings: &mediaconvert.OutputGroupSettings{
+				Type: aws.String(hlsGroup),
+				HlsGroupSettings: &mediaconvert.HlsGroupSettings{
+					Destination:      aws.String(destination + playlistFileName),
+					SegmentLength:    aws.Int64(int64(transcodeProfile.StreamingParams.SegmentDuration)),
+					MinSegmentLength: aws.Int64(0),
+				},
+			},
+			Outputs: hlsOutputs,
+		})
+	}
+	input := mediaconvert.CreateJobInput{
+		Role:         aws.String(p.config.Role),
+		Settings:     &settings,
+		UserMetadata: map[string]*string{"jobId": aws.String(job.ID)},
+	}
+	if p.config.Queue != "" {
+		input.Queue = aws.String(p.config.Queue)
+	}
+	p.setPriority(&input, job.Priority)
+	if len(transcodeProfile.BillingTags) > 0 {
+		// cost allocation tags, once activated in the billing console.
+		input.Tags = aws.StringMap(transcodeProfile.BillingTags)
+	}
+	if p.config.JobTemplate != "" {
+		input.JobTemplate = aws.String(p.config.JobTemplate)
+	}
+	resp, err := p.c.CreateJob(&input)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: aws.StringValue(resp.Job.Id),
+		Status:        provider.StatusQueued,
+	}, nil
+}
+
+// mcRotate returns the rotation of the video selector of the input for the
+// given rotation. MediaConvert ignores the rotation metadata of sources
+// unless told otherwise.
+func mcRotate(rotation *db.Rotation) string {
+	if rotation.Auto() {
+		return "AUTO"
+	}
+	switch rotation.Degrees {
+	case 90, 180, 270:
+		return fmt.Sprintf("DEGREES_%d", rotation.Degrees)
+	}
+	return "DEGREE_0"
+}
+
+// audioTrackSelector returns the name of the audio selector of the given
+// audio track of the job. The default track replaces the audio selector
+// used by presets.
+func audioTrackSelector(tracks []db.AudioTrack, track db.AudioTrack) string {
+	if track.Track == db.DefaultAudioTrack(tracks).Track {
+		return programAudioSelector
+	}
+	return fmt.Sprintf("Audio Track %d", track.Track)
+}
+
+// audioRenditionOutputs returns the audio renditions of HLS jobs with
+// several audio tracks or with an audio description track: the program
+// audio (a rendition for each audio track of the job, labeled with its
+// language and name), with the default track selected by default, and the
+// description, flagged as describing the video (public.accessibility.describes-video
+// in the master playlist) and never selected automatically.
+func audioRenditionOutputs(tracks []db.AudioTrack, described *db.DescribedAudio) []*mediaconvert.Output {
+	rendition := func(selector, name, trackType string) *mediaconvert.Output {
+		return &mediaconvert.Output{
+			NameModifier:      aws.String("_" + name),
+			ContainerSettings: &mediaconvert.ContainerSettings{Container: aws.String("M3U8")},
+			AudioDescriptions: []*mediaconvert.AudioDescription{{
+				AudioSourceName: aws.String(selector),
+				CodecSettings: &mediaconvert.AudioCodecSettings{
+					Codec: aws.String("AAC"),
+					AacSettings: &mediaconvert.AacSettings{
+						Bitrate:    aws.Int64(describedAudioBitrate),
+						CodingMode: aws.String("CODING_MODE_2_0"),
+						SampleRate: aws.Int64(48000),
+					},
+				},
+			}},
+			OutputSettings: &mediaconvert.OutputSettings{
+				HlsSettings: &mediaconvert.HlsSettings{
+					AudioGroupId:   aws.String(audioGroupID),
+					AudioTrackType: aws.String(trackType),
+				},
+			},
+		}
+	}
+	var outputs []*mediaconvert.Output
+	if len(tracks) == 0 {
+		outputs = append(outputs, rendition(programAudioSelector, "audio", "ALTERNATE_AUDIO_AUTO_SELECT_DEFAULT"))
+	}
+	for _, track := range tracks {
+		selector := audioTrackSelector(tracks, track)
+		trackType := "ALTERNATE_AUDIO_AUTO_SELECT"
+		if selector == programAudioSelector {
+			trackType = "ALTERNATE_AUDIO_AUTO_SELECT_DEFAULT"
+		}
+		program := rendition(selector, fmt.Sprintf("audio_%d", track.Track), trackType)
+		audio := program.AudioDescriptions[0]
+		audio.CustomLanguageCode = aws.String(track.Language)
+		audio.LanguageCodeControl = aws.String("USE_CONFIGURED")
+		audio.StreamName = aws.String(track.Name)
+		outputs = append(outputs, program)
+	}
+	if described == nil {
+		return outputs
+	}
+	description := rendition(describedAudioSelector, "described_audio", "ALTERNATE_AUDIO_NOT_AUTO_SELECT")
+	description.OutputSettings.HlsSettings.DescriptiveVideoServiceFlag = aws.String("FLAG")
+	audio := description.AudioDescriptions[0]
+	audio.AudioType = aws.Int64(3) // visually impaired commentary
+	audio.AudioTypeControl = aws.String("USE_CONFIGURED")
+	audio.CustomLanguageCode = aws.String(described.Language)
+	audio.LanguageCodeControl = aws.String("USE_CONFIGURED")
+	return append(outputs, description)
+}
+
+// priorities maps the priorities of jobs to the priorities of MediaConvert
+// jobs within their queue, from -50 to 50.
+var priorities = map[string]int64{
+	db.PriorityLow:  -50,
+	db.PriorityHigh: 50,
+}
+
+// setPriority sets the priority of the job in the queue. High priority jobs
+// hop to the hop queue when they wait for more than hopWaitMinutes.
+func (p *mcProvider) setPriority(input *mediaconvert.CreateJobInput, priority string) {
+	value, ok := priorities[priority]
+	if !ok {
+		return
+	}
+	input.Priority = aws.Int64(value)
+	if priority == db.PriorityHigh && p.config.HopQueue != "" {
+		input.HopDestinations = []*mediaconvert.HopDestination{{
+			Queue:       aws.String(p.config.HopQueue),
+			Priority:    aws.Int64(value),
+			WaitMinutes: aws.Int64(hopWaitMinutes),
+		}}
+	}
+}
+
+// destination returns the prefix of the outputs of the given job.
+func (p *mcProvider) destination(job *db.Job) string {
+	return strings.TrimRight(p.config.Destination, "/") + "/" + job.ID + "/"
+}
+
+// renditionGOP returns the GOP settings of the given preset, for presets
+// using H.264.
+func renditionGOP(name string, settings *mediaconvert.PresetSettings) (provider.RenditionGOP, bool) {
+	video := settings.VideoDescription
+	if video == nil || video.CodecSettings == nil || video.CodecSettings.H264Settings == nil {
+		return provider.RenditionGOP{}, false
+	}
+	h264 := video.CodecSettings.H264Settings
+	gop := provider.RenditionGOP{
+		Name:  name,
+		Fixed: aws.StringValue(h264.SceneChangeDetect) == "DISABLED",
+	}
+	if h264.GopSize != nil {
+		gop.Size = strconv.FormatFloat(aws.Float64Value(h264.GopSize), 'f', -1, 64)
+		if aws.StringValue(h264.GopSizeUnits) == "SECONDS" {
+			gop.Duration = aws.Float64Value(h264.GopSize)
+		} else if numerator, denominator := aws.Int64Value(h264.FramerateNumerator), aws.Int64Value(h264.FramerateDenominator); numerator > 0 && denominator > 0 {
+			gop.Duration = aws.Float64Value(h264.GopSize) * float64(denominator) / float64(numerator)
+		}
+	}
+	return gop, true
+}
+
+func (p *mcProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
+	resp, err := p.c.GetJob(&mediaconvert.GetJobInput{Id: aws.String(job.ProviderJobID)})
+	if err != nil {
+		return nil, err
+	}
+	mcJob := resp.Job
+	status := provider.JobStatus{
+		ProviderJobID: aws.StringValue(mcJob.Id),
+		Status:        p.statusMap(aws.StringValue(mcJob.Status)),
+		StatusMessage: aws.StringValue(mcJob.ErrorMessage),
+		Progress:      float64(aws.Int64Value(mcJob.JobPercentComplete)),
+		ProviderStatus: map[string]interface{}{
+			"status":       aws.StringValue(mcJob.Status),
+			"currentPhase": aws.StringValue(mcJob.CurrentPhase),
+			"queue":        aws.StringValue(mcJob.Queue),
+		},
+		Output: provider.JobOutput{Destination: strings.TrimRight(p.destination(job), "/")},
+	}
+	if status.Status == provider.StatusFinished {
+		status.Progress = 100
+	}
+	if mcJob.ErrorCode != nil {
+		status.ProviderStatus["errorCode"] = aws.Int64Value(mcJob.ErrorCode)
+	}
+	if mcJob.Settings != nil {
+		status.Output.Files, status.SourceInfo, err = p.outputFiles(mcJob)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &status, nil
+}
+
+// inputErrorCodes are the error codes of jobs that failed because
+// MediaConvert couldn't open or read their input file.
+// inputClipping returns the clipping of the input for the given clip.
+// Timecodes of MediaConvert count frames of the source, which aren't known
+// when the job is created, so clips must start and last whole seconds.
+func inputClipping(clip provider.Clip) (*mediaconvert.InputClipping, error) {
+	if clip.Start != float64(int64(clip.Start)) || clip.Duration != float64(int64(clip.Duration)) {
+		return nil, errMediaConvertClipPrecision
+	}
+	clipping := mediaconvert.InputClipping{StartTimecode: aws.String(secondsTimecode(int64(clip.Start)))}
+	if clip.Duration > 0 {
+		clipping.EndTimecode = aws.String(secondsTimecode(int64(clip.Start + clip.Duration)))
+	}
+	return &clipping, nil
+}
+
+// secondsTimecode returns the zero-based timecode (HH:MM:SS:FF) of the
+// given second of the source.
+func secondsTimecode(seconds int64) string {
+	return fmt.Sprintf("%02d:%02d:%02d:00", seconds/3600, seconds/60%60, seconds%60)
+}
+
+var inputErrorCodes = map[int64]bool{1010: true, 1030: true, 1040: true}
+
+// IsSourceFailure returns whether the job failed with one of the error codes
+// of input failures.
+func (p *mcProvider) IsSourceFailure(status *provider.JobStatus) bool {
+	code, _ := status.ProviderStatus["errorCode"].(int64)
+	return inputErrorCodes[code]
+}
+
+// outputFiles lists the files generated by the given job, along with the
+// information about the source available in the details of the outputs.
+func (p *mcProvider) outputFiles(job *mediaconvert.Job) ([]provider.OutputFile, provider.SourceInfo, error) {
+	var sourceInfo provider.SourceInfo
+	var files []provider.OutputFile
+	for i, group := range job.Settings.OutputGroups {
+		groupSettings := group.OutputGroupSettings
+		if groupSettings == nil {
+			continue
+		}
+		var details []*mediaconvert.OutputDetail
+		if i < len(job.OutputGroupDetails) {
+			details = job.OutputGroupDetails[i].OutputDetails
+		}
+		if sourceInfo.Duration == 0 && len(details) > 0 {
+			sourceInfo.Duration = time.Duration(aws.Int64Value(details[0].DurationInMs)) * time.Millisecond
+		}
+		switch aws.StringValue(groupSettings.Type) {
+		case hlsGroup:
+			files = append(files, provider.OutputFile{
+				Path:      aws.StringValue(groupSettings.HlsGroupSettings.Destination) + ".m3u8",
+				Container: "m3u8",
+			})
+		case fileGroup:
+			for j, output := range group.Outputs {
+				preset, err := p.c.GetPreset(&mediaconvert.GetPresetInput{Name: output.Preset})
+				if err != nil {
+					return nil, sourceInfo, err
+				}
+				file := provider.OutputFile{
+					Path:       aws.StringValue(groupSettings.FileGroupSettings.Destination) + aws.StringValue(output.NameModifier) + "." + aws.StringValue(output.Extension),
+					Container:  aws.StringValue(output.Extension),
+					VideoCodec: videoCodec(preset.Preset.Settings),
+				}
+				if j < len(details) && details[j].VideoDetails != nil {
+					file.Width = aws.Int64Value(details[j].VideoDetails.WidthInPx)
+					file.Height = aws.Int64Value(details[j].VideoDetails.HeightInPx)
+					if sourceInfo.Width == 0 {
+						sourceInfo.Width = file.Width
+						sourceInfo.Height = file.Height
+					}
+				}
+				files = append(files, file)
+			}
+		}
+	}
+	return files, sourceInfo, nil
+}
+
+func videoCodec(settings *mediaconvert.PresetSettings) string {
+	if settings == nil || settings.VideoDescription == nil || settings.VideoDescription.CodecSettings == nil {
+		return ""
+	}
+	codec := aws.StringValue(settings.VideoDescription.CodecSettings.Codec)
+	return strings.ToLower(strings.Replace(codec, "_", "", -1))
+}
+
+func (p *mcProvider) statusMap(mcStatus string) provider.Status {
+	switch mcStatus {
+	case "SUBMITTED":
+		return provider.StatusQueued
+	case "PROGRESSING":
+		return provider.StatusStarted
+	case "COMPLETE":
+		return provider.StatusFinished
+	case "CANCELED":
+		return provider.StatusCanceled
+	case "ERROR":
+		return provider.StatusFailed
+	default:
+		return provider.StatusUnknown
+	}
+}
+
+// DecryptsSource returns whether MediaConvert decrypts sources in the given
+// mode. MediaConvert decrypts AES-128 sources, with keys encrypted by AWS
+// KMS.
+func (p *mcProvider) DecryptsSource(mode string) bool {
+	return mode == db.SourceEncryptionAES128
+}
+
+func (p *mcProvider) CancelJob(id string) error {
+	_, err := p.c.CancelJob(&mediaconvert.CancelJobInput{Id: aws.String(id)})
+	return err
+}
+
+// ActiveJobs returns the jobs that are submitted or progressing in the queue
+// of the provider.
+func (p *mcProvider) ActiveJobs() ([]provider.ActiveJob, error) {
+	var jobs []provider.ActiveJob
+	for _, status := range []string{"SUBMITTED", "PROGRESSING"} {
+		input := mediaconvert.ListJobsInput{Status: aws.String(status)}
+		if p.config.Queue != "" {
+			input.Queue = aws.String(p.config.Queue)
+		}
+		for {
+			resp, err := p.c.ListJobs(&input)
+			if err != nil {
+				return nil, err
+			}
+			for _, job := range resp.Jobs {
+				jobs = append(jobs, provider.ActiveJob{ID: aws.StringValue(job.Id), CreationTime: aws.TimeValue(job.CreatedAt)})
+			}
+			if aws.StringValue(resp.NextToken) == "" {
+				break
+			}
+			input.NextToken = resp.NextToken
+		}
+	}
+	return jobs, nil
+}
+
+func (p *mcProvider) CreatePreset(preset db.Preset) (string, error) {
+	settings := mediaconvert.PresetSettings{
+		ContainerSettings: &mediaconvert.ContainerSettings{
+			Container: aws.String(mcContainer(preset.Container)),
+		},
+	}
+	if preset.Video != (db.VideoPreset{}) {
+		video, err := p.createVideoPreset(preset)
+		if err != nil {
+			return "", err
+		}
+		settings.VideoDescription = video
+	}
+	if preset.Audio != (db.AudioPreset{}) {
+		audio, err := p.createAudioPreset(preset)
+		if err != nil {
+			return "", err
+		}
+		settings.AudioDescriptions = []*mediaconvert.AudioDescription{audio}
+	}
+	resp, err := p.c.CreatePreset(&mediaconvert.CreatePresetInput{
+		Name:        aws.String(preset.Name),
+		Description: aws.String(preset.Description),
+		Settings:    &settings,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.Preset.Name), nil
+}
+
+// mcContainer returns the MediaConvert container for the given container of
+// a preset. Audio-only mp3 files have no container, and m4a files are MP4
+// files without video.
+func mcContainer(container string) string {
+	switch container {
+	case "mp3":
+		return "RAW"
+	case "m4a":
+		return "MP4"
+	}
+	return strings.ToUpper(container)
+}
+
+func (p *mcProvider) createVideoPreset(preset db.Preset) (*mediaconvert.VideoDescription, error) {
+	bitrate, err := atoi64(preset.Video.Bitrate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid video bitrate %q", preset.Video.Bitrate)
+	}
+	video := mediaconvert.VideoDescription{CodecSettings: &mediaconvert.VideoCodecSettings{}}
+	if preset.Video.Width != "" {
+		if video.Width, err = atoi64Ptr(preset.Video.Width); err != nil {
+			return nil, fmt.Errorf("invalid video width %q", preset.Video.Width)
+		}
+	}
+	if preset.Video.Height != "" {
+		if video.Height, err = atoi64Ptr(preset.Video.Height); err != nil {
+			return nil, fmt.Errorf("invalid video height %q", preset.Video.Height)
+		}
+	}
+	switch preset.Video.Codec {
+	case "h264":
+		h264 := mediaconvert.H264Settings{
+			Bitrate:           aws.Int64(bitrate),
+			RateControlMode:   aws.String("CBR"),
+			CodecProfile:      aws.String("MAIN"),
+			CodecLevel:        aws.String("AUTO"),
+			InterlaceMode:     aws.String("PROGRESSIVE"),
+			SceneChangeDetect: aws.String("ENABLED"),
+		}
+		if preset.RateControl != "" {
+			h264.RateControlMode = aws.String(strings.ToUpper(preset.RateControl))
+		}
+		if preset.Profile != "" {
+			h264.CodecProfile = aws.String(strings.ToUpper(preset.Profile))
+		}
+		if preset.ProfileLevel != "" {
+			h264.CodecLevel = aws.String("LEVEL_" + strings.Replace(preset.ProfileLevel, ".", "_", -1))
+		}
+		if preset.Video.GopSize != "" {
+			gopSize, err := strconv.ParseFloat(preset.Video.GopSize, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid GOP size %q", preset.Video.GopSize)
+			}
+			h264.GopSize = aws.Float64(gopSize)
+			h264.GopSizeUnits = aws.String("FRAMES")
+		}
+		if preset.Video.GopMode == "fixed" {
+			h264.SceneChangeDetect = aws.String("DISABLED")
+		}
+		if preset.Video.InterlaceMode != "" && preset.Video.InterlaceMode != "progressive" {
+			h264.InterlaceMode = aws.String("TOP_FIELD")
+		}
+		video.CodecSettings.Codec = aws.String("H_264")
+		video.CodecSettings.H264Settings = &h264
+	case "hevc":
+		h265 := mediaconvert.H265Settings{
+			Bitrate:           aws.Int64(bitrate),
+			RateControlMode:   aws.String("CBR"),
+			CodecProfile:      aws.String("MAIN_MAIN"),
+			CodecLevel:        aws.String("AUTO"),
+			SceneChangeDetect: aws.String("ENABLED"),
+		}
+		if preset.Video.HDR() {
+			// HDR outputs are encoded with 10 bits.
+			h265.CodecProfile = aws.String("MAIN10_MAIN")
+		}
+		if preset.RateControl != "" {
+			h265.RateControlMode = aws.String(strings.ToUpper(preset.RateControl))
+		}
+		if preset.Video.GopSize != "" {
+			gopSize, err := strconv.ParseFloat(preset.Video.GopSize, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid GOP size %q", preset.Video.GopSize)
+			}
+			h265.GopSize = aws.Float64(gopSize)
+			h265.GopSizeUnits = aws.String("FRAMES")
+		}
+		if preset.Video.GopMode == "fixed" {
+			h265.SceneChangeDetect = aws.String("DISABLED")
+		}
+		video.CodecSettings.Codec = aws.String("H_265")
+		video.CodecSettings.H265Settings = &h265
+	case "vp8":
+		video.CodecSettings.Codec = aws.String("VP8")
+		video.CodecSettings.Vp8Settings = &mediaconvert.Vp8Settings{
+			Bitrate:         aws.Int64(bitrate),
+			RateControlMode: aws.String("VBR"),
+		}
+	case "vp9":
+		video.CodecSettings.Codec = aws.String("VP9")
+		video.CodecSettings.Vp9Settings = &mediaconvert.Vp9Settings{
+			Bitrate:         aws.Int64(bitrate),
+			RateControlMode: aws.String("VBR"),
+		}
+	case "av1":
+		// MediaConvert encodes AV1 only in QVBR mode, so the bitrate of the
+		// preset is the maximum bitrate.
+		av1 := mediaconvert.Av1Settings{RateControlMode: aws.String("QVBR")}
+		if bitrate > 0 {
+			av1.MaxBitrate = aws.Int64(bitrate)
+		}
+		if preset.Video.GopSize != "" {
+			gopSize, err := strconv.ParseFloat(preset.Video.GopSize, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid GOP size %q", preset.Video.GopSize)
+			}
+			av1.GopSize = aws.Float64(gopSize)
+		}
+		video.CodecSettings.Codec = aws.String("AV1")
+		video.CodecSettings.Av1Settings = &av1
+	default:
+		return nil, fmt.Errorf("unsupported video codec %q", preset.Video.Codec)
+	}
+	colorCorrector, err := colorCorrector(preset.Video)
+	if err != nil {
+		return nil, err
+	}
+	if colorCorrector != nil {
+		video.VideoPreprocessors = &mediaconvert.VideoPreprocessor{ColorCorrector: colorCorrector}
+	}
+	if preset.Video.HDR() {
+		video.ColorMetadata = aws.String("INSERT")
+	}
+	return &video, nil
+}
+
+// colorCorrector returns the color conversion of the given video preset,
+// including the HDR10 metadata of the output and the tone mapping of HDR
+// sources, or nil when the colors of the source are kept.
+func colorCorrector(video db.VideoPreset) (*mediaconvert.ColorCorrector, error) {
+	var corrector mediaconvert.ColorCorrector
+	switch {
+	case video.ToneMap:
+		corrector.ColorSpaceConversion = aws.String("FORCE_709")
+		corrector.HdrToSdrToneMapper = aws.String("PRESERVE_DETAILS")
+	case video.TransferFunction == "pq":
+		corrector.ColorSpaceConversion = aws.String("FORCE_HDR10")
+	case video.TransferFunction == "hlg":
+		corrector.ColorSpaceConversion = aws.String("FORCE_HLG_2020")
+	case video.TransferFunction == "bt709" || video.ColorSpace == "bt709":
+		corrector.ColorSpaceConversion = aws.String("FORCE_709")
+	default:
+		return nil, nil
+	}
+	if video.MasteringDisplay != "" || video.ContentLightLevel != "" {
+		corrector.Hdr10Metadata = &mediaconvert.Hdr10Metadata{}
+	}
+	if video.MasteringDisplay != "" {
+		display, err := db.ParseMasteringDisplay(video.MasteringDisplay)
+		if err != nil {
+			return nil, err
+		}
+		metadata := corrector.Hdr10Metadata
+		metadata.GreenPrimaryX, metadata.GreenPrimaryY = aws.Int64(display.GreenX), aws.Int64(display.GreenY)
+		metadata.BluePrimaryX, metadata.BluePrimaryY = aws.Int64(display.BlueX), aws.Int64(display.BlueY)
+		metadata.RedPrimaryX, metadata.RedPrimaryY = aws.Int64(display.RedX), aws.Int64(display.RedY)
+		metadata.WhitePointX, metadata.WhitePointY = aws.Int64(display.WhitePointX), aws.Int64(display.WhitePointY)
+		metadata.MaxLuminance = aws.Int64(display.MaxLuminance)
+		metadata.MinLuminance = aws.Int64(display.MinLuminance)
+	}
+	if video.ContentLightLevel != "" {
+		maxCLL, maxFALL, err := db.ParseContentLightLevel(video.ContentLightLevel)
+		if err != nil {
+			return nil, err
+		}
+		corrector.Hdr10Metadata.MaxContentLightLevel = aws.Int64(maxCLL)
+		corrector.Hdr10Metadata.MaxFrameAverageLightLevel = aws.Int64(maxFALL)
+	}
+	return &corrector, nil
+}
+
+func (p *mcProvider) createAudioPreset(preset db.Preset) (*mediaconvert.AudioDescription, error) {
+	bitrate, err := atoi64(preset.Audio.Bitrate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audio bitrate %q", preset.Audio.Bitrate)
+	}
+	surround := preset.Audio.ChannelLayout == db.AudioLayout51
+	codecSettings := mediaconvert.AudioCodecSettings{}
+	switch preset.Audio.Codec {
+	case "aac":
+		codingMode := "CODING_MODE_2_0"
+		if surround {
+			codingMode = "CODING_MODE_5_1"
+		}
+		codecSettings.Codec = aws.String("AAC")
+		codecSettings.AacSettings = &mediaconvert.AacSettings{
+			Bitrate:    aws.Int64(bitrate),
+			CodingMode: aws.String(codingMode),
+			SampleRate: aws.Int64(48000),
+		}
+	case "ac3":
+		codingMode := "CODING_MODE_2_0"
+		if surround {
+			codingMode = "CODING_MODE_3_2_LFE"
+		}
+		codecSettings.Codec = aws.String("AC3")
+		codecSettings.Ac3Settings = &mediaconvert.Ac3Settings{
+			Bitrate:    aws.Int64(bitrate),
+			CodingMode: aws.String(codingMode),
+			SampleRate: aws.Int64(48000),
+		}
+	case "eac3":
+		eac3 := mediaconvert.Eac3Settings{
+			Bitrate:    aws.Int64(bitrate),
+			CodingMode: aws.String("CODING_MODE_2_0"),
+			SampleRate: aws.Int64(48000),
+		}
+		if surround {
+			eac3.CodingMode = aws.String("CODING_MODE_3_2")
+			eac3.LfeControl = aws.String("LFE")
+		}
+		codecSettings.Codec = aws.String("EAC3")
+		codecSettings.Eac3Settings = &eac3
+	case "mp3":
+		codecSettings.Codec = aws.String("MP3")
+		codecSettings.Mp3Settings = &mediaconvert.Mp3Settings{
+			Bitrate:    aws.Int64(bitrate),
+			Channels:   aws.Int64(2),
+			SampleRate: aws.Int64(48000),
+		}
+	case "libvorbis", "vorbis":
+		codecSettings.Codec = aws.String("VORBIS")
+		codecSettings.VorbisSettings = &mediaconvert.VorbisSettings{
+			Channels:   aws.Int64(2),
+			SampleRate: aws.Int64(48000),
+		}
+	case "opus", "libopus":
+		codecSettings.Codec = aws.String("OPUS")
+		codecSettings.OpusSettings = &mediaconvert.OpusSettings{
+			Bitrate:    aws.Int64(bitrate),
+			Channels:   aws.Int64(2),
+			SampleRate: aws.Int64(48000),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported audio codec %q", preset.Audio.Codec)
+	}
+	if surround && codecSettings.AacSettings == nil && codecSettings.Ac3Settings == nil && codecSettings.Eac3Settings == nil {
+		return nil, fmt.Errorf("mediaconvert doesn't support 5.1 outputs with the audio codec %q", preset.Audio.Codec)
+	}
+	normalization, err := audioNormalization(preset.Audio)
+	if err != nil {
+		return nil, err
+	}
+	return &mediaconvert.AudioDescription{
+		AudioSourceName:            aws.String(programAudioSelector),
+		CodecSettings:              &codecSettings,
+		AudioNormalizationSettings: normalization,
+	}, nil
+}
+
+// audioNormalization returns the settings for normalizing the loudness of
+// the audio to the target of the preset, measured with ITU-R BS.1770.
+func audioNormalization(audio db.AudioPreset) (*mediaconvert.AudioNormalizationSettings, error) {
+	if audio.Loudness == "" {
+		return nil, nil
+	}
+	loudness, truePeak, err := audio.Normalization()
+	if err != nil {
+		return nil, err
+	}
+	settings := mediaconvert.AudioNormalizationSettings{
+		Algorithm:        aws.String("ITU_BS_1770_3"),
+		AlgorithmControl: aws.String("CORRECT_AUDIO"),
+		TargetLkfs:       aws.Float64(loudness),
+	}
+	if audio.TruePeak != "" {
+		settings.PeakCalculation = aws.String("TRUE_PEAK")
+		settings.TruePeakLimiterThreshold = aws.Float64(truePeak)
+	}
+	return &settings, nil
+}
+
+func atoi64(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func atoi64Ptr(value string) (*int64, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return aws.Int64(n), nil
+}
+
+func (p *mcProvider) GetPreset(presetID string) (interface{}, error) {
+	return p.c.GetPreset(&mediaconvert.GetPresetInput{Name: aws.String(presetID)})
+}
+
+func (p *mcProvider) DeletePreset(presetID string) error {
+	_, err := p.c.DeletePreset(&mediaconvert.DeletePresetInput{Name: aws.String(presetID)})
+	return err
+}
+
+func (p *mcProvider) Healthcheck() error {
+	_, err := p.c.GetQueue(&mediaconvert.GetQueueInput{Name: aws.String(p.queueName())})
+	return err
+}
+
+// queueName returns the name of the queue configured in the provider, which
+// may be given as an ARN.
+func (p *mcProvider) queueName() string {
+	if p.config.Queue == "" {
+		return defaultQueue
+	}
+	parts := strings.Split(p.config.Queue, "/")
+	return parts[len(parts)-1]
+}
+
+func (p *mcProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		InputFormats:       []string{"prores", "h264", "h265", "mpeg2"},
+		OutputFormats:      []string{"mp4", "hls", "webm", "mov", "mp3", "m4a"},
+		Destinations:       []string{"s3"},
+		VideoCodecs:        []string{"h264", "hevc", "vp8", "vp9", "av1"},
+		AudioCodecs:        []string{"aac", "ac3", "eac3", "mp3", "opus", "vorbis"},
+		StreamingProtocols: []string{"hls"},
+		MaxAudioChannels:   6,
+		Conform:            true,
+		Clipping:           true,
+		AudioOnly:          true,
+		HDR:                true,
+		ToneMapping:        true,
+		Loudness:           true,
+		Rotation:           true,
+		NoAutorotation:     true,
+		DescribedAudio:     true,
+		AudioTracks:        true,
+		Progress:           true,
+	}
+}
+
+func mediaConvertFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
+	mcCfg := cfg.MediaConvert
+	if mcCfg == nil || mcCfg.AccessKeyID == "" || mcCfg.SecretAccessKey == "" || mcCfg.Endpoint == "" || mcCfg.Role == "" || mcCfg.Destination == "" {
+		return nil, errMediaConvertInvalidConfig
+	}
+	creds := credentials.NewStaticCredentials(mcCfg.AccessKeyID, mcCfg.SecretAccessKey, "")
+	region := mcCfg.Region
+	if region == "" {
+		region = defaultAWSRegion
+	}
+	awsSession := session.New(aws.NewConfig().WithCredentials(creds).WithRegion(region).WithEndpoint(mcCfg.Endpoint))
+	return &mcProvider{
+		c:      mediaconvert.New(awsSession),
+		config: mcCfg,
+	}, nil
+}
@@ -0,0 +1,192 @@
+package mediaconvert
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/mediaconvert"
+)
+
+type failure struct {
+	op  string
+	err error
+}
+
+type fakeMediaConvert struct {
+	*mediaconvert.MediaConvert
+	jobs         map[string]*mediaconvert.CreateJobInput
+	presets      map[string]*mediaconvert.CreatePresetInput
+	canceledJobs []string
+	failures     chan failure
+}
+
+func newFakeMediaConvert() *fakeMediaConvert {
+	return &fakeMediaConvert{
+		MediaConvert: &mediaconvert.MediaConvert{},
+		jobs:         make(map[string]*mediaconvert.CreateJobInput),
+		presets:      make(map[string]*mediaconvert.CreatePresetInput),
+		failures:     make(chan failure, 1),
+	}
+}
+
+func (c *fakeMediaConvert) CreateJob(input *mediaconvert.CreateJobInput) (*mediaconvert.CreateJobOutput, error) {
+	if err := c.getError("CreateJob"); err != nil {
+		return nil, err
+	}
+	id := fmt.Sprintf("1500000000000-job%d", len(c.jobs)+1)
+	c.jobs[id] = input
+	return &mediaconvert.CreateJobOutput{
+		Job: &mediaconvert.Job{
+			Id:       aws.String(id),
+			Queue:    input.Queue,
+			Role:     input.Role,
+			Settings: input.Settings,
+			Status:   aws.String("SUBMITTED"),
+		},
+	}, nil
+}
+
+func (c *fakeMediaConvert) GetJob(input *mediaconvert.GetJobInput) (*mediaconvert.GetJobOutput, error) {
+	if err := c.getError("GetJob"); err != nil {
+		return nil, err
+	}
+	createJobInput, ok := c.jobs[aws.StringValue(input.Id)]
+	if !ok {
+		return nil, errors.New("job not found")
+	}
+	details := make([]*mediaconvert.OutputGroupDetail, len(createJobInput.Settings.OutputGroups))
+	for i, group := range createJobInput.Settings.OutputGroups {
+		outputDetails := make([]*mediaconvert.OutputDetail, len(group.Outputs))
+		for j := range group.Outputs {
+			outputDetails[j] = &mediaconvert.OutputDetail{
+				DurationInMs: aws.Int64(120e3),
+				VideoDetails: &mediaconvert.VideoDetail{
+					WidthInPx:  aws.Int64(1280),
+					HeightInPx: aws.Int64(720),
+				},
+			}
+		}
+		details[i] = &mediaconvert.OutputGroupDetail{OutputDetails: outputDetails}
+	}
+	return &mediaconvert.GetJobOutput{
+		Job: &mediaconvert.Job{
+			Id:                 input.Id,
+			Queue:              aws.String("arn:aws:mediaconvert:us-east-1:123456789012:queues/Default"),
+			Settings:           createJobInput.Settings,
+			Status:             aws.String("COMPLETE"),
+			CurrentPhase:       aws.String("UPLOADING"),
+			OutputGroupDetails: details,
+		},
+	}, nil
+}
+
+func (c *fakeMediaConvert) CancelJob(input *mediaconvert.CancelJobInput) (*mediaconvert.CancelJobOutput, error) {
+	if err := c.getError("CancelJob"); err != nil {
+		return nil, err
+	}
+	c.canceledJobs = append(c.canceledJobs, aws.StringValue(input.Id))
+	return &mediaconvert.CancelJobOutput{}, nil
+}
+
+func (c *fakeMediaConvert) ListJobs(input *mediaconvert.ListJobsInput) (*mediaconvert.ListJobsOutput, error) {
+	if err := c.getError("ListJobs"); err != nil {
+		return nil, err
+	}
+	if aws.StringValue(input.Status) != "PROGRESSING" {
+		return &mediaconvert.ListJobsOutput{}, nil
+	}
+	ids := make([]string, 0, len(c.jobs))
+	for id := range c.jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	jobs := make([]*mediaconvert.Job, len(ids))
+	for i, id := range ids {
+		jobs[i] = &mediaconvert.Job{
+			Id:        aws.String(id),
+			Queue:     input.Queue,
+			Status:    aws.String("PROGRESSING"),
+			CreatedAt: aws.Time(time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)),
+		}
+	}
+	return &mediaconvert.ListJobsOutput{Jobs: jobs}, nil
+}
+
+func (c *fakeMediaConvert) CreatePreset(input *mediaconvert.CreatePresetInput) (*mediaconvert.CreatePresetOutput, error) {
+	if err := c.getError("CreatePreset"); err != nil {
+		return nil, err
+	}
+	c.presets[aws.StringValue(input.Name)] = input
+	return &mediaconvert.CreatePresetOutput{
+		Preset: &mediaconvert.Preset{
+			Name:        input.Name,
+			Description: input.Description,
+			Settings:    input.Settings,
+		},
+	}, nil
+}
+
+func (c *fakeMediaConvert) GetPreset(input *mediaconvert.GetPresetInput) (*mediaconvert.GetPresetOutput, error) {
+	if err := c.getError("GetPreset"); err != nil {
+		return nil, err
+	}
+	name := aws.StringValue(input.Name)
+	container := "MP4"
+	if strings.Contains(name, "hls") {
+		container = "M3U8"
+	}
+	return &mediaconvert.GetPresetOutput{
+		Preset: &mediaconvert.Preset{
+			Name: input.Name,
+			Settings: &mediaconvert.PresetSettings{
+				ContainerSettings: &mediaconvert.ContainerSettings{Container: aws.String(container)},
+				VideoDescription: &mediaconvert.VideoDescription{
+					CodecSettings: &mediaconvert.VideoCodecSettings{
+						Codec: aws.String("H_264"),
+						H264Settings: &mediaconvert.H264Settings{
+							GopSize:           aws.Float64(90),
+							SceneChangeDetect: aws.String("DISABLED"),
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (c *fakeMediaConvert) DeletePreset(input *mediaconvert.DeletePresetInput) (*mediaconvert.DeletePresetOutput, error) {
+	if err := c.getError("DeletePreset"); err != nil {
+		return nil, err
+	}
+	delete(c.presets, aws.StringValue(input.Name))
+	return &mediaconvert.DeletePresetOutput{}, nil
+}
+
+func (c *fakeMediaConvert) GetQueue(input *mediaconvert.GetQueueInput) (*mediaconvert.GetQueueOutput, error) {
+	if err := c.getError("GetQueue"); err != nil {
+		return nil, err
+	}
+	return &mediaconvert.GetQueueOutput{
+		Queue: &mediaconvert.Queue{Name: input.Name, Status: aws.String("ACTIVE")},
+	}, nil
+}
+
+func (c *fakeMediaConvert) prepareFailure(op string, err error) {
+	c.failures <- failure{op: op, err: err}
+}
+
+func (c *fakeMediaConvert) getError(op string) error {
+	select {
+	case prepFailure := <-c.failures:
+		if prepFailure.op == op {
+			return prepFailure.err
+		}
+		c.failures <- prepFailure
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+// +build integration
+
+package mediaconvert
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/provider/providertest"
+)
+
+func TestMediaConvertIntegration(t *testing.T) {
+	providertest.Run(t, Name)
+}
@@ -0,0 +1,1019 @@
+package mediaconvert
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/mediaconvert"
+)
+
+func newTestProvider(client *fakeMediaConvert) *mcProvider {
+	return &mcProvider{
+		c: client,
+		config: &config.MediaConvert{
+			AccessKeyID:     "AKIANOTREALLY",
+			SecretAccessKey: "secret",
+			Endpoint:        "https://abcd1234.mediaconvert.us-east-1.amazonaws.com",
+			Role:            "arn:aws:iam::123456789012:role/MediaConvert",
+			Queue:           "arn:aws:mediaconvert:us-east-1:123456789012:queues/transcoding-api",
+			Destination:     "s3://some-bucket/outputs/",
+		},
+	}
+}
+
+func TestFactoryIsRegistered(t *testing.T) {
+	_, err := provider.GetProviderFactory(Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMediaConvertProviderValidation(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		cfg      *config.MediaConvert
+		valid    bool
+	}{
+		{"missing config", nil, false},
+		{"empty config", &config.MediaConvert{}, false},
+		{
+			"missing endpoint",
+			&config.MediaConvert{AccessKeyID: "AKIA", SecretAccessKey: "secret", Role: "role", Destination: "s3://bucket/"},
+			false,
+		},
+		{
+			"missing role",
+			&config.MediaConvert{AccessKeyID: "AKIA", SecretAccessKey: "secret", Endpoint: "https://mediaconvert", Destination: "s3://bucket/"},
+			false,
+		},
+		{
+			"valid config",
+			&config.MediaConvert{AccessKeyID: "AKIA", SecretAccessKey: "secret", Endpoint: "https://mediaconvert", Role: "role", Destination: "s3://bucket/"},
+			true,
+		},
+	}
+	for _, test := range tests {
+		prov, err := mediaConvertFactory(&config.Config{MediaConvert: test.cfg})
+		if test.valid {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			} else if prov.(*mcProvider).config != test.cfg {
+				t.Errorf("%s: did not store the proper config", test.testCase)
+			}
+			continue
+		}
+		if err != errMediaConvertInvalidConfig {
+			t.Errorf("%s: wrong error returned. Want %#v. Got %#v", test.testCase, errMediaConvertInvalidConfig, err)
+		}
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	transcodeProfile := provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{
+				FileName: "video_1080p.mp4",
+				Preset:   db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}},
+			},
+			{
+				FileName: "hls_720p.m3u8",
+				Preset:   db.PresetMap{Name: "hls_720p", ProviderMapping: map[string]string{Name: "hls-720p"}},
+			},
+		},
+		StreamingParams: provider.StreamingParams{PlaylistFileName: "master.m3u8", SegmentDuration: 6},
+	}
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, transcodeProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobStatus.Status != provider.StatusQueued || jobStatus.ProviderName != Name {
+		t.Errorf("wrong job status returned: %#v", jobStatus)
+	}
+	jobInput, ok := fakeClient.jobs[jobStatus.ProviderJobID]
+	if !ok {
+		t.Fatalf("job %q not sent to MediaConvert", jobStatus.ProviderJobID)
+	}
+	expectedJobInput := mediaconvert.CreateJobInput{
+		Role:         aws.String("arn:aws:iam::123456789012:role/MediaConvert"),
+		Queue:        aws.String("arn:aws:mediaconvert:us-east-1:123456789012:queues/transcoding-api"),
+		UserMetadata: map[string]*string{"jobId": aws.String("job-123")},
+		Settings: &mediaconvert.JobSettings{
+			Inputs: []*mediaconvert.Input{{
+				FileInput: aws.String("s3://some-bucket/source.mov"),
+				AudioSelectors: map[string]*mediaconvert.AudioSelector{
+					"Audio Selector 1": {DefaultSelection: aws.String("DEFAULT")},
+				},
+				VideoSelector: &mediaconvert.VideoSelector{Rotate: aws.String("AUTO")},
+			}},
+			OutputGroups: []*mediaconvert.OutputGroup{
+				{
+					Name: aws.String("video_1080p.mp4"),
+					OutputGroupSettings: &mediaconvert.OutputGroupSettings{
+						Type: aws.String("FILE_GROUP_SETTINGS"),
+						FileGroupSettings: &mediaconvert.FileGroupSettings{
+							Destination: aws.String("s3://some-bucket/outputs/job-123/video_1080p"),
+						},
+					},
+					Outputs: []*mediaconvert.Output{{Preset: aws.String("mp4-1080p"), Extension: aws.String("mp4")}},
+				},
+				{
+					Name: aws.String("hls"),
+					OutputGroupSettings: &mediaconvert.OutputGroupSettings{
+						Type: aws.String("HLS_GROUP_SETTINGS"),
+						HlsGroupSettings: &mediaconvert.HlsGroupSettings{
+							Destination:      aws.String("s3://some-bucket/outputs/job-123/master"),
+							SegmentLength:    aws.Int64(6),
+							MinSegmentLength: aws.Int64(0),
+						},
+					},
+					Outputs: []*mediaconvert.Output{{Preset: aws.String("hls-720p"), NameModifier: aws.String("_hls_720p")}},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(*jobInput, expectedJobInput) {
+		t.Errorf("wrong job input\nWant %#v\nGot  %#v", expectedJobInput, *jobInput)
+	}
+}
+
+func TestTranscodeJobTemplate(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	prov.config.Queue = ""
+	prov.config.JobTemplate = "transcoding-api-defaults"
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobInput := fakeClient.jobs[jobStatus.ProviderJobID]
+	if aws.StringValue(jobInput.JobTemplate) != "transcoding-api-defaults" {
+		t.Errorf("wrong job template. Want %q. Got %q", "transcoding-api-defaults", aws.StringValue(jobInput.JobTemplate))
+	}
+	if jobInput.Queue != nil {
+		t.Errorf("unexpected queue in the job: %q", aws.StringValue(jobInput.Queue))
+	}
+}
+
+func TestTranscodePriority(t *testing.T) {
+	var tests = []struct {
+		testCase     string
+		priority     string
+		hopQueue     string
+		expectedPrio *int64
+		expectedHops []*mediaconvert.HopDestination
+	}{
+		{"no priority", "", "arn:aws:mediaconvert:us-east-1:some-account:queues/urgent", nil, nil},
+		{"low priority", "low", "arn:aws:mediaconvert:us-east-1:some-account:queues/urgent", aws.Int64(-50), nil},
+		{"high priority without hop queue", "high", "", aws.Int64(50), nil},
+		{
+			"high priority",
+			"high",
+			"arn:aws:mediaconvert:us-east-1:some-account:queues/urgent",
+			aws.Int64(50),
+			[]*mediaconvert.HopDestination{{
+				Queue:       aws.String("arn:aws:mediaconvert:us-east-1:some-account:queues/urgent"),
+				Priority:    aws.Int64(50),
+				WaitMinutes: aws.Int64(5),
+			}},
+		},
+	}
+	for _, test := range tests {
+		fakeClient := newFakeMediaConvert()
+		prov := newTestProvider(fakeClient)
+		prov.config.HopQueue = test.hopQueue
+		jobStatus, err := prov.Transcode(&db.Job{ID: "job-123", Priority: test.priority}, provider.TranscodeProfile{
+			SourceMedia: "s3://some-bucket/source.mov",
+			Outputs: []provider.TranscodeOutput{
+				{FileName: "video.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+			},
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		jobInput := fakeClient.jobs[jobStatus.ProviderJobID]
+		if !reflect.DeepEqual(jobInput.Priority, test.expectedPrio) {
+			t.Errorf("%s: wrong priority. Want %v. Got %v", test.testCase, aws.Int64Value(test.expectedPrio), aws.Int64Value(jobInput.Priority))
+		}
+		if !reflect.DeepEqual(jobInput.HopDestinations, test.expectedHops) {
+			t.Errorf("%s: wrong hop destinations\nWant %#v\nGot  %#v", test.testCase, test.expectedHops, jobInput.HopDestinations)
+		}
+	}
+}
+
+func TestTranscodeBillingTags(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+		},
+		BillingTags: map[string]string{"tenant": "newsroom", "costcenter": "video"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]*string{"tenant": aws.String("newsroom"), "costcenter": aws.String("video")}
+	tags := fakeClient.jobs[jobStatus.ProviderJobID].Tags
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("wrong tags\nWant %#v\nGot  %#v", aws.StringValueMap(expected), aws.StringValueMap(tags))
+	}
+}
+
+func TestTranscodeSourceEncryption(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+		},
+		SourceEncryption: &provider.SourceEncryption{
+			Mode: db.SourceEncryptionAES128,
+			Key:  []byte("0123456789abcdef"),
+			IV:   []byte("fedcba9876543210"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := mediaconvert.InputDecryptionSettings{
+		DecryptionMode:         aws.String("AES_CBC"),
+		EncryptedDecryptionKey: aws.String("MDEyMzQ1Njc4OWFiY2RlZg=="),
+		InitializationVector:   aws.String("ZmVkY2JhOTg3NjU0MzIxMA=="),
+	}
+	settings := fakeClient.jobs[jobStatus.ProviderJobID].Settings.Inputs[0].DecryptionSettings
+	if settings == nil || !reflect.DeepEqual(*settings, expected) {
+		t.Errorf("wrong decryption settings\nWant %#v\nGot  %#v", expected, settings)
+	}
+	if prov.DecryptsSource(db.SourceEncryptionSSEC) {
+		t.Error("MediaConvert should not decrypt SSE-C sources")
+	}
+}
+
+func TestTranscodeConform(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/event.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "highlight.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+		},
+		Conform: &db.Conform{
+			FrameRate: 29.97,
+			Ranges: []db.EditRange{
+				{In: "01:02:03;04", Out: "01:02:13;00"},
+				{In: "02:10:00;02", Out: "02:10:05;10"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := fakeClient.jobs[jobStatus.ProviderJobID].Settings.Inputs[0]
+	if aws.StringValue(input.TimecodeSource) != "ZEROBASED" {
+		t.Errorf("wrong timecode source. Want %q. Got %q", "ZEROBASED", aws.StringValue(input.TimecodeSource))
+	}
+	expected := []*mediaconvert.InputClipping{
+		{StartTimecode: aws.String("01:02:03;04"), EndTimecode: aws.String("01:02:13;00")},
+		{StartTimecode: aws.String("02:10:00;02"), EndTimecode: aws.String("02:10:05;10")},
+	}
+	if !reflect.DeepEqual(input.InputClippings, expected) {
+		t.Errorf("wrong input clippings\nWant %#v\nGot  %#v", expected, input.InputClippings)
+	}
+}
+
+func TestTranscodeClip(t *testing.T) {
+	var tests = []struct {
+		givenClip provider.Clip
+		want      *mediaconvert.InputClipping
+		wantErr   error
+	}{
+		{
+			provider.Clip{Start: 10, Duration: 30},
+			&mediaconvert.InputClipping{StartTimecode: aws.String("00:00:10:00"), EndTimecode: aws.String("00:00:40:00")},
+			nil,
+		},
+		{
+			provider.Clip{Start: 3725},
+			&mediaconvert.InputClipping{StartTimecode: aws.String("01:02:05:00")},
+			nil,
+		},
+		{provider.Clip{Start: 10.5, Duration: 30}, nil, errMediaConvertClipPrecision},
+	}
+	for _, test := range tests {
+		fakeClient := newFakeMediaConvert()
+		prov := newTestProvider(fakeClient)
+		jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			SourceMedia: "s3://some-bucket/master.mov",
+			Outputs: []provider.TranscodeOutput{
+				{FileName: "preview.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+			},
+			Clip: &test.givenClip,
+		})
+		if err != test.wantErr {
+			t.Errorf("%#v: wrong error returned. Want %v. Got %v", test.givenClip, test.wantErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		input := fakeClient.jobs[jobStatus.ProviderJobID].Settings.Inputs[0]
+		if aws.StringValue(input.TimecodeSource) != "ZEROBASED" {
+			t.Errorf("%#v: wrong timecode source. Want %q. Got %q", test.givenClip, "ZEROBASED", aws.StringValue(input.TimecodeSource))
+		}
+		expected := []*mediaconvert.InputClipping{test.want}
+		if !reflect.DeepEqual(input.InputClippings, expected) {
+			t.Errorf("%#v: wrong input clippings\nWant %#v\nGot  %#v", test.givenClip, expected, input.InputClippings)
+		}
+	}
+}
+
+func TestTranscodeDescribedAudio(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "hls_720p.m3u8", Preset: db.PresetMap{Name: "hls_720p", ProviderMapping: map[string]string{Name: "hls-720p"}}},
+		},
+		StreamingParams: provider.StreamingParams{PlaylistFileName: "master.m3u8", SegmentDuration: 6, Protocol: provider.ProtocolHLS},
+		DescribedAudio:  &db.DescribedAudio{Source: "s3://some-bucket/description.wav", Language: "eng"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := fakeClient.jobs[jobStatus.ProviderJobID].Settings
+	selector := settings.Inputs[0].AudioSelectors["Audio Description"]
+	if selector == nil || aws.StringValue(selector.ExternalAudioFileInput) != "s3://some-bucket/description.wav" {
+		t.Errorf("wrong audio description selector: %#v", selector)
+	}
+	outputs := settings.OutputGroups[0].Outputs
+	if len(outputs) != 3 {
+		t.Fatalf("wrong number of hls outputs. Want 3. Got %d", len(outputs))
+	}
+	if sets := aws.StringValue(outputs[0].OutputSettings.HlsSettings.AudioRenditionSets); sets != "program_audio" {
+		t.Errorf("wrong audio rendition sets. Want %q. Got %q", "program_audio", sets)
+	}
+	program, description := outputs[1], outputs[2]
+	if trackType := aws.StringValue(program.OutputSettings.HlsSettings.AudioTrackType); trackType != "ALTERNATE_AUDIO_AUTO_SELECT_DEFAULT" {
+		t.Errorf("wrong track type of the program audio: %q", trackType)
+	}
+	hlsSettings := description.OutputSettings.HlsSettings
+	if aws.StringValue(hlsSettings.AudioGroupId) != "program_audio" || aws.StringValue(hlsSettings.AudioTrackType) != "ALTERNATE_AUDIO_NOT_AUTO_SELECT" {
+		t.Errorf("wrong hls settings of the audio description: %#v", hlsSettings)
+	}
+	if aws.StringValue(hlsSettings.DescriptiveVideoServiceFlag) != "FLAG" {
+		t.Error("audio description not flagged as describing the video")
+	}
+	audio := description.AudioDescriptions[0]
+	if aws.StringValue(audio.AudioSourceName) != "Audio Description" || aws.Int64Value(audio.AudioType) != 3 || aws.StringValue(audio.CustomLanguageCode) != "eng" {
+		t.Errorf("wrong audio description: %#v", audio)
+	}
+}
+
+func TestTranscodeDescribedAudioWithoutHLS(t *testing.T) {
+	prov := newTestProvider(newFakeMediaConvert())
+	_, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video_1080p.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+		},
+		DescribedAudio: &db.DescribedAudio{Source: "s3://some-bucket/description.wav", Language: "eng"},
+	})
+	if err == nil || err.Error() != "described audio requires hls outputs" {
+		t.Errorf("wrong error returned: %v", err)
+	}
+}
+
+func TestTranscodeAudioTrack(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video_1080p.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+		},
+		AudioTracks: []db.AudioTrack{{Track: 2, Language: "spa", Name: "Español", Default: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]*mediaconvert.AudioSelector{
+		"Audio Selector 1": {SelectorType: aws.String("TRACK"), Tracks: []*int64{aws.Int64(2)}},
+	}
+	selectors := fakeClient.jobs[jobStatus.ProviderJobID].Settings.Inputs[0].AudioSelectors
+	if !reflect.DeepEqual(selectors, expected) {
+		t.Errorf("wrong audio selectors\nWant %#v\nGot  %#v", expected, selectors)
+	}
+}
+
+func TestTranscodeAudioTracks(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	jobStatus, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "hls_720p.m3u8", Preset: db.PresetMap{Name: "hls_720p", ProviderMapping: map[string]string{Name: "hls-720p"}}},
+		},
+		StreamingParams: provider.StreamingParams{PlaylistFileName: "master.m3u8", SegmentDuration: 6, Protocol: provider.ProtocolHLS},
+		AudioTracks: []db.AudioTrack{
+			{Track: 1, Language: "eng", Name: "English"},
+			{Track: 3, Language: "fra", Name: "Français", Default: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := fakeClient.jobs[jobStatus.ProviderJobID].Settings
+	expectedSelectors := map[string]*mediaconvert.AudioSelector{
+		"Audio Selector 1": {SelectorType: aws.String("TRACK"), Tracks: []*int64{aws.Int64(3)}},
+		"Audio Track 1":    {SelectorType: aws.String("TRACK"), Tracks: []*int64{aws.Int64(1)}},
+	}
+	if selectors := settings.Inputs[0].AudioSelectors; !reflect.DeepEqual(selectors, expectedSelectors) {
+		t.Errorf("wrong audio selectors\nWant %#v\nGot  %#v", expectedSelectors, selectors)
+	}
+	outputs := settings.OutputGroups[0].Outputs
+	if len(outputs) != 3 {
+		t.Fatalf("wrong number of hls outputs. Want 3. Got %d", len(outputs))
+	}
+	if sets := aws.StringValue(outputs[0].OutputSettings.HlsSettings.AudioRenditionSets); sets != "program_audio" {
+		t.Errorf("wrong audio rendition sets. Want %q. Got %q", "program_audio", sets)
+	}
+	var tests = []struct {
+		nameModifier string
+		selector     string
+		trackType    string
+		language     string
+		name         string
+	}{
+		{"_audio_1", "Audio Track 1", "ALTERNATE_AUDIO_AUTO_SELECT", "eng", "English"},
+		{"_audio_3", "Audio Selector 1", "ALTERNATE_AUDIO_AUTO_SELECT_DEFAULT", "fra", "Français"},
+	}
+	for i, test := range tests {
+		output := outputs[i+1]
+		audio := output.AudioDescriptions[0]
+		if aws.StringValue(output.NameModifier) != test.nameModifier || aws.StringValue(audio.AudioSourceName) != test.selector {
+			t.Errorf("%s: wrong rendition: %#v", test.nameModifier, output)
+		}
+		if trackType := aws.StringValue(output.OutputSettings.HlsSettings.AudioTrackType); trackType != test.trackType {
+			t.Errorf("%s: wrong track type. Want %q. Got %q", test.nameModifier, test.trackType, trackType)
+		}
+		if aws.StringValue(audio.CustomLanguageCode) != test.language || aws.StringValue(audio.StreamName) != test.name {
+			t.Errorf("%s: wrong labels of the rendition: %#v", test.nameModifier, audio)
+		}
+	}
+}
+
+func TestTranscodeAudioTracksWithoutHLS(t *testing.T) {
+	prov := newTestProvider(newFakeMediaConvert())
+	_, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video_1080p.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+		},
+		AudioTracks: []db.AudioTrack{{Track: 1, Default: true}, {Track: 2}},
+	})
+	if err == nil || err.Error() != "multiple audio tracks require hls outputs" {
+		t.Errorf("wrong error returned: %v", err)
+	}
+}
+
+func TestTranscodePresetNotFound(t *testing.T) {
+	prov := newTestProvider(newFakeMediaConvert())
+	_, err := prov.Transcode(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{"other": "irrelevant"}}},
+		},
+	})
+	if err != provider.ErrPresetMapNotFound {
+		t.Errorf("wrong error returned. Want %#v. Got %#v", provider.ErrPresetMapNotFound, err)
+	}
+}
+
+func TestJobStatus(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	job := db.Job{ID: "job-123"}
+	jobStatus, err := prov.Transcode(&job, provider.TranscodeProfile{
+		SourceMedia: "s3://some-bucket/source.mov",
+		Outputs: []provider.TranscodeOutput{
+			{FileName: "video_1080p.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{Name: "mp4-1080p"}}},
+			{FileName: "hls_720p.m3u8", Preset: db.PresetMap{Name: "hls_720p", ProviderMapping: map[string]string{Name: "hls-720p"}}},
+		},
+		StreamingParams: provider.StreamingParams{PlaylistFileName: "master.m3u8", SegmentDuration: 6},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.ProviderJobID = jobStatus.ProviderJobID
+	status, err := prov.JobStatus(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := provider.JobStatus{
+		ProviderJobID: jobStatus.ProviderJobID,
+		Status:        provider.StatusFinished,
+		Progress:      100,
+		ProviderStatus: map[string]interface{}{
+			"status":       "COMPLETE",
+			"currentPhase": "UPLOADING",
+			"queue":        "arn:aws:mediaconvert:us-east-1:123456789012:queues/Default",
+		},
+		SourceInfo: provider.SourceInfo{Duration: 2 * time.Minute, Width: 1280, Height: 720},
+		Output: provider.JobOutput{
+			Destination: "s3://some-bucket/outputs/job-123",
+			Files: []provider.OutputFile{
+				{
+					Path:       "s3://some-bucket/outputs/job-123/video_1080p.mp4",
+					Container:  "mp4",
+					VideoCodec: "h264",
+					Width:      1280,
+					Height:     720,
+				},
+				{Path: "s3://some-bucket/outputs/job-123/master.m3u8", Container: "m3u8"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(*status, expected) {
+		t.Errorf("wrong job status\nWant %#v\nGot  %#v", expected, *status)
+	}
+}
+
+func TestJobStatusInternalError(t *testing.T) {
+	prepErr := errors.New("failed to get job")
+	fakeClient := newFakeMediaConvert()
+	fakeClient.prepareFailure("GetJob", prepErr)
+	prov := newTestProvider(fakeClient)
+	_, err := prov.JobStatus(&db.Job{ID: "job-123", ProviderJobID: "1500000000000-job1"})
+	if err != prepErr {
+		t.Errorf("wrong error returned. Want %#v. Got %#v", prepErr, err)
+	}
+}
+
+func TestStatusMap(t *testing.T) {
+	var tests = []struct {
+		input  string
+		output provider.Status
+	}{
+		{"SUBMITTED", provider.StatusQueued},
+		{"PROGRESSING", provider.StatusStarted},
+		{"COMPLETE", provider.StatusFinished},
+		{"CANCELED", provider.StatusCanceled},
+		{"ERROR", provider.StatusFailed},
+		{"whatever", provider.StatusUnknown},
+	}
+	var prov mcProvider
+	for _, test := range tests {
+		result := prov.statusMap(test.input)
+		if result != test.output {
+			t.Errorf("statusMap(%q): wrong result. Want %q. Got %q", test.input, test.output, result)
+		}
+	}
+}
+
+func TestIsSourceFailure(t *testing.T) {
+	var tests = []struct {
+		providerStatus map[string]interface{}
+		want           bool
+	}{
+		{map[string]interface{}{"status": "ERROR", "errorCode": int64(1030)}, true},
+		{map[string]interface{}{"status": "ERROR", "errorCode": int64(1040)}, true},
+		{map[string]interface{}{"status": "ERROR", "errorCode": int64(1401)}, false},
+		{map[string]interface{}{"status": "ERROR"}, false},
+	}
+	var prov mcProvider
+	for _, test := range tests {
+		status := provider.JobStatus{Status: provider.StatusFailed, ProviderStatus: test.providerStatus}
+		if got := prov.IsSourceFailure(&status); got != test.want {
+			t.Errorf("IsSourceFailure(%v): wrong result. Want %v. Got %v", test.providerStatus, test.want, got)
+		}
+	}
+}
+
+func TestCreatePreset(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	presetID, err := prov.CreatePreset(db.Preset{
+		Name:         "mp4_720p",
+		Description:  "MP4 720p",
+		Container:    "mp4",
+		Profile:      "high",
+		ProfileLevel: "4.1",
+		RateControl:  "VBR",
+		Video: db.VideoPreset{
+			Width:   "1280",
+			Height:  "720",
+			Codec:   "h264",
+			Bitrate: "3500000",
+			GopSize: "90",
+			GopMode: "fixed",
+		},
+		Audio: db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetID != "mp4_720p" {
+		t.Errorf("wrong preset id returned. Want %q. Got %q", "mp4_720p", presetID)
+	}
+	expected := mediaconvert.PresetSettings{
+		ContainerSettings: &mediaconvert.ContainerSettings{Container: aws.String("MP4")},
+		VideoDescription: &mediaconvert.VideoDescription{
+			Width:  aws.Int64(1280),
+			Height: aws.Int64(720),
+			CodecSettings: &mediaconvert.VideoCodecSettings{
+				Codec: aws.String("H_264"),
+				H264Settings: &mediaconvert.H264Settings{
+					Bitrate:           aws.Int64(3500000),
+					RateControlMode:   aws.String("VBR"),
+					CodecProfile:      aws.String("HIGH"),
+					CodecLevel:        aws.String("LEVEL_4_1"),
+					InterlaceMode:     aws.String("PROGRESSIVE"),
+					SceneChangeDetect: aws.String("DISABLED"),
+					GopSize:           aws.Float64(90),
+					GopSizeUnits:      aws.String("FRAMES"),
+				},
+			},
+		},
+		AudioDescriptions: []*mediaconvert.AudioDescription{{
+			AudioSourceName: aws.String("Audio Selector 1"),
+			CodecSettings: &mediaconvert.AudioCodecSettings{
+				Codec: aws.String("AAC"),
+				AacSettings: &mediaconvert.AacSettings{
+					Bitrate:    aws.Int64(128000),
+					CodingMode: aws.String("CODING_MODE_2_0"),
+					SampleRate: aws.Int64(48000),
+				},
+			},
+		}},
+	}
+	input := fakeClient.presets["mp4_720p"]
+	if input == nil {
+		t.Fatal("preset not sent to MediaConvert")
+	}
+	if !reflect.DeepEqual(*input.Settings, expected) {
+		t.Errorf("wrong preset settings\nWant %#v\nGot  %#v", expected, *input.Settings)
+	}
+}
+
+func TestCreatePresetAudioOnly(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	_, err := prov.CreatePreset(db.Preset{
+		Name:      "mp3_128k",
+		Container: "mp3",
+		AudioOnly: true,
+		Audio:     db.AudioPreset{Codec: "mp3", Bitrate: "128000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := mediaconvert.PresetSettings{
+		ContainerSettings: &mediaconvert.ContainerSettings{Container: aws.String("RAW")},
+		AudioDescriptions: []*mediaconvert.AudioDescription{{
+			AudioSourceName: aws.String("Audio Selector 1"),
+			CodecSettings: &mediaconvert.AudioCodecSettings{
+				Codec: aws.String("MP3"),
+				Mp3Settings: &mediaconvert.Mp3Settings{
+					Bitrate:    aws.Int64(128000),
+					Channels:   aws.Int64(2),
+					SampleRate: aws.Int64(48000),
+				},
+			},
+		}},
+	}
+	input := fakeClient.presets["mp3_128k"]
+	if input == nil {
+		t.Fatal("preset not sent to MediaConvert")
+	}
+	if !reflect.DeepEqual(*input.Settings, expected) {
+		t.Errorf("wrong preset settings\nWant %#v\nGot  %#v", expected, *input.Settings)
+	}
+}
+
+func TestCreatePresetAV1(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	_, err := prov.CreatePreset(db.Preset{
+		Name:      "av1_720p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "av1", Bitrate: "1500000", GopSize: "90"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := fakeClient.presets["av1_720p"]
+	if input == nil {
+		t.Fatal("preset not sent to MediaConvert")
+	}
+	codecSettings := input.Settings.VideoDescription.CodecSettings
+	if codec := aws.StringValue(codecSettings.Codec); codec != "AV1" {
+		t.Errorf("wrong codec. Want %q. Got %q", "AV1", codec)
+	}
+	expected := &mediaconvert.Av1Settings{
+		GopSize:         aws.Float64(90),
+		MaxBitrate:      aws.Int64(1500000),
+		RateControlMode: aws.String("QVBR"),
+	}
+	if !reflect.DeepEqual(codecSettings.Av1Settings, expected) {
+		t.Errorf("wrong AV1 settings\nWant %#v\nGot  %#v", expected, codecSettings.Av1Settings)
+	}
+}
+
+func TestCreatePresetHDR(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		video     db.VideoPreset
+		wantCodec string
+		want      *mediaconvert.ColorCorrector
+	}{
+		{
+			"HDR10 with metadata",
+			db.VideoPreset{
+				Codec:             "hevc",
+				Bitrate:           "15000000",
+				ColorSpace:        "bt2020",
+				TransferFunction:  "pq",
+				MasteringDisplay:  "G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50)",
+				ContentLightLevel: "1000,400",
+			},
+			"H_265",
+			&mediaconvert.ColorCorrector{
+				ColorSpaceConversion: aws.String("FORCE_HDR10"),
+				Hdr10Metadata: &mediaconvert.Hdr10Metadata{
+					GreenPrimaryX:             aws.Int64(13250),
+					GreenPrimaryY:             aws.Int64(34500),
+					BluePrimaryX:              aws.Int64(7500),
+					BluePrimaryY:              aws.Int64(3000),
+					RedPrimaryX:               aws.Int64(34000),
+					RedPrimaryY:               aws.Int64(16000),
+					WhitePointX:               aws.Int64(15635),
+					WhitePointY:               aws.Int64(16450),
+					MaxLuminance:              aws.Int64(10000000),
+					MinLuminance:              aws.Int64(50),
+					MaxContentLightLevel:      aws.Int64(1000),
+					MaxFrameAverageLightLevel: aws.Int64(400),
+				},
+			},
+		},
+		{
+			"HLG",
+			db.VideoPreset{Codec: "hevc", Bitrate: "15000000", TransferFunction: "hlg"},
+			"H_265",
+			&mediaconvert.ColorCorrector{ColorSpaceConversion: aws.String("FORCE_HLG_2020")},
+		},
+		{
+			"tone mapped to SDR",
+			db.VideoPreset{Codec: "h264", Bitrate: "5000000", ToneMap: true},
+			"H_264",
+			&mediaconvert.ColorCorrector{
+				ColorSpaceConversion: aws.String("FORCE_709"),
+				HdrToSdrToneMapper:   aws.String("PRESERVE_DETAILS"),
+			},
+		},
+	}
+	for _, test := range tests {
+		fakeClient := newFakeMediaConvert()
+		prov := newTestProvider(fakeClient)
+		_, err := prov.CreatePreset(db.Preset{Name: "uhd", Container: "mp4", Video: test.video})
+		if err != nil {
+			t.Fatalf("%s: %s", test.testCase, err)
+		}
+		video := fakeClient.presets["uhd"].Settings.VideoDescription
+		if codec := aws.StringValue(video.CodecSettings.Codec); codec != test.wantCodec {
+			t.Errorf("%s: wrong codec. Want %q. Got %q", test.testCase, test.wantCodec, codec)
+		}
+		if video.VideoPreprocessors == nil {
+			t.Errorf("%s: no color conversion", test.testCase)
+			continue
+		}
+		if !reflect.DeepEqual(video.VideoPreprocessors.ColorCorrector, test.want) {
+			t.Errorf("%s: wrong color conversion\nWant %#v\nGot  %#v", test.testCase, test.want, video.VideoPreprocessors.ColorCorrector)
+		}
+		wantMetadata := ""
+		if test.video.HDR() {
+			wantMetadata = "INSERT"
+		}
+		if metadata := aws.StringValue(video.ColorMetadata); metadata != wantMetadata {
+			t.Errorf("%s: wrong color metadata. Want %q. Got %q", test.testCase, wantMetadata, metadata)
+		}
+	}
+}
+
+func TestCreatePresetLoudness(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		audio    db.AudioPreset
+		want     *mediaconvert.AudioNormalizationSettings
+	}{
+		{"no normalization", db.AudioPreset{Codec: "aac", Bitrate: "128000"}, nil},
+		{
+			"target loudness",
+			db.AudioPreset{Codec: "aac", Bitrate: "128000", Loudness: "-23"},
+			&mediaconvert.AudioNormalizationSettings{
+				Algorithm:        aws.String("ITU_BS_1770_3"),
+				AlgorithmControl: aws.String("CORRECT_AUDIO"),
+				TargetLkfs:       aws.Float64(-23),
+			},
+		},
+		{
+			"target loudness and true peak",
+			db.AudioPreset{Codec: "aac", Bitrate: "128000", Loudness: "-24", TruePeak: "-2"},
+			&mediaconvert.AudioNormalizationSettings{
+				Algorithm:                aws.String("ITU_BS_1770_3"),
+				AlgorithmControl:         aws.String("CORRECT_AUDIO"),
+				TargetLkfs:               aws.Float64(-24),
+				PeakCalculation:          aws.String("TRUE_PEAK"),
+				TruePeakLimiterThreshold: aws.Float64(-2),
+			},
+		},
+	}
+	for _, test := range tests {
+		fakeClient := newFakeMediaConvert()
+		prov := newTestProvider(fakeClient)
+		_, err := prov.CreatePreset(db.Preset{Name: "audio", Container: "m4a", AudioOnly: true, Audio: test.audio})
+		if err != nil {
+			t.Fatalf("%s: %s", test.testCase, err)
+		}
+		got := fakeClient.presets["audio"].Settings.AudioDescriptions[0].AudioNormalizationSettings
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: wrong audio normalization\nWant %#v\nGot  %#v", test.testCase, test.want, got)
+		}
+	}
+}
+
+func TestCreatePresetChannelLayout(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		audio    db.AudioPreset
+		want     mediaconvert.AudioCodecSettings
+	}{
+		{
+			"aac 5.1",
+			db.AudioPreset{Codec: "aac", Bitrate: "384000", ChannelLayout: "5.1"},
+			mediaconvert.AudioCodecSettings{
+				Codec: aws.String("AAC"),
+				AacSettings: &mediaconvert.AacSettings{
+					Bitrate:    aws.Int64(384000),
+					CodingMode: aws.String("CODING_MODE_5_1"),
+					SampleRate: aws.Int64(48000),
+				},
+			},
+		},
+		{
+			"ac3 stereo",
+			db.AudioPreset{Codec: "ac3", Bitrate: "192000", ChannelLayout: "stereo"},
+			mediaconvert.AudioCodecSettings{
+				Codec: aws.String("AC3"),
+				Ac3Settings: &mediaconvert.Ac3Settings{
+					Bitrate:    aws.Int64(192000),
+					CodingMode: aws.String("CODING_MODE_2_0"),
+					SampleRate: aws.Int64(48000),
+				},
+			},
+		},
+		{
+			"ac3 5.1",
+			db.AudioPreset{Codec: "ac3", Bitrate: "384000", ChannelLayout: "5.1"},
+			mediaconvert.AudioCodecSettings{
+				Codec: aws.String("AC3"),
+				Ac3Settings: &mediaconvert.Ac3Settings{
+					Bitrate:    aws.Int64(384000),
+					CodingMode: aws.String("CODING_MODE_3_2_LFE"),
+					SampleRate: aws.Int64(48000),
+				},
+			},
+		},
+		{
+			"eac3 5.1",
+			db.AudioPreset{Codec: "eac3", Bitrate: "640000", ChannelLayout: "5.1"},
+			mediaconvert.AudioCodecSettings{
+				Codec: aws.String("EAC3"),
+				Eac3Settings: &mediaconvert.Eac3Settings{
+					Bitrate:    aws.Int64(640000),
+					CodingMode: aws.String("CODING_MODE_3_2"),
+					LfeControl: aws.String("LFE"),
+					SampleRate: aws.Int64(48000),
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		fakeClient := newFakeMediaConvert()
+		prov := newTestProvider(fakeClient)
+		_, err := prov.CreatePreset(db.Preset{Name: "surround", Container: "mp4", Video: db.VideoPreset{Codec: "h264", Bitrate: "5000000"}, Audio: test.audio})
+		if err != nil {
+			t.Fatalf("%s: %s", test.testCase, err)
+		}
+		got := fakeClient.presets["surround"].Settings.AudioDescriptions[0].CodecSettings
+		if !reflect.DeepEqual(*got, test.want) {
+			t.Errorf("%s: wrong audio codec settings\nWant %#v\nGot  %#v", test.testCase, test.want, *got)
+		}
+	}
+	_, err := newTestProvider(newFakeMediaConvert()).CreatePreset(db.Preset{
+		Name:      "surround",
+		Container: "webm",
+		Video:     db.VideoPreset{Codec: "vp9", Bitrate: "5000000"},
+		Audio:     db.AudioPreset{Codec: "opus", Bitrate: "256000", ChannelLayout: "5.1"},
+	})
+	expectedMsg := `mediaconvert doesn't support 5.1 outputs with the audio codec "opus"`
+	if err == nil || err.Error() != expectedMsg {
+		t.Errorf("wrong error returned.\nWant %q\nGot  %v", expectedMsg, err)
+	}
+}
+
+func TestCreatePresetUnsupportedCodec(t *testing.T) {
+	prov := newTestProvider(newFakeMediaConvert())
+	_, err := prov.CreatePreset(db.Preset{
+		Name:      "theora",
+		Container: "ogg",
+		Video:     db.VideoPreset{Codec: "theora", Bitrate: "1000000"},
+	})
+	if err == nil {
+		t.Error("unexpected <nil> error")
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	prov := newTestProvider(fakeClient)
+	if err := prov.CancelJob("1500000000000-job1"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fakeClient.canceledJobs, []string{"1500000000000-job1"}) {
+		t.Errorf("wrong jobs canceled: %#v", fakeClient.canceledJobs)
+	}
+}
+
+func TestActiveJobs(t *testing.T) {
+	fakeClient := newFakeMediaConvert()
+	fakeClient.jobs["job-2"] = &mediaconvert.CreateJobInput{}
+	fakeClient.jobs["job-1"] = &mediaconvert.CreateJobInput{}
+	prov := newTestProvider(fakeClient)
+	jobs, err := prov.ActiveJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creationTime := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	expected := []provider.ActiveJob{{ID: "job-1", CreationTime: creationTime}, {ID: "job-2", CreationTime: creationTime}}
+	if !reflect.DeepEqual(jobs, expected) {
+		t.Errorf("wrong active jobs. Want %#v. Got %#v", expected, jobs)
+	}
+}
+
+func TestHealthcheck(t *testing.T) {
+	prepErr := errors.New("queue not found")
+	var tests = []struct {
+		testCase  string
+		failure   error
+		wantError error
+	}{
+		{"healthy queue", nil, nil},
+		{"failure to get the queue", prepErr, prepErr},
+	}
+	for _, test := range tests {
+		fakeClient := newFakeMediaConvert()
+		if test.failure != nil {
+			fakeClient.prepareFailure("GetQueue", test.failure)
+		}
+		prov := newTestProvider(fakeClient)
+		if err := prov.Healthcheck(); err != test.wantError {
+			t.Errorf("%s: wrong error returned. Want %#v. Got %#v", test.testCase, test.wantError, err)
+		}
+	}
+}
+
+func TestQueueName(t *testing.T) {
+	var tests = []struct {
+		queue string
+		want  string
+	}{
+		{"", "Default"},
+		{"transcoding-api", "transcoding-api"},
+		{"arn:aws:mediaconvert:us-east-1:123456789012:queues/transcoding-api", "transcoding-api"},
+	}
+	for _, test := range tests {
+		prov := mcProvider{config: &config.MediaConvert{Queue: test.queue}}
+		if got := prov.queueName(); got != test.want {
+			t.Errorf("queueName(%q): wrong name. Want %q. Got %q", test.queue, test.want, got)
+		}
+	}
+}
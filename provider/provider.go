@@ -69,27 +69,103 @@ func (err JobNotFoundError) Error() string {
 // JobStatus is the representation of the status as the provide sees it. The
 // provider is able to add customized information in the ProviderStatus field.
 //
+// When ProgressEstimated is true, the value in Progress was not reported by
+// the provider, but interpolated by the API.
+//
+// VerificationProblems lists the inconsistencies found by the API when
+// verifying the segments of adaptive streaming outputs.
+//
+// For jobs accepted by a fallback provider, RequestedProvider is the provider
+// requested when creating the job, and ProviderName the provider that
+// accepted it.
+//
+// For jobs with fallback sources, SourceMedia is the source currently in use
+// and FailedSources lists the sources that the provider failed to read.
+//
+// For jobs with a completion deadline, Deadline reports the deadline and
+// the actions taken by the API for meeting it.
+//
+// Links lists the URLs of the resources related to the job in the API, keyed
+// by their relation to the job (self, cancel, outputs, etc.).
+//
 // swagger:model
 type JobStatus struct {
-	ProviderJobID  string                 `json:"providerJobId,omitempty"`
-	Status         Status                 `json:"status,omitempty"`
-	ProviderName   string                 `json:"providerName,omitempty"`
-	StatusMessage  string                 `json:"statusMessage,omitempty"`
-	Progress       float64                `json:"progress"`
-	ProviderStatus map[string]interface{} `json:"providerStatus,omitempty"`
-	Output         JobOutput              `json:"output"`
-	SourceInfo     SourceInfo             `json:"sourceInfo,omitempty"`
+	ProviderJobID        string                   `json:"providerJobId,omitempty"`
+	Status               Status                   `json:"status,omitempty"`
+	ProviderName         string                   `json:"providerName,omitempty"`
+	RequestedProvider    string                   `json:"requestedProvider,omitempty"`
+	Deadline             *db.Deadline             `json:"deadline,omitempty"`
+	StatusMessage        string                   `json:"statusMessage,omitempty"`
+	Progress             float64                  `json:"progress"`
+	ProgressEstimated    bool                     `json:"progressEstimated,omitempty"`
+	ProviderStatus       map[string]interface{}   `json:"providerStatus,omitempty"`
+	Output               JobOutput                `json:"output"`
+	SourceInfo           SourceInfo               `json:"sourceInfo,omitempty"`
+	VerificationProblems []string                 `json:"verificationProblems,omitempty"`
+	Warnings             []string                 `json:"warnings,omitempty"`
+	Prediction           *JobPrediction           `json:"prediction,omitempty"`
+	Uploads              []FileUpload             `json:"uploads,omitempty"`
+	Fingerprints         []db.OutputFingerprint   `json:"fingerprints,omitempty"`
+	SourceMedia          string                   `json:"sourceMedia,omitempty"`
+	FailedSources        []string                 `json:"failedSources,omitempty"`
+	Links                map[string]string        `json:"links,omitempty"`
+	Poster               string                   `json:"poster,omitempty"`
+	PostProcessors       []db.PostProcessorResult `json:"postProcessors,omitempty"`
+}
+
+// FileUpload is the status of the upload of an output file to its final
+// destination, for destinations that providers can't write to directly.
+type FileUpload struct {
+	Path        string       `json:"path"`
+	Destination string       `json:"destination"`
+	Status      UploadStatus `json:"status"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// UploadStatus is the status of the upload of an output file.
+type UploadStatus string
+
+const (
+	// UploadPending is the status of files waiting to be uploaded.
+	UploadPending = UploadStatus("pending")
+
+	// UploadStarted is the status of files being uploaded.
+	UploadStarted = UploadStatus("started")
+
+	// UploadFinished is the status of files uploaded successfully.
+	UploadFinished = UploadStatus("finished")
+
+	// UploadFailed is the status of files that failed to upload.
+	UploadFailed = UploadStatus("failed")
+)
+
+// JobPrediction contains the expected completion time and cost of a job,
+// based on the history of jobs in the same provider.
+type JobPrediction struct {
+	// range of the expected completion time of the job
+	EarliestCompletion time.Time `json:"earliestCompletion"`
+	LatestCompletion   time.Time `json:"latestCompletion"`
+
+	// range of the expected cost of the job. Omitted when the price of
+	// the provider is unknown.
+	MinCost float64 `json:"minCost,omitempty"`
+	MaxCost float64 `json:"maxCost,omitempty"`
+
+	// number of previous jobs used in the prediction
+	Samples int `json:"samples"`
 }
 
 // JobOutput represents information about a job output.
 type JobOutput struct {
 	Destination string       `json:"destination,omitempty"`
+	CDNURL      string       `json:"cdnURL,omitempty"`
 	Files       []OutputFile `json:"files,omitempty"`
 }
 
 // OutputFile represents an output file in a given job.
 type OutputFile struct {
 	Path       string `json:"path"`
+	CDNURL     string `json:"cdnURL,omitempty"`
 	Container  string `json:"container"`
 	VideoCodec string `json:"videoCodec"`
 	Height     int64  `json:"height"`
@@ -110,25 +186,197 @@ type SourceInfo struct {
 	VideoCodec string `json:"videoCodec,omitempty"`
 }
 
+// Protocol is the adaptive streaming protocol of a job.
+type Protocol string
+
+const (
+	// ProtocolHLS is the protocol of HTTP Live Streaming jobs.
+	ProtocolHLS = Protocol("hls")
+
+	// ProtocolDASH is the protocol of MPEG-DASH jobs.
+	ProtocolDASH = Protocol("dash")
+
+	// ProtocolCMAF is the protocol of jobs with CMAF segments.
+	ProtocolCMAF = Protocol("cmaf")
+)
+
+// Valid returns whether the protocol is one of the known streaming
+// protocols.
+func (p Protocol) Valid() bool {
+	return p == ProtocolHLS || p == ProtocolDASH || p == ProtocolCMAF
+}
+
+// SegmentFormat is the container of the segments of HLS jobs.
+type SegmentFormat string
+
+const (
+	// SegmentFormatTS is the format of MPEG-TS segments, the default in
+	// HLS jobs.
+	SegmentFormatTS = SegmentFormat("ts")
+
+	// SegmentFormatFMP4 is the format of fragmented MP4 segments, which
+	// can be shared by HLS and DASH playlists.
+	SegmentFormatFMP4 = SegmentFormat("fmp4")
+)
+
+// Valid returns whether the format is one of the known segment formats.
+func (f SegmentFormat) Valid() bool {
+	return f == SegmentFormatTS || f == SegmentFormatFMP4
+}
+
 // StreamingParams contains all parameters related to the streaming protocol used.
+//
+// MinBufferTime is the minimum buffer time, in seconds, declared in the
+// manifests of DASH jobs, and SegmentFormat is the container of the segments
+// of HLS jobs (MPEG-TS when empty). AudioOnlyRendition adds an audio-only
+// variant to the master playlist of HLS jobs (required by the App Store for
+// streams delivered over cellular networks), encoded at AudioOnlyBitrate
+// bits per second (DefaultAudioOnlyBitrate when zero).
 type StreamingParams struct {
-	PlaylistFileName string `json:"playlistFileName,omitempty"`
-	SegmentDuration  uint   `json:"segmentDuration,omitempty"`
-	Protocol         string `json:"protocol,omitempty"`
+	PlaylistFileName   string             `json:"playlistFileName,omitempty"`
+	SegmentDuration    uint               `json:"segmentDuration,omitempty"`
+	Protocol           Protocol           `json:"protocol,omitempty"`
+	SegmentFormat      SegmentFormat      `json:"segmentFormat,omitempty"`
+	MinBufferTime      float64            `json:"minBufferTime,omitempty"`
+	KeyframeAlignment  *KeyframeAlignment `json:"keyframeAlignment,omitempty"`
+	AudioOnlyRendition bool               `json:"audioOnlyRendition,omitempty"`
+	AudioOnlyBitrate   uint               `json:"audioOnlyBitrate,omitempty"`
+}
+
+// DefaultAudioOnlyBitrate is the bitrate, in bits per second, of the
+// audio-only variant of HLS jobs.
+const DefaultAudioOnlyBitrate = 64000
+
+// DRM schemes supported in adaptive streaming outputs, as listed in
+// Capabilities.DRMSchemes.
+const (
+	DRMSchemeWidevine  = "widevine"
+	DRMSchemeFairPlay  = "fairplay"
+	DRMSchemePlayReady = "playready"
+	DRMSchemeAES128    = "aes-128"
+)
+
+// DRMParams contains the settings for encrypting the adaptive streaming
+// outputs of a job. Key is the 128-bit content key, identified by KeyID in
+// the license server at LicenseURL (for aes-128, the URL of the key
+// referenced in the playlists). IV is optional.
+type DRMParams struct {
+	Scheme     string
+	Key        []byte
+	KeyID      []byte
+	LicenseURL string
+	IV         []byte
+}
+
+// Formats of caption files.
+const (
+	CaptionFormatSRT    = "srt"
+	CaptionFormatWebVTT = "webvtt"
+	CaptionFormatTTML   = "ttml"
+)
+
+// Caption modes, as listed in Capabilities.CaptionModes. Sidecar captions
+// are delivered as caption files along with the outputs, and burned-in
+// captions are rendered in the video of the outputs.
+const (
+	CaptionModeSidecar = "sidecar"
+	CaptionModeBurnIn  = "burn-in"
+)
+
+// Caption is a caption (or subtitle) file ingested in a job. FileName is the
+// name of the caption file delivered by sidecar captions, relative to the
+// destination of the job.
+type Caption struct {
+	Source   string
+	Language string
+	Format   string
+	Mode     string
+	FileName string
+}
+
+// Thumbnails describes the thumbnails generated by a job: Count thumbnails
+// evenly spaced over the source, or one every Interval seconds. Thumbnails
+// are scaled to fit Width and Height, keeping the aspect ratio of the
+// source when one of them is zero, and encoded in Format (jpg or png, or
+// the default format of the provider when empty).
+type Thumbnails struct {
+	Interval uint
+	Count    uint
+	Width    uint
+	Height   uint
+	Format   string
 }
 
 // TranscodeProfile defines the set of inputs necessary for running a transcoding job.
+//
+// SourceEncryption is only set for providers implementing SourceDecrypter,
+// when the source must be decrypted by the provider. Conform is only set for
+// conform jobs, and requires the Conform capability. Clip is set for partial
+// transcodes, requiring the Clipping capability, and for jobs with trimmed
+// sources, requiring the Trim capability. DRM is set for
+// jobs with encrypted outputs, and requires support for its scheme in
+// Capabilities.DRMSchemes. Captions require support for their modes in
+// Capabilities.CaptionModes. Thumbnails requires the Thumbnails capability.
+// PlaylistHeaders are the headers of the
+// playlist (or manifest) generated by the provider in adaptive streaming
+// jobs, and, like the headers of outputs, require the OutputHeaders
+// capability. BillingTags are attached to the job in providers that support
+// tagging jobs (like AWS cost allocation tags), and ignored by the others.
+// DescribedAudio is set for adaptive streaming jobs with an audio
+// description track, and requires the DescribedAudio capability.
+// AudioTracks are the audio tracks of the source included in the outputs,
+// with the language of the job as their default language, and require the
+// AudioTracks capability. Adaptive streaming jobs with several tracks
+// deliver them as alternate audio renditions.
 type TranscodeProfile struct {
-	SourceMedia     string
-	Outputs         []TranscodeOutput
-	StreamingParams StreamingParams
+	SourceMedia      string
+	Outputs          []TranscodeOutput
+	StreamingParams  StreamingParams
+	DRM              *DRMParams
+	Captions         []Caption
+	Thumbnails       *Thumbnails
+	PlaylistHeaders  *OutputHeaders
+	SourceEncryption *SourceEncryption
+	Conform          *db.Conform
+	Clip             *Clip
+	BillingTags      map[string]string
+	DescribedAudio   *db.DescribedAudio
+	AudioTracks      []db.AudioTrack
+}
+
+// Clip is the range of the source included in the outputs, in seconds. A
+// zero Duration includes the rest of the source.
+type Clip struct {
+	Start    float64
+	Duration float64
+}
+
+// SourceEncryption contains the key and the settings for decrypting the
+// source of a job.
+type SourceEncryption struct {
+	Mode string
+	Key  []byte
+	IV   []byte
 }
 
 // TranscodeOutput represents a transcoding output. It's a combination of the
-// preset and the output file name.
+// preset and the output file name, along with the headers set on the files
+// of the output when they're uploaded to the destination. Preview is set for
+// outputs with a preview clip of the source, and requires the Previews
+// capability.
 type TranscodeOutput struct {
 	Preset   db.PresetMap
 	FileName string
+	Headers  *OutputHeaders
+	Preview  *db.Preview
+}
+
+// OutputHeaders are the HTTP headers and the custom metadata set on output
+// files when they're uploaded to the destination.
+type OutputHeaders struct {
+	CacheControl       string
+	ContentDisposition string
+	Metadata           map[string]string
 }
 
 // Status is the status of a transcoding job.
@@ -145,12 +393,32 @@ const (
 	// StatusFinished is the status for a job that finished successfully.
 	StatusFinished = Status("finished")
 
+	// StatusFinishedWithWarnings is the status for a job that finished,
+	// but whose outputs have problems listed in the warnings of the job.
+	StatusFinishedWithWarnings = Status("finished-with-warnings")
+
 	// StatusFailed is the status for a job that has failed.
 	StatusFailed = Status("failed")
 
 	// StatusCanceled is the status for a job that has been canceled.
 	StatusCanceled = Status("canceled")
 
+	// StatusQueuedLocally is the status for a job that was accepted by the
+	// API, but not yet submitted to the provider.
+	StatusQueuedLocally = Status("queued-locally")
+
+	// StatusPaused is the status for a job that is held in the API while
+	// the submission of jobs to its provider is paused.
+	StatusPaused = Status("paused")
+
+	// StatusSubmitting is the status for a job claimed by an instance of
+	// the API that is submitting it to the provider.
+	StatusSubmitting = Status("submitting")
+
+	// StatusPostProcessing is the status for a finished job claimed by an
+	// instance of the API that is running its post-processors.
+	StatusPostProcessing = Status("post-processing")
+
 	// StatusUnknown is an unexpected status for a job.
 	StatusUnknown = Status("unknown")
 )
@@ -210,5 +478,95 @@ func DescribeProvider(name string, c *config.Config) (*Description, error) {
 	if err = provider.Healthcheck(); err != nil {
 		description.Health = Health{OK: false, Message: err.Error()}
 	}
+	if reporter, ok := provider.(UsageReporter); ok {
+		usage, err := reporter.AccountUsage()
+		if err != nil {
+			description.Health = Health{OK: false, Message: "failed to retrieve account usage: " + err.Error()}
+		} else {
+			description.Usage = usage
+			if usage.NearExhaustion && description.Health.OK {
+				description.Health = Health{OK: false, Message: "account is near exhaustion"}
+			}
+		}
+	}
 	return &description, nil
 }
+
+// AccountUsage describes the consumption of the account used by a provider,
+// for providers that bill by encoding minutes.
+type AccountUsage struct {
+	Plan             string `json:"plan,omitempty"`
+	MinutesUsed      int64  `json:"minutesUsed"`
+	MinutesIncluded  int64  `json:"minutesIncluded"`
+	MinutesRemaining int64  `json:"minutesRemaining"`
+	NearExhaustion   bool   `json:"nearExhaustion"`
+}
+
+// UsageReporter is implemented by providers that are able to report the
+// usage of their accounts. Providers with accounts near exhaustion are
+// reported as unhealthy and don't receive new jobs.
+type UsageReporter interface {
+	AccountUsage() (*AccountUsage, error)
+}
+
+// PresetExporter is implemented by providers that are able to translate
+// their presets back into the generic preset format, allowing presets to be
+// migrated between providers. Settings that can't be represented in the
+// generic format are listed as unsupported.
+type PresetExporter interface {
+	ExportPreset(presetID string) (db.Preset, []string, error)
+}
+
+// PresetValidator is implemented by providers that are able to translate
+// presets into their own format without creating them. The translated
+// preset is returned along with warnings about settings that the provider
+// accepts but are likely wrong, like bitrates out of the range supported by
+// the provider.
+type PresetValidator interface {
+	ValidatePreset(db.Preset) (interface{}, []string, error)
+}
+
+// SourceFailureDetector is implemented by providers that are able to tell
+// whether a failed job failed because the source media couldn't be read.
+// Jobs with fallback sources are resubmitted using the next source when
+// that happens.
+type SourceFailureDetector interface {
+	IsSourceFailure(*JobStatus) bool
+}
+
+// ActiveJobLister is implemented by providers that are able to list the jobs
+// that are currently queued or running. It's used for finding orphaned jobs,
+// which the API has no record of (like jobs created by crashed replicas).
+// Only Elastic Transcoder and MediaConvert implement it.
+type ActiveJobLister interface {
+	ActiveJobs() ([]ActiveJob, error)
+}
+
+// ActiveJob is a job queued or running in the provider, as listed by
+// ActiveJobLister.
+type ActiveJob struct {
+	ID           string
+	CreationTime time.Time
+}
+
+// SourceDecrypter is implemented by providers that are able to decrypt
+// encrypted sources natively. Sources encrypted in modes that the provider
+// can't decrypt are decrypted by the API into a staging location before the
+// job is submitted.
+type SourceDecrypter interface {
+	DecryptsSource(mode string) bool
+}
+
+// AudioQCReporter is implemented by providers that check the audio of the
+// outputs of jobs (loudness, peaks, silence and clipping). The API checks
+// the outputs of the other providers with an ffmpeg pass.
+type AudioQCReporter interface {
+	AudioQC(*db.Job) ([]db.AudioQCReport, error)
+}
+
+// ArtifactReporter is implemented by providers that generate machine
+// readable output for jobs (like QC reports). The artifacts returned by the
+// provider are listed along with the artifacts stored in the API.
+type ArtifactReporter interface {
+	JobArtifacts(*db.Job) ([]db.Artifact, error)
+}
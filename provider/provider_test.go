@@ -169,3 +169,25 @@ func TestDescribeProviderNotFound(t *testing.T) {
 		t.Errorf("Unexpected non-nil description: %#v", description)
 	}
 }
+
+func TestDescribeProviderAccountUsage(t *testing.T) {
+	usage := AccountUsage{MinutesUsed: 990, MinutesIncluded: 1000, MinutesRemaining: 10, NearExhaustion: true}
+	providers = map[string]Factory{
+		"near-exhaustion": func(*config.Config) (TranscodingProvider, error) {
+			return &fakeUsageProvider{usage: usage}, nil
+		},
+	}
+	description, err := DescribeProvider("near-exhaustion", &config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Description{
+		Name:    "near-exhaustion",
+		Health:  Health{OK: false, Message: "account is near exhaustion"},
+		Enabled: true,
+		Usage:   &usage,
+	}
+	if !reflect.DeepEqual(*description, expected) {
+		t.Errorf("DescribeProvider: want %#v. Got %#v", expected, *description)
+	}
+}
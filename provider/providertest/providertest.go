@@ -0,0 +1,232 @@
+// +build integration
+
+// Package providertest is an end to end test harness for the transcoding
+// providers, run against their real APIs with the sandbox credentials of
+// the API (the SANDBOX_ prefixed environment variables, as in
+// SANDBOX_ZENCODER_API_KEY). Each provider creates a preset, transcodes a
+// tiny test asset with it and polls the job until it finishes, verifying
+// the translation of the preset and the mapping of the statuses.
+//
+// The tests are opt-in, behind the integration build tag, and are skipped
+// when the test asset or the sandbox credentials of the provider aren't
+// configured:
+//
+//     export INTEGRATION_SOURCE=s3://bucket/integration/tiny.mp4
+//     export INTEGRATION_DESTINATION=s3://bucket/integration/output/
+//     go test -tags integration ./provider/...
+package providertest
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	defaultTimeout      = 10 * time.Minute
+	defaultPollInterval = 10 * time.Second
+)
+
+// knownStatuses are the statuses that providers may report for jobs
+// submitted to them.
+var knownStatuses = map[provider.Status]bool{
+	provider.StatusQueued:   true,
+	provider.StatusStarted:  true,
+	provider.StatusFinished: true,
+	provider.StatusFailed:   true,
+	provider.StatusCanceled: true,
+}
+
+// Env is the environment of the integration tests.
+type Env struct {
+	// URL of the test asset, which should be a few seconds long
+	Source string
+
+	// base destination of the outputs. Defaults to the destination
+	// configured in the sandbox of each provider.
+	Destination string
+
+	// maximum time for the job to finish, and the time between queries
+	// of its status
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+// LoadEnv loads the environment of the integration tests, skipping the test
+// when the test asset isn't configured.
+func LoadEnv(t *testing.T) Env {
+	env := Env{
+		Source:       os.Getenv("INTEGRATION_SOURCE"),
+		Destination:  os.Getenv("INTEGRATION_DESTINATION"),
+		Timeout:      defaultTimeout,
+		PollInterval: defaultPollInterval,
+	}
+	if env.Source == "" {
+		t.Skip("INTEGRATION_SOURCE not set")
+	}
+	if value := os.Getenv("INTEGRATION_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			t.Fatalf("invalid INTEGRATION_TIMEOUT %q: %s", value, err)
+		}
+		env.Timeout = timeout
+	}
+	return env
+}
+
+// SandboxConfig returns the configuration of the providers in the sandbox
+// environment. Presets stored by the API are kept in memory, so the tests
+// never write to the database of the API.
+func SandboxConfig() *config.Config {
+	cfg := config.LoadConfig().SandboxConfig()
+	cfg.DatabaseDriver = "memory"
+	return cfg
+}
+
+// Preset returns the preset used in the integration tests: a small H.264
+// and AAC rendition, cheap to encode in every provider.
+func Preset() db.Preset {
+	return db.Preset{
+		Name:         "integration_" + strconv.FormatInt(time.Now().Unix(), 10),
+		Description:  "integration test preset",
+		Container:    "mp4",
+		Profile:      "main",
+		ProfileLevel: "3.1",
+		RateControl:  "VBR",
+		Video: db.VideoPreset{
+			Codec:   "h264",
+			Width:   "320",
+			Height:  "240",
+			Bitrate: "300000",
+			GopSize: "60",
+			GopMode: "fixed",
+		},
+		Audio: db.AudioPreset{Codec: "aac", Bitrate: "64000"},
+	}
+}
+
+// Run runs the integration test of the given provider, using its sandbox
+// configuration. The test is skipped when the provider isn't configured in
+// the sandbox.
+func Run(t *testing.T, providerName string) {
+	env := LoadEnv(t)
+	factory, err := provider.GetProviderFactory(providerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := factory(SandboxConfig())
+	if err != nil {
+		t.Skipf("provider %q isn't configured in the sandbox: %s", providerName, err)
+	}
+	if err = p.Healthcheck(); err != nil {
+		t.Fatalf("healthcheck failed: %s", err)
+	}
+	preset := Preset()
+	if validator, ok := p.(provider.PresetValidator); ok {
+		if _, _, err = validator.ValidatePreset(preset); err != nil {
+			t.Fatalf("error validating preset: %s", err)
+		}
+	}
+	presetID, err := p.CreatePreset(preset)
+	if err != nil {
+		t.Fatalf("error creating preset: %s", err)
+	}
+	defer func() {
+		if err := p.DeletePreset(presetID); err != nil {
+			t.Errorf("error deleting preset %q: %s", presetID, err)
+		}
+	}()
+	if exporter, ok := p.(provider.PresetExporter); ok {
+		exported, _, err := exporter.ExportPreset(presetID)
+		if err != nil {
+			t.Fatalf("error exporting preset %q: %s", presetID, err)
+		}
+		CheckTranslation(t, preset, exported)
+	}
+	status := transcode(t, p, providerName, presetID, preset, env)
+	if status.Status != provider.StatusFinished {
+		t.Fatalf("job didn't finish. Status: %q (%s)", status.Status, status.StatusMessage)
+	}
+	if len(status.Output.Files) == 0 {
+		t.Fatal("no output files reported for the finished job")
+	}
+	for _, file := range status.Output.Files {
+		if (file.Width > 0 && file.Width != 320) || (file.Height > 0 && file.Height != 240) {
+			t.Errorf("wrong resolution of output %q: %dx%d", file.Path, file.Width, file.Height)
+		}
+	}
+}
+
+// transcode submits the job to the provider, polling its status until it
+// reaches a terminal status or the timeout expires.
+func transcode(t *testing.T, p provider.TranscodingProvider, providerName, presetID string, preset db.Preset, env Env) *provider.JobStatus {
+	job := db.Job{
+		ID:           "integration-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		ProviderName: providerName,
+		Environment:  db.EnvironmentSandbox,
+		SourceMedia:  env.Source,
+		Destination:  env.Destination,
+		Outputs:      []db.TranscodeOutput{{Preset: preset.Name, FileName: "integration.mp4"}},
+	}
+	profile := provider.TranscodeProfile{
+		SourceMedia: env.Source,
+		Outputs: []provider.TranscodeOutput{{
+			FileName: "integration.mp4",
+			Preset: db.PresetMap{
+				Name:            preset.Name,
+				ProviderMapping: map[string]string{providerName: presetID},
+				OutputOpts:      db.OutputOptions{Extension: "mp4"},
+			},
+		}},
+	}
+	status, err := p.Transcode(&job, profile)
+	if err != nil {
+		t.Fatalf("error submitting job: %s", err)
+	}
+	job.ProviderJobID = status.ProviderJobID
+	timeout := time.Now().Add(env.Timeout)
+	for {
+		if !knownStatuses[status.Status] {
+			t.Errorf("unmapped status of job %q: %q (%s)", job.ProviderJobID, status.Status, status.StatusMessage)
+		}
+		switch status.Status {
+		case provider.StatusFinished, provider.StatusFailed, provider.StatusCanceled:
+			return status
+		}
+		if time.Now().After(timeout) {
+			p.CancelJob(job.ProviderJobID)
+			t.Fatalf("job %q didn't finish in %s. Last status: %q", job.ProviderJobID, env.Timeout, status.Status)
+		}
+		time.Sleep(env.PollInterval)
+		if status, err = p.JobStatus(&job); err != nil {
+			t.Fatalf("error loading the status of job %q: %s", job.ProviderJobID, err)
+		}
+	}
+}
+
+// CheckTranslation compares the given preset with the preset exported from
+// the provider, reporting the settings lost in the translation.
+func CheckTranslation(t *testing.T, preset, exported db.Preset) {
+	for _, setting := range []struct {
+		name string
+		want string
+		got  string
+	}{
+		{"container", preset.Container, exported.Container},
+		{"video codec", preset.Video.Codec, exported.Video.Codec},
+		{"video bitrate", preset.Video.Bitrate, exported.Video.Bitrate},
+		{"height", preset.Video.Height, exported.Video.Height},
+		{"audio codec", preset.Audio.Codec, exported.Audio.Codec},
+		{"audio bitrate", preset.Audio.Bitrate, exported.Audio.Bitrate},
+	} {
+		if !strings.EqualFold(setting.want, setting.got) {
+			t.Errorf("wrong %s in the exported preset. Want %q. Got %q", setting.name, setting.want, setting.got)
+		}
+	}
+}
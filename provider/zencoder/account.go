@@ -0,0 +1,52 @@
+package zencoder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// usageCacheTTL is the amount of time that the usage of an account is kept
+// in memory, avoiding a request to the Zencoder API for each new job.
+const usageCacheTTL = time.Minute
+
+type cachedUsage struct {
+	usage   *provider.AccountUsage
+	expires time.Time
+}
+
+var usageCache = struct {
+	sync.Mutex
+	entries map[string]cachedUsage
+}{entries: make(map[string]cachedUsage)}
+
+// AccountUsage returns the usage of the Zencoder account. The account is
+// considered near exhaustion when the remaining minutes are below the
+// minimum defined in the configuration.
+func (z *zencoderProvider) AccountUsage() (*provider.AccountUsage, error) {
+	apiKey := z.config.Zencoder.APIKey
+	usageCache.Lock()
+	entry, ok := usageCache.entries[apiKey]
+	usageCache.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.usage, nil
+	}
+	account, err := z.client.GetAccount()
+	if err != nil {
+		return nil, err
+	}
+	usage := provider.AccountUsage{
+		Plan:            account.Plan,
+		MinutesUsed:     int64(account.MinutesUsed),
+		MinutesIncluded: int64(account.MinutesIncluded),
+	}
+	usage.MinutesRemaining = usage.MinutesIncluded - usage.MinutesUsed
+	if minRemaining := z.config.Zencoder.MinRemainingMinutes; minRemaining > 0 {
+		usage.NearExhaustion = usage.MinutesRemaining < minRemaining
+	}
+	usageCache.Lock()
+	usageCache.entries[apiKey] = cachedUsage{usage: &usage, expires: time.Now().Add(usageCacheTTL)}
+	usageCache.Unlock()
+	return &usage, nil
+}
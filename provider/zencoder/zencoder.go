@@ -16,15 +16,20 @@
 package zencoder
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NYTimes/video-transcoding-api/config"
 	"github.com/NYTimes/video-transcoding-api/db"
-	"github.com/NYTimes/video-transcoding-api/db/redis"
+	"github.com/NYTimes/video-transcoding-api/db/repository"
 	"github.com/NYTimes/video-transcoding-api/provider"
 	"github.com/brandscreen/zencoder"
 )
@@ -33,7 +38,26 @@ import (
 // registry of providers.
 const Name = "zencoder"
 
-var errZencoderInvalidConfig = provider.InvalidConfigError("missing Zencoder API key. Please define the environment variables ZENCODER_API_KEY or set these values in the configuration file")
+const defaultDASHManifest = "dash/index.mpd"
+
+const defaultHLSPlaylist = "hls/index.m3u8"
+
+// defaultDeinterlace is the deinterlace mode of outputs without filters in
+// the job or in the preset.
+const defaultDeinterlace = "on"
+
+// bitrates accepted by Zencoder for the video and audio of outputs.
+var (
+	videoBitrates = provider.BitrateRange{Min: 100, Max: 50000}
+	audioBitrates = provider.BitrateRange{Min: 32, Max: 320}
+)
+
+var (
+	errZencoderInvalidConfig = provider.InvalidConfigError("missing Zencoder API key. Please define the environment variables ZENCODER_API_KEY or set these values in the configuration file")
+	errZencoderMinBufferTime = errors.New("zencoder doesn't support setting the minimum buffer time of DASH manifests")
+	errZencoderDRM           = errors.New("zencoder only supports aes-128 encryption in HLS jobs")
+	errZencoderBurnIn        = errors.New("zencoder doesn't support burning captions in")
+)
 
 func init() {
 	provider.Register(Name, zencoderFactory)
@@ -47,6 +71,7 @@ type Client interface {
 	CancelJob(id int64) error
 	GetJobProgress(id int64) (*zencoder.JobProgress, error)
 	GetJobDetails(id int64) (*zencoder.JobDetails, error)
+	GetAccount() (*zencoder.Account, error)
 }
 
 type zencoderProvider struct {
@@ -61,10 +86,12 @@ func (z *zencoderProvider) Transcode(job *db.Job, transcodeProfile provider.Tran
 		return nil, err
 	}
 	encodingSettings := zencoder.EncodingSettings{
-		Input:      transcodeProfile.SourceMedia,
-		Outputs:    outputs,
-		LiveStream: false,
-		Region:     "US",
+		Input:         transcodeProfile.SourceMedia,
+		Outputs:       outputs,
+		LiveStream:    false,
+		Region:        "US",
+		Notifications: z.notifications(),
+		PassThrough:   passThrough(job, transcodeProfile.BillingTags),
 	}
 	response, err := z.client.CreateJob(&encodingSettings)
 	if err != nil {
@@ -77,8 +104,52 @@ func (z *zencoderProvider) Transcode(job *db.Job, transcodeProfile provider.Tran
 	}, nil
 }
 
+// notifications returns the settings for having Zencoder notify the API
+// when jobs change, when the public URL of the API and the token of the
+// notifications are configured.
+func (z *zencoderProvider) notifications() []*zencoder.NotificationSettings {
+	cfg := z.config.ProviderCallbacks
+	if cfg == nil || cfg.BaseURL == "" || cfg.ZencoderToken == "" {
+		return nil
+	}
+	notificationURL := strings.TrimRight(cfg.BaseURL, "/") + "/callbacks/zencoder?token=" + url.QueryEscape(cfg.ZencoderToken)
+	return []*zencoder.NotificationSettings{{Format: "json", Url: notificationURL}}
+}
+
+// passThrough returns the pass-through data of the job in Zencoder, in the
+// form of a query string. Zencoder doesn't prioritize nor tag jobs, so the
+// priority and the billing tags of the job are passed through, and reported
+// back in notifications, in the details of the job and in invoices.
+func passThrough(job *db.Job, billingTags map[string]string) string {
+	values := make(url.Values, len(billingTags)+1)
+	for key, value := range billingTags {
+		values.Set(key, value)
+	}
+	if job.Priority != "" {
+		values.Set("priority", job.Priority)
+	}
+	return values.Encode()
+}
+
 func (z *zencoderProvider) buildOutputs(job *db.Job, transcodeProfile provider.TranscodeProfile) ([]*zencoder.OutputSettings, error) {
-	zencoderOutputs := make([]*zencoder.OutputSettings, 0, len(transcodeProfile.Outputs))
+	streaming := transcodeProfile.StreamingParams
+	dash := streaming.Protocol == provider.ProtocolDASH
+	fmp4 := streaming.Protocol == provider.ProtocolHLS && streaming.SegmentFormat == provider.SegmentFormatFMP4
+	if dash && streaming.MinBufferTime > 0 {
+		return nil, errZencoderMinBufferTime
+	}
+	drm := transcodeProfile.DRM
+	if drm != nil && (drm.Scheme != provider.DRMSchemeAES128 || streaming.Protocol != provider.ProtocolHLS) {
+		return nil, errZencoderDRM
+	}
+	alignment := streaming.KeyframeAlignment
+	if err := alignment.RequireSceneCutDisabled(Name); err != nil {
+		return nil, err
+	}
+	zencoderOutputs := make([]*zencoder.OutputSettings, 0, len(transcodeProfile.Outputs)+1)
+	gops := make([]provider.RenditionGOP, 0, len(transcodeProfile.Outputs))
+	var dashStreams, hlsStreams []*zencoder.StreamSettings
+	var hlsVariant *zencoder.OutputSettings
 	for _, output := range transcodeProfile.Outputs {
 		localPresetOutput, err := z.GetPreset(output.Preset.Name)
 		if err != nil {
@@ -89,11 +160,325 @@ func (z *zencoderProvider) buildOutputs(job *db.Job, transcodeProfile provider.T
 		if err != nil {
 			return nil, fmt.Errorf("Error building output: %s", err.Error())
 		}
+		if clip := transcodeProfile.Clip; clip != nil {
+			zencoderOutput.StartClip = strconv.FormatFloat(clip.Start, 'f', 3, 64)
+			if clip.Duration > 0 {
+				zencoderOutput.ClipLength = strconv.FormatFloat(clip.Duration, 'f', 3, 64)
+			}
+		}
+		if output.Preview != nil {
+			if err = applyPreview(&zencoderOutput, localPresetStruct.Preset, *output.Preview, transcodeProfile.Clip); err != nil {
+				return nil, err
+			}
+		}
+		if dash && localPresetStruct.Preset.Container == "mp4" {
+			zencoderOutput.Type = "segmented"
+			zencoderOutput.StreamingDeliveryFormat = "dash"
+			zencoderOutput.SegmentSeconds = int32(streaming.SegmentDuration)
+			dashStreams = append(dashStreams, &zencoder.StreamSettings{Path: output.FileName})
+		}
+		if fmp4 && localPresetStruct.Preset.Container == "m3u8" {
+			// segmented mp4 outputs are delivered as HLS with
+			// fragmented MP4 segments.
+			zencoderOutput.Type = "segmented"
+			zencoderOutput.Format = "mp4"
+			zencoderOutput.SegmentSeconds = int32(streaming.SegmentDuration)
+		}
+		if drm != nil && localPresetStruct.Preset.Container == "m3u8" {
+			zencoderOutput.EncryptionMethod = drm.Scheme
+			zencoderOutput.EncryptionKey = hex.EncodeToString(drm.Key)
+			zencoderOutput.EncryptionKeyUrl = drm.LicenseURL
+			if len(drm.IV) > 0 {
+				zencoderOutput.EncryptionIv = hex.EncodeToString(drm.IV)
+			}
+		}
+		zencoderOutput.Headers = zencoderHeaders(output.Headers)
 		zencoderOutputs = append(zencoderOutputs, &zencoderOutput)
+		if streaming.Protocol == provider.ProtocolHLS && localPresetStruct.Preset.Container == "m3u8" {
+			hlsStreams = append(hlsStreams, hlsStream(output.FileName, localPresetStruct.Preset, zencoderOutput))
+			if hlsVariant == nil {
+				hlsVariant = &zencoderOutput
+			}
+		}
+		if localPresetStruct.Preset.AudioOnly {
+			// audio-only renditions have no keyframes to align.
+			continue
+		}
+		if alignment != nil && alignment.Enabled {
+			// a fixed keyframe interval disables keyframes on scene
+			// changes, so all renditions get the same keyframes.
+			zencoderOutput.FixedKeyframeInterval = true
+		}
+		gops = append(gops, provider.RenditionGOP{
+			Name:  output.Preset.Name,
+			Size:  localPresetStruct.Preset.Video.GopSize,
+			Fixed: zencoderOutput.FixedKeyframeInterval,
+		})
+	}
+	if err := alignment.Validate(gops, streaming.SegmentDuration); err != nil {
+		return nil, err
+	}
+	if thumbnails := transcodeProfile.Thumbnails; thumbnails != nil && len(zencoderOutputs) > 0 {
+		settings, err := z.buildThumbnails(job, thumbnails)
+		if err != nil {
+			return nil, err
+		}
+		// thumbnails are taken from the first output.
+		zencoderOutputs[0].Thumbnails = []*zencoder.ThumbnailSettings{settings}
+	}
+	for _, caption := range transcodeProfile.Captions {
+		if caption.Mode != provider.CaptionModeSidecar {
+			return nil, errZencoderBurnIn
+		}
+		captionOutput, err := z.buildCaptionOutput(job, caption)
+		if err != nil {
+			return nil, err
+		}
+		zencoderOutputs = append(zencoderOutputs, captionOutput)
+	}
+	if len(dashStreams) > 0 {
+		manifest, err := z.buildDASHManifest(job, streaming.PlaylistFileName, dashStreams)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Headers = zencoderHeaders(transcodeProfile.PlaylistHeaders)
+		zencoderOutputs = append(zencoderOutputs, manifest)
+	}
+	if len(hlsStreams) > 0 {
+		playlistFile := streaming.PlaylistFileName
+		if playlistFile == "" {
+			playlistFile = defaultHLSPlaylist
+		}
+		if streaming.AudioOnlyRendition {
+			audioOutput, audioStream := buildAudioOnlyRendition(*hlsVariant, playlistFile, streaming.AudioOnlyBitrate)
+			zencoderOutputs = append(zencoderOutputs, audioOutput)
+			hlsStreams = append(hlsStreams, audioStream)
+		}
+		playlist, err := z.buildPlaylist(job, playlistFile, hlsStreams)
+		if err != nil {
+			return nil, err
+		}
+		playlist.Label = "hls"
+		playlist.Headers = zencoderHeaders(transcodeProfile.PlaylistHeaders)
+		zencoderOutputs = append(zencoderOutputs, playlist)
 	}
 	return zencoderOutputs, nil
 }
 
+// hlsStream returns the entry of the given HLS rendition in the master
+// playlist, with its bandwidth, resolution and codecs.
+func hlsStream(fileName string, preset db.Preset, output zencoder.OutputSettings) *zencoder.StreamSettings {
+	stream := zencoder.StreamSettings{
+		Path:      fileName,
+		Bandwidth: output.VideoBitrate + output.AudioBitrate,
+		Codecs:    hlsCodecs(preset),
+	}
+	if output.Width > 0 && output.Height > 0 {
+		stream.Resolution = fmt.Sprintf("%dx%d", output.Width, output.Height)
+	}
+	return &stream
+}
+
+// buildAudioOnlyRendition returns the output of the audio-only variant of
+// an HLS job, and its entry in the master playlist. The segments of the
+// variant match the ones of the given video variant, and its playlist is
+// written next to the master playlist.
+func buildAudioOnlyRendition(variant zencoder.OutputSettings, playlistFile string, bitrate uint) (*zencoder.OutputSettings, *zencoder.StreamSettings) {
+	if bitrate == 0 {
+		bitrate = provider.DefaultAudioOnlyBitrate
+	}
+	fileName := path.Join(path.Dir(playlistFile), "audio_only.m3u8")
+	output := zencoder.OutputSettings{
+		Label:            "hls:audio-only",
+		Format:           variant.Format,
+		Type:             variant.Type,
+		SegmentSeconds:   variant.SegmentSeconds,
+		BaseUrl:          variant.BaseUrl,
+		Filename:         fileName,
+		AudioCodec:       variant.AudioCodec,
+		AudioBitrate:     int32(bitrate / 1000),
+		SkipVideo:        true,
+		StartClip:        variant.StartClip,
+		ClipLength:       variant.ClipLength,
+		EncryptionMethod: variant.EncryptionMethod,
+		EncryptionKey:    variant.EncryptionKey,
+		EncryptionKeyUrl: variant.EncryptionKeyUrl,
+		EncryptionIv:     variant.EncryptionIv,
+	}
+	stream := zencoder.StreamSettings{
+		Path:      fileName,
+		Bandwidth: output.AudioBitrate,
+		Codecs:    audioCodecs[variant.AudioCodec],
+	}
+	return &output, &stream
+}
+
+// audioCodecs are the values of the CODECS attribute of HLS playlists for
+// the audio codecs of presets.
+var audioCodecs = map[string]string{
+	"aac":  "mp4a.40.2",
+	"mp3":  "mp4a.40.34",
+	"ac3":  "ac-3",
+	"eac3": "ec-3",
+}
+
+// h264Profiles are the profile_idc and constraint flags of the H.264
+// profiles, as used in the CODECS attribute of HLS playlists.
+var h264Profiles = map[string]string{
+	"baseline": "42e0",
+	"main":     "4d40",
+	"high":     "6400",
+}
+
+// hlsCodecs returns the value of the CODECS attribute of the given preset
+// in the master playlist, or an empty string when one of the codecs can't
+// be described (so players probe the rendition instead of trusting a wrong
+// value). The profile of H.264 renditions defaults to main, and the level
+// is estimated from the height when not set in the preset.
+func hlsCodecs(preset db.Preset) string {
+	audio, ok := audioCodecs[preset.Audio.Codec]
+	if !ok {
+		return ""
+	}
+	if preset.AudioOnly {
+		return audio
+	}
+	if preset.Video.Codec != "h264" {
+		return ""
+	}
+	profile := strings.ToLower(preset.Profile)
+	if profile == "" {
+		profile = "main"
+	}
+	profileIDC, ok := h264Profiles[profile]
+	if !ok {
+		return ""
+	}
+	level := preset.ProfileLevel
+	if level == "" {
+		level = "3.0"
+		if height, _ := strconv.Atoi(preset.Video.Height); height == 0 || height > 720 {
+			level = "4.0"
+		} else if height > 480 {
+			level = "3.1"
+		}
+	}
+	levelIDC, err := strconv.ParseFloat(level, 64)
+	if err != nil || levelIDC <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("avc1.%s%02x,%s", profileIDC, int(levelIDC*10+0.5), audio)
+}
+
+// buildThumbnails returns the settings of the thumbnails of the job, written
+// to the thumbnails directory in the destination of the job.
+func (z *zencoderProvider) buildThumbnails(job *db.Job, thumbnails *provider.Thumbnails) (*zencoder.ThumbnailSettings, error) {
+	destination := z.destination(job)
+	destinationURL, err := url.Parse(destination)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing destination (%q)", destination)
+	}
+	destinationURL.Path = path.Join(destinationURL.Path, job.ID, "thumbnails") + "/"
+	return &zencoder.ThumbnailSettings{
+		Label:    "thumbnails",
+		Format:   thumbnails.Format,
+		Number:   int32(thumbnails.Count),
+		Interval: int32(thumbnails.Interval),
+		Width:    int32(thumbnails.Width),
+		Height:   int32(thumbnails.Height),
+		BaseUrl:  destinationURL.String(),
+		Prefix:   "thumb",
+	}, nil
+}
+
+// zencoderCaptionFormats maps caption formats to the formats of Zencoder
+// caption outputs.
+var zencoderCaptionFormats = map[string]string{
+	provider.CaptionFormatSRT:    "srt",
+	provider.CaptionFormatWebVTT: "webvtt",
+	provider.CaptionFormatTTML:   "dfxp",
+}
+
+// buildCaptionOutput returns the output that delivers the given sidecar
+// caption to the destination of the job.
+func (z *zencoderProvider) buildCaptionOutput(job *db.Job, caption provider.Caption) (*zencoder.OutputSettings, error) {
+	destination := z.destination(job)
+	destinationURL, err := url.Parse(destination)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing destination (%q)", destination)
+	}
+	destinationURL.Path = path.Join(destinationURL.Path, job.ID) + "/"
+	return &zencoder.OutputSettings{
+		Label:      "captions:" + caption.Language,
+		Type:       "captions",
+		Format:     zencoderCaptionFormats[caption.Format],
+		CaptionUrl: caption.Source,
+		BaseUrl:    destinationURL.String(),
+		Filename:   caption.FileName,
+	}, nil
+}
+
+// zencoderHeaders returns the headers Zencoder sets on the files of an
+// output (segments included) when uploading them. Metadata is sent as
+// x-amz-meta-* headers.
+func zencoderHeaders(headers *provider.OutputHeaders) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	result := make(map[string]string, len(headers.Metadata)+2)
+	if headers.CacheControl != "" {
+		result["Cache-Control"] = headers.CacheControl
+	}
+	if headers.ContentDisposition != "" {
+		result["Content-Disposition"] = headers.ContentDisposition
+	}
+	for key, value := range headers.Metadata {
+		result["x-amz-meta-"+key] = value
+	}
+	return result
+}
+
+// buildDASHManifest returns the output that generates the MPD manifest
+// referencing the given DASH renditions.
+func (z *zencoderProvider) buildDASHManifest(job *db.Job, manifestFile string, streams []*zencoder.StreamSettings) (*zencoder.OutputSettings, error) {
+	if manifestFile == "" {
+		manifestFile = defaultDASHManifest
+	}
+	manifest, err := z.buildPlaylist(job, manifestFile, streams)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Label = "dash"
+	manifest.StreamingDeliveryFormat = "dash"
+	return manifest, nil
+}
+
+// buildPlaylist returns the output that generates the master playlist (or
+// manifest) referencing the given renditions. Paths of renditions are made
+// relative to the playlist.
+func (z *zencoderProvider) buildPlaylist(job *db.Job, manifestFile string, streams []*zencoder.StreamSettings) (*zencoder.OutputSettings, error) {
+	destination := z.destination(job)
+	destinationURL, err := url.Parse(destination)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing destination (%q)", destination)
+	}
+	destinationURL.Path = path.Join(destinationURL.Path, job.ID) + "/"
+	manifestDir := path.Dir(manifestFile)
+	for _, stream := range streams {
+		relPath, err := filepath.Rel(manifestDir, stream.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error building the path of the rendition %q: %s", stream.Path, err)
+		}
+		stream.Path = filepath.ToSlash(relPath)
+	}
+	return &zencoder.OutputSettings{
+		Type:     "playlist",
+		BaseUrl:  destinationURL.String(),
+		Filename: manifestFile,
+		Streams:  streams,
+	}, nil
+}
+
 func (z *zencoderProvider) getResolution(preset db.Preset) (int32, int32) {
 	var width, height int64
 	width, err := strconv.ParseInt(preset.Video.Width, 10, 32)
@@ -107,20 +492,58 @@ func (z *zencoderProvider) getResolution(preset db.Preset) (int32, int32) {
 	return int32(width), int32(height)
 }
 
+// webmVideoCodecs are the video codecs supported in WebM outputs.
+var webmVideoCodecs = map[string]bool{"vp8": true, "vp9": true}
+
+// zencoderAudioCodec returns the name of the given audio codec in Zencoder,
+// accepting the names of the libraries of the codecs (like libopus).
+func zencoderAudioCodec(codec string) string {
+	return strings.TrimPrefix(strings.ToLower(codec), "lib")
+}
+
 func (z *zencoderProvider) buildOutput(job *db.Job, preset db.Preset, outputFileName string) (zencoder.OutputSettings, error) {
 	zencoderOutput := zencoder.OutputSettings{
 		Label:      preset.Name + ":" + preset.Description,
 		Format:     preset.Container,
 		VideoCodec: preset.Video.Codec,
-		AudioCodec: preset.Audio.Codec,
+		AudioCodec: zencoderAudioCodec(preset.Audio.Codec),
 		Filename:   outputFileName,
 	}
-	destinationURL, err := url.Parse(z.config.Zencoder.Destination)
+	destination := z.destination(job)
+	destinationURL, err := url.Parse(destination)
 	if err != nil {
-		return zencoder.OutputSettings{}, fmt.Errorf("error parsing destination (%q)", z.config.Zencoder.Destination)
+		return zencoder.OutputSettings{}, fmt.Errorf("error parsing destination (%q)", destination)
 	}
 	destinationURL.Path = path.Join(destinationURL.Path, job.ID) + "/"
 	zencoderOutput.BaseUrl = destinationURL.String()
+	audioBitrate, err := strconv.ParseInt(preset.Audio.Bitrate, 10, 32)
+	if err != nil {
+		return zencoder.OutputSettings{}, fmt.Errorf("error converting preset audio bitrate (%q): %s", preset.Audio.Bitrate, err)
+	}
+	zencoderOutput.AudioBitrate = int32(audioBitrate) / 1000
+	if preset.Audio.Loudness != "" {
+		// Zencoder normalizes the peak level of the audio rather than its
+		// loudness, so the normalized audio is attenuated to the true peak
+		// of the preset. The loudness of the outputs can be checked with
+		// the audio QC of the job.
+		_, truePeak, err := preset.Audio.Normalization()
+		if err != nil {
+			return zencoder.OutputSettings{}, err
+		}
+		zencoderOutput.AudioNormalize = true
+		zencoderOutput.AudioPostAmplify = truePeak
+	}
+	if preset.Audio.ChannelLayout != "" {
+		zencoderOutput.AudioChannels = int32(preset.Audio.Channels())
+	}
+	if preset.AudioOnly {
+		zencoderOutput.SkipVideo = true
+		return zencoderOutput, nil
+	}
+
+	if preset.Container == "webm" && !webmVideoCodecs[preset.Video.Codec] {
+		return zencoder.OutputSettings{}, fmt.Errorf("unsupported video codec %q in webm outputs", preset.Video.Codec)
+	}
 	zencoderOutput.Width, zencoderOutput.Height = z.getResolution(preset)
 	videoBitrate, err := strconv.ParseInt(preset.Video.Bitrate, 10, 32)
 	if err != nil {
@@ -134,12 +557,6 @@ func (z *zencoderProvider) buildOutput(job *db.Job, preset db.Preset, outputFile
 	}
 	zencoderOutput.KeyframeInterval = int32(keyframeInterval)
 
-	audioBitrate, err := strconv.ParseInt(preset.Audio.Bitrate, 10, 32)
-	if err != nil {
-		return zencoder.OutputSettings{}, fmt.Errorf("error converting preset audio bitrate (%q): %s", preset.Audio.Bitrate, err)
-	}
-	zencoderOutput.AudioBitrate = int32(audioBitrate) / 1000
-
 	if preset.Video.GopMode == "fixed" {
 		zencoderOutput.FixedKeyframeInterval = true
 	}
@@ -150,10 +567,103 @@ func (z *zencoderProvider) buildOutput(job *db.Job, preset db.Preset, outputFile
 	if preset.RateControl == "CBR" {
 		zencoderOutput.ConstantBitrate = true
 	}
-	zencoderOutput.Deinterlace = "on"
+	// Zencoder encodes outputs with a target bitrate in two passes by
+	// default, so only single pass encoding needs to be forced.
+	if preset.Video.Passes == "1" {
+		zencoderOutput.OnePass = true
+	}
+	if preset.Watermark != nil {
+		watermark, err := buildWatermark(*preset.Watermark)
+		if err != nil {
+			return zencoder.OutputSettings{}, err
+		}
+		zencoderOutput.Watermarks = []*zencoder.WatermarkSettings{watermark}
+	}
+	filters := db.ResolveVideoFilters(job.Filters, preset.Filters)
+	if filters.Deinterlace == "" {
+		filters.Deinterlace = defaultDeinterlace
+	}
+	zencoderOutput.Deinterlace = filters.Deinterlace
+	zencoderOutput.Denoise = filters.Denoise
+	zencoderOutput.Sharpen = filters.Sharpen
+	if !job.Rotation.Auto() {
+		// Zencoder rotates outputs based on the metadata of the source
+		// unless the rotation is set, and a rotation of 0 is omitted.
+		if job.Rotation.Degrees == 0 {
+			return zencoder.OutputSettings{}, errors.New("zencoder can't disable autorotation without explicit degrees")
+		}
+		zencoderOutput.Rotate = int32(job.Rotation.Degrees)
+	}
 	return zencoderOutput, nil
 }
 
+// applyPreview turns the given output into a preview clip. The start of the
+// preview is relative to the clip of partial transcodes, and the preview
+// never goes past the end of the clip.
+func applyPreview(output *zencoder.OutputSettings, preset db.Preset, preview db.Preview, clip *provider.Clip) error {
+	start, duration := preview.Start, preview.Duration
+	if clip != nil {
+		start += clip.Start
+		if clip.Duration > 0 && preview.Start+duration > clip.Duration {
+			duration = clip.Duration - preview.Start
+		}
+	}
+	if duration <= 0 {
+		return fmt.Errorf("the preview of %q starts after the end of the clip", output.Filename)
+	}
+	output.StartClip = strconv.FormatFloat(start, 'f', 3, 64)
+	output.ClipLength = strconv.FormatFloat(duration, 'f', 3, 64)
+	if preset.AudioOnly {
+		return nil
+	}
+	maxHeight := int32(preview.MaxHeight)
+	if maxHeight > 0 && (output.Height == 0 || output.Height > maxHeight) {
+		// Zencoder keeps the aspect ratio of the source when only the
+		// height is set, and doesn't upscale smaller sources.
+		if output.Width > 0 && output.Height > 0 {
+			output.Width = (output.Width * maxHeight / output.Height) &^ 1
+		} else {
+			output.Width = 0
+		}
+		output.Height = maxHeight
+	}
+	if preview.Watermark != nil {
+		watermark, err := buildWatermark(*preview.Watermark)
+		if err != nil {
+			return err
+		}
+		output.Watermarks = []*zencoder.WatermarkSettings{watermark}
+	}
+	return nil
+}
+
+// buildWatermark returns the settings of the given watermark. Zencoder
+// places watermarks relative to the top-left corner of the frame, or to the
+// bottom-right corner with negative offsets.
+func buildWatermark(watermark db.Watermark) (*zencoder.WatermarkSettings, error) {
+	if watermark.Opacity != "" && watermark.Opacity != "100" {
+		return nil, errors.New("zencoder doesn't support translucent watermarks")
+	}
+	offset := watermark.Offset
+	if offset == "" {
+		offset = "0"
+	}
+	settings := zencoder.WatermarkSettings{
+		Url:    watermark.URL,
+		X:      "-" + offset,
+		Y:      "-" + offset,
+		Width:  watermark.Width,
+		Height: watermark.Height,
+	}
+	if strings.HasPrefix(watermark.Position, "top-") {
+		settings.Y = offset
+	}
+	if strings.HasSuffix(watermark.Position, "-left") {
+		settings.X = offset
+	}
+	return &settings, nil
+}
+
 func (z *zencoderProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 	jobID, err := strconv.ParseInt(job.ProviderJobID, 10, 64)
 	if err != nil {
@@ -163,7 +673,7 @@ func (z *zencoderProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error getting job details: %s", err)
 	}
-	jobOutputs, err := z.getJobOutputs(job, jobDetails.Job.OutputMediaFiles)
+	jobOutputs, err := z.getJobOutputs(job, jobDetails.Job.OutputMediaFiles, jobDetails.Job.Thumbnails)
 	if err != nil {
 		return nil, fmt.Errorf("error getting job outputs: %s", err)
 	}
@@ -194,6 +704,37 @@ func (z *zencoderProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 	}, nil
 }
 
+// JobArtifacts returns the media info of the input and of the outputs of
+// the job, as reported by Zencoder.
+func (z *zencoderProvider) JobArtifacts(job *db.Job) ([]db.Artifact, error) {
+	jobID, err := strconv.ParseInt(job.ProviderJobID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error converting job ID (%q): %s", job.ID, err)
+	}
+	jobDetails, err := z.client.GetJobDetails(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting job details: %s", err)
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"input":   jobDetails.Job.InputMediaFile,
+		"outputs": jobDetails.Job.OutputMediaFiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the artifact is updated along with the job in Zencoder.
+	updated, _ := time.Parse(time.RFC3339, jobDetails.Job.UpdatedAt)
+	return []db.Artifact{{
+		JobID:        job.ID,
+		Name:         "zencoder-media-info",
+		Kind:         "media-info",
+		Source:       Name,
+		ContentType:  "application/json",
+		Data:         data,
+		CreationTime: updated,
+	}}, nil
+}
+
 func (z *zencoderProvider) statusMap(zencoderStatus string) provider.Status {
 	switch zencoderStatus {
 	case "waiting":
@@ -213,7 +754,7 @@ func (z *zencoderProvider) statusMap(zencoderStatus string) provider.Status {
 	}
 }
 
-func (z *zencoderProvider) getJobOutputs(job *db.Job, outputMediaFiles []*zencoder.MediaFile) (provider.JobOutput, error) {
+func (z *zencoderProvider) getJobOutputs(job *db.Job, outputMediaFiles []*zencoder.MediaFile, thumbnails []*zencoder.Thumbnail) (provider.JobOutput, error) {
 	files := make([]provider.OutputFile, 0, len(outputMediaFiles))
 	for _, mediaFile := range outputMediaFiles {
 		file := provider.OutputFile{
@@ -225,18 +766,59 @@ func (z *zencoderProvider) getJobOutputs(job *db.Job, outputMediaFiles []*zencod
 		}
 		files = append(files, file)
 	}
-	destinationURL, err := url.Parse(z.config.Zencoder.Destination)
+	for _, thumbnail := range thumbnails {
+		files = append(files, provider.OutputFile{
+			Path:      thumbnail.Url,
+			Container: thumbnail.Format,
+			Width:     int64(thumbnail.Width),
+			Height:    int64(thumbnail.Height),
+		})
+	}
+	destination := z.destination(job)
+	destinationURL, err := url.Parse(destination)
 	if err != nil {
-		return provider.JobOutput{}, fmt.Errorf("error parsing destination (%q)", z.config.Zencoder.Destination)
+		return provider.JobOutput{}, fmt.Errorf("error parsing destination (%q)", destination)
 	}
 
 	destinationURL.Path = path.Join(destinationURL.Path, job.ID) + "/"
+	files = appendCaptionFiles(files, destinationURL.String(), job.Captions)
 	return provider.JobOutput{
 		Files:       files,
 		Destination: destinationURL.String(),
 	}, nil
 }
 
+// appendCaptionFiles adds the sidecar captions of the job to the output
+// files, unless Zencoder already reports them.
+func appendCaptionFiles(files []provider.OutputFile, destination string, captions []db.Caption) []provider.OutputFile {
+	for _, caption := range captions {
+		if caption.Mode != provider.CaptionModeSidecar {
+			continue
+		}
+		captionPath := destination + caption.FileName
+		reported := false
+		for _, file := range files {
+			if file.Path == captionPath {
+				reported = true
+				break
+			}
+		}
+		if !reported {
+			files = append(files, provider.OutputFile{Path: captionPath, Container: caption.Format})
+		}
+	}
+	return files
+}
+
+// destination returns the base destination of the given job, falling back to
+// the destination in the configuration.
+func (z *zencoderProvider) destination(job *db.Job) string {
+	if job.Destination != "" {
+		return job.Destination
+	}
+	return z.config.Zencoder.Destination
+}
+
 func (z *zencoderProvider) CancelJob(id string) error {
 	jobID, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
@@ -265,6 +847,38 @@ func (z *zencoderProvider) GetPreset(presetID string) (interface{}, error) {
 	return z.db.GetLocalPreset(presetID)
 }
 
+// ExportPreset returns the local preset used by the provider. Zencoder
+// presets are stored in the generic format, so there are no unsupported
+// settings.
+func (z *zencoderProvider) ExportPreset(presetID string) (db.Preset, []string, error) {
+	localPreset, err := z.db.GetLocalPreset(presetID)
+	if err != nil {
+		return db.Preset{}, nil, err
+	}
+	return localPreset.Preset, nil, nil
+}
+
+// ValidatePreset translates the preset into the settings of a Zencoder
+// output, leaving out the settings that depend on the job (like the file
+// name and the destination).
+func (z *zencoderProvider) ValidatePreset(preset db.Preset) (interface{}, []string, error) {
+	output, err := z.buildOutput(&db.Job{}, preset, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	output.BaseUrl = ""
+	var warnings []string
+	if warning := audioBitrates.Warning("audio", int64(output.AudioBitrate)); warning != "" {
+		warnings = append(warnings, warning)
+	}
+	if !preset.AudioOnly {
+		if warning := videoBitrates.Warning("video", int64(output.VideoBitrate)); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	return output, warnings, nil
+}
+
 func (z *zencoderProvider) DeletePreset(presetID string) error {
 	preset, err := z.GetPreset(presetID)
 	if err != nil {
@@ -275,9 +889,28 @@ func (z *zencoderProvider) DeletePreset(presetID string) error {
 
 func (z *zencoderProvider) Capabilities() provider.Capabilities {
 	return provider.Capabilities{
-		InputFormats:  []string{"prores", "h264"},
-		OutputFormats: []string{"mp4", "hls", "webm"},
-		Destinations:  []string{"akamai", "s3"},
+		InputFormats:       []string{"prores", "h264"},
+		OutputFormats:      []string{"mp4", "hls", "dash", "webm", "mp3", "m4a", "ogg"},
+		Destinations:       []string{"akamai", "s3"},
+		VideoCodecs:        []string{"h264", "hevc", "vp8", "vp9"},
+		AudioCodecs:        []string{"aac", "ac3", "eac3", "mp3", "vorbis", "opus"},
+		StreamingProtocols: []string{"hls", "dash"},
+		SegmentFormats:     []string{"fmp4"},
+		DRMSchemes:         []string{"aes-128"},
+		CaptionModes:       []string{"sidecar"},
+		CaptionFormats:     []string{"srt", "webvtt", "ttml"},
+		MaxAudioChannels:   6,
+		Thumbnails:         true,
+		Clipping:           true,
+		Trim:               true,
+		OutputHeaders:      true,
+		AudioOnly:          true,
+		Watermark:          true,
+		Previews:           true,
+		AudioOnlyRendition: true,
+		Loudness:           true,
+		Rotation:           true,
+		Progress:           true,
 	}
 }
 
@@ -286,7 +919,7 @@ func zencoderFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
 		return nil, errZencoderInvalidConfig
 	}
 	client := zencoder.NewZencoder(cfg.Zencoder.APIKey)
-	dbRepo, err := redis.NewRepository(cfg)
+	dbRepo, err := repository.New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("Error initializing zencoder wrapper: %s", err)
 	}
@@ -17,6 +17,15 @@ func (z *FakeZencoder) ListJobs() ([]*zencoderClient.JobDetails, error) {
 	return []*zencoderClient.JobDetails{}, nil
 }
 
+func (z *FakeZencoder) GetAccount() (*zencoderClient.Account, error) {
+	return &zencoderClient.Account{
+		AccountState:    "active",
+		Plan:            "Growth",
+		MinutesUsed:     9000,
+		MinutesIncluded: 10000,
+	}, nil
+}
+
 func (z *FakeZencoder) CancelJob(id int64) error {
 	return nil
 }
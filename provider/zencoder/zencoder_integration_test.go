@@ -0,0 +1,13 @@
+// +build integration
+
+package zencoder
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/provider/providertest"
+)
+
+func TestZencoderIntegration(t *testing.T) {
+	providertest.Run(t, Name)
+}
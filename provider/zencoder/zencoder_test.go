@@ -6,15 +6,14 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/NYTimes/video-transcoding-api/config"
 	"github.com/NYTimes/video-transcoding-api/db"
-	"github.com/NYTimes/video-transcoding-api/db/redis"
-	"github.com/NYTimes/video-transcoding-api/db/redis/storage"
+	"github.com/NYTimes/video-transcoding-api/db/memory"
 	"github.com/NYTimes/video-transcoding-api/provider"
 	"github.com/brandscreen/zencoder"
 	"github.com/kr/pretty"
-	redisDriver "gopkg.in/redis.v4"
 )
 
 func TestFactoryIsRegistered(t *testing.T) {
@@ -70,9 +69,28 @@ func TestZencoderFactoryValidation(t *testing.T) {
 func TestZencoderCapabilities(t *testing.T) {
 	var prov zencoderProvider
 	expected := provider.Capabilities{
-		InputFormats:  []string{"prores", "h264"},
-		OutputFormats: []string{"mp4", "hls", "webm"},
-		Destinations:  []string{"akamai", "s3"},
+		InputFormats:       []string{"prores", "h264"},
+		OutputFormats:      []string{"mp4", "hls", "dash", "webm", "mp3", "m4a", "ogg"},
+		Destinations:       []string{"akamai", "s3"},
+		VideoCodecs:        []string{"h264", "hevc", "vp8", "vp9"},
+		AudioCodecs:        []string{"aac", "ac3", "eac3", "mp3", "vorbis", "opus"},
+		StreamingProtocols: []string{"hls", "dash"},
+		SegmentFormats:     []string{"fmp4"},
+		DRMSchemes:         []string{"aes-128"},
+		CaptionModes:       []string{"sidecar"},
+		CaptionFormats:     []string{"srt", "webvtt", "ttml"},
+		MaxAudioChannels:   6,
+		Thumbnails:         true,
+		Clipping:           true,
+		Trim:               true,
+		OutputHeaders:      true,
+		AudioOnly:          true,
+		Watermark:          true,
+		Previews:           true,
+		AudioOnlyRendition: true,
+		Loudness:           true,
+		Rotation:           true,
+		Progress:           true,
 	}
 	cap := prov.Capabilities()
 	if !reflect.DeepEqual(cap, expected) {
@@ -81,10 +99,10 @@ func TestZencoderCapabilities(t *testing.T) {
 }
 
 func TestZencoderCreatePreset(t *testing.T) {
-	cleanLocalPresets()
+	memory.Reset()
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
 	preset := db.Preset{
 		Audio: db.AudioPreset{
@@ -106,7 +124,7 @@ func TestZencoderCreatePreset(t *testing.T) {
 		},
 	}
 	provider, err := zencoderFactory(&cfg)
-	repo, err := redis.NewRepository(&cfg)
+	repo, err := memory.NewRepository(&cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -129,10 +147,10 @@ func TestZencoderCreatePreset(t *testing.T) {
 }
 
 func TestCreatePresetError(t *testing.T) {
-	cleanLocalPresets()
+	memory.Reset()
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
 	preset := db.Preset{}
 	provider, err := zencoderFactory(&cfg)
@@ -143,11 +161,83 @@ func TestCreatePresetError(t *testing.T) {
 	}
 }
 
+func TestZencoderValidatePreset(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/destination-dir/"},
+		DatabaseDriver: "memory",
+	}
+	prov, err := zencoderFactory(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preset := db.Preset{
+		Name:        "mp4_4k",
+		Description: "4k",
+		Container:   "mp4",
+		Profile:     "high",
+		RateControl: "CBR",
+		Video: db.VideoPreset{
+			Bitrate: "60000000",
+			Codec:   "h264",
+			GopSize: "90",
+			Height:  "2160",
+		},
+		Audio: db.AudioPreset{
+			Bitrate: "128000",
+			Codec:   "aac",
+		},
+	}
+	output, warnings, err := prov.(*zencoderProvider).ValidatePreset(preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := zencoder.OutputSettings{
+		Label:            "mp4_4k:4k",
+		Format:           "mp4",
+		VideoCodec:       "h264",
+		AudioCodec:       "aac",
+		AudioBitrate:     128,
+		VideoBitrate:     60000,
+		Height:           2160,
+		KeyframeInterval: 90,
+		H264Profile:      "high",
+		ConstantBitrate:  true,
+		Deinterlace:      "on",
+	}
+	if !reflect.DeepEqual(output, expected) {
+		pretty.Fdiff(os.Stderr, output, expected)
+		t.Errorf("wrong output settings. Want %#v. Got %#v", expected, output)
+	}
+	expectedWarnings := []string{"video bitrate of 60000 kbps is out of the supported range (100-50000 kbps)"}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Errorf("wrong warnings. Want %#v. Got %#v", expectedWarnings, warnings)
+	}
+	if _, err = prov.GetPreset("mp4_4k"); err == nil {
+		t.Error("the preset was created when validating it")
+	}
+}
+
+func TestZencoderValidatePresetWebMCodec(t *testing.T) {
+	prov := &zencoderProvider{}
+	preset := db.Preset{
+		Name:      "webm_720p",
+		Container: "webm",
+		Video:     db.VideoPreset{Bitrate: "1800000", Codec: "h264", GopSize: "90"},
+		Audio:     db.AudioPreset{Bitrate: "96000", Codec: "opus"},
+	}
+	_, _, err := prov.ValidatePreset(preset)
+	want := `unsupported video codec "h264" in webm outputs`
+	if err == nil || err.Error() != want {
+		t.Errorf("wrong error. Want %q. Got %v", want, err)
+	}
+}
+
 func TestGetPreset(t *testing.T) {
-	cleanLocalPresets()
+	memory.Reset()
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
 	preset := db.Preset{
 		Name: "get_preset",
@@ -186,10 +276,10 @@ func TestGetPreset(t *testing.T) {
 }
 
 func TestZencoderDeletePreset(t *testing.T) {
-	cleanLocalPresets()
+	memory.Reset()
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
 	preset := db.Preset{
 		Name: "get_preset",
@@ -224,13 +314,13 @@ func TestZencoderDeletePreset(t *testing.T) {
 }
 
 func TestZencoderTranscode(t *testing.T) {
-	cleanLocalPresets()
+	memory.Reset()
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
 	fakeZencoder := &FakeZencoder{}
-	dbRepo, err := redis.NewRepository(&cfg)
+	dbRepo, err := memory.NewRepository(&cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -290,6 +380,60 @@ func TestZencoderTranscode(t *testing.T) {
 	}
 }
 
+func TestZencoderPassThrough(t *testing.T) {
+	var tests = []struct {
+		priority    string
+		billingTags map[string]string
+		expected    string
+	}{
+		{"", nil, ""},
+		{"low", nil, "priority=low"},
+		{"high", map[string]string{"tenant": "newsroom", "cost center": "video&audio"}, "cost+center=video%26audio&priority=high&tenant=newsroom"},
+		{"", map[string]string{"tenant": "newsroom"}, "tenant=newsroom"},
+	}
+	for _, test := range tests {
+		got := passThrough(&db.Job{ID: "job-123", Priority: test.priority}, test.billingTags)
+		if got != test.expected {
+			t.Errorf("passThrough(%q, %v): want %q. Got %q", test.priority, test.billingTags, test.expected, got)
+		}
+	}
+}
+
+func TestZencoderNotifications(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		cfg      *config.ProviderCallbacks
+		wantURL  string
+	}{
+		{"no provider callbacks", nil, ""},
+		{"no base URL", &config.ProviderCallbacks{ZencoderToken: "secret"}, ""},
+		{"no token", &config.ProviderCallbacks{BaseURL: "https://transcoding.example.com/"}, ""},
+		{
+			"base URL with trailing slash",
+			&config.ProviderCallbacks{BaseURL: "https://transcoding.example.com/", ZencoderToken: "secret"},
+			"https://transcoding.example.com/callbacks/zencoder?token=secret",
+		},
+		{
+			"base URL and token",
+			&config.ProviderCallbacks{BaseURL: "https://transcoding.example.com", ZencoderToken: "s3cr3t&"},
+			"https://transcoding.example.com/callbacks/zencoder?token=s3cr3t%26",
+		},
+	}
+	for _, test := range tests {
+		prov := zencoderProvider{config: &config.Config{ProviderCallbacks: test.cfg}}
+		notifications := prov.notifications()
+		if test.wantURL == "" {
+			if len(notifications) > 0 {
+				t.Errorf("%s: unexpected notifications: %#v", test.testCase, notifications)
+			}
+			continue
+		}
+		if len(notifications) != 1 || notifications[0].Url != test.wantURL || notifications[0].Format != "json" {
+			t.Errorf("%s: wrong notifications. Want URL %q. Got %#v", test.testCase, test.wantURL, notifications)
+		}
+	}
+}
+
 func TestZencoderBuildOutput(t *testing.T) {
 	prov := &zencoderProvider{}
 	var tests = []struct {
@@ -412,6 +556,152 @@ func TestZencoderBuildOutput(t *testing.T) {
 				"filename":          "test.webm",
 			},
 		},
+		{
+			"Test with vp9 and opus webm preset",
+			"test.webm",
+			"http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			db.Preset{
+				Name:        "webm_720p",
+				Description: "my vp9 preset",
+				Container:   "webm",
+				Video: db.VideoPreset{
+					Bitrate: "1800000",
+					Codec:   "vp9",
+					GopSize: "120",
+					Height:  "720",
+					Width:   "1280",
+					Passes:  "1",
+				},
+				Audio: db.AudioPreset{
+					Bitrate: "96000",
+					Codec:   "libopus",
+				},
+			},
+			map[string]interface{}{
+				"label":             "webm_720p:my vp9 preset",
+				"format":            "webm",
+				"video_codec":       "vp9",
+				"audio_codec":       "opus",
+				"width":             float64(1280),
+				"height":            float64(720),
+				"video_bitrate":     float64(1800),
+				"audio_bitrate":     float64(96),
+				"keyframe_interval": float64(120),
+				"one_pass":          true,
+				"deinterlace":       "on",
+				"base_url":          "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/abcdef/",
+				"filename":          "test.webm",
+			},
+		},
+		{
+			"Test with two-pass vp9 and vorbis webm preset",
+			"test.webm",
+			"http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			db.Preset{
+				Name:        "webm_720p",
+				Description: "my vp9 preset",
+				Container:   "webm",
+				Video: db.VideoPreset{
+					Bitrate: "1800000",
+					Codec:   "vp9",
+					GopSize: "120",
+					Height:  "720",
+					Width:   "1280",
+					Passes:  "2",
+				},
+				Audio: db.AudioPreset{
+					Bitrate: "128000",
+					Codec:   "vorbis",
+				},
+			},
+			map[string]interface{}{
+				"label":             "webm_720p:my vp9 preset",
+				"format":            "webm",
+				"video_codec":       "vp9",
+				"audio_codec":       "vorbis",
+				"width":             float64(1280),
+				"height":            float64(720),
+				"video_bitrate":     float64(1800),
+				"audio_bitrate":     float64(128),
+				"keyframe_interval": float64(120),
+				"deinterlace":       "on",
+				"base_url":          "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/abcdef/",
+				"filename":          "test.webm",
+			},
+		},
+		{
+			"Test with audio-only preset",
+			"test.mp3",
+			"http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			db.Preset{
+				Name:        "mp3_128k",
+				Description: "my podcast preset",
+				Container:   "mp3",
+				AudioOnly:   true,
+				Audio: db.AudioPreset{
+					Bitrate: "128000",
+					Codec:   "mp3",
+				},
+			},
+			map[string]interface{}{
+				"label":         "mp3_128k:my podcast preset",
+				"format":        "mp3",
+				"audio_codec":   "mp3",
+				"audio_bitrate": float64(128),
+				"skip_video":    true,
+				"base_url":      "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/abcdef/",
+				"filename":      "test.mp3",
+			},
+		},
+		{
+			"Test with watermark",
+			"test.webm",
+			"http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			db.Preset{
+				Name:        "webm_1080p",
+				Description: "my branded preset",
+				Container:   "webm",
+				Video: db.VideoPreset{
+					Bitrate: "3500000",
+					Codec:   "vp8",
+					GopSize: "90",
+					Height:  "1080",
+					Width:   "1920",
+				},
+				Audio: db.AudioPreset{
+					Bitrate: "128000",
+					Codec:   "aac",
+				},
+				Watermark: &db.Watermark{
+					URL:      "http://nyt.net/logo.png",
+					Position: "top-right",
+					Offset:   "20",
+					Width:    "10%",
+				},
+			},
+			map[string]interface{}{
+				"label":             "webm_1080p:my branded preset",
+				"format":            "webm",
+				"video_codec":       "vp8",
+				"audio_codec":       "aac",
+				"width":             float64(1920),
+				"height":            float64(1080),
+				"video_bitrate":     float64(3500),
+				"audio_bitrate":     float64(128),
+				"keyframe_interval": float64(90),
+				"deinterlace":       "on",
+				"base_url":          "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/abcdef/",
+				"filename":          "test.webm",
+				"watermarks": []interface{}{
+					map[string]interface{}{
+						"url":   "http://nyt.net/logo.png",
+						"x":     "-20",
+						"y":     "20",
+						"width": "10%",
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -446,79 +736,1111 @@ func TestZencoderBuildOutput(t *testing.T) {
 	}
 }
 
-func TestZencoderHealthcheck(t *testing.T) {
-	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+func TestZencoderBuildOutputJobDestination(t *testing.T) {
+	prov := &zencoderProvider{
+		config: &config.Config{
+			Zencoder: &config.Zencoder{
+				APIKey:      "api-key-here",
+				Destination: "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			},
+		},
 	}
-	fakeZencoder := &FakeZencoder{}
-	dbRepo, err := redis.NewRepository(&cfg)
+	job := db.Job{ID: "abcdef", Destination: "s3://tenant-bucket/videos/"}
+	preset := db.Preset{
+		Name:      "mp4_1080p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Bitrate: "3500000", Codec: "h264", GopSize: "90"},
+		Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+	}
+	res, err := prov.buildOutput(&job, preset, "test.mp4")
 	if err != nil {
 		t.Fatal(err)
 	}
+	expected := "s3://tenant-bucket/videos/abcdef/"
+	if res.BaseUrl != expected {
+		t.Errorf("wrong base url. Want %q. Got %q", expected, res.BaseUrl)
+	}
+}
+
+func TestZencoderBuildOutputFilters(t *testing.T) {
 	prov := &zencoderProvider{
-		config: &cfg,
-		client: fakeZencoder,
-		db:     dbRepo,
+		config: &config.Config{
+			Zencoder: &config.Zencoder{
+				APIKey:      "api-key-here",
+				Destination: "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			},
+		},
+	}
+	var tests = []struct {
+		givenTestCase   string
+		jobFilters      *db.VideoFilters
+		presetFilters   *db.VideoFilters
+		wantDeinterlace string
+		wantDenoise     string
+		wantSharpen     bool
+	}{
+		{"no filters", nil, nil, "on", "", false},
+		{"preset filters", nil, &db.VideoFilters{Deinterlace: "detect", Denoise: "weak"}, "detect", "weak", false},
+		{"job filters", &db.VideoFilters{Deinterlace: "off", Sharpen: true}, nil, "off", "", true},
+		{
+			"job filters override preset filters",
+			&db.VideoFilters{Deinterlace: "off"},
+			&db.VideoFilters{Deinterlace: "on", Denoise: "strong"},
+			"off", "strong", false,
+		},
+	}
+	for _, test := range tests {
+		job := db.Job{ID: "abcdef", Filters: test.jobFilters}
+		preset := db.Preset{
+			Name:      "mp4_1080p",
+			Container: "mp4",
+			Video:     db.VideoPreset{Bitrate: "3500000", Codec: "h264", GopSize: "90"},
+			Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+			Filters:   test.presetFilters,
+		}
+		res, err := prov.buildOutput(&job, preset, "test.mp4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Deinterlace != test.wantDeinterlace {
+			t.Errorf("%s: wrong deinterlace. Want %q. Got %q", test.givenTestCase, test.wantDeinterlace, res.Deinterlace)
+		}
+		if res.Denoise != test.wantDenoise {
+			t.Errorf("%s: wrong denoise. Want %q. Got %q", test.givenTestCase, test.wantDenoise, res.Denoise)
+		}
+		if res.Sharpen != test.wantSharpen {
+			t.Errorf("%s: wrong sharpen. Want %v. Got %v", test.givenTestCase, test.wantSharpen, res.Sharpen)
+		}
 	}
+}
 
-	err = prov.Healthcheck()
-	if err != nil {
-		t.Fatal(err)
+func TestZencoderBuildOutputLoudness(t *testing.T) {
+	prov := &zencoderProvider{
+		config: &config.Config{
+			Zencoder: &config.Zencoder{
+				APIKey:      "api-key-here",
+				Destination: "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			},
+		},
+	}
+	var tests = []struct {
+		givenTestCase   string
+		audio           db.AudioPreset
+		wantNormalize   bool
+		wantPostAmplify float64
+	}{
+		{"no normalization", db.AudioPreset{Bitrate: "128000", Codec: "aac"}, false, 0},
+		{"target loudness", db.AudioPreset{Bitrate: "128000", Codec: "aac", Loudness: "-23"}, true, 0},
+		{"target loudness and true peak", db.AudioPreset{Bitrate: "128000", Codec: "aac", Loudness: "-24", TruePeak: "-2"}, true, -2},
+	}
+	for _, test := range tests {
+		job := db.Job{ID: "abcdef"}
+		preset := db.Preset{Name: "m4a_128k", Container: "m4a", AudioOnly: true, Audio: test.audio}
+		res, err := prov.buildOutput(&job, preset, "test.m4a")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.givenTestCase, err)
+			continue
+		}
+		if res.AudioNormalize != test.wantNormalize {
+			t.Errorf("%s: wrong audio normalize. Want %v. Got %v", test.givenTestCase, test.wantNormalize, res.AudioNormalize)
+		}
+		if res.AudioPostAmplify != test.wantPostAmplify {
+			t.Errorf("%s: wrong audio post amplify. Want %g. Got %g", test.givenTestCase, test.wantPostAmplify, res.AudioPostAmplify)
+		}
 	}
 }
 
-func TestZencoderCancelJob(t *testing.T) {
+func TestZencoderBuildOutputChannelLayout(t *testing.T) {
+	prov := &zencoderProvider{
+		config: &config.Config{
+			Zencoder: &config.Zencoder{
+				APIKey:      "api-key-here",
+				Destination: "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			},
+		},
+	}
+	var tests = []struct {
+		givenTestCase string
+		audio         db.AudioPreset
+		wantCodec     string
+		wantChannels  int32
+	}{
+		{"default layout", db.AudioPreset{Bitrate: "128000", Codec: "aac"}, "aac", 0},
+		{"stereo", db.AudioPreset{Bitrate: "192000", Codec: "ac3", ChannelLayout: "stereo"}, "ac3", 2},
+		{"5.1 surround", db.AudioPreset{Bitrate: "640000", Codec: "eac3", ChannelLayout: "5.1"}, "eac3", 6},
+	}
+	for _, test := range tests {
+		job := db.Job{ID: "abcdef"}
+		preset := db.Preset{Name: "m4a_surround", Container: "m4a", AudioOnly: true, Audio: test.audio}
+		res, err := prov.buildOutput(&job, preset, "test.m4a")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.givenTestCase, err)
+			continue
+		}
+		if res.AudioCodec != test.wantCodec || res.AudioChannels != test.wantChannels {
+			t.Errorf("%s: wrong audio settings. Want %s with %d channels. Got %s with %d channels", test.givenTestCase, test.wantCodec, test.wantChannels, res.AudioCodec, res.AudioChannels)
+		}
+	}
+}
+
+func TestZencoderBuildOutputRotation(t *testing.T) {
+	prov := &zencoderProvider{
+		config: &config.Config{
+			Zencoder: &config.Zencoder{
+				APIKey:      "api-key-here",
+				Destination: "http://a:b@nyt-elastictranscoder-tests.s3.amazonaws.com/t/",
+			},
+		},
+	}
+	var tests = []struct {
+		givenTestCase string
+		rotation      *db.Rotation
+		wantRotate    int32
+		wantErr       bool
+	}{
+		{"default autorotation", nil, 0, false},
+		{"explicit autorotation", &db.Rotation{Autorotate: true}, 0, false},
+		{"explicit degrees", &db.Rotation{Degrees: 270}, 270, false},
+		{"autorotation disabled", &db.Rotation{}, 0, true},
+	}
+	for _, test := range tests {
+		job := db.Job{ID: "abcdef", Rotation: test.rotation}
+		preset := db.Preset{
+			Name:      "mp4_1080p",
+			Container: "mp4",
+			Video:     db.VideoPreset{Bitrate: "3500000", Codec: "h264", GopSize: "90"},
+			Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+		}
+		res, err := prov.buildOutput(&job, preset, "test.mp4")
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: unexpected <nil> error", test.givenTestCase)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.givenTestCase, err)
+			continue
+		}
+		if res.Rotate != test.wantRotate {
+			t.Errorf("%s: wrong rotate. Want %d. Got %d", test.givenTestCase, test.wantRotate, res.Rotate)
+		}
+	}
+}
+
+func TestZencoderBuildOutputsKeyframeAlignment(t *testing.T) {
+	memory.Reset()
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
-	fakeZencoder := &FakeZencoder{}
-	dbRepo, err := redis.NewRepository(&cfg)
+	dbRepo, err := memory.NewRepository(&cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
 	prov := &zencoderProvider{
 		config: &cfg,
-		client: fakeZencoder,
+		client: &FakeZencoder{},
 		db:     dbRepo,
 	}
-
-	err = prov.CancelJob("123")
-	if err != nil {
-		t.Fatal(err)
+	for name, gopSize := range map[string]string{"mp4_720p": "90", "mp4_1080p": "90", "mp4_360p": "60"} {
+		_, err = prov.CreatePreset(db.Preset{
+			Name:      name,
+			Container: "mp4",
+			Video:     db.VideoPreset{Bitrate: "1000000", Codec: "h264", GopSize: gopSize},
+			Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var tests = []struct {
+		testCase  string
+		presets   []string
+		alignment *provider.KeyframeAlignment
+		errMsg    string
+	}{
+		{
+			"aligned renditions",
+			[]string{"mp4_720p", "mp4_1080p"},
+			&provider.KeyframeAlignment{Enabled: true},
+			"",
+		},
+		{
+			"renditions with different GOP sizes",
+			[]string{"mp4_720p", "mp4_360p"},
+			&provider.KeyframeAlignment{Enabled: true},
+			`rendition "mp4_360p" has GOP size 60, expected 90`,
+		},
+		{
+			"synchronized scene-cut",
+			[]string{"mp4_720p", "mp4_1080p"},
+			&provider.KeyframeAlignment{Enabled: true, SceneCut: provider.SceneCutSynchronized},
+			"synchronized scene-cut keyframes are not supported by zencoder",
+		},
+	}
+	for _, test := range tests {
+		var outputs []provider.TranscodeOutput
+		for _, name := range test.presets {
+			outputs = append(outputs, provider.TranscodeOutput{
+				FileName: name + ".mp4",
+				Preset:   db.PresetMap{Name: name, ProviderMapping: map[string]string{Name: name}},
+			})
+		}
+		res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			Outputs:         outputs,
+			StreamingParams: provider.StreamingParams{KeyframeAlignment: test.alignment},
+		})
+		if test.errMsg != "" {
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("%s: wrong error\nWant %q\nGot  %v", test.testCase, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		for _, output := range res {
+			if !output.FixedKeyframeInterval {
+				t.Errorf("%s: output %q doesn't have a fixed keyframe interval", test.testCase, output.Label)
+			}
+		}
 	}
 }
 
-func TestZencoderJobStatus(t *testing.T) {
+func TestZencoderBuildOutputsClip(t *testing.T) {
+	memory.Reset()
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
-	fakeZencoder := &FakeZencoder{}
-	dbRepo, err := redis.NewRepository(&cfg)
+	dbRepo, err := memory.NewRepository(&cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
 	prov := &zencoderProvider{
 		config: &cfg,
-		client: fakeZencoder,
+		client: &FakeZencoder{},
 		db:     dbRepo,
 	}
-	jobStatus, err := prov.JobStatus(&db.Job{
-		ProviderJobID: "1234567890",
+	_, err = prov.CreatePreset(db.Preset{
+		Name:      "mp4_720p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Bitrate: "1000000", Codec: "h264", GopSize: "90"},
+		Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	resultJSON, err := json.Marshal(jobStatus)
-	if err != nil {
-		t.Fatal(err)
-	}
-	result := make(map[string]interface{})
-	err = json.Unmarshal(resultJSON, &result)
-	if err != nil {
-		t.Fatal(err)
+	var tests = []struct {
+		testCase           string
+		clip               *provider.Clip
+		expectedStartClip  string
+		expectedClipLength string
+	}{
+		{"no clip", nil, "", ""},
+		{"leading padding", &provider.Clip{Start: 2.04}, "2.040", ""},
+		{"leading and trailing padding", &provider.Clip{Start: 1.92, Duration: 58.08}, "1.920", "58.080"},
+	}
+	for _, test := range tests {
+		res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			Outputs: []provider.TranscodeOutput{{
+				FileName: "mp4_720p.mp4",
+				Preset:   db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}},
+			}},
+			Clip: test.clip,
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if res[0].StartClip != test.expectedStartClip || res[0].ClipLength != test.expectedClipLength {
+			t.Errorf("%s: wrong clip. Want %q/%q. Got %q/%q", test.testCase, test.expectedStartClip, test.expectedClipLength, res[0].StartClip, res[0].ClipLength)
+		}
+	}
+}
+
+func TestZencoderBuildOutputsPreview(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	_, err = prov.CreatePreset(db.Preset{
+		Name:      "mp4_720p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Width: "1280", Height: "720", Bitrate: "1000000", Codec: "h264", GopSize: "90"},
+		Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	watermark := db.Watermark{URL: "https://example.com/preview.png", Position: "top-right", Offset: "10"}
+	var tests = []struct {
+		testCase           string
+		preview            *db.Preview
+		clip               *provider.Clip
+		expectedStartClip  string
+		expectedClipLength string
+		expectedWidth      int32
+		expectedHeight     int32
+		expectedWatermarks []*zencoder.WatermarkSettings
+		errMsg             string
+	}{
+		{
+			"first seconds",
+			&db.Preview{Duration: 30},
+			nil,
+			"0.000", "30.000", 1280, 720, nil, "",
+		},
+		{
+			"capped resolution with watermark",
+			&db.Preview{Start: 60, Duration: 15, MaxHeight: 360, Watermark: &watermark},
+			nil,
+			"60.000", "15.000", 640, 360,
+			[]*zencoder.WatermarkSettings{{Url: "https://example.com/preview.png", X: "-10", Y: "10"}},
+			"",
+		},
+		{
+			"resolution below the cap",
+			&db.Preview{Duration: 30, MaxHeight: 1080},
+			nil,
+			"0.000", "30.000", 1280, 720, nil, "",
+		},
+		{
+			"relative to the clip",
+			&db.Preview{Start: 10, Duration: 30},
+			&provider.Clip{Start: 5, Duration: 20},
+			"15.000", "10.000", 1280, 720, nil, "",
+		},
+		{
+			"after the end of the clip",
+			&db.Preview{Start: 30, Duration: 30},
+			&provider.Clip{Start: 5, Duration: 20},
+			"", "", 0, 0, nil,
+			`the preview of "preview.mp4" starts after the end of the clip`,
+		},
+	}
+	for _, test := range tests {
+		res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			Outputs: []provider.TranscodeOutput{{
+				FileName: "preview.mp4",
+				Preset:   db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}},
+				Preview:  test.preview,
+			}},
+			Clip: test.clip,
+		})
+		if test.errMsg != "" {
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		output := res[0]
+		if output.StartClip != test.expectedStartClip || output.ClipLength != test.expectedClipLength {
+			t.Errorf("%s: wrong clip. Want %q/%q. Got %q/%q", test.testCase, test.expectedStartClip, test.expectedClipLength, output.StartClip, output.ClipLength)
+		}
+		if output.Width != test.expectedWidth || output.Height != test.expectedHeight {
+			t.Errorf("%s: wrong resolution. Want %dx%d. Got %dx%d", test.testCase, test.expectedWidth, test.expectedHeight, output.Width, output.Height)
+		}
+		if !reflect.DeepEqual(output.Watermarks, test.expectedWatermarks) {
+			t.Errorf("%s: wrong watermarks. Want %#v. Got %#v", test.testCase, test.expectedWatermarks, output.Watermarks)
+		}
+	}
+}
+
+func TestZencoderBuildOutputsDASH(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	for _, name := range []string{"dash_720p", "dash_480p"} {
+		_, err = prov.CreatePreset(db.Preset{
+			Name:      name,
+			Container: "mp4",
+			Video:     db.VideoPreset{Bitrate: "1000000", Codec: "h264", GopSize: "90"},
+			Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var tests = []struct {
+		testCase         string
+		streaming        provider.StreamingParams
+		expectedManifest string
+		expectedPaths    []string
+	}{
+		{
+			"default manifest",
+			provider.StreamingParams{Protocol: "dash", SegmentDuration: 4},
+			"dash/index.mpd",
+			[]string{"../video_720p.mp4", "../dash/video_480p.mp4"},
+		},
+		{
+			"manifest at the root",
+			provider.StreamingParams{Protocol: "dash", SegmentDuration: 4, PlaylistFileName: "manifest.mpd"},
+			"manifest.mpd",
+			[]string{"video_720p.mp4", "dash/video_480p.mp4"},
+		},
+	}
+	for _, test := range tests {
+		res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			Outputs: []provider.TranscodeOutput{
+				{FileName: "video_720p.mp4", Preset: db.PresetMap{Name: "dash_720p", ProviderMapping: map[string]string{Name: "dash_720p"}}},
+				{FileName: "dash/video_480p.mp4", Preset: db.PresetMap{Name: "dash_480p", ProviderMapping: map[string]string{Name: "dash_480p"}}},
+			},
+			StreamingParams: test.streaming,
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if len(res) != 3 {
+			t.Errorf("%s: wrong number of outputs. Want 3. Got %d", test.testCase, len(res))
+			continue
+		}
+		for _, output := range res[:2] {
+			if output.Type != "segmented" || output.StreamingDeliveryFormat != "dash" || output.SegmentSeconds != 4 {
+				t.Errorf("%s: wrong DASH settings in the rendition: %#v", test.testCase, output)
+			}
+		}
+		manifest := res[2]
+		if manifest.Type != "playlist" || manifest.StreamingDeliveryFormat != "dash" || manifest.Filename != test.expectedManifest || manifest.BaseUrl != "s3://mybucket/job-123/" {
+			t.Errorf("%s: wrong manifest output: %#v", test.testCase, manifest)
+		}
+		var paths []string
+		for _, stream := range manifest.Streams {
+			paths = append(paths, stream.Path)
+		}
+		if !reflect.DeepEqual(paths, test.expectedPaths) {
+			t.Errorf("%s: wrong streams in the manifest. Want %#v. Got %#v", test.testCase, test.expectedPaths, paths)
+		}
+	}
+	_, err = prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		Outputs:         []provider.TranscodeOutput{{FileName: "video_720p.mp4", Preset: db.PresetMap{Name: "dash_720p"}}},
+		StreamingParams: provider.StreamingParams{Protocol: "dash", MinBufferTime: 2},
+	})
+	if err != errZencoderMinBufferTime {
+		t.Errorf("wrong error for the min buffer time. Want %#v. Got %#v", errZencoderMinBufferTime, err)
+	}
+}
+
+func TestZencoderBuildOutputsHLSSegmentFormat(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	_, err = prov.CreatePreset(db.Preset{
+		Name:      "hls_720p",
+		Container: "m3u8",
+		Video:     db.VideoPreset{Bitrate: "1000000", Codec: "h264", GopSize: "90"},
+		Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		testCase       string
+		segmentFormat  provider.SegmentFormat
+		expectedType   string
+		expectedFormat string
+		expectedSecs   int32
+	}{
+		{"default segments", "", "", "m3u8", 0},
+		{"mpeg-ts segments", provider.SegmentFormatTS, "", "m3u8", 0},
+		{"fmp4 segments", provider.SegmentFormatFMP4, "segmented", "mp4", 6},
+	}
+	for _, test := range tests {
+		res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			Outputs: []provider.TranscodeOutput{
+				{FileName: "hls/video_720p.m3u8", Preset: db.PresetMap{Name: "hls_720p", ProviderMapping: map[string]string{Name: "hls_720p"}}},
+			},
+			StreamingParams: provider.StreamingParams{Protocol: "hls", SegmentDuration: 6, SegmentFormat: test.segmentFormat},
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		output := res[0]
+		if output.Type != test.expectedType || output.Format != test.expectedFormat || output.SegmentSeconds != test.expectedSecs {
+			t.Errorf("%s: wrong segment settings. Want %q/%q/%d. Got %q/%q/%d", test.testCase, test.expectedType, test.expectedFormat, test.expectedSecs, output.Type, output.Format, output.SegmentSeconds)
+		}
+	}
+}
+
+func TestZencoderBuildOutputsHLSPlaylist(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	for _, preset := range []db.Preset{
+		{
+			Name:         "hls_1080p",
+			Container:    "m3u8",
+			Profile:      "high",
+			ProfileLevel: "4.1",
+			Video:        db.VideoPreset{Width: "1920", Height: "1080", Bitrate: "5000000", Codec: "h264", GopSize: "90"},
+			Audio:        db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+		},
+		{
+			Name:      "hls_480p",
+			Container: "m3u8",
+			Video:     db.VideoPreset{Width: "854", Height: "480", Bitrate: "1000000", Codec: "h264", GopSize: "90"},
+			Audio:     db.AudioPreset{Bitrate: "96000", Codec: "aac"},
+		},
+	} {
+		if _, err = prov.CreatePreset(preset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputs := []provider.TranscodeOutput{
+		{FileName: "hls/video_1080p.m3u8", Preset: db.PresetMap{Name: "hls_1080p", ProviderMapping: map[string]string{Name: "hls_1080p"}}},
+		{FileName: "hls/video_480p.m3u8", Preset: db.PresetMap{Name: "hls_480p", ProviderMapping: map[string]string{Name: "hls_480p"}}},
+	}
+	var tests = []struct {
+		testCase        string
+		streaming       provider.StreamingParams
+		expectedStreams []zencoder.StreamSettings
+		expectedAudio   int32
+	}{
+		{
+			"master playlist",
+			provider.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8"},
+			[]zencoder.StreamSettings{
+				{Path: "video_1080p.m3u8", Bandwidth: 5128, Resolution: "1920x1080", Codecs: "avc1.640029,mp4a.40.2"},
+				{Path: "video_480p.m3u8", Bandwidth: 1096, Resolution: "854x480", Codecs: "avc1.4d401e,mp4a.40.2"},
+			},
+			0,
+		},
+		{
+			"audio-only rendition with the default bitrate",
+			provider.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8", AudioOnlyRendition: true},
+			[]zencoder.StreamSettings{
+				{Path: "video_1080p.m3u8", Bandwidth: 5128, Resolution: "1920x1080", Codecs: "avc1.640029,mp4a.40.2"},
+				{Path: "video_480p.m3u8", Bandwidth: 1096, Resolution: "854x480", Codecs: "avc1.4d401e,mp4a.40.2"},
+				{Path: "audio_only.m3u8", Bandwidth: 64, Codecs: "mp4a.40.2"},
+			},
+			64,
+		},
+		{
+			"audio-only rendition with custom bitrate",
+			provider.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8", AudioOnlyRendition: true, AudioOnlyBitrate: 48000},
+			[]zencoder.StreamSettings{
+				{Path: "video_1080p.m3u8", Bandwidth: 5128, Resolution: "1920x1080", Codecs: "avc1.640029,mp4a.40.2"},
+				{Path: "video_480p.m3u8", Bandwidth: 1096, Resolution: "854x480", Codecs: "avc1.4d401e,mp4a.40.2"},
+				{Path: "audio_only.m3u8", Bandwidth: 48, Codecs: "mp4a.40.2"},
+			},
+			48,
+		},
+	}
+	for _, test := range tests {
+		res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{Outputs: outputs, StreamingParams: test.streaming})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		playlist := res[len(res)-1]
+		if playlist.Type != "playlist" || playlist.Filename != "hls/index.m3u8" || playlist.StreamingDeliveryFormat != "" {
+			t.Errorf("%s: wrong playlist output: %#v", test.testCase, playlist)
+		}
+		streams := make([]zencoder.StreamSettings, len(playlist.Streams))
+		for i, stream := range playlist.Streams {
+			streams[i] = *stream
+		}
+		if !reflect.DeepEqual(streams, test.expectedStreams) {
+			t.Errorf("%s: wrong streams\nWant %#v\nGot  %#v", test.testCase, test.expectedStreams, streams)
+		}
+		if test.expectedAudio == 0 {
+			if len(res) != 3 {
+				t.Errorf("%s: wrong number of outputs. Want 3. Got %d", test.testCase, len(res))
+			}
+			continue
+		}
+		audio := res[2]
+		if audio.Filename != "hls/audio_only.m3u8" || !audio.SkipVideo || audio.AudioCodec != "aac" || audio.AudioBitrate != test.expectedAudio || audio.VideoBitrate != 0 {
+			t.Errorf("%s: wrong audio-only output: %#v", test.testCase, audio)
+		}
+	}
+}
+
+func TestZencoderHLSCodecs(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		preset   db.Preset
+		expected string
+	}{
+		{
+			"high profile with level",
+			db.Preset{Profile: "High", ProfileLevel: "4.1", Video: db.VideoPreset{Codec: "h264"}, Audio: db.AudioPreset{Codec: "aac"}},
+			"avc1.640029,mp4a.40.2",
+		},
+		{
+			"baseline profile with level",
+			db.Preset{Profile: "baseline", ProfileLevel: "3.0", Video: db.VideoPreset{Codec: "h264"}, Audio: db.AudioPreset{Codec: "aac"}},
+			"avc1.42e01e,mp4a.40.2",
+		},
+		{
+			"default profile and level from the height",
+			db.Preset{Video: db.VideoPreset{Codec: "h264", Height: "720"}, Audio: db.AudioPreset{Codec: "aac"}},
+			"avc1.4d401f,mp4a.40.2",
+		},
+		{
+			"default level without height",
+			db.Preset{Video: db.VideoPreset{Codec: "h264"}, Audio: db.AudioPreset{Codec: "aac"}},
+			"avc1.4d4028,mp4a.40.2",
+		},
+		{
+			"audio only",
+			db.Preset{AudioOnly: true, Audio: db.AudioPreset{Codec: "mp3"}},
+			"mp4a.40.34",
+		},
+		{
+			"unknown video codec",
+			db.Preset{Video: db.VideoPreset{Codec: "hevc"}, Audio: db.AudioPreset{Codec: "aac"}},
+			"",
+		},
+		{
+			"unknown audio codec",
+			db.Preset{Video: db.VideoPreset{Codec: "h264"}, Audio: db.AudioPreset{Codec: "vorbis"}},
+			"",
+		},
+	}
+	for _, test := range tests {
+		codecs := hlsCodecs(test.preset)
+		if codecs != test.expected {
+			t.Errorf("%s: wrong codecs. Want %q. Got %q", test.testCase, test.expected, codecs)
+		}
+	}
+}
+
+func TestZencoderBuildOutputsDRM(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	for _, preset := range []db.Preset{{Name: "hls_720p", Container: "m3u8"}, {Name: "mp4_720p", Container: "mp4"}} {
+		preset.Video = db.VideoPreset{Bitrate: "1000000", Codec: "h264", GopSize: "90"}
+		preset.Audio = db.AudioPreset{Bitrate: "128000", Codec: "aac"}
+		if _, err = prov.CreatePreset(preset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputs := []provider.TranscodeOutput{
+		{FileName: "hls/video_720p.m3u8", Preset: db.PresetMap{Name: "hls_720p", ProviderMapping: map[string]string{Name: "hls_720p"}}},
+		{FileName: "video_720p.mp4", Preset: db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}}},
+	}
+	var tests = []struct {
+		testCase    string
+		protocol    provider.Protocol
+		drm         provider.DRMParams
+		expectedErr error
+	}{
+		{
+			"aes-128 in HLS job",
+			"hls",
+			provider.DRMParams{Scheme: "aes-128", Key: []byte("0123456789abcdef"), LicenseURL: "https://keys.example.com/job-123", IV: []byte("fedcba9876543210")},
+			nil,
+		},
+		{
+			"widevine",
+			"dash",
+			provider.DRMParams{Scheme: "widevine", Key: []byte("0123456789abcdef"), KeyID: []byte("0123456789abcdef"), LicenseURL: "https://license.example.com"},
+			errZencoderDRM,
+		},
+		{
+			"aes-128 in DASH job",
+			"dash",
+			provider.DRMParams{Scheme: "aes-128", Key: []byte("0123456789abcdef"), LicenseURL: "https://keys.example.com/job-123"},
+			errZencoderDRM,
+		},
+	}
+	for _, test := range tests {
+		drm := test.drm
+		res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+			Outputs:         outputs,
+			StreamingParams: provider.StreamingParams{Protocol: test.protocol, SegmentDuration: 6},
+			DRM:             &drm,
+		})
+		if err != test.expectedErr {
+			t.Errorf("%s: wrong error. Want %v. Got %v", test.testCase, test.expectedErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		hls := res[0]
+		if hls.EncryptionMethod != "aes-128" || hls.EncryptionKey != "30313233343536373839616263646566" || hls.EncryptionKeyUrl != "https://keys.example.com/job-123" || hls.EncryptionIv != "66656463626139383736353433323130" {
+			t.Errorf("%s: wrong encryption of the HLS output: %#v", test.testCase, hls)
+		}
+		if mp4 := res[1]; mp4.EncryptionMethod != "" || mp4.EncryptionKey != "" {
+			t.Errorf("%s: unexpected encryption of the mp4 output: %#v", test.testCase, mp4)
+		}
+	}
+}
+
+func TestZencoderBuildOutputsHeaders(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	preset := db.Preset{
+		Name:      "mp4_720p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Bitrate: "1000000", Codec: "h264", GopSize: "90"},
+		Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+	}
+	if _, err = prov.CreatePreset(preset); err != nil {
+		t.Fatal(err)
+	}
+	res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		Outputs: []provider.TranscodeOutput{
+			{
+				FileName: "dash/video_720p.mp4",
+				Preset:   db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}},
+				Headers: &provider.OutputHeaders{
+					CacheControl: "max-age=31536000",
+					Metadata:     map[string]string{"asset-id": "123"},
+				},
+			},
+			{
+				FileName: "video_720p.mp4",
+				Preset:   db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}},
+			},
+		},
+		StreamingParams: provider.StreamingParams{Protocol: "dash", SegmentDuration: 6, PlaylistFileName: "dash/index.mpd"},
+		PlaylistHeaders: &provider.OutputHeaders{CacheControl: "max-age=2", ContentDisposition: "inline"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]string{
+		{"Cache-Control": "max-age=31536000", "x-amz-meta-asset-id": "123"},
+		nil,
+		{"Cache-Control": "max-age=2", "Content-Disposition": "inline"},
+	}
+	if len(res) != len(expected) {
+		t.Fatalf("wrong number of outputs. Want %d. Got %d", len(expected), len(res))
+	}
+	for i, output := range res {
+		if !reflect.DeepEqual(output.Headers, expected[i]) {
+			t.Errorf("wrong headers in output %d. Want %#v. Got %#v", i, expected[i], output.Headers)
+		}
+	}
+}
+
+func TestZencoderHealthcheck(t *testing.T) {
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
+	}
+	fakeZencoder := &FakeZencoder{}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: fakeZencoder,
+		db:     dbRepo,
+	}
+
+	err = prov.Healthcheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestZencoderCancelJob(t *testing.T) {
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
+	}
+	fakeZencoder := &FakeZencoder{}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: fakeZencoder,
+		db:     dbRepo,
+	}
+
+	err = prov.CancelJob("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestZencoderBuildOutputsCaptions(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	preset := db.Preset{
+		Name:      "mp4_720p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Bitrate: "1000000", Codec: "h264", GopSize: "90"},
+		Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+	}
+	if _, err = prov.CreatePreset(preset); err != nil {
+		t.Fatal(err)
+	}
+	outputs := []provider.TranscodeOutput{
+		{FileName: "video_720p.mp4", Preset: db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}}},
+	}
+	res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		Outputs: outputs,
+		Captions: []provider.Caption{
+			{Source: "http://example.com/captions/en.srt", Language: "en", Format: "srt", Mode: "sidecar", FileName: "captions/en.srt"},
+			{Source: "http://example.com/captions/es.xml", Language: "es", Format: "ttml", Mode: "sidecar", FileName: "captions/es.ttml"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []zencoder.OutputSettings{
+		{Label: "captions:en", Type: "captions", Format: "srt", CaptionUrl: "http://example.com/captions/en.srt", BaseUrl: "s3://mybucket/job-123/", Filename: "captions/en.srt"},
+		{Label: "captions:es", Type: "captions", Format: "dfxp", CaptionUrl: "http://example.com/captions/es.xml", BaseUrl: "s3://mybucket/job-123/", Filename: "captions/es.ttml"},
+	}
+	if len(res) != 3 {
+		t.Fatalf("wrong number of outputs. Want 3. Got %d", len(res))
+	}
+	for i, output := range res[1:] {
+		if !reflect.DeepEqual(*output, expected[i]) {
+			t.Errorf("wrong caption output. Want %#v. Got %#v", expected[i], *output)
+		}
+	}
+	_, err = prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{
+		Outputs:  outputs,
+		Captions: []provider.Caption{{Source: "http://example.com/captions/en.srt", Language: "en", Format: "srt", Mode: "burn-in"}},
+	})
+	if err != errZencoderBurnIn {
+		t.Errorf("wrong error for burned-in captions. Want %v. Got %v", errZencoderBurnIn, err)
+	}
+}
+
+func TestZencoderBuildOutputsThumbnails(t *testing.T) {
+	memory.Reset()
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	preset := db.Preset{
+		Name:      "mp4_720p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Bitrate: "1000000", Codec: "h264", GopSize: "90"},
+		Audio:     db.AudioPreset{Bitrate: "128000", Codec: "aac"},
+	}
+	if _, err = prov.CreatePreset(preset); err != nil {
+		t.Fatal(err)
+	}
+	outputs := []provider.TranscodeOutput{
+		{FileName: "video_720p.mp4", Preset: db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}}},
+		{FileName: "video_720p_copy.mp4", Preset: db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{Name: "mp4_720p"}}},
+	}
+	var tests = []struct {
+		testCase   string
+		thumbnails provider.Thumbnails
+		expected   zencoder.ThumbnailSettings
+	}{
+		{
+			"thumbnails by interval",
+			provider.Thumbnails{Interval: 10, Width: 320, Format: "jpg"},
+			zencoder.ThumbnailSettings{Label: "thumbnails", Format: "jpg", Interval: 10, Width: 320, BaseUrl: "s3://mybucket/job-123/thumbnails/", Prefix: "thumb"},
+		},
+		{
+			"thumbnails by count",
+			provider.Thumbnails{Count: 5, Width: 640, Height: 360, Format: "png"},
+			zencoder.ThumbnailSettings{Label: "thumbnails", Format: "png", Number: 5, Width: 640, Height: 360, BaseUrl: "s3://mybucket/job-123/thumbnails/", Prefix: "thumb"},
+		},
+	}
+	for _, test := range tests {
+		thumbnails := test.thumbnails
+		res, err := prov.buildOutputs(&db.Job{ID: "job-123"}, provider.TranscodeProfile{Outputs: outputs, Thumbnails: &thumbnails})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res[0].Thumbnails) != 1 || !reflect.DeepEqual(*res[0].Thumbnails[0], test.expected) {
+			t.Errorf("%s: wrong thumbnails. Want %#v. Got %#v", test.testCase, test.expected, res[0].Thumbnails)
+		}
+		if len(res[1].Thumbnails) != 0 {
+			t.Errorf("%s: unexpected thumbnails in the second output: %#v", test.testCase, res[1].Thumbnails)
+		}
+	}
+}
+
+func TestZencoderJobOutputsThumbnails(t *testing.T) {
+	prov := &zencoderProvider{
+		config: &config.Config{Zencoder: &config.Zencoder{Destination: "s3://mybucket/"}},
+	}
+	output, err := prov.getJobOutputs(&db.Job{ID: "job-123"}, []*zencoder.MediaFile{
+		{Url: "s3://mybucket/job-123/video_720p.mp4", Format: "mp4", VideoCodec: "h264", Width: 1280, Height: 720},
+	}, []*zencoder.Thumbnail{
+		{Url: "s3://mybucket/job-123/thumbnails/thumb_0000.jpg", Format: "jpg", Width: 320, Height: 180},
+		{Url: "s3://mybucket/job-123/thumbnails/thumb_0001.jpg", Format: "jpg", Width: 320, Height: 180},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []provider.OutputFile{
+		{Path: "s3://mybucket/job-123/video_720p.mp4", Container: "mp4", VideoCodec: "h264", Width: 1280, Height: 720},
+		{Path: "s3://mybucket/job-123/thumbnails/thumb_0000.jpg", Container: "jpg", Width: 320, Height: 180},
+		{Path: "s3://mybucket/job-123/thumbnails/thumb_0001.jpg", Container: "jpg", Width: 320, Height: 180},
+	}
+	if !reflect.DeepEqual(output.Files, expected) {
+		t.Errorf("wrong output files. Want %#v. Got %#v", expected, output.Files)
+	}
+}
+
+func TestZencoderJobStatusCaptions(t *testing.T) {
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here", Destination: "s3://mybucket/"},
+		DatabaseDriver: "memory",
+	}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: &FakeZencoder{},
+		db:     dbRepo,
+	}
+	jobStatus, err := prov.JobStatus(&db.Job{
+		ID:            "job-123",
+		ProviderJobID: "1234567890",
+		Captions: []db.Caption{
+			{Source: "http://example.com/captions/en.srt", Language: "en", Format: "srt", Mode: "sidecar", FileName: "captions/en.srt"},
+			{Source: "http://example.com/captions/es.srt", Language: "es", Format: "srt", Mode: "burn-in"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := jobStatus.Output.Files
+	if len(files) != 3 {
+		t.Fatalf("wrong number of output files. Want 3. Got %d", len(files))
+	}
+	expected := provider.OutputFile{Path: "s3://mybucket/job-123/captions/en.srt", Container: "srt"}
+	if files[2] != expected {
+		t.Errorf("wrong caption file. Want %#v. Got %#v", expected, files[2])
+	}
+}
+
+func TestZencoderJobStatus(t *testing.T) {
+	cfg := config.Config{
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
+	}
+	fakeZencoder := &FakeZencoder{}
+	dbRepo, err := memory.NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := &zencoderProvider{
+		config: &cfg,
+		client: fakeZencoder,
+		db:     dbRepo,
+	}
+	jobStatus, err := prov.JobStatus(&db.Job{
+		ProviderJobID: "1234567890",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resultJSON, err := json.Marshal(jobStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]interface{})
+	err = json.Unmarshal(resultJSON, &result)
+	if err != nil {
+		t.Fatal(err)
 	}
 	expected := map[string]interface{}{
 		"providerName":  "zencoder",
@@ -564,13 +1886,45 @@ func TestZencoderJobStatus(t *testing.T) {
 	}
 }
 
+func TestZencoderJobArtifacts(t *testing.T) {
+	cfg := config.Config{Zencoder: &config.Zencoder{APIKey: "api-key-here"}}
+	prov := &zencoderProvider{config: &cfg, client: &FakeZencoder{}}
+	artifacts, err := prov.JobArtifacts(&db.Job{ID: "job-123", ProviderJobID: "1234567890"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 1 {
+		t.Fatalf("wrong number of artifacts. Want 1. Got %d", len(artifacts))
+	}
+	artifact := artifacts[0]
+	if artifact.JobID != "job-123" || artifact.Name != "zencoder-media-info" || artifact.Kind != "media-info" || artifact.Source != Name {
+		t.Errorf("wrong artifact: %#v", artifact)
+	}
+	if want := time.Date(2016, 11, 5, 5, 2, 57, 0, time.UTC); !artifact.CreationTime.Equal(want) {
+		t.Errorf("wrong creation time. Want %s. Got %s", want, artifact.CreationTime)
+	}
+	var info struct {
+		Input   map[string]interface{}
+		Outputs []map[string]interface{}
+	}
+	if err = json.Unmarshal(artifact.Data, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Input["url"] != "http://nyt.net/input.mov" || len(info.Outputs) != 2 || info.Outputs[1]["url"] != "http://nyt.net/output2.webm" {
+		t.Errorf("wrong media info: %s", artifact.Data)
+	}
+	if _, err = prov.JobArtifacts(&db.Job{ID: "job-123", ProviderJobID: "abc"}); err == nil {
+		t.Error("unexpected <nil> error for an invalid job id")
+	}
+}
+
 func TestZencoderStatusMap(t *testing.T) {
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
 	fakeZencoder := &FakeZencoder{}
-	dbRepo, err := redis.NewRepository(&cfg)
+	dbRepo, err := memory.NewRepository(&cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -599,13 +1953,13 @@ func TestZencoderStatusMap(t *testing.T) {
 	}
 }
 func TestZencoderGetResolution(t *testing.T) {
-	cleanLocalPresets()
+	memory.Reset()
 	cfg := config.Config{
-		Zencoder: &config.Zencoder{APIKey: "api-key-here"},
-		Redis:    new(storage.Config),
+		Zencoder:       &config.Zencoder{APIKey: "api-key-here"},
+		DatabaseDriver: "memory",
 	}
 	fakeZencoder := &FakeZencoder{}
-	dbRepo, err := redis.NewRepository(&cfg)
+	dbRepo, err := memory.NewRepository(&cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -636,21 +1990,37 @@ func TestZencoderGetResolution(t *testing.T) {
 	}
 }
 
-func cleanLocalPresets() error {
-	client := redisDriver.NewClient(&redisDriver.Options{Addr: "127.0.0.1:6379"})
-	defer client.Close()
-	err := deleteKeys("localpreset:*", client)
-	err = deleteKeys("localpresets", client)
-	return err
-}
-
-func deleteKeys(pattern string, client *redisDriver.Client) error {
-	keys, err := client.Keys(pattern).Result()
-	if err != nil {
-		return err
+func TestZencoderAccountUsage(t *testing.T) {
+	var tests = []struct {
+		testCase            string
+		apiKey              string
+		minRemainingMinutes int64
+		wantNearExhaustion  bool
+	}{
+		{"no minimum", "api-key-1", 0, false},
+		{"above the minimum", "api-key-2", 500, false},
+		{"below the minimum", "api-key-3", 1500, true},
 	}
-	if len(keys) > 0 {
-		_, err = client.Del(keys...).Result()
+	for _, test := range tests {
+		prov := &zencoderProvider{
+			config: &config.Config{
+				Zencoder: &config.Zencoder{APIKey: test.apiKey, MinRemainingMinutes: test.minRemainingMinutes},
+			},
+			client: &FakeZencoder{},
+		}
+		usage, err := prov.AccountUsage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := provider.AccountUsage{
+			Plan:             "Growth",
+			MinutesUsed:      9000,
+			MinutesIncluded:  10000,
+			MinutesRemaining: 1000,
+			NearExhaustion:   test.wantNearExhaustion,
+		}
+		if !reflect.DeepEqual(*usage, expected) {
+			t.Errorf("%s: wrong usage\nWant %#v\nGot  %#v", test.testCase, expected, *usage)
+		}
 	}
-	return err
 }
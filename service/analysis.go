@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/playlist"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// presignedURLExpiration is the validity of the URLs used by ffmpeg for
+// reading files from S3.
+const presignedURLExpiration = time.Hour
+
+// defaultFingerprintInterval is the time between the frames hashed in
+// fingerprints, in seconds, when the configuration doesn't define one.
+const defaultFingerprintInterval = 2
+
+// mediaAnalyzer runs the ffmpeg passes of the API over sources and outputs
+// of jobs.
+type mediaAnalyzer struct {
+	detectPadding func(input string, black, silence bool) (*ffmpeg.Padding, error)
+	fingerprint   func(input string, interval float64) ([]string, error)
+	analyzeAudio  func(input string) (*ffmpeg.AudioAnalysis, error)
+	analyzeFlash  func(input string) (*ffmpeg.FlashAnalysis, error)
+	analyzeVideo  func(input string, blockiness float64) (*ffmpeg.VideoAnalysis, error)
+	extractFrames func(input string, count int) ([]ffmpeg.Frame, error)
+	probe         func(input string) (*ffmpeg.MediaInfo, error)
+	interval      float64
+	presign       func(bucket, key string) (string, error)
+	upload        func(bucket, key, contentType string, data []byte) error
+	remove        func(bucket, key string) error
+}
+
+func newMediaAnalyzer(cfg *config.Analysis) *mediaAnalyzer {
+	if cfg == nil {
+		cfg = &config.Analysis{}
+	}
+	awsConfig := aws.NewConfig().WithRegion(cfg.Region)
+	if cfg.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+	}
+	client := s3.New(session.New(awsConfig))
+	runner := ffmpeg.NewRunner(cfg.FFmpegPath)
+	interval := cfg.FingerprintInterval
+	if interval <= 0 {
+		interval = defaultFingerprintInterval
+	}
+	return &mediaAnalyzer{
+		detectPadding: runner.DetectPadding,
+		fingerprint:   runner.Fingerprint,
+		analyzeAudio:  runner.AnalyzeAudio,
+		analyzeFlash:  runner.AnalyzeFlashes,
+		analyzeVideo:  runner.AnalyzeVideo,
+		extractFrames: runner.ExtractFrames,
+		probe:         runner.Probe,
+		interval:      interval,
+		presign: func(bucket, key string) (string, error) {
+			req, _ := client.GetObjectRequest(&s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+			return req.Presign(presignedURLExpiration)
+		},
+		upload: func(bucket, key, contentType string, data []byte) error {
+			_, err := client.PutObject(&s3.PutObjectInput{
+				Bucket:      aws.String(bucket),
+				Key:         aws.String(key),
+				ContentType: aws.String(contentType),
+				Body:        bytes.NewReader(data),
+			})
+			return err
+		},
+		remove: func(bucket, key string) error {
+			_, err := client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+			return err
+		},
+	}
+}
+
+// analysisRuns tracks the analyses of the outputs of jobs that run in the
+// background, keyed by the id of the job.
+type analysisRuns struct {
+	mtx    sync.Mutex
+	active map[string]*analysisRun
+}
+
+type analysisRun struct {
+	done   bool
+	result interface{}
+	err    error
+}
+
+func newAnalysisRuns() *analysisRuns {
+	return &analysisRuns{active: make(map[string]*analysisRun)}
+}
+
+// poll starts the analysis of the given job in the background, unless it's
+// already running, and returns whether it's done, along with its result.
+// Finished analyses are forgotten once they're polled.
+func (r *analysisRuns) poll(jobID string, analyze func() (interface{}, error)) (bool, interface{}, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	run, ok := r.active[jobID]
+	if !ok {
+		run = &analysisRun{}
+		r.active[jobID] = run
+		go func() {
+			result, err := analyze()
+			r.mtx.Lock()
+			run.done, run.result, run.err = true, result, err
+			r.mtx.Unlock()
+		}()
+	}
+	if run.done {
+		delete(r.active, jobID)
+	}
+	return run.done, run.result, run.err
+}
+
+// input returns the URL that ffmpeg reads the given file from. Files in S3
+// are read through presigned URLs.
+func (a *mediaAnalyzer) input(path string) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "s3" {
+		return path, nil
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return "", fmt.Errorf("invalid S3 URL %q", path)
+	}
+	return a.presign(u.Host, key)
+}
+
+// fetcher returns a Fetcher that retrieves manifests with the given client.
+// Files in S3 are read through presigned URLs.
+func (a *mediaAnalyzer) fetcher(client *http.Client) playlist.Fetcher {
+	fetch := playlist.HTTPFetcher(client)
+	return func(rawURL string) ([]byte, error) {
+		input, err := a.input(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		return fetch(input)
+	}
+}
+
+// timeRanges converts the intervals found by ffmpeg to the ranges reported
+// in jobs.
+func timeRanges(intervals []ffmpeg.Interval) []db.TimeRange {
+	var ranges []db.TimeRange
+	for _, interval := range intervals {
+		ranges = append(ranges, db.TimeRange{Start: interval.Start, End: interval.End})
+	}
+	return ranges
+}
@@ -0,0 +1,35 @@
+package service
+
+import "testing"
+
+func TestMediaAnalyzerInput(t *testing.T) {
+	analyzer := mediaAnalyzer{
+		presign: func(bucket, key string) (string, error) {
+			return "https://" + bucket + ".s3.amazonaws.com/" + key + "?X-Amz-Signature=abc", nil
+		},
+	}
+	var tests = []struct {
+		source   string
+		expected string
+		errMsg   string
+	}{
+		{"s3://wire-bucket/2016/story.mp4", "https://wire-bucket.s3.amazonaws.com/2016/story.mp4?X-Amz-Signature=abc", ""},
+		{"https://example.com/story.mp4", "https://example.com/story.mp4", ""},
+		{"s3://wire-bucket/", "", `invalid S3 URL "s3://wire-bucket/"`},
+	}
+	for _, test := range tests {
+		input, err := analyzer.input(test.source)
+		if test.errMsg != "" {
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.source, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.source, err)
+		}
+		if input != test.expected {
+			t.Errorf("%s: wrong input. Want %q. Got %q", test.source, test.expected, input)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route POST /jobs/{jobId}/artifacts jobs newArtifact
+//
+// Attaches an artifact (QC reports, ffprobe dumps, etc.) to a job.
+//
+//     Responses:
+//       200: artifact
+//       400: invalidArtifact
+//       404: jobNotFound
+//       409: artifactAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newArtifact(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newArtifactInput
+	artifact, err := input.Artifact(web.Vars(r), r.Body)
+	if err != nil {
+		return newInvalidArtifactResponse(err)
+	}
+	_, err = s.db.GetJob(artifact.JobID)
+	if err != nil {
+		if err == db.ErrJobNotFound {
+			return newJobNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	err = s.db.CreateArtifact(&artifact)
+	switch err {
+	case nil:
+		return newArtifactResponse(&artifact)
+	case db.ErrArtifactAlreadyExists:
+		return newArtifactAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /jobs/{jobId}/artifacts jobs listArtifacts
+//
+// Lists the artifacts attached to a job, including the ones reported by the
+// provider.
+//
+//     Responses:
+//       200: listArtifacts
+//       404: jobNotFound
+//       500: genericError
+func (s *TranscodingService) listArtifacts(r *http.Request) swagger.GizmoJSONResponse {
+	var params listArtifactsInput
+	params.loadParams(web.Vars(r))
+	job, err := s.db.GetJob(params.JobID)
+	if err != nil {
+		if err == db.ErrJobNotFound {
+			return newJobNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	artifacts, err := s.db.ListArtifacts(job.ID)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	providerArtifacts, err := s.providerArtifacts(job)
+	if err != nil {
+		// the artifacts stored in the API are listed even when the
+		// provider is unavailable.
+		s.logger.WithError(err).WithField("jobId", job.ID).Warn("failed to list the artifacts reported by the provider")
+	}
+	return newListArtifactsResponse(append(artifacts, providerArtifacts...))
+}
+
+func (s *TranscodingService) providerArtifacts(job *db.Job) ([]db.Artifact, error) {
+	providerFactory, err := provider.GetProviderFactory(job.ProviderName)
+	if err != nil {
+		return nil, nil
+	}
+	providerObj, err := providerFactory(s.providerConfig(job.Environment))
+	if err != nil {
+		return nil, fmt.Errorf("error initializing provider %q on job id %q: %s", job.ProviderName, job.ID, err)
+	}
+	reporter, ok := providerObj.(provider.ArtifactReporter)
+	if !ok {
+		return nil, nil
+	}
+	artifacts, err := reporter.JobArtifacts(job)
+	if err != nil {
+		return nil, fmt.Errorf("Error with provider %q when trying to retrieve artifacts of job id %q: %s", job.ProviderName, job.ID, err)
+	}
+	return artifacts, nil
+}
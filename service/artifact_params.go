@@ -0,0 +1,124 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// maxInlineArtifactSize is the maximum size of the data stored inline in an
+// artifact. Larger artifacts should be stored in a sidecar bucket and
+// referenced by URL.
+const maxInlineArtifactSize = 64 * 1024
+
+// JSON-encoded artifact returned on the newArtifact operation.
+//
+// swagger:response artifact
+type artifactResponse struct {
+	// in: body
+	Payload *db.Artifact
+
+	baseResponse
+}
+
+// response for the listArtifacts operation.
+//
+// swagger:response listArtifacts
+type listArtifactsResponse struct {
+	// in: body
+	Artifacts []db.Artifact
+
+	baseResponse
+}
+
+// swagger:parameters newArtifact
+type newArtifactInput struct {
+	// in: path
+	// required: true
+	JobID string `json:"jobId"`
+
+	// in: body
+	// required: true
+	Payload db.Artifact
+}
+
+// swagger:parameters listArtifacts
+type listArtifactsInput struct {
+	getTranscodeJobInput
+}
+
+// error returned when the given artifact data is not valid.
+//
+// swagger:response invalidArtifact
+type invalidArtifactResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to attach an artifact using a name that is
+// already in use in the job.
+//
+// swagger:response artifactAlreadyExists
+type artifactAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newArtifactResponse(artifact *db.Artifact) *artifactResponse {
+	return &artifactResponse{
+		baseResponse: baseResponse{
+			payload: artifact,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newListArtifactsResponse(artifacts []db.Artifact) *listArtifactsResponse {
+	return &listArtifactsResponse{
+		baseResponse: baseResponse{
+			payload: artifacts,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newInvalidArtifactResponse(err error) *invalidArtifactResponse {
+	return &invalidArtifactResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidArtifactResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newArtifactAlreadyExistsResponse(err error) *artifactAlreadyExistsResponse {
+	return &artifactAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *artifactAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+// Artifact loads the input from the request path and body, validates it and
+// returns the artifact.
+func (p *newArtifactInput) Artifact(paramsMap map[string]string, body io.Reader) (db.Artifact, error) {
+	p.JobID = paramsMap["jobId"]
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	p.Payload.JobID = p.JobID
+	if p.Payload.Name == "" {
+		return p.Payload, errors.New("missing field name from the request")
+	}
+	if p.Payload.URL == "" && len(p.Payload.Data) == 0 {
+		return p.Payload, errors.New("either url or data must be provided")
+	}
+	if len(p.Payload.Data) > maxInlineArtifactSize {
+		return p.Payload, errors.New("inline data is too large, please store the artifact in a bucket and provide its url")
+	}
+	return p.Payload, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewArtifact(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenJobID       string
+		givenRequestBody string
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"New inline artifact",
+			"job-123",
+			`{"name":"ffprobe","kind":"ffprobe","source":"qc","data":{"format":{"duration":"183.0"}}}`,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"jobId":  "job-123",
+				"name":   "ffprobe",
+				"kind":   "ffprobe",
+				"source": "qc",
+				"data":   map[string]interface{}{"format": map[string]interface{}{"duration": "183.0"}},
+			},
+		},
+		{
+			"New artifact in sidecar bucket",
+			"job-123",
+			`{"name":"loudness-graph","contentType":"image/png","url":"s3://sidecar/job-123/loudness.png"}`,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"jobId":       "job-123",
+				"name":        "loudness-graph",
+				"contentType": "image/png",
+				"url":         "s3://sidecar/job-123/loudness.png",
+			},
+		},
+		{
+			"Duplicate artifact",
+			"job-123",
+			`{"name":"qc-report","data":{"passed":true}}`,
+
+			http.StatusConflict,
+			map[string]interface{}{"error": db.ErrArtifactAlreadyExists.Error()},
+		},
+		{
+			"Artifact without content",
+			"job-123",
+			`{"name":"qc-report"}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "either url or data must be provided"},
+		},
+		{
+			"Job not found",
+			"job-unknown",
+			`{"name":"qc-report","data":{"passed":true}}`,
+
+			http.StatusNotFound,
+			map[string]interface{}{"error": db.ErrJobNotFound.Error()},
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake"})
+		fakeDB.CreateArtifact(&db.Artifact{JobID: "job-123", Name: "qc-report", Data: json.RawMessage(`{"passed":true}`)})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/jobs/"+test.givenJobID+"/artifacts", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		delete(got, "creationTime")
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+	}
+}
+
+func TestListArtifacts(t *testing.T) {
+	tests := []struct {
+		givenTestCase string
+		givenJobID    string
+
+		wantCode  int
+		wantNames []string
+	}{
+		{
+			"Job with artifacts",
+			"job-123",
+			http.StatusOK,
+			[]string{"qc-report", "ffprobe"},
+		},
+		{
+			"Job without artifacts",
+			"job-456",
+			http.StatusOK,
+			[]string{},
+		},
+		{
+			"Job of a provider that can't be initialized",
+			"job-789",
+			http.StatusOK,
+			[]string{"qc-report"},
+		},
+		{
+			"Job not found",
+			"job-unknown",
+			http.StatusNotFound,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake"})
+		fakeDB.CreateJob(&db.Job{ID: "job-456", ProviderName: "fake"})
+		fakeDB.CreateJob(&db.Job{ID: "job-789", ProviderName: "chaos"})
+		fakeDB.CreateArtifact(&db.Artifact{JobID: "job-123", Name: "qc-report", Data: json.RawMessage(`{"passed":true}`)})
+		fakeDB.CreateArtifact(&db.Artifact{JobID: "job-123", Name: "ffprobe", URL: "s3://sidecar/job-123/ffprobe.json"})
+		fakeDB.CreateArtifact(&db.Artifact{JobID: "job-789", Name: "qc-report", Data: json.RawMessage(`{"passed":true}`)})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("GET", "/jobs/"+test.givenJobID+"/artifacts", nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantCode == http.StatusOK {
+			var artifacts []db.Artifact
+			err = json.NewDecoder(w.Body).Decode(&artifacts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, len(artifacts))
+			for i, artifact := range artifacts {
+				names[i] = artifact.Name
+			}
+			if !reflect.DeepEqual(names, test.wantNames) {
+				t.Errorf("%s: wrong artifacts returned. Want %#v. Got %#v", test.givenTestCase, test.wantNames, names)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	audioQCArtifactKind = "audio-qc"
+
+	// audioQCSource is the source of the reports computed by the API.
+	audioQCSource = "ffmpeg-ebur128"
+
+	defaultLoudnessTolerance = 1
+)
+
+// audioQCContainers are the containers of the outputs with audio that are
+// checked. Playlists, captions and images are skipped.
+var audioQCContainers = map[string]bool{
+	"mp4": true, "mov": true, "m4v": true, "m4a": true, "mp3": true, "aac": true,
+	"webm": true, "mkv": true, "ts": true, "ogg": true, "wav": true,
+}
+
+// audioQC returns the audio QC settings recorded in new jobs.
+func (p *AudioQCParams) audioQC() *db.AudioQC {
+	if p == nil {
+		return nil
+	}
+	qc := db.AudioQC{
+		LoudnessTarget:    p.LoudnessTarget,
+		LoudnessTolerance: p.LoudnessTolerance,
+		MaxTruePeak:       p.MaxTruePeak,
+		MaxSilence:        p.MaxSilence,
+		RejectClipping:    p.RejectClipping,
+	}
+	if qc.LoudnessTarget != 0 && qc.LoudnessTolerance == 0 {
+		qc.LoudnessTolerance = defaultLoudnessTolerance
+	}
+	return &qc
+}
+
+// checkAudio checks the audio of the outputs of the given job once it
+// finishes, using the reports of the provider when it checks the audio
+// itself. Reports are attached to the job as artifacts, and the job is
+// reported as started until the check is done, and as failed when the
+// outputs violate the thresholds.
+func (s *TranscodingService) checkAudio(job *db.Job, p provider.TranscodingProvider, status *provider.JobStatus) {
+	qc := job.AudioQC
+	if qc == nil || status.Status != provider.StatusFinished {
+		return
+	}
+	if !qc.Checked {
+		jobCopy := *job
+		files := status.Output.Files
+		done, result, err := s.audioQCRuns.poll(job.ID, func() (interface{}, error) {
+			if reporter, ok := p.(provider.AudioQCReporter); ok {
+				return reporter.AudioQC(&jobCopy)
+			}
+			return s.analyzeAudio(files)
+		})
+		if !done {
+			status.Status = provider.StatusStarted
+			status.StatusMessage = "checking the audio of the outputs"
+			return
+		}
+		if err != nil {
+			status.Status = provider.StatusFailed
+			status.StatusMessage = "failed to check the audio of the outputs: " + err.Error()
+			return
+		}
+		source := audioQCSource
+		if _, ok := p.(provider.AudioQCReporter); ok {
+			source = job.ProviderName
+		}
+		reports := result.([]db.AudioQCReport)
+		for _, report := range reports {
+			if err = s.db.CreateArtifact(audioQCArtifact(job.ID, source, report)); err != nil && err != db.ErrArtifactAlreadyExists {
+				s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to attach audio QC report")
+			}
+		}
+		qc.Checked = true
+		qc.Violations = audioQCViolations(qc, reports)
+	}
+	if len(qc.Violations) > 0 {
+		status.Status = provider.StatusFailed
+		status.StatusMessage = "audio QC failed: " + strings.Join(qc.Violations, "; ")
+	}
+}
+
+// analyzeAudio checks the audio of the given files with ffmpeg, one at a
+// time.
+func (s *TranscodingService) analyzeAudio(files []provider.OutputFile) ([]db.AudioQCReport, error) {
+	var reports []db.AudioQCReport
+	for _, file := range files {
+		if !audioQCContainers[strings.ToLower(file.Container)] {
+			continue
+		}
+		input, err := s.analyzer.input(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		analysis, err := s.analyzer.analyzeAudio(input)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+		reports = append(reports, db.AudioQCReport{
+			Path:               file.Path,
+			IntegratedLoudness: analysis.IntegratedLoudness,
+			LoudnessRange:      analysis.LoudnessRange,
+			TruePeak:           analysis.TruePeak,
+			ClippedSamples:     analysis.ClippedSamples,
+			Silence:            timeRanges(analysis.Silence),
+		})
+	}
+	return reports, nil
+}
+
+// audioQCViolations returns the thresholds violated by the given reports.
+func audioQCViolations(qc *db.AudioQC, reports []db.AudioQCReport) []string {
+	var violations []string
+	for _, report := range reports {
+		name := path.Base(report.Path)
+		if qc.LoudnessTarget != 0 && math.Abs(report.IntegratedLoudness-qc.LoudnessTarget) > qc.LoudnessTolerance {
+			violations = append(violations, fmt.Sprintf("%s: integrated loudness of %.1f LUFS is off the target of %.1f LUFS", name, report.IntegratedLoudness, qc.LoudnessTarget))
+		}
+		if qc.MaxTruePeak != nil && report.TruePeak > *qc.MaxTruePeak {
+			violations = append(violations, fmt.Sprintf("%s: true peak of %.1f dBTP is above %.1f dBTP", name, report.TruePeak, *qc.MaxTruePeak))
+		}
+		if qc.MaxSilence > 0 {
+			for _, silence := range report.Silence {
+				if silence.End-silence.Start > qc.MaxSilence {
+					violations = append(violations, fmt.Sprintf("%s: silence of %.1fs at %.1fs", name, silence.End-silence.Start, silence.Start))
+				}
+			}
+		}
+		if qc.RejectClipping && report.ClippedSamples > 0 {
+			violations = append(violations, fmt.Sprintf("%s: %d clipped samples", name, report.ClippedSamples))
+		}
+	}
+	return violations
+}
+
+func audioQCArtifact(jobID, source string, report db.AudioQCReport) *db.Artifact {
+	data, _ := json.Marshal(report)
+	return &db.Artifact{
+		JobID:        jobID,
+		Name:         audioQCArtifactKind + "-" + path.Base(report.Path),
+		Kind:         audioQCArtifactKind,
+		Source:       source,
+		ContentType:  "application/json",
+		Data:         data,
+		CreationTime: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestCheckAudio(t *testing.T) {
+	maxTruePeak := -1.0
+	var tests = []struct {
+		testCase       string
+		givenQC        db.AudioQC
+		givenAnalysis  ffmpeg.AudioAnalysis
+		givenError     error
+		wantStatus     provider.Status
+		wantMessage    string
+		wantViolations []string
+		wantArtifacts  int
+	}{
+		{
+			"audio within the thresholds",
+			db.AudioQC{LoudnessTarget: -23, LoudnessTolerance: 1, MaxTruePeak: &maxTruePeak, MaxSilence: 2, RejectClipping: true},
+			ffmpeg.AudioAnalysis{IntegratedLoudness: -23.4, TruePeak: -2, Silence: []ffmpeg.Interval{{Start: 0, End: 1.5}}},
+			nil,
+			provider.StatusFinished,
+			"",
+			nil,
+			1,
+		},
+		{
+			"audio violating the thresholds",
+			db.AudioQC{LoudnessTarget: -23, LoudnessTolerance: 1, MaxTruePeak: &maxTruePeak, MaxSilence: 2, RejectClipping: true},
+			ffmpeg.AudioAnalysis{IntegratedLoudness: -16, TruePeak: 0.5, ClippedSamples: 12, Silence: []ffmpeg.Interval{{Start: 10, End: 15}}},
+			nil,
+			provider.StatusFailed,
+			"audio QC failed: video.mp4: integrated loudness of -16.0 LUFS is off the target of -23.0 LUFS; video.mp4: true peak of 0.5 dBTP is above -1.0 dBTP; video.mp4: silence of 5.0s at 10.0s; video.mp4: 12 clipped samples",
+			[]string{
+				"video.mp4: integrated loudness of -16.0 LUFS is off the target of -23.0 LUFS",
+				"video.mp4: true peak of 0.5 dBTP is above -1.0 dBTP",
+				"video.mp4: silence of 5.0s at 10.0s",
+				"video.mp4: 12 clipped samples",
+			},
+			1,
+		},
+		{
+			"failed analysis",
+			db.AudioQC{LoudnessTarget: -23, LoudnessTolerance: 1},
+			ffmpeg.AudioAnalysis{},
+			errors.New("no audio stream"),
+			provider.StatusFailed,
+			"failed to check the audio of the outputs: s3://bucket/job-123/video.mp4: no audio stream",
+			nil,
+			0,
+		},
+	}
+	for _, test := range tests {
+		analysis, analysisErr := test.givenAnalysis, test.givenError
+		fakeDB := dbtest.NewFakeRepository(false)
+		service := TranscodingService{
+			db:          fakeDB,
+			logger:      logrus.New(),
+			audioQCRuns: newAnalysisRuns(),
+			analyzer: &mediaAnalyzer{
+				presign: func(bucket, key string) (string, error) {
+					return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+				},
+				analyzeAudio: func(string) (*ffmpeg.AudioAnalysis, error) {
+					if analysisErr != nil {
+						return nil, analysisErr
+					}
+					return &analysis, nil
+				},
+			},
+		}
+		qc := test.givenQC
+		job := db.Job{ID: "job-123", ProviderName: "fake", AudioQC: &qc}
+		var status provider.JobStatus
+		for i := 0; i < 100; i++ {
+			status = provider.JobStatus{
+				Status: provider.StatusFinished,
+				Output: provider.JobOutput{
+					Files: []provider.OutputFile{
+						{Path: "s3://bucket/job-123/video.mp4", Container: "mp4"},
+						{Path: "s3://bucket/job-123/hls/index.m3u8", Container: "m3u8"},
+					},
+				},
+			}
+			service.checkAudio(&job, &fakeProvider{}, &status)
+			if status.Status != provider.StatusStarted {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if status.Status != test.wantStatus || status.StatusMessage != test.wantMessage {
+			t.Errorf("%s: wrong status. Want %q (%q). Got %q (%q)", test.testCase, test.wantStatus, test.wantMessage, status.Status, status.StatusMessage)
+		}
+		if !reflect.DeepEqual(job.AudioQC.Violations, test.wantViolations) {
+			t.Errorf("%s: wrong violations.\nWant %#v\nGot  %#v", test.testCase, test.wantViolations, job.AudioQC.Violations)
+		}
+		artifacts, _ := fakeDB.ListArtifacts(job.ID)
+		if len(artifacts) != test.wantArtifacts {
+			t.Errorf("%s: wrong number of artifacts. Want %d. Got %#v", test.testCase, test.wantArtifacts, artifacts)
+		}
+		for _, artifact := range artifacts {
+			if artifact.Name != "audio-qc-video.mp4" || artifact.Kind != "audio-qc" || artifact.Source != "ffmpeg-ebur128" {
+				t.Errorf("%s: wrong artifact: %#v", test.testCase, artifact)
+			}
+		}
+		if _, ok := service.audioQCRuns.active[job.ID]; ok {
+			t.Errorf("%s: the run wasn't removed after finishing", test.testCase)
+		}
+	}
+}
+
+func TestAudioQCParams(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		params   *AudioQCParams
+		want     *db.AudioQC
+	}{
+		{"no audio QC", nil, nil},
+		{"default tolerance", &AudioQCParams{LoudnessTarget: -24}, &db.AudioQC{LoudnessTarget: -24, LoudnessTolerance: 1}},
+		{"custom tolerance", &AudioQCParams{LoudnessTarget: -24, LoudnessTolerance: 2}, &db.AudioQC{LoudnessTarget: -24, LoudnessTolerance: 2}},
+		{"no loudness target", &AudioQCParams{RejectClipping: true}, &db.AudioQC{RejectClipping: true}},
+	}
+	for _, test := range tests {
+		got := test.params.audioQC()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: wrong audio QC. Want %#v. Got %#v", test.testCase, test.want, got)
+		}
+	}
+}
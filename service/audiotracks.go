@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// validateAudioTracks checks the audio tracks of the source selected in a
+// job. Several tracks are delivered as alternate audio renditions, so they
+// require an adaptive streaming protocol, and each track can only be
+// delivered once.
+func validateAudioTracks(tracks []db.AudioTrack, protocol provider.Protocol) error {
+	if len(tracks) > 1 && protocol != provider.ProtocolHLS && protocol != provider.ProtocolDASH {
+		return errors.New("multiple audioTracks are only supported in hls and dash jobs")
+	}
+	seen := make(map[int]bool, len(tracks))
+	defaults := 0
+	for _, track := range tracks {
+		if track.Track < 1 {
+			return fmt.Errorf("invalid audio track %d, tracks start at 1", track.Track)
+		}
+		if seen[track.Track] {
+			return fmt.Errorf("duplicate audio track %d", track.Track)
+		}
+		seen[track.Track] = true
+		if track.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return errors.New("only one of the audioTracks can be the default")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func TestValidateAudioTracks(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		tracks   []db.AudioTrack
+		protocol provider.Protocol
+		errMsg   string
+	}{
+		{"no tracks", nil, "", ""},
+		{"single track in mp4 jobs", []db.AudioTrack{{Track: 2}}, "", ""},
+		{"multiple tracks in hls jobs", []db.AudioTrack{{Track: 1}, {Track: 2, Default: true}}, provider.ProtocolHLS, ""},
+		{"multiple tracks in dash jobs", []db.AudioTrack{{Track: 1}, {Track: 3}}, provider.ProtocolDASH, ""},
+		{
+			"multiple tracks in mp4 jobs",
+			[]db.AudioTrack{{Track: 1}, {Track: 2}},
+			"",
+			"multiple audioTracks are only supported in hls and dash jobs",
+		},
+		{"invalid track", []db.AudioTrack{{Track: 0}}, "", "invalid audio track 0, tracks start at 1"},
+		{"duplicate track", []db.AudioTrack{{Track: 1}, {Track: 1}}, provider.ProtocolHLS, "duplicate audio track 1"},
+		{
+			"multiple default tracks",
+			[]db.AudioTrack{{Track: 1, Default: true}, {Track: 2, Default: true}},
+			provider.ProtocolHLS,
+			"only one of the audioTracks can be the default",
+		},
+	}
+	for _, test := range tests {
+		err := validateAudioTracks(test.tracks, test.protocol)
+		if test.errMsg == "" && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+		}
+		if test.errMsg != "" && (err == nil || err.Error() != test.errMsg) {
+			t.Errorf("%s: wrong error returned.\nWant %q\nGot  %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+const defaultRetryAfter = 30
+
+var errServiceOverloaded = errors.New("too many jobs being submitted, please retry later")
+
+// submissionQueue limits the number of concurrent submissions of jobs to
+// providers. Jobs accepted while the limit is reached are queued, and
+// submitted in order of priority (and in order of arrival within the same
+// priority) as soon as there's capacity.
+type submissionQueue struct {
+	mtx         sync.Mutex
+	maxInFlight int
+	maxQueued   int
+	inFlight    int
+	pending     []queuedJob
+	queued      map[string]bool
+	dispatch    func(jobID string)
+}
+
+type queuedJob struct {
+	id   string
+	rank int
+}
+
+func newSubmissionQueue(cfg *config.Backpressure) *submissionQueue {
+	q := submissionQueue{queued: make(map[string]bool)}
+	if cfg != nil {
+		q.maxInFlight = cfg.MaxInFlight
+		q.maxQueued = cfg.MaxQueued
+	}
+	return &q
+}
+
+// acquire reserves a slot for submitting a job, returning false if there
+// are no slots available. Slots must be freed with release.
+func (q *submissionQueue) acquire() bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.maxInFlight > 0 && (q.inFlight >= q.maxInFlight || len(q.pending) > 0) {
+		return false
+	}
+	q.inFlight++
+	return true
+}
+
+// release frees a slot reserved with acquire, submitting the next queued job.
+func (q *submissionQueue) release() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.inFlight--
+	q.next()
+}
+
+// enqueue adds the job with the given id and priority to the queue, after
+// the jobs with the same or higher priority, returning false if the queue is
+// full.
+func (q *submissionQueue) enqueue(jobID, priority string) bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.queued[jobID] {
+		return true
+	}
+	if q.maxQueued > 0 && len(q.pending) >= q.maxQueued {
+		return false
+	}
+	job := queuedJob{id: jobID, rank: priorityRank(priority)}
+	i := len(q.pending)
+	for i > 0 && q.pending[i-1].rank > job.rank {
+		i--
+	}
+	q.pending = append(q.pending, queuedJob{})
+	copy(q.pending[i+1:], q.pending[i:])
+	q.pending[i] = job
+	q.queued[jobID] = true
+	q.next()
+	return true
+}
+
+// remove removes the job with the given id from the queue, returning false
+// if the job isn't waiting in the queue.
+func (q *submissionQueue) remove(jobID string) bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	for i, job := range q.pending {
+		if job.id == jobID {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			delete(q.queued, jobID)
+			return true
+		}
+	}
+	return false
+}
+
+// has returns whether the job with the given id is waiting in the queue or
+// being submitted.
+func (q *submissionQueue) has(jobID string) bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.queued[jobID]
+}
+
+// next starts submitting queued jobs while there are slots available. It
+// must be called with the lock held.
+func (q *submissionQueue) next() {
+	for len(q.pending) > 0 && (q.maxInFlight <= 0 || q.inFlight < q.maxInFlight) {
+		jobID := q.pending[0].id
+		q.pending = q.pending[1:]
+		q.inFlight++
+		go func() {
+			q.dispatch(jobID)
+			q.mtx.Lock()
+			delete(q.queued, jobID)
+			q.mtx.Unlock()
+			q.release()
+		}()
+	}
+}
+
+// queueJob records the given job and queues it for submission.
+func (s *TranscodingService) queueJob(job *db.Job) swagger.GizmoJSONResponse {
+	job.Status = string(provider.StatusQueuedLocally)
+	if err := s.db.CreateJob(job); err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	if !s.submissions.enqueue(job.ID, job.Priority) {
+		s.db.DeleteJob(job)
+		return newServiceOverloadedResponse(errServiceOverloaded)
+	}
+	s.notifyAsync(job, localJobStatus(job))
+	return newQueuedJobResponse(job)
+}
+
+// submitQueuedJob submits a job that was queued locally to its provider.
+// The job is claimed before being submitted, as other instances of the API
+// may have queued it as well.
+func (s *TranscodingService) submitQueuedJob(jobID string) {
+	queued, err := s.db.GetJob(jobID)
+	if err != nil {
+		s.logger.WithError(err).WithField("jobId", jobID).Error("failed to load queued job")
+		return
+	}
+	if queued.Status != string(provider.StatusQueuedLocally) {
+		return
+	}
+	paused, err := s.isPaused(queued.ProviderName)
+	if err != nil {
+		s.logger.WithError(err).WithField("jobId", jobID).Error("failed to load submission pauses")
+		return
+	}
+	job := *queued
+	job.Status = string(provider.StatusSubmitting)
+	if paused {
+		job.Status = string(provider.StatusPaused)
+	}
+	if err = s.db.UpdateJobIfStatus(&job, string(provider.StatusQueuedLocally)); err != nil {
+		if err != db.ErrJobStatusChanged {
+			s.logger.WithError(err).WithField("jobId", jobID).Error("failed to claim queued job")
+		}
+		return
+	}
+	if !paused {
+		if err = s.submitJob(&job); err != nil {
+			s.logger.WithError(err).WithField("jobId", jobID).Error("failed to submit queued job")
+			job.Status = string(provider.StatusFailed)
+			job.StatusMessage = err.Error()
+		}
+		if err = s.db.UpdateJob(&job); err != nil {
+			s.logger.WithError(err).WithField("jobId", jobID).Error("failed to update queued job")
+			return
+		}
+	}
+	if job.CallbackURL != "" {
+		if err = s.notify(&job, localJobStatus(&job)); err != nil {
+			s.logger.WithError(err).WithField("jobId", jobID).Error("failed to notify job")
+		}
+	}
+}
+
+func (s *TranscodingService) submitJob(job *db.Job) error {
+	providerObj, err := s.initEnvironmentProvider(job.ProviderName, job.Environment)
+	if err != nil {
+		return err
+	}
+	transcodeProfile, err := s.transcodeProfile(job, job.SourceMedia)
+	if err != nil {
+		return err
+	}
+	if err = s.prepareSource(job, providerObj, &transcodeProfile); err != nil {
+		return err
+	}
+	jobStatus, err := s.submit(job, providerObj, transcodeProfile)
+	if err != nil {
+		return err
+	}
+	job.ProviderJobID = jobStatus.ProviderJobID
+	job.Status = string(jobStatus.Status)
+	job.StatusMessage = ""
+	return nil
+}
+
+// localJobStatus returns the status of a job that was never submitted to
+// the provider.
+func localJobStatus(job *db.Job) *provider.JobStatus {
+	status := provider.JobStatus{
+		Status:        provider.Status(job.Status),
+		StatusMessage: job.StatusMessage,
+		ProviderName:  job.ProviderName,
+	}
+	status.Links = jobLinks(job, &status)
+	return &status
+}
+
+// prefersAsync returns whether the client accepts having the request
+// processed asynchronously, as in RFC 7240.
+func prefersAsync(r *http.Request) bool {
+	for _, value := range r.Header["Prefer"] {
+		for _, preference := range strings.Split(value, ",") {
+			if strings.TrimSpace(preference) == "respond-async" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// retryAfterWriter adds the Retry-After header to 503 responses.
+type retryAfterWriter struct {
+	http.ResponseWriter
+	retryAfter string
+}
+
+func (w *retryAfterWriter) WriteHeader(code int) {
+	if code == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+		w.Header().Set("Retry-After", w.retryAfter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (s *TranscodingService) retryAfterHandler(h http.Handler) http.Handler {
+	retryAfter := defaultRetryAfter
+	if s.config.Backpressure != nil && s.config.Backpressure.RetryAfter > 0 {
+		retryAfter = s.config.Backpressure.RetryAfter
+	}
+	value := strconv.Itoa(retryAfter)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(&retryAfterWriter{ResponseWriter: w, retryAfter: value}, r)
+	})
+}
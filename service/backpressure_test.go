@@ -0,0 +1,217 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestSubmissionQueue(t *testing.T) {
+	dispatched := make(chan string, 2)
+	queue := newSubmissionQueue(&config.Backpressure{MaxInFlight: 1, MaxQueued: 1})
+	queue.dispatch = func(jobID string) { dispatched <- jobID }
+	if !queue.acquire() {
+		t.Fatal("should acquire a slot in an empty queue")
+	}
+	if queue.acquire() {
+		t.Error("should not acquire more slots than the limit")
+	}
+	if !queue.enqueue("job-1", "") {
+		t.Error("should queue jobs while the queue isn't full")
+	}
+	if queue.enqueue("job-2", "") {
+		t.Error("should not queue jobs when the queue is full")
+	}
+	if !queue.has("job-1") {
+		t.Error("should report queued jobs")
+	}
+	queue.release()
+	select {
+	case jobID := <-dispatched:
+		if jobID != "job-1" {
+			t.Errorf("wrong job dispatched. Want %q. Got %q", "job-1", jobID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued job was not dispatched after releasing the slot")
+	}
+	if !queue.enqueue("job-3", "") || !queue.remove("job-3") {
+		t.Error("should remove jobs waiting in the queue")
+	}
+	if queue.remove("job-3") {
+		t.Error("should not remove jobs that aren't in the queue")
+	}
+}
+
+func TestSubmissionQueuePriority(t *testing.T) {
+	dispatched := make(chan string, 4)
+	queue := newSubmissionQueue(&config.Backpressure{MaxInFlight: 1})
+	queue.dispatch = func(jobID string) { dispatched <- jobID }
+	if !queue.acquire() {
+		t.Fatal("should acquire a slot in an empty queue")
+	}
+	queue.enqueue("job-low", "low")
+	queue.enqueue("job-normal-1", "")
+	queue.enqueue("job-high", "high")
+	queue.enqueue("job-normal-2", "normal")
+	queue.release()
+	expected := []string{"job-high", "job-normal-1", "job-normal-2", "job-low"}
+	var got []string
+	for len(got) < len(expected) {
+		select {
+		case jobID := <-dispatched:
+			got = append(got, jobID)
+		case <-time.After(time.Second):
+			t.Fatalf("queued jobs were not dispatched, got %#v", got)
+		}
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong dispatch order. Want %#v. Got %#v", expected, got)
+	}
+}
+
+func TestTranscodeBackpressure(t *testing.T) {
+	body := `{"source":"http://another.non.existent/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p","fileName":"video.mp4"}]}`
+	tests := []struct {
+		givenTestCase string
+		givenPrefer   string
+
+		wantCode       int
+		wantRetryAfter string
+		wantStatus     interface{}
+	}{
+		{
+			"overloaded",
+			"",
+
+			http.StatusServiceUnavailable,
+			"60",
+			nil,
+		},
+		{
+			"overloaded with asynchronous processing",
+			"respond-async",
+
+			http.StatusAccepted,
+			"",
+			"queued-locally",
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service, err := NewTranscodingService(&config.Config{
+			Backpressure: &config.Backpressure{MaxInFlight: 1, MaxQueued: 10, RetryAfter: 60},
+		}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		dispatched := make(chan string, 1)
+		dispatch := service.submissions.dispatch
+		service.submissions.dispatch = func(jobID string) {
+			dispatch(jobID)
+			dispatched <- jobID
+		}
+		srvr.Register(service)
+		service.submissions.acquire()
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		if test.givenPrefer != "" {
+			r.Header.Set("Prefer", test.givenPrefer)
+		}
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter != test.wantRetryAfter {
+			t.Errorf("%s: wrong Retry-After header. Want %q. Got %q", test.givenTestCase, test.wantRetryAfter, retryAfter)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got["status"], test.wantStatus) {
+			t.Errorf("%s: wrong status. Want %#v. Got %#v", test.givenTestCase, test.wantStatus, got["status"])
+		}
+		service.submissions.release()
+		if test.wantStatus == nil {
+			continue
+		}
+		select {
+		case <-dispatched:
+		case <-time.After(time.Second):
+			t.Errorf("%s: queued job was not submitted", test.givenTestCase)
+			continue
+		}
+		job, err := fakeDB.GetJob(got["jobId"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.ProviderJobID != "provider-preset-job-123" || job.Status != string(provider.StatusFinished) {
+			t.Errorf("%s: job not submitted to the provider: %#v", test.givenTestCase, job)
+		}
+	}
+}
+
+// staleJobRepository returns jobs as they were before being claimed by
+// another instance of the API.
+type staleJobRepository struct {
+	db.Repository
+}
+
+func (r staleJobRepository) GetJob(id string) (*db.Job, error) {
+	job, err := r.Repository.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	stale := *job
+	stale.Status = string(provider.StatusQueuedLocally)
+	return &stale, nil
+}
+
+func TestSubmitQueuedJobClaimed(t *testing.T) {
+	fprovider.jobs = nil
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "18828"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	fakeDB.CreateJob(&db.Job{
+		ID:           "job-123",
+		ProviderName: "fake",
+		Status:       string(provider.StatusSubmitting),
+		SourceMedia:  "http://another.non.existent/video.mp4",
+		Outputs:      []db.TranscodeOutput{{Preset: "mp4_1080p", FileName: "video.mp4"}},
+	})
+	service.db = staleJobRepository{fakeDB}
+	service.submitQueuedJob("job-123")
+	if len(fprovider.jobs) != 0 {
+		t.Errorf("job claimed by another instance was submitted: %#v", fprovider.jobs)
+	}
+	job, _ := fakeDB.GetJob("job-123")
+	if job.Status != string(provider.StatusSubmitting) {
+		t.Errorf("wrong status. Want %q. Got %q", provider.StatusSubmitting, job.Status)
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// billingTenantTag is the billing tag holding the tenant of the job.
+const billingTenantTag = "tenant"
+
+// billingTags returns the tags attached to the job in the provider, for
+// reconciling the invoices of the provider against the cost reports of the
+// API: the tenant of the job, and the labels of the job in the key=value
+// form. Other labels are skipped.
+func billingTags(job *db.Job) map[string]string {
+	tags := make(map[string]string)
+	for _, label := range job.Labels {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) == 2 && parts[0] != "" {
+			tags[parts[0]] = parts[1]
+		}
+	}
+	if job.Tenant != "" {
+		tags[billingTenantTag] = job.Tenant
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
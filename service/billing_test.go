@@ -0,0 +1,35 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestBillingTags(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		job      db.Job
+		expected map[string]string
+	}{
+		{"no tenant nor labels", db.Job{ID: "job-123"}, nil},
+		{"labels without values", db.Job{ID: "job-123", Labels: []string{"partner", "=orphan"}}, nil},
+		{
+			"tenant and labels",
+			db.Job{ID: "job-123", Tenant: "newsroom", Labels: []string{"partner", "costcenter=video", "show=daily=1"}},
+			map[string]string{"tenant": "newsroom", "costcenter": "video", "show": "daily=1"},
+		},
+		{
+			"tenant overriding labels",
+			db.Job{ID: "job-123", Tenant: "newsroom", Labels: []string{"tenant=other"}},
+			map[string]string{"tenant": "newsroom"},
+		},
+	}
+	for _, test := range tests {
+		tags := billingTags(&test.job)
+		if !reflect.DeepEqual(tags, test.expected) {
+			t.Errorf("%s: wrong tags. Want %#v. Got %#v", test.testCase, test.expected, tags)
+		}
+	}
+}
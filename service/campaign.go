@@ -0,0 +1,479 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// campaignLabel is the label of the jobs submitted by campaigns, in the
+// key=value form used by billing tags.
+const campaignLabel = "campaign"
+
+var errNoCampaignJobs = errors.New("no finished jobs match the filter of the campaign")
+
+// swagger:route POST /campaigns campaigns newCampaign
+//
+// Creates a new re-encode campaign. The finished jobs matching the filter
+// of the campaign are resubmitted in the background with the new presets,
+// at the rate defined in the campaign.
+//
+//     Responses:
+//       200: campaign
+//       400: invalidCampaign
+//       409: campaignAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newCampaign(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newCampaignInput
+	campaign, err := input.Campaign(r.Body)
+	if err != nil {
+		return newInvalidCampaignResponse(err)
+	}
+	for _, name := range campaign.Presets {
+		if _, err = s.db.GetPresetMap(name); err != nil {
+			if err == db.ErrPresetMapNotFound {
+				return newInvalidCampaignResponse(fmt.Errorf("presetmap %q not found", name))
+			}
+			return swagger.NewErrorResponse(err)
+		}
+	}
+	jobs, err := s.campaignJobs(&campaign)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	if len(jobs) == 0 {
+		return newInvalidCampaignResponse(errNoCampaignJobs)
+	}
+	campaign.Jobs = make([]db.CampaignJob, len(jobs))
+	for i, job := range jobs {
+		campaign.Jobs[i] = db.CampaignJob{SourceJobID: job.ID}
+		providerName := campaign.Provider
+		if providerName == "" {
+			providerName = job.ProviderName
+		}
+		if cost, ok := s.predictor.cost(providerName, sourceDuration(&job), len(job.Outputs)); ok {
+			campaign.Progress.EstimatedCost += cost
+		}
+	}
+	campaign.Status = db.CampaignStatusRunning
+	campaign.CreationTime = time.Now().UTC()
+	updateCampaignProgress(&campaign)
+	err = s.db.CreateCampaign(&campaign)
+	switch err {
+	case nil:
+		return newCampaignResponse(&campaign)
+	case db.ErrCampaignAlreadyExists:
+		return newCampaignAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /campaigns/{name} campaigns getCampaign
+//
+// Finds a campaign using its name, including the progress and the cost of
+// its jobs.
+//
+//     Responses:
+//       200: campaign
+//       404: campaignNotFound
+//       500: genericError
+func (s *TranscodingService) getCampaign(r *http.Request) swagger.GizmoJSONResponse {
+	var params getCampaignInput
+	params.loadParams(web.Vars(r))
+	campaign, err := s.db.GetCampaign(params.Name)
+	switch err {
+	case nil:
+		return newCampaignResponse(campaign)
+	case db.ErrCampaignNotFound:
+		return newCampaignNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route DELETE /campaigns/{name} campaigns deleteCampaign
+//
+// Deletes a campaign by name. Jobs already submitted by the campaign aren't
+// affected.
+//
+//     Responses:
+//       200: emptyResponse
+//       404: campaignNotFound
+//       500: genericError
+func (s *TranscodingService) deleteCampaign(r *http.Request) swagger.GizmoJSONResponse {
+	var params getCampaignInput
+	params.loadParams(web.Vars(r))
+	err := s.db.DeleteCampaign(&db.Campaign{Name: params.Name})
+	switch err {
+	case nil:
+		return emptyResponse(http.StatusOK)
+	case db.ErrCampaignNotFound:
+		return newCampaignNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /campaigns campaigns listCampaigns
+//
+// List campaigns registered in the API.
+//
+//     Responses:
+//       200: listCampaigns
+//       500: genericError
+func (s *TranscodingService) listCampaigns(r *http.Request) swagger.GizmoJSONResponse {
+	campaigns, err := s.db.ListCampaigns()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListCampaignsResponse(campaigns)
+}
+
+// swagger:route POST /campaigns/{name}/pause campaigns pauseCampaign
+//
+// Pauses a running campaign. Jobs already submitted keep running and are
+// still tracked, but no new jobs are submitted.
+//
+//     Responses:
+//       200: campaign
+//       400: invalidCampaign
+//       404: campaignNotFound
+//       500: genericError
+func (s *TranscodingService) pauseCampaign(r *http.Request) swagger.GizmoJSONResponse {
+	return s.setCampaignStatus(r, db.CampaignStatusRunning, db.CampaignStatusPaused)
+}
+
+// swagger:route POST /campaigns/{name}/resume campaigns resumeCampaign
+//
+// Resumes a paused campaign.
+//
+//     Responses:
+//       200: campaign
+//       400: invalidCampaign
+//       404: campaignNotFound
+//       500: genericError
+func (s *TranscodingService) resumeCampaign(r *http.Request) swagger.GizmoJSONResponse {
+	return s.setCampaignStatus(r, db.CampaignStatusPaused, db.CampaignStatusRunning)
+}
+
+func (s *TranscodingService) setCampaignStatus(r *http.Request, from, to string) swagger.GizmoJSONResponse {
+	var params getCampaignInput
+	params.loadParams(web.Vars(r))
+	campaign, err := s.db.GetCampaign(params.Name)
+	if err != nil {
+		if err == db.ErrCampaignNotFound {
+			return newCampaignNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	if campaign.Status != from {
+		return newInvalidCampaignResponse(fmt.Errorf("campaign %q is %s", campaign.Name, campaign.Status))
+	}
+	campaign.Status = to
+	if err = s.db.UpdateCampaign(campaign); err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newCampaignResponse(campaign)
+}
+
+// campaignJobs returns the finished jobs matching the filter of the
+// campaign, oldest first. Sandbox jobs and jobs without outputs using the
+// presetmaps replaced by the campaign are skipped.
+func (s *TranscodingService) campaignJobs(campaign *db.Campaign) ([]db.Job, error) {
+	filter := campaign.Filter
+	jobs, err := s.db.ListJobs(db.JobFilter{
+		Since:        filter.Since,
+		Until:        filter.Until,
+		Status:       string(provider.StatusFinished),
+		ProviderName: filter.Provider,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byCreationTime(jobs))
+	var selected []db.Job
+	for _, job := range jobs {
+		if job.Environment == db.EnvironmentSandbox {
+			continue
+		}
+		if filter.Tenant != "" && job.Tenant != filter.Tenant {
+			continue
+		}
+		var usesPreset, replaced bool
+		for _, output := range job.Outputs {
+			usesPreset = usesPreset || output.Preset == filter.Preset
+			_, ok := campaign.Presets[output.Preset]
+			replaced = replaced || ok
+		}
+		if !replaced || (filter.Preset != "" && !usesPreset) {
+			continue
+		}
+		selected = append(selected, job)
+		if filter.Limit > 0 && uint(len(selected)) == filter.Limit {
+			break
+		}
+	}
+	return selected, nil
+}
+
+// sourceDuration returns the duration of the source of the job, as stored
+// in its status snapshot, or zero when unknown.
+func sourceDuration(job *db.Job) time.Duration {
+	var status provider.JobStatus
+	if job.StatusSnapshot == "" || json.Unmarshal([]byte(job.StatusSnapshot), &status) != nil {
+		return 0
+	}
+	return status.SourceInfo.Duration
+}
+
+// byCreationTime sorts jobs by their creation time, oldest first.
+type byCreationTime []db.Job
+
+func (jobs byCreationTime) Len() int      { return len(jobs) }
+func (jobs byCreationTime) Swap(i, j int) { jobs[i], jobs[j] = jobs[j], jobs[i] }
+func (jobs byCreationTime) Less(i, j int) bool {
+	return jobs[i].CreationTime.Before(jobs[j].CreationTime)
+}
+
+// RunCampaigns periodically submits the jobs of running campaigns and
+// tracks their progress, until the given channel is closed. It returns
+// immediately when campaigns are disabled in the configuration.
+func (s *TranscodingService) RunCampaigns(stop <-chan struct{}) {
+	cfg := s.config.Campaigns
+	if cfg == nil || cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.runCampaigns(cfg.Interval)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// runCampaigns advances the running campaigns. It doesn't run while the API
+// is in maintenance mode.
+func (s *TranscodingService) runCampaigns(interval time.Duration) {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	campaigns, err := s.db.ListCampaigns()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list campaigns")
+		return
+	}
+	for i := range campaigns {
+		campaign := &campaigns[i]
+		if campaign.Status != db.CampaignStatusRunning {
+			continue
+		}
+		if err = s.advanceCampaign(campaign, interval); err != nil {
+			s.logger.WithError(err).WithField("campaign", campaign.Name).Error("failed to advance campaign")
+		}
+	}
+}
+
+// advanceCampaign refreshes the status of the jobs submitted by the
+// campaign, submits the next jobs allowed by its rate and stores the
+// campaign, which finishes once all of its jobs are done.
+func (s *TranscodingService) advanceCampaign(campaign *db.Campaign, interval time.Duration) error {
+	s.refreshCampaignJobs(campaign)
+	submitErr := s.submitCampaignJobs(campaign, interval, time.Now().UTC())
+	updateCampaignProgress(campaign)
+	if campaign.Progress.Finished+campaign.Progress.Failed == campaign.Progress.Total {
+		campaign.Status = db.CampaignStatusFinished
+	}
+	if err := s.db.UpdateCampaign(campaign); err != nil {
+		return err
+	}
+	return submitErr
+}
+
+// refreshCampaignJobs loads the status of the submitted jobs that aren't
+// done yet, recording the cost of the ones that finished.
+func (s *TranscodingService) refreshCampaignJobs(campaign *db.Campaign) {
+	for i := range campaign.Jobs {
+		campaignJob := &campaign.Jobs[i]
+		if campaignJob.JobID == "" || isTerminal(provider.Status(campaignJob.Status)) {
+			continue
+		}
+		job, status, _, err := s.readTranscodeJob(campaignJob.JobID)
+		if err != nil {
+			s.logger.WithError(err).WithField("jobId", campaignJob.JobID).Error("failed to load status of campaign job")
+			continue
+		}
+		campaignJob.Status = string(status.Status)
+		if status.Status == provider.StatusFinished {
+			campaignJob.Cost, _ = s.predictor.cost(job.ProviderName, status.SourceInfo.Duration, len(job.Outputs))
+		}
+	}
+}
+
+// submitCampaignJobs submits the pending jobs of the campaign allowed by its
+// rate. Jobs rejected by the API are marked as failed, while temporary
+// errors (like an overloaded provider) stop the submission, leaving the
+// remaining jobs for the next run.
+func (s *TranscodingService) submitCampaignJobs(campaign *db.Campaign, interval time.Duration, now time.Time) error {
+	slots := campaignSlots(campaign, interval, now)
+	presetMaps := make(map[string]*db.PresetMap)
+	for i := range campaign.Jobs {
+		if slots == 0 {
+			return nil
+		}
+		campaignJob := &campaign.Jobs[i]
+		if campaignJob.JobID != "" || campaignJob.Error != "" {
+			continue
+		}
+		original, err := s.db.GetJob(campaignJob.SourceJobID)
+		if err == db.ErrJobNotFound {
+			campaignJob.Error = fmt.Sprintf("original job %q not found", campaignJob.SourceJobID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		payload, err := s.campaignJobPayload(campaign, original, presetMaps)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		r, err := internalJobRequest(data)
+		if err != nil {
+			return err
+		}
+		code, result, err := s.newTranscodeJob(r).Result()
+		if err != nil {
+			if code >= http.StatusInternalServerError {
+				return err
+			}
+			campaignJob.Error = err.Error()
+			continue
+		}
+		partial := result.(*PartialJob)
+		campaignJob.JobID = partial.JobID
+		campaignJob.Status = string(partial.Status)
+		if campaignJob.Status == "" {
+			campaignJob.Status = string(provider.StatusQueued)
+		}
+		campaign.LastSubmission = now
+		slots--
+	}
+	return nil
+}
+
+// campaignSlots returns the number of jobs the campaign may submit in the
+// current run. Runs submit at most the jobs allowed in one interval, so
+// campaigns that were paused don't submit bursts of jobs when resumed.
+func campaignSlots(campaign *db.Campaign, interval time.Duration, now time.Time) int {
+	spacing := time.Hour / time.Duration(campaign.JobsPerHour)
+	if spacing <= 0 {
+		spacing = 1
+	}
+	max := int(interval / spacing)
+	if max < 1 {
+		max = 1
+	}
+	if campaign.LastSubmission.IsZero() {
+		return max
+	}
+	if slots := int(now.Sub(campaign.LastSubmission) / spacing); slots < max {
+		return slots
+	}
+	return max
+}
+
+// campaignJobPayload builds the job re-encoding the original job, with the
+// presetmaps of its outputs replaced. The extension of the output files
+// follows the new presetmaps. The new jobs have low priority, so campaigns
+// don't delay other jobs.
+func (s *TranscodingService) campaignJobPayload(campaign *db.Campaign, original *db.Job, presetMaps map[string]*db.PresetMap) (*NewTranscodeJobInputPayload, error) {
+	outputs := make([]db.TranscodeOutput, len(original.Outputs))
+	for i, output := range original.Outputs {
+		outputs[i] = output
+		name, ok := campaign.Presets[output.Preset]
+		if !ok {
+			continue
+		}
+		presetMap, ok := presetMaps[name]
+		if !ok {
+			var err error
+			if presetMap, err = s.db.GetPresetMap(name); err != nil {
+				return nil, err
+			}
+			presetMaps[name] = presetMap
+		}
+		outputs[i].Preset, outputs[i].PresetVersion = name, 0
+		if ext := path.Ext(output.FileName); ext != "" && presetMap.OutputOpts.Extension != "" {
+			outputs[i].FileName = strings.TrimSuffix(output.FileName, ext) + "." + presetMap.OutputOpts.Extension
+		}
+	}
+	providerName := campaign.Provider
+	if providerName == "" {
+		providerName = original.ProviderName
+	}
+	labels := append([]string{}, original.Labels...)
+	return &NewTranscodeJobInputPayload{
+		Source:           original.SourceMedia,
+		SourceChecksum:   original.SourceChecksum,
+		SourceEncryption: original.SourceEncryption,
+		Outputs:          outputs,
+		Provider:         providerName,
+		StreamingParams: provider.StreamingParams{
+			PlaylistFileName:   original.StreamingParams.PlaylistFileName,
+			SegmentDuration:    original.StreamingParams.SegmentDuration,
+			Protocol:           provider.Protocol(original.StreamingParams.Protocol),
+			MinBufferTime:      original.StreamingParams.MinBufferTime,
+			SegmentFormat:      provider.SegmentFormat(original.StreamingParams.SegmentFormat),
+			AudioOnlyRendition: original.StreamingParams.AudioOnlyRendition,
+			AudioOnlyBitrate:   original.StreamingParams.AudioOnlyBitrate,
+		},
+		Tenant:      original.Tenant,
+		Destination: original.Destination,
+		Language:    original.Language,
+		Labels:      append(labels, campaignLabel+"="+campaign.Name),
+		Priority:    db.PriorityLow,
+	}, nil
+}
+
+// updateCampaignProgress recomputes the progress of the campaign from its
+// jobs.
+func updateCampaignProgress(campaign *db.Campaign) {
+	progress := db.CampaignProgress{
+		Total:         len(campaign.Jobs),
+		EstimatedCost: campaign.Progress.EstimatedCost,
+	}
+	for _, job := range campaign.Jobs {
+		if job.JobID != "" {
+			progress.Submitted++
+		}
+		switch {
+		case job.Error != "":
+			progress.Failed++
+		case provider.Status(job.Status) == provider.StatusFinished:
+			progress.Finished++
+			progress.Cost += job.Cost
+		case isTerminal(provider.Status(job.Status)):
+			progress.Failed++
+		}
+	}
+	campaign.Progress = progress
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// JSON-encoded campaign returned on the newCampaign, getCampaign,
+// pauseCampaign and resumeCampaign operations.
+//
+// swagger:response campaign
+type campaignResponse struct {
+	// in: body
+	Payload *db.Campaign
+
+	baseResponse
+}
+
+// swagger:parameters getCampaign deleteCampaign pauseCampaign resumeCampaign
+type getCampaignInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+}
+
+// swagger:parameters newCampaign
+type newCampaignInput struct {
+	// in: body
+	// required: true
+	Payload newCampaignPayload
+}
+
+type newCampaignPayload struct {
+	// name of the campaign
+	//
+	// required: true
+	Name string `json:"name"`
+
+	// description of the campaign
+	Description string `json:"description,omitempty"`
+
+	// filter selecting the finished jobs that are re-encoded
+	Filter db.CampaignFilter `json:"filter"`
+
+	// mapping of the presetmaps used in the original jobs to the ones
+	// replacing them
+	//
+	// required: true
+	Presets map[string]string `json:"presets"`
+
+	// provider of the new jobs, defaults to the provider of each original
+	// job
+	Provider string `json:"provider,omitempty"`
+
+	// maximum number of jobs submitted per hour
+	//
+	// required: true
+	JobsPerHour uint `json:"jobsPerHour"`
+}
+
+// error returned when the given campaign name is not found on the API.
+//
+// swagger:response campaignNotFound
+type campaignNotFoundResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given campaign data is not valid.
+//
+// swagger:response invalidCampaign
+type invalidCampaignResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to create a new campaign using a name that is
+// already in use.
+//
+// swagger:response campaignAlreadyExists
+type campaignAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// response for the listCampaigns operation. It's a JSON-encoded object in
+// the format `campaignName: campaignObject`
+//
+// swagger:response listCampaigns
+type listCampaignsResponse struct {
+	// in: body
+	Campaigns map[string]db.Campaign
+
+	baseResponse
+}
+
+func newCampaignResponse(campaign *db.Campaign) *campaignResponse {
+	return &campaignResponse{
+		baseResponse: baseResponse{
+			payload: campaign,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newCampaignNotFoundResponse(err error) *campaignNotFoundResponse {
+	return &campaignNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
+}
+
+func (r *campaignNotFoundResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newInvalidCampaignResponse(err error) *invalidCampaignResponse {
+	return &invalidCampaignResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidCampaignResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newCampaignAlreadyExistsResponse(err error) *campaignAlreadyExistsResponse {
+	return &campaignAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *campaignAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newListCampaignsResponse(campaigns []db.Campaign) *listCampaignsResponse {
+	campaignMap := make(map[string]db.Campaign, len(campaigns))
+	for _, campaign := range campaigns {
+		campaignMap[campaign.Name] = campaign
+	}
+	return &listCampaignsResponse{
+		baseResponse: baseResponse{
+			status:  http.StatusOK,
+			payload: campaignMap,
+		},
+	}
+}
+
+// Campaign loads the input from the request body, validates it and returns
+// the campaign. Jobs and progress are filled by the service.
+func (p *newCampaignInput) Campaign(body io.Reader) (db.Campaign, error) {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return db.Campaign{}, err
+	}
+	campaign := db.Campaign{
+		Name:        p.Payload.Name,
+		Description: p.Payload.Description,
+		Filter:      p.Payload.Filter,
+		Presets:     p.Payload.Presets,
+		Provider:    p.Payload.Provider,
+		JobsPerHour: p.Payload.JobsPerHour,
+	}
+	return campaign, validateCampaign(&campaign)
+}
+
+func (p *getCampaignInput) loadParams(paramsMap map[string]string) {
+	p.Name = paramsMap["name"]
+}
+
+func validateCampaign(c *db.Campaign) error {
+	if c.Name == "" {
+		return errors.New("missing field name from the request")
+	}
+	if len(c.Presets) == 0 {
+		return errors.New("missing field presets from the request")
+	}
+	for from, to := range c.Presets {
+		if from == "" || to == "" {
+			return fmt.Errorf("invalid preset replacement %q => %q", from, to)
+		}
+	}
+	if c.JobsPerHour == 0 {
+		return errors.New("jobsPerHour must be greater than zero")
+	}
+	if !c.Filter.Until.IsZero() && !c.Filter.Since.Before(c.Filter.Until) {
+		return errors.New("the since filter must be before the until filter")
+	}
+	return nil
+}
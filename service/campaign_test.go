@@ -0,0 +1,297 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func campaignTestDB() db.Repository {
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreateTenant(&db.Tenant{Name: "news"})
+	for _, presetMap := range []db.PresetMap{
+		{Name: "h264_720p", ProviderMapping: map[string]string{"fake": "h264_720p"}, OutputOpts: db.OutputOptions{Extension: "mp4"}},
+		{Name: "av1_720p", ProviderMapping: map[string]string{"fake": "av1_720p"}, OutputOpts: db.OutputOptions{Extension: "webm"}},
+	} {
+		presetMap := presetMap
+		fakeDB.CreatePresetMap(&presetMap)
+	}
+	creation := time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, job := range []db.Job{
+		{ID: "job-1", Status: "finished", Tenant: "news", Outputs: []db.TranscodeOutput{{Preset: "h264_720p", FileName: "video_720p.mp4"}}},
+		{ID: "job-2", Status: "finished", Outputs: []db.TranscodeOutput{{Preset: "h264_720p", FileName: "video.mp4"}, {Preset: "thumbs", FileName: "thumbs.jpg"}}},
+		{ID: "job-3", Status: "failed", Outputs: []db.TranscodeOutput{{Preset: "h264_720p", FileName: "video.mp4"}}},
+		{ID: "job-4", Status: "finished", Outputs: []db.TranscodeOutput{{Preset: "thumbs", FileName: "thumbs.jpg"}}},
+		{ID: "job-5", Status: "finished", Environment: db.EnvironmentSandbox, Outputs: []db.TranscodeOutput{{Preset: "h264_720p", FileName: "video.mp4"}}},
+		{ID: "job-6", Status: "finished", Labels: []string{"show=daily"}, Outputs: []db.TranscodeOutput{{Preset: "h264_720p", FileName: "video.mp4"}}},
+	} {
+		job := job
+		creation = creation.Add(time.Hour)
+		job.CreationTime = creation
+		job.ProviderName = "fake"
+		job.ProviderJobID = "provider-" + job.ID
+		job.SourceMedia = "s3://bucket/" + job.ID + ".mov"
+		job.StatusSnapshot = `{"status":"` + job.Status + `","sourceInfo":{"duration":120000000000}}`
+		fakeDB.CreateJob(&job)
+	}
+	return fakeDB
+}
+
+func TestNewCampaign(t *testing.T) {
+	var tests = []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode          int
+		wantError         string
+		wantJobs          []string
+		wantEstimatedCost float64
+	}{
+		{
+			"all finished jobs using the preset",
+			`{"name":"av1","presets":{"h264_720p":"av1_720p"},"jobsPerHour":10}`,
+			http.StatusOK,
+			"",
+			[]string{"job-1", "job-2", "job-6"},
+			0.4,
+		},
+		{
+			"filtered by tenant",
+			`{"name":"av1","filter":{"tenant":"news"},"presets":{"h264_720p":"av1_720p"},"jobsPerHour":10}`,
+			http.StatusOK,
+			"",
+			[]string{"job-1"},
+			0.1,
+		},
+		{
+			"filtered by time with limit",
+			`{"name":"av1","filter":{"since":"2017-03-01T12:00:00Z","limit":1},"presets":{"h264_720p":"av1_720p"},"jobsPerHour":10}`,
+			http.StatusOK,
+			"",
+			[]string{"job-2"},
+			0.2,
+		},
+		{
+			"missing name",
+			`{"presets":{"h264_720p":"av1_720p"},"jobsPerHour":10}`,
+			http.StatusBadRequest,
+			"missing field name from the request",
+			nil,
+			0,
+		},
+		{
+			"missing rate",
+			`{"name":"av1","presets":{"h264_720p":"av1_720p"}}`,
+			http.StatusBadRequest,
+			"jobsPerHour must be greater than zero",
+			nil,
+			0,
+		},
+		{
+			"unknown presetmap",
+			`{"name":"av1","presets":{"h264_720p":"av1_1080p"},"jobsPerHour":10}`,
+			http.StatusBadRequest,
+			`presetmap "av1_1080p" not found`,
+			nil,
+			0,
+		},
+		{
+			"no matching jobs",
+			`{"name":"av1","filter":{"provider":"zencoder"},"presets":{"h264_720p":"av1_720p"},"jobsPerHour":10}`,
+			http.StatusBadRequest,
+			"no finished jobs match the filter of the campaign",
+			nil,
+			0,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := campaignTestDB()
+		service, err := NewTranscodingService(&config.Config{Prediction: &config.Prediction{CostPerMinute: "fake:0.05"}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/campaigns", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantError != "" {
+			var got map[string]interface{}
+			if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		campaign, err := fakeDB.GetCampaign("av1")
+		if err != nil {
+			t.Fatalf("%s: %s", test.givenTestCase, err)
+		}
+		var jobs []string
+		for _, job := range campaign.Jobs {
+			jobs = append(jobs, job.SourceJobID)
+		}
+		if !reflect.DeepEqual(jobs, test.wantJobs) {
+			t.Errorf("%s: wrong jobs. Want %#v. Got %#v", test.givenTestCase, test.wantJobs, jobs)
+		}
+		if campaign.Status != db.CampaignStatusRunning || campaign.Progress.Total != len(test.wantJobs) {
+			t.Errorf("%s: wrong campaign: %#v", test.givenTestCase, campaign)
+		}
+		if diff := campaign.Progress.EstimatedCost - test.wantEstimatedCost; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s: wrong estimated cost. Want %f. Got %f", test.givenTestCase, test.wantEstimatedCost, campaign.Progress.EstimatedCost)
+		}
+	}
+}
+
+func TestPauseResumeCampaign(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreateCampaign(&db.Campaign{Name: "av1", Status: db.CampaignStatusRunning})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	var tests = []struct {
+		givenURI   string
+		wantCode   int
+		wantStatus string
+	}{
+		{"/campaigns/av1/pause", http.StatusOK, db.CampaignStatusPaused},
+		{"/campaigns/av1/pause", http.StatusBadRequest, db.CampaignStatusPaused},
+		{"/campaigns/av1/resume", http.StatusOK, db.CampaignStatusRunning},
+		{"/campaigns/unknown/resume", http.StatusNotFound, db.CampaignStatusRunning},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("POST", test.givenURI, strings.NewReader(""))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenURI, test.wantCode, w.Code)
+		}
+		campaign, err := fakeDB.GetCampaign("av1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if campaign.Status != test.wantStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.givenURI, test.wantStatus, campaign.Status)
+		}
+	}
+}
+
+func TestRunCampaigns(t *testing.T) {
+	fakeDB := campaignTestDB()
+	fakeDB.CreateCampaign(&db.Campaign{
+		Name:        "av1",
+		Presets:     map[string]string{"h264_720p": "av1_720p"},
+		JobsPerHour: 120,
+		Status:      db.CampaignStatusRunning,
+		Jobs:        []db.CampaignJob{{SourceJobID: "job-2"}, {SourceJobID: "job-6"}, {SourceJobID: "job-404"}, {SourceJobID: "job-1"}},
+	})
+	service, err := NewTranscodingService(&config.Config{Prediction: &config.Prediction{CostPerMinute: "fake:0.05"}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	run := func() *db.Campaign {
+		service.runCampaigns(time.Minute)
+		campaign, err := fakeDB.GetCampaign("av1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, campaignJob := range campaign.Jobs {
+			if campaignJob.JobID == "" {
+				continue
+			}
+			job, err := fakeDB.GetJob(campaignJob.JobID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			job.ProviderJobID = "provider-job-123"
+			fakeDB.UpdateJob(job)
+		}
+		campaign.LastSubmission = campaign.LastSubmission.Add(-time.Minute)
+		fakeDB.UpdateCampaign(campaign)
+		return campaign
+	}
+
+	campaign := run()
+	if campaign.Progress.Submitted != 2 {
+		t.Fatalf("wrong number of submitted jobs. Want 2. Got %#v", campaign.Progress)
+	}
+	job, err := fakeDB.GetJob(campaign.Jobs[0].JobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantOutputs := []db.TranscodeOutput{{Preset: "av1_720p", PresetVersion: 1, FileName: "video.webm"}, {Preset: "thumbs", PresetVersion: 1, FileName: "thumbs.jpg"}}
+	if !reflect.DeepEqual(job.Outputs, wantOutputs) {
+		t.Errorf("wrong outputs.\nWant %#v\nGot  %#v", wantOutputs, job.Outputs)
+	}
+	if job.SourceMedia != "s3://bucket/job-2.mov" || job.Priority != db.PriorityLow {
+		t.Errorf("wrong job: %#v", job)
+	}
+	job, err = fakeDB.GetJob(campaign.Jobs[1].JobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantLabels := []string{"show=daily", "campaign=av1"}; !reflect.DeepEqual(job.Labels, wantLabels) {
+		t.Errorf("wrong labels. Want %#v. Got %#v", wantLabels, job.Labels)
+	}
+
+	campaign = run()
+	if campaign.Jobs[2].Error != `original job "job-404" not found` {
+		t.Errorf("wrong error of missing job. Got %q", campaign.Jobs[2].Error)
+	}
+	if diff := campaign.Progress.Cost - 0.4575; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("wrong cost. Want 0.4575. Got %f", campaign.Progress.Cost)
+	}
+	wantProgress := db.CampaignProgress{Total: 4, Submitted: 3, Finished: 2, Failed: 1, Cost: campaign.Progress.Cost}
+	if campaign.Progress != wantProgress || campaign.Status != db.CampaignStatusRunning {
+		t.Errorf("wrong progress after the second run.\nWant %#v\nGot  %#v", wantProgress, campaign.Progress)
+	}
+
+	campaign = run()
+	if campaign.Status != db.CampaignStatusFinished || campaign.Progress.Finished != 3 {
+		t.Errorf("the campaign didn't finish: %#v", campaign)
+	}
+}
+
+func TestCampaignSlots(t *testing.T) {
+	now := time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		testCase       string
+		jobsPerHour    uint
+		interval       time.Duration
+		lastSubmission time.Time
+		want           int
+	}{
+		{"first run", 120, time.Minute, time.Time{}, 2},
+		{"first run of slow campaign", 6, time.Minute, time.Time{}, 1},
+		{"slow campaign before its spacing", 6, time.Minute, now.Add(-5 * time.Minute), 0},
+		{"slow campaign after its spacing", 6, time.Minute, now.Add(-10 * time.Minute), 1},
+		{"resumed campaign", 120, time.Minute, now.Add(-time.Hour), 2},
+	}
+	for _, test := range tests {
+		campaign := db.Campaign{JobsPerHour: test.jobsPerHour, LastSubmission: test.lastSubmission}
+		if got := campaignSlots(&campaign, test.interval, now); got != test.want {
+			t.Errorf("%s: wrong slots. Want %d. Got %d", test.testCase, test.want, got)
+		}
+	}
+}
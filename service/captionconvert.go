@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/playlist"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/timedtext"
+)
+
+// captionEndTolerance is the time, in seconds, that captions may run past
+// the end of the source before the job gets a warning.
+const captionEndTolerance = 1.0
+
+var errCaptionConversionDestination = errors.New("caption conversion requires an s3:// destination")
+
+// captionContentTypes are the content types of the converted caption files.
+var captionContentTypes = map[string]string{
+	provider.CaptionFormatSRT:    "application/x-subrip",
+	provider.CaptionFormatWebVTT: "text/vtt",
+	provider.CaptionFormatTTML:   "application/ttml+xml",
+}
+
+// captionConverter converts the captions of new jobs to the formats
+// requested in the job, or to a format ingested by the provider, storing
+// the converted files next to the outputs of the job. Caption files are
+// checked by the source validator and read through its client.
+type captionConverter struct {
+	analyzer *mediaAnalyzer
+	sources  *sourceValidator
+	fetch    playlist.Fetcher
+}
+
+func newCaptionConverter(analyzer *mediaAnalyzer, sources *sourceValidator) *captionConverter {
+	return &captionConverter{
+		analyzer: analyzer,
+		sources:  sources,
+		fetch:    analyzer.fetcher(sources.client),
+	}
+}
+
+// convert returns the captions of the job, converting the ones that need
+// it. The timing of the cues of converted captions is validated, and the
+// end of their last cue is recorded for checking it against the duration
+// of the source once the job finishes.
+func (c *captionConverter) convert(captions []db.Caption, params []CaptionParams, formats []string, destination string) ([]db.Caption, error) {
+	for i := range captions {
+		caption := &captions[i]
+		target := params[i].ConvertTo
+		if target == "" && !ingestsCaptionFormat(formats, caption.Format) {
+			target = formats[0]
+		}
+		if target == "" || target == caption.Format {
+			continue
+		}
+		bucket, prefix, err := splitS3URL(destination)
+		if err != nil {
+			return nil, errCaptionConversionDestination
+		}
+		if err = c.sources.validateCaption(caption.Source); err != nil {
+			return nil, err
+		}
+		input, err := c.analyzer.input(caption.Source)
+		if err != nil {
+			return nil, err
+		}
+		data, err := c.fetch(input)
+		if err != nil {
+			return nil, err
+		}
+		cues, err := timedtext.Parse(data, caption.Format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid caption %q: %s", caption.Source, err)
+		}
+		if err = timedtext.Validate(cues, 0); err != nil {
+			return nil, fmt.Errorf("invalid caption %q: %s", caption.Source, err)
+		}
+		if data, err = timedtext.Write(cues, target); err != nil {
+			return nil, err
+		}
+		extension := captionExtensions[target]
+		key := strings.TrimPrefix(prefix, "/") + "captions/source/" + strconv.Itoa(i) + "-" + caption.Language + "." + extension
+		if err = c.analyzer.upload(bucket, key, captionContentTypes[target], data); err != nil {
+			return nil, err
+		}
+		caption.Source = "s3://" + bucket + "/" + key
+		caption.Format = target
+		caption.End = timedtext.End(cues).Seconds()
+		if caption.FileName != "" && params[i].FileName == "" {
+			caption.FileName = strings.TrimSuffix(caption.FileName, path.Ext(caption.FileName)) + "." + extension
+		}
+	}
+	return captions, nil
+}
+
+// ingestsCaptionFormat returns whether a provider that ingests the given
+// caption formats (any format, when empty) ingests captions in format.
+func ingestsCaptionFormat(formats []string, format string) bool {
+	if len(formats) == 0 {
+		return true
+	}
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCaptions warns about converted captions that run past the end of
+// the source of finished jobs.
+func checkCaptions(job *db.Job, status *provider.JobStatus) {
+	duration := status.SourceInfo.Duration.Seconds()
+	if status.Status != provider.StatusFinished || duration == 0 {
+		return
+	}
+	for _, caption := range job.Captions {
+		if caption.End > duration+captionEndTolerance {
+			status.Warnings = append(status.Warnings, fmt.Sprintf("the %s captions end at %.3fs, after the end of the source (%.3fs)", caption.Language, caption.End, duration))
+		}
+	}
+}
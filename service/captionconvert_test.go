@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const testSRT = "1\n00:00:01,000 --> 00:00:03,500\nHello\n\n2\n00:00:10,000 --> 00:01:02,250\nworld\n"
+
+func TestCaptionConverterConvert(t *testing.T) {
+	var tests = []struct {
+		testCase     string
+		captions     []db.Caption
+		params       []CaptionParams
+		formats      []string
+		destination  string
+		wantCaptions []db.Caption
+		wantUploads  map[string]string
+		wantErr      string
+	}{
+		{
+			"conversion requested in the job",
+			[]db.Caption{{Source: "s3://bucket/captions/en.srt", Format: "srt", Language: "en", FileName: "captions/en.srt"}},
+			[]CaptionParams{{Source: "s3://bucket/captions/en.srt", ConvertTo: "webvtt"}},
+			nil,
+			"s3://output/job/",
+			[]db.Caption{{Source: "s3://output/job/captions/source/0-en.vtt", Format: "webvtt", Language: "en", FileName: "captions/en.vtt", End: 62.25}},
+			map[string]string{
+				"output/job/captions/source/0-en.vtt": "WEBVTT\n\n00:00:01.000 --> 00:00:03.500\nHello\n\n00:00:10.000 --> 00:01:02.250\nworld\n\n",
+			},
+			"",
+		},
+		{
+			"format not ingested by the provider",
+			[]db.Caption{
+				{Source: "s3://bucket/captions/en.vtt", Format: "webvtt", Language: "en"},
+				{Source: "s3://bucket/captions/es.srt", Format: "srt", Language: "es", FileName: "subs/spanish.srt"},
+			},
+			[]CaptionParams{
+				{Source: "s3://bucket/captions/en.vtt"},
+				{Source: "s3://bucket/captions/es.srt", FileName: "subs/spanish.srt"},
+			},
+			[]string{"webvtt", "ttml"},
+			"s3://output/job/",
+			[]db.Caption{
+				{Source: "s3://bucket/captions/en.vtt", Format: "webvtt", Language: "en"},
+				{Source: "s3://output/job/captions/source/1-es.vtt", Format: "webvtt", Language: "es", FileName: "subs/spanish.srt", End: 62.25},
+			},
+			map[string]string{
+				"output/job/captions/source/1-es.vtt": "WEBVTT\n\n00:00:01.000 --> 00:00:03.500\nHello\n\n00:00:10.000 --> 00:01:02.250\nworld\n\n",
+			},
+			"",
+		},
+		{
+			"no conversion needed",
+			[]db.Caption{{Source: "s3://bucket/captions/en.srt", Format: "srt", Language: "en"}},
+			[]CaptionParams{{Source: "s3://bucket/captions/en.srt", ConvertTo: "srt"}},
+			[]string{"srt"},
+			"ftp://output/job/",
+			[]db.Caption{{Source: "s3://bucket/captions/en.srt", Format: "srt", Language: "en"}},
+			map[string]string{},
+			"",
+		},
+		{
+			"destination outside of s3",
+			[]db.Caption{{Source: "s3://bucket/captions/en.srt", Format: "srt", Language: "en"}},
+			[]CaptionParams{{Source: "s3://bucket/captions/en.srt", ConvertTo: "ttml"}},
+			nil,
+			"ftp://output/job/",
+			nil,
+			map[string]string{},
+			"caption conversion requires an s3:// destination",
+		},
+		{
+			"invalid caption file",
+			[]db.Caption{{Source: "s3://bucket/captions/broken.vtt", Format: "webvtt", Language: "en"}},
+			[]CaptionParams{{Source: "s3://bucket/captions/broken.vtt", ConvertTo: "srt"}},
+			nil,
+			"s3://output/job/",
+			nil,
+			map[string]string{},
+			`invalid caption "s3://bucket/captions/broken.vtt": webvtt: missing WEBVTT header`,
+		},
+		{
+			"unavailable caption file",
+			[]db.Caption{{Source: "s3://bucket/captions/missing.srt", Format: "srt", Language: "en"}},
+			[]CaptionParams{{Source: "s3://bucket/captions/missing.srt", ConvertTo: "webvtt"}},
+			nil,
+			"s3://output/job/",
+			nil,
+			map[string]string{},
+			"404 Not Found",
+		},
+		{
+			"caption in a private network",
+			[]db.Caption{{Source: "http://169.254.169.254/latest/meta-data/en.srt", Format: "srt", Language: "en"}},
+			[]CaptionParams{{Source: "http://169.254.169.254/latest/meta-data/en.srt", ConvertTo: "webvtt"}},
+			nil,
+			"s3://output/job/",
+			nil,
+			map[string]string{},
+			`caption "http://169.254.169.254/latest/meta-data/en.srt" points to a private network`,
+		},
+	}
+	for _, test := range tests {
+		uploads := map[string]string{}
+		converter := newCaptionConverter(&mediaAnalyzer{
+			presign: func(bucket, key string) (string, error) {
+				return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+			},
+			upload: func(bucket, key, contentType string, data []byte) error {
+				uploads[bucket+"/"+key] = string(data)
+				return nil
+			},
+		}, newSourceValidator(&config.SourceValidation{}))
+		converter.fetch = func(url string) ([]byte, error) {
+			switch url {
+			case "https://bucket.s3.amazonaws.com/captions/en.srt", "https://bucket.s3.amazonaws.com/captions/es.srt":
+				return []byte(testSRT), nil
+			case "https://bucket.s3.amazonaws.com/captions/broken.vtt":
+				return []byte(testSRT), nil
+			}
+			return nil, errors.New("404 Not Found")
+		}
+		captions, err := converter.convert(test.captions, test.params, test.formats, test.destination)
+		var gotErr string
+		if err != nil {
+			gotErr = err.Error()
+		}
+		if gotErr != test.wantErr {
+			t.Errorf("%s: wrong error. Want %q. Got %q", test.testCase, test.wantErr, gotErr)
+		}
+		if !reflect.DeepEqual(captions, test.wantCaptions) {
+			t.Errorf("%s: wrong captions.\nWant %#v\nGot  %#v", test.testCase, test.wantCaptions, captions)
+		}
+		if !reflect.DeepEqual(uploads, test.wantUploads) {
+			t.Errorf("%s: wrong uploads.\nWant %#v\nGot  %#v", test.testCase, test.wantUploads, uploads)
+		}
+	}
+}
+
+func TestCheckCaptions(t *testing.T) {
+	var tests = []struct {
+		testCase     string
+		status       provider.Status
+		duration     time.Duration
+		wantWarnings []string
+	}{
+		{"captions within the source", provider.StatusFinished, 62 * time.Second, nil},
+		{
+			"captions after the end of the source",
+			provider.StatusFinished,
+			30 * time.Second,
+			[]string{"the en captions end at 62.250s, after the end of the source (30.000s)"},
+		},
+		{"unknown duration", provider.StatusFinished, 0, nil},
+		{"job running", provider.StatusStarted, 30 * time.Second, nil},
+	}
+	for _, test := range tests {
+		job := db.Job{Captions: []db.Caption{
+			{Language: "en", End: 62.25},
+			{Language: "es"},
+		}}
+		status := provider.JobStatus{Status: test.status, SourceInfo: provider.SourceInfo{Duration: test.duration}}
+		checkCaptions(&job, &status)
+		if !reflect.DeepEqual(status.Warnings, test.wantWarnings) {
+			t.Errorf("%s: wrong warnings.\nWant %#v\nGot  %#v", test.testCase, test.wantWarnings, status.Warnings)
+		}
+	}
+}
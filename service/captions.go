@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// captionExtensions are the extensions of the caption files in each format.
+var captionExtensions = map[string]string{
+	provider.CaptionFormatSRT:    "srt",
+	provider.CaptionFormatWebVTT: "vtt",
+	provider.CaptionFormatTTML:   "ttml",
+}
+
+// format returns the format of the caption, detected from the extension of
+// its source when omitted.
+func (p *CaptionParams) format() string {
+	if p.Format != "" {
+		return strings.ToLower(p.Format)
+	}
+	sourcePath := p.Source
+	if u, err := url.Parse(p.Source); err == nil {
+		sourcePath = u.Path
+	}
+	switch strings.ToLower(path.Ext(sourcePath)) {
+	case ".srt":
+		return provider.CaptionFormatSRT
+	case ".vtt", ".webvtt":
+		return provider.CaptionFormatWebVTT
+	case ".ttml", ".dfxp":
+		return provider.CaptionFormatTTML
+	}
+	return ""
+}
+
+func (p *CaptionParams) mode() string {
+	if p.Mode == "" {
+		return provider.CaptionModeSidecar
+	}
+	return p.Mode
+}
+
+func validateCaptions(captions []CaptionParams) error {
+	var burnIns int
+	for _, caption := range captions {
+		if caption.Source == "" {
+			return errors.New("missing source of caption")
+		}
+		format := caption.format()
+		if format == "" {
+			return fmt.Errorf("can't detect the format of caption %q, please provide it", caption.Source)
+		}
+		if _, ok := captionExtensions[format]; !ok {
+			return fmt.Errorf("invalid caption format %q", caption.Format)
+		}
+		if _, ok := captionExtensions[caption.ConvertTo]; caption.ConvertTo != "" && !ok {
+			return fmt.Errorf("invalid caption format %q", caption.ConvertTo)
+		}
+		switch caption.mode() {
+		case provider.CaptionModeSidecar:
+		case provider.CaptionModeBurnIn:
+			if caption.FileName != "" {
+				return errors.New("fileName is only supported in sidecar captions")
+			}
+			burnIns++
+		default:
+			return fmt.Errorf("invalid caption mode %q", caption.Mode)
+		}
+	}
+	if burnIns > 1 {
+		return errors.New("only one caption can be burned in")
+	}
+	return nil
+}
+
+// captions returns the captions recorded in new jobs, with the language of
+// the job as the default language (or "und", as in outputs). The file names of sidecar captions
+// accept the same tokens as the file names of outputs.
+func captions(params []CaptionParams, language string) ([]db.Caption, error) {
+	var result []db.Caption
+	fileNames := make(map[string]bool)
+	for _, p := range params {
+		caption := db.Caption{
+			Source:   p.Source,
+			Language: p.Language,
+			Format:   p.format(),
+			Mode:     p.mode(),
+		}
+		if caption.Language == "" {
+			caption.Language = language
+		}
+		if caption.Language == "" {
+			caption.Language = "und"
+		}
+		if caption.Mode == provider.CaptionModeSidecar {
+			fileName := p.FileName
+			if fileName == "" {
+				fileName = "captions/{lang}." + captionExtensions[caption.Format]
+			}
+			caption.FileName = expandFileName(fileName, caption.Language, "")
+			if fileNames[caption.FileName] {
+				return nil, fmt.Errorf("duplicate caption file %q", caption.FileName)
+			}
+			fileNames[caption.FileName] = true
+		}
+		result = append(result, caption)
+	}
+	return result, nil
+}
+
+// providerCaptions returns the captions sent to providers.
+func providerCaptions(captions []db.Caption) []provider.Caption {
+	if len(captions) == 0 {
+		return nil
+	}
+	result := make([]provider.Caption, len(captions))
+	for i, caption := range captions {
+		result[i] = provider.Caption{
+			Source:   caption.Source,
+			Language: caption.Language,
+			Format:   caption.Format,
+			Mode:     caption.Mode,
+			FileName: caption.FileName,
+		}
+	}
+	return result
+}
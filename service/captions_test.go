@@ -0,0 +1,62 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestCaptions(t *testing.T) {
+	var tests = []struct {
+		testCase    string
+		params      []CaptionParams
+		language    string
+		expected    []db.Caption
+		expectedErr string
+	}{
+		{
+			"sidecar captions",
+			[]CaptionParams{
+				{Source: "http://example.com/captions/en.srt"},
+				{Source: "http://example.com/captions/es.dfxp?version=2", Language: "es", FileName: "subtitles/{lang}/video.ttml"},
+			},
+			"en",
+			[]db.Caption{
+				{Source: "http://example.com/captions/en.srt", Language: "en", Format: "srt", Mode: "sidecar", FileName: "captions/en.srt"},
+				{Source: "http://example.com/captions/es.dfxp?version=2", Language: "es", Format: "ttml", Mode: "sidecar", FileName: "subtitles/es/video.ttml"},
+			},
+			"",
+		},
+		{
+			"burned-in captions",
+			[]CaptionParams{{Source: "http://example.com/captions/forced", Format: "WebVTT", Mode: "burn-in"}},
+			"",
+			[]db.Caption{{Source: "http://example.com/captions/forced", Language: "und", Format: "webvtt", Mode: "burn-in"}},
+			"",
+		},
+		{
+			"duplicate caption files",
+			[]CaptionParams{
+				{Source: "http://example.com/captions/en.vtt"},
+				{Source: "http://example.com/captions/en-forced.vtt"},
+			},
+			"en",
+			nil,
+			`duplicate caption file "captions/en.vtt"`,
+		},
+	}
+	for _, test := range tests {
+		result, err := captions(test.params, test.language)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if errMsg != test.expectedErr {
+			t.Errorf("%s: wrong error. Want %q. Got %q", test.testCase, test.expectedErr, errMsg)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: wrong captions. Want %#v. Got %#v", test.testCase, test.expected, result)
+		}
+	}
+}
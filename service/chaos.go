@@ -0,0 +1,63 @@
+package service
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/provider/chaos"
+)
+
+// chaosWebhooks is implemented by the chaos provider, which hands over the
+// provider job ids of the jobs whose completion wasn't notified yet, in
+// place of the webhooks of real providers.
+type chaosWebhooks interface {
+	TakeWebhooks() []string
+}
+
+// RunChaosWebhooks periodically notifies the API about the completion of
+// the jobs of the chaos provider, standing in for the webhooks of real
+// providers, until the given channel is closed. It returns immediately
+// when the chaos provider is disabled in the configuration.
+func (s *TranscodingService) RunChaosWebhooks(stop <-chan struct{}) {
+	cfg := s.config.Chaos
+	if cfg == nil || !cfg.Enabled || cfg.WebhookInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.WebhookInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.deliverChaosWebhooks()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// deliverChaosWebhooks refreshes the jobs of the chaos provider whose
+// completion is pending notification, as the receivers of provider
+// notifications do. Webhooks of jobs that aren't stored, like the jobs of
+// self tests, are dropped. It doesn't run while the API is in maintenance
+// mode.
+func (s *TranscodingService) deliverChaosWebhooks() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	factory, err := provider.GetProviderFactory(chaos.Name)
+	if err != nil {
+		return
+	}
+	p, err := factory(s.config)
+	if err != nil {
+		s.logger.WithError(err).Error("error initializing the chaos provider")
+		return
+	}
+	webhooks, ok := p.(chaosWebhooks)
+	if !ok {
+		return
+	}
+	for _, id := range webhooks.TakeWebhooks() {
+		s.refreshProviderJob(chaos.Name, id)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/provider/chaos"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestChaosSubmitFailures(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		cfg       *config.Chaos
+		label     string
+		wantCodes []int
+	}{
+		{"no failures", &config.Chaos{Enabled: true}, "chaos:partial-outputs", []int{http.StatusOK, http.StatusOK}},
+		{"failing submissions", &config.Chaos{Enabled: true}, "chaos:submit-5xx", []int{http.StatusInternalServerError, http.StatusInternalServerError}},
+		{"failing the first submissions", &config.Chaos{Enabled: true}, "chaos:submit-5xx=2", []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK}},
+		{"chaos provider disabled", &config.Chaos{}, "chaos", []int{http.StatusBadRequest}},
+		{"chaos provider not configured", nil, "chaos", []int{http.StatusBadRequest}},
+	}
+	body := `{"source":"http://another.non.existent/video.mp4","provider":"chaos","outputs":[{"preset":"mp4_1080p"}],"labels":["%s"]}`
+	for _, test := range tests {
+		service, err := NewTranscodingService(&config.Config{Chaos: test.cfg}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"chaos": "mp4_1080p"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service.db = fakeDB
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		srvr.Register(service)
+		var codes []int
+		for range test.wantCodes {
+			r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(fmt.Sprintf(body, test.label)))
+			w := httptest.NewRecorder()
+			srvr.ServeHTTP(w, r)
+			codes = append(codes, w.Code)
+		}
+		if !reflect.DeepEqual(codes, test.wantCodes) {
+			t.Errorf("%s: wrong response codes. Want %v. Got %v", test.testCase, test.wantCodes, codes)
+		}
+	}
+}
+
+func TestChaosReconciliation(t *testing.T) {
+	service, err := NewTranscodingService(&config.Config{Chaos: &config.Chaos{Enabled: true, WebhookInterval: time.Second}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	factory, err := provider.GetProviderFactory(chaos.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chaosProvider, err := factory(service.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		jobID               string
+		labels              []string
+		wantStatusOnWebhook string
+		wantStatus          string
+		wantProgress        float64
+		wantFiles           int
+	}{
+		{"job-notified", nil, "finished", "finished", 100, 2},
+		{"job-webhook-loss", []string{"chaos:webhook-loss"}, "queued", "finished", 100, 2},
+		{"job-stuck", []string{"chaos:stuck-at=40"}, "queued", "started", 40, 0},
+		{"job-partial-outputs", []string{"chaos:partial-outputs"}, "finished", "finished", 100, 1},
+	}
+	for _, test := range tests {
+		job := db.Job{
+			ID:           test.jobID,
+			ProviderName: "chaos",
+			Labels:       test.labels,
+			Outputs:      []db.TranscodeOutput{{Preset: "mp4_720p", FileName: "video_720p.mp4"}, {Preset: "mp4_1080p", FileName: "video_1080p.mp4"}},
+		}
+		status, err := chaosProvider.Transcode(&job, provider.TranscodeProfile{
+			SourceMedia: "http://another.non.existent/" + test.jobID + ".mp4",
+			Outputs: []provider.TranscodeOutput{
+				{FileName: "video_720p.mp4", Preset: db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{"chaos": "mp4_720p"}}},
+				{FileName: "video_1080p.mp4", Preset: db.PresetMap{Name: "mp4_1080p", ProviderMapping: map[string]string{"chaos": "mp4_1080p"}}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		job.ProviderJobID = status.ProviderJobID
+		job.Status = string(status.Status)
+		if err = service.db.CreateJob(&job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service.deliverChaosWebhooks()
+	for _, test := range tests {
+		job, err := service.db.GetJob(test.jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != test.wantStatusOnWebhook {
+			t.Errorf("%s: wrong status after the webhooks. Want %q. Got %q", test.jobID, test.wantStatusOnWebhook, job.Status)
+		}
+	}
+	if webhooks := chaosProvider.(chaosWebhooks).TakeWebhooks(); len(webhooks) > 0 {
+		t.Errorf("unexpected webhooks delivered twice: %v", webhooks)
+	}
+	service.reconcile()
+	for _, test := range tests {
+		_, status, _, err := service.getTranscodeJobByID(test.jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(status.Status) != test.wantStatus || status.Progress != test.wantProgress {
+			t.Errorf("%s: wrong status after reconciliation. Want %s (%.0f%%). Got %s (%.0f%%)", test.jobID, test.wantStatus, test.wantProgress, status.Status, status.Progress)
+		}
+		if len(status.Output.Files) != test.wantFiles {
+			t.Errorf("%s: wrong number of output files. Want %d. Got %d", test.jobID, test.wantFiles, len(status.Output.Files))
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// clip returns the clip of the source recorded in new jobs, or nil when the
+// whole source is transcoded.
+func (p *NewTranscodeJobInputPayload) clip() *db.Clip {
+	if p.ClipStart == 0 && p.ClipDuration == 0 {
+		return nil
+	}
+	return &db.Clip{Start: p.ClipStart, Duration: p.ClipDuration}
+}
+
+// providerClip returns the clip sent to providers.
+func providerClip(clip *db.Clip) *provider.Clip {
+	if clip == nil {
+		return nil
+	}
+	return &provider.Clip{Start: clip.Start, Duration: clip.Duration}
+}
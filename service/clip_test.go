@@ -0,0 +1,41 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func TestClip(t *testing.T) {
+	var tests = []struct {
+		testCase         string
+		payload          NewTranscodeJobInputPayload
+		expected         *db.Clip
+		expectedProvider *provider.Clip
+	}{
+		{"whole source", NewTranscodeJobInputPayload{}, nil, nil},
+		{
+			"preview",
+			NewTranscodeJobInputPayload{ClipStart: 10, ClipDuration: 30},
+			&db.Clip{Start: 10, Duration: 30},
+			&provider.Clip{Start: 10, Duration: 30},
+		},
+		{
+			"first seconds",
+			NewTranscodeJobInputPayload{ClipDuration: 30},
+			&db.Clip{Duration: 30},
+			&provider.Clip{Duration: 30},
+		},
+	}
+	for _, test := range tests {
+		clip := test.payload.clip()
+		if !reflect.DeepEqual(clip, test.expected) {
+			t.Errorf("%s: wrong clip. Want %#v. Got %#v", test.testCase, test.expected, clip)
+		}
+		if providerClip := providerClip(clip); !reflect.DeepEqual(providerClip, test.expectedProvider) {
+			t.Errorf("%s: wrong provider clip. Want %#v. Got %#v", test.testCase, test.expectedProvider, providerClip)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route POST /presetclones presets clonePresets
+//
+// Clones presets, overriding some of their parameters. When bitrate scales
+// are provided, it generates a sweep with one clone of each preset per
+// scale.
+//
+//     Responses:
+//       200: clonePresets
+//       400: invalidClone
+//       500: genericError
+func (s *TranscodingService) clonePresets(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input clonePresetsInput
+	err := input.loadParams(r.Body)
+	if err != nil {
+		return newInvalidCloneResponse(err)
+	}
+	results := make(map[string]presetClone)
+	for _, name := range input.Payload.Presets {
+		presetMap, err := s.db.GetPresetMap(name)
+		if err != nil {
+			if err == db.ErrPresetMapNotFound {
+				return newInvalidCloneResponse(fmt.Errorf("presetmap %q not found", name))
+			}
+			return swagger.NewErrorResponse(err)
+		}
+		preset, unsupported, err := s.exportPreset(presetMap)
+		if err != nil {
+			results[name+input.Payload.Suffix] = presetClone{Source: name, Error: err.Error()}
+			continue
+		}
+		if len(input.Payload.Overrides) > 0 {
+			err = json.Unmarshal(input.Payload.Overrides, &preset)
+			if err != nil {
+				return newInvalidCloneResponse(fmt.Errorf("invalid overrides: %s", err))
+			}
+		}
+		providers := input.Payload.Providers
+		if len(providers) == 0 {
+			for p := range presetMap.ProviderMapping {
+				providers = append(providers, p)
+			}
+			sort.Strings(providers)
+		}
+		for _, clone := range presetSweep(preset, name+input.Payload.Suffix, input.Payload.BitrateScales) {
+			result := presetClone{Source: name, Unsupported: unsupported}
+			if clone.err != nil {
+				result.Error = clone.err.Error()
+			} else if result.newPresetOutputs, err = s.createPreset(clone.preset, providers, presetMap.OutputOpts, presetMap.Tenant); err != nil {
+				result.Error = err.Error()
+			}
+			results[clone.preset.Name] = result
+		}
+	}
+	return newClonePresetsResponse(results)
+}
+
+// exportPreset exports the preset referenced by the presetmap from the first
+// provider that supports exporting presets.
+func (s *TranscodingService) exportPreset(presetMap *db.PresetMap) (db.Preset, []string, error) {
+	providers := make([]string, 0, len(presetMap.ProviderMapping))
+	for p := range presetMap.ProviderMapping {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	for _, p := range providers {
+		providerObj, err := s.initProvider(p)
+		if err != nil {
+			continue
+		}
+		if exporter, ok := providerObj.(provider.PresetExporter); ok {
+			return exporter.ExportPreset(presetMap.ProviderMapping[p])
+		}
+	}
+	return db.Preset{}, nil, fmt.Errorf("none of the providers of presetmap %q support exporting presets", presetMap.Name)
+}
+
+type sweepPreset struct {
+	preset db.Preset
+	err    error
+}
+
+// presetSweep generates the presets of a sweep, scaling the video bitrate of
+// the given preset. Without scales, the sweep contains only the preset
+// itself.
+func presetSweep(preset db.Preset, name string, scales []float64) []sweepPreset {
+	if len(scales) == 0 {
+		preset.Name = name
+		return []sweepPreset{{preset: preset}}
+	}
+	sweep := make([]sweepPreset, len(scales))
+	for i, scale := range scales {
+		clone := preset
+		clone.Name = fmt.Sprintf("%s_%sx", name, strconv.FormatFloat(scale, 'f', -1, 64))
+		bitrate, err := strconv.Atoi(preset.Video.Bitrate)
+		if err != nil {
+			sweep[i] = sweepPreset{preset: clone, err: fmt.Errorf("invalid video bitrate %q", preset.Video.Bitrate)}
+			continue
+		}
+		clone.Video.Bitrate = strconv.Itoa(int(math.Floor(float64(bitrate)*scale + 0.5)))
+		sweep[i] = sweepPreset{preset: clone}
+	}
+	return sweep
+}
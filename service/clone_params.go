@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:parameters clonePresets
+type clonePresetsInput struct {
+	// in: body
+	// required: true
+	Payload clonePresetsPayload
+}
+
+type clonePresetsPayload struct {
+	// names of the presetmaps to clone
+	//
+	// required: true
+	Presets []string `json:"presets"`
+
+	// suffix appended to the name of the clones
+	Suffix string `json:"suffix,omitempty"`
+
+	// preset fields that replace the values of the original presets, in
+	// the same format used for creating presets
+	Overrides json.RawMessage `json:"overrides,omitempty"`
+
+	// generates one clone of each preset per scale, multiplying the video
+	// bitrate by the scale
+	BitrateScales []float64 `json:"bitrateScales,omitempty"`
+
+	// providers where the clones are created, defaults to the providers
+	// of the original presets
+	Providers []string `json:"providers,omitempty"`
+}
+
+// result of the creation of a single clone.
+type presetClone struct {
+	// name of the original presetmap
+	Source string `json:"source"`
+
+	// settings of the original preset that couldn't be exported
+	Unsupported []string `json:"unsupported,omitempty"`
+
+	newPresetOutputs
+
+	Error string `json:"error,omitempty"`
+}
+
+// response for the clonePresets operation, in the format
+// `cloneName: cloneResult`.
+//
+// swagger:response clonePresets
+type clonePresetsResponse struct {
+	// in: body
+	Results map[string]presetClone
+
+	baseResponse
+}
+
+// error returned when the given clone request is not valid.
+//
+// swagger:response invalidClone
+type invalidCloneResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newClonePresetsResponse(results map[string]presetClone) *clonePresetsResponse {
+	return &clonePresetsResponse{
+		baseResponse: baseResponse{
+			payload: results,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newInvalidCloneResponse(err error) *invalidCloneResponse {
+	return &invalidCloneResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidCloneResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+// loadParams loads the input from the request body and validates it.
+func (p *clonePresetsInput) loadParams(body io.Reader) error {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return err
+	}
+	if len(p.Payload.Presets) == 0 {
+		return errors.New("missing presets from the request")
+	}
+	if p.Payload.Suffix == "" && len(p.Payload.BitrateScales) == 0 {
+		return errors.New("either suffix or bitrateScales must be provided")
+	}
+	for _, scale := range p.Payload.BitrateScales {
+		if scale <= 0 {
+			return errors.New("bitrate scales must be positive")
+		}
+	}
+	return nil
+}
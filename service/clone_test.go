@@ -0,0 +1,149 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestClonePresets(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode       int
+		wantBody       map[string]interface{}
+		wantPresets    []db.Preset
+		wantPresetMaps []string
+	}{
+		{
+			"clone with overrides",
+			`{"presets":["mp4_1080p"],"suffix":"_gop48","overrides":{"video":{"gopSize":"48"}}}`,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"mp4_1080p_gop48": map[string]interface{}{
+					"source":      "mp4_1080p",
+					"unsupported": []interface{}{"thumbnails"},
+					"Results": map[string]interface{}{
+						"fake": map[string]interface{}{"PresetID": "presetID_here", "Error": ""},
+					},
+					"PresetMap": "mp4_1080p_gop48",
+				},
+			},
+			[]db.Preset{
+				{
+					Name:      "mp4_1080p_gop48",
+					Container: "mp4",
+					Video:     db.VideoPreset{Codec: "h264", Bitrate: "1000000", GopSize: "48"},
+					Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+				},
+			},
+			[]string{"mp4_1080p_gop48"},
+		},
+		{
+			"bitrate sweep",
+			`{"presets":["mp4_1080p"],"bitrateScales":[0.75,1.25]}`,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"mp4_1080p_0.75x": map[string]interface{}{
+					"source":      "mp4_1080p",
+					"unsupported": []interface{}{"thumbnails"},
+					"Results": map[string]interface{}{
+						"fake": map[string]interface{}{"PresetID": "presetID_here", "Error": ""},
+					},
+					"PresetMap": "mp4_1080p_0.75x",
+				},
+				"mp4_1080p_1.25x": map[string]interface{}{
+					"source":      "mp4_1080p",
+					"unsupported": []interface{}{"thumbnails"},
+					"Results": map[string]interface{}{
+						"fake": map[string]interface{}{"PresetID": "presetID_here", "Error": ""},
+					},
+					"PresetMap": "mp4_1080p_1.25x",
+				},
+			},
+			[]db.Preset{
+				{
+					Name:      "mp4_1080p_0.75x",
+					Container: "mp4",
+					Video:     db.VideoPreset{Codec: "h264", Bitrate: "750000"},
+					Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+				},
+				{
+					Name:      "mp4_1080p_1.25x",
+					Container: "mp4",
+					Video:     db.VideoPreset{Codec: "h264", Bitrate: "1250000"},
+					Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+				},
+			},
+			[]string{"mp4_1080p_0.75x", "mp4_1080p_1.25x"},
+		},
+		{
+			"presetmap not found",
+			`{"presets":["mp4_4k"],"suffix":"_copy"}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `presetmap "mp4_4k" not found`},
+			nil,
+			nil,
+		},
+		{
+			"clone without suffix",
+			`{"presets":["mp4_1080p"]}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "either suffix or bitrateScales must be provided"},
+			nil,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		fprovider.presets = nil
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "mp4_1080p"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/presetclones", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		if !reflect.DeepEqual(fprovider.presets, test.wantPresets) {
+			t.Errorf("%s: wrong presets created\nWant %#v\nGot  %#v", test.givenTestCase, test.wantPresets, fprovider.presets)
+		}
+		for _, name := range test.wantPresetMaps {
+			if _, err := fakeDB.GetPresetMap(name); err != nil {
+				t.Errorf("%s: presetmap %q not created: %s", test.givenTestCase, name, err)
+			}
+		}
+	}
+}
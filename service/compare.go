@@ -0,0 +1,173 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route GET /jobs/{jobId}/compare/{otherJobId} jobs compareJobs
+//
+// Compares the outputs of two jobs, for validating changes of providers or
+// presets: the renditions present in each job, along with their durations,
+// bitrates, sizes and QC scores. Output files are measured with ffmpeg, so
+// the comparison may take a while.
+//
+//     Responses:
+//       200: jobComparison
+//       404: jobNotFound
+//       410: jobNotFoundInTheProvider
+//       500: genericError
+func (s *TranscodingService) compareJobs(r *http.Request) swagger.GizmoJSONResponse {
+	var params compareJobsInput
+	params.loadParams(web.Vars(r))
+	renditions := make(map[string]*renditionComparison)
+	for i, jobID := range []string{params.JobID, params.OtherJobID} {
+		job, status, p, err := s.getTranscodeJobByID(jobID)
+		if err != nil {
+			return s.getJobStatusResponse(job, status, p, err)
+		}
+		details, err := s.renditionDetails(job, status)
+		if err != nil {
+			return swagger.NewErrorResponse(err)
+		}
+		for name, rendition := range details {
+			comparison, ok := renditions[name]
+			if !ok {
+				comparison = &renditionComparison{Name: name}
+				renditions[name] = comparison
+			}
+			if i == 0 {
+				comparison.Job = rendition
+			} else {
+				comparison.Other = rendition
+			}
+		}
+	}
+	names := make([]string, 0, len(renditions))
+	for name := range renditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	comparison := jobComparison{
+		JobID:      params.JobID,
+		OtherJobID: params.OtherJobID,
+		Renditions: make([]renditionComparison, len(names)),
+	}
+	for i, name := range names {
+		rendition := renditions[name]
+		if rendition.Job != nil && rendition.Other != nil {
+			rendition.Differences = compareRenditions(rendition.Job, rendition.Other)
+		}
+		comparison.Renditions[i] = *rendition
+	}
+	return newJobComparisonResponse(&comparison)
+}
+
+// renditionDetails describes the output files of the job, keyed by the name
+// of their rendition. Audio and video files are measured with ffmpeg, and
+// the QC scores are taken from the QC reports attached to the job.
+func (s *TranscodingService) renditionDetails(job *db.Job, status *provider.JobStatus) (map[string]*renditionDetails, error) {
+	artifacts, err := s.db.ListArtifacts(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	videoReports := make(map[string]db.VideoQCReport)
+	audioReports := make(map[string]db.AudioQCReport)
+	for _, artifact := range artifacts {
+		switch artifact.Kind {
+		case videoQCArtifactKind:
+			var report db.VideoQCReport
+			if json.Unmarshal(artifact.Data, &report) == nil {
+				videoReports[report.Path] = report
+			}
+		case audioQCArtifactKind:
+			var report db.AudioQCReport
+			if json.Unmarshal(artifact.Data, &report) == nil {
+				audioReports[report.Path] = report
+			}
+		}
+	}
+	details := make(map[string]*renditionDetails, len(status.Output.Files))
+	for _, file := range status.Output.Files {
+		rendition := renditionDetails{
+			Path:       file.Path,
+			Container:  file.Container,
+			VideoCodec: file.VideoCodec,
+			Width:      file.Width,
+			Height:     file.Height,
+		}
+		container := strings.ToLower(file.Container)
+		if fingerprintContainers[container] || audioContainers[container] {
+			s.probeRendition(&rendition)
+		}
+		if report, ok := videoReports[file.Path]; ok {
+			rendition.MaxBlockiness = &report.MaxBlockiness
+		}
+		if report, ok := audioReports[file.Path]; ok {
+			rendition.IntegratedLoudness = &report.IntegratedLoudness
+		}
+		name := renditionName(job, file.Path)
+		if _, ok := details[name]; ok {
+			name += "/" + path.Base(file.Path)
+		}
+		details[name] = &rendition
+	}
+	return details, nil
+}
+
+// probeRendition measures the duration, the bitrate and the size of the
+// rendition with ffmpeg.
+func (s *TranscodingService) probeRendition(rendition *renditionDetails) {
+	input, err := s.analyzer.input(rendition.Path)
+	if err != nil {
+		rendition.ProbeError = err.Error()
+		return
+	}
+	info, err := s.analyzer.probe(input)
+	if err != nil {
+		rendition.ProbeError = err.Error()
+		return
+	}
+	rendition.Duration = info.Duration
+	rendition.Bitrate = info.Bitrate
+	rendition.Size = info.Size()
+}
+
+// renditionName returns the presetmap of the output that generated the
+// given file, or the name of the file when it doesn't belong to any output.
+func renditionName(job *db.Job, filePath string) string {
+	for _, output := range job.Outputs {
+		if output.FileName != "" && (filePath == output.FileName || strings.HasSuffix(filePath, "/"+output.FileName)) {
+			return output.Preset
+		}
+	}
+	return path.Base(filePath)
+}
+
+// compareRenditions returns the differences of the rendition of the other
+// job to the rendition of the base job.
+func compareRenditions(base, other *renditionDetails) *renditionDifferences {
+	differences := renditionDifferences{
+		Duration:          other.Duration - base.Duration,
+		Bitrate:           relativeDifference(float64(base.Bitrate), float64(other.Bitrate)),
+		Size:              relativeDifference(float64(base.Size), float64(other.Size)),
+		ResolutionChanged: base.Width != other.Width || base.Height != other.Height,
+	}
+	if base.MaxBlockiness != nil && other.MaxBlockiness != nil {
+		difference := *other.MaxBlockiness - *base.MaxBlockiness
+		differences.MaxBlockiness = &difference
+	}
+	if base.IntegratedLoudness != nil && other.IntegratedLoudness != nil {
+		difference := *other.IntegratedLoudness - *base.IntegratedLoudness
+		differences.IntegratedLoudness = &difference
+	}
+	return &differences
+}
@@ -0,0 +1,130 @@
+package service
+
+import "net/http"
+
+// swagger:parameters compareJobs
+type compareJobsInput struct {
+	// id of the job used as the base of the comparison
+	//
+	// in: path
+	// required: true
+	JobID string `json:"jobId"`
+
+	// id of the job compared to the base job
+	//
+	// in: path
+	// required: true
+	OtherJobID string `json:"otherJobId"`
+}
+
+func (p *compareJobsInput) loadParams(paramsMap map[string]string) {
+	p.JobID = paramsMap["jobId"]
+	p.OtherJobID = paramsMap["otherJobId"]
+}
+
+// Comparison of the outputs of two jobs.
+//
+// swagger:model
+type jobComparison struct {
+	// id of the base job
+	JobID string `json:"jobId"`
+
+	// id of the job compared to the base job
+	OtherJobID string `json:"otherJobId"`
+
+	// renditions of the jobs, matched by presetmap (or by file name, for
+	// files that don't belong to any output, like thumbnails), sorted by
+	// name
+	Renditions []renditionComparison `json:"renditions"`
+}
+
+// renditionComparison compares a rendition in both jobs. Renditions missing
+// from one of the jobs have only the details of the other job.
+type renditionComparison struct {
+	// name of the presetmap of the rendition, or the name of the file
+	Name string `json:"name"`
+
+	// rendition in the base job
+	Job *renditionDetails `json:"job,omitempty"`
+
+	// rendition in the other job
+	Other *renditionDetails `json:"other,omitempty"`
+
+	// differences of the other job to the base job, for renditions
+	// present in both jobs
+	Differences *renditionDifferences `json:"differences,omitempty"`
+}
+
+// renditionDetails describes an output file of a job. The duration, the
+// bitrate and the size are measured by ffmpeg, and the QC scores are taken
+// from the QC reports attached to the job.
+type renditionDetails struct {
+	Path       string `json:"path"`
+	Container  string `json:"container,omitempty"`
+	VideoCodec string `json:"videoCodec,omitempty"`
+	Width      int64  `json:"width,omitempty"`
+	Height     int64  `json:"height,omitempty"`
+
+	// duration of the file, in seconds
+	Duration float64 `json:"duration,omitempty"`
+
+	// overall bitrate of the file, in bits per second
+	Bitrate int64 `json:"bitrate,omitempty"`
+
+	// approximate size of the file, in bytes, computed from its duration
+	// and its bitrate
+	Size int64 `json:"size,omitempty"`
+
+	// blockiness of the blockiest frame, from the video QC
+	MaxBlockiness *float64 `json:"maxBlockiness,omitempty"`
+
+	// integrated loudness, in LUFS, from the audio QC
+	IntegratedLoudness *float64 `json:"integratedLoudness,omitempty"`
+
+	// error measuring the file with ffmpeg
+	ProbeError string `json:"probeError,omitempty"`
+}
+
+// renditionDifferences are the differences of a rendition of the other job
+// to the same rendition of the base job.
+type renditionDifferences struct {
+	// difference of the durations, in seconds
+	Duration float64 `json:"duration"`
+
+	// difference of the bitrates, as a percentage of the bitrate of the
+	// base job
+	Bitrate float64 `json:"bitrate"`
+
+	// difference of the sizes, as a percentage of the size in the base
+	// job
+	Size float64 `json:"size"`
+
+	// whether the resolutions of the renditions differ
+	ResolutionChanged bool `json:"resolutionChanged,omitempty"`
+
+	// difference of the blockiness, when both jobs ran the video QC
+	MaxBlockiness *float64 `json:"maxBlockiness,omitempty"`
+
+	// difference of the integrated loudness, in LU, when both jobs ran the
+	// audio QC
+	IntegratedLoudness *float64 `json:"integratedLoudness,omitempty"`
+}
+
+// JSON-encoded comparison returned on the compareJobs operation.
+//
+// swagger:response jobComparison
+type jobComparisonResponse struct {
+	// in: body
+	Payload *jobComparison
+
+	baseResponse
+}
+
+func newJobComparisonResponse(comparison *jobComparison) *jobComparisonResponse {
+	return &jobComparisonResponse{
+		baseResponse: baseResponse{
+			payload: comparison,
+			status:  http.StatusOK,
+		},
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestCompareJobs(t *testing.T) {
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreateJob(&db.Job{
+		ID:            "job-1",
+		ProviderName:  "fake",
+		ProviderJobID: "provider-job-with-outputs",
+		Outputs: []db.TranscodeOutput{
+			{Preset: "mp4_720p", FileName: "video_720p.mp4"},
+			{Preset: "webm_1080p", FileName: "video_1080p.webm"},
+		},
+	})
+	fakeDB.CreateJob(&db.Job{
+		ID:            "job-2",
+		ProviderName:  "fake",
+		ProviderJobID: "provider-job-with-outputs",
+		Outputs:       []db.TranscodeOutput{{Preset: "mp4_720p", FileName: "video_720p.mp4"}},
+	})
+	fakeDB.CreateArtifact(videoQCArtifact("job-1", db.VideoQCReport{Path: "s3://mybucket/some/dir/job-1/video_720p.mp4", MaxBlockiness: 4}))
+	fakeDB.CreateArtifact(videoQCArtifact("job-2", db.VideoQCReport{Path: "s3://mybucket/some/dir/job-2/video_720p.mp4", MaxBlockiness: 5.5}))
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	service.analyzer = &mediaAnalyzer{
+		presign: func(bucket, key string) (string, error) {
+			return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+		},
+		probe: func(input string) (*ffmpeg.MediaInfo, error) {
+			switch {
+			case strings.Contains(input, "job-1/video_720p.mp4"):
+				return &ffmpeg.MediaInfo{Duration: 60, Bitrate: 2000000}, nil
+			case strings.Contains(input, "job-2/video_720p.mp4"):
+				return &ffmpeg.MediaInfo{Duration: 60.5, Bitrate: 1500000}, nil
+			case strings.Contains(input, "job-1/video_1080p.webm"):
+				return &ffmpeg.MediaInfo{Duration: 60, Bitrate: 4000000}, nil
+			}
+			return nil, errors.New("invalid data found when processing input")
+		},
+	}
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	srvr.Register(service)
+	r, _ := http.NewRequest("GET", "/jobs/job-1/compare/job-2", nil)
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var got jobComparison
+	if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	blockiness := func(value float64) *float64 { return &value }
+	want := jobComparison{
+		JobID:      "job-1",
+		OtherJobID: "job-2",
+		Renditions: []renditionComparison{
+			{
+				Name: "mp4_720p",
+				Job: &renditionDetails{
+					Path:          "s3://mybucket/some/dir/job-1/video_720p.mp4",
+					Container:     "mp4",
+					VideoCodec:    "h264",
+					Width:         1280,
+					Height:        720,
+					Duration:      60,
+					Bitrate:       2000000,
+					Size:          15000000,
+					MaxBlockiness: blockiness(4),
+				},
+				Other: &renditionDetails{
+					Path:          "s3://mybucket/some/dir/job-2/video_720p.mp4",
+					Container:     "mp4",
+					VideoCodec:    "h264",
+					Width:         1280,
+					Height:        720,
+					Duration:      60.5,
+					Bitrate:       1500000,
+					Size:          11343750,
+					MaxBlockiness: blockiness(5.5),
+				},
+				Differences: &renditionDifferences{
+					Duration:      0.5,
+					Bitrate:       -25,
+					Size:          -24.375,
+					MaxBlockiness: blockiness(1.5),
+				},
+			},
+			{
+				Name: "video_1080p.webm",
+				Other: &renditionDetails{
+					Path:       "s3://mybucket/some/dir/job-2/video_1080p.webm",
+					Container:  "webm",
+					VideoCodec: "vp9",
+					Width:      1920,
+					Height:     1080,
+					ProbeError: "invalid data found when processing input",
+				},
+			},
+			{
+				Name: "webm_1080p",
+				Job: &renditionDetails{
+					Path:       "s3://mybucket/some/dir/job-1/video_1080p.webm",
+					Container:  "webm",
+					VideoCodec: "vp9",
+					Width:      1920,
+					Height:     1080,
+					Duration:   60,
+					Bitrate:    4000000,
+					Size:       30000000,
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong comparison.\nWant %#v\nGot  %#v", want, got)
+	}
+
+	r, _ = http.NewRequest("GET", "/jobs/job-1/compare/job-3", nil)
+	w = httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("wrong response code for unknown job. Want %d. Got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+var (
+	errConformFrameRate = errors.New("conform jobs require the frame rate of the source")
+	errConformRanges    = errors.New("conform jobs require either a list of ranges or an EDL")
+
+	timecodeRegexp = regexp.MustCompile(`^(\d{2}):(\d{2}):(\d{2})([:;])(\d{2})$`)
+)
+
+// resolve validates the conform parameters, returning the ranges to
+// transcode. Ranges are read from the EDL when one is provided.
+func (p *ConformParams) resolve() (*db.Conform, error) {
+	if p.FrameRate <= 0 {
+		return nil, errConformFrameRate
+	}
+	ranges := p.Ranges
+	if p.EDL != "" {
+		if len(ranges) > 0 {
+			return nil, errors.New("conform ranges and EDL can't be combined")
+		}
+		var err error
+		if ranges, err = parseEDL(p.EDL); err != nil {
+			return nil, err
+		}
+	}
+	if len(ranges) == 0 {
+		return nil, errConformRanges
+	}
+	for i, r := range ranges {
+		in, err := parseTimecode(r.In, p.FrameRate)
+		if err != nil {
+			return nil, fmt.Errorf("range %d: %s", i+1, err)
+		}
+		out, err := parseTimecode(r.Out, p.FrameRate)
+		if err != nil {
+			return nil, fmt.Errorf("range %d: %s", i+1, err)
+		}
+		if out <= in {
+			return nil, fmt.Errorf("range %d: out point %s must be after the in point %s", i+1, r.Out, r.In)
+		}
+	}
+	return &db.Conform{FrameRate: p.FrameRate, Ranges: ranges}, nil
+}
+
+// parseEDL returns the source ranges of the video events in the given CMX
+// 3600 edit decision list, in the order of the events. Comments, titles and
+// audio-only events are ignored.
+func parseEDL(edl string) ([]db.EditRange, error) {
+	var ranges []db.EditRange
+	scanner := bufio.NewScanner(strings.NewReader(edl))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			// TITLE, FCM and comment lines
+			continue
+		}
+		// <event> <reel> <track> <transition> [<duration>] <src in> <src out> <rec in> <rec out>
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("invalid EDL event in line %d", line)
+		}
+		track := strings.ToUpper(fields[2])
+		if !strings.Contains(track, "V") && track != "B" {
+			continue
+		}
+		timecodes := fields[len(fields)-4:]
+		ranges = append(ranges, db.EditRange{In: timecodes[0], Out: timecodes[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, errors.New("the EDL doesn't contain any video event")
+	}
+	return ranges, nil
+}
+
+// parseTimecode returns the number of frames from the start of the source
+// to the given SMPTE timecode. Timecodes using ";" as the frame separator
+// are interpreted as drop-frame timecodes.
+func parseTimecode(timecode string, frameRate float64) (int64, error) {
+	parts := timecodeRegexp.FindStringSubmatch(timecode)
+	if parts == nil {
+		return 0, fmt.Errorf("invalid timecode %q", timecode)
+	}
+	var values [4]int64
+	for i, part := range []string{parts[1], parts[2], parts[3], parts[5]} {
+		values[i], _ = strconv.ParseInt(part, 10, 64)
+	}
+	hours, minutes, seconds, frames := values[0], values[1], values[2], values[3]
+	fps := int64(math.Floor(frameRate + 0.5))
+	if minutes > 59 || seconds > 59 || frames >= fps {
+		return 0, fmt.Errorf("invalid timecode %q for %g fps", timecode, frameRate)
+	}
+	total := ((hours*60+minutes)*60+seconds)*fps + frames
+	if parts[4] == ";" {
+		if fps%30 != 0 {
+			return 0, fmt.Errorf("drop-frame timecode %q is only valid for 29.97 and 59.94 fps", timecode)
+		}
+		dropped := fps / 15
+		if seconds == 0 && minutes%10 != 0 && frames < dropped {
+			return 0, fmt.Errorf("invalid drop-frame timecode %q", timecode)
+		}
+		totalMinutes := hours*60 + minutes
+		total -= dropped * (totalMinutes - totalMinutes/10)
+	}
+	return total, nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+const testEDL = `TITLE: EVENT HIGHLIGHTS
+FCM: NON-DROP FRAME
+
+001  AX       V     C        01:02:03:04 01:02:13:00 00:00:00:00 00:00:09:21
+* FROM CLIP NAME: event.mov
+002  AX       AA    C        01:02:03:04 01:02:13:00 00:00:00:00 00:00:09:21
+003  AX       B     D    012 02:10:00:00 02:10:05:10 00:00:09:21 00:00:15:06
+`
+
+func TestParseTimecode(t *testing.T) {
+	var tests = []struct {
+		timecode   string
+		frameRate  float64
+		wantFrames int64
+		wantError  string
+	}{
+		{"00:00:00:00", 25, 0, ""},
+		{"01:02:03:04", 25, 93079, ""},
+		{"00:00:01:23", 23.976, 47, ""},
+		{"00:01:00;02", 29.97, 1800, ""},
+		{"00:10:00;00", 29.97, 17982, ""},
+		{"01:00:00;00", 59.94, 215784, ""},
+		{"00:00:01:25", 25, 0, `invalid timecode "00:00:01:25" for 25 fps`},
+		{"00:60:00:00", 25, 0, `invalid timecode "00:60:00:00" for 25 fps`},
+		{"1:00:00:00", 25, 0, `invalid timecode "1:00:00:00"`},
+		{"00:01:00;00", 29.97, 0, `invalid drop-frame timecode "00:01:00;00"`},
+		{"00:01:00;00", 25, 0, `drop-frame timecode "00:01:00;00" is only valid for 29.97 and 59.94 fps`},
+	}
+	for _, test := range tests {
+		frames, err := parseTimecode(test.timecode, test.frameRate)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.wantError {
+			t.Errorf("%s at %g fps: wrong error. Want %q. Got %q", test.timecode, test.frameRate, test.wantError, err.Error())
+		}
+		if frames != test.wantFrames {
+			t.Errorf("%s at %g fps: wrong frames. Want %d. Got %d", test.timecode, test.frameRate, test.wantFrames, frames)
+		}
+	}
+}
+
+func TestConformParamsResolve(t *testing.T) {
+	var tests = []struct {
+		testCase   string
+		params     ConformParams
+		wantRanges []db.EditRange
+		wantError  string
+	}{
+		{
+			"ranges",
+			ConformParams{FrameRate: 25, Ranges: []db.EditRange{{In: "01:00:00:00", Out: "01:00:10:00"}}},
+			[]db.EditRange{{In: "01:00:00:00", Out: "01:00:10:00"}},
+			"",
+		},
+		{
+			"edl",
+			ConformParams{FrameRate: 25, EDL: testEDL},
+			[]db.EditRange{
+				{In: "01:02:03:04", Out: "01:02:13:00"},
+				{In: "02:10:00:00", Out: "02:10:05:10"},
+			},
+			"",
+		},
+		{
+			"missing frame rate",
+			ConformParams{Ranges: []db.EditRange{{In: "01:00:00:00", Out: "01:00:10:00"}}},
+			nil,
+			"conform jobs require the frame rate of the source",
+		},
+		{
+			"missing ranges",
+			ConformParams{FrameRate: 25},
+			nil,
+			"conform jobs require either a list of ranges or an EDL",
+		},
+		{
+			"ranges and edl",
+			ConformParams{FrameRate: 25, EDL: testEDL, Ranges: []db.EditRange{{In: "01:00:00:00", Out: "01:00:10:00"}}},
+			nil,
+			"conform ranges and EDL can't be combined",
+		},
+		{
+			"edl without video events",
+			ConformParams{FrameRate: 25, EDL: "TITLE: AUDIO\n001  AX       A     C        01:00:00:00 01:00:10:00 00:00:00:00 00:00:10:00\n"},
+			nil,
+			"the EDL doesn't contain any video event",
+		},
+		{
+			"invalid edl event",
+			ConformParams{FrameRate: 25, EDL: "001  AX       V     C        01:00:00:00\n"},
+			nil,
+			"invalid EDL event in line 1",
+		},
+		{
+			"out before in",
+			ConformParams{FrameRate: 25, Ranges: []db.EditRange{{In: "01:00:00:00", Out: "01:00:10:00"}, {In: "01:00:10:00", Out: "01:00:10:00"}}},
+			nil,
+			"range 2: out point 01:00:10:00 must be after the in point 01:00:10:00",
+		},
+		{
+			"invalid timecode",
+			ConformParams{FrameRate: 24, Ranges: []db.EditRange{{In: "01:00:00:00", Out: "01:00:10:24"}}},
+			nil,
+			`range 1: invalid timecode "01:00:10:24" for 24 fps`,
+		},
+	}
+	for _, test := range tests {
+		conform, err := test.params.resolve()
+		if test.wantError != "" {
+			if err == nil || err.Error() != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.wantError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if conform.FrameRate != test.params.FrameRate || !reflect.DeepEqual(conform.Ranges, test.wantRanges) {
+			t.Errorf("%s: wrong conform\nWant %#v\nGot  %#v", test.testCase, test.wantRanges, conform)
+		}
+	}
+}
+
+func TestTranscodeConform(t *testing.T) {
+	fprovider.jobs = nil
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "18828"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	body := `{"source":"s3://bucket/event.mov","provider":"fake","conform":{"frameRate":25,"ranges":[{"in":"01:02:03:04","out":"01:02:13:00"}]},"outputs":[{"preset":"mp4_1080p","fileName":"highlight.mp4"}]}`
+	r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var got map[string]interface{}
+	if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	expected := &db.Conform{FrameRate: 25, Ranges: []db.EditRange{{In: "01:02:03:04", Out: "01:02:13:00"}}}
+	if len(fprovider.jobs) != 1 || !reflect.DeepEqual(fprovider.jobs[0].Conform, expected) {
+		t.Errorf("wrong conform submitted to the provider. Want %#v. Got %#v", expected, fprovider.jobs)
+	}
+	job, err := fakeDB.GetJob(got["jobId"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(job.Conform, expected) {
+		t.Errorf("wrong conform recorded in the job. Want %#v. Got %#v", expected, job.Conform)
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+var errDeadlinePassed = errors.New("deadline must be in the future")
+
+// deadlineProviders returns the providers that jobs with deadlines may be
+// routed to besides the requested one, in order of preference.
+func (s *TranscodingService) deadlineProviders() []string {
+	if s.config.Deadlines == nil {
+		return nil
+	}
+	return splitList(s.config.Deadlines.Providers)
+}
+
+// routeDeadline picks the provider of a new job with a deadline: the first
+// candidate (the requested provider, then the deadline providers) expected
+// to finish the job in time, based on the jobs finished in each provider.
+// When none is, the job goes to the fastest candidate with high priority.
+// The requested provider is kept when no candidate has finished jobs.
+func (s *TranscodingService) routeDeadline(payload *NewTranscodeJobInputPayload) error {
+	now := s.predictor.now()
+	if !payload.Deadline.After(now) {
+		return errDeadlinePassed
+	}
+	available := payload.Deadline.Sub(now)
+	var fastest string
+	var fastestEstimate time.Duration
+	for _, name := range s.deadlineCandidates(payload.Provider) {
+		if name != payload.Provider && !s.mapsPresets(name, payload.Outputs) {
+			continue
+		}
+		estimate, ok := s.predictor.estimate(name)
+		if !ok {
+			continue
+		}
+		if estimate <= available {
+			payload.Provider = name
+			return nil
+		}
+		if fastest == "" || estimate < fastestEstimate {
+			fastest, fastestEstimate = name, estimate
+		}
+	}
+	if fastest != "" {
+		payload.Provider = fastest
+		payload.Priority = db.PriorityHigh
+	}
+	return nil
+}
+
+func (s *TranscodingService) deadlineCandidates(requested string) []string {
+	var candidates []string
+	if requested != "" {
+		candidates = append(candidates, requested)
+	}
+	for _, name := range s.deadlineProviders() {
+		if !containsString(candidates, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	return candidates
+}
+
+// mapsPresets returns whether the presetmaps of all the given outputs have
+// a preset in the given provider.
+func (s *TranscodingService) mapsPresets(providerName string, outputs []db.TranscodeOutput) bool {
+	for _, output := range outputs {
+		presetMap, err := s.db.GetPresetMap(output.Preset)
+		if err != nil {
+			return false
+		}
+		if _, ok := presetMap.ProviderMapping[providerName]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// RunDeadlineWatchdog periodically checks the active jobs with deadlines,
+// until the given channel is closed. It returns immediately when the
+// watchdog is disabled in the configuration.
+func (s *TranscodingService) RunDeadlineWatchdog(stop <-chan struct{}) {
+	cfg := s.config.Deadlines
+	if cfg == nil || cfg.WatchdogInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.WatchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.watchDeadlines()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// watchDeadlines fails over the active jobs at risk of missing their
+// deadlines to a provider expected to finish them in time, and escalates
+// them when there's none. It doesn't run while the API is in maintenance
+// mode.
+func (s *TranscodingService) watchDeadlines() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	jobs, err := s.db.ListJobs(db.JobFilter{})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list jobs for checking their deadlines")
+		return
+	}
+	for _, listed := range jobs {
+		if listed.Deadline == nil || listed.ProviderJobID == "" || isTerminal(provider.Status(listed.Status)) {
+			continue
+		}
+		job, status, p, err := s.getTranscodeJobByID(listed.ID)
+		if err != nil {
+			s.logger.WithError(err).WithField("jobId", listed.ID).Error("failed to check the deadline of job")
+			continue
+		}
+		if p == nil || isTerminal(status.Status) || !s.deadlineAtRisk(job, status) {
+			continue
+		}
+		if s.failOverDeadline(job, p) || job.Deadline.Escalated {
+			continue
+		}
+		s.escalateDeadline(job, status)
+	}
+}
+
+// deadlineAtRisk returns whether the job is expected to finish after its
+// deadline. The completion time is extrapolated from the progress of the
+// job or, before it reports any progress, from the jobs finished in its
+// provider.
+func (s *TranscodingService) deadlineAtRisk(job *db.Job, status *provider.JobStatus) bool {
+	now := s.predictor.now()
+	deadline := job.Deadline.Time
+	if now.After(deadline) {
+		return true
+	}
+	start := job.Deadline.SubmissionTime
+	if start.IsZero() {
+		start = job.CreationTime
+	}
+	var completion time.Time
+	if status.Progress > 0 {
+		elapsed := now.Sub(start)
+		completion = start.Add(time.Duration(float64(elapsed) * 100 / status.Progress))
+	} else if estimate, ok := s.predictor.estimate(job.ProviderName); ok {
+		completion = start.Add(estimate)
+	} else {
+		return false
+	}
+	return completion.After(deadline)
+}
+
+// failOverDeadline resubmits the job to the first deadline provider
+// expected to finish it before its deadline, canceling it in the current
+// provider. It returns false when there's no such provider.
+func (s *TranscodingService) failOverDeadline(job *db.Job, current provider.TranscodingProvider) bool {
+	now := s.predictor.now()
+	remaining := job.Deadline.Time.Sub(now)
+	for _, name := range s.deadlineProviders() {
+		if name == job.ProviderName || containsString(job.Deadline.FailedOver, name) {
+			continue
+		}
+		if estimate, ok := s.predictor.estimate(name); !ok || estimate > remaining {
+			continue
+		}
+		logger := s.logger.WithField("jobId", job.ID).WithField("provider", name)
+		transcodeProfile, err := s.transcodeProfile(job, job.SourceMedia)
+		if err != nil {
+			logger.WithError(err).Warn("skipping deadline provider")
+			continue
+		}
+		failoverJob := *job
+		failoverJob.ProviderName = name
+		p, err := s.fallbackProvider(name, &failoverJob, transcodeProfile)
+		if err != nil {
+			logger.WithError(err).Warn("skipping deadline provider")
+			continue
+		}
+		if err = s.prepareSource(&failoverJob, p, &transcodeProfile); err != nil {
+			logger.WithError(err).Warn("skipping deadline provider")
+			continue
+		}
+		status, err := p.Transcode(&failoverJob, transcodeProfile)
+		if err != nil {
+			logger.WithError(err).Warn("failed to fail over job to deadline provider")
+			continue
+		}
+		if err = current.CancelJob(job.ProviderJobID); err != nil {
+			logger.WithError(err).Warn("failed to cancel job in the previous provider")
+		}
+		deadline := *job.Deadline
+		deadline.FailedOver = append(append([]string(nil), job.Deadline.FailedOver...), job.ProviderName)
+		deadline.SubmissionTime = now
+		failoverJob.Deadline = &deadline
+		failoverJob.ProviderJobID = status.ProviderJobID
+		failoverJob.Status = string(status.Status)
+		if err = s.db.UpdateJob(&failoverJob); err != nil {
+			logger.WithError(err).Error("failed to update job failed over to deadline provider")
+			return true
+		}
+		logger.WithField("previousProvider", job.ProviderName).Warn("failed over job at risk of missing its deadline")
+		*job = failoverJob
+		return true
+	}
+	return false
+}
+
+// escalateDeadline flags the job as escalated, notifying its callback URL
+// with the given status.
+func (s *TranscodingService) escalateDeadline(job *db.Job, status *provider.JobStatus) {
+	deadline := *job.Deadline
+	deadline.Escalated = true
+	job.Deadline = &deadline
+	logger := s.logger.WithFields(logrus.Fields{
+		"jobId":        job.ID,
+		"providerName": job.ProviderName,
+		"deadline":     deadline.Time,
+	})
+	if err := s.db.UpdateJob(job); err != nil {
+		logger.WithError(err).Error("failed to escalate job")
+		return
+	}
+	logger.Warn("job at risk of missing its deadline")
+	status.Deadline = job.Deadline
+	if job.CallbackURL != "" {
+		if err := s.notify(job, status); err != nil {
+			logger.WithError(err).Error("failed to notify job")
+		}
+	}
+}
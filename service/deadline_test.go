@@ -0,0 +1,260 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func init() {
+	provider.Register("fake-deadline", deadlineProviderFactory)
+}
+
+// deadlineProvider is a provider that queues all jobs.
+type deadlineProvider struct {
+	fakeProvider
+}
+
+var dprovider deadlineProvider
+
+func (p *deadlineProvider) Transcode(job *db.Job, transcodeProfile provider.TranscodeProfile) (*provider.JobStatus, error) {
+	p.jobs = append(p.jobs, transcodeProfile)
+	return &provider.JobStatus{ProviderJobID: "deadline-" + job.ID, Status: provider.StatusQueued}, nil
+}
+
+// deadlineProviderFactory only initializes the provider when deadline
+// providers are configured, keeping it out of the listings of providers in
+// the other tests.
+func deadlineProviderFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
+	if cfg.Deadlines == nil || cfg.Deadlines.Providers == "" {
+		return nil, errors.New("deadline providers not configured")
+	}
+	return &dprovider, nil
+}
+
+func TestTranscodeDeadlineRouting(t *testing.T) {
+	now := time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		testCase     string
+		givenHistory map[string]time.Duration
+		givenMapping map[string]string
+		givenIn      time.Duration
+
+		wantCode     int
+		wantError    string
+		wantProvider string
+		wantPriority string
+	}{
+		{
+			"requested provider in time",
+			map[string]time.Duration{"fake": 10 * time.Minute, "fake-deadline": 2 * time.Minute},
+			map[string]string{"fake": "preset-1", "fake-deadline": "preset-2"},
+			30 * time.Minute,
+			http.StatusOK,
+			"",
+			"fake",
+			"",
+		},
+		{
+			"faster provider in time",
+			map[string]time.Duration{"fake": 10 * time.Minute, "fake-deadline": 2 * time.Minute},
+			map[string]string{"fake": "preset-1", "fake-deadline": "preset-2"},
+			5 * time.Minute,
+			http.StatusOK,
+			"",
+			"fake-deadline",
+			"",
+		},
+		{
+			"no provider in time",
+			map[string]time.Duration{"fake": 10 * time.Minute, "fake-deadline": 2 * time.Minute},
+			map[string]string{"fake": "preset-1", "fake-deadline": "preset-2"},
+			time.Minute,
+			http.StatusOK,
+			"",
+			"fake-deadline",
+			db.PriorityHigh,
+		},
+		{
+			"faster provider without the preset",
+			map[string]time.Duration{"fake": 10 * time.Minute, "fake-deadline": 2 * time.Minute},
+			map[string]string{"fake": "preset-1"},
+			5 * time.Minute,
+			http.StatusOK,
+			"",
+			"fake",
+			db.PriorityHigh,
+		},
+		{
+			"no history",
+			nil,
+			map[string]string{"fake": "preset-1", "fake-deadline": "preset-2"},
+			time.Minute,
+			http.StatusOK,
+			"",
+			"fake",
+			"",
+		},
+		{
+			"deadline in the past",
+			nil,
+			map[string]string{"fake": "preset-1"},
+			-time.Minute,
+			http.StatusBadRequest,
+			"deadline must be in the future",
+			"",
+			"",
+		},
+	}
+	for _, test := range tests {
+		fprovider = fakeProvider{}
+		dprovider = deadlineProvider{}
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: test.givenMapping,
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service, err := NewTranscodingService(&config.Config{Deadlines: &config.Deadlines{Providers: "fake-deadline"}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		service.predictor.now = func() time.Time { return now }
+		for name, elapsed := range test.givenHistory {
+			service.predictor.history[name] = []finishedJob{{id: "finished-" + name, elapsed: elapsed}}
+		}
+		srvr.Register(service)
+		body := `{
+			"source": "http://another.non.existent/video.mp4",
+			"destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+			"outputs": [{"preset": "mp4_1080p"}],
+			"deadline": "` + now.Add(test.givenIn).Format(time.RFC3339) + `",
+			"provider": "fake"
+		}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.testCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if test.wantError != "" {
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %#v", test.testCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		jobID, _ := got["jobId"].(string)
+		job, err := fakeDB.GetJob(jobID)
+		if err != nil {
+			t.Errorf("%s: job not created: %s", test.testCase, err)
+			continue
+		}
+		if job.ProviderName != test.wantProvider {
+			t.Errorf("%s: wrong provider. Want %q. Got %q", test.testCase, test.wantProvider, job.ProviderName)
+		}
+		if job.Priority != test.wantPriority {
+			t.Errorf("%s: wrong priority. Want %q. Got %q", test.testCase, test.wantPriority, job.Priority)
+		}
+		wantDeadline := db.Deadline{Time: now.Add(test.givenIn), SubmissionTime: now}
+		if job.Deadline == nil || !reflect.DeepEqual(*job.Deadline, wantDeadline) {
+			t.Errorf("%s: wrong deadline.\nWant %#v\nGot  %#v", test.testCase, wantDeadline, job.Deadline)
+		}
+	}
+}
+
+func TestWatchDeadlines(t *testing.T) {
+	now := time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		testCase       string
+		givenProviders string
+		givenProgress  float64
+
+		wantProvider      string
+		wantProviderJobID string
+		wantDeadline      db.Deadline
+	}{
+		{
+			"job in time",
+			"fake-deadline",
+			90,
+			"fake",
+			"chaos-job-123",
+			db.Deadline{Time: now.Add(30 * time.Minute), SubmissionTime: now.Add(-20 * time.Minute)},
+		},
+		{
+			"job failed over",
+			"fake-deadline",
+			10,
+			"fake-deadline",
+			"deadline-job-123",
+			db.Deadline{Time: now.Add(30 * time.Minute), SubmissionTime: now, FailedOver: []string{"fake"}},
+		},
+		{
+			"job escalated",
+			"",
+			10,
+			"fake",
+			"chaos-job-123",
+			db.Deadline{Time: now.Add(30 * time.Minute), SubmissionTime: now.Add(-20 * time.Minute), Escalated: true},
+		},
+	}
+	for _, test := range tests {
+		fprovider = fakeProvider{}
+		label := "chaos:stuck-at=" + strconv.FormatFloat(test.givenProgress, 'f', -1, 64)
+		if _, err := fprovider.chaosProvider().Transcode(&db.Job{ID: "job-123", Labels: []string{label}}, provider.TranscodeProfile{}); err != nil {
+			t.Fatal(err)
+		}
+		dprovider = deadlineProvider{}
+		service, err := NewTranscodingService(&config.Config{Deadlines: &config.Deadlines{Providers: test.givenProviders}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.db.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "preset-1", "fake-deadline": "preset-2"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service.db.CreateJob(&db.Job{
+			ID:            "job-123",
+			ProviderName:  "fake",
+			ProviderJobID: "chaos-job-123",
+			Status:        "started",
+			SourceMedia:   "http://another.non.existent/video.mp4",
+			Outputs:       []db.TranscodeOutput{{Preset: "mp4_1080p", FileName: "video.mp4"}},
+			Deadline:      &db.Deadline{Time: now.Add(30 * time.Minute), SubmissionTime: now.Add(-20 * time.Minute)},
+		})
+		service.predictor.now = func() time.Time { return now }
+		service.predictor.history["fake-deadline"] = []finishedJob{{id: "finished-job", elapsed: 5 * time.Minute}}
+		service.watchDeadlines()
+		job, err := service.db.GetJob("job-123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.ProviderName != test.wantProvider || job.ProviderJobID != test.wantProviderJobID {
+			t.Errorf("%s: wrong provider job. Want %s/%s. Got %s/%s", test.testCase, test.wantProvider, test.wantProviderJobID, job.ProviderName, job.ProviderJobID)
+		}
+		if job.Deadline == nil || !reflect.DeepEqual(*job.Deadline, test.wantDeadline) {
+			t.Errorf("%s: wrong deadline.\nWant %#v\nGot  %#v", test.testCase, test.wantDeadline, job.Deadline)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/transfer"
+)
+
+var errDecryptionStagingDisabled = errors.New("the provider can't decrypt sources and the decryption staging location is not configured")
+
+// sourceDecrypter resolves the keys of encrypted sources, and decrypts the
+// sources that providers can't decrypt natively into a staging location.
+type sourceDecrypter struct {
+	keys     map[string][]byte
+	staging  *transfer.HTTP
+	download func(source string, enc *db.SourceEncryption, key []byte) (io.ReadCloser, int64, error)
+}
+
+func newSourceDecrypter(cfg *config.SourceEncryption, client *http.Client) *sourceDecrypter {
+	d := sourceDecrypter{keys: make(map[string][]byte)}
+	d.download = func(source string, enc *db.SourceEncryption, key []byte) (io.ReadCloser, int64, error) {
+		return downloadSource(client, source, enc, key)
+	}
+	if cfg == nil {
+		return &d
+	}
+	for _, pair := range splitList(cfg.Keys) {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+		if err != nil {
+			continue
+		}
+		d.keys[strings.TrimSpace(parts[0])] = key
+	}
+	if cfg.StagingURL != "" {
+		d.staging = transfer.NewHTTP(cfg.StagingURL)
+	}
+	return &d
+}
+
+// key validates the given encryption settings, returning the key they
+// reference.
+func (d *sourceDecrypter) key(enc *db.SourceEncryption) ([]byte, error) {
+	key, ok := d.keys[enc.KeyReference]
+	if !ok {
+		return nil, fmt.Errorf("unknown source encryption key %q", enc.KeyReference)
+	}
+	switch enc.Mode {
+	case db.SourceEncryptionAES128:
+		if len(key) != 16 {
+			return nil, fmt.Errorf("source encryption key %q is not a 128-bit key", enc.KeyReference)
+		}
+		if iv, err := hex.DecodeString(enc.IV); err != nil || len(iv) != aes.BlockSize {
+			return nil, errors.New("aes-128 source encryption requires a 16 bytes hex encoded iv")
+		}
+	case db.SourceEncryptionSSEC:
+		if len(key) != 32 {
+			return nil, fmt.Errorf("source encryption key %q is not a 256-bit key", enc.KeyReference)
+		}
+	default:
+		return nil, fmt.Errorf("invalid source encryption mode %q", enc.Mode)
+	}
+	return key, nil
+}
+
+// check validates the encryption settings of a new job, for the given
+// provider.
+func (d *sourceDecrypter) check(enc *db.SourceEncryption, p provider.TranscodingProvider) error {
+	if enc == nil {
+		return nil
+	}
+	if _, err := d.key(enc); err != nil {
+		return err
+	}
+	if decrypter, ok := p.(provider.SourceDecrypter); ok && decrypter.DecryptsSource(enc.Mode) {
+		return nil
+	}
+	if d.staging == nil {
+		return errDecryptionStagingDisabled
+	}
+	return nil
+}
+
+// stage decrypts the given source and uploads it to the staging location,
+// returning the URL of the decrypted copy.
+func (d *sourceDecrypter) stage(jobID, source string, enc *db.SourceEncryption, key []byte) (string, error) {
+	if d.staging == nil {
+		return "", errDecryptionStagingDisabled
+	}
+	body, size, err := d.download(source, enc, key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	if enc.Mode == db.SourceEncryptionAES128 {
+		iv, _ := hex.DecodeString(enc.IV)
+		if body, size, err = decryptAES128(body, key, iv); err != nil {
+			return "", fmt.Errorf("error decrypting source %q: %s", source, err)
+		}
+		defer body.Close()
+	}
+	remotePath := jobID + "/" + path.Base(source)
+	if err = d.staging.Upload(remotePath, body, size); err != nil {
+		return "", fmt.Errorf("error staging decrypted source %q: %s", source, err)
+	}
+	return d.staging.URL(remotePath), nil
+}
+
+// prepareSource sets up the decryption of the source in the given profile,
+// and then trims the padding of the source for jobs with trimming enabled.
+func (s *TranscodingService) prepareSource(job *db.Job, p provider.TranscodingProvider, transcodeProfile *provider.TranscodeProfile) error {
+	if err := s.decryptSource(job, p, transcodeProfile); err != nil {
+		return err
+	}
+	return s.trimSource(job, transcodeProfile)
+}
+
+// decryptSource sets up the decryption of the source in the given profile.
+// Providers that decrypt sources natively get the key in the profile, while
+// for the other providers the source is decrypted into the staging
+// location.
+func (s *TranscodingService) decryptSource(job *db.Job, p provider.TranscodingProvider, transcodeProfile *provider.TranscodeProfile) error {
+	enc := job.SourceEncryption
+	if enc == nil {
+		return nil
+	}
+	key, err := s.decrypter.key(enc)
+	if err != nil {
+		return err
+	}
+	if decrypter, ok := p.(provider.SourceDecrypter); ok && decrypter.DecryptsSource(enc.Mode) {
+		iv, _ := hex.DecodeString(enc.IV)
+		transcodeProfile.SourceEncryption = &provider.SourceEncryption{Mode: enc.Mode, Key: key, IV: iv}
+		return nil
+	}
+	transcodeProfile.SourceMedia, err = s.decrypter.stage(job.ID, transcodeProfile.SourceMedia, enc, key)
+	return err
+}
+
+// decryptAES128 decrypts the AES-128-CBC encrypted body into a temporary
+// file, returning the file rewound for reading and its size. The file is
+// removed when closed.
+func decryptAES128(body io.Reader, key, iv []byte) (io.ReadCloser, int64, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := ioutil.TempFile("", "decrypted")
+	if err != nil {
+		return nil, 0, err
+	}
+	tmpFile := &tempFile{file}
+	size, err := decryptCBC(file, body, cipher.NewCBCDecrypter(block, iv))
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmpFile.Close()
+		return nil, 0, err
+	}
+	return tmpFile, size, nil
+}
+
+// decryptCBC decrypts r into w, removing the PKCS#7 padding. The last block
+// is held back until the end of the input, when the padding is known.
+func decryptCBC(w io.Writer, r io.Reader, mode cipher.BlockMode) (int64, error) {
+	var written int64
+	buf := make([]byte, 2048*aes.BlockSize)
+	var last []byte
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n%aes.BlockSize != 0 {
+			return written, errors.New("encrypted data is not a multiple of the block size")
+		}
+		if n > 0 {
+			if last != nil {
+				if _, err := w.Write(last); err != nil {
+					return written, err
+				}
+				written += int64(len(last))
+			}
+			mode.CryptBlocks(buf[:n], buf[:n])
+			if _, err := w.Write(buf[:n-aes.BlockSize]); err != nil {
+				return written, err
+			}
+			written += int64(n - aes.BlockSize)
+			last = append(last[:0], buf[n-aes.BlockSize:n]...)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	if last == nil {
+		return written, errors.New("empty encrypted data")
+	}
+	padding := int(last[len(last)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return written, errors.New("invalid padding")
+	}
+	for _, b := range last[len(last)-padding:] {
+		if int(b) != padding {
+			return written, errors.New("invalid padding")
+		}
+	}
+	n, err := w.Write(last[:len(last)-padding])
+	return written + int64(n), err
+}
+
+// tempFile is a temporary file that is removed when closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// downloadSource opens the given source for reading with the given client.
+// S3 URLs are translated to their HTTPS equivalent, and SSE-C encrypted
+// sources are requested with the customer key.
+func downloadSource(client *http.Client, source string, enc *db.SourceEncryption, key []byte) (io.ReadCloser, int64, error) {
+	if strings.HasPrefix(source, "s3://") {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, 0, err
+		}
+		source = "https://" + u.Host + ".s3.amazonaws.com" + u.Path
+	}
+	req, err := http.NewRequest("GET", source, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if enc.Mode == db.SourceEncryptionSSEC {
+		keyMD5 := md5.Sum(key)
+		req.Header.Set("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")
+		req.Header.Set("X-Amz-Server-Side-Encryption-Customer-Key", base64.StdEncoding.EncodeToString(key))
+		req.Header.Set("X-Amz-Server-Side-Encryption-Customer-Key-MD5", base64.StdEncoding.EncodeToString(keyMD5[:]))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("error fetching source %q: %s", source, resp.Status)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestSourceDecrypterKey(t *testing.T) {
+	decrypter := newSourceDecrypter(&config.SourceEncryption{
+		Keys: "archive:MDEyMzQ1Njc4OWFiY2RlZg==,vault:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+	}, http.DefaultClient)
+	var tests = []struct {
+		testCase  string
+		given     db.SourceEncryption
+		wantError string
+	}{
+		{
+			"aes-128",
+			db.SourceEncryption{Mode: "aes-128", KeyReference: "archive", IV: "000102030405060708090a0b0c0d0e0f"},
+			"",
+		},
+		{
+			"sse-c",
+			db.SourceEncryption{Mode: "sse-c", KeyReference: "vault"},
+			"",
+		},
+		{
+			"unknown key",
+			db.SourceEncryption{Mode: "sse-c", KeyReference: "nope"},
+			`unknown source encryption key "nope"`,
+		},
+		{
+			"aes-128 without iv",
+			db.SourceEncryption{Mode: "aes-128", KeyReference: "archive"},
+			"aes-128 source encryption requires a 16 bytes hex encoded iv",
+		},
+		{
+			"wrong key size",
+			db.SourceEncryption{Mode: "sse-c", KeyReference: "archive"},
+			`source encryption key "archive" is not a 256-bit key`,
+		},
+		{
+			"invalid mode",
+			db.SourceEncryption{Mode: "rot13", KeyReference: "archive"},
+			`invalid source encryption mode "rot13"`,
+		},
+	}
+	for _, test := range tests {
+		_, err := decrypter.key(&test.given)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.wantError {
+			t.Errorf("%s: wrong error. Want %q. Got %q", test.testCase, test.wantError, err.Error())
+		}
+	}
+}
+
+func TestDecryptAES128(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+	for _, size := range []int{0, 15, 16, 100000} {
+		plain := bytes.Repeat([]byte("m"), size)
+		body, gotSize, err := decryptAES128(bytes.NewReader(encryptAES128(plain, key, iv)), key, iv)
+		if err != nil {
+			t.Errorf("%d bytes: unexpected error: %s", size, err)
+			continue
+		}
+		data, _ := ioutil.ReadAll(body)
+		body.Close()
+		if !bytes.Equal(data, plain) || gotSize != int64(size) {
+			t.Errorf("%d bytes: wrong decrypted data (%d bytes, reported %d)", size, len(data), gotSize)
+		}
+	}
+	if _, _, err := decryptAES128(strings.NewReader("not encrypted"), key, iv); err == nil {
+		t.Error("unexpected <nil> error for invalid data")
+	}
+}
+
+func TestTranscodeEncryptedSource(t *testing.T) {
+	iv := []byte("fedcba9876543210")
+	var staged []byte
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			staged, _ = ioutil.ReadAll(r.Body)
+		}
+	}))
+	defer staging.Close()
+	tests := []struct {
+		givenTestCase string
+		givenConfig   config.SourceEncryption
+		givenIV       string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"source decrypted into the staging location",
+			config.SourceEncryption{Keys: "archive:MDEyMzQ1Njc4OWFiY2RlZg==", StagingURL: staging.URL + "/decrypted/"},
+			"66656463626139383736353433323130",
+			http.StatusOK,
+			"",
+		},
+		{
+			"staging location not configured",
+			config.SourceEncryption{Keys: "archive:MDEyMzQ1Njc4OWFiY2RlZg=="},
+			"66656463626139383736353433323130",
+			http.StatusBadRequest,
+			"the provider can't decrypt sources and the decryption staging location is not configured",
+		},
+		{
+			"invalid iv",
+			config.SourceEncryption{Keys: "archive:MDEyMzQ1Njc4OWFiY2RlZg==", StagingURL: staging.URL},
+			"abc",
+			http.StatusBadRequest,
+			"aes-128 source encryption requires a 16 bytes hex encoded iv",
+		},
+	}
+	for _, test := range tests {
+		fprovider.jobs = nil
+		staged = nil
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		givenConfig := test.givenConfig
+		service, err := NewTranscodingService(&config.Config{SourceEncryption: &givenConfig}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		service.decrypter.download = func(source string, enc *db.SourceEncryption, key []byte) (io.ReadCloser, int64, error) {
+			data := encryptAES128([]byte("mezzanine"), key, iv)
+			return ioutil.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+		}
+		srvr.Register(service)
+		body := `{"source":"s3://bucket/master.mov","provider":"fake","sourceEncryption":{"mode":"aes-128","keyReference":"archive","iv":"` + test.givenIV + `"},"outputs":[{"preset":"mp4_1080p","fileName":"video.mp4"}]}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+			continue
+		}
+		if test.wantError != "" {
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		jobID := got["jobId"].(string)
+		wantSource := staging.URL + "/decrypted/" + jobID + "/master.mov"
+		if len(fprovider.jobs) != 1 || fprovider.jobs[0].SourceMedia != wantSource {
+			t.Errorf("%s: wrong source submitted to the provider. Want %q. Got %#v", test.givenTestCase, wantSource, fprovider.jobs)
+		}
+		if string(staged) != "mezzanine" {
+			t.Errorf("%s: wrong staged content. Got %q", test.givenTestCase, staged)
+		}
+		job, err := fakeDB.GetJob(jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.SourceMedia != "s3://bucket/master.mov" || job.SourceEncryption == nil || job.SourceEncryption.KeyReference != "archive" {
+			t.Errorf("%s: wrong job recorded: %#v", test.givenTestCase, job)
+		}
+	}
+}
+
+func encryptAES128(plain, key, iv []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	data := append(append([]byte(nil), plain...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return data
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route POST /deliverytargets deliveryTargets newDeliveryTarget
+//
+// Creates a new delivery target in the API.
+//
+//     Responses:
+//       200: deliveryTarget
+//       400: invalidDeliveryTarget
+//       409: deliveryTargetAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newDeliveryTarget(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newDeliveryTargetInput
+	target, err := input.DeliveryTarget(r.Body)
+	if err != nil {
+		return newInvalidDeliveryTargetResponse(err)
+	}
+	err = s.db.CreateDeliveryTarget(&target)
+	switch err {
+	case nil:
+		return newDeliveryTargetResponse(&target)
+	case db.ErrDeliveryTargetAlreadyExists:
+		return newDeliveryTargetAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /deliverytargets/{name} deliveryTargets getDeliveryTarget
+//
+// Finds a delivery target using its name.
+//
+//     Responses:
+//       200: deliveryTarget
+//       404: deliveryTargetNotFound
+//       500: genericError
+func (s *TranscodingService) getDeliveryTarget(r *http.Request) swagger.GizmoJSONResponse {
+	var params getDeliveryTargetInput
+	params.loadParams(web.Vars(r))
+	target, err := s.db.GetDeliveryTarget(params.Name)
+	switch err {
+	case nil:
+		return newDeliveryTargetResponse(target)
+	case db.ErrDeliveryTargetNotFound:
+		return newDeliveryTargetNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route PUT /deliverytargets/{name} deliveryTargets updateDeliveryTarget
+//
+// Updates the origins of a delivery target using its name. Jobs created
+// before the update keep delivering to the previous origin.
+//
+//     Responses:
+//       200: deliveryTarget
+//       400: invalidDeliveryTarget
+//       404: deliveryTargetNotFound
+//       500: genericError
+func (s *TranscodingService) updateDeliveryTarget(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input updateDeliveryTargetInput
+	target, err := input.DeliveryTarget(web.Vars(r), r.Body)
+	if err != nil {
+		return newInvalidDeliveryTargetResponse(err)
+	}
+	err = s.db.UpdateDeliveryTarget(&target)
+	switch err {
+	case nil:
+		updatedTarget, _ := s.db.GetDeliveryTarget(target.Name)
+		return newDeliveryTargetResponse(updatedTarget)
+	case db.ErrDeliveryTargetNotFound:
+		return newDeliveryTargetNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route DELETE /deliverytargets/{name} deliveryTargets deleteDeliveryTarget
+//
+// Deletes a delivery target by name.
+//
+//     Responses:
+//       200: emptyResponse
+//       404: deliveryTargetNotFound
+//       500: genericError
+func (s *TranscodingService) deleteDeliveryTarget(r *http.Request) swagger.GizmoJSONResponse {
+	var params getDeliveryTargetInput
+	params.loadParams(web.Vars(r))
+	err := s.db.DeleteDeliveryTarget(&db.DeliveryTarget{Name: params.Name})
+	switch err {
+	case nil:
+		return emptyResponse(http.StatusOK)
+	case db.ErrDeliveryTargetNotFound:
+		return newDeliveryTargetNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /deliverytargets deliveryTargets listDeliveryTargets
+//
+// List delivery targets registered in the API.
+//
+//     Responses:
+//       200: listDeliveryTargets
+//       500: genericError
+func (s *TranscodingService) listDeliveryTargets(r *http.Request) swagger.GizmoJSONResponse {
+	targets, err := s.db.ListDeliveryTargets()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListDeliveryTargetsResponse(targets)
+}
+
+// setCDNURLs fills the CDN-facing URLs of the outputs of jobs delivered to a
+// delivery target.
+func setCDNURLs(job *db.Job, status *provider.JobStatus) {
+	if job.CDNBaseURL == "" {
+		return
+	}
+	origin := db.DeliveryOrigin{Destination: job.Destination, CDNBaseURL: job.CDNBaseURL}
+	status.Output.CDNURL = origin.URL(status.Output.Destination)
+	for i, file := range status.Output.Files {
+		status.Output.Files[i].CDNURL = origin.URL(file.Path)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// JSON-encoded delivery target returned on the newDeliveryTarget,
+// getDeliveryTarget and updateDeliveryTarget operations.
+//
+// swagger:response deliveryTarget
+type deliveryTargetResponse struct {
+	// in: body
+	Payload *db.DeliveryTarget
+
+	baseResponse
+}
+
+// swagger:parameters getDeliveryTarget deleteDeliveryTarget
+type getDeliveryTargetInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+}
+
+// swagger:parameters newDeliveryTarget
+type newDeliveryTargetInput struct {
+	// in: body
+	// required: true
+	Payload db.DeliveryTarget
+}
+
+// swagger:parameters updateDeliveryTarget
+type updateDeliveryTargetInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+
+	// in: body
+	// required: true
+	Payload db.DeliveryTarget
+}
+
+// error returned when the given delivery target name is not found on the
+// API.
+//
+// swagger:response deliveryTargetNotFound
+type deliveryTargetNotFoundResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given delivery target data is not valid.
+//
+// swagger:response invalidDeliveryTarget
+type invalidDeliveryTargetResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to create a new delivery target using a name
+// that is already in use.
+//
+// swagger:response deliveryTargetAlreadyExists
+type deliveryTargetAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// response for the listDeliveryTargets operation. It's a JSON-encoded object
+// in the format `targetName: targetObject`
+//
+// swagger:response listDeliveryTargets
+type listDeliveryTargetsResponse struct {
+	// in: body
+	DeliveryTargets map[string]db.DeliveryTarget
+
+	baseResponse
+}
+
+func newDeliveryTargetResponse(target *db.DeliveryTarget) *deliveryTargetResponse {
+	return &deliveryTargetResponse{
+		baseResponse: baseResponse{
+			payload: target,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newDeliveryTargetNotFoundResponse(err error) *deliveryTargetNotFoundResponse {
+	return &deliveryTargetNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
+}
+
+func (r *deliveryTargetNotFoundResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newInvalidDeliveryTargetResponse(err error) *invalidDeliveryTargetResponse {
+	return &invalidDeliveryTargetResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidDeliveryTargetResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newDeliveryTargetAlreadyExistsResponse(err error) *deliveryTargetAlreadyExistsResponse {
+	return &deliveryTargetAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *deliveryTargetAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newListDeliveryTargetsResponse(targets []db.DeliveryTarget) *listDeliveryTargetsResponse {
+	targetMap := make(map[string]db.DeliveryTarget, len(targets))
+	for _, target := range targets {
+		targetMap[target.Name] = target
+	}
+	return &listDeliveryTargetsResponse{
+		baseResponse: baseResponse{
+			status:  http.StatusOK,
+			payload: targetMap,
+		},
+	}
+}
+
+// DeliveryTarget loads the input from the request body, validates it and
+// returns the delivery target.
+func (p *newDeliveryTargetInput) DeliveryTarget(body io.Reader) (db.DeliveryTarget, error) {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	return p.Payload, validateDeliveryTarget(&p.Payload)
+}
+
+func (p *getDeliveryTargetInput) loadParams(paramsMap map[string]string) {
+	p.Name = paramsMap["name"]
+}
+
+// DeliveryTarget loads the input from the request path and body, validates
+// it and returns the delivery target.
+func (p *updateDeliveryTargetInput) DeliveryTarget(paramsMap map[string]string, body io.Reader) (db.DeliveryTarget, error) {
+	p.Name = paramsMap["name"]
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	p.Payload.Name = p.Name
+	return p.Payload, validateDeliveryTarget(&p.Payload)
+}
+
+func validateDeliveryTarget(t *db.DeliveryTarget) error {
+	if t.Name == "" {
+		return errors.New("missing field name from the request")
+	}
+	if len(t.Origins) == 0 {
+		return errors.New("missing origins from the request")
+	}
+	for environment, origin := range t.Origins {
+		if origin.Destination == "" {
+			return fmt.Errorf("missing destination of the origin for environment %q", environment)
+		}
+		if origin.CDNBaseURL != "" {
+			cdnURL, err := url.Parse(origin.CDNBaseURL)
+			if err != nil || (cdnURL.Scheme != "http" && cdnURL.Scheme != "https") || cdnURL.Host == "" {
+				return fmt.Errorf("invalid CDN base URL for environment %q: %q", environment, origin.CDNBaseURL)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewDeliveryTarget(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"valid target",
+			`{"name":"vod","origins":{"production":{"destination":"s3://vod-origin/","cdnBaseURL":"https://vod.example.com/"}}}`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"target without origins",
+			`{"name":"vod"}`,
+			http.StatusBadRequest,
+			"missing origins from the request",
+		},
+		{
+			"origin without destination",
+			`{"name":"vod","origins":{"staging":{"cdnBaseURL":"https://vod.example.com/"}}}`,
+			http.StatusBadRequest,
+			`missing destination of the origin for environment "staging"`,
+		},
+		{
+			"invalid CDN URL",
+			`{"name":"vod","origins":{"production":{"destination":"s3://vod-origin/","cdnBaseURL":"vod.example.com"}}}`,
+			http.StatusBadRequest,
+			`invalid CDN base URL for environment "production": "vod.example.com"`,
+		},
+		{
+			"target already exists",
+			`{"name":"live","origins":{"production":{"destination":"s3://live-origin/"}}}`,
+			http.StatusConflict,
+			db.ErrDeliveryTargetAlreadyExists.Error(),
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateDeliveryTarget(&db.DeliveryTarget{Name: "live"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/deliverytargets", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if test.wantError != "" && got["error"] != test.wantError {
+			t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+	}
+}
+
+func TestTranscodeDeliveryTarget(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenEnvironment string
+		givenRequestBody string
+
+		wantCode        int
+		wantError       string
+		wantDestination string
+		wantCDNBaseURL  string
+	}{
+		{
+			"production origin",
+			"production",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"deliveryTarget":"vod"}`,
+			http.StatusOK,
+			"",
+			"s3://vod-origin/videos/",
+			"https://vod.example.com/videos/",
+		},
+		{
+			"staging origin",
+			"staging",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"deliveryTarget":"vod"}`,
+			http.StatusOK,
+			"",
+			"s3://vod-origin-staging/videos/",
+			"",
+		},
+		{
+			"environment without origin",
+			"development",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"deliveryTarget":"vod"}`,
+			http.StatusBadRequest,
+			`delivery target "vod" has no origin for environment "development"`,
+			"",
+			"",
+		},
+		{
+			"destination and delivery target",
+			"production",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"deliveryTarget":"vod","destination":"s3://other/"}`,
+			http.StatusBadRequest,
+			"destination and deliveryTarget can't be used together",
+			"",
+			"",
+		},
+		{
+			"unknown delivery target",
+			"production",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"deliveryTarget":"live"}`,
+			http.StatusBadRequest,
+			db.ErrDeliveryTargetNotFound.Error(),
+			"",
+			"",
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		fakeDB.CreateDeliveryTarget(&db.DeliveryTarget{
+			Name: "vod",
+			Origins: map[string]db.DeliveryOrigin{
+				"production": {Destination: "s3://vod-origin/videos/", CDNBaseURL: "https://vod.example.com/videos/"},
+				"staging":    {Destination: "s3://vod-origin-staging/videos/"},
+			},
+		})
+		service, err := NewTranscodingService(&config.Config{DeliveryEnvironment: test.givenEnvironment}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.wantCode != http.StatusOK {
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		job, err := fakeDB.GetJob(got["jobId"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Destination != test.wantDestination {
+			t.Errorf("%s: wrong destination. Want %q. Got %q", test.givenTestCase, test.wantDestination, job.Destination)
+		}
+		if job.CDNBaseURL != test.wantCDNBaseURL {
+			t.Errorf("%s: wrong CDN base URL. Want %q. Got %q", test.givenTestCase, test.wantCDNBaseURL, job.CDNBaseURL)
+		}
+		if job.DeliveryTarget != "vod" {
+			t.Errorf("%s: wrong delivery target. Want %q. Got %q", test.givenTestCase, "vod", job.DeliveryTarget)
+		}
+	}
+}
+
+func TestSetCDNURLs(t *testing.T) {
+	job := db.Job{
+		Destination: "s3://vod-origin/videos/",
+		CDNBaseURL:  "https://vod.example.com/videos/",
+	}
+	status := provider.JobStatus{
+		Output: provider.JobOutput{
+			Destination: "s3://vod-origin/videos/job-123",
+			Files: []provider.OutputFile{
+				{Path: "s3://vod-origin/videos/job-123/video_1080p.mp4"},
+				{Path: "s3://elsewhere/job-123/video_720p.mp4"},
+			},
+		},
+	}
+	setCDNURLs(&job, &status)
+	expected := provider.JobOutput{
+		Destination: "s3://vod-origin/videos/job-123",
+		CDNURL:      "https://vod.example.com/videos/job-123",
+		Files: []provider.OutputFile{
+			{Path: "s3://vod-origin/videos/job-123/video_1080p.mp4", CDNURL: "https://vod.example.com/videos/job-123/video_1080p.mp4"},
+			{Path: "s3://elsewhere/job-123/video_720p.mp4"},
+		},
+	}
+	if !reflect.DeepEqual(status.Output, expected) {
+		t.Errorf("wrong output.\nWant %#v\nGot  %#v", expected, status.Output)
+	}
+}
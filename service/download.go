@@ -0,0 +1,151 @@
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// downloadClient fetches the output files archived by downloadJob. Its
+// timeouts bound connecting to the destination and waiting for its
+// responses, but not copying the files, as large outputs take long to copy.
+var downloadClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	},
+}
+
+// downloadJob streams a zip archive with the output files of a finished job,
+// for users that need the outputs locally. Every file is checked before the
+// response is written, so missing outputs fail the request. Files are then
+// fetched from their destination one at a time while the archive is
+// written, so a file that can't be fetched then leaves the archive
+// truncated.
+func (s *TranscodingService) downloadJob(w http.ResponseWriter, r *http.Request) {
+	var params getTranscodeJobInput
+	params.loadParams(web.Vars(r))
+	job, status, _, err := s.getTranscodeJobByID(params.JobID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if err == db.ErrJobNotFound {
+			code = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	switch status.Status {
+	case provider.StatusFinished, provider.StatusFinishedWithWarnings:
+	default:
+		http.Error(w, fmt.Sprintf("job %q isn't finished", job.ID), http.StatusConflict)
+		return
+	}
+	files := archiveFiles(status.Output)
+	if len(files) == 0 {
+		http.Error(w, fmt.Sprintf("job %q has no output files", job.ID), http.StatusConflict)
+		return
+	}
+	for _, file := range files {
+		if err = s.checkArchiveFile(file.path); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+job.ID+`.zip"`)
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		if err = s.archiveFile(archive, file.name, file.path); err != nil {
+			s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to archive output file")
+			return
+		}
+	}
+	if err = archive.Close(); err != nil {
+		s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to finish the archive of the outputs")
+	}
+}
+
+type archiveEntry struct {
+	name string
+	path string
+}
+
+// archiveFiles returns the output files of a job, named after their paths
+// relative to the destination of the job, so renditions of adaptive
+// streaming jobs keep their layout.
+func archiveFiles(output provider.JobOutput) []archiveEntry {
+	prefix := strings.TrimRight(output.Destination, "/") + "/"
+	names := make(map[string]bool, len(output.Files))
+	files := make([]archiveEntry, 0, len(output.Files))
+	for _, file := range output.Files {
+		name := strings.TrimPrefix(file.Path, prefix)
+		if name == file.Path || name == "" {
+			name = path.Base(file.Path)
+		}
+		base := name
+		for i := 2; names[name]; i++ {
+			name = strconv.Itoa(i) + "-" + base
+		}
+		names[name] = true
+		files = append(files, archiveEntry{name: name, path: file.Path})
+	}
+	return files
+}
+
+// checkArchiveFile verifies that the file in the given path can be fetched,
+// requesting only its first byte. Files in S3 are read through presigned
+// URLs, which are only valid for GET requests.
+func (s *TranscodingService) checkArchiveFile(filePath string) error {
+	input, err := s.analyzer.input(filePath)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("GET", input, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("%s: unexpected status %s", filePath, resp.Status)
+	}
+	return nil
+}
+
+// archiveFile copies the file in the given path to the archive. Outputs are
+// already compressed, so they're stored as they are.
+func (s *TranscodingService) archiveFile(archive *zip.Writer, name, filePath string) error {
+	input, err := s.analyzer.input(filePath)
+	if err != nil {
+		return err
+	}
+	resp, err := downloadClient.Get(input)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", filePath, resp.Status)
+	}
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, resp.Body)
+	return err
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestDownloadJob(t *testing.T) {
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "job-deleted-outputs") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("contents of " + r.URL.Path))
+	}))
+	defer storage.Close()
+	tests := []struct {
+		givenTestCase string
+		givenJobID    string
+
+		wantCode  int
+		wantFiles map[string]string
+	}{
+		{
+			"finished job",
+			"job-finished",
+			http.StatusOK,
+			map[string]string{
+				"video_720p.mp4":   "contents of /mybucket/some/dir/job-finished/video_720p.mp4",
+				"video_1080p.webm": "contents of /mybucket/some/dir/job-finished/video_1080p.webm",
+			},
+		},
+		{"job not finished", "job-queued", http.StatusConflict, nil},
+		{"missing outputs", "job-deleted-outputs", http.StatusBadGateway, nil},
+		{"job not found", "job-unknown", http.StatusNotFound, nil},
+	}
+	for _, test := range tests {
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateJob(&db.Job{ID: "job-finished", ProviderName: "fake", ProviderJobID: "provider-job-with-outputs"})
+		fakeDB.CreateJob(&db.Job{ID: "job-queued", ProviderName: "fake", Status: "queued"})
+		fakeDB.CreateJob(&db.Job{ID: "job-deleted-outputs", ProviderName: "fake", ProviderJobID: "provider-job-with-outputs"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		service.analyzer.presign = func(bucket, key string) (string, error) {
+			return storage.URL + "/" + bucket + "/" + key, nil
+		}
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		srvr.Register(service)
+		r, _ := http.NewRequest("GET", "/jobs/"+test.givenJobID+"/download", nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+			continue
+		}
+		if test.wantFiles == nil {
+			if contentType := w.Header().Get("Content-Type"); contentType == "application/zip" {
+				t.Errorf("%s: unexpected archive in the error response", test.givenTestCase)
+			}
+			continue
+		}
+		if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="job-finished.zip"` {
+			t.Errorf("%s: wrong content disposition: %q", test.givenTestCase, disposition)
+		}
+		data := w.Body.Bytes()
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%s: %s", test.givenTestCase, err)
+		}
+		gotFiles := make(map[string]string)
+		for _, file := range archive.File {
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			gotFiles[file.Name] = string(contents)
+		}
+		if !reflect.DeepEqual(gotFiles, test.wantFiles) {
+			t.Errorf("%s: wrong files in the archive. Want %#v. Got %#v", test.givenTestCase, test.wantFiles, gotFiles)
+		}
+	}
+}
+
+func TestArchiveFiles(t *testing.T) {
+	files := archiveFiles(provider.JobOutput{
+		Destination: "s3://mybucket/job-123/",
+		Files: []provider.OutputFile{
+			{Path: "s3://mybucket/job-123/hls/720p/video.m3u8"},
+			{Path: "s3://mybucket/job-123/hls/1080p/video.m3u8"},
+			{Path: "s3://otherbucket/captions/video.vtt"},
+			{Path: "s3://otherbucket/other/video.vtt"},
+		},
+	})
+	var names []string
+	for _, file := range files {
+		names = append(names, file.name)
+	}
+	expected := []string{"hls/720p/video.m3u8", "hls/1080p/video.m3u8", "video.vtt", "2-video.vtt"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("wrong names. Want %#v. Got %#v", expected, names)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// drmKeySize is the size of content keys, key ids and initialization
+// vectors, in bytes.
+const drmKeySize = 16
+
+func (p *DRMParams) validate() error {
+	switch p.Scheme {
+	case provider.DRMSchemeWidevine, provider.DRMSchemeFairPlay, provider.DRMSchemePlayReady:
+		if !validDRMValue(p.KeyID) {
+			return fmt.Errorf("drm scheme %q requires a 16 bytes hex encoded keyId", p.Scheme)
+		}
+	case provider.DRMSchemeAES128:
+		if p.KeyID != "" && !validDRMValue(p.KeyID) {
+			return errors.New("drm keyId must be 16 bytes hex encoded")
+		}
+	default:
+		return fmt.Errorf("invalid drm scheme %q", p.Scheme)
+	}
+	if !validDRMValue(p.Key) {
+		return errors.New("drm key must be 16 bytes hex encoded")
+	}
+	if p.IV != "" && !validDRMValue(p.IV) {
+		return errors.New("drm iv must be 16 bytes hex encoded")
+	}
+	if p.LicenseURL == "" {
+		return errors.New("drm licenseUrl is required")
+	}
+	return nil
+}
+
+func validDRMValue(value string) bool {
+	data, err := hex.DecodeString(value)
+	return err == nil && len(data) == drmKeySize
+}
+
+// drm returns the DRM settings recorded in new jobs, and their content key.
+func (p *DRMParams) drm() (*db.DRM, string) {
+	if p == nil {
+		return nil, ""
+	}
+	return &db.DRM{Scheme: p.Scheme, KeyID: p.KeyID, LicenseURL: p.LicenseURL, IV: p.IV}, p.Key
+}
+
+// drmParams returns the DRM settings sent to providers, decoding the values
+// validated when the job was created.
+func drmParams(drm *db.DRM, key string) *provider.DRMParams {
+	if drm == nil {
+		return nil
+	}
+	params := provider.DRMParams{Scheme: drm.Scheme, LicenseURL: drm.LicenseURL}
+	params.Key, _ = hex.DecodeString(key)
+	if drm.KeyID != "" {
+		params.KeyID, _ = hex.DecodeString(drm.KeyID)
+	}
+	if drm.IV != "" {
+		params.IV, _ = hex.DecodeString(drm.IV)
+	}
+	return &params
+}
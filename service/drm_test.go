@@ -0,0 +1,53 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func TestDRMParams(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		drm      *db.DRM
+		key      string
+		expected *provider.DRMParams
+	}{
+		{
+			"job without DRM",
+			nil,
+			"",
+			nil,
+		},
+		{
+			"AES-128 job",
+			&db.DRM{Scheme: "aes-128", LicenseURL: "https://keys.example.com/123", IV: "000102030405060708090a0b0c0d0e0f"},
+			"00112233445566778899aabbccddeeff",
+			&provider.DRMParams{
+				Scheme:     "aes-128",
+				Key:        []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				LicenseURL: "https://keys.example.com/123",
+				IV:         []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+			},
+		},
+		{
+			"PlayReady job",
+			&db.DRM{Scheme: "playready", KeyID: "ffeeddccbbaa99887766554433221100", LicenseURL: "https://license.example.com"},
+			"00112233445566778899aabbccddeeff",
+			&provider.DRMParams{
+				Scheme:     "playready",
+				Key:        []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				KeyID:      []byte{0xff, 0xee, 0xdd, 0xcc, 0xbb, 0xaa, 0x99, 0x88, 0x77, 0x66, 0x55, 0x44, 0x33, 0x22, 0x11, 0x00},
+				LicenseURL: "https://license.example.com",
+			},
+		},
+	}
+	for _, test := range tests {
+		params := drmParams(test.drm, test.key)
+		if !reflect.DeepEqual(params, test.expected) {
+			t.Errorf("%s: wrong DRM params. Want %#v. Got %#v", test.testCase, test.expected, params)
+		}
+	}
+}
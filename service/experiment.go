@@ -0,0 +1,259 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// experimentAssigner decides which jobs are enrolled in experiments and
+// which variant is used for encoding each enrolled job.
+type experimentAssigner struct {
+	mtx  sync.Mutex
+	intn func(n int) int
+}
+
+func newExperimentAssigner() *experimentAssigner {
+	return &experimentAssigner{
+		intn: rand.New(rand.NewSource(time.Now().UnixNano())).Intn,
+	}
+}
+
+// assign returns the variant of the experiment that should be used for the
+// next job, or nil if the job is not part of the sample.
+func (a *experimentAssigner) assign(experiment *db.Experiment) *db.ExperimentVariant {
+	if len(experiment.Variants) == 0 {
+		return nil
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if uint(a.intn(100)) >= experiment.SamplePercent {
+		return nil
+	}
+	return &experiment.Variants[a.intn(len(experiment.Variants))]
+}
+
+// swagger:route POST /experiments experiments newExperiment
+//
+// Creates a new experiment for comparing encoding configurations.
+//
+//     Responses:
+//       200: experiment
+//       400: invalidExperiment
+//       409: experimentAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newExperiment(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newExperimentInput
+	experiment, err := input.Experiment(r.Body)
+	if err != nil {
+		return newInvalidExperimentResponse(err)
+	}
+	err = s.db.CreateExperiment(&experiment)
+	switch err {
+	case nil:
+		return newExperimentResponse(&experiment)
+	case db.ErrExperimentAlreadyExists:
+		return newExperimentAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /experiments/{name} experiments getExperiment
+//
+// Finds an experiment using its name.
+//
+//     Responses:
+//       200: experiment
+//       404: experimentNotFound
+//       500: genericError
+func (s *TranscodingService) getExperiment(r *http.Request) swagger.GizmoJSONResponse {
+	var params getExperimentInput
+	params.loadParams(web.Vars(r))
+	experiment, err := s.db.GetExperiment(params.Name)
+	switch err {
+	case nil:
+		return newExperimentResponse(experiment)
+	case db.ErrExperimentNotFound:
+		return newExperimentNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route DELETE /experiments/{name} experiments deleteExperiment
+//
+// Deletes an experiment and the samples collected for it.
+//
+//     Responses:
+//       200: emptyResponse
+//       404: experimentNotFound
+//       500: genericError
+func (s *TranscodingService) deleteExperiment(r *http.Request) swagger.GizmoJSONResponse {
+	var params getExperimentInput
+	params.loadParams(web.Vars(r))
+	err := s.db.DeleteExperiment(&db.Experiment{Name: params.Name})
+	switch err {
+	case nil:
+		return emptyResponse(http.StatusOK)
+	case db.ErrExperimentNotFound:
+		return newExperimentNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /experiments experiments listExperiments
+//
+// List experiments registered in the API.
+//
+//     Responses:
+//       200: listExperiments
+//       500: genericError
+func (s *TranscodingService) listExperiments(r *http.Request) swagger.GizmoJSONResponse {
+	experiments, err := s.db.ListExperiments()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListExperimentsResponse(experiments)
+}
+
+// swagger:route POST /experiments/{name}/samples experiments newExperimentSample
+//
+// Records the metrics (QC score, size and encoding duration) of a job
+// enrolled in the experiment. Recording metrics for the same job again
+// replaces the previous sample.
+//
+//     Responses:
+//       200: experimentSample
+//       400: invalidExperimentSample
+//       404: experimentNotFound
+//       500: genericError
+func (s *TranscodingService) newExperimentSample(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newExperimentSampleInput
+	sample, err := input.Sample(web.Vars(r), r.Body)
+	if err != nil {
+		return newInvalidExperimentSampleResponse(err)
+	}
+	_, err = s.db.GetExperiment(sample.Experiment)
+	if err != nil {
+		if err == db.ErrExperimentNotFound {
+			return newExperimentNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	job, err := s.db.GetJob(sample.JobID)
+	if err != nil {
+		if err == db.ErrJobNotFound {
+			return newInvalidExperimentSampleResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	if job.Experiment != sample.Experiment {
+		return newInvalidExperimentSampleResponse(fmt.Errorf("job %q is not enrolled in experiment %q", job.ID, sample.Experiment))
+	}
+	sample.Variant = job.ExperimentVariant
+	err = s.db.SaveExperimentSample(&sample)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newExperimentSampleResponse(&sample)
+}
+
+// swagger:route GET /experiments/{name}/report experiments getExperimentReport
+//
+// Compares the metrics collected for each variant of the experiment. The
+// differences are relative to the first variant (the control).
+//
+//     Responses:
+//       200: experimentReport
+//       404: experimentNotFound
+//       500: genericError
+func (s *TranscodingService) getExperimentReport(r *http.Request) swagger.GizmoJSONResponse {
+	var params getExperimentInput
+	params.loadParams(web.Vars(r))
+	experiment, err := s.db.GetExperiment(params.Name)
+	if err != nil {
+		if err == db.ErrExperimentNotFound {
+			return newExperimentNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	samples, err := s.db.ListExperimentSamples(experiment.Name)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newExperimentReportResponse(compareVariants(experiment, samples))
+}
+
+// compareVariants aggregates the samples of the experiment by variant.
+func compareVariants(experiment *db.Experiment, samples []db.ExperimentSample) *ExperimentReport {
+	report := ExperimentReport{
+		Experiment: experiment.Name,
+		Variants:   make([]VariantReport, len(experiment.Variants)),
+	}
+	index := make(map[string]int, len(experiment.Variants))
+	sums := make([]metricSums, len(experiment.Variants))
+	for i, variant := range experiment.Variants {
+		report.Variants[i].Name = variant.Name
+		index[variant.Name] = i
+	}
+	for _, sample := range samples {
+		i, ok := index[sample.Variant]
+		if !ok {
+			continue
+		}
+		report.Variants[i].Samples++
+		sums[i].qcScore += sample.Metrics.QCScore
+		sums[i].outputSize += float64(sample.Metrics.OutputSize)
+		sums[i].encodeDuration += sample.Metrics.EncodeDuration
+	}
+	for i := range report.Variants {
+		if n := float64(report.Variants[i].Samples); n > 0 {
+			report.Variants[i].Mean = VariantMetrics{
+				QCScore:        sums[i].qcScore / n,
+				OutputSize:     sums[i].outputSize / n,
+				EncodeDuration: sums[i].encodeDuration / n,
+			}
+		}
+	}
+	if len(report.Variants) == 0 || report.Variants[0].Samples == 0 {
+		return &report
+	}
+	control := report.Variants[0].Mean
+	for i := 1; i < len(report.Variants); i++ {
+		if report.Variants[i].Samples == 0 {
+			continue
+		}
+		mean := report.Variants[i].Mean
+		report.Variants[i].DifferenceFromControl = &VariantMetrics{
+			QCScore:        relativeDifference(control.QCScore, mean.QCScore),
+			OutputSize:     relativeDifference(control.OutputSize, mean.OutputSize),
+			EncodeDuration: relativeDifference(control.EncodeDuration, mean.EncodeDuration),
+		}
+	}
+	return &report
+}
+
+type metricSums struct {
+	qcScore        float64
+	outputSize     float64
+	encodeDuration float64
+}
+
+// relativeDifference returns the difference between value and base, as a
+// percentage of base.
+func relativeDifference(base, value float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return (value - base) / base * 100
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// ExperimentReport compares the metrics collected for the variants of an
+// experiment.
+type ExperimentReport struct {
+	// name of the experiment
+	Experiment string `json:"experiment"`
+
+	// metrics of each variant, in the order they're defined in the
+	// experiment
+	Variants []VariantReport `json:"variants"`
+}
+
+// VariantReport contains the aggregated metrics of one of the variants of an
+// experiment.
+type VariantReport struct {
+	// name of the variant
+	Name string `json:"name"`
+
+	// number of samples collected for the variant
+	Samples int `json:"samples"`
+
+	// mean of the metrics of the samples
+	Mean VariantMetrics `json:"mean"`
+
+	// difference between the mean of the variant and the mean of the
+	// control, in percent. Not included for the control itself.
+	DifferenceFromControl *VariantMetrics `json:"differenceFromControl,omitempty"`
+}
+
+// VariantMetrics are the aggregated metrics of a variant.
+type VariantMetrics struct {
+	QCScore        float64 `json:"qcScore"`
+	OutputSize     float64 `json:"outputSize"`
+	EncodeDuration float64 `json:"encodeDuration"`
+}
+
+// JSON-encoded experiment returned on the newExperiment and getExperiment
+// operations.
+//
+// swagger:response experiment
+type experimentResponse struct {
+	// in: body
+	Payload *db.Experiment
+
+	baseResponse
+}
+
+// response for the listExperiments operation. It's a JSON-encoded object in
+// the format `experimentName: experimentObject`
+//
+// swagger:response listExperiments
+type listExperimentsResponse struct {
+	// in: body
+	Experiments map[string]db.Experiment
+
+	baseResponse
+}
+
+// JSON-encoded sample returned on the newExperimentSample operation.
+//
+// swagger:response experimentSample
+type experimentSampleResponse struct {
+	// in: body
+	Payload *db.ExperimentSample
+
+	baseResponse
+}
+
+// response for the getExperimentReport operation.
+//
+// swagger:response experimentReport
+type experimentReportResponse struct {
+	// in: body
+	Payload *ExperimentReport
+
+	baseResponse
+}
+
+// swagger:parameters getExperiment deleteExperiment getExperimentReport
+type getExperimentInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+}
+
+// swagger:parameters newExperiment
+type newExperimentInput struct {
+	// in: body
+	// required: true
+	Payload db.Experiment
+}
+
+// swagger:parameters newExperimentSample
+type newExperimentSampleInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+
+	// in: body
+	// required: true
+	Payload db.ExperimentSample
+}
+
+// error returned when the given experiment name is not found on the API.
+//
+// swagger:response experimentNotFound
+type experimentNotFoundResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given experiment data is not valid.
+//
+// swagger:response invalidExperiment
+type invalidExperimentResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to create a new experiment using a name that
+// is already in use.
+//
+// swagger:response experimentAlreadyExists
+type experimentAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given sample is not valid, or refers to a job
+// that isn't enrolled in the experiment.
+//
+// swagger:response invalidExperimentSample
+type invalidExperimentSampleResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newExperimentResponse(experiment *db.Experiment) *experimentResponse {
+	return &experimentResponse{
+		baseResponse: baseResponse{
+			payload: experiment,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newListExperimentsResponse(experiments []db.Experiment) *listExperimentsResponse {
+	experimentMap := make(map[string]db.Experiment, len(experiments))
+	for _, experiment := range experiments {
+		experimentMap[experiment.Name] = experiment
+	}
+	return &listExperimentsResponse{
+		baseResponse: baseResponse{
+			payload: experimentMap,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newExperimentSampleResponse(sample *db.ExperimentSample) *experimentSampleResponse {
+	return &experimentSampleResponse{
+		baseResponse: baseResponse{
+			payload: sample,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newExperimentReportResponse(report *ExperimentReport) *experimentReportResponse {
+	return &experimentReportResponse{
+		baseResponse: baseResponse{
+			payload: report,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newExperimentNotFoundResponse(err error) *experimentNotFoundResponse {
+	return &experimentNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
+}
+
+func (r *experimentNotFoundResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newInvalidExperimentResponse(err error) *invalidExperimentResponse {
+	return &invalidExperimentResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidExperimentResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newExperimentAlreadyExistsResponse(err error) *experimentAlreadyExistsResponse {
+	return &experimentAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *experimentAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newInvalidExperimentSampleResponse(err error) *invalidExperimentSampleResponse {
+	return &invalidExperimentSampleResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidExperimentSampleResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func (p *getExperimentInput) loadParams(paramsMap map[string]string) {
+	p.Name = paramsMap["name"]
+}
+
+// Experiment loads the input from the request body, validates it and returns
+// the experiment.
+func (p *newExperimentInput) Experiment(body io.Reader) (db.Experiment, error) {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	return p.Payload, validateExperiment(&p.Payload)
+}
+
+// Sample loads the input from the request path and body, validates it and
+// returns the sample.
+func (p *newExperimentSampleInput) Sample(paramsMap map[string]string, body io.Reader) (db.ExperimentSample, error) {
+	p.Name = paramsMap["name"]
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	p.Payload.Experiment = p.Name
+	if p.Payload.JobID == "" {
+		return p.Payload, errors.New("missing field jobId from the request")
+	}
+	metrics := p.Payload.Metrics
+	if metrics.QCScore < 0 || metrics.OutputSize < 0 || metrics.EncodeDuration < 0 {
+		return p.Payload, errors.New("metrics must not be negative")
+	}
+	return p.Payload, nil
+}
+
+func validateExperiment(e *db.Experiment) error {
+	if e.Name == "" {
+		return errors.New("missing field name from the request")
+	}
+	if e.SamplePercent > 100 {
+		return errors.New("samplePercent must be between 0 and 100")
+	}
+	if len(e.Variants) < 2 {
+		return errors.New("experiments must have at least two variants")
+	}
+	names := make(map[string]bool, len(e.Variants))
+	for _, variant := range e.Variants {
+		if variant.Name == "" {
+			return errors.New("missing name of variant")
+		}
+		if names[variant.Name] {
+			return fmt.Errorf("duplicate variant %q", variant.Name)
+		}
+		names[variant.Name] = true
+		if variant.Provider != "" {
+			if _, err := provider.GetProviderFactory(variant.Provider); err != nil {
+				return fmt.Errorf("variant %q: %s", variant.Name, err)
+			}
+		}
+	}
+	return nil
+}
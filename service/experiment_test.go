@@ -0,0 +1,318 @@
+package service
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewExperiment(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"valid experiment",
+			`{"name":"hevc","samplePercent":10,"variants":[{"name":"control"},{"name":"hevc","ladder":["720p_hevc"]}]}`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"single variant",
+			`{"name":"hevc","samplePercent":10,"variants":[{"name":"control"}]}`,
+			http.StatusBadRequest,
+			"experiments must have at least two variants",
+		},
+		{
+			"duplicate variant",
+			`{"name":"hevc","samplePercent":10,"variants":[{"name":"control"},{"name":"control"}]}`,
+			http.StatusBadRequest,
+			`duplicate variant "control"`,
+		},
+		{
+			"invalid sample percent",
+			`{"name":"hevc","samplePercent":150,"variants":[{"name":"control"},{"name":"hevc"}]}`,
+			http.StatusBadRequest,
+			"samplePercent must be between 0 and 100",
+		},
+		{
+			"unknown provider",
+			`{"name":"hevc","samplePercent":10,"variants":[{"name":"control"},{"name":"hevc","provider":"unknown"}]}`,
+			http.StatusBadRequest,
+			`variant "hevc": provider not found`,
+		},
+		{
+			"experiment already exists",
+			`{"name":"vp9","samplePercent":10,"variants":[{"name":"control"},{"name":"vp9"}]}`,
+			http.StatusConflict,
+			db.ErrExperimentAlreadyExists.Error(),
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateExperiment(&db.Experiment{Name: "vp9"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/experiments", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if test.wantError != "" && got["error"] != test.wantError {
+			t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+		if test.wantCode == http.StatusOK {
+			if _, err := fakeDB.GetExperiment("hevc"); err != nil {
+				t.Errorf("%s: experiment not stored: %s", test.givenTestCase, err)
+			}
+		}
+	}
+}
+
+func TestTranscodeExperiment(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+		givenIntn        []int
+
+		wantCode          int
+		wantExperiment    string
+		wantVariant       string
+		wantOutputPresets []string
+	}{
+		{
+			"job enrolled in the control",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"experiment":"hevc"}`,
+			[]int{5, 0},
+			http.StatusOK,
+			"hevc",
+			"control",
+			[]string{"mp4_1080p"},
+		},
+		{
+			"job enrolled in the candidate",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"experiment":"hevc"}`,
+			[]int{5, 1},
+			http.StatusOK,
+			"hevc",
+			"hevc",
+			[]string{"mp4_1080p_hevc", "mp4_720p_hevc"},
+		},
+		{
+			"job out of the sample",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"experiment":"hevc"}`,
+			[]int{10},
+			http.StatusOK,
+			"",
+			"",
+			[]string{"mp4_1080p"},
+		},
+		{
+			"unknown experiment",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p"}],"experiment":"av1"}`,
+			nil,
+			http.StatusBadRequest,
+			"",
+			"",
+			nil,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		for _, name := range []string{"mp4_1080p", "mp4_1080p_hevc", "mp4_720p_hevc"} {
+			fakeDB.CreatePresetMap(&db.PresetMap{
+				Name:            name,
+				ProviderMapping: map[string]string{"fake": name},
+				OutputOpts:      db.OutputOptions{Extension: "mp4"},
+			})
+		}
+		fakeDB.CreateExperiment(&db.Experiment{
+			Name:          "hevc",
+			SamplePercent: 10,
+			Variants: []db.ExperimentVariant{
+				{Name: "control"},
+				{Name: "hevc", Ladder: []string{"mp4_1080p_hevc", "mp4_720p_hevc"}},
+			},
+		})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		values := test.givenIntn
+		service.experiments.intn = func(int) int {
+			value := values[0]
+			values = values[1:]
+			return value
+		}
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantCode != http.StatusOK {
+			continue
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		job, err := fakeDB.GetJob(got["jobId"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Experiment != test.wantExperiment {
+			t.Errorf("%s: wrong experiment. Want %q. Got %q", test.givenTestCase, test.wantExperiment, job.Experiment)
+		}
+		if job.ExperimentVariant != test.wantVariant {
+			t.Errorf("%s: wrong variant. Want %q. Got %q", test.givenTestCase, test.wantVariant, job.ExperimentVariant)
+		}
+		presets := make([]string, len(job.Outputs))
+		for i, output := range job.Outputs {
+			presets[i] = output.Preset
+		}
+		if !reflect.DeepEqual(presets, test.wantOutputPresets) {
+			t.Errorf("%s: wrong outputs. Want %#v. Got %#v", test.givenTestCase, test.wantOutputPresets, presets)
+		}
+	}
+}
+
+func TestNewExperimentSample(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenExperiment  string
+		givenRequestBody string
+
+		wantCode    int
+		wantVariant string
+	}{
+		{
+			"sample of enrolled job",
+			"hevc",
+			`{"jobId":"job-123","metrics":{"qcScore":94.2,"outputSize":1048576,"encodeDuration":63.5}}`,
+			http.StatusOK,
+			"hevc",
+		},
+		{
+			"job not enrolled in the experiment",
+			"hevc",
+			`{"jobId":"job-456","metrics":{"qcScore":94.2}}`,
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"job not found",
+			"hevc",
+			`{"jobId":"job-unknown","metrics":{"qcScore":94.2}}`,
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"negative metrics",
+			"hevc",
+			`{"jobId":"job-123","metrics":{"outputSize":-1}}`,
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"experiment not found",
+			"av1",
+			`{"jobId":"job-123","metrics":{"qcScore":94.2}}`,
+			http.StatusNotFound,
+			"",
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateExperiment(&db.Experiment{Name: "hevc"})
+		fakeDB.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake", Experiment: "hevc", ExperimentVariant: "hevc"})
+		fakeDB.CreateJob(&db.Job{ID: "job-456", ProviderName: "fake"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/experiments/"+test.givenExperiment+"/samples", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantCode == http.StatusOK {
+			samples, err := fakeDB.ListExperimentSamples(test.givenExperiment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(samples) != 1 || samples[0].Variant != test.wantVariant {
+				t.Errorf("%s: wrong samples stored: %#v", test.givenTestCase, samples)
+			}
+		}
+	}
+}
+
+func TestCompareVariants(t *testing.T) {
+	experiment := db.Experiment{
+		Name:     "hevc",
+		Variants: []db.ExperimentVariant{{Name: "control"}, {Name: "hevc"}, {Name: "vp9"}},
+	}
+	samples := []db.ExperimentSample{
+		{Variant: "control", Metrics: db.ExperimentMetrics{QCScore: 90, OutputSize: 1000, EncodeDuration: 60}},
+		{Variant: "control", Metrics: db.ExperimentMetrics{QCScore: 94, OutputSize: 3000, EncodeDuration: 100}},
+		{Variant: "hevc", Metrics: db.ExperimentMetrics{QCScore: 92.4, OutputSize: 1000, EncodeDuration: 160}},
+		{Variant: "removed", Metrics: db.ExperimentMetrics{QCScore: 10}},
+	}
+	report := compareVariants(&experiment, samples)
+	expected := &ExperimentReport{
+		Experiment: "hevc",
+		Variants: []VariantReport{
+			{Name: "control", Samples: 2, Mean: VariantMetrics{QCScore: 92, OutputSize: 2000, EncodeDuration: 80}},
+			{
+				Name:                  "hevc",
+				Samples:               1,
+				Mean:                  VariantMetrics{QCScore: 92.4, OutputSize: 1000, EncodeDuration: 160},
+				DifferenceFromControl: &VariantMetrics{QCScore: 0.4 / 92 * 100, OutputSize: -50, EncodeDuration: 100},
+			},
+			{Name: "vp9"},
+		},
+	}
+	if len(report.Variants) != len(expected.Variants) {
+		t.Fatalf("wrong number of variants. Want %d. Got %d", len(expected.Variants), len(report.Variants))
+	}
+	diff := report.Variants[1].DifferenceFromControl
+	if diff != nil && math.Abs(diff.QCScore-expected.Variants[1].DifferenceFromControl.QCScore) < 1e-9 {
+		diff.QCScore = expected.Variants[1].DifferenceFromControl.QCScore
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("wrong report.\nWant %#v\nGot  %#v", expected, report)
+	}
+}
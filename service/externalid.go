@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// externalIDJobsPath is the prefix of the path of the lookup of jobs by
+// external id. The router doesn't take static segments in place of the id
+// of jobs, so externalIDHandler routes these requests to
+// externalIDJobsRoute.
+const (
+	externalIDJobsPath  = "/jobs/by-external-id/"
+	externalIDJobsRoute = "/externalids/"
+)
+
+// externalIDHandler routes the lookups of jobs by external id to their
+// route.
+func externalIDHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, externalIDJobsPath) {
+			r.URL.Path = externalIDJobsRoute + strings.TrimPrefix(r.URL.Path, externalIDJobsPath)
+			r.URL.RawPath = ""
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// swagger:route GET /jobs/by-external-id/{externalId} jobs getJobByExternalID
+//
+// Finds the most recent job with the given external id. Jobs submitted by
+// tenants are found using the tenant query string parameter, or the tenant
+// of the caller when the API identifies callers by a header.
+//
+//     Responses:
+//       200: jobRecord
+//       400: invalidJob
+//       403: jobRejected
+//       404: jobNotFound
+//       500: genericError
+func (s *TranscodingService) getJobByExternalID(r *http.Request) swagger.GizmoJSONResponse {
+	var params getJobByExternalIDInput
+	params.loadParams(web.Vars(r), r.URL.Query())
+	tenant, err := s.jobTenant(r, params.Tenant)
+	if err != nil {
+		if _, ok := err.(tenantMismatchError); ok {
+			return newJobRejectedResponse(err)
+		}
+		if err == errTenantRequired {
+			return newInvalidJobResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	jobs, err := s.db.ListJobsByExternalID(tenant, params.ExternalID)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	if len(jobs) == 0 {
+		return newJobNotFoundResponse(db.ErrJobNotFound)
+	}
+	return withFields(r, newJobRecordResponse(&jobs[len(jobs)-1]))
+}
+
+// checkExternalID returns an error if the given external id is held by
+// another job of the tenant, when the tenant requires unique external ids.
+func (s *TranscodingService) checkExternalID(tenant *db.Tenant, externalID string) error {
+	if tenant == nil || !tenant.UniqueExternalIDs || externalID == "" {
+		return nil
+	}
+	jobs, err := s.db.ListJobsByExternalID(tenant.Name, externalID)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		switch provider.Status(job.Status) {
+		case provider.StatusFailed, provider.StatusCanceled:
+		default:
+			return externalIDConflictError{externalID: externalID, jobID: job.ID}
+		}
+	}
+	return nil
+}
+
+type externalIDConflictError struct {
+	externalID string
+	jobID      string
+}
+
+func (err externalIDConflictError) Error() string {
+	return fmt.Sprintf("external id %q is already used by job %q", err.externalID, err.jobID)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewTranscodeJobExternalID(t *testing.T) {
+	tests := []struct {
+		givenTestCase string
+		givenTenant   string
+		givenJobs     []db.Job
+
+		wantCode int
+	}{
+		{
+			"new external id",
+			"nyt",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"external id in use",
+			"nyt",
+			[]db.Job{{ID: "job-1", Tenant: "nyt", ExternalID: "asset-1", Status: "started"}},
+			http.StatusConflict,
+		},
+		{
+			"external id of failed job",
+			"nyt",
+			[]db.Job{{ID: "job-1", Tenant: "nyt", ExternalID: "asset-1", Status: "failed"}},
+			http.StatusOK,
+		},
+		{
+			"external id in use by another tenant",
+			"nyt",
+			[]db.Job{{ID: "job-1", Tenant: "other", ExternalID: "asset-1", Status: "started"}},
+			http.StatusOK,
+		},
+		{
+			"tenant without unique external ids",
+			"other",
+			[]db.Job{{ID: "job-1", Tenant: "other", ExternalID: "asset-1", Status: "started"}},
+			http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateTenant(&db.Tenant{Name: "nyt", UniqueExternalIDs: true})
+		fakeDB.CreateTenant(&db.Tenant{Name: "other"})
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		for i := range test.givenJobs {
+			fakeDB.CreateJob(&test.givenJobs[i])
+		}
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		body := `{"source":"http://another.non.existent/video.mp4","provider":"fake","tenant":"` + test.givenTenant + `","externalId":"asset-1","outputs":[{"preset":"mp4_1080p","fileName":"video.mp4"}]}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+	}
+}
+
+func TestGetJobByExternalID(t *testing.T) {
+	tests := []struct {
+		givenTestCase string
+		givenURL      string
+
+		wantCode  int
+		wantJobID string
+	}{
+		{
+			"most recent job of the tenant",
+			"/jobs/by-external-id/asset-1?tenant=nyt",
+			http.StatusOK,
+			"job-3",
+		},
+		{
+			"job without tenant",
+			"/jobs/by-external-id/asset-1",
+			http.StatusOK,
+			"job-2",
+		},
+		{
+			"unknown external id",
+			"/jobs/by-external-id/asset-2?tenant=nyt",
+			http.StatusNotFound,
+			"",
+		},
+	}
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreateJob(&db.Job{ID: "job-1", Tenant: "nyt", ExternalID: "asset-1"})
+	fakeDB.CreateJob(&db.Job{ID: "job-2", ExternalID: "asset-1"})
+	fakeDB.CreateJob(&db.Job{ID: "job-3", Tenant: "nyt", ExternalID: "asset-1"})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", test.givenURL, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantJobID == "" {
+			continue
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if got["jobId"] != test.wantJobID {
+			t.Errorf("%s: wrong job returned. Want %q. Got %#v", test.givenTestCase, test.wantJobID, got["jobId"])
+		}
+	}
+}
+
+func TestGetJobByExternalIDTenantOfCaller(t *testing.T) {
+	tests := []struct {
+		givenTestCase string
+		givenURL      string
+		givenCaller   string
+
+		wantCode  int
+		wantJobID string
+	}{
+		{
+			"job of the caller",
+			"/jobs/by-external-id/asset-1",
+			"nyt",
+			http.StatusOK,
+			"job-1",
+		},
+		{
+			"tenant of the caller in the query string",
+			"/jobs/by-external-id/asset-1?tenant=nyt",
+			"nyt",
+			http.StatusOK,
+			"job-1",
+		},
+		{
+			"job of another tenant",
+			"/jobs/by-external-id/asset-1?tenant=other",
+			"nyt",
+			http.StatusForbidden,
+			"",
+		},
+		{
+			"caller without tenant",
+			"/jobs/by-external-id/asset-1",
+			"",
+			http.StatusOK,
+			"job-3",
+		},
+	}
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreateJob(&db.Job{ID: "job-1", Tenant: "nyt", ExternalID: "asset-1"})
+	fakeDB.CreateJob(&db.Job{ID: "job-2", Tenant: "other", ExternalID: "asset-1"})
+	fakeDB.CreateJob(&db.Job{ID: "job-3", ExternalID: "asset-1"})
+	service, err := NewTranscodingService(&config.Config{TenantHeader: "X-Tenant"}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", test.givenURL, nil)
+		r.Header.Set("X-Tenant", test.givenCaller)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantJobID == "" {
+			continue
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if got["jobId"] != test.wantJobID {
+			t.Errorf("%s: wrong job returned. Want %q. Got %#v", test.givenTestCase, test.wantJobID, got["jobId"])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// submit submits the job to the given provider. When the submission fails,
+// the job is submitted to the fallback providers, in order, and the provider
+// that accepts it replaces the requested provider in the job, which is kept
+// in RequestedProvider.
+func (s *TranscodingService) submit(job *db.Job, p provider.TranscodingProvider, transcodeProfile provider.TranscodeProfile) (*provider.JobStatus, error) {
+	status, err := p.Transcode(job, transcodeProfile)
+	if err == nil || !canFailover(err) {
+		return status, err
+	}
+	requested := job.ProviderName
+	for _, name := range splitList(s.config.FallbackProviders) {
+		if name == requested {
+			continue
+		}
+		logger := s.logger.WithField("jobId", job.ID).WithField("provider", name)
+		fallback, fallbackErr := s.fallbackProvider(name, job, transcodeProfile)
+		if fallbackErr != nil {
+			logger.WithError(fallbackErr).Warn("skipping fallback provider")
+			continue
+		}
+		job.ProviderName = name
+		status, fallbackErr = fallback.Transcode(job, transcodeProfile)
+		if fallbackErr == nil {
+			job.RequestedProvider = requested
+			return status, nil
+		}
+		job.ProviderName = requested
+		logger.WithError(fallbackErr).Warn("failed to submit job to fallback provider")
+	}
+	return nil, err
+}
+
+// fallbackProvider initializes the fallback provider with the given name,
+// returning an error if it can't run the job.
+func (s *TranscodingService) fallbackProvider(name string, job *db.Job, transcodeProfile provider.TranscodeProfile) (provider.TranscodingProvider, error) {
+	paused, err := s.isPaused(name)
+	if err != nil {
+		return nil, err
+	}
+	if paused {
+		return nil, fmt.Errorf("submissions to provider %q are paused", name)
+	}
+	p, err := s.initEnvironmentProvider(name, job.Environment)
+	if err != nil {
+		return nil, err
+	}
+	requirements := s.jobRequirements(transcodeProfile)
+	requirements.Trim = job.Trim != nil
+	requirements.Rotation = !job.Rotation.Auto()
+	requirements.NoAutorotation = job.Rotation.Disabled()
+	if err = p.Capabilities().Check(name, requirements); err != nil {
+		return nil, err
+	}
+	if enc := transcodeProfile.SourceEncryption; enc != nil {
+		if decrypter, ok := p.(provider.SourceDecrypter); !ok || !decrypter.DecryptsSource(enc.Mode) {
+			return nil, fmt.Errorf("provider %q can't decrypt the source", name)
+		}
+	}
+	return p, nil
+}
+
+// canFailover returns whether the submission of a job that failed with the
+// given error can be retried in another provider. Errors caused by the job
+// itself would fail in any provider.
+func canFailover(err error) bool {
+	if err == provider.ErrPresetMapNotFound {
+		return false
+	}
+	_, ok := err.(provider.KeyframeAlignmentError)
+	return !ok
+}
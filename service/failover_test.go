@@ -0,0 +1,147 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func init() {
+	provider.Register("fake-unavailable", unavailableProviderFactory)
+}
+
+// unavailableProvider is a provider that fails to accept any job.
+type unavailableProvider struct {
+	fakeProvider
+}
+
+func (*unavailableProvider) Transcode(*db.Job, provider.TranscodeProfile) (*provider.JobStatus, error) {
+	return nil, errors.New("503 Service Unavailable")
+}
+
+// unavailableProviderFactory only initializes the provider when fallback
+// providers are configured, keeping it out of the listings of providers in
+// the other tests.
+func unavailableProviderFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
+	if cfg.FallbackProviders == "" {
+		return nil, errors.New("fallback providers not configured")
+	}
+	return &unavailableProvider{}, nil
+}
+
+func TestTranscodeFallbackProvider(t *testing.T) {
+	var tests = []struct {
+		testCase              string
+		givenFallbacks        string
+		givenMapping          map[string]string
+		givenPaused           string
+		wantCode              int
+		wantProvider          string
+		wantRequestedProvider string
+		wantError             string
+	}{
+		{
+			"submission accepted by the fallback provider",
+			"fake-unavailable,fake",
+			map[string]string{"fake-unavailable": "preset-1", "fake": "preset-2"},
+			"",
+			http.StatusOK,
+			"fake",
+			"fake-unavailable",
+			"",
+		},
+		{
+			"fallback provider without the preset",
+			"fake",
+			map[string]string{"fake-unavailable": "preset-1"},
+			"",
+			http.StatusInternalServerError,
+			"",
+			"",
+			`Error with provider "fake-unavailable": 503 Service Unavailable`,
+		},
+		{
+			"fallback provider paused",
+			"fake",
+			map[string]string{"fake-unavailable": "preset-1", "fake": "preset-2"},
+			"fake",
+			http.StatusInternalServerError,
+			"",
+			"",
+			`Error with provider "fake-unavailable": 503 Service Unavailable`,
+		},
+		{
+			"no fallback providers for the requested provider",
+			"fake-unavailable",
+			map[string]string{"fake-unavailable": "preset-1", "fake": "preset-2"},
+			"",
+			http.StatusInternalServerError,
+			"",
+			"",
+			`Error with provider "fake-unavailable": 503 Service Unavailable`,
+		},
+	}
+	for _, test := range tests {
+		fprovider.jobs = nil
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: test.givenMapping,
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		if test.givenPaused != "" {
+			fakeDB.CreateSubmissionPause(&db.SubmissionPause{Provider: test.givenPaused})
+		}
+		service, err := NewTranscodingService(&config.Config{FallbackProviders: test.givenFallbacks}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		body := `{"source":"s3://bucket/video.mov","provider":"fake-unavailable","outputs":[{"preset":"mp4_1080p","fileName":"video.mp4"}]}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d: %s", test.testCase, test.wantCode, w.Code, w.Body.String())
+			continue
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if test.wantError != "" {
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %q", test.testCase, test.wantError, got["error"])
+			}
+			if len(fprovider.jobs) != 0 {
+				t.Errorf("%s: unexpected jobs in the fallback provider: %#v", test.testCase, fprovider.jobs)
+			}
+			continue
+		}
+		if got["providerName"] != test.wantProvider {
+			t.Errorf("%s: wrong provider in the response. Want %q. Got %v", test.testCase, test.wantProvider, got["providerName"])
+		}
+		job, err := fakeDB.GetJob(got["jobId"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.ProviderName != test.wantProvider || job.RequestedProvider != test.wantRequestedProvider {
+			t.Errorf("%s: wrong providers recorded in the job. Want %q (requested %q). Got %q (requested %q)", test.testCase, test.wantProvider, test.wantRequestedProvider, job.ProviderName, job.RequestedProvider)
+		}
+		if len(fprovider.jobs) != 1 || fprovider.jobs[0].Outputs[0].Preset.ProviderMapping["fake"] != "preset-2" {
+			t.Errorf("%s: job not submitted to the fallback provider: %#v", test.testCase, fprovider.jobs)
+		}
+	}
+}
@@ -1,18 +1,27 @@
 package service
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/NYTimes/video-transcoding-api/config"
 	"github.com/NYTimes/video-transcoding-api/db"
 	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/provider/chaos"
 )
 
 func init() {
 	provider.Register("fake", fakeProviderFactory)
 }
 
+// fakeProvider is the provider used in tests. Jobs with chaos labels are
+// handed to the chaos provider.
 type fakeProvider struct {
 	jobs         []provider.TranscodeProfile
 	canceledJobs []string
+	chaos        provider.TranscodingProvider
+	activeJobs   []provider.ActiveJob
+	presets      []db.Preset
 }
 
 var fprovider fakeProvider
@@ -23,6 +32,15 @@ func (p *fakeProvider) Transcode(job *db.Job, transcodeProfile provider.Transcod
 			return nil, provider.ErrPresetMapNotFound
 		}
 	}
+	if hasChaosLabel(job.Labels) {
+		chaosProfile := transcodeProfile
+		chaosProfile.Outputs = make([]provider.TranscodeOutput, len(transcodeProfile.Outputs))
+		for i, output := range transcodeProfile.Outputs {
+			output.Preset.ProviderMapping = map[string]string{chaos.Name: output.Preset.Name}
+			chaosProfile.Outputs[i] = output
+		}
+		return p.chaosProvider().Transcode(job, chaosProfile)
+	}
 	p.jobs = append(p.jobs, transcodeProfile)
 	return &provider.JobStatus{
 		ProviderJobID: "provider-preset-job-123",
@@ -35,7 +53,8 @@ func (p *fakeProvider) Transcode(job *db.Job, transcodeProfile provider.Transcod
 	}, nil
 }
 
-func (*fakeProvider) CreatePreset(preset db.Preset) (string, error) {
+func (p *fakeProvider) CreatePreset(preset db.Preset) (string, error) {
+	p.presets = append(p.presets, preset)
 	return "presetID_here", nil
 }
 
@@ -43,12 +62,35 @@ func (*fakeProvider) GetPreset(presetID string) (interface{}, error) {
 	return struct{ presetID string }{"presetID_here"}, nil
 }
 
+func (*fakeProvider) ExportPreset(presetID string) (db.Preset, []string, error) {
+	return db.Preset{
+		Name:      presetID,
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "h264", Bitrate: "1000000"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	}, []string{"thumbnails"}, nil
+}
+
+func (*fakeProvider) ValidatePreset(preset db.Preset) (interface{}, []string, error) {
+	if preset.Video.Bitrate == "invalid" {
+		return nil, nil, errors.New("invalid video bitrate")
+	}
+	var warnings []string
+	if preset.Video.Bitrate == "100000000" {
+		warnings = append(warnings, "video bitrate of 100000 kbps is out of the supported range (100-50000 kbps)")
+	}
+	return map[string]string{"codec": preset.Video.Codec, "bitrate": preset.Video.Bitrate}, warnings, nil
+}
+
 func (*fakeProvider) DeletePreset(presetID string) error {
 	return nil
 }
 
 func (p *fakeProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 	id := job.ProviderJobID
+	if strings.HasPrefix(id, "chaos-") {
+		return p.chaosProvider().JobStatus(job)
+	}
 	if id == "provider-job-123" {
 		status := provider.StatusFinished
 		if len(p.canceledJobs) > 0 {
@@ -74,10 +116,47 @@ func (p *fakeProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 			},
 		}, nil
 	}
+	if id == "provider-job-with-outputs" {
+		return &provider.JobStatus{
+			ProviderJobID: id,
+			Status:        provider.StatusFinished,
+			Output: provider.JobOutput{
+				Destination: "s3://mybucket/some/dir/" + job.ID,
+				Files: []provider.OutputFile{
+					{Path: "s3://mybucket/some/dir/" + job.ID + "/video_720p.mp4", Container: "mp4", VideoCodec: "h264", Width: 1280, Height: 720},
+					{Path: "s3://mybucket/some/dir/" + job.ID + "/video_1080p.webm", Container: "webm", VideoCodec: "vp9", Width: 1920, Height: 1080},
+				},
+			},
+		}, nil
+	}
+	if id == "provider-job-unreadable-source" {
+		return &provider.JobStatus{
+			ProviderJobID: id,
+			Status:        provider.StatusFailed,
+			StatusMessage: "Unable to download the source file",
+			ProviderStatus: map[string]interface{}{
+				"errorCode": "unreadable-source",
+			},
+		}, nil
+	}
 	return nil, provider.JobNotFoundError{ID: id}
 }
 
+func (p *fakeProvider) IsSourceFailure(status *provider.JobStatus) bool {
+	return status.ProviderStatus["errorCode"] == "unreadable-source"
+}
+
+func (p *fakeProvider) ActiveJobs() ([]provider.ActiveJob, error) {
+	return p.activeJobs, nil
+}
+
 func (p *fakeProvider) CancelJob(id string) error {
+	for _, activeJob := range p.activeJobs {
+		if id == activeJob.ID {
+			p.canceledJobs = append(p.canceledJobs, id)
+			return nil
+		}
+	}
 	if id == "provider-job-123" {
 		p.canceledJobs = append(p.canceledJobs, id)
 		return nil
@@ -91,10 +170,39 @@ func (p *fakeProvider) Healthcheck() error {
 
 func (p *fakeProvider) Capabilities() provider.Capabilities {
 	return provider.Capabilities{
-		InputFormats:  []string{"prores", "h264"},
-		OutputFormats: []string{"mp4", "webm", "hls"},
-		Destinations:  []string{"akamai", "s3"},
+		InputFormats:       []string{"prores", "h264"},
+		OutputFormats:      []string{"mp4", "webm", "hls"},
+		Destinations:       []string{"akamai", "s3"},
+		VideoCodecs:        []string{"h264", "vp8"},
+		AudioCodecs:        []string{"aac", "vorbis"},
+		StreamingProtocols: []string{"hls"},
+		MaxAudioChannels:   2,
+		Conform:            true,
+		Trim:               true,
+		Watermark:          true,
+		Rotation:           true,
+		NoAutorotation:     true,
+		AudioTracks:        true,
+	}
+}
+
+// chaosProvider returns the chaos provider that handles the jobs with chaos
+// labels. It's created on first use, so resetting fprovider resets it.
+func (p *fakeProvider) chaosProvider() provider.TranscodingProvider {
+	if p.chaos == nil {
+		factory, _ := provider.GetProviderFactory(chaos.Name)
+		p.chaos, _ = factory(&config.Config{Chaos: &config.Chaos{Enabled: true}})
+	}
+	return p.chaos
+}
+
+func hasChaosLabel(labels []string) bool {
+	for _, label := range labels {
+		if label == chaos.Name || strings.HasPrefix(label, chaos.Name+":") {
+			return true
+		}
 	}
+	return false
 }
 
 func fakeProviderFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// canFallback returns whether the given failed job has fallback sources
+// left and failed because the provider couldn't read its source. Only
+// providers that implement provider.SourceFailureDetector tell source
+// failures apart, jobs in other providers never fall back.
+func (s *TranscodingService) canFallback(job *db.Job, p provider.TranscodingProvider, status *provider.JobStatus) bool {
+	if len(job.FailedSources) >= len(job.FallbackSources) || job.Status == string(provider.StatusFailed) {
+		return false
+	}
+	detector, ok := p.(provider.SourceFailureDetector)
+	return ok && detector.IsSourceFailure(status)
+}
+
+// pendingFallback reports the given failed job, which is going to be
+// resubmitted by the fallback worker, as started.
+func pendingFallback(job *db.Job, status *provider.JobStatus) {
+	status.Status = provider.StatusStarted
+	status.StatusMessage = fmt.Sprintf("resubmitting with the fallback source %q", job.FallbackSources[len(job.FailedSources)])
+}
+
+// RunFallbacks periodically resubmits the jobs that failed because their
+// provider couldn't read their source, using their next fallback source,
+// until the given channel is closed. It returns immediately when the worker
+// is disabled in the configuration.
+func (s *TranscodingService) RunFallbacks(stop <-chan struct{}) {
+	cfg := s.config.Fallbacks
+	if cfg == nil || cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.fallbackJobs()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// fallbackJobs resubmits the active jobs with fallback sources left that
+// failed reading their source. It doesn't run while the API is in
+// maintenance mode.
+func (s *TranscodingService) fallbackJobs() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	jobs, err := s.db.ListJobs(db.JobFilter{})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list jobs for resubmitting them with fallback sources")
+		return
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.ProviderJobID == "" || len(job.FailedSources) >= len(job.FallbackSources) ||
+			isTerminal(provider.Status(job.Status)) || job.Status == string(provider.StatusSubmitting) {
+			continue
+		}
+		if err = s.fallback(job); err != nil {
+			s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to resubmit job with fallback source")
+		}
+	}
+}
+
+// fallback resubmits the job to the provider using its next fallback
+// source when the provider reports that it couldn't read the current one,
+// recording the source that failed. The job is claimed before being
+// resubmitted, so that only one instance of the API resubmits it, and
+// it's marked as failed when the resubmission fails.
+func (s *TranscodingService) fallback(job *db.Job) error {
+	p, err := s.initEnvironmentProvider(job.ProviderName, job.Environment)
+	if err != nil {
+		return err
+	}
+	status, err := p.JobStatus(job)
+	if err != nil {
+		return err
+	}
+	if status.Status != provider.StatusFailed || !s.canFallback(job, p, status) {
+		return nil
+	}
+	fallbackJob := *job
+	fallbackJob.Status = string(provider.StatusSubmitting)
+	if err = s.db.UpdateJobIfStatus(&fallbackJob, job.Status); err != nil {
+		if err == db.ErrJobStatusChanged {
+			return nil
+		}
+		return err
+	}
+	source := job.FallbackSources[len(job.FailedSources)]
+	resubmitted, status, err := s.resubmit(&fallbackJob, p, source)
+	if err != nil {
+		err = fmt.Errorf("error resubmitting job %q with fallback source %q: %s", job.ID, source, err)
+		fallbackJob.StatusMessage = err.Error()
+		status = &provider.JobStatus{Status: provider.StatusFailed, StatusMessage: err.Error()}
+	} else {
+		fallbackJob = *resubmitted
+		fallbackJob.FailedSources = append(append([]string(nil), job.FailedSources...), job.SourceMedia)
+		fallbackJob.ProviderJobID = status.ProviderJobID
+	}
+	if _, recordErr := s.recordStatus(&fallbackJob, status); recordErr != nil {
+		return recordErr
+	}
+	return err
+}
+
+// resubmit submits a copy of the job with the given source to the
+// provider, returning the copy as prepared for the submission.
+func (s *TranscodingService) resubmit(job *db.Job, p provider.TranscodingProvider, source string) (*db.Job, *provider.JobStatus, error) {
+	transcodeProfile, err := s.transcodeProfile(job, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	resubmitted := *job
+	resubmitted.SourceMedia = source
+	if err = s.prepareSource(&resubmitted, p, &transcodeProfile); err != nil {
+		return nil, nil, err
+	}
+	status, err := p.Transcode(&resubmitted, transcodeProfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resubmitted, status, nil
+}
+
+// transcodeProfile rebuilds the profile of a job that was already recorded,
+// for the given source. Jobs only record the presets of their outputs by
+// name and version, so the settings sent to providers are resolved again.
+func (s *TranscodingService) transcodeProfile(job *db.Job, source string) (provider.TranscodeProfile, error) {
+	transcodeProfile := provider.TranscodeProfile{
+		SourceMedia: source,
+		StreamingParams: provider.StreamingParams{
+			PlaylistFileName:   job.StreamingParams.PlaylistFileName,
+			SegmentDuration:    job.StreamingParams.SegmentDuration,
+			Protocol:           provider.Protocol(job.StreamingParams.Protocol),
+			MinBufferTime:      job.StreamingParams.MinBufferTime,
+			SegmentFormat:      provider.SegmentFormat(job.StreamingParams.SegmentFormat),
+			AudioOnlyRendition: job.StreamingParams.AudioOnlyRendition,
+			AudioOnlyBitrate:   job.StreamingParams.AudioOnlyBitrate,
+		},
+		Outputs:        make([]provider.TranscodeOutput, len(job.Outputs)),
+		Conform:        job.Conform,
+		DRM:            drmParams(job.DRM, job.DRMKey),
+		Captions:       providerCaptions(job.Captions),
+		Thumbnails:     providerThumbnails(job.Thumbnails),
+		Clip:           providerClip(job.Clip),
+		BillingTags:    billingTags(job),
+		DescribedAudio: job.DescribedAudio,
+		AudioTracks:    job.AudioTracks,
+	}
+	for i, output := range job.Outputs {
+		presetMap, _, err := s.resolvePresetMap(output.Preset, output.PresetVersion)
+		if err != nil {
+			return transcodeProfile, err
+		}
+		transcodeProfile.Outputs[i] = provider.TranscodeOutput{FileName: output.FileName, Preset: *presetMap, Preview: output.Preview}
+	}
+	if job.UploadDestination == "" {
+		applyOutputHeaders(&transcodeProfile, job.OutputHeaders)
+	}
+	return transcodeProfile, nil
+}
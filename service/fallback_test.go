@@ -0,0 +1,168 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestFallbackSource(t *testing.T) {
+	var tests = []struct {
+		testCase           string
+		givenFallbacks     []string
+		givenFailedSources []string
+		wantPolledStatus   provider.Status
+		wantStatus         provider.Status
+		wantSource         string
+		wantFailedSources  []string
+		wantProviderJobID  string
+	}{
+		{
+			"source failure with fallback",
+			[]string{"s3://bucket/proxy.mp4"},
+			nil,
+			provider.StatusStarted,
+			provider.StatusFinished,
+			"s3://bucket/proxy.mp4",
+			[]string{"s3://bucket/master.mov"},
+			"provider-preset-job-123",
+		},
+		{
+			"source failure without fallback",
+			nil,
+			nil,
+			provider.StatusFailed,
+			provider.StatusFailed,
+			"s3://bucket/master.mov",
+			nil,
+			"provider-job-unreadable-source",
+		},
+		{
+			"source failure after all fallbacks were used",
+			[]string{"s3://bucket/proxy.mp4"},
+			[]string{"s3://bucket/camera.mxf"},
+			provider.StatusFailed,
+			provider.StatusFailed,
+			"s3://bucket/master.mov",
+			[]string{"s3://bucket/camera.mxf"},
+			"provider-job-unreadable-source",
+		},
+	}
+	for _, test := range tests {
+		fprovider.jobs = nil
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.db.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "preset-1"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service.db.CreateJob(&db.Job{
+			ID:              "job-123",
+			ProviderName:    "fake",
+			ProviderJobID:   "provider-job-unreadable-source",
+			SourceMedia:     "s3://bucket/master.mov",
+			FallbackSources: test.givenFallbacks,
+			FailedSources:   test.givenFailedSources,
+			Outputs:         []db.TranscodeOutput{{Preset: "mp4_1080p", FileName: "video_1080p.mp4"}},
+		})
+		_, status, _, err := service.getTranscodeJobByID("job-123")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if status.Status != test.wantPolledStatus {
+			t.Errorf("%s: wrong status before the fallback. Want %q. Got %q", test.testCase, test.wantPolledStatus, status.Status)
+		}
+		if len(fprovider.jobs) != 0 {
+			t.Errorf("%s: job resubmitted when polled: %#v", test.testCase, fprovider.jobs)
+		}
+		service.fallbackJobs()
+		job, _ := service.db.GetJob("job-123")
+		if provider.Status(job.Status) != test.wantStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.testCase, test.wantStatus, job.Status)
+		}
+		if job.SourceMedia != test.wantSource {
+			t.Errorf("%s: wrong source. Want %q. Got %q", test.testCase, test.wantSource, job.SourceMedia)
+		}
+		if !reflect.DeepEqual(job.FailedSources, test.wantFailedSources) {
+			t.Errorf("%s: wrong failed sources. Want %#v. Got %#v", test.testCase, test.wantFailedSources, job.FailedSources)
+		}
+		if job.ProviderJobID != test.wantProviderJobID {
+			t.Errorf("%s: wrong provider job id. Want %q. Got %q", test.testCase, test.wantProviderJobID, job.ProviderJobID)
+		}
+		if test.wantStatus == provider.StatusFinished {
+			if len(fprovider.jobs) != 1 || fprovider.jobs[0].SourceMedia != test.wantSource {
+				t.Errorf("%s: job not resubmitted with the fallback source. Jobs: %#v", test.testCase, fprovider.jobs)
+			}
+		}
+	}
+}
+
+func TestFallbackClaimedJob(t *testing.T) {
+	fprovider.jobs = nil
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	service.db.CreateJob(&db.Job{
+		ID:              "job-123",
+		ProviderName:    "fake",
+		ProviderJobID:   "provider-job-unreadable-source",
+		Status:          string(provider.StatusSubmitting),
+		SourceMedia:     "s3://bucket/master.mov",
+		FallbackSources: []string{"s3://bucket/proxy.mp4"},
+	})
+	_, status, _, err := service.getTranscodeJobByID("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != provider.StatusSubmitting {
+		t.Errorf("wrong status. Want %q. Got %q", provider.StatusSubmitting, status.Status)
+	}
+	service.fallbackJobs()
+	if len(fprovider.jobs) != 0 {
+		t.Errorf("job claimed by another instance was resubmitted: %#v", fprovider.jobs)
+	}
+}
+
+func TestCanFallback(t *testing.T) {
+	var tests = []struct {
+		testCase       string
+		givenStatus    string
+		givenErrorCode string
+		givenFailed    []string
+		want           bool
+	}{
+		{"source failure", "started", "unreadable-source", nil, true},
+		{"other failure", "started", "unwritable-output", nil, false},
+		{"no error code", "started", "", nil, false},
+		{"no fallback sources left", "started", "unreadable-source", []string{"s3://bucket/camera.mxf"}, false},
+		{"job already failed", "failed", "unreadable-source", nil, false},
+	}
+	var service TranscodingService
+	for _, test := range tests {
+		job := db.Job{
+			Status:          test.givenStatus,
+			SourceMedia:     "s3://bucket/master.mov",
+			FallbackSources: []string{"s3://bucket/proxy.mp4"},
+			FailedSources:   test.givenFailed,
+		}
+		status := provider.JobStatus{
+			Status:         provider.StatusFailed,
+			ProviderStatus: map[string]interface{}{"errorCode": test.givenErrorCode},
+		}
+		if got := service.canFallback(&job, &fprovider, &status); got != test.want {
+			t.Errorf("%s: wrong result. Want %v. Got %v", test.testCase, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"encoding/xml"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+const mrssNamespace = "http://search.yahoo.com/mrss/"
+
+// feedContentTypes maps the containers of the renditions to their MIME
+// types in MRSS feeds.
+var feedContentTypes = map[string]string{
+	"mp4":  "video/mp4",
+	"m4v":  "video/mp4",
+	"mov":  "video/quicktime",
+	"webm": "video/webm",
+	"ts":   "video/mp2t",
+	"m3u8": "application/x-mpegURL",
+	"mpd":  "application/dash+xml",
+	"mp3":  "audio/mpeg",
+	"m4a":  "audio/mp4",
+	"ogg":  "audio/ogg",
+}
+
+// swagger:route GET /feed feeds getFeed
+//
+// Lists finished jobs along with their output renditions, newest first,
+// optionally filtered by label and creation time.
+//
+//     Responses:
+//       200: feed
+//       400: genericError
+//       500: genericError
+func (s *TranscodingService) getFeed(r *http.Request) swagger.GizmoJSONResponse {
+	var params getFeedInput
+	if err := params.loadParams(r.URL.Query()); err != nil {
+		return swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)
+	}
+	f, err := s.buildFeed(&params)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newFeedResponse(f)
+}
+
+// getMRSSFeed renders the feed of finished jobs as a Media RSS document,
+// with one item per job and one media:content per rendition.
+func (s *TranscodingService) getMRSSFeed(w http.ResponseWriter, r *http.Request) {
+	var params getFeedInput
+	if err := params.loadParams(r.URL.Query()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, err := s.buildFeed(&params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := xml.MarshalIndent(newMRSS(f, params.Label), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+// buildFeed lists the finished jobs matching the given parameters, newest
+// first. Renditions are taken from the status of the jobs in their
+// providers, and jobs whose status can't be retrieved are left out.
+func (s *TranscodingService) buildFeed(params *getFeedInput) (*feed, error) {
+	jobs, err := s.db.ListJobs(db.JobFilter{Since: params.since})
+	if err != nil {
+		return nil, err
+	}
+	f := feed{Items: []feedItem{}}
+	for i := len(jobs) - 1; i >= 0 && uint(len(f.Items)) < params.Limit; i-- {
+		job := jobs[i]
+		switch provider.Status(job.Status) {
+		case provider.StatusFinished, provider.StatusFinishedWithWarnings:
+		default:
+			continue
+		}
+		if !params.matches(&job) {
+			continue
+		}
+		_, status, _, err := s.readTranscodeJob(job.ID)
+		if err != nil {
+			s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to retrieve the renditions of the job for the feed")
+			continue
+		}
+		item := feedItem{
+			JobID:        job.ID,
+			ExternalID:   job.ExternalID,
+			Source:       job.SourceMedia,
+			Labels:       job.Labels,
+			CreationTime: job.CreationTime,
+			Renditions:   []feedRendition{},
+		}
+		for _, file := range status.Output.Files {
+			fileURL := file.CDNURL
+			if fileURL == "" {
+				fileURL = file.Path
+			}
+			item.Renditions = append(item.Renditions, feedRendition{
+				URL:        fileURL,
+				Container:  file.Container,
+				VideoCodec: file.VideoCodec,
+				Width:      file.Width,
+				Height:     file.Height,
+			})
+		}
+		f.Items = append(f.Items, item)
+	}
+	return &f, nil
+}
+
+type mrss struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	MediaNS string      `xml:"xmlns:media,attr"`
+	Channel mrssChannel `xml:"channel"`
+}
+
+type mrssChannel struct {
+	Title       string     `xml:"title"`
+	Description string     `xml:"description"`
+	Items       []mrssItem `xml:"item"`
+}
+
+type mrssItem struct {
+	Title      string        `xml:"title"`
+	GUID       mrssGUID      `xml:"guid"`
+	PubDate    string        `xml:"pubDate"`
+	Categories []string      `xml:"category"`
+	Contents   []mrssContent `xml:"media:group>media:content"`
+}
+
+type mrssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type mrssContent struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Medium string `xml:"medium,attr"`
+	Width  int64  `xml:"width,attr,omitempty"`
+	Height int64  `xml:"height,attr,omitempty"`
+}
+
+func newMRSS(f *feed, label string) *mrss {
+	title := "Video Transcoding API"
+	if label != "" {
+		title += ": " + label
+	}
+	doc := mrss{
+		Version: "2.0",
+		MediaNS: mrssNamespace,
+		Channel: mrssChannel{Title: title, Description: "Finished transcoding jobs"},
+	}
+	for _, item := range f.Items {
+		title := item.ExternalID
+		if title == "" {
+			title = path.Base(item.Source)
+		}
+		mrssItem := mrssItem{
+			Title:      title,
+			GUID:       mrssGUID{IsPermaLink: "false", Value: item.JobID},
+			PubDate:    item.CreationTime.UTC().Format(time.RFC1123Z),
+			Categories: item.Labels,
+		}
+		for _, rendition := range item.Renditions {
+			medium := "video"
+			if rendition.VideoCodec == "" && strings.HasPrefix(feedContentTypes[strings.ToLower(rendition.Container)], "audio/") {
+				medium = "audio"
+			}
+			mrssItem.Contents = append(mrssItem.Contents, mrssContent{
+				URL:    rendition.URL,
+				Type:   feedContentTypes[strings.ToLower(rendition.Container)],
+				Medium: medium,
+				Width:  rendition.Width,
+				Height: rendition.Height,
+			})
+		}
+		doc.Channel.Items = append(doc.Channel.Items, mrssItem)
+	}
+	return &doc
+}
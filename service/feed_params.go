@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+// swagger:parameters getFeed getMRSSFeed
+type getFeedInput struct {
+	// label of the jobs included in the feed
+	//
+	// in: query
+	Label string `json:"label"`
+
+	// include only jobs created at or after the given time, in RFC 3339
+	// format
+	//
+	// in: query
+	Since string `json:"since"`
+
+	// include only jobs created before the given time, in RFC 3339 format
+	//
+	// in: query
+	Until string `json:"until"`
+
+	// maximum number of jobs in the feed (defaults to 20, up to 100)
+	//
+	// in: query
+	Limit uint `json:"limit"`
+
+	since time.Time
+	until time.Time
+}
+
+func (p *getFeedInput) loadParams(query url.Values) error {
+	p.Label = query.Get("label")
+	p.Since = query.Get("since")
+	p.Until = query.Get("until")
+	var err error
+	if p.Since != "" {
+		if p.since, err = time.Parse(time.RFC3339, p.Since); err != nil {
+			return errors.New("invalid since, it must be in RFC 3339 format")
+		}
+	}
+	if p.Until != "" {
+		if p.until, err = time.Parse(time.RFC3339, p.Until); err != nil {
+			return errors.New("invalid until, it must be in RFC 3339 format")
+		}
+	}
+	p.Limit = defaultFeedLimit
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil || value == 0 || value > maxFeedLimit {
+			return errors.New("invalid limit, it must be between 1 and 100")
+		}
+		p.Limit = uint(value)
+	}
+	return nil
+}
+
+// matches returns whether the given job belongs in the feed.
+func (p *getFeedInput) matches(job *db.Job) bool {
+	if !p.until.IsZero() && !job.CreationTime.Before(p.until) {
+		return false
+	}
+	if p.Label == "" {
+		return true
+	}
+	for _, label := range job.Labels {
+		if label == p.Label {
+			return true
+		}
+	}
+	return false
+}
+
+// Feed of finished jobs, newest first.
+//
+// swagger:model
+type feed struct {
+	Items []feedItem `json:"items"`
+}
+
+// feedItem is a finished job in the feed, along with its output renditions.
+type feedItem struct {
+	JobID        string          `json:"jobId"`
+	ExternalID   string          `json:"externalId,omitempty"`
+	Source       string          `json:"source"`
+	Labels       []string        `json:"labels,omitempty"`
+	CreationTime time.Time       `json:"creationTime"`
+	Renditions   []feedRendition `json:"renditions"`
+}
+
+// feedRendition is an output file of a job in the feed. The URL is the CDN
+// URL of the file when the job was delivered to a delivery target.
+type feedRendition struct {
+	URL        string `json:"url"`
+	Container  string `json:"container"`
+	VideoCodec string `json:"videoCodec,omitempty"`
+	Width      int64  `json:"width,omitempty"`
+	Height     int64  `json:"height,omitempty"`
+}
+
+// JSON-encoded feed of finished jobs.
+//
+// swagger:response feed
+type feedResponse struct {
+	// in: body
+	Payload *feed
+
+	baseResponse
+}
+
+func newFeedResponse(f *feed) *feedResponse {
+	return &feedResponse{
+		baseResponse: baseResponse{
+			payload: f,
+			status:  http.StatusOK,
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func newFeedTestService(t *testing.T) *TranscodingService {
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeDB := dbtest.NewFakeRepository(false)
+	now := time.Now().UTC().Truncate(time.Second)
+	jobs := []db.Job{
+		{ID: "job-1", Status: "finished", Labels: []string{"partner"}, CreationTime: now.Add(-3 * time.Hour)},
+		{ID: "job-2", Status: "started", Labels: []string{"partner"}, CreationTime: now.Add(-2 * time.Hour)},
+		{ID: "job-3", Status: "finished", Labels: []string{"internal"}, CreationTime: now.Add(-90 * time.Minute)},
+		{ID: "job-4", Status: "finished-with-warnings", Labels: []string{"internal", "partner"}, ExternalID: "asset-4", CreationTime: now.Add(-time.Hour)},
+	}
+	for i := range jobs {
+		jobs[i].ProviderName = "fake"
+		jobs[i].ProviderJobID = "provider-job-with-outputs"
+		jobs[i].SourceMedia = "s3://mybucket/source/" + jobs[i].ID + ".mov"
+		fakeDB.CreateJob(&jobs[i])
+	}
+	service.db = fakeDB
+	return service
+}
+
+func TestGetFeed(t *testing.T) {
+	until := time.Now().UTC().Add(-80 * time.Minute).Format(time.RFC3339)
+	tests := []struct {
+		givenTestCase string
+		givenQuery    string
+
+		wantCode  int
+		wantError string
+		wantJobs  []string
+	}{
+		{"all finished jobs", "", http.StatusOK, "", []string{"job-4", "job-3", "job-1"}},
+		{"filtered by label", "?label=partner", http.StatusOK, "", []string{"job-4", "job-1"}},
+		{"filtered by creation time", "?until=" + until, http.StatusOK, "", []string{"job-3", "job-1"}},
+		{"limited", "?limit=1", http.StatusOK, "", []string{"job-4"}},
+		{"no matching jobs", "?label=archive", http.StatusOK, "", []string{}},
+		{"invalid since", "?since=yesterday", http.StatusBadRequest, "invalid since, it must be in RFC 3339 format", nil},
+		{"invalid limit", "?limit=1000", http.StatusBadRequest, "invalid limit, it must be between 1 and 100", nil},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		srvr.Register(newFeedTestService(t))
+		r, _ := http.NewRequest("GET", "/feed"+test.givenQuery, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantCode != http.StatusOK {
+			var got map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&got)
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		var got feed
+		err := json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotJobs := []string{}
+		for _, item := range got.Items {
+			gotJobs = append(gotJobs, item.JobID)
+			if len(item.Renditions) != 2 {
+				t.Errorf("%s: wrong renditions of job %q: %#v", test.givenTestCase, item.JobID, item.Renditions)
+			}
+		}
+		if !reflect.DeepEqual(gotJobs, test.wantJobs) {
+			t.Errorf("%s: wrong jobs in the feed. Want %#v. Got %#v", test.givenTestCase, test.wantJobs, gotJobs)
+		}
+	}
+}
+
+func TestGetMRSSFeed(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	srvr.Register(newFeedTestService(t))
+	r, _ := http.NewRequest("GET", "/feed/mrss?label=internal", nil)
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/rss+xml; charset=utf-8" {
+		t.Errorf("wrong content type. Got %q", contentType)
+	}
+	var got struct {
+		Items []struct {
+			Title    string `xml:"title"`
+			GUID     string `xml:"guid"`
+			Contents []struct {
+				URL    string `xml:"url,attr"`
+				Type   string `xml:"type,attr"`
+				Medium string `xml:"medium,attr"`
+				Width  int64  `xml:"width,attr"`
+				Height int64  `xml:"height,attr"`
+			} `xml:"group>content"`
+		} `xml:"channel>item"`
+	}
+	err := xml.NewDecoder(w.Body).Decode(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 {
+		t.Fatalf("wrong number of items. Want 2. Got %d", len(got.Items))
+	}
+	item := got.Items[0]
+	if item.Title != "asset-4" || item.GUID != "job-4" {
+		t.Errorf("wrong item. Want title %q and guid %q. Got %q and %q", "asset-4", "job-4", item.Title, item.GUID)
+	}
+	if got.Items[1].Title != "job-3.mov" {
+		t.Errorf("wrong title of item without external id. Want %q. Got %q", "job-3.mov", got.Items[1].Title)
+	}
+	if len(item.Contents) != 2 {
+		t.Fatalf("wrong number of media contents. Want 2. Got %d", len(item.Contents))
+	}
+	content := item.Contents[0]
+	if content.URL != "s3://mybucket/some/dir/job-4/video_720p.mp4" || content.Type != "video/mp4" || content.Medium != "video" || content.Width != 1280 || content.Height != 720 {
+		t.Errorf("wrong media content: %#v", content)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// sparseResponse wraps a response, keeping only the selected fields of its
+// payload. Fields are selected with dot-separated paths (for example,
+// "output.files.path"), and paths crossing lists select the field in every
+// item of the list.
+type sparseResponse struct {
+	swagger.GizmoJSONResponse
+	fields [][]string
+}
+
+// withFields returns the given response restricted to the fields selected
+// in the "fields" query string parameter of the request, if any.
+func withFields(r *http.Request, resp swagger.GizmoJSONResponse) swagger.GizmoJSONResponse {
+	var fields [][]string
+	for _, field := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, strings.Split(field, "."))
+		}
+	}
+	if len(fields) == 0 {
+		return resp
+	}
+	return &sparseResponse{GizmoJSONResponse: resp, fields: fields}
+}
+
+func (r *sparseResponse) Result() (int, interface{}, error) {
+	status, payload, err := r.GizmoJSONResponse.Result()
+	if err != nil || status >= http.StatusBadRequest {
+		return status, payload, err
+	}
+	sparse, err := selectFields(payload, r.fields)
+	if err != nil {
+		return swagger.NewErrorResponse(err).Result()
+	}
+	return status, sparse, nil
+}
+
+func selectFields(payload interface{}, fields [][]string) (interface{}, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	for _, path := range fields {
+		if selected, ok := selectPath(value, path); ok {
+			mergeFields(result, selected)
+		}
+	}
+	return result, nil
+}
+
+func selectPath(value interface{}, path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return nil, false
+		}
+		selected, ok := selectPath(child, path[1:])
+		if !ok {
+			return nil, false
+		}
+		return map[string]interface{}{path[0]: selected}, true
+	case []interface{}:
+		// items without the field are kept empty, so the lists selected
+		// by different paths can be merged item by item.
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			if selected, ok := selectPath(item, path); ok {
+				items[i] = selected
+			} else {
+				items[i] = map[string]interface{}{}
+			}
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+func mergeFields(dst map[string]interface{}, src interface{}) {
+	srcMap, ok := src.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key, value := range srcMap {
+		dst[key] = mergeValues(dst[key], value)
+	}
+}
+
+func mergeValues(dst, src interface{}) interface{} {
+	switch s := src.(type) {
+	case map[string]interface{}:
+		if d, ok := dst.(map[string]interface{}); ok {
+			mergeFields(d, s)
+			return d
+		}
+	case []interface{}:
+		if d, ok := dst.([]interface{}); ok && len(d) == len(s) {
+			for i := range s {
+				d[i] = mergeValues(d[i], s[i])
+			}
+			return d
+		}
+	}
+	return src
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestSelectFields(t *testing.T) {
+	status := provider.JobStatus{
+		ProviderJobID: "provider-job-123",
+		Status:        provider.StatusFinished,
+		Progress:      100,
+		Output: provider.JobOutput{
+			Destination: "s3://bucket/job-123/",
+			Files: []provider.OutputFile{
+				{Path: "s3://bucket/job-123/video_720p.mp4", Container: "mp4", Width: 1280, Height: 720},
+				{Path: "s3://bucket/job-123/video_1080p.mp4", Container: "mp4", Width: 1920, Height: 1080},
+			},
+		},
+	}
+	var tests = []struct {
+		givenFields string
+		want        string
+	}{
+		{
+			"status,progress",
+			`{"status":"finished","progress":100}`,
+		},
+		{
+			"status,output.files.path",
+			`{"status":"finished","output":{"files":[{"path":"s3://bucket/job-123/video_720p.mp4"},{"path":"s3://bucket/job-123/video_1080p.mp4"}]}}`,
+		},
+		{
+			"output.files.path,output.files.width,output.destination",
+			`{"output":{"destination":"s3://bucket/job-123/","files":[{"path":"s3://bucket/job-123/video_720p.mp4","width":1280},{"path":"s3://bucket/job-123/video_1080p.mp4","width":1920}]}}`,
+		},
+		{
+			"status,unknown,output.unknown",
+			`{"status":"finished"}`,
+		},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", "/jobs/job-123?fields="+test.givenFields, nil)
+		_, payload, err := withFields(r, newJobStatusResponse(&status)).Result()
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", test.givenFields, err)
+			continue
+		}
+		got, _ := json.Marshal(payload)
+		var gotValue, wantValue interface{}
+		json.Unmarshal(got, &gotValue)
+		json.Unmarshal([]byte(test.want), &wantValue)
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("%q: wrong payload.\nWant %s\nGot  %s", test.givenFields, test.want, got)
+		}
+	}
+}
+
+func TestGetTranscodeJobFields(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123"})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	r, _ := http.NewRequest("GET", "/jobs/job-123?fields=status,progress,output.destination", nil)
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var got map[string]interface{}
+	err = json.NewDecoder(w.Body).Decode(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"status":   "finished",
+		"progress": 10.3,
+		"output":   map[string]interface{}{"destination": "s3://mybucket/some/dir/job-123"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong response body.\nWant %#v\nGot  %#v", want, got)
+	}
+}
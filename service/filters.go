@@ -0,0 +1,26 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// deinterlaceModes are the supported values of the deinterlace filter.
+var deinterlaceModes = map[string]bool{"on": true, "off": true, "detect": true}
+
+// denoiseStrengths are the supported strengths of the denoise filter.
+var denoiseStrengths = map[string]bool{"weak": true, "medium": true, "strong": true, "strongest": true}
+
+func validateFilters(filters *db.VideoFilters) error {
+	if filters == nil {
+		return nil
+	}
+	if filters.Deinterlace != "" && !deinterlaceModes[filters.Deinterlace] {
+		return fmt.Errorf("invalid deinterlace mode %q", filters.Deinterlace)
+	}
+	if filters.Denoise != "" && !denoiseStrengths[filters.Denoise] {
+		return fmt.Errorf("invalid denoise strength %q", filters.Denoise)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const fingerprintAlgorithm = "dhash"
+
+// fingerprintContainers are the containers of the outputs that are
+// fingerprinted. Playlists, captions, images and audio-only outputs are
+// skipped.
+var fingerprintContainers = map[string]bool{"mp4": true, "mov": true, "m4v": true, "webm": true, "mkv": true, "ts": true}
+
+// outputFingerprinter computes the perceptual fingerprints of the outputs of
+// finished jobs with fingerprinting enabled. Fingerprints are computed in
+// the background, and stored in the job once all outputs are done.
+type outputFingerprinter struct {
+	analyzer *mediaAnalyzer
+	runs     *analysisRuns
+}
+
+func newOutputFingerprinter(analyzer *mediaAnalyzer) *outputFingerprinter {
+	return &outputFingerprinter{analyzer: analyzer, runs: newAnalysisRuns()}
+}
+
+// sync starts the fingerprinting of the outputs of the given job once it
+// finishes. The job is reported as started until the fingerprints are
+// stored in the job, or as failed when an output can't be fingerprinted.
+func (f *outputFingerprinter) sync(job *db.Job, status *provider.JobStatus) {
+	if !job.Fingerprint || status.Status != provider.StatusFinished {
+		return
+	}
+	if len(job.Fingerprints) > 0 {
+		status.Fingerprints = job.Fingerprints
+		return
+	}
+	files := status.Output.Files
+	done, result, err := f.runs.poll(job.ID, func() (interface{}, error) {
+		return f.run(files)
+	})
+	if !done {
+		status.Status = provider.StatusStarted
+		status.StatusMessage = "computing the fingerprints of the outputs"
+		return
+	}
+	if err != nil {
+		status.Status = provider.StatusFailed
+		status.StatusMessage = "failed to fingerprint outputs: " + err.Error()
+		return
+	}
+	job.Fingerprints = result.([]db.OutputFingerprint)
+	status.Fingerprints = job.Fingerprints
+}
+
+// run fingerprints the video files of a job, one at a time.
+func (f *outputFingerprinter) run(files []provider.OutputFile) ([]db.OutputFingerprint, error) {
+	var fingerprints []db.OutputFingerprint
+	for _, file := range files {
+		if !fingerprintContainers[strings.ToLower(file.Container)] {
+			continue
+		}
+		fingerprint, err := f.fingerprint(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints = append(fingerprints, *fingerprint)
+	}
+	return fingerprints, nil
+}
+
+func (f *outputFingerprinter) fingerprint(path string) (*db.OutputFingerprint, error) {
+	input, err := f.analyzer.input(path)
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := f.analyzer.fingerprint(input, f.analyzer.interval)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return &db.OutputFingerprint{
+		Path:      path,
+		Algorithm: fingerprintAlgorithm,
+		Interval:  f.analyzer.interval,
+		Hashes:    hashes,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func TestOutputFingerprinterSync(t *testing.T) {
+	var tests = []struct {
+		testCase         string
+		givenFailOn      string
+		wantStatus       provider.Status
+		wantMessage      string
+		wantFingerprints []db.OutputFingerprint
+	}{
+		{
+			"all outputs fingerprinted",
+			"",
+			provider.StatusFinished,
+			"",
+			[]db.OutputFingerprint{
+				{Path: "s3://bucket/job-123/video_1080p.mp4", Algorithm: "dhash", Interval: 2, Hashes: []string{"https://bucket.s3.amazonaws.com/job-123/video_1080p.mp4"}},
+				{Path: "http://cdn.example.com/job-123/video_720p.webm", Algorithm: "dhash", Interval: 2, Hashes: []string{"http://cdn.example.com/job-123/video_720p.webm"}},
+			},
+		},
+		{
+			"failed fingerprint",
+			"http://cdn.example.com/job-123/video_720p.webm",
+			provider.StatusFailed,
+			"failed to fingerprint outputs: http://cdn.example.com/job-123/video_720p.webm: ffmpeg failed: exit status 1",
+			nil,
+		},
+	}
+	for _, test := range tests {
+		var (
+			mtx    sync.Mutex
+			inputs []string
+		)
+		failOn := test.givenFailOn
+		fingerprinter := newOutputFingerprinter(&mediaAnalyzer{
+			interval: 2,
+			presign: func(bucket, key string) (string, error) {
+				return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+			},
+			fingerprint: func(input string, interval float64) ([]string, error) {
+				mtx.Lock()
+				inputs = append(inputs, input)
+				mtx.Unlock()
+				if input == failOn {
+					return nil, errors.New("ffmpeg failed: exit status 1")
+				}
+				return []string{input}, nil
+			},
+		})
+		job := db.Job{ID: "job-123", Fingerprint: true}
+		var status provider.JobStatus
+		for i := 0; i < 100; i++ {
+			status = provider.JobStatus{
+				Status: provider.StatusFinished,
+				Output: provider.JobOutput{
+					Files: []provider.OutputFile{
+						{Path: "s3://bucket/job-123/video_1080p.mp4", Container: "mp4"},
+						{Path: "s3://bucket/job-123/hls/index.m3u8", Container: "m3u8"},
+						{Path: "http://cdn.example.com/job-123/video_720p.webm", Container: "webm"},
+					},
+				},
+			}
+			fingerprinter.sync(&job, &status)
+			if status.Status != provider.StatusStarted {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if status.Status != test.wantStatus || status.StatusMessage != test.wantMessage {
+			t.Errorf("%s: wrong status. Want %q (%q). Got %q (%q)", test.testCase, test.wantStatus, test.wantMessage, status.Status, status.StatusMessage)
+		}
+		if !reflect.DeepEqual(status.Fingerprints, test.wantFingerprints) {
+			t.Errorf("%s: wrong fingerprints in the status.\nWant %#v\nGot  %#v", test.testCase, test.wantFingerprints, status.Fingerprints)
+		}
+		if !reflect.DeepEqual(job.Fingerprints, test.wantFingerprints) {
+			t.Errorf("%s: wrong fingerprints in the job.\nWant %#v\nGot  %#v", test.testCase, test.wantFingerprints, job.Fingerprints)
+		}
+		mtx.Lock()
+		if len(inputs) != 2 {
+			t.Errorf("%s: playlists shouldn't be fingerprinted: %#v", test.testCase, inputs)
+		}
+		mtx.Unlock()
+		if _, ok := fingerprinter.runs.active[job.ID]; ok {
+			t.Errorf("%s: the run wasn't removed after finishing", test.testCase)
+		}
+	}
+}
+
+func TestOutputFingerprinterSyncSkipped(t *testing.T) {
+	stored := []db.OutputFingerprint{{Path: "s3://bucket/job-123/video.mp4", Algorithm: "dhash", Interval: 2, Hashes: []string{"0f0f0f0f0f0f0f0f"}}}
+	var tests = []struct {
+		testCase string
+		job      db.Job
+		status   provider.Status
+	}{
+		{"fingerprinting disabled", db.Job{ID: "job-1"}, provider.StatusFinished},
+		{"job running", db.Job{ID: "job-2", Fingerprint: true}, provider.StatusStarted},
+		{"fingerprints stored", db.Job{ID: "job-3", Fingerprint: true, Fingerprints: stored}, provider.StatusFinished},
+	}
+	fingerprinter := newOutputFingerprinter(&mediaAnalyzer{
+		fingerprint: func(string, float64) ([]string, error) {
+			t.Error("unexpected fingerprint")
+			return nil, nil
+		},
+	})
+	for _, test := range tests {
+		status := provider.JobStatus{
+			Status: test.status,
+			Output: provider.JobOutput{Files: []provider.OutputFile{{Path: "s3://bucket/job-123/video.mp4", Container: "mp4"}}},
+		}
+		fingerprinter.sync(&test.job, &status)
+		if status.Status != test.status {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.testCase, test.status, status.Status)
+		}
+		if !reflect.DeepEqual(status.Fingerprints, test.job.Fingerprints) {
+			t.Errorf("%s: wrong fingerprints. Want %#v. Got %#v", test.testCase, test.job.Fingerprints, status.Fingerprints)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/transfer"
+)
+
+var metadataKeyRegexp = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+func validateOutputHeaders(rules []db.OutputHeaders) error {
+	for _, rule := range rules {
+		if rule.CacheControl == "" && rule.ContentDisposition == "" && len(rule.Metadata) == 0 {
+			return errors.New("outputHeaders rules require cacheControl, contentDisposition or metadata")
+		}
+		for key := range rule.Metadata {
+			if !metadataKeyRegexp.MatchString(key) {
+				return fmt.Errorf("invalid outputHeaders metadata key %q", key)
+			}
+		}
+	}
+	return nil
+}
+
+// outputHeaders returns the headers of the file with the given name, in the
+// output with the given preset (empty for playlists generated by the
+// provider), or nil when no rule matches the file.
+func outputHeaders(rules []db.OutputHeaders, fileName, preset string) *provider.OutputHeaders {
+	var headers *provider.OutputHeaders
+	extension := strings.TrimPrefix(path.Ext(fileName), ".")
+	for _, rule := range rules {
+		if rule.Extension != "" && !strings.EqualFold(strings.TrimPrefix(rule.Extension, "."), extension) {
+			continue
+		}
+		if rule.Preset != "" && rule.Preset != preset {
+			continue
+		}
+		if headers == nil {
+			headers = new(provider.OutputHeaders)
+		}
+		if rule.CacheControl != "" {
+			headers.CacheControl = rule.CacheControl
+		}
+		if rule.ContentDisposition != "" {
+			headers.ContentDisposition = rule.ContentDisposition
+		}
+		for key, value := range rule.Metadata {
+			if headers.Metadata == nil {
+				headers.Metadata = make(map[string]string)
+			}
+			headers.Metadata[key] = value
+		}
+	}
+	return headers
+}
+
+// applyOutputHeaders sets the headers of the outputs and of the playlist of
+// the given profile, for jobs whose outputs are written to the destination
+// by the provider.
+func applyOutputHeaders(transcodeProfile *provider.TranscodeProfile, rules []db.OutputHeaders) {
+	for i, output := range transcodeProfile.Outputs {
+		transcodeProfile.Outputs[i].Headers = outputHeaders(rules, output.FileName, output.Preset.Name)
+	}
+	if playlist := transcodeProfile.StreamingParams.PlaylistFileName; playlist != "" && transcodeProfile.StreamingParams.Protocol != "" {
+		transcodeProfile.PlaylistHeaders = outputHeaders(rules, playlist, "")
+	}
+}
+
+// checkUploadHeaders returns an error when the outputs uploaded to the
+// given transfer destination can't carry headers.
+func (u *outputUploader) checkUploadHeaders(destination string) error {
+	backend, _, err := u.backend(destination)
+	if err != nil {
+		return err
+	}
+	if _, ok := backend.uploader.(transfer.HeaderUploader); !ok {
+		return fmt.Errorf("outputHeaders can't be set on %s destinations", backend.name)
+	}
+	return nil
+}
+
+// uploadHeaders returns the headers of the file in the given path, uploaded
+// by the API. Files are matched to the outputs of the job by their names,
+// so segments only match rules by extension.
+func uploadHeaders(job *db.Job, filePath string) *transfer.Headers {
+	var preset string
+	for _, output := range job.Outputs {
+		if strings.HasSuffix(filePath, "/"+output.FileName) {
+			preset = output.Preset
+			break
+		}
+	}
+	headers := outputHeaders(job.OutputHeaders, filePath, preset)
+	if headers == nil {
+		return nil
+	}
+	return &transfer.Headers{
+		CacheControl:       headers.CacheControl,
+		ContentDisposition: headers.ContentDisposition,
+		Metadata:           headers.Metadata,
+	}
+}
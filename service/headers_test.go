@@ -0,0 +1,86 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/transfer"
+)
+
+func TestOutputHeaders(t *testing.T) {
+	rules := []db.OutputHeaders{
+		{CacheControl: "max-age=86400", Metadata: map[string]string{"asset-id": "123"}},
+		{Extension: "m3u8", CacheControl: "max-age=2"},
+		{Preset: "mp4_1080p", ContentDisposition: "attachment", Metadata: map[string]string{"rendition": "1080p"}},
+		{Extension: ".TS", CacheControl: "max-age=31536000"},
+	}
+	var tests = []struct {
+		testCase string
+		fileName string
+		preset   string
+		expected *provider.OutputHeaders
+	}{
+		{
+			"download output",
+			"video_1080p.mp4",
+			"mp4_1080p",
+			&provider.OutputHeaders{
+				CacheControl:       "max-age=86400",
+				ContentDisposition: "attachment",
+				Metadata:           map[string]string{"asset-id": "123", "rendition": "1080p"},
+			},
+		},
+		{
+			"playlist",
+			"hls/index.m3u8",
+			"",
+			&provider.OutputHeaders{CacheControl: "max-age=2", Metadata: map[string]string{"asset-id": "123"}},
+		},
+		{
+			"segment",
+			"hls/video_720p_00001.ts",
+			"",
+			&provider.OutputHeaders{CacheControl: "max-age=31536000", Metadata: map[string]string{"asset-id": "123"}},
+		},
+	}
+	for _, test := range tests {
+		headers := outputHeaders(rules, test.fileName, test.preset)
+		if !reflect.DeepEqual(headers, test.expected) {
+			t.Errorf("%s: wrong headers. Want %#v. Got %#v", test.testCase, test.expected, headers)
+		}
+	}
+	if headers := outputHeaders(rules[1:2], "video.mp4", "mp4_1080p"); headers != nil {
+		t.Errorf("unexpected headers for a file that matches no rule: %#v", headers)
+	}
+}
+
+func TestUploadHeaders(t *testing.T) {
+	job := db.Job{
+		ID:          "job-123",
+		Destination: "s3://staging/123456/videos/",
+		Outputs: []db.TranscodeOutput{
+			{FileName: "video_1080p.mp4", Preset: "mp4_1080p"},
+			{FileName: "hls/video_720p.m3u8", Preset: "hls_720p"},
+		},
+		OutputHeaders: []db.OutputHeaders{
+			{Preset: "mp4_1080p", ContentDisposition: "attachment"},
+			{Extension: "ts", CacheControl: "max-age=31536000"},
+		},
+	}
+	var tests = []struct {
+		path     string
+		expected *transfer.Headers
+	}{
+		{"s3://staging/123456/videos/job-123/video_1080p.mp4", &transfer.Headers{ContentDisposition: "attachment"}},
+		{"s3://staging/123456/videos/job-123/hls/video_720p.m3u8", nil},
+		{"s3://staging/123456/videos/job-123/hls/video_720p_00001.ts", &transfer.Headers{CacheControl: "max-age=31536000"}},
+	}
+	for _, test := range tests {
+		headers := uploadHeaders(&job, test.path)
+		if !reflect.DeepEqual(headers, test.expected) {
+			t.Errorf("%s: wrong headers. Want %#v. Got %#v", test.path, test.expected, headers)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// jobIDGenerator generates the ids of new jobs.
+type jobIDGenerator interface {
+	generate(tenant string) (string, error)
+}
+
+// newJobIDGenerator returns the generator for the given format (random,
+// ulid, uuid or sequential). The repository is used by the sequential
+// generator for keeping the sequence of each tenant.
+func newJobIDGenerator(format string, repo func() db.JobRepository) (jobIDGenerator, error) {
+	switch format {
+	case "", "random":
+		return randomIDGenerator{}, nil
+	case "ulid":
+		return &ulidGenerator{now: time.Now}, nil
+	case "uuid":
+		return uuidGenerator{}, nil
+	case "sequential":
+		return sequentialIDGenerator{repo: repo}, nil
+	}
+	return nil, fmt.Errorf("invalid job id format %q", format)
+}
+
+func readRandom(data []byte) error {
+	n, err := rand.Read(data)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return io.ErrShortWrite
+	}
+	return nil
+}
+
+// randomIDGenerator generates 16 hex digits random ids.
+type randomIDGenerator struct{}
+
+func (randomIDGenerator) generate(string) (string, error) {
+	var data [8]byte
+	if err := readRandom(data[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", data), nil
+}
+
+// uuidGenerator generates random (version 4) UUIDs, as in RFC 4122.
+type uuidGenerator struct{}
+
+func (uuidGenerator) generate(string) (string, error) {
+	var data [16]byte
+	if err := readRandom(data[:]); err != nil {
+		return "", err
+	}
+	data[6] = data[6]&0x0f | 0x40
+	data[8] = data[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", data[0:4], data[4:6], data[6:8], data[8:10], data[10:]), nil
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidGenerator generates ULIDs (https://github.com/ulid/spec), which are
+// lexicographically sorted by creation time. Ids generated in the same
+// millisecond increment the random part of the previous id, so they're
+// sorted as well.
+type ulidGenerator struct {
+	mtx      sync.Mutex
+	now      func() time.Time
+	lastTime uint64
+	entropy  [10]byte
+}
+
+func (g *ulidGenerator) generate(string) (string, error) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	ms := uint64(g.now().UnixNano() / int64(time.Millisecond))
+	if ms == g.lastTime {
+		g.increment()
+	} else if err := readRandom(g.entropy[:]); err != nil {
+		return "", err
+	}
+	g.lastTime = ms
+	var data [16]byte
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], ms)
+	copy(data[:6], timestamp[2:])
+	copy(data[6:], g.entropy[:])
+	return encodeULID(data), nil
+}
+
+func (g *ulidGenerator) increment() {
+	for i := len(g.entropy) - 1; i >= 0; i-- {
+		g.entropy[i]++
+		if g.entropy[i] != 0 {
+			return
+		}
+	}
+}
+
+// encodeULID encodes the 128 bits of the ULID in 26 characters of Crockford's
+// base32, with the 2 leftmost padding bits set to zero.
+func encodeULID(data [16]byte) string {
+	var id [26]byte
+	hi := binary.BigEndian.Uint64(data[:8])
+	lo := binary.BigEndian.Uint64(data[8:])
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(id[:])
+}
+
+// sequentialIDGenerator generates ids from a sequence kept per tenant,
+// prefixed by the name of the tenant. The number is zero padded, so ids of
+// the same tenant are lexicographically sorted.
+type sequentialIDGenerator struct {
+	repo func() db.JobRepository
+}
+
+func (g sequentialIDGenerator) generate(tenant string) (string, error) {
+	n, err := g.repo().NextJobSequence(tenant)
+	if err != nil {
+		return "", err
+	}
+	if tenant == "" {
+		return fmt.Sprintf("%012d", n), nil
+	}
+	return fmt.Sprintf("%s-%012d", tenant, n), nil
+}
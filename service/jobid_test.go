@@ -0,0 +1,63 @@
+package service
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+)
+
+func TestJobIDGenerator(t *testing.T) {
+	repo := dbtest.NewFakeRepository(false)
+	var tests = []struct {
+		givenFormat string
+		givenTenant string
+		wantPattern string
+	}{
+		{"", "", `^[0-9a-f]{16}$`},
+		{"random", "nyt", `^[0-9a-f]{16}$`},
+		{"uuid", "", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"ulid", "", `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`},
+		{"sequential", "nyt", `^nyt-000000000001$`},
+		{"sequential", "", `^000000000001$`},
+	}
+	for _, test := range tests {
+		generator, err := newJobIDGenerator(test.givenFormat, func() db.JobRepository { return repo })
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", test.givenFormat, err)
+			continue
+		}
+		id, err := generator.generate(test.givenTenant)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", test.givenFormat, err)
+			continue
+		}
+		if !regexp.MustCompile(test.wantPattern).MatchString(id) {
+			t.Errorf("%q: id %q doesn't match %s", test.givenFormat, id, test.wantPattern)
+		}
+	}
+	if _, err := newJobIDGenerator("snowflake", nil); err == nil {
+		t.Error("unexpected <nil> error for invalid format")
+	}
+}
+
+func TestULIDGeneratorIsSorted(t *testing.T) {
+	now := time.Date(2016, 11, 5, 10, 0, 0, 0, time.UTC)
+	generator := ulidGenerator{now: func() time.Time { return now }}
+	var previous string
+	for i := 0; i < 100; i++ {
+		if i%10 == 0 {
+			now = now.Add(time.Millisecond)
+		}
+		id, err := generator.generate("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= previous {
+			t.Fatalf("ids are not sorted: %q was generated after %q", id, previous)
+		}
+		previous = id
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route GET /jobs jobs listJobs
+//
+// Lists jobs by creation time, oldest first, optionally filtered by status,
+// provider and creation time. Results are paginated, and the link to the
+// next page is included while there may be more jobs.
+//
+//     Responses:
+//       200: jobList
+//       400: genericError
+//       500: genericError
+func (s *TranscodingService) listJobs(r *http.Request) swagger.GizmoJSONResponse {
+	var params listJobsInput
+	if err := params.loadParams(r.URL.Query()); err != nil {
+		return swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)
+	}
+	jobs, err := s.db.ListJobs(db.JobFilter{
+		Since:        params.since,
+		Until:        params.until,
+		Status:       params.Status,
+		ProviderName: params.Provider,
+		Offset:       params.Offset,
+		Limit:        params.Limit,
+	})
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	list := jobList{Jobs: make([]jobListItem, 0, len(jobs))}
+	for _, job := range jobs {
+		list.Jobs = append(list.Jobs, jobListItem{
+			JobID:         job.ID,
+			ExternalID:    job.ExternalID,
+			ProviderName:  job.ProviderName,
+			ProviderJobID: job.ProviderJobID,
+			Status:        job.Status,
+			Source:        job.SourceMedia,
+			Labels:        job.Labels,
+			Priority:      job.Priority,
+			CreationTime:  job.CreationTime,
+			Links:         map[string]string{"self": "/jobs/" + job.ID},
+		})
+	}
+	if uint(len(jobs)) == params.Limit {
+		list.Next = params.nextPage()
+	}
+	return newListJobsResponse(&list)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	defaultJobListLimit = 50
+	maxJobListLimit     = 500
+)
+
+// swagger:parameters listJobs
+type listJobsInput struct {
+	// include only jobs in the given status, as last known by the API
+	//
+	// in: query
+	Status string `json:"status"`
+
+	// include only jobs submitted to the given provider
+	//
+	// in: query
+	Provider string `json:"provider"`
+
+	// include only jobs created at or after the given time, in RFC 3339
+	// format
+	//
+	// in: query
+	Since string `json:"since"`
+
+	// include only jobs created before the given time, in RFC 3339 format
+	//
+	// in: query
+	Until string `json:"until"`
+
+	// number of matching jobs to skip
+	//
+	// in: query
+	Offset uint `json:"offset"`
+
+	// maximum number of jobs in the page (defaults to 50, up to 500)
+	//
+	// in: query
+	Limit uint `json:"limit"`
+
+	since time.Time
+	until time.Time
+}
+
+func (p *listJobsInput) loadParams(query url.Values) error {
+	p.Status = query.Get("status")
+	p.Provider = query.Get("provider")
+	p.Since = query.Get("since")
+	p.Until = query.Get("until")
+	if p.Status != "" && !notifiableStatuses[provider.Status(p.Status)] {
+		return fmt.Errorf("invalid status %q", p.Status)
+	}
+	var err error
+	if p.Since != "" {
+		if p.since, err = time.Parse(time.RFC3339, p.Since); err != nil {
+			return errors.New("invalid since, it must be in RFC 3339 format")
+		}
+	}
+	if p.Until != "" {
+		if p.until, err = time.Parse(time.RFC3339, p.Until); err != nil {
+			return errors.New("invalid until, it must be in RFC 3339 format")
+		}
+	}
+	if offset := query.Get("offset"); offset != "" {
+		value, err := strconv.ParseUint(offset, 10, 32)
+		if err != nil {
+			return errors.New("invalid offset, it must be a non-negative integer")
+		}
+		p.Offset = uint(value)
+	}
+	p.Limit = defaultJobListLimit
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil || value == 0 || value > maxJobListLimit {
+			return fmt.Errorf("invalid limit, it must be between 1 and %d", maxJobListLimit)
+		}
+		p.Limit = uint(value)
+	}
+	return nil
+}
+
+// nextPage returns the link to the page following the current one.
+func (p *listJobsInput) nextPage() string {
+	query := url.Values{}
+	for name, value := range map[string]string{"status": p.Status, "provider": p.Provider, "since": p.Since, "until": p.Until} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	query.Set("offset", strconv.FormatUint(uint64(p.Offset+p.Limit), 10))
+	query.Set("limit", strconv.FormatUint(uint64(p.Limit), 10))
+	return "/jobs?" + query.Encode()
+}
+
+// Page of jobs, oldest first.
+//
+// swagger:model
+type jobList struct {
+	Jobs []jobListItem `json:"jobs"`
+
+	// link to the next page, present when the page is full
+	Next string `json:"next,omitempty"`
+}
+
+// jobListItem summarizes a job in the list of jobs. The status is the last
+// status of the job known by the API.
+type jobListItem struct {
+	JobID         string            `json:"jobId"`
+	ExternalID    string            `json:"externalId,omitempty"`
+	ProviderName  string            `json:"providerName"`
+	ProviderJobID string            `json:"providerJobId,omitempty"`
+	Status        string            `json:"status,omitempty"`
+	Source        string            `json:"source"`
+	Labels        []string          `json:"labels,omitempty"`
+	Priority      string            `json:"priority,omitempty"`
+	CreationTime  time.Time         `json:"creationTime"`
+	Links         map[string]string `json:"links"`
+}
+
+// JSON-encoded page of jobs.
+//
+// swagger:response jobList
+type listJobsResponse struct {
+	// in: body
+	Payload *jobList
+
+	baseResponse
+}
+
+func newListJobsResponse(list *jobList) *listJobsResponse {
+	return &listJobsResponse{
+		baseResponse: baseResponse{
+			payload: list,
+			status:  http.StatusOK,
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestListJobs(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	until := now.Add(-90 * time.Minute).Format(time.RFC3339)
+	tests := []struct {
+		givenTestCase string
+		givenQuery    string
+
+		wantCode  int
+		wantError string
+		wantJobs  []string
+		wantNext  string
+	}{
+		{"all jobs", "", http.StatusOK, "", []string{"job-1", "job-2", "job-3", "job-4"}, ""},
+		{"filtered by status", "?status=finished", http.StatusOK, "", []string{"job-1", "job-3"}, ""},
+		{"filtered by provider", "?provider=zencoder", http.StatusOK, "", []string{"job-2", "job-4"}, ""},
+		{"filtered by creation time", "?until=" + until, http.StatusOK, "", []string{"job-1", "job-2"}, ""},
+		{"first page", "?limit=2&provider=fake", http.StatusOK, "", []string{"job-1", "job-3"}, "/jobs?limit=2&offset=2&provider=fake"},
+		{"last page", "?limit=3&offset=3", http.StatusOK, "", []string{"job-4"}, ""},
+		{"invalid status", "?status=done", http.StatusBadRequest, `invalid status "done"`, nil, ""},
+		{"invalid until", "?until=today", http.StatusBadRequest, "invalid until, it must be in RFC 3339 format", nil, ""},
+		{"invalid offset", "?offset=-1", http.StatusBadRequest, "invalid offset, it must be a non-negative integer", nil, ""},
+		{"invalid limit", "?limit=0", http.StatusBadRequest, "invalid limit, it must be between 1 and 500", nil, ""},
+	}
+	for _, test := range tests {
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeDB := dbtest.NewFakeRepository(false)
+		jobs := []db.Job{
+			{ID: "job-1", ProviderName: "fake", Status: "finished", CreationTime: now.Add(-3 * time.Hour)},
+			{ID: "job-2", ProviderName: "zencoder", Status: "failed", CreationTime: now.Add(-2 * time.Hour)},
+			{ID: "job-3", ProviderName: "fake", Status: "finished", CreationTime: now.Add(-90 * time.Minute)},
+			{ID: "job-4", ProviderName: "zencoder", Status: "started", CreationTime: now.Add(-time.Hour)},
+		}
+		for i := range jobs {
+			fakeDB.CreateJob(&jobs[i])
+		}
+		service.db = fakeDB
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		srvr.Register(service)
+		r, _ := http.NewRequest("GET", "/jobs"+test.givenQuery, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantCode != http.StatusOK {
+			var got map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&got)
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		var got jobList
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotJobs := []string{}
+		for _, job := range got.Jobs {
+			gotJobs = append(gotJobs, job.JobID)
+			if job.Links["self"] != "/jobs/"+job.JobID {
+				t.Errorf("%s: wrong self link of job %q: %q", test.givenTestCase, job.JobID, job.Links["self"])
+			}
+		}
+		if !reflect.DeepEqual(gotJobs, test.wantJobs) {
+			t.Errorf("%s: wrong jobs listed. Want %#v. Got %#v", test.givenTestCase, test.wantJobs, gotJobs)
+		}
+		if got.Next != test.wantNext {
+			t.Errorf("%s: wrong link to the next page. Want %q. Got %q", test.givenTestCase, test.wantNext, got.Next)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route POST /ladders ladders newLadder
+//
+// Creates a new ladder in the API.
+//
+//     Responses:
+//       200: ladder
+//       400: invalidLadder
+//       409: ladderAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newLadder(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newLadderInput
+	ladder, err := input.Ladder(r.Body)
+	if err != nil {
+		return newInvalidLadderResponse(err)
+	}
+	if resp := s.checkLadderPresets(&ladder); resp != nil {
+		return resp
+	}
+	err = s.db.CreateLadder(&ladder)
+	switch err {
+	case nil:
+		return newLadderResponse(&ladder)
+	case db.ErrLadderAlreadyExists:
+		return newLadderAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /ladders/{name} ladders getLadder
+//
+// Finds a ladder using its name.
+//
+//     Responses:
+//       200: ladder
+//       404: ladderNotFound
+//       500: genericError
+func (s *TranscodingService) getLadder(r *http.Request) swagger.GizmoJSONResponse {
+	var params getLadderInput
+	params.loadParams(web.Vars(r))
+	ladder, err := s.db.GetLadder(params.Name)
+	switch err {
+	case nil:
+		return newLadderResponse(ladder)
+	case db.ErrLadderNotFound:
+		return newLadderNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route PUT /ladders/{name} ladders updateLadder
+//
+// Updates the presets and the streaming parameters of a ladder using its
+// name. Jobs created before the update keep their outputs.
+//
+//     Responses:
+//       200: ladder
+//       400: invalidLadder
+//       404: ladderNotFound
+//       500: genericError
+func (s *TranscodingService) updateLadder(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input updateLadderInput
+	ladder, err := input.Ladder(web.Vars(r), r.Body)
+	if err != nil {
+		return newInvalidLadderResponse(err)
+	}
+	if resp := s.checkLadderPresets(&ladder); resp != nil {
+		return resp
+	}
+	err = s.db.UpdateLadder(&ladder)
+	switch err {
+	case nil:
+		updatedTarget, _ := s.db.GetLadder(ladder.Name)
+		return newLadderResponse(updatedTarget)
+	case db.ErrLadderNotFound:
+		return newLadderNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route DELETE /ladders/{name} ladders deleteLadder
+//
+// Deletes a ladder by name.
+//
+//     Responses:
+//       200: emptyResponse
+//       404: ladderNotFound
+//       500: genericError
+func (s *TranscodingService) deleteLadder(r *http.Request) swagger.GizmoJSONResponse {
+	var params getLadderInput
+	params.loadParams(web.Vars(r))
+	err := s.db.DeleteLadder(&db.Ladder{Name: params.Name})
+	switch err {
+	case nil:
+		return emptyResponse(http.StatusOK)
+	case db.ErrLadderNotFound:
+		return newLadderNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /ladders ladders listLadders
+//
+// List ladders registered in the API.
+//
+//     Responses:
+//       200: listLadders
+//       500: genericError
+func (s *TranscodingService) listLadders(r *http.Request) swagger.GizmoJSONResponse {
+	ladders, err := s.db.ListLadders()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListLaddersResponse(ladders)
+}
+
+// checkLadderPresets returns an error response when one of the presetmaps
+// of the ladder doesn't exist.
+func (s *TranscodingService) checkLadderPresets(ladder *db.Ladder) swagger.GizmoJSONResponse {
+	for _, name := range ladder.Presets {
+		_, err := s.db.GetPresetMap(name)
+		switch err {
+		case nil:
+		case db.ErrPresetMapNotFound:
+			return newInvalidLadderResponse(fmt.Errorf("presetmap %q not found", name))
+		default:
+			return swagger.NewErrorResponse(err)
+		}
+	}
+	return nil
+}
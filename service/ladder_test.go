@@ -0,0 +1,184 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewLadder(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"valid ladder",
+			`{"name":"hls_sd","presets":["hls_360p","hls_720p"],"streamingParams":{"protocol":"hls","segmentDuration":6}}`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"ladder without presets",
+			`{"name":"hls_sd"}`,
+			http.StatusBadRequest,
+			"missing presets from the request",
+		},
+		{
+			"unknown presetmap",
+			`{"name":"hls_sd","presets":["hls_360p","hls_1080p"]}`,
+			http.StatusBadRequest,
+			`presetmap "hls_1080p" not found`,
+		},
+		{
+			"invalid streaming protocol",
+			`{"name":"hls_sd","presets":["hls_360p"],"streamingParams":{"protocol":"smooth"}}`,
+			http.StatusBadRequest,
+			`invalid streaming protocol "smooth"`,
+		},
+		{
+			"ladder already exists",
+			`{"name":"mp4","presets":["hls_360p"]}`,
+			http.StatusConflict,
+			db.ErrLadderAlreadyExists.Error(),
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{Name: "hls_360p"})
+		fakeDB.CreatePresetMap(&db.PresetMap{Name: "hls_720p"})
+		fakeDB.CreateLadder(&db.Ladder{Name: "mp4", Presets: []string{"mp4_1080p"}})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/ladders", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if test.wantError != "" && got["error"] != test.wantError {
+			t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+	}
+}
+
+func TestTranscodeLadder(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode            int
+		wantError           string
+		wantPresets         []string
+		wantSegmentDuration uint
+	}{
+		{
+			"ladder",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","ladder":"hls_sd"}`,
+			http.StatusOK,
+			"",
+			[]string{"hls_360p", "hls_720p"},
+			6,
+		},
+		{
+			"ladder with streaming params",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","ladder":"hls_sd","streamingParams":{"protocol":"hls","segmentDuration":4}}`,
+			http.StatusOK,
+			"",
+			[]string{"hls_360p", "hls_720p"},
+			4,
+		},
+		{
+			"ladder and outputs",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","ladder":"hls_sd","outputs":[{"preset":"hls_360p"}]}`,
+			http.StatusBadRequest,
+			"ladder and outputs can't be used together",
+			nil,
+			0,
+		},
+		{
+			"unknown ladder",
+			`{"source":"http://some.nice/video.mp4","provider":"fake","ladder":"hls_hd"}`,
+			http.StatusBadRequest,
+			db.ErrLadderNotFound.Error(),
+			nil,
+			0,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		for _, name := range []string{"hls_360p", "hls_720p"} {
+			fakeDB.CreatePresetMap(&db.PresetMap{
+				Name:            name,
+				ProviderMapping: map[string]string{"fake": name},
+				OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+			})
+		}
+		fakeDB.CreateLadder(&db.Ladder{
+			Name:            "hls_sd",
+			Presets:         []string{"hls_360p", "hls_720p"},
+			StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6},
+		})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.wantCode != http.StatusOK {
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		job, err := fakeDB.GetJob(got["jobId"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Ladder != "hls_sd" {
+			t.Errorf("%s: wrong ladder. Want %q. Got %q", test.givenTestCase, "hls_sd", job.Ladder)
+		}
+		var presets []string
+		for _, output := range job.Outputs {
+			presets = append(presets, output.Preset)
+		}
+		if strings.Join(presets, ",") != strings.Join(test.wantPresets, ",") {
+			t.Errorf("%s: wrong presets. Want %v. Got %v", test.givenTestCase, test.wantPresets, presets)
+		}
+		if job.StreamingParams.Protocol != "hls" || job.StreamingParams.SegmentDuration != test.wantSegmentDuration {
+			t.Errorf("%s: wrong streaming params: %#v", test.givenTestCase, job.StreamingParams)
+		}
+	}
+}
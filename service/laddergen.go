@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// ladderHeights are the standard heights of the renditions of generated
+// ladders.
+var ladderHeights = []int{2160, 1440, 1080, 720, 540, 480, 360, 240}
+
+// lowRungMaxAudioBitrate is the maximum audio bitrate of the renditions
+// below 480p, in bps.
+const lowRungMaxAudioBitrate = 96000
+
+// swagger:route POST /ladders/{name}/generate ladders generateLadder
+//
+// Generates an adaptive bitrate ladder from its top rendition. The lower
+// renditions use the standard heights below the top one, with the video
+// bitrate scaled down with the number of pixels. The presets are created
+// in the providers along with their presetmaps, named after the ladder and
+// the height of the rendition (e.g. web_720p), and the ladder is created
+// referencing them.
+//
+//     Responses:
+//       200: generateLadder
+//       400: invalidLadder
+//       409: ladderAlreadyExists
+//       500: generateLadder
+func (s *TranscodingService) generateLadder(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input generateLadderInput
+	if err := input.loadParams(web.Vars(r), r.Body); err != nil {
+		return newInvalidLadderResponse(err)
+	}
+	payload := input.Payload
+	generated := generatedLadder{
+		Ladder: db.Ladder{
+			Name:            input.Name,
+			Description:     payload.Description,
+			StreamingParams: payload.StreamingParams,
+		},
+		Presets: ladderRungs(input.Name, payload.Top, payload.Rungs),
+	}
+	for _, preset := range generated.Presets {
+		generated.Ladder.Presets = append(generated.Ladder.Presets, preset.Name)
+	}
+	if payload.DryRun {
+		return newGenerateLadderResponse(&generated, http.StatusOK)
+	}
+	_, err := s.db.GetLadder(input.Name)
+	switch err {
+	case nil:
+		return newLadderAlreadyExistsResponse(db.ErrLadderAlreadyExists)
+	case db.ErrLadderNotFound:
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+	providers := payload.Providers
+	if len(providers) == 0 {
+		providers = provider.ListProviders(s.config)
+	}
+	presets := make([]bulkPreset, len(generated.Presets))
+	for i, preset := range generated.Presets {
+		presets[i] = bulkPreset{Preset: preset, OutputOptions: payload.OutputOptions}
+	}
+	generated.Results = s.createBulkPresets(presets, providers)
+	generated.Ladder.Presets = nil
+	for _, preset := range generated.Presets {
+		if presetMap := generated.Results[preset.Name].PresetMap; presetMap != "" {
+			generated.Ladder.Presets = append(generated.Ladder.Presets, presetMap)
+		}
+	}
+	if len(generated.Ladder.Presets) == 0 {
+		return newGenerateLadderResponse(&generated, http.StatusInternalServerError)
+	}
+	err = s.db.CreateLadder(&generated.Ladder)
+	switch err {
+	case nil:
+		return newGenerateLadderResponse(&generated, http.StatusOK)
+	case db.ErrLadderAlreadyExists:
+		return newLadderAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// ladderRungs returns the presets of the renditions of the ladder with the
+// given top rendition, from the lowest to the top one. The renditions are
+// limited to maxRungs, when positive.
+//
+// The video bitrate of each rendition is the bitrate of the top one scaled
+// by the ratio of pixels raised to 0.75, as the bits needed per pixel grow
+// as the frame gets smaller. The width, when given in the top rendition, is
+// scaled keeping the aspect ratio, otherwise it's left for the provider.
+func ladderRungs(name string, top db.Preset, maxRungs int) []db.Preset {
+	topHeight, _ := strconv.Atoi(top.Video.Height)
+	topWidth, _ := strconv.Atoi(top.Video.Width)
+	topBitrate, _ := strconv.ParseInt(top.Video.Bitrate, 10, 64)
+	heights := []int{topHeight}
+	for _, height := range ladderHeights {
+		if maxRungs > 0 && len(heights) >= maxRungs {
+			break
+		}
+		if height < topHeight {
+			heights = append(heights, height)
+		}
+	}
+	presets := make([]db.Preset, len(heights))
+	for i, height := range heights {
+		preset := top
+		preset.Name = fmt.Sprintf("%s_%dp", name, height)
+		preset.Description = fmt.Sprintf("%dp rendition of the %s ladder", height, name)
+		preset.Video.Height = strconv.Itoa(height)
+		if topWidth > 0 {
+			width := int(math.Floor(float64(topWidth*height)/float64(topHeight)/2+0.5)) * 2
+			preset.Video.Width = strconv.Itoa(width)
+		}
+		ratio := math.Pow(float64(height)/float64(topHeight), 1.5)
+		bitrate := int64(math.Floor(float64(topBitrate)*ratio/1000+0.5)) * 1000
+		preset.Video.Bitrate = strconv.FormatInt(bitrate, 10)
+		if audioBitrate, err := strconv.ParseInt(top.Audio.Bitrate, 10, 64); err == nil && height < 480 && audioBitrate > lowRungMaxAudioBitrate {
+			preset.Audio.Bitrate = strconv.Itoa(lowRungMaxAudioBitrate)
+		}
+		presets[len(heights)-1-i] = preset
+	}
+	return presets
+}
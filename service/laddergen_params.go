@@ -0,0 +1,120 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// swagger:parameters generateLadder
+type generateLadderInput struct {
+	// name of the ladder, also used as the prefix of the names of the
+	// generated presets
+	//
+	// in: path
+	// required: true
+	Name string `json:"name"`
+
+	// in: body
+	// required: true
+	Payload generateLadderPayload
+}
+
+type generateLadderPayload struct {
+	// providers where the presets are created, defaults to all the
+	// providers configured in the API
+	Providers []string `json:"providers,omitempty"`
+
+	// top rendition of the ladder. The lower renditions use the same
+	// settings, scaled down to the standard heights. The name of the preset
+	// is ignored.
+	//
+	// required: true
+	Top db.Preset `json:"top"`
+
+	// output options of the generated presetmaps
+	OutputOptions db.OutputOptions `json:"outputOptions"`
+
+	// maximum number of renditions in the ladder, including the top one.
+	// Defaults to all the standard heights below the top rendition.
+	Rungs int `json:"rungs,omitempty"`
+
+	// description of the ladder
+	Description string `json:"description,omitempty"`
+
+	// configuration for adaptive streaming jobs using the ladder
+	StreamingParams db.StreamingParams `json:"streamingParams,omitempty"`
+
+	// returns the generated presets without creating them
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// generatedLadder is the result of the generation of a ladder.
+type generatedLadder struct {
+	// the ladder, referencing the presetmaps of its renditions
+	Ladder db.Ladder `json:"ladder"`
+
+	// presets of the renditions, from the lowest to the top one
+	Presets []db.Preset `json:"presets"`
+
+	// results of the creation of each preset, absent on dry-runs
+	Results map[string]bulkPresetResult `json:"results,omitempty"`
+}
+
+// response for the generateLadder operation.
+//
+// swagger:response generateLadder
+type generateLadderResponse struct {
+	// in: body
+	Payload *generatedLadder
+
+	baseResponse
+}
+
+func newGenerateLadderResponse(generated *generatedLadder, status int) *generateLadderResponse {
+	return &generateLadderResponse{
+		baseResponse: baseResponse{
+			payload: generated,
+			status:  status,
+		},
+	}
+}
+
+// loadParams loads the input from the request path and body and
+// validates it.
+func (p *generateLadderInput) loadParams(paramsMap map[string]string, body io.Reader) error {
+	p.Name = paramsMap["name"]
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return err
+	}
+	top := p.Payload.Top
+	if top.AudioOnly {
+		return errors.New("the top rendition must have video")
+	}
+	if top.Video.Codec == "" {
+		return errors.New("missing video codec of the top rendition")
+	}
+	if height, err := strconv.Atoi(top.Video.Height); err != nil || height <= 0 {
+		return errors.New("invalid height of the top rendition")
+	}
+	if bitrate, err := strconv.ParseInt(top.Video.Bitrate, 10, 64); err != nil || bitrate <= 0 {
+		return errors.New("invalid video bitrate of the top rendition")
+	}
+	if top.Video.Width != "" {
+		if width, err := strconv.Atoi(top.Video.Width); err != nil || width <= 0 {
+			return errors.New("invalid width of the top rendition")
+		}
+	}
+	if p.Payload.Rungs < 0 {
+		return errors.New("rungs must not be negative")
+	}
+	return validateLadder(&db.Ladder{
+		Name:            p.Name,
+		Presets:         []string{p.Name},
+		StreamingParams: p.Payload.StreamingParams,
+	})
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestLadderRungs(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenTop      db.Preset
+		givenRungs    int
+
+		wantRungs []db.VideoPreset
+		wantAudio []string
+	}{
+		{
+			"1080p with width",
+			db.Preset{
+				Container: "mp4",
+				Video:     db.VideoPreset{Codec: "h264", Width: "1920", Height: "1080", Bitrate: "6000000"},
+				Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+			},
+			0,
+			[]db.VideoPreset{
+				{Codec: "h264", Width: "426", Height: "240", Bitrate: "629000"},
+				{Codec: "h264", Width: "640", Height: "360", Bitrate: "1155000"},
+				{Codec: "h264", Width: "854", Height: "480", Bitrate: "1778000"},
+				{Codec: "h264", Width: "960", Height: "540", Bitrate: "2121000"},
+				{Codec: "h264", Width: "1280", Height: "720", Bitrate: "3266000"},
+				{Codec: "h264", Width: "1920", Height: "1080", Bitrate: "6000000"},
+			},
+			[]string{"96000", "96000", "128000", "128000", "128000", "128000"},
+		},
+		{
+			"720p limited rungs",
+			db.Preset{
+				Container: "webm",
+				Video:     db.VideoPreset{Codec: "vp8", Height: "720", Bitrate: "2500000"},
+				Audio:     db.AudioPreset{Codec: "vorbis", Bitrate: "64000"},
+			},
+			3,
+			[]db.VideoPreset{
+				{Codec: "vp8", Height: "480", Bitrate: "1361000"},
+				{Codec: "vp8", Height: "540", Bitrate: "1624000"},
+				{Codec: "vp8", Height: "720", Bitrate: "2500000"},
+			},
+			[]string{"64000", "64000", "64000"},
+		},
+	}
+	for _, test := range tests {
+		presets := ladderRungs("web", test.givenTop, test.givenRungs)
+		if len(presets) != len(test.wantRungs) {
+			t.Errorf("%s: wrong number of rungs. Want %d. Got %d", test.givenTestCase, len(test.wantRungs), len(presets))
+			continue
+		}
+		for i, preset := range presets {
+			if wantName := "web_" + test.wantRungs[i].Height + "p"; preset.Name != wantName {
+				t.Errorf("%s: wrong name of rung %d. Want %q. Got %q", test.givenTestCase, i, wantName, preset.Name)
+			}
+			if preset.Container != test.givenTop.Container {
+				t.Errorf("%s: wrong container of rung %d. Want %q. Got %q", test.givenTestCase, i, test.givenTop.Container, preset.Container)
+			}
+			if !reflect.DeepEqual(preset.Video, test.wantRungs[i]) {
+				t.Errorf("%s: wrong video of rung %d.\nWant %#v\nGot  %#v", test.givenTestCase, i, test.wantRungs[i], preset.Video)
+			}
+			if preset.Audio.Bitrate != test.wantAudio[i] {
+				t.Errorf("%s: wrong audio bitrate of rung %d. Want %q. Got %q", test.givenTestCase, i, test.wantAudio[i], preset.Audio.Bitrate)
+			}
+		}
+	}
+}
+
+func TestGenerateLadder(t *testing.T) {
+	fprovider.presets = nil
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	body := `{
+		"providers": ["fake"],
+		"top": {"container": "mp4", "video": {"codec": "h264", "height": "720", "bitrate": "3000000"}, "audio": {"codec": "aac", "bitrate": "128000"}},
+		"rungs": 3,
+		"streamingParams": {"protocol": "hls", "segmentDuration": 6}
+	}`
+	r, _ := http.NewRequest("POST", "/ladders/web/generate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var generated generatedLadder
+	if err = json.NewDecoder(w.Body).Decode(&generated); err != nil {
+		t.Fatal(err)
+	}
+	wantLadder := db.Ladder{
+		Name:            "web",
+		Presets:         []string{"web_480p", "web_540p", "web_720p"},
+		StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6},
+	}
+	if !reflect.DeepEqual(generated.Ladder, wantLadder) {
+		t.Errorf("wrong ladder.\nWant %#v\nGot  %#v", wantLadder, generated.Ladder)
+	}
+	ladder, err := fakeDB.GetLadder("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*ladder, wantLadder) {
+		t.Errorf("wrong ladder saved.\nWant %#v\nGot  %#v", wantLadder, *ladder)
+	}
+	for _, name := range wantLadder.Presets {
+		if result := generated.Results[name]; result.PresetMap != name {
+			t.Errorf("wrong result of preset %q: %#v", name, result)
+		}
+		if _, err := fakeDB.GetPresetMap(name); err != nil {
+			t.Errorf("presetmap %q not created: %s", name, err)
+		}
+	}
+	if len(fprovider.presets) != 3 {
+		t.Errorf("wrong number of presets created in the provider. Want 3. Got %d", len(fprovider.presets))
+	}
+
+	r, _ = http.NewRequest("POST", "/ladders/web/generate", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusConflict {
+		t.Errorf("wrong response code generating an existing ladder. Want %d. Got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestGenerateLadderDryRun(t *testing.T) {
+	fprovider.presets = nil
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	body := `{"top": {"container": "mp4", "video": {"codec": "h264", "height": "480", "bitrate": "1500000"}}, "dryRun": true}`
+	r, _ := http.NewRequest("POST", "/ladders/sd/generate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var generated generatedLadder
+	if err = json.NewDecoder(w.Body).Decode(&generated); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sd_240p", "sd_360p", "sd_480p"}; !reflect.DeepEqual(generated.Ladder.Presets, want) {
+		t.Errorf("wrong presets. Want %#v. Got %#v", want, generated.Ladder.Presets)
+	}
+	if len(generated.Presets) != 3 || generated.Results != nil {
+		t.Errorf("wrong dry-run result: %#v", generated)
+	}
+	if _, err := fakeDB.GetLadder("sd"); err != db.ErrLadderNotFound {
+		t.Errorf("wrong error. Want ErrLadderNotFound. Got %#v", err)
+	}
+	if len(fprovider.presets) != 0 {
+		t.Errorf("presets created in the provider on a dry-run: %#v", fprovider.presets)
+	}
+}
+
+func TestGenerateLadderErrors(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenBody     string
+
+		wantError string
+	}{
+		{
+			"audio-only top rendition",
+			`{"top":{"audioOnly":true,"audio":{"codec":"aac"}}}`,
+			"the top rendition must have video",
+		},
+		{
+			"missing codec",
+			`{"top":{"video":{"height":"720","bitrate":"3000000"}}}`,
+			"missing video codec of the top rendition",
+		},
+		{
+			"invalid height",
+			`{"top":{"video":{"codec":"h264","height":"hd","bitrate":"3000000"}}}`,
+			"invalid height of the top rendition",
+		},
+		{
+			"missing bitrate",
+			`{"top":{"video":{"codec":"h264","height":"720"}}}`,
+			"invalid video bitrate of the top rendition",
+		},
+		{
+			"invalid streaming protocol",
+			`{"top":{"video":{"codec":"h264","height":"720","bitrate":"3000000"}},"streamingParams":{"protocol":"rtmp"}}`,
+			`invalid streaming protocol "rtmp"`,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/ladders/web/generate", strings.NewReader(test.givenBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, http.StatusBadRequest, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got["error"] != test.wantError {
+			t.Errorf("%s: wrong error. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// jobLinks returns the links to the resources related to the job in the
+// given status, keyed by their relation to the job:
+//
+//   - self: the status of the job
+//   - artifacts: the artifacts attached to the job
+//   - cancel: the cancelation of the job, while it isn't in a terminal status
+//   - outputs: the destination of the outputs (or their CDN URL)
+//   - manifest: the master playlist of adaptive streaming jobs that finished
+func jobLinks(job *db.Job, status *provider.JobStatus) map[string]string {
+	self := "/jobs/" + job.ID
+	links := map[string]string{
+		"self":      self,
+		"artifacts": self + "/artifacts",
+	}
+	if !isTerminal(status.Status) {
+		links["cancel"] = self + "/cancel"
+	}
+	outputs := status.Output.CDNURL
+	if outputs == "" {
+		outputs = status.Output.Destination
+	}
+	if outputs != "" {
+		links["outputs"] = outputs
+	}
+	if manifest := manifestURL(job, status, outputs); manifest != "" {
+		links["manifest"] = manifest
+	}
+	return links
+}
+
+func manifestURL(job *db.Job, status *provider.JobStatus, outputs string) string {
+	playlist := job.StreamingParams.PlaylistFileName
+	if playlist == "" || status.Status != provider.StatusFinished {
+		return ""
+	}
+	for _, file := range status.Output.Files {
+		if strings.HasSuffix(file.Path, playlist) {
+			if file.CDNURL != "" {
+				return file.CDNURL
+			}
+			return file.Path
+		}
+	}
+	if outputs == "" {
+		return ""
+	}
+	return strings.TrimRight(outputs, "/") + "/" + playlist
+}
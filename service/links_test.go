@@ -0,0 +1,82 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func TestJobLinks(t *testing.T) {
+	var tests = []struct {
+		testCase    string
+		givenJob    db.Job
+		givenStatus provider.JobStatus
+		want        map[string]string
+	}{
+		{
+			"job in progress",
+			db.Job{ID: "job-1"},
+			provider.JobStatus{
+				Status: provider.StatusStarted,
+				Output: provider.JobOutput{Destination: "s3://bucket/job-1/"},
+			},
+			map[string]string{
+				"self":      "/jobs/job-1",
+				"artifacts": "/jobs/job-1/artifacts",
+				"cancel":    "/jobs/job-1/cancel",
+				"outputs":   "s3://bucket/job-1/",
+			},
+		},
+		{
+			"finished adaptive streaming job",
+			db.Job{ID: "job-1", StreamingParams: db.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/index.m3u8"}},
+			provider.JobStatus{
+				Status: provider.StatusFinished,
+				Output: provider.JobOutput{Destination: "s3://bucket/job-1/", CDNURL: "https://cdn.example.com/job-1/"},
+			},
+			map[string]string{
+				"self":      "/jobs/job-1",
+				"artifacts": "/jobs/job-1/artifacts",
+				"outputs":   "https://cdn.example.com/job-1/",
+				"manifest":  "https://cdn.example.com/job-1/hls/index.m3u8",
+			},
+		},
+		{
+			"finished adaptive streaming job with playlist in the output files",
+			db.Job{ID: "job-1", StreamingParams: db.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/index.m3u8"}},
+			provider.JobStatus{
+				Status: provider.StatusFinished,
+				Output: provider.JobOutput{
+					Destination: "s3://bucket/job-1/",
+					Files: []provider.OutputFile{
+						{Path: "s3://bucket/job-1/hls/video_720p.m3u8"},
+						{Path: "s3://bucket/job-1/hls/index.m3u8", CDNURL: "https://cdn.example.com/job-1/hls/index.m3u8"},
+					},
+				},
+			},
+			map[string]string{
+				"self":      "/jobs/job-1",
+				"artifacts": "/jobs/job-1/artifacts",
+				"outputs":   "s3://bucket/job-1/",
+				"manifest":  "https://cdn.example.com/job-1/hls/index.m3u8",
+			},
+		},
+		{
+			"failed job",
+			db.Job{ID: "job-1", StreamingParams: db.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/index.m3u8"}},
+			provider.JobStatus{Status: provider.StatusFailed},
+			map[string]string{
+				"self":      "/jobs/job-1",
+				"artifacts": "/jobs/job-1/artifacts",
+			},
+		},
+	}
+	for _, test := range tests {
+		got := jobLinks(&test.givenJob, &test.givenStatus)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: wrong links. Want %#v. Got %#v", test.testCase, test.want, got)
+		}
+	}
+}
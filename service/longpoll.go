@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+var (
+	// maxStatusWait is the longest time a request for the status of a job
+	// can be held waiting for the status to change.
+	maxStatusWait = time.Minute
+
+	// statusPollInterval is the interval between queries for the status of
+	// jobs with requests waiting for the status to change.
+	statusPollInterval = 2 * time.Second
+)
+
+// parseWait parses the wait query string parameter, either as a duration
+// (30s) or as a number of seconds (30). Waits longer than maxStatusWait are
+// reduced to maxStatusWait.
+func parseWait(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.ParseUint(value, 10, 32)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid wait %q", value)
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait < 0 {
+		return 0, fmt.Errorf("invalid wait %q", value)
+	}
+	if wait > maxStatusWait {
+		wait = maxStatusWait
+	}
+	return wait, nil
+}
+
+// waitTranscodeJob returns the status of the job once it changes, or when
+// either the wait elapses or the done channel is closed (for example, when
+// the client goes away). Jobs in a terminal status are returned immediately.
+func (s *TranscodingService) waitTranscodeJob(jobID string, wait time.Duration, done <-chan struct{}) (*db.Job, *provider.JobStatus, provider.TranscodingProvider, error) {
+	job, status, p, err := s.readTranscodeJob(jobID)
+	if err != nil || wait <= 0 || isTerminal(status.Status) {
+		return job, status, p, err
+	}
+	initial := status.Status
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			job, status, p, err = s.readTranscodeJob(jobID)
+			if err != nil || status.Status != initial {
+				return job, status, p, err
+			}
+		case <-timeout.C:
+			return job, status, p, nil
+		case <-done:
+			return job, status, p, nil
+		}
+	}
+}
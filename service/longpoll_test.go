@@ -0,0 +1,101 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestParseWait(t *testing.T) {
+	var tests = []struct {
+		givenValue string
+		want       time.Duration
+		wantErr    bool
+	}{
+		{"", 0, false},
+		{"30s", 30 * time.Second, false},
+		{"15", 15 * time.Second, false},
+		{"10m", time.Minute, false},
+		{"-5s", 0, true},
+		{"soon", 0, true},
+	}
+	for _, test := range tests {
+		got, err := parseWait(test.givenValue)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: unexpected error value: %v", test.givenValue, err)
+		}
+		if got != test.want {
+			t.Errorf("%q: wrong wait. Want %s. Got %s", test.givenValue, test.want, got)
+		}
+	}
+}
+
+func TestWaitTranscodeJob(t *testing.T) {
+	defer func(interval time.Duration) { statusPollInterval = interval }(statusPollInterval)
+	statusPollInterval = 10 * time.Millisecond
+	var tests = []struct {
+		testCase    string
+		givenJob    db.Job
+		givenUpdate string
+		givenWait   time.Duration
+
+		wantStatus  provider.Status
+		wantMaxTime time.Duration
+	}{
+		{
+			"job in terminal status",
+			db.Job{ID: "job-1", ProviderName: "fake", ProviderJobID: "provider-job-123"},
+			"",
+			time.Minute,
+			provider.StatusFinished,
+			time.Second,
+		},
+		{
+			"status change",
+			db.Job{ID: "job-1", ProviderName: "fake", Status: string(provider.StatusPaused)},
+			string(provider.StatusCanceled),
+			time.Minute,
+			provider.StatusCanceled,
+			time.Second,
+		},
+		{
+			"wait elapsed",
+			db.Job{ID: "job-1", ProviderName: "fake", Status: string(provider.StatusPaused)},
+			"",
+			50 * time.Millisecond,
+			provider.StatusPaused,
+			time.Second,
+		},
+	}
+	for _, test := range tests {
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		job := test.givenJob
+		service.db.CreateJob(&job)
+		if test.givenUpdate != "" {
+			updated := job
+			updated.Status = test.givenUpdate
+			time.AfterFunc(50*time.Millisecond, func() { service.db.UpdateJob(&updated) })
+		}
+		start := time.Now()
+		_, status, _, err := service.waitTranscodeJob(job.ID, test.givenWait, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if status.Status != test.wantStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.testCase, test.wantStatus, status.Status)
+		}
+		if elapsed := time.Since(start); elapsed > test.wantMaxTime {
+			t.Errorf("%s: took too long to return: %s", test.testCase, elapsed)
+		}
+	}
+}
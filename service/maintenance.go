@@ -0,0 +1,130 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+const maintenancePath = "/maintenance"
+
+// maintenanceMode reads the state of the maintenance mode of the API, which
+// is stored in the repository and shared by all instances. While enabled,
+// the API is read-only: requests that would change any data fail with 503
+// and the maintenance message, and workers don't run. The state in the
+// configuration is used until the mode is switched for the first time.
+type maintenanceMode struct {
+	initial Maintenance
+}
+
+// Maintenance is the state of the maintenance mode.
+//
+// swagger:model
+type Maintenance struct {
+	// whether the API is in maintenance mode
+	Enabled bool `json:"enabled"`
+
+	// message returned by requests rejected during maintenance
+	Message string `json:"message,omitempty"`
+}
+
+func newMaintenanceMode(cfg *config.Maintenance) *maintenanceMode {
+	var m maintenanceMode
+	if cfg != nil {
+		m.initial = Maintenance{Enabled: cfg.Enabled, Message: cfg.Message}
+	}
+	return &m
+}
+
+// get returns the state of the maintenance mode stored in the given
+// repository. The initial state is returned when the mode was never
+// switched, or when the repository fails (as requests that change data fail
+// as well in that case).
+func (m *maintenanceMode) get(repo db.MaintenanceRepository) Maintenance {
+	mode, err := repo.GetMaintenanceMode()
+	if err != nil {
+		return m.initial
+	}
+	return Maintenance{Enabled: mode.Enabled, Message: mode.Message}
+}
+
+// set stores the given state in the repository, keeping the current
+// message when the state doesn't have one.
+func (m *maintenanceMode) set(repo db.MaintenanceRepository, state Maintenance) (Maintenance, error) {
+	if state.Message == "" {
+		state.Message = m.get(repo).Message
+	}
+	err := repo.SetMaintenanceMode(&db.MaintenanceMode{Enabled: state.Enabled, Message: state.Message})
+	return state, err
+}
+
+// rejects returns whether the given request must be rejected because of the
+// maintenance mode. Reads are always accepted, as well as the requests that
+// switch the maintenance mode.
+func (m *maintenanceMode) rejects(r *http.Request, state Maintenance) bool {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return false
+	}
+	return r.URL.Path != maintenancePath && state.Enabled
+}
+
+func (s *TranscodingService) maintenanceHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var state Maintenance
+		switch r.Method {
+		case "GET", "HEAD", "OPTIONS":
+		default:
+			state = s.maintenance.get(s.db)
+		}
+		if !s.maintenance.rejects(r, state) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		message := state.Message
+		if message == "" {
+			message = "the API is under maintenance"
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(swagger.NewErrorResponse(errors.New(message)))
+	})
+}
+
+// swagger:route GET /maintenance maintenance getMaintenance
+//
+// Returns the state of the maintenance mode.
+//
+//     Responses:
+//       200: maintenance
+func (s *TranscodingService) getMaintenance(r *http.Request) swagger.GizmoJSONResponse {
+	return newMaintenanceResponse(s.maintenance.get(s.db))
+}
+
+// swagger:route PUT /maintenance maintenance setMaintenance
+//
+// Enables or disables the maintenance mode, in which the API is read-only.
+// The mode is stored in the database, and applies to every instance of the
+// API.
+//
+//     Responses:
+//       200: maintenance
+//       400: invalidMaintenance
+func (s *TranscodingService) setMaintenance(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input setMaintenanceInput
+	state, err := input.Maintenance(r.Body)
+	if err != nil {
+		return newInvalidMaintenanceResponse(err)
+	}
+	state, err = s.maintenance.set(s.db, state)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	s.logger.WithField("enabled", state.Enabled).Warn("maintenance mode switched")
+	return newMaintenanceResponse(state)
+}
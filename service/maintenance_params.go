@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// JSON-encoded state of the maintenance mode.
+//
+// swagger:response maintenance
+type maintenanceResponse struct {
+	// in: body
+	Payload Maintenance
+
+	baseResponse
+}
+
+// swagger:parameters setMaintenance
+type setMaintenanceInput struct {
+	// in: body
+	// required: true
+	Payload Maintenance
+}
+
+// error returned when the given maintenance state is not valid.
+//
+// swagger:response invalidMaintenance
+type invalidMaintenanceResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newMaintenanceResponse(state Maintenance) *maintenanceResponse {
+	return &maintenanceResponse{
+		baseResponse: baseResponse{
+			payload: state,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newInvalidMaintenanceResponse(err error) *invalidMaintenanceResponse {
+	return &invalidMaintenanceResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidMaintenanceResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+// Maintenance loads the input from the request body and returns the state of
+// the maintenance mode.
+func (p *setMaintenanceInput) Maintenance(body io.Reader) (Maintenance, error) {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	return p.Payload, err
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	presetMapBody := `{"name":"mp4_1080p","providerMapping":{"fake":"18828"},"output":{"extension":"mp4"}}`
+	tests := []struct {
+		givenTestCase string
+		givenMethod   string
+		givenURL      string
+		givenBody     string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"mutation while in maintenance",
+			"POST",
+			"/presetmaps",
+			presetMapBody,
+			http.StatusServiceUnavailable,
+			"migrating storage",
+		},
+		{
+			"read while in maintenance",
+			"GET",
+			"/presetmaps",
+			"",
+			http.StatusOK,
+			"",
+		},
+		{
+			"change of the maintenance message",
+			"PUT",
+			"/maintenance",
+			`{"enabled":true,"message":"almost done"}`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"mutation with the new message",
+			"DELETE",
+			"/presetmaps/mp4_1080p",
+			"",
+			http.StatusServiceUnavailable,
+			"almost done",
+		},
+		{
+			"end of the maintenance",
+			"PUT",
+			"/maintenance",
+			`{"enabled":false}`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"mutation after the maintenance",
+			"POST",
+			"/presetmaps",
+			presetMapBody,
+			http.StatusOK,
+			"",
+		},
+	}
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	service, err := NewTranscodingService(&config.Config{
+		Maintenance: &config.Maintenance{Enabled: true, Message: "migrating storage"},
+	}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	srvr.Register(service)
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.givenMethod, test.givenURL, strings.NewReader(test.givenBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantError == "" {
+			continue
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: missing Retry-After header", test.givenTestCase)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if got["error"] != test.wantError {
+			t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+	}
+}
+
+func TestMaintenanceModeSharedByInstances(t *testing.T) {
+	fakeDB := dbtest.NewFakeRepository(false)
+	var servers []*server.SimpleServer
+	for i := 0; i < 2; i++ {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		servers = append(servers, srvr)
+	}
+	r, _ := http.NewRequest("PUT", "/maintenance", strings.NewReader(`{"enabled":true,"message":"migrating storage"}`))
+	w := httptest.NewRecorder()
+	servers[0].ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code switching the maintenance mode. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	r, _ = http.NewRequest("DELETE", "/presetmaps/mp4_1080p", nil)
+	w = httptest.NewRecorder()
+	servers[1].ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("wrong response code in the other instance. Want %d. Got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestGetTranscodeJobInMaintenance(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123"})
+	fakeDB.SetMaintenanceMode(&db.MaintenanceMode{Enabled: true})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	r, _ := http.NewRequest("GET", "/jobs/job-123", nil)
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var got map[string]interface{}
+	err = json.NewDecoder(w.Body).Decode(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["status"] != "finished" {
+		t.Errorf("wrong status returned. Want %q. Got %#v", "finished", got["status"])
+	}
+	job, err := fakeDB.GetJob("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "" {
+		t.Errorf("the status of the job was recorded during the maintenance: %q", job.Status)
+	}
+}
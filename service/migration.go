@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route POST /migrations presets migratePresetMaps
+//
+// Migrates presetmaps between providers, translating the presets of the
+// source provider and creating the equivalent presets in the target
+// provider. Settings that can't be translated are reported for each
+// presetmap.
+//
+//     Responses:
+//       200: migratePresetMaps
+//       400: invalidMigration
+//       500: genericError
+func (s *TranscodingService) migratePresetMaps(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input migratePresetMapsInput
+	err := input.loadParams(r.Body)
+	if err != nil {
+		return newInvalidMigrationResponse(err)
+	}
+	source, err := s.initProvider(input.Payload.From)
+	if err != nil {
+		return newInvalidMigrationResponse(err)
+	}
+	exporter, ok := source.(provider.PresetExporter)
+	if !ok {
+		return newInvalidMigrationResponse(fmt.Errorf("provider %q doesn't support exporting presets", input.Payload.From))
+	}
+	var target provider.TranscodingProvider
+	if !input.Payload.DryRun {
+		target, err = s.initProvider(input.Payload.To)
+		if err != nil {
+			return newInvalidMigrationResponse(err)
+		}
+	}
+	presetMaps, err := s.migrationPresetMaps(input.Payload.PresetMaps)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	results := make(map[string]presetMapMigration)
+	for _, presetMap := range presetMaps {
+		presetID, ok := presetMap.ProviderMapping[input.Payload.From]
+		if !ok {
+			continue
+		}
+		preset, unsupported, err := exporter.ExportPreset(presetID)
+		if err != nil {
+			results[presetMap.Name] = presetMapMigration{Error: "exporting preset: " + err.Error()}
+			continue
+		}
+		preset.Name = presetMap.Name
+		result := presetMapMigration{Preset: &preset, Unsupported: unsupported}
+		if !input.Payload.DryRun {
+			result.PresetID, err = s.migratePreset(target, input.Payload.To, presetMap, preset)
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+		results[presetMap.Name] = result
+	}
+	return newMigratePresetMapsResponse(results)
+}
+
+func (s *TranscodingService) initProvider(name string) (provider.TranscodingProvider, error) {
+	return s.initEnvironmentProvider(name, "")
+}
+
+func (s *TranscodingService) initEnvironmentProvider(name, environment string) (provider.TranscodingProvider, error) {
+	factory, err := provider.GetProviderFactory(name)
+	if err != nil {
+		return nil, fmt.Errorf("getting factory for provider %q: %s", name, err)
+	}
+	providerObj, err := factory(s.providerConfig(environment))
+	if err != nil {
+		return nil, fmt.Errorf("initializing provider %q: %s", name, err)
+	}
+	return providerObj, nil
+}
+
+func (s *TranscodingService) migrationPresetMaps(names []string) ([]db.PresetMap, error) {
+	if len(names) == 0 {
+		return s.db.ListPresetMaps(db.PresetMapFilter{})
+	}
+	presetMaps := make([]db.PresetMap, 0, len(names))
+	for _, name := range names {
+		presetMap, err := s.db.GetPresetMap(name)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving presetmap %q: %s", name, err)
+		}
+		presetMaps = append(presetMaps, *presetMap)
+	}
+	return presetMaps, nil
+}
+
+func (s *TranscodingService) migratePreset(target provider.TranscodingProvider, targetName string, presetMap db.PresetMap, preset db.Preset) (string, error) {
+	if presetID, ok := presetMap.ProviderMapping[targetName]; ok {
+		return presetID, fmt.Errorf("presetmap already has a preset in provider %q", targetName)
+	}
+	err := target.Capabilities().Check(targetName, presetRequirements(preset))
+	if err != nil {
+		return "", err
+	}
+	presetID, err := target.CreatePreset(preset)
+	if err != nil {
+		return "", fmt.Errorf("creating preset: %s", err)
+	}
+	presetMap = copyPresetMap(presetMap)
+	presetMap.ProviderMapping[targetName] = presetID
+	err = s.db.UpdatePresetMap(&presetMap)
+	if err != nil {
+		return presetID, fmt.Errorf("updating presetmap: %s", err)
+	}
+	return presetID, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:parameters migratePresetMaps
+type migratePresetMapsInput struct {
+	// in: body
+	// required: true
+	Payload migratePresetMapsPayload
+}
+
+type migratePresetMapsPayload struct {
+	// name of the provider that currently hosts the presets
+	//
+	// required: true
+	From string `json:"from"`
+
+	// name of the provider that will receive the new presets
+	//
+	// required: true
+	To string `json:"to"`
+
+	// names of the presetmaps to migrate, defaults to all presetmaps
+	PresetMaps []string `json:"presetmaps,omitempty"`
+
+	// translates the presets without creating them in the target provider
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// result of the migration of a single presetmap.
+type presetMapMigration struct {
+	// the preset translated from the source provider
+	Preset *db.Preset `json:"preset,omitempty"`
+
+	// id of the preset created in the target provider
+	PresetID string `json:"presetId,omitempty"`
+
+	// settings of the source preset that couldn't be translated
+	Unsupported []string `json:"unsupported,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// response for the migratePresetMaps operation, in the format
+// `presetMapName: migrationResult`.
+//
+// swagger:response migratePresetMaps
+type migratePresetMapsResponse struct {
+	// in: body
+	Results map[string]presetMapMigration
+
+	baseResponse
+}
+
+// error returned when the given migration is not valid.
+//
+// swagger:response invalidMigration
+type invalidMigrationResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newMigratePresetMapsResponse(results map[string]presetMapMigration) *migratePresetMapsResponse {
+	return &migratePresetMapsResponse{
+		baseResponse: baseResponse{
+			payload: results,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newInvalidMigrationResponse(err error) *invalidMigrationResponse {
+	return &invalidMigrationResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidMigrationResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+// loadParams loads the input from the request body and validates it.
+func (p *migratePresetMapsInput) loadParams(body io.Reader) error {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return err
+	}
+	if p.Payload.From == "" {
+		return errors.New("missing source provider from the request")
+	}
+	if p.Payload.To == "" {
+		return errors.New("missing target provider from the request")
+	}
+	if p.Payload.From == p.Payload.To {
+		return errors.New("source and target providers must be different")
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestMigratePresetMaps(t *testing.T) {
+	exportedPreset := map[string]interface{}{
+		"name":      "mp4_1080p",
+		"container": "mp4",
+		"video":     map[string]interface{}{"codec": "h264", "bitrate": "1000000"},
+		"audio":     map[string]interface{}{"codec": "aac", "bitrate": "128000"},
+	}
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"dry run of all presetmaps",
+			`{"from":"fake","to":"mediaconvert","dryRun":true}`,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"mp4_1080p": map[string]interface{}{
+					"preset":      exportedPreset,
+					"unsupported": []interface{}{"thumbnails"},
+				},
+			},
+		},
+		{
+			"dry run of a single presetmap",
+			`{"from":"fake","to":"mediaconvert","presetmaps":["mp4_1080p"],"dryRun":true}`,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"mp4_1080p": map[string]interface{}{
+					"preset":      exportedPreset,
+					"unsupported": []interface{}{"thumbnails"},
+				},
+			},
+		},
+		{
+			"unknown target provider",
+			`{"from":"fake","to":"mediaconvert"}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `getting factory for provider "mediaconvert": provider not found`},
+		},
+		{
+			"same source and target",
+			`{"from":"fake","to":"fake"}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "source and target providers must be different"},
+		},
+		{
+			"missing target provider",
+			`{"from":"fake"}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "missing target provider from the request"},
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "mp4_1080p", "elastictranscoder": "1234567-abc"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "webm_720p",
+			ProviderMapping: map[string]string{"elastictranscoder": "1234567-def"},
+			OutputOpts:      db.OutputOptions{Extension: "webm"},
+		})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/migrations", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/callback"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+// callbackPayload is the body of the requests sent to the callback URL of
+// jobs. Timestamp is the time of the notification, so clients can discard
+// notifications delivered out of order.
+type callbackPayload struct {
+	JobID     string    `json:"jobId"`
+	Timestamp time.Time `json:"timestamp"`
+	*provider.JobStatus
+}
+
+// slimCallbackPayload is the body of the notifications of jobs created
+// with the slim callback payload.
+type slimCallbackPayload struct {
+	JobID         string          `json:"jobId"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Status        provider.Status `json:"status"`
+	StatusMessage string          `json:"statusMessage,omitempty"`
+}
+
+// notifiableStatuses are the statuses accepted in the notification filters
+// of jobs.
+var notifiableStatuses = map[provider.Status]bool{
+	provider.StatusQueuedLocally:        true,
+	provider.StatusPaused:               true,
+	provider.StatusQueued:               true,
+	provider.StatusStarted:              true,
+	provider.StatusFinished:             true,
+	provider.StatusFinishedWithWarnings: true,
+	provider.StatusFailed:               true,
+	provider.StatusCanceled:             true,
+}
+
+func validateNotifications(notifyOn []string, payload string) error {
+	for _, status := range notifyOn {
+		if !notifiableStatuses[provider.Status(status)] {
+			return fmt.Errorf("invalid status %q in notifyOn", status)
+		}
+	}
+	if payload != "" && payload != db.CallbackPayloadFull && payload != db.CallbackPayloadSlim {
+		return fmt.Errorf("invalid callbackPayload %q, it must be full or slim", payload)
+	}
+	return nil
+}
+
+// notifiesOn returns whether the job has notifications enabled for the
+// given status.
+func notifiesOn(job *db.Job, status provider.Status) bool {
+	if len(job.NotifyOn) == 0 {
+		return true
+	}
+	for _, s := range job.NotifyOn {
+		if provider.Status(s) == status {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationLease is how long a notification is held by the worker
+// delivering it, so the workers of other instances don't deliver it at the
+// same time.
+const notificationLease = time.Minute
+
+// callbackNotifier delivers notifications to the callback URLs of jobs,
+// signing them with the configured key. Requests go through the dialer of
+// the source validator, so callbacks can't reach private networks.
+type callbackNotifier struct {
+	client        *http.Client
+	logger        *logrus.Logger
+	signingKey    string
+	maxRetries    uint
+	retryInterval time.Duration
+	now           func() time.Time
+}
+
+func newCallbackNotifier(cfg *config.Callbacks, sources *sourceValidator, logger *logrus.Logger) *callbackNotifier {
+	n := callbackNotifier{
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: sources.dialContext, TLSHandshakeTimeout: 10 * time.Second},
+		},
+		logger: logger,
+		now:    time.Now,
+	}
+	if cfg != nil {
+		n.signingKey = cfg.SigningKey
+		n.maxRetries = cfg.MaxRetries
+		n.retryInterval = cfg.RetryInterval
+	}
+	return &n
+}
+
+// backoff returns how long to wait before the next attempt of a
+// notification that failed the given number of times.
+func (n *callbackNotifier) backoff(attempts uint) time.Duration {
+	return n.retryInterval << (attempts - 1)
+}
+
+func (n *callbackNotifier) send(url, contentType string, data []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if n.signingKey != "" {
+		timestamp := strconv.FormatInt(n.now().Unix(), 10)
+		req.Header.Set(callback.TimestampHeader, timestamp)
+		req.Header.Set(callback.SignatureHeader, callback.Sign(n.signingKey, timestamp, data))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return nil
+}
+
+// isTerminal returns whether the given status is final, meaning that it
+// won't change anymore.
+func isTerminal(status provider.Status) bool {
+	switch status {
+	case provider.StatusFinished, provider.StatusFinishedWithWarnings, provider.StatusFailed, provider.StatusCanceled:
+		return true
+	}
+	return false
+}
+
+// recordStatus stores the given status as the last status of the job, and
+// notifies the callback URL of the job about the change. It returns whether
+// the status of the job changed. The first time a job is recorded as
+// finished, its finish time is stored and used for learning the throughput
+// of the provider.
+func (s *TranscodingService) recordStatus(job *db.Job, status *provider.JobStatus) (bool, error) {
+	if job.Status == string(status.Status) {
+		return false, nil
+	}
+	job.Status = string(status.Status)
+	finished := job.FinishTime == nil && (status.Status == provider.StatusFinished || status.Status == provider.StatusFinishedWithWarnings)
+	if finished {
+		finishTime := s.progress.now().UTC()
+		job.FinishTime = &finishTime
+	}
+	if err := s.db.UpdateJob(job); err != nil {
+		return true, err
+	}
+	if finished {
+		if err := s.progress.learn(s.db, job, status.SourceInfo.Duration); err != nil {
+			s.logger.WithError(err).WithField("jobId", job.ID).Warn("failed to learn the throughput of the provider")
+		}
+	}
+	s.indexSource(job, status)
+	if job.CallbackURL != "" {
+		return true, s.notify(job, status)
+	}
+	return true, nil
+}
+
+// notifyAsync notifies the callback URL of the job about the given status,
+// logging errors instead of returning them. It's used for the statuses set
+// by the API itself, like the initial status of new jobs.
+func (s *TranscodingService) notifyAsync(job *db.Job, status *provider.JobStatus) {
+	if job.CallbackURL == "" {
+		return
+	}
+	if err := s.notify(job, status); err != nil {
+		s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to notify job")
+	}
+}
+
+// notify queues the status of the job for delivery to its callback URL,
+// encrypting it with the callback key of the tenant when there's one.
+// Statuses filtered out by the job are skipped. Queued notifications are
+// stored in the database and delivered by RunCallbacks.
+func (s *TranscodingService) notify(job *db.Job, status *provider.JobStatus) error {
+	if !notifiesOn(job, status.Status) {
+		return nil
+	}
+	var publicKey string
+	if job.Tenant != "" {
+		tenant, err := s.db.GetTenant(job.Tenant)
+		if err != nil {
+			return fmt.Errorf("error notifying job %q: %s", job.ID, err)
+		}
+		publicKey = tenant.CallbackPublicKey
+	}
+	var payload interface{} = callbackPayload{JobID: job.ID, Timestamp: s.callbacks.now().UTC(), JobStatus: status}
+	if job.CallbackPayload == db.CallbackPayloadSlim {
+		payload = slimCallbackPayload{
+			JobID:         job.ID,
+			Timestamp:     s.callbacks.now().UTC(),
+			Status:        status.Status,
+			StatusMessage: status.StatusMessage,
+		}
+	}
+	data, contentType, err := callback.Encode(payload, publicKey)
+	if err != nil {
+		return fmt.Errorf("error notifying job %q: %s", job.ID, err)
+	}
+	id, err := uuidGenerator{}.generate("")
+	if err != nil {
+		return fmt.Errorf("error notifying job %q: %s", job.ID, err)
+	}
+	err = s.db.CreateNotification(&db.Notification{
+		ID:          id,
+		JobID:       job.ID,
+		URL:         job.CallbackURL,
+		ContentType: contentType,
+		Data:        data,
+		NextAttempt: s.callbacks.now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error notifying job %q: %s", job.ID, err)
+	}
+	return nil
+}
+
+// RunCallbacks periodically delivers the queued notifications to the
+// callback URLs of jobs, until the given channel is closed. It returns
+// immediately when the delivery interval isn't configured.
+func (s *TranscodingService) RunCallbacks(stop <-chan struct{}) {
+	cfg := s.config.Callbacks
+	if cfg == nil || cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.deliverNotifications()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// deliverNotifications delivers the queued notifications that are due,
+// leasing each of them while it's delivered. Failed notifications are
+// retried up to the configured number of times, doubling the interval on
+// each retry. Notifications are delivered at least once: a notification
+// delivered by an instance that stops before removing it is delivered again
+// once its lease expires. It doesn't run while the API is in maintenance
+// mode.
+func (s *TranscodingService) deliverNotifications() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	notifications, err := s.db.ListNotifications()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list notifications")
+		return
+	}
+	now := s.callbacks.now().UTC()
+	var wg sync.WaitGroup
+	for _, notification := range notifications {
+		if notification.NextAttempt.After(now) {
+			continue
+		}
+		notification.NextAttempt = now.Add(notificationLease)
+		if err = s.db.UpdateNotification(&notification); err != nil {
+			if err != db.ErrNotificationNotFound {
+				s.logger.WithError(err).WithField("jobId", notification.JobID).Error("failed to lease notification")
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(notification db.Notification) {
+			defer wg.Done()
+			s.deliverNotification(&notification)
+		}(notification)
+	}
+	wg.Wait()
+}
+
+func (s *TranscodingService) deliverNotification(notification *db.Notification) {
+	logger := s.logger.WithField("jobId", notification.JobID)
+	err := s.callbacks.send(notification.URL, notification.ContentType, notification.Data)
+	if err == nil {
+		if err = s.db.DeleteNotification(notification); err != nil && err != db.ErrNotificationNotFound {
+			logger.WithError(err).Error("failed to remove delivered notification")
+		}
+		return
+	}
+	notification.Attempts++
+	notification.LastError = err.Error()
+	logger.WithError(err).WithField("attempt", notification.Attempts).Warn("failed to notify job")
+	if notification.Attempts > s.callbacks.maxRetries {
+		logger.Error("giving up notifying job")
+		if err = s.db.DeleteNotification(notification); err != nil && err != db.ErrNotificationNotFound {
+			logger.WithError(err).Error("failed to remove notification")
+		}
+		return
+	}
+	notification.NextAttempt = s.callbacks.now().UTC().Add(s.callbacks.backoff(notification.Attempts))
+	if err = s.db.UpdateNotification(notification); err != nil && err != db.ErrNotificationNotFound {
+		logger.WithError(err).Error("failed to reschedule notification")
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/callback"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestDeliverNotifications(t *testing.T) {
+	var tests = []struct {
+		testCase      string
+		signingKey    string
+		maxRetries    uint
+		failures      int
+		wantAttempts  int
+		wantSignature string
+	}{
+		{"delivered on the first attempt", "", 3, 0, 1, ""},
+		{"signed notification", "callback-secret", 3, 0, 1, "sha256=8aff71e5820c1ba6d6116039a25b4d0ce323a9b1f847b22e0e404c41e3a062fc"},
+		{"delivered on a retry", "", 3, 2, 3, ""},
+		{"retries exhausted", "", 2, 5, 3, ""},
+		{"retries disabled", "", 0, 5, 1, ""},
+	}
+	body := `{"jobId":"job-123","status":"finished"}`
+	for _, test := range tests {
+		var mtx sync.Mutex
+		var attempts int
+		var signatures, timestamps []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := ioutil.ReadAll(r.Body)
+			mtx.Lock()
+			defer mtx.Unlock()
+			attempts++
+			signatures = append(signatures, r.Header.Get(callback.SignatureHeader))
+			timestamps = append(timestamps, r.Header.Get(callback.TimestampHeader))
+			if string(data) != body || r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("%s: wrong request: %s %q", test.testCase, r.Header.Get("Content-Type"), data)
+			}
+			if attempts <= test.failures {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+		service, err := NewTranscodingService(&config.Config{Callbacks: &config.Callbacks{
+			SigningKey:    test.signingKey,
+			MaxRetries:    test.maxRetries,
+			RetryInterval: time.Minute,
+		}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		now := time.Unix(1500000000, 0).UTC()
+		service.callbacks.now = func() time.Time { return now }
+		service.db.CreateNotification(&db.Notification{
+			ID:          "notification-123",
+			JobID:       "job-123",
+			URL:         server.URL,
+			ContentType: "application/json",
+			Data:        []byte(body),
+			NextAttempt: now,
+		})
+		service.deliverNotifications()
+		queued, _ := service.db.ListNotifications()
+		if test.failures > 0 && test.maxRetries > 0 {
+			if len(queued) != 1 || queued[0].Attempts != 1 || queued[0].LastError != "callback returned 502 Bad Gateway" || !queued[0].NextAttempt.Equal(now.Add(time.Minute)) {
+				t.Errorf("%s: wrong queued notification after the first attempt: %#v", test.testCase, queued)
+			}
+		}
+		for i := 0; i < 5; i++ {
+			service.deliverNotifications()
+			now = now.Add(time.Hour)
+		}
+		server.Close()
+		if attempts != test.wantAttempts {
+			t.Errorf("%s: wrong number of attempts. Want %d. Got %d", test.testCase, test.wantAttempts, attempts)
+		}
+		if queued, _ = service.db.ListNotifications(); len(queued) > 0 {
+			t.Errorf("%s: unexpected queued notifications: %#v", test.testCase, queued)
+		}
+		for i := range signatures {
+			if signatures[i] != test.wantSignature {
+				t.Errorf("%s: wrong signature. Want %q. Got %q", test.testCase, test.wantSignature, signatures[i])
+			}
+			if test.signingKey != "" && timestamps[i] != "1500000000" {
+				t.Errorf("%s: wrong timestamp. Want %q. Got %q", test.testCase, "1500000000", timestamps[i])
+			}
+		}
+	}
+}
+
+func TestDeliverNotificationsLeased(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+	}))
+	defer server.Close()
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	now := time.Unix(1500000000, 0).UTC()
+	service.callbacks.now = func() time.Time { return now }
+	service.db.CreateNotification(&db.Notification{
+		ID:          "notification-123",
+		JobID:       "job-123",
+		URL:         server.URL,
+		NextAttempt: now.Add(notificationLease),
+	})
+	service.deliverNotifications()
+	if attempts != 0 {
+		t.Errorf("wrong number of attempts before the lease expired. Want 0. Got %d", attempts)
+	}
+	now = now.Add(notificationLease)
+	service.deliverNotifications()
+	if attempts != 1 {
+		t.Errorf("wrong number of attempts after the lease expired. Want 1. Got %d", attempts)
+	}
+}
+
+func TestNotifyFilters(t *testing.T) {
+	var tests = []struct {
+		testCase      string
+		notifyOn      []string
+		payload       string
+		status        provider.Status
+		wantDelivered bool
+		wantSlim      bool
+	}{
+		{"no filters", nil, "", provider.StatusStarted, true, false},
+		{"status filtered out", []string{"failed", "finished"}, "", provider.StatusStarted, false, false},
+		{"status in the filters", []string{"failed", "finished"}, "full", provider.StatusFinished, true, false},
+		{"slim payload", []string{"failed"}, "slim", provider.StatusFailed, true, true},
+	}
+	for _, test := range tests {
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(data))
+		}))
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.callbacks.now = func() time.Time { return time.Unix(1500000000, 0) }
+		job := db.Job{ID: "job-123", CallbackURL: server.URL, NotifyOn: test.notifyOn, CallbackPayload: test.payload}
+		err = service.notify(&job, &provider.JobStatus{
+			Status:        test.status,
+			StatusMessage: "some message",
+			ProviderName:  "fake",
+			Progress:      20,
+		})
+		service.deliverNotifications()
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if !test.wantDelivered {
+			if len(bodies) > 0 {
+				t.Errorf("%s: unexpected notification: %s", test.testCase, bodies[0])
+			}
+			continue
+		}
+		if len(bodies) != 1 {
+			t.Errorf("%s: wrong number of notifications. Want 1. Got %d", test.testCase, len(bodies))
+			continue
+		}
+		if test.wantSlim {
+			want := `{"jobId":"job-123","timestamp":"2017-07-14T02:40:00Z","status":"failed","statusMessage":"some message"}`
+			if bodies[0] != want {
+				t.Errorf("%s: wrong notification.\nWant %s\nGot  %s", test.testCase, want, bodies[0])
+			}
+			continue
+		}
+		var payload map[string]interface{}
+		if err = json.Unmarshal([]byte(bodies[0]), &payload); err != nil {
+			t.Errorf("%s: invalid notification: %s", test.testCase, err)
+			continue
+		}
+		if payload["jobId"] != "job-123" || payload["status"] != string(test.status) || payload["providerName"] != "fake" || payload["output"] == nil {
+			t.Errorf("%s: wrong notification: %s", test.testCase, bodies[0])
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// orphanMinAge is the minimum age of the jobs reported as orphans. Younger
+// jobs may have just been submitted by the API, which records them only
+// after the provider accepts them.
+const orphanMinAge = 15 * time.Minute
+
+// swagger:route POST /orphanedjobs jobs collectOrphanedJobs
+//
+// Lists the active jobs on each provider and cancels the ones that the API
+// has no record of, like jobs created by crashed replicas or by manual
+// testing. Jobs created less than 15 minutes ago are left alone, and only
+// Elastic Transcoder and MediaConvert support listing jobs.
+//
+//     Responses:
+//       200: collectOrphanedJobs
+//       400: invalidOrphanCollection
+//       500: genericError
+func (s *TranscodingService) collectOrphanedJobs(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input collectOrphanedJobsInput
+	err := input.loadParams(r.Body)
+	if err != nil {
+		return newInvalidOrphanCollectionResponse(err)
+	}
+	names := input.Payload.Providers
+	if len(names) == 0 {
+		names = provider.ListProviders(s.config)
+	}
+	results := make(map[string]orphanCollection, len(names))
+	providers := make(map[string]provider.TranscodingProvider, len(names))
+	active := make(map[string][]provider.ActiveJob, len(names))
+	for _, name := range names {
+		providerObj, err := s.initProvider(name)
+		if err != nil {
+			return newInvalidOrphanCollectionResponse(err)
+		}
+		lister, ok := providerObj.(provider.ActiveJobLister)
+		if !ok {
+			results[name] = orphanCollection{Error: "provider doesn't support listing jobs"}
+			continue
+		}
+		jobs, err := lister.ActiveJobs()
+		if err != nil {
+			results[name] = orphanCollection{Error: "listing jobs: " + err.Error()}
+			continue
+		}
+		providers[name] = providerObj
+		active[name] = jobs
+	}
+	// jobs are loaded only after listing the active jobs in the providers,
+	// so jobs created in the meantime are not reported as orphans.
+	known, err := s.knownProviderJobs()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	for name, jobs := range active {
+		var collection orphanCollection
+		for _, job := range jobs {
+			if known[name][job.ID] || time.Since(job.CreationTime) < orphanMinAge {
+				continue
+			}
+			orphan := orphanedJob{ProviderJobID: job.ID}
+			if !input.Payload.DryRun {
+				if err := providers[name].CancelJob(job.ID); err != nil {
+					orphan.Error = err.Error()
+				} else {
+					orphan.Canceled = true
+				}
+			}
+			collection.Orphans = append(collection.Orphans, orphan)
+		}
+		results[name] = collection
+	}
+	return newCollectOrphanedJobsResponse(results)
+}
+
+// knownProviderJobs returns the ids of the jobs in the providers that have a
+// record in the API, indexed by provider name.
+func (s *TranscodingService) knownProviderJobs() (map[string]map[string]bool, error) {
+	jobs, err := s.db.ListJobs(db.JobFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing jobs: %s", err)
+	}
+	known := make(map[string]map[string]bool)
+	for _, job := range jobs {
+		if known[job.ProviderName] == nil {
+			known[job.ProviderName] = make(map[string]bool)
+		}
+		known[job.ProviderName][job.ProviderJobID] = true
+	}
+	return known, nil
+}
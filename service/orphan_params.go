@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:parameters collectOrphanedJobs
+type collectOrphanedJobsInput struct {
+	// in: body
+	Payload collectOrphanedJobsPayload
+}
+
+type collectOrphanedJobsPayload struct {
+	// names of the providers to look for orphaned jobs, defaults to all
+	// enabled providers
+	Providers []string `json:"providers,omitempty"`
+
+	// lists the orphaned jobs without canceling them
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// job found in a provider without a record in the API.
+type orphanedJob struct {
+	ProviderJobID string `json:"providerJobId"`
+	Canceled      bool   `json:"canceled"`
+	Error         string `json:"error,omitempty"`
+}
+
+// result of the collection of orphaned jobs in a single provider.
+type orphanCollection struct {
+	Orphans []orphanedJob `json:"orphans,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// response for the collectOrphanedJobs operation, in the format
+// `providerName: collectionResult`.
+//
+// swagger:response collectOrphanedJobs
+type collectOrphanedJobsResponse struct {
+	// in: body
+	Results map[string]orphanCollection
+
+	baseResponse
+}
+
+// error returned when the given parameters are not valid.
+//
+// swagger:response invalidOrphanCollection
+type invalidOrphanCollectionResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newCollectOrphanedJobsResponse(results map[string]orphanCollection) *collectOrphanedJobsResponse {
+	return &collectOrphanedJobsResponse{
+		baseResponse: baseResponse{
+			payload: results,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newInvalidOrphanCollectionResponse(err error) *invalidOrphanCollectionResponse {
+	return &invalidOrphanCollectionResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidOrphanCollectionResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+// loadParams loads the input from the request body. All parameters are
+// optional, so the body may be empty.
+func (p *collectOrphanedJobsInput) loadParams(body io.Reader) error {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
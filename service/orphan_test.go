@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestCollectOrphanedJobs(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode     int
+		wantBody     map[string]interface{}
+		wantCanceled []string
+	}{
+		{
+			"dry run",
+			`{"providers":["fake"],"dryRun":true}`,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"fake": map[string]interface{}{
+					"orphans": []interface{}{
+						map[string]interface{}{"providerJobId": "provider-job-orphan", "canceled": false},
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"cancel orphaned jobs",
+			`{"providers":["fake"]}`,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"fake": map[string]interface{}{
+					"orphans": []interface{}{
+						map[string]interface{}{"providerJobId": "provider-job-orphan", "canceled": true},
+					},
+				},
+			},
+			[]string{"provider-job-orphan"},
+		},
+		{
+			"unknown provider",
+			`{"providers":["whatever"]}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `getting factory for provider "whatever": provider not found`},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		fprovider.activeJobs = []provider.ActiveJob{
+			{ID: "provider-job-known", CreationTime: time.Now().Add(-time.Hour)},
+			{ID: "provider-job-orphan", CreationTime: time.Now().Add(-time.Hour)},
+			{ID: "provider-job-just-submitted", CreationTime: time.Now().Add(-time.Minute)},
+		}
+		fprovider.canceledJobs = nil
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-known"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/orphanedjobs", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		if !reflect.DeepEqual(fprovider.canceledJobs, test.wantCanceled) {
+			t.Errorf("%s: wrong canceled jobs. Want %#v. Got %#v", test.givenTestCase, test.wantCanceled, fprovider.canceledJobs)
+		}
+	}
+	fprovider.activeJobs = nil
+	fprovider.canceledJobs = nil
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+	"github.com/NYTimes/video-transcoding-api/transfer"
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+var (
+	errOutputEncryptionDisabled = errors.New("output encryption is not enabled")
+	errOutputsNotEncrypted      = errors.New("the outputs of the job are not encrypted")
+	errOutputKeyAccessDenied    = errors.New("missing or invalid key access token")
+)
+
+// outputEncryption encrypts the outputs of jobs at rest with per-job data
+// keys. Data keys are generated by KMS, and only their wrapped version is
+// stored with the job. Providers write the outputs to a staging
+// destination, and the API uploads them to S3 encrypted with the data key
+// of the job (SSE-C). The staging destination is read with the configured
+// AWS credentials, and each plaintext file is deleted once uploaded.
+type outputEncryption struct {
+	cfg         *config.OutputEncryption
+	kms         kmsiface.KMSAPI
+	newUploader func(bucket string, key []byte) transfer.Uploader
+	open        func(rawURL string) (io.ReadCloser, int64, error)
+	remove      func(rawURL string) error
+}
+
+func newOutputEncryption(cfg *config.OutputEncryption) *outputEncryption {
+	e := outputEncryption{cfg: cfg}
+	if cfg == nil || cfg.KMSKeyID == "" || cfg.StagingDestination == "" {
+		return &e
+	}
+	awsConfig := aws.NewConfig().WithRegion(cfg.Region)
+	if cfg.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+	}
+	sess := session.New(awsConfig)
+	client := s3.New(sess)
+	e.kms = kms.New(sess)
+	e.newUploader = func(bucket string, key []byte) transfer.Uploader {
+		return transfer.NewS3(sess, bucket, key)
+	}
+	e.open = func(rawURL string) (io.ReadCloser, int64, error) {
+		bucket, key, err := splitS3Object(rawURL)
+		if err != nil {
+			return nil, 0, err
+		}
+		resp, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		if err != nil {
+			return nil, 0, fmt.Errorf("error fetching %q: %s", rawURL, err)
+		}
+		return resp.Body, aws.Int64Value(resp.ContentLength), nil
+	}
+	e.remove = func(rawURL string) error {
+		bucket, key, err := splitS3Object(rawURL)
+		if err != nil {
+			return err
+		}
+		_, err = client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		return err
+	}
+	return &e
+}
+
+// check validates the destination of a job with encrypted outputs.
+func (e *outputEncryption) check(destination string) error {
+	if e.kms == nil {
+		return errOutputEncryptionDisabled
+	}
+	if _, _, err := splitS3URL(destination); err != nil {
+		return fmt.Errorf("encrypted outputs must be delivered to an s3:// destination, got %q", destination)
+	}
+	return nil
+}
+
+// stagingDestination returns the destination that providers should write
+// to, for the given final destination.
+func (e *outputEncryption) stagingDestination(destination string) string {
+	return strings.TrimRight(e.cfg.StagingDestination, "/") + "/" + strings.TrimPrefix(destination, "s3://")
+}
+
+// newKey generates the data key of the given job.
+func (e *outputEncryption) newKey(jobID string, embargoUntil time.Time) (*db.OutputEncryption, error) {
+	resp, err := e.kms.GenerateDataKey(&kms.GenerateDataKeyInput{
+		KeyId:             aws.String(e.cfg.KMSKeyID),
+		KeySpec:           aws.String(kms.DataKeySpecAes256),
+		EncryptionContext: map[string]*string{"jobId": aws.String(jobID)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error generating the output encryption key: %s", err)
+	}
+	return &db.OutputEncryption{
+		KMSKeyID:     aws.StringValue(resp.KeyId),
+		WrappedKey:   base64.StdEncoding.EncodeToString(resp.CiphertextBlob),
+		EmbargoUntil: embargoUntil,
+	}, nil
+}
+
+// key unwraps the data key of the given job.
+func (e *outputEncryption) key(job *db.Job) ([]byte, error) {
+	if e.kms == nil {
+		return nil, errOutputEncryptionDisabled
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(job.OutputEncryption.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped key in job %q: %s", job.ID, err)
+	}
+	resp, err := e.kms.Decrypt(&kms.DecryptInput{
+		CiphertextBlob:    wrappedKey,
+		EncryptionContext: map[string]*string{"jobId": aws.String(job.ID)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping the output encryption key: %s", err)
+	}
+	return resp.Plaintext, nil
+}
+
+// backend returns the backend that uploads the outputs of the given job to
+// its final destination, along with the remote path of the outputs. The
+// data key is unwrapped for each upload, so it's never kept in memory.
+func (e *outputEncryption) backend(job *db.Job) (*transferBackend, string, error) {
+	bucket, path, err := splitS3URL(job.UploadDestination)
+	if err != nil {
+		return nil, "", err
+	}
+	uploader := &encryptedUploader{encryption: e, job: job, bucket: bucket}
+	return &transferBackend{
+		name:     "S3",
+		staging:  e.cfg.StagingDestination,
+		uploader: uploader,
+		open:     e.open,
+		remove:   e.remove,
+	}, path, nil
+}
+
+// encryptedUploader uploads the outputs of a job to its bucket, encrypted
+// with the data key of the job.
+type encryptedUploader struct {
+	encryption *outputEncryption
+	job        *db.Job
+	bucket     string
+}
+
+func (u *encryptedUploader) Upload(remotePath string, body io.Reader, size int64) error {
+	return u.UploadWithHeaders(remotePath, body, size, transfer.Headers{})
+}
+
+func (u *encryptedUploader) UploadWithHeaders(remotePath string, body io.Reader, size int64, headers transfer.Headers) error {
+	key, err := u.encryption.key(u.job)
+	if err != nil {
+		return err
+	}
+	uploader := u.encryption.newUploader(u.bucket, key)
+	if headerUploader, ok := uploader.(transfer.HeaderUploader); ok {
+		return headerUploader.UploadWithHeaders(remotePath, body, size, headers)
+	}
+	return uploader.Upload(remotePath, body, size)
+}
+
+// authorized returns whether the request carries the key access token.
+func (e *outputEncryption) authorized(r *http.Request) bool {
+	if e.cfg == nil || e.cfg.KeyAccessToken == "" {
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(e.cfg.KeyAccessToken)) == 1
+}
+
+// splitS3URL returns the bucket and the path (/<prefix>/) of the given S3
+// URL.
+func splitS3URL(rawURL string) (string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q", rawURL)
+	}
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return u.Host, "/", nil
+	}
+	return u.Host, "/" + path + "/", nil
+}
+
+// splitS3Object returns the bucket and the key of the object in the given
+// S3 URL.
+func splitS3Object(rawURL string) (string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "s3" || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return "", "", fmt.Errorf("invalid S3 object URL %q", rawURL)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// swagger:route GET /jobs/{jobId}/key jobs getOutputKey
+//
+// Retrieves the data key used for encrypting the outputs of the job at
+// rest. Requests must be authenticated with the key access token
+// (Authorization: Bearer <token>), and keys can't be retrieved before the
+// embargo of the job.
+//
+//     Responses:
+//       200: outputKey
+//       403: outputKeyAccessDenied
+//       404: jobNotFound
+//       500: genericError
+func (s *TranscodingService) getOutputKey(r *http.Request) swagger.GizmoJSONResponse {
+	var params getOutputKeyInput
+	params.loadParams(web.Vars(r))
+	encryption := s.uploader.encryption
+	if !encryption.authorized(r) {
+		return newOutputKeyAccessDeniedResponse(errOutputKeyAccessDenied)
+	}
+	job, err := s.db.GetJob(params.JobID)
+	if err != nil {
+		if err == db.ErrJobNotFound {
+			return newJobNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	if job.OutputEncryption == nil {
+		return newJobNotFoundResponse(errOutputsNotEncrypted)
+	}
+	if embargo := job.OutputEncryption.EmbargoUntil; time.Now().Before(embargo) {
+		return newOutputKeyAccessDeniedResponse(fmt.Errorf("the key of the job is under embargo until %s", embargo.Format(time.RFC3339)))
+	}
+	key, err := encryption.key(job)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	s.logger.WithFields(logrus.Fields{"jobId": job.ID, "remoteAddr": r.RemoteAddr}).Info("output encryption key retrieved")
+	keyMD5 := md5.Sum(key)
+	return newOutputKeyResponse(&OutputKey{
+		JobID:     job.ID,
+		Algorithm: "AES256",
+		Key:       base64.StdEncoding.EncodeToString(key),
+		KeyMD5:    base64.StdEncoding.EncodeToString(keyMD5[:]),
+	})
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// OutputEncryptionParams are the parameters for encrypting the outputs of a
+// job at rest with a per-job data key.
+//
+// swagger:model
+type OutputEncryptionParams struct {
+	// the key of the job can't be retrieved before this time
+	EmbargoUntil time.Time `json:"embargoUntil"`
+}
+
+// OutputKey is the data key used for encrypting the outputs of a job, to be
+// used in the SSE-C headers of requests for the outputs.
+//
+// swagger:model
+type OutputKey struct {
+	// id of the job
+	JobID string `json:"jobId"`
+
+	// encryption algorithm of the outputs
+	Algorithm string `json:"algorithm"`
+
+	// base64 encoded data key
+	Key string `json:"key"`
+
+	// base64 encoded MD5 digest of the data key
+	KeyMD5 string `json:"keyMD5"`
+}
+
+// swagger:parameters getOutputKey
+type getOutputKeyInput struct {
+	getTranscodeJobInput
+}
+
+// JSON-encoded data key of a job.
+//
+// swagger:response outputKey
+type outputKeyResponse struct {
+	// in: body
+	Payload *OutputKey
+
+	baseResponse
+}
+
+// error returned when the request isn't allowed to retrieve the key of the
+// job, either because it's not authenticated or because the key is under
+// embargo.
+//
+// swagger:response outputKeyAccessDenied
+type outputKeyAccessDeniedResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newOutputKeyResponse(key *OutputKey) *outputKeyResponse {
+	return &outputKeyResponse{
+		baseResponse: baseResponse{
+			payload: key,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newOutputKeyAccessDeniedResponse(err error) *outputKeyAccessDeniedResponse {
+	return &outputKeyAccessDeniedResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusForbidden)}
+}
+
+func (r *outputKeyAccessDeniedResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/transfer"
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+var fakeDataKey = []byte("0123456789abcdef0123456789abcdef")
+
+type fakeKMS struct {
+	kmsiface.KMSAPI
+}
+
+func (f *fakeKMS) GenerateDataKey(input *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error) {
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          input.KeyId,
+		CiphertextBlob: []byte("wrapped:" + aws.StringValue(input.EncryptionContext["jobId"])),
+		Plaintext:      fakeDataKey,
+	}, nil
+}
+
+func (f *fakeKMS) Decrypt(input *kms.DecryptInput) (*kms.DecryptOutput, error) {
+	if string(input.CiphertextBlob) != "wrapped:"+aws.StringValue(input.EncryptionContext["jobId"]) {
+		return nil, errors.New("InvalidCiphertextException")
+	}
+	return &kms.DecryptOutput{Plaintext: fakeDataKey}, nil
+}
+
+func newEncryptionService(t *testing.T, cfg *config.OutputEncryption) (*server.SimpleServer, *TranscodingService) {
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	service, err := NewTranscodingService(&config.Config{OutputEncryption: cfg}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.uploader.encryption.kms != nil {
+		service.uploader.encryption.kms = &fakeKMS{}
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	srvr.Register(service)
+	return srvr, service
+}
+
+func TestTranscodeEncryptedOutputs(t *testing.T) {
+	enabled := config.OutputEncryption{
+		KMSKeyID:           "alias/embargo",
+		Region:             "us-east-1",
+		StagingDestination: "s3://staging-bucket/encrypted/",
+	}
+	tests := []struct {
+		givenTestCase    string
+		givenConfig      config.OutputEncryption
+		givenDestination string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"outputs encrypted",
+			enabled,
+			"s3://embargo-bucket/videos/",
+			http.StatusOK,
+			"",
+		},
+		{
+			"output encryption not enabled",
+			config.OutputEncryption{Region: "us-east-1"},
+			"s3://embargo-bucket/videos/",
+			http.StatusBadRequest,
+			"output encryption is not enabled",
+		},
+		{
+			"non-s3 destination",
+			enabled,
+			"gs://embargo-bucket/videos/",
+			http.StatusBadRequest,
+			`encrypted outputs must be delivered to an s3:// destination, got "gs://embargo-bucket/videos/"`,
+		},
+	}
+	for _, test := range tests {
+		fprovider.jobs = nil
+		givenConfig := test.givenConfig
+		srvr, service := newEncryptionService(t, &givenConfig)
+		service.db.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		body := `{"source":"s3://bucket/master.mov","destination":"` + test.givenDestination + `","provider":"fake","outputEncryption":{"embargoUntil":"2030-01-01T00:00:00Z"},"outputs":[{"preset":"mp4_1080p","fileName":"video.mp4"}]}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+			continue
+		}
+		if test.wantError != "" {
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		jobID := got["jobId"].(string)
+		job, err := service.db.GetJob(jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Destination != "s3://staging-bucket/encrypted/embargo-bucket/videos/" {
+			t.Errorf("%s: wrong staging destination. Got %q", test.givenTestCase, job.Destination)
+		}
+		if job.UploadDestination != test.givenDestination {
+			t.Errorf("%s: wrong upload destination. Want %q. Got %q", test.givenTestCase, test.givenDestination, job.UploadDestination)
+		}
+		wantWrappedKey := base64.StdEncoding.EncodeToString([]byte("wrapped:" + jobID))
+		if job.OutputEncryption == nil || job.OutputEncryption.WrappedKey != wantWrappedKey || job.OutputEncryption.KMSKeyID != "alias/embargo" {
+			t.Errorf("%s: wrong output encryption recorded: %#v", test.givenTestCase, job.OutputEncryption)
+		}
+	}
+}
+
+func TestGetOutputKey(t *testing.T) {
+	wrappedKey := base64.StdEncoding.EncodeToString([]byte("wrapped:job-123"))
+	tests := []struct {
+		givenTestCase  string
+		givenToken     string
+		givenJobID     string
+		givenEmbargo   time.Time
+		givenEncrypted bool
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"key retrieved",
+			"Bearer key-token",
+			"job-123",
+			time.Now().Add(-time.Hour),
+			true,
+			http.StatusOK,
+			map[string]interface{}{
+				"jobId":     "job-123",
+				"algorithm": "AES256",
+				"key":       base64.StdEncoding.EncodeToString(fakeDataKey),
+				"keyMD5":    "hRasmdxgYDKV3nvbahU1MA==",
+			},
+		},
+		{
+			"missing token",
+			"",
+			"job-123",
+			time.Now().Add(-time.Hour),
+			true,
+			http.StatusForbidden,
+			map[string]interface{}{"error": "missing or invalid key access token"},
+		},
+		{
+			"wrong token",
+			"Bearer nope",
+			"job-123",
+			time.Now().Add(-time.Hour),
+			true,
+			http.StatusForbidden,
+			map[string]interface{}{"error": "missing or invalid key access token"},
+		},
+		{
+			"key under embargo",
+			"Bearer key-token",
+			"job-123",
+			time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+			true,
+			http.StatusForbidden,
+			map[string]interface{}{"error": "the key of the job is under embargo until 2100-01-01T00:00:00Z"},
+		},
+		{
+			"outputs not encrypted",
+			"Bearer key-token",
+			"job-123",
+			time.Time{},
+			false,
+			http.StatusNotFound,
+			map[string]interface{}{"error": "the outputs of the job are not encrypted"},
+		},
+		{
+			"job not found",
+			"Bearer key-token",
+			"job-1234",
+			time.Time{},
+			true,
+			http.StatusNotFound,
+			map[string]interface{}{"error": "job not found"},
+		},
+	}
+	for _, test := range tests {
+		srvr, service := newEncryptionService(t, &config.OutputEncryption{
+			KMSKeyID:           "alias/embargo",
+			Region:             "us-east-1",
+			StagingDestination: "s3://staging-bucket/encrypted/",
+			KeyAccessToken:     "key-token",
+		})
+		job := db.Job{ID: "job-123", ProviderName: "fake"}
+		if test.givenEncrypted {
+			job.OutputEncryption = &db.OutputEncryption{KMSKeyID: "alias/embargo", WrappedKey: wrappedKey, EmbargoUntil: test.givenEmbargo}
+		}
+		service.db.CreateJob(&job)
+		r, _ := http.NewRequest("GET", "/jobs/"+test.givenJobID+"/key", nil)
+		if test.givenToken != "" {
+			r.Header.Set("Authorization", test.givenToken)
+		}
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+			continue
+		}
+		for k, want := range test.wantBody {
+			if got[k] != want {
+				t.Errorf("%s: wrong %q in the response. Want %#v. Got %#v", test.givenTestCase, k, want, got[k])
+			}
+		}
+	}
+}
+
+func TestOutputEncryptionBackend(t *testing.T) {
+	encryption := newOutputEncryption(&config.OutputEncryption{
+		KMSKeyID:           "alias/embargo",
+		Region:             "us-east-1",
+		StagingDestination: "s3://staging-bucket/encrypted/",
+	})
+	encryption.kms = &fakeKMS{}
+	var gotBucket, gotPath, gotData string
+	var gotKey []byte
+	encryption.newUploader = func(bucket string, key []byte) transfer.Uploader {
+		return transfer.UploaderFunc(func(path string, body io.Reader, size int64) error {
+			data, _ := ioutil.ReadAll(body)
+			gotBucket, gotKey, gotPath, gotData = bucket, key, path, string(data)
+			return nil
+		})
+	}
+	job := db.Job{
+		ID:                "job-123",
+		Destination:       "s3://staging-bucket/encrypted/embargo-bucket/videos/",
+		UploadDestination: "s3://embargo-bucket/videos",
+		OutputEncryption:  &db.OutputEncryption{WrappedKey: base64.StdEncoding.EncodeToString([]byte("wrapped:job-123"))},
+	}
+	backend, path, err := encryption.backend(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/videos/" {
+		t.Errorf("wrong remote path. Want %q. Got %q", "/videos/", path)
+	}
+	if err = backend.uploader.Upload(path+"video.mp4", strings.NewReader("video"), 5); err != nil {
+		t.Fatal(err)
+	}
+	if gotBucket != "embargo-bucket" || gotPath != "/videos/video.mp4" || gotData != "video" || string(gotKey) != string(fakeDataKey) {
+		t.Errorf("wrong upload. Got bucket=%q path=%q data=%q key=%q", gotBucket, gotPath, gotData, gotKey)
+	}
+	job.ID = "job-456"
+	if err = backend.uploader.Upload(path+"video.mp4", strings.NewReader("video"), 5); err == nil {
+		t.Error("unexpected <nil> error for a key wrapped for another job")
+	}
+}
+
+func TestUploadEncryptedOutputs(t *testing.T) {
+	_, service := newEncryptionService(t, &config.OutputEncryption{
+		KMSKeyID:           "alias/embargo",
+		Region:             "us-east-1",
+		StagingDestination: "s3://staging-bucket/encrypted/",
+	})
+	encryption := service.uploader.encryption
+	uploaded := make(map[string]string)
+	encryption.newUploader = func(bucket string, key []byte) transfer.Uploader {
+		return transfer.UploaderFunc(func(path string, body io.Reader, size int64) error {
+			data, _ := ioutil.ReadAll(body)
+			uploaded[path] = string(data)
+			return nil
+		})
+	}
+	encryption.open = func(rawURL string) (io.ReadCloser, int64, error) {
+		return ioutil.NopCloser(strings.NewReader(rawURL)), int64(len(rawURL)), nil
+	}
+	var removed []string
+	encryption.remove = func(rawURL string) error {
+		if strings.HasSuffix(rawURL, ".webm") {
+			return errors.New("AccessDenied")
+		}
+		removed = append(removed, rawURL)
+		return nil
+	}
+	service.uploader.open = func(rawURL string) (io.ReadCloser, int64, error) {
+		t.Errorf("unexpected read of %q without credentials", rawURL)
+		return nil, 0, errors.New("unexpected read")
+	}
+	service.db.CreateJob(&db.Job{
+		ID:                "job-123",
+		ProviderName:      "fake",
+		ProviderJobID:     "provider-job-with-outputs",
+		Destination:       "s3://mybucket/some/dir/job-123/",
+		UploadDestination: "s3://embargo-bucket/videos",
+		OutputEncryption:  &db.OutputEncryption{WrappedKey: base64.StdEncoding.EncodeToString([]byte("wrapped:job-123"))},
+		Status:            "started",
+	})
+	service.uploadOutputs()
+	waitUploads(service.uploader)
+	job, err := service.db.GetJob("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(job.Uploads) != 2 {
+		t.Fatalf("wrong number of uploads. Want 2. Got %#v", job.Uploads)
+	}
+	if job.Uploads[0].Status != "finished" {
+		t.Errorf("wrong status of the first upload. Want %q. Got %#v", "finished", job.Uploads[0])
+	}
+	wantError := "s3://mybucket/some/dir/job-123/video_1080p.webm: uploaded, but failed to remove the staged file: AccessDenied"
+	if job.Uploads[1].Status != "failed" || job.Uploads[1].Error != wantError {
+		t.Errorf("wrong status of the second upload. Want failed with %q. Got %#v", wantError, job.Uploads[1])
+	}
+	wantRemoved := []string{"s3://mybucket/some/dir/job-123/video_720p.mp4"}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("wrong staged files removed.\nWant %#v\nGot  %#v", wantRemoved, removed)
+	}
+	if uploaded["/videos/video_720p.mp4"] != "s3://mybucket/some/dir/job-123/video_720p.mp4" {
+		t.Errorf("wrong uploads: %#v", uploaded)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route POST /pauses pauses newSubmissionPause
+//
+// Pauses the submission of jobs to a provider, or to all providers when the
+// provider is "*". Jobs created while submissions are paused are held in the
+// API with the paused status.
+//
+//     Responses:
+//       200: submissionPause
+//       400: invalidSubmissionPause
+//       409: submissionPauseAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newSubmissionPause(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newSubmissionPauseInput
+	pause, err := input.SubmissionPause(r.Body)
+	if err != nil {
+		return newInvalidSubmissionPauseResponse(err)
+	}
+	err = s.db.CreateSubmissionPause(&pause)
+	switch err {
+	case nil:
+		return newSubmissionPauseResponse(&pause)
+	case db.ErrSubmissionPauseAlreadyExists:
+		return newSubmissionPauseAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route DELETE /pauses/{provider} pauses deleteSubmissionPause
+//
+// Resumes the submission of jobs to a provider. Jobs held while submissions
+// were paused are submitted in the order they were created.
+//
+//     Responses:
+//       200: emptyResponse
+//       404: submissionPauseNotFound
+//       500: genericError
+func (s *TranscodingService) deleteSubmissionPause(r *http.Request) swagger.GizmoJSONResponse {
+	var params deleteSubmissionPauseInput
+	params.loadParams(web.Vars(r))
+	err := s.db.DeleteSubmissionPause(&db.SubmissionPause{Provider: params.Provider})
+	switch err {
+	case nil:
+	case db.ErrSubmissionPauseNotFound:
+		return newSubmissionPauseNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+	jobs, err := s.db.ListJobs(db.JobFilter{})
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	if err = s.resumeJobs(jobs); err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return emptyResponse(http.StatusOK)
+}
+
+// swagger:route GET /pauses pauses listSubmissionPauses
+//
+// Lists the active submission pauses.
+//
+//     Responses:
+//       200: listSubmissionPauses
+//       500: genericError
+func (s *TranscodingService) listSubmissionPauses(r *http.Request) swagger.GizmoJSONResponse {
+	pauses, err := s.db.ListSubmissionPauses()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListSubmissionPausesResponse(pauses)
+}
+
+// isPaused returns whether the submission of jobs to the given provider is
+// paused.
+func (s *TranscodingService) isPaused(providerName string) (bool, error) {
+	pauses, err := s.db.ListSubmissionPauses()
+	if err != nil {
+		return false, err
+	}
+	return paused(pauses, providerName), nil
+}
+
+func paused(pauses []db.SubmissionPause, providerName string) bool {
+	for _, pause := range pauses {
+		if pause.Covers(providerName) {
+			return true
+		}
+	}
+	return false
+}
+
+// holdJob records the given job, without submitting it to the provider.
+func (s *TranscodingService) holdJob(job *db.Job) swagger.GizmoJSONResponse {
+	job.Status = string(provider.StatusPaused)
+	if err := s.db.CreateJob(job); err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	s.notifyAsync(job, localJobStatus(job))
+	return newQueuedJobResponse(job)
+}
+
+// resumeJobs queues the paused jobs in the given list whose providers are no
+// longer paused, by priority and then by creation time. Jobs that don't fit
+// in the queue remain paused, and are resumed in the next reconciliation.
+func (s *TranscodingService) resumeJobs(jobs []db.Job) error {
+	pauses, err := s.db.ListSubmissionPauses()
+	if err != nil {
+		return err
+	}
+	var resumed []db.Job
+	for _, job := range jobs {
+		if job.Status == string(provider.StatusPaused) && !paused(pauses, job.ProviderName) {
+			resumed = append(resumed, job)
+		}
+	}
+	sort.Stable(byPriority(resumed))
+	for i := range resumed {
+		job := &resumed[i]
+		job.Status = string(provider.StatusQueuedLocally)
+		if err = s.db.UpdateJob(job); err != nil {
+			return err
+		}
+		if !s.submissions.enqueue(job.ID, job.Priority) {
+			job.Status = string(provider.StatusPaused)
+			return s.db.UpdateJob(job)
+		}
+	}
+	return nil
+}
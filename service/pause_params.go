@@ -0,0 +1,135 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// JSON-encoded submission pause returned on the newSubmissionPause
+// operation.
+//
+// swagger:response submissionPause
+type submissionPauseResponse struct {
+	// in: body
+	Payload *db.SubmissionPause
+
+	baseResponse
+}
+
+// swagger:parameters newSubmissionPause
+type newSubmissionPauseInput struct {
+	// in: body
+	// required: true
+	Payload db.SubmissionPause
+}
+
+// swagger:parameters deleteSubmissionPause
+type deleteSubmissionPauseInput struct {
+	// in: path
+	// required: true
+	Provider string `json:"provider"`
+}
+
+// error returned when the given provider is not paused.
+//
+// swagger:response submissionPauseNotFound
+type submissionPauseNotFoundResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given submission pause data is not valid.
+//
+// swagger:response invalidSubmissionPause
+type invalidSubmissionPauseResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to pause a provider that is already paused.
+//
+// swagger:response submissionPauseAlreadyExists
+type submissionPauseAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// response for the listSubmissionPauses operation.
+//
+// swagger:response listSubmissionPauses
+type listSubmissionPausesResponse struct {
+	// in: body
+	Pauses []db.SubmissionPause
+
+	baseResponse
+}
+
+func newSubmissionPauseResponse(pause *db.SubmissionPause) *submissionPauseResponse {
+	return &submissionPauseResponse{
+		baseResponse: baseResponse{
+			payload: pause,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newSubmissionPauseNotFoundResponse(err error) *submissionPauseNotFoundResponse {
+	return &submissionPauseNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
+}
+
+func (r *submissionPauseNotFoundResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newInvalidSubmissionPauseResponse(err error) *invalidSubmissionPauseResponse {
+	return &invalidSubmissionPauseResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidSubmissionPauseResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newSubmissionPauseAlreadyExistsResponse(err error) *submissionPauseAlreadyExistsResponse {
+	return &submissionPauseAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *submissionPauseAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newListSubmissionPausesResponse(pauses []db.SubmissionPause) *listSubmissionPausesResponse {
+	return &listSubmissionPausesResponse{
+		baseResponse: baseResponse{
+			payload: pauses,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// SubmissionPause loads the input from the request body, validates it and
+// returns the submission pause.
+func (p *newSubmissionPauseInput) SubmissionPause(body io.Reader) (db.SubmissionPause, error) {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	if p.Payload.Provider == "" {
+		return p.Payload, errors.New("missing provider from the request")
+	}
+	if p.Payload.Provider != db.AllProviders {
+		if _, err = provider.GetProviderFactory(p.Payload.Provider); err != nil {
+			return p.Payload, err
+		}
+	}
+	return p.Payload, nil
+}
+
+func (p *deleteSubmissionPauseInput) loadParams(paramsMap map[string]string) {
+	p.Provider = paramsMap["provider"]
+}
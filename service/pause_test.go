@@ -0,0 +1,186 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewSubmissionPause(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"pause a provider",
+			`{"provider":"fake","reason":"provider incident"}`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"missing provider",
+			`{"reason":"maintenance window"}`,
+			http.StatusBadRequest,
+			"missing provider from the request",
+		},
+		{
+			"unknown provider",
+			`{"provider":"unknown"}`,
+			http.StatusBadRequest,
+			provider.ErrProviderNotFound.Error(),
+		},
+		{
+			"all providers already paused",
+			`{"provider":"*"}`,
+			http.StatusConflict,
+			db.ErrSubmissionPauseAlreadyExists.Error(),
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateSubmissionPause(&db.SubmissionPause{Provider: db.AllProviders})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/pauses", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if test.wantError != "" && got["error"] != test.wantError {
+			t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+	}
+}
+
+func TestPauseAndResumeSubmissions(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "18828"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	fakeDB.CreateSubmissionPause(&db.SubmissionPause{Provider: db.AllProviders, Reason: "maintenance window"})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	dispatched := make(chan string, 1)
+	dispatch := service.submissions.dispatch
+	service.submissions.dispatch = func(jobID string) {
+		dispatch(jobID)
+		dispatched <- jobID
+	}
+	srvr.Register(service)
+
+	body := `{"source":"http://another.non.existent/video.mp4","provider":"fake","outputs":[{"preset":"mp4_1080p","fileName":"video.mp4"}]}`
+	r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("wrong response code. Want %d. Got %d", http.StatusAccepted, w.Code)
+	}
+	var got map[string]interface{}
+	err = json.NewDecoder(w.Body).Decode(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["status"] != "paused" {
+		t.Errorf("wrong status. Want %q. Got %#v", "paused", got["status"])
+	}
+	jobID := got["jobId"].(string)
+
+	r, _ = http.NewRequest("DELETE", "/pauses/*", nil)
+	w = httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code when resuming. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("paused job was not submitted after resuming")
+	}
+	job, err := fakeDB.GetJob(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ProviderJobID != "provider-preset-job-123" || job.Status != string(provider.StatusFinished) {
+		t.Errorf("job not submitted to the provider: %#v", job)
+	}
+
+	r, _ = http.NewRequest("DELETE", "/pauses/*", nil)
+	w = httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("wrong response code when resuming twice. Want %d. Got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestResumeJobsOrder(t *testing.T) {
+	fakeDB := dbtest.NewFakeRepository(false)
+	now := time.Now().UTC()
+	jobs := []db.Job{
+		{ID: "job-low", Priority: db.PriorityLow, CreationTime: now.Add(-3 * time.Hour)},
+		{ID: "job-normal-new", CreationTime: now.Add(-time.Hour)},
+		{ID: "job-normal-old", CreationTime: now.Add(-2 * time.Hour)},
+		{ID: "job-high", Priority: db.PriorityHigh, CreationTime: now},
+	}
+	for i := range jobs {
+		jobs[i].ProviderName = "fake"
+		jobs[i].Status = string(provider.StatusPaused)
+		job := jobs[i]
+		fakeDB.CreateJob(&job)
+	}
+	service, err := NewTranscodingService(&config.Config{
+		Backpressure: &config.Backpressure{MaxInFlight: 1, MaxQueued: 2},
+	}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	service.submissions.acquire()
+	if err = service.resumeJobs(jobs); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]provider.Status{
+		"job-high":       provider.StatusQueuedLocally,
+		"job-normal-old": provider.StatusQueuedLocally,
+		"job-normal-new": provider.StatusPaused,
+		"job-low":        provider.StatusPaused,
+	}
+	for id, status := range want {
+		job, err := fakeDB.GetJob(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != string(status) {
+			t.Errorf("%s: wrong status. Want %q. Got %q", id, status, job.Status)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	photosensitivityArtifactKind = "photosensitivity"
+
+	// photosensitivitySource is the source of the reports computed by the
+	// API.
+	photosensitivitySource = "ffmpeg-signalstats"
+)
+
+// check returns the photosensitivity test recorded in new jobs.
+func (p *PhotosensitivityParams) check() *db.PhotosensitivityCheck {
+	if p == nil {
+		return nil
+	}
+	return &db.PhotosensitivityCheck{Reject: p.Reject}
+}
+
+// checkFlashes runs the photosensitivity test over the video outputs of the
+// given job once it finishes. Reports are attached to the job as artifacts,
+// and the job is reported as started until the test is done. Jobs with
+// failing outputs are reported as failed when the test rejects them.
+func (s *TranscodingService) checkFlashes(job *db.Job, status *provider.JobStatus) {
+	check := job.Photosensitivity
+	if check == nil || status.Status != provider.StatusFinished {
+		return
+	}
+	if !check.Checked {
+		files := status.Output.Files
+		done, result, err := s.flashRuns.poll(job.ID, func() (interface{}, error) {
+			return s.analyzeFlashes(files)
+		})
+		if !done {
+			status.Status = provider.StatusStarted
+			status.StatusMessage = "checking the outputs for flashing sequences"
+			return
+		}
+		if err != nil {
+			status.Status = provider.StatusFailed
+			status.StatusMessage = "failed to check the outputs for flashing sequences: " + err.Error()
+			return
+		}
+		reports := result.([]db.PhotosensitivityReport)
+		for _, report := range reports {
+			if err = s.db.CreateArtifact(photosensitivityArtifact(job.ID, report)); err != nil && err != db.ErrArtifactAlreadyExists {
+				s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to attach photosensitivity report")
+			}
+		}
+		check.Checked = true
+		check.Failures = photosensitivityFailures(reports)
+	}
+	if check.Reject && len(check.Failures) > 0 {
+		status.Status = provider.StatusFailed
+		status.StatusMessage = "photosensitivity test failed: " + strings.Join(check.Failures, "; ")
+	}
+}
+
+// analyzeFlashes looks for flashing sequences in the video files, one at a
+// time.
+func (s *TranscodingService) analyzeFlashes(files []provider.OutputFile) ([]db.PhotosensitivityReport, error) {
+	var reports []db.PhotosensitivityReport
+	for _, file := range files {
+		if !fingerprintContainers[strings.ToLower(file.Container)] {
+			continue
+		}
+		input, err := s.analyzer.input(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		analysis, err := s.analyzer.analyzeFlash(input)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+		reports = append(reports, db.PhotosensitivityReport{
+			Path:       file.Path,
+			MaxFlashes: analysis.MaxFlashes,
+			Failures:   timeRanges(analysis.Failures),
+		})
+	}
+	return reports, nil
+}
+
+// photosensitivityFailures describes the failing sequences in the given
+// reports.
+func photosensitivityFailures(reports []db.PhotosensitivityReport) []string {
+	var failures []string
+	for _, report := range reports {
+		for _, failure := range report.Failures {
+			failures = append(failures, fmt.Sprintf("%s: more than %d flashes per second between %.2fs and %.2fs", path.Base(report.Path), ffmpeg.MaxFlashesPerSecond, failure.Start, failure.End))
+		}
+	}
+	return failures
+}
+
+func photosensitivityArtifact(jobID string, report db.PhotosensitivityReport) *db.Artifact {
+	data, _ := json.Marshal(report)
+	return &db.Artifact{
+		JobID:        jobID,
+		Name:         photosensitivityArtifactKind + "-" + path.Base(report.Path),
+		Kind:         photosensitivityArtifactKind,
+		Source:       photosensitivitySource,
+		ContentType:  "application/json",
+		Data:         data,
+		CreationTime: time.Now().UTC(),
+	}
+}
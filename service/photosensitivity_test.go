@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestCheckFlashes(t *testing.T) {
+	failing := ffmpeg.FlashAnalysis{MaxFlashes: 5, Failures: []ffmpeg.Interval{{Start: 12.4, End: 14}}}
+	var tests = []struct {
+		testCase      string
+		givenReject   bool
+		givenAnalysis ffmpeg.FlashAnalysis
+		givenError    error
+		wantStatus    provider.Status
+		wantMessage   string
+		wantFailures  []string
+		wantArtifacts int
+	}{
+		{
+			"no flashing sequences",
+			true,
+			ffmpeg.FlashAnalysis{MaxFlashes: 1},
+			nil,
+			provider.StatusFinished,
+			"",
+			nil,
+			1,
+		},
+		{
+			"flashing sequence reported",
+			false,
+			failing,
+			nil,
+			provider.StatusFinished,
+			"",
+			[]string{"video.mp4: more than 3 flashes per second between 12.40s and 14.00s"},
+			1,
+		},
+		{
+			"flashing sequence rejected",
+			true,
+			failing,
+			nil,
+			provider.StatusFailed,
+			"photosensitivity test failed: video.mp4: more than 3 flashes per second between 12.40s and 14.00s",
+			[]string{"video.mp4: more than 3 flashes per second between 12.40s and 14.00s"},
+			1,
+		},
+		{
+			"failed analysis",
+			true,
+			ffmpeg.FlashAnalysis{},
+			errors.New("ffmpeg failed: exit status 1"),
+			provider.StatusFailed,
+			"failed to check the outputs for flashing sequences: s3://bucket/job-123/video.mp4: ffmpeg failed: exit status 1",
+			nil,
+			0,
+		},
+	}
+	for _, test := range tests {
+		analysis, analysisErr := test.givenAnalysis, test.givenError
+		fakeDB := dbtest.NewFakeRepository(false)
+		service := TranscodingService{
+			db:        fakeDB,
+			logger:    logrus.New(),
+			flashRuns: newAnalysisRuns(),
+			analyzer: &mediaAnalyzer{
+				presign: func(bucket, key string) (string, error) {
+					return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+				},
+				analyzeFlash: func(string) (*ffmpeg.FlashAnalysis, error) {
+					if analysisErr != nil {
+						return nil, analysisErr
+					}
+					return &analysis, nil
+				},
+			},
+		}
+		job := db.Job{ID: "job-123", Photosensitivity: &db.PhotosensitivityCheck{Reject: test.givenReject}}
+		var status provider.JobStatus
+		for i := 0; i < 100; i++ {
+			status = provider.JobStatus{
+				Status: provider.StatusFinished,
+				Output: provider.JobOutput{
+					Files: []provider.OutputFile{
+						{Path: "s3://bucket/job-123/video.mp4", Container: "mp4"},
+						{Path: "s3://bucket/job-123/audio.m4a", Container: "m4a"},
+					},
+				},
+			}
+			service.checkFlashes(&job, &status)
+			if status.Status != provider.StatusStarted {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if status.Status != test.wantStatus || status.StatusMessage != test.wantMessage {
+			t.Errorf("%s: wrong status. Want %q (%q). Got %q (%q)", test.testCase, test.wantStatus, test.wantMessage, status.Status, status.StatusMessage)
+		}
+		if !reflect.DeepEqual(job.Photosensitivity.Failures, test.wantFailures) {
+			t.Errorf("%s: wrong failures.\nWant %#v\nGot  %#v", test.testCase, test.wantFailures, job.Photosensitivity.Failures)
+		}
+		artifacts, _ := fakeDB.ListArtifacts(job.ID)
+		if len(artifacts) != test.wantArtifacts {
+			t.Errorf("%s: wrong number of artifacts. Want %d. Got %#v", test.testCase, test.wantArtifacts, artifacts)
+		}
+		for _, artifact := range artifacts {
+			if artifact.Name != "photosensitivity-video.mp4" || artifact.Kind != "photosensitivity" || artifact.Source != "ffmpeg-signalstats" {
+				t.Errorf("%s: wrong artifact: %#v", test.testCase, artifact)
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// policyDocument is the JSON document with the rules of the policy.
+type policyDocument struct {
+	Rules []policyRule `json:"rules"`
+}
+
+// policyRule is an organizational rule enforced on new jobs. Rules apply to
+// the jobs of the listed tenants that have any of the listed labels, and
+// empty lists match all jobs. Constraints left empty aren't enforced.
+type policyRule struct {
+	// name of the rule, included in the errors of the jobs breaking it
+	Name string `json:"name"`
+
+	// tenants and labels of the jobs that the rule applies to
+	Tenants []string `json:"tenants,omitempty"`
+	Labels  []string `json:"labels,omitempty"`
+
+	// providers that the jobs may be submitted to
+	AllowedProviders []string `json:"allowedProviders,omitempty"`
+
+	// video and audio codecs of the presets of the outputs
+	AllowedCodecs []string `json:"allowedCodecs,omitempty"`
+
+	// maximum resolution of the outputs, in pixels
+	MaxWidth  int `json:"maxWidth,omitempty"`
+	MaxHeight int `json:"maxHeight,omitempty"`
+
+	// whether the jobs must protect their outputs with DRM
+	RequireDRM bool `json:"requireDRM,omitempty"`
+}
+
+// policyRequest is the part of the request of a job checked by the policy.
+type policyRequest struct {
+	Tenant   string
+	Labels   []string
+	Provider string
+	DRM      bool
+}
+
+// policyViolationError is the error returned when a job breaks a rule of
+// the policy.
+type policyViolationError struct {
+	rule   string
+	reason string
+}
+
+func (e policyViolationError) Error() string {
+	return fmt.Sprintf("job violates policy rule %q: %s", e.rule, e.reason)
+}
+
+// policyEngine enforces the rules of the policy on new jobs.
+type policyEngine struct {
+	rules []policyRule
+}
+
+func newPolicyEngine(cfg *config.Policy) (*policyEngine, error) {
+	if cfg == nil || cfg.File == "" {
+		return &policyEngine{}, nil
+	}
+	f, err := os.Open(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var document policyDocument
+	if err = json.NewDecoder(f).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid policy document: %s", err)
+	}
+	if err = validatePolicyRules(document.Rules); err != nil {
+		return nil, err
+	}
+	return &policyEngine{rules: document.Rules}, nil
+}
+
+func validatePolicyRules(rules []policyRule) error {
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return errors.New("policy rules must have a name")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate policy rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.MaxWidth < 0 || rule.MaxHeight < 0 {
+			return fmt.Errorf("invalid maximum resolution in policy rule %q", rule.Name)
+		}
+	}
+	return nil
+}
+
+// match returns the rules that apply to the given request.
+func (p *policyEngine) match(request policyRequest) []policyRule {
+	var rules []policyRule
+	for _, rule := range p.rules {
+		if len(rule.Tenants) > 0 && !containsString(rule.Tenants, request.Tenant) {
+			continue
+		}
+		if len(rule.Labels) > 0 && !containsAny(rule.Labels, request.Labels) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// needsPresets returns whether any of the given rules constrains the
+// settings of the presets of the outputs.
+func needsPresets(rules []policyRule) bool {
+	for _, rule := range rules {
+		if len(rule.AllowedCodecs) > 0 || rule.MaxWidth > 0 || rule.MaxHeight > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns the violation of the first rule broken by the request, or
+// nil when it follows all the rules.
+func (r *policyRule) check(request policyRequest, presets []db.Preset) error {
+	if len(r.AllowedProviders) > 0 && !containsString(r.AllowedProviders, request.Provider) {
+		return policyViolationError{rule: r.Name, reason: fmt.Sprintf("provider %q is not allowed", request.Provider)}
+	}
+	if r.RequireDRM && !request.DRM {
+		return policyViolationError{rule: r.Name, reason: "DRM is required"}
+	}
+	for _, preset := range presets {
+		if reason := r.checkPreset(preset); reason != "" {
+			return policyViolationError{rule: r.Name, reason: fmt.Sprintf("preset %q: %s", preset.Name, reason)}
+		}
+	}
+	return nil
+}
+
+func (r *policyRule) checkPreset(preset db.Preset) string {
+	if len(r.AllowedCodecs) > 0 {
+		var codecs []string
+		if !preset.AudioOnly {
+			codecs = append(codecs, preset.Video.Codec)
+		}
+		codecs = append(codecs, preset.Audio.Codec)
+		for _, codec := range codecs {
+			if codec != "" && !containsString(r.AllowedCodecs, strings.ToLower(codec)) {
+				return fmt.Sprintf("codec %q is not allowed", codec)
+			}
+		}
+	}
+	if preset.AudioOnly {
+		return ""
+	}
+	for _, dimension := range []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"width", preset.Video.Width, r.MaxWidth},
+		{"height", preset.Video.Height, r.MaxHeight},
+	} {
+		if dimension.max == 0 {
+			continue
+		}
+		value, err := strconv.Atoi(dimension.value)
+		if err != nil {
+			return fmt.Sprintf("the %s of the video must be set, up to %d", dimension.name, dimension.max)
+		}
+		if value > dimension.max {
+			return fmt.Sprintf("%s %d exceeds the maximum of %d", dimension.name, value, dimension.max)
+		}
+	}
+	return ""
+}
+
+// checkPolicy checks the request of a new job against the rules of the
+// policy that apply to it. The presets of the outputs are exported from the
+// provider of the job when any of the rules depends on their settings.
+func (s *TranscodingService) checkPolicy(request policyRequest, providerObj provider.TranscodingProvider, presetMaps []*db.PresetMap) error {
+	rules := s.policy.match(request)
+	if len(rules) == 0 {
+		return nil
+	}
+	var presets []db.Preset
+	if needsPresets(rules) {
+		exporter, ok := providerObj.(provider.PresetExporter)
+		if !ok {
+			return fmt.Errorf("can't check the policy: provider %q doesn't support exporting presets", request.Provider)
+		}
+		for _, presetMap := range presetMaps {
+			preset, _, err := exporter.ExportPreset(presetMap.ProviderMapping[request.Provider])
+			if err != nil {
+				return fmt.Errorf("can't check the policy: error exporting preset %q: %s", presetMap.Name, err)
+			}
+			preset.Name = presetMap.Name
+			presets = append(presets, preset)
+		}
+	}
+	for _, rule := range rules {
+		if err := rule.check(request, presets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(values, others []string) bool {
+	for _, other := range others {
+		if containsString(values, other) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewPolicyEngine(t *testing.T) {
+	engine, err := newPolicyEngine(&config.Policy{File: "testdata/policy.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []policyRule{
+		{Name: "web-codecs", Tenants: []string{"news"}, AllowedCodecs: []string{"h264", "aac"}},
+		{Name: "partner-hd", Tenants: []string{"partner"}, MaxHeight: 1080},
+		{Name: "premium-drm", Labels: []string{"premium"}, RequireDRM: true},
+	}
+	if !reflect.DeepEqual(engine.rules, want) {
+		t.Errorf("wrong rules.\nWant %#v\nGot  %#v", want, engine.rules)
+	}
+	rules := engine.match(policyRequest{Tenant: "news", Labels: []string{"premium"}})
+	if len(rules) != 2 || rules[0].Name != "web-codecs" || rules[1].Name != "premium-drm" {
+		t.Errorf("wrong rules matched: %#v", rules)
+	}
+	if _, err = newPolicyEngine(&config.Policy{File: "testdata/missing.json"}); err == nil {
+		t.Error("unexpected <nil> error loading a missing policy")
+	}
+}
+
+func TestValidatePolicyRules(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenRules    []policyRule
+		wantError     string
+	}{
+		{"valid rules", []policyRule{{Name: "hd", MaxHeight: 1080}, {Name: "drm", RequireDRM: true}}, ""},
+		{"missing name", []policyRule{{MaxHeight: 1080}}, "policy rules must have a name"},
+		{"duplicate name", []policyRule{{Name: "hd"}, {Name: "hd"}}, `duplicate policy rule "hd"`},
+		{"negative resolution", []policyRule{{Name: "hd", MaxWidth: -1}}, `invalid maximum resolution in policy rule "hd"`},
+	}
+	for _, test := range tests {
+		var got string
+		if err := validatePolicyRules(test.givenRules); err != nil {
+			got = err.Error()
+		}
+		if got != test.wantError {
+			t.Errorf("%s: wrong error. Want %q. Got %q", test.givenTestCase, test.wantError, got)
+		}
+	}
+}
+
+func TestPolicyEnforcement(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenRules    []policyRule
+		givenLabels   string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"allowed job",
+			[]policyRule{{Name: "web-codecs", AllowedCodecs: []string{"h264", "aac"}}},
+			`["news"]`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"codec not allowed",
+			[]policyRule{{Name: "open-codecs", AllowedCodecs: []string{"vp9", "opus"}}},
+			`["news"]`,
+			http.StatusForbidden,
+			`job violates policy rule "open-codecs": preset "mp4_1080p": codec "h264" is not allowed`,
+		},
+		{
+			"resolution not set",
+			[]policyRule{{Name: "sd", MaxHeight: 720}},
+			`["news"]`,
+			http.StatusForbidden,
+			`job violates policy rule "sd": preset "mp4_1080p": the height of the video must be set, up to 720`,
+		},
+		{
+			"provider not allowed",
+			[]policyRule{{Name: "providers", AllowedProviders: []string{"zencoder"}}},
+			`["news"]`,
+			http.StatusForbidden,
+			`job violates policy rule "providers": provider "fake" is not allowed`,
+		},
+		{
+			"missing DRM",
+			[]policyRule{{Name: "premium-drm", Labels: []string{"premium"}, RequireDRM: true}},
+			`["premium"]`,
+			http.StatusForbidden,
+			`job violates policy rule "premium-drm": DRM is required`,
+		},
+		{
+			"rule out of scope",
+			[]policyRule{{Name: "premium-drm", Labels: []string{"premium"}, RequireDRM: true}},
+			`["news"]`,
+			http.StatusOK,
+			"",
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		service.policy = &policyEngine{rules: test.givenRules}
+		srvr.Register(service)
+		body := `{
+			"source": "http://another.non.existent/video.mp4",
+			"destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+			"outputs": [{"preset": "mp4_1080p"}],
+			"labels": ` + test.givenLabels + `,
+			"provider": "fake"
+		}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if test.wantError != "" && got["error"] != test.wantError {
+			t.Errorf("%s: wrong error. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// RunStatusPoller periodically loads the status of in-flight jobs from
+// their providers and stores it in the database, until the given channel
+// is closed. It returns immediately when the poller is disabled in the
+// configuration.
+func (s *TranscodingService) RunStatusPoller(stop <-chan struct{}) {
+	cfg := s.config.StatusPoller
+	if cfg == nil || cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.pollStatuses()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// activeStatuses are the statuses of the jobs submitted to providers that
+// didn't reach a terminal status yet.
+var activeStatuses = []provider.Status{provider.StatusQueued, provider.StatusStarted, provider.StatusUnknown}
+
+// listActiveJobs lists the jobs with an active status, from the indexes of
+// those statuses.
+func (s *TranscodingService) listActiveJobs() ([]db.Job, error) {
+	var jobs []db.Job
+	for _, status := range activeStatuses {
+		listed, err := s.db.ListJobs(db.JobFilter{Status: string(status)})
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, listed...)
+	}
+	return jobs, nil
+}
+
+// pollStatuses stores the status of the jobs submitted to providers that
+// don't have a stored terminal status yet, polling jobs with higher priority
+// first. The status of jobs that reach a terminal status while polled is
+// stored for good. It doesn't run while the API is in maintenance mode.
+func (s *TranscodingService) pollStatuses() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	jobs, err := s.listActiveJobs()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list jobs for polling")
+		return
+	}
+	sort.Stable(byPriority(jobs))
+	for _, job := range jobs {
+		if job.ProviderJobID == "" {
+			continue
+		}
+		current, status, _, err := s.getTranscodeJobByID(job.ID)
+		if err != nil {
+			s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to poll job status")
+			continue
+		}
+		if err = s.storeStatusSnapshot(current, status); err != nil {
+			s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to store job status")
+		}
+	}
+}
+
+// statusSnapshotData is the status stored by the status poller. The status
+// reported by the provider is stored compressed, with a summary in the
+// status.
+type statusSnapshotData struct {
+	provider.JobStatus
+	RawProviderStatus []byte `json:"rawProviderStatus,omitempty"`
+}
+
+// storeStatusSnapshot stores the status of the job, without the fields
+// that are already recorded in the job. The status is stored in the latest
+// version of the job, and only while the job keeps the status it was polled
+// with.
+func (s *TranscodingService) storeStatusSnapshot(job *db.Job, status *provider.JobStatus) error {
+	raw, err := compressProviderStatus(status.ProviderStatus)
+	if err != nil {
+		return err
+	}
+	snapshot := statusSnapshotData{JobStatus: compactStatus(*status), RawProviderStatus: raw}
+	snapshot.ProviderStatus = summarizeProviderStatus(status.ProviderStatus)
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	stored, err := s.db.GetJob(job.ID)
+	if err != nil {
+		return err
+	}
+	stored.StatusSnapshot = string(data)
+	stored.StatusSnapshotTime = time.Now().UTC()
+	err = s.db.UpdateJobIfStatus(stored, job.Status)
+	if err == db.ErrJobStatusChanged {
+		return nil
+	}
+	return err
+}
+
+// statusSnapshot returns the status of the job stored by the status
+// poller, when it's enabled and the status is fresh. Terminal statuses are
+// always fresh, as they don't change anymore.
+func (s *TranscodingService) statusSnapshot(job *db.Job) *provider.JobStatus {
+	cfg := s.config.StatusPoller
+	if cfg == nil || cfg.Interval <= 0 || job.StatusSnapshot == "" {
+		return nil
+	}
+	var status provider.JobStatus
+	if err := json.Unmarshal([]byte(job.StatusSnapshot), &status); err != nil {
+		return nil
+	}
+	maxAge := cfg.MaxAge
+	if maxAge <= 0 {
+		maxAge = 3 * cfg.Interval
+	}
+	if !isTerminal(status.Status) && time.Since(job.StatusSnapshotTime) > maxAge {
+		return nil
+	}
+	restoreStatus(job, &status)
+	return &status
+}
+
+// compactStatus returns the given status without the fields copied from
+// the job, which are restored by restoreStatus, so stored statuses can't
+// disagree with the job. These fields are small, stored statuses are mostly
+// made of the status reported by the provider.
+func compactStatus(status provider.JobStatus) provider.JobStatus {
+	status.ProviderJobID = ""
+	status.ProviderName = ""
+	status.RequestedProvider = ""
+	status.Deadline = nil
+	status.SourceMedia = ""
+	status.FailedSources = nil
+	status.Fingerprints = nil
+	status.PostProcessors = nil
+	status.Links = nil
+	return status
+}
+
+// restoreStatus copies the fields of the job dropped by compactStatus to
+// the given status.
+func restoreStatus(job *db.Job, status *provider.JobStatus) {
+	status.ProviderJobID = job.ProviderJobID
+	status.ProviderName = job.ProviderName
+	status.RequestedProvider = job.RequestedProvider
+	status.Deadline = job.Deadline
+	if len(job.FallbackSources) > 0 {
+		status.SourceMedia = job.SourceMedia
+		status.FailedSources = job.FailedSources
+	}
+	status.Fingerprints = job.Fingerprints
+	status.PostProcessors = job.PostProcessors
+	status.Links = jobLinks(job, status)
+}
+
+// readTranscodeJob returns the status of the job stored by the status
+// poller, falling back to the provider when there's no fresh status.
+func (s *TranscodingService) readTranscodeJob(jobID string) (*db.Job, *provider.JobStatus, provider.TranscodingProvider, error) {
+	job, err := s.db.GetJob(jobID)
+	if err == nil {
+		if status := s.statusSnapshot(job); status != nil {
+			return job, status, nil, nil
+		}
+	}
+	return s.getTranscodeJobByID(jobID)
+}
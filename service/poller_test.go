@@ -0,0 +1,97 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestPollStatuses(t *testing.T) {
+	service, err := NewTranscodingService(&config.Config{StatusPoller: &config.StatusPoller{Interval: time.Minute}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	service.db.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123", Status: "started"})
+	service.db.CreateJob(&db.Job{ID: "job-queued", ProviderName: "fake", Status: "queued-locally"})
+	service.db.CreateJob(&db.Job{ID: "job-failed", ProviderName: "fake", ProviderJobID: "provider-job-failed", Status: "failed"})
+	service.pollStatuses()
+	job, err := service.db.GetJob("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "finished" || job.StatusSnapshot == "" || time.Since(job.StatusSnapshotTime) > time.Minute {
+		t.Errorf("job status not stored: %#v", job)
+	}
+	if strings.Contains(job.StatusSnapshot, "provider-job-123") || strings.Contains(job.StatusSnapshot, "links") {
+		t.Errorf("fields of the job duplicated in the stored status: %s", job.StatusSnapshot)
+	}
+	_, status, p, err := service.readTranscodeJob("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != nil {
+		t.Error("stored status not used, the provider was queried")
+	}
+	if status.Status != provider.StatusFinished || status.ProviderJobID != "provider-job-123" || status.ProviderName != "fake" || status.Links["self"] != "/jobs/job-123" {
+		t.Errorf("wrong stored status: %#v", status)
+	}
+	queued, _ := service.db.GetJob("job-queued")
+	if queued.StatusSnapshot != "" {
+		t.Errorf("unexpected status stored for job that wasn't submitted: %#v", queued)
+	}
+	failed, _ := service.db.GetJob("job-failed")
+	if failed.Status != "failed" || failed.StatusSnapshot != "" {
+		t.Errorf("job with a terminal status was polled: %#v", failed)
+	}
+}
+
+func TestReadTranscodeJob(t *testing.T) {
+	var tests = []struct {
+		testCase          string
+		givenPoller       *config.StatusPoller
+		givenSnapshot     string
+		givenSnapshotAge  time.Duration
+		wantStatus        provider.Status
+		wantProviderQuery bool
+	}{
+		{"fresh status", &config.StatusPoller{Interval: time.Minute}, `{"status":"started"}`, time.Minute, provider.StatusStarted, false},
+		{"stale status", &config.StatusPoller{Interval: time.Minute}, `{"status":"started"}`, 5 * time.Minute, provider.StatusFinished, true},
+		{"stale status within the max age", &config.StatusPoller{Interval: time.Minute, MaxAge: 10 * time.Minute}, `{"status":"started"}`, 5 * time.Minute, provider.StatusStarted, false},
+		{"old terminal status", &config.StatusPoller{Interval: time.Minute}, `{"status":"canceled"}`, 24 * time.Hour, provider.StatusCanceled, false},
+		{"poller disabled", nil, `{"status":"started"}`, 0, provider.StatusFinished, true},
+		{"no stored status", &config.StatusPoller{Interval: time.Minute}, "", 0, provider.StatusFinished, true},
+	}
+	for _, test := range tests {
+		service, err := NewTranscodingService(&config.Config{StatusPoller: test.givenPoller}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.db.CreateJob(&db.Job{
+			ID:                 "job-123",
+			ProviderName:       "fake",
+			ProviderJobID:      "provider-job-123",
+			Status:             "started",
+			StatusSnapshot:     test.givenSnapshot,
+			StatusSnapshotTime: time.Now().Add(-test.givenSnapshotAge),
+		})
+		_, status, p, err := service.readTranscodeJob("job-123")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if status.Status != test.wantStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.testCase, test.wantStatus, status.Status)
+		}
+		if (p != nil) != test.wantProviderQuery {
+			t.Errorf("%s: wrong provider query. Want %v. Got %v", test.testCase, test.wantProviderQuery, p != nil)
+		}
+	}
+}
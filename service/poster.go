@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"path"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	defaultPosterCandidates = 5
+	maxPosterCandidates     = 20
+	defaultPosterAnalyzer   = "sharpness"
+	defaultPosterFileName   = "poster.jpg"
+)
+
+var (
+	errPosterDestination = errors.New("poster frames require an s3:// destination")
+	errPosterNoVideo     = errors.New("the job doesn't have any video output")
+)
+
+// posterAnalyzer chooses the poster frame among the candidates, returning
+// its index and its score. New analyzers (like face detection) are plugged
+// in by adding them to posterAnalyzers.
+type posterAnalyzer func(frames []ffmpeg.Frame) (int, float64, error)
+
+var posterAnalyzers = map[string]posterAnalyzer{
+	"sharpness": sharpestFrame,
+	"middle":    middleFrame,
+}
+
+func (p *PosterParams) validate() error {
+	if p.Candidates > maxPosterCandidates {
+		return fmt.Errorf("poster candidates can't be more than %d", maxPosterCandidates)
+	}
+	if _, ok := posterAnalyzers[p.Analyzer]; p.Analyzer != "" && !ok {
+		return fmt.Errorf("invalid poster analyzer %q", p.Analyzer)
+	}
+	if p.FileName != "" {
+		ext := strings.ToLower(path.Ext(p.FileName))
+		if ext != ".jpg" && ext != ".jpeg" {
+			return errors.New("poster fileName must have a .jpg extension")
+		}
+		if strings.HasPrefix(p.FileName, "/") || strings.Contains(p.FileName, "..") {
+			return errors.New("poster fileName must be relative to the destination of the outputs")
+		}
+	}
+	return nil
+}
+
+// poster returns the poster settings recorded in new jobs.
+func (p *PosterParams) poster() *db.Poster {
+	if p == nil {
+		return nil
+	}
+	poster := db.Poster{Candidates: p.Candidates, Analyzer: p.Analyzer, FileName: p.FileName}
+	if poster.Candidates == 0 {
+		poster.Candidates = defaultPosterCandidates
+	}
+	if poster.Analyzer == "" {
+		poster.Analyzer = defaultPosterAnalyzer
+	}
+	if poster.FileName == "" {
+		poster.FileName = defaultPosterFileName
+	}
+	return &poster
+}
+
+// extractPoster extracts the poster frame of the given job once it
+// finishes, reporting the job as started until the image is uploaded.
+// Failures are reported as warnings of the job.
+func (s *TranscodingService) extractPoster(job *db.Job, status *provider.JobStatus) {
+	poster := job.Poster
+	if poster == nil || status.Status != provider.StatusFinished {
+		return
+	}
+	if poster.Path == "" && poster.Error == "" {
+		posterCopy := *poster
+		destination := status.Output.Destination
+		files := status.Output.Files
+		done, result, err := s.posterRuns.poll(job.ID, func() (interface{}, error) {
+			return s.choosePoster(posterCopy, destination, files)
+		})
+		if !done {
+			status.Status = provider.StatusStarted
+			status.StatusMessage = "extracting the poster frame"
+			return
+		}
+		if err != nil {
+			poster.Error = err.Error()
+		} else {
+			*poster = result.(db.Poster)
+		}
+	}
+	if poster.Error != "" {
+		status.Warnings = append(status.Warnings, "failed to extract the poster frame: "+poster.Error)
+		return
+	}
+	status.Poster = poster.Path
+}
+
+// choosePoster extracts the candidate frames from the video output with the
+// highest resolution, and uploads the one chosen by the analyzer.
+func (s *TranscodingService) choosePoster(poster db.Poster, destination string, files []provider.OutputFile) (db.Poster, error) {
+	bucket, prefix, err := splitS3URL(destination)
+	if err != nil {
+		return poster, errPosterDestination
+	}
+	var source *provider.OutputFile
+	for i, file := range files {
+		if !fingerprintContainers[strings.ToLower(file.Container)] {
+			continue
+		}
+		if source == nil || file.Width*file.Height > source.Width*source.Height {
+			source = &files[i]
+		}
+	}
+	if source == nil {
+		return poster, errPosterNoVideo
+	}
+	analyzer, ok := posterAnalyzers[poster.Analyzer]
+	if !ok {
+		return poster, fmt.Errorf("invalid poster analyzer %q", poster.Analyzer)
+	}
+	input, err := s.analyzer.input(source.Path)
+	if err != nil {
+		return poster, err
+	}
+	frames, err := s.analyzer.extractFrames(input, int(poster.Candidates))
+	if err != nil {
+		return poster, fmt.Errorf("%s: %s", source.Path, err)
+	}
+	best, score, err := analyzer(frames)
+	if err != nil {
+		return poster, err
+	}
+	key := strings.TrimPrefix(prefix, "/") + poster.FileName
+	if err = s.analyzer.upload(bucket, key, "image/jpeg", frames[best].Data); err != nil {
+		return poster, err
+	}
+	poster.Path = "s3://" + bucket + "/" + key
+	poster.Time = frames[best].Time
+	poster.Score = score
+	return poster, nil
+}
+
+// middleFrame chooses the candidate in the middle of the video.
+func middleFrame(frames []ffmpeg.Frame) (int, float64, error) {
+	return len(frames) / 2, 0, nil
+}
+
+// sharpestFrame chooses the candidate with the highest variance of the
+// Laplacian of its luma, which is low for blurry frames (as in motion or
+// transitions) and for flat ones (as in black or fades).
+func sharpestFrame(frames []ffmpeg.Frame) (int, float64, error) {
+	best, bestScore := -1, -1.0
+	for i, frame := range frames {
+		img, err := jpeg.Decode(bytes.NewReader(frame.Data))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid frame at %.3fs: %s", frame.Time, err)
+		}
+		if score := laplacianVariance(img); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return 0, 0, errors.New("no candidate frames")
+	}
+	return best, bestScore, nil
+}
+
+func laplacianVariance(img image.Image) float64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 3 || height < 3 {
+		return 0
+	}
+	luma := func(x, y int) float64 {
+		return float64(color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y)
+	}
+	if ycbcr, ok := img.(*image.YCbCr); ok {
+		luma = func(x, y int) float64 {
+			return float64(ycbcr.Y[ycbcr.YOffset(bounds.Min.X+x, bounds.Min.Y+y)])
+		}
+	}
+	var sum, sumSquares float64
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			value := luma(x-1, y) + luma(x+1, y) + luma(x, y-1) + luma(x, y+1) - 4*luma(x, y)
+			sum += value
+			sumSquares += value * value
+		}
+	}
+	n := float64((width - 2) * (height - 2))
+	mean := sum / n
+	return sumSquares/n - mean*mean
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+// testFrame returns a JPEG frame, flat gray or with a checkerboard
+// pattern.
+func testFrame(t *testing.T, checkerboard bool) []byte {
+	img := image.NewGray(image.Rect(0, 0, 64, 36))
+	for y := 0; y < 36; y++ {
+		for x := 0; x < 64; x++ {
+			value := uint8(128)
+			if checkerboard && (x/4+y/4)%2 == 0 {
+				value = 16
+			} else if checkerboard {
+				value = 235
+			}
+			img.SetGray(x, y, color.Gray{Y: value})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractPoster(t *testing.T) {
+	sharp, flat := testFrame(t, true), testFrame(t, false)
+	frames := []ffmpeg.Frame{{Time: 5, Data: sharp}, {Time: 10, Data: flat}, {Time: 15, Data: flat}}
+	var tests = []struct {
+		testCase        string
+		givenPoster     PosterParams
+		givenDest       string
+		givenError      error
+		wantPoster      string
+		wantTime        float64
+		wantWarnings    []string
+		wantCandidates  int
+		wantUploadedKey string
+	}{
+		{
+			"sharpest frame",
+			PosterParams{Candidates: 3},
+			"s3://bucket/job-123",
+			nil,
+			"s3://bucket/job-123/poster.jpg",
+			5,
+			nil,
+			3,
+			"job-123/poster.jpg",
+		},
+		{
+			"middle frame",
+			PosterParams{Candidates: 3, Analyzer: "middle", FileName: "images/cover.jpg"},
+			"s3://bucket/job-123/",
+			nil,
+			"s3://bucket/job-123/images/cover.jpg",
+			10,
+			nil,
+			3,
+			"job-123/images/cover.jpg",
+		},
+		{
+			"default candidates",
+			PosterParams{},
+			"s3://bucket/job-123",
+			nil,
+			"s3://bucket/job-123/poster.jpg",
+			5,
+			nil,
+			5,
+			"job-123/poster.jpg",
+		},
+		{
+			"destination not in s3",
+			PosterParams{},
+			"gs://bucket/job-123",
+			nil,
+			"",
+			0,
+			[]string{"failed to extract the poster frame: poster frames require an s3:// destination"},
+			0,
+			"",
+		},
+		{
+			"failed extraction",
+			PosterParams{},
+			"s3://bucket/job-123",
+			errors.New("ffmpeg failed: exit status 1"),
+			"",
+			0,
+			[]string{"failed to extract the poster frame: s3://bucket/job-123/video_1080p.mp4: ffmpeg failed: exit status 1"},
+			5,
+			"",
+		},
+	}
+	for _, test := range tests {
+		extractErr := test.givenError
+		var gotInput, gotKey string
+		var gotCandidates int
+		var gotData []byte
+		service := TranscodingService{
+			logger:     logrus.New(),
+			posterRuns: newAnalysisRuns(),
+			analyzer: &mediaAnalyzer{
+				presign: func(bucket, key string) (string, error) {
+					return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+				},
+				extractFrames: func(input string, count int) ([]ffmpeg.Frame, error) {
+					gotInput, gotCandidates = input, count
+					if extractErr != nil {
+						return nil, extractErr
+					}
+					return frames, nil
+				},
+				upload: func(bucket, key, contentType string, data []byte) error {
+					if bucket != "bucket" || contentType != "image/jpeg" {
+						t.Errorf("%s: wrong upload: %s %s", test.testCase, bucket, contentType)
+					}
+					gotKey, gotData = key, data
+					return nil
+				},
+			},
+		}
+		job := db.Job{ID: "job-123", Poster: test.givenPoster.poster()}
+		var status provider.JobStatus
+		for i := 0; i < 100; i++ {
+			status = provider.JobStatus{
+				Status: provider.StatusFinished,
+				Output: provider.JobOutput{
+					Destination: test.givenDest,
+					Files: []provider.OutputFile{
+						{Path: "s3://bucket/job-123/video_720p.mp4", Container: "mp4", Width: 1280, Height: 720},
+						{Path: "s3://bucket/job-123/video_1080p.mp4", Container: "mp4", Width: 1920, Height: 1080},
+						{Path: "s3://bucket/job-123/hls/index.m3u8", Container: "m3u8"},
+					},
+				},
+			}
+			service.extractPoster(&job, &status)
+			if status.Status != provider.StatusStarted {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if status.Status != provider.StatusFinished {
+			t.Errorf("%s: wrong status. Want %q. Got %q (%q)", test.testCase, provider.StatusFinished, status.Status, status.StatusMessage)
+		}
+		if status.Poster != test.wantPoster {
+			t.Errorf("%s: wrong poster. Want %q. Got %q", test.testCase, test.wantPoster, status.Poster)
+		}
+		if job.Poster.Time != test.wantTime {
+			t.Errorf("%s: wrong poster time. Want %g. Got %g", test.testCase, test.wantTime, job.Poster.Time)
+		}
+		if !reflect.DeepEqual(status.Warnings, test.wantWarnings) {
+			t.Errorf("%s: wrong warnings.\nWant %#v\nGot  %#v", test.testCase, test.wantWarnings, status.Warnings)
+		}
+		if gotCandidates != test.wantCandidates {
+			t.Errorf("%s: wrong number of candidates. Want %d. Got %d", test.testCase, test.wantCandidates, gotCandidates)
+		}
+		if gotCandidates > 0 && gotInput != "https://bucket.s3.amazonaws.com/job-123/video_1080p.mp4" {
+			t.Errorf("%s: wrong input: %q", test.testCase, gotInput)
+		}
+		if gotKey != test.wantUploadedKey {
+			t.Errorf("%s: wrong uploaded key. Want %q. Got %q", test.testCase, test.wantUploadedKey, gotKey)
+		}
+		if test.wantTime == 5 && !bytes.Equal(gotData, sharp) {
+			t.Errorf("%s: the sharpest frame wasn't uploaded", test.testCase)
+		}
+	}
+}
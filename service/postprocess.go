@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/hook"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// postProcessingMessage is the status message of the finished jobs whose
+// post-processors didn't run yet.
+const postProcessingMessage = "running post-processors"
+
+// postProcessPayload is the input of the post-processors.
+type postProcessPayload struct {
+	JobID string `json:"jobId"`
+	*provider.JobStatus
+}
+
+// postProcessors runs the configured post-processors once jobs finish, in
+// order. They're run by RunPostProcessors, and jobs are reported as started
+// until all of them ran. Their results are recorded in the job. Failures of
+// the post-processors don't fail the job.
+type postProcessors struct {
+	hooks []hook.Hook
+	now   func() time.Time
+
+	// lease is the time after which jobs claimed by an instance of the
+	// API for running their post-processors are released, in case the
+	// instance stopped before recording their results.
+	lease time.Duration
+}
+
+func newPostProcessors(cfg *config.Hooks) (*postProcessors, error) {
+	p := postProcessors{now: time.Now}
+	if cfg == nil {
+		return &p, nil
+	}
+	var err error
+	p.hooks, err = hook.Parse(cfg.PostProcessors, cfg)
+	p.lease = time.Duration(len(p.hooks)+1) * cfg.Timeout
+	return &p, err
+}
+
+// sync reports the results of the post-processors of the given job in the
+// status, reporting finished jobs as started until they ran.
+func (p *postProcessors) sync(job *db.Job, status *provider.JobStatus) {
+	if len(p.hooks) == 0 {
+		return
+	}
+	if status.Status != provider.StatusFinished && status.Status != provider.StatusFinishedWithWarnings {
+		return
+	}
+	if len(job.PostProcessors) == 0 {
+		status.Status = provider.StatusStarted
+		status.StatusMessage = postProcessingMessage
+		status.Links = jobLinks(job, status)
+		return
+	}
+	status.PostProcessors = job.PostProcessors
+}
+
+// pending returns whether the given status, as reported by sync, is the
+// status of a finished job whose post-processors didn't run yet.
+func (p *postProcessors) pending(job *db.Job, status *provider.JobStatus) bool {
+	return len(p.hooks) > 0 && len(job.PostProcessors) == 0 &&
+		status.Status == provider.StatusStarted && status.StatusMessage == postProcessingMessage
+}
+
+// run runs the post-processors with the given input, one at a time.
+func (p *postProcessors) run(input []byte) []db.PostProcessorResult {
+	results := make([]db.PostProcessorResult, len(p.hooks))
+	for i, h := range p.hooks {
+		output, err := h.Process(input)
+		results[i] = db.PostProcessorResult{Name: h.Name, Time: p.now().UTC()}
+		var compacted bytes.Buffer
+		if len(output) > 0 && json.Compact(&compacted, output) == nil {
+			results[i].Output = compacted.Bytes()
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+	return results
+}
+
+// RunPostProcessors periodically runs the post-processors of the jobs that
+// finished, until the given channel is closed. It returns immediately when
+// there are no post-processors or the worker is disabled in the
+// configuration.
+func (s *TranscodingService) RunPostProcessors(stop <-chan struct{}) {
+	cfg := s.config.Hooks
+	if cfg == nil || cfg.PostProcessorsInterval <= 0 || len(s.postProcessors.hooks) == 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.PostProcessorsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.postProcessJobs()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// postProcessJobs runs the post-processors of the active jobs that finished
+// in their providers, one job at a time, after releasing the jobs whose
+// claim expired. It doesn't run while the API is in maintenance mode.
+func (s *TranscodingService) postProcessJobs() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	if err := s.releasePostProcessingJobs(); err != nil {
+		s.logger.WithError(err).Error("failed to release jobs claimed for post-processing")
+	}
+	jobs, err := s.listActiveJobs()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list jobs for running their post-processors")
+		return
+	}
+	for _, job := range jobs {
+		if job.ProviderJobID == "" || len(job.PostProcessors) > 0 {
+			continue
+		}
+		if err = s.postProcess(job.ID); err != nil {
+			s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to run the post-processors of the job")
+		}
+	}
+}
+
+// postProcess runs the post-processors of the given job once it finishes,
+// and records their results along with the final status of the job. The
+// job is claimed before running them, so that they run only once even with
+// several instances of the API.
+func (s *TranscodingService) postProcess(jobID string) error {
+	job, status, _, err := s.getTranscodeJobByID(jobID)
+	if err != nil {
+		return err
+	}
+	if !s.postProcessors.pending(job, status) {
+		return nil
+	}
+	claimed := *job
+	claimed.Status = string(provider.StatusPostProcessing)
+	claimTime := s.postProcessors.now().UTC()
+	claimed.PostProcessingTime = &claimTime
+	if err = s.db.UpdateJobIfStatus(&claimed, job.Status); err != nil {
+		if err == db.ErrJobStatusChanged {
+			return nil
+		}
+		return err
+	}
+	status.Status = provider.StatusFinished
+	if len(status.Warnings) > 0 {
+		status.Status = provider.StatusFinishedWithWarnings
+	}
+	status.StatusMessage = ""
+	input, err := json.Marshal(postProcessPayload{JobID: job.ID, JobStatus: status})
+	if err != nil {
+		return err
+	}
+	claimed.PostProcessors = s.postProcessors.run(input)
+	claimed.PostProcessingTime = nil
+	status.PostProcessors = claimed.PostProcessors
+	status.Links = jobLinks(&claimed, status)
+	_, err = s.recordStatus(&claimed, status)
+	return err
+}
+
+// releasePostProcessingJobs releases the jobs claimed for running their
+// post-processors longer than the lease ago, so that they're claimed again.
+func (s *TranscodingService) releasePostProcessingJobs() error {
+	jobs, err := s.db.ListJobs(db.JobFilter{Status: string(provider.StatusPostProcessing)})
+	if err != nil {
+		return err
+	}
+	now := s.postProcessors.now()
+	for _, job := range jobs {
+		if job.PostProcessingTime != nil && now.Sub(*job.PostProcessingTime) < s.postProcessors.lease {
+			continue
+		}
+		released := job
+		released.Status = string(provider.StatusStarted)
+		released.PostProcessingTime = nil
+		err = s.db.UpdateJobIfStatus(&released, job.Status)
+		if err != nil && err != db.ErrJobStatusChanged {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/hook"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestPostProcessors(t *testing.T) {
+	now := time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC)
+	var inputs []postProcessPayload
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	service.postProcessors = &postProcessors{
+		hooks: []hook.Hook{
+			{Name: "catalog", Processor: hook.ProcessorFunc(func(input []byte) ([]byte, error) {
+				var payload postProcessPayload
+				if err := json.Unmarshal(input, &payload); err != nil {
+					return nil, err
+				}
+				inputs = append(inputs, payload)
+				return []byte(`{"id": "asset-1"}`), nil
+			})},
+			{Name: "notifier", Processor: hook.ProcessorFunc(func([]byte) ([]byte, error) {
+				return []byte("<html>bad gateway</html>"), errors.New("webhook returned 502 Bad Gateway")
+			})},
+		},
+		now:   func() time.Time { return now },
+		lease: time.Minute,
+	}
+	job := db.Job{ID: "job-123"}
+	status := provider.JobStatus{Status: provider.StatusQueued}
+	service.postProcessors.sync(&job, &status)
+	if status.Status != provider.StatusQueued {
+		t.Errorf("unfinished job reported as %q", status.Status)
+	}
+	status = provider.JobStatus{Status: provider.StatusFinished}
+	service.postProcessors.sync(&job, &status)
+	if status.Status != provider.StatusStarted || status.StatusMessage != "running post-processors" {
+		t.Errorf("wrong status of job waiting for its post-processors: %#v", status)
+	}
+	if len(inputs) != 0 {
+		t.Errorf("post-processors ran when reading the status of the job: %#v", inputs)
+	}
+
+	service.db.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123", Status: "started"})
+	service.postProcessJobs()
+	stored, err := service.db.GetJob("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []db.PostProcessorResult{
+		{Name: "catalog", Output: json.RawMessage(`{"id":"asset-1"}`), Time: now},
+		{Name: "notifier", Error: "webhook returned 502 Bad Gateway", Time: now},
+	}
+	if !reflect.DeepEqual(stored.PostProcessors, want) {
+		t.Errorf("wrong results recorded in the job.\nWant %#v\nGot  %#v", want, stored.PostProcessors)
+	}
+	if stored.Status != string(provider.StatusFinished) || stored.PostProcessingTime != nil {
+		t.Errorf("wrong status recorded once the post-processors ran: %#v", stored)
+	}
+	if len(inputs) != 1 || inputs[0].JobID != "job-123" || inputs[0].Status != provider.StatusFinished {
+		t.Errorf("wrong inputs of the post-processor: %#v", inputs)
+	}
+	_, reported, _, err := service.getTranscodeJobByID("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reported.Status != provider.StatusFinished || !reflect.DeepEqual(reported.PostProcessors, want) {
+		t.Errorf("wrong status reported once the post-processors ran: %#v", reported)
+	}
+	service.postProcessJobs()
+	if len(inputs) != 1 {
+		t.Errorf("post-processors ran again for job: %#v", inputs)
+	}
+}
+
+func TestPostProcessorsClaimedJobs(t *testing.T) {
+	now := time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC)
+	var runs int
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	service.postProcessors = &postProcessors{
+		hooks: []hook.Hook{
+			{Name: "catalog", Processor: hook.ProcessorFunc(func([]byte) ([]byte, error) {
+				runs++
+				return nil, nil
+			})},
+		},
+		now:   func() time.Time { return now },
+		lease: time.Minute,
+	}
+	claimTime := now.Add(-30 * time.Second)
+	service.db.CreateJob(&db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123", Status: "post-processing", PostProcessingTime: &claimTime})
+	_, status, _, err := service.getTranscodeJobByID("job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != provider.StatusStarted {
+		t.Errorf("wrong status of claimed job: %#v", status)
+	}
+	service.postProcessJobs()
+	stored, _ := service.db.GetJob("job-123")
+	if runs != 0 || stored.Status != "post-processing" {
+		t.Errorf("post-processors ran for job claimed by another instance (%d runs): %#v", runs, stored)
+	}
+
+	now = now.Add(time.Minute)
+	service.postProcessJobs()
+	stored, _ = service.db.GetJob("job-123")
+	if runs != 1 || stored.Status != "finished" {
+		t.Errorf("post-processors didn't run for job with an expired claim (%d runs): %#v", runs, stored)
+	}
+}
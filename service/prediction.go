@@ -0,0 +1,190 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	// defaultPredictionHistorySize is the number of finished jobs kept
+	// per provider when the configuration doesn't define it.
+	defaultPredictionHistorySize = 100
+
+	// predictionLowPercentile and predictionHighPercentile delimit the
+	// range of the predictions, discarding outliers in the history.
+	predictionLowPercentile  = 0.1
+	predictionHighPercentile = 0.9
+)
+
+// finishedJob is a finished job in the history of a provider.
+type finishedJob struct {
+	id             string
+	elapsed        time.Duration
+	sourceDuration time.Duration
+}
+
+// jobPredictor predicts the completion time and the cost of jobs using the
+// jobs that finished in the same provider. The cost is based on the price
+// per minute of output in the configuration.
+type jobPredictor struct {
+	mtx     sync.Mutex
+	size    int
+	prices  map[string]float64
+	history map[string][]finishedJob
+	now     func() time.Time
+}
+
+func newJobPredictor(cfg *config.Prediction) *jobPredictor {
+	p := jobPredictor{
+		size:    defaultPredictionHistorySize,
+		prices:  make(map[string]float64),
+		history: make(map[string][]finishedJob),
+		now:     time.Now,
+	}
+	if cfg == nil {
+		return &p
+	}
+	if cfg.HistorySize > 0 {
+		p.size = cfg.HistorySize
+	}
+	for _, item := range splitList(cfg.CostPerMinute) {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || price < 0 {
+			continue
+		}
+		p.prices[strings.TrimSpace(parts[0])] = price
+	}
+	return &p
+}
+
+// update feeds the predictor with the given status. Finished jobs are added
+// to the history of the provider, while queued jobs get a prediction. Sandbox
+// jobs are ignored, so they never affect the costs reported by the API.
+func (p *jobPredictor) update(job *db.Job, status *provider.JobStatus) {
+	if job.Environment == db.EnvironmentSandbox {
+		return
+	}
+	switch status.Status {
+	case provider.StatusFinished:
+		p.record(job, status.SourceInfo.Duration)
+	case provider.StatusQueued:
+		status.Prediction = p.predict(job)
+	}
+}
+
+func (p *jobPredictor) record(job *db.Job, sourceDuration time.Duration) {
+	if job.CreationTime.IsZero() {
+		return
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	history := p.history[job.ProviderName]
+	for _, finished := range history {
+		if finished.id == job.ID {
+			return
+		}
+	}
+	history = append(history, finishedJob{
+		id:             job.ID,
+		elapsed:        p.now().Sub(job.CreationTime),
+		sourceDuration: sourceDuration,
+	})
+	if len(history) > p.size {
+		history = history[len(history)-p.size:]
+	}
+	p.history[job.ProviderName] = history
+}
+
+// predict returns the prediction for the given job, or nil when there are no
+// finished jobs in the history of its provider (or for sandbox jobs).
+func (p *jobPredictor) predict(job *db.Job) *provider.JobPrediction {
+	if job.Environment == db.EnvironmentSandbox {
+		return nil
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	history := p.history[job.ProviderName]
+	if len(history) == 0 {
+		return nil
+	}
+	elapsed := make([]float64, len(history))
+	minutes := make([]float64, 0, len(history))
+	for i, finished := range history {
+		elapsed[i] = finished.elapsed.Seconds()
+		if finished.sourceDuration > 0 {
+			minutes = append(minutes, finished.sourceDuration.Minutes())
+		}
+	}
+	creation := job.CreationTime
+	if creation.IsZero() {
+		creation = p.now()
+	}
+	prediction := provider.JobPrediction{
+		EarliestCompletion: creation.Add(seconds(percentile(elapsed, predictionLowPercentile))),
+		LatestCompletion:   creation.Add(seconds(percentile(elapsed, predictionHighPercentile))),
+		Samples:            len(history),
+	}
+	if price, ok := p.prices[job.ProviderName]; ok && len(minutes) > 0 {
+		outputs := float64(len(job.Outputs))
+		prediction.MinCost = percentile(minutes, predictionLowPercentile) * outputs * price
+		prediction.MaxCost = percentile(minutes, predictionHighPercentile) * outputs * price
+	}
+	return &prediction
+}
+
+// estimate returns the time that jobs take to finish in the given provider,
+// as the high percentile of the jobs in its history, and whether there are
+// jobs in the history.
+func (p *jobPredictor) estimate(providerName string) (time.Duration, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	history := p.history[providerName]
+	if len(history) == 0 {
+		return 0, false
+	}
+	elapsed := make([]float64, len(history))
+	for i, finished := range history {
+		elapsed[i] = finished.elapsed.Seconds()
+	}
+	return seconds(percentile(elapsed, predictionHighPercentile)), true
+}
+
+// cost returns the cost of encoding the given number of outputs of a source
+// with the given duration in the provider, and whether the price of the
+// provider is known.
+func (p *jobPredictor) cost(providerName string, sourceDuration time.Duration, outputs int) (float64, bool) {
+	price, ok := p.prices[providerName]
+	if !ok {
+		return 0, false
+	}
+	return sourceDuration.Minutes() * float64(outputs) * price, true
+}
+
+// percentile returns the given percentile of the values, using the
+// nearest-rank method. It sorts values in place.
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	rank := int(p*float64(len(values)) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(values) {
+		rank = len(values)
+	}
+	return values[rank-1]
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
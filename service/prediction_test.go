@@ -0,0 +1,96 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func TestJobPredictor(t *testing.T) {
+	now := time.Date(2016, 11, 5, 10, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		testCase       string
+		givenConfig    *config.Prediction
+		givenHistory   []time.Duration
+		givenProvider  string
+		givenOutputs   int
+		wantPrediction *provider.JobPrediction
+	}{
+		{
+			"provider without history",
+			&config.Prediction{CostPerMinute: "fake:0.05"},
+			nil,
+			"fake",
+			2,
+			nil,
+		},
+		{
+			"provider with history and price",
+			&config.Prediction{CostPerMinute: "fake:0.05,other:0.1"},
+			[]time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 10 * time.Minute},
+			"fake",
+			2,
+			&provider.JobPrediction{
+				EarliestCompletion: now.Add(time.Minute),
+				LatestCompletion:   now.Add(10 * time.Minute),
+				MinCost:            0.1,
+				MaxCost:            1,
+				Samples:            5,
+			},
+		},
+		{
+			"provider without price",
+			&config.Prediction{CostPerMinute: "other:0.1,invalid"},
+			[]time.Duration{time.Minute, 2 * time.Minute},
+			"fake",
+			2,
+			&provider.JobPrediction{
+				EarliestCompletion: now.Add(time.Minute),
+				LatestCompletion:   now.Add(2 * time.Minute),
+				Samples:            2,
+			},
+		},
+		{
+			"history limited by the configuration",
+			&config.Prediction{HistorySize: 2},
+			[]time.Duration{time.Hour, 2 * time.Minute, 3 * time.Minute},
+			"fake",
+			1,
+			&provider.JobPrediction{
+				EarliestCompletion: now.Add(2 * time.Minute),
+				LatestCompletion:   now.Add(3 * time.Minute),
+				Samples:            2,
+			},
+		},
+	}
+	for _, test := range tests {
+		predictor := newJobPredictor(test.givenConfig)
+		predictor.now = func() time.Time { return now }
+		for i, elapsed := range test.givenHistory {
+			job := db.Job{ID: string(rune('a' + i)), ProviderName: "fake", CreationTime: now.Add(-elapsed)}
+			// source media is as long as the time taken to encode it
+			status := provider.JobStatus{Status: provider.StatusFinished, SourceInfo: provider.SourceInfo{Duration: elapsed}}
+			predictor.update(&job, &status)
+			predictor.update(&job, &status)
+		}
+		job := db.Job{ProviderName: test.givenProvider, CreationTime: now, Outputs: make([]db.TranscodeOutput, test.givenOutputs)}
+		status := provider.JobStatus{Status: provider.StatusQueued}
+		predictor.update(&job, &status)
+		if status.Prediction != nil && test.wantPrediction != nil {
+			if math.Abs(status.Prediction.MinCost-test.wantPrediction.MinCost) < 1e-9 {
+				status.Prediction.MinCost = test.wantPrediction.MinCost
+			}
+			if math.Abs(status.Prediction.MaxCost-test.wantPrediction.MaxCost) < 1e-9 {
+				status.Prediction.MaxCost = test.wantPrediction.MaxCost
+			}
+		}
+		if !reflect.DeepEqual(status.Prediction, test.wantPrediction) {
+			t.Errorf("%s: wrong prediction.\nWant %#v\nGot  %#v", test.testCase, test.wantPrediction, status.Prediction)
+		}
+	}
+}
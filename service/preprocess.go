@@ -0,0 +1,65 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// preProcessPayload is the input of the pre-processors.
+type preProcessPayload struct {
+	Request NewTranscodeJobInputPayload `json:"request"`
+}
+
+// preProcessOutput is the output of the pre-processors, which may replace
+// the request of the job or reject it. Empty outputs accept the request as
+// is.
+type preProcessOutput struct {
+	Request  *NewTranscodeJobInputPayload `json:"request,omitempty"`
+	Rejected bool                         `json:"rejected,omitempty"`
+	Reason   string                       `json:"reason,omitempty"`
+}
+
+// jobRejectedError is the error returned when a pre-processor rejects the
+// request of a job.
+type jobRejectedError struct {
+	preProcessor string
+	reason       string
+}
+
+func (e jobRejectedError) Error() string {
+	if e.reason == "" {
+		return fmt.Sprintf("job rejected by %s", e.preProcessor)
+	}
+	return fmt.Sprintf("job rejected by %s: %s", e.preProcessor, e.reason)
+}
+
+// preProcess runs the pre-processors with the request of a new job, in
+// order, replacing the request with the ones returned by them. It returns
+// a jobRejectedError when a pre-processor rejects the request.
+func (s *TranscodingService) preProcess(payload *NewTranscodeJobInputPayload) error {
+	for _, h := range s.preProcessors {
+		input, err := json.Marshal(preProcessPayload{Request: *payload})
+		if err != nil {
+			return err
+		}
+		output, err := h.Process(input)
+		if err != nil {
+			return fmt.Errorf("error running pre-processor %q: %s", h.Name, err)
+		}
+		if len(bytes.TrimSpace(output)) == 0 {
+			continue
+		}
+		var result preProcessOutput
+		if err = json.Unmarshal(output, &result); err != nil {
+			return fmt.Errorf("invalid output of pre-processor %q: %s", h.Name, err)
+		}
+		if result.Rejected {
+			return jobRejectedError{preProcessor: h.Name, reason: result.Reason}
+		}
+		if result.Request != nil {
+			*payload = *result.Request
+		}
+	}
+	return nil
+}
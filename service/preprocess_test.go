@@ -0,0 +1,124 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/hook"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestPreProcessors(t *testing.T) {
+	labeler := hook.ProcessorFunc(func(input []byte) ([]byte, error) {
+		var payload preProcessPayload
+		if err := json.Unmarshal(input, &payload); err != nil {
+			return nil, err
+		}
+		payload.Request.Labels = append(payload.Request.Labels, "checked")
+		return json.Marshal(preProcessOutput{Request: &payload.Request})
+	})
+	rights := hook.ProcessorFunc(func(input []byte) ([]byte, error) {
+		var payload preProcessPayload
+		if err := json.Unmarshal(input, &payload); err != nil {
+			return nil, err
+		}
+		if strings.Contains(payload.Request.Source, "unlicensed") {
+			return []byte(`{"rejected": true, "reason": "no distribution rights"}`), nil
+		}
+		return nil, nil
+	})
+	unavailable := hook.ProcessorFunc(func([]byte) ([]byte, error) {
+		return nil, errors.New("webhook returned 503 Service Unavailable")
+	})
+	var tests = []struct {
+		givenTestCase string
+		givenHooks    []hook.Hook
+		givenSource   string
+
+		wantCode   int
+		wantError  string
+		wantLabels []string
+	}{
+		{
+			"payload mutation",
+			[]hook.Hook{{Name: "labeler", Processor: labeler}, {Name: "rights", Processor: rights}},
+			"http://another.non.existent/video.mp4",
+			http.StatusOK,
+			"",
+			[]string{"news", "checked"},
+		},
+		{
+			"rejection",
+			[]hook.Hook{{Name: "labeler", Processor: labeler}, {Name: "rights", Processor: rights}},
+			"http://another.non.existent/unlicensed.mp4",
+			http.StatusForbidden,
+			"job rejected by rights: no distribution rights",
+			nil,
+		},
+		{
+			"pre-processor failure",
+			[]hook.Hook{{Name: "rights", Processor: unavailable}},
+			"http://another.non.existent/video.mp4",
+			http.StatusInternalServerError,
+			`error running pre-processor "rights": webhook returned 503 Service Unavailable`,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		service.preProcessors = test.givenHooks
+		srvr.Register(service)
+		body := `{
+			"source": "` + test.givenSource + `",
+			"destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+			"outputs": [{"preset": "mp4_1080p"}],
+			"labels": ["news"],
+			"provider": "fake"
+		}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if test.wantError != "" {
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		jobID, _ := got["jobId"].(string)
+		job, err := fakeDB.GetJob(jobID)
+		if err != nil {
+			t.Errorf("%s: job not created: %s", test.givenTestCase, err)
+			continue
+		}
+		if !reflect.DeepEqual(job.Labels, test.wantLabels) {
+			t.Errorf("%s: wrong labels. Want %#v. Got %#v", test.givenTestCase, test.wantLabels, job.Labels)
+		}
+	}
+}
@@ -2,9 +2,14 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/NYTimes/gizmo/web"
 	"github.com/NYTimes/video-transcoding-api/db"
@@ -74,8 +79,6 @@ func (s *TranscodingService) deletePreset(r *http.Request) swagger.GizmoJSONResp
 func (s *TranscodingService) newPreset(r *http.Request) swagger.GizmoJSONResponse {
 	defer r.Body.Close()
 	var input newPresetInput
-	var output newPresetOutputs
-	var presetMap db.PresetMap
 
 	respData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -86,52 +89,302 @@ func (s *TranscodingService) newPreset(r *http.Request) swagger.GizmoJSONRespons
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
-	presetMap.OutputOpts = input.OutputOptions
+	output, err := s.createPreset(input.Preset, input.Providers, input.OutputOptions, input.Tenant)
+	if err != nil {
+		return newInvalidPresetResponse(err)
+	}
+	status := http.StatusInternalServerError
+	for _, result := range output.Results {
+		if result.PresetID != "" {
+			status = http.StatusOK
+			break
+		}
+	}
+	return &newPresetResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  status,
+		},
+	}
+}
 
-	presetMap.ProviderMapping = make(map[string]string)
-	output.Results = make(map[string]newPresetOutput)
-	for _, p := range input.Providers {
-		providerFactory, ierr := provider.GetProviderFactory(p)
-		if ierr != nil {
-			output.Results[p] = newPresetOutput{PresetID: "", Error: "getting factory: " + ierr.Error()}
+// createPreset creates the given preset in the providers, along with the
+// presetmap referencing the presets created, owned by the given tenant.
+func (s *TranscodingService) createPreset(preset db.Preset, providers []string, outputOpts db.OutputOptions, tenant string) (newPresetOutputs, error) {
+	var output newPresetOutputs
+	if err := validatePreset(preset); err != nil {
+		return output, err
+	}
+	var mapping map[string]string
+	mapping, output.Results = s.createProviderPresets(preset, providers)
+	var err error
+	output.PresetMap, err = s.createPresetMap(preset, mapping, outputOpts, tenant)
+	return output, err
+}
+
+// createPresetMap creates the presetmap referencing the presets created in
+// the providers, returning its name. No presetmap is created when the
+// preset wasn't created in any provider.
+func (s *TranscodingService) createPresetMap(preset db.Preset, mapping map[string]string, outputOpts db.OutputOptions, tenant string) (string, error) {
+	if len(mapping) == 0 {
+		return "", nil
+	}
+	presetMap := db.PresetMap{Name: preset.Name, ProviderMapping: mapping, OutputOpts: outputOpts, Tenant: tenant}
+	presetMap.OutputOpts.Extension = preset.Container
+	presetMap.OutputOpts.VideoCodec = preset.Video.Codec
+	presetMap.OutputOpts.AudioCodec = preset.Audio.Codec
+	if preset.Watermark != nil {
+		presetMap.OutputOpts.Watermark = preset.Watermark.URL
+	}
+
+	if err := presetMap.OutputOpts.Validate(); err != nil {
+		return "", fmt.Errorf("invalid outputOptions: %s", err)
+	}
+
+	if err := s.db.CreatePresetMap(&presetMap); err != nil {
+		return "", nil
+	}
+	return presetMap.Name, nil
+}
+
+// createProviderPresets creates the given preset in the providers,
+// returning the ids of the presets created, by provider, and the result of
+// each provider.
+func (s *TranscodingService) createProviderPresets(preset db.Preset, providers []string) (map[string]string, map[string]newPresetOutput) {
+	mapping := make(map[string]string)
+	results := make(map[string]newPresetOutput)
+	for _, p := range providers {
+		providerObj, err := s.presetProvider(p)
+		if err != nil {
+			results[p] = newPresetOutput{PresetID: "", Error: err.Error()}
 			continue
 		}
-		providerObj, ierr := providerFactory(s.config)
-		if ierr != nil {
-			output.Results[p] = newPresetOutput{PresetID: "", Error: "initializing provider: " + ierr.Error()}
-			continue
+		results[p] = createProviderPreset(providerObj, p, preset)
+		if results[p].PresetID != "" {
+			mapping[p] = results[p].PresetID
 		}
-		presetID, ierr := providerObj.CreatePreset(input.Preset)
-		if ierr != nil {
-			output.Results[p] = newPresetOutput{PresetID: "", Error: "creating preset: " + ierr.Error()}
-			continue
+	}
+	return mapping, results
+}
+
+// presetProvider initializes the given provider for creating presets.
+func (s *TranscodingService) presetProvider(name string) (provider.TranscodingProvider, error) {
+	providerFactory, err := provider.GetProviderFactory(name)
+	if err != nil {
+		return nil, errors.New("getting factory: " + err.Error())
+	}
+	providerObj, err := providerFactory(s.config)
+	if err != nil {
+		return nil, errors.New("initializing provider: " + err.Error())
+	}
+	return providerObj, nil
+}
+
+// createProviderPreset creates the given preset in the provider, when the
+// provider supports it.
+func createProviderPreset(providerObj provider.TranscodingProvider, name string, preset db.Preset) newPresetOutput {
+	err := providerObj.Capabilities().Check(name, presetRequirements(preset))
+	if err != nil {
+		return newPresetOutput{PresetID: "", Error: "unsupported preset: " + err.Error()}
+	}
+	presetID, err := providerObj.CreatePreset(preset)
+	if err != nil {
+		return newPresetOutput{PresetID: "", Error: "creating preset: " + err.Error()}
+	}
+	return newPresetOutput{PresetID: presetID, Error: ""}
+}
+
+// presetRequirements returns the set of features that a provider must
+// support in order to create the given preset.
+func presetRequirements(preset db.Preset) provider.Requirements {
+	var requirements provider.Requirements
+	if preset.Container != "" {
+		requirements.OutputFormats = []string{provider.FormatName(preset.Container)}
+	}
+	if preset.Video.Codec != "" {
+		requirements.VideoCodecs = []string{preset.Video.Codec}
+	}
+	if preset.Audio.Codec != "" {
+		requirements.AudioCodecs = []string{preset.Audio.Codec}
+	}
+	requirements.AudioOnly = preset.AudioOnly
+	requirements.Watermark = preset.Watermark != nil
+	requirements.HDR = preset.Video.HDR()
+	requirements.ToneMapping = preset.Video.ToneMap
+	requirements.Loudness = preset.Audio.Loudness != ""
+	if preset.Audio.ChannelLayout != "" {
+		requirements.AudioChannels = preset.Audio.Channels()
+	}
+	return requirements
+}
+
+// audioContainers are the containers that can only be used in audio-only
+// presets.
+var audioContainers = map[string]bool{"mp3": true, "m4a": true, "ogg": true}
+
+// watermarkPositions are the corners of the frame where watermarks can be
+// placed.
+var watermarkPositions = map[string]bool{"top-left": true, "top-right": true, "bottom-left": true, "bottom-right": true}
+
+// watermarkSizeRegexp matches the offsets and the sizes of watermarks, in
+// pixels or relative to the frame.
+var watermarkSizeRegexp = regexp.MustCompile(`^\d+%?$`)
+
+// videoCodecs are the video codecs accepted in presets. Providers advertise
+// the ones they support in their capabilities.
+var videoCodecs = map[string]bool{"h264": true, "hevc": true, "vp8": true, "vp9": true, "av1": true, "mpeg2": true}
+
+// validatePreset checks the name, the video codec, the number of passes and
+// the color settings of presets, the settings of audio-only presets, which
+// must have no video settings and an audio codec, and the watermark and the
+// video filters of presets.
+func validatePreset(preset db.Preset) error {
+	if err := validatePresetName(preset.Name); err != nil {
+		return err
+	}
+	if err := validateLoudness(preset.Audio); err != nil {
+		return err
+	}
+	if err := validateChannelLayout(preset.Audio); err != nil {
+		return err
+	}
+	if !preset.AudioOnly {
+		if audioContainers[preset.Container] {
+			return fmt.Errorf("the container %q is only available in audio-only presets", preset.Container)
+		}
+		if codec := preset.Video.Codec; codec != "" && !videoCodecs[strings.ToLower(codec)] {
+			return fmt.Errorf("invalid video codec %q", codec)
 		}
-		presetMap.ProviderMapping[p] = presetID
-		output.Results[p] = newPresetOutput{PresetID: presetID, Error: ""}
+		if passes := preset.Video.Passes; passes != "" && passes != "1" && passes != "2" {
+			return fmt.Errorf("invalid number of passes %q, it must be 1 or 2", passes)
+		}
+		if err := validateColor(preset.Video); err != nil {
+			return err
+		}
+		if preset.Watermark != nil {
+			if err := validateWatermark(*preset.Watermark); err != nil {
+				return err
+			}
+		}
+		return validateFilters(preset.Filters)
+	}
+	if preset.Watermark != nil {
+		return errors.New("audio-only presets can't have watermarks")
+	}
+	if preset.Filters != nil {
+		return errors.New("audio-only presets can't have video filters")
 	}
+	if preset.Video != (db.VideoPreset{}) {
+		return errors.New("audio-only presets can't have video settings")
+	}
+	if preset.Audio.Codec == "" {
+		return errors.New("audio-only presets require an audio codec")
+	}
+	return nil
+}
 
-	status := http.StatusOK
-	output.PresetMap = ""
-	if len(presetMap.ProviderMapping) > 0 {
-		presetMap.Name = input.Preset.Name
-		presetMap.OutputOpts.Extension = input.Preset.Container
+// hdrVideoCodecs are the video codecs that support HDR outputs.
+var hdrVideoCodecs = map[string]bool{"hevc": true, "vp9": true, "av1": true}
 
-		if err = presetMap.OutputOpts.Validate(); err != nil {
-			return newInvalidPresetResponse(fmt.Errorf("invalid outputOptions: %s", err))
+// validateColor checks the color settings of video presets. HDR outputs
+// require a codec with support for 10 bits and the bt2020 color space, and
+// only HDR10 outputs carry mastering display metadata.
+func validateColor(video db.VideoPreset) error {
+	if video.ColorSpace != "" && video.ColorSpace != "bt709" && video.ColorSpace != "bt2020" {
+		return fmt.Errorf("invalid color space %q, it must be bt709 or bt2020", video.ColorSpace)
+	}
+	switch video.TransferFunction {
+	case "", "bt709", "pq", "hlg":
+	default:
+		return fmt.Errorf("invalid transfer function %q, it must be bt709, pq or hlg", video.TransferFunction)
+	}
+	if video.HDR() {
+		if !hdrVideoCodecs[strings.ToLower(video.Codec)] {
+			return fmt.Errorf("the video codec %q doesn't support HDR outputs", video.Codec)
+		}
+		if video.ColorSpace == "bt709" {
+			return errors.New("HDR outputs require the bt2020 color space")
 		}
+		if video.ToneMap {
+			return errors.New("tone mapping generates SDR outputs, it can't be used with HDR transfer functions")
+		}
+	}
+	if video.MasteringDisplay != "" || video.ContentLightLevel != "" {
+		if video.TransferFunction != "pq" {
+			return errors.New("mastering display metadata requires the pq transfer function")
+		}
+	}
+	if video.MasteringDisplay != "" {
+		if _, err := db.ParseMasteringDisplay(video.MasteringDisplay); err != nil {
+			return err
+		}
+	}
+	if video.ContentLightLevel != "" {
+		if _, _, err := db.ParseContentLightLevel(video.ContentLightLevel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		err = s.db.CreatePresetMap(&presetMap)
-		if err == nil {
-			output.PresetMap = presetMap.Name
+// validateLoudness checks the loudness normalization settings of audio
+// presets, keeping them in the ranges that providers accept.
+func validateLoudness(audio db.AudioPreset) error {
+	loudness, truePeak, err := audio.Normalization()
+	if err != nil {
+		return err
+	}
+	if audio.Loudness != "" && (loudness < -59 || loudness > -6) {
+		return fmt.Errorf("invalid loudness %q, it must be between -59 and -6 LUFS", audio.Loudness)
+	}
+	if audio.TruePeak != "" {
+		if audio.Loudness == "" {
+			return errors.New("the true peak requires a target loudness")
+		}
+		if truePeak < -8 || truePeak > 0 {
+			return fmt.Errorf("invalid true peak %q, it must be between -8 and 0 dBTP", audio.TruePeak)
 		}
-	} else {
-		status = http.StatusInternalServerError
 	}
+	return nil
+}
 
-	return &newPresetResponse{
-		baseResponse: baseResponse{
-			payload: output,
-			status:  status,
-		},
+// surroundAudioCodecs are the audio codecs that support 5.1 surround
+// outputs.
+var surroundAudioCodecs = map[string]bool{"aac": true, "ac3": true, "eac3": true, "opus": true, "vorbis": true}
+
+// validateChannelLayout checks the channel layout of audio presets.
+func validateChannelLayout(audio db.AudioPreset) error {
+	switch audio.ChannelLayout {
+	case "", db.AudioLayoutStereo:
+		return nil
+	case db.AudioLayout51:
+		codec := strings.TrimPrefix(strings.ToLower(audio.Codec), "lib")
+		if !surroundAudioCodecs[codec] {
+			return fmt.Errorf("the audio codec %q doesn't support 5.1 outputs", audio.Codec)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid channel layout %q, it must be stereo or 5.1", audio.ChannelLayout)
+}
+
+func validateWatermark(watermark db.Watermark) error {
+	imageURL, err := url.Parse(watermark.URL)
+	if err != nil || imageURL.Host == "" || (imageURL.Scheme != "http" && imageURL.Scheme != "https" && imageURL.Scheme != "s3") {
+		return fmt.Errorf("invalid watermark url %q", watermark.URL)
+	}
+	if watermark.Position != "" && !watermarkPositions[watermark.Position] {
+		return fmt.Errorf("invalid watermark position %q", watermark.Position)
+	}
+	for _, size := range []string{watermark.Offset, watermark.Width, watermark.Height} {
+		if size != "" && !watermarkSizeRegexp.MatchString(size) {
+			return fmt.Errorf("invalid watermark size %q, it must be in pixels or a percentage of the frame", size)
+		}
+	}
+	if watermark.Opacity != "" {
+		opacity, err := strconv.Atoi(watermark.Opacity)
+		if err != nil || opacity < 0 || opacity > 100 {
+			return fmt.Errorf("invalid watermark opacity %q, it must be between 0 and 100", watermark.Opacity)
+		}
 	}
+	return nil
 }
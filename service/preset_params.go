@@ -8,6 +8,7 @@ type newPresetInput struct {
 	Providers     []string         `json:"providers"`
 	Preset        db.Preset        `json:"preset"`
 	OutputOptions db.OutputOptions `json:"outputOptions"`
+	Tenant        string           `json:"tenant"`
 }
 
 // list of the results of the attempt to create a preset
@@ -52,7 +52,9 @@ func TestNewPreset(t *testing.T) {
 				},
 			},
 			db.OutputOptions{
-				Extension: "mp4",
+				Extension:  "mp4",
+				VideoCodec: "h264",
+				AudioCodec: "aac",
 			},
 			map[string]interface{}{
 				"Results": map[string]interface{}{
@@ -113,6 +115,344 @@ func TestNewPreset(t *testing.T) {
 			},
 			http.StatusInternalServerError,
 		},
+		{
+			"Audio-only preset in provider without audio-only outputs",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "podcast_aac",
+					"container": "mp4",
+					"audioOnly": true,
+					"audio": map[string]string{
+						"codec":   "aac",
+						"bitrate": "128000",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{
+				"Results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"PresetID": "",
+						"Error":    `unsupported preset: provider "fake" doesn't support audio-only outputs`,
+					},
+				},
+				"PresetMap": "",
+			},
+			http.StatusInternalServerError,
+		},
+		{
+			"Audio-only preset with video settings",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "podcast_mp3",
+					"container": "mp3",
+					"audioOnly": true,
+					"video": map[string]string{
+						"codec":   "h264",
+						"bitrate": "1000",
+					},
+					"audio": map[string]string{
+						"codec":   "mp3",
+						"bitrate": "128000",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": "audio-only presets can't have video settings"},
+			http.StatusBadRequest,
+		},
+		{
+			"Unknown video codec",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "mp4_theora",
+					"container": "mp4",
+					"video":     map[string]string{"codec": "theora"},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `invalid video codec "theora"`},
+			http.StatusBadRequest,
+		},
+		{
+			"Preset named after a preset action",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "validate",
+					"container": "mp4",
+					"video":     map[string]string{"codec": "h264"},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `invalid name "validate", it's reserved for the /presets/validate endpoint`},
+			http.StatusBadRequest,
+		},
+		{
+			"Invalid number of passes",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "webm_vp9",
+					"container": "webm",
+					"video":     map[string]string{"codec": "vp9", "passes": "3"},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `invalid number of passes "3", it must be 1 or 2`},
+			http.StatusBadRequest,
+		},
+		{
+			"HDR preset with a codec without HDR support",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "mp4_hdr",
+					"container": "mp4",
+					"video":     map[string]string{"codec": "h264", "transferFunction": "pq"},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `the video codec "h264" doesn't support HDR outputs`},
+			http.StatusBadRequest,
+		},
+		{
+			"Mastering display metadata in HLG preset",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "mp4_hlg",
+					"container": "mp4",
+					"video": map[string]string{
+						"codec":            "hevc",
+						"transferFunction": "hlg",
+						"masteringDisplay": "G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50)",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": "mastering display metadata requires the pq transfer function"},
+			http.StatusBadRequest,
+		},
+		{
+			"Loudness out of range",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "podcast_mp3",
+					"container": "mp3",
+					"audioOnly": true,
+					"audio":     map[string]string{"codec": "mp3", "bitrate": "128000", "loudness": "-3"},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `invalid loudness "-3", it must be between -59 and -6 LUFS`},
+			http.StatusBadRequest,
+		},
+		{
+			"True peak without loudness",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "podcast_mp3",
+					"container": "mp3",
+					"audioOnly": true,
+					"audio":     map[string]string{"codec": "mp3", "bitrate": "128000", "truePeak": "-1"},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": "the true peak requires a target loudness"},
+			http.StatusBadRequest,
+		},
+		{
+			"Invalid channel layout",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "mp4_720p_surround",
+					"container": "mp4",
+					"video":     map[string]string{"codec": "h264", "bitrate": "1000"},
+					"audio":     map[string]string{"codec": "ac3", "bitrate": "384000", "channelLayout": "7.1"},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `invalid channel layout "7.1", it must be stereo or 5.1`},
+			http.StatusBadRequest,
+		},
+		{
+			"Surround audio with a stereo codec",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "podcast_mp3",
+					"container": "mp3",
+					"audioOnly": true,
+					"audio":     map[string]string{"codec": "mp3", "bitrate": "128000", "channelLayout": "5.1"},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `the audio codec "mp3" doesn't support 5.1 outputs`},
+			http.StatusBadRequest,
+		},
+		{
+			"Audio-only preset without audio codec",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "podcast_mp3",
+					"container": "mp3",
+					"audioOnly": true,
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": "audio-only presets require an audio codec"},
+			http.StatusBadRequest,
+		},
+		{
+			"Audio container in preset with video",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "podcast_mp3",
+					"container": "mp3",
+					"video": map[string]string{
+						"codec":   "h264",
+						"bitrate": "1000",
+					},
+					"audio": map[string]string{
+						"codec":   "mp3",
+						"bitrate": "128000",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `the container "mp3" is only available in audio-only presets`},
+			http.StatusBadRequest,
+		},
+		{
+			"Preset with watermark",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "mp4_720p_branded",
+					"container": "mp4",
+					"video": map[string]string{
+						"codec":   "h264",
+						"bitrate": "1000",
+					},
+					"audio": map[string]string{
+						"codec":   "aac",
+						"bitrate": "64000",
+					},
+					"watermark": map[string]string{
+						"url":      "s3://bucket/logos/nyt.png",
+						"position": "top-right",
+						"offset":   "5%",
+						"width":    "120",
+						"opacity":  "80",
+					},
+				},
+			},
+			db.OutputOptions{
+				Extension:  "mp4",
+				Watermark:  "s3://bucket/logos/nyt.png",
+				VideoCodec: "h264",
+				AudioCodec: "aac",
+			},
+			map[string]interface{}{
+				"Results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"PresetID": "presetID_here",
+						"Error":    "",
+					},
+				},
+				"PresetMap": "mp4_720p_branded",
+			},
+			http.StatusOK,
+		},
+		{
+			"Watermark with invalid position",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "mp4_720p_branded",
+					"container": "mp4",
+					"watermark": map[string]string{
+						"url":      "s3://bucket/logos/nyt.png",
+						"position": "center",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `invalid watermark position "center"`},
+			http.StatusBadRequest,
+		},
+		{
+			"Watermark with invalid opacity",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "mp4_720p_branded",
+					"container": "mp4",
+					"watermark": map[string]string{
+						"url":     "s3://bucket/logos/nyt.png",
+						"opacity": "0.5",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `invalid watermark opacity "0.5", it must be between 0 and 100`},
+			http.StatusBadRequest,
+		},
+		{
+			"Watermark in audio-only preset",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "podcast_mp3",
+					"container": "mp3",
+					"audioOnly": true,
+					"audio": map[string]string{
+						"codec":   "mp3",
+						"bitrate": "128000",
+					},
+					"watermark": map[string]string{
+						"url": "s3://bucket/logos/nyt.png",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": "audio-only presets can't have watermarks"},
+			http.StatusBadRequest,
+		},
+		{
+			"Invalid video filters",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"preset": map[string]interface{}{
+					"name":      "film_1080p",
+					"container": "mp4",
+					"video": map[string]string{
+						"height": "1080",
+						"codec":  "h264",
+					},
+					"audio": map[string]string{
+						"codec":   "aac",
+						"bitrate": "128000",
+					},
+					"filters": map[string]string{
+						"deinterlace": "off",
+						"denoise":     "extreme",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{"error": `invalid denoise strength "extreme"`},
+			http.StatusBadRequest,
+		},
 	}
 
 	for _, test := range tests {
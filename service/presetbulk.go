@@ -0,0 +1,102 @@
+package service
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route POST /presets/bulk presets newPresets
+//
+// Creates many presets at once. Presets are created concurrently in each
+// provider, and the results include the failures of each preset in each
+// provider. Presets created in at least one provider get their presetmap.
+//
+//     Responses:
+//       200: newPresets
+//       400: invalidPreset
+//       500: newPresets
+func (s *TranscodingService) newPresets(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newPresetsInput
+	if err := input.loadParams(r.Body); err != nil {
+		return newInvalidPresetResponse(err)
+	}
+	providers := input.Payload.Providers
+	if len(providers) == 0 {
+		providers = provider.ListProviders(s.config)
+	}
+	return newNewPresetsResponse(s.createBulkPresets(input.Payload.Presets, providers))
+}
+
+// createBulkPresets creates the presets in their providers, with one
+// goroutine per provider creating its presets in order, so providers are
+// never hit by concurrent requests of the same bulk.
+func (s *TranscodingService) createBulkPresets(presets []bulkPreset, providers []string) map[string]bulkPresetResult {
+	results := make(map[string]bulkPresetResult, len(presets))
+	presetsByProvider := make(map[string][]int)
+	for i, item := range presets {
+		if err := validatePreset(item.Preset); err != nil {
+			results[item.Preset.Name] = bulkPresetResult{Error: err.Error()}
+			continue
+		}
+		itemProviders := item.Providers
+		if len(itemProviders) == 0 {
+			itemProviders = providers
+		}
+		for _, p := range itemProviders {
+			presetsByProvider[p] = append(presetsByProvider[p], i)
+		}
+	}
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	outputs := make(map[string][]newPresetOutput, len(presetsByProvider))
+	for p, indexes := range presetsByProvider {
+		wg.Add(1)
+		go func(p string, indexes []int) {
+			defer wg.Done()
+			providerOutputs := make([]newPresetOutput, len(indexes))
+			providerObj, err := s.presetProvider(p)
+			for j, i := range indexes {
+				if err != nil {
+					providerOutputs[j] = newPresetOutput{PresetID: "", Error: err.Error()}
+					continue
+				}
+				providerOutputs[j] = createProviderPreset(providerObj, p, presets[i].Preset)
+			}
+			mtx.Lock()
+			outputs[p] = providerOutputs
+			mtx.Unlock()
+		}(p, indexes)
+	}
+	wg.Wait()
+	mappings := make([]map[string]string, len(presets))
+	providerResults := make([]map[string]newPresetOutput, len(presets))
+	for p, indexes := range presetsByProvider {
+		for j, i := range indexes {
+			if providerResults[i] == nil {
+				mappings[i] = make(map[string]string)
+				providerResults[i] = make(map[string]newPresetOutput)
+			}
+			providerResults[i][p] = outputs[p][j]
+			if outputs[p][j].PresetID != "" {
+				mappings[i][p] = outputs[p][j].PresetID
+			}
+		}
+	}
+	for i, item := range presets {
+		if _, invalid := results[item.Preset.Name]; invalid {
+			continue
+		}
+		result := bulkPresetResult{newPresetOutputs: newPresetOutputs{Results: providerResults[i]}}
+		presetMap, err := s.createPresetMap(item.Preset, mappings[i], item.OutputOptions, item.Tenant)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.PresetMap = presetMap
+		results[item.Preset.Name] = result
+	}
+	return results
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// swagger:parameters newPresets
+type newPresetsInput struct {
+	// in: body
+	// required: true
+	Payload newPresetsPayload
+}
+
+type newPresetsPayload struct {
+	// providers where the presets are created, defaults to all the
+	// providers configured in the API
+	Providers []string `json:"providers,omitempty"`
+
+	// presets to create
+	//
+	// required: true
+	Presets []bulkPreset `json:"presets"`
+}
+
+// bulkPreset is a preset created in bulk, in the same format used for
+// creating a single preset.
+type bulkPreset struct {
+	Preset        db.Preset        `json:"preset"`
+	OutputOptions db.OutputOptions `json:"outputOptions"`
+
+	// providers of the preset, overriding the providers of the request
+	Providers []string `json:"providers,omitempty"`
+
+	// tenant that owns the preset
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// result of the creation of a single preset in bulk.
+type bulkPresetResult struct {
+	newPresetOutputs
+
+	Error string `json:"error,omitempty"`
+}
+
+// response for the newPresets operation, in the format
+// `presetName: presetResult`.
+//
+// swagger:response newPresets
+type newPresetsResponse struct {
+	// in: body
+	Results map[string]bulkPresetResult
+
+	baseResponse
+}
+
+func newNewPresetsResponse(results map[string]bulkPresetResult) *newPresetsResponse {
+	status := http.StatusInternalServerError
+	for _, result := range results {
+		if result.PresetMap != "" {
+			status = http.StatusOK
+			break
+		}
+	}
+	return &newPresetsResponse{
+		baseResponse: baseResponse{
+			payload: results,
+			status:  status,
+		},
+	}
+}
+
+// loadParams loads the input from the request body and validates it.
+func (p *newPresetsInput) loadParams(body io.Reader) error {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return err
+	}
+	if len(p.Payload.Presets) == 0 {
+		return errors.New("missing presets from the request")
+	}
+	names := make(map[string]bool, len(p.Payload.Presets))
+	for _, item := range p.Payload.Presets {
+		if item.Preset.Name == "" {
+			return errors.New("presets must have a name")
+		}
+		if names[item.Preset.Name] {
+			return fmt.Errorf("duplicate preset %q in the request", item.Preset.Name)
+		}
+		names[item.Preset.Name] = true
+	}
+	return nil
+}
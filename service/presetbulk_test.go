@@ -0,0 +1,125 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewPresets(t *testing.T) {
+	fprovider.presets = nil
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	body := `{
+		"providers": ["fake", "unknown"],
+		"presets": [
+			{"preset": {"name": "mp4_720p", "container": "mp4", "video": {"codec": "h264"}, "audio": {"codec": "aac"}}},
+			{"preset": {"name": "mp4_1080p", "container": "mp4", "video": {"codec": "h264"}, "audio": {"codec": "aac"}}, "providers": ["fake"], "tenant": "newsroom"},
+			{"preset": {"name": "webm_vp9", "container": "webm", "video": {"codec": "vp9"}, "audio": {"codec": "vorbis"}}, "providers": ["fake"]},
+			{"preset": {"name": "mp3", "container": "mp3", "audioOnly": true}}
+		]
+	}`
+	r, _ := http.NewRequest("POST", "/presets/bulk", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("wrong response code. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var results map[string]bulkPresetResult
+	if err = json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bulkPresetResult{
+		"mp4_720p": {newPresetOutputs: newPresetOutputs{
+			Results: map[string]newPresetOutput{
+				"fake":    {PresetID: "presetID_here"},
+				"unknown": {Error: "getting factory: provider not found"},
+			},
+			PresetMap: "mp4_720p",
+		}},
+		"mp4_1080p": {newPresetOutputs: newPresetOutputs{
+			Results:   map[string]newPresetOutput{"fake": {PresetID: "presetID_here"}},
+			PresetMap: "mp4_1080p",
+		}},
+		"mp3": {Error: "audio-only presets require an audio codec"},
+	}
+	unsupported := results["webm_vp9"]
+	delete(results, "webm_vp9")
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("wrong results.\nWant %#v\nGot  %#v", want, results)
+	}
+	if unsupported.PresetMap != "" || !strings.HasPrefix(unsupported.Results["fake"].Error, "unsupported preset: ") {
+		t.Errorf("wrong result of unsupported preset: %#v", unsupported)
+	}
+	for _, name := range []string{"mp4_720p", "mp4_1080p"} {
+		presetMap, err := fakeDB.GetPresetMap(name)
+		if err != nil {
+			t.Errorf("presetmap %q not created: %s", name, err)
+			continue
+		}
+		if want := map[string]string{"fake": "presetID_here"}; !reflect.DeepEqual(presetMap.ProviderMapping, want) {
+			t.Errorf("wrong provider mapping of %q. Want %#v. Got %#v", name, want, presetMap.ProviderMapping)
+		}
+		if want := map[string]string{"mp4_1080p": "newsroom"}[name]; presetMap.Tenant != want {
+			t.Errorf("wrong tenant of %q. Want %q. Got %q", name, want, presetMap.Tenant)
+		}
+	}
+	if len(fprovider.presets) != 2 {
+		t.Errorf("wrong number of presets created in the provider. Want 2. Got %d", len(fprovider.presets))
+	}
+}
+
+func TestNewPresetsErrors(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenBody     string
+
+		wantCode  int
+		wantError string
+	}{
+		{"no presets", `{"presets":[]}`, http.StatusBadRequest, "missing presets from the request"},
+		{"preset without name", `{"presets":[{"preset":{"container":"mp4"}}]}`, http.StatusBadRequest, "presets must have a name"},
+		{
+			"duplicate preset",
+			`{"presets":[{"preset":{"name":"mp4"}},{"preset":{"name":"mp4"}}]}`,
+			http.StatusBadRequest,
+			`duplicate preset "mp4" in the request`,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/presets/bulk", strings.NewReader(test.givenBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got["error"] != test.wantError {
+			t.Errorf("%s: wrong error. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// presetActionNames are the names of the actions on the whole set of
+// presets, which share the path of the presets addressed by name in the
+// router, so presets and presetmaps can't be named after them.
+var presetActionNames = map[string]bool{"export": true, "import": true, "bulk": true, "validate": true}
+
+func validatePresetName(name string) error {
+	if presetActionNames[name] {
+		return fmt.Errorf("invalid name %q, it's reserved for the /presets/%s endpoint", name, name)
+	}
+	return nil
+}
+
+// presetActions returns the handler of the actions on the whole set of
+// presets (like /presets/export), which share the path of the presets
+// addressed by name in the router.
+func presetActions(actions map[string]swagger.Handler) swagger.Handler {
+	return func(r *http.Request) swagger.GizmoJSONResponse {
+		var params getPresetMapInput
+		params.loadParams(web.Vars(r))
+		action, ok := actions[params.Name]
+		if !ok {
+			return newPresetMapNotFoundResponse(fmt.Errorf("unknown preset action %q", params.Name))
+		}
+		return action(r)
+	}
+}
+
+// swagger:route GET /presets/export presets exportPresets
+//
+// Exports all presets and presetmaps as a single JSON document, that can be
+// imported in another environment. Presets are exported from the first
+// provider of each presetmap that supports exporting presets.
+//
+//     Responses:
+//       200: exportPresets
+//       500: genericError
+func (s *TranscodingService) exportPresets(r *http.Request) swagger.GizmoJSONResponse {
+	presetMaps, err := s.db.ListPresetMaps(db.PresetMapFilter{})
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	sort.Sort(presetMapsByName(presetMaps))
+	document := presetsDocument{Presets: make([]exportedPreset, len(presetMaps))}
+	for i, presetMap := range presetMaps {
+		// version numbers are specific to the environment, the importing
+		// environment numbers the presetmaps itself.
+		presetMap.Version = 0
+		document.Presets[i].PresetMap = presetMap
+		preset, unsupported, err := s.exportPreset(&presetMap)
+		if err != nil {
+			continue
+		}
+		document.Presets[i].Preset = &preset
+		document.Presets[i].Unsupported = unsupported
+	}
+	return newExportPresetsResponse(&document)
+}
+
+// swagger:route POST /presets/import presets importPresets
+//
+// Imports a document generated by exportPresets. Presets with a definition
+// are created again in the providers of their presetmaps, while the other
+// presetmaps are imported as they are. Existing presetmaps are updated,
+// keeping their previous version.
+//
+//     Responses:
+//       200: importPresets
+//       400: invalidPreset
+//       500: genericError
+func (s *TranscodingService) importPresets(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input importPresetsInput
+	if err := input.loadParams(r.Body); err != nil {
+		return newInvalidPresetMapResponse(err)
+	}
+	results := make(map[string]presetImport, len(input.Payload.Presets))
+	for _, item := range input.Payload.Presets {
+		results[item.PresetMap.Name] = s.importPreset(item)
+	}
+	return newImportPresetsResponse(results)
+}
+
+func (s *TranscodingService) importPreset(item exportedPreset) presetImport {
+	var result presetImport
+	if err := validatePresetName(item.PresetMap.Name); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	presetMap := copyPresetMap(item.PresetMap)
+	if item.Preset != nil {
+		preset := *item.Preset
+		preset.Name = presetMap.Name
+		if err := validatePreset(preset); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		providers := make([]string, 0, len(presetMap.ProviderMapping))
+		for p := range presetMap.ProviderMapping {
+			providers = append(providers, p)
+		}
+		sort.Strings(providers)
+		presetMap.ProviderMapping, result.Results = s.createProviderPresets(preset, providers)
+		if len(presetMap.ProviderMapping) == 0 {
+			result.Error = "the preset couldn't be created in any provider"
+			return result
+		}
+	}
+	if err := presetMap.OutputOpts.Validate(); err != nil {
+		result.Error = fmt.Sprintf("invalid outputOptions: %s", err)
+		return result
+	}
+	err := s.db.UpdatePresetMap(&presetMap)
+	result.Status = presetImportUpdated
+	if err == db.ErrPresetMapNotFound {
+		err = s.db.CreatePresetMap(&presetMap)
+		result.Status = presetImportCreated
+	}
+	if err != nil {
+		result.Status = ""
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// presetMapsByName sorts presetmaps by name.
+type presetMapsByName []db.PresetMap
+
+func (m presetMapsByName) Len() int           { return len(m) }
+func (m presetMapsByName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m presetMapsByName) Less(i, j int) bool { return m[i].Name < m[j].Name }
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// presetsDocument is the JSON document holding all the presets of the API,
+// used for migrating presets between environments.
+type presetsDocument struct {
+	// presets of the API, sorted by name
+	//
+	// required: true
+	Presets []exportedPreset `json:"presets"`
+}
+
+// exportedPreset is a preset in the presets document.
+type exportedPreset struct {
+	// presetmap of the preset, with the ids of the preset in each provider
+	//
+	// required: true
+	PresetMap db.PresetMap `json:"presetMap"`
+
+	// definition of the preset, exported from one of its providers. When
+	// present, importing the preset creates it again in the providers of
+	// the presetmap. Otherwise the presetmap is imported as is, keeping the
+	// ids of the presets in the providers.
+	Preset *db.Preset `json:"preset,omitempty"`
+
+	// settings of the preset that couldn't be exported
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// result of the import of a single preset.
+type presetImport struct {
+	// whether the presetmap was created or updated
+	Status string `json:"status,omitempty"`
+
+	// results of the creation of the preset in each provider, for presets
+	// imported with their definition
+	Results map[string]newPresetOutput `json:"results,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Statuses of imported presets.
+const (
+	presetImportCreated = "created"
+	presetImportUpdated = "updated"
+)
+
+// swagger:parameters importPresets
+type importPresetsInput struct {
+	// in: body
+	// required: true
+	Payload presetsDocument
+}
+
+// response for the exportPresets operation.
+//
+// swagger:response exportPresets
+type exportPresetsResponse struct {
+	// in: body
+	Payload *presetsDocument
+
+	baseResponse
+}
+
+// response for the importPresets operation, in the format
+// `presetName: importResult`.
+//
+// swagger:response importPresets
+type importPresetsResponse struct {
+	// in: body
+	Results map[string]presetImport
+
+	baseResponse
+}
+
+func newExportPresetsResponse(document *presetsDocument) *exportPresetsResponse {
+	return &exportPresetsResponse{
+		baseResponse: baseResponse{
+			payload: document,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newImportPresetsResponse(results map[string]presetImport) *importPresetsResponse {
+	return &importPresetsResponse{
+		baseResponse: baseResponse{
+			payload: results,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// loadParams loads the presets document from the request body and
+// validates it.
+func (p *importPresetsInput) loadParams(body io.Reader) error {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return err
+	}
+	if len(p.Payload.Presets) == 0 {
+		return errors.New("missing presets from the request")
+	}
+	names := make(map[string]bool, len(p.Payload.Presets))
+	for _, preset := range p.Payload.Presets {
+		if preset.PresetMap.Name == "" {
+			return errors.New("presetmaps in the document must have a name")
+		}
+		if names[preset.PresetMap.Name] {
+			return fmt.Errorf("duplicate preset %q in the document", preset.PresetMap.Name)
+		}
+		names[preset.PresetMap.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestExportImportPresets(t *testing.T) {
+	fprovider.presets = nil
+	request := func(fakeDB db.Repository, method, path, body string) *httptest.ResponseRecorder {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		return w
+	}
+	staging := dbtest.NewFakeRepository(false)
+	staging.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "mp4_1080p"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	staging.CreatePresetMap(&db.PresetMap{
+		Name:            "hls_720p",
+		ProviderMapping: map[string]string{"zencoder": "hls_720p"},
+		OutputOpts:      db.OutputOptions{Extension: "ts"},
+	})
+	w := request(staging, "GET", "/presets/export", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong status code exporting presets. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var document presetsDocument
+	if err := json.NewDecoder(w.Body).Decode(&document); err != nil {
+		t.Fatal(err)
+	}
+	wantDocument := presetsDocument{Presets: []exportedPreset{
+		{
+			PresetMap: db.PresetMap{
+				Name:            "hls_720p",
+				ProviderMapping: map[string]string{"zencoder": "hls_720p"},
+				OutputOpts:      db.OutputOptions{Extension: "ts"},
+			},
+		},
+		{
+			PresetMap: db.PresetMap{
+				Name:            "mp4_1080p",
+				ProviderMapping: map[string]string{"fake": "mp4_1080p"},
+				OutputOpts:      db.OutputOptions{Extension: "mp4"},
+			},
+			Preset: &db.Preset{
+				Name:      "mp4_1080p",
+				Container: "mp4",
+				Video:     db.VideoPreset{Codec: "h264", Bitrate: "1000000"},
+				Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+			},
+			Unsupported: []string{"thumbnails"},
+		},
+	}}
+	if !reflect.DeepEqual(document, wantDocument) {
+		t.Errorf("wrong document.\nWant %#v\nGot  %#v", wantDocument, document)
+	}
+
+	production := dbtest.NewFakeRepository(false)
+	production.CreatePresetMap(&db.PresetMap{
+		Name:            "hls_720p",
+		ProviderMapping: map[string]string{"zencoder": "old_hls_720p"},
+		OutputOpts:      db.OutputOptions{Extension: "ts"},
+	})
+	data, _ := json.Marshal(document)
+	w = request(production, "POST", "/presets/import", string(data))
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong status code importing presets. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var results map[string]presetImport
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	wantResults := map[string]presetImport{
+		"hls_720p": {Status: "updated"},
+		"mp4_1080p": {
+			Status:  "created",
+			Results: map[string]newPresetOutput{"fake": {PresetID: "presetID_here"}},
+		},
+	}
+	if !reflect.DeepEqual(results, wantResults) {
+		t.Errorf("wrong results.\nWant %#v\nGot  %#v", wantResults, results)
+	}
+	presetMap, err := production.GetPresetMap("mp4_1080p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetMap.ProviderMapping["fake"] != "presetID_here" {
+		t.Errorf("wrong provider mapping of the imported preset: %#v", presetMap.ProviderMapping)
+	}
+	if len(fprovider.presets) != 1 || fprovider.presets[0].Name != "mp4_1080p" {
+		t.Errorf("wrong presets created in the provider: %#v", fprovider.presets)
+	}
+	presetMap, err = production.GetPresetMap("hls_720p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetMap.ProviderMapping["zencoder"] != "hls_720p" {
+		t.Errorf("the existing presetmap wasn't updated: %#v", presetMap.ProviderMapping)
+	}
+	versions, err := production.ListPresetMapVersions("hls_720p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].PresetMap.ProviderMapping["zencoder"] != "old_hls_720p" {
+		t.Errorf("the previous version of the presetmap wasn't kept: %#v", versions)
+	}
+}
+
+func TestImportPresetsErrors(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenMethod   string
+		givenURI      string
+		givenBody     string
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"empty document",
+			"POST",
+			"/presets/import",
+			`{"presets":[]}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "missing presets from the request"},
+		},
+		{
+			"duplicate preset",
+			"POST",
+			"/presets/import",
+			`{"presets":[{"presetMap":{"name":"mp4"}},{"presetMap":{"name":"mp4"}}]}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `duplicate preset "mp4" in the document`},
+		},
+		{
+			"invalid preset",
+			"POST",
+			"/presets/import",
+			`{"presets":[{"presetMap":{"name":"mp3","providerMapping":{"fake":"mp3"},"output":{"extension":"mp3"}},"preset":{"audioOnly":true,"container":"mp3"}}]}`,
+			http.StatusOK,
+			map[string]interface{}{"mp3": map[string]interface{}{"error": "audio-only presets require an audio codec"}},
+		},
+		{
+			"unknown action",
+			"GET",
+			"/presets/backup",
+			"",
+			http.StatusNotFound,
+			map[string]interface{}{"error": `unknown preset action "backup"`},
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		srvr.Register(service)
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, strings.NewReader(test.givenBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: wrong body. Want %#v. Got %#v", test.givenTestCase, test.wantBody, got)
+		}
+	}
+}
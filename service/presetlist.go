@@ -0,0 +1,33 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route GET /presets presets listPresets
+//
+// Lists presets by name, optionally filtered by tenant, container and codec,
+// or sorted by container. Results are paginated, and the link to the next
+// page is included while there may be more presets.
+//
+//     Responses:
+//       200: presetList
+//       400: genericError
+//       500: genericError
+func (s *TranscodingService) listPresets(r *http.Request) swagger.GizmoJSONResponse {
+	var params listPresetsInput
+	if err := params.loadParams(r.URL.Query()); err != nil {
+		return swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)
+	}
+	presetMaps, err := s.db.ListPresetMaps(params.filter())
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	list := presetList{Presets: presetMaps}
+	if uint(len(presetMaps)) == params.Limit {
+		list.Next = params.nextPage()
+	}
+	return newListPresetsResponse(&list)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+const (
+	defaultPresetListLimit = 100
+	maxPresetListLimit     = 1000
+)
+
+// swagger:parameters listPresets
+type listPresetsInput struct {
+	// include only presets owned by the given tenant
+	//
+	// in: query
+	Tenant string `json:"tenant"`
+
+	// include only presets with the given container (like mp4 or m3u8)
+	//
+	// in: query
+	Container string `json:"container"`
+
+	// include only presets with the given video or audio codec
+	//
+	// in: query
+	Codec string `json:"codec"`
+
+	// field presets are sorted by, name (the default) or container,
+	// prefixed with - for descending order
+	//
+	// in: query
+	Sort string `json:"sort"`
+
+	// number of matching presets to skip
+	//
+	// in: query
+	Offset uint `json:"offset"`
+
+	// maximum number of presets in the page (defaults to 100, up to 1000)
+	//
+	// in: query
+	Limit uint `json:"limit"`
+}
+
+func (p *listPresetsInput) loadParams(query url.Values) error {
+	p.Tenant = query.Get("tenant")
+	p.Container = query.Get("container")
+	p.Codec = query.Get("codec")
+	p.Sort = query.Get("sort")
+	switch strings.TrimPrefix(p.Sort, "-") {
+	case "", db.PresetMapSortName, db.PresetMapSortContainer:
+	default:
+		return fmt.Errorf("invalid sort %q, it must be name or container, optionally prefixed with -", p.Sort)
+	}
+	if offset := query.Get("offset"); offset != "" {
+		value, err := strconv.ParseUint(offset, 10, 32)
+		if err != nil {
+			return errors.New("invalid offset, it must be a non-negative integer")
+		}
+		p.Offset = uint(value)
+	}
+	p.Limit = defaultPresetListLimit
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil || value == 0 || value > maxPresetListLimit {
+			return fmt.Errorf("invalid limit, it must be between 1 and %d", maxPresetListLimit)
+		}
+		p.Limit = uint(value)
+	}
+	return nil
+}
+
+// filter returns the filter of the presetmaps in the page.
+func (p *listPresetsInput) filter() db.PresetMapFilter {
+	return db.PresetMapFilter{
+		Tenant:     p.Tenant,
+		Container:  p.Container,
+		Codec:      p.Codec,
+		SortBy:     strings.TrimPrefix(p.Sort, "-"),
+		Descending: strings.HasPrefix(p.Sort, "-"),
+		Offset:     p.Offset,
+		Limit:      p.Limit,
+	}
+}
+
+// nextPage returns the link to the page following the current one.
+func (p *listPresetsInput) nextPage() string {
+	query := url.Values{}
+	for name, value := range map[string]string{"tenant": p.Tenant, "container": p.Container, "codec": p.Codec, "sort": p.Sort} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	query.Set("offset", strconv.FormatUint(uint64(p.Offset+p.Limit), 10))
+	query.Set("limit", strconv.FormatUint(uint64(p.Limit), 10))
+	return "/presets?" + query.Encode()
+}
+
+// Page of presets, sorted by name unless another order was requested.
+//
+// swagger:model
+type presetList struct {
+	Presets []db.PresetMap `json:"presets"`
+
+	// link to the next page, present when the page is full
+	Next string `json:"next,omitempty"`
+}
+
+// JSON-encoded page of presets.
+//
+// swagger:response presetList
+type listPresetsResponse struct {
+	// in: body
+	Payload *presetList
+
+	baseResponse
+}
+
+func newListPresetsResponse(list *presetList) *listPresetsResponse {
+	return &listPresetsResponse{
+		baseResponse: baseResponse{
+			payload: list,
+			status:  http.StatusOK,
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestListPresets(t *testing.T) {
+	tests := []struct {
+		givenTestCase string
+		givenQuery    string
+
+		wantCode    int
+		wantError   string
+		wantPresets []string
+		wantNext    string
+	}{
+		{"all presets", "", http.StatusOK, "", []string{"hls_720p", "mp4_1080p", "mp4_720p", "webm_720p"}, ""},
+		{"filtered by tenant", "?tenant=newsroom", http.StatusOK, "", []string{"mp4_1080p"}, ""},
+		{"filtered by container", "?container=mp4", http.StatusOK, "", []string{"mp4_1080p", "mp4_720p"}, ""},
+		{"filtered by codec", "?codec=vp8", http.StatusOK, "", []string{"webm_720p"}, ""},
+		{"sorted by container", "?sort=-container", http.StatusOK, "", []string{"webm_720p", "mp4_720p", "mp4_1080p", "hls_720p"}, ""},
+		{"first page", "?limit=2&codec=h264", http.StatusOK, "", []string{"hls_720p", "mp4_1080p"}, "/presets?codec=h264&limit=2&offset=2"},
+		{"last page", "?limit=3&offset=3", http.StatusOK, "", []string{"webm_720p"}, ""},
+		{"invalid sort", "?sort=bitrate", http.StatusBadRequest, `invalid sort "bitrate", it must be name or container, optionally prefixed with -`, nil, ""},
+		{"invalid offset", "?offset=-1", http.StatusBadRequest, "invalid offset, it must be a non-negative integer", nil, ""},
+		{"invalid limit", "?limit=1001", http.StatusBadRequest, "invalid limit, it must be between 1 and 1000", nil, ""},
+	}
+	for _, test := range tests {
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeDB := dbtest.NewFakeRepository(false)
+		presetMaps := []db.PresetMap{
+			{Name: "mp4_720p", OutputOpts: db.OutputOptions{Extension: "mp4", VideoCodec: "h264", AudioCodec: "aac"}},
+			{Name: "webm_720p", OutputOpts: db.OutputOptions{Extension: "webm", VideoCodec: "vp8", AudioCodec: "vorbis"}},
+			{Name: "mp4_1080p", OutputOpts: db.OutputOptions{Extension: "mp4", VideoCodec: "h264", AudioCodec: "aac"}, Tenant: "newsroom"},
+			{Name: "hls_720p", OutputOpts: db.OutputOptions{Extension: "m3u8", VideoCodec: "h264", AudioCodec: "aac"}},
+		}
+		for i := range presetMaps {
+			fakeDB.CreatePresetMap(&presetMaps[i])
+		}
+		service.db = fakeDB
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		srvr.Register(service)
+		r, _ := http.NewRequest("GET", "/presets"+test.givenQuery, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantCode != http.StatusOK {
+			var got map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&got)
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		var got presetList
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotPresets := []string{}
+		for _, presetMap := range got.Presets {
+			gotPresets = append(gotPresets, presetMap.Name)
+		}
+		if !reflect.DeepEqual(gotPresets, test.wantPresets) {
+			t.Errorf("%s: wrong presets listed. Want %#v. Got %#v", test.givenTestCase, test.wantPresets, gotPresets)
+		}
+		if got.Next != test.wantNext {
+			t.Errorf("%s: wrong link to the next page. Want %q. Got %q", test.givenTestCase, test.wantNext, got.Next)
+		}
+	}
+}
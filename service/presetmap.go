@@ -60,7 +60,8 @@ func (s *TranscodingService) getPresetMap(r *http.Request) swagger.GizmoJSONResp
 
 // swagger:route PUT /presetmaps/{name} presets updatePreset
 //
-// Updates a presetmap using its name.
+// Updates a presetmap using its name. The version being replaced is kept,
+// and can be restored with rollbackPreset.
 //
 //     Responses:
 //       200: preset
@@ -118,7 +119,7 @@ func (s *TranscodingService) deletePresetMap(r *http.Request) swagger.GizmoJSONR
 //       200: listPresetMaps
 //       500: genericError
 func (s *TranscodingService) listPresetMaps(r *http.Request) swagger.GizmoJSONResponse {
-	presetsMap, err := s.db.ListPresetMaps()
+	presetsMap, err := s.db.ListPresetMaps(db.PresetMapFilter{})
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
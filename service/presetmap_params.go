@@ -21,7 +21,7 @@ type presetMapResponse struct {
 	baseResponse
 }
 
-// swagger:parameters getPreset deletePreset deletePresetMap
+// swagger:parameters getPreset deletePreset deletePresetMap listPresetVersions
 type getPresetMapInput struct {
 	// in: path
 	// required: true
@@ -171,6 +171,9 @@ func validatePresetMap(p *db.PresetMap) error {
 	if p.Name == "" {
 		return errors.New("missing field name from the request")
 	}
+	if err := validatePresetName(p.Name); err != nil {
+		return err
+	}
 	if len(p.ProviderMapping) == 0 {
 		return errors.New("missing field providerMapping from the request")
 	}
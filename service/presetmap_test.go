@@ -48,6 +48,7 @@ func TestNewPresetMap(t *testing.T) {
 				"output": map[string]interface{}{
 					"extension": "mp4",
 				},
+				"version": float64(1),
 			},
 		},
 		{
@@ -84,6 +85,24 @@ func TestNewPresetMap(t *testing.T) {
 				"error": "missing field name from the request",
 			},
 		},
+		{
+			"New presetmap named after a preset action",
+			map[string]interface{}{
+				"name": "export",
+				"providerMapping": map[string]string{
+					"elementalconductor": "18",
+				},
+				"output": map[string]interface{}{
+					"extension": "mp4",
+				},
+			},
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{
+				"error": `invalid name "export", it's reserved for the /presets/export endpoint`,
+			},
+		},
 		{
 			"New presetmap missing extension",
 			map[string]interface{}{
@@ -179,7 +198,7 @@ func TestGetPresetMap(t *testing.T) {
 		{
 			"Get preset",
 			"preset-1",
-			&db.PresetMap{Name: "preset-1"},
+			&db.PresetMap{Name: "preset-1", Version: 1},
 			http.StatusOK,
 		},
 		{
@@ -242,6 +261,7 @@ func TestUpdatePresetMap(t *testing.T) {
 					"elementalconductor": "abc-123",
 					"elastictranscoder":  "def-345",
 				},
+				Version: 2,
 			},
 			http.StatusOK,
 		},
@@ -370,14 +390,17 @@ func TestListPresetMaps(t *testing.T) {
 				"preset-1": {
 					Name:            "preset-1",
 					ProviderMapping: map[string]string{"elementalconductor": "abc123"},
+					Version:         1,
 				},
 				"preset-2": {
 					Name:            "preset-2",
 					ProviderMapping: map[string]string{"elementalconductor": "abc124"},
+					Version:         1,
 				},
 				"preset-3": {
 					Name:            "preset-3",
 					ProviderMapping: map[string]string{"elementalconductor": "abc125"},
+					Version:         1,
 				},
 			},
 		},
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+const presetValidationUnavailable = "the provider doesn't support validating presets, only its capabilities were checked"
+
+// swagger:route POST /presets/validate presets validatePreset
+//
+// Validates a preset without creating it. Each provider translates the
+// preset into its own format, returning the translated preset along with
+// warnings about unsupported or suspicious settings.
+//
+//     Responses:
+//       200: validatePreset
+//       400: invalidPreset
+//       500: genericError
+func (s *TranscodingService) checkPreset(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input validatePresetInput
+	if err := input.loadParams(r.Body); err != nil {
+		return newInvalidPresetResponse(err)
+	}
+	providers := input.Payload.Providers
+	if len(providers) == 0 {
+		providers = provider.ListProviders(s.config)
+	}
+	results := make(map[string]presetValidation, len(providers))
+	for _, p := range providers {
+		providerObj, err := s.presetProvider(p)
+		if err != nil {
+			results[p] = presetValidation{Error: err.Error()}
+			continue
+		}
+		results[p] = validateProviderPreset(providerObj, p, input.Payload.Preset)
+	}
+	return newValidatePresetResponse(results)
+}
+
+// validateProviderPreset checks whether the provider supports the preset,
+// and translates it when the provider supports validating presets.
+func validateProviderPreset(providerObj provider.TranscodingProvider, name string, preset db.Preset) presetValidation {
+	err := providerObj.Capabilities().Check(name, presetRequirements(preset))
+	if err != nil {
+		return presetValidation{Warnings: []string{"unsupported preset: " + err.Error()}}
+	}
+	validator, ok := providerObj.(provider.PresetValidator)
+	if !ok {
+		return presetValidation{Valid: true, Warnings: []string{presetValidationUnavailable}}
+	}
+	providerPreset, warnings, err := validator.ValidatePreset(preset)
+	if err != nil {
+		return presetValidation{Error: "translating preset: " + err.Error()}
+	}
+	return presetValidation{Valid: true, Preset: providerPreset, Warnings: warnings}
+}
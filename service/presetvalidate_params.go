@@ -0,0 +1,73 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// swagger:parameters validatePreset
+type validatePresetInput struct {
+	// in: body
+	// required: true
+	Payload validatePresetPayload
+}
+
+type validatePresetPayload struct {
+	// providers where the preset is validated, defaults to all the
+	// providers configured in the API
+	Providers []string `json:"providers,omitempty"`
+
+	// preset to validate
+	//
+	// required: true
+	Preset db.Preset `json:"preset"`
+}
+
+// result of the validation of a preset in a provider.
+type presetValidation struct {
+	// whether the preset can be created in the provider
+	Valid bool `json:"valid"`
+
+	// preset translated into the format of the provider, for providers
+	// that support validating presets
+	Preset interface{} `json:"preset,omitempty"`
+
+	// settings of the preset that are unsupported by the provider or
+	// likely wrong, like bitrates out of the range of the provider
+	Warnings []string `json:"warnings,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// response for the validatePreset operation, in the format
+// `providerName: validationResult`.
+//
+// swagger:response validatePreset
+type validatePresetResponse struct {
+	// in: body
+	Results map[string]presetValidation
+
+	baseResponse
+}
+
+func newValidatePresetResponse(results map[string]presetValidation) *validatePresetResponse {
+	return &validatePresetResponse{
+		baseResponse: baseResponse{
+			payload: results,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// loadParams loads the input from the request body and validates the
+// generic settings of the preset.
+func (p *validatePresetInput) loadParams(body io.Reader) error {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return err
+	}
+	return validatePreset(p.Payload.Preset)
+}
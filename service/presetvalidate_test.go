@@ -0,0 +1,101 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestValidatePreset(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenBody     string
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"valid preset",
+			`{"providers":["fake","unknown"],"preset":{"name":"mp4_720p","container":"mp4","video":{"codec":"h264","bitrate":"2000000"},"audio":{"codec":"aac"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"fake": map[string]interface{}{
+					"valid":  true,
+					"preset": map[string]interface{}{"codec": "h264", "bitrate": "2000000"},
+				},
+				"unknown": map[string]interface{}{"valid": false, "error": "getting factory: provider not found"},
+			},
+		},
+		{
+			"bitrate out of range",
+			`{"providers":["fake"],"preset":{"name":"mp4_4k","container":"mp4","video":{"codec":"h264","bitrate":"100000000"},"audio":{"codec":"aac"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"fake": map[string]interface{}{
+					"valid":    true,
+					"preset":   map[string]interface{}{"codec": "h264", "bitrate": "100000000"},
+					"warnings": []interface{}{"video bitrate of 100000 kbps is out of the supported range (100-50000 kbps)"},
+				},
+			},
+		},
+		{
+			"unsupported codec",
+			`{"providers":["fake"],"preset":{"name":"webm_vp9","container":"webm","video":{"codec":"vp9"},"audio":{"codec":"vorbis"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"fake": map[string]interface{}{
+					"valid":    false,
+					"warnings": []interface{}{`unsupported preset: provider "fake" doesn't support the video codec "vp9"`},
+				},
+			},
+		},
+		{
+			"preset that can't be translated",
+			`{"providers":["fake"],"preset":{"name":"mp4_720p","container":"mp4","video":{"codec":"h264","bitrate":"invalid"},"audio":{"codec":"aac"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"fake": map[string]interface{}{"valid": false, "error": "translating preset: invalid video bitrate"},
+			},
+		},
+		{
+			"invalid preset",
+			`{"preset":{"name":"mp3","container":"mp3","audioOnly":true}}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "audio-only presets require an audio codec"},
+		},
+	}
+	for _, test := range tests {
+		fprovider.presets = nil
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/presets/validate", strings.NewReader(test.givenBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: wrong body.\nWant %#v\nGot  %#v", test.givenTestCase, test.wantBody, got)
+		}
+		if len(fprovider.presets) > 0 {
+			t.Errorf("%s: presets created in the provider: %#v", test.givenTestCase, fprovider.presets)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route GET /presets/{name}/versions presets listPresetVersions
+//
+// Lists the previous versions of a preset, oldest first. A version is kept
+// whenever the presetmap of the preset is updated or rolled back.
+//
+//     Responses:
+//       200: listPresetVersions
+//       404: presetNotFound
+//       500: genericError
+func (s *TranscodingService) listPresetVersions(r *http.Request) swagger.GizmoJSONResponse {
+	var params getPresetMapInput
+	params.loadParams(web.Vars(r))
+	if _, err := s.db.GetPresetMap(params.Name); err != nil {
+		if err == db.ErrPresetMapNotFound {
+			return newPresetMapNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	versions, err := s.db.ListPresetMapVersions(params.Name)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListPresetVersionsResponse(versions)
+}
+
+// swagger:route POST /presets/{name}/rollback presets rollbackPreset
+//
+// Restores a previous version of a preset. The version being replaced is
+// kept as a new version, so rollbacks can be reverted too.
+//
+//     Responses:
+//       200: preset
+//       400: invalidPreset
+//       404: presetNotFound
+//       500: genericError
+func (s *TranscodingService) rollbackPreset(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input rollbackPresetInput
+	if err := input.loadParams(web.Vars(r), r.Body); err != nil {
+		return newInvalidPresetMapResponse(err)
+	}
+	versions, err := s.db.ListPresetMapVersions(input.Name)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	var version *db.PresetMapVersion
+	for i := range versions {
+		if versions[i].Version == input.Payload.Version {
+			version = &versions[i]
+		}
+	}
+	if version == nil {
+		return newInvalidPresetMapResponse(fmt.Errorf("preset %q has no version %d", input.Name, input.Payload.Version))
+	}
+	presetMap := version.PresetMap
+	presetMap.Name = input.Name
+	switch err = s.db.UpdatePresetMap(&presetMap); err {
+	case nil:
+		return newPresetMapResponse(&presetMap)
+	case db.ErrPresetMapNotFound:
+		return newPresetMapNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// resolvePresetMap returns the given version of the presetmap, along with
+// the number of the version. Version 0 is the current version of the
+// presetmap.
+func (s *TranscodingService) resolvePresetMap(name string, version int) (*db.PresetMap, int, error) {
+	presetMap, err := s.db.GetPresetMap(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	versions, err := s.db.ListPresetMapVersions(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	current := presetMap.Version
+	if current == 0 {
+		// presetmaps stored before they were numbered follow their
+		// previous versions.
+		current = len(versions) + 1
+	}
+	if version == 0 || version == current {
+		return presetMap, current, nil
+	}
+	for i := range versions {
+		if versions[i].Version == version {
+			previous := versions[i].PresetMap
+			previous.Name = name
+			return &previous, version, nil
+		}
+	}
+	return nil, 0, presetVersionNotFoundError{name: name, version: version}
+}
+
+// presetVersionNotFoundError is returned by resolvePresetMap when the
+// presetmap doesn't have the requested version.
+type presetVersionNotFoundError struct {
+	name    string
+	version int
+}
+
+func (err presetVersionNotFoundError) Error() string {
+	return fmt.Sprintf("preset %q has no version %d", err.name, err.version)
+}
+
+// copyPresetMap returns a copy of the presetmap that doesn't share the
+// provider mapping with the original.
+func copyPresetMap(presetMap db.PresetMap) db.PresetMap {
+	mapping := make(map[string]string, len(presetMap.ProviderMapping))
+	for provider, presetID := range presetMap.ProviderMapping {
+		mapping[provider] = presetID
+	}
+	presetMap.ProviderMapping = mapping
+	return presetMap
+}
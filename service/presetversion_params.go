@@ -0,0 +1,59 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// response for the listPresetVersions operation.
+//
+// swagger:response listPresetVersions
+type listPresetVersionsResponse struct {
+	// in: body
+	Versions []db.PresetMapVersion
+
+	baseResponse
+}
+
+// swagger:parameters rollbackPreset
+type rollbackPresetInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+
+	// in: body
+	// required: true
+	Payload presetRollback
+}
+
+// presetRollback identifies the version restored by a rollback.
+type presetRollback struct {
+	// number of the version to restore
+	//
+	// required: true
+	Version int `json:"version"`
+}
+
+func newListPresetVersionsResponse(versions []db.PresetMapVersion) *listPresetVersionsResponse {
+	return &listPresetVersionsResponse{
+		baseResponse: baseResponse{
+			status:  http.StatusOK,
+			payload: versions,
+		},
+	}
+}
+
+func (p *rollbackPresetInput) loadParams(paramsMap map[string]string, body io.Reader) error {
+	p.Name = paramsMap["name"]
+	if err := json.NewDecoder(body).Decode(&p.Payload); err != nil {
+		return err
+	}
+	if p.Payload.Version < 1 {
+		return errors.New("missing field version from the request")
+	}
+	return nil
+}
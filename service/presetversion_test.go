@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestPresetVersions(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "720p",
+		ProviderMapping: map[string]string{"zencoder": "v1"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		return w
+	}
+	for _, presetID := range []string{"v2", "v3"} {
+		w := request("PUT", "/presetmaps/720p", `{"providerMapping":{"zencoder":"`+presetID+`"},"output":{"extension":"mp4"}}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("wrong status code updating the presetmap. Want %d. Got %d", http.StatusOK, w.Code)
+		}
+	}
+
+	w := request("GET", "/presets/720p/versions", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong status code listing versions. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var versions []db.PresetMapVersion
+	if err = json.NewDecoder(w.Body).Decode(&versions); err != nil {
+		t.Fatal(err)
+	}
+	var mappings []string
+	for i, version := range versions {
+		if version.Version != i+1 || version.ReplacedAt.IsZero() {
+			t.Errorf("wrong version %d: %#v", i+1, version)
+		}
+		mappings = append(mappings, version.PresetMap.ProviderMapping["zencoder"])
+	}
+	if want := []string{"v1", "v2"}; !reflect.DeepEqual(mappings, want) {
+		t.Errorf("wrong versions. Want %#v. Got %#v", want, mappings)
+	}
+
+	w = request("POST", "/presets/720p/rollback", `{"version":1}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong status code rolling back. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	presetMap, err := fakeDB.GetPresetMap("720p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetMap.ProviderMapping["zencoder"] != "v1" {
+		t.Errorf("the presetmap wasn't rolled back: %#v", presetMap)
+	}
+	versions, err = fakeDB.ListPresetMapVersions("720p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[2].PresetMap.ProviderMapping["zencoder"] != "v3" {
+		t.Errorf("the rolled back version wasn't kept: %#v", versions)
+	}
+}
+
+func TestPresetVersionsErrors(t *testing.T) {
+	var tests = []struct {
+		givenTestCase string
+		givenMethod   string
+		givenURI      string
+		givenBody     string
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"versions of unknown preset",
+			"GET",
+			"/presets/unknown/versions",
+			"",
+			http.StatusNotFound,
+			map[string]interface{}{"error": "presetmap not found"},
+		},
+		{
+			"rollback to unknown version",
+			"POST",
+			"/presets/720p/rollback",
+			`{"version":3}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `preset "720p" has no version 3`},
+		},
+		{
+			"rollback without version",
+			"POST",
+			"/presets/720p/rollback",
+			`{}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "missing field version from the request"},
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{Name: "720p", ProviderMapping: map[string]string{"zencoder": "v1"}})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, strings.NewReader(test.givenBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: wrong body. Want %#v. Got %#v", test.givenTestCase, test.wantBody, got)
+		}
+	}
+}
+
+func TestResolvePresetMap(t *testing.T) {
+	var tests = []struct {
+		testCase     string
+		givenVersion int
+		wantMapping  string
+		wantVersion  int
+		wantError    string
+	}{
+		{"current version by default", 0, "v3", 3, ""},
+		{"current version", 3, "v3", 3, ""},
+		{"previous version", 1, "v1", 1, ""},
+		{"unknown version", 4, "", 0, `preset "720p" has no version 4`},
+	}
+	for _, test := range tests {
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.db.CreatePresetMap(&db.PresetMap{Name: "720p", ProviderMapping: map[string]string{"zencoder": "v1"}})
+		for _, presetID := range []string{"v2", "v3"} {
+			if err = service.db.UpdatePresetMap(&db.PresetMap{Name: "720p", ProviderMapping: map[string]string{"zencoder": presetID}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		presetMap, version, err := service.resolvePresetMap("720p", test.givenVersion)
+		if test.wantError != "" {
+			if err == nil || err.Error() != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.wantError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if presetMap.Name != "720p" || presetMap.ProviderMapping["zencoder"] != test.wantMapping || version != test.wantVersion {
+			t.Errorf("%s: wrong presetmap. Want %s (version %d). Got %#v (version %d)", test.testCase, test.wantMapping, test.wantVersion, presetMap, version)
+		}
+	}
+}
+
+func TestResolvePresetMapUnnumbered(t *testing.T) {
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	presetMap := db.PresetMap{Name: "720p", ProviderMapping: map[string]string{"zencoder": "v1"}}
+	service.db.CreatePresetMap(&presetMap)
+	if err = service.db.UpdatePresetMap(&db.PresetMap{Name: "720p", ProviderMapping: map[string]string{"zencoder": "v2"}}); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := service.db.GetPresetMap("720p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored.Version = 0
+	got, version, err := service.resolvePresetMap("720p", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ProviderMapping["zencoder"] != "v2" || version != 2 {
+		t.Errorf("wrong presetmap stored before presetmaps were numbered. Want v2 (version 2). Got %#v (version %d)", got, version)
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// defaultPreviewDuration is the duration, in seconds, of preview clips that
+// don't set one.
+const defaultPreviewDuration = 30
+
+// previewExtensions are the extensions of the presets that can't be used in
+// preview clips, as the renditions of adaptive streaming outputs share the
+// range of the source.
+var previewExtensions = map[string]bool{"m3u8": true, "mpd": true}
+
+func validatePreviews(outputs []db.TranscodeOutput) error {
+	for _, output := range outputs {
+		preview := output.Preview
+		if preview == nil {
+			continue
+		}
+		if preview.Start < 0 || preview.Duration < 0 {
+			return fmt.Errorf("preview of %q: start and duration can't be negative", output.Preset)
+		}
+		if preview.Watermark != nil {
+			if err := validateWatermark(*preview.Watermark); err != nil {
+				return fmt.Errorf("preview of %q: %s", output.Preset, err)
+			}
+		}
+	}
+	return nil
+}
+
+// preview returns the preview clip recorded in new jobs for an output with
+// the given preset, with the default duration applied.
+func preview(p *db.Preview, preset *db.PresetMap) (*db.Preview, error) {
+	if p == nil {
+		return nil, nil
+	}
+	if previewExtensions[preset.OutputOpts.Extension] {
+		return nil, fmt.Errorf("preview clips require progressive outputs, but %q is an adaptive streaming preset", preset.Name)
+	}
+	result := *p
+	if result.Duration == 0 {
+		result.Duration = defaultPreviewDuration
+	}
+	return &result, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestPreview(t *testing.T) {
+	mp4 := &db.PresetMap{Name: "mp4_1080p", OutputOpts: db.OutputOptions{Extension: "mp4"}}
+	hls := &db.PresetMap{Name: "hls_1080p", OutputOpts: db.OutputOptions{Extension: "m3u8"}}
+	var tests = []struct {
+		testCase string
+		preview  *db.Preview
+		preset   *db.PresetMap
+		expected *db.Preview
+		errMsg   string
+	}{
+		{"no preview", nil, mp4, nil, ""},
+		{"default duration", &db.Preview{Start: 10}, mp4, &db.Preview{Start: 10, Duration: 30}, ""},
+		{
+			"capped resolution",
+			&db.Preview{Duration: 15, MaxHeight: 360},
+			mp4,
+			&db.Preview{Duration: 15, MaxHeight: 360},
+			"",
+		},
+		{
+			"adaptive streaming preset",
+			&db.Preview{Duration: 15},
+			hls,
+			nil,
+			`preview clips require progressive outputs, but "hls_1080p" is an adaptive streaming preset`,
+		},
+	}
+	for _, test := range tests {
+		got, err := preview(test.preview, test.preset)
+		if test.errMsg != "" {
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: wrong preview. Want %#v. Got %#v", test.testCase, test.expected, got)
+		}
+	}
+}
+
+func TestValidatePreviews(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		outputs  []db.TranscodeOutput
+		errMsg   string
+	}{
+		{
+			"valid previews",
+			[]db.TranscodeOutput{
+				{Preset: "mp4_1080p"},
+				{Preset: "mp4_720p", Preview: &db.Preview{Start: 10, Duration: 30, Watermark: &db.Watermark{URL: "https://example.com/logo.png"}}},
+			},
+			"",
+		},
+		{
+			"negative start",
+			[]db.TranscodeOutput{{Preset: "mp4_720p", Preview: &db.Preview{Start: -1}}},
+			`preview of "mp4_720p": start and duration can't be negative`,
+		},
+		{
+			"invalid watermark",
+			[]db.TranscodeOutput{{Preset: "mp4_720p", Preview: &db.Preview{Watermark: &db.Watermark{URL: "logo.png"}}}},
+			`preview of "mp4_720p": invalid watermark url "logo.png"`,
+		},
+	}
+	for _, test := range tests {
+		err := validatePreviews(test.outputs)
+		if test.errMsg == "" && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+		}
+		if test.errMsg != "" && (err == nil || err.Error() != test.errMsg) {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// priorityRanks maps the priorities of jobs to their rank, with the most
+// urgent priorities first. Jobs without priority have normal priority.
+var priorityRanks = map[string]int{
+	db.PriorityHigh:   0,
+	db.PriorityNormal: 1,
+	"":                1,
+	db.PriorityLow:    2,
+}
+
+func validatePriority(priority string) error {
+	if _, ok := priorityRanks[priority]; !ok {
+		return fmt.Errorf("invalid priority %q, it must be low, normal or high", priority)
+	}
+	return nil
+}
+
+func priorityRank(priority string) int {
+	if rank, ok := priorityRanks[priority]; ok {
+		return rank
+	}
+	return priorityRanks[db.PriorityNormal]
+}
+
+// byPriority sorts jobs by priority and then by creation time, oldest
+// first, keeping the order of jobs with the same priority and creation
+// time when used with sort.Stable.
+type byPriority []db.Job
+
+func (jobs byPriority) Len() int      { return len(jobs) }
+func (jobs byPriority) Swap(i, j int) { jobs[i], jobs[j] = jobs[j], jobs[i] }
+func (jobs byPriority) Less(i, j int) bool {
+	if rank, other := priorityRank(jobs[i].Priority), priorityRank(jobs[j].Priority); rank != other {
+		return rank < other
+	}
+	return jobs[i].CreationTime.Before(jobs[j].CreationTime)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestByPriority(t *testing.T) {
+	jobs := []db.Job{
+		{ID: "job-1", Priority: "low"},
+		{ID: "job-2"},
+		{ID: "job-3", Priority: "high"},
+		{ID: "job-4", Priority: "normal"},
+		{ID: "job-5", Priority: "high"},
+	}
+	sort.Stable(byPriority(jobs))
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	expected := []string{"job-3", "job-5", "job-2", "job-4", "job-1"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("wrong order. Want %#v. Got %#v", expected, ids)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	// defaultThroughput is the throughput assumed for providers that have
+	// no history yet, in seconds of media transcoded per second.
+	defaultThroughput = 1.0
+
+	// throughputWeight is the weight given to the newest observation when
+	// updating the moving average of the throughput of a provider.
+	throughputWeight = 0.2
+
+	// maxEstimatedProgress prevents the estimator from ever reporting a job
+	// as complete, only the provider can do that.
+	maxEstimatedProgress = 99.0
+)
+
+// progressEstimator interpolates the progress of jobs in providers that only
+// report discrete states (queued, started, finished), using the duration of
+// the source media and the throughput observed in previous jobs. The
+// throughput is stored in the repository, so it's shared by all the
+// instances of the API and survives restarts.
+type progressEstimator struct {
+	now func() time.Time
+}
+
+func newProgressEstimator() *progressEstimator {
+	return &progressEstimator{now: time.Now}
+}
+
+// update estimates the progress of started jobs without progress
+// information, from the time elapsed since their creation. It must only be
+// called for providers that don't report progress.
+func (e *progressEstimator) update(repo db.ThroughputRepository, job *db.Job, status *provider.JobStatus) {
+	if status.Status != provider.StatusStarted || status.Progress > 0 {
+		return
+	}
+	duration := status.SourceInfo.Duration
+	elapsed := e.now().Sub(job.CreationTime)
+	if job.CreationTime.IsZero() || duration <= 0 || elapsed < time.Second {
+		return
+	}
+	throughput := defaultThroughput
+	if stored, err := repo.GetProviderThroughput(job.ProviderName); err == nil {
+		throughput = stored.Throughput
+	}
+	expected := duration.Seconds() / throughput
+	progress := elapsed.Seconds() / expected * 100
+	if progress > maxEstimatedProgress {
+		progress = maxEstimatedProgress
+	}
+	status.Progress = progress
+	status.ProgressEstimated = true
+}
+
+// learn updates the stored throughput of the provider of the given job with
+// the time the job took to finish. It's called once per job, when its finish
+// time is recorded. Jobs of the same provider finishing at the same time in
+// different instances may overwrite each other's update, which only delays
+// the moving average a bit.
+func (e *progressEstimator) learn(repo db.ThroughputRepository, job *db.Job, duration time.Duration) error {
+	if job.FinishTime == nil || job.CreationTime.IsZero() || duration <= 0 {
+		return nil
+	}
+	elapsed := job.FinishTime.Sub(job.CreationTime)
+	if elapsed < time.Second {
+		return nil
+	}
+	observed := duration.Seconds() / elapsed.Seconds()
+	current, err := repo.GetProviderThroughput(job.ProviderName)
+	switch err {
+	case nil:
+		observed = current.Throughput*(1-throughputWeight) + observed*throughputWeight
+	case db.ErrProviderThroughputNotFound:
+	default:
+		return err
+	}
+	return repo.SaveProviderThroughput(&db.ProviderThroughput{Provider: job.ProviderName, Throughput: observed})
+}
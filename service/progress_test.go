@@ -0,0 +1,163 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestProgressEstimator(t *testing.T) {
+	now := time.Date(2016, 11, 5, 10, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		testCase        string
+		givenThroughput map[string]float64
+		givenElapsed    time.Duration
+		givenStatus     provider.JobStatus
+		wantProgress    float64
+		wantEstimated   bool
+	}{
+		{
+			"started job without history",
+			nil,
+			30 * time.Second,
+			provider.JobStatus{
+				Status:     provider.StatusStarted,
+				SourceInfo: provider.SourceInfo{Duration: 2 * time.Minute},
+			},
+			25,
+			true,
+		},
+		{
+			"started job with history",
+			map[string]float64{"fake": 4},
+			15 * time.Second,
+			provider.JobStatus{
+				Status:     provider.StatusStarted,
+				SourceInfo: provider.SourceInfo{Duration: 2 * time.Minute},
+			},
+			50,
+			true,
+		},
+		{
+			"started job taking longer than expected",
+			nil,
+			time.Hour,
+			provider.JobStatus{
+				Status:     provider.StatusStarted,
+				SourceInfo: provider.SourceInfo{Duration: 2 * time.Minute},
+			},
+			maxEstimatedProgress,
+			true,
+		},
+		{
+			"started job with progress reported by the provider",
+			nil,
+			30 * time.Second,
+			provider.JobStatus{
+				Status:     provider.StatusStarted,
+				Progress:   10,
+				SourceInfo: provider.SourceInfo{Duration: 2 * time.Minute},
+			},
+			10,
+			false,
+		},
+		{
+			"started job without source info",
+			nil,
+			30 * time.Second,
+			provider.JobStatus{Status: provider.StatusStarted},
+			0,
+			false,
+		},
+	}
+	for _, test := range tests {
+		estimator := newProgressEstimator()
+		estimator.now = func() time.Time { return now }
+		fakeDB := dbtest.NewFakeRepository(false)
+		for name, throughput := range test.givenThroughput {
+			fakeDB.SaveProviderThroughput(&db.ProviderThroughput{Provider: name, Throughput: throughput})
+		}
+		job := db.Job{ProviderName: "fake", CreationTime: now.Add(-test.givenElapsed)}
+		status := test.givenStatus
+		estimator.update(fakeDB, &job, &status)
+		if math.Abs(status.Progress-test.wantProgress) > 1e-9 {
+			t.Errorf("%s: wrong progress. Want %f. Got %f", test.testCase, test.wantProgress, status.Progress)
+		}
+		if status.ProgressEstimated != test.wantEstimated {
+			t.Errorf("%s: wrong progressEstimated flag. Want %v. Got %v", test.testCase, test.wantEstimated, status.ProgressEstimated)
+		}
+	}
+}
+
+func TestProgressEstimatorLearn(t *testing.T) {
+	now := time.Date(2016, 11, 5, 10, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		testCase        string
+		givenThroughput map[string]float64
+		givenElapsed    time.Duration
+		givenDuration   time.Duration
+		wantThroughput  float64
+	}{
+		{"finished job without history", nil, time.Minute, 2 * time.Minute, 2},
+		{"finished job with history", map[string]float64{"fake": 1}, time.Minute, 6 * time.Minute, 2},
+		{"finished job without source info", map[string]float64{"fake": 1}, time.Minute, 0, 1},
+	}
+	for _, test := range tests {
+		estimator := newProgressEstimator()
+		fakeDB := dbtest.NewFakeRepository(false)
+		for name, throughput := range test.givenThroughput {
+			fakeDB.SaveProviderThroughput(&db.ProviderThroughput{Provider: name, Throughput: throughput})
+		}
+		job := db.Job{ProviderName: "fake", CreationTime: now.Add(-test.givenElapsed), FinishTime: &now}
+		if err := estimator.learn(fakeDB, &job, test.givenDuration); err != nil {
+			t.Fatal(err)
+		}
+		throughput, err := fakeDB.GetProviderThroughput("fake")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(throughput.Throughput-test.wantThroughput) > 1e-9 {
+			t.Errorf("%s: wrong throughput. Want %f. Got %f", test.testCase, test.wantThroughput, throughput.Throughput)
+		}
+	}
+}
+
+func TestRecordStatusLearnsThroughputOnce(t *testing.T) {
+	now := time.Date(2016, 11, 5, 10, 0, 0, 0, time.UTC)
+	fakeDB := dbtest.NewFakeRepository(false)
+	service := TranscodingService{db: fakeDB, logger: logrus.New(), progress: newProgressEstimator()}
+	service.progress.now = func() time.Time { return now }
+	job := db.Job{ID: "job-123", ProviderName: "fake", Status: string(provider.StatusStarted)}
+	if err := fakeDB.CreateJob(&job); err != nil {
+		t.Fatal(err)
+	}
+	job.CreationTime = now.Add(-time.Minute)
+	status := provider.JobStatus{
+		Status:     provider.StatusFinished,
+		SourceInfo: provider.SourceInfo{Duration: 2 * time.Minute},
+	}
+	if _, err := service.recordStatus(&job, &status); err != nil {
+		t.Fatal(err)
+	}
+	if job.FinishTime == nil || !job.FinishTime.Equal(now) {
+		t.Errorf("wrong finish time recorded. Want %s. Got %v", now, job.FinishTime)
+	}
+	service.progress.now = func() time.Time { return now.Add(time.Hour) }
+	for i := 0; i < 3; i++ {
+		if _, err := service.recordStatus(&job, &status); err != nil {
+			t.Fatal(err)
+		}
+	}
+	throughput, err := fakeDB.GetProviderThroughput("fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(throughput.Throughput-2) > 1e-9 {
+		t.Errorf("wrong throughput. Want 2. Got %f", throughput.Throughput)
+	}
+}
@@ -52,9 +52,16 @@ func TestGetProvider(t *testing.T) {
 				"name":   "fake",
 				"health": map[string]interface{}{"ok": true},
 				"capabilities": map[string]interface{}{
-					"input":        []interface{}{"prores", "h264"},
-					"output":       []interface{}{"mp4", "webm", "hls"},
-					"destinations": []interface{}{"akamai", "s3"},
+					"input":              []interface{}{"prores", "h264"},
+					"output":             []interface{}{"mp4", "webm", "hls"},
+					"destinations":       []interface{}{"akamai", "s3"},
+					"videoCodecs":        []interface{}{"h264", "vp8"},
+					"audioCodecs":        []interface{}{"aac", "vorbis"},
+					"streamingProtocols": []interface{}{"hls"},
+					"maxAudioChannels":   float64(2),
+					"conform":            true,
+					"trim":               true,
+					"watermark":          true,
 				},
 				"enabled": true,
 			},
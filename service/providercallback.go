@@ -0,0 +1,274 @@
+package service
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// snsCertHostRegexp matches the hosts of the certificates used by Amazon
+// SNS for signing messages.
+var snsCertHostRegexp = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+var (
+	errProviderCallbacksDisabled = errors.New("provider notifications are not enabled")
+	errInvalidNotificationToken  = errors.New("invalid notification token")
+	errInvalidSNSSignature       = errors.New("invalid SNS signature")
+	errUnexpectedSNSTopic        = errors.New("unexpected SNS topic")
+)
+
+// swagger:route POST /callbacks/zencoder callbacks receiveZencoderNotification
+//
+// Receives the notifications sent by Zencoder when jobs change, updating
+// the status of the job immediately. Notifications are only accepted when
+// both the public URL of the API and the notification token are configured,
+// and must include the token.
+//
+//     Responses:
+//       200: jobStatus
+//       400: invalidProviderNotification
+//       403: providerNotificationForbidden
+//       404: jobNotFound
+//       500: genericError
+func (s *TranscodingService) receiveZencoderNotification(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	cfg := s.config.ProviderCallbacks
+	if cfg == nil || cfg.BaseURL == "" || cfg.ZencoderToken == "" {
+		return newProviderNotificationForbiddenResponse(errProviderCallbacksDisabled)
+	}
+	token := r.URL.Query().Get("token")
+	if !hmac.Equal([]byte(token), []byte(cfg.ZencoderToken)) {
+		return newProviderNotificationForbiddenResponse(errInvalidNotificationToken)
+	}
+	var notification zencoderNotification
+	if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+		return newInvalidProviderNotificationResponse(err)
+	}
+	if notification.Job.ID == 0 {
+		return newInvalidProviderNotificationResponse(errors.New("missing job id"))
+	}
+	return s.refreshProviderJob("zencoder", strconv.FormatInt(notification.Job.ID, 10))
+}
+
+// swagger:route POST /callbacks/mediaconvert callbacks receiveMediaConvertNotification
+//
+// Receives the MediaConvert job state change events delivered by Amazon
+// SNS, updating the status of the job immediately. Subscriptions to the
+// configured topic are confirmed automatically.
+//
+//     Responses:
+//       200: jobStatus
+//       400: invalidProviderNotification
+//       403: providerNotificationForbidden
+//       404: jobNotFound
+//       500: genericError
+func (s *TranscodingService) receiveMediaConvertNotification(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	cfg := s.config.ProviderCallbacks
+	if cfg == nil || cfg.MediaConvertTopicARN == "" {
+		return newProviderNotificationForbiddenResponse(errProviderCallbacksDisabled)
+	}
+	var message snsMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		return newInvalidProviderNotificationResponse(err)
+	}
+	if message.TopicArn != cfg.MediaConvertTopicARN {
+		return newProviderNotificationForbiddenResponse(errUnexpectedSNSTopic)
+	}
+	if err := s.sns.verify(&message); err != nil {
+		return newProviderNotificationForbiddenResponse(err)
+	}
+	switch message.Type {
+	case "SubscriptionConfirmation":
+		if err := s.sns.confirm(&message); err != nil {
+			return swagger.NewErrorResponse(err)
+		}
+		return emptyResponse(http.StatusOK)
+	case "Notification":
+		var event mediaConvertEvent
+		if err := json.Unmarshal([]byte(message.Message), &event); err != nil {
+			return newInvalidProviderNotificationResponse(err)
+		}
+		if event.Detail.JobID == "" {
+			return newInvalidProviderNotificationResponse(errors.New("missing job id"))
+		}
+		return s.refreshProviderJob("mediaconvert", event.Detail.JobID)
+	default:
+		return emptyResponse(http.StatusOK)
+	}
+}
+
+// refreshProviderJob loads the status of the job with the given provider
+// job id, recording it and notifying the callback URL of the job.
+func (s *TranscodingService) refreshProviderJob(providerName, providerJobID string) swagger.GizmoJSONResponse {
+	job, err := s.db.GetJobByProviderJobID(providerName, providerJobID)
+	if err == db.ErrJobNotFound {
+		return newJobNotFoundResponse(err)
+	}
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	_, status, _, err := s.getTranscodeJobByID(job.ID)
+	if err != nil {
+		if _, ok := err.(provider.JobNotFoundError); ok {
+			return newJobNotFoundProviderResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	return newJobStatusResponse(status)
+}
+
+// snsVerifier verifies the signatures of the messages delivered by Amazon
+// SNS, caching the signing certificates.
+type snsVerifier struct {
+	client *http.Client
+	fetch  func(certURL string) (*x509.Certificate, error)
+
+	mtx   sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+func newSNSVerifier() *snsVerifier {
+	v := snsVerifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		certs:  make(map[string]*x509.Certificate),
+	}
+	v.fetch = v.fetchCert
+	return &v
+}
+
+// verify checks the signature of the message, as described in
+// https://docs.aws.amazon.com/sns/latest/dg/sns-verify-signature-of-message.html.
+func (v *snsVerifier) verify(message *snsMessage) error {
+	var hash crypto.Hash
+	switch message.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("unsupported SNS signature version %q", message.SignatureVersion)
+	}
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return errInvalidSNSSignature
+	}
+	cert, err := v.cert(message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errInvalidSNSSignature
+	}
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum(snsStringToSign(message))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256(snsStringToSign(message))
+		digest = sum[:]
+	}
+	if rsa.VerifyPKCS1v15(publicKey, hash, digest, signature) != nil {
+		return errInvalidSNSSignature
+	}
+	return nil
+}
+
+// snsStringToSign returns the signed fields of the message, in the order
+// defined by SNS.
+func snsStringToSign(message *snsMessage) []byte {
+	values := map[string]string{
+		"Message":      message.Message,
+		"MessageId":    message.MessageID,
+		"Subject":      message.Subject,
+		"SubscribeURL": message.SubscribeURL,
+		"Timestamp":    message.Timestamp,
+		"Token":        message.Token,
+		"TopicArn":     message.TopicArn,
+		"Type":         message.Type,
+	}
+	keys := []string{"Message", "MessageId", "SubscribeURL", "Timestamp", "Token", "TopicArn", "Type"}
+	if message.Type == "Notification" {
+		keys = []string{"Message", "MessageId"}
+		if message.Subject != "" {
+			keys = append(keys, "Subject")
+		}
+		keys = append(keys, "Timestamp", "TopicArn", "Type")
+	}
+	var data []byte
+	for _, key := range keys {
+		data = append(data, key+"\n"+values[key]+"\n"...)
+	}
+	return data
+}
+
+func (v *snsVerifier) cert(certURL string) (*x509.Certificate, error) {
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !snsCertHostRegexp.MatchString(u.Host) {
+		return nil, fmt.Errorf("invalid SNS certificate URL %q", certURL)
+	}
+	v.mtx.Lock()
+	cert, ok := v.certs[certURL]
+	v.mtx.Unlock()
+	if ok {
+		return cert, nil
+	}
+	cert, err = v.fetch(certURL)
+	if err != nil {
+		return nil, err
+	}
+	v.mtx.Lock()
+	v.certs[certURL] = cert
+	v.mtx.Unlock()
+	return cert, nil
+}
+
+func (v *snsVerifier) fetchCert(certURL string) (*x509.Certificate, error) {
+	resp, err := v.client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching SNS certificate: %s", err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching SNS certificate: %s", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("error fetching SNS certificate: invalid PEM data")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// confirm confirms the subscription of the API to the topic of the message.
+func (v *snsVerifier) confirm(message *snsMessage) error {
+	resp, err := v.client.Get(message.SubscribeURL)
+	if err != nil {
+		return fmt.Errorf("error confirming SNS subscription: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error confirming SNS subscription: %s", resp.Status)
+	}
+	return nil
+}
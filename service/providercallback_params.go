@@ -0,0 +1,94 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:parameters receiveZencoderNotification
+type zencoderNotificationInput struct {
+	// secret token included in the notification URL registered in the
+	// jobs.
+	//
+	// in: query
+	Token string `json:"token"`
+
+	// in: body
+	// required: true
+	Payload zencoderNotification
+}
+
+// zencoderNotification is the job notification sent by Zencoder. Only the
+// id of the job is used, the status is loaded from the Zencoder API.
+type zencoderNotification struct {
+	Job struct {
+		ID    int64  `json:"id"`
+		State string `json:"state"`
+	} `json:"job"`
+}
+
+// swagger:parameters receiveMediaConvertNotification
+type mediaConvertNotificationInput struct {
+	// in: body
+	// required: true
+	Payload snsMessage
+}
+
+// snsMessage is a message delivered by Amazon SNS to HTTP subscribers.
+type snsMessage struct {
+	Type             string
+	MessageID        string `json:"MessageId"`
+	Token            string
+	TopicArn         string
+	Subject          string
+	Message          string
+	Timestamp        string
+	SignatureVersion string
+	Signature        string
+	SigningCertURL   string
+	SubscribeURL     string
+	UnsubscribeURL   string
+}
+
+// mediaConvertEvent is the CloudWatch event published by MediaConvert when
+// the state of a job changes.
+type mediaConvertEvent struct {
+	Detail struct {
+		JobID  string `json:"jobId"`
+		Status string `json:"status"`
+	} `json:"detail"`
+}
+
+// error returned when the provider notification is malformed.
+//
+// swagger:response invalidProviderNotification
+type invalidProviderNotificationResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the token or the signature of the provider
+// notification doesn't match.
+//
+// swagger:response providerNotificationForbidden
+type providerNotificationForbiddenResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newInvalidProviderNotificationResponse(err error) *invalidProviderNotificationResponse {
+	return &invalidProviderNotificationResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidProviderNotificationResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newProviderNotificationForbiddenResponse(err error) *providerNotificationForbiddenResponse {
+	return &providerNotificationForbiddenResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusForbidden)}
+}
+
+func (r *providerNotificationForbiddenResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	testMediaConvertTopic = "arn:aws:sns:us-west-2:123456789012:mediaconvert-events"
+	testSNSCertURL        = "https://sns.us-west-2.amazonaws.com/SimpleNotificationService-1234.pem"
+)
+
+func init() {
+	provider.Register("zencoder", notifyingProviderFactory)
+	provider.Register("mediaconvert", notifyingProviderFactory)
+}
+
+// notifyingProvider is a provider that reports every job as finished.
+type notifyingProvider struct {
+	fakeProvider
+}
+
+func (*notifyingProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
+	return &provider.JobStatus{ProviderJobID: job.ProviderJobID, Status: provider.StatusFinished}, nil
+}
+
+// notifyingProviderFactory only initializes the provider when provider
+// callbacks are configured, keeping it out of the listings of providers in
+// the other tests.
+func notifyingProviderFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
+	if cfg.ProviderCallbacks == nil {
+		return nil, errors.New("provider callbacks not configured")
+	}
+	return &notifyingProvider{}, nil
+}
+
+func TestReceiveZencoderNotification(t *testing.T) {
+	var tests = []struct {
+		testCase   string
+		cfg        *config.ProviderCallbacks
+		token      string
+		body       string
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			"valid notification",
+			&config.ProviderCallbacks{BaseURL: "https://transcoding.example.com", ZencoderToken: "secret"},
+			"secret",
+			`{"job":{"id":123,"state":"finished"}}`,
+			http.StatusOK,
+			"finished",
+		},
+		{
+			"wrong token",
+			&config.ProviderCallbacks{BaseURL: "https://transcoding.example.com", ZencoderToken: "secret"},
+			"other",
+			`{"job":{"id":123,"state":"finished"}}`,
+			http.StatusForbidden,
+			"started",
+		},
+		{
+			"provider callbacks disabled",
+			&config.ProviderCallbacks{},
+			"",
+			`{"job":{"id":123,"state":"finished"}}`,
+			http.StatusForbidden,
+			"started",
+		},
+		{
+			"no token configured",
+			&config.ProviderCallbacks{BaseURL: "https://transcoding.example.com"},
+			"",
+			`{"job":{"id":123,"state":"finished"}}`,
+			http.StatusForbidden,
+			"started",
+		},
+		{
+			"unknown job",
+			&config.ProviderCallbacks{BaseURL: "https://transcoding.example.com", ZencoderToken: "secret"},
+			"secret",
+			`{"job":{"id":456,"state":"finished"}}`,
+			http.StatusNotFound,
+			"started",
+		},
+		{
+			"invalid notification",
+			&config.ProviderCallbacks{BaseURL: "https://transcoding.example.com", ZencoderToken: "secret"},
+			"secret",
+			`{"job":{}}`,
+			http.StatusBadRequest,
+			"started",
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		service, err := NewTranscodingService(&config.Config{ProviderCallbacks: test.cfg}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.db.CreateJob(&db.Job{ID: "job-123", ProviderName: "zencoder", ProviderJobID: "123", Status: "started"})
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/callbacks/zencoder?token="+test.token, strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d: %s", test.testCase, test.wantCode, w.Code, w.Body.String())
+		}
+		job, _ := service.db.GetJob("job-123")
+		if job.Status != test.wantStatus {
+			t.Errorf("%s: wrong job status. Want %q. Got %q", test.testCase, test.wantStatus, job.Status)
+		}
+	}
+}
+
+func TestReceiveMediaConvertNotification(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certData, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var confirmed int
+	subscribeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		confirmed++
+	}))
+	defer subscribeServer.Close()
+	sign := func(message snsMessage) snsMessage {
+		message.SignatureVersion = "1"
+		message.SigningCertURL = testSNSCertURL
+		digest := sha1.Sum(snsStringToSign(&message))
+		signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, digest[:])
+		message.Signature = base64.StdEncoding.EncodeToString(signature)
+		return message
+	}
+	notification := func(jobID string) snsMessage {
+		return snsMessage{
+			Type:      "Notification",
+			MessageID: "message-1",
+			TopicArn:  testMediaConvertTopic,
+			Message:   `{"source":"aws.mediaconvert","detail":{"jobId":"` + jobID + `","status":"COMPLETE"}}`,
+			Timestamp: "2017-06-01T12:00:00.000Z",
+		}
+	}
+	tampered := sign(notification("1496-mc"))
+	tampered.Message = strings.Replace(tampered.Message, "1496-mc", "other-job", 1)
+	otherTopic := notification("1496-mc")
+	otherTopic.TopicArn = "arn:aws:sns:us-west-2:123456789012:other"
+	otherCert := sign(notification("1496-mc"))
+	otherCert.SigningCertURL = "https://attacker.example.com/cert.pem"
+	var tests = []struct {
+		testCase      string
+		message       snsMessage
+		wantCode      int
+		wantStatus    string
+		wantConfirmed int
+	}{
+		{"valid notification", sign(notification("1496-mc")), http.StatusOK, "finished", 0},
+		{"tampered notification", tampered, http.StatusForbidden, "started", 0},
+		{"unsigned notification", notification("1496-mc"), http.StatusForbidden, "started", 0},
+		{"wrong topic", sign(otherTopic), http.StatusForbidden, "started", 0},
+		{"wrong certificate URL", otherCert, http.StatusForbidden, "started", 0},
+		{"unknown job", sign(notification("other-job")), http.StatusNotFound, "started", 0},
+		{
+			"subscription confirmation",
+			sign(snsMessage{
+				Type:         "SubscriptionConfirmation",
+				MessageID:    "message-2",
+				Token:        "token",
+				TopicArn:     testMediaConvertTopic,
+				Message:      "You have chosen to subscribe to the topic",
+				SubscribeURL: subscribeServer.URL,
+				Timestamp:    "2017-06-01T12:00:00.000Z",
+			}),
+			http.StatusOK,
+			"started",
+			1,
+		},
+	}
+	for _, test := range tests {
+		confirmed = 0
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		service, err := NewTranscodingService(&config.Config{
+			ProviderCallbacks: &config.ProviderCallbacks{MediaConvertTopicARN: testMediaConvertTopic},
+		}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.db.CreateJob(&db.Job{ID: "job-123", ProviderName: "mediaconvert", ProviderJobID: "1496-mc", Status: "started"})
+		service.sns.fetch = func(certURL string) (*x509.Certificate, error) {
+			if certURL != testSNSCertURL {
+				t.Errorf("%s: wrong certificate URL: %q", test.testCase, certURL)
+			}
+			return cert, nil
+		}
+		srvr.Register(service)
+		body, _ := json.Marshal(test.message)
+		r, _ := http.NewRequest("POST", "/callbacks/mediaconvert", strings.NewReader(string(body)))
+		r.Header.Set("Content-Type", "text/plain; charset=UTF-8")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d: %s", test.testCase, test.wantCode, w.Code, w.Body.String())
+		}
+		job, _ := service.db.GetJob("job-123")
+		if job.Status != test.wantStatus {
+			t.Errorf("%s: wrong job status. Want %q. Got %q", test.testCase, test.wantStatus, job.Status)
+		}
+		if confirmed != test.wantConfirmed {
+			t.Errorf("%s: wrong number of confirmations. Want %d. Got %d", test.testCase, test.wantConfirmed, confirmed)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// maxSummaryValueLength is the maximum length of the values kept in the
+// summary of the status reported by providers, in bytes of JSON.
+const maxSummaryValueLength = 256
+
+// summarizeProviderStatus returns the compact summary of the status
+// reported by a provider: its scalar values (strings, numbers, booleans and
+// times), leaving out nested documents (like the details of each output)
+// and long values.
+func summarizeProviderStatus(raw map[string]interface{}) map[string]interface{} {
+	var summary map[string]interface{}
+	for key, value := range raw {
+		data, err := json.Marshal(value)
+		if err != nil || len(data) == 0 || len(data) > maxSummaryValueLength || data[0] == '{' || data[0] == '[' || string(data) == "null" {
+			continue
+		}
+		if summary == nil {
+			summary = make(map[string]interface{})
+		}
+		summary[key] = value
+	}
+	return summary
+}
+
+// compressProviderStatus returns the JSON of the status reported by a
+// provider, compressed with gzip.
+func compressProviderStatus(raw map[string]interface{}) ([]byte, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(w).Encode(raw); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressProviderStatus(data []byte) (map[string]interface{}, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	err = json.Unmarshal(body, &raw)
+	return raw, err
+}
+
+// parseProviderStatusMode checks the providerStatus query string parameter
+// of job requests, returning whether the complete status reported by the
+// provider was requested.
+func parseProviderStatusMode(value string) (bool, error) {
+	switch value {
+	case "", "summary":
+		return false, nil
+	case "raw":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid providerStatus %q, it must be summary or raw", value)
+}
+
+// setProviderStatus replaces the status reported by the provider with its
+// summary, or with the complete status when raw is true. Statuses served
+// by the status poller only keep the summary, the complete status is
+// decompressed from the stored status on demand.
+func (s *TranscodingService) setProviderStatus(job *db.Job, status *provider.JobStatus, p provider.TranscodingProvider, raw bool) {
+	if !raw {
+		status.ProviderStatus = summarizeProviderStatus(status.ProviderStatus)
+		return
+	}
+	if p != nil || job == nil || job.StatusSnapshot == "" {
+		return
+	}
+	var snapshot statusSnapshotData
+	if err := json.Unmarshal([]byte(job.StatusSnapshot), &snapshot); err != nil || len(snapshot.RawProviderStatus) == 0 {
+		return
+	}
+	rawStatus, err := decompressProviderStatus(snapshot.RawProviderStatus)
+	if err != nil {
+		s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to decompress the status reported by the provider")
+		return
+	}
+	status.ProviderStatus = rawStatus
+}
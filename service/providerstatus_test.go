@@ -0,0 +1,95 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestSummarizeProviderStatus(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		raw      map[string]interface{}
+		want     map[string]interface{}
+	}{
+		{"empty status", nil, nil},
+		{
+			"scalar values",
+			map[string]interface{}{"status": "COMPLETE", "progress": 100, "retried": false},
+			map[string]interface{}{"status": "COMPLETE", "progress": 100, "retried": false},
+		},
+		{
+			"nested and long values",
+			map[string]interface{}{
+				"status":     "COMPLETE",
+				"outputs":    []map[string]string{{"key": "video.mp4"}},
+				"input":      map[string]string{"key": "video.mov"},
+				"message":    strings.Repeat("a", maxSummaryValueLength),
+				"finishedAt": nil,
+			},
+			map[string]interface{}{"status": "COMPLETE"},
+		},
+	}
+	for _, test := range tests {
+		got := summarizeProviderStatus(test.raw)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: wrong summary.\nWant %#v\nGot  %#v", test.testCase, test.want, got)
+		}
+	}
+}
+
+func TestStoredProviderStatus(t *testing.T) {
+	service, err := NewTranscodingService(&config.Config{StatusPoller: &config.StatusPoller{Interval: time.Minute}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	job := db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123", Status: "finished"}
+	service.db.CreateJob(&job)
+	raw := map[string]interface{}{
+		"status":  "COMPLETE",
+		"outputs": []interface{}{map[string]interface{}{"key": "video.mp4", "duration": float64(120)}},
+	}
+	err = service.storeStatusSnapshot(&job, &provider.JobStatus{ProviderJobID: "provider-job-123", Status: provider.StatusFinished, ProviderStatus: raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(job.StatusSnapshot, "video.mp4") {
+		t.Errorf("complete provider status stored uncompressed: %s", job.StatusSnapshot)
+	}
+	for _, test := range []struct {
+		raw  bool
+		want map[string]interface{}
+	}{
+		{false, map[string]interface{}{"status": "COMPLETE"}},
+		{true, raw},
+	} {
+		stored, status, p, err := service.readTranscodeJob("job-123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.setProviderStatus(stored, status, p, test.raw)
+		if !reflect.DeepEqual(status.ProviderStatus, test.want) {
+			t.Errorf("raw=%v: wrong provider status.\nWant %#v\nGot  %#v", test.raw, test.want, status.ProviderStatus)
+		}
+	}
+}
+
+func TestParseProviderStatusMode(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "summary": false, "raw": true} {
+		got, err := parseProviderStatusMode(value)
+		if err != nil || got != want {
+			t.Errorf("%q: wrong mode. Want %v. Got %v (%v)", value, want, got, err)
+		}
+	}
+	if _, err := parseProviderStatusMode("full"); err == nil {
+		t.Error("unexpected <nil> error for invalid mode")
+	}
+}
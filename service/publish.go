@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+const defaultPublishStagingPrefix = "unpublished"
+
+// maxDeleteObjects is the maximum number of keys in a single DeleteObjects
+// request.
+const maxDeleteObjects = 1000
+
+var errPublishDestination = errors.New("publish requires an s3:// destination")
+
+// publishStore lists, copies and removes the objects of published outputs.
+type publishStore interface {
+	list(bucket, prefix string) ([]string, error)
+	copy(bucket, source, destination string) error
+	remove(bucket string, keys []string) error
+}
+
+// outputPublisher moves the outputs of jobs from the staging prefix to
+// their destination once they pass verification. Playlists are moved after
+// the media they reference, and the master playlist (or manifest) is moved
+// last, so players never see incomplete outputs.
+type outputPublisher struct {
+	prefix string
+	store  publishStore
+	runs   *analysisRuns
+}
+
+func newOutputPublisher(cfg *config.Publish) *outputPublisher {
+	if cfg == nil {
+		cfg = &config.Publish{}
+	}
+	awsConfig := aws.NewConfig().WithRegion(cfg.Region)
+	if cfg.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+	}
+	prefix := strings.Trim(cfg.StagingPrefix, "/")
+	if prefix == "" {
+		prefix = defaultPublishStagingPrefix
+	}
+	return &outputPublisher{
+		prefix: prefix,
+		store:  &s3PublishStore{client: s3.New(session.New(awsConfig))},
+		runs:   newAnalysisRuns(),
+	}
+}
+
+// jobPrefix returns the staging prefix of the outputs of the given job, in
+// the bucket of the destination.
+func (p *outputPublisher) jobPrefix(jobID string) string {
+	return p.prefix + "/" + jobID + "/"
+}
+
+// stagingDestination returns the destination that providers write the
+// outputs of the given job to, in the bucket of its destination.
+func (p *outputPublisher) stagingDestination(jobID, destination string) (string, error) {
+	bucket, path, err := splitS3URL(destination)
+	if err != nil {
+		return "", errPublishDestination
+	}
+	return "s3://" + bucket + "/" + p.jobPrefix(jobID) + strings.TrimPrefix(path, "/"), nil
+}
+
+// sync publishes the outputs of the given job once it finishes, reporting
+// the job as started until they're published. Jobs with verification
+// problems are reported as failed, and their outputs aren't published.
+// Paths of published outputs are reported in the destination.
+func (p *outputPublisher) sync(job *db.Job, status *provider.JobStatus) {
+	publish := job.Publish
+	if publish == nil || status.Status != provider.StatusFinished {
+		return
+	}
+	if !publish.Published {
+		if len(status.VerificationProblems) > 0 {
+			status.Status = provider.StatusFailed
+			status.StatusMessage = "outputs not published, verification failed: " + strings.Join(status.VerificationProblems, "; ")
+			return
+		}
+		jobCopy := *job
+		done, _, err := p.runs.poll(job.ID, func() (interface{}, error) {
+			return nil, p.publish(&jobCopy)
+		})
+		if !done {
+			status.Status = provider.StatusStarted
+			status.StatusMessage = "publishing outputs"
+			return
+		}
+		if err != nil {
+			status.Status = provider.StatusFailed
+			status.StatusMessage = "failed to publish outputs: " + err.Error()
+			return
+		}
+		publish.Published = true
+	}
+	staging := "/" + p.jobPrefix(job.ID)
+	status.Output.Destination = strings.Replace(status.Output.Destination, staging, "/", 1)
+	for i, file := range status.Output.Files {
+		status.Output.Files[i].Path = strings.Replace(file.Path, staging, "/", 1)
+	}
+}
+
+// publish copies the staged outputs of the given job to the destination,
+// and removes them from the staging prefix.
+func (p *outputPublisher) publish(job *db.Job) error {
+	bucket, _, err := splitS3URL(job.Publish.Destination)
+	if err != nil {
+		return err
+	}
+	prefix := p.jobPrefix(job.ID)
+	keys, err := p.store.list(bucket, prefix)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no outputs found in s3://%s/%s", bucket, prefix)
+	}
+	for _, key := range publishOrder(keys, job.StreamingParams.PlaylistFileName) {
+		if err = p.store.copy(bucket, key, strings.TrimPrefix(key, prefix)); err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+	}
+	return p.store.remove(bucket, keys)
+}
+
+// publishOrder sorts the given keys in the order they're published: media
+// first, then playlists and manifests, then the master playlist.
+func publishOrder(keys []string, masterPlaylist string) []string {
+	var media, playlists, master []string
+	for _, key := range keys {
+		switch {
+		case masterPlaylist != "" && strings.HasSuffix(key, "/"+masterPlaylist):
+			master = append(master, key)
+		case strings.HasSuffix(key, ".m3u8") || strings.HasSuffix(key, ".mpd"):
+			playlists = append(playlists, key)
+		default:
+			media = append(media, key)
+		}
+	}
+	return append(append(media, playlists...), master...)
+}
+
+type s3PublishStore struct {
+	client s3iface.S3API
+}
+
+func (s *s3PublishStore) list(bucket, prefix string) ([]string, error) {
+	var keys []string
+	err := s.client.ListObjectsPages(&s3.ListObjectsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, object := range page.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	return keys, err
+}
+
+func (s *s3PublishStore) copy(bucket, source, destination string) error {
+	copySource := (&url.URL{Path: bucket + "/" + source}).EscapedPath()
+	_, err := s.client.CopyObject(&s3.CopyObjectInput{
+		Bucket:     aws.String(bucket),
+		CopySource: aws.String(copySource),
+		Key:        aws.String(destination),
+	})
+	return err
+}
+
+func (s *s3PublishStore) remove(bucket string, keys []string) error {
+	for start := 0; start < len(keys); start += maxDeleteObjects {
+		end := start + maxDeleteObjects
+		if end > len(keys) {
+			end = len(keys)
+		}
+		objects := make([]*s3.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+		_, err := s.client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &s3.Delete{Objects: objects},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
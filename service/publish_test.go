@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+type fakePublishStore struct {
+	keys    []string
+	copies  [][2]string
+	removed []string
+	failOn  string
+}
+
+func (s *fakePublishStore) list(bucket, prefix string) ([]string, error) {
+	return s.keys, nil
+}
+
+func (s *fakePublishStore) copy(bucket, source, destination string) error {
+	if source == s.failOn {
+		return errors.New("access denied")
+	}
+	s.copies = append(s.copies, [2]string{source, destination})
+	return nil
+}
+
+func (s *fakePublishStore) remove(bucket string, keys []string) error {
+	s.removed = append(s.removed, keys...)
+	return nil
+}
+
+func TestOutputPublisherStagingDestination(t *testing.T) {
+	publisher := newOutputPublisher(nil)
+	var tests = []struct {
+		destination string
+		expected    string
+		expectedErr error
+	}{
+		{"s3://videos/2017/", "s3://videos/unpublished/job-123/2017/", nil},
+		{"s3://videos", "s3://videos/unpublished/job-123/", nil},
+		{"ftp://videos/2017/", "", errPublishDestination},
+	}
+	for _, test := range tests {
+		destination, err := publisher.stagingDestination("job-123", test.destination)
+		if err != test.expectedErr {
+			t.Errorf("%s: wrong error. Want %v. Got %v", test.destination, test.expectedErr, err)
+		}
+		if destination != test.expected {
+			t.Errorf("%s: wrong staging destination. Want %q. Got %q", test.destination, test.expected, destination)
+		}
+	}
+}
+
+func TestOutputPublisherSync(t *testing.T) {
+	keys := []string{
+		"unpublished/job-123/2017/job-123/hls/index.m3u8",
+		"unpublished/job-123/2017/job-123/hls/video_720p.m3u8",
+		"unpublished/job-123/2017/job-123/hls/video_720p_00001.ts",
+		"unpublished/job-123/2017/job-123/video_1080p.mp4",
+	}
+	var tests = []struct {
+		testCase        string
+		failOn          string
+		problems        []string
+		expectedStatus  provider.Status
+		expectedMessage string
+		expectedCopies  [][2]string
+	}{
+		{
+			"outputs published",
+			"",
+			nil,
+			provider.StatusFinished,
+			"",
+			[][2]string{
+				{"unpublished/job-123/2017/job-123/hls/video_720p_00001.ts", "2017/job-123/hls/video_720p_00001.ts"},
+				{"unpublished/job-123/2017/job-123/video_1080p.mp4", "2017/job-123/video_1080p.mp4"},
+				{"unpublished/job-123/2017/job-123/hls/video_720p.m3u8", "2017/job-123/hls/video_720p.m3u8"},
+				{"unpublished/job-123/2017/job-123/hls/index.m3u8", "2017/job-123/hls/index.m3u8"},
+			},
+		},
+		{
+			"verification problems",
+			"",
+			[]string{"hls/video_720p.m3u8: missing segments"},
+			provider.StatusFailed,
+			"outputs not published, verification failed: hls/video_720p.m3u8: missing segments",
+			nil,
+		},
+		{
+			"failed copy",
+			"unpublished/job-123/2017/job-123/video_1080p.mp4",
+			nil,
+			provider.StatusFailed,
+			"failed to publish outputs: unpublished/job-123/2017/job-123/video_1080p.mp4: access denied",
+			[][2]string{
+				{"unpublished/job-123/2017/job-123/hls/video_720p_00001.ts", "2017/job-123/hls/video_720p_00001.ts"},
+			},
+		},
+	}
+	for _, test := range tests {
+		store := &fakePublishStore{keys: keys, failOn: test.failOn}
+		publisher := &outputPublisher{prefix: "unpublished", store: store, runs: newAnalysisRuns()}
+		job := db.Job{
+			ID:              "job-123",
+			Destination:     "s3://videos/unpublished/job-123/2017/",
+			Publish:         &db.Publish{Destination: "s3://videos/2017/"},
+			StreamingParams: db.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/index.m3u8"},
+		}
+		var status provider.JobStatus
+		for i := 0; i < 100; i++ {
+			status = provider.JobStatus{
+				Status:               provider.StatusFinished,
+				VerificationProblems: test.problems,
+				Output: provider.JobOutput{
+					Destination: "s3://videos/unpublished/job-123/2017/job-123/",
+					Files: []provider.OutputFile{
+						{Path: "s3://videos/unpublished/job-123/2017/job-123/video_1080p.mp4", Container: "mp4"},
+					},
+				},
+			}
+			publisher.sync(&job, &status)
+			if status.Status != provider.StatusStarted {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if status.Status != test.expectedStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.testCase, test.expectedStatus, status.Status)
+		}
+		if status.StatusMessage != test.expectedMessage {
+			t.Errorf("%s: wrong status message. Want %q. Got %q", test.testCase, test.expectedMessage, status.StatusMessage)
+		}
+		if !reflect.DeepEqual(store.copies, test.expectedCopies) {
+			t.Errorf("%s: wrong copies. Want %#v. Got %#v", test.testCase, test.expectedCopies, store.copies)
+		}
+		if test.expectedStatus != provider.StatusFinished {
+			if job.Publish.Published || len(store.removed) > 0 {
+				t.Errorf("%s: unexpected publication of the outputs", test.testCase)
+			}
+			continue
+		}
+		if !job.Publish.Published || !reflect.DeepEqual(store.removed, keys) {
+			t.Errorf("%s: outputs not published. Removed %#v", test.testCase, store.removed)
+		}
+		if status.Output.Destination != "s3://videos/2017/job-123/" || status.Output.Files[0].Path != "s3://videos/2017/job-123/video_1080p.mp4" {
+			t.Errorf("%s: wrong output after publication: %#v", test.testCase, status.Output)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+// RunReconciliation periodically reconciles the status of active jobs with
+// their status in the providers, until the given channel is closed. It
+// returns immediately when reconciliation is disabled in the configuration.
+func (s *TranscodingService) RunReconciliation(stop <-chan struct{}) {
+	cfg := s.config.Reconciliation
+	if cfg == nil || cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.reconcile()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reconcile queries the status of the jobs that aren't in a terminal status
+// in the API, repairing the jobs that reached a terminal status in the
+// provider without the API noticing it (and firing their callbacks). It
+// doesn't run while the API is in maintenance mode.
+func (s *TranscodingService) reconcile() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	jobs, err := s.db.ListJobs(db.JobFilter{})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list jobs for reconciliation")
+		return
+	}
+	if err = s.resumeJobs(jobs); err != nil {
+		s.logger.WithError(err).Error("failed to resume paused jobs")
+	}
+	for _, job := range jobs {
+		if isTerminal(provider.Status(job.Status)) || job.Status == string(provider.StatusPaused) {
+			continue
+		}
+		if job.Status == string(provider.StatusQueuedLocally) {
+			// jobs queued by previous instances of the API
+			if !s.submissions.has(job.ID) && s.submissions.enqueue(job.ID, job.Priority) {
+				s.logger.WithField("jobId", job.ID).Warn("requeued job")
+			}
+			continue
+		}
+		previous := job.Status
+		_, status, _, err := s.getTranscodeJobByID(job.ID)
+		if err != nil {
+			s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to reconcile job")
+			continue
+		}
+		if isTerminal(status.Status) {
+			s.logger.WithFields(logrus.Fields{
+				"jobId":          job.ID,
+				"providerName":   job.ProviderName,
+				"providerJobId":  job.ProviderJobID,
+				"previousStatus": previous,
+				"status":         status.Status,
+			}).Warn("reconciled job status")
+		}
+	}
+}
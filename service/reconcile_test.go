@@ -0,0 +1,76 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestReconcile(t *testing.T) {
+	var mtx sync.Mutex
+	var callbacks []map[string]interface{}
+	callbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mtx.Lock()
+		callbacks = append(callbacks, payload)
+		mtx.Unlock()
+	}))
+	defer callbackServer.Close()
+	var tests = []struct {
+		testCase      string
+		givenStatus   string
+		wantStatus    string
+		wantCallbacks int
+	}{
+		{"job finished without the API noticing", "started", "finished", 1},
+		{"job without status", "", "finished", 1},
+		{"job already finished", "finished", "finished", 0},
+	}
+	for _, test := range tests {
+		mtx.Lock()
+		callbacks = nil
+		mtx.Unlock()
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.db.CreateJob(&db.Job{
+			ID:            "job-123",
+			ProviderName:  "fake",
+			ProviderJobID: "provider-job-123",
+			CallbackURL:   callbackServer.URL,
+			Status:        test.givenStatus,
+		})
+		service.reconcile()
+		service.reconcile()
+		service.deliverNotifications()
+		job, err := service.db.GetJob("job-123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != test.wantStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.testCase, test.wantStatus, job.Status)
+		}
+		mtx.Lock()
+		got := callbacks
+		mtx.Unlock()
+		if len(got) != test.wantCallbacks {
+			t.Errorf("%s: wrong number of callbacks. Want %d. Got %d", test.testCase, test.wantCallbacks, len(got))
+			continue
+		}
+		for _, payload := range got {
+			if payload["jobId"] != "job-123" || payload["status"] != test.wantStatus {
+				t.Errorf("%s: wrong callback payload: %#v", test.testCase, payload)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// parseEnvironment validates the environment of a new job, returning the
+// environment recorded in the job (empty for production).
+func parseEnvironment(environment string) (string, error) {
+	switch environment {
+	case "", "production":
+		return "", nil
+	case db.EnvironmentSandbox:
+		return environment, nil
+	}
+	return "", fmt.Errorf("invalid environment %q", environment)
+}
+
+// providerConfig returns the configuration used for initializing providers
+// in the given environment.
+func (s *TranscodingService) providerConfig(environment string) *config.Config {
+	if environment == db.EnvironmentSandbox {
+		return s.config.SandboxConfig()
+	}
+	return s.config
+}
+
+// checkSandboxDestination returns an error if the given destination isn't
+// allowed for sandbox jobs. Jobs without destination use the destination
+// configured in the sandbox of the provider.
+func (s *TranscodingService) checkSandboxDestination(destination string) error {
+	if destination == "" {
+		return nil
+	}
+	var allowed string
+	if s.config.Sandbox != nil {
+		allowed = s.config.Sandbox.AllowedDestinations
+	}
+	for _, prefix := range splitList(allowed) {
+		if strings.HasPrefix(destination, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("destination %q is not allowed in the sandbox", destination)
+}
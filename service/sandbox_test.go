@@ -0,0 +1,123 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestTranscodeSandbox(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenEnvironment string
+		givenDestination string
+
+		wantCode        int
+		wantError       string
+		wantEnvironment string
+	}{
+		{
+			"sandbox job",
+			"sandbox",
+			"s3://sandbox-bucket/videos/",
+			http.StatusOK,
+			"",
+			"sandbox",
+		},
+		{
+			"sandbox job with the default destination",
+			"sandbox",
+			"",
+			http.StatusOK,
+			"",
+			"sandbox",
+		},
+		{
+			"sandbox job writing to production",
+			"sandbox",
+			"s3://production-bucket/videos/",
+			http.StatusBadRequest,
+			`destination "s3://production-bucket/videos/" is not allowed in the sandbox`,
+			"",
+		},
+		{
+			"production job",
+			"production",
+			"s3://production-bucket/videos/",
+			http.StatusOK,
+			"",
+			"",
+		},
+		{
+			"invalid environment",
+			"staging",
+			"",
+			http.StatusBadRequest,
+			`invalid environment "staging"`,
+			"",
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service, err := NewTranscodingService(&config.Config{
+			Sandbox: &config.Sandbox{AllowedDestinations: "s3://sandbox-bucket/"},
+		}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		body := `{"source":"http://another.non.existent/video.mp4","provider":"fake","environment":"` + test.givenEnvironment + `","destination":"` + test.givenDestination + `","outputs":[{"preset":"mp4_1080p","fileName":"video.mp4"}]}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+			continue
+		}
+		if test.wantError != "" {
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		job, err := fakeDB.GetJob(got["jobId"].(string))
+		if err != nil {
+			t.Errorf("%s: job not created: %s", test.givenTestCase, err)
+			continue
+		}
+		if job.Environment != test.wantEnvironment {
+			t.Errorf("%s: wrong environment. Want %q. Got %q", test.givenTestCase, test.wantEnvironment, job.Environment)
+		}
+	}
+}
+
+func TestProviderConfig(t *testing.T) {
+	cfg := config.Config{Zencoder: &config.Zencoder{APIKey: "production-api-key"}}
+	service := TranscodingService{config: &cfg}
+	if got := service.providerConfig("").Zencoder.APIKey; got != "production-api-key" {
+		t.Errorf("wrong production API key. Got %q", got)
+	}
+	if got := service.providerConfig(db.EnvironmentSandbox).Zencoder.APIKey; got != "" {
+		t.Errorf("sandbox jobs should never use production credentials. Got %q", got)
+	}
+}
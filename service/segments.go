@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/playlist"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// segmentVerifier verifies the segments of adaptive streaming outputs once
+// their jobs finish. Outputs are verified in the background, and the
+// outcome is recorded in the job, so outputs are fetched only once per job.
+type segmentVerifier struct {
+	cfg   *config.SegmentVerification
+	fetch playlist.Fetcher
+	runs  *analysisRuns
+}
+
+func newSegmentVerifier(cfg *config.SegmentVerification, analyzer *mediaAnalyzer, client *http.Client) *segmentVerifier {
+	return &segmentVerifier{
+		cfg:   cfg,
+		fetch: analyzer.fetcher(client),
+		runs:  newAnalysisRuns(),
+	}
+}
+
+// verify checks the output of the given job, listing the problems found in
+// the status. The job is reported as started until the verification is
+// done. Failures to fetch the output are reported, but they don't fail the
+// job.
+func (v *segmentVerifier) verify(job *db.Job, status *provider.JobStatus) {
+	if v.cfg == nil || !v.cfg.Enabled || status.Status != provider.StatusFinished {
+		return
+	}
+	params := job.StreamingParams
+	if params.Protocol == "" || params.PlaylistFileName == "" || status.Output.Destination == "" {
+		return
+	}
+	if job.SegmentVerification == nil {
+		masterURL := strings.TrimRight(status.Output.Destination, "/") + "/" + strings.TrimLeft(params.PlaylistFileName, "/")
+		expected := v.expectedVariants(job)
+		done, result, err := v.runs.poll(job.ID, func() (interface{}, error) {
+			return playlist.Verify(masterURL, expected, v.cfg.Tolerance, v.fetch)
+		})
+		if !done {
+			status.Status = provider.StatusStarted
+			status.StatusMessage = "verifying the segments of the outputs"
+			return
+		}
+		var verification db.SegmentVerification
+		if err != nil {
+			verification.Error = err.Error()
+		} else {
+			verification.Problems = result.(*playlist.Report).Problems
+		}
+		job.SegmentVerification = &verification
+	}
+	if job.SegmentVerification.Error != "" {
+		status.VerificationProblems = []string{fmt.Sprintf("failed to verify output: %s", job.SegmentVerification.Error)}
+		return
+	}
+	if len(job.SegmentVerification.Problems) == 0 {
+		return
+	}
+	status.VerificationProblems = job.SegmentVerification.Problems
+	if v.cfg.FailJobs {
+		status.Status = provider.StatusFailed
+		status.StatusMessage = "output verification failed: " + strings.Join(job.SegmentVerification.Problems, "; ")
+	}
+}
+
+// expectedVariants returns the file names of the variant playlists that must
+// be referenced by the master playlist of the given job.
+func (v *segmentVerifier) expectedVariants(job *db.Job) []string {
+	if job.StreamingParams.Protocol != "hls" {
+		return nil
+	}
+	var variants []string
+	for _, output := range job.Outputs {
+		if strings.HasSuffix(output.FileName, ".m3u8") && output.FileName != job.StreamingParams.PlaylistFileName {
+			variants = append(variants, output.FileName)
+		}
+	}
+	return variants
+}
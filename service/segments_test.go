@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const testMasterPlaylist = `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=1000000
+video_360p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=3000000
+video_720p.m3u8
+`
+
+const testMediaPlaylist = `#EXTM3U
+#EXTINF:6.0,
+0.ts
+#EXTINF:6.0,
+1.ts
+#EXT-X-ENDLIST
+`
+
+func TestSegmentVerifier(t *testing.T) {
+	files := map[string]string{
+		"s3://bucket/job-123/hls/index.m3u8":      testMasterPlaylist,
+		"s3://bucket/job-123/hls/video_360p.m3u8": testMediaPlaylist,
+		"s3://bucket/job-123/hls/video_720p.m3u8": testMediaPlaylist,
+	}
+	job := db.Job{
+		ID:              "job-123",
+		StreamingParams: db.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/index.m3u8"},
+		Outputs: []db.TranscodeOutput{
+			{Preset: "360p", FileName: "hls/video_360p.m3u8"},
+			{Preset: "720p", FileName: "hls/video_720p.m3u8"},
+			{Preset: "1080p", FileName: "hls/video_1080p.m3u8"},
+		},
+	}
+	var tests = []struct {
+		testCase     string
+		cfg          *config.SegmentVerification
+		givenStatus  provider.Status
+		wantStatus   provider.Status
+		wantProblems []string
+	}{
+		{
+			"disabled verification",
+			nil,
+			provider.StatusFinished,
+			provider.StatusFinished,
+			nil,
+		},
+		{
+			"job still running",
+			&config.SegmentVerification{Enabled: true},
+			provider.StatusStarted,
+			provider.StatusStarted,
+			nil,
+		},
+		{
+			"flagged job",
+			&config.SegmentVerification{Enabled: true},
+			provider.StatusFinished,
+			provider.StatusFinished,
+			[]string{`variant playlist "hls/video_1080p.m3u8" is not referenced in the master playlist`},
+		},
+		{
+			"failed job",
+			&config.SegmentVerification{Enabled: true, FailJobs: true},
+			provider.StatusFinished,
+			provider.StatusFailed,
+			[]string{`variant playlist "hls/video_1080p.m3u8" is not referenced in the master playlist`},
+		},
+	}
+	for _, test := range tests {
+		verifier := newSegmentVerifier(test.cfg, newMediaAnalyzer(nil), http.DefaultClient)
+		fetches := 0
+		verifier.fetch = func(url string) ([]byte, error) {
+			fetches++
+			if data, ok := files[url]; ok {
+				return []byte(data), nil
+			}
+			return nil, errors.New("not found")
+		}
+		job := job
+		status := verifySegments(verifier, &job, test.givenStatus)
+		if status.Status != test.wantStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.testCase, test.wantStatus, status.Status)
+		}
+		if !reflect.DeepEqual(status.VerificationProblems, test.wantProblems) {
+			t.Errorf("%s: wrong problems\nWant %#v\nGot  %#v", test.testCase, test.wantProblems, status.VerificationProblems)
+		}
+		fetched := fetches
+		status = verifySegments(verifier, &job, test.givenStatus)
+		if fetches != fetched {
+			t.Errorf("%s: outputs fetched again. Want %d fetches. Got %d", test.testCase, fetched, fetches)
+		}
+		if !reflect.DeepEqual(status.VerificationProblems, test.wantProblems) {
+			t.Errorf("%s: wrong problems on the second verification\nWant %#v\nGot  %#v", test.testCase, test.wantProblems, status.VerificationProblems)
+		}
+	}
+}
+
+func TestSegmentVerifierFetchError(t *testing.T) {
+	verifier := newSegmentVerifier(&config.SegmentVerification{Enabled: true, FailJobs: true}, newMediaAnalyzer(nil), http.DefaultClient)
+	verifier.fetch = func(url string) ([]byte, error) {
+		return nil, errors.New("access denied")
+	}
+	job := db.Job{ID: "job-123", StreamingParams: db.StreamingParams{Protocol: "hls", PlaylistFileName: "index.m3u8"}}
+	status := verifySegments(verifier, &job, provider.StatusFinished)
+	if status.Status != provider.StatusFinished {
+		t.Errorf("wrong status. Want %q. Got %q", provider.StatusFinished, status.Status)
+	}
+	wantProblems := []string{`failed to verify output: access denied`}
+	if !reflect.DeepEqual(status.VerificationProblems, wantProblems) {
+		t.Errorf("wrong problems\nWant %#v\nGot  %#v", wantProblems, status.VerificationProblems)
+	}
+	want := db.SegmentVerification{Error: "access denied"}
+	if job.SegmentVerification == nil || !reflect.DeepEqual(*job.SegmentVerification, want) {
+		t.Errorf("wrong verification recorded in the job\nWant %#v\nGot  %#v", want, job.SegmentVerification)
+	}
+}
+
+// verifySegments verifies the output of the given job, waiting for the
+// verification that runs in the background.
+func verifySegments(verifier *segmentVerifier, job *db.Job, given provider.Status) provider.JobStatus {
+	for i := 0; ; i++ {
+		status := provider.JobStatus{
+			Status: given,
+			Output: provider.JobOutput{Destination: "s3://bucket/job-123/"},
+		}
+		verifier.verify(job, &status)
+		if status.StatusMessage != "verifying the segments of the outputs" || i == 100 {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
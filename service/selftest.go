@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+const selfTestLabel = "selftest"
+
+// selfTestPollInterval is the interval between queries for the status of
+// the jobs of self tests.
+var selfTestPollInterval = 5 * time.Second
+
+// swagger:route POST /selftest selftest runSelfTest
+//
+// Runs the small source configured for self tests through a provider, for
+// verifying deployments and for synthetic monitoring. The outputs are
+// written to a scratch destination, checked with ffmpeg and removed, and
+// the report lists the outcome and the duration of each step.
+//
+//     Responses:
+//       200: selfTest
+//       400: genericError
+//       500: genericError
+//       503: selfTest
+func (s *TranscodingService) runSelfTest(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input runSelfTestInput
+	if err := input.loadParams(r.Body, s.config.SelfTest); err != nil {
+		return swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)
+	}
+	p, err := s.initProvider(input.Payload.Provider)
+	if err != nil {
+		return swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)
+	}
+	presetMap, err := s.db.GetPresetMap(input.Payload.PresetMap)
+	if err != nil {
+		if err == db.ErrPresetMapNotFound {
+			return swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	id, err := randomIDGenerator{}.generate("")
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	cfg := s.config.SelfTest
+	job := db.Job{
+		ID:           selfTestLabel + "-" + id,
+		ProviderName: input.Payload.Provider,
+		SourceMedia:  cfg.SourceMedia,
+		Destination:  strings.TrimRight(cfg.Destination, "/") + "/" + selfTestLabel + "-" + id + "/",
+		Labels:       append([]string{selfTestLabel}, input.Payload.Labels...),
+		CreationTime: time.Now().UTC(),
+	}
+	return newSelfTestResponse(s.selfTest(p, &job, presetMap, cfg.Timeout, r.Context().Done()))
+}
+
+// selfTest submits the given job with one output of the given presetmap,
+// waits for it to finish, checks its outputs and cleans up. The job isn't
+// stored, so it doesn't show up in listings, feeds or the workers of the
+// API.
+func (s *TranscodingService) selfTest(p provider.TranscodingProvider, job *db.Job, presetMap *db.PresetMap, timeout time.Duration, done <-chan struct{}) *selfTestReport {
+	start := time.Now()
+	report := selfTestReport{JobID: job.ID, Provider: job.ProviderName, Steps: []selfTestStep{}}
+	step := func(name string, run func() error) bool {
+		stepStart := time.Now()
+		err := run()
+		result := selfTestStep{Name: name, Passed: err == nil, Duration: time.Since(stepStart).Seconds()}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		report.Steps = append(report.Steps, result)
+		return err == nil
+	}
+	var status *provider.JobStatus
+	report.Passed = step("submit", func() error {
+		submitted, err := p.Transcode(job, provider.TranscodeProfile{
+			SourceMedia: job.SourceMedia,
+			Outputs:     []provider.TranscodeOutput{{Preset: *presetMap, FileName: s.defaultFileName(job.SourceMedia, presetMap)}},
+			BillingTags: billingTags(job),
+		})
+		if err != nil {
+			return err
+		}
+		job.ProviderJobID = submitted.ProviderJobID
+		report.ProviderJobID = submitted.ProviderJobID
+		return nil
+	}) && step("transcode", func() error {
+		var err error
+		status, err = s.waitSelfTestJob(p, job, timeout, done)
+		return err
+	}) && step("validate", func() error {
+		return s.validateSelfTestOutputs(status)
+	})
+	if job.ProviderJobID != "" {
+		cleaned := step("cleanup", func() error {
+			return s.cleanUpSelfTest(p, job, status)
+		})
+		report.Passed = report.Passed && cleaned
+	}
+	report.Duration = time.Since(start).Seconds()
+	return &report
+}
+
+// waitSelfTestJob polls the provider until the job finishes, fails or the
+// timeout elapses. The last known status of the job is returned along with
+// the error.
+func (s *TranscodingService) waitSelfTestJob(p provider.TranscodingProvider, job *db.Job, timeout time.Duration, done <-chan struct{}) (*provider.JobStatus, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(selfTestPollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := p.JobStatus(job)
+		if err != nil {
+			return nil, err
+		}
+		switch status.Status {
+		case provider.StatusFinished, provider.StatusFinishedWithWarnings:
+			return status, nil
+		case provider.StatusFailed, provider.StatusCanceled:
+			return status, fmt.Errorf("the job is %s: %s", status.Status, status.StatusMessage)
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return status, fmt.Errorf("the job didn't finish within %s, its last status is %s", timeout, status.Status)
+		case <-done:
+			return status, errors.New("the request was canceled")
+		}
+	}
+}
+
+// validateSelfTestOutputs checks that the job has outputs, and that ffmpeg
+// can decode the video outputs.
+func (s *TranscodingService) validateSelfTestOutputs(status *provider.JobStatus) error {
+	if len(status.Output.Files) == 0 {
+		return errors.New("the job has no output files")
+	}
+	for _, file := range status.Output.Files {
+		if !fingerprintContainers[strings.ToLower(file.Container)] {
+			continue
+		}
+		input, err := s.analyzer.input(file.Path)
+		if err != nil {
+			return err
+		}
+		padding, err := s.analyzer.detectPadding(input, true, false)
+		if err != nil {
+			return fmt.Errorf("%s: %s", file.Path, err)
+		}
+		if padding.Duration <= 0 {
+			return fmt.Errorf("%s: the output is empty", file.Path)
+		}
+	}
+	return nil
+}
+
+// cleanUpSelfTest cancels the job, unless it's done, and removes its output
+// files from S3.
+func (s *TranscodingService) cleanUpSelfTest(p provider.TranscodingProvider, job *db.Job, status *provider.JobStatus) error {
+	if status == nil || !isTerminal(status.Status) {
+		err := p.CancelJob(job.ProviderJobID)
+		if _, notFound := err.(provider.JobNotFoundError); err != nil && !notFound {
+			return fmt.Errorf("canceling the job: %s", err)
+		}
+	}
+	if status == nil {
+		return nil
+	}
+	for _, file := range status.Output.Files {
+		u, err := url.Parse(file.Path)
+		if err != nil || u.Scheme != "s3" {
+			return fmt.Errorf("can't remove %s, only outputs in S3 are removed", file.Path)
+		}
+		if err = s.analyzer.remove(u.Host, strings.TrimPrefix(u.Path, "/")); err != nil {
+			return fmt.Errorf("removing %s: %s", file.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+)
+
+// swagger:parameters runSelfTest
+type runSelfTestInput struct {
+	// in: body
+	Payload runSelfTestPayload
+}
+
+type runSelfTestPayload struct {
+	// provider that runs the test, defaults to the provider in the
+	// configuration
+	Provider string `json:"provider,omitempty"`
+
+	// presetmap of the output of the test, defaults to the presetmap in
+	// the configuration
+	PresetMap string `json:"presetmap,omitempty"`
+
+	// labels of the test job, in addition to the selftest label
+	Labels []string `json:"labels,omitempty"`
+}
+
+// loadParams loads the optional payload of the request, filling the
+// provider and the presetmap from the configuration.
+func (p *runSelfTestInput) loadParams(body io.Reader, cfg *config.SelfTest) error {
+	if cfg == nil || cfg.SourceMedia == "" || cfg.Destination == "" {
+		return errors.New("the self test isn't configured")
+	}
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if p.Payload.Provider == "" {
+		p.Payload.Provider = cfg.Provider
+	}
+	if p.Payload.PresetMap == "" {
+		p.Payload.PresetMap = cfg.PresetMap
+	}
+	if p.Payload.Provider == "" {
+		return errors.New("missing provider from the request")
+	}
+	if p.Payload.PresetMap == "" {
+		return errors.New("missing presetmap from the request")
+	}
+	return nil
+}
+
+// Report of a self test.
+//
+// swagger:model
+type selfTestReport struct {
+	Passed        bool   `json:"passed"`
+	JobID         string `json:"jobId"`
+	Provider      string `json:"provider"`
+	ProviderJobID string `json:"providerJobId,omitempty"`
+
+	// duration of the whole test, in seconds
+	Duration float64 `json:"duration"`
+
+	// steps of the test, in the order they ran: submit, transcode,
+	// validate and cleanup. The cleanup runs even when other steps fail.
+	Steps []selfTestStep `json:"steps"`
+}
+
+// selfTestStep is the outcome of a step of the self test.
+type selfTestStep struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+
+	// duration of the step, in seconds
+	Duration float64 `json:"duration"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// JSON-encoded report of a self test. Failed tests are reported with status
+// 503.
+//
+// swagger:response selfTest
+type selfTestResponse struct {
+	// in: body
+	Payload *selfTestReport
+
+	baseResponse
+}
+
+func newSelfTestResponse(report *selfTestReport) *selfTestResponse {
+	status := http.StatusOK
+	if !report.Passed {
+		status = http.StatusServiceUnavailable
+	}
+	return &selfTestResponse{
+		baseResponse: baseResponse{
+			payload: report,
+			status:  status,
+		},
+	}
+}
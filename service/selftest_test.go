@@ -0,0 +1,185 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	defer func(interval time.Duration) { selfTestPollInterval = interval }(selfTestPollInterval)
+	selfTestPollInterval = 10 * time.Millisecond
+	selfTestConfig := &config.SelfTest{
+		Provider:    "fake",
+		SourceMedia: "s3://mybucket/selftest.mp4",
+		PresetMap:   "mp4_360p",
+		Destination: "s3://mybucket/scratch",
+		Timeout:     50 * time.Millisecond,
+	}
+	var tests = []struct {
+		givenTestCase  string
+		givenConfig    *config.SelfTest
+		givenBody      string
+		givenDecodeErr error
+
+		wantCode    int
+		wantError   string
+		wantSteps   []string
+		wantFailed  string
+		wantRemoved int
+	}{
+		{
+			"passing test",
+			selfTestConfig,
+			`{"labels":["chaos"]}`,
+			nil,
+			http.StatusOK,
+			"",
+			[]string{"submit", "transcode", "validate", "cleanup"},
+			"",
+			1,
+		},
+		{
+			"failing submission",
+			selfTestConfig,
+			`{"labels":["chaos:submit-5xx"]}`,
+			nil,
+			http.StatusServiceUnavailable,
+			"",
+			[]string{"submit"},
+			"submit",
+			0,
+		},
+		{
+			"stuck job",
+			selfTestConfig,
+			`{"labels":["chaos:stuck-at=40"]}`,
+			nil,
+			http.StatusServiceUnavailable,
+			"",
+			[]string{"submit", "transcode", "cleanup"},
+			"transcode",
+			0,
+		},
+		{
+			"undecodable output",
+			selfTestConfig,
+			`{"labels":["chaos"]}`,
+			errors.New("no frames"),
+			http.StatusServiceUnavailable,
+			"",
+			[]string{"submit", "transcode", "validate", "cleanup"},
+			"validate",
+			1,
+		},
+		{
+			"unknown presetmap",
+			selfTestConfig,
+			`{"presetmap":"mp4_4k"}`,
+			nil,
+			http.StatusBadRequest,
+			"presetmap not found",
+			nil,
+			"",
+			0,
+		},
+		{
+			"not configured",
+			nil,
+			"",
+			nil,
+			http.StatusBadRequest,
+			"the self test isn't configured",
+			nil,
+			"",
+			0,
+		},
+	}
+	for _, test := range tests {
+		fprovider = fakeProvider{}
+		service, err := NewTranscodingService(&config.Config{SelfTest: test.givenConfig}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_360p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service.db = fakeDB
+		var removed []string
+		service.analyzer = &mediaAnalyzer{
+			presign: func(bucket, key string) (string, error) {
+				return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+			},
+			detectPadding: func(input string, black, silence bool) (*ffmpeg.Padding, error) {
+				if test.givenDecodeErr != nil {
+					return nil, test.givenDecodeErr
+				}
+				return &ffmpeg.Padding{Duration: 10}, nil
+			},
+			remove: func(bucket, key string) error {
+				removed = append(removed, bucket+"/"+key)
+				return nil
+			},
+		}
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/selftest", strings.NewReader(test.givenBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantError != "" {
+			var got map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&got)
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		var report selfTestReport
+		err = json.NewDecoder(w.Body).Decode(&report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Passed != (test.wantFailed == "") {
+			t.Errorf("%s: wrong outcome. Want passed=%v. Got %#v", test.givenTestCase, test.wantFailed == "", report)
+		}
+		if !strings.HasPrefix(report.JobID, "selftest-") || report.Provider != "fake" {
+			t.Errorf("%s: wrong job in the report: %#v", test.givenTestCase, report)
+		}
+		var gotSteps []string
+		for _, step := range report.Steps {
+			gotSteps = append(gotSteps, step.Name)
+			if step.Passed != (step.Name != test.wantFailed) {
+				t.Errorf("%s: wrong outcome of step %q: %#v", test.givenTestCase, step.Name, step)
+			}
+		}
+		if !reflect.DeepEqual(gotSteps, test.wantSteps) {
+			t.Errorf("%s: wrong steps. Want %#v. Got %#v", test.givenTestCase, test.wantSteps, gotSteps)
+		}
+		if len(removed) != test.wantRemoved {
+			t.Errorf("%s: wrong number of removed outputs. Want %d. Got %#v", test.givenTestCase, test.wantRemoved, removed)
+		}
+		for _, path := range removed {
+			if !strings.HasSuffix(path, "/selftest_mp4_360p.mp4") {
+				t.Errorf("%s: wrong output removed: %q", test.givenTestCase, path)
+			}
+		}
+	}
+}
@@ -8,7 +8,8 @@ import (
 	"github.com/NYTimes/gziphandler"
 	"github.com/NYTimes/video-transcoding-api/config"
 	"github.com/NYTimes/video-transcoding-api/db"
-	"github.com/NYTimes/video-transcoding-api/db/redis"
+	"github.com/NYTimes/video-transcoding-api/db/repository"
+	"github.com/NYTimes/video-transcoding-api/hook"
 	"github.com/NYTimes/video-transcoding-api/swagger"
 	"github.com/Sirupsen/logrus"
 	"github.com/fsouza/ctxlogger"
@@ -17,19 +18,88 @@ import (
 // TranscodingService will implement server.JSONService and handle all requests
 // to the server.
 type TranscodingService struct {
-	config *config.Config
-	db     db.Repository
-	logger *logrus.Logger
+	config           *config.Config
+	db               db.Repository
+	logger           *logrus.Logger
+	progress         *progressEstimator
+	sources          *sourceValidator
+	segments         *segmentVerifier
+	experiments      *experimentAssigner
+	predictor        *jobPredictor
+	uploader         *outputUploader
+	publisher        *outputPublisher
+	submissions      *submissionQueue
+	maintenance      *maintenanceMode
+	jobIDs           jobIDGenerator
+	decrypter        *sourceDecrypter
+	analyzer         *mediaAnalyzer
+	fingerprints     *outputFingerprinter
+	captionConverter *captionConverter
+	audioQCRuns      *analysisRuns
+	flashRuns        *analysisRuns
+	videoQCRuns      *analysisRuns
+	posterRuns       *analysisRuns
+	watchers         *folderWatchers
+	callbacks        *callbackNotifier
+	preProcessors    []hook.Hook
+	policy           *policyEngine
+	postProcessors   *postProcessors
+	sns              *snsVerifier
 }
 
 // NewTranscodingService will instantiate a JSONService
 // with the given configuration.
 func NewTranscodingService(cfg *config.Config, logger *logrus.Logger) (*TranscodingService, error) {
-	dbRepo, err := redis.NewRepository(cfg)
+	dbRepo, err := repository.New(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("Error initializing Redis client: %s", err)
+		return nil, fmt.Errorf("Error initializing database client: %s", err)
 	}
-	return &TranscodingService{config: cfg, db: dbRepo, logger: logger}, nil
+	sources := newSourceValidator(cfg.SourceValidation)
+	s := TranscodingService{
+		config:      cfg,
+		db:          dbRepo,
+		logger:      logger,
+		progress:    newProgressEstimator(),
+		sources:     sources,
+		experiments: newExperimentAssigner(),
+		predictor:   newJobPredictor(cfg.Prediction),
+		uploader:    newOutputUploader(cfg),
+		publisher:   newOutputPublisher(cfg.Publish),
+		submissions: newSubmissionQueue(cfg.Backpressure),
+		maintenance: newMaintenanceMode(cfg.Maintenance),
+		decrypter:   newSourceDecrypter(cfg.SourceEncryption, sources.client),
+		analyzer:    newMediaAnalyzer(cfg.Analysis),
+		audioQCRuns: newAnalysisRuns(),
+		flashRuns:   newAnalysisRuns(),
+		videoQCRuns: newAnalysisRuns(),
+		posterRuns:  newAnalysisRuns(),
+		watchers:    newFolderWatchers(cfg.WatchFolders),
+		callbacks:   newCallbackNotifier(cfg.Callbacks, sources, logger),
+		sns:         newSNSVerifier(),
+	}
+	s.fingerprints = newOutputFingerprinter(s.analyzer)
+	s.segments = newSegmentVerifier(cfg.SegmentVerification, s.analyzer, sources.client)
+	s.captionConverter = newCaptionConverter(s.analyzer, sources)
+	s.submissions.dispatch = s.submitQueuedJob
+	s.jobIDs, err = newJobIDGenerator(cfg.JobIDFormat, func() db.JobRepository { return s.db })
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Hooks != nil {
+		s.preProcessors, err = hook.Parse(cfg.Hooks.PreProcessors, cfg.Hooks)
+		if err != nil {
+			return nil, fmt.Errorf("Error initializing pre-processors: %s", err)
+		}
+	}
+	s.postProcessors, err = newPostProcessors(cfg.Hooks)
+	if err != nil {
+		return nil, fmt.Errorf("Error initializing post-processors: %s", err)
+	}
+	s.policy, err = newPolicyEngine(cfg.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading policy: %s", err)
+	}
+	return &s, nil
 }
 
 // Prefix returns the string prefix used for all endpoints within
@@ -43,7 +113,7 @@ func (s *TranscodingService) Prefix() string {
 // compress our responses.
 func (s *TranscodingService) Middleware(h http.Handler) http.Handler {
 	logMiddleware := ctxlogger.ContextLogger(s.logger)
-	return gziphandler.GzipHandler(server.CORSHandler(logMiddleware(h), ""))
+	return gziphandler.GzipHandler(server.CORSHandler(logMiddleware(s.retryAfterHandler(s.maintenanceHandler(externalIDHandler(h)))), ""))
 }
 
 // JSONMiddleware provides a JSONEndpoint hook wrapped around all requests.
@@ -62,19 +132,51 @@ func (s *TranscodingService) JSONEndpoints() map[string]map[string]server.JSONEn
 	return map[string]map[string]server.JSONEndpoint{
 		"/jobs": {
 			"POST": swagger.HandlerToJSONEndpoint(s.newTranscodeJob),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listJobs),
 		},
 		"/jobs/:jobId": {
 			"GET": swagger.HandlerToJSONEndpoint(s.getTranscodeJob),
 		},
+		externalIDJobsRoute + ":externalId": {
+			"GET": swagger.HandlerToJSONEndpoint(s.getJobByExternalID),
+		},
 		"/jobs/:jobId/cancel": {
 			"POST": swagger.HandlerToJSONEndpoint(s.cancelTranscodeJob),
 		},
+		"/jobs/:jobId/artifacts": {
+			"GET":  swagger.HandlerToJSONEndpoint(s.listArtifacts),
+			"POST": swagger.HandlerToJSONEndpoint(s.newArtifact),
+		},
+		"/jobs/:jobId/compare/:otherJobId": {
+			"GET": swagger.HandlerToJSONEndpoint(s.compareJobs),
+		},
+		"/jobs/:jobId/key": {
+			"GET": swagger.HandlerToJSONEndpoint(s.getOutputKey),
+		},
 		"/presets": {
+			"GET":  swagger.HandlerToJSONEndpoint(s.listPresets),
 			"POST": swagger.HandlerToJSONEndpoint(s.newPreset),
 		},
 		"/presets/:name": {
+			"GET": swagger.HandlerToJSONEndpoint(presetActions(map[string]swagger.Handler{
+				"export": s.exportPresets,
+			})),
+			"POST": swagger.HandlerToJSONEndpoint(presetActions(map[string]swagger.Handler{
+				"import":   s.importPresets,
+				"bulk":     s.newPresets,
+				"validate": s.checkPreset,
+			})),
 			"DELETE": swagger.HandlerToJSONEndpoint(s.deletePreset),
 		},
+		"/presets/:name/versions": {
+			"GET": swagger.HandlerToJSONEndpoint(s.listPresetVersions),
+		},
+		"/presets/:name/rollback": {
+			"POST": swagger.HandlerToJSONEndpoint(s.rollbackPreset),
+		},
+		"/presetclones": {
+			"POST": swagger.HandlerToJSONEndpoint(s.clonePresets),
+		},
 		"/presetmaps": {
 			"POST": swagger.HandlerToJSONEndpoint(s.newPresetMap),
 			"GET":  swagger.HandlerToJSONEndpoint(s.listPresetMaps),
@@ -84,6 +186,104 @@ func (s *TranscodingService) JSONEndpoints() map[string]map[string]server.JSONEn
 			"PUT":    swagger.HandlerToJSONEndpoint(s.updatePresetMap),
 			"DELETE": swagger.HandlerToJSONEndpoint(s.deletePresetMap),
 		},
+		"/tenants": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newTenant),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listTenants),
+		},
+		"/tenants/:name": {
+			"GET":    swagger.HandlerToJSONEndpoint(s.getTenant),
+			"PUT":    swagger.HandlerToJSONEndpoint(s.updateTenant),
+			"DELETE": swagger.HandlerToJSONEndpoint(s.deleteTenant),
+		},
+		"/orphanedjobs": {
+			"POST": swagger.HandlerToJSONEndpoint(s.collectOrphanedJobs),
+		},
+		"/pauses": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newSubmissionPause),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listSubmissionPauses),
+		},
+		"/pauses/:provider": {
+			"DELETE": swagger.HandlerToJSONEndpoint(s.deleteSubmissionPause),
+		},
+		"/maintenance": {
+			"GET": swagger.HandlerToJSONEndpoint(s.getMaintenance),
+			"PUT": swagger.HandlerToJSONEndpoint(s.setMaintenance),
+		},
+		"/migrations": {
+			"POST": swagger.HandlerToJSONEndpoint(s.migratePresetMaps),
+		},
+		"/experiments": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newExperiment),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listExperiments),
+		},
+		"/experiments/:name": {
+			"GET":    swagger.HandlerToJSONEndpoint(s.getExperiment),
+			"DELETE": swagger.HandlerToJSONEndpoint(s.deleteExperiment),
+		},
+		"/experiments/:name/samples": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newExperimentSample),
+		},
+		"/experiments/:name/report": {
+			"GET": swagger.HandlerToJSONEndpoint(s.getExperimentReport),
+		},
+		"/deliverytargets": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newDeliveryTarget),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listDeliveryTargets),
+		},
+		"/deliverytargets/:name": {
+			"GET":    swagger.HandlerToJSONEndpoint(s.getDeliveryTarget),
+			"PUT":    swagger.HandlerToJSONEndpoint(s.updateDeliveryTarget),
+			"DELETE": swagger.HandlerToJSONEndpoint(s.deleteDeliveryTarget),
+		},
+		"/ladders": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newLadder),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listLadders),
+		},
+		"/ladders/:name": {
+			"GET":    swagger.HandlerToJSONEndpoint(s.getLadder),
+			"PUT":    swagger.HandlerToJSONEndpoint(s.updateLadder),
+			"DELETE": swagger.HandlerToJSONEndpoint(s.deleteLadder),
+		},
+		"/ladders/:name/generate": {
+			"POST": swagger.HandlerToJSONEndpoint(s.generateLadder),
+		},
+		"/feed": {
+			"GET": swagger.HandlerToJSONEndpoint(s.getFeed),
+		},
+		"/selftest": {
+			"POST": swagger.HandlerToJSONEndpoint(s.runSelfTest),
+		},
+		"/watchfolders": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newWatchFolder),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listWatchFolders),
+		},
+		"/watchfolders/:name": {
+			"GET":    swagger.HandlerToJSONEndpoint(s.getWatchFolder),
+			"DELETE": swagger.HandlerToJSONEndpoint(s.deleteWatchFolder),
+		},
+		"/campaigns": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newCampaign),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listCampaigns),
+		},
+		"/campaigns/:name": {
+			"GET":    swagger.HandlerToJSONEndpoint(s.getCampaign),
+			"DELETE": swagger.HandlerToJSONEndpoint(s.deleteCampaign),
+		},
+		"/campaigns/:name/pause": {
+			"POST": swagger.HandlerToJSONEndpoint(s.pauseCampaign),
+		},
+		"/campaigns/:name/resume": {
+			"POST": swagger.HandlerToJSONEndpoint(s.resumeCampaign),
+		},
+		"/sources/:checksum": {
+			"GET": swagger.HandlerToJSONEndpoint(s.getSource),
+		},
+		"/callbacks/zencoder": {
+			"POST": swagger.HandlerToJSONEndpoint(s.receiveZencoderNotification),
+		},
+		"/callbacks/mediaconvert": {
+			"POST": swagger.HandlerToJSONEndpoint(s.receiveMediaConvertNotification),
+		},
 		"/providers": {
 			"GET": swagger.HandlerToJSONEndpoint(s.listProviders),
 		},
@@ -99,5 +299,11 @@ func (s *TranscodingService) Endpoints() map[string]map[string]http.HandlerFunc
 		"/swagger.json": {
 			"GET": s.swaggerManifest,
 		},
+		"/feed/mrss": {
+			"GET": s.getMRSSFeed,
+		},
+		"/jobs/:jobId/download": {
+			"GET": s.downloadJob,
+		},
 	}
 }
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+)
+
+// privateNetworks are the private, loopback, link-local, multicast and
+// reserved networks that sources can't point to.
+var privateNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"100::/64",
+	"2001:db8::/32",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// sourceFetchTimeout is the timeout of the requests made by the API for
+// reading sources.
+const sourceFetchTimeout = 30 * time.Second
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// sourceValidator checks source URLs against the restrictions defined in
+// the configuration before the API hands them to a provider. Only the
+// allowed schemes are accepted, and the hosts of HTTP(S) sources are
+// checked. The requests made by the API itself for reading sources go
+// through client, which checks the addresses of hosts again when
+// connecting, so they can't be rebound to private networks, and so do the
+// requests of ffmpeg, which go through proxy.
+type sourceValidator struct {
+	cfg      *config.SourceValidation
+	lookupIP func(host string) ([]net.IP, error)
+	client   *http.Client
+	proxy    *sourceProxy
+}
+
+func newSourceValidator(cfg *config.SourceValidation) *sourceValidator {
+	v := sourceValidator{cfg: cfg, lookupIP: net.LookupIP}
+	v.client = &http.Client{
+		Timeout:   sourceFetchTimeout,
+		Transport: &http.Transport{DialContext: v.dialContext, TLSHandshakeTimeout: 10 * time.Second},
+	}
+	v.proxy = newSourceProxy(v.dialContext)
+	return &v
+}
+
+func (v *sourceValidator) validate(source string) error {
+	return v.validateURL("source media", source, v.schemeAllowed)
+}
+
+// validateCallback checks the callback URL of a job, which must be an
+// HTTP(S) URL pointing to an allowed host, as notifications are sent by the
+// API itself.
+func (v *sourceValidator) validateCallback(callbackURL string) error {
+	return v.validateURL("callbackURL", callbackURL, func(scheme string) bool {
+		return scheme == "http" || scheme == "https"
+	})
+}
+
+// validateCaption checks the source of a caption file, which is read by
+// the API when the caption is converted and by providers otherwise.
+func (v *sourceValidator) validateCaption(source string) error {
+	return v.validateURL("caption", source, v.schemeAllowed)
+}
+
+// validateWatermark checks the URL of the watermark image of a job, which
+// is read by providers like sources.
+func (v *sourceValidator) validateWatermark(imageURL string) error {
+	return v.validateURL("watermark", imageURL, v.schemeAllowed)
+}
+
+// validateURL checks the given URL, described by what in the errors,
+// against the restrictions of the configuration.
+func (v *sourceValidator) validateURL(what, rawURL string, schemeAllowed func(string) bool) error {
+	if v.cfg == nil || v.cfg.Disabled {
+		return nil
+	}
+	sourceURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", what, err)
+	}
+	scheme := strings.ToLower(sourceURL.Scheme)
+	if !schemeAllowed(scheme) {
+		return fmt.Errorf("%s %q uses a scheme that is not allowed", what, rawURL)
+	}
+	if scheme != "http" && scheme != "https" {
+		return nil
+	}
+	host, port, err := net.SplitHostPort(sourceURL.Host)
+	if err != nil {
+		host = sourceURL.Host
+		port = "80"
+		if scheme == "https" {
+			port = "443"
+		}
+	}
+	host = strings.ToLower(strings.TrimSuffix(strings.Trim(host, "[]"), "."))
+	if host == "" {
+		return fmt.Errorf("invalid %s %q: missing host", what, rawURL)
+	}
+	if !v.portAllowed(port) {
+		return fmt.Errorf("%s %q uses a port that is not allowed", what, rawURL)
+	}
+	for _, blocked := range splitList(v.cfg.BlockedHosts) {
+		if host == strings.ToLower(blocked) {
+			return fmt.Errorf("%s %q points to a blocked host", what, rawURL)
+		}
+	}
+	if v.cfg.AllowPrivateNetworks {
+		return nil
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ips, err = v.lookupIP(host)
+		if err != nil {
+			return fmt.Errorf("unable to resolve the host of the %s %q: %s", what, rawURL, err)
+		}
+	}
+	if privateIP(ips) {
+		return fmt.Errorf("%s %q points to a private network", what, rawURL)
+	}
+	return nil
+}
+
+// dialContext connects to the given address, checking the port and the
+// addresses of the host at the time of the connection. The connection is
+// made to the address that was checked.
+func (v *sourceValidator) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	if v.cfg == nil || v.cfg.Disabled {
+		return dialer.DialContext(ctx, network, address)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if !v.portAllowed(port) {
+		return nil, fmt.Errorf("connections to port %s are not allowed", port)
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = v.lookupIP(host); err != nil {
+			return nil, err
+		}
+	}
+	if !v.cfg.AllowPrivateNetworks && privateIP(ips) {
+		return nil, fmt.Errorf("%s points to a private network", host)
+	}
+	var conn net.Conn
+	for _, ip := range ips {
+		if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// ffmpegInput validates a source before ffmpeg reads it, returning the
+// input of ffmpeg and a function called once ffmpeg is done with it. ffmpeg
+// follows the URLs listed in playlists, so only HTTP(S) and S3 sources are
+// accepted, and HLS playlists are rejected. HTTP(S) sources are requested
+// through the client of the validator first, and ffmpeg reads the URL
+// reached after following the redirects through the proxy of the
+// validator, unless the validation is disabled.
+func (v *sourceValidator) ffmpegInput(source string) (string, func(), error) {
+	sourceURL, err := url.Parse(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid source media: %s", err)
+	}
+	switch strings.ToLower(sourceURL.Scheme) {
+	case "s3":
+		return source, func() {}, nil
+	case "http", "https":
+	default:
+		return "", nil, fmt.Errorf("source media %q can't be analyzed, only http(s) and s3 sources are supported", source)
+	}
+	if err = v.validate(source); err != nil {
+		return "", nil, err
+	}
+	req, err := http.NewRequest("GET", source, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Range", "bytes=0-6")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("error fetching source media %q: %s", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", nil, fmt.Errorf("error fetching source media %q: %s", source, resp.Status)
+	}
+	head := make([]byte, 7)
+	n, _ := io.ReadFull(resp.Body, head)
+	if string(head[:n]) == "#EXTM3U" {
+		return "", nil, fmt.Errorf("source media %q is an HLS playlist, which can't be analyzed", source)
+	}
+	if v.cfg == nil || v.cfg.Disabled {
+		return resp.Request.URL.String(), func() {}, nil
+	}
+	return v.proxy.serve(resp.Request.URL.String())
+}
+
+// privateIP returns whether any of the given addresses is in a private
+// network.
+func privateIP(ips []net.IP) bool {
+	for _, ip := range ips {
+		for _, network := range privateNetworks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *sourceValidator) schemeAllowed(scheme string) bool {
+	allowedSchemes := splitList(v.cfg.AllowedSchemes)
+	if len(allowedSchemes) == 0 {
+		return true
+	}
+	for _, allowed := range allowedSchemes {
+		if scheme == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *sourceValidator) portAllowed(port string) bool {
+	allowedPorts := splitList(v.cfg.AllowedPorts)
+	if len(allowedPorts) == 0 {
+		return true
+	}
+	for _, allowed := range allowedPorts {
+		if port == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
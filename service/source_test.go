@@ -0,0 +1,335 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+)
+
+func TestSourceValidator(t *testing.T) {
+	defaultCfg := config.SourceValidation{
+		AllowedPorts:   "80,443",
+		AllowedSchemes: "http,https,s3,gs,ftp,sftp",
+		BlockedHosts:   "metadata.google.internal,metadata",
+	}
+	hosts := map[string][]net.IP{
+		"videos.example.com":   {net.ParseIP("93.184.216.34")},
+		"internal.example.com": {net.ParseIP("93.184.216.35"), net.ParseIP("10.0.12.5")},
+	}
+	var tests = []struct {
+		testCase string
+		cfg      *config.SourceValidation
+		source   string
+		errMsg   string
+	}{
+		{
+			"public host",
+			&defaultCfg,
+			"https://videos.example.com/video.mp4",
+			"",
+		},
+		{
+			"non-http source",
+			&defaultCfg,
+			"s3://some-bucket/video.mp4",
+			"",
+		},
+		{
+			"file source",
+			&defaultCfg,
+			"file:///etc/passwd",
+			`source media "file:///etc/passwd" uses a scheme that is not allowed`,
+		},
+		{
+			"multicast address",
+			&defaultCfg,
+			"http://239.255.255.250/video.mp4",
+			`source media "http://239.255.255.250/video.mp4" points to a private network`,
+		},
+		{
+			"reserved address",
+			&defaultCfg,
+			"http://240.0.0.1/video.mp4",
+			`source media "http://240.0.0.1/video.mp4" points to a private network`,
+		},
+		{
+			"metadata endpoint",
+			&defaultCfg,
+			"http://169.254.169.254/latest/meta-data/",
+			`source media "http://169.254.169.254/latest/meta-data/" points to a private network`,
+		},
+		{
+			"blocked host",
+			&defaultCfg,
+			"http://metadata.google.internal/computeMetadata/v1/",
+			`source media "http://metadata.google.internal/computeMetadata/v1/" points to a blocked host`,
+		},
+		{
+			"loopback IPv6",
+			&defaultCfg,
+			"http://[::1]/video.mp4",
+			`source media "http://[::1]/video.mp4" points to a private network`,
+		},
+		{
+			"host resolving to private address",
+			&defaultCfg,
+			"http://internal.example.com/video.mp4",
+			`source media "http://internal.example.com/video.mp4" points to a private network`,
+		},
+		{
+			"disallowed port",
+			&defaultCfg,
+			"http://videos.example.com:6379/video.mp4",
+			`source media "http://videos.example.com:6379/video.mp4" uses a port that is not allowed`,
+		},
+		{
+			"unresolvable host",
+			&defaultCfg,
+			"http://unknown.example.com/video.mp4",
+			`unable to resolve the host of the source media "http://unknown.example.com/video.mp4": no such host`,
+		},
+		{
+			"private networks allowed",
+			&config.SourceValidation{AllowPrivateNetworks: true},
+			"http://10.0.12.5:8080/video.mp4",
+			"",
+		},
+		{
+			"validation disabled",
+			&config.SourceValidation{Disabled: true},
+			"http://127.0.0.1:6379/",
+			"",
+		},
+		{
+			"no configuration",
+			nil,
+			"http://127.0.0.1:6379/",
+			"",
+		},
+	}
+	for _, test := range tests {
+		validator := newSourceValidator(test.cfg)
+		validator.lookupIP = func(host string) ([]net.IP, error) {
+			if ips, ok := hosts[host]; ok {
+				return ips, nil
+			}
+			return nil, errors.New("no such host")
+		}
+		err := validator.validate(test.source)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestSourceValidatorCallback(t *testing.T) {
+	cfg := config.SourceValidation{AllowedPorts: "80,443", AllowedSchemes: "http,https,s3"}
+	var tests = []struct {
+		testCase    string
+		callbackURL string
+		errMsg      string
+	}{
+		{"public host", "https://93.184.216.34/callback", ""},
+		{"non-http callback", "s3://some-bucket/callback", `callbackURL "s3://some-bucket/callback" uses a scheme that is not allowed`},
+		{"private network", "http://169.254.169.254/latest/meta-data", `callbackURL "http://169.254.169.254/latest/meta-data" points to a private network`},
+		{"port not allowed", "http://93.184.216.34:6379/", `callbackURL "http://93.184.216.34:6379/" uses a port that is not allowed`},
+	}
+	for _, test := range tests {
+		err := newSourceValidator(&cfg).validateCallback(test.callbackURL)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestSourceValidatorWatermark(t *testing.T) {
+	validator := newSourceValidator(&config.SourceValidation{AllowedPorts: "80,443"})
+	if err := validator.validateWatermark("https://93.184.216.34/logo.png"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	want := `watermark "http://10.0.12.5/logo.png" points to a private network`
+	if err := validator.validateWatermark("http://10.0.12.5/logo.png"); err == nil || err.Error() != want {
+		t.Errorf("wrong error. Want %q. Got %v", want, err)
+	}
+}
+
+func TestSourceValidatorClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "video")
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(serverURL.Host)
+	var tests = []struct {
+		testCase string
+		cfg      *config.SourceValidation
+		errMsg   string
+	}{
+		{
+			"host rebound to a private address",
+			&config.SourceValidation{},
+			"videos.example.com points to a private network",
+		},
+		{
+			"disallowed port",
+			&config.SourceValidation{AllowedPorts: "80,443"},
+			"connections to port " + port + " are not allowed",
+		},
+		{
+			"private networks allowed",
+			&config.SourceValidation{AllowPrivateNetworks: true},
+			"",
+		},
+	}
+	for _, test := range tests {
+		validator := newSourceValidator(test.cfg)
+		validator.lookupIP = func(host string) ([]net.IP, error) {
+			return []net.IP{net.ParseIP("127.0.0.1")}, nil
+		}
+		resp, err := validator.client.Get("http://videos.example.com:" + port + "/video.mp4")
+		if test.errMsg == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.testCase, err)
+				continue
+			}
+			resp.Body.Close()
+			continue
+		}
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("%s: unexpected <nil> error", test.testCase)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.errMsg) {
+			t.Errorf("%s: wrong error\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestSourceValidatorFFmpegInput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect.mp4":
+			http.Redirect(w, r, "/video.mp4", http.StatusFound)
+		case "/playlist":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-VERSION:3\n")
+		default:
+			http.ServeContent(w, r, "video.mp4", time.Time{}, strings.NewReader("video contents"))
+		}
+	}))
+	defer server.Close()
+	var tests = []struct {
+		testCase     string
+		cfg          *config.SourceValidation
+		source       string
+		wantInput    string
+		wantContents string
+		errMsg       string
+	}{
+		{"s3 source", &config.SourceValidation{}, "s3://some-bucket/video.mp4", "s3://some-bucket/video.mp4", "", ""},
+		{"http source", &config.SourceValidation{AllowPrivateNetworks: true}, server.URL + "/video.mp4", "", "video contents", ""},
+		{"redirect", &config.SourceValidation{AllowPrivateNetworks: true}, server.URL + "/redirect.mp4", "", "video contents", ""},
+		{"validation disabled", nil, server.URL + "/redirect.mp4", server.URL + "/video.mp4", "", ""},
+		{
+			"hls playlist",
+			&config.SourceValidation{AllowPrivateNetworks: true},
+			server.URL + "/playlist",
+			"",
+			"",
+			fmt.Sprintf("source media %q is an HLS playlist, which can't be analyzed", server.URL+"/playlist"),
+		},
+		{
+			"file source",
+			&config.SourceValidation{AllowPrivateNetworks: true},
+			"file:///etc/passwd",
+			"",
+			"",
+			`source media "file:///etc/passwd" can't be analyzed, only http(s) and s3 sources are supported`,
+		},
+	}
+	for _, test := range tests {
+		validator := newSourceValidator(test.cfg)
+		input, release, err := validator.ffmpegInput(test.source)
+		if test.errMsg != "" {
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("%s: wrong error message\nWant %q\nGot  %v", test.testCase, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testCase, err)
+			continue
+		}
+		if test.wantInput != "" && input != test.wantInput {
+			t.Errorf("%s: wrong input\nWant %q\nGot  %q", test.testCase, test.wantInput, input)
+		}
+		if test.wantContents != "" {
+			if !strings.HasPrefix(input, "http://127.0.0.1:") {
+				t.Errorf("%s: input %q isn't served by the proxy", test.testCase, input)
+			}
+			req, _ := http.NewRequest("GET", input, nil)
+			req.Header.Set("Range", "bytes=6-")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusPartialContent || string(contents) != test.wantContents[6:] {
+				t.Errorf("%s: wrong range of the source. Want 206 %q. Got %d %q", test.testCase, test.wantContents[6:], resp.StatusCode, contents)
+			}
+		}
+		release()
+		if test.wantContents != "" {
+			resp, err := http.Get(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("%s: wrong status of the released input. Want 404. Got %d", test.testCase, resp.StatusCode)
+			}
+		}
+	}
+}
+
+func TestSourceProxyRebinding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "video")
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(serverURL.Host)
+	validator := newSourceValidator(&config.SourceValidation{})
+	validator.lookupIP = func(host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("127.0.0.1")}, nil
+	}
+	input, release, err := validator.proxy.serve("http://videos.example.com:" + port + "/video.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	resp, err := http.Get(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("wrong status of a source rebound to a private network. Want 502. Got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route GET /sources/{checksum} sources getSource
+//
+// Finds the finished jobs that encoded the source media with the given
+// checksum, optionally filtered by ladder or presets. It's used for
+// checking whether a file was already encoded before submitting it again.
+//
+//     Responses:
+//       200: source
+//       400: invalidSource
+//       500: genericError
+func (s *TranscodingService) getSource(r *http.Request) swagger.GizmoJSONResponse {
+	var params getSourceInput
+	if err := params.loadParams(web.Vars(r), r.URL.Query()); err != nil {
+		return newInvalidSourceResponse(err)
+	}
+	presets := params.presets
+	if params.Ladder != "" {
+		ladder, err := s.db.GetLadder(params.Ladder)
+		if err != nil {
+			if err == db.ErrLadderNotFound {
+				return newInvalidSourceResponse(err)
+			}
+			return swagger.NewErrorResponse(err)
+		}
+		presets = append(presets, ladder.Presets...)
+	}
+	encodes, err := s.db.ListSourceEncodes(params.Checksum)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	result := source{Checksum: params.Checksum, Encodes: []db.SourceEncode{}}
+	for _, encode := range encodes {
+		if encodedWith(encode, presets) {
+			result.Encodes = append(result.Encodes, encode)
+		}
+	}
+	sort.Sort(byFinishedTime(result.Encodes))
+	result.Encoded = len(result.Encodes) > 0
+	return newSourceResponse(&result)
+}
+
+// indexSource adds the job to the source index when it finishes, so later
+// submissions of the same source can be detected. Jobs without a source
+// checksum and sandbox jobs aren't indexed.
+func (s *TranscodingService) indexSource(job *db.Job, status *provider.JobStatus) {
+	if job.SourceChecksum == "" || job.Environment == db.EnvironmentSandbox {
+		return
+	}
+	if status.Status != provider.StatusFinished && status.Status != provider.StatusFinishedWithWarnings {
+		return
+	}
+	encode := db.SourceEncode{
+		Checksum:     job.SourceChecksum,
+		JobID:        job.ID,
+		Ladder:       job.Ladder,
+		Provider:     job.ProviderName,
+		Destination:  status.Output.Destination,
+		FinishedTime: time.Now().UTC(),
+	}
+	for _, output := range job.Outputs {
+		encode.Presets = append(encode.Presets, output.Preset)
+	}
+	sort.Strings(encode.Presets)
+	for _, file := range status.Output.Files {
+		encode.Outputs = append(encode.Outputs, file.Path)
+	}
+	if err := s.db.SaveSourceEncode(&encode); err != nil {
+		s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to index the source of the job")
+	}
+}
+
+// encodedWith returns whether the encode produced all the given presetmaps.
+func encodedWith(encode db.SourceEncode, presets []string) bool {
+	encoded := make(map[string]bool, len(encode.Presets))
+	for _, preset := range encode.Presets {
+		encoded[preset] = true
+	}
+	for _, preset := range presets {
+		if !encoded[preset] {
+			return false
+		}
+	}
+	return true
+}
+
+// byFinishedTime sorts source encodes by the time their jobs finished,
+// newest first.
+type byFinishedTime []db.SourceEncode
+
+func (encodes byFinishedTime) Len() int      { return len(encodes) }
+func (encodes byFinishedTime) Swap(i, j int) { encodes[i], encodes[j] = encodes[j], encodes[i] }
+func (encodes byFinishedTime) Less(i, j int) bool {
+	return encodes[i].FinishedTime.After(encodes[j].FinishedTime)
+}
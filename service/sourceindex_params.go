@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+var sourceChecksumRegexp = regexp.MustCompile(`^[a-z0-9-]+:[A-Za-z0-9+/=_-]+$`)
+
+var errInvalidSourceChecksum = errors.New(`invalid source checksum, it must be in the format "algorithm:digest"`)
+
+// swagger:parameters getSource
+type getSourceInput struct {
+	// checksum of the source media, in the format "algorithm:digest" (for
+	// example, "sha256:9f86d0...")
+	//
+	// in: path
+	// required: true
+	Checksum string `json:"checksum"`
+
+	// name of a ladder. Only encodes that produced all the presetmaps of
+	// the ladder are included.
+	//
+	// in: query
+	Ladder string `json:"ladder"`
+
+	// comma-separated list of presetmaps. Only encodes that produced all
+	// the given presetmaps are included.
+	//
+	// in: query
+	Presets string `json:"presets"`
+
+	presets []string
+}
+
+func (p *getSourceInput) loadParams(vars map[string]string, query url.Values) error {
+	p.Checksum = vars["checksum"]
+	if !sourceChecksumRegexp.MatchString(p.Checksum) {
+		return errInvalidSourceChecksum
+	}
+	p.Ladder = query.Get("ladder")
+	p.Presets = query.Get("presets")
+	for _, preset := range strings.Split(p.Presets, ",") {
+		if preset = strings.TrimSpace(preset); preset != "" {
+			p.presets = append(p.presets, preset)
+		}
+	}
+	return nil
+}
+
+// Encodes of a source media, answering whether the source was already
+// encoded with the given ladder or presets.
+//
+// swagger:model
+type source struct {
+	// checksum of the source media
+	Checksum string `json:"checksum"`
+
+	// whether any finished job encoded the source with all the requested
+	// presetmaps
+	Encoded bool `json:"encoded"`
+
+	// finished jobs that encoded the source with all the requested
+	// presetmaps, newest first
+	Encodes []db.SourceEncode `json:"encodes"`
+}
+
+// JSON-encoded source returned on the getSource operation.
+//
+// swagger:response source
+type sourceResponse struct {
+	// in: body
+	Payload *source
+
+	baseResponse
+}
+
+// error returned when the given source checksum or filter is not valid.
+//
+// swagger:response invalidSource
+type invalidSourceResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newSourceResponse(s *source) *sourceResponse {
+	return &sourceResponse{
+		baseResponse: baseResponse{
+			payload: s,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newInvalidSourceResponse(err error) *invalidSourceResponse {
+	return &invalidSourceResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidSourceResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestSourceIndex(t *testing.T) {
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreateLadder(&db.Ladder{Name: "web", Presets: []string{"mp4_720p", "webm_1080p"}})
+	fakeDB.CreateLadder(&db.Ladder{Name: "archive", Presets: []string{"mp4_720p", "prores"}})
+	jobs := []db.Job{
+		{
+			ID:             "job-1",
+			ProviderName:   "fake",
+			ProviderJobID:  "provider-job-with-outputs",
+			SourceMedia:    "s3://mybucket/source.mov",
+			SourceChecksum: "sha256:9f86d081",
+			Ladder:         "web",
+			Outputs:        []db.TranscodeOutput{{Preset: "webm_1080p"}, {Preset: "mp4_720p"}},
+		},
+		{
+			ID:             "job-2",
+			ProviderName:   "fake",
+			ProviderJobID:  "provider-job-with-outputs",
+			SourceMedia:    "s3://mybucket/source.mov",
+			SourceChecksum: "sha256:9f86d081",
+			Environment:    db.EnvironmentSandbox,
+			Outputs:        []db.TranscodeOutput{{Preset: "prores"}},
+		},
+		{
+			ID:            "job-3",
+			ProviderName:  "fake",
+			ProviderJobID: "provider-job-with-outputs",
+			SourceMedia:   "s3://mybucket/source.mov",
+			Outputs:       []db.TranscodeOutput{{Preset: "prores"}},
+		},
+	}
+	for i := range jobs {
+		fakeDB.CreateJob(&jobs[i])
+	}
+	srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	for _, job := range jobs {
+		r, _ := http.NewRequest("GET", "/jobs/"+job.ID, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("wrong status code getting job %q: %d", job.ID, w.Code)
+		}
+	}
+	var tests = []struct {
+		givenTestCase string
+		givenURI      string
+
+		wantCode    int
+		wantEncoded bool
+		wantError   string
+	}{
+		{"no filter", "/sources/sha256:9f86d081", http.StatusOK, true, ""},
+		{"matching ladder", "/sources/sha256:9f86d081?ladder=web", http.StatusOK, true, ""},
+		{"matching presets", "/sources/sha256:9f86d081?presets=mp4_720p", http.StatusOK, true, ""},
+		{"missing preset of the ladder", "/sources/sha256:9f86d081?ladder=archive", http.StatusOK, false, ""},
+		{"missing preset", "/sources/sha256:9f86d081?presets=mp4_720p,prores", http.StatusOK, false, ""},
+		{"unknown source", "/sources/sha256:2c26b46b", http.StatusOK, false, ""},
+		{"unknown ladder", "/sources/sha256:9f86d081?ladder=tv", http.StatusBadRequest, false, "ladder not found"},
+		{
+			"invalid checksum",
+			"/sources/9f86d081",
+			http.StatusBadRequest,
+			false,
+			`invalid source checksum, it must be in the format "algorithm:digest"`,
+		},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", test.givenURI, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantError != "" {
+			var got map[string]interface{}
+			if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+			}
+			continue
+		}
+		var got source
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Encoded != test.wantEncoded {
+			t.Errorf("%s: wrong encoded. Want %v. Got %v", test.givenTestCase, test.wantEncoded, got.Encoded)
+		}
+		if !test.wantEncoded {
+			continue
+		}
+		if len(got.Encodes) != 1 {
+			t.Fatalf("%s: wrong number of encodes. Want 1. Got %d", test.givenTestCase, len(got.Encodes))
+		}
+		encode := got.Encodes[0]
+		if encode.FinishedTime.IsZero() {
+			t.Errorf("%s: finished time not set", test.givenTestCase)
+		}
+		encode.FinishedTime = time.Time{}
+		want := db.SourceEncode{
+			Checksum:    "sha256:9f86d081",
+			JobID:       "job-1",
+			Ladder:      "web",
+			Presets:     []string{"mp4_720p", "webm_1080p"},
+			Provider:    "fake",
+			Destination: "s3://mybucket/some/dir/job-1",
+			Outputs: []string{
+				"s3://mybucket/some/dir/job-1/video_720p.mp4",
+				"s3://mybucket/some/dir/job-1/video_1080p.webm",
+			},
+		}
+		if !reflect.DeepEqual(encode, want) {
+			t.Errorf("%s: wrong encode.\nWant %#v\nGot  %#v", test.givenTestCase, want, encode)
+		}
+	}
+}
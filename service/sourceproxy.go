@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// proxiedHeaders are the headers copied between ffmpeg and the sources read
+// through the sourceProxy, so ffmpeg can seek in sources with range
+// requests.
+var proxiedHeaders = []string{"Accept-Ranges", "Content-Length", "Content-Range", "Content-Type", "Last-Modified"}
+
+// sourceProxy serves sources to ffmpeg from a loopback address, reading
+// them through its client. ffmpeg resolves and connects to hosts on
+// its own, so handing it the URL of a source would let the host be rebound
+// to a private network after it was validated. Every connection of the
+// client is checked by the dialer of the sourceValidator instead. Sources
+// are read for as long as ffmpeg takes to analyze them, so only connecting
+// and waiting for the responses are limited.
+type sourceProxy struct {
+	client *http.Client
+
+	mtx     sync.Mutex
+	baseURL string
+	sources map[string]string
+}
+
+func newSourceProxy(dialContext func(ctx context.Context, network, address string) (net.Conn, error)) *sourceProxy {
+	return &sourceProxy{
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext:           dialContext,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: sourceFetchTimeout,
+			},
+		},
+		sources: make(map[string]string),
+	}
+}
+
+// serve makes the given source available to ffmpeg, returning the URL that
+// ffmpeg reads and a function that makes the URL unavailable again, called
+// once ffmpeg is done. The listener of the proxy is started on first use.
+func (p *sourceProxy) serve(source string) (string, func(), error) {
+	token := make([]byte, 16)
+	if err := readRandom(token); err != nil {
+		return "", nil, err
+	}
+	name := hex.EncodeToString(token)
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.baseURL == "" {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return "", nil, err
+		}
+		go http.Serve(listener, p)
+		p.baseURL = "http://" + listener.Addr().String() + "/"
+	}
+	p.sources[name] = source
+	release := func() {
+		p.mtx.Lock()
+		delete(p.sources, name)
+		p.mtx.Unlock()
+	}
+	return p.baseURL + name, release, nil
+}
+
+func (p *sourceProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mtx.Lock()
+	source, ok := p.sources[strings.TrimPrefix(r.URL.Path, "/")]
+	p.mtx.Unlock()
+	if !ok || (r.Method != "GET" && r.Method != "HEAD") {
+		http.NotFound(w, r)
+		return
+	}
+	req, err := http.NewRequest(r.Method, source, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for _, header := range proxiedHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+// RunJobSweeper periodically removes the index entries of expired jobs
+// from the database, until the given channel is closed. It returns
+// immediately when jobs don't expire, or when the database doesn't need
+// sweeping.
+func (s *TranscodingService) RunJobSweeper(stop <-chan struct{}) {
+	cfg := s.config.JobExpiration
+	if cfg == nil || cfg.TTL <= 0 || cfg.SweepInterval <= 0 {
+		return
+	}
+	sweeper, ok := s.db.(db.JobSweeper)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(cfg.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.sweepJobs(sweeper)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *TranscodingService) sweepJobs(sweeper db.JobSweeper) {
+	swept, err := sweeper.SweepExpiredJobs()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to sweep expired jobs")
+	}
+	if swept > 0 {
+		s.logger.WithField("jobs", swept).Info("swept expired jobs")
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+type sweepingRepository struct {
+	db.Repository
+	mtx    sync.Mutex
+	sweeps int
+}
+
+func (r *sweepingRepository) SweepExpiredJobs() (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.sweeps++
+	return 1, nil
+}
+
+func (r *sweepingRepository) sweepCount() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.sweeps
+}
+
+func TestRunJobSweeper(t *testing.T) {
+	var tests = []struct {
+		testCase   string
+		givenTTL   time.Duration
+		wantSweeps bool
+	}{
+		{"jobs expire", time.Hour, true},
+		{"jobs don't expire", 0, false},
+	}
+	for _, test := range tests {
+		cfg := config.Config{JobExpiration: &config.JobExpiration{TTL: test.givenTTL, SweepInterval: 10 * time.Millisecond}}
+		service, err := NewTranscodingService(&cfg, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		repo := &sweepingRepository{Repository: dbtest.NewFakeRepository(false)}
+		service.db = repo
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			service.RunJobSweeper(stop)
+			close(done)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		close(stop)
+		<-done
+		if sweeps := repo.sweepCount() > 0; sweeps != test.wantSweeps {
+			t.Errorf("%s: wrong sweeps. Want %v. Got %d sweeps", test.testCase, test.wantSweeps, repo.sweepCount())
+		}
+	}
+}
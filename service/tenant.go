@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// swagger:route POST /tenants tenants newTenant
+//
+// Creates a new tenant in the API.
+//
+//     Responses:
+//       200: tenant
+//       400: invalidTenant
+//       409: tenantAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newTenant(r *http.Request) swagger.GizmoJSONResponse {
+	var input newTenantInput
+	defer r.Body.Close()
+	tenant, err := input.Tenant(r.Body)
+	if err != nil {
+		return newInvalidTenantResponse(err)
+	}
+	err = s.db.CreateTenant(&tenant)
+	switch err {
+	case nil:
+		return newTenantResponse(&tenant)
+	case db.ErrTenantAlreadyExists:
+		return newTenantAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /tenants/{name} tenants getTenant
+//
+// Finds a tenant using its name.
+//
+//     Responses:
+//       200: tenant
+//       404: tenantNotFound
+//       500: genericError
+func (s *TranscodingService) getTenant(r *http.Request) swagger.GizmoJSONResponse {
+	var params getTenantInput
+	params.loadParams(web.Vars(r))
+	tenant, err := s.db.GetTenant(params.Name)
+	switch err {
+	case nil:
+		return newTenantResponse(tenant)
+	case db.ErrTenantNotFound:
+		return newTenantNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route PUT /tenants/{name} tenants updateTenant
+//
+// Updates the defaults of a tenant using its name.
+//
+//     Responses:
+//       200: tenant
+//       400: invalidTenant
+//       404: tenantNotFound
+//       500: genericError
+func (s *TranscodingService) updateTenant(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input updateTenantInput
+	tenant, err := input.Tenant(web.Vars(r), r.Body)
+	if err != nil {
+		return newInvalidTenantResponse(err)
+	}
+	err = s.db.UpdateTenant(&tenant)
+	switch err {
+	case nil:
+		updatedTenant, _ := s.db.GetTenant(tenant.Name)
+		return newTenantResponse(updatedTenant)
+	case db.ErrTenantNotFound:
+		return newTenantNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route DELETE /tenants/{name} tenants deleteTenant
+//
+// Deletes a tenant by name.
+//
+//     Responses:
+//       200: emptyResponse
+//       404: tenantNotFound
+//       500: genericError
+func (s *TranscodingService) deleteTenant(r *http.Request) swagger.GizmoJSONResponse {
+	var params getTenantInput
+	params.loadParams(web.Vars(r))
+	err := s.db.DeleteTenant(&db.Tenant{Name: params.Name})
+	switch err {
+	case nil:
+		return emptyResponse(http.StatusOK)
+	case db.ErrTenantNotFound:
+		return newTenantNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /tenants tenants listTenants
+//
+// List tenants registered in the API.
+//
+//     Responses:
+//       200: listTenants
+//       500: genericError
+func (s *TranscodingService) listTenants(r *http.Request) swagger.GizmoJSONResponse {
+	tenants, err := s.db.ListTenants()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListTenantsResponse(tenants)
+}
+
+// errTenantRequired is the error returned for jobs that don't name a tenant
+// once tenants are configured, as they would skip the allow-lists of the
+// tenants.
+var errTenantRequired = errors.New("tenant is required")
+
+// tenantMismatchError is the error returned for jobs naming a tenant other
+// than the one of the caller.
+type tenantMismatchError struct {
+	tenant string
+	caller string
+}
+
+func (e tenantMismatchError) Error() string {
+	return fmt.Sprintf("tenant %q doesn't match the tenant of the caller (%q)", e.tenant, e.caller)
+}
+
+// internalJobKey marks the context of requests created by the workers of
+// the API (see internalJobRequest).
+type internalJobKey struct{}
+
+// jobTenant returns the name of the tenant of a new job. Behind an
+// authenticating proxy (TenantHeader), the tenant is the one of the
+// caller. Otherwise it's the tenant named in the job, which is required
+// once tenants are configured, except in jobs created by the workers of
+// the API (like re-encodes of jobs that predate the tenants).
+func (s *TranscodingService) jobTenant(r *http.Request, name string) (string, error) {
+	internal, _ := r.Context().Value(internalJobKey{}).(bool)
+	if header := s.config.TenantHeader; header != "" && !internal {
+		caller := r.Header.Get(header)
+		if name != "" && name != caller {
+			return "", tenantMismatchError{tenant: name, caller: caller}
+		}
+		name = caller
+	}
+	if name != "" || internal {
+		return name, nil
+	}
+	tenants, err := s.db.ListTenants()
+	if err != nil {
+		return "", err
+	}
+	if len(tenants) > 0 {
+		return "", errTenantRequired
+	}
+	return "", nil
+}
+
+// internalJobRequest returns a request for creating a job from the workers
+// of the API (like watch folders and campaigns), where the tenant is the
+// one named in the job.
+func internalJobRequest(data []byte) (*http.Request, error) {
+	r, err := http.NewRequest("POST", "/jobs", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Prefer", "respond-async")
+	return r.WithContext(context.WithValue(r.Context(), internalJobKey{}, true)), nil
+}
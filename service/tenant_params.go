@@ -0,0 +1,173 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/NYTimes/video-transcoding-api/callback"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// JSON-encoded tenant returned on the newTenant, getTenant and updateTenant
+// operations.
+//
+// swagger:response tenant
+type tenantResponse struct {
+	// in: body
+	Payload *db.Tenant
+
+	baseResponse
+}
+
+// swagger:parameters getTenant deleteTenant
+type getTenantInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+}
+
+// swagger:parameters newTenant
+type newTenantInput struct {
+	// in: body
+	// required: true
+	Payload db.Tenant
+}
+
+// swagger:parameters updateTenant
+type updateTenantInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+
+	// in: body
+	// required: true
+	Payload db.Tenant
+}
+
+// error returned when the given tenant name is not found on the API.
+//
+// swagger:response tenantNotFound
+type tenantNotFoundResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given tenant data is not valid.
+//
+// swagger:response invalidTenant
+type invalidTenantResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to create a new tenant using a name that is
+// already in-use.
+//
+// swagger:response tenantAlreadyExists
+type tenantAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// response for the listTenants operation. It's a JSON-encoded object in the
+// format `tenantName: tenantObject`
+//
+// swagger:response listTenants
+type listTenantsResponse struct {
+	// in: body
+	Tenants map[string]db.Tenant
+
+	baseResponse
+}
+
+func newTenantResponse(tenant *db.Tenant) *tenantResponse {
+	return &tenantResponse{
+		baseResponse: baseResponse{
+			payload: tenant,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newTenantNotFoundResponse(err error) *tenantNotFoundResponse {
+	return &tenantNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
+}
+
+func (r *tenantNotFoundResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newInvalidTenantResponse(err error) *invalidTenantResponse {
+	return &invalidTenantResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidTenantResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newTenantAlreadyExistsResponse(err error) *tenantAlreadyExistsResponse {
+	return &tenantAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *tenantAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newListTenantsResponse(tenants []db.Tenant) *listTenantsResponse {
+	tenantMap := make(map[string]db.Tenant, len(tenants))
+	for _, tenant := range tenants {
+		tenantMap[tenant.Name] = tenant
+	}
+	return &listTenantsResponse{
+		baseResponse: baseResponse{
+			status:  http.StatusOK,
+			payload: tenantMap,
+		},
+	}
+}
+
+// Tenant loads the input from the request body, validates it and returns the
+// tenant.
+func (p *newTenantInput) Tenant(body io.Reader) (db.Tenant, error) {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	return p.Payload, validateTenant(&p.Payload)
+}
+
+func (p *getTenantInput) loadParams(paramsMap map[string]string) {
+	p.Name = paramsMap["name"]
+}
+
+// Tenant loads the input from the request path and body, validates it and
+// returns the tenant.
+func (p *updateTenantInput) Tenant(paramsMap map[string]string, body io.Reader) (db.Tenant, error) {
+	p.Name = paramsMap["name"]
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	p.Payload.Name = p.Name
+	return p.Payload, validateTenant(&p.Payload)
+}
+
+func validateTenant(t *db.Tenant) error {
+	if t.Name == "" {
+		return errors.New("missing field name from the request")
+	}
+	if t.CallbackPublicKey != "" {
+		if _, err := callback.ParsePublicKey(t.CallbackPublicKey); err != nil {
+			return err
+		}
+	}
+	for i := range t.NamingRules {
+		if err := t.NamingRules[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return validateFilters(t.Defaults.Filters)
+}
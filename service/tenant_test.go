@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewTenant(t *testing.T) {
+	tests := []struct {
+		givenTestCase       string
+		givenRequestData    map[string]interface{}
+		givenTriggerDBError bool
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"New tenant",
+			map[string]interface{}{
+				"name": "newsroom",
+				"defaults": map[string]interface{}{
+					"provider":    "fake",
+					"destination": "s3://newsroom-bucket/videos/",
+					"ladder":      []string{"mp4_720p", "mp4_1080p"},
+				},
+			},
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{
+				"name": "newsroom",
+				"defaults": map[string]interface{}{
+					"provider":    "fake",
+					"destination": "s3://newsroom-bucket/videos/",
+					"ladder":      []interface{}{"mp4_720p", "mp4_1080p"},
+					"streamingParams": map[string]interface{}{
+						"segmentDuration": float64(0),
+						"protocol":        "",
+					},
+				},
+			},
+		},
+		{
+			"New tenant duplicate name",
+			map[string]interface{}{"name": "cooking"},
+			false,
+
+			http.StatusConflict,
+			map[string]interface{}{"error": db.ErrTenantAlreadyExists.Error()},
+		},
+		{
+			"New tenant missing name",
+			map[string]interface{}{"defaults": map[string]interface{}{"provider": "fake"}},
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "missing field name from the request"},
+		},
+		{
+			"New tenant invalid callback public key",
+			map[string]interface{}{"name": "newsroom", "callbackPublicKey": "not a key"},
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "invalid public key: no PEM data found"},
+		},
+		{
+			"New tenant invalid filters",
+			map[string]interface{}{
+				"name":     "newsroom",
+				"defaults": map[string]interface{}{"filters": map[string]interface{}{"deinterlace": "always"}},
+			},
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid deinterlace mode "always"`},
+		},
+		{
+			"New tenant DB failure",
+			map[string]interface{}{"name": "newsroom"},
+			true,
+
+			http.StatusInternalServerError,
+			map[string]interface{}{"error": "database error"},
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(test.givenTriggerDBError)
+		fakeDB.CreateTenant(&db.Tenant{Name: "cooking"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		body, _ := json.Marshal(test.givenRequestData)
+		r, _ := http.NewRequest("POST", "/tenants", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		if test.wantCode == http.StatusOK {
+			if _, err := fakeDB.GetTenant(got["name"].(string)); err != nil {
+				t.Errorf("%s: didn't save the tenant in the database: %s", test.givenTestCase, err)
+			}
+		}
+	}
+}
+
+func TestGetTenant(t *testing.T) {
+	tests := []struct {
+		givenTestCase   string
+		givenTenantName string
+
+		wantBody *db.Tenant
+		wantCode int
+	}{
+		{
+			"Get tenant",
+			"newsroom",
+			&db.Tenant{Name: "newsroom", Defaults: db.JobDefaults{Provider: "fake"}},
+			http.StatusOK,
+		},
+		{
+			"Get tenant not found",
+			"cooking",
+			nil,
+			http.StatusNotFound,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateTenant(&db.Tenant{Name: "newsroom", Defaults: db.JobDefaults{Provider: "fake"}})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("GET", "/tenants/"+test.givenTenantName, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantBody != nil {
+			var gotTenant db.Tenant
+			err := json.NewDecoder(w.Body).Decode(&gotTenant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotTenant, *test.wantBody) {
+				t.Errorf("%s: wrong body. Want %#v. Got %#v", test.givenTestCase, *test.wantBody, gotTenant)
+			}
+		}
+	}
+}
+
+func TestUpdateTenant(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenTenantName  string
+		givenRequestData map[string]interface{}
+
+		wantBody *db.Tenant
+		wantCode int
+	}{
+		{
+			"Update tenant",
+			"newsroom",
+			map[string]interface{}{
+				"defaults": map[string]interface{}{"provider": "zencoder", "callbackURL": "https://newsroom.example.com/callback"},
+			},
+			&db.Tenant{
+				Name:     "newsroom",
+				Defaults: db.JobDefaults{Provider: "zencoder", CallbackURL: "https://newsroom.example.com/callback"},
+			},
+			http.StatusOK,
+		},
+		{
+			"Update tenant not found",
+			"cooking",
+			map[string]interface{}{"defaults": map[string]interface{}{"provider": "zencoder"}},
+			nil,
+			http.StatusNotFound,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateTenant(&db.Tenant{Name: "newsroom", Defaults: db.JobDefaults{Provider: "fake"}})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		data, _ := json.Marshal(test.givenRequestData)
+		r, _ := http.NewRequest("PUT", "/tenants/"+test.givenTenantName, bytes.NewReader(data))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantBody != nil {
+			var gotTenant db.Tenant
+			err := json.NewDecoder(w.Body).Decode(&gotTenant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotTenant, *test.wantBody) {
+				t.Errorf("%s: wrong body. Want %#v. Got %#v", test.givenTestCase, *test.wantBody, gotTenant)
+			}
+		}
+	}
+}
+
+func TestDeleteTenant(t *testing.T) {
+	tests := []struct {
+		givenTestCase   string
+		givenTenantName string
+		wantCode        int
+	}{
+		{
+			"Delete tenant",
+			"newsroom",
+			http.StatusOK,
+		},
+		{
+			"Delete tenant not found",
+			"cooking",
+			http.StatusNotFound,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateTenant(&db.Tenant{Name: "newsroom"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("DELETE", "/tenants/"+test.givenTenantName, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantCode == http.StatusOK {
+			_, err := fakeDB.GetTenant(test.givenTenantName)
+			if err != db.ErrTenantNotFound {
+				t.Errorf("%s: didn't delete the tenant in the database", test.givenTestCase)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+func (p *ThumbnailsParams) validate() error {
+	if p.Interval == 0 && p.Count == 0 {
+		return errors.New("thumbnails require an interval or a count")
+	}
+	if p.Interval > 0 && p.Count > 0 {
+		return errors.New("thumbnails interval and count can't be combined")
+	}
+	switch strings.ToLower(p.Format) {
+	case "", "jpg", "png":
+		return nil
+	}
+	return fmt.Errorf("invalid thumbnail format %q", p.Format)
+}
+
+// thumbnails returns the thumbnail settings recorded in new jobs.
+func (p *ThumbnailsParams) thumbnails() *db.Thumbnails {
+	if p == nil {
+		return nil
+	}
+	return &db.Thumbnails{
+		Interval: p.Interval,
+		Count:    p.Count,
+		Width:    p.Width,
+		Height:   p.Height,
+		Format:   strings.ToLower(p.Format),
+	}
+}
+
+// providerThumbnails returns the thumbnail settings sent to providers.
+func providerThumbnails(thumbnails *db.Thumbnails) *provider.Thumbnails {
+	if thumbnails == nil {
+		return nil
+	}
+	return &provider.Thumbnails{
+		Interval: thumbnails.Interval,
+		Count:    thumbnails.Count,
+		Width:    thumbnails.Width,
+		Height:   thumbnails.Height,
+		Format:   thumbnails.Format,
+	}
+}
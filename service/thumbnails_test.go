@@ -0,0 +1,34 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+)
+
+func TestThumbnails(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		params   *ThumbnailsParams
+		expected *db.Thumbnails
+	}{
+		{"no thumbnails", nil, nil},
+		{
+			"interval",
+			&ThumbnailsParams{Interval: 10, Width: 320, Format: "PNG"},
+			&db.Thumbnails{Interval: 10, Width: 320, Format: "png"},
+		},
+		{
+			"count in the format of the provider",
+			&ThumbnailsParams{Count: 5},
+			&db.Thumbnails{Count: 5},
+		},
+	}
+	for _, test := range tests {
+		thumbnails := test.params.thumbnails()
+		if !reflect.DeepEqual(thumbnails, test.expected) {
+			t.Errorf("%s: wrong thumbnails. Want %#v. Got %#v", test.testCase, test.expected, thumbnails)
+		}
+	}
+}
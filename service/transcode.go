@@ -1,12 +1,12 @@
 package service
 
 import (
-	"crypto/rand"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/NYTimes/gizmo/web"
 	"github.com/NYTimes/video-transcoding-api/db"
@@ -20,16 +20,188 @@ import (
 //
 //     Responses:
 //       200: job
+//       202: job
 //       400: invalidJob
+//       403: jobRejected
+//       409: externalIDConflict
 //       500: genericError
+//       503: serviceOverloaded
 func (s *TranscodingService) newTranscodeJob(r *http.Request) swagger.GizmoJSONResponse {
 	defer r.Body.Close()
 	var input newTranscodeJobInput
-	providerFactory, err := input.ProviderFactory(r.Body)
+	err := input.loadParams(r.Body)
 	if err != nil {
 		return newInvalidJobResponse(err)
 	}
-	providerObj, err := providerFactory(s.config)
+	if input.Payload.Ladder != "" {
+		ladder, ladderErr := s.db.GetLadder(input.Payload.Ladder)
+		if ladderErr != nil {
+			if ladderErr == db.ErrLadderNotFound {
+				return newInvalidJobResponse(ladderErr)
+			}
+			return swagger.NewErrorResponse(ladderErr)
+		}
+		if err = input.applyLadder(*ladder); err != nil {
+			return newInvalidJobResponse(err)
+		}
+	}
+	input.Payload.Tenant, err = s.jobTenant(r, input.Payload.Tenant)
+	if err != nil {
+		if _, ok := err.(tenantMismatchError); ok {
+			return newJobRejectedResponse(err)
+		}
+		if err == errTenantRequired {
+			return newInvalidJobResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	var tenant *db.Tenant
+	if input.Payload.Tenant != "" {
+		tenant, err = s.db.GetTenant(input.Payload.Tenant)
+		if err != nil {
+			if err == db.ErrTenantNotFound {
+				return newInvalidJobResponse(err)
+			}
+			return swagger.NewErrorResponse(err)
+		}
+		input.applyDefaults(tenant.Defaults)
+	}
+	var variant *db.ExperimentVariant
+	if input.Payload.Experiment != "" {
+		experiment, experimentErr := s.db.GetExperiment(input.Payload.Experiment)
+		if experimentErr != nil {
+			if experimentErr == db.ErrExperimentNotFound {
+				return newInvalidJobResponse(experimentErr)
+			}
+			return swagger.NewErrorResponse(experimentErr)
+		}
+		variant = s.experiments.assign(experiment)
+		if variant != nil {
+			input.applyVariant(*variant)
+		}
+	}
+	var origin db.DeliveryOrigin
+	if input.Payload.DeliveryTarget != "" {
+		if input.Payload.Destination != "" {
+			return newInvalidJobResponse(errors.New("destination and deliveryTarget can't be used together"))
+		}
+		target, targetErr := s.db.GetDeliveryTarget(input.Payload.DeliveryTarget)
+		if targetErr != nil {
+			if targetErr == db.ErrDeliveryTargetNotFound {
+				return newInvalidJobResponse(targetErr)
+			}
+			return swagger.NewErrorResponse(targetErr)
+		}
+		origin, err = target.Origin(s.config.DeliveryEnvironment)
+		if err != nil {
+			return newInvalidJobResponse(err)
+		}
+		input.Payload.Destination = origin.Destination
+	}
+	if err = s.preProcess(&input.Payload); err != nil {
+		if _, ok := err.(jobRejectedError); ok {
+			return newJobRejectedResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	if input.Payload.Deadline != nil {
+		if err = s.routeDeadline(&input.Payload); err != nil {
+			return newInvalidJobResponse(err)
+		}
+	}
+	providerFactory, err := input.ProviderFactory()
+	if err != nil {
+		return newInvalidJobResponse(err)
+	}
+	sources := append([]string{input.Payload.Source}, input.Payload.FallbackSources...)
+	if input.Payload.DescribedAudio != nil {
+		sources = append(sources, input.Payload.DescribedAudio.Source)
+	}
+	for _, source := range sources {
+		if err = s.sources.validate(source); err != nil {
+			return newInvalidJobResponse(err)
+		}
+		if tenant != nil {
+			if err = tenant.ValidateSource(source); err != nil {
+				return newInvalidJobResponse(err)
+			}
+		}
+	}
+	for _, caption := range input.Payload.Captions {
+		if err = s.sources.validateCaption(caption.Source); err != nil {
+			return newInvalidJobResponse(err)
+		}
+		if tenant != nil {
+			if err = tenant.ValidateSource(caption.Source); err != nil {
+				return newInvalidJobResponse(err)
+			}
+		}
+	}
+	for _, output := range input.Payload.Outputs {
+		if output.Preview == nil || output.Preview.Watermark == nil {
+			continue
+		}
+		if err = s.sources.validateWatermark(output.Preview.Watermark.URL); err != nil {
+			return newInvalidJobResponse(err)
+		}
+		if tenant != nil {
+			if err = tenant.ValidateSource(output.Preview.Watermark.URL); err != nil {
+				return newInvalidJobResponse(err)
+			}
+		}
+	}
+	if input.Payload.CallbackURL != "" {
+		if err = s.sources.validateCallback(input.Payload.CallbackURL); err != nil {
+			return newInvalidJobResponse(err)
+		}
+	}
+	if tenant != nil {
+		if err = tenant.ValidateDestination(input.Payload.Destination); err != nil {
+			return newInvalidJobResponse(err)
+		}
+	}
+	environment, err := parseEnvironment(input.Payload.Environment)
+	if err != nil {
+		return newInvalidJobResponse(err)
+	}
+	if environment == db.EnvironmentSandbox {
+		if err = s.checkSandboxDestination(input.Payload.Destination); err != nil {
+			return newInvalidJobResponse(err)
+		}
+	}
+	if err = s.checkExternalID(tenant, input.Payload.ExternalID); err != nil {
+		if _, ok := err.(externalIDConflictError); ok {
+			return newExternalIDConflictResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	var uploadDestination string
+	if isTransferDestination(input.Payload.Destination) {
+		uploadDestination = input.Payload.Destination
+		input.Payload.Destination, err = s.uploader.stagingDestination(uploadDestination)
+		if err != nil {
+			return newInvalidJobResponse(err)
+		}
+	}
+	if input.Payload.OutputEncryption != nil {
+		if uploadDestination != "" {
+			return newInvalidJobResponse(errors.New("encrypted outputs can't be delivered to transfer destinations"))
+		}
+		if err = s.uploader.encryption.check(input.Payload.Destination); err != nil {
+			return newInvalidJobResponse(err)
+		}
+		uploadDestination = input.Payload.Destination
+		input.Payload.Destination = s.uploader.encryption.stagingDestination(uploadDestination)
+	}
+	if input.Payload.Publish {
+		if uploadDestination != "" {
+			return newInvalidJobResponse(errors.New("publish can't be combined with transfer destinations or encrypted outputs"))
+		}
+		if _, _, err = splitS3URL(input.Payload.Destination); err != nil {
+			return newInvalidJobResponse(errPublishDestination)
+		}
+	}
+	providerObj, err := providerFactory(s.providerConfig(environment))
 	if err != nil {
 		formattedErr := fmt.Errorf("Error initializing provider %s for new job: %v %s", input.Payload.Provider, providerObj, err)
 		if _, ok := err.(provider.InvalidConfigError); ok {
@@ -37,79 +209,283 @@ func (s *TranscodingService) newTranscodeJob(r *http.Request) swagger.GizmoJSONR
 		}
 		return swagger.NewErrorResponse(formattedErr)
 	}
+	if reporter, ok := providerObj.(provider.UsageReporter); ok {
+		usage, usageErr := reporter.AccountUsage()
+		if usageErr == nil && usage.NearExhaustion {
+			return newProviderUnavailableResponse(fmt.Errorf("the account of provider %q is near exhaustion (%d minutes remaining)", input.Payload.Provider, usage.MinutesRemaining))
+		}
+	}
+	drm, drmKey := input.Payload.DRM.drm()
+	jobCaptions, err := captions(input.Payload.Captions, input.Payload.Language)
+	if err != nil {
+		return newInvalidJobResponse(err)
+	}
+	thumbnails := input.Payload.Thumbnails.thumbnails()
 	transcodeProfile := provider.TranscodeProfile{
 		SourceMedia:     input.Payload.Source,
 		StreamingParams: input.Payload.StreamingParams,
+		DRM:             drmParams(drm, drmKey),
+		Captions:        providerCaptions(jobCaptions),
+		Thumbnails:      providerThumbnails(thumbnails),
+		Clip:            providerClip(input.Payload.clip()),
+		DescribedAudio:  input.describedAudio(),
+		AudioTracks:     input.audioTracks(),
+	}
+	if input.Payload.Conform != nil {
+		transcodeProfile.Conform, err = input.Payload.Conform.resolve()
+		if err != nil {
+			return newInvalidJobResponse(err)
+		}
 	}
 	outputs := make([]provider.TranscodeOutput, len(input.Payload.Outputs))
+	jobOutputs := make([]db.TranscodeOutput, len(input.Payload.Outputs))
+	presetMaps := make([]*db.PresetMap, len(input.Payload.Outputs))
 	for i, output := range input.Payload.Outputs {
-		presetMap, presetErr := s.db.GetPresetMap(output.Preset)
+		presetMap, presetVersion, presetErr := s.resolvePresetMap(output.Preset, output.PresetVersion)
 		if presetErr != nil {
-			if presetErr == db.ErrPresetMapNotFound {
+			if _, ok := presetErr.(presetVersionNotFoundError); ok || presetErr == db.ErrPresetMapNotFound {
 				return newInvalidJobResponse(presetErr)
 			}
 			return swagger.NewErrorResponse(presetErr)
 		}
+		presetMaps[i] = presetMap
 		fileName := output.FileName
 		if fileName == "" {
 			fileName = s.defaultFileName(input.Payload.Source, presetMap)
 		}
-		outputs[i] = provider.TranscodeOutput{FileName: fileName, Preset: *presetMap}
+		language := input.outputLanguage(output)
+		fileName = expandFileName(fileName, language, output.Variant)
+		if tenant != nil {
+			tokens := db.FileNameTokens{
+				Source:    sourceName(input.Payload.Source),
+				Preset:    presetMap.Name,
+				Extension: presetMap.OutputOpts.Extension,
+				Language:  expandFileName("{lang}", language, ""),
+				Variant:   expandFileName("{variant}", "", output.Variant),
+			}
+			if err = tenant.ValidateFileName(fileName, tokens); err != nil {
+				return newInvalidJobResponse(err)
+			}
+		}
+		outputPreview, previewErr := preview(output.Preview, presetMap)
+		if previewErr != nil {
+			return newInvalidJobResponse(previewErr)
+		}
+		outputs[i] = provider.TranscodeOutput{FileName: fileName, Preset: *presetMap, Preview: outputPreview}
+		jobOutputs[i] = db.TranscodeOutput{
+			FileName:      fileName,
+			Preset:        output.Preset,
+			PresetVersion: presetVersion,
+			Language:      language,
+			Variant:       output.Variant,
+			Preview:       outputPreview,
+		}
 	}
 	transcodeProfile.Outputs = outputs
-	jobID, err := s.genID()
-	if err != nil {
+	request := policyRequest{
+		Tenant:   input.Payload.Tenant,
+		Labels:   input.Payload.Labels,
+		Provider: input.Payload.Provider,
+		DRM:      drm != nil,
+	}
+	if err = s.checkPolicy(request, providerObj, presetMaps); err != nil {
+		if _, ok := err.(policyViolationError); ok {
+			return newJobRejectedResponse(err)
+		}
 		return swagger.NewErrorResponse(err)
 	}
-	if transcodeProfile.StreamingParams.Protocol == "hls" {
+	if playlist, ok := defaultPlaylistFileNames[transcodeProfile.StreamingParams.Protocol]; ok {
 		if transcodeProfile.StreamingParams.PlaylistFileName == "" {
-			transcodeProfile.StreamingParams.PlaylistFileName = "hls/index.m3u8"
+			transcodeProfile.StreamingParams.PlaylistFileName = playlist
 		}
 		if transcodeProfile.StreamingParams.SegmentDuration == 0 {
 			transcodeProfile.StreamingParams.SegmentDuration = s.config.DefaultSegmentDuration
 		}
 	}
-	job := db.Job{ID: jobID}
-	jobStatus, err := providerObj.Transcode(&job, transcodeProfile)
-	if err == provider.ErrPresetMapNotFound {
+	if len(input.Payload.OutputHeaders) > 0 {
+		if uploadDestination == "" {
+			applyOutputHeaders(&transcodeProfile, input.Payload.OutputHeaders)
+		} else if input.Payload.OutputEncryption == nil {
+			if err = s.uploader.checkUploadHeaders(uploadDestination); err != nil {
+				return newInvalidJobResponse(err)
+			}
+		}
+	}
+	requirements := s.jobRequirements(transcodeProfile)
+	requirements.Trim = input.Payload.Trim != nil
+	requirements.Rotation = input.Payload.rotation() != nil
+	requirements.NoAutorotation = input.Payload.rotation().Disabled()
+	if err = providerObj.Capabilities().Check(input.Payload.Provider, requirements); err != nil {
+		return newInvalidJobResponse(err)
+	}
+	if err = s.decrypter.check(input.Payload.SourceEncryption, providerObj); err != nil {
 		return newInvalidJobResponse(err)
 	}
+	jobCaptions, err = s.captionConverter.convert(jobCaptions, input.Payload.Captions, providerObj.Capabilities().CaptionFormats, input.Payload.Destination)
 	if err != nil {
-		providerError := fmt.Errorf("Error with provider %q: %s", input.Payload.Provider, err)
-		return swagger.NewErrorResponse(providerError)
+		return newInvalidJobResponse(err)
+	}
+	transcodeProfile.Captions = providerCaptions(jobCaptions)
+	jobID, err := s.jobIDs.generate(input.Payload.Tenant)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	job := db.Job{
+		ID:                jobID,
+		Tenant:            input.Payload.Tenant,
+		ExternalID:        input.Payload.ExternalID,
+		Labels:            input.Payload.Labels,
+		Environment:       environment,
+		Priority:          input.Payload.Priority,
+		SourceMedia:       input.Payload.Source,
+		SourceChecksum:    input.Payload.SourceChecksum,
+		FallbackSources:   input.Payload.FallbackSources,
+		SourceEncryption:  input.Payload.SourceEncryption,
+		DRM:               drm,
+		DRMKey:            drmKey,
+		Captions:          jobCaptions,
+		Thumbnails:        thumbnails,
+		OutputHeaders:     input.Payload.OutputHeaders,
+		Conform:           transcodeProfile.Conform,
+		Trim:              input.Payload.Trim.trim(),
+		Clip:              input.Payload.clip(),
+		DescribedAudio:    transcodeProfile.DescribedAudio,
+		AudioTracks:       transcodeProfile.AudioTracks,
+		Destination:       input.Payload.Destination,
+		CallbackURL:       input.Payload.CallbackURL,
+		NotifyOn:          input.Payload.NotifyOn,
+		CallbackPayload:   input.Payload.CallbackPayload,
+		Language:          input.Payload.Language,
+		Filters:           input.Payload.Filters,
+		Rotation:          input.Payload.rotation(),
+		Outputs:           jobOutputs,
+		DeliveryTarget:    input.Payload.DeliveryTarget,
+		Ladder:            input.Payload.Ladder,
+		CDNBaseURL:        origin.CDNBaseURL,
+		UploadDestination: uploadDestination,
+		Fingerprint:       input.Payload.Fingerprint,
+		AudioQC:           input.Payload.AudioQC.audioQC(),
+		Photosensitivity:  input.Payload.Photosensitivity.check(),
+		VideoQC:           input.Payload.VideoQC.videoQC(),
+		Poster:            input.Payload.Poster.poster(),
+	}
+	if input.Payload.OutputEncryption != nil {
+		job.OutputEncryption, err = s.uploader.encryption.newKey(jobID, input.Payload.OutputEncryption.EmbargoUntil)
+		if err != nil {
+			return swagger.NewErrorResponse(err)
+		}
+	}
+	if input.Payload.Publish {
+		job.Publish = &db.Publish{Destination: job.Destination}
+		job.Destination, err = s.publisher.stagingDestination(jobID, job.Destination)
+		if err != nil {
+			return newInvalidJobResponse(err)
+		}
+	}
+	if variant != nil {
+		job.Experiment = input.Payload.Experiment
+		job.ExperimentVariant = variant.Name
+	}
+	job.ProviderName = input.Payload.Provider
+	if input.Payload.Deadline != nil {
+		job.Deadline = &db.Deadline{Time: input.Payload.Deadline.UTC(), SubmissionTime: s.predictor.now().UTC()}
 	}
-	jobStatus.ProviderName = input.Payload.Provider
-	job.ProviderName = jobStatus.ProviderName
-	job.ProviderJobID = jobStatus.ProviderJobID
 	if transcodeProfile.StreamingParams.Protocol != "" {
 		job.StreamingParams = db.StreamingParams{
-			SegmentDuration: transcodeProfile.StreamingParams.SegmentDuration,
-			Protocol:        transcodeProfile.StreamingParams.Protocol,
+			SegmentDuration:    transcodeProfile.StreamingParams.SegmentDuration,
+			Protocol:           string(transcodeProfile.StreamingParams.Protocol),
+			MinBufferTime:      transcodeProfile.StreamingParams.MinBufferTime,
+			SegmentFormat:      string(transcodeProfile.StreamingParams.SegmentFormat),
+			PlaylistFileName:   transcodeProfile.StreamingParams.PlaylistFileName,
+			AudioOnlyRendition: transcodeProfile.StreamingParams.AudioOnlyRendition,
+			AudioOnlyBitrate:   transcodeProfile.StreamingParams.AudioOnlyBitrate,
 		}
 	}
+	paused, err := s.isPaused(job.ProviderName)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	if paused {
+		return s.holdJob(&job)
+	}
+	if !s.submissions.acquire() {
+		if !prefersAsync(r) {
+			return newServiceOverloadedResponse(errServiceOverloaded)
+		}
+		return s.queueJob(&job)
+	}
+	defer s.submissions.release()
+	transcodeProfile.BillingTags = billingTags(&job)
+	if err = s.prepareSource(&job, providerObj, &transcodeProfile); err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	jobStatus, err := s.submit(&job, providerObj, transcodeProfile)
+	if err == provider.ErrPresetMapNotFound {
+		return newInvalidJobResponse(err)
+	}
+	if _, ok := err.(provider.KeyframeAlignmentError); ok {
+		return newInvalidJobResponse(err)
+	}
+	if err != nil {
+		providerError := fmt.Errorf("Error with provider %q: %s", input.Payload.Provider, err)
+		return swagger.NewErrorResponse(providerError)
+	}
+	job.ProviderJobID = jobStatus.ProviderJobID
+	job.Status = string(jobStatus.Status)
 	err = s.db.CreateJob(&job)
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
-	return newJobResponse(job.ID)
+	s.notifyAsync(&job, jobStatus)
+	return newJobResponse(&job, s.predictor.predict(&job))
 }
 
-func (s *TranscodingService) genID() (string, error) {
-	var data [8]byte
-	n, err := rand.Read(data[:])
-	if err != nil {
-		return "", err
+// defaultPlaylistFileNames are the names of the master playlists (or
+// manifests) of adaptive streaming jobs that don't define one.
+var defaultPlaylistFileNames = map[provider.Protocol]string{
+	provider.ProtocolHLS:  "hls/index.m3u8",
+	provider.ProtocolDASH: "dash/index.mpd",
+	provider.ProtocolCMAF: "cmaf/index.m3u8",
+}
+
+// jobRequirements returns the set of features that the provider must
+// support in order to run a job with the given profile.
+func (s *TranscodingService) jobRequirements(transcodeProfile provider.TranscodeProfile) provider.Requirements {
+	requirements := provider.Requirements{
+		StreamingProtocol:  string(transcodeProfile.StreamingParams.Protocol),
+		Conform:            transcodeProfile.Conform != nil,
+		OutputHeaders:      transcodeProfile.PlaylistHeaders != nil,
+		Thumbnails:         transcodeProfile.Thumbnails != nil,
+		Clipping:           transcodeProfile.Clip != nil,
+		AudioOnlyRendition: transcodeProfile.StreamingParams.AudioOnlyRendition,
+		DescribedAudio:     transcodeProfile.DescribedAudio != nil,
+		AudioTracks:        len(transcodeProfile.AudioTracks) > 0,
+	}
+	if drm := transcodeProfile.DRM; drm != nil {
+		requirements.DRMScheme = drm.Scheme
+	}
+	for _, caption := range transcodeProfile.Captions {
+		requirements.CaptionModes = append(requirements.CaptionModes, caption.Mode)
+	}
+	if format := transcodeProfile.StreamingParams.SegmentFormat; format != provider.SegmentFormatTS {
+		// MPEG-TS segments are supported by all providers.
+		requirements.SegmentFormat = string(format)
 	}
-	if n != len(data) {
-		return "", io.ErrShortWrite
+	for _, output := range transcodeProfile.Outputs {
+		requirements.OutputFormats = append(requirements.OutputFormats, provider.FormatName(output.Preset.OutputOpts.Extension))
+		if output.Headers != nil {
+			requirements.OutputHeaders = true
+		}
+		if output.Preview != nil {
+			requirements.Previews = true
+			requirements.Watermark = requirements.Watermark || output.Preview.Watermark != nil
+		}
 	}
-	return fmt.Sprintf("%x", data), nil
+	return requirements
 }
 
 func (s *TranscodingService) defaultFileName(source string, preset *db.PresetMap) string {
-	sourceExtension := filepath.Ext(source)
-	_, source = path.Split(source)
-	source = source[:len(source)-len(sourceExtension)]
+	source = sourceName(source)
 	pattern := "%s_%s.%s"
 	if preset.OutputOpts.Extension == "m3u8" {
 		pattern = "hls/" + pattern
@@ -117,20 +493,61 @@ func (s *TranscodingService) defaultFileName(source string, preset *db.PresetMap
 	return fmt.Sprintf(pattern, source, preset.Name, preset.OutputOpts.Extension)
 }
 
+// sourceName returns the name of the file of the source media, without
+// extension.
+func sourceName(source string) string {
+	sourceExtension := filepath.Ext(source)
+	_, source = path.Split(source)
+	return source[:len(source)-len(sourceExtension)]
+}
+
+// expandFileName replaces the {lang} and {variant} tokens in the given file
+// name. Outputs without language are tagged as "und" (undetermined, as in
+// ISO 639-2), and outputs without variant are tagged as "main".
+func expandFileName(fileName, language, variant string) string {
+	if language == "" {
+		language = "und"
+	}
+	if variant == "" {
+		variant = "main"
+	}
+	return strings.NewReplacer("{lang}", language, "{variant}", variant).Replace(fileName)
+}
+
 // swagger:route GET /jobs/{jobId} jobs getJob
 //
 // Finds a trancode job using its ID.
-// It also queries the provider to get the status of the job.
+// It also queries the provider to get the status of the job, unless the
+// status poller stored a fresh status of the job. The fields
+// query string parameter restricts the response to the given comma separated
+// list of fields (for example, "status,progress,output.files.path").
+//
+// The wait query string parameter (for example, "30s") holds the request
+// until the status of the job changes or the wait elapses, whatever happens
+// first. Waits are limited to one minute.
 //
 //     Responses:
 //       200: jobStatus
+//       400: genericError
 //       404: jobNotFound
 //       410: jobNotFoundInTheProvider
 //       500: genericError
 func (s *TranscodingService) getTranscodeJob(r *http.Request) swagger.GizmoJSONResponse {
 	var params getTranscodeJobInput
 	params.loadParams(web.Vars(r))
-	return s.getJobStatusResponse(s.getTranscodeJobByID(params.JobID))
+	wait, err := parseWait(r.URL.Query().Get("wait"))
+	if err != nil {
+		return swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)
+	}
+	raw, err := parseProviderStatusMode(r.URL.Query().Get("providerStatus"))
+	if err != nil {
+		return swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)
+	}
+	job, status, p, err := s.waitTranscodeJob(params.JobID, wait, r.Context().Done())
+	if err == nil {
+		s.setProviderStatus(job, status, p, raw)
+	}
+	return withFields(r, s.getJobStatusResponse(job, status, p, err))
 }
 
 func (s *TranscodingService) getJobStatusResponse(job *db.Job, status *provider.JobStatus, p provider.TranscodingProvider, err error) swagger.GizmoJSONResponse {
@@ -158,11 +575,14 @@ func (s *TranscodingService) getTranscodeJobByID(jobID string) (*db.Job, *provid
 		}
 		return nil, nil, nil, fmt.Errorf("error retrieving job with id %q: %s", jobID, err)
 	}
+	if job.ProviderJobID == "" || job.Status == string(provider.StatusSubmitting) {
+		return job, localJobStatus(job), nil, nil
+	}
 	providerFactory, err := provider.GetProviderFactory(job.ProviderName)
 	if err != nil {
 		return job, nil, nil, fmt.Errorf("unknown provider %q for job id %q", job.ProviderName, jobID)
 	}
-	providerObj, err := providerFactory(s.config)
+	providerObj, err := providerFactory(s.providerConfig(job.Environment))
 	if err != nil {
 		return job, nil, nil, fmt.Errorf("error initializing provider %q on job id %q: %s %s", job.ProviderName, jobID, providerObj, err)
 	}
@@ -170,7 +590,51 @@ func (s *TranscodingService) getTranscodeJobByID(jobID string) (*db.Job, *provid
 	if err != nil {
 		return job, nil, providerObj, err
 	}
+	if jobStatus.Status == provider.StatusFailed && s.canFallback(job, providerObj, jobStatus) {
+		pendingFallback(job, jobStatus)
+	}
 	jobStatus.ProviderName = job.ProviderName
+	jobStatus.RequestedProvider = job.RequestedProvider
+	jobStatus.Deadline = job.Deadline
+	if len(job.FallbackSources) > 0 {
+		jobStatus.SourceMedia = job.SourceMedia
+		jobStatus.FailedSources = job.FailedSources
+	}
+	if s.maintenance.get(s.db).Enabled {
+		// The API is read-only during the maintenance: report the status
+		// as given by the provider, without recording it or triggering
+		// any of the steps that follow the transcoding.
+		setCDNURLs(job, jobStatus)
+		jobStatus.Links = jobLinks(job, jobStatus)
+		return job, jobStatus, providerObj, nil
+	}
+	if !providerObj.Capabilities().Progress {
+		s.progress.update(s.db, job, jobStatus)
+	}
+	s.segments.verify(job, jobStatus)
+	s.uploader.sync(job, jobStatus)
+	s.fingerprints.sync(job, jobStatus)
+	s.checkAudio(job, providerObj, jobStatus)
+	s.checkFlashes(job, jobStatus)
+	s.checkVideo(job, jobStatus)
+	checkCaptions(job, jobStatus)
+	s.publisher.sync(job, jobStatus)
+	s.extractPoster(job, jobStatus)
+	s.predictor.update(job, jobStatus)
+	setCDNURLs(job, jobStatus)
+	jobStatus.Links = jobLinks(job, jobStatus)
+	if jobStatus.Status == provider.StatusFinished && len(jobStatus.Warnings) > 0 {
+		jobStatus.Status = provider.StatusFinishedWithWarnings
+	}
+	s.postProcessors.sync(job, jobStatus)
+	if job.Status == string(provider.StatusPostProcessing) {
+		// the status is recorded by the instance of the API running
+		// the post-processors of the job, once they finish
+		return job, jobStatus, providerObj, nil
+	}
+	if _, err = s.recordStatus(job, jobStatus); err != nil {
+		s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to record the status of the job")
+	}
 	return job, jobStatus, providerObj, nil
 }
 
@@ -184,9 +648,13 @@ func (s *TranscodingService) getTranscodeJobByID(jobID string) (*db.Job, *provid
 //       410: jobNotFoundInTheProvider
 //       500: genericError
 func (s *TranscodingService) cancelTranscodeJob(r *http.Request) swagger.GizmoJSONResponse {
+	return withFields(r, s.cancelJob(r))
+}
+
+func (s *TranscodingService) cancelJob(r *http.Request) swagger.GizmoJSONResponse {
 	var params cancelTranscodeJobInput
 	params.loadParams(web.Vars(r))
-	job, _, prov, err := s.getTranscodeJobByID(params.JobID)
+	job, status, prov, err := s.getTranscodeJobByID(params.JobID)
 	if err != nil {
 		if err == db.ErrJobNotFound {
 			return newJobNotFoundResponse(err)
@@ -196,14 +664,36 @@ func (s *TranscodingService) cancelTranscodeJob(r *http.Request) swagger.GizmoJS
 		}
 		return swagger.NewErrorResponse(err)
 	}
+	if prov == nil {
+		if job.Status != string(provider.StatusPaused) && !s.submissions.remove(job.ID) && job.Status != string(provider.StatusQueuedLocally) {
+			return newJobStatusResponse(status)
+		}
+		// jobs queued by other instances of the API may be claimed for
+		// submission in the meantime
+		canceled := *job
+		canceled.Status = string(provider.StatusCanceled)
+		if err = s.db.UpdateJobIfStatus(&canceled, job.Status); err != nil {
+			if err == db.ErrJobStatusChanged {
+				return s.getJobStatusResponse(s.getTranscodeJobByID(job.ID))
+			}
+			return swagger.NewErrorResponse(err)
+		}
+		status = localJobStatus(&canceled)
+		s.notifyAsync(&canceled, status)
+		return newJobStatusResponse(status)
+	}
 	err = prov.CancelJob(job.ProviderJobID)
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
-	status, err := prov.JobStatus(job)
+	status, err = prov.JobStatus(job)
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
 	status.ProviderName = job.ProviderName
+	status.Links = jobLinks(job, status)
+	if _, err = s.recordStatus(job, status); err != nil {
+		s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to record the status of the job")
+	}
 	return newJobStatusResponse(status)
 }
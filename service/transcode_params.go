@@ -3,8 +3,13 @@ package service
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
+	"regexp"
+	"time"
 
+	"github.com/NYTimes/video-transcoding-api/db"
 	"github.com/NYTimes/video-transcoding-api/provider"
 )
 
@@ -14,17 +19,378 @@ type NewTranscodeJobInputPayload struct {
 	// source media for the transcoding job.
 	Source string `json:"source"`
 
+	// alternative sources for the job (like a proxy of the source media).
+	// When the provider fails to read the source, the job is automatically
+	// resubmitted using the next fallback source.
+	FallbackSources []string `json:"fallbackSources,omitempty"`
+
+	// checksum of the source media, in the format "algorithm:digest" (for
+	// example, "sha256:9f86d0..."). When the job finishes, it's added to
+	// the source index, so later submissions of the same file can be
+	// detected with the getSource operation.
+	SourceChecksum string `json:"sourceChecksum,omitempty"`
+
+	// encryption of the sources, for sources encrypted at rest with
+	// customer keys. The key is referenced by its name in the key store of
+	// the API.
+	SourceEncryption *db.SourceEncryption `json:"sourceEncryption,omitempty"`
+
+	// encryption of the outputs at rest with a per-job data key, for
+	// embargoed content. Encrypted outputs must be delivered to S3.
+	OutputEncryption *OutputEncryptionParams `json:"outputEncryption,omitempty"`
+
+	// ranges of the source to transcode, for conform jobs (for example,
+	// cutting a highlight from a long event recording). Ranges are cut
+	// with frame-accurate in and out points, and require a provider that
+	// supports conform.
+	Conform *ConformParams `json:"conform,omitempty"`
+
+	// trimming of the black frames and the silence padding the beginning
+	// and the end of the source (for example, in wire-ingest content). The
+	// padding is detected before the job is submitted, and the trimmed
+	// ranges are recorded in the job. Can't be combined with conform.
+	Trim *TrimParams `json:"trim,omitempty"`
+
+	// start of the range of the source to transcode, in seconds, for
+	// partial transcodes (like previews). Requires a provider that
+	// supports clipping, and can't be combined with conform or trim.
+	ClipStart float64 `json:"clipStart,omitempty"`
+
+	// duration of the range of the source to transcode, in seconds. When
+	// zero, the source is transcoded from ClipStart to its end.
+	ClipDuration float64 `json:"clipDuration,omitempty"`
+
+	// compute perceptual fingerprints of the video outputs once the job
+	// finishes, for matching republished copies back to the job. The job
+	// is reported as finished after the fingerprints are stored.
+	Fingerprint bool `json:"fingerprint,omitempty"`
+
+	// quality control of the audio of the outputs (loudness, peaks,
+	// silence and clipping). Reports are attached to the job as artifacts
+	// once it finishes, and the job fails when an output violates the
+	// thresholds.
+	AudioQC *AudioQCParams `json:"audioQC,omitempty"`
+
+	// photosensitivity test of the video outputs, looking for flashing
+	// sequences that fail the general flash test of the Harding and the
+	// PSE guidelines. Reports are attached to the job as artifacts once
+	// it finishes.
+	Photosensitivity *PhotosensitivityParams `json:"photosensitivity,omitempty"`
+
+	// quality control of the video of the outputs (extended black and
+	// frozen ranges, and severe macroblocking). Reports are attached to
+	// the job as artifacts once it finishes, and the job finishes with
+	// warnings when an output violates the thresholds.
+	VideoQC *VideoQCParams `json:"videoQC,omitempty"`
+
+	// poster frame extracted from the video outputs once the job
+	// finishes, and uploaded next to them. The path of the image is
+	// reported in the status of the job.
+	Poster *PosterParams `json:"poster,omitempty"`
+
+	// DRM of the adaptive streaming outputs
+	DRM *DRMParams `json:"drm,omitempty"`
+
+	// write the outputs to a staging prefix, and move them to the
+	// destination (an s3:// URL) only after all of them pass
+	// verification, with the playlists last, so players never see
+	// incomplete outputs
+	Publish bool `json:"publish,omitempty"`
+
+	// thumbnails generated from the source
+	Thumbnails *ThumbnailsParams `json:"thumbnails,omitempty"`
+
+	// caption (or subtitle) files ingested in the job
+	Captions []CaptionParams `json:"captions,omitempty"`
+
+	// HTTP headers and custom metadata set on the output files, by
+	// extension or preset. When more than one rule matches a file, later
+	// rules take precedence.
+	OutputHeaders []db.OutputHeaders `json:"outputHeaders,omitempty"`
+
 	// list of outputs in this job
-	Outputs []struct {
-		FileName string `json:"fileName"`
-		Preset   string `json:"preset"`
-	} `json:"outputs"`
+	Outputs []db.TranscodeOutput `json:"outputs"`
+
+	// name of the ladder that defines the outputs and the streaming
+	// parameters of the job, instead of listing them in the request.
+	// Can't be combined with outputs.
+	Ladder string `json:"ladder,omitempty"`
 
 	// provider to use in this job
 	Provider string `json:"provider"`
 
 	// provider Adaptive Streaming parameters
 	StreamingParams provider.StreamingParams `json:"streamingParams,omitempty"`
+
+	// name of the tenant submitting the job. The defaults of the tenant
+	// are used for any parameter omitted in the request. Required once
+	// tenants are registered, unless the API takes the tenant from the
+	// caller (TENANT_HEADER).
+	Tenant string `json:"tenant,omitempty"`
+
+	// base destination for the outputs of the job
+	Destination string `json:"destination,omitempty"`
+
+	// HTTP(S) URL that should be notified about the job, which can't point
+	// to private networks
+	CallbackURL string `json:"callbackURL,omitempty"`
+
+	// statuses that trigger notifications to the callback URL (for
+	// example, ["failed", "finished"]). Defaults to all statuses.
+	NotifyOn []string `json:"notifyOn,omitempty"`
+
+	// payload of the notifications: "full" (the default) for the whole
+	// status of the job, or "slim" for the id, the status and the status
+	// message of the job.
+	CallbackPayload string `json:"callbackPayload,omitempty"`
+
+	// language of the audio in the source media, used for replacing the
+	// {lang} token in output file names.
+	Language string `json:"language,omitempty"`
+
+	// filters applied to the video of the outputs (deinterlacing,
+	// denoising and sharpening). Settings omitted here fall back to the
+	// filters of the presets. Defaults to the filters of the tenant.
+	Filters *db.VideoFilters `json:"filters,omitempty"`
+
+	// whether the video is rotated following the rotation metadata of the
+	// source (like the one of phone-shot videos). Defaults to true.
+	Autorotate *bool `json:"autorotate,omitempty"`
+
+	// clockwise rotation of the video: 90, 180 or 270 degrees. Takes
+	// precedence over the rotation metadata of the source.
+	Rotate int `json:"rotate,omitempty"`
+
+	// audio description track, delivered as an alternate audio rendition
+	// flagged as describing the video. Only supported in hls and dash
+	// jobs. The language of the track defaults to the language of the job.
+	DescribedAudio *db.DescribedAudio `json:"describedAudio,omitempty"`
+
+	// audio tracks of the source included in the outputs, for sources
+	// with several audio tracks. A single track replaces the audio of the
+	// outputs, and several tracks are delivered as alternate audio
+	// renditions, only supported in hls and dash jobs. Defaults to the
+	// first audio track of the source.
+	AudioTracks []db.AudioTrack `json:"audioTracks,omitempty"`
+
+	// name of the experiment that the job may be enrolled in. Enrolled jobs
+	// are encoded with one of the variants of the experiment.
+	Experiment string `json:"experiment,omitempty"`
+
+	// name of the delivery target of the outputs. The destination of the
+	// job is resolved from the target, so both can't be provided.
+	DeliveryTarget string `json:"deliveryTarget,omitempty"`
+
+	// id of the job in the system of the client (for example, the id of
+	// the asset in the CMS). Jobs can be found by their external ids.
+	ExternalID string `json:"externalId,omitempty"`
+
+	// labels of the job. Finished jobs can be syndicated in feeds
+	// filtered by label. Jobs of the chaos provider (enabled with
+	// CHAOS_PROVIDER_ENABLED) inject the failures named by their labels:
+	// chaos:submit-5xx fails all submissions of the source, or only the
+	// first ones with chaos:submit-5xx=N, chaos:stuck-at=N keeps the job
+	// started at N% forever, chaos:webhook-loss drops the notification of
+	// its completion and chaos:partial-outputs finishes it with only its
+	// first output.
+	Labels []string `json:"labels,omitempty"`
+
+	// environment of the provider: production (the default) or sandbox.
+	// Sandbox jobs use the sandbox credentials of the provider, and can
+	// only write to the destinations allowed in the sandbox.
+	Environment string `json:"environment,omitempty"`
+
+	// priority of the job: low, normal (the default) or high. Jobs queued
+	// in the API are submitted in order of priority, and providers with
+	// priority settings get the priority of the job.
+	Priority string `json:"priority,omitempty"`
+
+	// time by which the job should be finished. Jobs with a deadline are
+	// routed to the provider most likely to finish them in time, and
+	// failed over to another provider (or escalated) when at risk of
+	// missing it.
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// ConformParams are the parameters of a conform job. Ranges can be provided
+// either as a list of timecodes or as a CMX 3600 EDL.
+//
+// swagger:model
+type ConformParams struct {
+	// frame rate of the source, used for interpreting the timecodes
+	//
+	// required: true
+	FrameRate float64 `json:"frameRate"`
+
+	// ranges of the source, in the order they appear in the outputs
+	Ranges []db.EditRange `json:"ranges,omitempty"`
+
+	// CMX 3600 edit decision list. The source in and out points of the
+	// video events are used as the ranges.
+	EDL string `json:"edl,omitempty"`
+}
+
+// TrimParams are the parameters for trimming the padding of the source.
+//
+// swagger:model
+type TrimParams struct {
+	// trim leading and trailing black frames
+	Black bool `json:"black,omitempty"`
+
+	// trim leading and trailing silence
+	Silence bool `json:"silence,omitempty"`
+}
+
+// AudioQCParams are the thresholds of the audio QC of the outputs. Zero
+// thresholds aren't enforced.
+//
+// swagger:model
+type AudioQCParams struct {
+	// target integrated loudness, in LUFS (for example, -23 for EBU R128
+	// or -24 for ATSC A/85)
+	LoudnessTarget float64 `json:"loudnessTarget,omitempty"`
+
+	// maximum distance from the target loudness, in LU. Defaults to 1.
+	LoudnessTolerance float64 `json:"loudnessTolerance,omitempty"`
+
+	// maximum true peak, in dBTP
+	MaxTruePeak *float64 `json:"maxTruePeak,omitempty"`
+
+	// maximum duration of silent ranges, in seconds
+	MaxSilence float64 `json:"maxSilence,omitempty"`
+
+	// fail outputs with clipped samples
+	RejectClipping bool `json:"rejectClipping,omitempty"`
+}
+
+// PhotosensitivityParams are the settings of the photosensitivity test of
+// the outputs.
+//
+// swagger:model
+type PhotosensitivityParams struct {
+	// fail the job when an output fails the test, instead of only
+	// reporting the failing sequences
+	Reject bool `json:"reject,omitempty"`
+}
+
+// VideoQCParams are the thresholds of the video QC of the outputs.
+//
+// swagger:model
+type VideoQCParams struct {
+	// maximum duration of black ranges, in seconds. Defaults to 2.
+	MaxBlack float64 `json:"maxBlack,omitempty"`
+
+	// maximum duration of frozen ranges, in seconds. Defaults to 2.
+	MaxFreeze float64 `json:"maxFreeze,omitempty"`
+
+	// blockiness, as measured by the ffmpeg blockdetect filter, from
+	// which frames are considered macroblocked. Macroblocking isn't
+	// checked when omitted.
+	MaxBlockiness float64 `json:"maxBlockiness,omitempty"`
+
+	// fail the job when an output violates the thresholds, instead of
+	// finishing it with warnings
+	Reject bool `json:"reject,omitempty"`
+}
+
+// PosterParams are the settings of the poster frame of the job.
+//
+// swagger:model
+type PosterParams struct {
+	// number of candidate frames, evenly spaced over the video. Defaults
+	// to 5.
+	Candidates uint `json:"candidates,omitempty"`
+
+	// analyzer that scores the candidates (sharpness or middle). Defaults
+	// to sharpness.
+	Analyzer string `json:"analyzer,omitempty"`
+
+	// name of the image, relative to the destination of the outputs.
+	// Defaults to poster.jpg.
+	FileName string `json:"fileName,omitempty"`
+}
+
+// DRMParams are the settings for encrypting the adaptive streaming outputs
+// of a job.
+//
+// swagger:model
+type DRMParams struct {
+	// DRM scheme (widevine, fairplay, playready or aes-128)
+	//
+	// required: true
+	Scheme string `json:"scheme"`
+
+	// hex encoded 128-bit content key
+	//
+	// required: true
+	Key string `json:"key"`
+
+	// hex encoded 128-bit id of the content key, required by all schemes
+	// but aes-128
+	KeyID string `json:"keyId,omitempty"`
+
+	// URL of the license server (or of the key, for aes-128)
+	//
+	// required: true
+	LicenseURL string `json:"licenseUrl"`
+
+	// hex encoded 128-bit initialization vector
+	IV string `json:"iv,omitempty"`
+}
+
+// ThumbnailsParams are the settings of the thumbnails generated by a job,
+// either one every interval or a given number of them.
+//
+// swagger:model
+type ThumbnailsParams struct {
+	// time between thumbnails, in seconds
+	Interval uint `json:"interval,omitempty"`
+
+	// number of thumbnails, evenly spaced over the source
+	Count uint `json:"count,omitempty"`
+
+	// maximum width of the thumbnails. The aspect ratio of the source is
+	// kept when only the width or the height is provided.
+	Width uint `json:"width,omitempty"`
+
+	// maximum height of the thumbnails
+	Height uint `json:"height,omitempty"`
+
+	// image format of the thumbnails: jpg or png. Defaults to the format
+	// of the provider.
+	Format string `json:"format,omitempty"`
+}
+
+// CaptionParams describe a caption (or subtitle) file ingested in a job.
+//
+// swagger:model
+type CaptionParams struct {
+	// URL of the caption file
+	//
+	// required: true
+	Source string `json:"source"`
+
+	// language of the captions, as an ISO 639 code. Defaults to the
+	// language of the job.
+	Language string `json:"language,omitempty"`
+
+	// format of the caption file: srt, webvtt or ttml. Detected from the
+	// extension of the source when omitted.
+	Format string `json:"format,omitempty"`
+
+	// sidecar (the default), for delivering the captions as a file along
+	// with the outputs, or burn-in, for rendering them in the video
+	Mode string `json:"mode,omitempty"`
+
+	// name of the delivered caption file, for sidecar captions. Defaults
+	// to captions/{lang}.<extension of the format>.
+	FileName string `json:"fileName,omitempty"`
+
+	// format the caption file is converted to before the job is submitted
+	// (srt, webvtt or ttml). Captions in formats that the provider can't
+	// ingest are converted to one it can even when omitted. Conversions
+	// require an s3:// destination, where the converted files are stored.
+	ConvertTo string `json:"convertTo,omitempty"`
 }
 
 // swagger:parameters newJob
@@ -34,14 +400,10 @@ type newTranscodeJobInput struct {
 	Payload NewTranscodeJobInputPayload
 }
 
-// ProviderFactory loads and validates the parameters, and then returns the
-// provider factory.
-func (p *newTranscodeJobInput) ProviderFactory(body io.Reader) (provider.Factory, error) {
-	err := p.loadParams(body)
-	if err != nil {
-		return nil, err
-	}
-	err = p.validate()
+// ProviderFactory validates the parameters, and then returns the provider
+// factory.
+func (p *newTranscodeJobInput) ProviderFactory() (provider.Factory, error) {
+	err := p.validate()
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +414,148 @@ func (p *newTranscodeJobInput) loadParams(body io.Reader) error {
 	return json.NewDecoder(body).Decode(&p.Payload)
 }
 
+// applyDefaults fills the parameters omitted in the request with the given
+// defaults.
+func (p *newTranscodeJobInput) applyDefaults(defaults db.JobDefaults) {
+	if p.Payload.Provider == "" {
+		p.Payload.Provider = defaults.Provider
+	}
+	if p.Payload.Destination == "" && p.Payload.DeliveryTarget == "" {
+		p.Payload.DeliveryTarget = defaults.DeliveryTarget
+		if p.Payload.DeliveryTarget == "" {
+			p.Payload.Destination = defaults.Destination
+		}
+	}
+	if p.Payload.CallbackURL == "" {
+		p.Payload.CallbackURL = defaults.CallbackURL
+	}
+	if p.Payload.Experiment == "" {
+		p.Payload.Experiment = defaults.Experiment
+	}
+	if p.Payload.Filters == nil {
+		p.Payload.Filters = defaults.Filters
+	}
+	if len(p.Payload.Outputs) == 0 {
+		for _, preset := range defaults.Ladder {
+			p.Payload.Outputs = append(p.Payload.Outputs, db.TranscodeOutput{Preset: preset})
+		}
+	}
+	if p.Payload.StreamingParams.Protocol == "" && defaults.StreamingParams.Protocol != "" {
+		p.Payload.StreamingParams.Protocol = provider.Protocol(defaults.StreamingParams.Protocol)
+		p.Payload.StreamingParams.SegmentDuration = defaults.StreamingParams.SegmentDuration
+		p.Payload.StreamingParams.MinBufferTime = defaults.StreamingParams.MinBufferTime
+		p.Payload.StreamingParams.SegmentFormat = provider.SegmentFormat(defaults.StreamingParams.SegmentFormat)
+		p.Payload.StreamingParams.AudioOnlyRendition = defaults.StreamingParams.AudioOnlyRendition
+		p.Payload.StreamingParams.AudioOnlyBitrate = defaults.StreamingParams.AudioOnlyBitrate
+	}
+}
+
+// applyLadder fills the outputs of the request with the presetmaps of the
+// given ladder, and the streaming parameters omitted in the request with
+// the ones of the ladder.
+func (p *newTranscodeJobInput) applyLadder(ladder db.Ladder) error {
+	if len(p.Payload.Outputs) > 0 {
+		return errors.New("ladder and outputs can't be used together")
+	}
+	for _, preset := range ladder.Presets {
+		p.Payload.Outputs = append(p.Payload.Outputs, db.TranscodeOutput{Preset: preset})
+	}
+	streaming := ladder.StreamingParams
+	if p.Payload.StreamingParams.Protocol == "" && streaming.Protocol != "" {
+		p.Payload.StreamingParams.Protocol = provider.Protocol(streaming.Protocol)
+		p.Payload.StreamingParams.SegmentDuration = streaming.SegmentDuration
+		p.Payload.StreamingParams.MinBufferTime = streaming.MinBufferTime
+		p.Payload.StreamingParams.SegmentFormat = provider.SegmentFormat(streaming.SegmentFormat)
+		p.Payload.StreamingParams.AudioOnlyRendition = streaming.AudioOnlyRendition
+		p.Payload.StreamingParams.AudioOnlyBitrate = streaming.AudioOnlyBitrate
+		if p.Payload.StreamingParams.PlaylistFileName == "" {
+			p.Payload.StreamingParams.PlaylistFileName = streaming.PlaylistFileName
+		}
+	}
+	return nil
+}
+
+// applyVariant overrides the parameters of the request with the ones
+// defined in the given experiment variant.
+func (p *newTranscodeJobInput) applyVariant(variant db.ExperimentVariant) {
+	if variant.Provider != "" {
+		p.Payload.Provider = variant.Provider
+	}
+	if len(variant.Ladder) > 0 {
+		outputs := make([]db.TranscodeOutput, len(variant.Ladder))
+		for i, preset := range variant.Ladder {
+			outputs[i] = db.TranscodeOutput{Preset: preset}
+		}
+		p.Payload.Outputs = outputs
+	}
+}
+
+// rotation returns the rotation recorded in new jobs, or nil when the video
+// is rotated following the rotation metadata of the source.
+func (p *NewTranscodeJobInputPayload) rotation() *db.Rotation {
+	rotation := db.Rotation{Autorotate: p.Autorotate == nil || *p.Autorotate, Degrees: p.Rotate}
+	if rotation.Autorotate && rotation.Degrees == 0 {
+		return nil
+	}
+	return &rotation
+}
+
+// outputLanguage returns the language of the given output, which defaults to
+// the language of the job, or to the one of its default audio track.
+func (p *newTranscodeJobInput) outputLanguage(output db.TranscodeOutput) string {
+	if output.Language != "" {
+		return output.Language
+	}
+	if p.Payload.Language != "" {
+		return p.Payload.Language
+	}
+	if track := db.DefaultAudioTrack(p.Payload.AudioTracks); track != nil {
+		return track.Language
+	}
+	return ""
+}
+
+// audioTracks returns the audio tracks of the job, with the language of the
+// job as the default language of the tracks, their language as their
+// default name, and the first track selected by default unless another
+// track is.
+func (p *newTranscodeJobInput) audioTracks() []db.AudioTrack {
+	if len(p.Payload.AudioTracks) == 0 {
+		return nil
+	}
+	tracks := make([]db.AudioTrack, len(p.Payload.AudioTracks))
+	copy(tracks, p.Payload.AudioTracks)
+	for i := range tracks {
+		if tracks[i].Language == "" {
+			tracks[i].Language = p.Payload.Language
+		}
+		if tracks[i].Language == "" {
+			tracks[i].Language = "und"
+		}
+		if tracks[i].Name == "" {
+			tracks[i].Name = tracks[i].Language
+		}
+	}
+	db.DefaultAudioTrack(tracks).Default = true
+	return tracks
+}
+
+// describedAudio returns the audio description track of the job, with the
+// language of the job as the default language of the track.
+func (p *newTranscodeJobInput) describedAudio() *db.DescribedAudio {
+	if p.Payload.DescribedAudio == nil {
+		return nil
+	}
+	described := *p.Payload.DescribedAudio
+	if described.Language == "" {
+		described.Language = p.Payload.Language
+	}
+	if described.Language == "" {
+		described.Language = "und"
+	}
+	return &described
+}
+
 func (p *newTranscodeJobInput) validate() error {
 	if p.Payload.Provider == "" {
 		return errors.New("missing provider from request")
@@ -62,6 +566,155 @@ func (p *newTranscodeJobInput) validate() error {
 	if len(p.Payload.Outputs) == 0 {
 		return errors.New("missing output list from request")
 	}
+	if p.Payload.SourceChecksum != "" && !sourceChecksumRegexp.MatchString(p.Payload.SourceChecksum) {
+		return errInvalidSourceChecksum
+	}
+	if trim := p.Payload.Trim; trim != nil {
+		if !trim.Black && !trim.Silence {
+			return errors.New("trim requires black frames, silence or both to be trimmed")
+		}
+		if p.Payload.Conform != nil {
+			return errors.New("trim and conform can't be combined")
+		}
+	}
+	if p.Payload.ClipStart < 0 || p.Payload.ClipDuration < 0 {
+		return errors.New("clipStart and clipDuration can't be negative")
+	}
+	if p.Payload.clip() != nil && (p.Payload.Conform != nil || p.Payload.Trim != nil) {
+		return errors.New("clipping can't be combined with conform or trim")
+	}
+	if qc := p.Payload.AudioQC; qc != nil {
+		if qc.LoudnessTarget > 0 {
+			return errors.New("audioQC loudnessTarget must be negative")
+		}
+		if qc.LoudnessTolerance < 0 || qc.MaxSilence < 0 {
+			return errors.New("audioQC thresholds can't be negative")
+		}
+		if qc.LoudnessTolerance > 0 && qc.LoudnessTarget == 0 {
+			return errors.New("audioQC loudnessTolerance requires a loudnessTarget")
+		}
+	}
+	if qc := p.Payload.VideoQC; qc != nil && (qc.MaxBlack < 0 || qc.MaxFreeze < 0 || qc.MaxBlockiness < 0) {
+		return errors.New("videoQC thresholds can't be negative")
+	}
+	if err := validateNotifications(p.Payload.NotifyOn, p.Payload.CallbackPayload); err != nil {
+		return err
+	}
+	if err := validatePriority(p.Payload.Priority); err != nil {
+		return err
+	}
+	if err := p.Payload.validateFileNameTokens(); err != nil {
+		return err
+	}
+	if err := validateFilters(p.Payload.Filters); err != nil {
+		return err
+	}
+	switch p.Payload.Rotate {
+	case 0, 90, 180, 270:
+	default:
+		return fmt.Errorf("invalid rotate %d, it must be 90, 180 or 270", p.Payload.Rotate)
+	}
+	if err := validatePreviews(p.Payload.Outputs); err != nil {
+		return err
+	}
+	if poster := p.Payload.Poster; poster != nil {
+		if err := poster.validate(); err != nil {
+			return err
+		}
+	}
+	streaming := p.Payload.StreamingParams
+	if streaming.Protocol != "" && !streaming.Protocol.Valid() {
+		return fmt.Errorf("invalid streaming protocol %q", streaming.Protocol)
+	}
+	if streaming.MinBufferTime < 0 {
+		return errors.New("minBufferTime can't be negative")
+	}
+	if streaming.MinBufferTime > 0 && streaming.Protocol != provider.ProtocolDASH && streaming.Protocol != provider.ProtocolCMAF {
+		return errors.New("minBufferTime is only supported in dash and cmaf jobs")
+	}
+	if streaming.SegmentFormat != "" && !streaming.SegmentFormat.Valid() {
+		return fmt.Errorf("invalid segment format %q", streaming.SegmentFormat)
+	}
+	if streaming.SegmentFormat != "" && streaming.Protocol != provider.ProtocolHLS {
+		return errors.New("segmentFormat is only supported in hls jobs")
+	}
+	if streaming.AudioOnlyRendition && streaming.Protocol != provider.ProtocolHLS {
+		return errors.New("audioOnlyRendition is only supported in hls jobs")
+	}
+	if streaming.AudioOnlyBitrate > 0 && !streaming.AudioOnlyRendition {
+		return errors.New("audioOnlyBitrate requires audioOnlyRendition")
+	}
+	if described := p.Payload.DescribedAudio; described != nil {
+		if described.Source == "" {
+			return errors.New("describedAudio requires a source")
+		}
+		if streaming.Protocol != provider.ProtocolHLS && streaming.Protocol != provider.ProtocolDASH {
+			return errors.New("describedAudio is only supported in hls and dash jobs")
+		}
+	}
+	if err := validateAudioTracks(p.Payload.AudioTracks, streaming.Protocol); err != nil {
+		return err
+	}
+	if thumbnails := p.Payload.Thumbnails; thumbnails != nil {
+		if err := thumbnails.validate(); err != nil {
+			return err
+		}
+	}
+	if err := validateCaptions(p.Payload.Captions); err != nil {
+		return err
+	}
+	if err := validateOutputHeaders(p.Payload.OutputHeaders); err != nil {
+		return err
+	}
+	if drm := p.Payload.DRM; drm != nil {
+		if streaming.Protocol == "" {
+			return errors.New("drm requires an adaptive streaming protocol")
+		}
+		return drm.validate()
+	}
+	return nil
+}
+
+// fileNameTokenRegexp matches the languages and variants that replace the
+// {lang} and {variant} tokens in file names, so they can't add path
+// separators or dot segments to the names.
+var fileNameTokenRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validateFileNameTokens checks the languages and variants of the job, its
+// outputs, audio tracks and captions, which may end up in file names.
+func (p *NewTranscodeJobInputPayload) validateFileNameTokens() error {
+	languages := []string{p.Language}
+	var variants []string
+	for _, output := range p.Outputs {
+		languages = append(languages, output.Language)
+		variants = append(variants, output.Variant)
+	}
+	for _, track := range p.AudioTracks {
+		languages = append(languages, track.Language)
+	}
+	if p.DescribedAudio != nil {
+		languages = append(languages, p.DescribedAudio.Language)
+	}
+	for _, caption := range p.Captions {
+		languages = append(languages, caption.Language)
+	}
+	for _, language := range languages {
+		if err := validateFileNameToken("language", language); err != nil {
+			return err
+		}
+	}
+	for _, variant := range variants {
+		if err := validateFileNameToken("variant", variant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFileNameToken(name, value string) error {
+	if value != "" && !fileNameTokenRegexp.MatchString(value) {
+		return fmt.Errorf("invalid %s %q, it may only contain letters, digits, underscores and dashes", name, value)
+	}
 	return nil
 }
 
@@ -70,6 +723,23 @@ type getTranscodeJobInput struct {
 	// in: path
 	// required: true
 	JobID string `json:"jobId"`
+
+	// comma separated list of fields included in the response
+	//
+	// in: query
+	Fields string `json:"fields"`
+
+	// maximum time to wait for a change in the status of the job (for
+	// example, 30s)
+	//
+	// in: query
+	Wait string `json:"wait"`
+
+	// "raw" includes the complete status reported by the provider in the
+	// response, instead of its summary
+	//
+	// in: query
+	ProviderStatus string `json:"providerStatus"`
 }
 
 func (p *getTranscodeJobInput) loadParams(paramsMap map[string]string) {
@@ -80,3 +750,20 @@ func (p *getTranscodeJobInput) loadParams(paramsMap map[string]string) {
 type cancelTranscodeJobInput struct {
 	getTranscodeJobInput
 }
+
+// swagger:parameters getJobByExternalID
+type getJobByExternalIDInput struct {
+	// in: path
+	// required: true
+	ExternalID string `json:"externalId"`
+
+	// name of the tenant that submitted the job
+	//
+	// in: query
+	Tenant string `json:"tenant"`
+}
+
+func (p *getJobByExternalIDInput) loadParams(paramsMap map[string]string, query url.Values) {
+	p.ExternalID = paramsMap["externalId"]
+	p.Tenant = query.Get("tenant")
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"net/http"
 
+	"github.com/NYTimes/video-transcoding-api/db"
 	"github.com/NYTimes/video-transcoding-api/provider"
 	"github.com/NYTimes/video-transcoding-api/swagger"
 )
@@ -15,6 +16,22 @@ type PartialJob struct {
 	//
 	// unique: true
 	JobID string `json:"jobId"`
+
+	// expected completion time and cost of the job. Omitted when there's
+	// no history of jobs in the provider.
+	Prediction *provider.JobPrediction `json:"prediction,omitempty"`
+
+	// name of the provider that accepted the job, when the submission to
+	// the requested provider failed and the job was submitted to a
+	// fallback provider
+	ProviderName string `json:"providerName,omitempty"`
+
+	// status of jobs that were held in the API instead of being submitted
+	// to the provider (queued-locally or paused)
+	Status provider.Status `json:"status,omitempty"`
+
+	// links to the resources related to the job (self, cancel, etc.)
+	Links map[string]string `json:"links,omitempty"`
 }
 
 // JSON-encoded version of the Job, includes only the id of the job, that can
@@ -28,15 +45,37 @@ type jobResponse struct {
 	baseResponse
 }
 
-func newJobResponse(jobID string) *jobResponse {
+func newJobResponse(job *db.Job, prediction *provider.JobPrediction) *jobResponse {
+	partialJob := PartialJob{
+		JobID:      job.ID,
+		Prediction: prediction,
+		Links:      jobLinks(job, &provider.JobStatus{Status: provider.Status(job.Status)}),
+	}
+	if job.RequestedProvider != "" {
+		partialJob.ProviderName = job.ProviderName
+	}
 	return &jobResponse{
 		baseResponse: baseResponse{
-			payload: &PartialJob{JobID: jobID},
+			payload: &partialJob,
 			status:  http.StatusOK,
 		},
 	}
 }
 
+func newQueuedJobResponse(job *db.Job) *jobResponse {
+	status := provider.Status(job.Status)
+	return &jobResponse{
+		baseResponse: baseResponse{
+			payload: &PartialJob{
+				JobID:  job.ID,
+				Status: status,
+				Links:  jobLinks(job, &provider.JobStatus{Status: status}),
+			},
+			status: http.StatusAccepted,
+		},
+	}
+}
+
 // JSON-encoded JobStatus, containing status information given by the
 // underlying provider.
 //
@@ -73,6 +112,59 @@ func (r *invalidJobResponse) Result() (int, interface{}, error) {
 	return r.Error.Result()
 }
 
+// error returned when a pre-processor rejects the job, or when the job
+// violates the policy of the API.
+//
+// swagger:response jobRejected
+type jobRejectedResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newJobRejectedResponse(err error) *jobRejectedResponse {
+	return &jobRejectedResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusForbidden)}
+}
+
+func (r *jobRejectedResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+// JSON-encoded Job, as recorded in the API.
+//
+// swagger:response jobRecord
+type jobRecordResponse struct {
+	// in: body
+	Payload *db.Job
+
+	baseResponse
+}
+
+func newJobRecordResponse(job *db.Job) *jobRecordResponse {
+	return &jobRecordResponse{
+		baseResponse: baseResponse{
+			payload: job,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// error returned when the external id of the job is already used by
+// another job of the tenant.
+//
+// swagger:response externalIDConflict
+type externalIDConflictResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newExternalIDConflictResponse(err error) *externalIDConflictResponse {
+	return &externalIDConflictResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *externalIDConflictResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
 // error returned the given job id could not be found on the API.
 //
 // swagger:response jobNotFound
@@ -105,3 +197,37 @@ func newJobNotFoundProviderResponse(err error) *jobNotFoundProviderResponse {
 func (r *jobNotFoundProviderResponse) Result() (int, interface{}, error) {
 	return r.Error.Result()
 }
+
+// error returned when the provider can't receive new jobs, because its
+// account is near exhaustion.
+//
+// swagger:response providerUnavailable
+type providerUnavailableResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newProviderUnavailableResponse(err error) *providerUnavailableResponse {
+	return &providerUnavailableResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusServiceUnavailable)}
+}
+
+func (r *providerUnavailableResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+// error returned when the API is receiving more jobs than it can submit to
+// providers. The response includes the Retry-After header.
+//
+// swagger:response serviceOverloaded
+type serviceOverloadedResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newServiceOverloadedResponse(err error) *serviceOverloadedResponse {
+	return &serviceOverloadedResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusServiceUnavailable)}
+}
+
+func (r *serviceOverloadedResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -161,33 +161,717 @@ func TestTranscode(t *testing.T) {
 			"",
 			0,
 		},
+		{
+			"New job with language and variant tokens",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "language": "en",
+  "outputs": [
+    {"preset":"mp4_1080p","fileName":"video_{lang}_{variant}.mp4"},
+    {"preset":"mp4_1080p","fileName":"video_{lang}_{variant}.mp4","language":"es","variant":"described"}
+  ],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"video_en_main.mp4", "video_es_described.mp4"},
+			"",
+			0,
+		},
 		{
 			"New job with invalid provider",
 			`{
   "source": "http://another.non.existent/video.mp4",
-  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
-  "outputs": [{"preset":"mp4_1080p"}],
-  "provider": "nonexistent-provider"
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "provider": "nonexistent-provider"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "provider not found"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job missing outputs",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "missing output list from request"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid streaming protocol",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"smooth"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid streaming protocol "smooth"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with min buffer time in HLS",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls","minBufferTime":2},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "minBufferTime is only supported in dash and cmaf jobs"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New DASH job in provider without DASH support",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"dash","minBufferTime":2},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support the streaming protocol "dash"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with a positive loudness target",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "audioQC": {"loudnessTarget":23},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "audioQC loudnessTarget must be negative"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid segment format",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls","segmentFormat":"mkv"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid segment format "mkv"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New DASH job with segment format",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"dash","segmentFormat":"fmp4"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "segmentFormat is only supported in hls jobs"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New DASH job with audio-only rendition",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"dash","audioOnlyRendition":true},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "audioOnlyRendition is only supported in hls jobs"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New HLS job with audio-only bitrate and no audio-only rendition",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls","audioOnlyBitrate":48000},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "audioOnlyBitrate requires audioOnlyRendition"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New HLS job with audio-only rendition in provider without support",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls","audioOnlyRendition":true},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support audio-only HLS renditions`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New MP4 job with described audio",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "describedAudio": {"source":"http://another.non.existent/description.wav"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "describedAudio is only supported in hls and dash jobs"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New HLS job with described audio without source",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "describedAudio": {"language":"eng"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "describedAudio requires a source"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New HLS job with described audio in provider without support",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "describedAudio": {"source":"http://another.non.existent/description.wav"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support described audio`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New fMP4 HLS job in provider without fMP4 support",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls","segmentFormat":"fmp4"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support fmp4 segments`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid DRM scheme",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "drm": {"scheme":"clearkey","key":"00112233445566778899aabbccddeeff","licenseUrl":"https://keys.example.com"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid drm scheme "clearkey"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New Widevine job without key id",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"dash"},
+  "drm": {"scheme":"widevine","key":"00112233445566778899aabbccddeeff","licenseUrl":"https://license.example.com"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `drm scheme "widevine" requires a 16 bytes hex encoded keyId`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New DRM job with invalid key",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "drm": {"scheme":"aes-128","key":"secret","licenseUrl":"https://keys.example.com"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "drm key must be 16 bytes hex encoded"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New DRM job without streaming protocol",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "drm": {"scheme":"aes-128","key":"00112233445566778899aabbccddeeff","licenseUrl":"https://keys.example.com"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "drm requires an adaptive streaming protocol"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New DRM job in provider without DRM support",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "drm": {"scheme":"aes-128","key":"00112233445566778899aabbccddeeff","licenseUrl":"https://keys.example.com"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support the DRM scheme "aes-128"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with empty output headers rule",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "outputHeaders": [{"extension":"mp4"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "outputHeaders rules require cacheControl, contentDisposition or metadata"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid output metadata key",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "outputHeaders": [{"metadata":{"asset id":"123"}}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid outputHeaders metadata key "asset id"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with output headers in provider without support",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "outputHeaders": [{"preset":"mp4_1080p","cacheControl":"max-age=86400"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support output headers`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid caption format",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "captions": [{"source":"http://another.non.existent/captions.scc","format":"scc"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid caption format "scc"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with caption of unknown format",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "captions": [{"source":"http://another.non.existent/captions"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `can't detect the format of caption "http://another.non.existent/captions", please provide it`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid caption mode",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "captions": [{"source":"http://another.non.existent/captions.srt","mode":"open"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid caption mode "open"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with two burned-in captions",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "captions": [{"source":"http://another.non.existent/en.srt","mode":"burn-in"},{"source":"http://another.non.existent/es.srt","mode":"burn-in"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "only one caption can be burned in"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with captions in provider without caption support",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "captions": [{"source":"http://another.non.existent/captions.srt","language":"en"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support sidecar captions`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New published job without S3 destination",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "destination": "gs://videos/2017/",
+  "publish": true,
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "publish requires an s3:// destination"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with thumbnails interval and count",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "thumbnails": {"interval": 10, "count": 5},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "thumbnails interval and count can't be combined"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with thumbnails without interval or count",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "thumbnails": {"width": 320},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "thumbnails require an interval or a count"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid thumbnail format",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "thumbnails": {"interval": 10, "format": "gif"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid thumbnail format "gif"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with thumbnails in provider without thumbnails",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "thumbnails": {"interval": 10},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support thumbnails`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid poster analyzer",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "poster": {"analyzer": "faces"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid poster analyzer "faces"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with poster outside of the destination",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "poster": {"fileName": "../poster.jpg"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "poster fileName must be relative to the destination of the outputs"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid source checksum",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "sourceChecksum": "9f86d081884c7d65",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid source checksum, it must be in the format "algorithm:digest"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with negative clipStart",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "clipStart": -5,
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "clipStart and clipDuration can't be negative"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with clipping and trim",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "clipDuration": 30,
+  "trim": {"black": true},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "clipping can't be combined with conform or trim"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with clipping in provider without clipping",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "clipStart": 10,
+  "clipDuration": 30,
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `provider "fake" doesn't support clipping`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid notification filter",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "callbackURL": "http://example.com/callback",
+  "notifyOn": ["finished", "done"],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid status "done" in notifyOn`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with invalid priority",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "priority": "urgent",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid priority "urgent", it must be low, normal or high`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with preview in provider without previews",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p"},{"preset":"mp4_1080p","fileName":"preview.mp4","preview":{"duration":30}}],
+  "provider": "fake"
 }`,
 			false,
 
 			http.StatusBadRequest,
-			map[string]interface{}{"error": "provider not found"},
+			map[string]interface{}{"error": `provider "fake" doesn't support preview clips`},
 			nil,
 			"",
 			0,
 		},
 		{
-			"New job missing outputs",
+			"New job with preview of adaptive streaming output",
 			`{
   "source": "http://another.non.existent/video.mp4",
-  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"hls_1080p","preview":{"duration":30}}],
+  "streamingParams": {"protocol":"hls"},
   "provider": "fake"
 }`,
 			false,
 
 			http.StatusBadRequest,
-			map[string]interface{}{"error": "missing output list from request"},
+			map[string]interface{}{"error": `preview clips require progressive outputs, but "hls_1080p" is an adaptive streaming preset`},
 			nil,
 			"",
 			0,
@@ -234,7 +918,9 @@ func TestTranscode(t *testing.T) {
 			t.Errorf("%s: missing jobId from the response: %#v", test.givenTestCase, got)
 		}
 		if _, ok := test.wantBody["jobId"]; ok {
-			test.wantBody["jobId"] = got["jobId"]
+			jobID, _ := got["jobId"].(string)
+			test.wantBody["jobId"] = jobID
+			test.wantBody["links"] = finishedJobLinks(jobID)
 		}
 		if !reflect.DeepEqual(got, test.wantBody) {
 			t.Logf("%s: raw response from the api:\n%s", test.givenTestCase, w.Body.Bytes())
@@ -265,6 +951,473 @@ func TestTranscode(t *testing.T) {
 	}
 }
 
+func finishedJobLinks(jobID string) map[string]interface{} {
+	return map[string]interface{}{
+		"self":      "/jobs/" + jobID,
+		"artifacts": "/jobs/" + jobID + "/artifacts",
+	}
+}
+
+func TestExpandFileName(t *testing.T) {
+	var tests = []struct {
+		fileName string
+		language string
+		variant  string
+		expected string
+	}{
+		{"video_{lang}_{variant}.mp4", "pt-BR", "described", "video_pt-BR_described.mp4"},
+		{"{lang}/video_{variant}.mp4", "", "", "und/video_main.mp4"},
+		{"video.mp4", "en", "main", "video.mp4"},
+	}
+	for _, test := range tests {
+		got := expandFileName(test.fileName, test.language, test.variant)
+		if got != test.expected {
+			t.Errorf("expandFileName(%q, %q, %q): want %q. Got %q", test.fileName, test.language, test.variant, test.expected, got)
+		}
+	}
+}
+
+func TestTranscodeTenantDefaults(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode int
+		wantBody map[string]interface{}
+		wantJob  db.Job
+	}{
+		{
+			"source only",
+			`{"source": "http://another.non.existent/video.mp4", "tenant": "newsroom"}`,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			db.Job{
+				ProviderName:    "fake",
+				ProviderJobID:   "provider-preset-job-123",
+				Status:          "finished",
+				Tenant:          "newsroom",
+				SourceMedia:     "http://another.non.existent/video.mp4",
+				Destination:     "s3://newsroom-bucket/videos/",
+				CallbackURL:     "https://newsroom.example.com/callback",
+				Filters:         &db.VideoFilters{Deinterlace: "off"},
+				StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8"},
+				Outputs: []db.TranscodeOutput{
+					{Preset: "mp4_1080p", PresetVersion: 1, FileName: "video_mp4_1080p.mp4"},
+					{Preset: "hls_1080p", PresetVersion: 1, FileName: "hls/video_hls_1080p.m3u8"},
+				},
+			},
+		},
+		{
+			"overriding defaults",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "destination": "s3://other-bucket/",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}]
+}`,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			db.Job{
+				ProviderName:    "fake",
+				ProviderJobID:   "provider-preset-job-123",
+				Status:          "finished",
+				Tenant:          "newsroom",
+				SourceMedia:     "http://another.non.existent/video.mp4",
+				Destination:     "s3://other-bucket/",
+				CallbackURL:     "https://newsroom.example.com/callback",
+				Filters:         &db.VideoFilters{Deinterlace: "off"},
+				StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8"},
+				Outputs:         []db.TranscodeOutput{{Preset: "mp4_1080p", PresetVersion: 1, FileName: "video.mp4"}},
+			},
+		},
+		{
+			"overriding filters",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "filters": {"deinterlace": "detect", "denoise": "weak"},
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}]
+}`,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			db.Job{
+				ProviderName:    "fake",
+				ProviderJobID:   "provider-preset-job-123",
+				Status:          "finished",
+				Tenant:          "newsroom",
+				SourceMedia:     "http://another.non.existent/video.mp4",
+				Destination:     "s3://newsroom-bucket/videos/",
+				CallbackURL:     "https://newsroom.example.com/callback",
+				Filters:         &db.VideoFilters{Deinterlace: "detect", Denoise: "weak"},
+				StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8"},
+				Outputs:         []db.TranscodeOutput{{Preset: "mp4_1080p", PresetVersion: 1, FileName: "video.mp4"}},
+			},
+		},
+		{
+			"invalid filters",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "filters": {"deinterlace": "yadif"}
+}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid deinterlace mode "yadif"`},
+			db.Job{},
+		},
+		{
+			"explicit rotation",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "rotate": 90,
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}]
+}`,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			db.Job{
+				ProviderName:    "fake",
+				ProviderJobID:   "provider-preset-job-123",
+				Status:          "finished",
+				Tenant:          "newsroom",
+				SourceMedia:     "http://another.non.existent/video.mp4",
+				Destination:     "s3://newsroom-bucket/videos/",
+				CallbackURL:     "https://newsroom.example.com/callback",
+				Filters:         &db.VideoFilters{Deinterlace: "off"},
+				Rotation:        &db.Rotation{Autorotate: true, Degrees: 90},
+				StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8"},
+				Outputs:         []db.TranscodeOutput{{Preset: "mp4_1080p", PresetVersion: 1, FileName: "video.mp4"}},
+			},
+		},
+		{
+			"autorotation disabled",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "autorotate": false,
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}]
+}`,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			db.Job{
+				ProviderName:    "fake",
+				ProviderJobID:   "provider-preset-job-123",
+				Status:          "finished",
+				Tenant:          "newsroom",
+				SourceMedia:     "http://another.non.existent/video.mp4",
+				Destination:     "s3://newsroom-bucket/videos/",
+				CallbackURL:     "https://newsroom.example.com/callback",
+				Filters:         &db.VideoFilters{Deinterlace: "off"},
+				Rotation:        &db.Rotation{},
+				StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8"},
+				Outputs:         []db.TranscodeOutput{{Preset: "mp4_1080p", PresetVersion: 1, FileName: "video.mp4"}},
+			},
+		},
+		{
+			"invalid rotation",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "rotate": 45
+}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "invalid rotate 45, it must be 90, 180 or 270"},
+			db.Job{},
+		},
+		{
+			"audio tracks",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "language": "en",
+  "audioTracks": [{"track": 1}, {"track": 2, "language": "es", "name": "Español"}],
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}]
+}`,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			db.Job{
+				ProviderName:    "fake",
+				ProviderJobID:   "provider-preset-job-123",
+				Status:          "finished",
+				Tenant:          "newsroom",
+				SourceMedia:     "http://another.non.existent/video.mp4",
+				Destination:     "s3://newsroom-bucket/videos/",
+				CallbackURL:     "https://newsroom.example.com/callback",
+				Language:        "en",
+				Filters:         &db.VideoFilters{Deinterlace: "off"},
+				AudioTracks:     []db.AudioTrack{{Track: 1, Language: "en", Name: "en", Default: true}, {Track: 2, Language: "es", Name: "Español"}},
+				StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8"},
+				Outputs:         []db.TranscodeOutput{{Preset: "mp4_1080p", PresetVersion: 1, FileName: "video.mp4", Language: "en"}},
+			},
+		},
+		{
+			"language of the default audio track",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "audioTracks": [{"track": 1, "language": "es"}],
+  "outputs": [{"preset":"mp4_1080p","fileName":"video_{lang}_{variant}.mp4"}]
+}`,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			db.Job{
+				ProviderName:    "fake",
+				ProviderJobID:   "provider-preset-job-123",
+				Status:          "finished",
+				Tenant:          "newsroom",
+				SourceMedia:     "http://another.non.existent/video.mp4",
+				Destination:     "s3://newsroom-bucket/videos/",
+				CallbackURL:     "https://newsroom.example.com/callback",
+				Filters:         &db.VideoFilters{Deinterlace: "off"},
+				AudioTracks:     []db.AudioTrack{{Track: 1, Language: "es", Name: "es", Default: true}},
+				StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6, PlaylistFileName: "hls/index.m3u8"},
+				Outputs:         []db.TranscodeOutput{{Preset: "mp4_1080p", PresetVersion: 1, FileName: "video_es_main.mp4", Language: "es"}},
+			},
+		},
+		{
+			"invalid variant",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "outputs": [{"preset":"mp4_1080p","fileName":"{variant}/video.mp4","variant":"../../other"}]
+}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid variant "../../other", it may only contain letters, digits, underscores and dashes`},
+			db.Job{},
+		},
+		{
+			"invalid caption language",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "captions": [{"source": "http://another.non.existent/video.srt", "language": "en/../.."}],
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}]
+}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid language "en/../..", it may only contain letters, digits, underscores and dashes`},
+			db.Job{},
+		},
+		{
+			"duplicate audio tracks",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "audioTracks": [{"track": 2}, {"track": 2, "language": "es"}]
+}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "duplicate audio track 2"},
+			db.Job{},
+		},
+		{
+			"destination not allowed",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "destination": "s3://attacker-bucket/"
+}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `destination "s3://attacker-bucket/" is not allowed for tenant "newsroom"`},
+			db.Job{},
+		},
+		{
+			"source not allowed",
+			`{"source": "http://169.254.169.254/latest/meta-data", "tenant": "newsroom"}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `source media "http://169.254.169.254/latest/meta-data" is not allowed for tenant "newsroom"`},
+			db.Job{},
+		},
+		{
+			"file name breaking the naming rules",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "tenant": "newsroom",
+  "outputs": [{"preset":"mp4_1080p","fileName":"My Video.mp4"}]
+}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `file name "My Video.mp4" breaks the naming rules of tenant "newsroom" (CMS-safe names: it doesn't match "[a-z0-9_]+\\.mp4")`},
+			db.Job{},
+		},
+		{
+			"unknown tenant",
+			`{"source": "http://another.non.existent/video.mp4", "tenant": "cooking"}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "tenant not found"},
+			db.Job{},
+		},
+		{
+			"no tenant",
+			`{"source": "http://not.allowed.example/video.mp4", "destination": "s3://attacker-bucket/", "provider": "fake", "outputs": [{"preset": "mp4_1080p", "fileName": "video.mp4"}]}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "tenant is required"},
+			db.Job{},
+		},
+	}
+
+	for _, test := range tests {
+		fprovider.jobs = nil
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDBObj := dbtest.NewFakeRepository(false)
+		fakeDBObj.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		fakeDBObj.CreatePresetMap(&db.PresetMap{
+			Name:            "hls_1080p",
+			ProviderMapping: map[string]string{"fake": "19928"},
+			OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+		})
+		fakeDBObj.CreateTenant(&db.Tenant{
+			Name: "newsroom",
+			Defaults: db.JobDefaults{
+				Provider:        "fake",
+				Destination:     "s3://newsroom-bucket/videos/",
+				Ladder:          []string{"mp4_1080p", "hls_1080p"},
+				CallbackURL:     "https://newsroom.example.com/callback",
+				StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6},
+				Filters:         &db.VideoFilters{Deinterlace: "off"},
+			},
+			AllowedDestinations:  []string{"s3://newsroom-bucket/", "s3://other-bucket/"},
+			AllowedSourceDomains: []string{"another.non.existent"},
+			NamingRules: []db.NamingRule{
+				{Description: "CMS-safe names", Extensions: []string{"mp4"}, Pattern: `[a-z0-9_]+\.mp4`},
+			},
+		})
+		service, err := NewTranscodingService(&config.Config{DefaultSegmentDuration: 5}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDBObj
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(test.givenRequestBody))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: expected response code of %d. got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if _, ok := test.wantBody["jobId"]; ok {
+			jobID, _ := got["jobId"].(string)
+			test.wantBody["jobId"] = jobID
+			test.wantBody["links"] = finishedJobLinks(jobID)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		if test.wantCode == http.StatusOK {
+			job, err := fakeDBObj.GetJob(got["jobId"].(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.wantJob.ID = job.ID
+			test.wantJob.CreationTime = job.CreationTime
+			if !reflect.DeepEqual(*job, test.wantJob) {
+				t.Errorf("%s: wrong job recorded\nwant %#v\ngot  %#v", test.givenTestCase, test.wantJob, *job)
+			}
+		}
+	}
+}
+
+func TestTranscodeTenantOfCaller(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenTenant      string
+		givenRequestBody string
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"tenant taken from the caller",
+			"newsroom",
+			`{"source": "http://not.allowed.example/video.mp4", "provider": "fake", "outputs": [{"preset": "mp4_1080p", "fileName": "video.mp4"}]}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `source media "http://not.allowed.example/video.mp4" is not allowed for tenant "newsroom"`},
+		},
+		{
+			"caption not allowed for the tenant",
+			"newsroom",
+			`{"source": "http://another.non.existent/video.mp4", "provider": "fake", "outputs": [{"preset": "mp4_1080p", "fileName": "video.mp4"}], "captions": [{"source": "http://not.allowed.example/en.srt"}]}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `source media "http://not.allowed.example/en.srt" is not allowed for tenant "newsroom"`},
+		},
+		{
+			"tenant of another caller",
+			"newsroom",
+			`{"source": "http://not.allowed.example/video.mp4", "tenant": "archive", "provider": "fake", "outputs": [{"preset": "mp4_1080p", "fileName": "video.mp4"}]}`,
+
+			http.StatusForbidden,
+			map[string]interface{}{"error": `tenant "archive" doesn't match the tenant of the caller ("newsroom")`},
+		},
+		{
+			"caller without tenant",
+			"",
+			`{"source": "http://not.allowed.example/video.mp4", "provider": "fake", "outputs": [{"preset": "mp4_1080p", "fileName": "video.mp4"}]}`,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "tenant is required"},
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDBObj := dbtest.NewFakeRepository(false)
+		fakeDBObj.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		fakeDBObj.CreateTenant(&db.Tenant{Name: "newsroom", AllowedSourceDomains: []string{"another.non.existent"}})
+		fakeDBObj.CreateTenant(&db.Tenant{Name: "archive"})
+		service, err := NewTranscodingService(&config.Config{DefaultSegmentDuration: 5, TenantHeader: "X-Authenticated-Tenant"}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDBObj
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(test.givenRequestBody))
+		r.Header.Set("Content-Type", "application/json")
+		if test.givenTenant != "" {
+			r.Header.Set("X-Authenticated-Tenant", test.givenTenant)
+		}
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: expected response code of %d. got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+	}
+}
+
 func TestGetTranscodeJob(t *testing.T) {
 	tests := []struct {
 		givenTestCase        string
@@ -302,6 +1455,11 @@ func TestGetTranscodeJob(t *testing.T) {
 					"duration":   183e9,
 					"videoCodec": "VP9",
 				},
+				"links": map[string]interface{}{
+					"self":      "/jobs/job-123",
+					"artifacts": "/jobs/job-123/artifacts",
+					"outputs":   "s3://mybucket/some/dir/job-123",
+				},
 			},
 		},
 		{
@@ -385,6 +1543,11 @@ func TestCancelTranscodeJob(t *testing.T) {
 					"duration":   183e9,
 					"videoCodec": "VP9",
 				},
+				"links": map[string]interface{}{
+					"self":      "/jobs/job-123",
+					"artifacts": "/jobs/job-123/artifacts",
+					"outputs":   "s3://mybucket/some/dir/job-123",
+				},
 			},
 		},
 		{
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+// paddingTolerance is the maximum distance, in seconds, between black or
+// silent intervals and the edges of the source for them to be considered
+// padding.
+const paddingTolerance = 0.1
+
+var errTrimProviderDecryption = errors.New("sources decrypted by the provider can't be trimmed")
+
+// trim returns the trimming settings recorded in new jobs.
+func (p *TrimParams) trim() *db.Trim {
+	if p == nil {
+		return nil
+	}
+	return &db.Trim{Black: p.Black, Silence: p.Silence}
+}
+
+// trimSource detects the padding of the source of a job with trimming
+// enabled, recording the trimmed ranges in the job and setting the clip of
+// the profile to the content between the padding.
+func (s *TranscodingService) trimSource(job *db.Job, transcodeProfile *provider.TranscodeProfile) error {
+	if job.Trim == nil {
+		return nil
+	}
+	if transcodeProfile.SourceEncryption != nil {
+		return errTrimProviderDecryption
+	}
+	input := transcodeProfile.SourceMedia
+	var err error
+	if input == job.SourceMedia {
+		// decrypted sources are read from the staging location of the
+		// API, while sources given by the caller must be validated.
+		var release func()
+		if input, release, err = s.sources.ffmpegInput(input); err != nil {
+			return err
+		}
+		defer release()
+	}
+	input, err = s.analyzer.input(input)
+	if err != nil {
+		return err
+	}
+	padding, err := s.analyzer.detectPadding(input, job.Trim.Black, job.Trim.Silence)
+	if err != nil {
+		return fmt.Errorf("error detecting the padding of %q: %s", transcodeProfile.SourceMedia, err)
+	}
+	start, end := contentRange(padding, job.Trim.Black, job.Trim.Silence)
+	if end <= start {
+		return fmt.Errorf("the source %q doesn't have any content between the padding", transcodeProfile.SourceMedia)
+	}
+	trim := db.Trim{Black: job.Trim.Black, Silence: job.Trim.Silence}
+	transcodeProfile.Clip = nil
+	if start > 0 {
+		trim.Trimmed = append(trim.Trimmed, db.TimeRange{Start: 0, End: start})
+	}
+	if end < padding.Duration {
+		trim.Trimmed = append(trim.Trimmed, db.TimeRange{Start: end, End: padding.Duration})
+	}
+	if len(trim.Trimmed) > 0 {
+		transcodeProfile.Clip = &provider.Clip{Start: start}
+		if end < padding.Duration {
+			transcodeProfile.Clip.Duration = end - start
+		}
+	}
+	job.Trim = &trim
+	return nil
+}
+
+// contentRange returns the range of the media between the leading and the
+// trailing padding. When both black frames and silence are trimmed, only
+// the padding that is both black and silent is excluded.
+func contentRange(padding *ffmpeg.Padding, black, silence bool) (float64, float64) {
+	var kinds [][]ffmpeg.Interval
+	if black {
+		kinds = append(kinds, padding.Black)
+	}
+	if silence {
+		kinds = append(kinds, padding.Silence)
+	}
+	start, end := padding.Duration, 0.0
+	for _, intervals := range kinds {
+		leading, trailing := 0.0, padding.Duration
+		for _, interval := range intervals {
+			if interval.Start <= paddingTolerance {
+				leading = interval.End
+			}
+			if interval.End >= padding.Duration-paddingTolerance {
+				trailing = interval.Start
+			}
+		}
+		if leading < start {
+			start = leading
+		}
+		if trailing > end {
+			end = trailing
+		}
+	}
+	return start, end
+}
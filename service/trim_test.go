@@ -0,0 +1,172 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestContentRange(t *testing.T) {
+	padding := ffmpeg.Padding{
+		Duration: 62.5,
+		Black:    []ffmpeg.Interval{{Start: 0, End: 2.04}, {Start: 30, End: 31}, {Start: 60, End: 62.5}},
+		Silence:  []ffmpeg.Interval{{Start: 0, End: 1.92}, {Start: 59.48, End: 62.5}},
+	}
+	var tests = []struct {
+		testCase      string
+		padding       ffmpeg.Padding
+		black         bool
+		silence       bool
+		expectedStart float64
+		expectedEnd   float64
+	}{
+		{"black", padding, true, false, 2.04, 60},
+		{"silence", padding, false, true, 1.92, 59.48},
+		{"black and silence", padding, true, true, 1.92, 60},
+		{"no padding", ffmpeg.Padding{Duration: 62.5, Black: []ffmpeg.Interval{{Start: 30, End: 31}}}, true, true, 0, 62.5},
+		{"leading black only", ffmpeg.Padding{Duration: 62.5, Black: []ffmpeg.Interval{{Start: 0.04, End: 1}}}, true, false, 1, 62.5},
+		{"black and silent", ffmpeg.Padding{Duration: 10, Black: []ffmpeg.Interval{{Start: 0, End: 10}}}, true, false, 10, 0},
+	}
+	for _, test := range tests {
+		start, end := contentRange(&test.padding, test.black, test.silence)
+		if start != test.expectedStart || end != test.expectedEnd {
+			t.Errorf("%s: wrong range. Want [%g, %g]. Got [%g, %g]", test.testCase, test.expectedStart, test.expectedEnd, start, end)
+		}
+	}
+}
+
+func TestTranscodeTrim(t *testing.T) {
+	var tests = []struct {
+		testCase        string
+		trim            string
+		padding         ffmpeg.Padding
+		detectErr       error
+		expectedStatus  int
+		expectedClip    *provider.Clip
+		expectedTrimmed []db.TimeRange
+		expectedError   string
+	}{
+		{
+			"leading and trailing padding",
+			`{"black":true,"silence":true}`,
+			ffmpeg.Padding{
+				Duration: 62.5,
+				Black:    []ffmpeg.Interval{{Start: 0, End: 2.04}, {Start: 60, End: 62.5}},
+				Silence:  []ffmpeg.Interval{{Start: 0, End: 1.92}, {Start: 59.48, End: 62.5}},
+			},
+			nil,
+			http.StatusOK,
+			&provider.Clip{Start: 1.92, Duration: 58.08},
+			[]db.TimeRange{{Start: 0, End: 1.92}, {Start: 60, End: 62.5}},
+			"",
+		},
+		{
+			"no padding",
+			`{"black":true}`,
+			ffmpeg.Padding{Duration: 62.5},
+			nil,
+			http.StatusOK,
+			nil,
+			nil,
+			"",
+		},
+		{
+			"nothing to trim",
+			`{}`,
+			ffmpeg.Padding{},
+			nil,
+			http.StatusBadRequest,
+			nil,
+			nil,
+			"trim requires black frames, silence or both to be trimmed",
+		},
+		{
+			"entirely black source",
+			`{"black":true}`,
+			ffmpeg.Padding{Duration: 10, Black: []ffmpeg.Interval{{Start: 0, End: 10}}},
+			nil,
+			http.StatusInternalServerError,
+			nil,
+			nil,
+			`the source "s3://wire-bucket/story.mp4" doesn't have any content between the padding`,
+		},
+		{
+			"detection failure",
+			`{"silence":true}`,
+			ffmpeg.Padding{},
+			errors.New("ffmpeg failed: exit status 1"),
+			http.StatusInternalServerError,
+			nil,
+			nil,
+			`error detecting the padding of "s3://wire-bucket/story.mp4": ffmpeg failed: exit status 1`,
+		},
+	}
+	for _, test := range tests {
+		fprovider.jobs = nil
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "18828"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		var gotInput string
+		padding, detectErr := test.padding, test.detectErr
+		service.analyzer.presign = func(bucket, key string) (string, error) {
+			return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+		}
+		service.analyzer.detectPadding = func(input string, black, silence bool) (*ffmpeg.Padding, error) {
+			gotInput = input
+			return &padding, detectErr
+		}
+		srvr.Register(service)
+		body := `{"source":"s3://wire-bucket/story.mp4","provider":"fake","trim":` + test.trim + `,"outputs":[{"preset":"mp4_1080p","fileName":"story.mp4"}]}`
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.expectedStatus {
+			t.Errorf("%s: wrong response code. Want %d. Got %d: %s", test.testCase, test.expectedStatus, w.Code, w.Body.String())
+			continue
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if test.expectedError != "" {
+			if got["error"] != test.expectedError {
+				t.Errorf("%s: wrong error. Want %q. Got %q", test.testCase, test.expectedError, got["error"])
+			}
+			continue
+		}
+		if gotInput != "https://wire-bucket.s3.amazonaws.com/story.mp4" {
+			t.Errorf("%s: wrong input for the detection: %q", test.testCase, gotInput)
+		}
+		if len(fprovider.jobs) != 1 || !reflect.DeepEqual(fprovider.jobs[0].Clip, test.expectedClip) {
+			t.Errorf("%s: wrong clip submitted to the provider. Want %#v. Got %#v", test.testCase, test.expectedClip, fprovider.jobs)
+		}
+		job, err := fakeDB.GetJob(got["jobId"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Trim == nil || !reflect.DeepEqual(job.Trim.Trimmed, test.expectedTrimmed) {
+			t.Errorf("%s: wrong trimmed ranges recorded in the job. Want %#v. Got %#v", test.testCase, test.expectedTrimmed, job.Trim)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/netstorage"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/transfer"
+)
+
+// transferBackend delivers outputs to destinations in a given scheme. When
+// set, open reads the staged outputs instead of the default reader of the
+// uploader, and remove deletes each staged output once it's uploaded.
+type transferBackend struct {
+	name     string
+	staging  string
+	uploader transfer.Uploader
+	open     func(rawURL string) (io.ReadCloser, int64, error)
+	remove   func(rawURL string) error
+}
+
+// outputUploader uploads the outputs of jobs with destinations that
+// providers can't write to, like NetStorage or Aspera servers. Providers
+// write the outputs to a staging destination, and the files are uploaded by
+// the upload worker once the job finishes. The status of the uploads is
+// stored in the job, and active lists the jobs being uploaded by this
+// instance.
+type outputUploader struct {
+	backends    map[string]*transferBackend
+	encryption  *outputEncryption
+	maxAttempts int
+	mtx         sync.Mutex
+	active      map[string]bool
+	open        func(rawURL string) (io.ReadCloser, int64, error)
+}
+
+func newOutputUploader(cfg *config.Config) *outputUploader {
+	u := outputUploader{
+		backends:    make(map[string]*transferBackend),
+		encryption:  newOutputEncryption(cfg.OutputEncryption),
+		maxAttempts: 1,
+		active:      make(map[string]bool),
+		open:        openOutput,
+	}
+	if cfg.Uploads != nil && cfg.Uploads.MaxAttempts > 1 {
+		u.maxAttempts = cfg.Uploads.MaxAttempts
+	}
+	if c := cfg.NetStorage; c != nil && c.Host != "" && c.StagingDestination != "" {
+		u.backends["netstorage"] = &transferBackend{
+			name:     "NetStorage",
+			staging:  c.StagingDestination,
+			uploader: netstorage.NewClient(c.Host, c.KeyName, c.Key),
+		}
+	}
+	if c := cfg.Aspera; c != nil && c.Host != "" && c.StagingDestination != "" {
+		u.backends["fasp"] = &transferBackend{
+			name:     "Aspera",
+			staging:  c.StagingDestination,
+			uploader: transfer.NewAspera(c.Host, c.User, c.Password, c.KeyFile, c.TargetRate),
+		}
+	}
+	if c := cfg.Signiant; c != nil && c.Command != "" && c.StagingDestination != "" {
+		u.backends["signiant"] = &transferBackend{
+			name:     "Signiant",
+			staging:  c.StagingDestination,
+			uploader: transfer.NewSigniant(c.Command),
+		}
+	}
+	return &u
+}
+
+// isTransferDestination returns whether the given destination uses a
+// scheme handled by the API instead of the provider.
+func isTransferDestination(destination string) bool {
+	for _, scheme := range []string{"netstorage", "fasp", "signiant"} {
+		if strings.HasPrefix(destination, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// backend returns the backend and the remote path of the given
+// destination (<scheme>://<path>).
+func (u *outputUploader) backend(destination string) (*transferBackend, string, error) {
+	parts := strings.SplitN(destination, "://", 2)
+	if len(parts) != 2 {
+		return nil, "", fmt.Errorf("invalid destination %q", destination)
+	}
+	backend, ok := u.backends[parts[0]]
+	if !ok {
+		return nil, "", fmt.Errorf("%s:// destinations are not enabled", parts[0])
+	}
+	path := strings.Trim(parts[1], "/")
+	if path == "" {
+		return nil, "", fmt.Errorf("invalid destination %q", destination)
+	}
+	return backend, "/" + path + "/", nil
+}
+
+// jobBackend returns the backend and the remote path of the upload
+// destination of the given job.
+func (u *outputUploader) jobBackend(job *db.Job) (*transferBackend, string, error) {
+	if job.OutputEncryption != nil {
+		return u.encryption.backend(job)
+	}
+	return u.backend(job.UploadDestination)
+}
+
+// stagingDestination returns the destination that providers should write
+// to, for the given destination.
+func (u *outputUploader) stagingDestination(destination string) (string, error) {
+	backend, path, err := u.backend(destination)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(backend.staging, "/") + path, nil
+}
+
+// sync reports the status of the uploads of the outputs of the given job
+// once it finishes. The job is reported as started until all files are
+// uploaded, and as failed if any upload fails in all its attempts.
+func (u *outputUploader) sync(job *db.Job, status *provider.JobStatus) {
+	if job.UploadDestination == "" || status.Status != provider.StatusFinished {
+		return
+	}
+	backend, _, err := u.jobBackend(job)
+	if err != nil {
+		status.Status = provider.StatusFailed
+		status.StatusMessage = "failed to upload outputs: " + err.Error()
+		return
+	}
+	var failed []string
+	pending := len(job.Uploads) == 0
+	status.Uploads = make([]provider.FileUpload, len(job.Uploads))
+	for i, upload := range job.Uploads {
+		status.Uploads[i] = provider.FileUpload{
+			Path:        upload.Path,
+			Destination: upload.Destination,
+			Status:      provider.UploadStatus(upload.Status),
+			Error:       upload.Error,
+		}
+		if u.retry(upload) {
+			pending = true
+		} else if upload.Status == string(provider.UploadFailed) {
+			failed = append(failed, upload.Error)
+		}
+	}
+	if pending {
+		status.Status = provider.StatusStarted
+		status.StatusMessage = "uploading outputs to " + backend.name
+	} else if len(failed) > 0 {
+		status.Status = provider.StatusFailed
+		status.StatusMessage = "failed to upload outputs to " + backend.name + ": " + strings.Join(failed, "; ")
+	}
+}
+
+// retry returns whether the given file still has to be uploaded.
+func (u *outputUploader) retry(upload db.FileUpload) bool {
+	switch provider.UploadStatus(upload.Status) {
+	case provider.UploadFinished:
+		return false
+	case provider.UploadFailed:
+		return upload.Attempts < u.maxAttempts
+	}
+	return true
+}
+
+// plan maps the files written by the provider to their remote paths.
+func (u *outputUploader) plan(staging, path string, files []provider.OutputFile) []db.FileUpload {
+	staging = strings.TrimRight(staging, "/") + "/"
+	uploads := make([]db.FileUpload, len(files))
+	for i, file := range files {
+		uploads[i] = db.FileUpload{
+			Path:        file.Path,
+			Destination: path + strings.TrimPrefix(file.Path, staging),
+			Status:      string(provider.UploadPending),
+		}
+	}
+	return uploads
+}
+
+// start marks the given job as being uploaded by this instance, returning
+// false when it already is.
+func (u *outputUploader) start(jobID string) bool {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	if u.active[jobID] {
+		return false
+	}
+	u.active[jobID] = true
+	return true
+}
+
+func (u *outputUploader) done(jobID string) {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	delete(u.active, jobID)
+}
+
+// uploadFile uploads the given staged output to its destination. Uploads
+// to backends that remove the staged outputs fail when the removal fails,
+// so the file is uploaded (and removed) again in the next attempt.
+func (u *outputUploader) uploadFile(backend *transferBackend, source, destination string, headers *transfer.Headers) error {
+	open := u.open
+	if backend.open != nil {
+		open = backend.open
+	}
+	body, size, err := open(source)
+	if err != nil {
+		return err
+	}
+	if headerUploader, ok := backend.uploader.(transfer.HeaderUploader); ok && headers != nil {
+		err = headerUploader.UploadWithHeaders(destination, body, size, *headers)
+	} else {
+		err = backend.uploader.Upload(destination, body, size)
+	}
+	body.Close()
+	if err != nil || backend.remove == nil {
+		return err
+	}
+	if err = backend.remove(source); err != nil {
+		return fmt.Errorf("uploaded, but failed to remove the staged file: %s", err)
+	}
+	return nil
+}
+
+// RunUploads periodically uploads the outputs of the finished jobs with
+// upload destinations, until the given channel is closed. It returns
+// immediately when the worker is disabled in the configuration.
+func (s *TranscodingService) RunUploads(stop <-chan struct{}) {
+	cfg := s.config.Uploads
+	if cfg == nil || cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.uploadOutputs()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// uploadOutputs starts uploading the outputs of the active jobs with upload
+// destinations, in the background. It doesn't run while the API is in
+// maintenance mode.
+func (s *TranscodingService) uploadOutputs() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	jobs, err := s.db.ListJobs(db.JobFilter{})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list jobs for uploading their outputs")
+		return
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.UploadDestination == "" || job.ProviderJobID == "" || isTerminal(provider.Status(job.Status)) {
+			continue
+		}
+		if !s.uploader.start(job.ID) {
+			continue
+		}
+		go func() {
+			defer s.uploader.done(job.ID)
+			if err := s.uploadJobOutputs(job); err != nil {
+				s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to upload the outputs of the job")
+			}
+		}()
+	}
+}
+
+// uploadJobOutputs uploads the outputs of the given job, one at a time,
+// once it finishes in the provider. The status of each upload is stored in
+// the job before and after the upload.
+func (s *TranscodingService) uploadJobOutputs(job *db.Job) error {
+	backend, path, err := s.uploader.jobBackend(job)
+	if err != nil {
+		return err
+	}
+	if job.Uploads == nil {
+		p, err := s.initEnvironmentProvider(job.ProviderName, job.Environment)
+		if err != nil {
+			return err
+		}
+		status, err := p.JobStatus(job)
+		if err != nil {
+			return err
+		}
+		if status.Status != provider.StatusFinished {
+			return nil
+		}
+		job.Uploads = s.uploader.plan(job.Destination, path, status.Output.Files)
+		if err = s.saveUploads(job); err != nil {
+			return err
+		}
+	}
+	for i := range job.Uploads {
+		upload := &job.Uploads[i]
+		if !s.uploader.retry(*upload) {
+			continue
+		}
+		upload.Status = string(provider.UploadStarted)
+		upload.Error = ""
+		upload.Attempts++
+		if err = s.saveUploads(job); err != nil {
+			return err
+		}
+		err = s.uploader.uploadFile(backend, upload.Path, upload.Destination, uploadHeaders(job, upload.Path))
+		if err != nil {
+			upload.Status = string(provider.UploadFailed)
+			upload.Error = fmt.Sprintf("%s: %s", upload.Path, err)
+		} else {
+			upload.Status = string(provider.UploadFinished)
+		}
+		if err = s.saveUploads(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveUploads stores the status of the uploads of the given job, keeping
+// the other fields of the job as stored.
+func (s *TranscodingService) saveUploads(job *db.Job) error {
+	stored, err := s.db.GetJob(job.ID)
+	if err != nil {
+		return err
+	}
+	stored.Uploads = job.Uploads
+	return s.db.UpdateJob(stored)
+}
+
+// openOutput opens the output in the given URL for reading. S3 URLs
+// (s3://bucket/key) are translated to their HTTPS equivalent. Encrypted
+// outputs are never read this way, their staging destination is read with
+// credentials (see outputEncryption).
+func openOutput(rawURL string) (io.ReadCloser, int64, error) {
+	if strings.HasPrefix(rawURL, "s3://") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, 0, err
+		}
+		rawURL = "https://" + u.Host + ".s3.amazonaws.com" + u.Path
+	}
+	resp, err := http.Get(rawURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("error fetching %q: %s", rawURL, resp.Status)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/transfer"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestOutputUploaderStagingDestination(t *testing.T) {
+	var tests = []struct {
+		testCase        string
+		givenConfig     config.Config
+		givenDest       string
+		wantDestination string
+		wantErr         string
+	}{
+		{
+			"valid destination",
+			config.Config{NetStorage: &config.NetStorage{Host: "example-nsu.akamaihd.net", StagingDestination: "s3://staging/netstorage"}},
+			"netstorage://123456/videos/",
+			"s3://staging/netstorage/123456/videos/",
+			"",
+		},
+		{
+			"Aspera destination",
+			config.Config{Aspera: &config.Aspera{Host: "aspera.example.com", StagingDestination: "s3://staging/aspera/"}},
+			"fasp://archive/videos",
+			"s3://staging/aspera/archive/videos/",
+			"",
+		},
+		{
+			"Signiant destination",
+			config.Config{Signiant: &config.Signiant{Command: "sigcli put {source} {destination}", StagingDestination: "s3://staging/signiant"}},
+			"signiant://archive/videos",
+			"s3://staging/signiant/archive/videos/",
+			"",
+		},
+		{
+			"destination without path",
+			config.Config{NetStorage: &config.NetStorage{Host: "example-nsu.akamaihd.net", StagingDestination: "s3://staging/netstorage/"}},
+			"netstorage://",
+			"",
+			`invalid destination "netstorage://"`,
+		},
+		{
+			"NetStorage not configured",
+			config.Config{NetStorage: &config.NetStorage{}},
+			"netstorage://123456/videos/",
+			"",
+			"netstorage:// destinations are not enabled",
+		},
+	}
+	for _, test := range tests {
+		uploader := newOutputUploader(&test.givenConfig)
+		destination, err := uploader.stagingDestination(test.givenDest)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.wantErr {
+			t.Errorf("%s: wrong error. Want %q. Got %q", test.testCase, test.wantErr, err.Error())
+		}
+		if destination != test.wantDestination {
+			t.Errorf("%s: wrong destination. Want %q. Got %q", test.testCase, test.wantDestination, destination)
+		}
+	}
+}
+
+func TestOutputUploaderSync(t *testing.T) {
+	var tests = []struct {
+		testCase     string
+		givenUploads []db.FileUpload
+		wantStatus   provider.Status
+		wantMessage  string
+	}{
+		{
+			"uploads not planned",
+			nil,
+			provider.StatusStarted,
+			"uploading outputs to NetStorage",
+		},
+		{
+			"pending uploads",
+			[]db.FileUpload{
+				{Path: "s3://staging/123456/videos/video_1080p.mp4", Status: "finished", Attempts: 1},
+				{Path: "s3://staging/123456/videos/hls/index.m3u8", Status: "failed", Error: "403 Forbidden", Attempts: 1},
+			},
+			provider.StatusStarted,
+			"uploading outputs to NetStorage",
+		},
+		{
+			"all files uploaded",
+			[]db.FileUpload{
+				{Path: "s3://staging/123456/videos/video_1080p.mp4", Status: "finished", Attempts: 1},
+				{Path: "s3://staging/123456/videos/hls/index.m3u8", Status: "finished", Attempts: 2},
+			},
+			provider.StatusFinished,
+			"",
+		},
+		{
+			"failed upload",
+			[]db.FileUpload{
+				{Path: "s3://staging/123456/videos/video_1080p.mp4", Status: "finished", Attempts: 1},
+				{Path: "s3://staging/123456/videos/hls/index.m3u8", Status: "failed", Error: "403 Forbidden", Attempts: 2},
+			},
+			provider.StatusFailed,
+			"failed to upload outputs to NetStorage: 403 Forbidden",
+		},
+	}
+	uploader := newOutputUploader(&config.Config{Uploads: &config.Uploads{MaxAttempts: 2}})
+	uploader.backends["netstorage"] = &transferBackend{name: "NetStorage"}
+	for _, test := range tests {
+		job := db.Job{
+			ID:                "job-123",
+			Destination:       "s3://staging/123456/videos/",
+			UploadDestination: "netstorage://123456/videos",
+			Uploads:           test.givenUploads,
+		}
+		status := provider.JobStatus{Status: provider.StatusFinished}
+		uploader.sync(&job, &status)
+		if status.Status != test.wantStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q", test.testCase, test.wantStatus, status.Status)
+		}
+		if status.StatusMessage != test.wantMessage {
+			t.Errorf("%s: wrong status message. Want %q. Got %q", test.testCase, test.wantMessage, status.StatusMessage)
+		}
+		if len(status.Uploads) != len(test.givenUploads) {
+			t.Errorf("%s: wrong number of uploads. Want %d. Got %d", test.testCase, len(test.givenUploads), len(status.Uploads))
+		}
+	}
+}
+
+func TestUploadOutputs(t *testing.T) {
+	var tests = []struct {
+		testCase    string
+		givenFails  int
+		wantUploads []db.FileUpload
+	}{
+		{
+			"all files uploaded",
+			0,
+			[]db.FileUpload{
+				{Path: "s3://mybucket/some/dir/job-123/video_720p.mp4", Destination: "/123456/videos/video_720p.mp4", Status: "finished", Attempts: 1},
+				{Path: "s3://mybucket/some/dir/job-123/video_1080p.webm", Destination: "/123456/videos/video_1080p.webm", Status: "finished", Attempts: 1},
+			},
+		},
+		{
+			"upload retried",
+			1,
+			[]db.FileUpload{
+				{Path: "s3://mybucket/some/dir/job-123/video_720p.mp4", Destination: "/123456/videos/video_720p.mp4", Status: "finished", Attempts: 1},
+				{Path: "s3://mybucket/some/dir/job-123/video_1080p.webm", Destination: "/123456/videos/video_1080p.webm", Status: "finished", Attempts: 2},
+			},
+		},
+		{
+			"upload failed in all attempts",
+			2,
+			[]db.FileUpload{
+				{Path: "s3://mybucket/some/dir/job-123/video_720p.mp4", Destination: "/123456/videos/video_720p.mp4", Status: "finished", Attempts: 1},
+				{Path: "s3://mybucket/some/dir/job-123/video_1080p.webm", Destination: "/123456/videos/video_1080p.webm", Status: "failed", Error: "s3://mybucket/some/dir/job-123/video_1080p.webm: 403 Forbidden", Attempts: 2},
+			},
+		},
+	}
+	for _, test := range tests {
+		service, err := NewTranscodingService(&config.Config{Uploads: &config.Uploads{MaxAttempts: 2}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		service.db.CreateJob(&db.Job{
+			ID:                "job-123",
+			ProviderName:      "fake",
+			ProviderJobID:     "provider-job-with-outputs",
+			Destination:       "s3://mybucket/some/dir/job-123/",
+			UploadDestination: "netstorage://123456/videos",
+			Status:            "started",
+		})
+		var mtx sync.Mutex
+		uploaded := make(map[string]string)
+		fails := test.givenFails
+		service.uploader.open = func(rawURL string) (io.ReadCloser, int64, error) {
+			return ioutil.NopCloser(strings.NewReader(rawURL)), int64(len(rawURL)), nil
+		}
+		service.uploader.backends["netstorage"] = &transferBackend{
+			name: "NetStorage",
+			uploader: transfer.UploaderFunc(func(path string, body io.Reader, size int64) error {
+				mtx.Lock()
+				defer mtx.Unlock()
+				if strings.HasSuffix(path, ".webm") && fails > 0 {
+					fails--
+					return errors.New("403 Forbidden")
+				}
+				data, _ := ioutil.ReadAll(body)
+				uploaded[path] = string(data)
+				return nil
+			}),
+		}
+		for i := 0; i < 3; i++ {
+			service.uploadOutputs()
+			waitUploads(service.uploader)
+		}
+		job, err := service.db.GetJob("job-123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(job.Uploads, test.wantUploads) {
+			t.Errorf("%s: wrong uploads.\nWant %#v\nGot  %#v", test.testCase, test.wantUploads, job.Uploads)
+		}
+		service.uploader.mtx.Lock()
+		if len(service.uploader.active) > 0 {
+			t.Errorf("%s: finished uploads not evicted: %#v", test.testCase, service.uploader.active)
+		}
+		service.uploader.mtx.Unlock()
+		mtx.Lock()
+		if got := uploaded["/123456/videos/video_720p.mp4"]; got != "s3://mybucket/some/dir/job-123/video_720p.mp4" {
+			t.Errorf("%s: wrong content uploaded: %q", test.testCase, got)
+		}
+		mtx.Unlock()
+	}
+}
+
+// waitUploads waits for the uploads running in the background.
+func waitUploads(uploader *outputUploader) {
+	for i := 0; i < 100; i++ {
+		uploader.mtx.Lock()
+		active := len(uploader.active)
+		uploader.mtx.Unlock()
+		if active == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
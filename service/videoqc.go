@@ -0,0 +1,148 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+)
+
+const (
+	videoQCArtifactKind = "video-qc"
+	videoQCSource       = "ffmpeg"
+
+	defaultMaxBlack  = 2
+	defaultMaxFreeze = 2
+
+	// minMacroblockedDuration is the duration from which ranges of
+	// macroblocked frames are violations, so blocky frames on scene
+	// changes don't count.
+	minMacroblockedDuration = 0.5
+)
+
+// videoQC returns the video QC settings recorded in new jobs.
+func (p *VideoQCParams) videoQC() *db.VideoQC {
+	if p == nil {
+		return nil
+	}
+	qc := db.VideoQC{
+		MaxBlack:      p.MaxBlack,
+		MaxFreeze:     p.MaxFreeze,
+		MaxBlockiness: p.MaxBlockiness,
+		Reject:        p.Reject,
+	}
+	if qc.MaxBlack == 0 {
+		qc.MaxBlack = defaultMaxBlack
+	}
+	if qc.MaxFreeze == 0 {
+		qc.MaxFreeze = defaultMaxFreeze
+	}
+	return &qc
+}
+
+// checkVideo checks the video outputs of the given job once it finishes.
+// Reports are attached to the job as artifacts, and the job is reported as
+// started until the check is done. Violations are reported as warnings of
+// the job, or fail it when the QC rejects them.
+func (s *TranscodingService) checkVideo(job *db.Job, status *provider.JobStatus) {
+	qc := job.VideoQC
+	if qc == nil || status.Status != provider.StatusFinished {
+		return
+	}
+	if !qc.Checked {
+		files := status.Output.Files
+		blockiness := qc.MaxBlockiness
+		done, result, err := s.videoQCRuns.poll(job.ID, func() (interface{}, error) {
+			return s.analyzeVideo(files, blockiness)
+		})
+		if !done {
+			status.Status = provider.StatusStarted
+			status.StatusMessage = "checking the video of the outputs"
+			return
+		}
+		if err != nil {
+			status.Status = provider.StatusFailed
+			status.StatusMessage = "failed to check the video of the outputs: " + err.Error()
+			return
+		}
+		reports := result.([]db.VideoQCReport)
+		for _, report := range reports {
+			if err = s.db.CreateArtifact(videoQCArtifact(job.ID, report)); err != nil && err != db.ErrArtifactAlreadyExists {
+				s.logger.WithError(err).WithField("jobId", job.ID).Error("failed to attach video QC report")
+			}
+		}
+		qc.Checked = true
+		qc.Violations = videoQCViolations(qc, reports)
+	}
+	if len(qc.Violations) == 0 {
+		return
+	}
+	if qc.Reject {
+		status.Status = provider.StatusFailed
+		status.StatusMessage = "video QC failed: " + strings.Join(qc.Violations, "; ")
+		return
+	}
+	status.Warnings = append(status.Warnings, qc.Violations...)
+}
+
+// analyzeVideo checks the video files with ffmpeg, one at a time.
+func (s *TranscodingService) analyzeVideo(files []provider.OutputFile, blockiness float64) ([]db.VideoQCReport, error) {
+	var reports []db.VideoQCReport
+	for _, file := range files {
+		if !fingerprintContainers[strings.ToLower(file.Container)] {
+			continue
+		}
+		input, err := s.analyzer.input(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		analysis, err := s.analyzer.analyzeVideo(input, blockiness)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+		reports = append(reports, db.VideoQCReport{
+			Path:          file.Path,
+			Black:         timeRanges(analysis.Black),
+			Frozen:        timeRanges(analysis.Frozen),
+			Macroblocked:  timeRanges(analysis.Blocky),
+			MaxBlockiness: analysis.MaxBlockiness,
+		})
+	}
+	return reports, nil
+}
+
+// videoQCViolations returns the thresholds violated by the given reports.
+func videoQCViolations(qc *db.VideoQC, reports []db.VideoQCReport) []string {
+	var violations []string
+	for _, report := range reports {
+		name := path.Base(report.Path)
+		check := func(ranges []db.TimeRange, max float64, defect string) {
+			for _, r := range ranges {
+				if r.End-r.Start > max {
+					violations = append(violations, fmt.Sprintf("%s: %s for %.1fs at %.1fs", name, defect, r.End-r.Start, r.Start))
+				}
+			}
+		}
+		check(report.Black, qc.MaxBlack, "black")
+		check(report.Frozen, qc.MaxFreeze, "frozen")
+		check(report.Macroblocked, minMacroblockedDuration, "macroblocked")
+	}
+	return violations
+}
+
+func videoQCArtifact(jobID string, report db.VideoQCReport) *db.Artifact {
+	data, _ := json.Marshal(report)
+	return &db.Artifact{
+		JobID:        jobID,
+		Name:         videoQCArtifactKind + "-" + path.Base(report.Path),
+		Kind:         videoQCArtifactKind,
+		Source:       videoQCSource,
+		ContentType:  "application/json",
+		Data:         data,
+		CreationTime: time.Now().UTC(),
+	}
+}
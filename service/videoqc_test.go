@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/ffmpeg"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestCheckVideo(t *testing.T) {
+	defective := ffmpeg.VideoAnalysis{
+		Black:         []ffmpeg.Interval{{Start: 0, End: 1}, {Start: 30, End: 34}},
+		Frozen:        []ffmpeg.Interval{{Start: 12, End: 15.5}},
+		Blocky:        []ffmpeg.Interval{{Start: 20, End: 20.2}, {Start: 40, End: 42}},
+		MaxBlockiness: 9.1,
+	}
+	violations := []string{
+		"video.mp4: black for 4.0s at 30.0s",
+		"video.mp4: frozen for 3.5s at 12.0s",
+		"video.mp4: macroblocked for 2.0s at 40.0s",
+	}
+	var tests = []struct {
+		testCase       string
+		givenQC        VideoQCParams
+		givenAnalysis  ffmpeg.VideoAnalysis
+		givenError     error
+		wantStatus     provider.Status
+		wantMessage    string
+		wantWarnings   []string
+		wantBlockiness float64
+		wantArtifacts  int
+	}{
+		{
+			"video within the thresholds",
+			VideoQCParams{MaxBlockiness: 8},
+			ffmpeg.VideoAnalysis{Black: []ffmpeg.Interval{{Start: 0, End: 1}}, MaxBlockiness: 2},
+			nil,
+			provider.StatusFinished,
+			"",
+			nil,
+			8,
+			1,
+		},
+		{
+			"video violating the thresholds",
+			VideoQCParams{MaxBlockiness: 8},
+			defective,
+			nil,
+			provider.StatusFinished,
+			"",
+			violations,
+			8,
+			1,
+		},
+		{
+			"video rejected",
+			VideoQCParams{MaxBlockiness: 8, Reject: true},
+			defective,
+			nil,
+			provider.StatusFailed,
+			"video QC failed: video.mp4: black for 4.0s at 30.0s; video.mp4: frozen for 3.5s at 12.0s; video.mp4: macroblocked for 2.0s at 40.0s",
+			nil,
+			8,
+			1,
+		},
+		{
+			"failed analysis",
+			VideoQCParams{},
+			ffmpeg.VideoAnalysis{},
+			errors.New("ffmpeg failed: exit status 1"),
+			provider.StatusFailed,
+			"failed to check the video of the outputs: s3://bucket/job-123/video.mp4: ffmpeg failed: exit status 1",
+			nil,
+			0,
+			0,
+		},
+	}
+	for _, test := range tests {
+		analysis, analysisErr := test.givenAnalysis, test.givenError
+		var gotBlockiness float64
+		fakeDB := dbtest.NewFakeRepository(false)
+		service := TranscodingService{
+			db:          fakeDB,
+			logger:      logrus.New(),
+			videoQCRuns: newAnalysisRuns(),
+			analyzer: &mediaAnalyzer{
+				presign: func(bucket, key string) (string, error) {
+					return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+				},
+				analyzeVideo: func(input string, blockiness float64) (*ffmpeg.VideoAnalysis, error) {
+					gotBlockiness = blockiness
+					if analysisErr != nil {
+						return nil, analysisErr
+					}
+					return &analysis, nil
+				},
+			},
+		}
+		job := db.Job{ID: "job-123", VideoQC: test.givenQC.videoQC()}
+		var status provider.JobStatus
+		for i := 0; i < 100; i++ {
+			status = provider.JobStatus{
+				Status: provider.StatusFinished,
+				Output: provider.JobOutput{
+					Files: []provider.OutputFile{
+						{Path: "s3://bucket/job-123/video.mp4", Container: "mp4"},
+						{Path: "s3://bucket/job-123/hls/index.m3u8", Container: "m3u8"},
+					},
+				},
+			}
+			service.checkVideo(&job, &status)
+			if status.Status != provider.StatusStarted {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if status.Status != test.wantStatus || status.StatusMessage != test.wantMessage {
+			t.Errorf("%s: wrong status. Want %q (%q). Got %q (%q)", test.testCase, test.wantStatus, test.wantMessage, status.Status, status.StatusMessage)
+		}
+		if !reflect.DeepEqual(status.Warnings, test.wantWarnings) {
+			t.Errorf("%s: wrong warnings.\nWant %#v\nGot  %#v", test.testCase, test.wantWarnings, status.Warnings)
+		}
+		if gotBlockiness != test.wantBlockiness {
+			t.Errorf("%s: wrong blockiness threshold. Want %g. Got %g", test.testCase, test.wantBlockiness, gotBlockiness)
+		}
+		artifacts, _ := fakeDB.ListArtifacts(job.ID)
+		if len(artifacts) != test.wantArtifacts {
+			t.Errorf("%s: wrong number of artifacts. Want %d. Got %#v", test.testCase, test.wantArtifacts, artifacts)
+		}
+		for _, artifact := range artifacts {
+			if artifact.Name != "video-qc-video.mp4" || artifact.Kind != "video-qc" || artifact.Source != "ffmpeg" {
+				t.Errorf("%s: wrong artifact: %#v", test.testCase, artifact)
+			}
+		}
+	}
+}
+
+func TestVideoQCParams(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		params   *VideoQCParams
+		want     *db.VideoQC
+	}{
+		{"no video QC", nil, nil},
+		{"default thresholds", &VideoQCParams{}, &db.VideoQC{MaxBlack: 2, MaxFreeze: 2}},
+		{"custom thresholds", &VideoQCParams{MaxBlack: 5, MaxFreeze: 1, MaxBlockiness: 10, Reject: true}, &db.VideoQC{MaxBlack: 5, MaxFreeze: 1, MaxBlockiness: 10, Reject: true}},
+	}
+	for _, test := range tests {
+		got := test.params.videoQC()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: wrong video QC. Want %#v. Got %#v", test.testCase, test.want, got)
+		}
+	}
+}
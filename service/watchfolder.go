@@ -0,0 +1,256 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/gizmo/web"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/provider"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+	"github.com/NYTimes/video-transcoding-api/watch"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// swagger:route POST /watchfolders watchfolders newWatchFolder
+//
+// Creates a new watch folder in the API. Jobs are created from its template
+// for each new file found in the folder.
+//
+//     Responses:
+//       200: watchFolder
+//       400: invalidWatchFolder
+//       409: watchFolderAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newWatchFolder(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newWatchFolderInput
+	folder, err := input.WatchFolder(r.Body)
+	if err != nil {
+		return newInvalidWatchFolderResponse(err)
+	}
+	err = s.db.CreateWatchFolder(&folder)
+	switch err {
+	case nil:
+		return newWatchFolderResponse(&folder)
+	case db.ErrWatchFolderAlreadyExists:
+		return newWatchFolderAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /watchfolders/{name} watchfolders getWatchFolder
+//
+// Finds a watch folder using its name.
+//
+//     Responses:
+//       200: watchFolder
+//       404: watchFolderNotFound
+//       500: genericError
+func (s *TranscodingService) getWatchFolder(r *http.Request) swagger.GizmoJSONResponse {
+	var params getWatchFolderInput
+	params.loadParams(web.Vars(r))
+	folder, err := s.db.GetWatchFolder(params.Name)
+	switch err {
+	case nil:
+		return newWatchFolderResponse(folder)
+	case db.ErrWatchFolderNotFound:
+		return newWatchFolderNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route DELETE /watchfolders/{name} watchfolders deleteWatchFolder
+//
+// Deletes a watch folder by name. Files in the folder aren't touched.
+//
+//     Responses:
+//       200: emptyResponse
+//       404: watchFolderNotFound
+//       500: genericError
+func (s *TranscodingService) deleteWatchFolder(r *http.Request) swagger.GizmoJSONResponse {
+	var params getWatchFolderInput
+	params.loadParams(web.Vars(r))
+	err := s.db.DeleteWatchFolder(&db.WatchFolder{Name: params.Name})
+	switch err {
+	case nil:
+		return emptyResponse(http.StatusOK)
+	case db.ErrWatchFolderNotFound:
+		return newWatchFolderNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /watchfolders watchfolders listWatchFolders
+//
+// List watch folders registered in the API.
+//
+//     Responses:
+//       200: listWatchFolders
+//       500: genericError
+func (s *TranscodingService) listWatchFolders(r *http.Request) swagger.GizmoJSONResponse {
+	folders, err := s.db.ListWatchFolders()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListWatchFoldersResponse(folders)
+}
+
+// folderWatchers keeps the watchers of the registered watch folders between
+// polls, so they can track the files found in previous polls.
+type folderWatchers struct {
+	mtx        sync.Mutex
+	watchers   map[string]watch.Watcher
+	newWatcher func(db.WatchFolder) (watch.Watcher, error)
+}
+
+func newFolderWatchers(cfg *config.WatchFolders) *folderWatchers {
+	if cfg == nil {
+		cfg = &config.WatchFolders{}
+	}
+	return &folderWatchers{
+		watchers: make(map[string]watch.Watcher),
+		newWatcher: func(folder db.WatchFolder) (watch.Watcher, error) {
+			if folder.Queue == "" {
+				return watch.NewSFTP(folder.URL, cfg.SFTPKeyFile)
+			}
+			awsConfig := aws.NewConfig().WithRegion(cfg.Region)
+			if cfg.AccessKeyID != "" {
+				awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+			}
+			return watch.NewSQS(session.New(awsConfig), folder.Queue, folder.URL)
+		},
+	}
+}
+
+// get returns the watcher of the given folder, creating it on the first
+// call and when the location of the folder changes.
+func (w *folderWatchers) get(folder db.WatchFolder) (watch.Watcher, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	key := folder.Name + "\n" + folder.URL + "\n" + folder.Queue
+	if watcher, ok := w.watchers[key]; ok {
+		return watcher, nil
+	}
+	watcher, err := w.newWatcher(folder)
+	if err != nil {
+		return nil, err
+	}
+	w.watchers[key] = watcher
+	return watcher, nil
+}
+
+// prune drops the watchers of the folders that aren't registered anymore.
+func (w *folderWatchers) prune(folders []db.WatchFolder) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	keys := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		keys[folder.Name+"\n"+folder.URL+"\n"+folder.Queue] = true
+	}
+	for key := range w.watchers {
+		if !keys[key] {
+			delete(w.watchers, key)
+		}
+	}
+}
+
+// RunWatchFolders periodically polls the registered watch folders, creating
+// jobs for the new files, until the given channel is closed. It returns
+// immediately when watch folders are disabled in the configuration.
+func (s *TranscodingService) RunWatchFolders(stop <-chan struct{}) {
+	cfg := s.config.WatchFolders
+	if cfg == nil || cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.pollWatchFolders()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// pollWatchFolders creates a job for each new file in the registered watch
+// folders. Files are acknowledged once their jobs are created, so files
+// whose jobs fail to be created are retried in the next poll. It doesn't
+// run while the API is in maintenance mode.
+func (s *TranscodingService) pollWatchFolders() {
+	if s.maintenance.get(s.db).Enabled {
+		return
+	}
+	folders, err := s.db.ListWatchFolders()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list watch folders")
+		return
+	}
+	s.watchers.prune(folders)
+	for _, folder := range folders {
+		logger := s.logger.WithField("watchFolder", folder.Name)
+		watcher, err := s.watchers.get(folder)
+		if err != nil {
+			logger.WithError(err).Error("failed to watch folder")
+			continue
+		}
+		files, err := watcher.Poll()
+		if err != nil {
+			logger.WithError(err).Error("failed to poll watch folder")
+			continue
+		}
+		for _, file := range files {
+			if err = s.createWatchedJob(folder, file.URL); err != nil {
+				logger.WithError(err).WithField("source", file.URL).Error("failed to create job for watched file")
+				continue
+			}
+			if err = watcher.Done(file); err != nil {
+				logger.WithError(err).WithField("source", file.URL).Error("failed to acknowledge watched file")
+			}
+		}
+	}
+}
+
+// createWatchedJob creates a job for the given file using the template of
+// the folder. The URL of the file is the external id of the job, so files
+// reported more than once (e.g. after a restart) don't get duplicate jobs.
+func (s *TranscodingService) createWatchedJob(folder db.WatchFolder, fileURL string) error {
+	tenant, _ := folder.Template["tenant"].(string)
+	jobs, err := s.db.ListJobsByExternalID(tenant, fileURL)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		switch provider.Status(job.Status) {
+		case provider.StatusFailed, provider.StatusCanceled:
+		default:
+			return nil
+		}
+	}
+	payload := make(map[string]interface{}, len(folder.Template)+2)
+	for key, value := range folder.Template {
+		payload[key] = value
+	}
+	payload["source"] = fileURL
+	payload["externalId"] = fileURL
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	r, err := internalJobRequest(data)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.newTranscodeJob(r).Result()
+	return err
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/swagger"
+)
+
+// JSON-encoded watch folder returned on the newWatchFolder and
+// getWatchFolder operations.
+//
+// swagger:response watchFolder
+type watchFolderResponse struct {
+	// in: body
+	Payload *db.WatchFolder
+
+	baseResponse
+}
+
+// swagger:parameters getWatchFolder deleteWatchFolder
+type getWatchFolderInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+}
+
+// swagger:parameters newWatchFolder
+type newWatchFolderInput struct {
+	// in: body
+	// required: true
+	Payload db.WatchFolder
+}
+
+// error returned when the given watch folder name is not found on the
+// API.
+//
+// swagger:response watchFolderNotFound
+type watchFolderNotFoundResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given watch folder data is not valid.
+//
+// swagger:response invalidWatchFolder
+type invalidWatchFolderResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to create a new watch folder using a name
+// that is already in use.
+//
+// swagger:response watchFolderAlreadyExists
+type watchFolderAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// response for the listWatchFolders operation. It's a JSON-encoded object
+// in the format `watchFolderName: watchFolderObject`
+//
+// swagger:response listWatchFolders
+type listWatchFoldersResponse struct {
+	// in: body
+	WatchFolders map[string]db.WatchFolder
+
+	baseResponse
+}
+
+func newWatchFolderResponse(folder *db.WatchFolder) *watchFolderResponse {
+	return &watchFolderResponse{
+		baseResponse: baseResponse{
+			payload: folder,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newWatchFolderNotFoundResponse(err error) *watchFolderNotFoundResponse {
+	return &watchFolderNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
+}
+
+func (r *watchFolderNotFoundResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newInvalidWatchFolderResponse(err error) *invalidWatchFolderResponse {
+	return &invalidWatchFolderResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidWatchFolderResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newWatchFolderAlreadyExistsResponse(err error) *watchFolderAlreadyExistsResponse {
+	return &watchFolderAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *watchFolderAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newListWatchFoldersResponse(folders []db.WatchFolder) *listWatchFoldersResponse {
+	folderMap := make(map[string]db.WatchFolder, len(folders))
+	for _, folder := range folders {
+		folderMap[folder.Name] = folder
+	}
+	return &listWatchFoldersResponse{
+		baseResponse: baseResponse{
+			status:  http.StatusOK,
+			payload: folderMap,
+		},
+	}
+}
+
+// WatchFolder loads the input from the request body, validates it and
+// returns the watch folder.
+func (p *newWatchFolderInput) WatchFolder(body io.Reader) (db.WatchFolder, error) {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	return p.Payload, validateWatchFolder(&p.Payload)
+}
+
+func (p *getWatchFolderInput) loadParams(paramsMap map[string]string) {
+	p.Name = paramsMap["name"]
+}
+
+func validateWatchFolder(f *db.WatchFolder) error {
+	if f.Name == "" {
+		return errors.New("missing field name from the request")
+	}
+	folderURL, err := url.Parse(f.URL)
+	if err != nil || folderURL.Host == "" {
+		return fmt.Errorf("invalid watch folder url %q", f.URL)
+	}
+	switch folderURL.Scheme {
+	case "s3":
+		if f.Queue == "" {
+			return errors.New("s3 watch folders require the queue receiving the notifications of the bucket")
+		}
+	case "sftp":
+		if folderURL.User == nil {
+			return errors.New("sftp watch folders require the user in the url")
+		}
+		if f.Queue != "" {
+			return errors.New("queue is only available in s3 watch folders")
+		}
+	default:
+		return fmt.Errorf("unsupported watch folder scheme %q", folderURL.Scheme)
+	}
+	if len(f.Template) == 0 {
+		return errors.New("missing template from the request")
+	}
+	data, err := json.Marshal(f.Template)
+	if err != nil {
+		return err
+	}
+	var payload NewTranscodeJobInputPayload
+	if err = json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("invalid template: %s", err)
+	}
+	if payload.Source != "" || payload.ExternalID != "" {
+		return errors.New("the template can't define the source or the externalId of jobs")
+	}
+	return nil
+}
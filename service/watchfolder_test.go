@@ -0,0 +1,164 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/NYTimes/video-transcoding-api/config"
+	"github.com/NYTimes/video-transcoding-api/db"
+	"github.com/NYTimes/video-transcoding-api/db/dbtest"
+	"github.com/NYTimes/video-transcoding-api/watch"
+	"github.com/Sirupsen/logrus"
+)
+
+func TestNewWatchFolder(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+
+		wantCode  int
+		wantError string
+	}{
+		{
+			"valid s3 watch folder",
+			`{"name":"partner","url":"s3://dropbox/partner/","queue":"https://sqs.us-east-1.amazonaws.com/123/dropbox","template":{"provider":"fake","ladder":"hls_sd"}}`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"valid sftp watch folder",
+			`{"name":"partner","url":"sftp://partner@sftp.example.com/incoming","template":{"provider":"fake","ladder":"hls_sd"}}`,
+			http.StatusOK,
+			"",
+		},
+		{
+			"s3 watch folder without queue",
+			`{"name":"partner","url":"s3://dropbox/partner/","template":{"provider":"fake"}}`,
+			http.StatusBadRequest,
+			"s3 watch folders require the queue receiving the notifications of the bucket",
+		},
+		{
+			"sftp watch folder without user",
+			`{"name":"partner","url":"sftp://sftp.example.com/incoming","template":{"provider":"fake"}}`,
+			http.StatusBadRequest,
+			"sftp watch folders require the user in the url",
+		},
+		{
+			"unsupported scheme",
+			`{"name":"partner","url":"gs://dropbox/partner/","template":{"provider":"fake"}}`,
+			http.StatusBadRequest,
+			`unsupported watch folder scheme "gs"`,
+		},
+		{
+			"watch folder without template",
+			`{"name":"partner","url":"sftp://partner@sftp.example.com/incoming"}`,
+			http.StatusBadRequest,
+			"missing template from the request",
+		},
+		{
+			"template with source",
+			`{"name":"partner","url":"sftp://partner@sftp.example.com/incoming","template":{"provider":"fake","source":"s3://bucket/video.mp4"}}`,
+			http.StatusBadRequest,
+			"the template can't define the source or the externalId of jobs",
+		},
+		{
+			"invalid template",
+			`{"name":"partner","url":"sftp://partner@sftp.example.com/incoming","template":{"provider":"fake","outputs":"mp4"}}`,
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"watch folder already exists",
+			`{"name":"existing","url":"sftp://partner@sftp.example.com/incoming","template":{"provider":"fake"}}`,
+			http.StatusConflict,
+			db.ErrWatchFolderAlreadyExists.Error(),
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{RouterType: "fast"})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreateWatchFolder(&db.WatchFolder{Name: "existing", URL: "sftp://partner@sftp.example.com/other"})
+		service, err := NewTranscodingService(&config.Config{}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/watchfolders", strings.NewReader(test.givenRequestBody))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if test.wantError != "" && got["error"] != test.wantError {
+			t.Errorf("%s: wrong error returned. Want %q. Got %#v", test.givenTestCase, test.wantError, got["error"])
+		}
+	}
+}
+
+type fakeWatcher struct {
+	files []watch.File
+	done  []string
+}
+
+func (w *fakeWatcher) Poll() ([]watch.File, error) {
+	return w.files, nil
+}
+
+func (w *fakeWatcher) Done(file watch.File) error {
+	w.done = append(w.done, file.URL)
+	return nil
+}
+
+func TestPollWatchFolders(t *testing.T) {
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "mp4_1080p"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	fakeDB.CreateWatchFolder(&db.WatchFolder{
+		Name: "partner",
+		URL:  "s3://dropbox/partner/",
+		Template: map[string]interface{}{
+			"provider": "fake",
+			"outputs":  []interface{}{map[string]interface{}{"preset": "mp4_1080p"}},
+		},
+	})
+	service, err := NewTranscodingService(&config.Config{}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	watcher := &fakeWatcher{files: []watch.File{{URL: "s3://dropbox/partner/video.mov"}}}
+	service.watchers.newWatcher = func(folder db.WatchFolder) (watch.Watcher, error) {
+		return watcher, nil
+	}
+	service.pollWatchFolders()
+	service.pollWatchFolders()
+	jobs, err := fakeDB.ListJobs(db.JobFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("wrong number of jobs. Want 1. Got %d", len(jobs))
+	}
+	if jobs[0].SourceMedia != "s3://dropbox/partner/video.mov" {
+		t.Errorf("wrong job source. Want %q. Got %q", "s3://dropbox/partner/video.mov", jobs[0].SourceMedia)
+	}
+	if jobs[0].ExternalID != "s3://dropbox/partner/video.mov" {
+		t.Errorf("wrong job external id. Want %q. Got %q", "s3://dropbox/partner/video.mov", jobs[0].ExternalID)
+	}
+	if len(watcher.done) != 2 {
+		t.Errorf("wrong acknowledged files. Want the file acknowledged on both polls. Got %#v", watcher.done)
+	}
+}
@@ -0,0 +1,114 @@
+package timedtext
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampRegexp matches the timestamps of SubRip and WebVTT cues, where
+// hours are optional in WebVTT, and SubRip separates milliseconds with a
+// comma.
+var timestampRegexp = regexp.MustCompile(`^(?:(\d+):)?(\d{2}):(\d{2})[,.](\d{3})$`)
+
+func parseSRT(data []byte) ([]Cue, error) {
+	var cues []Cue
+	for _, block := range splitBlocks(data) {
+		lines := strings.Split(block, "\n")
+		if !strings.Contains(lines[0], "-->") {
+			// the sequence number of the cue
+			lines = lines[1:]
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("srt: cue %d without timing", len(cues)+1)
+		}
+		cue, err := parseTiming(lines[0])
+		if err != nil {
+			return nil, fmt.Errorf("srt: cue %d: %s", len(cues)+1, err)
+		}
+		cue.Text = strings.Join(lines[1:], "\n")
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
+
+func writeSRT(cues []Cue) []byte {
+	var buf bytes.Buffer
+	for i, cue := range cues {
+		fmt.Fprintf(&buf, "%d\n%s --> %s\n%s\n\n", i+1, formatTimestamp(cue.Start, ','), formatTimestamp(cue.End, ','), cue.Text)
+	}
+	return buf.Bytes()
+}
+
+// splitBlocks splits SubRip and WebVTT files into blocks separated by
+// blank lines.
+func splitBlocks(data []byte) []string {
+	text := strings.Replace(string(data), "\r\n", "\n", -1)
+	text = strings.Replace(text, "\r", "\n", -1)
+	var blocks []string
+	var current []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+// parseTiming parses the timing line of a cue ("start --> end"), ignoring
+// the cue settings that may follow it in WebVTT.
+func parseTiming(line string) (Cue, error) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return Cue{}, fmt.Errorf("invalid timing %q", line)
+	}
+	end := strings.Fields(parts[1])
+	if len(end) == 0 {
+		return Cue{}, fmt.Errorf("invalid timing %q", line)
+	}
+	start, err := parseTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return Cue{}, err
+	}
+	cue := Cue{Start: start}
+	cue.End, err = parseTimestamp(end[0])
+	return cue, err
+}
+
+func parseTimestamp(value string) (time.Duration, error) {
+	match := timestampRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+	var fields [4]int64
+	for i, field := range match[1:] {
+		if field != "" {
+			fields[i], _ = strconv.ParseInt(field, 10, 64)
+		}
+	}
+	if fields[1] > 59 || fields[2] > 59 {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+	return time.Duration(fields[0])*time.Hour +
+		time.Duration(fields[1])*time.Minute +
+		time.Duration(fields[2])*time.Second +
+		time.Duration(fields[3])*time.Millisecond, nil
+}
+
+// formatTimestamp formats the timestamp as HH:MM:SS followed by the
+// milliseconds, separated by sep.
+func formatTimestamp(d time.Duration, sep byte) string {
+	ms := int64(d / time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
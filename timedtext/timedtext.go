@@ -0,0 +1,98 @@
+// Package timedtext converts captions between the timed text formats
+// supported by the API (SubRip, WebVTT and TTML), and validates the timing
+// of their cues.
+//
+// Cues keep their text only: positioning and styling are dropped in
+// conversions, with the exception of the inline tags shared by SubRip and
+// WebVTT (<b>, <i> and <u>).
+package timedtext
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Formats of timed text, matching the caption formats of the provider
+// package.
+const (
+	FormatSRT    = "srt"
+	FormatWebVTT = "webvtt"
+	FormatTTML   = "ttml"
+)
+
+// Cue is a piece of text displayed from Start to End. Lines of the text are
+// separated by "\n".
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Parse parses timed text in the given format.
+func Parse(data []byte, format string) ([]Cue, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	switch format {
+	case FormatSRT:
+		return parseSRT(data)
+	case FormatWebVTT:
+		return parseWebVTT(data)
+	case FormatTTML:
+		return parseTTML(data)
+	}
+	return nil, fmt.Errorf("unsupported timed text format %q", format)
+}
+
+// Write encodes the cues in the given format.
+func Write(cues []Cue, format string) ([]byte, error) {
+	switch format {
+	case FormatSRT:
+		return writeSRT(cues), nil
+	case FormatWebVTT:
+		return writeWebVTT(cues), nil
+	case FormatTTML:
+		return writeTTML(cues)
+	}
+	return nil, fmt.Errorf("unsupported timed text format %q", format)
+}
+
+// Convert converts timed text between the given formats.
+func Convert(data []byte, from, to string) ([]byte, error) {
+	cues, err := Parse(data, from)
+	if err != nil {
+		return nil, err
+	}
+	return Write(cues, to)
+}
+
+// Validate checks the timing of the cues: cues must end after they start,
+// appear in the order they start, and, when duration isn't zero, end
+// within the given duration of the media.
+func Validate(cues []Cue, duration time.Duration) error {
+	for i, cue := range cues {
+		if cue.Start < 0 {
+			return fmt.Errorf("cue %d starts before the media", i+1)
+		}
+		if cue.End <= cue.Start {
+			return fmt.Errorf("cue %d ends before it starts (%s --> %s)", i+1, cue.Start, cue.End)
+		}
+		if i > 0 && cue.Start < cues[i-1].Start {
+			return fmt.Errorf("cue %d is out of order (starts at %s, after a cue starting at %s)", i+1, cue.Start, cues[i-1].Start)
+		}
+		if duration > 0 && cue.End > duration {
+			return fmt.Errorf("cue %d ends at %s, after the end of the media (%s)", i+1, cue.End, duration)
+		}
+	}
+	return nil
+}
+
+// End returns the time the last cue ends.
+func End(cues []Cue) time.Duration {
+	var end time.Duration
+	for _, cue := range cues {
+		if cue.End > end {
+			end = cue.End
+		}
+	}
+	return end
+}
@@ -0,0 +1,224 @@
+package timedtext
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testCues = []Cue{
+	{Start: time.Second, End: 3500 * time.Millisecond, Text: "Hello,\nworld!"},
+	{Start: time.Hour + 2*time.Minute + 3*time.Second + 40*time.Millisecond, End: time.Hour + 2*time.Minute + 5*time.Second, Text: "Fish & chips"},
+}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		format   string
+		data     string
+	}{
+		{
+			"srt",
+			FormatSRT,
+			"\xef\xbb\xbf1\r\n00:00:01,000 --> 00:00:03,500\r\nHello,\r\nworld!\r\n\r\n2\r\n01:02:03,040 --> 01:02:05,000\r\nFish & chips\r\n",
+		},
+		{
+			"webvtt",
+			FormatWebVTT,
+			`WEBVTT - some title
+
+NOTE this is a comment
+
+STYLE
+::cue { color: yellow }
+
+intro
+00:01.000 --> 00:03.500 align:start line:0
+Hello,
+world!
+
+01:02:03.040 --> 01:02:05.000
+Fish & chips
+`,
+		},
+		{
+			"ttml with clock times",
+			FormatTTML,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xml:lang="en">
+  <body>
+    <div>
+      <p begin="00:00:01.000" end="00:00:03.500">Hello,<br/>
+        world!</p>
+      <p begin="01:02:03.040" end="01:02:05.000"><span>Fish &amp; chips</span></p>
+    </div>
+  </body>
+</tt>`,
+		},
+		{
+			"ttml with offsets, durations and frames",
+			FormatTTML,
+			`<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:frameRate="25">
+  <body><div>
+    <p begin="1s" dur="2500ms">Hello,<br/>world!</p>
+    <p begin="01:02:03:01" end="3725s">Fish &amp; chips</p>
+  </div></body>
+</tt>`,
+		},
+	}
+	for _, test := range tests {
+		cues, err := Parse([]byte(test.data), test.format)
+		if err != nil {
+			t.Errorf("%s: %s", test.testCase, err)
+			continue
+		}
+		if !reflect.DeepEqual(cues, testCues) {
+			t.Errorf("%s: wrong cues\nWant %#v\nGot  %#v", test.testCase, testCues, cues)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		format   string
+		data     string
+		wantErr  string
+	}{
+		{"unknown format", "scc", "", `unsupported timed text format "scc"`},
+		{"srt with invalid timestamp", FormatSRT, "1\n00:00:01.0 --> 00:00:02,000\nhi\n", `srt: cue 1: invalid timestamp "00:00:01.0"`},
+		{"srt without timing", FormatSRT, "1\nhi\n", `srt: cue 1: invalid timing "hi"`},
+		{"webvtt without header", FormatWebVTT, "00:01.000 --> 00:02.000\nhi\n", "webvtt: missing WEBVTT header"},
+		{"ttml without end", FormatTTML, `<tt><body><p begin="1s">hi</p></body></tt>`, "ttml: cue 1: missing end of the cue"},
+		{"ttml with invalid time", FormatTTML, `<tt><body><p begin="soon" end="2s">hi</p></body></tt>`, `ttml: cue 1: invalid time expression "soon"`},
+		{"ttml without cues", FormatTTML, `<tt><body></body></tt>`, "ttml: no cues found"},
+	}
+	for _, test := range tests {
+		_, err := Parse([]byte(test.data), test.format)
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.testCase, test.wantErr, err)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var tests = []struct {
+		format string
+		want   string
+	}{
+		{
+			FormatSRT,
+			"1\n00:00:01,000 --> 00:00:03,500\nHello,\nworld!\n\n2\n01:02:03,040 --> 01:02:05,000\nFish & chips\n\n",
+		},
+		{
+			FormatWebVTT,
+			"WEBVTT\n\n00:00:01.000 --> 00:00:03.500\nHello,\nworld!\n\n01:02:03.040 --> 01:02:05.000\nFish & chips\n\n",
+		},
+		{
+			FormatTTML,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xml:lang="">
+  <body>
+    <div>
+      <p begin="00:00:01.000" end="00:00:03.500">Hello,<br/>world!</p>
+      <p begin="01:02:03.040" end="01:02:05.000">Fish &amp; chips</p>
+    </div>
+  </body>
+</tt>
+`,
+		},
+	}
+	for _, test := range tests {
+		data, err := Write(testCues, test.format)
+		if err != nil {
+			t.Errorf("%s: %s", test.format, err)
+			continue
+		}
+		if string(data) != test.want {
+			t.Errorf("%s: wrong output\nWant %q\nGot  %q", test.format, test.want, data)
+		}
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	formats := []string{FormatSRT, FormatWebVTT, FormatTTML}
+	for _, from := range formats {
+		data, err := Write(testCues, from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, to := range formats {
+			converted, err := Convert(data, from, to)
+			if err != nil {
+				t.Errorf("%s to %s: %s", from, to, err)
+				continue
+			}
+			cues, err := Parse(converted, to)
+			if err != nil {
+				t.Errorf("%s to %s: %s", from, to, err)
+				continue
+			}
+			if !reflect.DeepEqual(cues, testCues) {
+				t.Errorf("%s to %s: wrong cues\nWant %#v\nGot  %#v", from, to, testCues, cues)
+			}
+		}
+	}
+}
+
+func TestWriteTTMLDropsTags(t *testing.T) {
+	data, err := Write([]Cue{{Start: 0, End: time.Second, Text: "<i>a < b</i>"}}, FormatTTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cues, err := Parse(data, FormatTTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cues[0].Text != "a < b" {
+		t.Errorf("wrong text. Want %q. Got %q", "a < b", cues[0].Text)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		cues     []Cue
+		duration time.Duration
+		wantErr  string
+	}{
+		{"valid cues", testCues, 2 * time.Hour, ""},
+		{"unknown duration", testCues, 0, ""},
+		{"cues after the end of the media", testCues, time.Hour, "cue 2 ends at 1h2m5s, after the end of the media (1h0m0s)"},
+		{
+			"cue ending before it starts",
+			[]Cue{{Start: 2 * time.Second, End: time.Second}},
+			0,
+			"cue 1 ends before it starts (2s --> 1s)",
+		},
+		{
+			"cues out of order",
+			[]Cue{{Start: 2 * time.Second, End: 3 * time.Second}, {Start: time.Second, End: 2 * time.Second}},
+			0,
+			"cue 2 is out of order (starts at 1s, after a cue starting at 2s)",
+		},
+	}
+	for _, test := range tests {
+		err := Validate(test.cues, test.duration)
+		var gotErr string
+		if err != nil {
+			gotErr = err.Error()
+		}
+		if gotErr != test.wantErr {
+			t.Errorf("%s: wrong error. Want %q. Got %q", test.testCase, test.wantErr, gotErr)
+		}
+	}
+}
+
+func TestEnd(t *testing.T) {
+	if end := End(testCues); end != time.Hour+2*time.Minute+5*time.Second {
+		t.Errorf("wrong end. Want 1h2m5s. Got %s", end)
+	}
+	if end := End(nil); end != 0 {
+		t.Errorf("wrong end of empty cues. Want 0. Got %s", end)
+	}
+}
@@ -0,0 +1,199 @@
+package timedtext
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFrameRate = 30
+	defaultTickRate  = 1
+)
+
+var (
+	clockTimeRegexp  = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2})(?:(\.\d+)|:(\d+)(?:\.\d+)?)?$`)
+	offsetTimeRegexp = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|m|s|ms|f|t)$`)
+	spaceRegexp      = regexp.MustCompile(`[ \t\n\r]+`)
+	tagRegexp        = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// ttmlTiming holds the parameters of the document used for computing the
+// time of frames and ticks.
+type ttmlTiming struct {
+	frameRate float64
+	tickRate  float64
+}
+
+func parseTTML(data []byte) ([]Cue, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	timing := ttmlTiming{frameRate: defaultFrameRate, tickRate: defaultTickRate}
+	var cues []Cue
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ttml: %s", err)
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch element.Name.Local {
+		case "tt":
+			if err = timing.load(element); err != nil {
+				return nil, err
+			}
+		case "p":
+			cue, err := timing.parseParagraph(decoder, element)
+			if err != nil {
+				return nil, fmt.Errorf("ttml: cue %d: %s", len(cues)+1, err)
+			}
+			cues = append(cues, cue)
+		}
+	}
+	if cues == nil {
+		return nil, fmt.Errorf("ttml: no cues found")
+	}
+	return cues, nil
+}
+
+func (t *ttmlTiming) load(element xml.StartElement) error {
+	for _, attr := range element.Attr {
+		var target *float64
+		switch attr.Name.Local {
+		case "frameRate":
+			target = &t.frameRate
+		case "tickRate":
+			target = &t.tickRate
+		default:
+			continue
+		}
+		value, err := strconv.ParseFloat(attr.Value, 64)
+		if err != nil || value <= 0 {
+			return fmt.Errorf("ttml: invalid %s %q", attr.Name.Local, attr.Value)
+		}
+		*target = value
+	}
+	return nil
+}
+
+// parseParagraph parses a <p> element, whose text (including the text of
+// its spans) becomes the text of the cue.
+func (t *ttmlTiming) parseParagraph(decoder *xml.Decoder, element xml.StartElement) (Cue, error) {
+	var cue Cue
+	var hasEnd, hasDur bool
+	var dur time.Duration
+	for _, attr := range element.Attr {
+		var err error
+		switch attr.Name.Local {
+		case "begin":
+			cue.Start, err = t.parseTime(attr.Value)
+		case "end":
+			cue.End, err = t.parseTime(attr.Value)
+			hasEnd = true
+		case "dur":
+			dur, err = t.parseTime(attr.Value)
+			hasDur = true
+		}
+		if err != nil {
+			return cue, err
+		}
+	}
+	if !hasEnd {
+		if !hasDur {
+			return cue, fmt.Errorf("missing end of the cue")
+		}
+		cue.End = cue.Start + dur
+	}
+	var lines []string
+	var line bytes.Buffer
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return cue, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			if token.Name.Local == "br" {
+				lines = append(lines, line.String())
+				line.Reset()
+			}
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			line.Write(token)
+		}
+	}
+	lines = append(lines, line.String())
+	for i := range lines {
+		lines[i] = strings.TrimSpace(spaceRegexp.ReplaceAllString(lines[i], " "))
+	}
+	cue.Text = strings.Join(lines, "\n")
+	return cue, nil
+}
+
+func (t *ttmlTiming) parseTime(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if match := clockTimeRegexp.FindStringSubmatch(value); match != nil {
+		hours, _ := strconv.ParseInt(match[1], 10, 64)
+		minutes, _ := strconv.ParseInt(match[2], 10, 64)
+		seconds, _ := strconv.ParseFloat(match[3]+match[4], 64)
+		total := float64(hours*3600+minutes*60) + seconds
+		if match[5] != "" {
+			frames, _ := strconv.ParseFloat(match[5], 64)
+			total += frames / t.frameRate
+		}
+		return seconds2duration(total), nil
+	}
+	if match := offsetTimeRegexp.FindStringSubmatch(value); match != nil {
+		n, _ := strconv.ParseFloat(match[1], 64)
+		switch match[2] {
+		case "h":
+			n *= 3600
+		case "m":
+			n *= 60
+		case "ms":
+			n /= 1000
+		case "f":
+			n /= t.frameRate
+		case "t":
+			n /= t.tickRate
+		}
+		return seconds2duration(n), nil
+	}
+	return 0, fmt.Errorf("invalid time expression %q", value)
+}
+
+func seconds2duration(seconds float64) time.Duration {
+	return time.Duration(seconds*float64(time.Second) + 0.5)
+}
+
+func writeTTML(cues []Cue) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<tt xmlns="http://www.w3.org/ns/ttml" xml:lang="">` + "\n  <body>\n    <div>\n")
+	for _, cue := range cues {
+		fmt.Fprintf(&buf, `      <p begin="%s" end="%s">`, formatTimestamp(cue.Start, '.'), formatTimestamp(cue.End, '.'))
+		for i, line := range strings.Split(tagRegexp.ReplaceAllString(cue.Text, ""), "\n") {
+			if i > 0 {
+				buf.WriteString("<br/>")
+			}
+			if err := xml.EscapeText(&buf, []byte(line)); err != nil {
+				return nil, err
+			}
+		}
+		buf.WriteString("</p>\n")
+	}
+	buf.WriteString("    </div>\n  </body>\n</tt>\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,64 @@
+package timedtext
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// webVTTSkippedBlocks are the blocks of WebVTT files that don't contain
+// cues.
+var webVTTSkippedBlocks = []string{"NOTE", "STYLE", "REGION"}
+
+func parseWebVTT(data []byte) ([]Cue, error) {
+	blocks := splitBlocks(data)
+	if len(blocks) == 0 || !isWebVTTHeader(blocks[0]) {
+		return nil, errors.New("webvtt: missing WEBVTT header")
+	}
+	var cues []Cue
+	for _, block := range blocks[1:] {
+		if isSkippedWebVTTBlock(block) {
+			continue
+		}
+		lines := strings.Split(block, "\n")
+		if !strings.Contains(lines[0], "-->") {
+			// the identifier of the cue
+			lines = lines[1:]
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("webvtt: cue %d without timing", len(cues)+1)
+		}
+		cue, err := parseTiming(lines[0])
+		if err != nil {
+			return nil, fmt.Errorf("webvtt: cue %d: %s", len(cues)+1, err)
+		}
+		cue.Text = strings.Join(lines[1:], "\n")
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
+
+func writeWebVTT(cues []Cue) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		fmt.Fprintf(&buf, "%s --> %s\n%s\n\n", formatTimestamp(cue.Start, '.'), formatTimestamp(cue.End, '.'), cue.Text)
+	}
+	return buf.Bytes()
+}
+
+func isWebVTTHeader(block string) bool {
+	line := strings.SplitN(block, "\n", 2)[0]
+	return line == "WEBVTT" || strings.HasPrefix(line, "WEBVTT ") || strings.HasPrefix(line, "WEBVTT\t")
+}
+
+func isSkippedWebVTTBlock(block string) bool {
+	line := strings.SplitN(block, "\n", 2)[0]
+	for _, keyword := range webVTTSkippedBlocks {
+		if line == keyword || strings.HasPrefix(line, keyword+" ") || strings.HasPrefix(line, keyword+"\t") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+)
+
+// Aspera uploads files using Aspera FASP, through the ascp command line
+// tool.
+type Aspera struct {
+	Host       string
+	Port       int
+	User       string
+	Password   string
+	KeyFile    string
+	TargetRate string
+
+	run func(cmd *exec.Cmd) ([]byte, error)
+}
+
+// NewAspera returns an uploader for the given Aspera server.
+func NewAspera(host, user, password, keyFile, targetRate string) *Aspera {
+	return &Aspera{
+		Host:       host,
+		Port:       33001,
+		User:       user,
+		Password:   password,
+		KeyFile:    keyFile,
+		TargetRate: targetRate,
+		run:        (*exec.Cmd).CombinedOutput,
+	}
+}
+
+// Upload uploads the content of body to the given path in the Aspera
+// server.
+func (a *Aspera) Upload(remotePath string, body io.Reader, size int64) error {
+	fileName, cleanup, err := spool(remotePath, body)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	cmd := a.command(fileName, remotePath)
+	if output, err := a.run(cmd); err != nil {
+		return fmt.Errorf("ascp failed: %s: %s", err, output)
+	}
+	return nil
+}
+
+func (a *Aspera) command(fileName, remotePath string) *exec.Cmd {
+	args := []string{"-Q", "-T", "-d", "-P", strconv.Itoa(a.Port)}
+	if a.TargetRate != "" {
+		args = append(args, "-l", a.TargetRate)
+	}
+	if a.KeyFile != "" {
+		args = append(args, "-i", a.KeyFile)
+	}
+	args = append(args, fileName, a.User+"@"+a.Host+":"+path.Dir(remotePath))
+	cmd := exec.Command("ascp", args...)
+	cmd.Env = os.Environ()
+	if a.Password != "" {
+		cmd.Env = append(cmd.Env, "ASPERA_SCP_PASS="+a.Password)
+	}
+	return cmd
+}
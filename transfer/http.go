@@ -0,0 +1,44 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HTTP uploads files with PUT requests to paths under a base URL, like
+// buckets that accept uploads from the API.
+type HTTP struct {
+	BaseURL string
+
+	client *http.Client
+}
+
+// NewHTTP returns an uploader for the given base URL.
+func NewHTTP(baseURL string) *HTTP {
+	return &HTTP{BaseURL: baseURL, client: http.DefaultClient}
+}
+
+// URL returns the URL of the file uploaded to the given path.
+func (h *HTTP) URL(remotePath string) string {
+	return strings.TrimRight(h.BaseURL, "/") + "/" + strings.TrimLeft(remotePath, "/")
+}
+
+// Upload uploads the content of body to the given path under the base URL.
+func (h *HTTP) Upload(remotePath string, body io.Reader, size int64) error {
+	req, err := http.NewRequest("PUT", h.URL(remotePath), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("error uploading to %q: %s", req.URL, resp.Status)
+	}
+	return nil
+}
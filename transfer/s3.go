@@ -0,0 +1,58 @@
+package transfer
+
+import (
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+type s3Uploader interface {
+	Upload(*s3manager.UploadInput, ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error)
+}
+
+// S3 uploads files to an S3 bucket, encrypted at rest with a customer
+// provided key (SSE-C).
+type S3 struct {
+	Bucket string
+
+	key      []byte
+	uploader s3Uploader
+}
+
+// NewS3 returns an uploader for the given bucket, that encrypts the files
+// with the given 256-bit key.
+func NewS3(sess client.ConfigProvider, bucket string, key []byte) *S3 {
+	return &S3{Bucket: bucket, key: key, uploader: s3manager.NewUploader(sess)}
+}
+
+// Upload uploads the content of body to the given key in the bucket.
+func (s *S3) Upload(path string, body io.Reader, size int64) error {
+	return s.UploadWithHeaders(path, body, size, Headers{})
+}
+
+// UploadWithHeaders uploads the content of body to the given key in the
+// bucket, setting the given headers on the object. Metadata is stored as
+// x-amz-meta-* headers.
+func (s *S3) UploadWithHeaders(path string, body io.Reader, size int64, headers Headers) error {
+	input := s3manager.UploadInput{
+		Bucket:               aws.String(s.Bucket),
+		Key:                  aws.String(strings.TrimLeft(path, "/")),
+		Body:                 body,
+		SSECustomerAlgorithm: aws.String("AES256"),
+		SSECustomerKey:       aws.String(string(s.key)),
+	}
+	if headers.CacheControl != "" {
+		input.CacheControl = aws.String(headers.CacheControl)
+	}
+	if headers.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(headers.ContentDisposition)
+	}
+	if len(headers.Metadata) > 0 {
+		input.Metadata = aws.StringMap(headers.Metadata)
+	}
+	_, err := s.uploader.Upload(&input)
+	return err
+}
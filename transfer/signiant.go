@@ -0,0 +1,52 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Signiant uploads files using a Signiant command line client. The command
+// is a template, where {source} is replaced with the local file and
+// {destination} with the remote path, for example:
+//
+//     sigcli upload --file {source} --destination {destination}
+type Signiant struct {
+	Command string
+
+	run func(cmd *exec.Cmd) ([]byte, error)
+}
+
+// NewSigniant returns an uploader that runs the given command template.
+func NewSigniant(command string) *Signiant {
+	return &Signiant{Command: command, run: (*exec.Cmd).CombinedOutput}
+}
+
+// Upload uploads the content of body to the given path using the Signiant
+// client.
+func (s *Signiant) Upload(remotePath string, body io.Reader, size int64) error {
+	if strings.TrimSpace(s.Command) == "" {
+		return errors.New("missing Signiant command")
+	}
+	fileName, cleanup, err := spool(remotePath, body)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	cmd := s.command(fileName, remotePath)
+	if output, err := s.run(cmd); err != nil {
+		return fmt.Errorf("%s failed: %s: %s", cmd.Args[0], err, output)
+	}
+	return nil
+}
+
+func (s *Signiant) command(fileName, remotePath string) *exec.Cmd {
+	fields := strings.Fields(s.Command)
+	replacer := strings.NewReplacer("{source}", fileName, "{destination}", remotePath)
+	for i := range fields {
+		fields[i] = replacer.Replace(fields[i])
+	}
+	return exec.Command(fields[0], fields[1:]...)
+}
@@ -0,0 +1,66 @@
+// Package transfer provides backends for delivering files to destinations
+// that transcoding providers can't write to, like accelerated transfer
+// servers (Aspera FASP or Signiant).
+package transfer
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Uploader uploads the content of body to the given path in the remote
+// destination.
+type Uploader interface {
+	Upload(path string, body io.Reader, size int64) error
+}
+
+// Headers are the HTTP headers and the custom metadata set on uploaded
+// files.
+type Headers struct {
+	CacheControl       string
+	ContentDisposition string
+	Metadata           map[string]string
+}
+
+// HeaderUploader is implemented by uploaders that can set headers on the
+// uploaded files.
+type HeaderUploader interface {
+	Uploader
+	UploadWithHeaders(path string, body io.Reader, size int64, headers Headers) error
+}
+
+// UploaderFunc is an adapter for using functions as uploaders.
+type UploaderFunc func(path string, body io.Reader, size int64) error
+
+// Upload calls f(path, body, size).
+func (f UploaderFunc) Upload(path string, body io.Reader, size int64) error {
+	return f(path, body, size)
+}
+
+// spool writes body to a temporary file, named after the base name of path,
+// for tools that only transfer local files. The returned function removes
+// the file.
+func spool(path string, body io.Reader) (string, func(), error) {
+	dir, err := ioutil.TempDir("", "transfer")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	fileName := filepath.Join(dir, filepath.Base(path))
+	file, err := os.Create(fileName)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return fileName, cleanup, nil
+}
@@ -0,0 +1,195 @@
+package transfer
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+func TestAsperaUpload(t *testing.T) {
+	var (
+		gotArgs    []string
+		gotEnv     []string
+		gotContent string
+	)
+	aspera := NewAspera("aspera.example.com", "archive", "secret", "", "500m")
+	aspera.run = func(cmd *exec.Cmd) ([]byte, error) {
+		gotArgs = cmd.Args
+		gotEnv = cmd.Env
+		data, err := ioutil.ReadFile(cmd.Args[len(cmd.Args)-2])
+		if err != nil {
+			return nil, err
+		}
+		gotContent = string(data)
+		return nil, nil
+	}
+	err := aspera.Upload("/archive/2016/video.mov", strings.NewReader("mezzanine"), 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotArgs) < 2 {
+		t.Fatalf("unexpected command: %#v", gotArgs)
+	}
+	localFile := gotArgs[len(gotArgs)-2]
+	if filepath.Base(localFile) != "video.mov" {
+		t.Errorf("wrong local file name: %q", localFile)
+	}
+	expectedArgs := []string{"ascp", "-Q", "-T", "-d", "-P", "33001", "-l", "500m", localFile, "archive@aspera.example.com:/archive/2016"}
+	if !reflect.DeepEqual(gotArgs, expectedArgs) {
+		t.Errorf("wrong command.\nWant %#v\nGot  %#v", expectedArgs, gotArgs)
+	}
+	if gotContent != "mezzanine" {
+		t.Errorf("wrong content. Want %q. Got %q", "mezzanine", gotContent)
+	}
+	if gotEnv[len(gotEnv)-1] != "ASPERA_SCP_PASS=secret" {
+		t.Errorf("password not set in the environment: %#v", gotEnv)
+	}
+	if _, err := ioutil.ReadFile(localFile); err == nil {
+		t.Error("temporary file not removed")
+	}
+}
+
+func TestAsperaUploadError(t *testing.T) {
+	aspera := NewAspera("aspera.example.com", "archive", "", "/etc/aspera/key", "")
+	aspera.run = func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte("Session Stop (Error: Disk write failed)"), errors.New("exit status 1")
+	}
+	err := aspera.Upload("/archive/video.mov", strings.NewReader("mezzanine"), 9)
+	want := "ascp failed: exit status 1: Session Stop (Error: Disk write failed)"
+	if err == nil || err.Error() != want {
+		t.Errorf("wrong error. Want %q. Got %v", want, err)
+	}
+}
+
+func TestSigniantUpload(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		command   string
+		wantArgs  []string
+		wantError string
+	}{
+		{
+			"command template",
+			"sigcli upload --file {source} --destination {destination}",
+			[]string{"sigcli", "upload", "--file", "<local>", "--destination", "/archive/video.mov"},
+			"",
+		},
+		{
+			"empty command",
+			" ",
+			nil,
+			"missing Signiant command",
+		},
+	}
+	for _, test := range tests {
+		var gotArgs []string
+		signiant := NewSigniant(test.command)
+		signiant.run = func(cmd *exec.Cmd) ([]byte, error) {
+			gotArgs = cmd.Args
+			return nil, nil
+		}
+		err := signiant.Upload("/archive/video.mov", strings.NewReader("mezzanine"), 9)
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.wantError {
+			t.Errorf("%s: wrong error. Want %q. Got %q", test.testCase, test.wantError, err.Error())
+		}
+		for i, arg := range gotArgs {
+			if filepath.Base(arg) == "video.mov" && strings.HasPrefix(arg, "/") && arg != "/archive/video.mov" {
+				gotArgs[i] = "<local>"
+			}
+		}
+		if !reflect.DeepEqual(gotArgs, test.wantArgs) {
+			t.Errorf("%s: wrong command.\nWant %#v\nGot  %#v", test.testCase, test.wantArgs, gotArgs)
+		}
+	}
+}
+
+func TestHTTPUpload(t *testing.T) {
+	var tests = []struct {
+		testCase  string
+		status    int
+		wantError bool
+	}{
+		{"successful upload", http.StatusOK, false},
+		{"upload rejected", http.StatusForbidden, true},
+	}
+	for _, test := range tests {
+		var gotMethod, gotPath, gotContent string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := ioutil.ReadAll(r.Body)
+			gotMethod, gotPath, gotContent = r.Method, r.URL.Path, string(data)
+			w.WriteHeader(test.status)
+		}))
+		uploader := NewHTTP(server.URL + "/decrypted/")
+		err := uploader.Upload("/job-123/video.mov", strings.NewReader("mezzanine"), 9)
+		server.Close()
+		if (err != nil) != test.wantError {
+			t.Errorf("%s: unexpected error: %v", test.testCase, err)
+		}
+		if gotMethod != "PUT" || gotPath != "/decrypted/job-123/video.mov" || gotContent != "mezzanine" {
+			t.Errorf("%s: wrong request: %s %s %q", test.testCase, gotMethod, gotPath, gotContent)
+		}
+	}
+}
+
+type fakeS3Uploader struct {
+	inputs []*s3manager.UploadInput
+}
+
+func (u *fakeS3Uploader) Upload(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+	u.inputs = append(u.inputs, input)
+	return &s3manager.UploadOutput{}, nil
+}
+
+func TestS3Upload(t *testing.T) {
+	fakeUploader := &fakeS3Uploader{}
+	key := []byte("0123456789abcdef0123456789abcdef")
+	uploader := &S3{Bucket: "embargoed", key: key, uploader: fakeUploader}
+	if err := uploader.Upload("/job-123/video.mp4", strings.NewReader("video"), 5); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakeUploader.inputs) != 1 {
+		t.Fatalf("wrong number of uploads. Want 1. Got %d", len(fakeUploader.inputs))
+	}
+	input := fakeUploader.inputs[0]
+	if aws.StringValue(input.Bucket) != "embargoed" || aws.StringValue(input.Key) != "job-123/video.mp4" {
+		t.Errorf("wrong upload destination: %s/%s", aws.StringValue(input.Bucket), aws.StringValue(input.Key))
+	}
+	if aws.StringValue(input.SSECustomerAlgorithm) != "AES256" || aws.StringValue(input.SSECustomerKey) != string(key) {
+		t.Errorf("upload not encrypted with the customer key: %#v", input)
+	}
+	if input.CacheControl != nil || input.ContentDisposition != nil || input.Metadata != nil {
+		t.Errorf("unexpected headers in upload without headers: %#v", input)
+	}
+}
+
+func TestS3UploadWithHeaders(t *testing.T) {
+	fakeUploader := &fakeS3Uploader{}
+	uploader := &S3{Bucket: "embargoed", key: []byte("0123456789abcdef0123456789abcdef"), uploader: fakeUploader}
+	headers := Headers{
+		CacheControl:       "max-age=31536000",
+		ContentDisposition: "attachment",
+		Metadata:           map[string]string{"asset-id": "123"},
+	}
+	if err := uploader.UploadWithHeaders("/job-123/video.mp4", strings.NewReader("video"), 5, headers); err != nil {
+		t.Fatal(err)
+	}
+	input := fakeUploader.inputs[0]
+	if aws.StringValue(input.CacheControl) != "max-age=31536000" || aws.StringValue(input.ContentDisposition) != "attachment" {
+		t.Errorf("wrong headers in upload: %#v", input)
+	}
+	if metadata := aws.StringValueMap(input.Metadata); !reflect.DeepEqual(metadata, headers.Metadata) {
+		t.Errorf("wrong metadata. Want %#v. Got %#v", headers.Metadata, metadata)
+	}
+}
@@ -0,0 +1,128 @@
+package watch
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SFTP watches a folder of an SFTP server, listing it with the sftp command
+// line tool in batch mode, so the server must accept the key in KeyFile (or
+// a key from the SSH agent). Files are reported once their size is the same
+// in two listings, so files still being uploaded are skipped, and until
+// they're processed.
+type SFTP struct {
+	Host    string
+	Port    int
+	User    string
+	Path    string
+	KeyFile string
+
+	url   string
+	run   func(cmd *exec.Cmd) ([]byte, error)
+	sizes map[string]int64
+	done  map[string]bool
+}
+
+// NewSFTP returns a watcher for the given folder, in the format
+// sftp://user@host[:port]/path/.
+func NewSFTP(folderURL, keyFile string) (*SFTP, error) {
+	u, err := parseFolderURL(folderURL, "sftp")
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("missing user in sftp folder %q", folderURL)
+	}
+	host, port := u.Host, 22
+	if strings.Contains(u.Host, ":") {
+		var portStr string
+		if host, portStr, err = net.SplitHostPort(u.Host); err != nil {
+			return nil, fmt.Errorf("invalid sftp folder %q", folderURL)
+		}
+		if port, err = strconv.Atoi(portStr); err != nil {
+			return nil, fmt.Errorf("invalid port in sftp folder %q", folderURL)
+		}
+	}
+	folderPath := "/" + folderPrefix(u.Path)
+	return &SFTP{
+		Host:    host,
+		Port:    port,
+		User:    u.User.Username(),
+		Path:    folderPath,
+		KeyFile: keyFile,
+		url:     "sftp://" + u.User.Username() + "@" + u.Host + folderPath,
+		run:     (*exec.Cmd).CombinedOutput,
+		sizes:   make(map[string]int64),
+		done:    make(map[string]bool),
+	}, nil
+}
+
+// Poll lists the folder, returning the files that stopped growing since the
+// previous listing.
+func (s *SFTP) Poll() ([]File, error) {
+	output, err := s.run(s.command())
+	if err != nil {
+		return nil, fmt.Errorf("sftp failed: %s: %s", err, output)
+	}
+	sizes := parseListing(string(output))
+	var names []string
+	for name, size := range sizes {
+		if previous, ok := s.sizes[name]; ok && previous == size && !s.done[name] {
+			names = append(names, name)
+		}
+	}
+	for name := range s.done {
+		if _, ok := sizes[name]; !ok {
+			delete(s.done, name)
+		}
+	}
+	s.sizes = sizes
+	sort.Strings(names)
+	files := make([]File, len(names))
+	for i, name := range names {
+		files[i] = File{URL: s.url + name, id: name}
+	}
+	return files, nil
+}
+
+// Done marks the given file as processed. Files are reported again when
+// they're removed from the folder and uploaded again.
+func (s *SFTP) Done(file File) error {
+	s.done[file.id] = true
+	return nil
+}
+
+func (s *SFTP) command() *exec.Cmd {
+	args := []string{"-b", "-", "-P", strconv.Itoa(s.Port), "-o", "BatchMode=yes"}
+	if s.KeyFile != "" {
+		args = append(args, "-i", s.KeyFile)
+	}
+	args = append(args, s.User+"@"+s.Host)
+	cmd := exec.Command("sftp", args...)
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("ls -l %q\n", s.Path))
+	return cmd
+}
+
+// parseListing returns the sizes of the regular files in the output of
+// "ls -l" in sftp, by name.
+func parseListing(output string) map[string]int64 {
+	sizes := make(map[string]int64)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 9 || !strings.HasPrefix(fields[0], "-") {
+			// echoed commands, directories and links.
+			continue
+		}
+		size, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[path.Base(strings.Join(fields[8:], " "))] = size
+	}
+	return sizes
+}
@@ -0,0 +1,124 @@
+package watch
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// maxMessages is the maximum number of messages received from SQS in a
+// single request.
+const maxMessages = 10
+
+type sqsClient interface {
+	ReceiveMessage(*sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(*sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error)
+}
+
+// SQS watches a folder of an S3 bucket through the event notifications of
+// the bucket, delivered to an SQS queue. Messages are deleted once their
+// files are processed, so files that fail are reported again when their
+// messages become visible in the queue. Messages without files created in
+// the folder (like test events) are deleted right away.
+type SQS struct {
+	QueueURL string
+	Bucket   string
+	Prefix   string
+
+	client sqsClient
+}
+
+// s3Event is the body of the messages of S3 event notifications.
+type s3Event struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	}
+}
+
+// NewSQS returns a watcher for the given folder (in the format
+// s3://bucket/prefix/), receiving the notifications of the bucket from the
+// given queue.
+func NewSQS(sess client.ConfigProvider, queueURL, folderURL string) (*SQS, error) {
+	u, err := parseFolderURL(folderURL, "s3")
+	if err != nil {
+		return nil, err
+	}
+	return &SQS{
+		QueueURL: queueURL,
+		Bucket:   u.Host,
+		Prefix:   folderPrefix(u.Path),
+		client:   sqs.New(sess),
+	}, nil
+}
+
+// Poll receives the pending notifications from the queue, returning the
+// files created in the folder.
+func (s *SQS) Poll() ([]File, error) {
+	resp, err := s.client.ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(s.QueueURL),
+		MaxNumberOfMessages: aws.Int64(maxMessages),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, message := range resp.Messages {
+		receipt := aws.StringValue(message.ReceiptHandle)
+		found := s.files(aws.StringValue(message.Body), receipt)
+		if len(found) == 0 {
+			if err = s.delete(receipt); err != nil {
+				return files, err
+			}
+			continue
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// files returns the files created in the folder, as reported in the body
+// of a notification.
+func (s *SQS) files(body, receipt string) []File {
+	var event s3Event
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		return nil
+	}
+	var files []File
+	for _, record := range event.Records {
+		if !strings.HasPrefix(record.EventName, "ObjectCreated:") || record.S3.Bucket.Name != s.Bucket {
+			continue
+		}
+		// keys are URL encoded in notifications.
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil || !strings.HasPrefix(key, s.Prefix) || strings.HasSuffix(key, "/") {
+			continue
+		}
+		files = append(files, File{URL: "s3://" + s.Bucket + "/" + key, id: receipt})
+	}
+	return files
+}
+
+// Done deletes the notification of the given file from the queue.
+func (s *SQS) Done(file File) error {
+	return s.delete(file.id)
+}
+
+func (s *SQS) delete(receipt string) error {
+	_, err := s.client.DeleteMessage(&sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(s.QueueURL),
+		ReceiptHandle: aws.String(receipt),
+	})
+	return err
+}
@@ -0,0 +1,48 @@
+// Package watch provides the monitors of watch folders, reporting the files
+// dropped in folders of S3 buckets or SFTP servers.
+package watch
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// File is a new file found in a watch folder.
+type File struct {
+	// URL of the file, in the scheme of the folder.
+	URL string
+
+	// id identifies the file in the watcher that found it.
+	id string
+}
+
+// Watcher reports the new files in a folder.
+type Watcher interface {
+	// Poll returns the files added to the folder that weren't processed
+	// yet.
+	Poll() ([]File, error)
+
+	// Done acknowledges that the given file was processed, so it isn't
+	// reported again.
+	Done(File) error
+}
+
+// parseFolderURL parses the URL of a folder, checking its scheme.
+func parseFolderURL(folderURL, scheme string) (*url.URL, error) {
+	u, err := url.Parse(folderURL)
+	if err != nil || u.Scheme != scheme || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s folder %q", scheme, folderURL)
+	}
+	return u, nil
+}
+
+// folderPrefix returns the path of a folder with a trailing slash, so files
+// of folders sharing a prefix aren't mixed up.
+func folderPrefix(folderPath string) string {
+	folderPath = strings.Trim(folderPath, "/")
+	if folderPath == "" {
+		return ""
+	}
+	return folderPath + "/"
+}
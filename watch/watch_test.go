@@ -0,0 +1,156 @@
+package watch
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+type fakeSQS struct {
+	messages []*sqs.Message
+	deleted  []string
+}
+
+func (c *fakeSQS) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	messages := c.messages
+	c.messages = nil
+	return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+}
+
+func (c *fakeSQS) DeleteMessage(input *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	c.deleted = append(c.deleted, aws.StringValue(input.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func s3Message(receipt, body string) *sqs.Message {
+	return &sqs.Message{ReceiptHandle: aws.String(receipt), Body: aws.String(body)}
+}
+
+func TestSQSPoll(t *testing.T) {
+	client := &fakeSQS{messages: []*sqs.Message{
+		s3Message("created", `{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"dropbox"},"object":{"key":"partner/my+video%281%29.mov","size":1024}}}]}`),
+		s3Message("test", `{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"dropbox"}`),
+		s3Message("other-folder", `{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"dropbox"},"object":{"key":"partners/video.mov"}}}]}`),
+		s3Message("folder", `{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"dropbox"},"object":{"key":"partner/2018/"}}}]}`),
+		s3Message("removed", `{"Records":[{"eventName":"ObjectRemoved:Delete","s3":{"bucket":{"name":"dropbox"},"object":{"key":"partner/old.mov"}}}]}`),
+	}}
+	watcher := SQS{QueueURL: "https://sqs.us-east-1.amazonaws.com/123/dropbox", Bucket: "dropbox", Prefix: "partner/", client: client}
+	files, err := watcher.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFiles := []File{{URL: "s3://dropbox/partner/my video(1).mov", id: "created"}}
+	if !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("wrong files returned\nWant %#v\nGot  %#v", expectedFiles, files)
+	}
+	expectedDeleted := []string{"test", "other-folder", "folder", "removed"}
+	if !reflect.DeepEqual(client.deleted, expectedDeleted) {
+		t.Errorf("wrong messages deleted\nWant %#v\nGot  %#v", expectedDeleted, client.deleted)
+	}
+	if err = watcher.Done(files[0]); err != nil {
+		t.Fatal(err)
+	}
+	if last := client.deleted[len(client.deleted)-1]; last != "created" {
+		t.Errorf("message of the processed file not deleted. Last deleted: %q", last)
+	}
+}
+
+func TestNewSFTP(t *testing.T) {
+	var tests = []struct {
+		folderURL string
+		wantHost  string
+		wantPort  int
+		wantPath  string
+		wantErr   string
+	}{
+		{"sftp://partner@sftp.example.com/incoming/", "sftp.example.com", 22, "/incoming/", ""},
+		{"sftp://partner@sftp.example.com:2222/incoming", "sftp.example.com", 2222, "/incoming/", ""},
+		{"sftp://sftp.example.com/incoming/", "", 0, "", `missing user in sftp folder "sftp://sftp.example.com/incoming/"`},
+		{"s3://dropbox/incoming/", "", 0, "", `invalid sftp folder "s3://dropbox/incoming/"`},
+	}
+	for _, test := range tests {
+		watcher, err := NewSFTP(test.folderURL, "")
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.folderURL, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.folderURL, err)
+			continue
+		}
+		if watcher.Host != test.wantHost || watcher.Port != test.wantPort || watcher.Path != test.wantPath {
+			t.Errorf("%s: wrong watcher. Want %s:%d%s. Got %s:%d%s", test.folderURL, test.wantHost, test.wantPort, test.wantPath, watcher.Host, watcher.Port, watcher.Path)
+		}
+	}
+}
+
+func TestSFTPPoll(t *testing.T) {
+	listings := []string{
+		"sftp> ls -l \"/incoming/\"\n" +
+			"drwxr-xr-x    2 partner  partner      4096 Jan 10 10:00 /incoming/archive\n" +
+			"-rw-r--r--    1 partner  partner   1048576 Jan 10 10:01 /incoming/episode 1.mov\n",
+		"sftp> ls -l \"/incoming/\"\n" +
+			"-rw-r--r--    1 partner  partner   1048576 Jan 10 10:01 /incoming/episode 1.mov\n" +
+			"-rw-r--r--    1 partner  partner       512 Jan 10 10:02 /incoming/episode2.mov\n",
+		"sftp> ls -l \"/incoming/\"\n" +
+			"-rw-r--r--    1 partner  partner   1048576 Jan 10 10:01 /incoming/episode 1.mov\n" +
+			"-rw-r--r--    1 partner  partner   2097152 Jan 10 10:03 /incoming/episode2.mov\n",
+		"sftp> ls -l \"/incoming/\"\n" +
+			"-rw-r--r--    1 partner  partner   1048576 Jan 10 10:01 /incoming/episode 1.mov\n" +
+			"-rw-r--r--    1 partner  partner   2097152 Jan 10 10:03 /incoming/episode2.mov\n",
+	}
+	watcher, err := NewSFTP("sftp://partner@sftp.example.com/incoming/", "/etc/watch/id_rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commands [][]string
+	watcher.run = func(cmd *exec.Cmd) ([]byte, error) {
+		commands = append(commands, cmd.Args)
+		if len(listings) == 0 {
+			return []byte("Connection closed"), errors.New("exit status 255")
+		}
+		listing := listings[0]
+		listings = listings[1:]
+		return []byte(listing), nil
+	}
+	var tests = []struct {
+		wantFiles []string
+		done      bool
+	}{
+		{nil, false},
+		{[]string{"sftp://partner@sftp.example.com/incoming/episode 1.mov"}, false},
+		{[]string{"sftp://partner@sftp.example.com/incoming/episode 1.mov"}, true},
+		{[]string{"sftp://partner@sftp.example.com/incoming/episode2.mov"}, true},
+	}
+	for i, test := range tests {
+		files, err := watcher.Poll()
+		if err != nil {
+			t.Fatalf("poll %d: %s", i, err)
+		}
+		var urls []string
+		for _, file := range files {
+			urls = append(urls, file.URL)
+			if test.done {
+				watcher.Done(file)
+			}
+		}
+		if !reflect.DeepEqual(urls, test.wantFiles) {
+			t.Errorf("poll %d: wrong files\nWant %#v\nGot  %#v", i, test.wantFiles, urls)
+		}
+	}
+	_, err = watcher.Poll()
+	if err == nil || !strings.Contains(err.Error(), "Connection closed") {
+		t.Errorf("wrong error. Want sftp failure. Got %v", err)
+	}
+	expectedArgs := []string{"sftp", "-b", "-", "-P", "22", "-o", "BatchMode=yes", "-i", "/etc/watch/id_rsa", "partner@sftp.example.com"}
+	if !reflect.DeepEqual(commands[0], expectedArgs) {
+		t.Errorf("wrong sftp command\nWant %#v\nGot  %#v", expectedArgs, commands[0])
+	}
+}